@@ -4,27 +4,78 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	netmail "net/mail"
 	"os"
 	"os/signal"
+	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	backupApp "github.com/elprogramadorgt/lucidRAG/internal/application/backup"
+	bookingApp "github.com/elprogramadorgt/lucidRAG/internal/application/booking"
+	"github.com/elprogramadorgt/lucidRAG/internal/application/cluster"
 	convApp "github.com/elprogramadorgt/lucidRAG/internal/application/conversation"
+	crmApp "github.com/elprogramadorgt/lucidRAG/internal/application/crm"
+	digestApp "github.com/elprogramadorgt/lucidRAG/internal/application/digest"
 	docApp "github.com/elprogramadorgt/lucidRAG/internal/application/document"
+	emailApp "github.com/elprogramadorgt/lucidRAG/internal/application/email"
+	integrationApp "github.com/elprogramadorgt/lucidRAG/internal/application/integration"
+	productApp "github.com/elprogramadorgt/lucidRAG/internal/application/product"
+	reportApp "github.com/elprogramadorgt/lucidRAG/internal/application/report"
+	"github.com/elprogramadorgt/lucidRAG/internal/application/scheduler"
+	slackApp "github.com/elprogramadorgt/lucidRAG/internal/application/slack"
+	systemApp "github.com/elprogramadorgt/lucidRAG/internal/application/system"
+	toolApp "github.com/elprogramadorgt/lucidRAG/internal/application/tool"
+	topicApp "github.com/elprogramadorgt/lucidRAG/internal/application/topic"
+	transcriptApp "github.com/elprogramadorgt/lucidRAG/internal/application/transcript"
 	userApp "github.com/elprogramadorgt/lucidRAG/internal/application/user"
 	"github.com/elprogramadorgt/lucidRAG/internal/application/whatsapp"
+	widgetApp "github.com/elprogramadorgt/lucidRAG/internal/application/widget"
 	"github.com/elprogramadorgt/lucidRAG/internal/config"
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	emailDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/email"
+	whatsappDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/whatsapp"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
 	"github.com/elprogramadorgt/lucidRAG/internal/repository/mongo"
 	"github.com/elprogramadorgt/lucidRAG/internal/transport/http/middleware"
+	adminHandler "github.com/elprogramadorgt/lucidRAG/internal/transport/http/v1/admin"
 	authHandler "github.com/elprogramadorgt/lucidRAG/internal/transport/http/v1/auth"
+	backupHandler "github.com/elprogramadorgt/lucidRAG/internal/transport/http/v1/backup"
+	bookingHandler "github.com/elprogramadorgt/lucidRAG/internal/transport/http/v1/booking"
 	conversationHandler "github.com/elprogramadorgt/lucidRAG/internal/transport/http/v1/conversation"
+	crmHandler "github.com/elprogramadorgt/lucidRAG/internal/transport/http/v1/crm"
 	documentHandler "github.com/elprogramadorgt/lucidRAG/internal/transport/http/v1/document"
+	emailHandler "github.com/elprogramadorgt/lucidRAG/internal/transport/http/v1/email"
+	integrationHandler "github.com/elprogramadorgt/lucidRAG/internal/transport/http/v1/integration"
+	productHandler "github.com/elprogramadorgt/lucidRAG/internal/transport/http/v1/product"
 	ragHandler "github.com/elprogramadorgt/lucidRAG/internal/transport/http/v1/rag"
+	reportHandler "github.com/elprogramadorgt/lucidRAG/internal/transport/http/v1/report"
+	slackHandler "github.com/elprogramadorgt/lucidRAG/internal/transport/http/v1/slack"
 	systemHandler "github.com/elprogramadorgt/lucidRAG/internal/transport/http/v1/system"
+	toolHandler "github.com/elprogramadorgt/lucidRAG/internal/transport/http/v1/tool"
+	topicHandler "github.com/elprogramadorgt/lucidRAG/internal/transport/http/v1/topic"
+	transcriptHandler "github.com/elprogramadorgt/lucidRAG/internal/transport/http/v1/transcript"
 	whatsappHandler "github.com/elprogramadorgt/lucidRAG/internal/transport/http/v1/whatsapp"
+	widgetHandler "github.com/elprogramadorgt/lucidRAG/internal/transport/http/v1/widget"
+	"github.com/elprogramadorgt/lucidRAG/pkg/asn"
+	"github.com/elprogramadorgt/lucidRAG/pkg/breaker"
+	"github.com/elprogramadorgt/lucidRAG/pkg/cache"
+	"github.com/elprogramadorgt/lucidRAG/pkg/captcha"
 	"github.com/elprogramadorgt/lucidRAG/pkg/chunker"
+	"github.com/elprogramadorgt/lucidRAG/pkg/confirm"
+	"github.com/elprogramadorgt/lucidRAG/pkg/extract"
+	"github.com/elprogramadorgt/lucidRAG/pkg/gcal"
+	"github.com/elprogramadorgt/lucidRAG/pkg/guardrail"
 	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/elprogramadorgt/lucidRAG/pkg/mail"
+	"github.com/elprogramadorgt/lucidRAG/pkg/objectstore"
 	"github.com/elprogramadorgt/lucidRAG/pkg/openai"
+	"github.com/elprogramadorgt/lucidRAG/pkg/secretbox"
+	slackClient "github.com/elprogramadorgt/lucidRAG/pkg/slack"
+	"github.com/elprogramadorgt/lucidRAG/pkg/tei"
+	whatsappClient "github.com/elprogramadorgt/lucidRAG/pkg/whatsapp"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
@@ -39,6 +90,7 @@ func main() {
 		fmt.Fprintf(os.Stderr, "config: %v\n", err)
 		os.Exit(1)
 	}
+	workspaceTZ, _ := time.LoadLocation(cfg.Server.DefaultTimezone) // checked by config.Load
 
 	if cfg.Server.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -48,7 +100,11 @@ func main() {
 	mongoURI := fmt.Sprintf("mongodb://%s:%s@%s:%d/%s?authSource=admin",
 		cfg.Database.User, cfg.Database.Password, cfg.Database.Host, cfg.Database.Port, cfg.Database.Name)
 
-	db, err := mongo.NewClient(ctx, mongoURI, cfg.Database.Name)
+	db, err := mongo.NewClient(ctx, mongoURI, cfg.Database.Name, mongo.ClientOptions{
+		RetryWindow:    time.Duration(cfg.Database.RetryWindowSeconds) * time.Second,
+		ReadPreference: cfg.Database.ReadPreference,
+		ReadYourWrites: cfg.Database.ReadYourWrites,
+	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "mongo: %v\n", err)
 		os.Exit(1)
@@ -61,45 +117,457 @@ func main() {
 		Store: logRepo,
 	})
 
+	indexRepo := mongo.NewIndexRepo(db)
+	indexCtx, cancelIndexes := context.WithTimeout(ctx, time.Minute)
+	if err := indexRepo.EnsureIndexes(indexCtx); err != nil {
+		log.Warn("failed to ensure indexes", "error", err)
+	}
+	cancelIndexes()
+
+	breakerCooldown := time.Duration(cfg.Server.BreakerCooldownSeconds) * time.Second
+	openaiBreaker := breaker.New("openai", cfg.Server.BreakerFailures, breakerCooldown)
+	whatsappBreaker := breaker.New("whatsapp", cfg.Server.BreakerFailures, breakerCooldown)
+
+	bus := events.NewBus()
+	bus.SubscribeAll(events.LogHandler(log))
+
+	spend := docApp.NewSpendTracker(docApp.SpendConfig{
+		Repo: mongo.NewSpendRepo(db), Prices: modelPrices(cfg.RAG.ModelPrices),
+		DailyCapUSD: cfg.RAG.DailySpendCapUSD, MaxRequestUSD: cfg.RAG.MaxRequestCostUSD, Events: bus, Log: log,
+	})
+
 	var openaiClient *openai.Client
 	if cfg.RAG.OpenAIAPIKey != "" {
-		openaiClient = openai.NewClient(cfg.RAG.OpenAIAPIKey)
+		openaiClient = openai.NewClient(cfg.RAG.OpenAIAPIKey, openai.WithBreaker(openaiBreaker), openai.WithUsageHook(spend.Record))
+	}
+	var embeddingServer *tei.Client
+	var embeddingBreaker *breaker.Breaker
+	if cfg.RAG.EmbeddingServerURL != "" {
+		embeddingBreaker = breaker.New("embeddings", cfg.Server.BreakerFailures, breakerCooldown)
+		embeddingServer = tei.NewClient(cfg.RAG.EmbeddingServerURL, tei.WithAPIKey(cfg.RAG.EmbeddingServerAPIKey),
+			tei.WithBatchSize(cfg.RAG.EmbeddingBatchSize), tei.WithBreaker(embeddingBreaker))
+		if err := detectEmbeddingModel(ctx, cfg, embeddingServer, log); err != nil {
+			fmt.Fprintf(os.Stderr, "embedding server: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	var embedder docApp.Embedder
+	if embeddingServer != nil {
+		embedder = embeddingServer
+	}
+	fallbackModels, ollamaBreaker := chatFallbacks(cfg, openaiClient, breakerCooldown)
+	providers := systemApp.NewProviderProber(systemApp.ProberConfig{
+		Providers: modelProviders(openaiClient, embeddingServer, fallbackModels), Log: log,
+		Interval: time.Duration(cfg.Server.ProviderProbeSeconds) * time.Second,
+	})
+	providers.Start()
+
+	appCache, closeCache, err := newCache(ctx, cfg.Cache)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cache: %v\n", err)
+		os.Exit(1)
+	}
+
+	objects, err := newObjectStore(cfg.Objects)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "object store: %v\n", err)
+		os.Exit(1)
 	}
 
 	whatsappSvc := whatsapp.NewService(mongo.NewWhatsappRepo(db))
+	documentRepo, chunkRepo, storageRepo := mongo.NewDocumentRepo(db), mongo.NewChunkRepo(db), mongo.NewStorageRepo(db)
+	migrationRepo := mongo.NewEmbeddingMigrationRepo(db)
+	convRepo, msgRepo := mongo.NewConversationRepo(db), mongo.NewMessageRepo(db)
+	conversationSvc := convApp.NewService(convApp.ServiceConfig{
+		ConvRepo: convRepo, MsgRepo: msgRepo,
+		ReadRepo: mongo.NewReadMarkerRepo(db), NoteRepo: mongo.NewNoteRepo(db), CannedRepo: mongo.NewCannedResponseRepo(db), Events: bus,
+		TagRepo: mongo.NewTagRepo(db), FilterRepo: mongo.NewSavedFilterRepo(db),
+		RuleRepo: mongo.NewAssignmentRuleRepo(db), AssignmentRepo: mongo.NewAssignmentRepo(db), Cache: appCache,
+		SLA: conversationDomain.SLATargets{
+			FirstResponse: time.Duration(cfg.SLA.FirstResponseMinutes) * time.Minute,
+			Resolution:    time.Duration(cfg.SLA.ResolutionHours) * time.Hour,
+		},
+		Retention: conversationDomain.Retention{
+			ConversationDays: cfg.Retention.ConversationDays,
+			MessageDays:      cfg.Retention.MessageDays,
+			NoteDays:         cfg.Retention.NoteDays,
+		},
+	})
+	convApp.NewTagger(conversationSvc, log).Subscribe(bus)
+	// Resources with a calendar_id need the service account to read and
+	// write their Google Calendar.
+	bookingCfg := bookingApp.ServiceConfig{
+		Repo: mongo.NewBookingRepo(db), Resources: mongo.NewBookingResourceRepo(db), ConvRepo: convRepo, ConvSvc: conversationSvc,
+		PhoneNumberID: cfg.WhatsApp.PhoneNumberID, ReminderHours: cfg.Booking.ReminderHours,
+	}
+	if cfg.Booking.CalendarCredentialsFile != "" {
+		key, err := os.ReadFile(cfg.Booking.CalendarCredentialsFile)
+		if err == nil {
+			bookingCfg.Calendar, err = gcal.NewClient(key)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "google calendar: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if cfg.WhatsApp.APIKey != "" && cfg.WhatsApp.PhoneNumberID != "" {
+		bookingCfg.Sender = whatsappClient.NewClient(cfg.WhatsApp.APIKey,
+			whatsappClient.WithAPIVersion(cfg.WhatsApp.APIVersion), whatsappClient.WithBreaker(whatsappBreaker))
+	}
+	bookingSvc := bookingApp.NewService(bookingCfg)
+	toolRepo, toolInvocationRepo := mongo.NewToolRepo(db), mongo.NewToolInvocationRepo(db)
+	queryLogRepo := mongo.NewQueryLogRepo(db)
+	productSvc := productApp.NewService(productApp.ServiceConfig{Repo: mongo.NewProductRepo(db)})
+	toolSvc := toolApp.NewService(toolApp.ServiceConfig{Repo: toolRepo, InvocationRepo: toolInvocationRepo, LookupHosts: cfg.Tools.LookupHosts})
+	textChunker := chunker.New(cfg.RAG.ChunkSize, cfg.RAG.ChunkOverlap)
+	textChunker.MaxTableRows = cfg.RAG.TableRowsPerChunk
 	documentSvc := docApp.NewService(docApp.ServiceConfig{
-		Repo: mongo.NewDocumentRepo(db), ChunkRepo: mongo.NewChunkRepo(db),
-		OpenAIClient: openaiClient, Chunker: chunker.New(cfg.RAG.ChunkSize, cfg.RAG.ChunkOverlap),
-		EmbeddingModel: cfg.RAG.EmbeddingModel, ModelName: cfg.RAG.ModelName,
+		Repo: documentRepo, ChunkRepo: chunkRepo, RuleRepo: mongo.NewRuleRepo(db), StorageRepo: storageRepo,
+		OpenAIClient: openaiClient, Embedder: embedder, Chunker: textChunker,
+		EmbeddingModel: cfg.RAG.EmbeddingModel, ModelName: cfg.RAG.ModelName, FallbackModels: fallbackModels,
+		MaxContextTokens: cfg.RAG.MaxContextTokens, DedupThreshold: cfg.RAG.DedupThreshold, AllowedModels: cfg.RAG.AllowedModels,
+		Cache: appCache, AnswerCacheTTL: cfg.RAG.AnswerCacheTTL, Events: bus,
+		Tools: toolApp.NewRunner(toolRepo, toolInvocationRepo, bookingSvc, cfg.Tools.LookupHosts), Products: productSvc, FormatRepo: mongo.NewFormatProfileRepo(db),
+		MigrationRepo: migrationRepo, CollectionRepo: mongo.NewCollectionRepo(db), ShortcutRepo: mongo.NewShortcutRepo(db),
+		GlossaryRepo: mongo.NewGlossaryRepo(db), CleaningRepo: mongo.NewCleaningProfileRepo(db), QueryLogRepo: queryLogRepo,
+		Duplicates: documentDomain.DuplicatePolicy(cfg.RAG.DuplicateDocuments), DuplicateSimilarity: cfg.RAG.DuplicateSimilarity,
+		Spend: spend, Models: providers, MaxDocumentBytes: int64(cfg.RAG.MaxDocumentMB) << 20, Log: log, Guardrail: guardrail.Policy{
+			StripUnsourced: cfg.RAG.StripUnsourced, BannedPhrases: cfg.RAG.BannedPhrases,
+			MinGroundedness: cfg.RAG.MinGroundedness, MaxChars: cfg.RAG.MaxAnswerChars,
+		},
+		Files: objects,
+		Extractor: extract.New(extract.Config{
+			OCR: cfg.Extract.OCREnabled, Language: cfg.Extract.OCRLanguage, MinChars: cfg.Extract.OCRMinChars,
+			TesseractPath: cfg.Extract.TesseractPath, PdftotextPath: cfg.Extract.PdftotextPath, PdftoppmPath: cfg.Extract.PdftoppmPath,
+		}),
 	})
-	userSvc := userApp.NewService(userApp.ServiceConfig{
-		Repo: mongo.NewUserRepo(db), JWTSecret: cfg.Auth.JWTSecret,
-		JWTExpiry: time.Duration(cfg.Auth.JWTExpiryHours) * time.Hour,
+	userRepo, preferencesRepo := mongo.NewUserRepo(db), mongo.NewPreferencesRepo(db)
+	userCfg := userApp.ServiceConfig{
+		Repo: userRepo, PreferencesRepo: preferencesRepo, JWTSecret: cfg.Auth.JWTSecret,
+		JWTExpiry: time.Duration(cfg.Auth.JWTExpiryHours) * time.Hour, Cache: appCache,
+		RevalidateTokens: cfg.Auth.RevalidateTokens, Events: bus, DefaultTimezone: cfg.Server.DefaultTimezone,
+		Sessions: mongo.NewSessionRepo(db), BindAdminDevices: cfg.Auth.BindAdminDevices,
+	}
+	// Admin devices are only remembered to alert on new ones, which takes SMTP.
+	if cfg.Auth.AdminLoginAlerts && cfg.Email.SMTPHost != "" {
+		userCfg.Devices = mongo.NewDeviceRepo(db)
+	}
+	userSvc := userApp.NewService(userCfg)
+	registrationCfg := userApp.RegistrationGuardConfig{
+		Cache: appCache, SiteKey: cfg.Register.CaptchaSiteKey, PerIP: cfg.Register.PerIP, PerDomain: cfg.Register.PerDomain,
+	}
+	if cfg.Register.CaptchaProvider != "" {
+		if registrationCfg.Captcha, err = captcha.NewClient(cfg.Register.CaptchaProvider, cfg.Register.CaptchaSecret); err != nil {
+			fmt.Fprintf(os.Stderr, "captcha: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	// Without a key CRM connections cannot be saved and the sync is idle.
+	var crmBox *secretbox.Box
+	if cfg.CRM.EncryptionKey != "" {
+		if crmBox, err = secretbox.New(cfg.CRM.EncryptionKey); err != nil {
+			fmt.Fprintf(os.Stderr, "crm: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	crmSvc := crmApp.NewService(crmApp.ServiceConfig{Repo: mongo.NewCRMRepo(db), ConvRepo: convRepo, Box: crmBox, Log: log})
+	backupSvc := backupApp.NewService(backupApp.ServiceConfig{
+		Repo: mongo.NewBackupRepo(db), Store: objects, StorageRepo: storageRepo, Events: bus, Log: log,
 	})
-	conversationSvc := convApp.NewService(convApp.ServiceConfig{
-		ConvRepo: mongo.NewConversationRepo(db), MsgRepo: mongo.NewMessageRepo(db),
+	whatsappCfg := whatsappHandler.HandlerConfig{
+		WhatsAppSvc: whatsappSvc, ConversationSvc: conversationSvc,
+		WebhookVerifyToken: cfg.WhatsApp.WebhookVerifyToken, Log: log,
+	}
+	var voice whatsappDomain.VoiceReplier
+	var attachments whatsappDomain.AttachmentReplier
+	if cfg.WhatsApp.Attachments {
+		sender := whatsappClient.NewClient(cfg.WhatsApp.APIKey,
+			whatsappClient.WithAPIVersion(cfg.WhatsApp.APIVersion), whatsappClient.WithBreaker(whatsappBreaker))
+		attachments = whatsapp.NewAttachmentReplier(documentSvc, sender, cfg.WhatsApp.PhoneNumberID)
+	}
+	// Voice notes and photos are downloaded from WhatsApp before OpenAI
+	// reads them, so both need credentials for each.
+	if openaiClient != nil && cfg.WhatsApp.APIKey != "" {
+		media := whatsappClient.NewClient(cfg.WhatsApp.APIKey,
+			whatsappClient.WithAPIVersion(cfg.WhatsApp.APIVersion), whatsappClient.WithBreaker(whatsappBreaker))
+		whatsappCfg.Transcriber = whatsapp.NewTranscriber(media, openaiClient, cfg.RAG.TranscriptionModel)
+		whatsappCfg.ImageDescriber = whatsapp.NewImageDescriber(media, openaiClient, cfg.RAG.VisionModel)
+		if cfg.WhatsApp.VoiceReplies {
+			voice = whatsapp.NewVoiceReplier(openaiClient, media, cfg.WhatsApp.PhoneNumberID, cfg.RAG.SpeechVoice, cfg.RAG.SpeechModel)
+		}
+	}
+	onboardingCfg := whatsapp.OnboardingConfig{
+		Repo: mongo.NewOnboardingRepo(db), ConvRepo: convRepo, ConvSvc: conversationSvc, PhoneNumberID: cfg.WhatsApp.PhoneNumberID,
+	}
+	if cfg.WhatsApp.APIKey != "" && cfg.WhatsApp.PhoneNumberID != "" {
+		onboardingCfg.Sender = whatsappClient.NewClient(cfg.WhatsApp.APIKey,
+			whatsappClient.WithAPIVersion(cfg.WhatsApp.APIVersion), whatsappClient.WithBreaker(whatsappBreaker))
+	}
+	onboarding := whatsapp.NewOnboardingService(onboardingCfg)
+	whatsappCfg.Onboarding = onboarding
+	flowRepo := mongo.NewWhatsAppFlowRepo(db)
+	flowCfg := whatsapp.FlowConfig{Repo: flowRepo, ConvSvc: conversationSvc, PhoneNumberID: cfg.WhatsApp.PhoneNumberID}
+	if cfg.WhatsApp.APIKey != "" && cfg.WhatsApp.PhoneNumberID != "" {
+		flowCfg.Sender = whatsappClient.NewClient(cfg.WhatsApp.APIKey,
+			whatsappClient.WithAPIVersion(cfg.WhatsApp.APIVersion), whatsappClient.WithBreaker(whatsappBreaker))
+	}
+	whatsappCfg.Flows = whatsapp.NewFlowService(flowCfg)
+	whatsapp.NewFlowCollector(flowRepo, convRepo, log).Subscribe(bus)
+	var typing whatsappDomain.TypingIndicator
+	if cfg.WhatsApp.TypingIndicator && cfg.WhatsApp.APIKey != "" {
+		typing = whatsapp.NewTypingIndicator(whatsappClient.NewClient(cfg.WhatsApp.APIKey,
+			whatsappClient.WithAPIVersion(cfg.WhatsApp.APIVersion), whatsappClient.WithBreaker(whatsappBreaker)), cfg.WhatsApp.PhoneNumberID)
+	}
+	whatsapp.NewResponder(conversationSvc, documentSvc, voice, attachments, onboarding, typing, cfg.RAG.HistoryMessages, log).Subscribe(bus)
+	if openaiClient != nil && cfg.RAG.SummarizeHistory && cfg.RAG.HistoryMessages > 0 {
+		convApp.NewSummarizer(convApp.SummarizerConfig{
+			ConvRepo: convRepo, MsgRepo: msgRepo, Model: openaiClient, ModelName: cfg.RAG.ModelName,
+			Window: cfg.RAG.HistoryMessages, Log: log,
+		}).Subscribe(bus)
+	}
+	whatsappHdlr := whatsappHandler.NewHandler(whatsappCfg)
+
+	questionRepo := mongo.NewQuestionRepo(db)
+	topicApp.NewRecorder(questionRepo, log).Subscribe(bus)
+	topicCfg := topicApp.ServiceConfig{
+		QuestionRepo: questionRepo, SnapshotRepo: mongo.NewTopicSnapshotRepo(db),
+		EmbeddingModel: cfg.RAG.EmbeddingModel, ChatModel: cfg.RAG.ModelName,
+		Window: time.Duration(cfg.Topics.WindowDays) * 24 * time.Hour, MaxTopics: cfg.Topics.MaxTopics, Log: log,
+	}
+	if openaiClient != nil {
+		topicCfg.Models = openaiClient
+	}
+	if embeddingServer != nil {
+		topicCfg.Embedder = embeddingServer
+	}
+	topicSvc := topicApp.NewService(topicCfg)
+
+	triggerRepo := mongo.NewTriggerRepo(db)
+	integrationApp.NewRecorder(triggerRepo, cfg.RAG.LowConfidence, log).Subscribe(bus)
+	webhookRepo := mongo.NewWebhookRepo(db)
+	integrationApp.NewWebhookDispatcher(webhookRepo, log).Subscribe(bus)
+	apiKeyRepo := mongo.NewAPIKeyRepo(db)
+	integrationCfg := integrationApp.ServiceConfig{
+		KeyRepo: apiKeyRepo, TriggerRepo: triggerRepo, WebhookRepo: webhookRepo, ConvSvc: conversationSvc, DocSvc: documentSvc, Log: log,
+	}
+	if cfg.WhatsApp.APIKey != "" && cfg.WhatsApp.PhoneNumberID != "" {
+		integrationCfg.Sender = whatsapp.NewTextSender(
+			whatsappClient.NewClient(cfg.WhatsApp.APIKey,
+				whatsappClient.WithAPIVersion(cfg.WhatsApp.APIVersion), whatsappClient.WithBreaker(whatsappBreaker)),
+			cfg.WhatsApp.PhoneNumberID,
+		)
+	}
+	integrationSvc := integrationApp.NewService(integrationCfg)
+	widgetSvc := widgetApp.NewService(widgetApp.ServiceConfig{
+		Keys: integrationSvc, KeyRepo: apiKeyRepo, DocSvc: documentSvc, Cache: appCache, Secret: cfg.Auth.JWTSecret,
+		SessionTTL: cfg.Widget.SessionTTL, MaxQuestions: cfg.Widget.MaxQuestions, Log: log,
 	})
 
-	whatsappHdlr := whatsappHandler.NewHandler(whatsappHandler.HandlerConfig{
-		WhatsAppSvc: whatsappSvc, ConversationSvc: conversationSvc, DocumentSvc: documentSvc,
-		WebhookVerifyToken: cfg.WhatsApp.WebhookVerifyToken, Log: log,
+	var slackHdlr *slackHandler.Handler
+	if cfg.Slack.SigningSecret != "" && cfg.Slack.BotToken != "" {
+		slackAPI := slackClient.NewClient(cfg.Slack.BotToken)
+		slackApp.NewResponder(slackAPI, conversationSvc, documentSvc, cfg.RAG.HistoryMessages, log).Subscribe(bus)
+		slackSvc := slackApp.NewService(slackApp.ServiceConfig{
+			Client: slackAPI, LinkRepo: mongo.NewSlackLinkRepo(db), UserRepo: userRepo,
+			ConvSvc: conversationSvc, DocSvc: documentSvc, Log: log,
+		})
+		slackHdlr = slackHandler.NewHandler(slackSvc, cfg.Slack.SigningSecret, log)
+	}
+
+	// EMAIL_FROM is validated when SMTP is configured, and the email channel
+	// requires SMTP.
+	var mailer *mail.SMTPClient
+	from, _ := netmail.ParseAddress(cfg.Email.From)
+	if cfg.Email.SMTPHost != "" {
+		mailer = mail.NewSMTPClient(cfg.Email.SMTPHost, cfg.Email.SMTPPort, cfg.Email.SMTPUsername, cfg.Email.SMTPPassword, *from)
+		if cfg.Auth.AdminLoginAlerts {
+			userApp.NewDeviceAlerter(mailer, cfg.Transcript.BrandName, log).Subscribe(bus)
+		}
+	}
+
+	var emailHdlr *emailHandler.Handler
+	if cfg.Email.InboundToken != "" {
+		emailSvc := emailApp.NewService(emailApp.ServiceConfig{
+			DraftRepo: mongo.NewEmailDraftRepo(db), ConvSvc: conversationSvc, DocSvc: documentSvc, Mailer: mailer,
+			Mode: emailDomain.ReplyMode(cfg.Email.ReplyMode), LowConfidence: cfg.RAG.LowConfidence,
+			Address: from.Address, HistoryWindow: cfg.RAG.HistoryMessages, Log: log,
+		})
+		emailHdlr = emailHandler.NewHandler(emailSvc, cfg.Email.InboundToken, log)
+	}
+
+	transcriptCfg := transcriptApp.ServiceConfig{
+		ConvSvc: conversationSvc, UserRepo: userRepo,
+		Brand: transcriptApp.Brand{Name: cfg.Transcript.BrandName, Color: cfg.Transcript.BrandColor},
+	}
+	if mailer != nil {
+		transcriptCfg.Mailer = mailer
+	}
+	transcriptSvc := transcriptApp.NewService(transcriptCfg)
+
+	reportCfg := reportApp.ServiceConfig{
+		Repo: mongo.NewReportScheduleRepo(db), ConvSvc: conversationSvc, TopicSvc: topicSvc, Queries: queryLogRepo,
+		LowConfidence: cfg.RAG.LowConfidence, Log: log,
+		Brand: reportApp.Brand{Name: cfg.Transcript.BrandName, Color: cfg.Transcript.BrandColor},
+	}
+	if mailer != nil {
+		reportCfg.Mailer = mailer
+	}
+	reportSvc := reportApp.NewService(reportCfg)
+
+	elector := cluster.NewElector(cluster.ElectorConfig{
+		Repo: mongo.NewLeaseRepo(db), Log: log, Instance: cfg.Server.InstanceID,
+		TTL: time.Duration(cfg.Server.LeaderLeaseSeconds) * time.Second,
+	})
+	elector.Start()
+
+	// The embedding migration is what maintenance windows are usually for,
+	// so it keeps running.
+	maintenance := systemApp.NewMaintenanceMode(systemApp.MaintenanceConfig{
+		Repo: mongo.NewMaintenanceRepo(db), Log: log, KeepJobs: []string{"embedding_migration"},
+	})
+	if cfg.Server.MaintenanceMode {
+		if _, err := maintenance.Set(ctx, true, cfg.Server.MaintenanceMessage, "startup"); err != nil {
+			fmt.Fprintf(os.Stderr, "maintenance mode: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	maintenance.Start()
+
+	jobs := scheduler.New(scheduler.Config{
+		Repo: mongo.NewJobRepo(db), Leader: elector, Pauser: maintenance, Log: log, Owner: elector.Instance(), Location: workspaceTZ,
 	})
+	mustRegisterJob(jobs, "document_publication", "* * * * *", 30*time.Second,
+		docApp.NewPublicationJob(documentRepo, chunkRepo).Run)
+	mustRegisterJob(jobs, "log_retention", "0 3 * * *", 5*time.Minute, func(ctx context.Context) error {
+		_, err := logRepo.DeleteOlderThan(ctx, cfg.Server.LogRetentionDays)
+		return err
+	})
+	mustRegisterJob(jobs, "chunk_gc", "30 4 * * *", 10*time.Minute, func(ctx context.Context) error {
+		_, err := documentSvc.CollectChunkGarbage(ctx, documentDomain.UserContext{IsAdmin: true}, false)
+		return err
+	})
+	mustRegisterJob(jobs, "crm_sync", cfg.CRM.SyncSchedule, 10*time.Minute, crmSvc.Sync)
+	if cfg.Booking.ReminderHours > 0 {
+		mustRegisterJob(jobs, "booking_reminders", "*/5 * * * *", 2*time.Minute, bookingSvc.SendReminders)
+	}
+	mustRegisterJob(jobs, "sla_breaches", "* * * * *", 30*time.Second, convApp.NewSLABreachJob(convRepo).Run)
+	if cfg.Server.ConversationAutoCloseDays > 0 {
+		mustRegisterJob(jobs, "conversation_auto_close", "15 * * * *", 5*time.Minute,
+			convApp.NewAutoCloseJob(convRepo, cfg.Server.ConversationAutoCloseDays).Run)
+	}
+	if cfg.Retention.ConversationDays > 0 || cfg.Retention.MessageDays > 0 || cfg.Retention.NoteDays > 0 {
+		mustRegisterJob(jobs, "data_retention", cfg.Retention.Schedule, 30*time.Minute, func(ctx context.Context) error {
+			_, err := conversationSvc.ApplyRetention(ctx)
+			return err
+		})
+	}
+	if embeddingServer != nil {
+		mustRegisterJob(jobs, "embedding_migration", "* * * * *", 55*time.Second,
+			docApp.NewEmbeddingMigrationJob(migrationRepo, chunkRepo, embeddingServer).Run)
+	} else if openaiClient != nil {
+		mustRegisterJob(jobs, "embedding_migration", "* * * * *", 55*time.Second,
+			docApp.NewEmbeddingMigrationJob(migrationRepo, chunkRepo, openaiClient).Run)
+	}
+	if openaiClient != nil {
+		mustRegisterJob(jobs, "topic_clustering", cfg.Topics.Schedule, 15*time.Minute, topicApp.NewClusteringJob(topicSvc).Run)
+	}
+	// Digests go out at DIGEST_HOUR in each admin's time zone and scheduled
+	// reports at their own hour and time zone, so the jobs check every hour.
+	if mailer != nil {
+		mustRegisterJob(jobs, "notification_digest", "0 * * * *", 10*time.Minute, digestApp.NewJob(digestApp.JobConfig{
+			Users: userRepo, Preferences: preferencesRepo, Queries: queryLogRepo, Invocations: toolInvocationRepo,
+			Mailer: mailer, LowConfidence: cfg.RAG.LowConfidence, Hour: cfg.Digest.Hour, Weekday: cfg.Digest.Weekday,
+			Brand: cfg.Transcript.BrandName, Log: log,
+		}).Run)
+		mustRegisterJob(jobs, "report_delivery", "0 * * * *", 10*time.Minute, reportSvc.SendDue)
+	}
+	jobs.Start()
+
+	var requestCount atomic.Int64
+	routeStats := systemApp.NewRouteStats()
+	metricsRepo := mongo.NewMetricsRepo(db)
+	sampler := systemApp.NewSampler(systemApp.SamplerConfig{
+		Repo: metricsRepo, DB: db, Requests: &requestCount, Log: log, Instance: elector.Instance(),
+		Interval: time.Duration(cfg.Server.MetricsSampleSeconds) * time.Second,
+	})
+	sampler.Start()
 
 	authMw, adminMw := middleware.AuthMiddleware(userSvc), middleware.RequireRole("admin")
-	rateLimiter := middleware.NewRateLimiter(100, time.Minute)
+	rateLimiter := middleware.NewClientRateLimiter(middleware.NewCacheRateLimiter(appCache, cfg.RateLimit.PerMinute, time.Minute), middleware.RatePolicy{
+		AllowIPs:        cfg.RateLimit.AllowIPs,
+		AllowUsers:      cfg.RateLimit.AllowUsers,
+		AllowAPIKeys:    cfg.RateLimit.AllowAPIKeys,
+		RoleMultipliers: cfg.RateLimit.RoleMultipliers,
+	}, userSvc, integrationSvc)
+
+	var asnDB *asn.DB
+	if cfg.Abuse.ASNDatabase != "" {
+		if asnDB, err = asn.Open(cfg.Abuse.ASNDatabase); err != nil {
+			fmt.Fprintf(os.Stderr, "ASN database: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	abuseGuard := systemApp.NewAbuseGuard(systemApp.AbuseConfig{
+		Cache: appCache, Repo: mongo.NewSecurityEventRepo(db), ASN: asnDB, Log: log,
+		BurstRequests: cfg.Abuse.BurstRequests, BurstWindow: cfg.Abuse.BurstWindow,
+		BlockFor: cfg.Abuse.BlockFor, TravelWindow: cfg.Abuse.TravelWindow, BlockedASNs: cfg.Abuse.BlockedASNs,
+	})
+	// Webhooks only count failed deliveries, so providers sending in bulk
+	// are not taken for abuse.
+	widgetAbuseMw := middleware.AbuseGuard(abuseGuard, "widget", false)
+	webhookAbuseMw := middleware.AbuseGuard(abuseGuard, "webhook", true)
 
 	r := gin.New()
-	r.Use(gin.Recovery(), middleware.RequestID(), middleware.Logger(log))
+	r.Use(gin.Recovery(), middleware.RequestID())
+	// Compression wraps the writer first, so it sees bodies as translated.
+	if cfg.Server.CompressMinBytes > 0 {
+		r.Use(middleware.Compress(cfg.Server.CompressMinBytes, []string{"/api/v1/conversations/stream"}))
+	}
+	r.Use(middleware.Language(cfg.Server.DefaultLanguage), middleware.Timezone(userSvc, workspaceTZ), middleware.Logger(log), middleware.CountRequests(&requestCount), middleware.RecordRoutes(routeStats))
+	r.Use(middleware.PublicCORS("/api/v1/public/"))
 	r.Use(middleware.CORS([]string{"http://localhost:4200", "http://localhost:8080"}))
-	r.Use(middleware.RateLimit(rateLimiter))
+	r.Use(middleware.Maintenance(maintenance, userSvc, []string{"/healthz", "/readyz", "/api/v1/auth/"}))
+	r.Use(middleware.ClientRateLimit(rateLimiter))
+	uploadTimeout := time.Duration(cfg.Server.UploadTimeoutSeconds) * time.Second
+	ragTimeout := time.Duration(cfg.Server.RAGTimeoutSeconds) * time.Second
+	r.Use(middleware.Timeout(time.Duration(cfg.Server.RequestTimeoutSeconds)*time.Second, []middleware.TimeoutRule{
+		// Creating, uploading, updating and reprocessing documents chunk
+		// and embed them before answering; reading them does not.
+		{Method: http.MethodPost, Prefix: "/api/v1/documents", Timeout: uploadTimeout},
+		{Method: http.MethodPut, Prefix: "/api/v1/documents", Timeout: uploadTimeout},
+		{Prefix: "/api/v1/chunks/gc", Timeout: uploadTimeout},
+		{Prefix: "/api/v1/integrations/actions/create-document", Timeout: uploadTimeout},
+		{Prefix: "/api/v1/system/storage/rebuild", Timeout: uploadTimeout},
+		{Prefix: "/api/v1/crm/sync", Timeout: uploadTimeout},
+		{Prefix: "/api/v1/email/drafts", Timeout: uploadTimeout},
+		{Prefix: "/api/v1/conversations/:id/transcript", Timeout: uploadTimeout},
+		{Prefix: "/api/v1/rag/query", Timeout: ragTimeout},
+		{Prefix: "/api/v1/public/chat/messages", Timeout: ragTimeout},
+		{Prefix: "/api/v1/whatsapp/webhook", Timeout: ragTimeout},
+		// These clear the connection deadlines and run until done.
+		{Prefix: "/api/v1/conversations/stream", Timeout: 0},
+		{Prefix: "/api/v1/chunks/export", Timeout: 0},
+		{Prefix: "/api/v1/chunks/import", Timeout: 0},
+		{Prefix: "/api/v1/documents/stream", Timeout: 0},
+		{Prefix: "/api/v1/system/backups", Timeout: 0},
+	}))
 
 	r.GET("/healthz", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"status": "ok"}) })
+	// An open breaker is reported but does not fail readiness: every replica
+	// shares the dependency, so none would be better placed to serve.
 	r.GET("/readyz", func(c *gin.Context) {
+		breakers := gin.H{"openai": openaiBreaker.Status(), "whatsapp": whatsappBreaker.Status()}
+		if ollamaBreaker != nil {
+			breakers["ollama"] = ollamaBreaker.Status()
+		}
+		if embeddingBreaker != nil {
+			breakers["embeddings"] = embeddingBreaker.Status()
+		}
 		if err := db.Ping(c.Request.Context()); err != nil {
-			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "error"})
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "error", "breakers": breakers})
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "breakers": breakers})
 	})
 
 	v1 := r.Group("/api/v1")
@@ -108,15 +576,80 @@ func main() {
 		Secure:      cfg.Auth.CookieSecure,
 		ExpiryHours: cfg.Auth.JWTExpiryHours,
 	}
-	authHandler.Register(v1, authHandler.NewHandler(userSvc, log, cookieCfg), authMw)
-	authHandler.RegisterOAuth(v1, authHandler.NewOAuthHandler(userSvc, log, cfg.Auth.OAuth, cookieCfg))
-	whatsappHandler.Register(v1, whatsappHdlr)
-	ragHandler.Register(v1.Group("/rag", authMw), ragHandler.NewHandler(documentSvc, log))
-	documentHandler.Register(v1.Group("/documents", authMw), documentHandler.NewHandler(documentSvc, log))
-	conversationHandler.Register(v1.Group("/conversations", authMw), conversationHandler.NewHandler(conversationSvc, log))
+	confirmSigner := confirm.New(cfg.Auth.JWTSecret, cfg.Auth.ConfirmTokenTTL, appCache)
+	authHdlr := authHandler.NewHandler(userSvc, userApp.NewRegistrationGuard(registrationCfg), log, cookieCfg)
+	authHandler.Register(v1, authHdlr, authMw)
+	authHandler.RegisterSessions(v1.Group("/users/me/sessions", authMw), authHdlr)
+	authHandler.RegisterUsers(v1.Group("/users", authMw, adminMw), authHdlr)
+	adminHandler.Register(v1.Group("/admin", authMw, adminMw), adminHandler.NewHandler(documentSvc, conversationSvc, confirmSigner, log))
+	authHandler.RegisterOAuth(v1, authHandler.NewOAuthHandler(userSvc, log, cfg.Auth.OAuth, cookieCfg, appCache))
+	whatsappHandler.Register(v1.Group("", webhookAbuseMw), whatsappHdlr)
+	whatsappHandler.RegisterOnboarding(v1.Group("/whatsapp/onboarding", authMw, adminMw), whatsappHdlr)
+	whatsappHandler.RegisterFlows(v1.Group("/whatsapp/flows", authMw, adminMw), whatsappHdlr)
+	whatsappHandler.RegisterFlowSends(v1.Group("/whatsapp/flows", authMw), whatsappHdlr)
+	if slackHdlr != nil {
+		slackHandler.Register(v1.Group("", webhookAbuseMw), slackHdlr)
+	}
+	widgetHandler.Register(v1.Group("", widgetAbuseMw), widgetHandler.NewHandler(widgetSvc, log),
+		middleware.RateLimitBy(middleware.NewCacheRateLimiter(appCache, cfg.Widget.SessionsPerMinute, time.Minute),
+			func(c *gin.Context) string { return "widget_session:" + c.ClientIP() }),
+		middleware.RateLimitBy(middleware.NewCacheRateLimiter(appCache, cfg.Widget.MessagesPerMinute, time.Minute),
+			func(c *gin.Context) string { return "widget_message:" + c.ClientIP() }),
+	)
+	if emailHdlr != nil {
+		emailHandler.RegisterInbound(v1.Group("", webhookAbuseMw), emailHdlr)
+		emailHandler.RegisterDrafts(v1.Group("/email/drafts", authMw, adminMw), emailHdlr)
+	}
+	ragHdlr := ragHandler.NewHandler(documentSvc, log)
+	ragHandler.Register(v1.Group("/rag", authMw), ragHdlr)
+	ragHandler.RegisterRules(v1.Group("/rag/rules", authMw, adminMw), ragHdlr)
+	ragHandler.RegisterShortcuts(v1.Group("/rag/shortcuts", authMw, adminMw), ragHdlr)
+	ragHandler.RegisterGlossary(v1.Group("/rag/glossary", authMw, adminMw), ragHdlr)
+	ragHandler.RegisterFormats(v1.Group("/rag/formats", authMw, adminMw), ragHdlr)
+	ragHandler.RegisterQueryLog(v1.Group("/rag/queries", authMw, adminMw), ragHdlr)
+	ragHandler.RegisterReplay(v1.Group("/rag/replay", authMw, adminMw), ragHdlr)
+	toolHandler.Register(v1.Group("/rag/tools", authMw, adminMw), toolHandler.NewHandler(toolSvc, log))
+	bookingHdlr := bookingHandler.NewHandler(bookingSvc, log)
+	bookingHandler.RegisterResources(v1.Group("/bookings/resources", authMw, adminMw), bookingHdlr)
+	bookingHandler.Register(v1.Group("/bookings", authMw), bookingHdlr)
+	productHandler.Register(v1.Group("/products", authMw, adminMw), productHandler.NewHandler(productSvc, log))
+	documentHdlr := documentHandler.NewHandler(documentSvc, confirmSigner, log)
+	documentHandler.Register(v1.Group("/documents", authMw), documentHdlr)
+	documentHandler.RegisterChunks(v1.Group("/chunks", authMw, adminMw), documentHdlr)
+	documentHandler.RegisterStorage(v1.Group("/system/storage", authMw, adminMw), documentHdlr)
+	documentHandler.RegisterEmbeddings(v1.Group("/system/embeddings", authMw, adminMw), documentHdlr)
+	documentHandler.RegisterCollections(v1.Group("/collections", authMw, adminMw), documentHdlr)
+	documentHandler.RegisterCleaningProfiles(v1.Group("/cleaning-profiles", authMw, adminMw), documentHdlr)
+	backupHandler.Register(v1.Group("/system/backups", authMw, adminMw), backupHandler.NewHandler(backupSvc, log))
+	conversations := v1.Group("/conversations", authMw)
+	conversationHdlr := conversationHandler.NewHandler(conversationSvc, log)
+	conversationHandler.Register(conversations, conversationHdlr)
+	conversationHandler.RegisterAnalytics(v1.Group("/analytics", authMw, adminMw), conversationHdlr)
+	conversationHandler.RegisterCompliance(v1.Group("/compliance", authMw, adminMw), conversationHdlr)
+	topicHandler.Register(v1.Group("/analytics/topics", authMw, adminMw), topicHandler.NewHandler(topicSvc, log))
+	reportHandler.Register(v1.Group("/reports", authMw, adminMw), reportHandler.NewHandler(reportSvc, log))
+	conversationHandler.RegisterCannedResponses(v1.Group("/canned-responses", authMw, adminMw), conversationHdlr)
+	conversationHandler.RegisterTags(v1.Group("/tags", authMw), conversationHdlr)
+	conversationHandler.RegisterAssignmentRules(v1.Group("/assignment-rules", authMw, adminMw), conversationHdlr)
+	transcriptHandler.Register(conversations, transcriptHandler.NewHandler(transcriptSvc, log))
+	crmHandler.Register(v1.Group("/crm", authMw, adminMw), crmHandler.NewHandler(crmSvc, log))
+	integrationHdlr := integrationHandler.NewHandler(integrationSvc, log)
+	integrationHandler.RegisterKeys(v1.Group("/integrations/keys", authMw, adminMw), integrationHdlr)
+	integrationHandler.RegisterWebhooks(v1.Group("/integrations/webhooks", authMw, adminMw), integrationHdlr)
+	integrationHandler.Register(v1.Group("/integrations", middleware.APIKeyAuth(integrationSvc)), integrationHdlr)
 	systemHandler.Register(v1.Group("/system", authMw, adminMw), systemHandler.NewHandler(systemHandler.HandlerConfig{
 		Repo:        logRepo,
+		Overview:    mongo.NewOverviewRepo(db),
+		Metrics:     metricsRepo,
+		Routes:      routeStats,
+		Indexes:     indexRepo,
 		DB:          db,
+		Jobs:        jobs,
+		Cluster:     elector,
+		Providers:   providers,
+		Maintenance: maintenance,
+		RateLimits:  rateLimiter,
+		Abuse:       abuseGuard,
 		Log:         log,
 		StartTime:   startTime,
 		Environment: cfg.Server.Environment,
@@ -141,10 +674,137 @@ func main() {
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 	_ = srv.Shutdown(shutdownCtx)
-	rateLimiter.Stop()
+	sampler.Stop()
+	providers.Stop()
+	maintenance.Stop()
+	jobs.Stop()
+	elector.Stop()
+	closeCache()
+	_ = log.Close(shutdownCtx)
 	_ = db.Close(shutdownCtx)
 }
 
+func mustRegisterJob(s *scheduler.Scheduler, name, spec string, timeout time.Duration, fn scheduler.Func) {
+	if err := s.Register(name, spec, timeout, fn); err != nil {
+		fmt.Fprintf(os.Stderr, "scheduler: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// newCache builds the configured cache backend and returns a function that
+// releases it on shutdown.
+func newCache(ctx context.Context, cfg config.CacheConfig) (cache.Cache, func(), error) {
+	if cfg.Driver == "redis" {
+		c, err := cache.NewRedis(ctx, cache.RedisOptions{
+			Addr:     cfg.RedisAddr,
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+			Prefix:   "lucidrag:",
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return c, func() { _ = c.Close() }, nil
+	}
+
+	c := cache.NewMemory()
+	return c, c.Stop, nil
+}
+
+// newObjectStore builds the configured object storage backend.
+func newObjectStore(cfg config.ObjectStoreConfig) (objectstore.Store, error) {
+	if cfg.Driver == "s3" {
+		return objectstore.NewS3(objectstore.S3Options{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+		}), nil
+	}
+	return objectstore.NewFS(cfg.Dir)
+}
+
+// chatFallbacks builds the fallback answer models. OpenAI models share the
+// primary client and its breaker; Ollama models share a client and breaker
+// of their own, which is nil when none is configured.
+func chatFallbacks(cfg *config.Config, openaiClient *openai.Client, cooldown time.Duration) ([]docApp.ChatModel, *breaker.Breaker) {
+	var models []docApp.ChatModel
+	var ollama *openai.Client
+	var ollamaBreaker *breaker.Breaker
+	for _, entry := range cfg.RAG.FallbackModels {
+		name, isOllama := strings.CutPrefix(entry, "ollama:")
+		if !isOllama {
+			if openaiClient != nil {
+				models = append(models, docApp.ChatModel{Name: entry, Model: entry, Client: openaiClient})
+			}
+			continue
+		}
+		if ollama == nil {
+			ollamaBreaker = breaker.New("ollama", cfg.Server.BreakerFailures, cooldown)
+			ollama = openai.NewClient("", openai.WithBaseURL(cfg.RAG.OllamaBaseURL), openai.WithBreaker(ollamaBreaker))
+		}
+		models = append(models, docApp.ChatModel{Name: entry, Model: name, Client: ollama})
+	}
+	return models, ollamaBreaker
+}
+
+// detectEmbeddingModel asks the embedding server for its model, which
+// becomes the embedding model when none is configured, and its vector
+// size. Only an unknown model is fatal: a configured one is checked
+// against the server on every call.
+func detectEmbeddingModel(ctx context.Context, cfg *config.Config, server *tei.Client, log *logger.Logger) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	info, err := server.Info(ctx)
+	if err != nil {
+		if cfg.RAG.EmbeddingModel == "" {
+			return fmt.Errorf("failed to detect model: %w", err)
+		}
+		log.Warn("embedding server unavailable", "error", err)
+		return nil
+	}
+	if cfg.RAG.EmbeddingModel == "" {
+		cfg.RAG.EmbeddingModel = info.ModelID
+	}
+	dimensions, err := server.Dimensions(ctx)
+	if err != nil {
+		log.Warn("failed to detect embedding dimensions", "error", err)
+		return nil
+	}
+	log.Info("using embedding server", "model", info.ModelID, "dimensions", dimensions)
+	return nil
+}
+
+// modelProviders lists the model providers to probe: OpenAI, the
+// embedding server when one is configured, and the Ollama server when a
+// fallback uses it.
+func modelProviders(openaiClient *openai.Client, embeddingServer *tei.Client, fallbacks []docApp.ChatModel) []systemApp.Provider {
+	var providers []systemApp.Provider
+	if openaiClient != nil {
+		providers = append(providers, systemApp.Provider{Name: "openai", Client: openaiClient})
+	}
+	if embeddingServer != nil {
+		providers = append(providers, systemApp.Provider{Name: "embeddings", Client: embeddingServer})
+	}
+	for _, model := range fallbacks {
+		if strings.HasPrefix(model.Name, "ollama:") {
+			providers = append(providers, systemApp.Provider{Name: "ollama", Prefix: "ollama:", Client: model.Client})
+			break
+		}
+	}
+	return providers
+}
+
+func modelPrices(prices map[string]config.ModelPrice) map[string]documentDomain.ModelPrice {
+	out := make(map[string]documentDomain.ModelPrice, len(prices))
+	for model, price := range prices {
+		out[model] = documentDomain.ModelPrice{Input: price.Input, Output: price.Output}
+	}
+	return out
+}
+
 func logLevel(env string) string {
 	if env == "development" {
 		return "debug"
@@ -0,0 +1,404 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	backupDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/backup"
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/elprogramadorgt/lucidRAG/pkg/objectstore"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	keyPrefix     = "backups/"
+	archiveSuffix = ".jsonl.gz"
+	restoreBatch  = 500
+)
+
+var (
+	ErrBackupNotFound     = errors.New("backup not found")
+	ErrCorruptBackup      = errors.New("backup failed integrity check")
+	ErrUnsupportedVersion = errors.New("unsupported backup version")
+)
+
+type service struct {
+	repo        backupDomain.Repository
+	store       objectstore.Store
+	storageRepo documentDomain.StorageRepository
+	events      *events.Bus
+	log         *logger.Logger
+	now         func() time.Time
+}
+
+type ServiceConfig struct {
+	Repo  backupDomain.Repository
+	Store objectstore.Store
+	// StorageRepo, when set, has its totals rebuilt after a restore.
+	StorageRepo documentDomain.StorageRepository
+	Events      *events.Bus
+	// Log receives warnings about best-effort steps that failed; defaults
+	// to standard output.
+	Log *logger.Logger
+}
+
+func NewService(cfg ServiceConfig) backupDomain.Service {
+	bus := cfg.Events
+	if bus == nil {
+		bus = events.NewBus()
+	}
+	log := cfg.Log
+	if log == nil {
+		log = logger.New()
+	}
+	return &service{
+		repo:        cfg.Repo,
+		store:       cfg.Store,
+		storageRepo: cfg.StorageRepo,
+		events:      bus,
+		log:         log.With("service", "backup"),
+		now:         time.Now,
+	}
+}
+
+func archiveKey(id string) string  { return keyPrefix + id + archiveSuffix }
+func manifestKey(id string) string { return keyPrefix + id + ".json" }
+
+// record is one line of an archive.
+type record struct {
+	Kind backupDomain.Kind `json:"kind"`
+	Data json.RawMessage   `json:"data"`
+}
+
+// archiveWriter writes records as JSON lines, hashing everything it writes.
+type archiveWriter struct {
+	w      io.Writer
+	hash   hash.Hash
+	counts map[backupDomain.Kind]int64
+}
+
+func (a *archiveWriter) write(kind backupDomain.Kind, v any) error {
+	line, err := encodeRecord(kind, v)
+	if err != nil {
+		return err
+	}
+	if _, err := a.w.Write(line); err != nil {
+		return err
+	}
+	a.hash.Write(line)
+	a.counts[kind]++
+	return nil
+}
+
+func encodeRecord(kind backupDomain.Kind, v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	line, err := json.Marshal(record{Kind: kind, Data: data})
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+func (s *service) Create(ctx context.Context, adminID string) (*backupDomain.Manifest, error) {
+	manifest := &backupDomain.Manifest{
+		ID:        primitive.NewObjectID().Hex(),
+		Version:   backupDomain.FormatVersion,
+		CreatedAt: s.now().UTC(),
+		CreatedBy: adminID,
+	}
+
+	// The archive is staged on disk so its size is known before uploading.
+	tmp, err := os.CreateTemp("", "lucidrag-backup-*"+archiveSuffix)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if err := s.export(ctx, tmp, manifest); err != nil {
+		return nil, err
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if err := s.store.Put(ctx, archiveKey(manifest.ID), tmp, size); err != nil {
+		return nil, fmt.Errorf("upload backup: %w", err)
+	}
+
+	manifest.SizeBytes = size
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.Put(ctx, manifestKey(manifest.ID), bytes.NewReader(data), int64(len(data))); err != nil {
+		return nil, fmt.Errorf("upload manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// export writes the knowledge base to w as a gzipped archive and fills in
+// the manifest's counts and checksum.
+func (s *service) export(ctx context.Context, w io.Writer, manifest *backupDomain.Manifest) error {
+	zw := gzip.NewWriter(w)
+	buf := bufio.NewWriter(zw)
+	aw := &archiveWriter{w: buf, hash: sha256.New(), counts: map[backupDomain.Kind]int64{}}
+
+	if err := s.repo.EachDocument(ctx, func(d *documentDomain.Document) error {
+		return aw.write(backupDomain.KindDocument, d)
+	}); err != nil {
+		return fmt.Errorf("export documents: %w", err)
+	}
+	if err := s.repo.EachChunk(ctx, func(c *documentDomain.Chunk) error {
+		return aw.write(backupDomain.KindChunk, c)
+	}); err != nil {
+		return fmt.Errorf("export chunks: %w", err)
+	}
+	if err := s.repo.EachRule(ctx, func(r *documentDomain.RetrievalRule) error {
+		return aw.write(backupDomain.KindRule, r)
+	}); err != nil {
+		return fmt.Errorf("export rules: %w", err)
+	}
+
+	manifest.Counts = aw.counts
+	manifest.SHA256 = hex.EncodeToString(aw.hash.Sum(nil))
+	// The trailing manifest is not covered by its own checksum.
+	line, err := encodeRecord(backupDomain.KindManifest, manifest)
+	if err != nil {
+		return err
+	}
+	if _, err := buf.Write(line); err != nil {
+		return err
+	}
+
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func (s *service) List(ctx context.Context) ([]backupDomain.Manifest, error) {
+	keys, err := s.store.List(ctx, keyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	manifests := []backupDomain.Manifest{}
+	for _, key := range keys {
+		if !strings.HasSuffix(key, ".json") {
+			continue
+		}
+		manifest, err := s.readManifest(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, *manifest)
+	}
+	sort.Slice(manifests, func(i, j int) bool {
+		return manifests[i].CreatedAt.After(manifests[j].CreatedAt)
+	})
+	return manifests, nil
+}
+
+func (s *service) readManifest(ctx context.Context, key string) (*backupDomain.Manifest, error) {
+	rc, err := s.store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+
+	var manifest backupDomain.Manifest
+	if err := json.NewDecoder(rc).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("read manifest %s: %w", key, err)
+	}
+	return &manifest, nil
+}
+
+func (s *service) Open(ctx context.Context, id string) (io.ReadCloser, error) {
+	rc, err := s.store.Get(ctx, archiveKey(id))
+	if errors.Is(err, objectstore.ErrNotFound) || errors.Is(err, objectstore.ErrInvalidKey) {
+		return nil, ErrBackupNotFound
+	}
+	return rc, err
+}
+
+func (s *service) Restore(ctx context.Context, adminID, id string) (*backupDomain.Manifest, error) {
+	return s.RestoreFile(ctx, adminID, func() (io.ReadCloser, error) {
+		return s.Open(ctx, id)
+	})
+}
+
+// RestoreFile reads the archive twice: once to check its checksum, counts
+// and version, and only then to replace the knowledge base with it.
+func (s *service) RestoreFile(ctx context.Context, adminID string, open func() (io.ReadCloser, error)) (*backupDomain.Manifest, error) {
+	manifest, err := s.readArchive(open, func(any) error { return nil })
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Clear(ctx); err != nil {
+		return nil, fmt.Errorf("clear knowledge base: %w", err)
+	}
+
+	var (
+		docs   []documentDomain.Document
+		chunks []documentDomain.Chunk
+		rules  []documentDomain.RetrievalRule
+	)
+	flush := func(force bool) error {
+		if len(docs) > 0 && (force || len(docs) >= restoreBatch) {
+			if err := s.repo.InsertDocuments(ctx, docs); err != nil {
+				return err
+			}
+			docs = docs[:0]
+		}
+		if len(chunks) > 0 && (force || len(chunks) >= restoreBatch) {
+			if err := s.repo.InsertChunks(ctx, chunks); err != nil {
+				return err
+			}
+			chunks = chunks[:0]
+		}
+		if len(rules) > 0 && (force || len(rules) >= restoreBatch) {
+			if err := s.repo.InsertRules(ctx, rules); err != nil {
+				return err
+			}
+			rules = rules[:0]
+		}
+		return nil
+	}
+
+	if _, err := s.readArchive(open, func(v any) error {
+		switch v := v.(type) {
+		case *documentDomain.Document:
+			docs = append(docs, *v)
+		case *documentDomain.Chunk:
+			chunks = append(chunks, *v)
+		case *documentDomain.RetrievalRule:
+			rules = append(rules, *v)
+		}
+		return flush(false)
+	}); err != nil {
+		return nil, fmt.Errorf("restore backup %s: %w", manifest.ID, err)
+	}
+	if err := flush(true); err != nil {
+		return nil, fmt.Errorf("restore backup %s: %w", manifest.ID, err)
+	}
+
+	if s.storageRepo != nil {
+		if err := s.storageRepo.Rebuild(ctx); err != nil {
+			s.log.WarnContext(ctx, "failed to rebuild storage after restore", "error", err)
+		}
+	}
+	s.events.Publish(ctx, events.KnowledgeChanged{Reason: "backup_restored", ActorID: adminID})
+	return manifest, nil
+}
+
+// readArchive decodes every record of an archive, passes it to fn and checks
+// the whole against the trailing manifest. fn runs before the checksum is
+// known, so callers that change state must read the archive once with a
+// no-op fn first.
+func (s *service) readArchive(open func() (io.ReadCloser, error), fn func(any) error) (*backupDomain.Manifest, error) {
+	rc, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+
+	zr, err := gzip.NewReader(rc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptBackup, err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	br := bufio.NewReader(zr)
+	sum := sha256.New()
+	counts := map[backupDomain.Kind]int64{}
+	var manifest *backupDomain.Manifest
+
+	for {
+		line, err := br.ReadBytes('\n')
+		if errors.Is(err, io.EOF) && len(line) == 0 {
+			break
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: %v", ErrCorruptBackup, err)
+		}
+		if manifest != nil {
+			return nil, fmt.Errorf("%w: data after manifest", ErrCorruptBackup)
+		}
+
+		var r record
+		if err := json.Unmarshal(line, &r); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorruptBackup, err)
+		}
+		switch r.Kind {
+		case backupDomain.KindManifest:
+			manifest = &backupDomain.Manifest{}
+			if err := json.Unmarshal(r.Data, manifest); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrCorruptBackup, err)
+			}
+			continue
+		}
+
+		var v any
+		switch r.Kind {
+		case backupDomain.KindDocument:
+			v = &documentDomain.Document{}
+		case backupDomain.KindChunk:
+			v = &documentDomain.Chunk{}
+		case backupDomain.KindRule:
+			v = &documentDomain.RetrievalRule{}
+		default:
+			return nil, fmt.Errorf("%w: unknown record kind %q", ErrCorruptBackup, r.Kind)
+		}
+		if err := json.Unmarshal(r.Data, v); err != nil {
+			return nil, fmt.Errorf("%w: %s record: %v", ErrCorruptBackup, r.Kind, err)
+		}
+
+		sum.Write(line)
+		counts[r.Kind]++
+		if err := fn(v); err != nil {
+			return nil, err
+		}
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("%w: missing manifest", ErrCorruptBackup)
+	}
+	if manifest.Version != backupDomain.FormatVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, manifest.Version)
+	}
+	if hex.EncodeToString(sum.Sum(nil)) != manifest.SHA256 {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrCorruptBackup)
+	}
+	for _, kind := range []backupDomain.Kind{backupDomain.KindDocument, backupDomain.KindChunk, backupDomain.KindRule} {
+		if counts[kind] != manifest.Counts[kind] {
+			return nil, fmt.Errorf("%w: expected %d %s records, found %d", ErrCorruptBackup, manifest.Counts[kind], kind, counts[kind])
+		}
+	}
+	return manifest, nil
+}
@@ -0,0 +1,232 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	backupDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/backup"
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+	"github.com/elprogramadorgt/lucidRAG/pkg/objectstore"
+)
+
+// mockRepo holds a knowledge base in memory.
+type mockRepo struct {
+	documents []documentDomain.Document
+	chunks    []documentDomain.Chunk
+	rules     []documentDomain.RetrievalRule
+	cleared   bool
+}
+
+func (m *mockRepo) EachDocument(ctx context.Context, fn func(*documentDomain.Document) error) error {
+	for i := range m.documents {
+		if err := fn(&m.documents[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mockRepo) EachChunk(ctx context.Context, fn func(*documentDomain.Chunk) error) error {
+	for i := range m.chunks {
+		if err := fn(&m.chunks[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mockRepo) EachRule(ctx context.Context, fn func(*documentDomain.RetrievalRule) error) error {
+	for i := range m.rules {
+		if err := fn(&m.rules[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mockRepo) Clear(ctx context.Context) error {
+	m.documents, m.chunks, m.rules = nil, nil, nil
+	m.cleared = true
+	return nil
+}
+
+func (m *mockRepo) InsertDocuments(ctx context.Context, docs []documentDomain.Document) error {
+	m.documents = append(m.documents, docs...)
+	return nil
+}
+
+func (m *mockRepo) InsertChunks(ctx context.Context, chunks []documentDomain.Chunk) error {
+	m.chunks = append(m.chunks, chunks...)
+	return nil
+}
+
+func (m *mockRepo) InsertRules(ctx context.Context, rules []documentDomain.RetrievalRule) error {
+	m.rules = append(m.rules, rules...)
+	return nil
+}
+
+func newTestService(t *testing.T, repo *mockRepo, bus *events.Bus) (*service, objectstore.Store) {
+	t.Helper()
+	store, err := objectstore.NewFS(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	return NewService(ServiceConfig{Repo: repo, Store: store, Events: bus}).(*service), store
+}
+
+func seededRepo() *mockRepo {
+	chunks := make([]documentDomain.Chunk, restoreBatch+1)
+	for i := range chunks {
+		chunks[i] = documentDomain.Chunk{ID: string(rune('a'+i%26)) + "-chunk", DocumentID: "doc-1", ChunkIndex: i, Content: "text", Embedding: []float64{0.25, -1}}
+	}
+	return &mockRepo{
+		documents: []documentDomain.Document{{ID: "doc-1", Title: "guide", Content: "hello", Status: documentDomain.StatusPublished}},
+		chunks:    chunks,
+		rules:     []documentDomain.RetrievalRule{{ID: "rule-1", Name: "pin", Action: documentDomain.RuleActionPin, IsActive: true}},
+	}
+}
+
+func TestCreateAndRestore(t *testing.T) {
+	repo := seededRepo()
+	bus := events.NewBus()
+	var changed []events.KnowledgeChanged
+	bus.Subscribe(func(ctx context.Context, e events.Event) {
+		changed = append(changed, e.(events.KnowledgeChanged))
+	}, events.NameKnowledgeChanged)
+	svc, _ := newTestService(t, repo, bus)
+	ctx := context.Background()
+
+	manifest, err := svc.Create(ctx, "admin-1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if manifest.Counts["document"] != 1 || manifest.Counts["chunk"] != restoreBatch+1 || manifest.Counts["rule"] != 1 {
+		t.Errorf("Unexpected counts %v", manifest.Counts)
+	}
+	if manifest.SHA256 == "" || manifest.SizeBytes == 0 {
+		t.Errorf("Expected checksum and size, got %+v", manifest)
+	}
+
+	list, err := svc.List(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(list) != 1 || list[0].ID != manifest.ID {
+		t.Fatalf("Expected the new backup to be listed, got %+v", list)
+	}
+
+	original := seededRepo()
+	repo.documents[0].Title = "changed"
+	repo.chunks = repo.chunks[:1]
+
+	restored, err := svc.Restore(ctx, "admin-1", manifest.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if restored.ID != manifest.ID {
+		t.Errorf("Expected manifest %s, got %s", manifest.ID, restored.ID)
+	}
+	if repo.documents[0].Title != "guide" {
+		t.Errorf("Expected document title to be restored, got %s", repo.documents[0].Title)
+	}
+	if len(repo.chunks) != len(original.chunks) || len(repo.rules) != 1 {
+		t.Errorf("Expected %d chunks and 1 rule, got %d and %d", len(original.chunks), len(repo.chunks), len(repo.rules))
+	}
+	if got := repo.chunks[3].Embedding; len(got) != 2 || got[0] != 0.25 {
+		t.Errorf("Expected embedding to survive, got %v", got)
+	}
+	if len(changed) != 1 || changed[0].Reason != "backup_restored" {
+		t.Errorf("Expected a backup_restored event, got %+v", changed)
+	}
+}
+
+func TestRestoreRejectsTamperedArchive(t *testing.T) {
+	repo := seededRepo()
+	svc, store := newTestService(t, repo, nil)
+	ctx := context.Background()
+
+	manifest, err := svc.Create(ctx, "admin-1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	rc, err := store.Get(ctx, archiveKey(manifest.ID))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	zr, err := gzip.NewReader(rc)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	raw, _ := io.ReadAll(zr)
+	_ = rc.Close()
+
+	tampered := bytes.Replace(raw, []byte(`"guide"`), []byte(`"evil!"`), 1)
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write(tampered)
+	_ = zw.Close()
+
+	repo.cleared = false
+	_, err = svc.RestoreFile(ctx, "admin-1", func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(buf.Bytes())), nil
+	})
+	if !errors.Is(err, ErrCorruptBackup) {
+		t.Errorf("Expected ErrCorruptBackup, got %v", err)
+	}
+	if repo.cleared {
+		t.Error("Expected knowledge base to be left alone")
+	}
+}
+
+func TestRestoreRejectsTruncatedArchive(t *testing.T) {
+	repo := seededRepo()
+	svc, _ := newTestService(t, repo, nil)
+
+	var buf bytes.Buffer
+	if err := svc.export(context.Background(), &buf, &backupDomain.Manifest{ID: "b1", Version: backupDomain.FormatVersion}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	truncated := buf.Bytes()[:buf.Len()/2]
+
+	_, err := svc.RestoreFile(context.Background(), "admin-1", func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(truncated)), nil
+	})
+	if !errors.Is(err, ErrCorruptBackup) {
+		t.Errorf("Expected ErrCorruptBackup, got %v", err)
+	}
+	if repo.cleared {
+		t.Error("Expected knowledge base to be left alone")
+	}
+}
+
+func TestOpenMissingBackup(t *testing.T) {
+	svc, _ := newTestService(t, &mockRepo{}, nil)
+
+	if _, err := svc.Open(context.Background(), "missing"); !errors.Is(err, ErrBackupNotFound) {
+		t.Errorf("Expected ErrBackupNotFound, got %v", err)
+	}
+	if _, err := svc.Restore(context.Background(), "admin-1", "../etc"); !errors.Is(err, ErrBackupNotFound) {
+		t.Errorf("Expected ErrBackupNotFound for invalid id, got %v", err)
+	}
+}
+
+func TestCreateLeavesNoTempFiles(t *testing.T) {
+	svc, _ := newTestService(t, seededRepo(), nil)
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	if _, err := svc.Create(context.Background(), "admin-1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	entries, _ := os.ReadDir(tmp)
+	if len(entries) != 0 {
+		t.Errorf("Expected temp dir to be empty, got %d entries", len(entries))
+	}
+}
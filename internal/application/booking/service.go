@@ -0,0 +1,598 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	bookingDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/booking"
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	"github.com/elprogramadorgt/lucidRAG/pkg/gcal"
+	"github.com/elprogramadorgt/lucidRAG/pkg/tz"
+	whatsappClient "github.com/elprogramadorgt/lucidRAG/pkg/whatsapp"
+)
+
+var (
+	ErrResourceNotFound = errors.New("resource not found")
+	ErrInvalidResource  = errors.New("invalid resource")
+	// ErrResourceInUse is a resource that still has upcoming bookings.
+	ErrResourceInUse   = errors.New("resource has upcoming bookings")
+	ErrBookingNotFound = errors.New("booking not found")
+	ErrInvalidBooking  = errors.New("invalid booking")
+	// ErrSlotUnavailable is a start time outside the resource's hours or
+	// already taken.
+	ErrSlotUnavailable = errors.New("slot is not available")
+)
+
+const (
+	defaultSlotMinutes = 30
+	maxSlotMinutes     = 8 * 60
+	maxHours           = 50
+	maxNotesLength     = 1000
+	// maxRange bounds the period slots are listed for.
+	maxRange = 31 * 24 * time.Hour
+	// offerWindow is how far ahead slots are offered to contacts.
+	offerWindow = 14 * 24 * time.Hour
+	// slotLabel names slots on reply buttons, e.g. "Tue 21 Oct 10:00".
+	slotLabel = "Mon 2 Jan 15:04"
+	offerBody = "Which time suits you?"
+)
+
+// Calendar is the Google Calendar of resources that have one.
+type Calendar interface {
+	Busy(ctx context.Context, calendarID string, from, to time.Time) ([]gcal.Interval, error)
+	CreateEvent(ctx context.Context, calendarID string, event gcal.Event) (string, error)
+	UpdateEvent(ctx context.Context, calendarID, eventID string, event gcal.Event) error
+	DeleteEvent(ctx context.Context, calendarID, eventID string) error
+}
+
+// Sender sends slot offers and reminders from a business phone number.
+type Sender interface {
+	SendText(ctx context.Context, phoneNumberID, to, body string) error
+	SendButtons(ctx context.Context, phoneNumberID, to, body string, titles []string) error
+}
+
+type ServiceConfig struct {
+	Repo      bookingDomain.Repository
+	Resources bookingDomain.ResourceRepository
+	// ConvRepo looks up the contact of the conversation a booking is made
+	// from, and ConvSvc records the offers and reminders sent there.
+	ConvRepo conversationDomain.ConversationRepository
+	ConvSvc  conversationDomain.Service
+	// Calendar, when set, lets resources sync with a Google Calendar.
+	Calendar Calendar
+	// Sender, when set, sends offers as reply buttons and reminders.
+	Sender        Sender
+	PhoneNumberID string
+	// ReminderHours is how long before the start contacts are reminded;
+	// zero sends no reminders.
+	ReminderHours int
+}
+
+type service struct {
+	repo          bookingDomain.Repository
+	resources     bookingDomain.ResourceRepository
+	convRepo      conversationDomain.ConversationRepository
+	convSvc       conversationDomain.Service
+	calendar      Calendar
+	sender        Sender
+	phoneNumberID string
+	reminder      time.Duration
+	now           func() time.Time
+}
+
+func NewService(cfg ServiceConfig) bookingDomain.Service {
+	return &service{
+		repo:          cfg.Repo,
+		resources:     cfg.Resources,
+		convRepo:      cfg.ConvRepo,
+		convSvc:       cfg.ConvSvc,
+		calendar:      cfg.Calendar,
+		sender:        cfg.Sender,
+		phoneNumberID: cfg.PhoneNumberID,
+		reminder:      time.Duration(cfg.ReminderHours) * time.Hour,
+		now:           time.Now,
+	}
+}
+
+func (s *service) CreateResource(ctx context.Context, adminID string, resource *bookingDomain.Resource) (string, error) {
+	if err := s.validateResource(resource); err != nil {
+		return "", err
+	}
+	resource.ID = ""
+	resource.CreatedBy = adminID
+	return s.resources.Create(ctx, resource)
+}
+
+func (s *service) ListResources(ctx context.Context) ([]bookingDomain.Resource, error) {
+	return s.resources.List(ctx)
+}
+
+func (s *service) UpdateResource(ctx context.Context, adminID string, resource *bookingDomain.Resource) error {
+	existing, err := s.resource(ctx, resource.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.validateResource(resource); err != nil {
+		return err
+	}
+	resource.CreatedBy = existing.CreatedBy
+	resource.CreatedAt = existing.CreatedAt
+	return s.resources.Update(ctx, resource)
+}
+
+// DeleteResource deletes a resource without upcoming bookings; those have
+// to be cancelled first.
+func (s *service) DeleteResource(ctx context.Context, adminID, id string) error {
+	if _, err := s.resource(ctx, id); err != nil {
+		return err
+	}
+	upcoming, err := s.repo.List(ctx, bookingDomain.Filter{ResourceID: id, Status: bookingDomain.StatusConfirmed, From: s.now()})
+	if err != nil {
+		return err
+	}
+	if len(upcoming) > 0 {
+		return ErrResourceInUse
+	}
+	return s.resources.Delete(ctx, id)
+}
+
+func (s *service) Slots(ctx context.Context, resourceID string, from, to time.Time) ([]bookingDomain.Slot, error) {
+	if to.Sub(from) > maxRange {
+		return nil, fmt.Errorf("%w: slots can be listed for at most 31 days", ErrInvalidBooking)
+	}
+	resource, err := s.resource(ctx, resourceID)
+	if err != nil {
+		return nil, err
+	}
+	return s.freeSlots(ctx, resource, from, to, nil)
+}
+
+func (s *service) CreateBooking(ctx context.Context, userID string, booking *bookingDomain.Booking) (string, error) {
+	if err := validateBooking(booking); err != nil {
+		return "", err
+	}
+	resource, err := s.resource(ctx, booking.ResourceID)
+	if err != nil {
+		return "", err
+	}
+	if booking.ConversationID != "" {
+		conv, err := s.convRepo.GetByID(ctx, booking.ConversationID)
+		if err != nil {
+			return "", err
+		}
+		if conv == nil {
+			return "", fmt.Errorf("%w: conversation not found", ErrInvalidBooking)
+		}
+		if booking.ContactName == "" {
+			booking.ContactName = conv.ContactName
+		}
+		if booking.PhoneNumber == "" {
+			booking.PhoneNumber = conv.PhoneNumber
+		}
+	}
+	if err := s.checkFree(ctx, resource, booking.Start, nil); err != nil {
+		return "", err
+	}
+	booking.CreatedBy = userID
+	return s.create(ctx, resource, booking)
+}
+
+func (s *service) GetBooking(ctx context.Context, id string) (*bookingDomain.Booking, error) {
+	booking, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if booking == nil {
+		return nil, ErrBookingNotFound
+	}
+	return booking, nil
+}
+
+func (s *service) ListBookings(ctx context.Context, filter bookingDomain.Filter) ([]bookingDomain.Booking, error) {
+	return s.repo.List(ctx, filter)
+}
+
+// UpdateBooking moves a booking and its calendar event. A booking moved to
+// a new time is reminded again.
+func (s *service) UpdateBooking(ctx context.Context, userID string, booking *bookingDomain.Booking) error {
+	existing, err := s.GetBooking(ctx, booking.ID)
+	if err != nil {
+		return err
+	}
+	if existing.Status == bookingDomain.StatusCancelled {
+		return fmt.Errorf("%w: cancelled bookings cannot be changed", ErrInvalidBooking)
+	}
+	booking.ResourceID = existing.ResourceID
+	if err := validateBooking(booking); err != nil {
+		return err
+	}
+	resource, err := s.resource(ctx, existing.ResourceID)
+	if err != nil {
+		return err
+	}
+
+	updated := *existing
+	updated.Notes = booking.Notes
+	if booking.ContactName != "" {
+		updated.ContactName = booking.ContactName
+	}
+	if booking.PhoneNumber != "" {
+		updated.PhoneNumber = booking.PhoneNumber
+	}
+	if !booking.Start.Equal(existing.Start) {
+		if err := s.checkFree(ctx, resource, booking.Start, existing); err != nil {
+			return err
+		}
+		updated.Start = booking.Start
+		updated.End = booking.Start.Add(slotLength(resource))
+		updated.RemindedAt = nil
+	}
+
+	if updated.CalendarEventID != "" && s.calendar != nil {
+		if err := s.calendar.UpdateEvent(ctx, resource.CalendarID, updated.CalendarEventID, event(resource, &updated)); err != nil {
+			return fmt.Errorf("update calendar event: %w", err)
+		}
+	}
+	if err := s.repo.Update(ctx, &updated); err != nil {
+		return err
+	}
+	*booking = updated
+	return nil
+}
+
+// CancelBooking cancels a booking and removes its calendar event.
+// Cancelling it again does nothing.
+func (s *service) CancelBooking(ctx context.Context, userID, id string) error {
+	booking, err := s.GetBooking(ctx, id)
+	if err != nil {
+		return err
+	}
+	if booking.Status == bookingDomain.StatusCancelled {
+		return nil
+	}
+	if booking.CalendarEventID != "" && s.calendar != nil {
+		resource, err := s.resources.GetByID(ctx, booking.ResourceID)
+		if err != nil {
+			return err
+		}
+		if resource != nil && resource.CalendarID != "" {
+			if err := s.calendar.DeleteEvent(ctx, resource.CalendarID, booking.CalendarEventID); err != nil {
+				return fmt.Errorf("delete calendar event: %w", err)
+			}
+		}
+	}
+	booking.Status = bookingDomain.StatusCancelled
+	return s.repo.Update(ctx, booking)
+}
+
+// OfferSlots returns up to as many of the next free slots as fit on reply
+// buttons. They are sent only to WhatsApp contacts and only when a sender
+// is configured; otherwise the caller presents them.
+func (s *service) OfferSlots(ctx context.Context, resourceID, conversationID string) ([]bookingDomain.Slot, error) {
+	resource, err := s.resource(ctx, resourceID)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	slots, err := s.freeSlots(ctx, resource, now, now.Add(offerWindow), nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(slots) > whatsappClient.MaxButtons {
+		slots = slots[:whatsappClient.MaxButtons]
+	}
+	if len(slots) == 0 || conversationID == "" || s.sender == nil {
+		return slots, nil
+	}
+
+	conv, err := s.convRepo.GetByID(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if conv == nil || conv.PhoneNumber == "" || (conv.Channel != "" && conv.Channel != conversationDomain.ChannelWhatsApp) {
+		return slots, nil
+	}
+	labels := make([]string, len(slots))
+	for i, slot := range slots {
+		labels[i] = slot.Label
+	}
+	if err := s.sender.SendButtons(ctx, s.phoneNumberID, conv.PhoneNumber, offerBody, labels); err != nil {
+		return nil, fmt.Errorf("send slots: %w", err)
+	}
+	if _, err := s.convSvc.SaveOutgoingMessage(ctx, conv.ID, offerBody+" "+strings.Join(labels, " | "), ""); err != nil {
+		return nil, err
+	}
+	return slots, nil
+}
+
+// Book books the free slot named by slot, which is either a label the
+// contact picked from an offer or a start time.
+func (s *service) Book(ctx context.Context, resourceID, conversationID, slot string) (*bookingDomain.Booking, error) {
+	resource, err := s.resource(ctx, resourceID)
+	if err != nil {
+		return nil, err
+	}
+	if conversationID == "" {
+		return nil, fmt.Errorf("%w: bookings are made from a conversation", ErrInvalidBooking)
+	}
+
+	now := s.now()
+	from, to := now, now.Add(maxRange)
+	start, err := tz.Parse(strings.TrimSpace(slot), location(resource))
+	if err == nil {
+		from, to = start, start.Add(slotLength(resource))
+	}
+	free, err := s.freeSlots(ctx, resource, from, to, nil)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(free, func(f bookingDomain.Slot) bool {
+		return f.Start.Equal(start) || strings.EqualFold(f.Label, strings.TrimSpace(slot))
+	})
+	if i < 0 {
+		return nil, ErrSlotUnavailable
+	}
+
+	booking := &bookingDomain.Booking{ResourceID: resource.ID, ConversationID: conversationID, Start: free[i].Start}
+	conv, err := s.convRepo.GetByID(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if conv != nil {
+		booking.ContactName = conv.ContactName
+		booking.PhoneNumber = conv.PhoneNumber
+	}
+	if _, err := s.create(ctx, resource, booking); err != nil {
+		return nil, err
+	}
+	return booking, nil
+}
+
+// SendReminders sends a WhatsApp reminder to the contact of each booking
+// starting within the reminder period and records it in the booking's
+// conversation. A failed reminder is retried on the next run.
+func (s *service) SendReminders(ctx context.Context) error {
+	if s.reminder <= 0 || s.sender == nil {
+		return nil
+	}
+	now := s.now()
+	due, err := s.repo.DueReminders(ctx, now, now.Add(s.reminder))
+	if err != nil {
+		return err
+	}
+
+	resources := map[string]*bookingDomain.Resource{}
+	var errs []error
+	for _, booking := range due {
+		resource, ok := resources[booking.ResourceID]
+		if !ok {
+			if resource, err = s.resources.GetByID(ctx, booking.ResourceID); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			resources[booking.ResourceID] = resource
+		}
+		if resource == nil || booking.PhoneNumber == "" {
+			continue
+		}
+
+		text := fmt.Sprintf("Reminder: your appointment with %s is on %s.", resource.Name, booking.Start.In(location(resource)).Format(slotLabel))
+		if err := s.sender.SendText(ctx, s.phoneNumberID, booking.PhoneNumber, text); err != nil {
+			errs = append(errs, fmt.Errorf("remind booking %s: %w", booking.ID, err))
+			continue
+		}
+		if err := s.repo.MarkReminded(ctx, booking.ID, now); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if booking.ConversationID != "" {
+			if _, err := s.convSvc.SaveOutgoingMessage(ctx, booking.ConversationID, text, ""); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *service) resource(ctx context.Context, id string) (*bookingDomain.Resource, error) {
+	resource, err := s.resources.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if resource == nil {
+		return nil, ErrResourceNotFound
+	}
+	return resource, nil
+}
+
+// create stores a confirmed booking at booking.Start, adding it to the
+// resource's calendar first so a calendar failure books nothing.
+func (s *service) create(ctx context.Context, resource *bookingDomain.Resource, booking *bookingDomain.Booking) (string, error) {
+	booking.ID = ""
+	booking.Status = bookingDomain.StatusConfirmed
+	booking.End = booking.Start.Add(slotLength(resource))
+	booking.RemindedAt = nil
+	booking.CalendarEventID = ""
+
+	if resource.CalendarID != "" && s.calendar != nil {
+		eventID, err := s.calendar.CreateEvent(ctx, resource.CalendarID, event(resource, booking))
+		if err != nil {
+			return "", fmt.Errorf("add calendar event: %w", err)
+		}
+		booking.CalendarEventID = eventID
+	}
+	id, err := s.repo.Create(ctx, booking)
+	if err != nil && booking.CalendarEventID != "" {
+		_ = s.calendar.DeleteEvent(context.WithoutCancel(ctx), resource.CalendarID, booking.CalendarEventID)
+	}
+	return id, err
+}
+
+// checkFree reports whether a booking may start at start: it has to begin
+// one of the resource's free slots. except is the booking being moved,
+// whose own time does not count as taken.
+func (s *service) checkFree(ctx context.Context, resource *bookingDomain.Resource, start time.Time, except *bookingDomain.Booking) error {
+	free, err := s.freeSlots(ctx, resource, start, start.Add(slotLength(resource)), except)
+	if err != nil {
+		return err
+	}
+	if len(free) == 0 || !free[0].Start.Equal(start) {
+		return ErrSlotUnavailable
+	}
+	return nil
+}
+
+type interval struct {
+	start, end time.Time
+}
+
+// freeSlots cuts the resource's hours in [from, to) into slots, leaving out
+// the past, confirmed bookings and the busy times of its calendar.
+func (s *service) freeSlots(ctx context.Context, resource *bookingDomain.Resource, from, to time.Time, except *bookingDomain.Booking) ([]bookingDomain.Slot, error) {
+	if !resource.IsActive {
+		return []bookingDomain.Slot{}, nil
+	}
+	if now := s.now(); from.Before(now) {
+		from = now
+	}
+	if !to.After(from) {
+		return []bookingDomain.Slot{}, nil
+	}
+
+	bookings, err := s.repo.Overlapping(ctx, resource.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	var busy []interval
+	for _, b := range bookings {
+		if except == nil || b.ID != except.ID {
+			busy = append(busy, interval{b.Start, b.End})
+		}
+	}
+	if resource.CalendarID != "" && s.calendar != nil {
+		periods, err := s.calendar.Busy(ctx, resource.CalendarID, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("read calendar: %w", err)
+		}
+		for _, p := range periods {
+			// The moved booking's own event shows as busy in the calendar.
+			if except != nil && p.Start.Equal(except.Start) && p.End.Equal(except.End) {
+				continue
+			}
+			busy = append(busy, interval{p.Start, p.End})
+		}
+	}
+
+	loc := location(resource)
+	length := slotLength(resource)
+	slots := []bookingDomain.Slot{}
+	first := from.In(loc)
+	for day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, loc); day.Before(to); day = day.AddDate(0, 0, 1) {
+		for _, hours := range resource.Hours {
+			if hours.Weekday != day.Weekday() {
+				continue
+			}
+			end := clock(day, hours.End)
+			for start := clock(day, hours.Start); !start.Add(length).After(end); start = start.Add(length) {
+				if start.Before(from) || start.Add(length).After(to) || overlaps(busy, start, start.Add(length)) {
+					continue
+				}
+				slots = append(slots, bookingDomain.Slot{Start: start, End: start.Add(length), Label: start.Format(slotLabel)})
+			}
+		}
+	}
+	slices.SortFunc(slots, func(a, b bookingDomain.Slot) int { return a.Start.Compare(b.Start) })
+	return slots, nil
+}
+
+func overlaps(busy []interval, start, end time.Time) bool {
+	for _, b := range busy {
+		if start.Before(b.end) && b.start.Before(end) {
+			return true
+		}
+	}
+	return false
+}
+
+// clock returns the wall clock time hhmm, such as "09:30", on day.
+func clock(day time.Time, hhmm string) time.Time {
+	t, _ := time.Parse("15:04", hhmm)
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location())
+}
+
+func location(resource *bookingDomain.Resource) *time.Location {
+	if loc, err := time.LoadLocation(resource.Timezone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+func slotLength(resource *bookingDomain.Resource) time.Duration {
+	return time.Duration(resource.SlotMinutes) * time.Minute
+}
+
+func event(resource *bookingDomain.Resource, booking *bookingDomain.Booking) gcal.Event {
+	summary := resource.Name
+	if booking.ContactName != "" {
+		summary += ": " + booking.ContactName
+	}
+	description := booking.Notes
+	if booking.PhoneNumber != "" {
+		description = strings.TrimSpace("Phone: " + booking.PhoneNumber + "\n\n" + description)
+	}
+	return gcal.Event{Summary: summary, Description: description, Start: booking.Start, End: booking.End}
+}
+
+func (s *service) validateResource(resource *bookingDomain.Resource) error {
+	resource.Name = strings.TrimSpace(resource.Name)
+	resource.Description = strings.TrimSpace(resource.Description)
+	resource.Timezone = strings.TrimSpace(resource.Timezone)
+	resource.CalendarID = strings.TrimSpace(resource.CalendarID)
+	if resource.SlotMinutes == 0 {
+		resource.SlotMinutes = defaultSlotMinutes
+	}
+
+	switch {
+	case resource.Name == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidResource)
+	case resource.SlotMinutes < 5 || resource.SlotMinutes > maxSlotMinutes:
+		return fmt.Errorf("%w: slot_minutes must be between 5 and %d", ErrInvalidResource, maxSlotMinutes)
+	case len(resource.Hours) == 0 || len(resource.Hours) > maxHours:
+		return fmt.Errorf("%w: hours must list 1 to %d windows", ErrInvalidResource, maxHours)
+	case resource.CalendarID != "" && s.calendar == nil:
+		return fmt.Errorf("%w: calendar_id needs GOOGLE_CALENDAR_CREDENTIALS_FILE to be set", ErrInvalidResource)
+	}
+	if resource.Timezone != "" {
+		if _, err := time.LoadLocation(resource.Timezone); err != nil {
+			return fmt.Errorf("%w: unknown timezone", ErrInvalidResource)
+		}
+	}
+	for _, hours := range resource.Hours {
+		start, err1 := time.Parse("15:04", hours.Start)
+		end, err2 := time.Parse("15:04", hours.End)
+		if hours.Weekday < time.Sunday || hours.Weekday > time.Saturday || err1 != nil || err2 != nil || !start.Before(end) {
+			return fmt.Errorf("%w: hours need a weekday from 0 (Sunday) to 6 and a start before the end, as HH:MM", ErrInvalidResource)
+		}
+	}
+	return nil
+}
+
+func validateBooking(booking *bookingDomain.Booking) error {
+	booking.ContactName = strings.TrimSpace(booking.ContactName)
+	booking.PhoneNumber = strings.TrimSpace(booking.PhoneNumber)
+	booking.Notes = strings.TrimSpace(booking.Notes)
+	switch {
+	case booking.ResourceID == "":
+		return fmt.Errorf("%w: resource_id is required", ErrInvalidBooking)
+	case booking.Start.IsZero():
+		return fmt.Errorf("%w: start is required", ErrInvalidBooking)
+	case utf8.RuneCountInString(booking.Notes) > maxNotesLength:
+		return fmt.Errorf("%w: notes must be at most %d characters", ErrInvalidBooking, maxNotesLength)
+	}
+	return nil
+}
@@ -0,0 +1,396 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	bookingDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/booking"
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	"github.com/elprogramadorgt/lucidRAG/pkg/gcal"
+)
+
+type mockResourceRepo struct {
+	resources map[string]bookingDomain.Resource
+}
+
+func (m *mockResourceRepo) Create(ctx context.Context, resource *bookingDomain.Resource) (string, error) {
+	resource.ID = fmt.Sprintf("res-%d", len(m.resources)+1)
+	m.resources[resource.ID] = *resource
+	return resource.ID, nil
+}
+
+func (m *mockResourceRepo) GetByID(ctx context.Context, id string) (*bookingDomain.Resource, error) {
+	resource, ok := m.resources[id]
+	if !ok {
+		return nil, nil
+	}
+	return &resource, nil
+}
+
+func (m *mockResourceRepo) List(ctx context.Context) ([]bookingDomain.Resource, error) {
+	var resources []bookingDomain.Resource
+	for _, resource := range m.resources {
+		resources = append(resources, resource)
+	}
+	return resources, nil
+}
+
+func (m *mockResourceRepo) Update(ctx context.Context, resource *bookingDomain.Resource) error {
+	m.resources[resource.ID] = *resource
+	return nil
+}
+
+func (m *mockResourceRepo) Delete(ctx context.Context, id string) error {
+	delete(m.resources, id)
+	return nil
+}
+
+type mockBookingRepo struct {
+	bookings []bookingDomain.Booking
+}
+
+func (m *mockBookingRepo) Create(ctx context.Context, booking *bookingDomain.Booking) (string, error) {
+	booking.ID = fmt.Sprintf("booking-%d", len(m.bookings)+1)
+	m.bookings = append(m.bookings, *booking)
+	return booking.ID, nil
+}
+
+func (m *mockBookingRepo) GetByID(ctx context.Context, id string) (*bookingDomain.Booking, error) {
+	for _, b := range m.bookings {
+		if b.ID == id {
+			return &b, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockBookingRepo) List(ctx context.Context, filter bookingDomain.Filter) ([]bookingDomain.Booking, error) {
+	var bookings []bookingDomain.Booking
+	for _, b := range m.bookings {
+		if (filter.ResourceID == "" || b.ResourceID == filter.ResourceID) && (filter.Status == "" || b.Status == filter.Status) && !b.Start.Before(filter.From) {
+			bookings = append(bookings, b)
+		}
+	}
+	return bookings, nil
+}
+
+func (m *mockBookingRepo) Update(ctx context.Context, booking *bookingDomain.Booking) error {
+	for i := range m.bookings {
+		if m.bookings[i].ID == booking.ID {
+			m.bookings[i] = *booking
+		}
+	}
+	return nil
+}
+
+func (m *mockBookingRepo) Overlapping(ctx context.Context, resourceID string, start, end time.Time) ([]bookingDomain.Booking, error) {
+	var bookings []bookingDomain.Booking
+	for _, b := range m.bookings {
+		if b.ResourceID == resourceID && b.Status == bookingDomain.StatusConfirmed && b.Start.Before(end) && start.Before(b.End) {
+			bookings = append(bookings, b)
+		}
+	}
+	return bookings, nil
+}
+
+func (m *mockBookingRepo) DueReminders(ctx context.Context, from, to time.Time) ([]bookingDomain.Booking, error) {
+	var bookings []bookingDomain.Booking
+	for _, b := range m.bookings {
+		if b.Status == bookingDomain.StatusConfirmed && !b.Start.Before(from) && b.Start.Before(to) && b.RemindedAt == nil {
+			bookings = append(bookings, b)
+		}
+	}
+	return bookings, nil
+}
+
+func (m *mockBookingRepo) MarkReminded(ctx context.Context, id string, at time.Time) error {
+	for i := range m.bookings {
+		if m.bookings[i].ID == id {
+			m.bookings[i].RemindedAt = &at
+		}
+	}
+	return nil
+}
+
+type mockCalendar struct {
+	busy    []gcal.Interval
+	events  []gcal.Event
+	deleted []string
+}
+
+func (m *mockCalendar) Busy(ctx context.Context, calendarID string, from, to time.Time) ([]gcal.Interval, error) {
+	return m.busy, nil
+}
+
+func (m *mockCalendar) CreateEvent(ctx context.Context, calendarID string, event gcal.Event) (string, error) {
+	m.events = append(m.events, event)
+	return fmt.Sprintf("event-%d", len(m.events)), nil
+}
+
+func (m *mockCalendar) UpdateEvent(ctx context.Context, calendarID, eventID string, event gcal.Event) error {
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *mockCalendar) DeleteEvent(ctx context.Context, calendarID, eventID string) error {
+	m.deleted = append(m.deleted, eventID)
+	return nil
+}
+
+type mockSender struct {
+	texts   []string
+	buttons []string
+}
+
+func (m *mockSender) SendText(ctx context.Context, phoneNumberID, to, body string) error {
+	m.texts = append(m.texts, to+": "+body)
+	return nil
+}
+
+func (m *mockSender) SendButtons(ctx context.Context, phoneNumberID, to, body string, titles []string) error {
+	m.buttons = append(m.buttons, titles...)
+	return nil
+}
+
+// mockConvs serves one WhatsApp conversation; the methods bookings do not
+// use are left to the embedded interface.
+type mockConvs struct {
+	conversationDomain.ConversationRepository
+	conv conversationDomain.Conversation
+}
+
+func (m *mockConvs) GetByID(ctx context.Context, id string) (*conversationDomain.Conversation, error) {
+	if id != m.conv.ID {
+		return nil, nil
+	}
+	conv := m.conv
+	return &conv, nil
+}
+
+type mockMessages struct {
+	conversationDomain.Service
+	saved []string
+}
+
+func (m *mockMessages) SaveOutgoingMessage(ctx context.Context, conversationID, content, ragAnswer string) (*conversationDomain.Message, error) {
+	m.saved = append(m.saved, content)
+	return &conversationDomain.Message{Content: content}, nil
+}
+
+type fixture struct {
+	svc       *service
+	bookings  *mockBookingRepo
+	resources *mockResourceRepo
+	calendar  *mockCalendar
+	sender    *mockSender
+	messages  *mockMessages
+	loc       *time.Location
+}
+
+// newFixture is a service whose clock reads Monday 19 October 2026, 08:00
+// in Guatemala, with a consultant bookable 09:00 to 11:00 on weekdays.
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	loc, err := time.LoadLocation("America/Guatemala")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+	f := &fixture{
+		bookings:  &mockBookingRepo{},
+		resources: &mockResourceRepo{resources: map[string]bookingDomain.Resource{}},
+		calendar:  &mockCalendar{},
+		sender:    &mockSender{},
+		messages:  &mockMessages{},
+		loc:       loc,
+	}
+	f.svc = NewService(ServiceConfig{
+		Repo: f.bookings, Resources: f.resources, Calendar: f.calendar, Sender: f.sender, ReminderHours: 24,
+		ConvRepo: &mockConvs{conv: conversationDomain.Conversation{ID: "conv-1", PhoneNumber: "50255551234", ContactName: "Ana"}},
+		ConvSvc:  f.messages,
+	}).(*service)
+	f.svc.now = func() time.Time { return time.Date(2026, 10, 19, 8, 0, 0, 0, loc) }
+
+	var hours []bookingDomain.Hours
+	for day := time.Monday; day <= time.Friday; day++ {
+		hours = append(hours, bookingDomain.Hours{Weekday: day, Start: "09:00", End: "11:00"})
+	}
+	_, err = f.svc.CreateResource(context.Background(), "admin-1", &bookingDomain.Resource{
+		Name: "Consultant", Timezone: "America/Guatemala", SlotMinutes: 60, Hours: hours, CalendarID: "team@example.com", IsActive: true,
+	})
+	if err != nil {
+		t.Fatalf("Expected the resource to be created, got %v", err)
+	}
+	return f
+}
+
+func (f *fixture) at(day, hour int) time.Time {
+	return time.Date(2026, 10, day, hour, 0, 0, 0, f.loc)
+}
+
+func TestCreateResourceValidates(t *testing.T) {
+	f := newFixture(t)
+	invalid := []bookingDomain.Resource{
+		{Name: " ", Hours: []bookingDomain.Hours{{Weekday: time.Monday, Start: "09:00", End: "10:00"}}},
+		{Name: "Room", Hours: nil},
+		{Name: "Room", Hours: []bookingDomain.Hours{{Weekday: time.Monday, Start: "10:00", End: "09:00"}}},
+		{Name: "Room", Hours: []bookingDomain.Hours{{Weekday: 7, Start: "09:00", End: "10:00"}}},
+		{Name: "Room", Timezone: "Mars/Olympus", Hours: []bookingDomain.Hours{{Weekday: time.Monday, Start: "09:00", End: "10:00"}}},
+		{Name: "Room", SlotMinutes: 1, Hours: []bookingDomain.Hours{{Weekday: time.Monday, Start: "09:00", End: "10:00"}}},
+	}
+	for i, resource := range invalid {
+		if _, err := f.svc.CreateResource(context.Background(), "admin-1", &resource); !errors.Is(err, ErrInvalidResource) {
+			t.Errorf("Case %d: expected ErrInvalidResource, got %v", i, err)
+		}
+	}
+
+	noCalendar := NewService(ServiceConfig{Resources: f.resources})
+	_, err := noCalendar.CreateResource(context.Background(), "admin-1", &bookingDomain.Resource{
+		Name: "Room", CalendarID: "room@example.com", Hours: []bookingDomain.Hours{{Weekday: time.Monday, Start: "09:00", End: "10:00"}},
+	})
+	if !errors.Is(err, ErrInvalidResource) {
+		t.Errorf("Expected a calendar without credentials to be refused, got %v", err)
+	}
+}
+
+func TestSlotsSkipBookingsAndBusyTimes(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	f.bookings.bookings = append(f.bookings.bookings, bookingDomain.Booking{
+		ID: "taken", ResourceID: "res-1", Start: f.at(19, 9), End: f.at(19, 10), Status: bookingDomain.StatusConfirmed,
+	})
+	f.calendar.busy = []gcal.Interval{{Start: f.at(20, 10).Add(30 * time.Minute), End: f.at(20, 12)}}
+
+	slots, err := f.svc.Slots(ctx, "res-1", f.at(19, 0), f.at(21, 0))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var labels []string
+	for _, slot := range slots {
+		labels = append(labels, slot.Label)
+	}
+	if got, want := strings.Join(labels, ", "), "Mon 19 Oct 10:00, Tue 20 Oct 09:00"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	if _, err := f.svc.Slots(ctx, "res-1", f.at(1, 0), f.at(1, 0).Add(40*24*time.Hour)); !errors.Is(err, ErrInvalidBooking) {
+		t.Errorf("Expected ranges over 31 days to be refused, got %v", err)
+	}
+	if _, err := f.svc.Slots(ctx, "missing", f.at(19, 0), f.at(21, 0)); !errors.Is(err, ErrResourceNotFound) {
+		t.Errorf("Expected ErrResourceNotFound, got %v", err)
+	}
+}
+
+func TestOfferAndBookFromConversation(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	slots, err := f.svc.OfferSlots(ctx, "res-1", "conv-1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(slots) != 3 || strings.Join(f.sender.buttons, ", ") != "Mon 19 Oct 09:00, Mon 19 Oct 10:00, Tue 20 Oct 09:00" {
+		t.Fatalf("Expected the next 3 slots as buttons, got %v", f.sender.buttons)
+	}
+	if len(f.messages.saved) != 1 {
+		t.Errorf("Expected the offer recorded in the conversation, got %v", f.messages.saved)
+	}
+
+	booking, err := f.svc.Book(ctx, "res-1", "conv-1", "tue 20 oct 09:00")
+	if err != nil {
+		t.Fatalf("Expected the picked slot to be booked, got %v", err)
+	}
+	if !booking.Start.Equal(f.at(20, 9)) || !booking.End.Equal(f.at(20, 10)) || booking.PhoneNumber != "50255551234" || booking.ContactName != "Ana" {
+		t.Errorf("Unexpected booking %+v", booking)
+	}
+	if booking.CalendarEventID != "event-1" || f.calendar.events[0].Summary != "Consultant: Ana" {
+		t.Errorf("Expected the booking added to the calendar, got %+v", f.calendar.events)
+	}
+
+	if _, err := f.svc.Book(ctx, "res-1", "conv-1", "Tue 20 Oct 09:00"); !errors.Is(err, ErrSlotUnavailable) {
+		t.Errorf("Expected a taken slot to be refused, got %v", err)
+	}
+	if _, err := f.svc.Book(ctx, "res-1", "conv-1", f.at(21, 10).Format(time.RFC3339)); err != nil {
+		t.Errorf("Expected a slot given by start time to be booked, got %v", err)
+	}
+	if _, err := f.svc.Book(ctx, "res-1", "conv-1", f.at(21, 12).Format(time.RFC3339)); !errors.Is(err, ErrSlotUnavailable) {
+		t.Errorf("Expected a time outside the hours to be refused, got %v", err)
+	}
+}
+
+func TestUpdateAndCancelBooking(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	booking := &bookingDomain.Booking{ResourceID: "res-1", ContactName: "Luis", Start: f.at(19, 9)}
+	id, err := f.svc.CreateBooking(ctx, "agent-1", booking)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := f.svc.CreateBooking(ctx, "agent-1", &bookingDomain.Booking{ResourceID: "res-1", Start: f.at(19, 9).Add(30 * time.Minute)}); !errors.Is(err, ErrSlotUnavailable) {
+		t.Errorf("Expected a start between slots to be refused, got %v", err)
+	}
+
+	// The booking's own calendar event does not block moving it.
+	f.calendar.busy = []gcal.Interval{{Start: f.at(19, 9), End: f.at(19, 10)}}
+	if err := f.svc.UpdateBooking(ctx, "agent-1", &bookingDomain.Booking{ID: id, Start: f.at(19, 10), Notes: "Second visit"}); err != nil {
+		t.Fatalf("Expected the booking to be moved, got %v", err)
+	}
+	moved, _ := f.svc.GetBooking(ctx, id)
+	if !moved.Start.Equal(f.at(19, 10)) || !moved.End.Equal(f.at(19, 11)) || moved.ContactName != "Luis" || moved.Notes != "Second visit" {
+		t.Errorf("Unexpected moved booking %+v", moved)
+	}
+
+	if err := f.svc.CancelBooking(ctx, "agent-1", id); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	cancelled, _ := f.svc.GetBooking(ctx, id)
+	if cancelled.Status != bookingDomain.StatusCancelled || len(f.calendar.deleted) != 1 {
+		t.Errorf("Expected the booking cancelled and its event deleted, got %+v, %v", cancelled, f.calendar.deleted)
+	}
+	if err := f.svc.UpdateBooking(ctx, "agent-1", &bookingDomain.Booking{ID: id, Start: f.at(20, 9)}); !errors.Is(err, ErrInvalidBooking) {
+		t.Errorf("Expected cancelled bookings to be unchangeable, got %v", err)
+	}
+	if err := f.svc.CancelBooking(ctx, "agent-1", "missing"); !errors.Is(err, ErrBookingNotFound) {
+		t.Errorf("Expected ErrBookingNotFound, got %v", err)
+	}
+}
+
+func TestDeleteResourceWithUpcomingBookings(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	id, _ := f.svc.CreateBooking(ctx, "agent-1", &bookingDomain.Booking{ResourceID: "res-1", Start: f.at(19, 9)})
+	if err := f.svc.DeleteResource(ctx, "admin-1", "res-1"); !errors.Is(err, ErrResourceInUse) {
+		t.Errorf("Expected ErrResourceInUse, got %v", err)
+	}
+	f.svc.CancelBooking(ctx, "agent-1", id)
+	if err := f.svc.DeleteResource(ctx, "admin-1", "res-1"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestSendReminders(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	f.svc.Book(ctx, "res-1", "conv-1", "Mon 19 Oct 10:00")
+	f.svc.CreateBooking(ctx, "agent-1", &bookingDomain.Booking{ResourceID: "res-1", PhoneNumber: "50255550000", Start: f.at(26, 9)})
+
+	if err := f.svc.SendReminders(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(f.sender.texts) != 1 || f.sender.texts[0] != "50255551234: Reminder: your appointment with Consultant is on Mon 19 Oct 10:00." {
+		t.Fatalf("Expected only the booking within a day reminded, got %v", f.sender.texts)
+	}
+	if len(f.messages.saved) != 1 || !strings.HasPrefix(f.messages.saved[0], "Reminder:") {
+		t.Errorf("Expected the reminder recorded in the conversation, got %v", f.messages.saved)
+	}
+
+	if err := f.svc.SendReminders(ctx); err != nil || len(f.sender.texts) != 1 {
+		t.Errorf("Expected each booking reminded once, got %v (%v)", f.sender.texts, err)
+	}
+}
@@ -0,0 +1,165 @@
+package cluster
+
+import (
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	clusterDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/cluster"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultLeaseName = "leader"
+	defaultLeaseTTL  = 15 * time.Second
+)
+
+// Elector keeps this instance in the running for a shared lease. The holder
+// renews it every third of the TTL; when the leader stops renewing, another
+// instance takes over once the lease expires.
+type Elector struct {
+	repo     clusterDomain.LeaseRepository
+	log      *logger.Logger
+	name     string
+	instance string
+	ttl      time.Duration
+	leader   atomic.Bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type ElectorConfig struct {
+	Repo clusterDomain.LeaseRepository
+	Log  *logger.Logger
+	// Name of the lease; instances sharing it elect one leader.
+	Name string
+	// Instance identifies this replica. Defaults to the hostname plus a
+	// random suffix.
+	Instance string
+	// TTL is how long a lease outlives its last renewal, which bounds how
+	// long failover takes. Defaults to 15 seconds.
+	TTL time.Duration
+}
+
+func NewElector(cfg ElectorConfig) *Elector {
+	name := cfg.Name
+	if name == "" {
+		name = defaultLeaseName
+	}
+	instance := cfg.Instance
+	if instance == "" {
+		host, _ := os.Hostname()
+		instance = host + "-" + primitive.NewObjectID().Hex()
+	}
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = defaultLeaseTTL
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Elector{
+		repo:     cfg.Repo,
+		log:      cfg.Log.With("component", "leader_election"),
+		name:     name,
+		instance: instance,
+		ttl:      ttl,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Instance returns the identifier this replica campaigns under.
+func (e *Elector) Instance() string {
+	return e.instance
+}
+
+// IsLeader reports whether this instance held the lease at its last renewal.
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Start campaigns immediately and then on every renewal interval until Stop
+// is called.
+func (e *Elector) Start() {
+	e.campaign()
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		ticker := time.NewTicker(e.ttl / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-e.ctx.Done():
+				return
+			case <-ticker.C:
+				e.campaign()
+			}
+		}
+	}()
+}
+
+// Stop ends the campaign and releases the lease so another instance can take
+// over without waiting for it to expire.
+func (e *Elector) Stop() {
+	e.cancel()
+	e.wg.Wait()
+
+	if !e.leader.Swap(false) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.repo.Release(ctx, e.name, e.instance); err != nil {
+		e.log.Error("failed to release leader lease", "error", err)
+		return
+	}
+	e.log.Info("leadership released", "instance", e.instance)
+}
+
+func (e *Elector) campaign() {
+	ctx, cancel := context.WithTimeout(e.ctx, e.ttl/3)
+	defer cancel()
+
+	ok, err := e.repo.Acquire(ctx, e.name, e.instance, time.Now().Add(e.ttl))
+	if err != nil {
+		// Without a confirmed renewal another instance may take over once
+		// the lease expires, so leadership is given up right away.
+		e.log.Error("failed to renew leader lease", "error", err)
+		ok = false
+	}
+
+	if was := e.leader.Swap(ok); was != ok {
+		if ok {
+			e.log.Info("leadership acquired", "instance", e.instance)
+		} else {
+			e.log.Warn("leadership lost", "instance", e.instance)
+		}
+	}
+}
+
+// Leader reports the current lease holder. An expired lease means no instance
+// is leading until the next campaign.
+func (e *Elector) Leader(ctx context.Context) (*clusterDomain.LeaderStatus, error) {
+	status := &clusterDomain.LeaderStatus{
+		Instance: e.instance,
+		IsLeader: e.IsLeader(),
+	}
+
+	lease, err := e.repo.Get(ctx, e.name)
+	if err != nil {
+		return nil, err
+	}
+	if lease != nil && lease.ExpiresAt.After(time.Now()) {
+		status.Leader = lease.Holder
+		status.LeaderSince = &lease.AcquiredAt
+		status.LeaseExpiresAt = &lease.ExpiresAt
+	}
+	return status, nil
+}
@@ -0,0 +1,149 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	clusterDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/cluster"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+)
+
+// mockLeaseRepo mimics the Mongo repository's lease semantics in memory.
+type mockLeaseRepo struct {
+	mu         sync.Mutex
+	leases     map[string]*clusterDomain.Lease
+	acquireErr error
+}
+
+func newMockLeaseRepo() *mockLeaseRepo {
+	return &mockLeaseRepo{leases: make(map[string]*clusterDomain.Lease)}
+}
+
+func (m *mockLeaseRepo) Acquire(ctx context.Context, name, holder string, expiresAt time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.acquireErr != nil {
+		return false, m.acquireErr
+	}
+
+	now := time.Now()
+	lease, ok := m.leases[name]
+	if ok && lease.Holder == holder {
+		lease.RenewedAt = now
+		lease.ExpiresAt = expiresAt
+		return true, nil
+	}
+	if ok && lease.ExpiresAt.After(now) {
+		return false, nil
+	}
+	m.leases[name] = &clusterDomain.Lease{Name: name, Holder: holder, AcquiredAt: now, RenewedAt: now, ExpiresAt: expiresAt}
+	return true, nil
+}
+
+func (m *mockLeaseRepo) Release(ctx context.Context, name, holder string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if lease, ok := m.leases[name]; ok && lease.Holder == holder {
+		lease.ExpiresAt = time.Now()
+	}
+	return nil
+}
+
+func (m *mockLeaseRepo) Get(ctx context.Context, name string) (*clusterDomain.Lease, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lease, ok := m.leases[name]
+	if !ok {
+		return nil, nil
+	}
+	copied := *lease
+	return &copied, nil
+}
+
+func newTestElector(repo clusterDomain.LeaseRepository, instance string) *Elector {
+	return NewElector(ElectorConfig{
+		Repo:     repo,
+		Log:      logger.New(logger.Options{Level: "error"}),
+		Instance: instance,
+		TTL:      time.Minute,
+	})
+}
+
+func TestSingleLeader(t *testing.T) {
+	repo := newMockLeaseRepo()
+	a := newTestElector(repo, "replica-a")
+	b := newTestElector(repo, "replica-b")
+
+	a.campaign()
+	b.campaign()
+
+	if !a.IsLeader() {
+		t.Error("Expected replica-a to be leader")
+	}
+	if b.IsLeader() {
+		t.Error("Expected replica-b to be follower")
+	}
+
+	status, err := b.Leader(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if status.Leader != "replica-a" || status.Instance != "replica-b" || status.IsLeader {
+		t.Errorf("Unexpected status %+v", status)
+	}
+}
+
+func TestFailoverAfterStop(t *testing.T) {
+	repo := newMockLeaseRepo()
+	a := newTestElector(repo, "replica-a")
+	b := newTestElector(repo, "replica-b")
+
+	a.Start()
+	b.campaign()
+	a.Stop()
+
+	if a.IsLeader() {
+		t.Error("Expected stopped elector to give up leadership")
+	}
+
+	b.campaign()
+	if !b.IsLeader() {
+		t.Error("Expected replica-b to take over the released lease")
+	}
+}
+
+func TestRenewalErrorDropsLeadership(t *testing.T) {
+	repo := newMockLeaseRepo()
+	e := newTestElector(repo, "replica-a")
+
+	e.campaign()
+	if !e.IsLeader() {
+		t.Fatal("Expected leader after first campaign")
+	}
+
+	repo.acquireErr = errors.New("connection refused")
+	e.campaign()
+	if e.IsLeader() {
+		t.Error("Expected leadership to be dropped when renewal fails")
+	}
+}
+
+func TestLeaderWithExpiredLease(t *testing.T) {
+	repo := newMockLeaseRepo()
+	repo.leases["leader"] = &clusterDomain.Lease{Name: "leader", Holder: "gone", ExpiresAt: time.Now().Add(-time.Second)}
+	e := newTestElector(repo, "replica-a")
+
+	status, err := e.Leader(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if status.Leader != "" {
+		t.Errorf("Expected no leader for expired lease, got %s", status.Leader)
+	}
+}
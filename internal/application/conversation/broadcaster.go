@@ -0,0 +1,71 @@
+package conversation
+
+import (
+	"sync"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+)
+
+// subscriberBuffer is how many events a slow subscriber may fall behind
+// before further events are dropped for it.
+const subscriberBuffer = 32
+
+type subscriber struct {
+	userCtx conversationDomain.UserContext
+	events  chan conversationDomain.Event
+}
+
+// broadcaster fans conversation events out to live subscribers. Publishing
+// never blocks: events are dropped for subscribers whose buffer is full.
+type broadcaster struct {
+	mu   sync.RWMutex
+	subs map[*subscriber]struct{}
+}
+
+func newBroadcaster() *broadcaster {
+	return &broadcaster{subs: make(map[*subscriber]struct{})}
+}
+
+func (b *broadcaster) subscribe(userCtx conversationDomain.UserContext) (<-chan conversationDomain.Event, func()) {
+	sub := &subscriber{
+		userCtx: userCtx,
+		events:  make(chan conversationDomain.Event, subscriberBuffer),
+	}
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return sub.events, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, sub)
+			b.mu.Unlock()
+			close(sub.events)
+		})
+	}
+}
+
+func (b *broadcaster) hasSubscribers() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs) > 0
+}
+
+// publish delivers event to every subscriber allowed to see conversations
+// owned by ownerID.
+func (b *broadcaster) publish(ownerID string, event conversationDomain.Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subs {
+		if !sub.userCtx.IsAdmin && sub.userCtx.UserID != ownerID {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+		}
+	}
+}
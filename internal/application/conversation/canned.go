@@ -0,0 +1,200 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+)
+
+var (
+	ErrCannedResponseNotFound  = errors.New("canned response not found")
+	ErrInvalidCannedResponse   = errors.New("invalid canned response")
+	ErrDuplicateCannedResponse = errors.New("a canned response with that shortcut already exists")
+)
+
+// maxCannedText is the longest text WhatsApp delivers in one message.
+const maxCannedText = 4096
+
+var (
+	cannedShortcut = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,39}$`)
+	// placeholder matches {name}, where name is spelled like a variable.
+	placeholder = regexp.MustCompile(`\{([a-z][a-z0-9_]{0,39})\}`)
+)
+
+func (s *service) CreateCannedResponse(ctx context.Context, userCtx conversationDomain.UserContext, response *conversationDomain.CannedResponse) (string, error) {
+	if !userCtx.IsAdmin {
+		return "", ErrForbidden
+	}
+	if s.cannedRepo == nil {
+		return "", fmt.Errorf("%w: canned responses are not configured", ErrInvalidCannedResponse)
+	}
+	if err := normalizeCannedResponse(response); err != nil {
+		return "", err
+	}
+
+	existing, err := s.cannedRepo.GetByShortcut(ctx, response.Shortcut)
+	if err != nil {
+		return "", err
+	}
+	if existing != nil {
+		return "", ErrDuplicateCannedResponse
+	}
+
+	response.CreatedBy = userCtx.UserID
+	return s.cannedRepo.Create(ctx, response)
+}
+
+func (s *service) ListCannedResponses(ctx context.Context, userCtx conversationDomain.UserContext, query string) ([]conversationDomain.CannedResponse, error) {
+	if !userCtx.IsAdmin {
+		return nil, ErrForbidden
+	}
+	if s.cannedRepo == nil {
+		return []conversationDomain.CannedResponse{}, nil
+	}
+	return s.cannedRepo.List(ctx, strings.TrimPrefix(strings.TrimSpace(query), "/"))
+}
+
+func (s *service) UpdateCannedResponse(ctx context.Context, userCtx conversationDomain.UserContext, response *conversationDomain.CannedResponse) error {
+	if !userCtx.IsAdmin {
+		return ErrForbidden
+	}
+	if s.cannedRepo == nil {
+		return ErrCannedResponseNotFound
+	}
+	if err := normalizeCannedResponse(response); err != nil {
+		return err
+	}
+
+	existing, err := s.cannedRepo.GetByID(ctx, response.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrCannedResponseNotFound
+	}
+	if response.Shortcut != existing.Shortcut {
+		other, err := s.cannedRepo.GetByShortcut(ctx, response.Shortcut)
+		if err != nil {
+			return err
+		}
+		if other != nil {
+			return ErrDuplicateCannedResponse
+		}
+	}
+
+	response.CreatedBy = existing.CreatedBy
+	response.CreatedAt = existing.CreatedAt
+	return s.cannedRepo.Update(ctx, response)
+}
+
+func (s *service) DeleteCannedResponse(ctx context.Context, userCtx conversationDomain.UserContext, id string) error {
+	if !userCtx.IsAdmin {
+		return ErrForbidden
+	}
+	if s.cannedRepo == nil {
+		return ErrCannedResponseNotFound
+	}
+
+	existing, err := s.cannedRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrCannedResponseNotFound
+	}
+	return s.cannedRepo.Delete(ctx, id)
+}
+
+func (s *service) RenderCannedResponse(ctx context.Context, userCtx conversationDomain.UserContext, conversationID, shortcut string) (*conversationDomain.RenderedResponse, error) {
+	if s.cannedRepo == nil {
+		return nil, ErrCannedResponseNotFound
+	}
+
+	conv, err := s.convRepo.GetByID(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if conv == nil {
+		return nil, ErrConversationNotFound
+	}
+	if !userCtx.IsAdmin && conv.UserID != userCtx.UserID {
+		return nil, ErrForbidden
+	}
+
+	response, err := s.cannedRepo.GetByShortcut(ctx, strings.ToLower(strings.TrimPrefix(shortcut, "/")))
+	if err != nil {
+		return nil, err
+	}
+	if response == nil {
+		return nil, ErrCannedResponseNotFound
+	}
+
+	text, missing := renderPlaceholders(response.Text, placeholderValues(conv))
+	return &conversationDomain.RenderedResponse{
+		ID:       response.ID,
+		Shortcut: response.Shortcut,
+		Text:     text,
+		Missing:  missing,
+	}, nil
+}
+
+// placeholderValues is what a conversation fills placeholders with: its
+// variables, and the contact's name and phone number, which take precedence
+// over variables of the same name.
+func placeholderValues(conv *conversationDomain.Conversation) map[string]string {
+	values := make(map[string]string, len(conv.Variables)+2)
+	for k, v := range conv.Variables {
+		values[k] = v
+	}
+	if name := strings.TrimSpace(conv.ContactName); name != "" {
+		values["contact_name"] = name
+	}
+	if conv.PhoneNumber != "" {
+		values["phone_number"] = conv.PhoneNumber
+	}
+	return values
+}
+
+// renderPlaceholders fills the placeholders in text from values, leaving
+// those without a value in place and listing them once each.
+func renderPlaceholders(text string, values map[string]string) (string, []string) {
+	var missing []string
+	rendered := placeholder.ReplaceAllStringFunc(text, func(match string) string {
+		name := match[1 : len(match)-1]
+		if v, ok := values[name]; ok {
+			return v
+		}
+		if !slices.Contains(missing, name) {
+			missing = append(missing, name)
+		}
+		return match
+	})
+	return rendered, missing
+}
+
+// normalizeCannedResponse checks response and stores its shortcut the way
+// agents type it, lowercase and without the leading slash.
+func normalizeCannedResponse(response *conversationDomain.CannedResponse) error {
+	response.Shortcut = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(response.Shortcut), "/"))
+	response.Title = strings.TrimSpace(response.Title)
+	response.Text = strings.TrimSpace(response.Text)
+	if !cannedShortcut.MatchString(response.Shortcut) {
+		return fmt.Errorf("%w: shortcut must be up to 40 lowercase letters, digits, dashes and underscores", ErrInvalidCannedResponse)
+	}
+	if response.Text == "" {
+		return fmt.Errorf("%w: text is required", ErrInvalidCannedResponse)
+	}
+	if utf8.RuneCountInString(response.Text) > maxCannedText {
+		return fmt.Errorf("%w: text is longer than %d characters", ErrInvalidCannedResponse, maxCannedText)
+	}
+	if response.Title == "" {
+		response.Title = response.Shortcut
+	}
+	return nil
+}
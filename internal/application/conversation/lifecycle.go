@@ -0,0 +1,140 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+)
+
+var ErrInvalidState = errors.New("invalid conversation state")
+
+func (s *service) SetState(ctx context.Context, userCtx conversationDomain.UserContext, conversationID string, state conversationDomain.State) (*conversationDomain.Conversation, error) {
+	if !state.Valid() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidState, state)
+	}
+
+	conv, err := s.convRepo.GetByID(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if conv == nil {
+		return nil, ErrConversationNotFound
+	}
+	if !userCtx.IsAdmin && conv.UserID != userCtx.UserID {
+		return nil, ErrForbidden
+	}
+
+	defaultState(conv)
+	if conv.State == state {
+		return conv, nil
+	}
+	if err := s.changeState(ctx, conv, state); err != nil {
+		return nil, err
+	}
+	return conv, nil
+}
+
+func (s *service) Stats(ctx context.Context, userCtx conversationDomain.UserContext, filter conversationDomain.ConversationFilter) (*conversationDomain.Stats, error) {
+	filter.UserID = ""
+	if !userCtx.IsAdmin {
+		filter.UserID = userCtx.UserID
+	}
+	filter.Query = strings.TrimSpace(filter.Query)
+	if filter.Query != "" {
+		ids, err := s.msgRepo.SearchConversationIDs(ctx, filter.UserID, filter.Query, maxSearchMatches)
+		if err != nil {
+			return nil, err
+		}
+		filter.ConversationIDs = ids
+	}
+
+	counts, err := s.convRepo.CountByState(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &conversationDomain.Stats{States: make(map[conversationDomain.State]int64, len(conversationDomain.States))}
+	for _, state := range conversationDomain.States {
+		stats.States[state] = counts[state]
+		stats.Total += counts[state]
+	}
+	return stats, nil
+}
+
+// reopen moves a conversation the contact wrote to back to open, unless it
+// already is.
+func (s *service) reopen(ctx context.Context, conv *conversationDomain.Conversation) error {
+	defaultState(conv)
+	if conv.State == conversationDomain.StateOpen {
+		return nil
+	}
+	return s.changeState(ctx, conv, conversationDomain.StateOpen)
+}
+
+func (s *service) changeState(ctx context.Context, conv *conversationDomain.Conversation, state conversationDomain.State) error {
+	previous := conv.State
+	if err := s.convRepo.SetState(ctx, conv.ID, state); err != nil {
+		return err
+	}
+	conv.State = state
+	conv.StateChangedAt = time.Now()
+
+	if state == conversationDomain.StateResolved || state == conversationDomain.StateClosed {
+		if err := s.recordResolution(ctx, conv, conv.StateChangedAt); err != nil {
+			return err
+		}
+	}
+	s.notifyConversation(conv)
+	s.bus.Publish(ctx, events.ConversationStateChanged{ConversationID: conv.ID, From: string(previous), To: string(state)})
+	return nil
+}
+
+// notifyConversation pushes a changed conversation to live subscribers.
+func (s *service) notifyConversation(conv *conversationDomain.Conversation) {
+	s.events.publish(conv.UserID, conversationDomain.Event{
+		Type:           conversationDomain.EventConversationUpdated,
+		ConversationID: conv.ID,
+		Conversation:   conv,
+		Timestamp:      time.Now(),
+	})
+}
+
+// defaultState reports conversations from before states were tracked as
+// open.
+func defaultState(conv *conversationDomain.Conversation) {
+	if conv.State == "" {
+		conv.State = conversationDomain.StateOpen
+	}
+}
+
+// AutoCloseJob closes conversations that have had no messages for a while.
+// It is meant to run from the scheduler.
+type AutoCloseJob struct {
+	repo  conversationDomain.ConversationRepository
+	after time.Duration
+}
+
+// NewAutoCloseJob closes conversations after days without messages.
+func NewAutoCloseJob(repo conversationDomain.ConversationRepository, days int) *AutoCloseJob {
+	return &AutoCloseJob{
+		repo:  repo,
+		after: time.Duration(days) * 24 * time.Hour,
+	}
+}
+
+// Run closes the conversations inactive as of the current time.
+func (j *AutoCloseJob) Run(ctx context.Context) error {
+	_, err := j.Close(ctx, time.Now())
+	return err
+}
+
+// Close closes the conversations inactive as of now and returns how many
+// it closed.
+func (j *AutoCloseJob) Close(ctx context.Context, now time.Time) (int64, error) {
+	return j.repo.CloseInactive(ctx, now.Add(-j.after))
+}
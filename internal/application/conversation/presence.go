@@ -0,0 +1,395 @@
+package conversation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	"github.com/elprogramadorgt/lucidRAG/pkg/cache"
+)
+
+var (
+	ErrInvalidPresence = errors.New("invalid presence status")
+	ErrNotConnected    = errors.New("agent is not connected to the live inbox")
+)
+
+// agentPresence is an agent's status and how many live streams they have
+// open, one per browser tab.
+type agentPresence struct {
+	streams int
+	status  conversationDomain.PresenceStatus
+	since   time.Time
+}
+
+// presenceTracker keeps the presence of agents connected to this instance.
+type presenceTracker struct {
+	mu     sync.Mutex
+	agents map[string]*agentPresence
+}
+
+func newPresenceTracker() *presenceTracker {
+	return &presenceTracker{agents: make(map[string]*agentPresence)}
+}
+
+// connect counts a stream opened by agentID. It returns the agent's
+// presence when this is their first stream, and nil otherwise.
+func (t *presenceTracker) connect(agentID string, now time.Time) *conversationDomain.Presence {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if agent, ok := t.agents[agentID]; ok {
+		agent.streams++
+		return nil
+	}
+	t.agents[agentID] = &agentPresence{streams: 1, status: conversationDomain.PresenceOnline, since: now}
+	return &conversationDomain.Presence{AgentID: agentID, Status: conversationDomain.PresenceOnline, Since: now}
+}
+
+// disconnect counts a stream closed by agentID. It returns the agent's
+// offline presence when this was their last stream, and nil otherwise.
+func (t *presenceTracker) disconnect(agentID string, now time.Time) *conversationDomain.Presence {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	agent, ok := t.agents[agentID]
+	if !ok {
+		return nil
+	}
+	if agent.streams--; agent.streams > 0 {
+		return nil
+	}
+	delete(t.agents, agentID)
+	return &conversationDomain.Presence{AgentID: agentID, Status: conversationDomain.PresenceOffline, Since: now}
+}
+
+// set changes a connected agent's status. changed is false when it already
+// was status.
+func (t *presenceTracker) set(agentID string, status conversationDomain.PresenceStatus, now time.Time) (presence *conversationDomain.Presence, changed bool, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	agent, ok := t.agents[agentID]
+	if !ok {
+		return nil, false, ErrNotConnected
+	}
+	if agent.status != status {
+		agent.status, agent.since, changed = status, now, true
+	}
+	return &conversationDomain.Presence{AgentID: agentID, Status: agent.status, Since: agent.since}, changed, nil
+}
+
+func (t *presenceTracker) list() []conversationDomain.Presence {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	presence := make([]conversationDomain.Presence, 0, len(t.agents))
+	for agentID, agent := range t.agents {
+		presence = append(presence, conversationDomain.Presence{AgentID: agentID, Status: agent.status, Since: agent.since})
+	}
+	sort.Slice(presence, func(i, j int) bool { return presence[i].AgentID < presence[j].AgentID })
+	return presence
+}
+
+// online returns the agents that are connected and not away, by ID.
+func (t *presenceTracker) online() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var agents []string
+	for agentID, agent := range t.agents {
+		if agent.status == conversationDomain.PresenceOnline {
+			agents = append(agents, agentID)
+		}
+	}
+	sort.Strings(agents)
+	return agents
+}
+
+func (s *service) Subscribe(userCtx conversationDomain.UserContext) (<-chan conversationDomain.Event, func()) {
+	if !userCtx.IsAdmin {
+		return s.events.subscribe(userCtx)
+	}
+
+	// Other agents see this one come online; their own stream does not.
+	presence := s.presence.connect(userCtx.UserID, time.Now())
+	s.sharePresence(presence, false)
+	s.notifyPresence(presence)
+	events, unsubscribe := s.events.subscribe(userCtx)
+	var once sync.Once
+	return events, func() {
+		once.Do(func() {
+			unsubscribe()
+			presence := s.presence.disconnect(userCtx.UserID, time.Now())
+			s.sharePresence(presence, true)
+			s.notifyPresence(presence)
+		})
+	}
+}
+
+// sharePresence lists an agent who connected to this replica in the
+// presence directory, or lets go of one who left. An agent still connected
+// to another replica stays listed.
+func (s *service) sharePresence(presence *conversationDomain.Presence, left bool) {
+	if presence == nil || s.directory == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), presenceTimeout)
+	defer cancel()
+	if left {
+		_ = s.directory.leave(ctx, presence.AgentID)
+		return
+	}
+	_ = s.directory.join(ctx, *presence)
+	s.directory.keepFresh()
+}
+
+// SetPresence sets a connected agent's status. With the presence directory
+// the agent may be connected to another replica.
+func (s *service) SetPresence(ctx context.Context, userCtx conversationDomain.UserContext, status conversationDomain.PresenceStatus) (*conversationDomain.Presence, error) {
+	if !userCtx.IsAdmin {
+		return nil, ErrForbidden
+	}
+	if status != conversationDomain.PresenceOnline && status != conversationDomain.PresenceAway {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidPresence, status)
+	}
+
+	now := time.Now()
+	presence, changed, err := s.presence.set(userCtx.UserID, status, now)
+	if errors.Is(err, ErrNotConnected) && s.directory != nil {
+		presence, changed, err = s.setSharedPresence(ctx, userCtx.UserID, status, now)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		if s.directory != nil {
+			if err := s.directory.put(ctx, *presence); err != nil {
+				return nil, err
+			}
+		}
+		s.notifyPresence(presence)
+	}
+	return presence, nil
+}
+
+// setSharedPresence sets the status of an agent connected to another
+// replica.
+func (s *service) setSharedPresence(ctx context.Context, agentID string, status conversationDomain.PresenceStatus, now time.Time) (*conversationDomain.Presence, bool, error) {
+	presence, err := s.directory.get(ctx, agentID)
+	if err != nil {
+		return nil, false, err
+	}
+	if presence == nil {
+		return nil, false, ErrNotConnected
+	}
+	if presence.Status == status {
+		return presence, false, nil
+	}
+	presence.Status, presence.Since = status, now
+	return presence, true, nil
+}
+
+func (s *service) ListPresence(ctx context.Context, userCtx conversationDomain.UserContext) ([]conversationDomain.Presence, error) {
+	if !userCtx.IsAdmin {
+		return nil, ErrForbidden
+	}
+	if s.directory != nil {
+		return s.directory.list(ctx)
+	}
+	return s.presence.list(), nil
+}
+
+// onlineAgents returns the agents handoffs can be routed to. A cache outage
+// falls back to the agents connected to this replica.
+func (s *service) onlineAgents(ctx context.Context) []string {
+	if s.directory != nil {
+		if agents, err := s.directory.online(ctx); err == nil {
+			return agents
+		}
+	}
+	return s.presence.online()
+}
+
+// notifyPresence pushes an agent's presence to the other agents, when
+// there is a change to push.
+func (s *service) notifyPresence(presence *conversationDomain.Presence) {
+	if presence == nil {
+		return
+	}
+	// Presence belongs to no conversation, so only admins receive it.
+	s.events.publish("", conversationDomain.Event{
+		Type:      conversationDomain.EventPresenceUpdated,
+		Presence:  presence,
+		Timestamp: presence.Since,
+	})
+}
+
+const (
+	// presenceTTL is how long an agent stays listed after the replica
+	// holding their stream stops refreshing them, such as when it crashes.
+	presenceTTL     = 90 * time.Second
+	presenceRefresh = 30 * time.Second
+	presenceTimeout = 5 * time.Second
+	presenceRoster  = "presence:agents"
+)
+
+func presenceKey(agentID string) string {
+	return "presence:agent:" + agentID
+}
+
+// holdersKey names the set of replicas an agent has a stream open on.
+func holdersKey(agentID string) string {
+	return "presence:holders:" + agentID
+}
+
+type sharedPresence struct {
+	Status conversationDomain.PresenceStatus `json:"status"`
+	Since  time.Time                         `json:"since"`
+}
+
+// presenceDirectory shares agent presence between replicas through the
+// cache, so every replica lists and routes to the agents connected to any
+// of them. Each replica writes the presence of its own agents, notes that
+// it holds them and refreshes both while they stay connected. The roster
+// and holders are cache sets, so replicas never overwrite each other's
+// entries.
+type presenceDirectory struct {
+	cache   cache.Cache
+	local   *presenceTracker
+	replica string
+	mu      sync.Mutex
+	running bool
+}
+
+func newPresenceDirectory(c cache.Cache, local *presenceTracker) *presenceDirectory {
+	if c == nil {
+		return nil
+	}
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	return &presenceDirectory{cache: c, local: local, replica: hex.EncodeToString(id)}
+}
+
+// put writes an agent's presence and adds them to the roster.
+func (d *presenceDirectory) put(ctx context.Context, presence conversationDomain.Presence) error {
+	shared := sharedPresence{Status: presence.Status, Since: presence.Since}
+	if err := cache.SetJSON(ctx, d.cache, presenceKey(presence.AgentID), shared, presenceTTL); err != nil {
+		return err
+	}
+	return d.cache.AddMember(ctx, presenceRoster, presence.AgentID, presenceTTL)
+}
+
+// join lists an agent who opened their first stream on this replica.
+func (d *presenceDirectory) join(ctx context.Context, presence conversationDomain.Presence) error {
+	if err := d.cache.AddMember(ctx, holdersKey(presence.AgentID), d.replica, presenceTTL); err != nil {
+		return err
+	}
+	return d.put(ctx, presence)
+}
+
+// leave lets go of an agent who closed their last stream on this replica,
+// and takes them off the roster unless another replica still holds them.
+func (d *presenceDirectory) leave(ctx context.Context, agentID string) error {
+	if err := d.cache.RemoveMember(ctx, holdersKey(agentID), d.replica); err != nil {
+		return err
+	}
+	holders, err := d.cache.Members(ctx, holdersKey(agentID))
+	if err != nil || len(holders) > 0 {
+		return err
+	}
+	if err := d.cache.RemoveMember(ctx, presenceRoster, agentID); err != nil {
+		return err
+	}
+	return d.cache.Delete(ctx, presenceKey(agentID))
+}
+
+func (d *presenceDirectory) get(ctx context.Context, agentID string) (*conversationDomain.Presence, error) {
+	var shared sharedPresence
+	ok, err := cache.GetJSON(ctx, d.cache, presenceKey(agentID), &shared)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &conversationDomain.Presence{AgentID: agentID, Status: shared.Status, Since: shared.Since}, nil
+}
+
+func (d *presenceDirectory) list(ctx context.Context) ([]conversationDomain.Presence, error) {
+	roster, err := d.cache.Members(ctx, presenceRoster)
+	if err != nil {
+		return nil, err
+	}
+	presence := make([]conversationDomain.Presence, 0, len(roster))
+	for _, agentID := range roster {
+		agent, err := d.get(ctx, agentID)
+		if err != nil {
+			return nil, err
+		}
+		if agent != nil {
+			presence = append(presence, *agent)
+		}
+	}
+	sort.Slice(presence, func(i, j int) bool { return presence[i].AgentID < presence[j].AgentID })
+	return presence, nil
+}
+
+// online returns the agents connected to any replica and not away, by ID.
+func (d *presenceDirectory) online(ctx context.Context) ([]string, error) {
+	presence, err := d.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var agents []string
+	for _, agent := range presence {
+		if agent.Status == conversationDomain.PresenceOnline {
+			agents = append(agents, agent.AgentID)
+		}
+	}
+	return agents, nil
+}
+
+// keepFresh refreshes this replica's agents every presenceRefresh until
+// none is left connected.
+func (d *presenceDirectory) keepFresh() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.running {
+		return
+	}
+	d.running = true
+
+	go func() {
+		ticker := time.NewTicker(presenceRefresh)
+		defer ticker.Stop()
+		for range ticker.C {
+			d.mu.Lock()
+			local := d.local.list()
+			if len(local) == 0 {
+				d.running = false
+				d.mu.Unlock()
+				return
+			}
+			d.mu.Unlock()
+
+			ctx, cancel := context.WithTimeout(context.Background(), presenceTimeout)
+			d.refresh(ctx, local)
+			cancel()
+		}
+	}()
+}
+
+// refresh extends the listing of local agents. A status set through
+// another replica is kept; an agent missing from the cache is written
+// again.
+func (d *presenceDirectory) refresh(ctx context.Context, local []conversationDomain.Presence) {
+	for _, agent := range local {
+		if shared, err := d.get(ctx, agent.AgentID); err == nil && shared != nil {
+			agent = *shared
+		}
+		_ = d.join(ctx, agent)
+	}
+}
@@ -0,0 +1,70 @@
+package conversation
+
+import (
+	"context"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+)
+
+// purgeBatchSize is how many conversations are read at a time while
+// purging.
+const purgeBatchSize = 100
+
+func (s *service) PurgeUserConversations(ctx context.Context, userCtx conversationDomain.UserContext, userID string, dryRun bool) (*conversationDomain.PurgeResult, error) {
+	if !userCtx.IsAdmin {
+		return nil, ErrForbidden
+	}
+
+	result := &conversationDomain.PurgeResult{}
+	offset := 0
+	for {
+		convs, err := s.convRepo.ListByUser(ctx, userID, purgeBatchSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, conv := range convs {
+			messages, err := s.msgRepo.CountByConversation(ctx, conv.ID)
+			if err != nil {
+				return nil, err
+			}
+			if !dryRun {
+				if err := s.removeConversation(ctx, conv.ID); err != nil {
+					return nil, err
+				}
+			}
+			result.Conversations++
+			result.Messages += messages
+		}
+		if len(convs) < purgeBatchSize {
+			return result, nil
+		}
+		// Deleted conversations drop out of the listing; counted ones do not.
+		if dryRun {
+			offset += len(convs)
+		}
+	}
+}
+
+// removeConversation deletes a conversation after everything attached to
+// it, so a failure part way leaves it in place to purge again.
+func (s *service) removeConversation(ctx context.Context, id string) error {
+	if err := s.msgRepo.DeleteByConversation(ctx, id); err != nil {
+		return err
+	}
+	if s.noteRepo != nil {
+		if err := s.noteRepo.DeleteByConversation(ctx, id); err != nil {
+			return err
+		}
+	}
+	if s.readRepo != nil {
+		if err := s.readRepo.DeleteByConversation(ctx, id); err != nil {
+			return err
+		}
+	}
+	if s.assignRepo != nil {
+		if err := s.assignRepo.DeleteByConversation(ctx, id); err != nil {
+			return err
+		}
+	}
+	return s.convRepo.Delete(ctx, id)
+}
@@ -0,0 +1,110 @@
+package conversation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+)
+
+// retentionTarget is a collection a retention policy applies to.
+type retentionTarget struct {
+	collection string
+	days       int
+	// fields are what anonymizing clears, for the compliance report.
+	fields    []string
+	anonymize func(ctx context.Context, before time.Time) (int64, error)
+	counts    func(ctx context.Context, before time.Time) (*conversationDomain.RetentionCounts, error)
+}
+
+func (s *service) retentionTargets() []retentionTarget {
+	targets := []retentionTarget{
+		{
+			collection: conversationDomain.RetentionConversations,
+			days:       s.retention.ConversationDays,
+			fields:     []string{"phone_number", "contact_name", "external_id", "summary", "variables"},
+			anonymize:  s.convRepo.AnonymizeInactive,
+			counts:     s.convRepo.RetentionCounts,
+		},
+		{
+			collection: conversationDomain.RetentionMessages,
+			days:       s.retention.MessageDays,
+			fields:     []string{"content", "rag_answer", "media.description"},
+			anonymize:  s.msgRepo.AnonymizeBefore,
+			counts:     s.msgRepo.RetentionCounts,
+		},
+	}
+	if s.noteRepo != nil {
+		targets = append(targets, retentionTarget{
+			collection: conversationDomain.RetentionNotes,
+			days:       s.retention.NoteDays,
+			fields:     []string{"content"},
+			anonymize:  s.noteRepo.AnonymizeBefore,
+			counts:     s.noteRepo.RetentionCounts,
+		})
+	}
+	return targets
+}
+
+// retentionCutoff is the time before which data kept for days is past its
+// policy.
+func retentionCutoff(now time.Time, days int) time.Time {
+	return now.AddDate(0, 0, -days)
+}
+
+// ApplyRetention anonymizes every collection with a policy, stopping at
+// the first that fails. Each run picks up whatever an earlier one left.
+func (s *service) ApplyRetention(ctx context.Context) (*conversationDomain.RetentionResult, error) {
+	now := time.Now()
+	result := &conversationDomain.RetentionResult{}
+	for _, target := range s.retentionTargets() {
+		if target.days <= 0 {
+			continue
+		}
+		anonymized, err := target.anonymize(ctx, retentionCutoff(now, target.days))
+		if err != nil {
+			return result, fmt.Errorf("failed to anonymize %s: %w", target.collection, err)
+		}
+		switch target.collection {
+		case conversationDomain.RetentionConversations:
+			result.Conversations = anonymized
+		case conversationDomain.RetentionMessages:
+			result.Messages = anonymized
+		case conversationDomain.RetentionNotes:
+			result.Notes = anonymized
+		}
+	}
+	return result, nil
+}
+
+func (s *service) RetentionReport(ctx context.Context, userCtx conversationDomain.UserContext) (*conversationDomain.RetentionReport, error) {
+	if !userCtx.IsAdmin {
+		return nil, ErrForbidden
+	}
+
+	now := time.Now()
+	report := &conversationDomain.RetentionReport{GeneratedAt: now, Compliant: true}
+	for _, target := range s.retentionTargets() {
+		policy := conversationDomain.RetentionPolicy{
+			Collection: target.collection,
+			Days:       target.days,
+			Fields:     target.fields,
+		}
+		var before time.Time
+		if target.days > 0 {
+			before = retentionCutoff(now, target.days)
+			policy.Cutoff = &before
+		}
+		counts, err := target.counts(ctx, before)
+		if err != nil {
+			return nil, err
+		}
+		policy.RetentionCounts = *counts
+		if policy.Overdue > 0 {
+			report.Compliant = false
+		}
+		report.Policies = append(report.Policies, policy)
+	}
+	return report, nil
+}
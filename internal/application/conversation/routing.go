@@ -0,0 +1,271 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+	"github.com/elprogramadorgt/lucidRAG/pkg/cache"
+)
+
+var (
+	ErrNotHandedOff           = errors.New("conversation is not handed off to an agent")
+	ErrNoAgentAvailable       = errors.New("no other agent is online")
+	ErrAssignmentRuleNotFound = errors.New("assignment rule not found")
+	ErrInvalidAssignmentRule  = errors.New("invalid assignment rule")
+)
+
+const (
+	maxRuleAgents = 50
+	maxRuleName   = 60
+)
+
+// route is the agent a conversation goes to and why.
+type route struct {
+	agentID string
+	reason  conversationDomain.AssignmentReason
+	ruleID  string
+}
+
+// rotation takes turns between agents. Each gets the next conversation in
+// the order they last got one, those who never did first. With a cache the
+// replicas share one turn counter and go round the agents in ID order
+// instead, as the counter is the only step that is atomic between them.
+type rotation struct {
+	cache cache.Cache
+	mu    sync.Mutex
+	turn  uint64
+	turns map[string]uint64
+}
+
+func newRotation(c cache.Cache) *rotation {
+	return &rotation{cache: c, turns: make(map[string]uint64)}
+}
+
+// next picks whose turn it is among agents, which must not be empty, and
+// counts the turn taken. A cache outage falls back to this replica's turns.
+func (r *rotation) next(ctx context.Context, agents []string) string {
+	if r.cache != nil {
+		if turn, err := r.cache.Incr(ctx, "handoff:turn", 0); err == nil {
+			sorted := slices.Sorted(slices.Values(agents))
+			return sorted[(turn-1)%int64(len(sorted))]
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next := agents[0]
+	for _, agentID := range agents[1:] {
+		if r.turns[agentID] < r.turns[next] {
+			next = agentID
+		}
+	}
+	r.turn++
+	r.turns[next] = r.turn
+	return next
+}
+
+// route picks the online agent other than exclude that conv goes to: the
+// next in the first matching rule that has one online, or else the next of
+// them all. It has no agent when none is online.
+func (s *service) route(ctx context.Context, conv *conversationDomain.Conversation, exclude string) (route, error) {
+	online := slices.DeleteFunc(s.onlineAgents(ctx), func(agentID string) bool { return agentID == exclude })
+	if len(online) == 0 {
+		return route{}, nil
+	}
+
+	if s.ruleRepo != nil && len(conv.Tags) > 0 {
+		rules, err := s.ruleRepo.List(ctx)
+		if err != nil {
+			return route{}, err
+		}
+		for _, rule := range rules {
+			if !rule.Matches(conv.Tags) {
+				continue
+			}
+			agents := slices.DeleteFunc(slices.Clone(online), func(agentID string) bool { return !slices.Contains(rule.AgentIDs, agentID) })
+			if len(agents) > 0 {
+				return route{agentID: s.rotation.next(ctx, agents), reason: conversationDomain.AssignmentRuleMatch, ruleID: rule.ID}, nil
+			}
+		}
+	}
+	return route{agentID: s.rotation.next(ctx, online), reason: conversationDomain.AssignmentRoundRobin}, nil
+}
+
+func (s *service) recordAssignment(ctx context.Context, assignment conversationDomain.Assignment) error {
+	if s.assignRepo != nil {
+		if _, err := s.assignRepo.Create(ctx, &assignment); err != nil {
+			return err
+		}
+	}
+	s.bus.Publish(ctx, events.ConversationAssigned{
+		ConversationID:  assignment.ConversationID,
+		AgentID:         assignment.AgentID,
+		PreviousAgentID: assignment.PreviousAgentID,
+		Reason:          string(assignment.Reason),
+		RuleID:          assignment.RuleID,
+		ActorID:         assignment.ActorID,
+	})
+	return nil
+}
+
+func (s *service) Reassign(ctx context.Context, userCtx conversationDomain.UserContext, conversationID, agentID string) (*conversationDomain.Conversation, error) {
+	if !userCtx.IsAdmin {
+		return nil, ErrForbidden
+	}
+	conv, err := s.convRepo.GetByID(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if conv == nil {
+		return nil, ErrConversationNotFound
+	}
+	if conv.Mode != conversationDomain.ModeHuman {
+		return nil, ErrNotHandedOff
+	}
+
+	to := route{agentID: agentID, reason: conversationDomain.AssignmentManual}
+	if agentID == "" {
+		if to, err = s.route(ctx, conv, conv.AgentID); err != nil {
+			return nil, err
+		}
+		if to.agentID == "" {
+			return nil, ErrNoAgentAvailable
+		}
+	}
+	if err := s.assign(ctx, userCtx, conv, conversationDomain.ModeHuman, to); err != nil {
+		return nil, err
+	}
+	return conv, nil
+}
+
+func (s *service) ListAssignments(ctx context.Context, userCtx conversationDomain.UserContext, conversationID string) ([]conversationDomain.Assignment, error) {
+	conv, err := s.convRepo.GetByID(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if conv == nil {
+		return nil, ErrConversationNotFound
+	}
+	if !userCtx.IsAdmin && conv.UserID != userCtx.UserID {
+		return nil, ErrForbidden
+	}
+	if s.assignRepo == nil {
+		return []conversationDomain.Assignment{}, nil
+	}
+	return s.assignRepo.ListByConversation(ctx, conversationID)
+}
+
+func (s *service) ListAssignmentRules(ctx context.Context, userCtx conversationDomain.UserContext) ([]conversationDomain.AssignmentRule, error) {
+	if !userCtx.IsAdmin {
+		return nil, ErrForbidden
+	}
+	if s.ruleRepo == nil {
+		return []conversationDomain.AssignmentRule{}, nil
+	}
+	return s.ruleRepo.List(ctx)
+}
+
+func (s *service) CreateAssignmentRule(ctx context.Context, userCtx conversationDomain.UserContext, rule *conversationDomain.AssignmentRule) (string, error) {
+	if !userCtx.IsAdmin {
+		return "", ErrForbidden
+	}
+	if s.ruleRepo == nil {
+		return "", fmt.Errorf("%w: assignment rules are not configured", ErrInvalidAssignmentRule)
+	}
+	if err := s.normalizeAssignmentRule(ctx, rule); err != nil {
+		return "", err
+	}
+
+	rule.CreatedBy = userCtx.UserID
+	return s.ruleRepo.Create(ctx, rule)
+}
+
+func (s *service) UpdateAssignmentRule(ctx context.Context, userCtx conversationDomain.UserContext, rule *conversationDomain.AssignmentRule) error {
+	if !userCtx.IsAdmin {
+		return ErrForbidden
+	}
+	if s.ruleRepo == nil {
+		return ErrAssignmentRuleNotFound
+	}
+	existing, err := s.ruleRepo.GetByID(ctx, rule.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrAssignmentRuleNotFound
+	}
+	if err := s.normalizeAssignmentRule(ctx, rule); err != nil {
+		return err
+	}
+
+	rule.CreatedBy = existing.CreatedBy
+	rule.CreatedAt = existing.CreatedAt
+	return s.ruleRepo.Update(ctx, rule)
+}
+
+func (s *service) DeleteAssignmentRule(ctx context.Context, userCtx conversationDomain.UserContext, id string) error {
+	if !userCtx.IsAdmin {
+		return ErrForbidden
+	}
+	if s.ruleRepo == nil {
+		return ErrAssignmentRuleNotFound
+	}
+	existing, err := s.ruleRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrAssignmentRuleNotFound
+	}
+	return s.ruleRepo.Delete(ctx, id)
+}
+
+// normalizeAssignmentRule checks rule, whose tags must exist, and drops
+// blank and repeated tags and agents.
+func (s *service) normalizeAssignmentRule(ctx context.Context, rule *conversationDomain.AssignmentRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	rule.Tags = normalizeTagNames(rule.Tags)
+	agents := []string{}
+	for _, agentID := range rule.AgentIDs {
+		agentID = strings.TrimSpace(agentID)
+		if agentID != "" && !slices.Contains(agents, agentID) {
+			agents = append(agents, agentID)
+		}
+	}
+	rule.AgentIDs = agents
+
+	switch {
+	case rule.Name == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidAssignmentRule)
+	case utf8.RuneCountInString(rule.Name) > maxRuleName:
+		return fmt.Errorf("%w: name is longer than %d characters", ErrInvalidAssignmentRule, maxRuleName)
+	case len(rule.Tags) == 0:
+		return fmt.Errorf("%w: at least one tag is required", ErrInvalidAssignmentRule)
+	case len(rule.AgentIDs) == 0:
+		return fmt.Errorf("%w: at least one agent is required", ErrInvalidAssignmentRule)
+	case len(rule.AgentIDs) > maxRuleAgents:
+		return fmt.Errorf("%w: at most %d agents per rule", ErrInvalidAssignmentRule, maxRuleAgents)
+	}
+
+	if s.tagRepo == nil {
+		return fmt.Errorf("%w: tags are not configured", ErrInvalidAssignmentRule)
+	}
+	defined, err := s.tagRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, name := range rule.Tags {
+		if !slices.ContainsFunc(defined, func(t conversationDomain.Tag) bool { return t.Name == name }) {
+			return fmt.Errorf("%w: tag %q does not exist", ErrInvalidAssignmentRule, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+	"github.com/elprogramadorgt/lucidRAG/pkg/cache"
+)
+
+func newRoutingService(rules *mockAssignmentRuleRepo, history *mockAssignmentRepo, bus *events.Bus, agents ...string) (*service, *mockConversationRepo) {
+	convRepo := newMockConversationRepo()
+	convRepo.conversations["conv_1"] = &conversationDomain.Conversation{ID: "conv_1", UserID: "owner"}
+	cfg := ServiceConfig{
+		ConvRepo: convRepo,
+		MsgRepo:  newMockMessageRepo(),
+		TagRepo:  newMockTagRepo(conversationDomain.Tag{Name: "billing"}, conversationDomain.Tag{Name: "vip"}),
+		Events:   bus,
+	}
+	if rules != nil {
+		cfg.RuleRepo = rules
+	}
+	if history != nil {
+		cfg.AssignmentRepo = history
+	}
+	svc := NewService(cfg).(*service)
+	for _, agentID := range agents {
+		svc.presence.connect(agentID, time.Now())
+	}
+	return svc, convRepo
+}
+
+func TestSetModeRoundRobin(t *testing.T) {
+	svc, convRepo := newRoutingService(nil, nil, nil, "agent-b", "agent-a", "agent-c")
+	ctx := context.Background()
+	owner := conversationDomain.UserContext{UserID: "owner"}
+	if _, _, err := svc.presence.set("agent-c", conversationDomain.PresenceAway, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, id := range []string{"conv_1", "conv_2", "conv_3"} {
+		convRepo.conversations[id] = &conversationDomain.Conversation{ID: id, UserID: "owner"}
+		conv, err := svc.SetMode(ctx, owner, id, conversationDomain.ModeHuman, "")
+		if err != nil {
+			t.Fatalf("SetMode failed: %v", err)
+		}
+		got = append(got, conv.AgentID)
+	}
+	if got[0] != "agent-a" || got[1] != "agent-b" || got[2] != "agent-a" {
+		t.Errorf("Expected online agents in turn, skipping the away one, got %v", got)
+	}
+}
+
+func TestSetModeByRule(t *testing.T) {
+	rules := newMockAssignmentRuleRepo(
+		conversationDomain.AssignmentRule{Name: "Billing", Tags: []string{"billing"}, AgentIDs: []string{"offline", "agent-b"}, Priority: 1},
+		conversationDomain.AssignmentRule{Name: "Nobody", Tags: []string{"billing"}, AgentIDs: []string{"offline"}},
+	)
+	history := &mockAssignmentRepo{}
+	bus := events.NewBus()
+	var published []events.ConversationAssigned
+	bus.Subscribe(func(ctx context.Context, event events.Event) {
+		published = append(published, event.(events.ConversationAssigned))
+	}, events.NameConversationAssigned)
+	svc, convRepo := newRoutingService(rules, history, bus, "agent-a", "agent-b")
+	ctx := context.Background()
+	admin := conversationDomain.UserContext{UserID: "admin", IsAdmin: true}
+	convRepo.conversations["conv_1"].Tags = []string{"billing"}
+
+	conv, err := svc.SetMode(ctx, admin, "conv_1", conversationDomain.ModeHuman, "")
+	if err != nil {
+		t.Fatalf("SetMode failed: %v", err)
+	}
+	if conv.AgentID != "agent-b" {
+		t.Errorf("Expected the billing rule's online agent, got %q", conv.AgentID)
+	}
+
+	if len(history.assignments) != 1 || history.assignments[0].Reason != conversationDomain.AssignmentRuleMatch || history.assignments[0].RuleID != "rule_1" || history.assignments[0].ActorID != "admin" {
+		t.Errorf("Expected the rule assignment recorded, got %+v", history.assignments)
+	}
+	if len(published) != 1 || published[0].AgentID != "agent-b" || published[0].Reason != "rule" {
+		t.Errorf("Expected the assignment published, got %+v", published)
+	}
+}
+
+func TestSetModeFallsBackToRequester(t *testing.T) {
+	history := &mockAssignmentRepo{}
+	svc, _ := newRoutingService(nil, history, nil)
+	ctx := context.Background()
+	admin := conversationDomain.UserContext{UserID: "admin", IsAdmin: true}
+
+	conv, err := svc.SetMode(ctx, admin, "conv_1", conversationDomain.ModeHuman, "")
+	if err != nil {
+		t.Fatalf("SetMode failed: %v", err)
+	}
+	if conv.AgentID != "admin" || history.assignments[0].Reason != conversationDomain.AssignmentFallback {
+		t.Errorf("Expected the requester with no agent online, got %q %+v", conv.AgentID, history.assignments)
+	}
+
+	// Handing back to the bot is recorded; doing it again is not.
+	for range 2 {
+		if _, err := svc.SetMode(ctx, admin, "conv_1", conversationDomain.ModeBot, ""); err != nil {
+			t.Fatalf("SetMode failed: %v", err)
+		}
+	}
+	if len(history.assignments) != 2 || history.assignments[1].Reason != conversationDomain.AssignmentReleased || history.assignments[1].PreviousAgentID != "admin" {
+		t.Errorf("Expected one release recorded, got %+v", history.assignments)
+	}
+}
+
+func TestReassign(t *testing.T) {
+	history := &mockAssignmentRepo{}
+	svc, _ := newRoutingService(nil, history, nil, "agent-a")
+	ctx := context.Background()
+	admin := conversationDomain.UserContext{UserID: "admin", IsAdmin: true}
+
+	if _, err := svc.Reassign(ctx, admin, "conv_1", ""); !errors.Is(err, ErrNotHandedOff) {
+		t.Errorf("Expected ErrNotHandedOff, got %v", err)
+	}
+	handed, err := svc.SetMode(ctx, admin, "conv_1", conversationDomain.ModeHuman, "")
+	if err != nil || handed.AgentID != "agent-a" {
+		t.Fatalf("Expected agent-a, got %+v (%v)", handed, err)
+	}
+	started := handed.SLA.StartedAt
+
+	// agent-a already has it and is the only one online.
+	if _, err := svc.Reassign(ctx, admin, "conv_1", ""); !errors.Is(err, ErrNoAgentAvailable) {
+		t.Errorf("Expected ErrNoAgentAvailable, got %v", err)
+	}
+	svc.presence.connect("agent-b", time.Now())
+	conv, err := svc.Reassign(ctx, admin, "conv_1", "")
+	if err != nil {
+		t.Fatalf("Reassign failed: %v", err)
+	}
+	if conv.AgentID != "agent-b" || conv.SLA.AgentID != "agent-b" || !conv.SLA.StartedAt.Equal(started) {
+		t.Errorf("Expected agent-b with the running SLA, got %q %+v", conv.AgentID, conv.SLA)
+	}
+	if _, err := svc.Reassign(ctx, admin, "conv_1", "agent-c"); err != nil {
+		t.Fatalf("Reassign failed: %v", err)
+	}
+	if _, err := svc.Reassign(ctx, conversationDomain.UserContext{UserID: "owner"}, "conv_1", "agent-a"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden, got %v", err)
+	}
+
+	assignments, err := svc.ListAssignments(ctx, conversationDomain.UserContext{UserID: "owner"}, "conv_1")
+	if err != nil {
+		t.Fatalf("ListAssignments failed: %v", err)
+	}
+	want := []struct {
+		agent, previous string
+		reason          conversationDomain.AssignmentReason
+	}{
+		{"agent-a", "", conversationDomain.AssignmentRoundRobin},
+		{"agent-b", "agent-a", conversationDomain.AssignmentRoundRobin},
+		{"agent-c", "agent-b", conversationDomain.AssignmentManual},
+	}
+	if len(assignments) != len(want) {
+		t.Fatalf("Expected %d assignments, got %+v", len(want), assignments)
+	}
+	for i, w := range want {
+		if a := assignments[i]; a.AgentID != w.agent || a.PreviousAgentID != w.previous || a.Reason != w.reason {
+			t.Errorf("Assignment %d: expected %+v, got %+v", i, w, a)
+		}
+	}
+}
+
+func TestAssignmentRules(t *testing.T) {
+	rules := newMockAssignmentRuleRepo()
+	svc, _ := newRoutingService(rules, nil, nil)
+	ctx := context.Background()
+	admin := conversationDomain.UserContext{UserID: "admin", IsAdmin: true}
+
+	rule := &conversationDomain.AssignmentRule{Name: " Billing ", Tags: []string{"Billing", "billing"}, AgentIDs: []string{"agent-a", " agent-a", ""}}
+	id, err := svc.CreateAssignmentRule(ctx, admin, rule)
+	if err != nil {
+		t.Fatalf("CreateAssignmentRule failed: %v", err)
+	}
+	if rule.Name != "Billing" || len(rule.Tags) != 1 || len(rule.AgentIDs) != 1 || rule.CreatedBy != "admin" {
+		t.Errorf("Expected a normalized rule, got %+v", rule)
+	}
+
+	tests := []struct {
+		name    string
+		userCtx conversationDomain.UserContext
+		rule    conversationDomain.AssignmentRule
+		want    error
+	}{
+		{"not admin", conversationDomain.UserContext{UserID: "owner"}, conversationDomain.AssignmentRule{Name: "x", Tags: []string{"vip"}, AgentIDs: []string{"a"}}, ErrForbidden},
+		{"no tags", admin, conversationDomain.AssignmentRule{Name: "x", AgentIDs: []string{"a"}}, ErrInvalidAssignmentRule},
+		{"no agents", admin, conversationDomain.AssignmentRule{Name: "x", Tags: []string{"vip"}}, ErrInvalidAssignmentRule},
+		{"unknown tag", admin, conversationDomain.AssignmentRule{Name: "x", Tags: []string{"sales"}, AgentIDs: []string{"a"}}, ErrInvalidAssignmentRule},
+	}
+	for _, tt := range tests {
+		if _, err := svc.CreateAssignmentRule(ctx, tt.userCtx, &tt.rule); !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	// Renaming a tag carries over to the rules routing by it.
+	if err := svc.UpdateTag(ctx, admin, &conversationDomain.Tag{ID: "tag_billing", Name: "payments"}); err != nil {
+		t.Fatalf("UpdateTag failed: %v", err)
+	}
+	if got := rules.rules[id].Tags; len(got) != 1 || got[0] != "payments" {
+		t.Errorf("Expected the rule's tag renamed, got %v", got)
+	}
+
+	if err := svc.DeleteAssignmentRule(ctx, admin, id); err != nil {
+		t.Fatalf("DeleteAssignmentRule failed: %v", err)
+	}
+	if err := svc.DeleteAssignmentRule(ctx, admin, id); !errors.Is(err, ErrAssignmentRuleNotFound) {
+		t.Errorf("Expected ErrAssignmentRuleNotFound, got %v", err)
+	}
+}
+
+func TestRoutingAcrossReplicas(t *testing.T) {
+	shared := cache.NewMemory()
+	t.Cleanup(shared.Stop)
+	convRepo := newMockConversationRepo()
+	replica := func() *service {
+		return NewService(ServiceConfig{ConvRepo: convRepo, MsgRepo: newMockMessageRepo(), Cache: shared}).(*service)
+	}
+	a, b := replica(), replica()
+	ctx := context.Background()
+	owner := conversationDomain.UserContext{UserID: "owner"}
+
+	// Each agent has the live stream open on a different replica.
+	_, leaveA := a.Subscribe(conversationDomain.UserContext{UserID: "agent-a", IsAdmin: true})
+	defer leaveA()
+	_, leaveB := b.Subscribe(conversationDomain.UserContext{UserID: "agent-b", IsAdmin: true})
+
+	presence, err := a.ListPresence(ctx, conversationDomain.UserContext{UserID: "agent-a", IsAdmin: true})
+	if err != nil || len(presence) != 2 {
+		t.Fatalf("Expected both agents listed on either replica, got %+v, %v", presence, err)
+	}
+
+	var got []string
+	for i, svc := range []*service{a, b, a, b} {
+		id := "conv_" + strconv.Itoa(i)
+		convRepo.conversations[id] = &conversationDomain.Conversation{ID: id, UserID: "owner"}
+		conv, err := svc.SetMode(ctx, owner, id, conversationDomain.ModeHuman, "")
+		if err != nil {
+			t.Fatalf("SetMode failed: %v", err)
+		}
+		got = append(got, conv.AgentID)
+	}
+	if got[0] != "agent-a" || got[1] != "agent-b" || got[2] != "agent-a" || got[3] != "agent-b" {
+		t.Errorf("Expected the replicas to share turns, got %v", got)
+	}
+
+	// agent-b goes away through the replica they are not connected to.
+	if _, err := a.SetPresence(ctx, conversationDomain.UserContext{UserID: "agent-b", IsAdmin: true}, conversationDomain.PresenceAway); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	convRepo.conversations["conv_away"] = &conversationDomain.Conversation{ID: "conv_away", UserID: "owner"}
+	if conv, _ := b.SetMode(ctx, owner, "conv_away", conversationDomain.ModeHuman, ""); conv.AgentID != "agent-a" {
+		t.Errorf("Expected the away agent skipped, got %q", conv.AgentID)
+	}
+
+	leaveB()
+	if agents := a.onlineAgents(ctx); len(agents) != 1 || agents[0] != "agent-a" {
+		t.Errorf("Expected agent-b gone once their stream closed, got %v", agents)
+	}
+
+	// agent-a also connects to replica b, then closes that stream only.
+	_, leaveAOnB := b.Subscribe(conversationDomain.UserContext{UserID: "agent-a", IsAdmin: true})
+	leaveAOnB()
+	if agents := b.onlineAgents(ctx); len(agents) != 1 || agents[0] != "agent-a" {
+		t.Errorf("Expected agent-a listed while still connected to replica a, got %v", agents)
+	}
+}
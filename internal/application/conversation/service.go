@@ -3,30 +3,96 @@ package conversation
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
 	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+	"github.com/elprogramadorgt/lucidRAG/pkg/cache"
 )
 
 var (
 	ErrConversationNotFound = errors.New("conversation not found")
 	ErrForbidden            = errors.New("access denied")
+	ErrMessageNotFound      = errors.New("message not found")
+	ErrInvalidNote          = errors.New("note content is required")
+	ErrInvalidVariables     = errors.New("invalid conversation variables")
 )
 
+// maxSearchMatches caps the conversations a text query matches, so a
+// common word does not turn into an unbounded ID list.
+const maxSearchMatches = 1000
+
 type service struct {
 	convRepo conversationDomain.ConversationRepository
 	msgRepo  conversationDomain.MessageRepository
+	readRepo conversationDomain.ReadMarkerRepository
+	noteRepo conversationDomain.NoteRepository
+	// cannedRepo is optional; without it there are no canned responses.
+	cannedRepo conversationDomain.CannedResponseRepository
+	// tagRepo and filterRepo are optional; without them there are no tags
+	// or saved filters.
+	tagRepo    conversationDomain.TagRepository
+	filterRepo conversationDomain.SavedFilterRepository
+	// ruleRepo and assignRepo are optional; without them handoffs only go
+	// round-robin and keep no assignment history.
+	ruleRepo   conversationDomain.AssignmentRuleRepository
+	assignRepo conversationDomain.AssignmentRepository
+	sla        conversationDomain.SLATargets
+	retention  conversationDomain.Retention
+	events     *broadcaster
+	presence   *presenceTracker
+	directory  *presenceDirectory
+	rotation   *rotation
+	bus        *events.Bus
 }
 
 type ServiceConfig struct {
 	ConvRepo conversationDomain.ConversationRepository
 	MsgRepo  conversationDomain.MessageRepository
+	ReadRepo conversationDomain.ReadMarkerRepository
+	NoteRepo conversationDomain.NoteRepository
+	// CannedRepo stores the canned responses agents reply with.
+	CannedRepo conversationDomain.CannedResponseRepository
+	// TagRepo stores the tags conversations are labelled with, FilterRepo
+	// the listing filters users saved.
+	TagRepo    conversationDomain.TagRepository
+	FilterRepo conversationDomain.SavedFilterRepository
+	// RuleRepo stores the rules handoffs are routed by, AssignmentRepo the
+	// history of who each conversation was assigned to.
+	RuleRepo       conversationDomain.AssignmentRuleRepository
+	AssignmentRepo conversationDomain.AssignmentRepository
+	// Events receives MessageReceived for every stored incoming message
+	// and ConversationAssigned for every assignment.
+	Events *events.Bus
+	// SLA is what conversations handed off to agents are tracked against.
+	SLA conversationDomain.SLATargets
+	// Retention is when conversation data is anonymized.
+	Retention conversationDomain.Retention
+	// Cache shares agent presence and round-robin turns between replicas.
+	// Without it each replica only routes to the agents connected to it.
+	Cache cache.Cache
 }
 
 func NewService(cfg ServiceConfig) conversationDomain.Service {
+	presence := newPresenceTracker()
 	return &service{
-		convRepo: cfg.ConvRepo,
-		msgRepo:  cfg.MsgRepo,
+		convRepo:   cfg.ConvRepo,
+		msgRepo:    cfg.MsgRepo,
+		readRepo:   cfg.ReadRepo,
+		noteRepo:   cfg.NoteRepo,
+		cannedRepo: cfg.CannedRepo,
+		tagRepo:    cfg.TagRepo,
+		filterRepo: cfg.FilterRepo,
+		ruleRepo:   cfg.RuleRepo,
+		assignRepo: cfg.AssignmentRepo,
+		sla:        cfg.SLA,
+		retention:  cfg.Retention,
+		events:     newBroadcaster(),
+		presence:   presence,
+		directory:  newPresenceDirectory(cfg.Cache, presence),
+		rotation:   newRotation(cfg.Cache),
+		bus:        cfg.Events,
 	}
 }
 
@@ -42,9 +108,12 @@ func (s *service) GetOrCreateConversation(ctx context.Context, userID, phoneNumb
 
 	newConv := &conversationDomain.Conversation{
 		UserID:       userID,
+		Channel:      conversationDomain.ChannelWhatsApp,
 		PhoneNumber:  phoneNumber,
 		ContactName:  contactName,
 		MessageCount: 0,
+		Onboarding:   conversationDomain.OnboardingPending,
+		State:        conversationDomain.StateOpen,
 	}
 
 	id, err := s.convRepo.Create(ctx, newConv)
@@ -56,29 +125,42 @@ func (s *service) GetOrCreateConversation(ctx context.Context, userID, phoneNumb
 	return newConv, nil
 }
 
-func (s *service) ListConversations(ctx context.Context, userCtx conversationDomain.UserContext, limit, offset int) ([]conversationDomain.Conversation, int64, error) {
-	if limit <= 0 {
-		limit = 20
+func (s *service) ListConversations(ctx context.Context, userCtx conversationDomain.UserContext, filter conversationDomain.ConversationFilter) ([]conversationDomain.Conversation, int64, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 20
 	}
-	if limit > 100 {
-		limit = 100
+	if filter.Limit > 100 {
+		filter.Limit = 100
 	}
-	if offset < 0 {
-		offset = 0
+	if filter.Offset < 0 {
+		filter.Offset = 0
 	}
+	filter.Query = strings.TrimSpace(filter.Query)
 
 	var convs []conversationDomain.Conversation
 	var total int64
 	var err error
 
-	if userCtx.IsAdmin {
-		convs, err = s.convRepo.List(ctx, limit, offset)
+	if filter.HasCriteria() {
+		filter.UserID = ""
+		if !userCtx.IsAdmin {
+			filter.UserID = userCtx.UserID
+		}
+		if filter.Query != "" {
+			filter.ConversationIDs, err = s.msgRepo.SearchConversationIDs(ctx, filter.UserID, filter.Query, maxSearchMatches)
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+		convs, total, err = s.convRepo.Search(ctx, filter)
+	} else if userCtx.IsAdmin {
+		convs, err = s.convRepo.List(ctx, filter.Limit, filter.Offset)
 		if err != nil {
 			return nil, 0, err
 		}
 		total, err = s.convRepo.Count(ctx)
 	} else {
-		convs, err = s.convRepo.ListByUser(ctx, userCtx.UserID, limit, offset)
+		convs, err = s.convRepo.ListByUser(ctx, userCtx.UserID, filter.Limit, filter.Offset)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -89,6 +171,13 @@ func (s *service) ListConversations(ctx context.Context, userCtx conversationDom
 		return nil, 0, err
 	}
 
+	if err := s.fillUnreadCounts(ctx, userCtx.UserID, convs); err != nil {
+		return nil, 0, err
+	}
+	for i := range convs {
+		defaultState(&convs[i])
+	}
+
 	return convs, total, nil
 }
 
@@ -105,10 +194,37 @@ func (s *service) GetConversation(ctx context.Context, userCtx conversationDomai
 		return nil, ErrForbidden
 	}
 
+	single := []conversationDomain.Conversation{*conv}
+	if err := s.fillUnreadCounts(ctx, userCtx.UserID, single); err != nil {
+		return nil, err
+	}
+	conv.UnreadCount = single[0].UnreadCount
+	defaultState(conv)
+
+	if s.noteRepo != nil {
+		notes, err := s.noteRepo.ListByConversation(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		conv.Notes = notes
+	}
+
 	return conv, nil
 }
 
 func (s *service) SaveIncomingMessage(ctx context.Context, phoneNumber, contactName, whatsappMsgID, content, msgType string) (*conversationDomain.Message, error) {
+	return s.saveIncoming(ctx, phoneNumber, contactName, whatsappMsgID, content, msgType, nil, nil)
+}
+
+func (s *service) SaveIncomingMedia(ctx context.Context, phoneNumber, contactName, whatsappMsgID, content, msgType string, media conversationDomain.Media) (*conversationDomain.Message, error) {
+	return s.saveIncoming(ctx, phoneNumber, contactName, whatsappMsgID, content, msgType, &media, nil)
+}
+
+func (s *service) SaveIncomingForm(ctx context.Context, phoneNumber, contactName, whatsappMsgID, content string, form map[string]any) (*conversationDomain.Message, error) {
+	return s.saveIncoming(ctx, phoneNumber, contactName, whatsappMsgID, content, "flow", nil, form)
+}
+
+func (s *service) saveIncoming(ctx context.Context, phoneNumber, contactName, whatsappMsgID, content, msgType string, media *conversationDomain.Media, form map[string]any) (*conversationDomain.Message, error) {
 	// For incoming WhatsApp messages, use empty userID (system-created conversations)
 	conv, err := s.GetOrCreateConversation(ctx, "", phoneNumber, contactName)
 	if err != nil {
@@ -121,6 +237,8 @@ func (s *service) SaveIncomingMessage(ctx context.Context, phoneNumber, contactN
 		Direction:      conversationDomain.DirectionIncoming,
 		Content:        content,
 		MessageType:    msgType,
+		Media:          media,
+		Form:           form,
 		Timestamp:      time.Now(),
 	}
 
@@ -132,6 +250,70 @@ func (s *service) SaveIncomingMessage(ctx context.Context, phoneNumber, contactN
 
 	_ = s.convRepo.UpdateLastMessage(ctx, conv.ID)
 	_ = s.convRepo.IncrementMessageCount(ctx, conv.ID)
+	_ = s.reopen(ctx, conv)
+	s.notifyMessage(ctx, msg)
+	received := events.MessageReceived{
+		MessageID:      msg.ID,
+		ConversationID: conv.ID,
+		Channel:        conv.Channel,
+		From:           phoneNumber,
+		Content:        content,
+		MessageType:    msgType,
+		WhatsAppMsgID:  whatsappMsgID,
+		Form:           form,
+		HumanMode:      conv.Mode == conversationDomain.ModeHuman,
+	}
+	if media != nil {
+		received.MediaDescription = media.Description
+	}
+	s.bus.Publish(ctx, received)
+
+	return msg, nil
+}
+
+func (s *service) SaveThreadMessage(ctx context.Context, in conversationDomain.ThreadMessage) (*conversationDomain.Message, error) {
+	conv, err := s.convRepo.GetByExternalID(ctx, in.Channel, in.ExternalID)
+	if err != nil {
+		return nil, err
+	}
+	if conv == nil {
+		conv = &conversationDomain.Conversation{
+			UserID:      in.UserID,
+			Channel:     in.Channel,
+			ExternalID:  in.ExternalID,
+			ContactName: in.ContactName,
+			State:       conversationDomain.StateOpen,
+		}
+		if conv.ID, err = s.convRepo.Create(ctx, conv); err != nil {
+			return nil, err
+		}
+	}
+
+	msg := &conversationDomain.Message{
+		ConversationID: conv.ID,
+		Direction:      conversationDomain.DirectionIncoming,
+		Content:        in.Content,
+		MessageType:    "text",
+		Timestamp:      time.Now(),
+	}
+	if msg.ID, err = s.msgRepo.Create(ctx, msg); err != nil {
+		return nil, err
+	}
+
+	_ = s.convRepo.UpdateLastMessage(ctx, conv.ID)
+	_ = s.convRepo.IncrementMessageCount(ctx, conv.ID)
+	_ = s.reopen(ctx, conv)
+	s.notifyMessage(ctx, msg)
+	s.bus.Publish(ctx, events.MessageReceived{
+		MessageID:      msg.ID,
+		ConversationID: conv.ID,
+		Channel:        conv.Channel,
+		ExternalID:     conv.ExternalID,
+		From:           in.From,
+		Content:        in.Content,
+		MessageType:    msg.MessageType,
+		HumanMode:      conv.Mode == conversationDomain.ModeHuman,
+	})
 
 	return msg, nil
 }
@@ -154,10 +336,48 @@ func (s *service) SaveOutgoingMessage(ctx context.Context, conversationID, conte
 
 	_ = s.convRepo.UpdateLastMessage(ctx, conversationID)
 	_ = s.convRepo.IncrementMessageCount(ctx, conversationID)
+	_ = s.recordFirstResponse(ctx, conversationID, msg.Timestamp)
+	s.notifyMessage(ctx, msg)
+	s.bus.Publish(ctx, events.MessageSent{
+		MessageID:      msg.ID,
+		ConversationID: conversationID,
+		Content:        content,
+		MessageType:    msg.MessageType,
+		FromBot:        ragAnswer != "",
+	})
 
 	return msg, nil
 }
 
+func (s *service) GetHistory(ctx context.Context, conversationID string, limit int) (*conversationDomain.History, error) {
+	conv, err := s.convRepo.GetByID(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if conv == nil {
+		return nil, ErrConversationNotFound
+	}
+
+	history := &conversationDomain.History{Summary: conv.Summary, Variables: conv.Variables}
+	if limit <= 0 {
+		return history, nil
+	}
+
+	recent, err := s.msgRepo.GetByConversationID(ctx, conversationID, limit, 0)
+	if err != nil {
+		return nil, err
+	}
+	// recent is newest first; keep what the summary does not cover, oldest
+	// first.
+	for i := len(recent) - 1; i >= 0; i-- {
+		if recent[i].Timestamp.After(conv.SummaryThrough) {
+			history.Messages = append(history.Messages, recent[i])
+		}
+	}
+
+	return history, nil
+}
+
 func (s *service) GetMessages(ctx context.Context, userCtx conversationDomain.UserContext, conversationID string, limit, offset int) ([]conversationDomain.Message, int64, error) {
 	conv, err := s.convRepo.GetByID(ctx, conversationID)
 	if err != nil {
@@ -193,3 +413,115 @@ func (s *service) GetMessages(ctx context.Context, userCtx conversationDomain.Us
 
 	return msgs, total, nil
 }
+
+func (s *service) MarkRead(ctx context.Context, userCtx conversationDomain.UserContext, conversationID string) error {
+	conv, err := s.convRepo.GetByID(ctx, conversationID)
+	if err != nil {
+		return err
+	}
+	if conv == nil {
+		return ErrConversationNotFound
+	}
+	if !userCtx.IsAdmin && conv.UserID != userCtx.UserID {
+		return ErrForbidden
+	}
+
+	if s.readRepo == nil {
+		return nil
+	}
+	return s.readRepo.MarkRead(ctx, userCtx.UserID, conversationID, time.Now())
+}
+
+// AddNote attaches an internal note to a conversation the user can access.
+// When MessageID is set the message must belong to the same conversation.
+func (s *service) AddNote(ctx context.Context, userCtx conversationDomain.UserContext, note *conversationDomain.Note) (*conversationDomain.Note, error) {
+	note.Content = strings.TrimSpace(note.Content)
+	if note.Content == "" || s.noteRepo == nil {
+		return nil, ErrInvalidNote
+	}
+
+	conv, err := s.convRepo.GetByID(ctx, note.ConversationID)
+	if err != nil {
+		return nil, err
+	}
+	if conv == nil {
+		return nil, ErrConversationNotFound
+	}
+	if !userCtx.IsAdmin && conv.UserID != userCtx.UserID {
+		return nil, ErrForbidden
+	}
+
+	if note.MessageID != "" {
+		msg, err := s.msgRepo.GetByID(ctx, note.MessageID)
+		if err != nil {
+			return nil, err
+		}
+		if msg == nil || msg.ConversationID != note.ConversationID {
+			return nil, ErrMessageNotFound
+		}
+	}
+
+	note.AuthorID = userCtx.UserID
+	id, err := s.noteRepo.Create(ctx, note)
+	if err != nil {
+		return nil, err
+	}
+	note.ID = id
+
+	return note, nil
+}
+
+// fillUnreadCounts sets UnreadCount on each conversation from the user's read
+// markers. Conversations never opened count every incoming message as unread.
+func (s *service) fillUnreadCounts(ctx context.Context, userID string, convs []conversationDomain.Conversation) error {
+	if s.readRepo == nil || len(convs) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(convs))
+	for i, c := range convs {
+		ids[i] = c.ID
+	}
+
+	markers, err := s.readRepo.GetByUser(ctx, userID, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range convs {
+		count, err := s.msgRepo.CountIncomingSince(ctx, convs[i].ID, markers[convs[i].ID])
+		if err != nil {
+			return err
+		}
+		convs[i].UnreadCount = count
+	}
+
+	return nil
+}
+
+// notifyMessage pushes the stored message and the refreshed conversation to
+// live subscribers. The conversation is only reloaded when someone listens.
+func (s *service) notifyMessage(ctx context.Context, msg *conversationDomain.Message) {
+	if !s.events.hasSubscribers() {
+		return
+	}
+
+	conv, err := s.convRepo.GetByID(ctx, msg.ConversationID)
+	if err != nil || conv == nil {
+		return
+	}
+
+	now := time.Now()
+	s.events.publish(conv.UserID, conversationDomain.Event{
+		Type:           conversationDomain.EventMessageCreated,
+		ConversationID: conv.ID,
+		Message:        msg,
+		Timestamp:      now,
+	})
+	s.events.publish(conv.UserID, conversationDomain.Event{
+		Type:           conversationDomain.EventConversationUpdated,
+		ConversationID: conv.ID,
+		Conversation:   conv,
+		Timestamp:      now,
+	})
+}
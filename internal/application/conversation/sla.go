@@ -0,0 +1,196 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+)
+
+var ErrInvalidMode = errors.New("invalid conversation mode")
+
+// defaultSLAReportDays is how far back SLA reports go without a start.
+const defaultSLAReportDays = 30
+
+func (s *service) SetMode(ctx context.Context, userCtx conversationDomain.UserContext, conversationID string, mode conversationDomain.Mode, agentID string) (*conversationDomain.Conversation, error) {
+	if mode != conversationDomain.ModeBot && mode != conversationDomain.ModeHuman {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidMode, mode)
+	}
+
+	conv, err := s.convRepo.GetByID(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if conv == nil {
+		return nil, ErrConversationNotFound
+	}
+	if !userCtx.IsAdmin && conv.UserID != userCtx.UserID {
+		return nil, ErrForbidden
+	}
+
+	if mode == conversationDomain.ModeBot {
+		if err := s.assign(ctx, userCtx, conv, mode, route{reason: conversationDomain.AssignmentReleased}); err != nil {
+			return nil, err
+		}
+		return conv, nil
+	}
+
+	to := route{agentID: agentID, reason: conversationDomain.AssignmentManual}
+	if agentID == "" {
+		if to, err = s.route(ctx, conv, ""); err != nil {
+			return nil, err
+		}
+		if to.agentID == "" {
+			to = route{agentID: userCtx.UserID, reason: conversationDomain.AssignmentFallback}
+		}
+	}
+	if err := s.assign(ctx, userCtx, conv, mode, to); err != nil {
+		return nil, err
+	}
+	return conv, nil
+}
+
+// assign puts conv in mode with the agent to routes it to, recording the
+// change in its assignment history.
+func (s *service) assign(ctx context.Context, userCtx conversationDomain.UserContext, conv *conversationDomain.Conversation, mode conversationDomain.Mode, to route) error {
+	previousMode, previousAgent := conv.Mode, conv.AgentID
+	// Conversations that were never handed off are already with the bot.
+	if previousMode == "" {
+		previousMode = conversationDomain.ModeBot
+	}
+
+	var sla *conversationDomain.SLA
+	if mode == conversationDomain.ModeHuman {
+		if conv.Mode == conversationDomain.ModeHuman && conv.SLA != nil {
+			// A reassignment moves the running SLA to the new agent.
+			reassigned := *conv.SLA
+			reassigned.AgentID = to.agentID
+			sla = &reassigned
+		} else {
+			sla = s.startSLA(to.agentID, time.Now())
+		}
+	}
+
+	if err := s.convRepo.SetMode(ctx, conv.ID, mode, to.agentID, sla); err != nil {
+		return err
+	}
+	conv.Mode = mode
+	conv.AgentID = to.agentID
+	if sla != nil {
+		conv.SLA = sla
+	}
+	defaultState(conv)
+	s.notifyConversation(conv)
+
+	if mode == previousMode && to.agentID == previousAgent {
+		return nil
+	}
+	return s.recordAssignment(ctx, conversationDomain.Assignment{
+		ConversationID:  conv.ID,
+		AgentID:         to.agentID,
+		PreviousAgentID: previousAgent,
+		Reason:          to.reason,
+		RuleID:          to.ruleID,
+		ActorID:         userCtx.UserID,
+	})
+}
+
+func (s *service) SLAReport(ctx context.Context, userCtx conversationDomain.UserContext, from, to time.Time) (*conversationDomain.SLAReport, error) {
+	if !userCtx.IsAdmin {
+		return nil, ErrForbidden
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -defaultSLAReportDays)
+	}
+
+	agents, err := s.convRepo.SLAByAgent(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for i := range agents {
+		if agents[i].Conversations > 0 {
+			agents[i].Compliance = 1 - float64(agents[i].Breached)/float64(agents[i].Conversations)
+		}
+	}
+
+	return &conversationDomain.SLAReport{
+		FirstResponseTargetSeconds: int64(s.sla.FirstResponse.Seconds()),
+		ResolutionTargetSeconds:    int64(s.sla.Resolution.Seconds()),
+		From:                       from,
+		To:                         to,
+		Agents:                     agents,
+	}, nil
+}
+
+// startSLA starts tracking a handoff to agentID at now against the current
+// targets.
+func (s *service) startSLA(agentID string, now time.Time) *conversationDomain.SLA {
+	sla := &conversationDomain.SLA{AgentID: agentID, StartedAt: now}
+	if s.sla.FirstResponse > 0 {
+		sla.FirstResponseDue = now.Add(s.sla.FirstResponse)
+	}
+	if s.sla.Resolution > 0 {
+		sla.ResolutionDue = now.Add(s.sla.Resolution)
+	}
+	return sla
+}
+
+// recordFirstResponse records the first reply to a handed-off conversation.
+func (s *service) recordFirstResponse(ctx context.Context, conversationID string, at time.Time) error {
+	conv, err := s.convRepo.GetByID(ctx, conversationID)
+	if err != nil || conv == nil {
+		return err
+	}
+	if conv.Mode != conversationDomain.ModeHuman || conv.SLA == nil || conv.SLA.FirstResponseAt != nil {
+		return nil
+	}
+
+	sla := *conv.SLA
+	sla.FirstResponseAt = &at
+	sla.FirstResponseBreached = sla.FirstResponseBreached || overdue(sla.FirstResponseDue, at)
+	return s.convRepo.UpdateSLA(ctx, conversationID, sla)
+}
+
+// recordResolution records that a handed-off conversation was resolved or
+// closed.
+func (s *service) recordResolution(ctx context.Context, conv *conversationDomain.Conversation, at time.Time) error {
+	if conv.Mode != conversationDomain.ModeHuman || conv.SLA == nil || conv.SLA.ResolvedAt != nil {
+		return nil
+	}
+
+	sla := *conv.SLA
+	sla.ResolvedAt = &at
+	sla.ResolutionBreached = sla.ResolutionBreached || overdue(sla.ResolutionDue, at)
+	if err := s.convRepo.UpdateSLA(ctx, conv.ID, sla); err != nil {
+		return err
+	}
+	conv.SLA = &sla
+	return nil
+}
+
+// overdue reports whether at is past due, when there is a due time.
+func overdue(due, at time.Time) bool {
+	return !due.IsZero() && at.After(due)
+}
+
+// SLABreachJob flags SLAs whose first reply or resolution is overdue, so
+// breaches show up before the agent gets to them. It is meant to run every
+// minute from the scheduler.
+type SLABreachJob struct {
+	repo conversationDomain.ConversationRepository
+}
+
+func NewSLABreachJob(repo conversationDomain.ConversationRepository) *SLABreachJob {
+	return &SLABreachJob{repo: repo}
+}
+
+// Run flags the SLAs overdue as of the current time.
+func (j *SLABreachJob) Run(ctx context.Context) error {
+	_, err := j.repo.FlagSLABreaches(ctx, time.Now())
+	return err
+}
@@ -0,0 +1,150 @@
+package conversation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/elprogramadorgt/lucidRAG/pkg/openai"
+)
+
+// ChatModel writes the summaries. *openai.Client satisfies it.
+type ChatModel interface {
+	CreateChatCompletion(ctx context.Context, messages []openai.ChatMessage, model string, opts *openai.CompletionOptions) (string, error)
+}
+
+const (
+	defaultHistoryWindow = 20
+	summaryTimeout       = time.Minute
+)
+
+const summaryPrompt = `You maintain the running summary of a customer conversation.
+Update the summary with the new messages. Keep what the assistant will need later:
+who the customer is, orders and products mentioned, preferences, open questions
+and anything promised. Write at most 200 words, in the language of the
+conversation. Reply with the summary only.`
+
+type SummarizerConfig struct {
+	ConvRepo  conversationDomain.ConversationRepository
+	MsgRepo   conversationDomain.MessageRepository
+	Model     ChatModel
+	ModelName string
+	// Window is how many messages reply prompts include verbatim. Once that
+	// many have piled up past the summary, the older half is folded in.
+	Window int
+	Log    *logger.Logger
+}
+
+// Summarizer keeps a rolling summary of every conversation so reply prompts
+// stay bounded however long a thread gets. It works off MessageReceived in
+// the background, one conversation at a time.
+type Summarizer struct {
+	convRepo  conversationDomain.ConversationRepository
+	msgRepo   conversationDomain.MessageRepository
+	model     ChatModel
+	modelName string
+	window    int
+	log       *logger.Logger
+	running   sync.Map
+}
+
+func NewSummarizer(cfg SummarizerConfig) *Summarizer {
+	window := cfg.Window
+	if window < 2 {
+		window = defaultHistoryWindow
+	}
+	return &Summarizer{
+		convRepo:  cfg.ConvRepo,
+		msgRepo:   cfg.MsgRepo,
+		model:     cfg.Model,
+		modelName: cfg.ModelName,
+		window:    window,
+		log:       cfg.Log.With("subscriber", "conversation_summarizer"),
+	}
+}
+
+// Subscribe registers the summarizer on bus.
+func (s *Summarizer) Subscribe(bus *events.Bus) {
+	bus.Subscribe(s.handle, events.NameMessageReceived)
+}
+
+func (s *Summarizer) handle(ctx context.Context, event events.Event) {
+	msg, ok := event.(events.MessageReceived)
+	if !ok {
+		return
+	}
+	// A run already under way will see this message or the next one will.
+	if _, busy := s.running.LoadOrStore(msg.ConversationID, true); busy {
+		return
+	}
+
+	go func() {
+		defer s.running.Delete(msg.ConversationID)
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), summaryTimeout)
+		defer cancel()
+		if err := s.Summarize(ctx, msg.ConversationID); err != nil {
+			s.log.ErrorContext(ctx, "failed to update conversation summary", "error", err, "conversation_id", msg.ConversationID)
+		}
+	}()
+}
+
+// Summarize folds the older half of the unsummarized messages into the
+// conversation's summary once they fill the window.
+func (s *Summarizer) Summarize(ctx context.Context, conversationID string) error {
+	conv, err := s.convRepo.GetByID(ctx, conversationID)
+	if err != nil {
+		return err
+	}
+	if conv == nil {
+		return ErrConversationNotFound
+	}
+
+	pending, err := s.msgRepo.ListAfter(ctx, conversationID, conv.SummaryThrough, s.window)
+	if err != nil {
+		return err
+	}
+	if len(pending) < s.window {
+		return nil
+	}
+	fold := pending[:len(pending)-s.window/2]
+
+	var b strings.Builder
+	if conv.Summary != "" {
+		b.WriteString("Current summary:\n" + conv.Summary + "\n\n")
+	}
+	b.WriteString("New messages:\n")
+	for _, m := range fold {
+		b.WriteString(TranscriptLine(m) + "\n")
+	}
+
+	summary, err := s.model.CreateChatCompletion(ctx, []openai.ChatMessage{
+		{Role: "system", Content: summaryPrompt},
+		{Role: "user", Content: b.String()},
+	}, s.modelName, &openai.CompletionOptions{MaxTokens: 400})
+	if err != nil {
+		return fmt.Errorf("failed to summarize: %w", err)
+	}
+
+	through := fold[len(fold)-1].Timestamp
+	if err := s.convRepo.UpdateSummary(ctx, conversationID, strings.TrimSpace(summary), through); err != nil {
+		return err
+	}
+
+	s.log.InfoContext(ctx, "conversation summary updated", "conversation_id", conversationID, "messages_folded", len(fold))
+	return nil
+}
+
+// TranscriptLine renders a message as one line of a conversation
+// transcript, including what an attached photo shows.
+func TranscriptLine(m conversationDomain.Message) string {
+	speaker := "Customer"
+	if m.Direction == conversationDomain.DirectionOutgoing {
+		speaker = "Assistant"
+	}
+	return speaker + ": " + m.PromptText()
+}
@@ -0,0 +1,97 @@
+package conversation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/elprogramadorgt/lucidRAG/pkg/openai"
+)
+
+type mockChatModel struct {
+	prompts []string
+	reply   string
+}
+
+func (m *mockChatModel) CreateChatCompletion(ctx context.Context, messages []openai.ChatMessage, model string, opts *openai.CompletionOptions) (string, error) {
+	m.prompts = append(m.prompts, messages[len(messages)-1].Content)
+	return m.reply, nil
+}
+
+func newSummarizerFixture(t *testing.T, messages int) (*Summarizer, *mockConversationRepo, *mockChatModel, []*conversationDomain.Message) {
+	t.Helper()
+	convRepo := newMockConversationRepo()
+	msgRepo := newMockMessageRepo()
+	convRepo.Create(context.Background(), &conversationDomain.Conversation{PhoneNumber: "1"})
+
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var created []*conversationDomain.Message
+	for i := 0; i < messages; i++ {
+		direction := conversationDomain.DirectionIncoming
+		if i%2 == 1 {
+			direction = conversationDomain.DirectionOutgoing
+		}
+		msg := &conversationDomain.Message{
+			ConversationID: "conv_1",
+			Direction:      direction,
+			Content:        fmt.Sprintf("message %d", i),
+			Timestamp:      base.Add(time.Duration(i) * time.Minute),
+		}
+		msgRepo.Create(context.Background(), msg)
+		created = append(created, msg)
+	}
+
+	model := &mockChatModel{reply: " Customer asked about order 42. "}
+	s := NewSummarizer(SummarizerConfig{
+		ConvRepo: convRepo, MsgRepo: msgRepo, Model: model, Window: 6,
+		Log: logger.New(logger.Options{Level: "error"}),
+	})
+	return s, convRepo, model, created
+}
+
+func TestSummarizeWaitsForFullWindow(t *testing.T) {
+	s, convRepo, model, _ := newSummarizerFixture(t, 5)
+
+	if err := s.Summarize(context.Background(), "conv_1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(model.prompts) != 0 || convRepo.conversations["conv_1"].Summary != "" {
+		t.Error("Expected no summary before the window fills")
+	}
+}
+
+func TestSummarizeFoldsOlderHalf(t *testing.T) {
+	s, convRepo, model, created := newSummarizerFixture(t, 6)
+
+	if err := s.Summarize(context.Background(), "conv_1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	conv := convRepo.conversations["conv_1"]
+	if conv.Summary != "Customer asked about order 42." {
+		t.Errorf("Expected trimmed summary, got %q", conv.Summary)
+	}
+	if !conv.SummaryThrough.Equal(created[2].Timestamp) {
+		t.Errorf("Expected summary through the third message, got %v", conv.SummaryThrough)
+	}
+	prompt := model.prompts[0]
+	if !strings.Contains(prompt, "Customer: message 0") || !strings.Contains(prompt, "Assistant: message 1") {
+		t.Errorf("Expected a speaker-labelled transcript, got %q", prompt)
+	}
+	if strings.Contains(prompt, "message 3") {
+		t.Errorf("Expected recent messages to stay out of the summary, got %q", prompt)
+	}
+
+	// The next run has only the three recent messages pending and must not
+	// fold again until the window fills.
+	if err := s.Summarize(context.Background(), "conv_1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(model.prompts) != 1 {
+		t.Errorf("Expected 1 summary request, got %d", len(model.prompts))
+	}
+}
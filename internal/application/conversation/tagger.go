@@ -0,0 +1,53 @@
+package conversation
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+)
+
+const tagTimeout = 5 * time.Second
+
+// Tagger puts the tags whose keywords an incoming message mentions on its
+// conversation, on every channel.
+type Tagger struct {
+	svc conversationDomain.Service
+	log *logger.Logger
+}
+
+func NewTagger(svc conversationDomain.Service, log *logger.Logger) *Tagger {
+	return &Tagger{svc: svc, log: log.With("subscriber", "conversation_tagger")}
+}
+
+// Subscribe registers the tagger on bus.
+func (t *Tagger) Subscribe(bus *events.Bus) {
+	bus.Subscribe(t.handle, events.NameMessageReceived)
+}
+
+func (t *Tagger) handle(ctx context.Context, event events.Event) {
+	msg, ok := event.(events.MessageReceived)
+	if !ok {
+		return
+	}
+	text := strings.TrimSpace(msg.Content + " " + msg.MediaDescription)
+	if text == "" {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tagTimeout)
+		defer cancel()
+		tags, err := t.svc.AutoTag(ctx, msg.ConversationID, text)
+		if err != nil {
+			t.log.ErrorContext(ctx, "failed to tag conversation", "error", err, "conversation_id", msg.ConversationID)
+			return
+		}
+		if len(tags) > 0 {
+			t.log.DebugContext(ctx, "conversation tagged", "conversation_id", msg.ConversationID, "tags", tags)
+		}
+	}()
+}
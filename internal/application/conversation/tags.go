@@ -0,0 +1,349 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+)
+
+var (
+	ErrTagNotFound         = errors.New("tag not found")
+	ErrInvalidTag          = errors.New("invalid tag")
+	ErrDuplicateTag        = errors.New("a tag with that name already exists")
+	ErrSavedFilterNotFound = errors.New("saved filter not found")
+	ErrInvalidSavedFilter  = errors.New("invalid saved filter")
+)
+
+const (
+	maxConversationTags = 20
+	maxTagKeywords      = 50
+	maxKeywordLength    = 60
+	maxSavedFilters     = 50
+	maxFilterName       = 60
+)
+
+var (
+	tagName  = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,39}$`)
+	tagColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+)
+
+func (s *service) ListTags(ctx context.Context, userCtx conversationDomain.UserContext) ([]conversationDomain.Tag, error) {
+	if s.tagRepo == nil {
+		return []conversationDomain.Tag{}, nil
+	}
+	return s.tagRepo.List(ctx)
+}
+
+func (s *service) CreateTag(ctx context.Context, userCtx conversationDomain.UserContext, tag *conversationDomain.Tag) (string, error) {
+	if !userCtx.IsAdmin {
+		return "", ErrForbidden
+	}
+	if s.tagRepo == nil {
+		return "", fmt.Errorf("%w: tags are not configured", ErrInvalidTag)
+	}
+	if err := normalizeTag(tag); err != nil {
+		return "", err
+	}
+
+	existing, err := s.tagRepo.GetByName(ctx, tag.Name)
+	if err != nil {
+		return "", err
+	}
+	if existing != nil {
+		return "", ErrDuplicateTag
+	}
+
+	tag.CreatedBy = userCtx.UserID
+	return s.tagRepo.Create(ctx, tag)
+}
+
+func (s *service) UpdateTag(ctx context.Context, userCtx conversationDomain.UserContext, tag *conversationDomain.Tag) error {
+	if !userCtx.IsAdmin {
+		return ErrForbidden
+	}
+	if s.tagRepo == nil {
+		return ErrTagNotFound
+	}
+	if err := normalizeTag(tag); err != nil {
+		return err
+	}
+
+	existing, err := s.tagRepo.GetByID(ctx, tag.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrTagNotFound
+	}
+	renamed := tag.Name != existing.Name
+	if renamed {
+		other, err := s.tagRepo.GetByName(ctx, tag.Name)
+		if err != nil {
+			return err
+		}
+		if other != nil {
+			return ErrDuplicateTag
+		}
+	}
+
+	tag.CreatedBy = existing.CreatedBy
+	tag.CreatedAt = existing.CreatedAt
+	if err := s.tagRepo.Update(ctx, tag); err != nil {
+		return err
+	}
+	if renamed {
+		return s.renameTag(ctx, existing.Name, tag.Name)
+	}
+	return nil
+}
+
+func (s *service) DeleteTag(ctx context.Context, userCtx conversationDomain.UserContext, id string) error {
+	if !userCtx.IsAdmin {
+		return ErrForbidden
+	}
+	if s.tagRepo == nil {
+		return ErrTagNotFound
+	}
+
+	existing, err := s.tagRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrTagNotFound
+	}
+	if err := s.tagRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	return s.renameTag(ctx, existing.Name, "")
+}
+
+// renameTag carries a renamed or deleted tag over to the conversations,
+// saved filters and assignment rules that use it.
+func (s *service) renameTag(ctx context.Context, from, to string) error {
+	if err := s.convRepo.RenameTag(ctx, from, to); err != nil {
+		return err
+	}
+	if s.filterRepo != nil {
+		if err := s.filterRepo.RenameTag(ctx, from, to); err != nil {
+			return err
+		}
+	}
+	if s.ruleRepo != nil {
+		return s.ruleRepo.RenameTag(ctx, from, to)
+	}
+	return nil
+}
+
+func (s *service) SetTags(ctx context.Context, userCtx conversationDomain.UserContext, conversationID string, tags []string) ([]string, error) {
+	if s.tagRepo == nil {
+		return nil, fmt.Errorf("%w: tags are not configured", ErrInvalidTag)
+	}
+	conv, err := s.convRepo.GetByID(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if conv == nil {
+		return nil, ErrConversationNotFound
+	}
+	if !userCtx.IsAdmin && conv.UserID != userCtx.UserID {
+		return nil, ErrForbidden
+	}
+
+	tags = normalizeTagNames(tags)
+	if len(tags) > maxConversationTags {
+		return nil, fmt.Errorf("%w: at most %d tags per conversation", ErrInvalidTag, maxConversationTags)
+	}
+	defined, err := s.tagRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range tags {
+		if !slices.ContainsFunc(defined, func(t conversationDomain.Tag) bool { return t.Name == name }) {
+			return nil, fmt.Errorf("%w: %q does not exist", ErrInvalidTag, name)
+		}
+	}
+
+	if err := s.convRepo.SetTags(ctx, conversationID, tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (s *service) AutoTag(ctx context.Context, conversationID, text string) ([]string, error) {
+	if s.tagRepo == nil || strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	tags, err := s.tagRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	text = strings.ToLower(text)
+	var matched []string
+	for _, tag := range tags {
+		if slices.ContainsFunc(tag.Keywords, func(keyword string) bool { return mentions(text, keyword) }) {
+			matched = append(matched, tag.Name)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, nil
+	}
+	if err := s.convRepo.AddTags(ctx, conversationID, matched); err != nil {
+		return nil, err
+	}
+	return matched, nil
+}
+
+// mentions reports whether text holds keyword as a whole word or phrase.
+// Both are lowercase.
+func mentions(text, keyword string) bool {
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], keyword)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(keyword)
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if !isWordRune(before) && !isWordRune(after) {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// normalizeTag checks tag and stores its name and keywords lowercase, the
+// keywords once each with their spacing collapsed.
+func normalizeTag(tag *conversationDomain.Tag) error {
+	tag.Name = strings.ToLower(strings.TrimSpace(tag.Name))
+	tag.Color = strings.TrimSpace(tag.Color)
+	if !tagName.MatchString(tag.Name) {
+		return fmt.Errorf("%w: name must be up to 40 lowercase letters, digits, dashes and underscores", ErrInvalidTag)
+	}
+	if tag.Color != "" && !tagColor.MatchString(tag.Color) {
+		return fmt.Errorf("%w: color must be #rrggbb", ErrInvalidTag)
+	}
+
+	var keywords []string
+	for _, keyword := range tag.Keywords {
+		keyword = strings.ToLower(strings.Join(strings.Fields(keyword), " "))
+		if keyword == "" || slices.Contains(keywords, keyword) {
+			continue
+		}
+		if utf8.RuneCountInString(keyword) > maxKeywordLength {
+			return fmt.Errorf("%w: keyword %q is longer than %d characters", ErrInvalidTag, keyword, maxKeywordLength)
+		}
+		keywords = append(keywords, keyword)
+	}
+	if len(keywords) > maxTagKeywords {
+		return fmt.Errorf("%w: at most %d keywords per tag", ErrInvalidTag, maxTagKeywords)
+	}
+	tag.Keywords = keywords
+	return nil
+}
+
+// normalizeTagNames lowercases names and drops blanks and repeats.
+func normalizeTagNames(names []string) []string {
+	normalized := []string{}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" && !slices.Contains(normalized, name) {
+			normalized = append(normalized, name)
+		}
+	}
+	return normalized
+}
+
+func (s *service) ListSavedFilters(ctx context.Context, userCtx conversationDomain.UserContext) ([]conversationDomain.SavedFilter, error) {
+	if s.filterRepo == nil {
+		return []conversationDomain.SavedFilter{}, nil
+	}
+	return s.filterRepo.ListByUser(ctx, userCtx.UserID)
+}
+
+// GetSavedFilter returns one of the user's saved filters; other users'
+// are not found.
+func (s *service) GetSavedFilter(ctx context.Context, userCtx conversationDomain.UserContext, id string) (*conversationDomain.SavedFilter, error) {
+	if s.filterRepo == nil {
+		return nil, ErrSavedFilterNotFound
+	}
+	filter, err := s.filterRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if filter == nil || filter.UserID != userCtx.UserID {
+		return nil, ErrSavedFilterNotFound
+	}
+	return filter, nil
+}
+
+func (s *service) CreateSavedFilter(ctx context.Context, userCtx conversationDomain.UserContext, filter *conversationDomain.SavedFilter) (string, error) {
+	if s.filterRepo == nil {
+		return "", fmt.Errorf("%w: saved filters are not configured", ErrInvalidSavedFilter)
+	}
+	if err := normalizeSavedFilter(filter); err != nil {
+		return "", err
+	}
+	existing, err := s.filterRepo.ListByUser(ctx, userCtx.UserID)
+	if err != nil {
+		return "", err
+	}
+	if len(existing) >= maxSavedFilters {
+		return "", fmt.Errorf("%w: at most %d saved filters per user", ErrInvalidSavedFilter, maxSavedFilters)
+	}
+
+	filter.UserID = userCtx.UserID
+	return s.filterRepo.Create(ctx, filter)
+}
+
+func (s *service) UpdateSavedFilter(ctx context.Context, userCtx conversationDomain.UserContext, filter *conversationDomain.SavedFilter) error {
+	existing, err := s.GetSavedFilter(ctx, userCtx, filter.ID)
+	if err != nil {
+		return err
+	}
+	if err := normalizeSavedFilter(filter); err != nil {
+		return err
+	}
+
+	filter.UserID = existing.UserID
+	filter.CreatedAt = existing.CreatedAt
+	return s.filterRepo.Update(ctx, filter)
+}
+
+func (s *service) DeleteSavedFilter(ctx context.Context, userCtx conversationDomain.UserContext, id string) error {
+	if _, err := s.GetSavedFilter(ctx, userCtx, id); err != nil {
+		return err
+	}
+	return s.filterRepo.Delete(ctx, id)
+}
+
+func normalizeSavedFilter(filter *conversationDomain.SavedFilter) error {
+	filter.Name = strings.TrimSpace(filter.Name)
+	filter.Query = strings.TrimSpace(filter.Query)
+	filter.Tags = normalizeTagNames(filter.Tags)
+	if filter.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidSavedFilter)
+	}
+	if utf8.RuneCountInString(filter.Name) > maxFilterName {
+		return fmt.Errorf("%w: name is longer than %d characters", ErrInvalidSavedFilter, maxFilterName)
+	}
+	if filter.State != "" && !filter.State.Valid() {
+		return fmt.Errorf("%w: state must be open, pending, resolved or closed", ErrInvalidSavedFilter)
+	}
+	if filter.Mode != "" && filter.Mode != conversationDomain.ModeBot && filter.Mode != conversationDomain.ModeHuman {
+		return fmt.Errorf("%w: mode must be bot or human", ErrInvalidSavedFilter)
+	}
+	return nil
+}
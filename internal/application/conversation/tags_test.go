@@ -0,0 +1,208 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+)
+
+func newTagService(tags *mockTagRepo, filters *mockSavedFilterRepo) (*service, *mockConversationRepo) {
+	convRepo := newMockConversationRepo()
+	convRepo.conversations["conv_1"] = &conversationDomain.Conversation{ID: "conv_1", UserID: "owner"}
+	svc := NewService(ServiceConfig{
+		ConvRepo:   convRepo,
+		MsgRepo:    newMockMessageRepo(),
+		TagRepo:    tags,
+		FilterRepo: filters,
+	}).(*service)
+	return svc, convRepo
+}
+
+func TestCreateTag(t *testing.T) {
+	svc, _ := newTagService(newMockTagRepo(), newMockSavedFilterRepo())
+	ctx := context.Background()
+	admin := conversationDomain.UserContext{UserID: "admin", IsAdmin: true}
+
+	tag := &conversationDomain.Tag{Name: " Billing ", Color: "#ff0000", Keywords: []string{"Refund", "credit   card", "refund", ""}}
+	if _, err := svc.CreateTag(ctx, admin, tag); err != nil {
+		t.Fatalf("CreateTag failed: %v", err)
+	}
+	if tag.Name != "billing" || !slices.Equal(tag.Keywords, []string{"refund", "credit card"}) || tag.CreatedBy != "admin" {
+		t.Errorf("Expected a normalized tag, got %+v", tag)
+	}
+
+	tests := []struct {
+		name    string
+		userCtx conversationDomain.UserContext
+		tag     conversationDomain.Tag
+		want    error
+	}{
+		{"not admin", conversationDomain.UserContext{UserID: "owner"}, conversationDomain.Tag{Name: "vip"}, ErrForbidden},
+		{"bad name", admin, conversationDomain.Tag{Name: "needs review!"}, ErrInvalidTag},
+		{"bad color", admin, conversationDomain.Tag{Name: "vip", Color: "red"}, ErrInvalidTag},
+		{"duplicate", admin, conversationDomain.Tag{Name: "BILLING"}, ErrDuplicateTag},
+	}
+	for _, tt := range tests {
+		if _, err := svc.CreateTag(ctx, tt.userCtx, &tt.tag); !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestSetTags(t *testing.T) {
+	svc, convRepo := newTagService(newMockTagRepo(conversationDomain.Tag{Name: "billing"}, conversationDomain.Tag{Name: "vip"}), nil)
+	ctx := context.Background()
+	owner := conversationDomain.UserContext{UserID: "owner"}
+
+	tags, err := svc.SetTags(ctx, owner, "conv_1", []string{"VIP", "billing", "vip"})
+	if err != nil {
+		t.Fatalf("SetTags failed: %v", err)
+	}
+	if !slices.Equal(tags, []string{"vip", "billing"}) || !slices.Equal(convRepo.conversations["conv_1"].Tags, tags) {
+		t.Errorf("Expected vip and billing, got %v", convRepo.conversations["conv_1"].Tags)
+	}
+
+	if _, err := svc.SetTags(ctx, owner, "conv_1", []string{"unknown"}); !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("Expected an undefined tag to be refused, got %v", err)
+	}
+	if _, err := svc.SetTags(ctx, conversationDomain.UserContext{UserID: "other"}, "conv_1", nil); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden, got %v", err)
+	}
+	if tags, err := svc.SetTags(ctx, owner, "conv_1", []string{}); err != nil || len(tags) != 0 {
+		t.Errorf("Expected the tags to be cleared, got %v (%v)", tags, err)
+	}
+}
+
+func TestRenameAndDeleteTag(t *testing.T) {
+	tags, filters := newMockTagRepo(conversationDomain.Tag{Name: "billing"}, conversationDomain.Tag{Name: "vip"}), newMockSavedFilterRepo()
+	svc, convRepo := newTagService(tags, filters)
+	ctx := context.Background()
+	admin := conversationDomain.UserContext{UserID: "admin", IsAdmin: true}
+	convRepo.conversations["conv_1"].Tags = []string{"billing", "vip"}
+	filterID, err := svc.CreateSavedFilter(ctx, admin, &conversationDomain.SavedFilter{Name: "Billing", Tags: []string{"billing"}})
+	if err != nil {
+		t.Fatalf("CreateSavedFilter failed: %v", err)
+	}
+
+	if err := svc.UpdateTag(ctx, admin, &conversationDomain.Tag{ID: "tag_billing", Name: "vip"}); !errors.Is(err, ErrDuplicateTag) {
+		t.Errorf("Expected ErrDuplicateTag, got %v", err)
+	}
+	if err := svc.UpdateTag(ctx, admin, &conversationDomain.Tag{ID: "tag_billing", Name: "payments"}); err != nil {
+		t.Fatalf("UpdateTag failed: %v", err)
+	}
+	if got := convRepo.conversations["conv_1"].Tags; !slices.Equal(got, []string{"payments", "vip"}) {
+		t.Errorf("Expected the conversation's tag renamed, got %v", got)
+	}
+	if got := filters.filters[filterID].Tags; !slices.Equal(got, []string{"payments"}) {
+		t.Errorf("Expected the saved filter's tag renamed, got %v", got)
+	}
+
+	if err := svc.DeleteTag(ctx, admin, "tag_vip"); err != nil {
+		t.Fatalf("DeleteTag failed: %v", err)
+	}
+	if got := convRepo.conversations["conv_1"].Tags; !slices.Equal(got, []string{"payments"}) {
+		t.Errorf("Expected the deleted tag removed, got %v", got)
+	}
+	if err := svc.DeleteTag(ctx, admin, "tag_vip"); !errors.Is(err, ErrTagNotFound) {
+		t.Errorf("Expected ErrTagNotFound, got %v", err)
+	}
+}
+
+func TestAutoTag(t *testing.T) {
+	svc, convRepo := newTagService(newMockTagRepo(
+		conversationDomain.Tag{Name: "billing", Keywords: []string{"refund", "credit card"}},
+		conversationDomain.Tag{Name: "cancellation", Keywords: []string{"cancel"}},
+		conversationDomain.Tag{Name: "vip"},
+	), nil)
+	ctx := context.Background()
+
+	matched, err := svc.AutoTag(ctx, "conv_1", "Can I get a REFUND? My Credit Card was charged twice")
+	if err != nil {
+		t.Fatalf("AutoTag failed: %v", err)
+	}
+	if !slices.Equal(matched, []string{"billing"}) {
+		t.Errorf("Expected billing, got %v", matched)
+	}
+
+	// "cancelled" is not the keyword "cancel"; tags already there stay once.
+	if _, err := svc.AutoTag(ctx, "conv_1", "my order was cancelled, I want a refund"); err != nil {
+		t.Fatalf("AutoTag failed: %v", err)
+	}
+	if got := convRepo.conversations["conv_1"].Tags; !slices.Equal(got, []string{"billing"}) {
+		t.Errorf("Expected only billing, got %v", got)
+	}
+}
+
+func TestMentions(t *testing.T) {
+	tests := []struct {
+		text, keyword string
+		want          bool
+	}{
+		{"i want a refund", "refund", true},
+		{"refund, please", "refund", true},
+		{"refunded already", "refund", false},
+		{"nonrefund", "refund", false},
+		{"prefund then refund", "refund", true},
+		{"pago con tarjeta de crédito", "tarjeta de crédito", true},
+		{"créditos", "crédito", false},
+		{"", "refund", false},
+	}
+	for _, tt := range tests {
+		if got := mentions(tt.text, tt.keyword); got != tt.want {
+			t.Errorf("mentions(%q, %q) = %v, want %v", tt.text, tt.keyword, got, tt.want)
+		}
+	}
+}
+
+func TestSavedFilters(t *testing.T) {
+	filters := newMockSavedFilterRepo()
+	svc, convRepo := newTagService(newMockTagRepo(), filters)
+	ctx := context.Background()
+	admin := conversationDomain.UserContext{UserID: "admin", IsAdmin: true}
+	convRepo.conversations["conv_2"] = &conversationDomain.Conversation{ID: "conv_2", UserID: "owner", Tags: []string{"vip"}}
+
+	if _, err := svc.CreateSavedFilter(ctx, admin, &conversationDomain.SavedFilter{Name: "Open", State: "archived"}); !errors.Is(err, ErrInvalidSavedFilter) {
+		t.Errorf("Expected an unknown state to be refused, got %v", err)
+	}
+	id, err := svc.CreateSavedFilter(ctx, admin, &conversationDomain.SavedFilter{Name: " VIPs ", Tags: []string{"VIP"}})
+	if err != nil {
+		t.Fatalf("CreateSavedFilter failed: %v", err)
+	}
+
+	saved, err := svc.GetSavedFilter(ctx, admin, id)
+	if err != nil || saved.Name != "VIPs" || saved.UserID != "admin" {
+		t.Fatalf("Expected the admin's saved filter, got %+v (%v)", saved, err)
+	}
+	if _, err := svc.GetSavedFilter(ctx, conversationDomain.UserContext{UserID: "other", IsAdmin: true}, id); !errors.Is(err, ErrSavedFilterNotFound) {
+		t.Errorf("Expected another user's filter not to be found, got %v", err)
+	}
+	if err := svc.DeleteSavedFilter(ctx, conversationDomain.UserContext{UserID: "other", IsAdmin: true}, id); !errors.Is(err, ErrSavedFilterNotFound) {
+		t.Errorf("Expected another user's filter not to be deleted, got %v", err)
+	}
+
+	var filter conversationDomain.ConversationFilter
+	saved.ApplyTo(&filter)
+	convs, total, err := svc.ListConversations(ctx, admin, filter)
+	if err != nil {
+		t.Fatalf("ListConversations failed: %v", err)
+	}
+	if total != 1 || convs[0].ID != "conv_2" {
+		t.Errorf("Expected only the VIP conversation, got %v", convs)
+	}
+}
+
+func TestSavedFilterApplyTo(t *testing.T) {
+	saved := conversationDomain.SavedFilter{Channel: "whatsapp", State: conversationDomain.StateOpen, Tags: []string{"vip"}, SLABreached: true}
+	filter := conversationDomain.ConversationFilter{State: conversationDomain.StatePending, Tags: []string{"billing", "vip"}}
+	saved.ApplyTo(&filter)
+
+	if filter.Channel != "whatsapp" || filter.State != conversationDomain.StatePending || !filter.SLABreached {
+		t.Errorf("Expected the saved filter under the request's criteria, got %+v", filter)
+	}
+	if !slices.Equal(filter.Tags, []string{"billing", "vip"}) {
+		t.Errorf("Expected the tags combined once each, got %v", filter.Tags)
+	}
+}
@@ -0,0 +1,59 @@
+package conversation
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+)
+
+const (
+	maxVariables      = 30
+	maxVariableLength = 200
+)
+
+var variableName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,39}$`)
+
+func (s *service) SetVariables(ctx context.Context, userCtx conversationDomain.UserContext, conversationID string, variables map[string]string) (map[string]string, error) {
+	conv, err := s.convRepo.GetByID(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if conv == nil {
+		return nil, ErrConversationNotFound
+	}
+	if !userCtx.IsAdmin && conv.UserID != userCtx.UserID {
+		return nil, ErrForbidden
+	}
+
+	merged := make(map[string]string, len(conv.Variables)+len(variables))
+	for k, v := range conv.Variables {
+		merged[k] = v
+	}
+	for k, v := range variables {
+		if !variableName.MatchString(k) {
+			return nil, fmt.Errorf("%w: %q must be lowercase letters, digits and underscores, starting with a letter", ErrInvalidVariables, k)
+		}
+		// Values end up in prompts; keep each on one line.
+		v = strings.Join(strings.Fields(v), " ")
+		if utf8.RuneCountInString(v) > maxVariableLength {
+			return nil, fmt.Errorf("%w: %q is longer than %d characters", ErrInvalidVariables, k, maxVariableLength)
+		}
+		if v == "" {
+			delete(merged, k)
+			continue
+		}
+		merged[k] = v
+	}
+	if len(merged) > maxVariables {
+		return nil, fmt.Errorf("%w: at most %d variables per conversation", ErrInvalidVariables, maxVariables)
+	}
+
+	if err := s.convRepo.UpdateVariables(ctx, conversationID, merged); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
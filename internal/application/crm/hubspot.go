@@ -0,0 +1,67 @@
+package crm
+
+import (
+	"context"
+	"maps"
+	"net/http"
+
+	crmDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/crm"
+)
+
+// hubspot syncs contacts through the HubSpot CRM v3 API with a private app
+// token.
+type hubspot struct {
+	baseURL      string
+	token        string
+	httpClient   *http.Client
+	fields       []string
+	summaryField string
+}
+
+type hubspotRecord struct {
+	ID         string         `json:"id"`
+	Properties map[string]any `json:"properties"`
+}
+
+func (h *hubspot) SyncContact(ctx context.Context, contact crmDomain.Contact) (map[string]string, error) {
+	search := map[string]any{
+		"filterGroups": []any{map[string]any{
+			"filters": []any{map[string]any{"propertyName": "phone", "operator": "EQ", "value": contact.Phone}},
+		}},
+		"properties": h.fields,
+		"limit":      1,
+	}
+	var found struct {
+		Results []hubspotRecord `json:"results"`
+	}
+	if err := doJSON(ctx, h.httpClient, http.MethodPost, h.baseURL+"/crm/v3/objects/contacts/search", h.token, search, &found); err != nil {
+		return nil, err
+	}
+
+	properties := maps.Clone(contact.Fields)
+	if properties == nil {
+		properties = map[string]string{}
+	}
+	if h.summaryField != "" && contact.Summary != "" {
+		properties[h.summaryField] = contact.Summary
+	}
+
+	if len(found.Results) == 0 {
+		properties["phone"] = contact.Phone
+		if contact.Name != "" {
+			properties["firstname"] = contact.Name
+		}
+		var created hubspotRecord
+		err := doJSON(ctx, h.httpClient, http.MethodPost, h.baseURL+"/crm/v3/objects/contacts", h.token, map[string]any{"properties": properties}, &created)
+		return stringFields(created.Properties, h.fields), err
+	}
+
+	record := found.Results[0]
+	if len(properties) > 0 {
+		url := h.baseURL + "/crm/v3/objects/contacts/" + record.ID
+		if err := doJSON(ctx, h.httpClient, http.MethodPatch, url, h.token, map[string]any{"properties": properties}, nil); err != nil {
+			return nil, err
+		}
+	}
+	return stringFields(record.Properties, h.fields), nil
+}
@@ -0,0 +1,114 @@
+package crm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"strings"
+
+	crmDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/crm"
+)
+
+const salesforceAPI = "/services/data/v59.0"
+
+// salesforce syncs Contact records through the Salesforce REST API. It
+// authenticates with the client credentials flow on first use.
+type salesforce struct {
+	instanceURL  string
+	clientID     string
+	clientSecret string
+	httpClient   *http.Client
+	fields       []string
+	summaryField string
+
+	token string
+}
+
+func (s *salesforce) SyncContact(ctx context.Context, contact crmDomain.Contact) (map[string]string, error) {
+	if err := s.authenticate(ctx); err != nil {
+		return nil, err
+	}
+
+	// Field names are validated when the connection is saved; only the
+	// phone number needs escaping.
+	soql := fmt.Sprintf("SELECT %s FROM Contact WHERE Phone = '%s' LIMIT 1",
+		strings.Join(append([]string{"Id"}, s.fields...), ", "), soqlEscape(contact.Phone))
+	var found struct {
+		Records []map[string]any `json:"records"`
+	}
+	if err := doJSON(ctx, s.httpClient, http.MethodGet, s.instanceURL+salesforceAPI+"/query?q="+url.QueryEscape(soql), s.token, nil, &found); err != nil {
+		return nil, err
+	}
+
+	fields := maps.Clone(contact.Fields)
+	if fields == nil {
+		fields = map[string]string{}
+	}
+	if s.summaryField != "" && contact.Summary != "" {
+		fields[s.summaryField] = contact.Summary
+	}
+
+	if len(found.Records) == 0 {
+		fields["Phone"] = contact.Phone
+		// LastName is required on Contact.
+		fields["LastName"] = contact.Name
+		if fields["LastName"] == "" {
+			fields["LastName"] = contact.Phone
+		}
+		return nil, doJSON(ctx, s.httpClient, http.MethodPost, s.instanceURL+salesforceAPI+"/sobjects/Contact", s.token, fields, nil)
+	}
+
+	record := found.Records[0]
+	if len(fields) > 0 {
+		id, _ := record["Id"].(string)
+		if err := doJSON(ctx, s.httpClient, http.MethodPatch, s.instanceURL+salesforceAPI+"/sobjects/Contact/"+url.PathEscape(id), s.token, fields, nil); err != nil {
+			return nil, err
+		}
+	}
+	return stringFields(record, s.fields), nil
+}
+
+func (s *salesforce) authenticate(ctx context.Context) error {
+	if s.token != "" {
+		return nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {s.clientID},
+		"client_secret": {s.clientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.instanceURL+"/services/oauth2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("salesforce authentication returned status %d", resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return err
+	}
+	if token.AccessToken == "" {
+		return fmt.Errorf("salesforce authentication returned no access token")
+	}
+	s.token = token.AccessToken
+	return nil
+}
+
+func soqlEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s)
+}
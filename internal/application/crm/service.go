@@ -0,0 +1,193 @@
+package crm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	crmDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/crm"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/elprogramadorgt/lucidRAG/pkg/secretbox"
+)
+
+var (
+	ErrConnectionNotFound = errors.New("crm connection not found")
+	ErrInvalidConnection  = errors.New("invalid crm connection")
+	ErrNoEncryptionKey    = errors.New("crm credentials cannot be stored without CRM_ENCRYPTION_KEY")
+)
+
+const (
+	maxAttributes  = 30
+	defaultTimeout = 10 * time.Second
+)
+
+var (
+	fieldName    = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,99}$`)
+	variableName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,39}$`)
+)
+
+type service struct {
+	repo       crmDomain.Repository
+	convRepo   conversationDomain.ConversationRepository
+	box        *secretbox.Box
+	httpClient *http.Client
+	log        *logger.Logger
+
+	// hubspotURL is the HubSpot API root, replaced in tests.
+	hubspotURL string
+}
+
+type ServiceConfig struct {
+	Repo     crmDomain.Repository
+	ConvRepo conversationDomain.ConversationRepository
+	// Box encrypts credentials. Without it connections cannot be saved or
+	// synced.
+	Box        *secretbox.Box
+	HTTPClient *http.Client
+	Log        *logger.Logger
+}
+
+func NewService(cfg ServiceConfig) crmDomain.Service {
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+	return &service{
+		repo:       cfg.Repo,
+		convRepo:   cfg.ConvRepo,
+		box:        cfg.Box,
+		httpClient: httpClient,
+		log:        cfg.Log,
+		hubspotURL: "https://api.hubapi.com",
+	}
+}
+
+func (s *service) ListConnections(ctx context.Context) ([]crmDomain.Connection, error) {
+	conns, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range conns {
+		conns[i].HasCredentials = conns[i].SealedCredentials != ""
+	}
+	return conns, nil
+}
+
+// SaveConnection creates or replaces a CRM connection. Empty credentials keep
+// the stored ones, since credentials are never sent back to clients.
+func (s *service) SaveConnection(ctx context.Context, adminID string, conn *crmDomain.Connection) error {
+	if s.box == nil {
+		return ErrNoEncryptionKey
+	}
+	if err := validate(conn); err != nil {
+		return err
+	}
+
+	existing, err := s.repo.Get(ctx, conn.Provider)
+	if err != nil {
+		return err
+	}
+
+	if conn.Credentials == (crmDomain.Credentials{}) {
+		if existing == nil || existing.SealedCredentials == "" {
+			return fmt.Errorf("%w: credentials are required", ErrInvalidConnection)
+		}
+		conn.SealedCredentials = existing.SealedCredentials
+	} else {
+		if err := validateCredentials(conn.Provider, conn.Credentials); err != nil {
+			return err
+		}
+		data, err := json.Marshal(conn.Credentials)
+		if err != nil {
+			return err
+		}
+		if conn.SealedCredentials, err = s.box.Seal(string(data)); err != nil {
+			return err
+		}
+	}
+	conn.Credentials = crmDomain.Credentials{}
+
+	if existing != nil {
+		conn.LastSyncAt = existing.LastSyncAt
+		conn.LastError = existing.LastError
+	}
+	conn.UpdatedBy = adminID
+	return s.repo.Upsert(ctx, conn)
+}
+
+func (s *service) DeleteConnection(ctx context.Context, adminID string, provider crmDomain.Provider) error {
+	existing, err := s.repo.Get(ctx, provider)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrConnectionNotFound
+	}
+	return s.repo.Delete(ctx, provider)
+}
+
+func validate(conn *crmDomain.Connection) error {
+	if !slices.Contains(crmDomain.Providers, conn.Provider) {
+		return fmt.Errorf("%w: provider must be hubspot or salesforce", ErrInvalidConnection)
+	}
+
+	switch conn.Provider {
+	case crmDomain.ProviderSalesforce:
+		conn.InstanceURL = strings.TrimRight(strings.TrimSpace(conn.InstanceURL), "/")
+		u, err := url.Parse(conn.InstanceURL)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("%w: instance_url must be an https URL", ErrInvalidConnection)
+		}
+	default:
+		conn.InstanceURL = ""
+	}
+
+	if conn.SummaryField != "" && !fieldName.MatchString(conn.SummaryField) {
+		return fmt.Errorf("%w: summary_field is not a valid field name", ErrInvalidConnection)
+	}
+	if len(conn.Attributes) > maxAttributes {
+		return fmt.Errorf("%w: at most %d attributes", ErrInvalidConnection, maxAttributes)
+	}
+	if len(conn.Exports) > maxAttributes {
+		return fmt.Errorf("%w: at most %d exports", ErrInvalidConnection, maxAttributes)
+	}
+	if err := validateMapping(conn.Attributes); err != nil {
+		return err
+	}
+	return validateMapping(conn.Exports)
+}
+
+// validateMapping checks a mapping of contact fields to variable names.
+func validateMapping(mapping map[string]string) error {
+	for field, variable := range mapping {
+		if !fieldName.MatchString(field) {
+			return fmt.Errorf("%w: %q is not a valid field name", ErrInvalidConnection, field)
+		}
+		if !variableName.MatchString(variable) {
+			return fmt.Errorf("%w: variable %q must be lowercase letters, digits and underscores, starting with a letter", ErrInvalidConnection, variable)
+		}
+	}
+	return nil
+}
+
+func validateCredentials(provider crmDomain.Provider, creds crmDomain.Credentials) error {
+	switch provider {
+	case crmDomain.ProviderHubSpot:
+		if creds.Token == "" {
+			return fmt.Errorf("%w: hubspot needs a private app token", ErrInvalidConnection)
+		}
+	case crmDomain.ProviderSalesforce:
+		if creds.ClientID == "" || creds.ClientSecret == "" {
+			return fmt.Errorf("%w: salesforce needs a client_id and client_secret", ErrInvalidConnection)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package crm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	crmDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/crm"
+	"github.com/elprogramadorgt/lucidRAG/pkg/secretbox"
+)
+
+type mockRepo struct {
+	conns map[crmDomain.Provider]*crmDomain.Connection
+}
+
+func newMockRepo() *mockRepo {
+	return &mockRepo{conns: map[crmDomain.Provider]*crmDomain.Connection{}}
+}
+
+func (m *mockRepo) Get(ctx context.Context, provider crmDomain.Provider) (*crmDomain.Connection, error) {
+	if conn, ok := m.conns[provider]; ok {
+		c := *conn
+		return &c, nil
+	}
+	return nil, nil
+}
+
+func (m *mockRepo) List(ctx context.Context) ([]crmDomain.Connection, error) {
+	var conns []crmDomain.Connection
+	for _, conn := range m.conns {
+		conns = append(conns, *conn)
+	}
+	return conns, nil
+}
+
+func (m *mockRepo) Upsert(ctx context.Context, conn *crmDomain.Connection) error {
+	c := *conn
+	m.conns[conn.Provider] = &c
+	return nil
+}
+
+func (m *mockRepo) Delete(ctx context.Context, provider crmDomain.Provider) error {
+	delete(m.conns, provider)
+	return nil
+}
+
+func (m *mockRepo) RecordSync(ctx context.Context, provider crmDomain.Provider, at time.Time, syncErr string) error {
+	conn := m.conns[provider]
+	conn.LastError = syncErr
+	if syncErr == "" {
+		conn.LastSyncAt = &at
+	}
+	return nil
+}
+
+// mockConvRepo implements the conversation repository methods sync uses.
+type mockConvRepo struct {
+	conversationDomain.ConversationRepository
+	convs []conversationDomain.Conversation
+}
+
+func (m *mockConvRepo) Search(ctx context.Context, filter conversationDomain.ConversationFilter) ([]conversationDomain.Conversation, int64, error) {
+	var convs []conversationDomain.Conversation
+	for _, conv := range m.convs {
+		if conv.LastMessageAt.Before(filter.StartTime) {
+			continue
+		}
+		convs = append(convs, conv)
+	}
+	return convs, int64(len(convs)), nil
+}
+
+func (m *mockConvRepo) UpdateVariables(ctx context.Context, id string, variables map[string]string) error {
+	for i := range m.convs {
+		if m.convs[i].ID == id {
+			m.convs[i].Variables = variables
+		}
+	}
+	return nil
+}
+
+func newTestService(t *testing.T, repo *mockRepo, convRepo *mockConvRepo) *service {
+	t.Helper()
+	box, err := secretbox.New("test-key")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return NewService(ServiceConfig{Repo: repo, ConvRepo: convRepo, Box: box}).(*service)
+}
+
+func TestSaveConnectionEncryptsCredentials(t *testing.T) {
+	repo := newMockRepo()
+	svc := newTestService(t, repo, &mockConvRepo{})
+	ctx := context.Background()
+
+	err := svc.SaveConnection(ctx, "admin-1", &crmDomain.Connection{
+		Provider:    crmDomain.ProviderHubSpot,
+		IsActive:    true,
+		Credentials: crmDomain.Credentials{Token: "pat-secret"},
+		Attributes:  map[string]string{"plan_tier": "plan"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	stored := repo.conns[crmDomain.ProviderHubSpot]
+	if stored.SealedCredentials == "" || strings.Contains(stored.SealedCredentials, "pat-secret") {
+		t.Errorf("Expected encrypted credentials, got %q", stored.SealedCredentials)
+	}
+	if stored.Credentials.Token != "" {
+		t.Error("Expected plaintext credentials not to be stored")
+	}
+
+	// Saving without credentials keeps the stored ones.
+	sealed := stored.SealedCredentials
+	if err := svc.SaveConnection(ctx, "admin-1", &crmDomain.Connection{Provider: crmDomain.ProviderHubSpot}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if repo.conns[crmDomain.ProviderHubSpot].SealedCredentials != sealed {
+		t.Error("Expected stored credentials to be kept")
+	}
+
+	conns, _ := svc.ListConnections(ctx)
+	if len(conns) != 1 || !conns[0].HasCredentials {
+		t.Errorf("Expected one connection with credentials, got %+v", conns)
+	}
+}
+
+func TestSaveConnectionValidation(t *testing.T) {
+	svc := newTestService(t, newMockRepo(), &mockConvRepo{})
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		conn crmDomain.Connection
+	}{
+		{"unknown provider", crmDomain.Connection{Provider: "pipedrive", Credentials: crmDomain.Credentials{Token: "x"}}},
+		{"no credentials", crmDomain.Connection{Provider: crmDomain.ProviderHubSpot}},
+		{"salesforce without secret", crmDomain.Connection{Provider: crmDomain.ProviderSalesforce, InstanceURL: "https://acme.my.salesforce.com", Credentials: crmDomain.Credentials{ClientID: "id"}}},
+		{"salesforce over http", crmDomain.Connection{Provider: crmDomain.ProviderSalesforce, InstanceURL: "http://acme.my.salesforce.com", Credentials: crmDomain.Credentials{ClientID: "id", ClientSecret: "s"}}},
+		{"bad field", crmDomain.Connection{Provider: crmDomain.ProviderHubSpot, Credentials: crmDomain.Credentials{Token: "x"}, Attributes: map[string]string{"plan' OR": "plan"}}},
+		{"bad variable", crmDomain.Connection{Provider: crmDomain.ProviderHubSpot, Credentials: crmDomain.Credentials{Token: "x"}, Attributes: map[string]string{"plan": "Plan"}}},
+		{"bad export", crmDomain.Connection{Provider: crmDomain.ProviderHubSpot, Credentials: crmDomain.Credentials{Token: "x"}, Exports: map[string]string{"Budget__c": "Lead Budget"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := svc.SaveConnection(ctx, "admin-1", &tt.conn); !errors.Is(err, ErrInvalidConnection) {
+				t.Errorf("Expected ErrInvalidConnection, got %v", err)
+			}
+		})
+	}
+
+	noKey := NewService(ServiceConfig{Repo: newMockRepo()})
+	err := noKey.SaveConnection(ctx, "admin-1", &crmDomain.Connection{Provider: crmDomain.ProviderHubSpot, Credentials: crmDomain.Credentials{Token: "x"}})
+	if !errors.Is(err, ErrNoEncryptionKey) {
+		t.Errorf("Expected ErrNoEncryptionKey, got %v", err)
+	}
+}
+
+func TestDeleteConnectionNotFound(t *testing.T) {
+	svc := newTestService(t, newMockRepo(), &mockConvRepo{})
+
+	if err := svc.DeleteConnection(context.Background(), "admin-1", crmDomain.ProviderHubSpot); !errors.Is(err, ErrConnectionNotFound) {
+		t.Errorf("Expected ErrConnectionNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,234 @@
+package crm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	crmDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/crm"
+)
+
+const (
+	syncPageSize      = 100
+	maxVariableLength = 200
+	maxErrorBody      = 1 << 10
+)
+
+// contactClient pushes a contact to a CRM and returns the requested fields of
+// the matching record.
+type contactClient interface {
+	SyncContact(ctx context.Context, contact crmDomain.Contact) (map[string]string, error)
+}
+
+func (s *service) Sync(ctx context.Context) error {
+	if s.box == nil {
+		return nil
+	}
+	conns, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, conn := range conns {
+		if !conn.IsActive {
+			continue
+		}
+		started := time.Now()
+		syncErr := s.syncConnection(ctx, conn)
+
+		message := ""
+		if syncErr != nil {
+			message = syncErr.Error()
+			errs = append(errs, fmt.Errorf("%s: %w", conn.Provider, syncErr))
+		}
+		if err := s.repo.RecordSync(ctx, conn.Provider, started, message); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// syncConnection pushes every conversation with messages since the last
+// successful sync. A failed contact does not stop the others, but fails the
+// sync so the same window is retried.
+func (s *service) syncConnection(ctx context.Context, conn crmDomain.Connection) error {
+	client, err := s.client(conn)
+	if err != nil {
+		return err
+	}
+
+	filter := conversationDomain.ConversationFilter{Limit: syncPageSize}
+	if conn.LastSyncAt != nil {
+		filter.StartTime = *conn.LastSyncAt
+	}
+
+	var synced, failed int
+	var firstErr error
+	for {
+		convs, _, err := s.convRepo.Search(ctx, filter)
+		if err != nil {
+			return err
+		}
+		for _, conv := range convs {
+			if err := s.syncConversation(ctx, client, conn, conv); err != nil {
+				failed++
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			synced++
+		}
+		if len(convs) < syncPageSize {
+			break
+		}
+		filter.Offset += syncPageSize
+	}
+
+	if s.log != nil {
+		s.log.InfoContext(ctx, "crm sync finished", "provider", conn.Provider, "synced", synced, "failed", failed)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d contacts failed: %w", failed, synced+failed, firstErr)
+	}
+	return nil
+}
+
+func (s *service) syncConversation(ctx context.Context, client contactClient, conn crmDomain.Connection, conv conversationDomain.Conversation) error {
+	if conv.PhoneNumber == "" {
+		return nil
+	}
+	contact := crmDomain.Contact{
+		Phone:   conv.PhoneNumber,
+		Name:    conv.ContactName,
+		Summary: conv.Summary,
+	}
+	for field, variable := range conn.Exports {
+		if value := conv.Variables[variable]; value != "" {
+			if contact.Fields == nil {
+				contact.Fields = map[string]string{}
+			}
+			contact.Fields[field] = value
+		}
+	}
+	fields, err := client.SyncContact(ctx, contact)
+	if err != nil {
+		return err
+	}
+
+	variables := maps.Clone(conv.Variables)
+	if variables == nil {
+		variables = map[string]string{}
+	}
+	for field, variable := range conn.Attributes {
+		value := variableValue(fields[field])
+		if value == "" {
+			continue
+		}
+		variables[variable] = value
+	}
+	if maps.Equal(variables, conv.Variables) {
+		return nil
+	}
+	return s.convRepo.UpdateVariables(ctx, conv.ID, variables)
+}
+
+// client opens the connection's credentials and builds its API client.
+func (s *service) client(conn crmDomain.Connection) (contactClient, error) {
+	data, err := s.box.Open(conn.SealedCredentials)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt credentials: %w", err)
+	}
+	var creds crmDomain.Credentials
+	if err := json.Unmarshal([]byte(data), &creds); err != nil {
+		return nil, fmt.Errorf("cannot decode credentials: %w", err)
+	}
+
+	fields := slices.Sorted(maps.Keys(conn.Attributes))
+	switch conn.Provider {
+	case crmDomain.ProviderHubSpot:
+		return &hubspot{
+			baseURL: s.hubspotURL, token: creds.Token, httpClient: s.httpClient,
+			fields: fields, summaryField: conn.SummaryField,
+		}, nil
+	case crmDomain.ProviderSalesforce:
+		return &salesforce{
+			instanceURL: conn.InstanceURL, clientID: creds.ClientID, clientSecret: creds.ClientSecret,
+			httpClient: s.httpClient, fields: fields, summaryField: conn.SummaryField,
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported provider %q", conn.Provider)
+}
+
+// variableValue makes a CRM field fit the rules conversation variables follow:
+// one line of at most maxVariableLength characters.
+func variableValue(value string) string {
+	value = strings.Join(strings.Fields(value), " ")
+	if utf8.RuneCountInString(value) > maxVariableLength {
+		value = string([]rune(value)[:maxVariableLength])
+	}
+	return value
+}
+
+// doJSON sends in as JSON, when set, and decodes the response into out, when
+// set.
+func doJSON(ctx context.Context, client *http.Client, method, url, token string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("%s %s returned status %d: %s", method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// stringFields keeps the string-convertible values of a decoded record.
+func stringFields(record map[string]any, fields []string) map[string]string {
+	values := make(map[string]string, len(fields))
+	for _, field := range fields {
+		switch v := record[field].(type) {
+		case string:
+			values[field] = v
+		case float64, bool:
+			values[field] = fmt.Sprint(v)
+		}
+	}
+	return values
+}
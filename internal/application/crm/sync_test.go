@@ -0,0 +1,141 @@
+package crm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	crmDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/crm"
+)
+
+func TestSyncHubSpotPushesSummaryAndPullsAttributes(t *testing.T) {
+	var patched map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer pat-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/crm/v3/objects/contacts/search":
+			_, _ = w.Write([]byte(`{"results":[{"id":"501","properties":{"plan_tier":"Pro","region":"EU\nwest","phone":"+15550100"}}]}`))
+		case r.Method == http.MethodPatch && r.URL.Path == "/crm/v3/objects/contacts/501":
+			_ = json.NewDecoder(r.Body).Decode(&patched)
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	repo := newMockRepo()
+	convRepo := &mockConvRepo{convs: []conversationDomain.Conversation{{
+		ID: "conv-1", PhoneNumber: "+15550100", ContactName: "Ana", Summary: "Asked about invoices.",
+		LastMessageAt: time.Now(), Variables: map[string]string{"account_id": "A-1", "lead_budget": "5000"},
+	}}}
+	svc := newTestService(t, repo, convRepo)
+	svc.hubspotURL = srv.URL
+	ctx := context.Background()
+
+	if err := svc.SaveConnection(ctx, "admin-1", &crmDomain.Connection{
+		Provider: crmDomain.ProviderHubSpot, IsActive: true, SummaryField: "lucidrag_summary",
+		Credentials: crmDomain.Credentials{Token: "pat-secret"},
+		Attributes:  map[string]string{"plan_tier": "plan", "region": "region"},
+		Exports:     map[string]string{"budget": "lead_budget", "timeline": "lead_timeline"},
+	}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := svc.Sync(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	props, _ := patched["properties"].(map[string]any)
+	if props["lucidrag_summary"] != "Asked about invoices." {
+		t.Errorf("Expected summary pushed, got %v", patched)
+	}
+	if _, ok := props["timeline"]; props["budget"] != "5000" || ok {
+		t.Errorf("Expected only set variables exported, got %v", props)
+	}
+	vars := convRepo.convs[0].Variables
+	if vars["plan"] != "Pro" || vars["region"] != "EU west" || vars["account_id"] != "A-1" {
+		t.Errorf("Expected CRM attributes merged into variables, got %v", vars)
+	}
+	conn := repo.conns[crmDomain.ProviderHubSpot]
+	if conn.LastSyncAt == nil || conn.LastError != "" {
+		t.Errorf("Expected successful sync recorded, got %+v", conn)
+	}
+}
+
+func TestSyncSalesforceCreatesMissingContact(t *testing.T) {
+	var created map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/services/oauth2/token":
+			if r.FormValue("client_secret") != "sf-secret" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"access_token":"sf-token"}`))
+		case r.URL.Path == "/services/data/v59.0/query":
+			if !strings.Contains(r.URL.Query().Get("q"), `Phone = '+1555\'0101'`) {
+				t.Errorf("Expected escaped phone in query, got %q", r.URL.Query().Get("q"))
+			}
+			_, _ = w.Write([]byte(`{"records":[]}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/services/data/v59.0/sobjects/Contact":
+			if r.Header.Get("Authorization") != "Bearer sf-token" {
+				t.Errorf("Expected bearer token, got %q", r.Header.Get("Authorization"))
+			}
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":"003"}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	repo := newMockRepo()
+	convRepo := &mockConvRepo{convs: []conversationDomain.Conversation{{ID: "conv-1", PhoneNumber: "+1555'0101", LastMessageAt: time.Now()}}}
+	svc := newTestService(t, repo, convRepo)
+	sealed, _ := svc.box.Seal(`{"client_id":"sf-id","client_secret":"sf-secret"}`)
+	repo.conns[crmDomain.ProviderSalesforce] = &crmDomain.Connection{
+		Provider: crmDomain.ProviderSalesforce, IsActive: true, InstanceURL: srv.URL,
+		SealedCredentials: sealed, Attributes: map[string]string{"Plan__c": "plan"},
+	}
+
+	if err := svc.Sync(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if created["Phone"] != "+1555'0101" || created["LastName"] != "+1555'0101" {
+		t.Errorf("Expected contact created with phone as last name, got %v", created)
+	}
+}
+
+func TestSyncRecordsFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	repo := newMockRepo()
+	convRepo := &mockConvRepo{convs: []conversationDomain.Conversation{{ID: "conv-1", PhoneNumber: "+15550100", LastMessageAt: time.Now()}}}
+	svc := newTestService(t, repo, convRepo)
+	svc.hubspotURL = srv.URL
+	sealed, _ := svc.box.Seal(`{"token":"pat"}`)
+	repo.conns[crmDomain.ProviderHubSpot] = &crmDomain.Connection{Provider: crmDomain.ProviderHubSpot, IsActive: true, SealedCredentials: sealed}
+
+	if err := svc.Sync(context.Background()); err == nil {
+		t.Fatal("Expected sync error")
+	}
+	conn := repo.conns[crmDomain.ProviderHubSpot]
+	if conn.LastSyncAt != nil || !strings.Contains(conn.LastError, "status 429") {
+		t.Errorf("Expected failure recorded without advancing the sync, got %+v", conn)
+	}
+}
@@ -0,0 +1,230 @@
+// Package digest emails admins a summary of how the assistant did: query
+// volume, low-confidence answers, new knowledge gaps and failed tool
+// webhooks.
+package digest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	toolDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/tool"
+	userDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/user"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	mailpkg "github.com/elprogramadorgt/lucidRAG/pkg/mail"
+)
+
+const (
+	// maxGaps caps the knowledge gaps listed in one digest.
+	maxGaps    = 10
+	timeLayout = "Mon 2 Jan 15:04"
+)
+
+// Mailer delivers an email and returns its Message-ID.
+type Mailer interface {
+	Send(ctx context.Context, msg mailpkg.Message) (string, error)
+}
+
+// Digest is what one email reports on, for the period [Start, End).
+type Digest struct {
+	Frequency      userDomain.DigestFrequency
+	Start, End     time.Time
+	Queries        documentDomain.QueryStats
+	Gaps           []documentDomain.KnowledgeGap
+	FailedWebhooks []toolDomain.FailureCount
+}
+
+// Job sends the digests due this hour. It is meant to run hourly: each
+// admin who saved preferences asking for email digests gets theirs at Hour
+// in their own time zone, daily or, for weekly digests, on Weekday.
+// Admins who never saved preferences get none.
+type Job struct {
+	users         userDomain.Repository
+	prefs         userDomain.PreferencesRepository
+	queries       documentDomain.QueryLogRepository
+	invocations   toolDomain.InvocationRepository
+	mailer        Mailer
+	lowConfidence float64
+	hour          int
+	weekday       time.Weekday
+	brand         string
+	log           *logger.Logger
+	now           func() time.Time
+}
+
+type JobConfig struct {
+	Users       userDomain.Repository
+	Preferences userDomain.PreferencesRepository
+	Queries     documentDomain.QueryLogRepository
+	// Invocations is nil when tools are not stored; digests then leave out
+	// failed webhooks.
+	Invocations   toolDomain.InvocationRepository
+	Mailer        Mailer
+	LowConfidence float64
+	Hour          int
+	Weekday       time.Weekday
+	// Brand names the workspace in subjects.
+	Brand string
+	Log   *logger.Logger
+}
+
+func NewJob(cfg JobConfig) *Job {
+	return &Job{
+		users:         cfg.Users,
+		prefs:         cfg.Preferences,
+		queries:       cfg.Queries,
+		invocations:   cfg.Invocations,
+		mailer:        cfg.Mailer,
+		lowConfidence: cfg.LowConfidence,
+		hour:          cfg.Hour,
+		weekday:       cfg.Weekday,
+		brand:         cfg.Brand,
+		log:           cfg.Log.With("component", "digest"),
+		now:           time.Now,
+	}
+}
+
+// Run emails the digests due now. One admin's failed delivery does not
+// stop the others; the failures are returned together.
+func (j *Job) Run(ctx context.Context) error {
+	now := j.now().Truncate(time.Hour)
+	admins, err := j.users.ListByRole(ctx, userDomain.RoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	// Admins due at the same hour share the period, so each is built once.
+	built := map[userDomain.DigestFrequency]*Digest{}
+	var errs []error
+	for _, admin := range admins {
+		prefs, err := j.prefs.Get(ctx, admin.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("preferences of %s: %w", admin.ID, err))
+			continue
+		}
+		frequency, loc := j.due(prefs, now)
+		if frequency == "" || admin.Email == "" {
+			continue
+		}
+
+		digest, ok := built[frequency]
+		if !ok {
+			if digest, err = j.Build(ctx, frequency, now); err != nil {
+				return err
+			}
+			built[frequency] = digest
+		}
+		_, err = j.mailer.Send(ctx, mailpkg.Message{
+			To:      admin.Email,
+			Subject: j.subject(digest),
+			Body:    render(digest, loc),
+		})
+		if err != nil {
+			j.log.WarnContext(ctx, "failed to send digest", "error", err, "user_id", admin.ID)
+			errs = append(errs, fmt.Errorf("digest to %s: %w", admin.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// due returns the digest an admin is owed at now, if any, and the time
+// zone to show it in.
+func (j *Job) due(prefs *userDomain.Preferences, now time.Time) (userDomain.DigestFrequency, *time.Location) {
+	if prefs == nil || !prefs.WantsChannel(userDomain.NotificationEmail) {
+		return "", nil
+	}
+	loc := prefs.Location()
+	local := now.In(loc)
+	if local.Hour() != j.hour {
+		return "", nil
+	}
+	switch prefs.DigestFrequency {
+	case userDomain.DigestDaily:
+		return userDomain.DigestDaily, loc
+	case userDomain.DigestWeekly:
+		if local.Weekday() == j.weekday {
+			return userDomain.DigestWeekly, loc
+		}
+	}
+	return "", nil
+}
+
+// Build gathers the digest of the day or week ending at end.
+func (j *Job) Build(ctx context.Context, frequency userDomain.DigestFrequency, end time.Time) (*Digest, error) {
+	start := end.AddDate(0, 0, -1)
+	if frequency == userDomain.DigestWeekly {
+		start = end.AddDate(0, 0, -7)
+	}
+	digest := &Digest{Frequency: frequency, Start: start, End: end}
+
+	stats, err := j.queries.Stats(ctx, start, end, j.lowConfidence)
+	if err != nil {
+		return nil, fmt.Errorf("query stats: %w", err)
+	}
+	digest.Queries = *stats
+	if digest.Gaps, err = j.queries.Gaps(ctx, start, end, j.lowConfidence, maxGaps); err != nil {
+		return nil, fmt.Errorf("knowledge gaps: %w", err)
+	}
+	if j.invocations != nil {
+		if digest.FailedWebhooks, err = j.invocations.Failures(ctx, start, end); err != nil {
+			return nil, fmt.Errorf("tool failures: %w", err)
+		}
+	}
+	return digest, nil
+}
+
+func (j *Job) subject(d *Digest) string {
+	if d.Frequency == userDomain.DigestWeekly {
+		return "Weekly digest - " + j.brand
+	}
+	return "Daily digest - " + j.brand
+}
+
+// render writes the digest as plain text, with times in loc.
+func render(d *Digest, loc *time.Location) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Activity from %s to %s (%s)\n\n",
+		d.Start.In(loc).Format(timeLayout), d.End.In(loc).Format(timeLayout), loc)
+
+	q := d.Queries
+	b.WriteString("Queries\n")
+	fmt.Fprintf(&b, "  Total: %d\n", q.Total)
+	fmt.Fprintf(&b, "  Low confidence: %d (%s)\n", q.LowConfidence, percent(q.LowConfidence, q.Total))
+	fmt.Fprintf(&b, "  No results: %d (%s)\n", q.NoResults, percent(q.NoResults, q.Total))
+
+	b.WriteString("\nNew knowledge gaps\n")
+	if len(d.Gaps) == 0 {
+		b.WriteString("  None\n")
+	}
+	for _, gap := range d.Gaps {
+		fmt.Fprintf(&b, "  - %q, asked %d %s\n", gap.Query, gap.Count, plural(gap.Count, "time", "times"))
+	}
+
+	b.WriteString("\nFailed webhook deliveries\n")
+	if len(d.FailedWebhooks) == 0 {
+		b.WriteString("  None\n")
+	}
+	for _, f := range d.FailedWebhooks {
+		fmt.Fprintf(&b, "  - %s: %d %s, last: %s\n", f.ToolName, f.Failures, plural(f.Failures, "failure", "failures"), f.LastError)
+	}
+
+	b.WriteString("\nYou can change or stop these digests in your notification preferences.\n")
+	return b.String()
+}
+
+func percent(n, total int64) string {
+	if total == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%.1f%%", float64(n)*100/float64(total))
+}
+
+func plural(n int64, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -0,0 +1,170 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	toolDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/tool"
+	userDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/user"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	mailpkg "github.com/elprogramadorgt/lucidRAG/pkg/mail"
+)
+
+type mockUserRepo struct {
+	userDomain.Repository
+	admins []userDomain.User
+}
+
+func (m *mockUserRepo) ListByRole(ctx context.Context, role userDomain.Role) ([]userDomain.User, error) {
+	return m.admins, nil
+}
+
+type mockPrefsRepo struct {
+	userDomain.PreferencesRepository
+	prefs map[string]*userDomain.Preferences
+}
+
+func (m *mockPrefsRepo) Get(ctx context.Context, userID string) (*userDomain.Preferences, error) {
+	return m.prefs[userID], nil
+}
+
+type mockQueryLogRepo struct {
+	documentDomain.QueryLogRepository
+	starts []time.Time
+}
+
+func (m *mockQueryLogRepo) Stats(ctx context.Context, start, end time.Time, lowConfidence float64) (*documentDomain.QueryStats, error) {
+	m.starts = append(m.starts, start)
+	return &documentDomain.QueryStats{Total: 200, LowConfidence: 9, NoResults: 4}, nil
+}
+
+func (m *mockQueryLogRepo) Gaps(ctx context.Context, start, end time.Time, lowConfidence float64, limit int) ([]documentDomain.KnowledgeGap, error) {
+	return []documentDomain.KnowledgeGap{{Query: "Do you ship to Peru?", Count: 3}}, nil
+}
+
+type mockInvocationRepo struct {
+	toolDomain.InvocationRepository
+}
+
+func (m *mockInvocationRepo) Failures(ctx context.Context, start, end time.Time) ([]toolDomain.FailureCount, error) {
+	return []toolDomain.FailureCount{{ToolName: "order_status", Failures: 2, LastError: "status 502"}}, nil
+}
+
+type mockMailer struct {
+	sent []mailpkg.Message
+	err  error
+}
+
+func (m *mockMailer) Send(ctx context.Context, msg mailpkg.Message) (string, error) {
+	if m.err != nil {
+		return "", m.err
+	}
+	m.sent = append(m.sent, msg)
+	return "<id@example.com>", nil
+}
+
+func prefs(id, timezone string, frequency userDomain.DigestFrequency, channels ...userDomain.NotificationChannel) *userDomain.Preferences {
+	return &userDomain.Preferences{UserID: id, Timezone: timezone, DigestFrequency: frequency, NotificationChannels: channels}
+}
+
+func newTestJob(mailer *mockMailer, queries *mockQueryLogRepo, now time.Time) *Job {
+	admins := []userDomain.User{
+		{ID: "daily", Email: "daily@example.com"},
+		{ID: "weekly", Email: "weekly@example.com"},
+		{ID: "guatemala", Email: "gt@example.com"},
+		{ID: "in-app", Email: "in-app@example.com"},
+		{ID: "never", Email: "never@example.com"},
+		{ID: "unset", Email: "unset@example.com"},
+	}
+	job := NewJob(JobConfig{
+		Users: &mockUserRepo{admins: admins},
+		Preferences: &mockPrefsRepo{prefs: map[string]*userDomain.Preferences{
+			"daily":     prefs("daily", "UTC", userDomain.DigestDaily, userDomain.NotificationEmail),
+			"weekly":    prefs("weekly", "UTC", userDomain.DigestWeekly, userDomain.NotificationEmail),
+			"guatemala": prefs("guatemala", "America/Guatemala", userDomain.DigestDaily, userDomain.NotificationEmail),
+			"in-app":    prefs("in-app", "UTC", userDomain.DigestDaily, userDomain.NotificationInApp),
+			"never":     prefs("never", "UTC", userDomain.DigestNever, userDomain.NotificationEmail),
+		}},
+		Queries:       queries,
+		Invocations:   &mockInvocationRepo{},
+		Mailer:        mailer,
+		LowConfidence: 0.5,
+		Hour:          8,
+		Weekday:       time.Monday,
+		Brand:         "Acme",
+		Log:           logger.New(logger.Options{Level: "error"}),
+	})
+	job.now = func() time.Time { return now }
+	return job
+}
+
+func recipients(sent []mailpkg.Message) []string {
+	var to []string
+	for _, msg := range sent {
+		to = append(to, msg.To)
+	}
+	return to
+}
+
+func TestRunSendsDueDigests(t *testing.T) {
+	// Monday 2 March 2026, 08:20 UTC.
+	now := time.Date(2026, 3, 2, 8, 20, 0, 0, time.UTC)
+	mailer, queries := &mockMailer{}, &mockQueryLogRepo{}
+	if err := newTestJob(mailer, queries, now).Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	got := strings.Join(recipients(mailer.sent), ",")
+	if got != "daily@example.com,weekly@example.com" {
+		t.Fatalf("Expected the daily and weekly digests only, got %s", got)
+	}
+	if mailer.sent[0].Subject != "Daily digest - Acme" || mailer.sent[1].Subject != "Weekly digest - Acme" {
+		t.Errorf("Unexpected subjects %q and %q", mailer.sent[0].Subject, mailer.sent[1].Subject)
+	}
+	end := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	if len(queries.starts) != 2 || !queries.starts[0].Equal(end.AddDate(0, 0, -1)) || !queries.starts[1].Equal(end.AddDate(0, 0, -7)) {
+		t.Errorf("Expected a day and a week ending at %v, got starts %v", end, queries.starts)
+	}
+
+	body := mailer.sent[0].Body
+	for _, want := range []string{"Total: 200", "Low confidence: 9 (4.5%)", `"Do you ship to Peru?", asked 3 times`, "order_status: 2 failures, last: status 502"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected body to contain %q, got:\n%s", want, body)
+		}
+	}
+}
+
+func TestRunUsesAdminTimezone(t *testing.T) {
+	// 08:00 in Guatemala, a Tuesday.
+	now := time.Date(2026, 3, 3, 14, 0, 0, 0, time.UTC)
+	mailer := &mockMailer{}
+	if err := newTestJob(mailer, &mockQueryLogRepo{}, now).Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got := strings.Join(recipients(mailer.sent), ","); got != "gt@example.com" {
+		t.Fatalf("Expected only the Guatemala admin, got %s", got)
+	}
+	if !strings.Contains(mailer.sent[0].Body, "(America/Guatemala)") {
+		t.Errorf("Expected times in the admin's zone, got:\n%s", mailer.sent[0].Body)
+	}
+}
+
+func TestRunReturnsSendFailures(t *testing.T) {
+	now := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	mailer := &mockMailer{err: errors.New("smtp down")}
+	err := newTestJob(mailer, &mockQueryLogRepo{}, now).Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "daily") || !strings.Contains(err.Error(), "weekly") {
+		t.Errorf("Expected both failed deliveries reported, got %v", err)
+	}
+}
+
+func TestRenderWithoutActivity(t *testing.T) {
+	body := render(&Digest{Start: time.Unix(0, 0), End: time.Unix(86400, 0)}, time.UTC)
+	if !strings.Contains(body, "Low confidence: 0 (0%)") || strings.Count(body, "None") != 2 {
+		t.Errorf("Unexpected empty digest:\n%s", body)
+	}
+}
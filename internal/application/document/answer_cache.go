@@ -0,0 +1,81 @@
+package document
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/cache"
+)
+
+// answerGenerationKey holds a counter that is bumped whenever the knowledge
+// base changes. It is part of every answer key, so bumping it orphans all
+// cached answers at once; they then expire on their own.
+const answerGenerationKey = "rag:answers:generation"
+
+func (s *service) answerCacheEnabled() bool {
+	return s.cache != nil && s.answerCacheTTL > 0
+}
+
+// answerCacheKey identifies a query by its normalized text, retrieval
+// parameters, channel and response schema, so trivially different
+// phrasings of the same question share an entry.
+func (s *service) answerCacheKey(ctx context.Context, query documentDomain.RAGQuery) string {
+	// Follow-up questions depend on the conversation and personalized ones
+	// on the customer, so they are never shared.
+	if !s.answerCacheEnabled() || query.Summary != "" || len(query.History) > 0 || len(query.CustomerContext) > 0 {
+		return ""
+	}
+
+	generation := "0"
+	if data, err := s.cache.Get(ctx, answerGenerationKey); err == nil {
+		generation = string(data)
+	}
+
+	key := fmt.Sprintf("%s|%d|%g|%s|%s", normalizeText(query.Query), query.TopK, query.Threshold, query.Channel, query.Collection)
+	if query.ResponseSchema != nil {
+		// encoding/json sorts map keys, so equal schemas hash alike.
+		schema, _ := json.Marshal(query.ResponseSchema)
+		key += "|" + string(schema)
+	}
+	if query.Model != "" || query.Temperature != nil || query.TopP != nil || query.MaxTokens > 0 {
+		key += fmt.Sprintf("|%s|%s|%s|%d", query.Model, formatOverride(query.Temperature), formatOverride(query.TopP), query.MaxTokens)
+	}
+	sum := sha256.Sum256([]byte(key))
+	return fmt.Sprintf("rag:answer:%s:%x", generation, sum)
+}
+
+func (s *service) cachedAnswer(ctx context.Context, key string) *documentDomain.RAGResponse {
+	if key == "" {
+		return nil
+	}
+	var resp documentDomain.RAGResponse
+	if ok, _ := cache.GetJSON(ctx, s.cache, key, &resp); !ok {
+		return nil
+	}
+	return &resp
+}
+
+func (s *service) storeAnswer(ctx context.Context, key string, resp *documentDomain.RAGResponse) {
+	if key == "" {
+		return
+	}
+	_ = cache.SetJSON(ctx, s.cache, key, resp, s.answerCacheTTL)
+}
+
+// formatOverride renders an optional sampling setting for a cache key.
+func formatOverride(value *float64) string {
+	if value == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%g", *value)
+}
+
+func (s *service) invalidateAnswers(ctx context.Context) {
+	if !s.answerCacheEnabled() {
+		return
+	}
+	_, _ = s.cache.Incr(ctx, answerGenerationKey, 0)
+}
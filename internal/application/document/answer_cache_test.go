@@ -0,0 +1,64 @@
+package document
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/cache"
+)
+
+func TestAnswerCacheKeyNormalizesQuery(t *testing.T) {
+	c := cache.NewMemory()
+	defer c.Stop()
+	s := &service{cache: c, answerCacheTTL: time.Minute}
+	ctx := context.Background()
+
+	a := s.answerCacheKey(ctx, documentDomain.RAGQuery{Query: "Store  Hours?", TopK: 5, Threshold: 0.7})
+	b := s.answerCacheKey(ctx, documentDomain.RAGQuery{Query: "store hours?", TopK: 5, Threshold: 0.7})
+	if a != b {
+		t.Errorf("Expected normalized queries to share a key, got %s and %s", a, b)
+	}
+
+	other := s.answerCacheKey(ctx, documentDomain.RAGQuery{Query: "store hours?", TopK: 3, Threshold: 0.7})
+	if other == a {
+		t.Error("Expected different retrieval parameters to use a different key")
+	}
+}
+
+func TestAnswerCacheInvalidation(t *testing.T) {
+	c := cache.NewMemory()
+	defer c.Stop()
+	s := &service{cache: c, answerCacheTTL: time.Minute}
+	ctx := context.Background()
+	query := documentDomain.RAGQuery{Query: "returns", TopK: 5, Threshold: 0.7}
+
+	key := s.answerCacheKey(ctx, query)
+	s.storeAnswer(ctx, key, &documentDomain.RAGResponse{Answer: "30 days"})
+
+	if cached := s.cachedAnswer(ctx, s.answerCacheKey(ctx, query)); cached == nil || cached.Answer != "30 days" {
+		t.Fatalf("Expected cached answer, got %+v", cached)
+	}
+
+	s.invalidateAnswers(ctx)
+
+	if cached := s.cachedAnswer(ctx, s.answerCacheKey(ctx, query)); cached != nil {
+		t.Errorf("Expected no cached answer after invalidation, got %+v", cached)
+	}
+}
+
+func TestAnswerCacheDisabled(t *testing.T) {
+	s := &service{}
+	ctx := context.Background()
+
+	key := s.answerCacheKey(ctx, documentDomain.RAGQuery{Query: "returns"})
+	if key != "" {
+		t.Errorf("Expected empty key without a cache, got %s", key)
+	}
+	s.storeAnswer(ctx, key, &documentDomain.RAGResponse{Answer: "x"})
+	s.invalidateAnswers(ctx)
+	if s.cachedAnswer(ctx, key) != nil {
+		t.Error("Expected no cached answer without a cache")
+	}
+}
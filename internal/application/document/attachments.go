@@ -0,0 +1,163 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/extract"
+	"github.com/elprogramadorgt/lucidRAG/pkg/objectstore"
+)
+
+const (
+	pdfContentType = "application/pdf"
+	// attachmentProbe is how much of a chunk's opening text is looked up in
+	// its document to find the page it comes from.
+	attachmentProbe = 80
+)
+
+// storeFile keeps an upload's original under the document's ID. PDFs also
+// record where each page's text starts in the content.
+func (s *service) storeFile(ctx context.Context, id string, upload documentDomain.Upload, result *extract.Result) (*documentDomain.StoredFile, error) {
+	file := &documentDomain.StoredFile{
+		Key:         "documents/" + id + "/original",
+		Filename:    upload.Filename,
+		ContentType: result.ContentType,
+		Size:        int64(len(upload.Data)),
+	}
+	if result.ContentType == pdfContentType {
+		file.PageOffsets = pageOffsets(result.Pages)
+	}
+	if err := s.files.Put(ctx, file.Key, bytes.NewReader(upload.Data), file.Size); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// pageOffsets returns where each page's text starts in the content
+// Result.Text builds from them. Empty pages start where the next text does.
+func pageOffsets(pages []extract.Page) []int {
+	offsets := make([]int, len(pages))
+	pos := 0
+	for i, page := range pages {
+		offsets[i] = pos
+		if text := strings.TrimSpace(page.Text); text != "" {
+			pos += len(text) + len("\n\n")
+		}
+	}
+	return offsets
+}
+
+func (s *service) deleteFile(ctx context.Context, file *documentDomain.StoredFile) {
+	if file == nil || s.files == nil {
+		return
+	}
+	if err := s.files.Delete(ctx, file.Key); err != nil {
+		s.log.WarnContext(ctx, "failed to delete file", "error", err, "key", file.Key)
+	}
+}
+
+func (s *service) Attachment(ctx context.Context, chunk documentDomain.Chunk) (*documentDomain.Attachment, error) {
+	if s.files == nil {
+		return nil, nil
+	}
+	doc, err := s.repo.GetByID(ctx, chunk.DocumentID)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil || !doc.IsActive || !doc.Shareable || doc.File == nil {
+		return nil, nil
+	}
+
+	r, err := s.files.Get(ctx, doc.File.Key)
+	if err != nil {
+		return nil, fmt.Errorf("read file of document %s: %w", doc.ID, err)
+	}
+	defer func() { _ = r.Close() }()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read file of document %s: %w", doc.ID, err)
+	}
+
+	// The page itself shows a diagram or table the way the text cannot.
+	if page := chunkPage(doc, chunk); page > 0 && s.extractor != nil {
+		image, err := s.extractor.RenderPage(ctx, data, page)
+		if err == nil {
+			return &documentDomain.Attachment{
+				Data:        image,
+				ContentType: "image/png",
+				Filename:    fmt.Sprintf("page-%d.png", page),
+				Caption:     fmt.Sprintf("%s, page %d", doc.Title, page),
+			}, nil
+		}
+		s.log.WarnContext(ctx, "failed to render page", "error", err, "page", page, "document_id", doc.ID)
+	}
+
+	return &documentDomain.Attachment{
+		Data:        data,
+		ContentType: doc.File.ContentType,
+		Filename:    doc.File.Filename,
+		Caption:     doc.Title,
+	}, nil
+}
+
+// downloadURLExpiry is how long a signed download URL works.
+const downloadURLExpiry = 5 * time.Minute
+
+func (s *service) Download(ctx context.Context, userCtx documentDomain.UserContext, id string) (*documentDomain.Download, error) {
+	doc, err := s.GetDocument(ctx, userCtx, id)
+	if err != nil {
+		return nil, err
+	}
+	if doc.File == nil || s.files == nil {
+		return nil, ErrNoFile
+	}
+
+	download := &documentDomain.Download{
+		Filename:    doc.File.Filename,
+		ContentType: doc.File.ContentType,
+		Size:        doc.File.Size,
+	}
+	if presigner, ok := s.files.(objectstore.Presigner); ok {
+		download.URL, err = presigner.PresignGet(doc.File.Key, doc.File.Filename, downloadURLExpiry)
+		if err != nil {
+			return nil, fmt.Errorf("sign file of document %s: %w", doc.ID, err)
+		}
+		download.ExpiresAt = time.Now().Add(downloadURLExpiry)
+		return download, nil
+	}
+
+	download.Body, err = s.files.Get(ctx, doc.File.Key)
+	if errors.Is(err, objectstore.ErrNotFound) {
+		return nil, ErrNoFile
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read file of document %s: %w", doc.ID, err)
+	}
+	return download, nil
+}
+
+// chunkPage returns the PDF page chunk's text starts on, or 0 when the
+// document has no pages or the text cannot be found in it.
+func chunkPage(doc *documentDomain.Document, chunk documentDomain.Chunk) int {
+	if doc.File.ContentType != pdfContentType || len(doc.File.PageOffsets) == 0 {
+		return 0
+	}
+	probe := strings.TrimSpace(chunk.Content)
+	if len(probe) > attachmentProbe {
+		probe = probe[:attachmentProbe]
+	}
+	if probe == "" {
+		return 0
+	}
+	offset := strings.Index(doc.Content, probe)
+	if offset < 0 {
+		return 0
+	}
+	return doc.File.Page(offset)
+}
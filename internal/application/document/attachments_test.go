@@ -0,0 +1,134 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/extract"
+	"github.com/elprogramadorgt/lucidRAG/pkg/objectstore"
+)
+
+func TestChunkPage(t *testing.T) {
+	pages := []extract.Page{{Text: "Safety notes."}, {Text: " "}, {Text: "Wiring diagram for the pump."}}
+	offsets := pageOffsets(pages)
+	if offsets[0] != 0 || offsets[1] != 15 || offsets[2] != 15 {
+		t.Fatalf("Expected offsets [0 15 15], got %v", offsets)
+	}
+
+	doc := &documentDomain.Document{
+		Content: "Safety notes.\n\nWiring diagram for the pump.",
+		File:    &documentDomain.StoredFile{ContentType: pdfContentType, PageOffsets: offsets},
+	}
+	if page := chunkPage(doc, documentDomain.Chunk{Content: "Wiring diagram"}); page != 3 {
+		t.Errorf("Expected page 3, got %d", page)
+	}
+	if page := chunkPage(doc, documentDomain.Chunk{Content: "Not in the document"}); page != 0 {
+		t.Errorf("Expected no page, got %d", page)
+	}
+	doc.File.ContentType = "text/plain"
+	if page := chunkPage(doc, documentDomain.Chunk{Content: "Safety"}); page != 0 {
+		t.Errorf("Expected no page for text, got %d", page)
+	}
+}
+
+func TestAttachment(t *testing.T) {
+	files, err := objectstore.NewFS(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newMockDocumentRepo()
+	svc := NewService(ServiceConfig{Repo: repo, Extractor: extract.New(extract.Config{}), Files: files})
+
+	id, err := svc.UploadDocument(context.Background(), adminCtx, documentDomain.Upload{
+		Filename:    "returns.txt",
+		ContentType: "text/plain",
+		Data:        []byte("Returns are accepted within 30 days."),
+		Title:       "Returns",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	file := repo.documents[id].File
+	if file == nil {
+		t.Fatal("Expected the original to be kept")
+	}
+
+	chunk := documentDomain.Chunk{DocumentID: id, Content: "Returns are accepted"}
+	if attachment, err := svc.Attachment(context.Background(), chunk); err != nil || attachment != nil {
+		t.Errorf("Expected no attachment for a document that is not shareable, got %+v, %v", attachment, err)
+	}
+
+	repo.documents[id].IsActive, repo.documents[id].Shareable = true, true
+	attachment, err := svc.Attachment(context.Background(), chunk)
+	if err != nil || attachment == nil {
+		t.Fatalf("Expected an attachment, got %v", err)
+	}
+	if string(attachment.Data) != "Returns are accepted within 30 days." || attachment.Filename != "returns.txt" || attachment.Caption != "Returns" {
+		t.Errorf("Expected the original file, got %+v", attachment)
+	}
+
+	if err := svc.DeleteDocument(context.Background(), adminCtx, id); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := files.Get(context.Background(), file.Key); err == nil {
+		t.Error("Expected the file to be deleted with the document")
+	}
+}
+
+// presigningFS is a filesystem store that signs URLs like S3.
+type presigningFS struct {
+	*objectstore.FS
+}
+
+func (p presigningFS) PresignGet(key, filename string, expires time.Duration) (string, error) {
+	return "https://files.example.com/" + key + "?expires=" + expires.String(), nil
+}
+
+func TestDownload(t *testing.T) {
+	fs, err := objectstore.NewFS(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := newMockDocumentRepo()
+	svc := NewService(ServiceConfig{Repo: repo, Extractor: extract.New(extract.Config{}), Files: fs})
+	ctx := context.Background()
+
+	id, err := svc.UploadDocument(ctx, adminCtx, documentDomain.Upload{
+		Filename: "returns.txt", ContentType: "text/plain", Data: []byte("Returns are accepted within 30 days."), Title: "Returns",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	download, err := svc.Download(ctx, adminCtx, id)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	data, _ := io.ReadAll(download.Body)
+	_ = download.Body.Close()
+	if download.URL != "" || string(data) != "Returns are accepted within 30 days." || download.Filename != "returns.txt" {
+		t.Errorf("Expected the file streamed from the filesystem, got %+v", download)
+	}
+
+	if _, err := svc.Download(ctx, documentDomain.UserContext{UserID: "someone-else"}, id); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden for another user's document, got %v", err)
+	}
+
+	signed := NewService(ServiceConfig{Repo: repo, Files: presigningFS{fs}})
+	download, err = signed.Download(ctx, adminCtx, id)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if download.Body != nil || download.URL != "https://files.example.com/"+repo.documents[id].File.Key+"?expires=5m0s" || time.Until(download.ExpiresAt) > downloadURLExpiry {
+		t.Errorf("Expected a signed URL valid for %v, got %+v", downloadURLExpiry, download)
+	}
+
+	repo.documents[id].File = nil
+	if _, err := svc.Download(ctx, adminCtx, id); !errors.Is(err, ErrNoFile) {
+		t.Errorf("Expected ErrNoFile for a document without a file, got %v", err)
+	}
+}
@@ -0,0 +1,235 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/boilerplate"
+	"github.com/elprogramadorgt/lucidRAG/pkg/extract"
+)
+
+var (
+	ErrCleaningProfileNotFound = errors.New("cleaning profile not found")
+	ErrInvalidCleaningProfile  = errors.New("invalid cleaning profile")
+)
+
+const (
+	maxCleaningSources     = 20
+	maxCleaningStopPhrases = 50
+	maxStopPhraseLen       = 200
+)
+
+func (s *service) CreateCleaningProfile(ctx context.Context, userCtx documentDomain.UserContext, profile *documentDomain.CleaningProfile) (string, error) {
+	if !userCtx.IsAdmin {
+		return "", ErrForbidden
+	}
+	if s.cleaningRepo == nil {
+		return "", fmt.Errorf("%w: cleaning profiles are not configured", ErrInvalidCleaningProfile)
+	}
+	if err := normalizeCleaningProfile(profile); err != nil {
+		return "", err
+	}
+	profile.CreatedBy = userCtx.UserID
+	profile.IsActive = true
+	return s.cleaningRepo.Create(ctx, profile)
+}
+
+func (s *service) ListCleaningProfiles(ctx context.Context, userCtx documentDomain.UserContext) ([]documentDomain.CleaningProfile, error) {
+	if !userCtx.IsAdmin {
+		return nil, ErrForbidden
+	}
+	if s.cleaningRepo == nil {
+		return []documentDomain.CleaningProfile{}, nil
+	}
+	return s.cleaningRepo.List(ctx)
+}
+
+func (s *service) UpdateCleaningProfile(ctx context.Context, userCtx documentDomain.UserContext, profile *documentDomain.CleaningProfile) error {
+	if !userCtx.IsAdmin {
+		return ErrForbidden
+	}
+	if s.cleaningRepo == nil {
+		return ErrCleaningProfileNotFound
+	}
+	if err := normalizeCleaningProfile(profile); err != nil {
+		return err
+	}
+
+	existing, err := s.cleaningRepo.GetByID(ctx, profile.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrCleaningProfileNotFound
+	}
+	profile.CreatedBy = existing.CreatedBy
+	profile.CreatedAt = existing.CreatedAt
+	return s.cleaningRepo.Update(ctx, profile)
+}
+
+func (s *service) DeleteCleaningProfile(ctx context.Context, userCtx documentDomain.UserContext, id string) error {
+	if !userCtx.IsAdmin {
+		return ErrForbidden
+	}
+	if s.cleaningRepo == nil {
+		return ErrCleaningProfileNotFound
+	}
+
+	existing, err := s.cleaningRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrCleaningProfileNotFound
+	}
+	return s.cleaningRepo.Delete(ctx, id)
+}
+
+// normalizeCleaningProfile checks profile, trimming its source patterns
+// and stop phrases and dropping repeats.
+func normalizeCleaningProfile(profile *documentDomain.CleaningProfile) error {
+	profile.Name = strings.TrimSpace(profile.Name)
+	if profile.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidCleaningProfile)
+	}
+
+	sources := make([]string, 0, len(profile.Sources))
+	for _, src := range profile.Sources {
+		if src = strings.TrimSpace(src); src != "" && !containsString(sources, src) {
+			sources = append(sources, src)
+		}
+	}
+	if len(sources) == 0 {
+		return fmt.Errorf("%w: at least one source pattern is required", ErrInvalidCleaningProfile)
+	}
+	if len(sources) > maxCleaningSources {
+		return fmt.Errorf("%w: at most %d source patterns are allowed", ErrInvalidCleaningProfile, maxCleaningSources)
+	}
+	profile.Sources = sources
+
+	phrases := make([]string, 0, len(profile.StopPhrases))
+	seen := make([]string, 0, len(profile.StopPhrases))
+	for _, p := range profile.StopPhrases {
+		p = strings.TrimSpace(p)
+		if p == "" || containsString(seen, strings.ToLower(p)) {
+			continue
+		}
+		if len(p) > maxStopPhraseLen {
+			return fmt.Errorf("%w: stop phrase %q is longer than %d characters", ErrInvalidCleaningProfile, p, maxStopPhraseLen)
+		}
+		seen = append(seen, strings.ToLower(p))
+		phrases = append(phrases, p)
+	}
+	if len(phrases) > maxCleaningStopPhrases {
+		return fmt.Errorf("%w: at most %d stop phrases are allowed", ErrInvalidCleaningProfile, maxCleaningStopPhrases)
+	}
+	profile.StopPhrases = phrases
+
+	if cleaningRules(profile).IsZero() {
+		return fmt.Errorf("%w: nothing to strip", ErrInvalidCleaningProfile)
+	}
+	return nil
+}
+
+func cleaningRules(profile *documentDomain.CleaningProfile) boilerplate.Rules {
+	return boilerplate.Rules{
+		RepeatedLines: profile.RepeatedLines,
+		PageNumbers:   profile.PageNumbers,
+		Navigation:    profile.Navigation,
+		StopPhrases:   profile.StopPhrases,
+	}
+}
+
+// sourceMatches reports whether source matches pattern, ignoring case,
+// where each * in pattern stands for any text.
+func sourceMatches(pattern, source string) bool {
+	parts := strings.Split(strings.ToLower(pattern), "*")
+	source = strings.ToLower(source)
+	if !strings.HasPrefix(source, parts[0]) {
+		return false
+	}
+	source = source[len(parts[0]):]
+	last := len(parts) - 1
+	if last == 0 {
+		return source == ""
+	}
+	for _, part := range parts[1:last] {
+		i := strings.Index(source, part)
+		if i < 0 {
+			return false
+		}
+		source = source[i+len(part):]
+	}
+	return strings.HasSuffix(source, parts[last])
+}
+
+// cleaningProfile returns the active profile for source: the one with the
+// longest pattern matching it. Without one, or failing to load them, the
+// content is left as given.
+func (s *service) cleaningProfile(ctx context.Context, source string) *documentDomain.CleaningProfile {
+	if s.cleaningRepo == nil {
+		return nil
+	}
+	profiles, err := s.cleaningRepo.List(ctx)
+	if err != nil {
+		s.log.WarnContext(ctx, "failed to load cleaning profiles", "error", err)
+		return nil
+	}
+
+	var best *documentDomain.CleaningProfile
+	bestLen := -1
+	for i, profile := range profiles {
+		if !profile.IsActive {
+			continue
+		}
+		for _, pattern := range profile.Sources {
+			if len(pattern) > bestLen && sourceMatches(pattern, source) {
+				best, bestLen = &profiles[i], len(pattern)
+			}
+		}
+	}
+	return best
+}
+
+// cleanPages strips boilerplate from the text of pages in place, with the
+// profile for source.
+func (s *service) cleanPages(ctx context.Context, source string, pages []extract.Page) {
+	texts := make([]string, len(pages))
+	for i, p := range pages {
+		texts[i] = p.Text
+	}
+	texts = s.clean(ctx, source, texts)
+	for i := range pages {
+		pages[i].Text = texts[i]
+	}
+}
+
+// cleanText strips boilerplate from text with the profile for source. Form
+// feeds, which pdftotext puts between pages, split text into pages.
+func (s *service) cleanText(ctx context.Context, source, text string) string {
+	if text == "" {
+		return text
+	}
+	return strings.Join(s.clean(ctx, source, strings.Split(text, "\f")), "\f")
+}
+
+// clean strips boilerplate from pages with the profile for source. Content
+// that would be left without any text is kept as given instead.
+func (s *service) clean(ctx context.Context, source string, pages []string) []string {
+	profile := s.cleaningProfile(ctx, source)
+	if profile == nil {
+		return pages
+	}
+	cleaned, removed := boilerplate.Strip(pages, cleaningRules(profile))
+	if removed == 0 {
+		return pages
+	}
+	if strings.TrimSpace(strings.Join(cleaned, "")) == "" {
+		return pages
+	}
+	s.log.InfoContext(ctx, "stripped boilerplate", "profile", profile.Name, "source", source, "lines", removed)
+	return cleaned
+}
@@ -0,0 +1,177 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/extract"
+)
+
+type mockCleaningRepo struct {
+	profiles map[string]*documentDomain.CleaningProfile
+}
+
+func newMockCleaningRepo() *mockCleaningRepo {
+	return &mockCleaningRepo{profiles: make(map[string]*documentDomain.CleaningProfile)}
+}
+
+func (m *mockCleaningRepo) Create(ctx context.Context, profile *documentDomain.CleaningProfile) (string, error) {
+	if profile.ID == "" {
+		profile.ID = "profile_" + profile.Name
+	}
+	m.profiles[profile.ID] = profile
+	return profile.ID, nil
+}
+
+func (m *mockCleaningRepo) GetByID(ctx context.Context, id string) (*documentDomain.CleaningProfile, error) {
+	return m.profiles[id], nil
+}
+
+func (m *mockCleaningRepo) List(ctx context.Context) ([]documentDomain.CleaningProfile, error) {
+	profiles := make([]documentDomain.CleaningProfile, 0, len(m.profiles))
+	for _, p := range m.profiles {
+		profiles = append(profiles, *p)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	return profiles, nil
+}
+
+func (m *mockCleaningRepo) Update(ctx context.Context, profile *documentDomain.CleaningProfile) error {
+	m.profiles[profile.ID] = profile
+	return nil
+}
+
+func (m *mockCleaningRepo) Delete(ctx context.Context, id string) error {
+	delete(m.profiles, id)
+	return nil
+}
+
+func TestCleaningProfileCRUD(t *testing.T) {
+	repo := newMockCleaningRepo()
+	svc := NewService(ServiceConfig{Repo: newMockDocumentRepo(), CleaningRepo: repo})
+	ctx := context.Background()
+
+	profile := &documentDomain.CleaningProfile{Name: " Help center ", Sources: []string{" https://help.example.com/* ", "https://help.example.com/*"}, Navigation: true, StopPhrases: []string{"Was this helpful?", "was this HELPFUL?", " "}}
+	if _, err := svc.CreateCleaningProfile(ctx, documentDomain.UserContext{UserID: "u"}, profile); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden, got %v", err)
+	}
+	if _, err := svc.CreateCleaningProfile(ctx, adminCtx, &documentDomain.CleaningProfile{Name: "idle", Sources: []string{"*"}}); !errors.Is(err, ErrInvalidCleaningProfile) {
+		t.Errorf("Expected ErrInvalidCleaningProfile with nothing to strip, got %v", err)
+	}
+	if _, err := svc.CreateCleaningProfile(ctx, adminCtx, &documentDomain.CleaningProfile{Name: "nowhere", PageNumbers: true}); !errors.Is(err, ErrInvalidCleaningProfile) {
+		t.Errorf("Expected ErrInvalidCleaningProfile without sources, got %v", err)
+	}
+
+	id, err := svc.CreateCleaningProfile(ctx, adminCtx, profile)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	saved := repo.profiles[id]
+	if saved.Name != "Help center" || !saved.IsActive || len(saved.Sources) != 1 || len(saved.StopPhrases) != 1 {
+		t.Errorf("Expected an active profile without repeats, got %+v", saved)
+	}
+
+	update := &documentDomain.CleaningProfile{ID: id, Name: "Help center", Sources: []string{"*.pdf"}, PageNumbers: true}
+	if err := svc.UpdateCleaningProfile(ctx, adminCtx, update); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if repo.profiles[id].Sources[0] != "*.pdf" || repo.profiles[id].CreatedBy != adminCtx.UserID {
+		t.Errorf("Expected the sources updated and the author kept, got %+v", repo.profiles[id])
+	}
+	if err := svc.UpdateCleaningProfile(ctx, adminCtx, &documentDomain.CleaningProfile{ID: "missing", Name: "x", Sources: []string{"*"}, Navigation: true}); !errors.Is(err, ErrCleaningProfileNotFound) {
+		t.Errorf("Expected ErrCleaningProfileNotFound, got %v", err)
+	}
+
+	if err := svc.DeleteCleaningProfile(ctx, adminCtx, id); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := svc.DeleteCleaningProfile(ctx, adminCtx, id); !errors.Is(err, ErrCleaningProfileNotFound) {
+		t.Errorf("Expected ErrCleaningProfileNotFound, got %v", err)
+	}
+}
+
+func TestSourceMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		source  string
+		want    bool
+	}{
+		{"*", "anything", true},
+		{"*.pdf", "Handbook.PDF", true},
+		{"*.pdf", "handbook.pdf.txt", false},
+		{"https://help.example.com/*", "https://help.example.com/billing/refunds", true},
+		{"https://help.example.com/*", "https://example.com/help", false},
+		{"https://*.example.com/*/faq", "https://shop.example.com/en/faq", true},
+		{"https://*.example.com/*/faq", "https://shop.example.com/faq", false},
+		{"manual.txt", "manual.txt", true},
+		{"manual.txt", "old-manual.txt", false},
+	}
+	for _, tt := range tests {
+		if got := sourceMatches(tt.pattern, tt.source); got != tt.want {
+			t.Errorf("sourceMatches(%q, %q) = %v, want %v", tt.pattern, tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestCreateDocumentCleansContent(t *testing.T) {
+	cleaning := newMockCleaningRepo()
+	cleaning.profiles["web"] = &documentDomain.CleaningProfile{ID: "web", Name: "web", Sources: []string{"https://*"}, Navigation: true, IsActive: true}
+	cleaning.profiles["help"] = &documentDomain.CleaningProfile{ID: "help", Name: "help", Sources: []string{"https://help.example.com/*"}, Navigation: true, StopPhrases: []string{"was this helpful"}, IsActive: true}
+	cleaning.profiles["off"] = &documentDomain.CleaningProfile{ID: "off", Name: "off", Sources: []string{"https://help.example.com/billing/*"}, Navigation: true}
+	repo := newMockDocumentRepo()
+	svc := NewService(ServiceConfig{Repo: repo, CleaningRepo: cleaning})
+	ctx := context.Background()
+
+	content := "Home | Billing | Refunds\nRefunds take five days.\nWas this helpful? Yes / No"
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"https://help.example.com/billing/refunds", "Refunds take five days."},
+		{"https://blog.example.com/refunds", "Refunds take five days.\nWas this helpful? Yes / No"},
+		{"refunds.txt", content},
+	}
+	for _, tt := range tests {
+		id, err := svc.CreateDocument(ctx, adminCtx, &documentDomain.Document{Title: "Refunds", Content: content, Source: tt.source})
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", tt.source, err)
+		}
+		if got := repo.documents[id].Content; got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.source, tt.want, got)
+		}
+	}
+
+	// Stripping everything would leave nothing to answer from.
+	id, _ := svc.CreateDocument(ctx, adminCtx, &documentDomain.Document{Title: "Menu", Content: "Home | Billing | Refunds", Source: "https://example.com"})
+	if repo.documents[id].Content != "Home | Billing | Refunds" {
+		t.Errorf("Expected content kept when nothing would be left, got %q", repo.documents[id].Content)
+	}
+}
+
+func TestUploadDocumentCleansPages(t *testing.T) {
+	var out strings.Builder
+	for i := 1; i <= 3; i++ {
+		fmt.Fprintf(&out, "Acme Handbook\nChapter %d begins.\nIt has rules.\nChapter %d ends.\n%d\f", i, i, i)
+	}
+	run := func(ctx context.Context, stdin []byte, name string, args ...string) ([]byte, error) {
+		return []byte(out.String()), nil
+	}
+	cleaning := newMockCleaningRepo()
+	cleaning.profiles["pdf"] = &documentDomain.CleaningProfile{ID: "pdf", Name: "pdf", Sources: []string{"*.pdf"}, RepeatedLines: true, PageNumbers: true, IsActive: true}
+	repo := newMockDocumentRepo()
+	svc := NewService(ServiceConfig{Repo: repo, CleaningRepo: cleaning, Extractor: extract.New(extract.Config{Run: run})})
+
+	id, err := svc.UploadDocument(context.Background(), adminCtx, documentDomain.Upload{Filename: "handbook.pdf", ContentType: "application/pdf", Data: []byte("%PDF")})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := "Chapter 1 begins.\nIt has rules.\nChapter 1 ends.\n\nChapter 2 begins.\nIt has rules.\nChapter 2 ends.\n\nChapter 3 begins.\nIt has rules.\nChapter 3 ends."
+	if got := repo.documents[id].Content; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,134 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+)
+
+var (
+	ErrInvalidCollection  = errors.New("invalid collection")
+	ErrCollectionNotFound = errors.New("collection not found")
+	ErrCollectionInUse    = errors.New("collection has documents")
+)
+
+var collectionNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// maxDimensions is the largest vector size the embedding models produce.
+const maxDimensions = 4096
+
+// embeddingSpace returns the space the chunks of collection are embedded
+// in. The default space uses the active embedding model.
+func (s *service) embeddingSpace(ctx context.Context, collection string) (documentDomain.EmbeddingSpace, error) {
+	if collection == "" {
+		return documentDomain.EmbeddingSpace{Model: s.activeEmbeddingModel(ctx)}, nil
+	}
+	if s.collectionRepo == nil {
+		return documentDomain.EmbeddingSpace{}, ErrCollectionNotFound
+	}
+	c, err := s.collectionRepo.Get(ctx, collection)
+	if err != nil {
+		return documentDomain.EmbeddingSpace{}, err
+	}
+	if c == nil {
+		return documentDomain.EmbeddingSpace{}, ErrCollectionNotFound
+	}
+	return documentDomain.EmbeddingSpace{Collection: c.Name, Model: c.EmbeddingModel, Dimensions: c.Dimensions}, nil
+}
+
+// embed embeds text in space.
+func (s *service) embed(ctx context.Context, space documentDomain.EmbeddingSpace, text string) ([]float64, error) {
+	return s.embedder.CreateEmbeddingWithDimensions(ctx, text, space.Model, space.Dimensions)
+}
+
+func (s *service) ListCollections(ctx context.Context, userCtx documentDomain.UserContext) ([]documentDomain.Collection, error) {
+	if !userCtx.IsAdmin {
+		return nil, ErrForbidden
+	}
+	if s.collectionRepo == nil {
+		return []documentDomain.Collection{}, nil
+	}
+	return s.collectionRepo.List(ctx)
+}
+
+func (s *service) SaveCollection(ctx context.Context, userCtx documentDomain.UserContext, collection *documentDomain.Collection) error {
+	if !userCtx.IsAdmin {
+		return ErrForbidden
+	}
+	if s.collectionRepo == nil {
+		return fmt.Errorf("%w: collections are not configured", ErrInvalidCollection)
+	}
+
+	collection.EmbeddingModel = strings.TrimSpace(collection.EmbeddingModel)
+	if !collectionNamePattern.MatchString(collection.Name) {
+		return fmt.Errorf("%w: name must be lowercase letters, digits, - or _", ErrInvalidCollection)
+	}
+	if collection.EmbeddingModel == "" {
+		return fmt.Errorf("%w: embedding_model is required", ErrInvalidCollection)
+	}
+	if collection.Dimensions < 0 || collection.Dimensions > maxDimensions {
+		return fmt.Errorf("%w: dimensions must be between 0 and %d", ErrInvalidCollection, maxDimensions)
+	}
+
+	existing, err := s.collectionRepo.Get(ctx, collection.Name)
+	if err != nil {
+		return err
+	}
+	if existing != nil && (existing.EmbeddingModel != collection.EmbeddingModel || existing.Dimensions != collection.Dimensions) {
+		// The documents' chunks would be left in the old space.
+		count, err := s.repo.CountByCollection(ctx, collection.Name)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("%w: its embedding model cannot change", ErrCollectionInUse)
+		}
+	}
+
+	if !s.offered(collection.EmbeddingModel) {
+		return fmt.Errorf("%w: %s is no longer offered by its provider", ErrInvalidCollection, collection.EmbeddingModel)
+	}
+	// Rejecting an unknown model or size now beats failing every upload.
+	if s.embedder != nil {
+		space := documentDomain.EmbeddingSpace{Model: collection.EmbeddingModel, Dimensions: collection.Dimensions}
+		vector, err := s.embed(ctx, space, "embedding model check")
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidCollection, err)
+		}
+		if collection.Dimensions > 0 && len(vector) != collection.Dimensions {
+			return fmt.Errorf("%w: %s returned %d dimensions", ErrInvalidCollection, collection.EmbeddingModel, len(vector))
+		}
+	}
+
+	collection.UpdatedBy = userCtx.UserID
+	return s.collectionRepo.Upsert(ctx, collection)
+}
+
+func (s *service) DeleteCollection(ctx context.Context, userCtx documentDomain.UserContext, name string) error {
+	if !userCtx.IsAdmin {
+		return ErrForbidden
+	}
+	if s.collectionRepo == nil {
+		return ErrCollectionNotFound
+	}
+	existing, err := s.collectionRepo.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrCollectionNotFound
+	}
+
+	count, err := s.repo.CountByCollection(ctx, name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrCollectionInUse
+	}
+	return s.collectionRepo.Delete(ctx, name)
+}
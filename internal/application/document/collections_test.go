@@ -0,0 +1,182 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/chunker"
+	"github.com/elprogramadorgt/lucidRAG/pkg/openai"
+)
+
+type mockCollectionRepo struct {
+	collections map[string]*documentDomain.Collection
+}
+
+func (m *mockCollectionRepo) Get(ctx context.Context, name string) (*documentDomain.Collection, error) {
+	return m.collections[name], nil
+}
+
+func (m *mockCollectionRepo) List(ctx context.Context) ([]documentDomain.Collection, error) {
+	collections := []documentDomain.Collection{}
+	for _, c := range m.collections {
+		collections = append(collections, *c)
+	}
+	return collections, nil
+}
+
+func (m *mockCollectionRepo) Upsert(ctx context.Context, collection *documentDomain.Collection) error {
+	m.collections[collection.Name] = collection
+	return nil
+}
+
+func (m *mockCollectionRepo) Delete(ctx context.Context, name string) error {
+	delete(m.collections, name)
+	return nil
+}
+
+// embeddingServer answers embedding requests with vectors of the requested
+// size, recording each request, and chat requests with a fixed answer.
+func embeddingServer(t *testing.T) (*httptest.Server, *[]map[string]any) {
+	t.Helper()
+	var requests []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		var body any
+		if strings.HasSuffix(r.URL.Path, "/embeddings") {
+			requests = append(requests, req)
+			size := 3
+			if dims, ok := req["dimensions"].(float64); ok {
+				size = int(dims)
+			}
+			body = map[string]any{"data": []any{map[string]any{"embedding": make([]float64, size)}}}
+		} else {
+			body = map[string]any{
+				"choices": []any{map[string]any{"message": map[string]any{"role": "assistant", "content": "def main(): pass"}}},
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	return server, &requests
+}
+
+func TestQueryRAGSearchesCollectionSpace(t *testing.T) {
+	server, requests := embeddingServer(t)
+	defer server.Close()
+
+	chunkRepo := newMockChunkRepo()
+	chunkRepo.chunks = []documentDomain.Chunk{
+		{ID: "c1", DocumentID: "d1", Content: "Opening hours are 9 to 5"},
+		{ID: "c2", DocumentID: "d2", Content: "def main(): pass", Collection: "code", EmbeddingModel: "code-embed", Dimensions: 256},
+	}
+	svc := NewService(ServiceConfig{
+		Repo:         newMockDocumentRepo(),
+		ChunkRepo:    chunkRepo,
+		OpenAIClient: openai.NewClient("test-key", openai.WithBaseURL(server.URL)),
+		CollectionRepo: &mockCollectionRepo{collections: map[string]*documentDomain.Collection{
+			"code": {Name: "code", EmbeddingModel: "code-embed", Dimensions: 256},
+		}},
+	})
+
+	resp, err := svc.QueryRAG(context.Background(), documentDomain.RAGQuery{Query: "entry point?", Collection: "code"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(resp.RelevantChunks) != 1 || resp.RelevantChunks[0].ID != "c2" {
+		t.Errorf("Expected only the collection's chunk, got %+v", resp.RelevantChunks)
+	}
+	if len(*requests) != 1 || (*requests)[0]["model"] != "code-embed" || (*requests)[0]["dimensions"] != 256.0 {
+		t.Errorf("Expected the query embedded with the collection's model, got %v", *requests)
+	}
+
+	if _, err := svc.QueryRAG(context.Background(), documentDomain.RAGQuery{Query: "entry point?", Collection: "missing"}); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("Expected ErrInvalidQuery for an unknown collection, got %v", err)
+	}
+}
+
+func TestCreateDocumentInCollection(t *testing.T) {
+	server, _ := embeddingServer(t)
+	defer server.Close()
+
+	chunkRepo := newMockChunkRepo()
+	svc := NewService(ServiceConfig{
+		Repo:         newMockDocumentRepo(),
+		ChunkRepo:    chunkRepo,
+		OpenAIClient: openai.NewClient("test-key", openai.WithBaseURL(server.URL)),
+		Chunker:      chunker.New(500, 50),
+		CollectionRepo: &mockCollectionRepo{collections: map[string]*documentDomain.Collection{
+			"multilingual": {Name: "multilingual", EmbeddingModel: "text-embedding-3-large", Dimensions: 1024},
+		}},
+	})
+	admin := documentDomain.UserContext{UserID: "admin-1", IsAdmin: true}
+
+	_, err := svc.CreateDocument(context.Background(), admin, &documentDomain.Document{Title: "FAQ", Content: "Horario de atención", Collection: "multilingual"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(chunkRepo.chunks) == 0 {
+		t.Fatal("Expected chunks to be created")
+	}
+	chunk := chunkRepo.chunks[0]
+	if chunk.Collection != "multilingual" || chunk.EmbeddingModel != "text-embedding-3-large" || chunk.Dimensions != 1024 {
+		t.Errorf("Expected the chunk in the collection's space, got %s %s %d", chunk.Collection, chunk.EmbeddingModel, chunk.Dimensions)
+	}
+
+	_, err = svc.CreateDocument(context.Background(), admin, &documentDomain.Document{Title: "FAQ", Content: "text", Collection: "missing"})
+	if !errors.Is(err, ErrCollectionNotFound) {
+		t.Errorf("Expected ErrCollectionNotFound, got %v", err)
+	}
+}
+
+func TestSaveCollection(t *testing.T) {
+	repo := newMockDocumentRepo()
+	collections := &mockCollectionRepo{collections: map[string]*documentDomain.Collection{}}
+	svc := &service{repo: repo, collectionRepo: collections}
+	admin := documentDomain.UserContext{UserID: "admin-1", IsAdmin: true}
+
+	if err := svc.SaveCollection(context.Background(), documentDomain.UserContext{UserID: "u"}, &documentDomain.Collection{Name: "code"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden, got %v", err)
+	}
+	invalid := []documentDomain.Collection{
+		{Name: "Code!", EmbeddingModel: "m"},
+		{Name: "code"},
+		{Name: "code", EmbeddingModel: "m", Dimensions: -1},
+	}
+	for _, c := range invalid {
+		if err := svc.SaveCollection(context.Background(), admin, &c); !errors.Is(err, ErrInvalidCollection) {
+			t.Errorf("Expected ErrInvalidCollection for %+v, got %v", c, err)
+		}
+	}
+
+	if err := svc.SaveCollection(context.Background(), admin, &documentDomain.Collection{Name: "code", EmbeddingModel: "code-embed"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	repo.documents["d1"] = &documentDomain.Document{ID: "d1", Collection: "code"}
+
+	err := svc.SaveCollection(context.Background(), admin, &documentDomain.Collection{Name: "code", EmbeddingModel: "other", Description: "x"})
+	if !errors.Is(err, ErrCollectionInUse) {
+		t.Errorf("Expected ErrCollectionInUse when changing the model, got %v", err)
+	}
+	if err := svc.SaveCollection(context.Background(), admin, &documentDomain.Collection{Name: "code", EmbeddingModel: "code-embed", Description: "Source code"}); err != nil {
+		t.Errorf("Expected the description to change, got %v", err)
+	}
+	if err := svc.DeleteCollection(context.Background(), admin, "code"); !errors.Is(err, ErrCollectionInUse) {
+		t.Errorf("Expected ErrCollectionInUse on delete, got %v", err)
+	}
+
+	delete(repo.documents, "d1")
+	if err := svc.DeleteCollection(context.Background(), admin, "code"); err != nil {
+		t.Errorf("Expected the empty collection to be deleted, got %v", err)
+	}
+	if err := svc.DeleteCollection(context.Background(), admin, "code"); !errors.Is(err, ErrCollectionNotFound) {
+		t.Errorf("Expected ErrCollectionNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package document
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/vectormath"
+)
+
+const (
+	defaultMaxContextTokens = 3000
+	defaultDedupThreshold   = 0.95
+
+	// minTruncatedTokens is the smallest remainder worth filling with a
+	// truncated chunk; anything shorter is dropped instead.
+	minTruncatedTokens = 32
+)
+
+// estimateTokens approximates the token count of text using the common
+// four-characters-per-token heuristic.
+func estimateTokens(text string) int {
+	n := len([]rune(text))
+	if n == 0 {
+		return 0
+	}
+	return (n + 3) / 4
+}
+
+// isNearDuplicate reports whether two chunks carry essentially the same text.
+// Embeddings are compared when both chunks have them, otherwise the
+// whitespace-normalized content is compared.
+func isNearDuplicate(a, b documentDomain.Chunk, threshold float64) bool {
+	if len(a.Embedding) > 0 && len(a.Embedding) == len(b.Embedding) {
+		return vectormath.CosineSimilarity(a.Embedding, b.Embedding) >= threshold
+	}
+	return normalizeText(a.Content) == normalizeText(b.Content)
+}
+
+func normalizeText(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// truncateToTokens cuts text on a word boundary so it fits within maxTokens.
+func truncateToTokens(text string, maxTokens int) string {
+	words := strings.Fields(text)
+	var b strings.Builder
+	for _, w := range words {
+		next := w
+		if b.Len() > 0 {
+			next = " " + w
+		}
+		if estimateTokens(b.String()+next+" ...") > maxTokens {
+			break
+		}
+		b.WriteString(next)
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return b.String() + " ..."
+}
+
+// assembleContext selects chunks in relevance order until the token budget is
+// spent, skipping near-duplicates, then orders the selection by document and
+// position so adjacent passages read naturally in the prompt.
+func assembleContext(chunks []documentDomain.Chunk, maxTokens int, dedupThreshold float64) []documentDomain.Chunk {
+	if maxTokens <= 0 {
+		maxTokens = defaultMaxContextTokens
+	}
+	if dedupThreshold <= 0 {
+		dedupThreshold = defaultDedupThreshold
+	}
+
+	selected := make([]documentDomain.Chunk, 0, len(chunks))
+	docRank := make(map[string]int)
+	used := 0
+
+	for _, chunk := range chunks {
+		duplicate := false
+		for _, s := range selected {
+			if isNearDuplicate(chunk, s, dedupThreshold) {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			continue
+		}
+
+		tokens := estimateTokens(chunk.Content)
+		if used+tokens > maxTokens {
+			remaining := maxTokens - used
+			if remaining >= minTruncatedTokens || len(selected) == 0 {
+				if truncated := truncateToTokens(chunk.Content, remaining); truncated != "" {
+					chunk.Content = truncated
+					selected = append(selected, chunk)
+					if _, ok := docRank[chunk.DocumentID]; !ok {
+						docRank[chunk.DocumentID] = len(docRank)
+					}
+				}
+			}
+			break
+		}
+
+		used += tokens
+		selected = append(selected, chunk)
+		if _, ok := docRank[chunk.DocumentID]; !ok {
+			docRank[chunk.DocumentID] = len(docRank)
+		}
+	}
+
+	sort.SliceStable(selected, func(i, j int) bool {
+		ri, rj := docRank[selected[i].DocumentID], docRank[selected[j].DocumentID]
+		if ri != rj {
+			return ri < rj
+		}
+		return selected[i].ChunkIndex < selected[j].ChunkIndex
+	})
+
+	return selected
+}
+
+func buildContextPrompt(chunks []documentDomain.Chunk) string {
+	var b strings.Builder
+	for i, chunk := range chunks {
+		if label := sourceLabel(chunk.Source); label != "" {
+			b.WriteString(fmt.Sprintf("[Source %d: %s]\n%s\n\n", i+1, label, chunk.Content))
+			continue
+		}
+		b.WriteString(fmt.Sprintf("[Source %d]\n%s\n\n", i+1, chunk.Content))
+	}
+	return b.String()
+}
@@ -0,0 +1,115 @@
+package document
+
+import (
+	"strings"
+	"testing"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	if got := estimateTokens(""); got != 0 {
+		t.Errorf("Expected 0 tokens for empty text, got %d", got)
+	}
+	if got := estimateTokens("abcd"); got != 1 {
+		t.Errorf("Expected 1 token, got %d", got)
+	}
+	if got := estimateTokens("abcde"); got != 2 {
+		t.Errorf("Expected 2 tokens, got %d", got)
+	}
+}
+
+func TestAssembleContextDeduplicates(t *testing.T) {
+	chunks := []documentDomain.Chunk{
+		{ID: "a", DocumentID: "doc-1", ChunkIndex: 0, Content: "Store hours are 9 to 5", Embedding: []float64{1, 0, 0}},
+		{ID: "b", DocumentID: "doc-1", ChunkIndex: 1, Content: "Store hours are 9 to 5.", Embedding: []float64{0.999, 0.01, 0}},
+		{ID: "c", DocumentID: "doc-2", ChunkIndex: 0, Content: "Returns within 30 days", Embedding: []float64{0, 1, 0}},
+	}
+
+	result := assembleContext(chunks, 1000, 0.95)
+	if len(result) != 2 {
+		t.Fatalf("Expected 2 chunks after dedup, got %d", len(result))
+	}
+	if result[0].ID != "a" || result[1].ID != "c" {
+		t.Errorf("Expected chunks a and c, got %s and %s", result[0].ID, result[1].ID)
+	}
+}
+
+func TestAssembleContextDeduplicatesByTextWithoutEmbeddings(t *testing.T) {
+	chunks := []documentDomain.Chunk{
+		{ID: "a", DocumentID: "doc-1", Content: "Same   text here"},
+		{ID: "b", DocumentID: "doc-2", Content: "same text HERE"},
+	}
+
+	result := assembleContext(chunks, 1000, 0.95)
+	if len(result) != 1 {
+		t.Fatalf("Expected 1 chunk after dedup, got %d", len(result))
+	}
+}
+
+func TestAssembleContextOrdersByDocumentAndPosition(t *testing.T) {
+	chunks := []documentDomain.Chunk{
+		{ID: "d1-2", DocumentID: "doc-1", ChunkIndex: 2, Content: "third"},
+		{ID: "d2-0", DocumentID: "doc-2", ChunkIndex: 0, Content: "other"},
+		{ID: "d1-0", DocumentID: "doc-1", ChunkIndex: 0, Content: "first"},
+	}
+
+	result := assembleContext(chunks, 1000, 0.95)
+	want := []string{"d1-0", "d1-2", "d2-0"}
+	for i, id := range want {
+		if result[i].ID != id {
+			t.Errorf("Position %d: expected %s, got %s", i, id, result[i].ID)
+		}
+	}
+}
+
+func TestAssembleContextRespectsBudget(t *testing.T) {
+	long := strings.Repeat("word ", 100)
+	chunks := []documentDomain.Chunk{
+		{ID: "a", DocumentID: "doc-1", ChunkIndex: 0, Content: long},
+		{ID: "b", DocumentID: "doc-2", ChunkIndex: 0, Content: long + "different"},
+	}
+
+	result := assembleContext(chunks, estimateTokens(long)+40, 0.95)
+	if len(result) != 2 {
+		t.Fatalf("Expected 2 chunks, got %d", len(result))
+	}
+	if !strings.HasSuffix(result[1].Content, "...") {
+		t.Error("Expected second chunk to be truncated")
+	}
+
+	total := 0
+	for _, c := range result {
+		total += estimateTokens(c.Content)
+	}
+	if total > estimateTokens(long)+40 {
+		t.Errorf("Expected context within budget, got %d tokens", total)
+	}
+}
+
+func TestAssembleContextDropsSmallRemainder(t *testing.T) {
+	long := strings.Repeat("word ", 100)
+	chunks := []documentDomain.Chunk{
+		{ID: "a", DocumentID: "doc-1", Content: long},
+		{ID: "b", DocumentID: "doc-2", Content: long + "different"},
+	}
+
+	result := assembleContext(chunks, estimateTokens(long)+5, 0.95)
+	if len(result) != 1 {
+		t.Fatalf("Expected 1 chunk when remainder is too small, got %d", len(result))
+	}
+}
+
+func TestAssembleContextTruncatesOversizedFirstChunk(t *testing.T) {
+	chunks := []documentDomain.Chunk{
+		{ID: "a", DocumentID: "doc-1", Content: strings.Repeat("word ", 100)},
+	}
+
+	result := assembleContext(chunks, 10, 0.95)
+	if len(result) != 1 {
+		t.Fatalf("Expected truncated chunk, got %d chunks", len(result))
+	}
+	if estimateTokens(result[0].Content) > 10 {
+		t.Errorf("Expected truncated chunk within 10 tokens, got %d", estimateTokens(result[0].Content))
+	}
+}
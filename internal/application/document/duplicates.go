@@ -0,0 +1,131 @@
+package document
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+)
+
+var ErrDuplicateDocument = errors.New("duplicate document")
+
+// duplicateCandidates is how many similar opening chunks are checked for a
+// near-duplicate.
+const duplicateCandidates = 5
+
+// contentHash fingerprints content ignoring case and whitespace, so the
+// same text extracted twice hashes alike.
+func contentHash(content string) string {
+	normalized := strings.ToLower(strings.Join(strings.Fields(content), " "))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// findDuplicate returns the document doc repeats: one with the same
+// content hash or, when a similarity is configured, one whose opening
+// chunk is at least that similar to doc's. It returns nil if there is none.
+func (s *service) findDuplicate(ctx context.Context, doc *documentDomain.Document) (*documentDomain.Document, error) {
+	original, err := s.repo.GetByContentHash(ctx, doc.Collection, doc.ContentHash)
+	if err != nil || original != nil {
+		return original, err
+	}
+	if s.duplicateSimilarity <= 0 || s.embedder == nil || s.chunker == nil || s.chunkRepo == nil {
+		return nil, nil
+	}
+
+	textChunks := s.chunker.ChunkStructured(doc.Content)
+	if len(textChunks) == 0 {
+		return nil, nil
+	}
+	space, err := s.embeddingSpace(ctx, doc.Collection)
+	if err != nil {
+		return nil, err
+	}
+	embedding, err := s.embed(ctx, space, textChunks[0].Content)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := s.chunkRepo.Search(ctx, space, embedding, duplicateCandidates, s.duplicateSimilarity)
+	if err != nil {
+		return nil, err
+	}
+	for _, match := range matches {
+		if match.ChunkIndex != 0 {
+			continue
+		}
+		candidate, err := s.repo.GetByID(ctx, match.DocumentID)
+		if err != nil {
+			return nil, err
+		}
+		if candidate != nil && candidate.IsActive && candidate.DuplicateOf == "" {
+			return candidate, nil
+		}
+	}
+	return nil, nil
+}
+
+// applyDuplicatePolicy checks a new document for duplicates. It returns the
+// existing document's ID when the duplicate is merged into it, and links
+// doc to it when duplicates are linked.
+func (s *service) applyDuplicatePolicy(ctx context.Context, doc *documentDomain.Document) (string, error) {
+	doc.ContentHash = contentHash(doc.Content)
+	if s.duplicates == documentDomain.DuplicateAllow || doc.Content == "" {
+		return "", nil
+	}
+
+	original, err := s.findDuplicate(ctx, doc)
+	if err != nil {
+		// A failed check should not block the upload.
+		s.log.WarnContext(ctx, "failed to check document for duplicates", "error", err)
+		return "", nil
+	}
+	if original == nil {
+		return "", nil
+	}
+
+	switch s.duplicates {
+	case documentDomain.DuplicateReject:
+		return "", fmt.Errorf("%w of document %s", ErrDuplicateDocument, original.ID)
+	case documentDomain.DuplicateMerge:
+		doc.DuplicateOf = original.ID
+		return original.ID, nil
+	case documentDomain.DuplicateLink:
+		doc.DuplicateOf = original.ID
+	}
+	return "", nil
+}
+
+// releaseDuplicates hands a deleted document's knowledge to the documents
+// linked to it: the oldest gets chunks of its own and the rest link to it.
+func (s *service) releaseDuplicates(ctx context.Context, id string) {
+	duplicates, err := s.repo.ListDuplicates(ctx, id)
+	if err != nil {
+		s.log.WarnContext(ctx, "failed to list duplicates", "error", err, "document_id", id)
+		return
+	}
+	if len(duplicates) == 0 {
+		return
+	}
+
+	heir := duplicates[0]
+	heir.DuplicateOf = ""
+	if err := s.repo.SetDuplicateOf(ctx, heir.ID, ""); err != nil {
+		s.log.WarnContext(ctx, "failed to unlink document", "error", err, "document_id", heir.ID)
+		return
+	}
+	for _, duplicate := range duplicates[1:] {
+		if err := s.repo.SetDuplicateOf(ctx, duplicate.ID, heir.ID); err != nil {
+			s.log.WarnContext(ctx, "failed to relink document", "error", err, "document_id", duplicate.ID)
+		}
+	}
+
+	if s.embedder != nil && s.chunker != nil && s.chunkRepo != nil {
+		if err := s.createChunksForDocument(ctx, &heir); err != nil {
+			s.log.WarnContext(ctx, "failed to create chunks", "error", err, "document_id", heir.ID)
+		}
+	}
+}
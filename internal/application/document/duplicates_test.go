@@ -0,0 +1,100 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/chunker"
+	"github.com/elprogramadorgt/lucidRAG/pkg/openai"
+)
+
+func TestContentHash(t *testing.T) {
+	if contentHash("Opening  hours\n are 9 to 5") != contentHash("opening hours are 9 TO 5 ") {
+		t.Error("Expected case and whitespace to be ignored")
+	}
+	if contentHash("Opening hours") == contentHash("Closing hours") {
+		t.Error("Expected different content to hash differently")
+	}
+}
+
+func TestCreateDocumentDuplicatePolicies(t *testing.T) {
+	admin := documentDomain.UserContext{UserID: "admin-1", IsAdmin: true}
+	ctx := context.Background()
+
+	tests := []struct {
+		policy  documentDomain.DuplicatePolicy
+		wantErr error
+		wantID  string
+		wantDoc int
+	}{
+		{documentDomain.DuplicateAllow, nil, "doc_copy", 2},
+		{documentDomain.DuplicateReject, ErrDuplicateDocument, "", 1},
+		{documentDomain.DuplicateMerge, nil, "doc_original", 1},
+		{documentDomain.DuplicateLink, nil, "doc_copy", 2},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			repo := newMockDocumentRepo()
+			svc := NewService(ServiceConfig{Repo: repo, Duplicates: tt.policy})
+
+			if _, err := svc.CreateDocument(ctx, admin, &documentDomain.Document{Title: "original", Content: "Returns are free"}); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			copyDoc := &documentDomain.Document{Title: "copy", Content: "returns  are FREE"}
+			id, err := svc.CreateDocument(ctx, admin, copyDoc)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if id != tt.wantID || len(repo.documents) != tt.wantDoc {
+				t.Errorf("Expected id %q and %d documents, got %q and %d", tt.wantID, tt.wantDoc, id, len(repo.documents))
+			}
+			if tt.policy == documentDomain.DuplicateLink && copyDoc.DuplicateOf != "doc_original" {
+				t.Errorf("Expected the copy to link to the original, got %q", copyDoc.DuplicateOf)
+			}
+		})
+	}
+}
+
+func TestNearDuplicateIsLinked(t *testing.T) {
+	server, _ := embeddingServer(t)
+	defer server.Close()
+
+	repo := newMockDocumentRepo()
+	chunkRepo := newMockChunkRepo()
+	repo.documents["doc_original"] = &documentDomain.Document{ID: "doc_original", Content: "Returns are free", IsActive: true}
+	chunkRepo.chunks = []documentDomain.Chunk{{ID: "c1", DocumentID: "doc_original", ChunkIndex: 0}}
+	svc := NewService(ServiceConfig{
+		Repo:                repo,
+		ChunkRepo:           chunkRepo,
+		OpenAIClient:        openai.NewClient("test-key", openai.WithBaseURL(server.URL)),
+		Chunker:             chunker.New(500, 50),
+		Duplicates:          documentDomain.DuplicateLink,
+		DuplicateSimilarity: 0.9,
+	})
+	ctx := context.Background()
+	admin := documentDomain.UserContext{UserID: "admin-1", IsAdmin: true}
+
+	doc := &documentDomain.Document{Title: "revised", Content: "Returns are free within 30 days"}
+	if _, err := svc.CreateDocument(ctx, admin, doc); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if doc.DuplicateOf != "doc_original" {
+		t.Errorf("Expected a link to the original, got %q", doc.DuplicateOf)
+	}
+	if n, _ := chunkRepo.CountByDocumentID(ctx, doc.ID); n != 0 {
+		t.Errorf("Expected a linked document to have no chunks, got %d", n)
+	}
+
+	// Deleting the original hands its knowledge to the duplicate.
+	if err := svc.DeleteDocument(ctx, admin, "doc_original"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if repo.documents[doc.ID].DuplicateOf != "" {
+		t.Error("Expected the duplicate to be unlinked")
+	}
+	if n, _ := chunkRepo.CountByDocumentID(ctx, doc.ID); n == 0 {
+		t.Error("Expected the duplicate to get chunks of its own")
+	}
+}
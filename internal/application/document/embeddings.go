@@ -0,0 +1,266 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/breaker"
+)
+
+var (
+	ErrInvalidMigration = errors.New("invalid embedding migration")
+	ErrMigrationRunning = errors.New("an embedding migration is already running")
+	ErrNoMigration      = errors.New("no embedding migration is running")
+)
+
+const (
+	// migrationBatchSize is how many chunks are embedded per request.
+	migrationBatchSize = 100
+	// migrationBatchTime is left before a run's deadline when starting a
+	// batch, so the batch can finish within the run.
+	migrationBatchTime = 10 * time.Second
+	// maxFailedRuns consecutive failed runs fail a migration.
+	maxFailedRuns = 5
+)
+
+// activeEmbeddingModel returns the model chunk vectors are made with: the
+// one the last migration switched to, or the configured one.
+func (s *service) activeEmbeddingModel(ctx context.Context) string {
+	if s.chunkRepo == nil {
+		return s.embeddingModel
+	}
+	index, err := s.chunkRepo.EmbeddingIndex(ctx)
+	if err != nil {
+		s.log.WarnContext(ctx, "failed to read embedding index", "error", err)
+		return s.embeddingModel
+	}
+	if index.ActiveModel == "" {
+		return s.embeddingModel
+	}
+	return index.ActiveModel
+}
+
+func (s *service) GetEmbeddingStatus(ctx context.Context, userCtx documentDomain.UserContext) (*documentDomain.EmbeddingStatus, error) {
+	if !userCtx.IsAdmin {
+		return nil, ErrForbidden
+	}
+	status := &documentDomain.EmbeddingStatus{ActiveModel: s.activeEmbeddingModel(ctx)}
+	if s.migrationRepo != nil {
+		migration, err := s.migrationRepo.Latest(ctx)
+		if err != nil {
+			return nil, err
+		}
+		status.Migration = migration
+	}
+	return status, nil
+}
+
+func (s *service) StartEmbeddingMigration(ctx context.Context, userCtx documentDomain.UserContext, model string) (*documentDomain.EmbeddingMigration, error) {
+	if !userCtx.IsAdmin {
+		return nil, ErrForbidden
+	}
+	model = strings.TrimSpace(model)
+	if model == "" {
+		return nil, fmt.Errorf("%w: model is required", ErrInvalidMigration)
+	}
+	if s.migrationRepo == nil || s.chunkRepo == nil || s.embedder == nil {
+		return nil, fmt.Errorf("%w: embeddings are not configured", ErrInvalidMigration)
+	}
+
+	latest, err := s.migrationRepo.Latest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && latest.Status == documentDomain.MigrationRunning {
+		return nil, ErrMigrationRunning
+	}
+	active := s.activeEmbeddingModel(ctx)
+	if model == active {
+		return nil, fmt.Errorf("%w: %s is already in use", ErrInvalidMigration, model)
+	}
+	if !s.offered(model) {
+		return nil, fmt.Errorf("%w: %s is no longer offered by its provider", ErrInvalidMigration, model)
+	}
+	// Rejecting an unknown model now beats failing in the background.
+	if _, err := s.embedder.CreateEmbeddingWithDimensions(ctx, "embedding model check", model, 0); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMigration, err)
+	}
+
+	migration := &documentDomain.EmbeddingMigration{
+		FromModel:   active,
+		ToModel:     model,
+		Status:      documentDomain.MigrationRunning,
+		RequestedBy: userCtx.UserID,
+	}
+	if _, err := s.migrationRepo.Create(ctx, migration); err != nil {
+		return nil, err
+	}
+	return migration, nil
+}
+
+func (s *service) CancelEmbeddingMigration(ctx context.Context, userCtx documentDomain.UserContext) error {
+	if !userCtx.IsAdmin {
+		return ErrForbidden
+	}
+	if s.migrationRepo == nil {
+		return ErrNoMigration
+	}
+	migration, err := s.migrationRepo.Latest(ctx)
+	if err != nil {
+		return err
+	}
+	if migration == nil || migration.Status != documentDomain.MigrationRunning {
+		return ErrNoMigration
+	}
+
+	now := time.Now()
+	migration.Status = documentDomain.MigrationCancelled
+	migration.CompletedAt = &now
+	ok, err := s.migrationRepo.Update(ctx, migration)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNoMigration
+	}
+	return nil
+}
+
+// Embedder embeds one text. Zero dimensions keeps the model's size.
+// *openai.Client and *tei.Client satisfy it.
+type Embedder interface {
+	CreateEmbeddingWithDimensions(ctx context.Context, text string, model string, dimensions int) ([]float64, error)
+}
+
+// BatchEmbedder embeds texts in one request.
+type BatchEmbedder interface {
+	CreateEmbeddings(ctx context.Context, texts []string, model string) ([][]float64, error)
+}
+
+// EmbeddingMigrationJob carries out the running embedding migration: it
+// stages a vector from the new model on every chunk, in batches, and once
+// none is left switches queries to the new model. Queries keep using the
+// old vectors meanwhile. It is meant to run every minute from the
+// scheduler; each run continues where the last one stopped.
+type EmbeddingMigrationJob struct {
+	migrations documentDomain.EmbeddingMigrationRepository
+	chunkRepo  documentDomain.ChunkRepository
+	embedder   BatchEmbedder
+}
+
+func NewEmbeddingMigrationJob(migrations documentDomain.EmbeddingMigrationRepository, chunkRepo documentDomain.ChunkRepository, embedder BatchEmbedder) *EmbeddingMigrationJob {
+	return &EmbeddingMigrationJob{
+		migrations: migrations,
+		chunkRepo:  chunkRepo,
+		embedder:   embedder,
+	}
+}
+
+func (j *EmbeddingMigrationJob) Run(ctx context.Context) error {
+	migration, err := j.migrations.Latest(ctx)
+	if err != nil {
+		return err
+	}
+	if migration == nil || migration.Status != documentDomain.MigrationRunning {
+		return nil
+	}
+
+	index, err := j.chunkRepo.EmbeddingIndex(ctx)
+	if err != nil {
+		return err
+	}
+	if !index.Promoting {
+		if index.StagedModel != migration.ToModel {
+			if err := j.chunkRepo.StageModel(ctx, migration.FromModel, migration.ToModel); err != nil {
+				return err
+			}
+		}
+		done, err := j.stage(ctx, migration)
+		if err != nil || !done {
+			return err
+		}
+	}
+
+	if err := j.chunkRepo.ActivateStaged(ctx); err != nil {
+		return err
+	}
+	now := time.Now()
+	migration.Status = documentDomain.MigrationCompleted
+	migration.CompletedAt = &now
+	_, err = j.migrations.Update(ctx, migration)
+	return err
+}
+
+// stage embeds unstaged chunks until none is left, reporting true, or the
+// run runs out of time or the migration ends.
+func (j *EmbeddingMigrationJob) stage(ctx context.Context, migration *documentDomain.EmbeddingMigration) (bool, error) {
+	for {
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < migrationBatchTime {
+			return false, nil
+		}
+
+		chunks, err := j.chunkRepo.ListUnstaged(ctx, migrationBatchSize)
+		if err != nil {
+			return false, err
+		}
+		if len(chunks) == 0 {
+			return true, nil
+		}
+
+		texts := make([]string, len(chunks))
+		for i, chunk := range chunks {
+			texts[i] = chunk.Content
+		}
+		vectors, err := j.embedder.CreateEmbeddings(ctx, texts, migration.ToModel)
+		if err == nil && len(vectors) != len(chunks) {
+			err = fmt.Errorf("got %d embeddings for %d chunks", len(vectors), len(chunks))
+		}
+		if err != nil {
+			return false, j.recordFailure(ctx, migration, err)
+		}
+
+		staged := make(map[string][]float64, len(chunks))
+		for i, chunk := range chunks {
+			staged[chunk.ID] = vectors[i]
+		}
+		if err := j.chunkRepo.SetStaged(ctx, staged); err != nil {
+			return false, err
+		}
+
+		remaining, err := j.chunkRepo.CountUnstaged(ctx)
+		if err != nil {
+			return false, err
+		}
+		migration.Done += int64(len(chunks))
+		migration.Total = migration.Done + remaining
+		migration.Error, migration.FailedRuns = "", 0
+		ok, err := j.migrations.Update(ctx, migration)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+}
+
+// recordFailure notes a failed run on the migration, failing it after
+// maxFailedRuns in a row. Runs cut short by their deadline or by an open
+// circuit breaker are retried without counting.
+func (j *EmbeddingMigrationJob) recordFailure(ctx context.Context, migration *documentDomain.EmbeddingMigration, cause error) error {
+	if ctx.Err() != nil || errors.Is(cause, breaker.ErrOpen) {
+		return nil
+	}
+	migration.Error = cause.Error()
+	migration.FailedRuns++
+	if migration.FailedRuns >= maxFailedRuns {
+		now := time.Now()
+		migration.Status = documentDomain.MigrationFailed
+		migration.CompletedAt = &now
+	}
+	if _, err := j.migrations.Update(ctx, migration); err != nil {
+		return err
+	}
+	return fmt.Errorf("embedding migration: %w", cause)
+}
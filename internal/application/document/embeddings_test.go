@@ -0,0 +1,164 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/breaker"
+)
+
+type mockMigrationRepo struct {
+	migrations []*documentDomain.EmbeddingMigration
+}
+
+func (m *mockMigrationRepo) Create(ctx context.Context, migration *documentDomain.EmbeddingMigration) (string, error) {
+	migration.ID = fmt.Sprintf("mig-%d", len(m.migrations)+1)
+	m.migrations = append(m.migrations, migration)
+	return migration.ID, nil
+}
+
+func (m *mockMigrationRepo) Latest(ctx context.Context) (*documentDomain.EmbeddingMigration, error) {
+	if len(m.migrations) == 0 {
+		return nil, nil
+	}
+	latest := *m.migrations[len(m.migrations)-1]
+	return &latest, nil
+}
+
+func (m *mockMigrationRepo) Update(ctx context.Context, migration *documentDomain.EmbeddingMigration) (bool, error) {
+	for i, existing := range m.migrations {
+		if existing.ID == migration.ID {
+			if existing.Status != documentDomain.MigrationRunning {
+				return false, nil
+			}
+			saved := *migration
+			m.migrations[i] = &saved
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type mockEmbedder struct {
+	calls int
+	err   error
+}
+
+func (m *mockEmbedder) CreateEmbeddings(ctx context.Context, texts []string, model string) ([][]float64, error) {
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	vectors := make([][]float64, len(texts))
+	for i := range texts {
+		vectors[i] = []float64{1, 2, 3}
+	}
+	return vectors, nil
+}
+
+func chunksForMigration(n int) *mockChunkRepo {
+	repo := newMockChunkRepo()
+	for i := 0; i < n; i++ {
+		repo.chunks = append(repo.chunks, documentDomain.Chunk{ID: fmt.Sprintf("c%d", i), Content: "text", Embedding: []float64{1}})
+	}
+	return repo
+}
+
+func TestEmbeddingMigrationJobSwitchesWhenStaged(t *testing.T) {
+	chunks := chunksForMigration(migrationBatchSize + 5)
+	migrations := &mockMigrationRepo{}
+	_, _ = migrations.Create(context.Background(), &documentDomain.EmbeddingMigration{
+		FromModel: "text-embedding-ada-002", ToModel: "text-embedding-3-small", Status: documentDomain.MigrationRunning,
+	})
+	embedder := &mockEmbedder{}
+
+	if err := NewEmbeddingMigrationJob(migrations, chunks, embedder).Run(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	migration, _ := migrations.Latest(context.Background())
+	if migration.Status != documentDomain.MigrationCompleted || migration.CompletedAt == nil {
+		t.Errorf("Expected a completed migration, got %s", migration.Status)
+	}
+	if migration.Done != int64(len(chunks.chunks)) || migration.Total != migration.Done {
+		t.Errorf("Expected %d of %d chunks done, got %d of %d", len(chunks.chunks), len(chunks.chunks), migration.Done, migration.Total)
+	}
+	if embedder.calls != 2 {
+		t.Errorf("Expected 2 batches, got %d", embedder.calls)
+	}
+	if chunks.index.ActiveModel != "text-embedding-3-small" || len(chunks.chunks[0].Embedding) != 3 {
+		t.Errorf("Expected the new vectors to be active, got %+v", chunks.index)
+	}
+
+	// Once completed, runs do nothing.
+	if err := NewEmbeddingMigrationJob(migrations, chunks, embedder).Run(context.Background()); err != nil || embedder.calls != 2 {
+		t.Errorf("Expected an idle run, got %v after %d calls", err, embedder.calls)
+	}
+}
+
+func TestEmbeddingMigrationJobFailures(t *testing.T) {
+	chunks := chunksForMigration(3)
+	migrations := &mockMigrationRepo{}
+	_, _ = migrations.Create(context.Background(), &documentDomain.EmbeddingMigration{
+		FromModel: "a", ToModel: "b", Status: documentDomain.MigrationRunning,
+	})
+	embedder := &mockEmbedder{err: fmt.Errorf("wrap: %w", breaker.ErrOpen)}
+	job := NewEmbeddingMigrationJob(migrations, chunks, embedder)
+
+	if err := job.Run(context.Background()); err != nil {
+		t.Errorf("Expected an open breaker to be retried quietly, got %v", err)
+	}
+	if migration, _ := migrations.Latest(context.Background()); migration.FailedRuns != 0 {
+		t.Errorf("Expected an open breaker not to count, got %d failed runs", migration.FailedRuns)
+	}
+
+	embedder.err = errors.New("model not found")
+	for i := 0; i < maxFailedRuns; i++ {
+		if err := job.Run(context.Background()); err == nil {
+			t.Errorf("Expected run %d to fail", i)
+		}
+	}
+	migration, _ := migrations.Latest(context.Background())
+	if migration.Status != documentDomain.MigrationFailed || migration.Error != "model not found" {
+		t.Errorf("Expected a failed migration with its error, got %s %q", migration.Status, migration.Error)
+	}
+	if chunks.index.ActiveModel == "b" {
+		t.Error("Expected queries to keep the old model")
+	}
+}
+
+func TestStartEmbeddingMigration(t *testing.T) {
+	admin := documentDomain.UserContext{UserID: "admin-1", IsAdmin: true}
+	migrations := &mockMigrationRepo{}
+	svc := &service{
+		chunkRepo:      chunksForMigration(1),
+		migrationRepo:  migrations,
+		embeddingModel: "text-embedding-ada-002",
+	}
+
+	if _, err := svc.StartEmbeddingMigration(context.Background(), documentDomain.UserContext{UserID: "u"}, "m"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden, got %v", err)
+	}
+	if _, err := svc.StartEmbeddingMigration(context.Background(), admin, "text-embedding-3-small"); !errors.Is(err, ErrInvalidMigration) {
+		t.Errorf("Expected ErrInvalidMigration without OpenAI, got %v", err)
+	}
+
+	_, _ = migrations.Create(context.Background(), &documentDomain.EmbeddingMigration{Status: documentDomain.MigrationRunning})
+	if err := svc.CancelEmbeddingMigration(context.Background(), admin); err != nil {
+		t.Fatalf("Expected the running migration to be cancelled, got %v", err)
+	}
+	if migration, _ := migrations.Latest(context.Background()); migration.Status != documentDomain.MigrationCancelled {
+		t.Errorf("Expected cancelled, got %s", migration.Status)
+	}
+	if err := svc.CancelEmbeddingMigration(context.Background(), admin); !errors.Is(err, ErrNoMigration) {
+		t.Errorf("Expected ErrNoMigration, got %v", err)
+	}
+
+	status, err := svc.GetEmbeddingStatus(context.Background(), admin)
+	if err != nil || status.ActiveModel != "text-embedding-ada-002" || status.Migration == nil {
+		t.Errorf("Expected the configured model and last migration, got %+v, %v", status, err)
+	}
+}
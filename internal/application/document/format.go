@@ -0,0 +1,182 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+)
+
+var ErrInvalidProfile = errors.New("invalid format profile")
+
+// maxProfileTokens bounds MaxTokens to what the chat models accept for a reply.
+const maxProfileTokens = 4096
+
+func (s *service) ListFormatProfiles(ctx context.Context, userCtx documentDomain.UserContext) ([]documentDomain.FormatProfile, error) {
+	if !userCtx.IsAdmin {
+		return nil, ErrForbidden
+	}
+
+	var saved []documentDomain.FormatProfile
+	if s.formatRepo != nil {
+		var err error
+		if saved, err = s.formatRepo.List(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	profiles := make([]documentDomain.FormatProfile, 0, len(documentDomain.Channels))
+	for _, channel := range documentDomain.Channels {
+		profile := *documentDomain.DefaultFormatProfile(channel)
+		for _, p := range saved {
+			if p.Channel == channel {
+				profile = p
+			}
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+func (s *service) SaveFormatProfile(ctx context.Context, userCtx documentDomain.UserContext, profile *documentDomain.FormatProfile) error {
+	if !userCtx.IsAdmin {
+		return ErrForbidden
+	}
+	if s.formatRepo == nil {
+		return ErrInvalidProfile
+	}
+
+	if !slices.Contains(documentDomain.Channels, profile.Channel) {
+		return fmt.Errorf("%w: unknown channel %q", ErrInvalidProfile, profile.Channel)
+	}
+	switch profile.Citations {
+	case documentDomain.CitationNone, documentDomain.CitationInline, documentDomain.CitationFootnotes:
+	default:
+		return fmt.Errorf("%w: citations must be none, inline or footnotes", ErrInvalidProfile)
+	}
+	switch profile.Emoji {
+	case documentDomain.EmojiAllow, documentDomain.EmojiNone:
+	default:
+		return fmt.Errorf("%w: emoji must be allow or none", ErrInvalidProfile)
+	}
+	if profile.MaxTokens < 0 || profile.MaxTokens > maxProfileTokens {
+		return fmt.Errorf("%w: max_tokens must be between 0 and %d", ErrInvalidProfile, maxProfileTokens)
+	}
+
+	profile.UpdatedBy = userCtx.UserID
+	if err := s.formatRepo.Upsert(ctx, profile); err != nil {
+		return err
+	}
+
+	// Cached answers were shaped by the old profile.
+	s.invalidateAnswers(ctx)
+	return nil
+}
+
+// formatProfile returns the profile for channel, falling back to the
+// built-in default when none is saved or the store is unavailable.
+func (s *service) formatProfile(ctx context.Context, channel documentDomain.Channel) *documentDomain.FormatProfile {
+	if channel == "" {
+		channel = documentDomain.ChannelWeb
+	}
+	if s.formatRepo != nil {
+		profile, err := s.formatRepo.Get(ctx, channel)
+		if err != nil {
+			s.log.WarnContext(ctx, "failed to load format profile", "error", err, "channel", channel)
+		}
+		if profile != nil {
+			return profile
+		}
+	}
+	return documentDomain.DefaultFormatProfile(channel)
+}
+
+// formatInstructions tells the model how the profile wants answers written.
+func formatInstructions(profile *documentDomain.FormatProfile) string {
+	var b strings.Builder
+
+	if profile.Markdown {
+		b.WriteString("\nFormat the answer with Markdown where it helps readability.")
+	} else {
+		b.WriteString("\nReply in plain text: no Markdown, headings, bold text or tables.")
+	}
+
+	switch profile.Citations {
+	case documentDomain.CitationInline:
+		b.WriteString("\nCite the sources you use inline as [n], with n the source number from the context.")
+	case documentDomain.CitationFootnotes:
+		b.WriteString("\nEnd the answer with a line \"Sources:\" listing the numbers of the sources you used.")
+	default:
+		b.WriteString("\nDo not mention source numbers.")
+	}
+
+	if profile.Emoji == documentDomain.EmojiNone {
+		b.WriteString("\nDo not use emoji.")
+	}
+
+	if profile.MaxTokens > 0 && profile.MaxTokens <= 300 {
+		b.WriteString("\nKeep the answer to a few short sentences.")
+	}
+
+	return b.String()
+}
+
+var (
+	headingPattern   = regexp.MustCompile(`(?m)^#{1,6}\s+`)
+	emphasisPattern  = regexp.MustCompile(`(\*\*|__|~~)(.+?)(\*\*|__|~~)`)
+	codePattern      = regexp.MustCompile("`+([^`]*)`+")
+	fencePattern     = regexp.MustCompile("(?m)^```.*\n?")
+	linkPattern      = regexp.MustCompile(`!?\[([^\]]+)\]\(([^)\s]+)\)`)
+	bulletPattern    = regexp.MustCompile(`(?m)^(\s*)[*+]\s+`)
+	citationPattern  = regexp.MustCompile(`\s?\[(?:[Ss]ource\s*)?\d+(?:\s*,\s*\d+)*\]`)
+	doubleSpaceRegex = regexp.MustCompile(` {2,}`)
+)
+
+// applyFormat enforces the parts of the profile the model may have ignored.
+func applyFormat(answer string, profile *documentDomain.FormatProfile) string {
+	if !profile.Markdown {
+		answer = stripMarkdown(answer)
+	}
+	if profile.Citations == documentDomain.CitationNone {
+		answer = citationPattern.ReplaceAllString(answer, "")
+	}
+	if profile.Emoji == documentDomain.EmojiNone {
+		answer = stripEmoji(answer)
+	}
+	return strings.TrimSpace(answer)
+}
+
+func stripMarkdown(text string) string {
+	text = fencePattern.ReplaceAllString(text, "")
+	text = headingPattern.ReplaceAllString(text, "")
+	text = linkPattern.ReplaceAllString(text, "$1 ($2)")
+	text = emphasisPattern.ReplaceAllString(text, "$2")
+	text = codePattern.ReplaceAllString(text, "$1")
+	return bulletPattern.ReplaceAllString(text, "$1- ")
+}
+
+func stripEmoji(text string) string {
+	text = strings.Map(func(r rune) rune {
+		if isEmoji(r) {
+			return -1
+		}
+		return r
+	}, text)
+	return doubleSpaceRegex.ReplaceAllString(text, " ")
+}
+
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // pictographs, emoticons, flags
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // misc symbols and dingbats
+		return true
+	case r == 0xFE0F || r == 0x200D: // emoji presentation selector, joiner
+		return true
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+)
+
+// mockFormatRepo is a mock implementation of FormatProfileRepository
+type mockFormatRepo struct {
+	profiles map[documentDomain.Channel]documentDomain.FormatProfile
+}
+
+func (m *mockFormatRepo) Get(ctx context.Context, channel documentDomain.Channel) (*documentDomain.FormatProfile, error) {
+	if p, ok := m.profiles[channel]; ok {
+		return &p, nil
+	}
+	return nil, nil
+}
+
+func (m *mockFormatRepo) List(ctx context.Context) ([]documentDomain.FormatProfile, error) {
+	var out []documentDomain.FormatProfile
+	for _, p := range m.profiles {
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func (m *mockFormatRepo) Upsert(ctx context.Context, profile *documentDomain.FormatProfile) error {
+	m.profiles[profile.Channel] = *profile
+	return nil
+}
+
+func TestApplyFormatPlainText(t *testing.T) {
+	profile := documentDomain.DefaultFormatProfile(documentDomain.ChannelWhatsApp)
+	profile.Emoji = documentDomain.EmojiNone
+	answer := "## Store hours\n* **Mon-Fri**: 9 AM - 6 PM [1]\n* Sat: see [our site](https://shop.example.com) [Source 2] 😊"
+
+	got := applyFormat(answer, profile)
+
+	want := "Store hours\n- Mon-Fri: 9 AM - 6 PM\n- Sat: see our site (https://shop.example.com)"
+	if got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestApplyFormatKeepsMarkdownAndCitations(t *testing.T) {
+	profile := documentDomain.DefaultFormatProfile(documentDomain.ChannelWeb)
+	answer := "**Returns** are accepted within 30 days [1]."
+
+	if got := applyFormat(answer, profile); got != answer {
+		t.Errorf("Expected answer unchanged, got %q", got)
+	}
+}
+
+func TestFormatInstructions(t *testing.T) {
+	whatsapp := formatInstructions(documentDomain.DefaultFormatProfile(documentDomain.ChannelWhatsApp))
+	if !strings.Contains(whatsapp, "plain text") || !strings.Contains(whatsapp, "few short sentences") {
+		t.Errorf("Expected short plain-text instructions, got %q", whatsapp)
+	}
+
+	web := formatInstructions(documentDomain.DefaultFormatProfile(documentDomain.ChannelWeb))
+	if !strings.Contains(web, "Markdown") || !strings.Contains(web, "[n]") || !strings.Contains(web, "emoji") {
+		t.Errorf("Expected markdown, inline citation and emoji instructions, got %q", web)
+	}
+}
+
+func TestSaveFormatProfile(t *testing.T) {
+	repo := &mockFormatRepo{profiles: map[documentDomain.Channel]documentDomain.FormatProfile{}}
+	s := &service{formatRepo: repo}
+	admin := documentDomain.UserContext{UserID: "admin-1", IsAdmin: true}
+	ctx := context.Background()
+
+	profile := &documentDomain.FormatProfile{
+		Channel: documentDomain.ChannelWhatsApp, MaxTokens: 150,
+		Citations: documentDomain.CitationFootnotes, Emoji: documentDomain.EmojiNone,
+	}
+	if err := s.SaveFormatProfile(ctx, admin, profile); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := s.formatProfile(ctx, documentDomain.ChannelWhatsApp); got.MaxTokens != 150 || got.UpdatedBy != "admin-1" {
+		t.Errorf("Expected saved profile to be used, got %+v", got)
+	}
+
+	profiles, err := s.ListFormatProfiles(ctx, admin)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(profiles) != len(documentDomain.Channels) {
+		t.Errorf("Expected a profile per channel, got %d", len(profiles))
+	}
+
+	bad := *profile
+	bad.Channel = "fax"
+	if err := s.SaveFormatProfile(ctx, admin, &bad); !errors.Is(err, ErrInvalidProfile) {
+		t.Errorf("Expected ErrInvalidProfile for an unknown channel, got %v", err)
+	}
+	bad = *profile
+	bad.Citations = "endnotes"
+	if err := s.SaveFormatProfile(ctx, admin, &bad); !errors.Is(err, ErrInvalidProfile) {
+		t.Errorf("Expected ErrInvalidProfile for an unknown citation style, got %v", err)
+	}
+	if err := s.SaveFormatProfile(ctx, documentDomain.UserContext{UserID: "u"}, profile); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden for non-admins, got %v", err)
+	}
+}
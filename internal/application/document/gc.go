@@ -0,0 +1,66 @@
+package document
+
+import (
+	"context"
+	"time"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+)
+
+const (
+	// gcBatchSize caps how many unchunked documents one collection chunks.
+	gcBatchSize = 50
+	// gcGracePeriod leaves recently saved documents alone, as their chunks
+	// may still be on the way.
+	gcGracePeriod = 10 * time.Minute
+)
+
+func (s *service) CollectChunkGarbage(ctx context.Context, userCtx documentDomain.UserContext, dryRun bool) (*documentDomain.ChunkGCResult, error) {
+	if !userCtx.IsAdmin {
+		return nil, ErrForbidden
+	}
+
+	result := &documentDomain.ChunkGCResult{
+		DryRun:               dryRun,
+		OrphanDocumentIDs:    []string{},
+		UnchunkedDocumentIDs: []string{},
+	}
+	if s.chunkRepo == nil {
+		return result, nil
+	}
+
+	orphans, err := s.chunkRepo.ListOrphanDocumentIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range orphans {
+		count, err := s.chunkRepo.CountByDocumentID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if !dryRun {
+			if err := s.chunkRepo.DeleteByDocumentID(ctx, id); err != nil {
+				return nil, err
+			}
+		}
+		result.OrphanDocumentIDs = append(result.OrphanDocumentIDs, id)
+		result.OrphanChunks += count
+	}
+
+	docs, err := s.repo.ListUnchunked(ctx, time.Now().Add(-gcGracePeriod), gcBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	for i := range docs {
+		result.UnchunkedDocumentIDs = append(result.UnchunkedDocumentIDs, docs[i].ID)
+		if dryRun || s.embedder == nil || s.chunker == nil {
+			continue
+		}
+		if err := s.createChunksForDocument(ctx, &docs[i]); err != nil {
+			s.log.WarnContext(ctx, "failed to chunk document", "error", err, "document_id", docs[i].ID)
+			continue
+		}
+		result.Rechunked++
+	}
+	return result, nil
+}
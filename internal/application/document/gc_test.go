@@ -0,0 +1,68 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/chunker"
+	"github.com/elprogramadorgt/lucidRAG/pkg/openai"
+)
+
+func TestCollectChunkGarbage(t *testing.T) {
+	server, _ := embeddingServer(t)
+	defer server.Close()
+
+	repo := newMockDocumentRepo()
+	chunkRepo := newMockChunkRepo()
+	repo.chunks, chunkRepo.documents = chunkRepo, repo.documents
+	repo.documents["kept"] = &documentDomain.Document{ID: "kept", Content: "Opening hours"}
+	repo.documents["empty"] = &documentDomain.Document{ID: "empty", Content: "Returns are free within 30 days"}
+	repo.documents["new"] = &documentDomain.Document{ID: "new", Content: "Still chunking", UpdatedAt: time.Now()}
+	chunkRepo.chunks = []documentDomain.Chunk{
+		{ID: "c1", DocumentID: "kept"},
+		{ID: "c2", DocumentID: "gone"},
+		{ID: "c3", DocumentID: "gone"},
+	}
+	svc := NewService(ServiceConfig{
+		Repo:         repo,
+		ChunkRepo:    chunkRepo,
+		OpenAIClient: openai.NewClient("test-key", openai.WithBaseURL(server.URL)),
+		Chunker:      chunker.New(500, 50),
+	})
+	ctx := context.Background()
+	admin := documentDomain.UserContext{UserID: "admin-1", IsAdmin: true}
+
+	if _, err := svc.CollectChunkGarbage(ctx, documentDomain.UserContext{UserID: "u"}, true); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden, got %v", err)
+	}
+
+	result, err := svc.CollectChunkGarbage(ctx, admin, true)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(result.OrphanDocumentIDs) != 1 || result.OrphanChunks != 2 || len(result.UnchunkedDocumentIDs) != 1 {
+		t.Errorf("Expected 2 orphaned chunks and 1 unchunked document, got %+v", result)
+	}
+	if len(chunkRepo.chunks) != 3 || result.Rechunked != 0 {
+		t.Error("Expected a dry run to change nothing")
+	}
+
+	result, err = svc.CollectChunkGarbage(ctx, admin, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Rechunked != 1 {
+		t.Errorf("Expected 1 document chunked again, got %d", result.Rechunked)
+	}
+	for _, chunk := range chunkRepo.chunks {
+		if chunk.DocumentID == "gone" {
+			t.Error("Expected orphaned chunks to be removed")
+		}
+	}
+	if n, _ := chunkRepo.CountByDocumentID(ctx, "empty"); n == 0 {
+		t.Error("Expected the unchunked document to have chunks")
+	}
+}
@@ -0,0 +1,230 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+)
+
+var (
+	ErrGlossaryEntryNotFound = errors.New("glossary entry not found")
+	ErrInvalidGlossaryEntry  = errors.New("invalid glossary entry")
+	ErrDuplicateGlossaryTerm = errors.New("glossary term already exists")
+)
+
+const (
+	maxGlossaryPhraseLen = 100
+	maxGlossarySynonyms  = 20
+)
+
+func (s *service) CreateGlossaryEntry(ctx context.Context, userCtx documentDomain.UserContext, entry *documentDomain.GlossaryEntry) (string, error) {
+	if !userCtx.IsAdmin {
+		return "", ErrForbidden
+	}
+	if s.glossaryRepo == nil {
+		return "", fmt.Errorf("%w: glossary is not configured", ErrInvalidGlossaryEntry)
+	}
+	if err := normalizeGlossaryEntry(entry); err != nil {
+		return "", err
+	}
+	if err := s.checkGlossaryTerm(ctx, entry); err != nil {
+		return "", err
+	}
+	entry.CreatedBy = userCtx.UserID
+
+	id, err := s.glossaryRepo.Create(ctx, entry)
+	if err != nil {
+		return "", err
+	}
+	s.events.Publish(ctx, events.KnowledgeChanged{Reason: "glossary_created", ActorID: userCtx.UserID})
+	return id, nil
+}
+
+func (s *service) ListGlossaryEntries(ctx context.Context, userCtx documentDomain.UserContext) ([]documentDomain.GlossaryEntry, error) {
+	if !userCtx.IsAdmin {
+		return nil, ErrForbidden
+	}
+	if s.glossaryRepo == nil {
+		return []documentDomain.GlossaryEntry{}, nil
+	}
+	return s.glossaryRepo.List(ctx)
+}
+
+func (s *service) UpdateGlossaryEntry(ctx context.Context, userCtx documentDomain.UserContext, entry *documentDomain.GlossaryEntry) error {
+	if !userCtx.IsAdmin {
+		return ErrForbidden
+	}
+	if s.glossaryRepo == nil {
+		return ErrGlossaryEntryNotFound
+	}
+	if err := normalizeGlossaryEntry(entry); err != nil {
+		return err
+	}
+
+	existing, err := s.glossaryRepo.GetByID(ctx, entry.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrGlossaryEntryNotFound
+	}
+	if err := s.checkGlossaryTerm(ctx, entry); err != nil {
+		return err
+	}
+	entry.CreatedBy = existing.CreatedBy
+	entry.CreatedAt = existing.CreatedAt
+
+	if err := s.glossaryRepo.Update(ctx, entry); err != nil {
+		return err
+	}
+	s.events.Publish(ctx, events.KnowledgeChanged{Reason: "glossary_updated", ActorID: userCtx.UserID})
+	return nil
+}
+
+func (s *service) DeleteGlossaryEntry(ctx context.Context, userCtx documentDomain.UserContext, id string) error {
+	if !userCtx.IsAdmin {
+		return ErrForbidden
+	}
+	if s.glossaryRepo == nil {
+		return ErrGlossaryEntryNotFound
+	}
+
+	existing, err := s.glossaryRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrGlossaryEntryNotFound
+	}
+
+	if err := s.glossaryRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.events.Publish(ctx, events.KnowledgeChanged{Reason: "glossary_deleted", ActorID: userCtx.UserID})
+	return nil
+}
+
+// normalizeGlossaryEntry checks entry and trims its phrases, dropping
+// synonyms that match the term or an earlier synonym. Phrases keep their
+// spelling, since it is what expanded questions are searched with.
+func normalizeGlossaryEntry(entry *documentDomain.GlossaryEntry) error {
+	entry.Term = strings.Join(strings.Fields(entry.Term), " ")
+	if len(questionWords(entry.Term)) == 0 {
+		return fmt.Errorf("%w: term is required", ErrInvalidGlossaryEntry)
+	}
+	if len(entry.Term) > maxGlossaryPhraseLen {
+		return fmt.Errorf("%w: term is longer than %d characters", ErrInvalidGlossaryEntry, maxGlossaryPhraseLen)
+	}
+
+	seen := []string{glossaryKey(entry.Term)}
+	synonyms := make([]string, 0, len(entry.Synonyms))
+	for _, syn := range entry.Synonyms {
+		syn = strings.Join(strings.Fields(syn), " ")
+		key := glossaryKey(syn)
+		if key == "" || containsString(seen, key) {
+			continue
+		}
+		if len(syn) > maxGlossaryPhraseLen {
+			return fmt.Errorf("%w: synonym %q is longer than %d characters", ErrInvalidGlossaryEntry, syn, maxGlossaryPhraseLen)
+		}
+		seen = append(seen, key)
+		synonyms = append(synonyms, syn)
+	}
+	if len(synonyms) == 0 {
+		return fmt.Errorf("%w: at least one synonym is required", ErrInvalidGlossaryEntry)
+	}
+	if len(synonyms) > maxGlossarySynonyms {
+		return fmt.Errorf("%w: at most %d synonyms are allowed", ErrInvalidGlossaryEntry, maxGlossarySynonyms)
+	}
+	entry.Synonyms = synonyms
+	return nil
+}
+
+// checkGlossaryTerm rejects entry when another entry has the same term.
+func (s *service) checkGlossaryTerm(ctx context.Context, entry *documentDomain.GlossaryEntry) error {
+	entries, err := s.glossaryRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+	key := glossaryKey(entry.Term)
+	for _, other := range entries {
+		if other.ID != entry.ID && glossaryKey(other.Term) == key {
+			return fmt.Errorf("%w: %q", ErrDuplicateGlossaryTerm, entry.Term)
+		}
+	}
+	return nil
+}
+
+// glossaryKey is phrase the way questions are matched: lowercase words
+// separated by single spaces, so "F/X" and "f x" are the same phrase.
+func glossaryKey(phrase string) string {
+	return strings.Join(questionWords(phrase), " ")
+}
+
+// expandQuery returns query followed by the glossary equivalents of the
+// phrases it uses, and the entries that matched. Phrases are matched as
+// whole words; failing to load the glossary leaves query as asked.
+func (s *service) expandQuery(ctx context.Context, query string) (string, []documentDomain.GlossaryEntry) {
+	if s.glossaryRepo == nil {
+		return query, nil
+	}
+	entries, err := s.glossaryRepo.List(ctx)
+	if err != nil {
+		s.log.WarnContext(ctx, "failed to load glossary", "error", err)
+		return query, nil
+	}
+	return expandWithGlossary(query, entries)
+}
+
+func expandWithGlossary(query string, entries []documentDomain.GlossaryEntry) (string, []documentDomain.GlossaryEntry) {
+	padded := " " + glossaryKey(query) + " "
+	used := func(phrase string) bool {
+		return strings.Contains(padded, " "+glossaryKey(phrase)+" ")
+	}
+
+	var matched []documentDomain.GlossaryEntry
+	var added, addedKeys []string
+	for _, entry := range entries {
+		phrases := append([]string{entry.Term}, entry.Synonyms...)
+		if !anyPhrase(phrases, used) {
+			continue
+		}
+		matched = append(matched, entry)
+		for _, p := range phrases {
+			if key := glossaryKey(p); !used(p) && !containsString(addedKeys, key) {
+				added = append(added, p)
+				addedKeys = append(addedKeys, key)
+			}
+		}
+	}
+	if len(added) == 0 {
+		return query, matched
+	}
+	return query + " (" + strings.Join(added, ", ") + ")", matched
+}
+
+func anyPhrase(phrases []string, match func(string) bool) bool {
+	for _, p := range phrases {
+		if match(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildGlossaryPrompt tells the model what the jargon in the question
+// means, ahead of the retrieved chunks.
+func buildGlossaryPrompt(entries []documentDomain.GlossaryEntry) string {
+	if len(entries) == 0 {
+		return ""
+	}
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		lines[i] = fmt.Sprintf("%s: %s", e.Term, strings.Join(e.Synonyms, ", "))
+	}
+	return "[Glossary]\n" + strings.Join(lines, "\n") + "\n\n"
+}
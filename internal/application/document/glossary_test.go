@@ -0,0 +1,136 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+)
+
+type mockGlossaryRepo struct {
+	entries map[string]*documentDomain.GlossaryEntry
+}
+
+func newMockGlossaryRepo() *mockGlossaryRepo {
+	return &mockGlossaryRepo{entries: make(map[string]*documentDomain.GlossaryEntry)}
+}
+
+func (m *mockGlossaryRepo) Create(ctx context.Context, entry *documentDomain.GlossaryEntry) (string, error) {
+	if entry.ID == "" {
+		entry.ID = "glossary_" + entry.Term
+	}
+	m.entries[entry.ID] = entry
+	return entry.ID, nil
+}
+
+func (m *mockGlossaryRepo) GetByID(ctx context.Context, id string) (*documentDomain.GlossaryEntry, error) {
+	return m.entries[id], nil
+}
+
+func (m *mockGlossaryRepo) List(ctx context.Context) ([]documentDomain.GlossaryEntry, error) {
+	entries := make([]documentDomain.GlossaryEntry, 0, len(m.entries))
+	for _, e := range m.entries {
+		entries = append(entries, *e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Term < entries[j].Term })
+	return entries, nil
+}
+
+func (m *mockGlossaryRepo) Update(ctx context.Context, entry *documentDomain.GlossaryEntry) error {
+	m.entries[entry.ID] = entry
+	return nil
+}
+
+func (m *mockGlossaryRepo) Delete(ctx context.Context, id string) error {
+	delete(m.entries, id)
+	return nil
+}
+
+func TestGlossaryCRUD(t *testing.T) {
+	repo := newMockGlossaryRepo()
+	svc := NewService(ServiceConfig{Repo: newMockDocumentRepo(), GlossaryRepo: repo})
+	ctx := context.Background()
+
+	entry := &documentDomain.GlossaryEntry{Term: " f/x ", Synonyms: []string{"foreign  exchange", "F/X", "Foreign Exchange", "fx"}}
+	if _, err := svc.CreateGlossaryEntry(ctx, documentDomain.UserContext{UserID: "u"}, entry); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden, got %v", err)
+	}
+	if _, err := svc.CreateGlossaryEntry(ctx, adminCtx, &documentDomain.GlossaryEntry{Term: "sku", Synonyms: []string{"SKU", "?!"}}); !errors.Is(err, ErrInvalidGlossaryEntry) {
+		t.Errorf("Expected ErrInvalidGlossaryEntry without synonyms, got %v", err)
+	}
+
+	id, err := svc.CreateGlossaryEntry(ctx, adminCtx, entry)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	saved := repo.entries[id]
+	if saved.Term != "f/x" || len(saved.Synonyms) != 2 || saved.Synonyms[0] != "foreign exchange" || saved.Synonyms[1] != "fx" {
+		t.Errorf("Expected trimmed phrases without repeats, got %+v", saved)
+	}
+
+	if _, err := svc.CreateGlossaryEntry(ctx, adminCtx, &documentDomain.GlossaryEntry{Term: "F X", Synonyms: []string{"forex"}}); !errors.Is(err, ErrDuplicateGlossaryTerm) {
+		t.Errorf("Expected ErrDuplicateGlossaryTerm, got %v", err)
+	}
+
+	update := &documentDomain.GlossaryEntry{ID: id, Term: "f/x", Synonyms: []string{"foreign exchange", "forex"}}
+	if err := svc.UpdateGlossaryEntry(ctx, adminCtx, update); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if repo.entries[id].Synonyms[1] != "forex" || repo.entries[id].CreatedBy != adminCtx.UserID {
+		t.Errorf("Expected the synonyms updated and the author kept, got %+v", repo.entries[id])
+	}
+	if err := svc.UpdateGlossaryEntry(ctx, adminCtx, &documentDomain.GlossaryEntry{ID: "missing", Term: "x", Synonyms: []string{"y"}}); !errors.Is(err, ErrGlossaryEntryNotFound) {
+		t.Errorf("Expected ErrGlossaryEntryNotFound, got %v", err)
+	}
+
+	if err := svc.DeleteGlossaryEntry(ctx, adminCtx, id); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := svc.DeleteGlossaryEntry(ctx, adminCtx, id); !errors.Is(err, ErrGlossaryEntryNotFound) {
+		t.Errorf("Expected ErrGlossaryEntryNotFound, got %v", err)
+	}
+}
+
+func TestExpandWithGlossary(t *testing.T) {
+	entries := []documentDomain.GlossaryEntry{
+		{Term: "f/x", Synonyms: []string{"foreign exchange", "fx"}},
+		{Term: "ach", Synonyms: []string{"bank transfer"}},
+		{Term: "pto", Synonyms: []string{"paid time off", "vacation"}},
+	}
+
+	tests := []struct {
+		query    string
+		expanded string
+		matched  int
+	}{
+		{"What is the F/X fee?", "What is the F/X fee? (foreign exchange, fx)", 1},
+		{"foreign exchange rates", "foreign exchange rates (f/x, fx)", 1},
+		{"Is ACH or a bank transfer faster?", "Is ACH or a bank transfer faster?", 1},
+		{"fx and vacation", "fx and vacation (f/x, foreign exchange, pto, paid time off)", 2},
+		{"Where is the coach?", "Where is the coach?", 0},
+	}
+	for _, tt := range tests {
+		expanded, matched := expandWithGlossary(tt.query, entries)
+		if expanded != tt.expanded || len(matched) != tt.matched {
+			t.Errorf("%q: expected %q with %d entries, got %q with %d", tt.query, tt.expanded, tt.matched, expanded, len(matched))
+		}
+	}
+}
+
+func TestQueryRAGGlossaryShortcut(t *testing.T) {
+	shortcuts := newMockShortcutRepo()
+	shortcuts.shortcuts["fx"] = &documentDomain.FAQShortcut{ID: "fx", Keywords: []string{"foreign exchange"}, Answer: "We charge 1% on foreign exchange.", IsActive: true}
+	glossary := newMockGlossaryRepo()
+	glossary.entries["fx"] = &documentDomain.GlossaryEntry{ID: "fx", Term: "f/x", Synonyms: []string{"foreign exchange"}}
+	svc := NewService(ServiceConfig{Repo: newMockDocumentRepo(), ShortcutRepo: shortcuts, GlossaryRepo: glossary})
+
+	resp, err := svc.QueryRAG(context.Background(), documentDomain.RAGQuery{Query: "Any f/x fees?"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.Shortcut != "fx" {
+		t.Errorf("Expected the synonym to match the shortcut, got %+v", resp)
+	}
+}
@@ -0,0 +1,42 @@
+package document
+
+import (
+	"strings"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/guardrail"
+	"github.com/elprogramadorgt/lucidRAG/pkg/i18n"
+)
+
+// applyGuardrail checks resp.Answer against the chunks and tool results it
+// was generated from and records the outcome on resp. A replaced answer is
+// replaced with the fallback in lang.
+func (s *service) applyGuardrail(resp *documentDomain.RAGResponse, toolResults []string, lang string) {
+	sources := make([]string, 0, len(resp.RelevantChunks)+len(toolResults))
+	for _, chunk := range resp.RelevantChunks {
+		sources = append(sources, chunk.Content)
+	}
+	sources = append(sources, toolResults...)
+
+	// Structured answers are validated JSON; rewriting them would break
+	// that, so they are only scored.
+	if resp.Data != nil {
+		resp.Groundedness = guardrail.Groundedness(resp.Answer, strings.Join(sources, "\n"))
+		return
+	}
+
+	policy := s.guardrail
+	if policy.Fallback == "" {
+		policy.Fallback = i18n.Translate(lang, guardrail.DefaultFallback)
+	}
+	res := policy.Apply(resp.Answer, sources)
+	resp.Answer = res.Answer
+	resp.Groundedness = res.Groundedness
+	resp.Guardrails = res.Actions
+
+	for _, action := range res.Actions {
+		if action == guardrail.ActionUngrounded {
+			resp.ConfidenceScore = 0
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package document
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/guardrail"
+	"github.com/elprogramadorgt/lucidRAG/pkg/i18n"
+)
+
+func TestApplyGuardrailUsesChunksAndToolResults(t *testing.T) {
+	s := &service{guardrail: guardrail.Policy{StripUnsourced: true}}
+	resp := &documentDomain.RAGResponse{
+		Answer:          "Order 55512345 ships today. Track it at https://track.example.com or call 555-000-1111.",
+		RelevantChunks:  []documentDomain.Chunk{{Content: "Orders can be tracked at https://track.example.com"}},
+		ConfidenceScore: 0.85,
+	}
+
+	s.applyGuardrail(resp, []string{`{"order":"55512345","status":"ships today"}`}, i18n.English)
+
+	if !strings.Contains(resp.Answer, "55512345") || !strings.Contains(resp.Answer, "track.example.com") {
+		t.Errorf("Expected sourced order number and link to be kept, got %q", resp.Answer)
+	}
+	if strings.Contains(resp.Answer, "555-000-1111") {
+		t.Errorf("Expected invented phone to be removed, got %q", resp.Answer)
+	}
+	if !slices.Equal(resp.Guardrails, []string{guardrail.ActionStrippedPhone}) {
+		t.Errorf("Expected only stripped_phone, got %v", resp.Guardrails)
+	}
+}
+
+func TestApplyGuardrailZeroesConfidenceWhenUngrounded(t *testing.T) {
+	s := &service{guardrail: guardrail.Policy{MinGroundedness: 0.5}}
+	resp := &documentDomain.RAGResponse{
+		Answer:          "Penguins deliver parcels on Sundays.",
+		RelevantChunks:  []documentDomain.Chunk{{Content: "Store hours: Monday to Friday."}},
+		ConfidenceScore: 0.85,
+	}
+
+	s.applyGuardrail(resp, nil, i18n.English)
+
+	if resp.Answer != guardrail.DefaultFallback || resp.ConfidenceScore != 0 {
+		t.Errorf("Expected fallback with zero confidence, got %q (%f)", resp.Answer, resp.ConfidenceScore)
+	}
+}
+
+func TestApplyGuardrailTranslatesFallback(t *testing.T) {
+	s := &service{guardrail: guardrail.Policy{MinGroundedness: 0.5}}
+	resp := &documentDomain.RAGResponse{
+		Answer:         "Los pingüinos reparten paquetes los domingos.",
+		RelevantChunks: []documentDomain.Chunk{{Content: "Horario: lunes a viernes."}},
+	}
+
+	s.applyGuardrail(resp, nil, i18n.Spanish)
+
+	if resp.Answer != i18n.Translate(i18n.Spanish, guardrail.DefaultFallback) || resp.Answer == guardrail.DefaultFallback {
+		t.Errorf("Expected the Spanish fallback, got %q", resp.Answer)
+	}
+}
+
+func TestApplyGuardrailLeavesStructuredAnswers(t *testing.T) {
+	s := &service{guardrail: guardrail.Policy{StripUnsourced: true, MaxChars: 10}}
+	answer := `{"site":"https://invented.example.org"}`
+	resp := &documentDomain.RAGResponse{Answer: answer, Data: json.RawMessage(answer)}
+
+	s.applyGuardrail(resp, nil, i18n.English)
+
+	if resp.Answer != answer || len(resp.Guardrails) != 0 {
+		t.Errorf("Expected structured answer to be untouched, got %q %v", resp.Answer, resp.Guardrails)
+	}
+}
@@ -0,0 +1,56 @@
+package document
+
+import (
+	"sort"
+	"strings"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/openai"
+)
+
+// promptMessages puts the conversation a query continues between the system
+// prompt and the question, so follow-ups can be answered. The system prompt
+// stays first because later stages extend it.
+func promptMessages(systemPrompt string, query documentDomain.RAGQuery, userPrompt string) []openai.ChatMessage {
+	messages := make([]openai.ChatMessage, 0, len(query.History)+3)
+	messages = append(messages, openai.ChatMessage{Role: "system", Content: systemPrompt})
+
+	if len(query.CustomerContext) > 0 {
+		messages = append(messages, openai.ChatMessage{Role: "system", Content: customerContext(query.CustomerContext)})
+	}
+	if query.Summary != "" {
+		messages = append(messages, openai.ChatMessage{
+			Role:    "system",
+			Content: "Summary of the earlier conversation with this customer:\n" + query.Summary,
+		})
+	}
+	for _, turn := range query.History {
+		if turn.Content == "" || (turn.Role != "user" && turn.Role != "assistant") {
+			continue
+		}
+		messages = append(messages, openai.ChatMessage{Role: turn.Role, Content: turn.Content})
+	}
+
+	return append(messages, openai.ChatMessage{Role: "user", Content: userPrompt})
+}
+
+// customerContext lists the customer's variables in a stable order, so the
+// same customer always gets the same prompt.
+func customerContext(vars map[string]string) string {
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("What is known about this customer. Tailor the answer to it where relevant, but do not recite it:\n")
+	for _, k := range keys {
+		b.WriteString("- ")
+		b.WriteString(k)
+		b.WriteString(": ")
+		b.WriteString(vars[k])
+		b.WriteString("\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,74 @@
+package document
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/cache"
+)
+
+func TestPromptMessagesIncludeConversation(t *testing.T) {
+	query := documentDomain.RAGQuery{
+		Query:   "and in blue?",
+		Summary: "Customer is looking for a mug.",
+		History: []documentDomain.Turn{
+			{Role: "user", Content: "Do you have the large mug?"},
+			{Role: "assistant", Content: "Yes, in red."},
+			{Role: "system", Content: "ignored"},
+		},
+	}
+
+	messages := promptMessages("sys", query, "Context: ...")
+
+	roles := make([]string, len(messages))
+	for i, m := range messages {
+		roles[i] = m.Role
+	}
+	want := []string{"system", "system", "user", "assistant", "user"}
+	if len(roles) != len(want) {
+		t.Fatalf("Expected roles %v, got %v", want, roles)
+	}
+	for i := range want {
+		if roles[i] != want[i] {
+			t.Fatalf("Expected roles %v, got %v", want, roles)
+		}
+	}
+	if messages[0].Content != "sys" || messages[len(messages)-1].Content != "Context: ..." {
+		t.Errorf("Expected system prompt first and question last, got %+v", messages)
+	}
+}
+
+func TestAnswerCacheSkipsConversations(t *testing.T) {
+	c := cache.NewMemory()
+	defer c.Stop()
+	s := &service{cache: c, answerCacheTTL: time.Minute}
+	ctx := context.Background()
+
+	query := documentDomain.RAGQuery{Query: "and in blue?"}
+	if key := s.answerCacheKey(ctx, query); key == "" {
+		t.Fatal("Expected a cache key for a standalone question")
+	}
+	query.History = []documentDomain.Turn{{Role: "user", Content: "Do you have the large mug?"}}
+	if key := s.answerCacheKey(ctx, query); key != "" {
+		t.Errorf("Expected no cache key for follow-ups, got %q", key)
+	}
+}
+
+func TestPromptMessagesIncludeCustomerContext(t *testing.T) {
+	query := documentDomain.RAGQuery{
+		Query:           "can I add users?",
+		CustomerContext: map[string]string{"region": "EU", "plan": "Pro"},
+	}
+
+	messages := promptMessages("sys", query, "Context: ...")
+
+	if len(messages) != 3 || messages[1].Role != "system" {
+		t.Fatalf("Expected customer context as second system message, got %+v", messages)
+	}
+	if !strings.Contains(messages[1].Content, "- plan: Pro\n- region: EU") {
+		t.Errorf("Expected sorted customer variables, got %q", messages[1].Content)
+	}
+}
@@ -0,0 +1,198 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrIngestUnavailable = errors.New("streaming ingestion needs embeddings and chunk storage")
+	ErrDocumentTooLarge  = errors.New("document is too large")
+	ErrEmptyDocument     = errors.New("document has no text")
+)
+
+const (
+	// defaultMaxDocumentBytes bounds a streamed body when no limit is
+	// configured.
+	defaultMaxDocumentBytes = 256 << 20
+	// ingestPreviewBytes is how much of a streamed body is kept as the
+	// document's content; the rest lives only in its chunks.
+	ingestPreviewBytes = 1 << 20
+	ingestBatchSize    = 100
+)
+
+// limitedReader fails with ErrDocumentTooLarge once more than n bytes are
+// read, where io.LimitReader would end the body silently.
+type limitedReader struct {
+	r    io.Reader
+	n    int64
+	read int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.n {
+		return n, ErrDocumentTooLarge
+	}
+	return n, err
+}
+
+// IngestDocument creates a document from a body read as a stream: chunks
+// are embedded and written in batches as the text arrives, so only the
+// first megabyte, kept as the document's content, and one batch are ever
+// held in memory. A failure part way removes the document and the chunks
+// written so far. Streamed documents are not checked for duplicates, as
+// their content is only known once it has been written.
+func (s *service) IngestDocument(ctx context.Context, userCtx documentDomain.UserContext, ingest documentDomain.Ingest) (*documentDomain.IngestResult, error) {
+	if s.embedder == nil || s.chunker == nil || s.chunkRepo == nil {
+		return nil, ErrIngestUnavailable
+	}
+	if err := validateMeta(&ingest.DocumentMeta); err != nil {
+		return nil, err
+	}
+	space, err := s.embeddingSpace(ctx, ingest.Collection)
+	if err != nil {
+		return nil, err
+	}
+
+	body := &limitedReader{r: ingest.Body, n: s.maxDocumentBytes}
+	preview, err := io.ReadAll(io.LimitReader(body, ingestPreviewBytes))
+	if err != nil {
+		return nil, err
+	}
+	truncated := len(preview) == ingestPreviewBytes
+	// The preview may end part way through a rune; the stream still reads
+	// it whole.
+	content := bytes.ToValidUTF8(preview, nil)
+	if strings.TrimSpace(string(content)) == "" && !truncated {
+		return nil, ErrEmptyDocument
+	}
+
+	doc := &documentDomain.Document{
+		UserID:     userCtx.UserID,
+		Title:      ingest.Title,
+		Content:    string(content),
+		Source:     ingest.Source,
+		Metadata:   ingest.Metadata,
+		Collection: ingest.Collection,
+		Truncated:  truncated,
+		Status:     documentDomain.StatusDraft,
+
+		DocumentMeta: ingest.DocumentMeta,
+	}
+	if userCtx.IsAdmin {
+		doc.Status = documentDomain.StatusPublished
+	}
+	id, err := s.repo.Create(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+	doc.ID = id
+	s.recordStorage(ctx, doc.UserID, id, documentDomain.StorageUsage{
+		Documents:     1,
+		DocumentBytes: int64(len(doc.Content)),
+	})
+
+	chunks, err := s.ingestChunks(ctx, doc, space, io.MultiReader(bytes.NewReader(preview), body))
+	if err != nil {
+		s.discardIngest(ctx, doc)
+		return nil, err
+	}
+
+	s.events.Publish(ctx, events.DocumentCreated{
+		DocumentID: id,
+		UserID:     doc.UserID,
+		Title:      doc.Title,
+		Status:     string(doc.Status),
+	})
+	return &documentDomain.IngestResult{ID: id, Chunks: chunks, Bytes: body.read}, nil
+}
+
+// ingestChunks chunks and embeds r into doc's chunks, writing them in
+// batches, and returns how many it wrote.
+func (s *service) ingestChunks(ctx context.Context, doc *documentDomain.Document, space documentDomain.EmbeddingSpace, r io.Reader) (int, error) {
+	hidden := !doc.IsRetrievable(time.Now())
+	batch := make([]documentDomain.Chunk, 0, ingestBatchSize)
+	written := 0
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := s.chunkRepo.CreateBatch(ctx, batch); err != nil {
+			return err
+		}
+		s.recordStorage(ctx, doc.UserID, doc.ID, chunkUsage(batch...))
+		written += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	stream := s.chunker.NewStream(r)
+	for i := 0; ; i++ {
+		text, err := stream.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return written, err
+		}
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+
+		embedding, err := s.embed(ctx, space, text)
+		if err != nil {
+			return written, fmt.Errorf("embed chunk %d: %w", i, err)
+		}
+		batch = append(batch, documentDomain.Chunk{
+			ID:          primitive.NewObjectID().Hex(),
+			DocumentID:  doc.ID,
+			ChunkIndex:  i,
+			Content:     text,
+			Embedding:   embedding,
+			Hidden:      hidden,
+			CreatedAt:   time.Now(),
+			Kind:        documentDomain.ChunkKindText,
+			ContentHash: chunkHash(documentDomain.ChunkKindText, text),
+
+			Collection:     space.Collection,
+			EmbeddingModel: space.Model,
+			Dimensions:     len(embedding),
+		})
+		if len(batch) == ingestBatchSize {
+			if err := flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return written, err
+	}
+	if written == 0 {
+		return 0, ErrEmptyDocument
+	}
+	return written, nil
+}
+
+// discardIngest removes a document whose ingestion failed, with the
+// chunks written for it, even when ctx was cancelled.
+func (s *service) discardIngest(ctx context.Context, doc *documentDomain.Document) {
+	ctx = context.WithoutCancel(ctx)
+	if err := s.chunkRepo.DeleteByDocumentID(ctx, doc.ID); err != nil {
+		s.log.WarnContext(ctx, "failed to delete chunks", "error", err, "document_id", doc.ID)
+	}
+	if err := s.repo.Delete(ctx, doc.ID); err != nil {
+		s.log.WarnContext(ctx, "failed to delete document", "error", err, "document_id", doc.ID)
+	}
+	s.releaseDocumentStorage(ctx, doc.UserID, doc.ID)
+}
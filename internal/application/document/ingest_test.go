@@ -0,0 +1,63 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/chunker"
+	"github.com/elprogramadorgt/lucidRAG/pkg/openai"
+)
+
+func TestIngestDocument(t *testing.T) {
+	server, requests := embeddingServer(t)
+	defer server.Close()
+
+	repo := newMockDocumentRepo()
+	chunkRepo := newMockChunkRepo()
+	svc := NewService(ServiceConfig{
+		Repo:             repo,
+		ChunkRepo:        chunkRepo,
+		OpenAIClient:     openai.NewClient("test-key", openai.WithBaseURL(server.URL)),
+		Chunker:          chunker.New(200, 20),
+		MaxDocumentBytes: 2 << 20,
+	})
+	ctx := context.Background()
+	admin := documentDomain.UserContext{UserID: "admin-1", IsAdmin: true}
+
+	// Enough text to pass the kept preview and fill more than one batch.
+	body := strings.Repeat("lorem ipsum dolor sit amet ", 60000)
+	result, err := svc.IngestDocument(ctx, admin, documentDomain.Ingest{Title: "export", Body: strings.NewReader(body)})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Bytes != int64(len(body)) || result.Chunks != len(chunkRepo.chunks) || result.Chunks != len(*requests) {
+		t.Errorf("Expected every chunk embedded and stored, got %+v with %d chunks", result, len(chunkRepo.chunks))
+	}
+	if want := len(chunker.New(200, 20).Chunk(body)); result.Chunks != want {
+		t.Errorf("Expected %d chunks, got %d", want, result.Chunks)
+	}
+	doc := repo.documents[result.ID]
+	if !doc.Truncated || len(doc.Content) != ingestPreviewBytes || doc.Status != documentDomain.StatusPublished {
+		t.Errorf("Expected a published document keeping the first megabyte, got truncated=%v, %d bytes, %s", doc.Truncated, len(doc.Content), doc.Status)
+	}
+
+	_, err = svc.IngestDocument(ctx, admin, documentDomain.Ingest{Title: "huge", Body: strings.NewReader(body + body)})
+	if !errors.Is(err, ErrDocumentTooLarge) {
+		t.Errorf("Expected ErrDocumentTooLarge, got %v", err)
+	}
+	if _, ok := repo.documents["doc_huge"]; ok {
+		t.Error("Expected the oversized document removed")
+	}
+	for _, chunk := range chunkRepo.chunks {
+		if chunk.DocumentID == "doc_huge" {
+			t.Fatal("Expected the oversized document's chunks removed")
+		}
+	}
+
+	if _, err := svc.IngestDocument(ctx, admin, documentDomain.Ingest{Title: "blank", Body: strings.NewReader(" \n\t")}); !errors.Is(err, ErrEmptyDocument) {
+		t.Errorf("Expected ErrEmptyDocument, got %v", err)
+	}
+}
@@ -0,0 +1,395 @@
+package document
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported format")
+	ErrInvalidImport     = errors.New("invalid import")
+)
+
+const (
+	exportPageSize  = 100
+	importBatchSize = 500
+	// llamaIndexSource is the key of the SOURCE entry in a node's
+	// relationships.
+	llamaIndexSource = "1"
+)
+
+type langChainRecord struct {
+	ID          string         `json:"id,omitempty"`
+	Type        string         `json:"type,omitempty"`
+	PageContent string         `json:"page_content"`
+	Metadata    map[string]any `json:"metadata"`
+	Embedding   []float64      `json:"embedding,omitempty"`
+}
+
+type llamaIndexRecord struct {
+	ID            string                     `json:"id_,omitempty"`
+	ClassName     string                     `json:"class_name,omitempty"`
+	Text          string                     `json:"text"`
+	Metadata      map[string]any             `json:"metadata"`
+	Embedding     []float64                  `json:"embedding,omitempty"`
+	Relationships map[string]json.RawMessage `json:"relationships,omitempty"`
+}
+
+type llamaIndexRelation struct {
+	NodeID string `json:"node_id"`
+}
+
+func validFormat(format documentDomain.InterchangeFormat) bool {
+	return format == documentDomain.FormatLangChain || format == documentDomain.FormatLlamaIndex
+}
+
+func (s *service) ExportChunks(ctx context.Context, userCtx documentDomain.UserContext, format documentDomain.InterchangeFormat, documentID string, w io.Writer) error {
+	if !userCtx.IsAdmin {
+		return ErrForbidden
+	}
+	if !validFormat(format) {
+		return ErrUnsupportedFormat
+	}
+
+	// Everything that can fail with a client error is checked before the
+	// first line is written.
+	var single *documentDomain.Document
+	if documentID != "" {
+		doc, err := s.repo.GetByID(ctx, documentID)
+		if err != nil {
+			return err
+		}
+		if doc == nil {
+			return ErrDocumentNotFound
+		}
+		single = doc
+	}
+	if s.chunkRepo == nil {
+		return nil
+	}
+
+	buf := bufio.NewWriter(w)
+	enc := json.NewEncoder(buf)
+	export := func(doc documentDomain.Document) error {
+		chunks, err := s.chunkRepo.GetByDocumentID(ctx, doc.ID)
+		if err != nil {
+			return err
+		}
+		sort.Slice(chunks, func(i, j int) bool { return chunks[i].ChunkIndex < chunks[j].ChunkIndex })
+		for _, c := range chunks {
+			if err := enc.Encode(exportRecord(format, doc, c)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if single != nil {
+		if err := export(*single); err != nil {
+			return err
+		}
+		return buf.Flush()
+	}
+
+	for offset := 0; ; offset += exportPageSize {
+		docs, err := s.repo.List(ctx, exportPageSize, offset)
+		if err != nil {
+			return err
+		}
+		for _, doc := range docs {
+			if err := export(doc); err != nil {
+				return err
+			}
+		}
+		if len(docs) < exportPageSize {
+			break
+		}
+	}
+	return buf.Flush()
+}
+
+func exportRecord(format documentDomain.InterchangeFormat, doc documentDomain.Document, c documentDomain.Chunk) any {
+	metadata := map[string]any{
+		"document_id": doc.ID,
+		"title":       doc.Title,
+		"source":      doc.Source,
+		"chunk_index": c.ChunkIndex,
+	}
+	if format == documentDomain.FormatLlamaIndex {
+		source, _ := json.Marshal(llamaIndexRelation{NodeID: doc.ID})
+		return llamaIndexRecord{
+			ID:            c.ID,
+			ClassName:     "TextNode",
+			Text:          c.Content,
+			Metadata:      metadata,
+			Embedding:     c.Embedding,
+			Relationships: map[string]json.RawMessage{llamaIndexSource: source},
+		}
+	}
+	return langChainRecord{
+		ID:          c.ID,
+		Type:        "Document",
+		PageContent: c.Content,
+		Metadata:    metadata,
+		Embedding:   c.Embedding,
+	}
+}
+
+// importedChunk is one parsed line, before it is assigned to a document.
+type importedChunk struct {
+	group     string
+	title     string
+	source    string
+	index     int
+	line      int
+	content   string
+	embedding []float64
+}
+
+// importGroup collects the chunks that become one document.
+type importGroup struct {
+	key    string
+	title  string
+	source string
+	chunks []importedChunk
+}
+
+func (s *service) ImportChunks(ctx context.Context, userCtx documentDomain.UserContext, format documentDomain.InterchangeFormat, r io.Reader) (*documentDomain.ImportResult, error) {
+	if !userCtx.IsAdmin {
+		return nil, ErrForbidden
+	}
+	if !validFormat(format) {
+		return nil, ErrUnsupportedFormat
+	}
+	if s.chunkRepo == nil {
+		return nil, fmt.Errorf("%w: chunk storage is not configured", ErrInvalidImport)
+	}
+
+	groups, err := s.parseImport(format, r)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &documentDomain.ImportResult{DocumentIDs: []string{}}
+	for _, g := range groups {
+		id, n, err := s.importGroup(ctx, userCtx, format, g)
+		if err != nil {
+			return result, err
+		}
+		result.Documents++
+		result.Chunks += n
+		result.DocumentIDs = append(result.DocumentIDs, id)
+	}
+	return result, nil
+}
+
+// parseImport reads and validates the whole corpus before anything is
+// written, so a bad line leaves the knowledge base untouched.
+func (s *service) parseImport(format documentDomain.InterchangeFormat, r io.Reader) ([]*importGroup, error) {
+	var (
+		groups    []*importGroup
+		byKey     = map[string]*importGroup{}
+		dimension int
+	)
+
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, err := br.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if trimmed := strings.TrimSpace(string(line)); trimmed != "" {
+			c, perr := parseImportLine(format, []byte(trimmed))
+			if perr != nil {
+				return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidImport, n, perr)
+			}
+			c.line = n
+
+			if len(c.embedding) == 0 && s.embedder == nil {
+				return nil, fmt.Errorf("%w: line %d: missing embedding", ErrInvalidImport, n)
+			}
+			if len(c.embedding) > 0 {
+				if dimension == 0 {
+					dimension = len(c.embedding)
+				} else if len(c.embedding) != dimension {
+					return nil, fmt.Errorf("%w: line %d: embedding has %d dimensions, expected %d", ErrInvalidImport, n, len(c.embedding), dimension)
+				}
+			}
+
+			g, ok := byKey[c.group]
+			if !ok {
+				g = &importGroup{key: c.group, title: c.title, source: c.source}
+				byKey[c.group] = g
+				groups = append(groups, g)
+			}
+			g.chunks = append(g.chunks, c)
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("%w: no chunks found", ErrInvalidImport)
+	}
+	return groups, nil
+}
+
+func parseImportLine(format documentDomain.InterchangeFormat, line []byte) (importedChunk, error) {
+	var (
+		c        importedChunk
+		metadata map[string]any
+	)
+
+	if format == documentDomain.FormatLlamaIndex {
+		var rec llamaIndexRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return c, err
+		}
+		c.content, c.embedding, metadata = rec.Text, rec.Embedding, rec.Metadata
+		if raw, ok := rec.Relationships[llamaIndexSource]; ok {
+			var source llamaIndexRelation
+			if err := json.Unmarshal(raw, &source); err == nil {
+				c.group = source.NodeID
+			}
+		}
+		if c.group == "" {
+			c.group = metaString(metadata, "ref_doc_id", "doc_id", "document_id")
+		}
+	} else {
+		var rec langChainRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return c, err
+		}
+		c.content, c.embedding, metadata = rec.PageContent, rec.Embedding, rec.Metadata
+		c.group = metaString(metadata, "document_id", "doc_id", "source")
+	}
+
+	if strings.TrimSpace(c.content) == "" {
+		return c, errors.New("empty text")
+	}
+	c.title = metaString(metadata, "title", "file_name", "source")
+	c.source = metaString(metadata, "source", "file_path", "url")
+	c.index = metaInt(metadata, "chunk_index", -1)
+	return c, nil
+}
+
+func metaString(metadata map[string]any, keys ...string) string {
+	for _, k := range keys {
+		if v, ok := metadata[k].(string); ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func metaInt(metadata map[string]any, key string, fallback int) int {
+	switch v := metadata[key].(type) {
+	case float64:
+		return int(v)
+	case string:
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return fallback
+}
+
+// importGroup creates one published document from g, reusing the imported
+// embeddings and filling in any that are missing.
+func (s *service) importGroup(ctx context.Context, userCtx documentDomain.UserContext, format documentDomain.InterchangeFormat, g *importGroup) (string, int, error) {
+	// Chunks keep their stated order; unnumbered ones follow in file order.
+	sort.SliceStable(g.chunks, func(i, j int) bool {
+		a, b := g.chunks[i], g.chunks[j]
+		if (a.index < 0) != (b.index < 0) {
+			return b.index < 0
+		}
+		return a.index < b.index
+	})
+
+	texts := make([]string, len(g.chunks))
+	for i, c := range g.chunks {
+		texts[i] = c.content
+	}
+
+	title := g.title
+	if title == "" {
+		title = g.key
+	}
+	if title == "" {
+		title = "Imported " + string(format) + " corpus"
+	}
+	source := g.source
+	if source == "" {
+		source = string(format)
+	}
+	metadata, _ := json.Marshal(map[string]string{"imported_from": string(format), "original_id": g.key})
+
+	doc := &documentDomain.Document{
+		UserID:   userCtx.UserID,
+		Title:    title,
+		Content:  strings.Join(texts, "\n\n"),
+		Source:   source,
+		Metadata: string(metadata),
+		Status:   documentDomain.StatusPublished,
+	}
+	id, err := s.repo.Create(ctx, doc)
+	if err != nil {
+		return "", 0, err
+	}
+	doc.ID = id
+	s.recordStorage(ctx, doc.UserID, id, documentDomain.StorageUsage{
+		Documents:     1,
+		DocumentBytes: int64(len(doc.Content)),
+	})
+
+	now := time.Now()
+	model := s.activeEmbeddingModel(ctx)
+	chunks := make([]documentDomain.Chunk, 0, len(g.chunks))
+	for i, c := range g.chunks {
+		embedding := c.embedding
+		if len(embedding) == 0 {
+			embedding, err = s.embedder.CreateEmbeddingWithDimensions(ctx, c.content, model, 0)
+			if err != nil {
+				return id, 0, fmt.Errorf("embed line %d: %w", c.line, err)
+			}
+		}
+		chunks = append(chunks, documentDomain.Chunk{
+			ID:         primitive.NewObjectID().Hex(),
+			DocumentID: id,
+			ChunkIndex: i,
+			Content:    c.content,
+			Embedding:  embedding,
+			Hidden:     !doc.IsRetrievable(now),
+			CreatedAt:  now,
+		})
+	}
+
+	for start := 0; start < len(chunks); start += importBatchSize {
+		end := min(start+importBatchSize, len(chunks))
+		if err := s.chunkRepo.CreateBatch(ctx, chunks[start:end]); err != nil {
+			return id, 0, err
+		}
+		s.recordStorage(ctx, doc.UserID, id, chunkUsage(chunks[start:end]...))
+	}
+
+	s.events.Publish(ctx, events.DocumentCreated{
+		DocumentID: id,
+		UserID:     doc.UserID,
+		Title:      doc.Title,
+		Status:     string(doc.Status),
+	})
+	return id, len(chunks), nil
+}
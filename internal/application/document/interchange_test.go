@@ -0,0 +1,167 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+)
+
+var adminCtx = documentDomain.UserContext{UserID: "admin-1", IsAdmin: true}
+
+func TestImportLangChain(t *testing.T) {
+	repo := newMockDocumentRepo()
+	chunkRepo := newMockChunkRepo()
+	svc := NewService(ServiceConfig{Repo: repo, ChunkRepo: chunkRepo})
+
+	corpus := strings.Join([]string{
+		`{"page_content":"second","metadata":{"source":"guide.md","chunk_index":1},"embedding":[0.3,0.4]}`,
+		`{"page_content":"first","metadata":{"source":"guide.md","chunk_index":0},"embedding":[0.1,0.2]}`,
+		``,
+		`{"page_content":"other","metadata":{"source":"faq.md","title":"FAQ"},"embedding":[0.5,0.6]}`,
+	}, "\n")
+
+	result, err := svc.ImportChunks(context.Background(), adminCtx, documentDomain.FormatLangChain, strings.NewReader(corpus))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Documents != 2 || result.Chunks != 3 {
+		t.Errorf("Expected 2 documents and 3 chunks, got %+v", result)
+	}
+
+	guide := repo.documents["doc_guide.md"]
+	if guide == nil {
+		t.Fatal("Expected a document titled after its source")
+	}
+	if guide.Content != "first\n\nsecond" || guide.Status != documentDomain.StatusPublished {
+		t.Errorf("Unexpected document %+v", guide)
+	}
+	if repo.documents["doc_FAQ"] == nil {
+		t.Error("Expected metadata title to be used")
+	}
+
+	chunks, _ := chunkRepo.GetByDocumentID(context.Background(), guide.ID)
+	if len(chunks) != 2 || chunks[0].Content != "first" || chunks[0].Embedding[0] != 0.1 {
+		t.Errorf("Expected ordered chunks with their embeddings, got %+v", chunks)
+	}
+}
+
+func TestImportLlamaIndexGroupsBySource(t *testing.T) {
+	repo := newMockDocumentRepo()
+	chunkRepo := newMockChunkRepo()
+	svc := NewService(ServiceConfig{Repo: repo, ChunkRepo: chunkRepo})
+
+	corpus := `{"id_":"n1","text":"alpha","metadata":{"file_name":"a.txt"},"embedding":[1,0],"relationships":{"1":{"node_id":"src-a"}}}
+{"id_":"n2","text":"beta","metadata":{},"embedding":[0,1],"relationships":{"1":{"node_id":"src-a"}}}
+`
+	result, err := svc.ImportChunks(context.Background(), adminCtx, documentDomain.FormatLlamaIndex, strings.NewReader(corpus))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Documents != 1 || result.Chunks != 2 {
+		t.Errorf("Expected 1 document and 2 chunks, got %+v", result)
+	}
+	if doc := repo.documents["doc_a.txt"]; doc == nil || doc.Content != "alpha\n\nbeta" {
+		t.Errorf("Unexpected documents %+v", repo.documents)
+	}
+}
+
+func TestImportRejectsBadCorpus(t *testing.T) {
+	tests := []struct {
+		name   string
+		corpus string
+	}{
+		{"empty", "\n\n"},
+		{"bad json", `{"page_content":`},
+		{"missing embedding", `{"page_content":"text","metadata":{}}`},
+		{"empty text", `{"page_content":" ","metadata":{},"embedding":[1]}`},
+		{"mixed dimensions", `{"page_content":"a","metadata":{},"embedding":[1,2]}
+{"page_content":"b","metadata":{},"embedding":[1,2,3]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMockDocumentRepo()
+			svc := NewService(ServiceConfig{Repo: repo, ChunkRepo: newMockChunkRepo()})
+
+			_, err := svc.ImportChunks(context.Background(), adminCtx, documentDomain.FormatLangChain, strings.NewReader(tt.corpus))
+			if !errors.Is(err, ErrInvalidImport) {
+				t.Errorf("Expected ErrInvalidImport, got %v", err)
+			}
+			if len(repo.documents) != 0 {
+				t.Error("Expected nothing to be written")
+			}
+		})
+	}
+}
+
+func TestImportExportRequireAdmin(t *testing.T) {
+	svc := NewService(ServiceConfig{Repo: newMockDocumentRepo(), ChunkRepo: newMockChunkRepo()})
+	userCtx := documentDomain.UserContext{UserID: "user-1"}
+
+	if _, err := svc.ImportChunks(context.Background(), userCtx, documentDomain.FormatLangChain, strings.NewReader("")); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden on import, got %v", err)
+	}
+	if err := svc.ExportChunks(context.Background(), userCtx, documentDomain.FormatLangChain, "", &bytes.Buffer{}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden on export, got %v", err)
+	}
+	if err := svc.ExportChunks(context.Background(), adminCtx, "haystack", "", &bytes.Buffer{}); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Expected ErrUnsupportedFormat, got %v", err)
+	}
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	repo := newMockDocumentRepo()
+	repo.documents["doc-1"] = &documentDomain.Document{ID: "doc-1", Title: "guide", Source: "guide.md"}
+	chunkRepo := newMockChunkRepo()
+	chunkRepo.chunks = []documentDomain.Chunk{
+		{ID: "c2", DocumentID: "doc-1", ChunkIndex: 1, Content: "second", Embedding: []float64{0.3, 0.4}},
+		{ID: "c1", DocumentID: "doc-1", ChunkIndex: 0, Content: "first", Embedding: []float64{0.1, 0.2}},
+	}
+	svc := NewService(ServiceConfig{Repo: repo, ChunkRepo: chunkRepo})
+
+	for _, format := range []documentDomain.InterchangeFormat{documentDomain.FormatLangChain, documentDomain.FormatLlamaIndex} {
+		var out bytes.Buffer
+		if err := svc.ExportChunks(context.Background(), adminCtx, format, "doc-1", &out); err != nil {
+			t.Fatalf("Expected no error exporting %s, got %v", format, err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("Expected 2 lines for %s, got %d", format, len(lines))
+		}
+		var first map[string]any
+		if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+			t.Fatalf("Expected valid JSON, got %v", err)
+		}
+		if first["page_content"] != "first" && first["text"] != "first" {
+			t.Errorf("Expected chunks in order for %s, got %s", format, lines[0])
+		}
+
+		target := newMockDocumentRepo()
+		importer := NewService(ServiceConfig{Repo: target, ChunkRepo: newMockChunkRepo()})
+		result, err := importer.ImportChunks(context.Background(), adminCtx, format, &out)
+		if err != nil {
+			t.Fatalf("Expected no error importing %s, got %v", format, err)
+		}
+		if result.Documents != 1 || result.Chunks != 2 {
+			t.Errorf("Expected export to import back as 1 document and 2 chunks, got %+v", result)
+		}
+		if doc := target.documents["doc_guide"]; doc == nil || doc.Source != "guide.md" {
+			t.Errorf("Expected title and source to survive %s, got %+v", format, target.documents)
+		}
+	}
+}
+
+func TestExportMissingDocument(t *testing.T) {
+	svc := NewService(ServiceConfig{Repo: newMockDocumentRepo(), ChunkRepo: newMockChunkRepo()})
+
+	err := svc.ExportChunks(context.Background(), adminCtx, documentDomain.FormatLangChain, "missing", &bytes.Buffer{})
+	if !errors.Is(err, ErrDocumentNotFound) {
+		t.Errorf("Expected ErrDocumentNotFound, got %v", err)
+	}
+}
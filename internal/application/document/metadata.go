@@ -0,0 +1,96 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+)
+
+var ErrInvalidMetadata = errors.New("invalid document metadata")
+
+// languagePattern matches an ISO 639-1 code with an optional region, once
+// normalized: "es", "en-US".
+var languagePattern = regexp.MustCompile(`^[a-z]{2}(-[A-Z]{2})?$`)
+
+// normalizeLanguage writes a language code the way it is stored: the
+// language lowercase, the region uppercase, joined by a hyphen.
+func normalizeLanguage(code string) string {
+	code = strings.ReplaceAll(strings.TrimSpace(code), "_", "-")
+	lang, region, ok := strings.Cut(code, "-")
+	if !ok {
+		return strings.ToLower(lang)
+	}
+	return strings.ToLower(lang) + "-" + strings.ToUpper(region)
+}
+
+// validateMeta checks meta and normalizes it in place.
+func validateMeta(meta *documentDomain.DocumentMeta) error {
+	meta.Author = strings.TrimSpace(meta.Author)
+	meta.Department = strings.TrimSpace(meta.Department)
+	meta.URL = strings.TrimSpace(meta.URL)
+
+	if meta.Language != "" {
+		meta.Language = normalizeLanguage(meta.Language)
+		if !languagePattern.MatchString(meta.Language) {
+			return fmt.Errorf("%w: language %q is not an ISO 639-1 code", ErrInvalidMetadata, meta.Language)
+		}
+	}
+	if meta.URL != "" {
+		u, err := url.Parse(meta.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidMetadata)
+		}
+	}
+	return nil
+}
+
+// attachSources fills in each chunk's Source from its document, so answers
+// can cite it. Chunks whose document cannot be read are left without one.
+func (s *service) attachSources(ctx context.Context, chunks []documentDomain.Chunk) {
+	sources := make(map[string]*documentDomain.ChunkSource)
+	for i := range chunks {
+		id := chunks[i].DocumentID
+		source, ok := sources[id]
+		if !ok {
+			doc, err := s.repo.GetByID(ctx, id)
+			if err != nil {
+				s.log.WarnContext(ctx, "failed to read document for its citation", "error", err, "document_id", id)
+			}
+			if doc != nil {
+				source = &documentDomain.ChunkSource{Title: doc.Title, DocumentMeta: doc.DocumentMeta, ExpiresAt: doc.ExpiresAt}
+			}
+			sources[id] = source
+		}
+		chunks[i].Source = source
+	}
+}
+
+// sourceLabel describes a chunk's document in the prompt, so the model can
+// tell sources apart by date and origin.
+func sourceLabel(source *documentDomain.ChunkSource) string {
+	if source == nil {
+		return ""
+	}
+	parts := []string{source.Title}
+	if source.Author != "" {
+		parts = append(parts, "by "+source.Author)
+	}
+	if source.Department != "" {
+		parts = append(parts, source.Department)
+	}
+	if source.EffectiveAt != nil {
+		parts = append(parts, "effective "+source.EffectiveAt.Format("2006-01-02"))
+	}
+	if source.ExpiresAt != nil {
+		parts = append(parts, "until "+source.ExpiresAt.Format("2006-01-02"))
+	}
+	if source.URL != "" {
+		parts = append(parts, source.URL)
+	}
+	return strings.Join(parts, ", ")
+}
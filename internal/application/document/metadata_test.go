@@ -0,0 +1,99 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+)
+
+func TestValidateMeta(t *testing.T) {
+	meta := documentDomain.DocumentMeta{Author: " Ana ", Language: "en_us", URL: "https://shop.example.com/returns"}
+	if err := validateMeta(&meta); err != nil {
+		t.Fatalf("Expected valid metadata, got %v", err)
+	}
+	if meta.Author != "Ana" || meta.Language != "en-US" {
+		t.Errorf("Expected normalized metadata, got %+v", meta)
+	}
+
+	for _, bad := range []documentDomain.DocumentMeta{
+		{Language: "english"},
+		{Language: "e"},
+		{URL: "shop.example.com/returns"},
+		{URL: "ftp://shop.example.com/returns"},
+	} {
+		if err := validateMeta(&bad); !errors.Is(err, ErrInvalidMetadata) {
+			t.Errorf("Expected ErrInvalidMetadata for %+v, got %v", bad, err)
+		}
+	}
+}
+
+func TestListDocumentsFilters(t *testing.T) {
+	repo := newMockDocumentRepo()
+	svc := NewService(ServiceConfig{Repo: repo})
+	ctx := context.Background()
+	admin := documentDomain.UserContext{UserID: "admin-1", IsAdmin: true}
+
+	jan := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	jun := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	docs := []*documentDomain.Document{
+		{Title: "returns-es", Content: "Devoluciones", DocumentMeta: documentDomain.DocumentMeta{Language: "ES", Department: "support", EffectiveAt: &jan}},
+		{Title: "returns-en", Content: "Returns", DocumentMeta: documentDomain.DocumentMeta{Language: "en", Department: "support", EffectiveAt: &jun}},
+		{Title: "payroll", Content: "Payroll", DocumentMeta: documentDomain.DocumentMeta{Language: "en", Department: "hr"}},
+	}
+	for _, doc := range docs {
+		if _, err := svc.CreateDocument(ctx, admin, doc); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	tests := []struct {
+		filter documentDomain.DocumentFilter
+		want   int64
+	}{
+		{documentDomain.DocumentFilter{}, 3},
+		{documentDomain.DocumentFilter{Language: "es"}, 1},
+		{documentDomain.DocumentFilter{Department: "support"}, 2},
+		{documentDomain.DocumentFilter{Department: "support", EffectiveOn: jan.AddDate(0, 1, 0)}, 1},
+	}
+	for _, tt := range tests {
+		_, total, err := svc.ListDocuments(ctx, admin, tt.filter, 10, 0)
+		if err != nil || total != tt.want {
+			t.Errorf("Expected %d documents for %+v, got %d (%v)", tt.want, tt.filter, total, err)
+		}
+	}
+
+	other := documentDomain.UserContext{UserID: "user-2"}
+	if _, total, _ := svc.ListDocuments(ctx, other, documentDomain.DocumentFilter{UserID: "admin-1"}, 10, 0); total != 0 {
+		t.Errorf("Expected users to see only their own documents, got %d", total)
+	}
+}
+
+func TestContextPromptCitesSources(t *testing.T) {
+	repo := newMockDocumentRepo()
+	effective := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	repo.documents["doc-1"] = &documentDomain.Document{ID: "doc-1", Title: "Return policy", DocumentMeta: documentDomain.DocumentMeta{
+		Author: "Support team", URL: "https://shop.example.com/returns", EffectiveAt: &effective,
+	}}
+	svc := &service{repo: repo}
+
+	chunks := []documentDomain.Chunk{
+		{DocumentID: "doc-1", Content: "Returns within 30 days."},
+		{DocumentID: "gone", Content: "Orphaned text."},
+	}
+	svc.attachSources(context.Background(), chunks)
+	if chunks[0].Source == nil || chunks[0].Source.Title != "Return policy" || chunks[1].Source != nil {
+		t.Fatalf("Expected a source for the known document only, got %+v, %+v", chunks[0].Source, chunks[1].Source)
+	}
+
+	prompt := buildContextPrompt(chunks)
+	if !strings.Contains(prompt, "[Source 1: Return policy, by Support team, effective 2025-03-01, https://shop.example.com/returns]") {
+		t.Errorf("Expected the first source to be labelled, got %q", prompt)
+	}
+	if !strings.Contains(prompt, "[Source 2]\nOrphaned text.") {
+		t.Errorf("Expected an unlabelled second source, got %q", prompt)
+	}
+}
@@ -0,0 +1,135 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/openai"
+)
+
+// Bounds on the generation settings a query may override.
+const (
+	maxTemperature  = 2.0
+	maxAnswerTokens = 4096
+)
+
+// ChatModel is a model that can write answers, on OpenAI or any server
+// with an OpenAI-compatible API such as Ollama.
+type ChatModel struct {
+	// Name is how the model is reported on answers, e.g. "gpt-4o" or
+	// "ollama:llama3".
+	Name string
+	// Model is the model ID sent to Client.
+	Model  string
+	Client *openai.Client
+}
+
+// ModelCatalog knows which models the providers currently offer.
+type ModelCatalog interface {
+	Offers(model string) bool
+}
+
+// offered reports whether model's provider still offers it; without a
+// catalog every model is assumed to be.
+func (s *service) offered(model string) bool {
+	return s.models == nil || s.models.Offers(model)
+}
+
+// chatModels returns the models to try, in order: the configured model on
+// the OpenAI client, then the fallbacks. A preferred model, when it is one
+// of them or an allowed OpenAI model, is tried first; the others still
+// answer if it fails.
+func (s *service) chatModels(preferred string) []ChatModel {
+	models := make([]ChatModel, 0, len(s.fallbackModels)+2)
+	if s.openaiClient != nil {
+		models = append(models, ChatModel{Name: s.modelName, Model: s.modelName, Client: s.openaiClient})
+	}
+	models = append(models, s.fallbackModels...)
+	if preferred == "" {
+		return models
+	}
+
+	for i, model := range models {
+		if model.Name == preferred {
+			return append(append([]ChatModel{model}, models[:i]...), models[i+1:]...)
+		}
+	}
+	if s.openaiClient != nil && slices.Contains(s.allowedModels, preferred) {
+		return append([]ChatModel{{Name: preferred, Model: preferred, Client: s.openaiClient}}, models...)
+	}
+	return models
+}
+
+// modelAllowed reports whether queries may ask for the named model.
+func (s *service) modelAllowed(name string) bool {
+	if name == s.modelName || slices.Contains(s.allowedModels, name) {
+		return true
+	}
+	return slices.ContainsFunc(s.fallbackModels, func(model ChatModel) bool { return model.Name == name })
+}
+
+// checkOverrides validates the generation settings query overrides.
+func (s *service) checkOverrides(query documentDomain.RAGQuery) error {
+	if t := query.Temperature; t != nil && (*t < 0 || *t > maxTemperature) {
+		return fmt.Errorf("%w: temperature must be between 0 and %g", ErrInvalidQuery, maxTemperature)
+	}
+	if p := query.TopP; p != nil && (*p <= 0 || *p > 1) {
+		return fmt.Errorf("%w: top_p must be above 0 and at most 1", ErrInvalidQuery)
+	}
+	if query.MaxTokens < 0 || query.MaxTokens > maxAnswerTokens {
+		return fmt.Errorf("%w: max_tokens must be between 1 and %d", ErrInvalidQuery, maxAnswerTokens)
+	}
+	if query.Model != "" && !s.modelAllowed(query.Model) {
+		return fmt.Errorf("%w: model %q is not allowed", ErrInvalidQuery, query.Model)
+	}
+	if query.Model != "" && !s.offered(query.Model) {
+		return fmt.Errorf("%w: model %q is no longer offered by its provider", ErrInvalidQuery, query.Model)
+	}
+	return nil
+}
+
+// completionOptions are the sampling settings for query: its overrides,
+// else the models' defaults and maxTokens.
+func completionOptions(query documentDomain.RAGQuery, maxTokens int) *openai.CompletionOptions {
+	opts := &openai.CompletionOptions{Temperature: query.Temperature, TopP: query.TopP, MaxTokens: maxTokens}
+	if query.MaxTokens > 0 {
+		opts.MaxTokens = query.MaxTokens
+	}
+	return opts
+}
+
+// generate answers messages, letting the model call tools when any are
+// offered. When a model fails, including when its circuit breaker is
+// open, the next one answers instead; the error returned wraps every
+// model's failure.
+func (s *service) generate(ctx context.Context, models []ChatModel, messages []openai.ChatMessage, tools []openai.Tool, opts *openai.CompletionOptions) (generation, error) {
+	var errs []error
+	for _, model := range models {
+		gen, err := s.generateWith(ctx, model, messages, tools, opts)
+		if err == nil {
+			gen.model = model.Name
+			return gen, nil
+		}
+		// The caller gave up, so the other models would fail the same way.
+		if ctx.Err() != nil {
+			return gen, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", model.Name, err))
+		s.log.WarnContext(ctx, "chat model failed", "error", err, "model", model.Name)
+	}
+	if len(errs) == 0 {
+		return generation{}, errors.New("no chat model configured")
+	}
+	return generation{}, errors.Join(errs...)
+}
+
+func (s *service) generateWith(ctx context.Context, model ChatModel, messages []openai.ChatMessage, tools []openai.Tool, opts *openai.CompletionOptions) (generation, error) {
+	if len(tools) == 0 {
+		answer, err := model.Client.CreateChatCompletion(ctx, messages, model.Model, opts)
+		return generation{answer: answer}, err
+	}
+	return s.completeWithTools(ctx, model, messages, tools, opts)
+}
@@ -0,0 +1,195 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/elprogramadorgt/lucidRAG/pkg/openai"
+)
+
+func TestGenerateFallsBackToNextModel(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer primary.Close()
+	var fallbackModel string
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string `json:"model"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		fallbackModel = req.Model
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"We open at 9."}}]}`))
+	}))
+	defer fallback.Close()
+
+	s := &service{
+		log:          logger.New(),
+		openaiClient: openai.NewClient("test-key", openai.WithBaseURL(primary.URL)),
+		modelName:    "gpt-4o",
+		fallbackModels: []ChatModel{
+			{Name: "ollama:llama3", Model: "llama3", Client: openai.NewClient("", openai.WithBaseURL(fallback.URL))},
+		},
+	}
+
+	gen, err := s.generate(context.Background(), s.chatModels(""), []openai.ChatMessage{{Role: "user", Content: "hours?"}}, nil, nil)
+	if err != nil {
+		t.Fatalf("Expected the fallback to answer, got %v", err)
+	}
+	if gen.answer != "We open at 9." || gen.model != "ollama:llama3" {
+		t.Errorf("Expected the fallback's answer and name, got %q from %q", gen.answer, gen.model)
+	}
+	if fallbackModel != "llama3" {
+		t.Errorf("Expected the fallback's model ID to be sent, got %q", fallbackModel)
+	}
+}
+
+func TestGenerateReportsEveryFailure(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+
+	s := &service{
+		log:            logger.New(),
+		openaiClient:   openai.NewClient("test-key", openai.WithBaseURL(failing.URL)),
+		modelName:      "gpt-4o",
+		fallbackModels: []ChatModel{{Name: "gpt-3.5-turbo", Model: "gpt-3.5-turbo", Client: openai.NewClient("test-key", openai.WithBaseURL(failing.URL))}},
+	}
+
+	_, err := s.generate(context.Background(), s.chatModels(""), []openai.ChatMessage{{Role: "user", Content: "hours?"}}, nil, nil)
+	if err == nil {
+		t.Fatal("Expected an error when every model fails")
+	}
+	for _, name := range []string{"gpt-4o", "gpt-3.5-turbo"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("Expected the error to name %s, got %v", name, err)
+		}
+	}
+}
+
+func TestChatModelsPreferred(t *testing.T) {
+	client := openai.NewClient("test-key")
+	s := &service{
+		openaiClient:   client,
+		modelName:      "gpt-3.5-turbo",
+		fallbackModels: []ChatModel{{Name: "ollama:llama3", Model: "llama3"}, {Name: "gpt-4o-mini", Model: "gpt-4o-mini"}},
+		allowedModels:  []string{"gpt-4o"},
+	}
+	names := func(models []ChatModel) string {
+		var out []string
+		for _, model := range models {
+			out = append(out, model.Name)
+		}
+		return strings.Join(out, ",")
+	}
+
+	tests := []struct {
+		preferred string
+		want      string
+	}{
+		{"", "gpt-3.5-turbo,ollama:llama3,gpt-4o-mini"},
+		{"gpt-4o-mini", "gpt-4o-mini,gpt-3.5-turbo,ollama:llama3"},
+		{"gpt-4o", "gpt-4o,gpt-3.5-turbo,ollama:llama3,gpt-4o-mini"},
+		{"gpt-5", "gpt-3.5-turbo,ollama:llama3,gpt-4o-mini"},
+	}
+	for _, tt := range tests {
+		if got := names(s.chatModels(tt.preferred)); got != tt.want {
+			t.Errorf("chatModels(%q) = %s, want %s", tt.preferred, got, tt.want)
+		}
+	}
+	if got := names(s.chatModels("")); got != tests[0].want {
+		t.Errorf("Expected preferring a model to leave the fallbacks alone, got %s", got)
+	}
+}
+
+type mockCatalog map[string]bool
+
+func (m mockCatalog) Offers(model string) bool { return m[model] }
+
+func TestCheckOverrides(t *testing.T) {
+	s := &service{
+		modelName:     "gpt-3.5-turbo",
+		allowedModels: []string{"gpt-4o", "gpt-4-turbo"},
+		models:        mockCatalog{"gpt-3.5-turbo": true, "gpt-4o": true},
+	}
+	ptr := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name  string
+		query documentDomain.RAGQuery
+		valid bool
+	}{
+		{"none", documentDomain.RAGQuery{}, true},
+		{"zero temperature", documentDomain.RAGQuery{Temperature: ptr(0)}, true},
+		{"hot temperature", documentDomain.RAGQuery{Temperature: ptr(2.5)}, false},
+		{"top p", documentDomain.RAGQuery{TopP: ptr(0.9)}, true},
+		{"zero top p", documentDomain.RAGQuery{TopP: ptr(0)}, false},
+		{"max tokens", documentDomain.RAGQuery{MaxTokens: 200}, true},
+		{"too many tokens", documentDomain.RAGQuery{MaxTokens: maxAnswerTokens + 1}, false},
+		{"configured model", documentDomain.RAGQuery{Model: "gpt-3.5-turbo"}, true},
+		{"allowed model", documentDomain.RAGQuery{Model: "gpt-4o"}, true},
+		{"unknown model", documentDomain.RAGQuery{Model: "gpt-5"}, false},
+		{"retired model", documentDomain.RAGQuery{Model: "gpt-4-turbo"}, false},
+	}
+	for _, tt := range tests {
+		err := s.checkOverrides(tt.query)
+		if tt.valid && err != nil {
+			t.Errorf("%s: expected no error, got %v", tt.name, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("%s: expected ErrInvalidQuery, got %v", tt.name, err)
+		}
+	}
+}
+
+func TestQueryRAGSendsOverrides(t *testing.T) {
+	var chat map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/embeddings") {
+			_, _ = w.Write([]byte(`{"data":[{"embedding":[0,0,1]}]}`))
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&chat)
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"We open at 9."}}]}`))
+	}))
+	defer server.Close()
+
+	chunkRepo := newMockChunkRepo()
+	chunkRepo.chunks = []documentDomain.Chunk{{ID: "c1", DocumentID: "d1", Content: "We open at 9"}}
+	svc := NewService(ServiceConfig{
+		Repo:          newMockDocumentRepo(),
+		ChunkRepo:     chunkRepo,
+		OpenAIClient:  openai.NewClient("test-key", openai.WithBaseURL(server.URL)),
+		AllowedModels: []string{"gpt-4o"},
+	})
+
+	temperature := 0.0
+	resp, err := svc.QueryRAG(context.Background(), documentDomain.RAGQuery{
+		Query:       "when do you open?",
+		Model:       "gpt-4o",
+		Temperature: &temperature,
+		MaxTokens:   120,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.Model != "gpt-4o" || chat["model"] != "gpt-4o" {
+		t.Errorf("Expected gpt-4o to answer, got %q and sent %v", resp.Model, chat["model"])
+	}
+	if chat["temperature"] != 0.0 || chat["max_tokens"] != 120.0 {
+		t.Errorf("Expected temperature 0 and max_tokens 120 sent, got %v and %v", chat["temperature"], chat["max_tokens"])
+	}
+	if _, ok := chat["top_p"]; ok {
+		t.Errorf("Expected no top_p without an override, got %v", chat["top_p"])
+	}
+}
@@ -0,0 +1,61 @@
+package document
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/markdown"
+)
+
+// maxPreviewBytes bounds the Markdown of a previewed draft.
+const maxPreviewBytes = 1 << 20
+
+// PreviewArticle renders the draft and splits it with the configured
+// chunker, as creating the document would. A stored document is previewed
+// only for users who can read it.
+func (s *service) PreviewArticle(ctx context.Context, userCtx documentDomain.UserContext, draft documentDomain.ArticleDraft) (*documentDomain.ArticlePreview, error) {
+	content := draft.Content
+	if strings.TrimSpace(content) == "" {
+		if draft.DocumentID == "" {
+			return nil, fmt.Errorf("%w: content or a document is required", ErrInvalidDraft)
+		}
+		doc, err := s.GetDocument(ctx, userCtx, draft.DocumentID)
+		if err != nil {
+			return nil, err
+		}
+		content = doc.Content
+	}
+	if len(content) > maxPreviewBytes {
+		return nil, fmt.Errorf("%w: content must be at most %d bytes", ErrInvalidDraft, maxPreviewBytes)
+	}
+
+	html, problems := markdown.Render(content)
+	preview := &documentDomain.ArticlePreview{
+		HTML:     html,
+		Valid:    true,
+		Problems: make([]documentDomain.MarkdownProblem, 0, len(problems)),
+		Chunks:   []documentDomain.ChunkPreview{},
+	}
+	for _, p := range problems {
+		if p.Severity == markdown.SeverityError {
+			preview.Valid = false
+		}
+		preview.Problems = append(preview.Problems, documentDomain.MarkdownProblem{Line: p.Line, Severity: string(p.Severity), Message: p.Message})
+	}
+
+	if s.chunker == nil {
+		return preview, nil
+	}
+	for i, chunk := range s.chunker.ChunkStructured(content) {
+		preview.Chunks = append(preview.Chunks, documentDomain.ChunkPreview{
+			Index:   i,
+			Kind:    documentDomain.ChunkKind(chunk.Kind),
+			Content: chunk.Content,
+			Tokens:  estimateTokens(chunk.Content),
+			Table:   tableInfo(chunk.Table),
+		})
+	}
+	return preview, nil
+}
@@ -0,0 +1,62 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/chunker"
+)
+
+func TestPreviewArticle(t *testing.T) {
+	svc := NewService(ServiceConfig{
+		Repo:    newMockDocumentRepo(),
+		Chunker: chunker.New(8, 0),
+	})
+	ctx := context.Background()
+	owner := documentDomain.UserContext{UserID: "user-1"}
+
+	content := "# Returns\n\nItems can be returned within thirty days of delivery for a full refund.\n\n" +
+		"| Item | Days |\n| --- | --- |\n| Shoes | 30 |\n\nSee [details](javascript:void(0))."
+	preview, err := svc.PreviewArticle(ctx, owner, documentDomain.ArticleDraft{Content: content})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.HasPrefix(preview.HTML, "<h1>Returns</h1>") || preview.Valid {
+		t.Errorf("Expected rendered HTML and an invalid draft, got %+v", preview)
+	}
+	if len(preview.Problems) != 1 || preview.Problems[0].Line != 9 || preview.Problems[0].Severity != "error" {
+		t.Errorf("Expected the javascript link reported, got %+v", preview.Problems)
+	}
+
+	var tables int
+	for i, chunk := range preview.Chunks {
+		if chunk.Index != i || chunk.Tokens == 0 {
+			t.Errorf("Unexpected chunk %+v", chunk)
+		}
+		if chunk.Kind == documentDomain.ChunkKindTable {
+			tables++
+			if chunk.Table == nil || chunk.Table.Columns[0] != "Item" {
+				t.Errorf("Expected the table's columns, got %+v", chunk.Table)
+			}
+		}
+	}
+	if len(preview.Chunks) < 3 || tables != 1 {
+		t.Errorf("Expected prose split in several chunks and one table chunk, got %+v", preview.Chunks)
+	}
+
+	// A stored draft is previewed for those who can read it.
+	id, _ := svc.CreateDocument(ctx, owner, &documentDomain.Document{Title: "Returns", Content: "# Returns", Status: documentDomain.StatusDraft})
+	preview, err = svc.PreviewArticle(ctx, owner, documentDomain.ArticleDraft{DocumentID: id})
+	if err != nil || !preview.Valid || len(preview.Chunks) != 1 {
+		t.Errorf("Expected the stored draft previewed, got %+v, %v", preview, err)
+	}
+	if _, err := svc.PreviewArticle(ctx, documentDomain.UserContext{UserID: "user-2"}, documentDomain.ArticleDraft{DocumentID: id}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden for another user, got %v", err)
+	}
+	if _, err := svc.PreviewArticle(ctx, owner, documentDomain.ArticleDraft{Content: "  "}); !errors.Is(err, ErrInvalidDraft) {
+		t.Errorf("Expected ErrInvalidDraft for an empty draft, got %v", err)
+	}
+}
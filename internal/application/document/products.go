@@ -0,0 +1,57 @@
+package document
+
+import (
+	"context"
+	"strings"
+
+	productDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/product"
+)
+
+// ProductMatcher finds the catalog products a question is about.
+type ProductMatcher interface {
+	Match(ctx context.Context, query string) ([]productDomain.Product, error)
+}
+
+// matchProducts returns the products query names, or nil when there is no
+// catalog. A failing catalog degrades to retrieval alone.
+func (s *service) matchProducts(ctx context.Context, query string) []productDomain.Product {
+	if s.products == nil {
+		return nil
+	}
+	products, err := s.products.Match(ctx, query)
+	if err != nil {
+		s.log.WarnContext(ctx, "failed to match products", "error", err)
+		return nil
+	}
+	return products
+}
+
+// productFacts returns the facts of each product, in the order given.
+func productFacts(products []productDomain.Product) []string {
+	facts := make([]string, len(products))
+	for i, p := range products {
+		facts[i] = p.Facts()
+	}
+	return facts
+}
+
+// buildProductPrompt lists the matched products ahead of the retrieved
+// chunks.
+func buildProductPrompt(facts []string) string {
+	if len(facts) == 0 {
+		return ""
+	}
+	return "[Product catalog]\n" + strings.Join(facts, "\n\n") + "\n\n"
+}
+
+// productSKUs returns the SKUs of products.
+func productSKUs(products []productDomain.Product) []string {
+	if len(products) == 0 {
+		return nil
+	}
+	skus := make([]string, len(products))
+	for i, p := range products {
+		skus[i] = p.SKU
+	}
+	return skus
+}
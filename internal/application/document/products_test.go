@@ -0,0 +1,67 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	productDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/product"
+	"github.com/elprogramadorgt/lucidRAG/pkg/openai"
+)
+
+type mockProducts struct {
+	products []productDomain.Product
+}
+
+func (m *mockProducts) Match(ctx context.Context, query string) ([]productDomain.Product, error) {
+	return m.products, nil
+}
+
+func TestQueryRAGAnswersFromMatchedProducts(t *testing.T) {
+	var prompt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		var body any
+		if strings.HasSuffix(r.URL.Path, "/embeddings") {
+			body = map[string]any{"data": []any{map[string]any{"embedding": []float64{0, 0, 0}}}}
+		} else {
+			prompt = req.Messages[len(req.Messages)-1].Content
+			body = map[string]any{
+				"choices": []any{map[string]any{"message": map[string]any{"role": "assistant", "content": "The coffee mug costs 8.00 USD."}}},
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	defer server.Close()
+
+	price := 8.0
+	svc := NewService(ServiceConfig{
+		Repo:         newMockDocumentRepo(),
+		ChunkRepo:    newMockChunkRepo(),
+		OpenAIClient: openai.NewClient("test-key", openai.WithBaseURL(server.URL)),
+		Products:     &mockProducts{products: []productDomain.Product{{SKU: "MUG-2", Name: "Coffee mug", Price: &price, Currency: "USD"}}},
+	})
+
+	// No chunk matches, yet the catalog answers.
+	resp, err := svc.QueryRAG(context.Background(), documentDomain.RAGQuery{Query: "How much is the coffee mug?"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.Answer != "The coffee mug costs 8.00 USD." || len(resp.Products) != 1 || resp.Products[0] != "MUG-2" {
+		t.Errorf("Unexpected response %+v", resp)
+	}
+	if !strings.Contains(prompt, "SKU: MUG-2\nPrice: 8.00 USD") {
+		t.Errorf("Expected the product facts in the prompt, got %q", prompt)
+	}
+}
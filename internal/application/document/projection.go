@@ -0,0 +1,88 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/vectormath"
+)
+
+var ErrInvalidProjection = errors.New("invalid projection")
+
+const (
+	defaultProjectionSample = 500
+	// maxProjectionSample bounds the chunks projected per request: PCA
+	// reads every vector a few hundred times.
+	maxProjectionSample = 2000
+)
+
+// ProjectEmbeddings samples the chunks of one embedding space and places
+// them on a plane, or returns their vectors for the client to project.
+func (s *service) ProjectEmbeddings(ctx context.Context, userCtx documentDomain.UserContext, query documentDomain.ProjectionQuery) (*documentDomain.EmbeddingProjection, error) {
+	if !userCtx.IsAdmin {
+		return nil, ErrForbidden
+	}
+	if query.Method == "" {
+		query.Method = documentDomain.ProjectionPCA
+	}
+	if query.Method != documentDomain.ProjectionPCA && query.Method != documentDomain.ProjectionRaw {
+		return nil, fmt.Errorf("%w: method must be pca or raw", ErrInvalidProjection)
+	}
+	if query.Sample == 0 {
+		query.Sample = defaultProjectionSample
+	}
+	if query.Sample < 0 || query.Sample > maxProjectionSample {
+		return nil, fmt.Errorf("%w: sample must be between 1 and %d", ErrInvalidProjection, maxProjectionSample)
+	}
+
+	space, err := s.embeddingSpace(ctx, query.Collection)
+	if err != nil {
+		return nil, err
+	}
+	projection := &documentDomain.EmbeddingProjection{
+		Method:     query.Method,
+		Collection: space.Collection,
+		Model:      space.Model,
+		Points:     []documentDomain.ProjectedChunk{},
+	}
+	if s.chunkRepo == nil {
+		return projection, nil
+	}
+
+	chunks, err := s.chunkRepo.Sample(ctx, space, query.Sample)
+	if err != nil {
+		return nil, err
+	}
+	s.attachSources(ctx, chunks)
+
+	vectors := make([][]float64, len(chunks))
+	for i, chunk := range chunks {
+		vectors[i] = chunk.Embedding
+	}
+	if query.Method == documentDomain.ProjectionPCA && len(chunks) > 0 {
+		points, explained := vectormath.PCA2D(vectors)
+		if points == nil {
+			return nil, errors.New("sampled vectors differ in size")
+		}
+		for i, p := range points {
+			vectors[i] = []float64{p[0], p[1]}
+		}
+		projection.ExplainedVariance = explained[:]
+	}
+
+	for i, chunk := range chunks {
+		point := documentDomain.ProjectedChunk{
+			ChunkID:    chunk.ID,
+			DocumentID: chunk.DocumentID,
+			ChunkIndex: chunk.ChunkIndex,
+			Vector:     vectors[i],
+		}
+		if chunk.Source != nil {
+			point.DocumentTitle = chunk.Source.Title
+		}
+		projection.Points = append(projection.Points, point)
+	}
+	return projection, nil
+}
@@ -0,0 +1,84 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+)
+
+func TestProjectEmbeddings(t *testing.T) {
+	repo := newMockDocumentRepo()
+	chunkRepo := newMockChunkRepo()
+	repo.documents["refunds"] = &documentDomain.Document{ID: "refunds", Title: "Refund policy"}
+	repo.documents["hours"] = &documentDomain.Document{ID: "hours", Title: "Opening hours"}
+	chunkRepo.chunks = []documentDomain.Chunk{
+		{ID: "c1", DocumentID: "refunds", Embedding: []float64{1, 0, 0}},
+		{ID: "c2", DocumentID: "refunds", ChunkIndex: 1, Embedding: []float64{0.9, 0.1, 0}},
+		{ID: "c3", DocumentID: "hours", Embedding: []float64{0, 0, 1}},
+		{ID: "c4", DocumentID: "hours", Embedding: []float64{0, 1, 1}, Hidden: true},
+		{ID: "c5", DocumentID: "other", Collection: "code", Embedding: []float64{1, 1, 1}},
+	}
+	svc := NewService(ServiceConfig{Repo: repo, ChunkRepo: chunkRepo, EmbeddingModel: "text-embedding-3-small"})
+	ctx := context.Background()
+	admin := documentDomain.UserContext{UserID: "admin-1", IsAdmin: true}
+
+	if _, err := svc.ProjectEmbeddings(ctx, documentDomain.UserContext{UserID: "u"}, documentDomain.ProjectionQuery{}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden, got %v", err)
+	}
+	for _, query := range []documentDomain.ProjectionQuery{
+		{Method: "tsne"},
+		{Sample: -1},
+		{Sample: maxProjectionSample + 1},
+	} {
+		if _, err := svc.ProjectEmbeddings(ctx, admin, query); !errors.Is(err, ErrInvalidProjection) {
+			t.Errorf("Expected ErrInvalidProjection for %+v, got %v", query, err)
+		}
+	}
+
+	projection, err := svc.ProjectEmbeddings(ctx, admin, documentDomain.ProjectionQuery{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if projection.Method != documentDomain.ProjectionPCA || projection.Model != "text-embedding-3-small" {
+		t.Errorf("Expected a PCA projection of the default space, got %+v", projection)
+	}
+	if len(projection.Points) != 3 {
+		t.Fatalf("Expected the 3 visible default-space chunks, got %d", len(projection.Points))
+	}
+	if len(projection.ExplainedVariance) != 2 {
+		t.Errorf("Expected explained variance for both axes, got %v", projection.ExplainedVariance)
+	}
+	for _, p := range projection.Points {
+		if len(p.Vector) != 2 {
+			t.Errorf("Expected 2D coordinates for %s, got %v", p.ChunkID, p.Vector)
+		}
+		if want := repo.documents[p.DocumentID].Title; p.DocumentTitle != want {
+			t.Errorf("Expected %s to be labelled %q, got %q", p.ChunkID, want, p.DocumentTitle)
+		}
+	}
+	// The two refund chunks should land closer to each other than to the
+	// opening hours.
+	a, b, c := projection.Points[0].Vector, projection.Points[1].Vector, projection.Points[2].Vector
+	if dist(a, b) >= dist(a, c) {
+		t.Errorf("Expected similar chunks to stay close, got %v %v %v", a, b, c)
+	}
+
+	projection, err = svc.ProjectEmbeddings(ctx, admin, documentDomain.ProjectionQuery{Method: documentDomain.ProjectionRaw, Sample: 1})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(projection.Points) != 1 || len(projection.Points[0].Vector) != 3 || projection.ExplainedVariance != nil {
+		t.Errorf("Expected one raw vector, got %+v", projection)
+	}
+
+	if _, err := svc.ProjectEmbeddings(ctx, admin, documentDomain.ProjectionQuery{Collection: "code"}); !errors.Is(err, ErrCollectionNotFound) {
+		t.Errorf("Expected ErrCollectionNotFound without collections, got %v", err)
+	}
+}
+
+func dist(a, b []float64) float64 {
+	dx, dy := a[0]-b[0], a[1]-b[1]
+	return dx*dx + dy*dy
+}
@@ -0,0 +1,37 @@
+package document
+
+import (
+	"context"
+	"time"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+)
+
+// PublicationJob hides the chunks of documents that are not yet published or
+// have expired, and restores them once they become available. It is meant to
+// run every minute from the scheduler.
+type PublicationJob struct {
+	repo      documentDomain.Repository
+	chunkRepo documentDomain.ChunkRepository
+}
+
+func NewPublicationJob(repo documentDomain.Repository, chunkRepo documentDomain.ChunkRepository) *PublicationJob {
+	return &PublicationJob{
+		repo:      repo,
+		chunkRepo: chunkRepo,
+	}
+}
+
+// Run applies the publication windows as of the current time.
+func (j *PublicationJob) Run(ctx context.Context) error {
+	return j.Sync(ctx, time.Now())
+}
+
+// Sync applies the publication windows as of now.
+func (j *PublicationJob) Sync(ctx context.Context, now time.Time) error {
+	ids, err := j.repo.ListUnavailableIDs(ctx, now)
+	if err != nil {
+		return err
+	}
+	return j.chunkRepo.SyncHidden(ctx, ids)
+}
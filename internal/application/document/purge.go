@@ -0,0 +1,47 @@
+package document
+
+import (
+	"context"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+)
+
+// purgeBatchSize is how many documents are read at a time while purging.
+const purgeBatchSize = 100
+
+func (s *service) PurgeUserDocuments(ctx context.Context, userCtx documentDomain.UserContext, userID string, dryRun bool) (*documentDomain.PurgeResult, error) {
+	if !userCtx.IsAdmin {
+		return nil, ErrForbidden
+	}
+
+	result := &documentDomain.PurgeResult{}
+	offset := 0
+	for {
+		docs, err := s.repo.ListByUser(ctx, userID, purgeBatchSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		for i := range docs {
+			if s.chunkRepo != nil {
+				chunks, err := s.chunkRepo.CountByDocumentID(ctx, docs[i].ID)
+				if err != nil {
+					return nil, err
+				}
+				result.Chunks += chunks
+			}
+			if !dryRun {
+				if err := s.removeDocument(ctx, &docs[i], userCtx.UserID); err != nil {
+					return nil, err
+				}
+			}
+			result.Documents++
+		}
+		if len(docs) < purgeBatchSize {
+			return result, nil
+		}
+		// Deleted documents drop out of the listing; counted ones do not.
+		if dryRun {
+			offset += len(docs)
+		}
+	}
+}
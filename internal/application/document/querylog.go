@@ -0,0 +1,195 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+)
+
+var (
+	ErrQueryLogNotFound  = errors.New("query not found")
+	ErrReplayUnavailable = errors.New("replay needs RAG configured")
+)
+
+const (
+	defaultQueryLogLimit = 20
+	maxQueryLogLimit     = 100
+)
+
+// queryHits records the chunks retrieval returned, marking those that
+// made it into the prompt.
+func queryHits(retrieved, inContext []documentDomain.Chunk) []documentDomain.QueryHit {
+	used := make(map[string]bool, len(inContext))
+	for _, chunk := range inContext {
+		used[chunk.ID] = true
+	}
+	hits := make([]documentDomain.QueryHit, len(retrieved))
+	for i, chunk := range retrieved {
+		hits[i] = documentDomain.QueryHit{
+			ChunkID:    chunk.ID,
+			DocumentID: chunk.DocumentID,
+			ChunkIndex: chunk.ChunkIndex,
+			Score:      chunk.Score,
+			Pinned:     chunk.Pinned,
+			InContext:  used[chunk.ID],
+		}
+	}
+	return hits
+}
+
+// queryRun carries a query through answering. A replay skips the answer
+// cache, events and the query log, keeping its entry in entry instead.
+type queryRun struct {
+	replay bool
+	entry  *documentDomain.QueryLog
+}
+
+// logQuery records query with its answer and hits, and tags resp with the
+// entry's ID. A failure costs only the entry, never the answer.
+func (s *service) logQuery(ctx context.Context, run *queryRun, query documentDomain.RAGQuery, outcome documentDomain.QueryOutcome, resp *documentDomain.RAGResponse, hits []documentDomain.QueryHit) {
+	if s.queryLogRepo == nil && !run.replay {
+		return
+	}
+	if hits == nil {
+		hits = []documentDomain.QueryHit{}
+	}
+	entry := &documentDomain.QueryLog{
+		Query:            query.Query,
+		Channel:          query.Channel,
+		Collection:       query.Collection,
+		TopK:             query.TopK,
+		Threshold:        query.Threshold,
+		Outcome:          outcome,
+		Hits:             hits,
+		Answer:           resp.Answer,
+		Model:            resp.Model,
+		ConfidenceScore:  resp.ConfidenceScore,
+		Groundedness:     resp.Groundedness,
+		ToolsUsed:        resp.ToolsUsed,
+		Shortcut:         resp.Shortcut,
+		ProcessingTimeMs: resp.ProcessingTimeMs,
+	}
+	if run.replay {
+		entry.CreatedAt = time.Now()
+		run.entry = entry
+		return
+	}
+	id, err := s.queryLogRepo.Create(ctx, entry)
+	if err != nil {
+		s.log.WarnContext(ctx, "failed to log query", "error", err)
+		return
+	}
+	resp.QueryID = id
+}
+
+func (s *service) ListQueryLogs(ctx context.Context, userCtx documentDomain.UserContext, filter documentDomain.QueryLogFilter) ([]documentDomain.QueryLog, int64, error) {
+	if !userCtx.IsAdmin {
+		return nil, 0, ErrForbidden
+	}
+	if !filter.StartTime.IsZero() && !filter.EndTime.IsZero() && filter.EndTime.Before(filter.StartTime) {
+		return nil, 0, fmt.Errorf("%w: end_time is before start_time", ErrInvalidQuery)
+	}
+	if s.queryLogRepo == nil {
+		return []documentDomain.QueryLog{}, 0, nil
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultQueryLogLimit
+	}
+	if filter.Limit > maxQueryLogLimit {
+		filter.Limit = maxQueryLogLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	return s.queryLogRepo.List(ctx, filter)
+}
+
+func (s *service) GetQueryLog(ctx context.Context, userCtx documentDomain.UserContext, id string) (*documentDomain.QueryLog, error) {
+	if !userCtx.IsAdmin {
+		return nil, ErrForbidden
+	}
+	if s.queryLogRepo == nil {
+		return nil, ErrQueryLogNotFound
+	}
+	entry, err := s.queryLogRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, ErrQueryLogNotFound
+	}
+	return entry, nil
+}
+
+// ReplayQuery answers a logged query again with the current documents,
+// rules and settings, and compares the two runs. The replay is neither
+// cached nor logged.
+func (s *service) ReplayQuery(ctx context.Context, userCtx documentDomain.UserContext, id string) (*documentDomain.QueryReplay, error) {
+	original, err := s.GetQueryLog(ctx, userCtx, id)
+	if err != nil {
+		return nil, err
+	}
+	if s.embedder == nil || s.chunkRepo == nil {
+		return nil, ErrReplayUnavailable
+	}
+
+	run := &queryRun{replay: true}
+	_, err = s.queryRAG(ctx, documentDomain.RAGQuery{
+		Query:      original.Query,
+		TopK:       original.TopK,
+		Threshold:  original.Threshold,
+		Channel:    original.Channel,
+		Collection: original.Collection,
+	}, run)
+	if err != nil {
+		return nil, err
+	}
+	return compareRuns(*original, *run.entry), nil
+}
+
+// compareRuns lists how the hits and answer of replay differ from those
+// of original.
+func compareRuns(original, replay documentDomain.QueryLog) *documentDomain.QueryReplay {
+	result := &documentDomain.QueryReplay{
+		Original:       original,
+		Replay:         replay,
+		Added:          []documentDomain.QueryHit{},
+		Removed:        []documentDomain.QueryHit{},
+		Rescored:       []documentDomain.HitChange{},
+		OutcomeChanged: original.Outcome != replay.Outcome,
+		AnswerChanged:  original.Answer != replay.Answer,
+	}
+
+	before := make(map[string]documentDomain.QueryHit, len(original.Hits))
+	for _, hit := range original.Hits {
+		before[hit.ChunkID] = hit
+	}
+	for _, hit := range replay.Hits {
+		old, ok := before[hit.ChunkID]
+		if !ok {
+			result.Added = append(result.Added, hit)
+			continue
+		}
+		delete(before, hit.ChunkID)
+		if old.Score != hit.Score || old.InContext != hit.InContext {
+			result.Rescored = append(result.Rescored, documentDomain.HitChange{
+				ChunkID:         hit.ChunkID,
+				DocumentID:      hit.DocumentID,
+				ScoreBefore:     old.Score,
+				ScoreAfter:      hit.Score,
+				InContextBefore: old.InContext,
+				InContextAfter:  hit.InContext,
+			})
+		}
+	}
+	// Walk the original hits again to keep them in their retrieval order.
+	for _, hit := range original.Hits {
+		if _, ok := before[hit.ChunkID]; ok {
+			result.Removed = append(result.Removed, hit)
+		}
+	}
+	return result
+}
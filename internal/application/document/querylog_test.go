@@ -0,0 +1,217 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/openai"
+)
+
+type mockQueryLogRepo struct {
+	entries []documentDomain.QueryLog
+	filter  documentDomain.QueryLogFilter
+}
+
+func (m *mockQueryLogRepo) Create(ctx context.Context, entry *documentDomain.QueryLog) (string, error) {
+	entry.ID = fmt.Sprintf("q%d", len(m.entries)+1)
+	m.entries = append(m.entries, *entry)
+	return entry.ID, nil
+}
+
+func (m *mockQueryLogRepo) GetByID(ctx context.Context, id string) (*documentDomain.QueryLog, error) {
+	for i := range m.entries {
+		if m.entries[i].ID == id {
+			return &m.entries[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockQueryLogRepo) List(ctx context.Context, filter documentDomain.QueryLogFilter) ([]documentDomain.QueryLog, int64, error) {
+	m.filter = filter
+	return m.entries, int64(len(m.entries)), nil
+}
+
+func (m *mockQueryLogRepo) Stats(ctx context.Context, start, end time.Time, lowConfidence float64) (*documentDomain.QueryStats, error) {
+	return &documentDomain.QueryStats{}, nil
+}
+
+func (m *mockQueryLogRepo) Gaps(ctx context.Context, start, end time.Time, lowConfidence float64, limit int) ([]documentDomain.KnowledgeGap, error) {
+	return nil, nil
+}
+
+func TestQueryRAGLogsQuery(t *testing.T) {
+	server, _ := embeddingServer(t)
+	defer server.Close()
+
+	chunkRepo := newMockChunkRepo()
+	chunkRepo.chunks = []documentDomain.Chunk{
+		{ID: "c1", DocumentID: "d1", ChunkIndex: 2, Content: "Opening hours are 9 to 5", Score: 0.91},
+		{ID: "c2", DocumentID: "d2", Content: "We ship worldwide", Score: 0.78},
+	}
+	logs := &mockQueryLogRepo{}
+	svc := NewService(ServiceConfig{
+		Repo:         newMockDocumentRepo(),
+		ChunkRepo:    chunkRepo,
+		OpenAIClient: openai.NewClient("test-key", openai.WithBaseURL(server.URL)),
+		QueryLogRepo: logs,
+	})
+
+	resp, err := svc.QueryRAG(context.Background(), documentDomain.RAGQuery{Query: "when do you open?", Channel: documentDomain.ChannelWhatsApp})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(logs.entries) != 1 || resp.QueryID != logs.entries[0].ID {
+		t.Fatalf("Expected the query logged under the response's query_id, got %q and %+v", resp.QueryID, logs.entries)
+	}
+	entry := logs.entries[0]
+	if entry.Outcome != documentDomain.OutcomeAnswered || entry.Answer != resp.Answer || entry.Channel != documentDomain.ChannelWhatsApp {
+		t.Errorf("Expected the answered query, got %+v", entry)
+	}
+	if entry.TopK != 5 || entry.Threshold != 0.7 {
+		t.Errorf("Expected the effective top_k and threshold, got %d and %v", entry.TopK, entry.Threshold)
+	}
+	want := []documentDomain.QueryHit{
+		{ChunkID: "c1", DocumentID: "d1", ChunkIndex: 2, Score: 0.91, InContext: true},
+		{ChunkID: "c2", DocumentID: "d2", Score: 0.78, InContext: true},
+	}
+	if len(entry.Hits) != len(want) {
+		t.Fatalf("Expected %d hits, got %+v", len(want), entry.Hits)
+	}
+	for i, hit := range want {
+		if entry.Hits[i] != hit {
+			t.Errorf("Expected hit %d to be %+v, got %+v", i, hit, entry.Hits[i])
+		}
+	}
+}
+
+func TestQueryRAGLogsShortcut(t *testing.T) {
+	shortcuts := newMockShortcutRepo()
+	shortcuts.shortcuts["hours"] = &documentDomain.FAQShortcut{ID: "hours", Keywords: []string{"hours"}, Answer: "We open 9 to 5.", IsActive: true}
+	logs := &mockQueryLogRepo{}
+	svc := NewService(ServiceConfig{Repo: newMockDocumentRepo(), ShortcutRepo: shortcuts, QueryLogRepo: logs})
+
+	if _, err := svc.QueryRAG(context.Background(), documentDomain.RAGQuery{Query: "your hours?"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(logs.entries) != 1 || logs.entries[0].Outcome != documentDomain.OutcomeShortcut || logs.entries[0].Shortcut != "hours" {
+		t.Fatalf("Expected the shortcut answer logged, got %+v", logs.entries)
+	}
+	if logs.entries[0].Hits == nil {
+		t.Error("Expected an empty hit list rather than none")
+	}
+}
+
+func TestQueryHits(t *testing.T) {
+	retrieved := []documentDomain.Chunk{{ID: "c1", Score: 0.9}, {ID: "c2", Score: 0.8, Pinned: true}, {ID: "c3", Score: 0.7}}
+	hits := queryHits(retrieved, []documentDomain.Chunk{{ID: "c2"}, {ID: "c1"}})
+
+	if len(hits) != 3 || hits[0].ChunkID != "c1" || !hits[1].Pinned {
+		t.Fatalf("Expected the hits in retrieval order, got %+v", hits)
+	}
+	if !hits[0].InContext || !hits[1].InContext || hits[2].InContext {
+		t.Errorf("Expected only c1 and c2 in context, got %+v", hits)
+	}
+}
+
+func TestListQueryLogs(t *testing.T) {
+	logs := &mockQueryLogRepo{entries: []documentDomain.QueryLog{{ID: "q1", Query: "hours?"}}}
+	svc := NewService(ServiceConfig{Repo: newMockDocumentRepo(), QueryLogRepo: logs})
+	admin := documentDomain.UserContext{UserID: "admin-1", IsAdmin: true}
+	ctx := context.Background()
+
+	if _, _, err := svc.ListQueryLogs(ctx, documentDomain.UserContext{UserID: "u"}, documentDomain.QueryLogFilter{}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden, got %v", err)
+	}
+
+	entries, total, err := svc.ListQueryLogs(ctx, admin, documentDomain.QueryLogFilter{Limit: 500, Offset: -3})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(entries) != 1 || total != 1 {
+		t.Errorf("Expected 1 entry, got %d of %d", len(entries), total)
+	}
+	if logs.filter.Limit != maxQueryLogLimit || logs.filter.Offset != 0 {
+		t.Errorf("Expected the limit capped and the offset reset, got %d and %d", logs.filter.Limit, logs.filter.Offset)
+	}
+
+	now := time.Now()
+	if _, _, err := svc.ListQueryLogs(ctx, admin, documentDomain.QueryLogFilter{StartTime: now, EndTime: now.Add(-time.Hour)}); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("Expected ErrInvalidQuery for a reversed range, got %v", err)
+	}
+
+	if entry, err := svc.GetQueryLog(ctx, admin, "q1"); err != nil || entry.Query != "hours?" {
+		t.Errorf("Expected the logged query, got %+v, %v", entry, err)
+	}
+	if _, err := svc.GetQueryLog(ctx, admin, "missing"); !errors.Is(err, ErrQueryLogNotFound) {
+		t.Errorf("Expected ErrQueryLogNotFound, got %v", err)
+	}
+}
+
+func TestReplayQuery(t *testing.T) {
+	server, _ := embeddingServer(t)
+	defer server.Close()
+
+	chunkRepo := newMockChunkRepo()
+	chunkRepo.chunks = []documentDomain.Chunk{
+		{ID: "c1", DocumentID: "d1", Content: "Opening hours are 9 to 6", Score: 0.91},
+		{ID: "c2", DocumentID: "d2", Content: "Holiday hours are 10 to 2", Score: 0.8},
+	}
+	logs := &mockQueryLogRepo{entries: []documentDomain.QueryLog{{
+		ID:      "q1",
+		Query:   "when do you open?",
+		Channel: documentDomain.ChannelWeb,
+		TopK:    5,
+		Outcome: documentDomain.OutcomeAnswered,
+		Answer:  "We open at 8.",
+		Hits: []documentDomain.QueryHit{
+			{ChunkID: "c9", DocumentID: "d9", Score: 0.95, InContext: true},
+			{ChunkID: "c1", DocumentID: "d1", Score: 0.91, InContext: false},
+		},
+	}}}
+	svc := NewService(ServiceConfig{
+		Repo:         newMockDocumentRepo(),
+		ChunkRepo:    chunkRepo,
+		OpenAIClient: openai.NewClient("test-key", openai.WithBaseURL(server.URL)),
+		QueryLogRepo: logs,
+	})
+	admin := documentDomain.UserContext{UserID: "admin-1", IsAdmin: true}
+
+	replay, err := svc.ReplayQuery(context.Background(), admin, "q1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(logs.entries) != 1 {
+		t.Errorf("Expected the replay not to be logged, got %d entries", len(logs.entries))
+	}
+	if replay.Original.ID != "q1" || replay.Replay.Query != "when do you open?" || replay.Replay.Outcome != documentDomain.OutcomeAnswered {
+		t.Errorf("Expected both runs of the query, got %+v and %+v", replay.Original, replay.Replay)
+	}
+	if len(replay.Added) != 1 || replay.Added[0].ChunkID != "c2" {
+		t.Errorf("Expected c2 added, got %+v", replay.Added)
+	}
+	if len(replay.Removed) != 1 || replay.Removed[0].ChunkID != "c9" {
+		t.Errorf("Expected c9 removed, got %+v", replay.Removed)
+	}
+	if len(replay.Rescored) != 1 || replay.Rescored[0].ChunkID != "c1" || !replay.Rescored[0].InContextAfter {
+		t.Errorf("Expected c1 to have entered the prompt, got %+v", replay.Rescored)
+	}
+	if !replay.AnswerChanged || replay.OutcomeChanged {
+		t.Errorf("Expected only the answer to change, got answer %v and outcome %v", replay.AnswerChanged, replay.OutcomeChanged)
+	}
+
+	if _, err := svc.ReplayQuery(context.Background(), admin, "missing"); !errors.Is(err, ErrQueryLogNotFound) {
+		t.Errorf("Expected ErrQueryLogNotFound, got %v", err)
+	}
+	if _, err := svc.ReplayQuery(context.Background(), documentDomain.UserContext{UserID: "u"}, "q1"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden, got %v", err)
+	}
+	unconfigured := NewService(ServiceConfig{Repo: newMockDocumentRepo(), QueryLogRepo: logs})
+	if _, err := unconfigured.ReplayQuery(context.Background(), admin, "q1"); !errors.Is(err, ErrReplayUnavailable) {
+		t.Errorf("Expected ErrReplayUnavailable, got %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package document
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/chunker"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// chunkSync counts what syncChunks did with a document's chunks.
+type chunkSync struct {
+	Kept     int
+	Embedded int
+	Removed  int
+}
+
+// chunkHash fingerprints a chunk's kind and exact text, which is what its
+// vector was made from.
+func chunkHash(kind documentDomain.ChunkKind, content string) string {
+	sum := sha256.Sum256([]byte(string(kind) + "\x00" + content))
+	return hex.EncodeToString(sum[:])
+}
+
+// storedChunkHash returns c's hash, computing it for chunks stored before
+// chunks were hashed.
+func storedChunkHash(c documentDomain.Chunk) string {
+	if c.ContentHash != "" {
+		return c.ContentHash
+	}
+	kind := c.Kind
+	if kind == "" {
+		kind = documentDomain.ChunkKindText
+	}
+	return chunkHash(kind, c.Content)
+}
+
+// inSpace reports whether c's vector was made in space, so it can be
+// reused there.
+func inSpace(c documentDomain.Chunk, space documentDomain.EmbeddingSpace) bool {
+	if c.Collection != space.Collection {
+		return false
+	}
+	if space.Collection == "" {
+		return true
+	}
+	return c.EmbeddingModel == space.Model && (space.Dimensions == 0 || c.Dimensions == space.Dimensions)
+}
+
+// rechunkDocument replaces doc's chunks after its content changed.
+func (s *service) rechunkDocument(ctx context.Context, doc *documentDomain.Document) error {
+	if s.embedder == nil || s.chunker == nil || doc.Content == "" {
+		if err := s.chunkRepo.DeleteByDocumentID(ctx, doc.ID); err != nil {
+			return err
+		}
+		s.releaseChunkStorage(ctx, doc.UserID, doc.ID)
+		return nil
+	}
+
+	existing, err := s.chunkRepo.GetByDocumentID(ctx, doc.ID)
+	if err != nil {
+		return err
+	}
+	result, err := s.syncChunks(ctx, doc, existing)
+	if err != nil {
+		return err
+	}
+	s.log.InfoContext(ctx, "rechunked document", "document_id", doc.ID,
+		"kept", result.Kept, "embedded", result.Embedded, "removed", result.Removed)
+	return nil
+}
+
+// syncChunks chunks doc's content and stores the chunks. Chunks in
+// existing, doc's chunks before an update, whose text and embedding space
+// are unchanged are reused: they keep their IDs and vectors, so pins,
+// feedback and query logs that refer to them survive the edit, and only
+// new or changed text is embedded. Existing chunks left unused are
+// deleted once the new ones are written.
+func (s *service) syncChunks(ctx context.Context, doc *documentDomain.Document, existing []documentDomain.Chunk) (chunkSync, error) {
+	textChunks := s.chunker.ChunkStructured(doc.Content)
+
+	space, err := s.embeddingSpace(ctx, doc.Collection)
+	if err != nil {
+		return chunkSync{}, err
+	}
+
+	// Repeated text, such as a table header carried into every table
+	// chunk, reuses its old chunks in order.
+	reusable := make(map[string][]documentDomain.Chunk, len(existing))
+	for _, c := range existing {
+		if inSpace(c, space) && len(c.Embedding) > 0 {
+			h := storedChunkHash(c)
+			reusable[h] = append(reusable[h], c)
+		}
+	}
+
+	hidden := !doc.IsRetrievable(time.Now())
+	var created, kept []documentDomain.Chunk
+	keptIDs := make(map[string]bool)
+	for i, text := range textChunks {
+		kind := documentDomain.ChunkKind(text.Kind)
+		h := chunkHash(kind, text.Content)
+
+		if olds := reusable[h]; len(olds) > 0 {
+			c := olds[0]
+			reusable[h] = olds[1:]
+			c.ChunkIndex = i
+			c.Table = tableInfo(text.Table)
+			c.Hidden = hidden
+			c.ContentHash = h
+			kept = append(kept, c)
+			keptIDs[c.ID] = true
+			continue
+		}
+
+		embedding, err := s.embed(ctx, space, text.Content)
+		if err != nil {
+			s.log.WarnContext(ctx, "failed to create embedding", "error", err, "chunk_index", i)
+			continue
+		}
+		created = append(created, documentDomain.Chunk{
+			ID:          primitive.NewObjectID().Hex(),
+			DocumentID:  doc.ID,
+			ChunkIndex:  i,
+			Content:     text.Content,
+			Embedding:   embedding,
+			Hidden:      hidden,
+			CreatedAt:   time.Now(),
+			Kind:        kind,
+			Table:       tableInfo(text.Table),
+			ContentHash: h,
+
+			Collection:     space.Collection,
+			EmbeddingModel: space.Model,
+			Dimensions:     len(embedding),
+		})
+	}
+
+	if err := s.chunkRepo.CreateBatch(ctx, created); err != nil {
+		return chunkSync{}, err
+	}
+	s.recordStorage(ctx, doc.UserID, doc.ID, chunkUsage(created...))
+	if err := s.chunkRepo.UpdatePlacement(ctx, kept); err != nil {
+		return chunkSync{}, err
+	}
+
+	var removed []documentDomain.Chunk
+	var removedIDs []string
+	for _, c := range existing {
+		if !keptIDs[c.ID] {
+			removed = append(removed, c)
+			removedIDs = append(removedIDs, c.ID)
+		}
+	}
+	if err := s.chunkRepo.DeleteByIDs(ctx, removedIDs); err != nil {
+		return chunkSync{}, err
+	}
+	s.recordStorage(ctx, doc.UserID, doc.ID, chunkUsage(removed...).Negate())
+
+	return chunkSync{Kept: len(kept), Embedded: len(created), Removed: len(removed)}, nil
+}
+
+func tableInfo(t *chunker.Table) *documentDomain.TableInfo {
+	if t == nil {
+		return nil
+	}
+	return &documentDomain.TableInfo{
+		Index:    t.Index,
+		Columns:  t.Columns,
+		FirstRow: t.FirstRow,
+		LastRow:  t.LastRow,
+	}
+}
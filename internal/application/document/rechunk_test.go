@@ -0,0 +1,94 @@
+package document
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/chunker"
+	"github.com/elprogramadorgt/lucidRAG/pkg/openai"
+)
+
+func TestUpdateDocumentKeepsUnchangedChunks(t *testing.T) {
+	server, requests := embeddingServer(t)
+	defer server.Close()
+
+	repo := newMockDocumentRepo()
+	chunkRepo := newMockChunkRepo()
+	svc := NewService(ServiceConfig{
+		Repo:         repo,
+		ChunkRepo:    chunkRepo,
+		OpenAIClient: openai.NewClient("test-key", openai.WithBaseURL(server.URL)),
+		Chunker:      chunker.New(200, 0),
+	})
+	ctx := context.Background()
+
+	sections := []string{
+		"## Shipping\n\n" + strings.Repeat("Orders ship within two days. ", 8),
+		"## Returns\n\n" + strings.Repeat("Returns are accepted for thirty days. ", 8),
+		"## Warranty\n\n" + strings.Repeat("Parts are covered for one year. ", 8),
+	}
+	id, err := svc.CreateDocument(ctx, adminCtx, &documentDomain.Document{Title: "Policies", Content: strings.Join(sections, "\n\n")})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	before := make(map[string]documentDomain.Chunk)
+	for _, c := range chunkRepo.chunks {
+		if c.ContentHash == "" {
+			t.Fatalf("Expected chunk %d hashed", c.ChunkIndex)
+		}
+		before[c.Content] = c
+	}
+	if len(before) < 3 {
+		t.Fatalf("Expected a chunk per section at least, got %d", len(before))
+	}
+	embedded := len(*requests)
+
+	// A new section goes first and the warranty changes; the rest stays.
+	sections[2] = "## Warranty\n\n" + strings.Repeat("Parts are covered for two years. ", 8)
+	updated := strings.Join(append([]string{"## Hours\n\nWe open at nine."}, sections...), "\n\n")
+	if err := svc.UpdateDocument(ctx, adminCtx, &documentDomain.Document{ID: id, Title: "Policies", Content: updated}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	kept, fresh := 0, 0
+	for _, c := range chunkRepo.chunks {
+		old, ok := before[c.Content]
+		if !ok {
+			fresh++
+			continue
+		}
+		kept++
+		if c.ID != old.ID || len(c.Embedding) != len(old.Embedding) {
+			t.Errorf("Expected unchanged chunk %q to keep its ID and vector", c.Content[:20])
+		}
+		if c.ChunkIndex == old.ChunkIndex {
+			t.Errorf("Expected chunk %q moved after the new section", c.Content[:20])
+		}
+	}
+	if kept == 0 || fresh == 0 {
+		t.Fatalf("Expected kept and new chunks, got %d kept and %d new", kept, fresh)
+	}
+	if got := len(*requests) - embedded; got != fresh {
+		t.Errorf("Expected only the %d new chunks embedded, got %d embeddings", fresh, got)
+	}
+	if want := len(chunker.New(200, 0).ChunkStructured(updated)); len(chunkRepo.chunks) != want {
+		t.Errorf("Expected %d chunks after the update, got %d", want, len(chunkRepo.chunks))
+	}
+	for _, c := range chunkRepo.chunks {
+		if strings.Contains(c.Content, "one year") {
+			t.Error("Expected the changed chunk removed")
+		}
+	}
+}
+
+func TestStoredChunkHash(t *testing.T) {
+	legacy := documentDomain.Chunk{Content: "Orders ship within two days."}
+	if storedChunkHash(legacy) != chunkHash(documentDomain.ChunkKindText, legacy.Content) {
+		t.Error("Expected chunks from before hashing to hash as text")
+	}
+	if chunkHash(documentDomain.ChunkKindTable, legacy.Content) == chunkHash(documentDomain.ChunkKindText, legacy.Content) {
+		t.Error("Expected the kind to be part of the hash")
+	}
+}
@@ -0,0 +1,201 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+)
+
+var (
+	ErrRuleNotFound = errors.New("retrieval rule not found")
+	ErrInvalidRule  = errors.New("invalid retrieval rule")
+)
+
+func (s *service) CreateRetrievalRule(ctx context.Context, userCtx documentDomain.UserContext, rule *documentDomain.RetrievalRule) (string, error) {
+	if !userCtx.IsAdmin {
+		return "", ErrForbidden
+	}
+	if s.ruleRepo == nil {
+		return "", ErrInvalidRule
+	}
+
+	switch rule.Action {
+	case documentDomain.RuleActionPin, documentDomain.RuleActionBoost:
+	default:
+		return "", ErrInvalidRule
+	}
+	if len(rule.ChunkIDs) == 0 && len(rule.DocumentIDs) == 0 {
+		return "", ErrInvalidRule
+	}
+	if rule.Action == documentDomain.RuleActionBoost && rule.Boost == 0 {
+		return "", ErrInvalidRule
+	}
+
+	keywords := make([]string, 0, len(rule.Keywords))
+	for _, k := range rule.Keywords {
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+			keywords = append(keywords, k)
+		}
+	}
+	rule.Keywords = keywords
+	rule.CreatedBy = userCtx.UserID
+	rule.IsActive = true
+
+	id, err := s.ruleRepo.Create(ctx, rule)
+	if err != nil {
+		return "", err
+	}
+	s.events.Publish(ctx, events.KnowledgeChanged{Reason: "rule_created", ActorID: userCtx.UserID})
+	return id, nil
+}
+
+func (s *service) ListRetrievalRules(ctx context.Context, userCtx documentDomain.UserContext) ([]documentDomain.RetrievalRule, error) {
+	if !userCtx.IsAdmin {
+		return nil, ErrForbidden
+	}
+	if s.ruleRepo == nil {
+		return []documentDomain.RetrievalRule{}, nil
+	}
+	return s.ruleRepo.List(ctx)
+}
+
+func (s *service) DeleteRetrievalRule(ctx context.Context, userCtx documentDomain.UserContext, id string) error {
+	if !userCtx.IsAdmin {
+		return ErrForbidden
+	}
+	if s.ruleRepo == nil {
+		return ErrRuleNotFound
+	}
+
+	existing, err := s.ruleRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrRuleNotFound
+	}
+
+	if err := s.ruleRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.events.Publish(ctx, events.KnowledgeChanged{Reason: "rule_deleted", ActorID: userCtx.UserID})
+	return nil
+}
+
+func ruleMatches(rule documentDomain.RetrievalRule, query string) bool {
+	if len(rule.Keywords) == 0 {
+		return true
+	}
+	q := strings.ToLower(query)
+	for _, k := range rule.Keywords {
+		if strings.Contains(q, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+// applyRetrievalRules adjusts search results with the active rules matching
+// query: boosts are added to scores, pinned chunks are fetched if missing and
+// moved to the front so context assembly always keeps them.
+func (s *service) applyRetrievalRules(ctx context.Context, query string, chunks []documentDomain.Chunk) ([]documentDomain.Chunk, error) {
+	if s.ruleRepo == nil {
+		return chunks, nil
+	}
+
+	rules, err := s.ruleRepo.ListActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []documentDomain.RetrievalRule
+	for _, rule := range rules {
+		if ruleMatches(rule, query) {
+			matched = append(matched, rule)
+		}
+	}
+	if len(matched) == 0 {
+		return chunks, nil
+	}
+
+	seen := make(map[string]bool, len(chunks))
+	for _, c := range chunks {
+		seen[c.ID] = true
+	}
+
+	var pinnedChunkIDs, pinnedDocIDs []string
+	for _, rule := range matched {
+		if rule.Action != documentDomain.RuleActionPin {
+			continue
+		}
+		pinnedChunkIDs = append(pinnedChunkIDs, rule.ChunkIDs...)
+		pinnedDocIDs = append(pinnedDocIDs, rule.DocumentIDs...)
+	}
+
+	for _, id := range pinnedChunkIDs {
+		if seen[id] {
+			continue
+		}
+		chunk, err := s.chunkRepo.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if chunk != nil {
+			seen[chunk.ID] = true
+			chunks = append(chunks, *chunk)
+		}
+	}
+	for _, docID := range pinnedDocIDs {
+		docChunks, err := s.chunkRepo.GetByDocumentID(ctx, docID)
+		if err != nil {
+			return nil, err
+		}
+		for _, chunk := range docChunks {
+			if !seen[chunk.ID] {
+				seen[chunk.ID] = true
+				chunks = append(chunks, chunk)
+			}
+		}
+	}
+
+	result := make([]documentDomain.Chunk, 0, len(chunks))
+	for _, c := range chunks {
+		c.Pinned = containsString(pinnedChunkIDs, c.ID) || containsString(pinnedDocIDs, c.DocumentID)
+		penalized := false
+		for _, rule := range matched {
+			if rule.Action != documentDomain.RuleActionBoost {
+				continue
+			}
+			if containsString(rule.ChunkIDs, c.ID) || containsString(rule.DocumentIDs, c.DocumentID) {
+				c.Score += rule.Boost
+				penalized = penalized || rule.Boost < 0
+			}
+		}
+		if penalized && !c.Pinned && c.Score <= 0 {
+			continue
+		}
+		result = append(result, c)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Pinned != result[j].Pinned {
+			return result[i].Pinned
+		}
+		return result[i].Score > result[j].Score
+	})
+
+	return result, nil
+}
@@ -0,0 +1,159 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+)
+
+// mockRuleRepo is a mock implementation of RuleRepository
+type mockRuleRepo struct {
+	rules map[string]*documentDomain.RetrievalRule
+}
+
+func newMockRuleRepo() *mockRuleRepo {
+	return &mockRuleRepo{
+		rules: make(map[string]*documentDomain.RetrievalRule),
+	}
+}
+
+func (m *mockRuleRepo) Create(ctx context.Context, rule *documentDomain.RetrievalRule) (string, error) {
+	if rule.ID == "" {
+		rule.ID = "rule_" + rule.Name
+	}
+	m.rules[rule.ID] = rule
+	return rule.ID, nil
+}
+
+func (m *mockRuleRepo) GetByID(ctx context.Context, id string) (*documentDomain.RetrievalRule, error) {
+	return m.rules[id], nil
+}
+
+func (m *mockRuleRepo) List(ctx context.Context) ([]documentDomain.RetrievalRule, error) {
+	rules := make([]documentDomain.RetrievalRule, 0, len(m.rules))
+	for _, r := range m.rules {
+		rules = append(rules, *r)
+	}
+	return rules, nil
+}
+
+func (m *mockRuleRepo) ListActive(ctx context.Context) ([]documentDomain.RetrievalRule, error) {
+	rules := make([]documentDomain.RetrievalRule, 0, len(m.rules))
+	for _, r := range m.rules {
+		if r.IsActive {
+			rules = append(rules, *r)
+		}
+	}
+	return rules, nil
+}
+
+func (m *mockRuleRepo) Delete(ctx context.Context, id string) error {
+	delete(m.rules, id)
+	return nil
+}
+
+func TestCreateRetrievalRuleValidation(t *testing.T) {
+	svc := NewService(ServiceConfig{Repo: newMockDocumentRepo(), RuleRepo: newMockRuleRepo()})
+	ctx := context.Background()
+	adminCtx := documentDomain.UserContext{UserID: "admin", IsAdmin: true}
+
+	_, err := svc.CreateRetrievalRule(ctx, documentDomain.UserContext{UserID: "user-1"}, &documentDomain.RetrievalRule{
+		Name: "r", Action: documentDomain.RuleActionPin, ChunkIDs: []string{"c1"},
+	})
+	if !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden for non-admin, got %v", err)
+	}
+
+	_, err = svc.CreateRetrievalRule(ctx, adminCtx, &documentDomain.RetrievalRule{Name: "r", Action: "explode", ChunkIDs: []string{"c1"}})
+	if !errors.Is(err, ErrInvalidRule) {
+		t.Errorf("Expected ErrInvalidRule for unknown action, got %v", err)
+	}
+
+	_, err = svc.CreateRetrievalRule(ctx, adminCtx, &documentDomain.RetrievalRule{Name: "r", Action: documentDomain.RuleActionPin})
+	if !errors.Is(err, ErrInvalidRule) {
+		t.Errorf("Expected ErrInvalidRule without targets, got %v", err)
+	}
+
+	_, err = svc.CreateRetrievalRule(ctx, adminCtx, &documentDomain.RetrievalRule{Name: "r", Action: documentDomain.RuleActionBoost, DocumentIDs: []string{"d1"}})
+	if !errors.Is(err, ErrInvalidRule) {
+		t.Errorf("Expected ErrInvalidRule for zero boost, got %v", err)
+	}
+
+	rule := &documentDomain.RetrievalRule{Name: "r", Action: documentDomain.RuleActionPin, ChunkIDs: []string{"c1"}, Keywords: []string{" Pricing ", ""}}
+	if _, err := svc.CreateRetrievalRule(ctx, adminCtx, rule); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(rule.Keywords) != 1 || rule.Keywords[0] != "pricing" {
+		t.Errorf("Expected normalized keywords [pricing], got %v", rule.Keywords)
+	}
+	if !rule.IsActive || rule.CreatedBy != "admin" {
+		t.Error("Expected rule to be active and attributed to admin")
+	}
+}
+
+func TestApplyRetrievalRulesPinsChunks(t *testing.T) {
+	chunkRepo := newMockChunkRepo()
+	chunkRepo.chunks = []documentDomain.Chunk{
+		{ID: "disclaimer", DocumentID: "legal", Content: "Prices exclude tax."},
+	}
+	ruleRepo := newMockRuleRepo()
+	ruleRepo.rules["r1"] = &documentDomain.RetrievalRule{
+		ID: "r1", Keywords: []string{"price"}, ChunkIDs: []string{"disclaimer"},
+		Action: documentDomain.RuleActionPin, IsActive: true,
+	}
+	svc := NewService(ServiceConfig{Repo: newMockDocumentRepo(), ChunkRepo: chunkRepo, RuleRepo: ruleRepo}).(*service)
+
+	results := []documentDomain.Chunk{{ID: "a", DocumentID: "catalog", Score: 0.9}}
+
+	got, err := svc.applyRetrievalRules(context.Background(), "What is the price?", results)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(got) != 2 || got[0].ID != "disclaimer" || !got[0].Pinned {
+		t.Fatalf("Expected pinned disclaimer first, got %+v", got)
+	}
+
+	got, _ = svc.applyRetrievalRules(context.Background(), "Opening hours?", results)
+	if len(got) != 1 {
+		t.Errorf("Expected rule not to apply for unrelated query, got %d chunks", len(got))
+	}
+}
+
+func TestApplyRetrievalRulesBoostsAndPenalizes(t *testing.T) {
+	ruleRepo := newMockRuleRepo()
+	ruleRepo.rules["up"] = &documentDomain.RetrievalRule{
+		ID: "up", DocumentIDs: []string{"d2"}, Action: documentDomain.RuleActionBoost, Boost: 0.5, IsActive: true,
+	}
+	ruleRepo.rules["down"] = &documentDomain.RetrievalRule{
+		ID: "down", ChunkIDs: []string{"c3"}, Action: documentDomain.RuleActionBoost, Boost: -1, IsActive: true,
+	}
+	svc := NewService(ServiceConfig{Repo: newMockDocumentRepo(), ChunkRepo: newMockChunkRepo(), RuleRepo: ruleRepo}).(*service)
+
+	results := []documentDomain.Chunk{
+		{ID: "c1", DocumentID: "d1", Score: 0.9},
+		{ID: "c2", DocumentID: "d2", Score: 0.8},
+		{ID: "c3", DocumentID: "d3", Score: 0.75},
+	}
+
+	got, err := svc.applyRetrievalRules(context.Background(), "anything", results)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Expected penalized chunk to be dropped, got %d chunks", len(got))
+	}
+	if got[0].ID != "c2" {
+		t.Errorf("Expected boosted chunk c2 first, got %s", got[0].ID)
+	}
+}
+
+func TestDeleteRetrievalRuleNotFound(t *testing.T) {
+	svc := NewService(ServiceConfig{Repo: newMockDocumentRepo(), RuleRepo: newMockRuleRepo()})
+
+	err := svc.DeleteRetrievalRule(context.Background(), documentDomain.UserContext{IsAdmin: true}, "missing")
+	if !errors.Is(err, ErrRuleNotFound) {
+		t.Errorf("Expected ErrRuleNotFound, got %v", err)
+	}
+}
@@ -2,39 +2,155 @@ package document
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+	"github.com/elprogramadorgt/lucidRAG/pkg/breaker"
+	"github.com/elprogramadorgt/lucidRAG/pkg/cache"
 	"github.com/elprogramadorgt/lucidRAG/pkg/chunker"
+	"github.com/elprogramadorgt/lucidRAG/pkg/extract"
+	"github.com/elprogramadorgt/lucidRAG/pkg/guardrail"
+	"github.com/elprogramadorgt/lucidRAG/pkg/i18n"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/elprogramadorgt/lucidRAG/pkg/objectstore"
 	"github.com/elprogramadorgt/lucidRAG/pkg/openai"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 var (
 	ErrDocumentNotFound = errors.New("document not found")
 	ErrInvalidQuery     = errors.New("invalid query")
 	ErrForbidden        = errors.New("access denied")
+	ErrChunkNotFound    = errors.New("chunk not found")
+	ErrInvalidSchedule  = errors.New("expires_at must be after publish_at")
+	ErrInvalidPatch     = errors.New("invalid document patch")
+	ErrVersionConflict  = errors.New("document was changed by another update")
+	ErrInvalidStatus    = errors.New("invalid status transition")
+	ErrInvalidSchema    = errors.New("invalid response schema")
+	ErrStructuredAnswer = errors.New("answer did not match the response schema")
+	ErrNoFile           = errors.New("document has no original file")
+	ErrInvalidDraft     = errors.New("invalid draft")
 )
 
 type service struct {
-	repo           documentDomain.Repository
-	chunkRepo      documentDomain.ChunkRepository
-	openaiClient   *openai.Client
-	chunker        *chunker.Chunker
-	embeddingModel string
-	modelName      string
+	repo             documentDomain.Repository
+	chunkRepo        documentDomain.ChunkRepository
+	ruleRepo         documentDomain.RuleRepository
+	shortcutRepo     documentDomain.ShortcutRepository
+	glossaryRepo     documentDomain.GlossaryRepository
+	cleaningRepo     documentDomain.CleaningProfileRepository
+	allowedModels    []string
+	queryLogRepo     documentDomain.QueryLogRepository
+	spend            *SpendTracker
+	models           ModelCatalog
+	storageRepo      documentDomain.StorageRepository
+	openaiClient     *openai.Client
+	embedder         Embedder
+	chunker          *chunker.Chunker
+	embeddingModel   string
+	modelName        string
+	fallbackModels   []ChatModel
+	maxContextTokens int
+	dedupThreshold   float64
+	cache            cache.Cache
+	answerCacheTTL   time.Duration
+	events           *events.Bus
+	extractor        *extract.Extractor
+	files            objectstore.Store
+	tools            ToolRunner
+	products         ProductMatcher
+	guardrail        guardrail.Policy
+	formatRepo       documentDomain.FormatProfileRepository
+	migrationRepo    documentDomain.EmbeddingMigrationRepository
+	collectionRepo   documentDomain.CollectionRepository
+	duplicates       documentDomain.DuplicatePolicy
+	maxDocumentBytes int64
+	log              *logger.Logger
+	// duplicateSimilarity of zero only treats identical content as a
+	// duplicate.
+	duplicateSimilarity float64
 }
 
 type ServiceConfig struct {
-	Repo           documentDomain.Repository
-	ChunkRepo      documentDomain.ChunkRepository
-	OpenAIClient   *openai.Client
+	Repo         documentDomain.Repository
+	ChunkRepo    documentDomain.ChunkRepository
+	RuleRepo     documentDomain.RuleRepository
+	StorageRepo  documentDomain.StorageRepository
+	OpenAIClient *openai.Client
+	// Embedder makes the embeddings, such as a self-hosted embedding
+	// server; without it OpenAIClient does.
+	Embedder       Embedder
 	Chunker        *chunker.Chunker
 	EmbeddingModel string
 	ModelName      string
+	// FallbackModels answer, in order, when ModelName fails.
+	FallbackModels []ChatModel
+	// AllowedModels are the OpenAI models a query may ask for besides
+	// ModelName and the fallbacks.
+	AllowedModels    []string
+	MaxContextTokens int
+	DedupThreshold   float64
+	// Cache enables the answer cache; AnswerCacheTTL of zero disables it.
+	Cache          cache.Cache
+	AnswerCacheTTL time.Duration
+	// Events receives document and answer events. The service also
+	// subscribes its answer cache to knowledge-base changes on it.
+	Events *events.Bus
+	// Extractor reads uploaded files; without it uploads are rejected.
+	Extractor *extract.Extractor
+	// Files keeps the original of every upload, so shareable ones can be
+	// attached to chat answers; without it originals are discarded.
+	Files objectstore.Store
+	// Tools, when set, lets the answer model call the configured tools.
+	Tools ToolRunner
+	// Products, when set, answers questions naming catalog products from
+	// their fields, looked up before vector search.
+	Products ProductMatcher
+	// Guardrail checks every generated answer before it is returned.
+	Guardrail guardrail.Policy
+	// FormatRepo holds per-channel format profiles; without it every
+	// channel uses its default.
+	FormatRepo documentDomain.FormatProfileRepository
+	// MigrationRepo records embedding model migrations; without it the
+	// embedding model cannot be changed.
+	MigrationRepo documentDomain.EmbeddingMigrationRepository
+	// CollectionRepo holds collections and their embedding spaces; without
+	// it every document uses the default space.
+	CollectionRepo documentDomain.CollectionRepository
+	// Duplicates says what happens to new documents that repeat an
+	// existing one; empty allows them. DuplicateSimilarity, when above
+	// zero, also catches near-duplicates whose opening chunk is at least
+	// that similar to an existing document's.
+	Duplicates          documentDomain.DuplicatePolicy
+	DuplicateSimilarity float64
+	// ShortcutRepo holds FAQ shortcuts; without it every question goes
+	// through retrieval.
+	ShortcutRepo documentDomain.ShortcutRepository
+	// GlossaryRepo holds synonyms questions are expanded with; without it
+	// questions are searched as asked.
+	GlossaryRepo documentDomain.GlossaryRepository
+	// CleaningRepo holds cleaning profiles; without it content is chunked
+	// as given.
+	CleaningRepo documentDomain.CleaningProfileRepository
+	// QueryLogRepo records every query with its retrieval hits; without it
+	// queries are not logged.
+	QueryLogRepo documentDomain.QueryLogRepository
+	// Spend holds answers to the spend limits; without it model spend is
+	// not limited.
+	Spend *SpendTracker
+	// Models, when set, rejects chat and embedding models their provider
+	// no longer offers.
+	Models ModelCatalog
+	// MaxDocumentBytes bounds a streamed document. Defaults to 256 MB.
+	MaxDocumentBytes int64
+	// Log receives warnings about best-effort steps that failed; defaults
+	// to standard output.
+	Log *logger.Logger
 }
 
 func NewService(cfg ServiceConfig) documentDomain.Service {
@@ -48,62 +164,157 @@ func NewService(cfg ServiceConfig) documentDomain.Service {
 		modelName = "gpt-3.5-turbo"
 	}
 
-	return &service{
-		repo:           cfg.Repo,
-		chunkRepo:      cfg.ChunkRepo,
-		openaiClient:   cfg.OpenAIClient,
-		chunker:        cfg.Chunker,
-		embeddingModel: embeddingModel,
-		modelName:      modelName,
-	}
+	maxContextTokens := cfg.MaxContextTokens
+	if maxContextTokens <= 0 {
+		maxContextTokens = defaultMaxContextTokens
+	}
+
+	dedupThreshold := cfg.DedupThreshold
+	if dedupThreshold <= 0 || dedupThreshold > 1 {
+		dedupThreshold = defaultDedupThreshold
+	}
+
+	duplicates := cfg.Duplicates
+	if !duplicates.IsValid() {
+		duplicates = documentDomain.DuplicateAllow
+	}
+
+	maxDocumentBytes := cfg.MaxDocumentBytes
+	if maxDocumentBytes <= 0 {
+		maxDocumentBytes = defaultMaxDocumentBytes
+	}
+
+	embedder := cfg.Embedder
+	if embedder == nil && cfg.OpenAIClient != nil {
+		embedder = cfg.OpenAIClient
+	}
+
+	bus := cfg.Events
+	if bus == nil {
+		bus = events.NewBus()
+	}
+
+	log := cfg.Log
+	if log == nil {
+		log = logger.New()
+	}
+
+	s := &service{
+		repo:             cfg.Repo,
+		chunkRepo:        cfg.ChunkRepo,
+		ruleRepo:         cfg.RuleRepo,
+		shortcutRepo:     cfg.ShortcutRepo,
+		glossaryRepo:     cfg.GlossaryRepo,
+		cleaningRepo:     cfg.CleaningRepo,
+		queryLogRepo:     cfg.QueryLogRepo,
+		spend:            cfg.Spend,
+		models:           cfg.Models,
+		storageRepo:      cfg.StorageRepo,
+		openaiClient:     cfg.OpenAIClient,
+		embedder:         embedder,
+		chunker:          cfg.Chunker,
+		embeddingModel:   embeddingModel,
+		modelName:        modelName,
+		fallbackModels:   cfg.FallbackModels,
+		allowedModels:    cfg.AllowedModels,
+		maxContextTokens: maxContextTokens,
+		dedupThreshold:   dedupThreshold,
+		cache:            cfg.Cache,
+		answerCacheTTL:   cfg.AnswerCacheTTL,
+		events:           bus,
+		extractor:        cfg.Extractor,
+		files:            cfg.Files,
+		tools:            cfg.Tools,
+		products:         cfg.Products,
+		guardrail:        cfg.Guardrail,
+		formatRepo:       cfg.FormatRepo,
+		migrationRepo:    cfg.MigrationRepo,
+		collectionRepo:   cfg.CollectionRepo,
+		duplicates:       duplicates,
+		maxDocumentBytes: maxDocumentBytes,
+		log:              log.With("service", "documents"),
+
+		duplicateSimilarity: cfg.DuplicateSimilarity,
+	}
+
+	bus.Subscribe(func(ctx context.Context, _ events.Event) {
+		s.invalidateAnswers(ctx)
+	},
+		events.NameDocumentCreated,
+		events.NameDocumentUpdated,
+		events.NameDocumentStatusChanged,
+		events.NameDocumentDeleted,
+		events.NameKnowledgeChanged,
+	)
+
+	return s
 }
 
 func (s *service) CreateDocument(ctx context.Context, userCtx documentDomain.UserContext, doc *documentDomain.Document) (string, error) {
+	doc.Content = s.cleanText(ctx, doc.Source, doc.Content)
+	return s.createDocument(ctx, userCtx, doc)
+}
+
+// createDocument stores doc, whose content has already been cleaned, and
+// chunks it.
+func (s *service) createDocument(ctx context.Context, userCtx documentDomain.UserContext, doc *documentDomain.Document) (string, error) {
+	if err := validateSchedule(doc); err != nil {
+		return "", err
+	}
+	if err := validateMeta(&doc.DocumentMeta); err != nil {
+		return "", err
+	}
+	if doc.Collection != "" {
+		if _, err := s.embeddingSpace(ctx, doc.Collection); err != nil {
+			return "", err
+		}
+	}
+
+	if existingID, err := s.applyDuplicatePolicy(ctx, doc); err != nil || existingID != "" {
+		return existingID, err
+	}
+
 	doc.UserID = userCtx.UserID
+	doc.Status = documentDomain.StatusDraft
+	if userCtx.IsAdmin {
+		doc.Status = documentDomain.StatusPublished
+	}
 
 	id, err := s.repo.Create(ctx, doc)
 	if err != nil {
 		return "", err
 	}
+	s.recordStorage(ctx, doc.UserID, id, documentDomain.StorageUsage{
+		Documents:     1,
+		DocumentBytes: int64(len(doc.Content)),
+	})
 
-	if s.openaiClient != nil && s.chunker != nil && s.chunkRepo != nil && doc.Content != "" {
-		if err := s.createChunksForDocument(ctx, id, doc.Content); err != nil {
-			fmt.Printf("warning: failed to create chunks for document %s: %v\n", id, err)
+	// Linked duplicates are answered from their original's chunks.
+	if s.embedder != nil && s.chunker != nil && s.chunkRepo != nil && doc.Content != "" && doc.DuplicateOf == "" {
+		if err := s.createChunksForDocument(ctx, doc); err != nil {
+			s.log.WarnContext(ctx, "failed to create chunks", "error", err, "document_id", id)
 		}
 	}
 
+	s.events.Publish(ctx, events.DocumentCreated{
+		DocumentID: id,
+		UserID:     doc.UserID,
+		Title:      doc.Title,
+		Status:     string(doc.Status),
+	})
 	return id, nil
 }
 
-func (s *service) createChunksForDocument(ctx context.Context, documentID, content string) error {
-	textChunks := s.chunker.Chunk(content)
-	if len(textChunks) == 0 {
-		return nil
-	}
-
-	chunks := make([]documentDomain.Chunk, 0, len(textChunks))
-	for i, text := range textChunks {
-		embedding, err := s.openaiClient.CreateEmbedding(ctx, text, s.embeddingModel)
-		if err != nil {
-			fmt.Printf("warning: failed to create embedding for chunk %d: %v\n", i, err)
-			continue
-		}
-
-		chunks = append(chunks, documentDomain.Chunk{
-			ID:         primitive.NewObjectID().Hex(),
-			DocumentID: documentID,
-			ChunkIndex: i,
-			Content:    text,
-			Embedding:  embedding,
-			CreatedAt:  time.Now(),
-		})
-	}
-
-	if len(chunks) == 0 {
-		return nil
+func validateSchedule(doc *documentDomain.Document) error {
+	if doc.PublishAt != nil && doc.ExpiresAt != nil && !doc.ExpiresAt.After(*doc.PublishAt) {
+		return ErrInvalidSchedule
 	}
+	return nil
+}
 
-	return s.chunkRepo.CreateBatch(ctx, chunks)
+func (s *service) createChunksForDocument(ctx context.Context, doc *documentDomain.Document) error {
+	_, err := s.syncChunks(ctx, doc, nil)
+	return err
 }
 
 func (s *service) GetDocument(ctx context.Context, userCtx documentDomain.UserContext, id string) (*documentDomain.Document, error) {
@@ -122,7 +333,7 @@ func (s *service) GetDocument(ctx context.Context, userCtx documentDomain.UserCo
 	return doc, nil
 }
 
-func (s *service) ListDocuments(ctx context.Context, userCtx documentDomain.UserContext, limit, offset int) ([]documentDomain.Document, int64, error) {
+func (s *service) ListDocuments(ctx context.Context, userCtx documentDomain.UserContext, filter documentDomain.DocumentFilter, limit, offset int) ([]documentDomain.Document, int64, error) {
 	if limit <= 0 {
 		limit = 10
 	}
@@ -133,32 +344,24 @@ func (s *service) ListDocuments(ctx context.Context, userCtx documentDomain.User
 		offset = 0
 	}
 
-	var docs []documentDomain.Document
-	var total int64
-	var err error
-
-	if userCtx.IsAdmin {
-		docs, err = s.repo.List(ctx, limit, offset)
-		if err != nil {
-			return nil, 0, err
-		}
-		total, err = s.repo.Count(ctx)
-	} else {
-		docs, err = s.repo.ListByUser(ctx, userCtx.UserID, limit, offset)
-		if err != nil {
-			return nil, 0, err
-		}
-		total, err = s.repo.CountByUser(ctx, userCtx.UserID)
+	if !userCtx.IsAdmin {
+		filter.UserID = userCtx.UserID
 	}
-
-	if err != nil {
-		return nil, 0, err
+	if filter.Language != "" {
+		filter.Language = normalizeLanguage(filter.Language)
 	}
 
-	return docs, total, nil
+	return s.repo.ListFiltered(ctx, filter, limit, offset)
 }
 
 func (s *service) UpdateDocument(ctx context.Context, userCtx documentDomain.UserContext, doc *documentDomain.Document) error {
+	if err := validateSchedule(doc); err != nil {
+		return err
+	}
+	if err := validateMeta(&doc.DocumentMeta); err != nil {
+		return err
+	}
+
 	existing, err := s.repo.GetByID(ctx, doc.ID)
 	if err != nil {
 		return err
@@ -173,23 +376,103 @@ func (s *service) UpdateDocument(ctx context.Context, userCtx documentDomain.Use
 
 	doc.UploadedAt = existing.UploadedAt
 	doc.UserID = existing.UserID
+	doc.Status = existing.Status
+	doc.Collection = existing.Collection
+	doc.File = existing.File
+	doc.Truncated = existing.Truncated
+	return s.saveDocument(ctx, userCtx, existing, doc)
+}
+
+// PatchDocument changes only the fields set in patch, keeping the rest.
+func (s *service) PatchDocument(ctx context.Context, userCtx documentDomain.UserContext, id string, patch documentDomain.DocumentPatch) (*documentDomain.Document, error) {
+	if patch.IsEmpty() {
+		return nil, fmt.Errorf("%w: no fields to update", ErrInvalidPatch)
+	}
+	if patch.Title != nil && strings.TrimSpace(*patch.Title) == "" {
+		return nil, fmt.Errorf("%w: title cannot be empty", ErrInvalidPatch)
+	}
+	if patch.Content != nil && strings.TrimSpace(*patch.Content) == "" {
+		return nil, fmt.Errorf("%w: content cannot be empty", ErrInvalidPatch)
+	}
+
+	existing, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, ErrDocumentNotFound
+	}
+	if !userCtx.IsAdmin && existing.UserID != userCtx.UserID {
+		return nil, ErrForbidden
+	}
 
-	if err := s.repo.Update(ctx, doc); err != nil {
+	doc := *existing
+	doc.Version = patch.Version
+	patch.Apply(&doc)
+	if err := validateSchedule(&doc); err != nil {
+		return nil, err
+	}
+	if err := validateMeta(&doc.DocumentMeta); err != nil {
+		return nil, err
+	}
+	if err := s.saveDocument(ctx, userCtx, existing, &doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// saveDocument stores doc over existing, re-chunking it only when its
+// content changed. A doc.Version other than zero must match the stored
+// one; either way, an update landing since existing was read conflicts.
+func (s *service) saveDocument(ctx context.Context, userCtx documentDomain.UserContext, existing, doc *documentDomain.Document) error {
+	if doc.Version != 0 && doc.Version != existing.Version {
+		return fmt.Errorf("%w: version %d is not the current %d", ErrVersionConflict, doc.Version, existing.Version)
+	}
+	doc.Version = existing.Version
+	if doc.Content != existing.Content {
+		doc.Content = s.cleanText(ctx, doc.Source, doc.Content)
+	}
+	doc.ContentHash = contentHash(doc.Content)
+	// New content is chunked from what is stored, which is all of it.
+	if doc.Content != existing.Content {
+		doc.Truncated = false
+	}
+
+	ok, err := s.repo.Update(ctx, doc)
+	if err != nil {
 		return err
 	}
+	if !ok {
+		return ErrVersionConflict
+	}
+	// New content gets chunks of its own below, ending the link.
+	if existing.DuplicateOf != "" && doc.Content != existing.Content {
+		if err := s.repo.SetDuplicateOf(ctx, doc.ID, ""); err != nil {
+			s.log.WarnContext(ctx, "failed to unlink document", "error", err, "document_id", doc.ID)
+		}
+	}
+	s.recordStorage(ctx, doc.UserID, doc.ID, documentDomain.StorageUsage{
+		DocumentBytes: int64(len(doc.Content) - len(existing.Content)),
+	})
 
 	if s.chunkRepo != nil && doc.Content != existing.Content {
-		if err := s.chunkRepo.DeleteByDocumentID(ctx, doc.ID); err != nil {
-			fmt.Printf("warning: failed to delete old chunks for document %s: %v\n", doc.ID, err)
+		if err := s.rechunkDocument(ctx, doc); err != nil {
+			s.log.WarnContext(ctx, "failed to rechunk document", "error", err, "document_id", doc.ID)
 		}
-
-		if s.openaiClient != nil && s.chunker != nil && doc.Content != "" {
-			if err := s.createChunksForDocument(ctx, doc.ID, doc.Content); err != nil {
-				fmt.Printf("warning: failed to create new chunks for document %s: %v\n", doc.ID, err)
+	} else if s.chunkRepo != nil {
+		now := time.Now()
+		if available := doc.IsRetrievable(now); available != existing.IsRetrievable(now) {
+			if err := s.chunkRepo.SetHiddenByDocumentID(ctx, doc.ID, !available); err != nil {
+				s.log.WarnContext(ctx, "failed to update chunk visibility", "error", err, "document_id", doc.ID)
 			}
 		}
 	}
 
+	s.events.Publish(ctx, events.DocumentUpdated{
+		DocumentID:     doc.ID,
+		ActorID:        userCtx.UserID,
+		ContentChanged: doc.Content != existing.Content,
+	})
 	return nil
 }
 
@@ -206,21 +489,208 @@ func (s *service) DeleteDocument(ctx context.Context, userCtx documentDomain.Use
 		return ErrForbidden
 	}
 
+	return s.removeDocument(ctx, existing, userCtx.UserID)
+}
+
+// removeDocument deletes doc with its chunks and storage totals.
+func (s *service) removeDocument(ctx context.Context, doc *documentDomain.Document, actorID string) error {
 	if s.chunkRepo != nil {
-		if err := s.chunkRepo.DeleteByDocumentID(ctx, id); err != nil {
-			fmt.Printf("warning: failed to delete chunks for document %s: %v\n", id, err)
+		if err := s.chunkRepo.DeleteByDocumentID(ctx, doc.ID); err != nil {
+			s.log.WarnContext(ctx, "failed to delete chunks", "error", err, "document_id", doc.ID)
 		}
 	}
 
-	return s.repo.Delete(ctx, id)
+	if err := s.repo.Delete(ctx, doc.ID); err != nil {
+		return err
+	}
+	s.deleteFile(ctx, doc.File)
+	s.releaseDocumentStorage(ctx, doc.UserID, doc.ID)
+	if doc.DuplicateOf == "" {
+		s.releaseDuplicates(ctx, doc.ID)
+	}
+	s.events.Publish(ctx, events.DocumentDeleted{DocumentID: doc.ID, ActorID: actorID})
+	return nil
+}
+
+func (s *service) ChangeStatus(ctx context.Context, userCtx documentDomain.UserContext, id string, status documentDomain.Status) error {
+	existing, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrDocumentNotFound
+	}
+
+	if !documentDomain.CanTransition(existing.Status, status) {
+		return ErrInvalidStatus
+	}
+
+	// Owners and editors may submit drafts; every other transition is an
+	// approval decision reserved for admins.
+	if status == documentDomain.StatusReview {
+		if !userCtx.IsAdmin && !userCtx.IsEditor && existing.UserID != userCtx.UserID {
+			return ErrForbidden
+		}
+	} else if !userCtx.IsAdmin {
+		return ErrForbidden
+	}
+
+	now := time.Now()
+	wasRetrievable := existing.IsRetrievable(now)
+	updated := *existing
+	updated.Status = status
+
+	if err := s.repo.UpdateStatus(ctx, id, status); err != nil {
+		return err
+	}
+
+	if s.chunkRepo != nil {
+		if available := updated.IsRetrievable(now); available != wasRetrievable {
+			if err := s.chunkRepo.SetHiddenByDocumentID(ctx, id, !available); err != nil {
+				s.log.WarnContext(ctx, "failed to update chunk visibility", "error", err, "document_id", id)
+			}
+		}
+	}
+
+	s.events.Publish(ctx, events.DocumentStatusChanged{
+		DocumentID: id,
+		ActorID:    userCtx.UserID,
+		From:       string(existing.Status),
+		To:         string(status),
+	})
+	return nil
+}
+
+func (s *service) ListPendingReview(ctx context.Context, userCtx documentDomain.UserContext, limit, offset int) ([]documentDomain.Document, int64, error) {
+	if !userCtx.IsAdmin && !userCtx.IsEditor {
+		return nil, 0, ErrForbidden
+	}
+
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	docs, err := s.repo.ListByStatus(ctx, documentDomain.StatusReview, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.repo.CountByStatus(ctx, documentDomain.StatusReview)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return docs, total, nil
+}
+
+func (s *service) ListChunks(ctx context.Context, userCtx documentDomain.UserContext, documentID string, limit, offset int, withEmbeddings bool) ([]documentDomain.Chunk, int64, error) {
+	if _, err := s.GetDocument(ctx, userCtx, documentID); err != nil {
+		return nil, 0, err
+	}
+
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	if s.chunkRepo == nil {
+		return []documentDomain.Chunk{}, 0, nil
+	}
+
+	chunks, err := s.chunkRepo.ListByDocumentID(ctx, documentID, limit, offset, withEmbeddings)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.chunkRepo.CountByDocumentID(ctx, documentID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return chunks, total, nil
+}
+
+func (s *service) DeleteChunk(ctx context.Context, userCtx documentDomain.UserContext, id string) error {
+	if !userCtx.IsAdmin {
+		return ErrForbidden
+	}
+
+	if s.chunkRepo == nil {
+		return ErrChunkNotFound
+	}
+
+	chunk, err := s.chunkRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if chunk == nil {
+		return ErrChunkNotFound
+	}
+
+	if err := s.chunkRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	if doc, err := s.repo.GetByID(ctx, chunk.DocumentID); err == nil && doc != nil {
+		s.recordStorage(ctx, doc.UserID, doc.ID, chunkUsage(*chunk).Negate())
+	}
+	s.events.Publish(ctx, events.KnowledgeChanged{
+		Reason:     "chunk_deleted",
+		ActorID:    userCtx.UserID,
+		DocumentID: chunk.DocumentID,
+	})
+	return nil
 }
 
 func (s *service) QueryRAG(ctx context.Context, query documentDomain.RAGQuery) (*documentDomain.RAGResponse, error) {
+	return s.queryRAG(ctx, query, &queryRun{})
+}
+
+func (s *service) queryRAG(ctx context.Context, query documentDomain.RAGQuery, run *queryRun) (*documentDomain.RAGResponse, error) {
 	start := time.Now()
 
 	if query.Query == "" {
 		return nil, ErrInvalidQuery
 	}
+	if query.ResponseSchema != nil {
+		if err := checkResponseSchema(query.ResponseSchema); err != nil {
+			return nil, err
+		}
+	}
+	if query.Channel == "" {
+		query.Channel = documentDomain.ChannelWeb
+	}
+	if !slices.Contains(documentDomain.Channels, query.Channel) {
+		return nil, fmt.Errorf("%w: unknown channel %q", ErrInvalidQuery, query.Channel)
+	}
+	if err := s.checkOverrides(query); err != nil {
+		return nil, err
+	}
+	lang := replyLanguage(ctx, query)
+
+	// Matching and search use the question with its glossary synonyms;
+	// the model is still asked the question as written.
+	expanded, glossary := s.expandQuery(ctx, query.Query)
+
+	// A canned answer cannot follow a response schema.
+	if query.ResponseSchema == nil {
+		if shortcut := s.matchShortcut(ctx, query.Query, expanded); shortcut != nil {
+			resp := shortcutResponse(shortcut, start)
+			s.logQuery(ctx, run, query, documentDomain.OutcomeShortcut, resp, nil)
+			s.publishAnswer(ctx, run, query.Query, resp, false)
+			return resp, nil
+		}
+	}
 
 	if query.TopK <= 0 {
 		query.TopK = 5
@@ -229,64 +699,210 @@ func (s *service) QueryRAG(ctx context.Context, query documentDomain.RAGQuery) (
 		query.Threshold = 0.7
 	}
 
-	if s.openaiClient == nil || s.chunkRepo == nil {
+	if s.embedder == nil || s.chunkRepo == nil {
 		return &documentDomain.RAGResponse{
-			Answer:           "RAG service is not configured. Please set OPENAI_API_KEY.",
+			Answer:           "RAG service is not configured. Please set OPENAI_API_KEY or EMBEDDING_SERVER_URL.",
 			RelevantChunks:   []documentDomain.Chunk{},
 			ConfidenceScore:  0.0,
 			ProcessingTimeMs: time.Since(start).Milliseconds(),
 		}, nil
 	}
 
-	queryEmbedding, err := s.openaiClient.CreateEmbedding(ctx, query.Query, s.embeddingModel)
+	// Catalog products are matched exactly before any vector search.
+	products := s.matchProducts(ctx, expanded)
+	facts := productFacts(products)
+
+	// A replay must see what the index answers now, not a stored answer,
+	// and prices and stock must not be answered from one either.
+	var cacheKey string
+	if !run.replay && len(products) == 0 {
+		cacheKey = s.answerCacheKey(ctx, query)
+	}
+	if cached := s.cachedAnswer(ctx, cacheKey); cached != nil {
+		cached.ProcessingTimeMs = time.Since(start).Milliseconds()
+		s.logQuery(ctx, run, query, documentDomain.OutcomeCached, cached, nil)
+		s.publishAnswer(ctx, run, query.Query, cached, true)
+		return cached, nil
+	}
+
+	// Past the daily spend cap only free models answer; without any, the
+	// answer cache above is all that is left.
+	models := s.chatModels(query.Model)
+	var spendGuardrails []string
+	if s.spend != nil && s.spend.overDailyCap(ctx) {
+		models = s.spend.freeModels(models)
+		if len(models) == 0 {
+			resp := unavailableResponse(start, lang)
+			resp.Guardrails = []string{"daily_spend_cap"}
+			s.logQuery(ctx, run, query, documentDomain.OutcomeUnavailable, resp, nil)
+			return resp, nil
+		}
+		spendGuardrails = append(spendGuardrails, "daily_spend_cap")
+	}
+
+	space, err := s.embeddingSpace(ctx, query.Collection)
+	if errors.Is(err, ErrCollectionNotFound) {
+		return nil, fmt.Errorf("%w: unknown collection %q", ErrInvalidQuery, query.Collection)
+	}
+	if err != nil {
+		return nil, err
+	}
+	queryEmbedding, err := s.embed(ctx, space, expanded)
+	if errors.Is(err, breaker.ErrOpen) {
+		resp := unavailableResponse(start, lang)
+		s.logQuery(ctx, run, query, documentDomain.OutcomeUnavailable, resp, nil)
+		return resp, nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
 	}
 
-	relevantChunks, err := s.chunkRepo.Search(ctx, queryEmbedding, query.TopK, query.Threshold)
+	relevantChunks, err := s.chunkRepo.Search(ctx, space, queryEmbedding, query.TopK, query.Threshold)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search chunks: %w", err)
 	}
 
-	if len(relevantChunks) == 0 {
-		return &documentDomain.RAGResponse{
-			Answer:           "I couldn't find any relevant information in the knowledge base to answer your question.",
+	relevantChunks, err = s.applyRetrievalRules(ctx, expanded, relevantChunks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply retrieval rules: %w", err)
+	}
+
+	// With tools the model may still answer from them alone.
+	tools := s.toolDefinitions(ctx)
+
+	if len(relevantChunks) == 0 && len(tools) == 0 && len(products) == 0 {
+		resp := &documentDomain.RAGResponse{
+			Answer:           i18n.Translate(lang, noResultsAnswer),
 			RelevantChunks:   []documentDomain.Chunk{},
 			ConfidenceScore:  0.0,
 			ProcessingTimeMs: time.Since(start).Milliseconds(),
-		}, nil
-	}
-
-	var contextBuilder strings.Builder
-	for i, chunk := range relevantChunks {
-		contextBuilder.WriteString(fmt.Sprintf("[Source %d]\n%s\n\n", i+1, chunk.Content))
+		}
+		s.logQuery(ctx, run, query, documentDomain.OutcomeNoResults, resp, nil)
+		return resp, nil
 	}
 
 	systemPrompt := `You are a helpful assistant for a store. Answer questions based ONLY on the provided context.
 If the context doesn't contain enough information to answer the question, say so honestly.
 Be concise and helpful in your responses.`
+	if len(tools) > 0 {
+		systemPrompt += "\nUse the available tools for live data such as order status, calculations or today's date."
+	}
+	if len(products) > 0 {
+		systemPrompt += "\nTake prices, stock and product details only from the product catalog in the context, never from other sources."
+	}
 
-	userPrompt := fmt.Sprintf("Context:\n%s\nQuestion: %s", contextBuilder.String(), query.Query)
+	var profile *documentDomain.FormatProfile
+	opts := completionOptions(query, 0)
+	if query.ResponseSchema == nil {
+		profile = s.formatProfile(ctx, query.Channel)
+		systemPrompt += formatInstructions(profile)
+		opts = completionOptions(query, profile.MaxTokens)
+	}
 
-	messages := []openai.ChatMessage{
-		{Role: "system", Content: systemPrompt},
-		{Role: "user", Content: userPrompt},
+	// Over the per-request limit the context shrinks rather than the query
+	// failing.
+	contextTokens := s.maxContextTokens
+	if s.spend != nil {
+		budget, capped := s.spend.contextBudget(models[0].Name, promptTokens(promptMessages(systemPrompt, query, query.Query)), opts.MaxTokens, contextTokens)
+		if capped {
+			contextTokens = budget
+			spendGuardrails = append(spendGuardrails, "cost_capped_context")
+		}
 	}
 
-	answer, err := s.openaiClient.CreateChatCompletion(ctx, messages, s.modelName, nil)
+	retrieved := relevantChunks
+	relevantChunks = assembleContext(relevantChunks, contextTokens, s.dedupThreshold)
+	hits := queryHits(retrieved, relevantChunks)
+	s.attachSources(ctx, relevantChunks)
+
+	userPrompt := fmt.Sprintf("Context:\n%s%s%s\nQuestion: %s", buildGlossaryPrompt(glossary), buildProductPrompt(facts), buildContextPrompt(relevantChunks), query.Query)
+
+	messages := promptMessages(systemPrompt, query, userPrompt)
+
+	var gen generation
+	var data json.RawMessage
+	if query.ResponseSchema != nil {
+		gen, data, err = s.generateStructured(ctx, models, messages, tools, query.ResponseSchema, opts)
+	} else {
+		gen, err = s.generate(ctx, models, messages, tools, opts)
+		gen.answer = applyFormat(gen.answer, profile)
+	}
+	if errors.Is(err, ErrStructuredAnswer) {
+		return nil, err
+	}
+	if errors.Is(err, breaker.ErrOpen) {
+		resp := unavailableResponse(start, lang)
+		s.logQuery(ctx, run, query, documentDomain.OutcomeUnavailable, resp, hits)
+		return resp, nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate answer: %w", err)
 	}
 
 	confidenceScore := 0.85
-	if len(relevantChunks) < query.TopK/2 {
+	if len(retrieved) < query.TopK/2 && len(products) == 0 {
 		confidenceScore = 0.6
 	}
 
+	resp := &documentDomain.RAGResponse{
+		Answer:          gen.answer,
+		RelevantChunks:  relevantChunks,
+		ConfidenceScore: confidenceScore,
+		ToolsUsed:       gen.toolsUsed,
+		Data:            data,
+		Model:           gen.model,
+		Products:        productSKUs(products),
+	}
+	s.applyGuardrail(resp, append(gen.toolResults, facts...), lang)
+	resp.Guardrails = append(resp.Guardrails, spendGuardrails...)
+	resp.ProcessingTimeMs = time.Since(start).Milliseconds()
+
+	// Tool results are live data, so those answers must not be replayed.
+	if len(gen.toolsUsed) == 0 {
+		s.storeAnswer(ctx, cacheKey, resp)
+	}
+	s.logQuery(ctx, run, query, documentDomain.OutcomeAnswered, resp, hits)
+	s.publishAnswer(ctx, run, query.Query, resp, false)
+
+	return resp, nil
+}
+
+// Built-in replies, translated by pkg/i18n.
+const (
+	noResultsAnswer   = "I couldn't find any relevant information in the knowledge base to answer your question."
+	unavailableAnswer = "I can't answer right now because the assistant is temporarily unavailable. Please try again in a few minutes."
+)
+
+// replyLanguage is the language of the built-in replies to query: the one
+// it asks for, else the one it is written in, else the request's.
+func replyLanguage(ctx context.Context, query documentDomain.RAGQuery) string {
+	if lang := strings.ToLower(query.Language); i18n.Supported(lang) {
+		return lang
+	}
+	return i18n.Guess(query.Query, i18n.FromContext(ctx))
+}
+
+// unavailableResponse is the answer while OpenAI's circuit breaker is open,
+// so callers get a reply at once instead of waiting on a failing API.
+func unavailableResponse(start time.Time, lang string) *documentDomain.RAGResponse {
 	return &documentDomain.RAGResponse{
-		Answer:           answer,
-		RelevantChunks:   relevantChunks,
-		ConfidenceScore:  confidenceScore,
+		Answer:           i18n.Translate(lang, unavailableAnswer),
+		RelevantChunks:   []documentDomain.Chunk{},
+		ConfidenceScore:  0.0,
 		ProcessingTimeMs: time.Since(start).Milliseconds(),
-	}, nil
+	}
+}
+
+func (s *service) publishAnswer(ctx context.Context, run *queryRun, query string, resp *documentDomain.RAGResponse, cacheHit bool) {
+	if run.replay {
+		return
+	}
+	s.events.Publish(ctx, events.AnswerGenerated{
+		Query:            query,
+		Answer:           resp.Answer,
+		ConfidenceScore:  resp.ConfidenceScore,
+		ChunkCount:       len(resp.RelevantChunks),
+		CacheHit:         cacheHit,
+		ProcessingTimeMs: resp.ProcessingTimeMs,
+	})
 }
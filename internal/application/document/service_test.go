@@ -3,10 +3,17 @@ package document
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
 	"testing"
 	"time"
 
 	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/breaker"
+	"github.com/elprogramadorgt/lucidRAG/pkg/i18n"
+	"github.com/elprogramadorgt/lucidRAG/pkg/openai"
 )
 
 // mockDocumentRepo is a mock implementation of document.Repository
@@ -14,6 +21,8 @@ type mockDocumentRepo struct {
 	documents   map[string]*documentDomain.Document
 	createError error
 	getError    error
+	// chunks, when set, tells which documents have chunks.
+	chunks *mockChunkRepo
 }
 
 func newMockDocumentRepo() *mockDocumentRepo {
@@ -28,6 +37,7 @@ func (m *mockDocumentRepo) Create(ctx context.Context, doc *documentDomain.Docum
 	}
 	id := "doc_" + doc.Title
 	doc.ID = id
+	doc.Version = 1
 	m.documents[id] = doc
 	return id, nil
 }
@@ -61,6 +71,24 @@ func (m *mockDocumentRepo) ListByUser(ctx context.Context, userID string, limit,
 	return docs, nil
 }
 
+func (m *mockDocumentRepo) ListFiltered(ctx context.Context, filter documentDomain.DocumentFilter, limit, offset int) ([]documentDomain.Document, int64, error) {
+	docs := make([]documentDomain.Document, 0)
+	for _, doc := range m.documents {
+		if filter.UserID != "" && doc.UserID != filter.UserID ||
+			filter.Author != "" && doc.Author != filter.Author ||
+			filter.Language != "" && doc.Language != filter.Language ||
+			filter.Department != "" && doc.Department != filter.Department {
+			continue
+		}
+		if !filter.EffectiveOn.IsZero() && (doc.EffectiveAt != nil && doc.EffectiveAt.After(filter.EffectiveOn) ||
+			doc.ExpiresAt != nil && !doc.ExpiresAt.After(filter.EffectiveOn)) {
+			continue
+		}
+		docs = append(docs, *doc)
+	}
+	return docs, int64(len(docs)), nil
+}
+
 func (m *mockDocumentRepo) Count(ctx context.Context) (int64, error) {
 	return int64(len(m.documents)), nil
 }
@@ -75,8 +103,97 @@ func (m *mockDocumentRepo) CountByUser(ctx context.Context, userID string) (int6
 	return count, nil
 }
 
-func (m *mockDocumentRepo) Update(ctx context.Context, doc *documentDomain.Document) error {
+func (m *mockDocumentRepo) ListUnavailableIDs(ctx context.Context, now time.Time) ([]string, error) {
+	ids := make([]string, 0)
+	for id, doc := range m.documents {
+		if doc.IsActive && !doc.IsRetrievable(now) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (m *mockDocumentRepo) UpdateStatus(ctx context.Context, id string, status documentDomain.Status) error {
+	if doc, ok := m.documents[id]; ok {
+		doc.Status = status
+	}
+	return nil
+}
+
+func (m *mockDocumentRepo) ListByStatus(ctx context.Context, status documentDomain.Status, limit, offset int) ([]documentDomain.Document, error) {
+	docs := make([]documentDomain.Document, 0)
+	for _, doc := range m.documents {
+		if doc.Status == status {
+			docs = append(docs, *doc)
+		}
+	}
+	return docs, nil
+}
+
+func (m *mockDocumentRepo) CountByStatus(ctx context.Context, status documentDomain.Status) (int64, error) {
+	docs, _ := m.ListByStatus(ctx, status, 0, 0)
+	return int64(len(docs)), nil
+}
+
+func (m *mockDocumentRepo) CountByCollection(ctx context.Context, collection string) (int64, error) {
+	var count int64
+	for _, doc := range m.documents {
+		if doc.Collection == collection {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *mockDocumentRepo) Update(ctx context.Context, doc *documentDomain.Document) (bool, error) {
+	if current, ok := m.documents[doc.ID]; !ok || current.Version != doc.Version {
+		return false, nil
+	}
+	doc.Version++
 	m.documents[doc.ID] = doc
+	return true, nil
+}
+
+func (m *mockDocumentRepo) ListUnchunked(ctx context.Context, before time.Time, limit int) ([]documentDomain.Document, error) {
+	docs := make([]documentDomain.Document, 0)
+	for _, doc := range m.documents {
+		if doc.Content == "" || !doc.UpdatedAt.Before(before) {
+			continue
+		}
+		if m.chunks != nil {
+			if n, _ := m.chunks.CountByDocumentID(ctx, doc.ID); n > 0 {
+				continue
+			}
+		}
+		docs = append(docs, *doc)
+	}
+	return docs, nil
+}
+
+func (m *mockDocumentRepo) GetByContentHash(ctx context.Context, collection, hash string) (*documentDomain.Document, error) {
+	for _, doc := range m.documents {
+		if doc.ContentHash == hash && doc.Collection == collection && doc.DuplicateOf == "" {
+			return doc, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockDocumentRepo) ListDuplicates(ctx context.Context, id string) ([]documentDomain.Document, error) {
+	docs := make([]documentDomain.Document, 0)
+	for _, doc := range m.documents {
+		if doc.DuplicateOf == id {
+			docs = append(docs, *doc)
+		}
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].UploadedAt.Before(docs[j].UploadedAt) })
+	return docs, nil
+}
+
+func (m *mockDocumentRepo) SetDuplicateOf(ctx context.Context, id, original string) error {
+	if doc, ok := m.documents[id]; ok {
+		doc.DuplicateOf = original
+	}
 	return nil
 }
 
@@ -88,6 +205,10 @@ func (m *mockDocumentRepo) Delete(ctx context.Context, id string) error {
 // mockChunkRepo is a mock implementation of ChunkRepository
 type mockChunkRepo struct {
 	chunks []documentDomain.Chunk
+	index  documentDomain.EmbeddingIndex
+	staged map[string][]float64
+	// documents, when set, tells which chunks are orphaned.
+	documents map[string]*documentDomain.Document
 }
 
 func newMockChunkRepo() *mockChunkRepo {
@@ -101,15 +222,38 @@ func (m *mockChunkRepo) CreateBatch(ctx context.Context, chunks []documentDomain
 	return nil
 }
 
-func (m *mockChunkRepo) Search(ctx context.Context, embedding []float64, topK int, threshold float64) ([]documentDomain.Chunk, error) {
-	if len(m.chunks) == 0 {
-		return []documentDomain.Chunk{}, nil
+func (m *mockChunkRepo) Search(ctx context.Context, space documentDomain.EmbeddingSpace, embedding []float64, topK int, threshold float64) ([]documentDomain.Chunk, error) {
+	matches := []documentDomain.Chunk{}
+	for _, chunk := range m.chunks {
+		if chunk.Collection == space.Collection {
+			matches = append(matches, chunk)
+		}
 	}
 	limit := topK
-	if limit > len(m.chunks) {
-		limit = len(m.chunks)
+	if limit > len(matches) {
+		limit = len(matches)
+	}
+	return matches[:limit], nil
+}
+
+func (m *mockChunkRepo) ListVectors(ctx context.Context, space documentDomain.EmbeddingSpace) ([]documentDomain.Chunk, error) {
+	listed := []documentDomain.Chunk{}
+	for _, chunk := range m.chunks {
+		if chunk.Collection == space.Collection && !chunk.Hidden && chunk.Embedding != nil {
+			listed = append(listed, chunk)
+		}
 	}
-	return m.chunks[:limit], nil
+	return listed, nil
+}
+
+func (m *mockChunkRepo) Sample(ctx context.Context, space documentDomain.EmbeddingSpace, size int) ([]documentDomain.Chunk, error) {
+	sampled := []documentDomain.Chunk{}
+	for _, chunk := range m.chunks {
+		if chunk.Collection == space.Collection && !chunk.Hidden && len(sampled) < size {
+			sampled = append(sampled, chunk)
+		}
+	}
+	return sampled, nil
 }
 
 func (m *mockChunkRepo) GetByDocumentID(ctx context.Context, documentID string) ([]documentDomain.Chunk, error) {
@@ -122,6 +266,88 @@ func (m *mockChunkRepo) GetByDocumentID(ctx context.Context, documentID string)
 	return result, nil
 }
 
+func (m *mockChunkRepo) GetByID(ctx context.Context, id string) (*documentDomain.Chunk, error) {
+	for i := range m.chunks {
+		if m.chunks[i].ID == id {
+			return &m.chunks[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockChunkRepo) ListByDocumentID(ctx context.Context, documentID string, limit, offset int, withEmbeddings bool) ([]documentDomain.Chunk, error) {
+	result := make([]documentDomain.Chunk, 0)
+	for _, chunk := range m.chunks {
+		if chunk.DocumentID == documentID {
+			if !withEmbeddings {
+				chunk.Embedding = nil
+			}
+			result = append(result, chunk)
+		}
+	}
+	if offset >= len(result) {
+		return []documentDomain.Chunk{}, nil
+	}
+	result = result[offset:]
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (m *mockChunkRepo) CountByDocumentID(ctx context.Context, documentID string) (int64, error) {
+	count := int64(0)
+	for _, chunk := range m.chunks {
+		if chunk.DocumentID == documentID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *mockChunkRepo) Delete(ctx context.Context, id string) error {
+	newChunks := make([]documentDomain.Chunk, 0)
+	for _, chunk := range m.chunks {
+		if chunk.ID != id {
+			newChunks = append(newChunks, chunk)
+		}
+	}
+	m.chunks = newChunks
+	return nil
+}
+
+func (m *mockChunkRepo) SetHiddenByDocumentID(ctx context.Context, documentID string, hidden bool) error {
+	for i := range m.chunks {
+		if m.chunks[i].DocumentID == documentID {
+			m.chunks[i].Hidden = hidden
+		}
+	}
+	return nil
+}
+
+func (m *mockChunkRepo) SyncHidden(ctx context.Context, hiddenDocumentIDs []string) error {
+	hidden := make(map[string]bool, len(hiddenDocumentIDs))
+	for _, id := range hiddenDocumentIDs {
+		hidden[id] = true
+	}
+	for i := range m.chunks {
+		m.chunks[i].Hidden = hidden[m.chunks[i].DocumentID]
+	}
+	return nil
+}
+
+func (m *mockChunkRepo) ListOrphanDocumentIDs(ctx context.Context) ([]string, error) {
+	ids := []string{}
+	seen := map[string]bool{}
+	for _, chunk := range m.chunks {
+		if _, ok := m.documents[chunk.DocumentID]; !ok && !seen[chunk.DocumentID] {
+			seen[chunk.DocumentID] = true
+			ids = append(ids, chunk.DocumentID)
+		}
+	}
+	return ids, nil
+}
+
 func (m *mockChunkRepo) DeleteByDocumentID(ctx context.Context, documentID string) error {
 	newChunks := make([]documentDomain.Chunk, 0)
 	for _, chunk := range m.chunks {
@@ -133,6 +359,75 @@ func (m *mockChunkRepo) DeleteByDocumentID(ctx context.Context, documentID strin
 	return nil
 }
 
+func (m *mockChunkRepo) DeleteByIDs(ctx context.Context, ids []string) error {
+	newChunks := make([]documentDomain.Chunk, 0)
+	for _, chunk := range m.chunks {
+		if !slices.Contains(ids, chunk.ID) {
+			newChunks = append(newChunks, chunk)
+		}
+	}
+	m.chunks = newChunks
+	return nil
+}
+
+func (m *mockChunkRepo) UpdatePlacement(ctx context.Context, chunks []documentDomain.Chunk) error {
+	for _, c := range chunks {
+		for i := range m.chunks {
+			if m.chunks[i].ID == c.ID {
+				m.chunks[i].ChunkIndex = c.ChunkIndex
+				m.chunks[i].Table = c.Table
+				m.chunks[i].Hidden = c.Hidden
+				m.chunks[i].ContentHash = c.ContentHash
+			}
+		}
+	}
+	return nil
+}
+
+func (m *mockChunkRepo) EmbeddingIndex(ctx context.Context) (*documentDomain.EmbeddingIndex, error) {
+	index := m.index
+	return &index, nil
+}
+
+func (m *mockChunkRepo) StageModel(ctx context.Context, active, model string) error {
+	if m.index.ActiveModel == "" {
+		m.index.ActiveModel = active
+	}
+	m.index.StagedModel = model
+	m.staged = map[string][]float64{}
+	return nil
+}
+
+func (m *mockChunkRepo) ListUnstaged(ctx context.Context, limit int) ([]documentDomain.Chunk, error) {
+	var unstaged []documentDomain.Chunk
+	for _, chunk := range m.chunks {
+		if _, ok := m.staged[chunk.ID]; !ok && len(unstaged) < limit {
+			unstaged = append(unstaged, chunk)
+		}
+	}
+	return unstaged, nil
+}
+
+func (m *mockChunkRepo) CountUnstaged(ctx context.Context) (int64, error) {
+	return int64(len(m.chunks) - len(m.staged)), nil
+}
+
+func (m *mockChunkRepo) SetStaged(ctx context.Context, vectors map[string][]float64) error {
+	for id, vector := range vectors {
+		m.staged[id] = vector
+	}
+	return nil
+}
+
+func (m *mockChunkRepo) ActivateStaged(ctx context.Context) error {
+	for i := range m.chunks {
+		m.chunks[i].Embedding = m.staged[m.chunks[i].ID]
+	}
+	m.index = documentDomain.EmbeddingIndex{ActiveModel: m.index.StagedModel}
+	m.staged = nil
+	return nil
+}
+
 func TestNewService(t *testing.T) {
 	repo := newMockDocumentRepo()
 	svc := NewService(ServiceConfig{
@@ -325,7 +620,7 @@ func TestListDocuments(t *testing.T) {
 		svc.CreateDocument(ctx, userCtx, doc)
 	}
 
-	docs, total, err := svc.ListDocuments(ctx, userCtx, 10, 0)
+	docs, total, err := svc.ListDocuments(ctx, userCtx, documentDomain.DocumentFilter{}, 10, 0)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -351,19 +646,19 @@ func TestListDocumentsWithLimits(t *testing.T) {
 	}
 
 	// Test with negative limit (should default to 10)
-	_, _, err := svc.ListDocuments(ctx, userCtx, -1, 0)
+	_, _, err := svc.ListDocuments(ctx, userCtx, documentDomain.DocumentFilter{}, -1, 0)
 	if err != nil {
 		t.Fatalf("Expected no error with negative limit, got %v", err)
 	}
 
 	// Test with limit > 100 (should cap at 100)
-	_, _, err = svc.ListDocuments(ctx, userCtx, 200, 0)
+	_, _, err = svc.ListDocuments(ctx, userCtx, documentDomain.DocumentFilter{}, 200, 0)
 	if err != nil {
 		t.Fatalf("Expected no error with large limit, got %v", err)
 	}
 
 	// Test with negative offset (should default to 0)
-	_, _, err = svc.ListDocuments(ctx, userCtx, 10, -5)
+	_, _, err = svc.ListDocuments(ctx, userCtx, documentDomain.DocumentFilter{}, 10, -5)
 	if err != nil {
 		t.Fatalf("Expected no error with negative offset, got %v", err)
 	}
@@ -410,6 +705,88 @@ func TestUpdateDocument(t *testing.T) {
 	}
 }
 
+func TestPatchDocument(t *testing.T) {
+	repo := newMockDocumentRepo()
+	chunkRepo := newMockChunkRepo()
+	svc := NewService(ServiceConfig{Repo: repo, ChunkRepo: chunkRepo})
+
+	ctx := context.Background()
+	userCtx := documentDomain.UserContext{UserID: "user-123"}
+	id, _ := svc.CreateDocument(ctx, userCtx, &documentDomain.Document{
+		Title: "policy.txt", Source: "manual", Content: "Returns within 30 days", IsActive: true,
+	})
+	chunkRepo.chunks = []documentDomain.Chunk{{ID: "c1", DocumentID: id}}
+
+	title := "returns.txt"
+	doc, err := svc.PatchDocument(ctx, userCtx, id, documentDomain.DocumentPatch{Title: &title})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if doc.Title != title || doc.Source != "manual" || doc.Content != "Returns within 30 days" || !doc.IsActive {
+		t.Errorf("Expected only the title to change, got %+v", doc)
+	}
+	if len(chunkRepo.chunks) != 1 {
+		t.Error("Expected chunks to be kept when the content is unchanged")
+	}
+
+	same := "Returns within 30 days"
+	if _, err := svc.PatchDocument(ctx, userCtx, id, documentDomain.DocumentPatch{Content: &same}); err != nil || len(chunkRepo.chunks) != 1 {
+		t.Errorf("Expected identical content not to re-index, got %v with %d chunks", err, len(chunkRepo.chunks))
+	}
+
+	content := "Returns within 60 days"
+	if _, err := svc.PatchDocument(ctx, userCtx, id, documentDomain.DocumentPatch{Content: &content}); err != nil || len(chunkRepo.chunks) != 0 {
+		t.Errorf("Expected new content to drop the old chunks, got %v with %d chunks", err, len(chunkRepo.chunks))
+	}
+
+	empty := " "
+	if _, err := svc.PatchDocument(ctx, userCtx, id, documentDomain.DocumentPatch{}); !errors.Is(err, ErrInvalidPatch) {
+		t.Errorf("Expected ErrInvalidPatch for an empty patch, got %v", err)
+	}
+	if _, err := svc.PatchDocument(ctx, userCtx, id, documentDomain.DocumentPatch{Content: &empty}); !errors.Is(err, ErrInvalidPatch) {
+		t.Errorf("Expected ErrInvalidPatch for empty content, got %v", err)
+	}
+	other := documentDomain.UserContext{UserID: "user-456"}
+	if _, err := svc.PatchDocument(ctx, other, id, documentDomain.DocumentPatch{Title: &title}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden, got %v", err)
+	}
+}
+
+func TestUpdateDocumentVersionConflict(t *testing.T) {
+	repo := newMockDocumentRepo()
+	svc := NewService(ServiceConfig{Repo: repo})
+
+	ctx := context.Background()
+	admin := documentDomain.UserContext{UserID: "admin-1", IsAdmin: true}
+	id, _ := svc.CreateDocument(ctx, admin, &documentDomain.Document{Title: "faq.txt", Content: "Open 9-5"})
+
+	first := &documentDomain.Document{ID: id, Title: "faq.txt", Content: "Open 9-6", Version: 1}
+	if err := svc.UpdateDocument(ctx, admin, first); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if first.Version != 2 {
+		t.Errorf("Expected version 2, got %d", first.Version)
+	}
+
+	second := &documentDomain.Document{ID: id, Title: "faq.txt", Content: "Open 8-5", Version: 1}
+	if err := svc.UpdateDocument(ctx, admin, second); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("Expected ErrVersionConflict, got %v", err)
+	}
+	title := "hours.txt"
+	if _, err := svc.PatchDocument(ctx, admin, id, documentDomain.DocumentPatch{Version: 1, Title: &title}); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("Expected ErrVersionConflict for a stale patch, got %v", err)
+	}
+	if doc, _ := svc.GetDocument(ctx, admin, id); doc.Content != "Open 9-6" {
+		t.Errorf("Expected the first update to stand, got %q", doc.Content)
+	}
+
+	// Without a version the update applies to whatever is current.
+	doc, err := svc.PatchDocument(ctx, admin, id, documentDomain.DocumentPatch{Title: &title})
+	if err != nil || doc.Version != 3 {
+		t.Errorf("Expected an unchecked patch to apply at version 3, got %v", err)
+	}
+}
+
 func TestUpdateDocumentNotFound(t *testing.T) {
 	repo := newMockDocumentRepo()
 	svc := NewService(ServiceConfig{
@@ -498,6 +875,46 @@ func TestDeleteDocument(t *testing.T) {
 	}
 }
 
+func TestPurgeUserDocuments(t *testing.T) {
+	repo := newMockDocumentRepo()
+	chunkRepo := newMockChunkRepo()
+	svc := NewService(ServiceConfig{
+		Repo:      repo,
+		ChunkRepo: chunkRepo,
+	})
+	ctx := context.Background()
+	admin := documentDomain.UserContext{UserID: "admin-1", IsAdmin: true}
+
+	for _, doc := range []*documentDomain.Document{
+		{Title: "a", UserID: "user-1"},
+		{Title: "b", UserID: "user-1"},
+		{Title: "c", UserID: "user-2"},
+	} {
+		id, _ := repo.Create(ctx, doc)
+		_ = chunkRepo.CreateBatch(ctx, []documentDomain.Chunk{{DocumentID: id}, {DocumentID: id}})
+	}
+
+	if _, err := svc.PurgeUserDocuments(ctx, documentDomain.UserContext{UserID: "user-1"}, "user-1", true); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden, got %v", err)
+	}
+
+	result, err := svc.PurgeUserDocuments(ctx, admin, "user-1", true)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Documents != 2 || result.Chunks != 4 || len(repo.documents) != 3 {
+		t.Errorf("Expected a dry run to count 2 documents and 4 chunks, got %+v", result)
+	}
+
+	result, err = svc.PurgeUserDocuments(ctx, admin, "user-1", false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Documents != 2 || len(repo.documents) != 1 || len(chunkRepo.chunks) != 2 {
+		t.Errorf("Expected user-1's documents and chunks to be deleted, got %+v", result)
+	}
+}
+
 func TestDeleteDocumentNotFound(t *testing.T) {
 	repo := newMockDocumentRepo()
 	svc := NewService(ServiceConfig{
@@ -588,6 +1005,57 @@ func TestQueryRAGNotConfigured(t *testing.T) {
 	}
 }
 
+func TestQueryRAGBreakerOpen(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	svc := NewService(ServiceConfig{
+		Repo:         newMockDocumentRepo(),
+		ChunkRepo:    newMockChunkRepo(),
+		OpenAIClient: openai.NewClient("test-key", openai.WithBaseURL(server.URL), openai.WithBreaker(breaker.New("openai", 1, time.Minute))),
+	})
+	query := documentDomain.RAGQuery{Query: "What are your hours?"}
+
+	if _, err := svc.QueryRAG(context.Background(), query); err == nil {
+		t.Fatal("Expected the failing call to return an error")
+	}
+	resp, err := svc.QueryRAG(context.Background(), query)
+	if err != nil {
+		t.Fatalf("Expected a fallback answer, got %v", err)
+	}
+	if resp.Answer == "" || resp.ConfidenceScore != 0.0 {
+		t.Errorf("Expected a fallback answer with no confidence, got %+v", resp)
+	}
+	if requests != 1 {
+		t.Errorf("Expected the open breaker to skip OpenAI, got %d requests", requests)
+	}
+}
+
+func TestReplyLanguage(t *testing.T) {
+	spanishRequest := i18n.WithLanguage(context.Background(), i18n.Spanish)
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		query documentDomain.RAGQuery
+		want  string
+	}{
+		{"asked for", context.Background(), documentDomain.RAGQuery{Query: "What are your hours?", Language: "ES"}, i18n.Spanish},
+		{"written in", context.Background(), documentDomain.RAGQuery{Query: "¿Cuál es el horario?"}, i18n.Spanish},
+		{"written in despite the request", spanishRequest, documentDomain.RAGQuery{Query: "What are your hours?"}, i18n.English},
+		{"request when unclear", spanishRequest, documentDomain.RAGQuery{Query: "iPhone 15"}, i18n.Spanish},
+		{"unsupported guesses", context.Background(), documentDomain.RAGQuery{Query: "¿Cuál es el horario?", Language: "pt"}, i18n.Spanish},
+	}
+	for _, tt := range tests {
+		if got := replyLanguage(tt.ctx, tt.query); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, got)
+		}
+	}
+}
+
 func TestQueryRAGDefaultValues(t *testing.T) {
 	repo := newMockDocumentRepo()
 	chunkRepo := newMockChunkRepo()
@@ -614,3 +1082,194 @@ func TestQueryRAGDefaultValues(t *testing.T) {
 		t.Error("Expected non-empty response")
 	}
 }
+
+func TestListChunks(t *testing.T) {
+	repo := newMockDocumentRepo()
+	chunkRepo := newMockChunkRepo()
+	svc := NewService(ServiceConfig{
+		Repo:      repo,
+		ChunkRepo: chunkRepo,
+	})
+
+	ctx := context.Background()
+	userCtx := documentDomain.UserContext{UserID: "user-123"}
+
+	id, _ := svc.CreateDocument(ctx, userCtx, &documentDomain.Document{Title: "test.txt"})
+	chunkRepo.chunks = []documentDomain.Chunk{
+		{ID: "c1", DocumentID: id, ChunkIndex: 0, Embedding: []float64{0.1}},
+		{ID: "c2", DocumentID: id, ChunkIndex: 1, Embedding: []float64{0.2}},
+		{ID: "c3", DocumentID: "other", ChunkIndex: 0},
+	}
+
+	chunks, total, err := svc.ListChunks(ctx, userCtx, id, 10, 0, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if total != 2 {
+		t.Errorf("Expected total 2, got %d", total)
+	}
+	if len(chunks) != 2 {
+		t.Fatalf("Expected 2 chunks, got %d", len(chunks))
+	}
+	if chunks[0].Embedding != nil {
+		t.Error("Expected embeddings to be omitted")
+	}
+}
+
+func TestListChunksForbidden(t *testing.T) {
+	repo := newMockDocumentRepo()
+	svc := NewService(ServiceConfig{
+		Repo:      repo,
+		ChunkRepo: newMockChunkRepo(),
+	})
+
+	ctx := context.Background()
+	id, _ := svc.CreateDocument(ctx, documentDomain.UserContext{UserID: "user-123"}, &documentDomain.Document{Title: "test.txt"})
+
+	_, _, err := svc.ListChunks(ctx, documentDomain.UserContext{UserID: "user-456"}, id, 10, 0, false)
+	if !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden, got %v", err)
+	}
+}
+
+func TestDeleteChunk(t *testing.T) {
+	chunkRepo := newMockChunkRepo()
+	chunkRepo.chunks = []documentDomain.Chunk{{ID: "c1", DocumentID: "doc-1"}}
+	svc := NewService(ServiceConfig{
+		Repo:      newMockDocumentRepo(),
+		ChunkRepo: chunkRepo,
+	})
+
+	ctx := context.Background()
+
+	err := svc.DeleteChunk(ctx, documentDomain.UserContext{UserID: "user-123"}, "c1")
+	if !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden for non-admin, got %v", err)
+	}
+
+	adminCtx := documentDomain.UserContext{UserID: "admin", IsAdmin: true}
+	if err := svc.DeleteChunk(ctx, adminCtx, "c1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(chunkRepo.chunks) != 0 {
+		t.Errorf("Expected chunk to be deleted, %d remain", len(chunkRepo.chunks))
+	}
+
+	err = svc.DeleteChunk(ctx, adminCtx, "c1")
+	if !errors.Is(err, ErrChunkNotFound) {
+		t.Errorf("Expected ErrChunkNotFound, got %v", err)
+	}
+}
+
+func TestCreateDocumentInvalidSchedule(t *testing.T) {
+	svc := NewService(ServiceConfig{Repo: newMockDocumentRepo()})
+
+	publishAt := time.Now().Add(time.Hour)
+	expiresAt := time.Now()
+	_, err := svc.CreateDocument(context.Background(), documentDomain.UserContext{UserID: "user-123"}, &documentDomain.Document{
+		Title:     "promo.txt",
+		PublishAt: &publishAt,
+		ExpiresAt: &expiresAt,
+	})
+	if !errors.Is(err, ErrInvalidSchedule) {
+		t.Errorf("Expected ErrInvalidSchedule, got %v", err)
+	}
+}
+
+func TestUpdateDocumentScheduleHidesChunks(t *testing.T) {
+	repo := newMockDocumentRepo()
+	chunkRepo := newMockChunkRepo()
+	svc := NewService(ServiceConfig{Repo: repo, ChunkRepo: chunkRepo})
+
+	ctx := context.Background()
+	userCtx := documentDomain.UserContext{UserID: "admin-1", IsAdmin: true}
+	id, _ := svc.CreateDocument(ctx, userCtx, &documentDomain.Document{Title: "promo.txt", Content: "Promo"})
+	chunkRepo.chunks = []documentDomain.Chunk{{ID: "c1", DocumentID: id}}
+
+	expired := time.Now().Add(-time.Minute)
+	err := svc.UpdateDocument(ctx, userCtx, &documentDomain.Document{ID: id, Title: "promo.txt", Content: "Promo", ExpiresAt: &expired})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !chunkRepo.chunks[0].Hidden {
+		t.Error("Expected chunks of expired document to be hidden")
+	}
+}
+
+func TestPublicationJobSync(t *testing.T) {
+	repo := newMockDocumentRepo()
+	chunkRepo := newMockChunkRepo()
+
+	now := time.Now()
+	later := now.Add(time.Hour)
+	earlier := now.Add(-time.Hour)
+	repo.documents["scheduled"] = &documentDomain.Document{ID: "scheduled", IsActive: true, PublishAt: &later}
+	repo.documents["expired"] = &documentDomain.Document{ID: "expired", IsActive: true, ExpiresAt: &earlier}
+	repo.documents["live"] = &documentDomain.Document{ID: "live", IsActive: true, PublishAt: &earlier}
+	chunkRepo.chunks = []documentDomain.Chunk{
+		{ID: "c1", DocumentID: "scheduled"},
+		{ID: "c2", DocumentID: "expired"},
+		{ID: "c3", DocumentID: "live", Hidden: true},
+	}
+
+	job := NewPublicationJob(repo, chunkRepo)
+	if err := job.Sync(context.Background(), now); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := map[string]bool{"c1": true, "c2": true, "c3": false}
+	for _, c := range chunkRepo.chunks {
+		if c.Hidden != want[c.ID] {
+			t.Errorf("Chunk %s: expected hidden=%v, got %v", c.ID, want[c.ID], c.Hidden)
+		}
+	}
+}
+
+func TestApprovalWorkflow(t *testing.T) {
+	repo := newMockDocumentRepo()
+	chunkRepo := newMockChunkRepo()
+	svc := NewService(ServiceConfig{Repo: repo, ChunkRepo: chunkRepo})
+
+	ctx := context.Background()
+	ownerCtx := documentDomain.UserContext{UserID: "editor-1", IsEditor: true}
+	adminCtx := documentDomain.UserContext{UserID: "admin-1", IsAdmin: true}
+
+	id, _ := svc.CreateDocument(ctx, ownerCtx, &documentDomain.Document{Title: "faq.txt"})
+	if repo.documents[id].Status != documentDomain.StatusDraft {
+		t.Fatalf("Expected new document to be a draft, got %s", repo.documents[id].Status)
+	}
+	chunkRepo.chunks = []documentDomain.Chunk{{ID: "c1", DocumentID: id, Hidden: true}}
+
+	if err := svc.ChangeStatus(ctx, ownerCtx, id, documentDomain.StatusPublished); !errors.Is(err, ErrInvalidStatus) {
+		t.Errorf("Expected ErrInvalidStatus skipping review, got %v", err)
+	}
+
+	if err := svc.ChangeStatus(ctx, ownerCtx, id, documentDomain.StatusReview); err != nil {
+		t.Fatalf("Expected owner to submit for review, got %v", err)
+	}
+
+	pending, total, err := svc.ListPendingReview(ctx, adminCtx, 10, 0)
+	if err != nil || total != 1 || len(pending) != 1 {
+		t.Fatalf("Expected 1 pending document, got %d (%v)", total, err)
+	}
+
+	if err := svc.ChangeStatus(ctx, ownerCtx, id, documentDomain.StatusPublished); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden for non-admin approval, got %v", err)
+	}
+
+	if err := svc.ChangeStatus(ctx, adminCtx, id, documentDomain.StatusPublished); err != nil {
+		t.Fatalf("Expected admin approval, got %v", err)
+	}
+	if chunkRepo.chunks[0].Hidden {
+		t.Error("Expected chunks to become retrievable once published")
+	}
+}
+
+func TestListPendingReviewForbidden(t *testing.T) {
+	svc := NewService(ServiceConfig{Repo: newMockDocumentRepo()})
+
+	_, _, err := svc.ListPendingReview(context.Background(), documentDomain.UserContext{UserID: "user-1"}, 10, 0)
+	if !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden, got %v", err)
+	}
+}
@@ -0,0 +1,199 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+)
+
+var (
+	ErrShortcutNotFound = errors.New("faq shortcut not found")
+	ErrInvalidShortcut  = errors.New("invalid faq shortcut")
+)
+
+// shortcutMaxWords is the longest question a shortcut answers. Longer ones
+// tend to ask something more specific than the canned answer covers, such
+// as "do the holiday hours apply to online orders".
+const shortcutMaxWords = 12
+
+func (s *service) CreateFAQShortcut(ctx context.Context, userCtx documentDomain.UserContext, shortcut *documentDomain.FAQShortcut) (string, error) {
+	if !userCtx.IsAdmin {
+		return "", ErrForbidden
+	}
+	if s.shortcutRepo == nil {
+		return "", fmt.Errorf("%w: shortcuts are not configured", ErrInvalidShortcut)
+	}
+	if err := normalizeShortcut(shortcut); err != nil {
+		return "", err
+	}
+	shortcut.CreatedBy = userCtx.UserID
+	shortcut.IsActive = true
+
+	id, err := s.shortcutRepo.Create(ctx, shortcut)
+	if err != nil {
+		return "", err
+	}
+	s.events.Publish(ctx, events.KnowledgeChanged{Reason: "shortcut_created", ActorID: userCtx.UserID})
+	return id, nil
+}
+
+func (s *service) ListFAQShortcuts(ctx context.Context, userCtx documentDomain.UserContext) ([]documentDomain.FAQShortcut, error) {
+	if !userCtx.IsAdmin {
+		return nil, ErrForbidden
+	}
+	if s.shortcutRepo == nil {
+		return []documentDomain.FAQShortcut{}, nil
+	}
+	return s.shortcutRepo.List(ctx)
+}
+
+func (s *service) UpdateFAQShortcut(ctx context.Context, userCtx documentDomain.UserContext, shortcut *documentDomain.FAQShortcut) error {
+	if !userCtx.IsAdmin {
+		return ErrForbidden
+	}
+	if s.shortcutRepo == nil {
+		return ErrShortcutNotFound
+	}
+	if err := normalizeShortcut(shortcut); err != nil {
+		return err
+	}
+
+	existing, err := s.shortcutRepo.GetByID(ctx, shortcut.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrShortcutNotFound
+	}
+	shortcut.CreatedBy = existing.CreatedBy
+	shortcut.CreatedAt = existing.CreatedAt
+
+	if err := s.shortcutRepo.Update(ctx, shortcut); err != nil {
+		return err
+	}
+	s.events.Publish(ctx, events.KnowledgeChanged{Reason: "shortcut_updated", ActorID: userCtx.UserID})
+	return nil
+}
+
+func (s *service) DeleteFAQShortcut(ctx context.Context, userCtx documentDomain.UserContext, id string) error {
+	if !userCtx.IsAdmin {
+		return ErrForbidden
+	}
+	if s.shortcutRepo == nil {
+		return ErrShortcutNotFound
+	}
+
+	existing, err := s.shortcutRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrShortcutNotFound
+	}
+
+	if err := s.shortcutRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.events.Publish(ctx, events.KnowledgeChanged{Reason: "shortcut_deleted", ActorID: userCtx.UserID})
+	return nil
+}
+
+// normalizeShortcut checks shortcut and stores its keywords the way
+// questions are matched: lowercase words separated by single spaces.
+func normalizeShortcut(shortcut *documentDomain.FAQShortcut) error {
+	shortcut.Name = strings.TrimSpace(shortcut.Name)
+	shortcut.Answer = strings.TrimSpace(shortcut.Answer)
+	if shortcut.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidShortcut)
+	}
+	if shortcut.Answer == "" {
+		return fmt.Errorf("%w: answer is required", ErrInvalidShortcut)
+	}
+
+	keywords := make([]string, 0, len(shortcut.Keywords))
+	for _, k := range shortcut.Keywords {
+		k = strings.Join(questionWords(k), " ")
+		if k != "" && !containsString(keywords, k) {
+			keywords = append(keywords, k)
+		}
+	}
+	if len(keywords) == 0 {
+		return fmt.Errorf("%w: at least one keyword is required", ErrInvalidShortcut)
+	}
+	shortcut.Keywords = keywords
+	return nil
+}
+
+// questionWords splits text into lowercase words, dropping punctuation.
+func questionWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// shortcutMatch returns the length in words of the longest of shortcut's
+// keywords found as whole words in question, or 0 when none is.
+func shortcutMatch(shortcut documentDomain.FAQShortcut, question string) int {
+	padded := " " + question + " "
+	best := 0
+	for _, k := range shortcut.Keywords {
+		if n := strings.Count(k, " ") + 1; n > best && strings.Contains(padded, " "+k+" ") {
+			best = n
+		}
+	}
+	return best
+}
+
+// matchShortcut returns the active shortcut that answers query: the one
+// with the highest priority among those matching it, ties going to the
+// longer keyword. Keywords are matched against expanded, query with its
+// glossary synonyms, while the length limit applies to query as asked.
+// Failing to load shortcuts leaves the query to retrieval.
+func (s *service) matchShortcut(ctx context.Context, query, expanded string) *documentDomain.FAQShortcut {
+	if s.shortcutRepo == nil {
+		return nil
+	}
+	question := questionWords(query)
+	if len(question) == 0 || len(question) > shortcutMaxWords {
+		return nil
+	}
+
+	shortcuts, err := s.shortcutRepo.ListActive(ctx)
+	if err != nil {
+		s.log.WarnContext(ctx, "failed to load faq shortcuts", "error", err)
+		return nil
+	}
+
+	joined := glossaryKey(expanded)
+	var best *documentDomain.FAQShortcut
+	bestLen := 0
+	for i, shortcut := range shortcuts {
+		n := shortcutMatch(shortcut, joined)
+		if n == 0 {
+			continue
+		}
+		if best == nil || shortcut.Priority > best.Priority || (shortcut.Priority == best.Priority && n > bestLen) {
+			best, bestLen = &shortcuts[i], n
+		}
+	}
+	return best
+}
+
+// shortcutResponse answers with shortcut's canned answer. It is written by
+// an admin, so it is treated as fully confident and grounded.
+func shortcutResponse(shortcut *documentDomain.FAQShortcut, start time.Time) *documentDomain.RAGResponse {
+	return &documentDomain.RAGResponse{
+		Answer:           shortcut.Answer,
+		RelevantChunks:   []documentDomain.Chunk{},
+		ConfidenceScore:  1,
+		Groundedness:     1,
+		Shortcut:         shortcut.ID,
+		ProcessingTimeMs: time.Since(start).Milliseconds(),
+	}
+}
@@ -0,0 +1,138 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+)
+
+type mockShortcutRepo struct {
+	shortcuts map[string]*documentDomain.FAQShortcut
+}
+
+func newMockShortcutRepo() *mockShortcutRepo {
+	return &mockShortcutRepo{shortcuts: make(map[string]*documentDomain.FAQShortcut)}
+}
+
+func (m *mockShortcutRepo) Create(ctx context.Context, shortcut *documentDomain.FAQShortcut) (string, error) {
+	if shortcut.ID == "" {
+		shortcut.ID = "shortcut_" + shortcut.Name
+	}
+	m.shortcuts[shortcut.ID] = shortcut
+	return shortcut.ID, nil
+}
+
+func (m *mockShortcutRepo) GetByID(ctx context.Context, id string) (*documentDomain.FAQShortcut, error) {
+	return m.shortcuts[id], nil
+}
+
+func (m *mockShortcutRepo) List(ctx context.Context) ([]documentDomain.FAQShortcut, error) {
+	shortcuts := make([]documentDomain.FAQShortcut, 0, len(m.shortcuts))
+	for _, s := range m.shortcuts {
+		shortcuts = append(shortcuts, *s)
+	}
+	return shortcuts, nil
+}
+
+func (m *mockShortcutRepo) ListActive(ctx context.Context) ([]documentDomain.FAQShortcut, error) {
+	shortcuts := make([]documentDomain.FAQShortcut, 0, len(m.shortcuts))
+	for _, s := range m.shortcuts {
+		if s.IsActive {
+			shortcuts = append(shortcuts, *s)
+		}
+	}
+	return shortcuts, nil
+}
+
+func (m *mockShortcutRepo) Update(ctx context.Context, shortcut *documentDomain.FAQShortcut) error {
+	m.shortcuts[shortcut.ID] = shortcut
+	return nil
+}
+
+func (m *mockShortcutRepo) Delete(ctx context.Context, id string) error {
+	delete(m.shortcuts, id)
+	return nil
+}
+
+func TestFAQShortcutCRUD(t *testing.T) {
+	repo := newMockShortcutRepo()
+	svc := NewService(ServiceConfig{Repo: newMockDocumentRepo(), ShortcutRepo: repo})
+	ctx := context.Background()
+
+	shortcut := &documentDomain.FAQShortcut{Name: "hours", Keywords: []string{" Opening Hours ", "hours", "HOURS?"}, Answer: "We open 9 to 5."}
+	if _, err := svc.CreateFAQShortcut(ctx, documentDomain.UserContext{UserID: "u"}, shortcut); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden, got %v", err)
+	}
+	if _, err := svc.CreateFAQShortcut(ctx, adminCtx, &documentDomain.FAQShortcut{Name: "empty", Keywords: []string{"?!"}, Answer: "x"}); !errors.Is(err, ErrInvalidShortcut) {
+		t.Errorf("Expected ErrInvalidShortcut without keywords, got %v", err)
+	}
+
+	id, err := svc.CreateFAQShortcut(ctx, adminCtx, shortcut)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	saved := repo.shortcuts[id]
+	if !saved.IsActive || len(saved.Keywords) != 2 || saved.Keywords[0] != "opening hours" || saved.Keywords[1] != "hours" {
+		t.Errorf("Expected an active shortcut with normalized keywords, got %+v", saved)
+	}
+
+	update := &documentDomain.FAQShortcut{ID: id, Name: "hours", Keywords: []string{"hours"}, Answer: "We open 8 to 6."}
+	if err := svc.UpdateFAQShortcut(ctx, adminCtx, update); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if repo.shortcuts[id].Answer != "We open 8 to 6." || repo.shortcuts[id].CreatedBy != adminCtx.UserID {
+		t.Errorf("Expected the answer updated and the author kept, got %+v", repo.shortcuts[id])
+	}
+	if err := svc.UpdateFAQShortcut(ctx, adminCtx, &documentDomain.FAQShortcut{ID: "missing", Name: "x", Keywords: []string{"x"}, Answer: "x"}); !errors.Is(err, ErrShortcutNotFound) {
+		t.Errorf("Expected ErrShortcutNotFound, got %v", err)
+	}
+
+	if err := svc.DeleteFAQShortcut(ctx, adminCtx, id); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := svc.DeleteFAQShortcut(ctx, adminCtx, id); !errors.Is(err, ErrShortcutNotFound) {
+		t.Errorf("Expected ErrShortcutNotFound, got %v", err)
+	}
+}
+
+func TestQueryRAGShortcut(t *testing.T) {
+	repo := newMockShortcutRepo()
+	repo.shortcuts["hours"] = &documentDomain.FAQShortcut{ID: "hours", Keywords: []string{"hours"}, Answer: "We open 9 to 5.", IsActive: true}
+	repo.shortcuts["holiday"] = &documentDomain.FAQShortcut{ID: "holiday", Keywords: []string{"holiday hours"}, Answer: "Closed on holidays.", IsActive: true}
+	repo.shortcuts["menu"] = &documentDomain.FAQShortcut{ID: "menu", Keywords: []string{"menu"}, Answer: "See the menu.", IsActive: false}
+	// Without OpenAI every query that is not a shortcut gets the
+	// not-configured answer.
+	svc := NewService(ServiceConfig{Repo: newMockDocumentRepo(), ShortcutRepo: repo})
+
+	tests := []struct {
+		query    string
+		shortcut string
+	}{
+		{"What are your hours?", "hours"},
+		{"HOURS", "hours"},
+		{"Holiday hours, please", "holiday"},
+		{"How many work-hours does shipping take in practice for orders placed late on a weekend?", ""},
+		{"Do you have a menu?", ""},
+		{"What are your opening times", ""},
+		{"Show me the hourly rates", ""},
+	}
+	for _, tt := range tests {
+		resp, err := svc.QueryRAG(context.Background(), documentDomain.RAGQuery{Query: tt.query})
+		if err != nil {
+			t.Fatalf("%q: expected no error, got %v", tt.query, err)
+		}
+		if resp.Shortcut != tt.shortcut {
+			t.Errorf("%q: expected shortcut %q, got %q", tt.query, tt.shortcut, resp.Shortcut)
+		}
+		if tt.shortcut != "" && (resp.Answer != repo.shortcuts[tt.shortcut].Answer || resp.ConfidenceScore != 1) {
+			t.Errorf("%q: expected the canned answer, got %+v", tt.query, resp)
+		}
+	}
+
+	repo.shortcuts["hours"].Priority = 1
+	if resp, _ := svc.QueryRAG(context.Background(), documentDomain.RAGQuery{Query: "holiday hours"}); resp.Shortcut != "hours" {
+		t.Errorf("Expected the higher priority to win, got %q", resp.Shortcut)
+	}
+}
@@ -0,0 +1,92 @@
+package document
+
+import (
+	"context"
+	"sort"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/vectormath"
+)
+
+const (
+	defaultSimilarDocuments = 5
+	maxSimilarDocuments     = 20
+)
+
+// SimilarDocuments ranks the documents sharing id's embedding space by the
+// cosine similarity of their chunk vector centroids to id's. Content is left
+// out of the results, which are meant for suggestions.
+func (s *service) SimilarDocuments(ctx context.Context, userCtx documentDomain.UserContext, id string, limit int) ([]documentDomain.SimilarDocument, error) {
+	if limit <= 0 {
+		limit = defaultSimilarDocuments
+	}
+	if limit > maxSimilarDocuments {
+		limit = maxSimilarDocuments
+	}
+
+	doc, err := s.GetDocument(ctx, userCtx, id)
+	if err != nil {
+		return nil, err
+	}
+	similar := []documentDomain.SimilarDocument{}
+	if s.chunkRepo == nil {
+		return similar, nil
+	}
+
+	space, err := s.embeddingSpace(ctx, doc.Collection)
+	if err != nil {
+		return nil, err
+	}
+	chunks, err := s.chunkRepo.ListVectors(ctx, space)
+	if err != nil {
+		return nil, err
+	}
+
+	byDocument := make(map[string][][]float64)
+	for _, chunk := range chunks {
+		byDocument[chunk.DocumentID] = append(byDocument[chunk.DocumentID], chunk.Embedding)
+	}
+	target := vectormath.Centroid(byDocument[doc.ID])
+	if target == nil {
+		return similar, nil
+	}
+
+	ids := make([]string, 0, len(byDocument))
+	centroids := make([][]float64, 0, len(byDocument))
+	for docID, vectors := range byDocument {
+		if docID == doc.ID {
+			continue
+		}
+		ids = append(ids, docID)
+		centroids = append(centroids, vectormath.Centroid(vectors))
+	}
+	ranked := vectormath.TopKBySimilarity(target, centroids, len(centroids), -1)
+	// Equal scores keep a stable order across requests.
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ids[ranked[i].Index] < ids[ranked[j].Index]
+	})
+
+	for _, scored := range ranked {
+		if len(similar) == limit {
+			break
+		}
+		candidate, err := s.repo.GetByID(ctx, ids[scored.Index])
+		if err != nil {
+			return nil, err
+		}
+		if candidate == nil || !candidate.IsActive || (!userCtx.IsAdmin && candidate.UserID != userCtx.UserID) {
+			continue
+		}
+		summary := *candidate
+		summary.Content = ""
+		similar = append(similar, documentDomain.SimilarDocument{
+			Document:      summary,
+			Similarity:    scored.Score,
+			NearDuplicate: s.duplicateSimilarity > 0 && scored.Score >= s.duplicateSimilarity,
+		})
+	}
+	return similar, nil
+}
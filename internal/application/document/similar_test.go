@@ -0,0 +1,63 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+)
+
+func TestSimilarDocuments(t *testing.T) {
+	repo := newMockDocumentRepo()
+	chunkRepo := newMockChunkRepo()
+	for _, doc := range []*documentDomain.Document{
+		{ID: "refunds", Title: "Refund policy", UserID: "u1", Content: "Refunds take 5 days", IsActive: true},
+		{ID: "returns", Title: "Returns", UserID: "u1", Content: "Returns are free", IsActive: true},
+		{ID: "shipping", Title: "Shipping", UserID: "u1", IsActive: true},
+		{ID: "private", Title: "Someone else's", UserID: "u2", IsActive: true},
+		{ID: "archived", Title: "Old refunds", UserID: "u1", IsActive: false},
+	} {
+		repo.documents[doc.ID] = doc
+	}
+	chunkRepo.chunks = []documentDomain.Chunk{
+		{ID: "c1", DocumentID: "refunds", Embedding: []float64{1, 0, 0}},
+		{ID: "c2", DocumentID: "refunds", Embedding: []float64{1, 0.2, 0}},
+		{ID: "c3", DocumentID: "returns", Embedding: []float64{1, 0.1, 0}},
+		{ID: "c4", DocumentID: "shipping", Embedding: []float64{0.3, 0, 1}},
+		{ID: "c5", DocumentID: "private", Embedding: []float64{1, 0.1, 0.01}},
+		{ID: "c6", DocumentID: "archived", Embedding: []float64{1, 0.1, 0}},
+	}
+	svc := NewService(ServiceConfig{Repo: repo, ChunkRepo: chunkRepo, DuplicateSimilarity: 0.99})
+	ctx := context.Background()
+	user := documentDomain.UserContext{UserID: "u1"}
+
+	similar, err := svc.SimilarDocuments(ctx, user, "refunds", 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(similar) != 2 || similar[0].Document.ID != "returns" || similar[1].Document.ID != "shipping" {
+		t.Fatalf("Expected returns then shipping, got %+v", similar)
+	}
+	if !similar[0].NearDuplicate || similar[1].NearDuplicate {
+		t.Errorf("Expected only returns to be a near-duplicate, got %+v", similar)
+	}
+	if similar[0].Document.Content != "" {
+		t.Error("Expected content to be left out")
+	}
+	if repo.documents["returns"].Content == "" {
+		t.Error("Expected the stored document to keep its content")
+	}
+
+	similar, _ = svc.SimilarDocuments(ctx, documentDomain.UserContext{UserID: "admin", IsAdmin: true}, "refunds", 1)
+	if len(similar) != 1 || (similar[0].Document.ID != "returns" && similar[0].Document.ID != "private") {
+		t.Errorf("Expected the closest document for an admin, got %+v", similar)
+	}
+
+	if _, err := svc.SimilarDocuments(ctx, user, "private", 5); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden, got %v", err)
+	}
+	if _, err := svc.SimilarDocuments(ctx, user, "missing", 5); !errors.Is(err, ErrDocumentNotFound) {
+		t.Errorf("Expected ErrDocumentNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+package document
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/elprogramadorgt/lucidRAG/pkg/openai"
+)
+
+const (
+	spendDayLayout = "2006-01-02"
+	spendTimeout   = 5 * time.Second
+	// defaultAnswerTokens is the reply length assumed when estimating a
+	// request whose completion is not capped.
+	defaultAnswerTokens = 500
+)
+
+// DefaultModelPrices are OpenAI's list prices, used for models the
+// configuration does not price. Models served by Ollama are free.
+var DefaultModelPrices = map[string]documentDomain.ModelPrice{
+	"gpt-3.5-turbo":          {Input: 0.5, Output: 1.5},
+	"gpt-4o":                 {Input: 2.5, Output: 10},
+	"gpt-4o-mini":            {Input: 0.15, Output: 0.6},
+	"gpt-4-turbo":            {Input: 10, Output: 30},
+	"text-embedding-3-small": {Input: 0.02},
+	"text-embedding-3-large": {Input: 0.13},
+	"text-embedding-ada-002": {Input: 0.1},
+}
+
+type SpendConfig struct {
+	Repo documentDomain.SpendRepository
+	// Prices are added to DefaultModelPrices, replacing the defaults for
+	// the models they name.
+	Prices map[string]documentDomain.ModelPrice
+	// DailyCapUSD, when above zero, limits answers to free models once a
+	// UTC day's spend reaches it.
+	DailyCapUSD float64
+	// MaxRequestUSD, when above zero, shrinks the context of answers whose
+	// estimated cost exceeds it.
+	MaxRequestUSD float64
+	// Events receives SpendCapReached the first time each day's cap is
+	// reached.
+	Events *events.Bus
+	Log    *logger.Logger
+}
+
+// SpendTracker prices the tokens the OpenAI client reports and keeps the
+// daily total, so queries can be held to the configured limits.
+type SpendTracker struct {
+	repo          documentDomain.SpendRepository
+	prices        map[string]documentDomain.ModelPrice
+	dailyCapUSD   float64
+	maxRequestUSD float64
+	events        *events.Bus
+	log           *logger.Logger
+
+	mu sync.Mutex
+	// cappedDay is the last day known to have reached the cap, which
+	// saves a lookup on every query for the rest of that day.
+	cappedDay string
+	// unpriced holds the models already warned about.
+	unpriced map[string]bool
+}
+
+func NewSpendTracker(cfg SpendConfig) *SpendTracker {
+	prices := make(map[string]documentDomain.ModelPrice, len(DefaultModelPrices)+len(cfg.Prices))
+	for model, price := range DefaultModelPrices {
+		prices[model] = price
+	}
+	for model, price := range cfg.Prices {
+		prices[model] = price
+	}
+
+	bus := cfg.Events
+	if bus == nil {
+		bus = events.NewBus()
+	}
+
+	return &SpendTracker{
+		repo:          cfg.Repo,
+		prices:        prices,
+		dailyCapUSD:   cfg.DailyCapUSD,
+		maxRequestUSD: cfg.MaxRequestUSD,
+		events:        bus,
+		log:           cfg.Log,
+		unpriced:      make(map[string]bool),
+	}
+}
+
+// Price returns model's price. Dated snapshots such as
+// "gpt-4o-2024-08-06" use the price of the longest model name they start
+// with.
+func (t *SpendTracker) Price(model string) (documentDomain.ModelPrice, bool) {
+	if strings.HasPrefix(model, "ollama:") {
+		return documentDomain.ModelPrice{}, true
+	}
+	if price, ok := t.prices[model]; ok {
+		return price, true
+	}
+
+	var best string
+	for name := range t.prices {
+		if len(name) > len(best) && strings.HasPrefix(model, name+"-") {
+			best = name
+		}
+	}
+	if best == "" {
+		return documentDomain.ModelPrice{}, false
+	}
+	return t.prices[best], true
+}
+
+// Cost is the price in USD of a call to model with the given token counts.
+// Unpriced models cost nothing.
+func (t *SpendTracker) Cost(model string, promptTokens, completionTokens int) float64 {
+	price, _ := t.Price(model)
+	return (float64(promptTokens)*price.Input + float64(completionTokens)*price.Output) / 1e6
+}
+
+// Record adds a call's usage to today's spend. It has the shape of an
+// openai.UsageHook, so the OpenAI client can report every call to it.
+func (t *SpendTracker) Record(ctx context.Context, model string, usage openai.Usage) {
+	if usage.PromptTokens == 0 && usage.CompletionTokens == 0 {
+		return
+	}
+	if _, ok := t.Price(model); !ok {
+		t.warnUnpriced(model)
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), spendTimeout)
+	defer cancel()
+
+	now := time.Now().UTC()
+	day := now.Format(spendDayLayout)
+	spend, err := t.repo.Add(ctx, day, usage.PromptTokens, usage.CompletionTokens, t.Cost(model, usage.PromptTokens, usage.CompletionTokens))
+	if err != nil {
+		t.log.ErrorContext(ctx, "failed to record model spend", "error", err, "model", model)
+		return
+	}
+	if t.dailyCapUSD <= 0 || spend.CostUSD < t.dailyCapUSD {
+		return
+	}
+
+	t.markCapped(day)
+	// Only the call that crosses the cap alerts.
+	first, err := t.repo.MarkCapReached(ctx, day, now)
+	if err != nil {
+		t.log.ErrorContext(ctx, "failed to mark daily spend cap", "error", err, "day", day)
+		return
+	}
+	if first {
+		t.log.WarnContext(ctx, "daily model spend cap reached", "day", day, "spent_usd", spend.CostUSD, "cap_usd", t.dailyCapUSD)
+		t.events.Publish(ctx, events.SpendCapReached{Day: day, SpentUSD: spend.CostUSD, CapUSD: t.dailyCapUSD})
+	}
+}
+
+// overDailyCap reports whether today's spend has reached the daily cap. It
+// reports false when the spend cannot be read, so an outage of the store
+// does not take answers down with it.
+func (t *SpendTracker) overDailyCap(ctx context.Context) bool {
+	if t.dailyCapUSD <= 0 {
+		return false
+	}
+	day := time.Now().UTC().Format(spendDayLayout)
+	t.mu.Lock()
+	capped := t.cappedDay == day
+	t.mu.Unlock()
+	if capped {
+		return true
+	}
+
+	spend, err := t.repo.Get(ctx, day)
+	if err != nil {
+		t.log.WarnContext(ctx, "failed to read model spend", "error", err)
+		return false
+	}
+	if spend == nil || spend.CostUSD < t.dailyCapUSD {
+		return false
+	}
+	t.markCapped(day)
+	return true
+}
+
+// freeModels returns the models that cost nothing to call, in order.
+func (t *SpendTracker) freeModels(models []ChatModel) []ChatModel {
+	var free []ChatModel
+	for _, model := range models {
+		if price, ok := t.Price(model.Name); ok && price.Input == 0 && price.Output == 0 {
+			free = append(free, model)
+		}
+	}
+	return free
+}
+
+// contextBudget returns how many context tokens an answer from model can
+// use without its estimated cost exceeding the per-request limit, given
+// the tokens of the rest of the prompt and of the reply. It returns
+// maxTokens, and false, when the limit does not shrink the context.
+func (t *SpendTracker) contextBudget(model string, promptTokens, answerTokens, maxTokens int) (int, bool) {
+	price, ok := t.Price(model)
+	if t.maxRequestUSD <= 0 || !ok || price.Input == 0 {
+		return maxTokens, false
+	}
+	if answerTokens <= 0 {
+		answerTokens = defaultAnswerTokens
+	}
+
+	fixed := t.Cost(model, promptTokens, answerTokens)
+	budget := int((t.maxRequestUSD - fixed) * 1e6 / price.Input)
+	if budget >= maxTokens {
+		return maxTokens, false
+	}
+	// The answer still needs some context, even over the limit.
+	return max(budget, minTruncatedTokens), true
+}
+
+func (t *SpendTracker) markCapped(day string) {
+	t.mu.Lock()
+	t.cappedDay = day
+	t.mu.Unlock()
+}
+
+func (t *SpendTracker) warnUnpriced(model string) {
+	t.mu.Lock()
+	warned := t.unpriced[model]
+	t.unpriced[model] = true
+	t.mu.Unlock()
+	if !warned {
+		t.log.Warn("model has no price, its spend is not counted", "model", model)
+	}
+}
+
+// promptTokens estimates the tokens of messages.
+func promptTokens(messages []openai.ChatMessage) int {
+	var n int
+	for _, msg := range messages {
+		n += estimateTokens(msg.Content)
+	}
+	return n
+}
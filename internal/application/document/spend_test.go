@@ -0,0 +1,227 @@
+package document
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/elprogramadorgt/lucidRAG/pkg/openai"
+)
+
+type mockSpendRepo struct {
+	mu   sync.Mutex
+	days map[string]*documentDomain.DailySpend
+}
+
+func newMockSpendRepo() *mockSpendRepo {
+	return &mockSpendRepo{days: make(map[string]*documentDomain.DailySpend)}
+}
+
+func (m *mockSpendRepo) Add(ctx context.Context, day string, promptTokens, completionTokens int, costUSD float64) (*documentDomain.DailySpend, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	spend, ok := m.days[day]
+	if !ok {
+		spend = &documentDomain.DailySpend{Day: day}
+		m.days[day] = spend
+	}
+	spend.PromptTokens += int64(promptTokens)
+	spend.CompletionTokens += int64(completionTokens)
+	spend.CostUSD += costUSD
+	copied := *spend
+	return &copied, nil
+}
+
+func (m *mockSpendRepo) Get(ctx context.Context, day string) (*documentDomain.DailySpend, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if spend, ok := m.days[day]; ok {
+		copied := *spend
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (m *mockSpendRepo) MarkCapReached(ctx context.Context, day string, at time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	spend, ok := m.days[day]
+	if !ok || spend.CapReachedAt != nil {
+		return false, nil
+	}
+	spend.CapReachedAt = &at
+	return true, nil
+}
+
+func newTestSpendTracker(repo documentDomain.SpendRepository, cfg SpendConfig) *SpendTracker {
+	cfg.Repo = repo
+	cfg.Log = logger.New(logger.Options{Level: "error"})
+	return NewSpendTracker(cfg)
+}
+
+func TestSpendTrackerPrice(t *testing.T) {
+	tracker := newTestSpendTracker(newMockSpendRepo(), SpendConfig{
+		Prices: map[string]documentDomain.ModelPrice{"gpt-4o": {Input: 5, Output: 15}},
+	})
+
+	tests := []struct {
+		model string
+		want  documentDomain.ModelPrice
+		known bool
+	}{
+		{"gpt-4o", documentDomain.ModelPrice{Input: 5, Output: 15}, true},
+		{"gpt-4o-2024-08-06", documentDomain.ModelPrice{Input: 5, Output: 15}, true},
+		{"gpt-4o-mini-2024-07-18", documentDomain.ModelPrice{Input: 0.15, Output: 0.6}, true},
+		{"ollama:llama3", documentDomain.ModelPrice{}, true},
+		{"gpt-4oo", documentDomain.ModelPrice{}, false},
+	}
+	for _, tt := range tests {
+		got, known := tracker.Price(tt.model)
+		if got != tt.want || known != tt.known {
+			t.Errorf("Price(%q) = %+v, %v; expected %+v, %v", tt.model, got, known, tt.want, tt.known)
+		}
+	}
+
+	if got := tracker.Cost("gpt-4o", 1000, 100); got != 0.0065 {
+		t.Errorf("Expected 1000 prompt and 100 completion tokens to cost 0.0065, got %v", got)
+	}
+}
+
+func TestSpendTrackerRecordAlertsOnce(t *testing.T) {
+	bus := events.NewBus()
+	var alerts []events.SpendCapReached
+	bus.Subscribe(func(ctx context.Context, event events.Event) {
+		alerts = append(alerts, event.(events.SpendCapReached))
+	}, events.NameSpendCapReached)
+
+	repo := newMockSpendRepo()
+	tracker := newTestSpendTracker(repo, SpendConfig{DailyCapUSD: 1, Events: bus})
+	ctx := context.Background()
+
+	// 200k prompt tokens of gpt-4o cost 0.50.
+	usage := openai.Usage{PromptTokens: 200_000}
+	tracker.Record(ctx, "gpt-4o", usage)
+	if tracker.overDailyCap(ctx) || len(alerts) != 0 {
+		t.Fatalf("Expected no alert below the cap, got %+v", alerts)
+	}
+	tracker.Record(ctx, "gpt-4o", usage)
+	tracker.Record(ctx, "gpt-4o", usage)
+	if !tracker.overDailyCap(ctx) {
+		t.Error("Expected the cap reached")
+	}
+	if len(alerts) != 1 || alerts[0].CapUSD != 1 || alerts[0].SpentUSD != 1 {
+		t.Errorf("Expected one alert at 1.00 spent, got %+v", alerts)
+	}
+
+	day := time.Now().UTC().Format(spendDayLayout)
+	if spend := repo.days[day]; spend.PromptTokens != 600_000 || spend.CostUSD != 1.5 {
+		t.Errorf("Expected 600000 tokens costing 1.50 recorded, got %+v", spend)
+	}
+}
+
+func TestContextBudget(t *testing.T) {
+	tracker := newTestSpendTracker(newMockSpendRepo(), SpendConfig{MaxRequestUSD: 0.01})
+
+	// gpt-4o: 0.01 USD covers 4000 input tokens, less 500 answer tokens
+	// at four times the price and 200 prompt tokens.
+	if got, capped := tracker.contextBudget("gpt-4o", 200, 0, 3000); !capped || got != 1800 {
+		t.Errorf("Expected gpt-4o capped to 1800 tokens, got %d, %v", got, capped)
+	}
+	if got, capped := tracker.contextBudget("gpt-4o-mini", 200, 0, 3000); capped || got != 3000 {
+		t.Errorf("Expected gpt-4o-mini within the limit, got %d, %v", got, capped)
+	}
+	if got, capped := tracker.contextBudget("gpt-4-turbo", 200, 0, 3000); !capped || got != minTruncatedTokens {
+		t.Errorf("Expected gpt-4-turbo to keep %d tokens, got %d, %v", minTruncatedTokens, got, capped)
+	}
+	if got, capped := tracker.contextBudget("ollama:llama3", 200, 0, 3000); capped || got != 3000 {
+		t.Errorf("Expected a free model uncapped, got %d, %v", got, capped)
+	}
+}
+
+func spendServer(t *testing.T, chats *[]string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/embeddings") {
+			_, _ = w.Write([]byte(`{"data":[{"embedding":[0,0,1]}],"usage":{"prompt_tokens":5}}`))
+			return
+		}
+		*chats = append(*chats, r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"We open at 9."}}],"usage":{"prompt_tokens":50,"completion_tokens":5}}`))
+	}))
+}
+
+func TestQueryRAGOverDailyCap(t *testing.T) {
+	var chats []string
+	server := spendServer(t, &chats)
+	defer server.Close()
+
+	repo := newMockSpendRepo()
+	repo.days[time.Now().UTC().Format(spendDayLayout)] = &documentDomain.DailySpend{CostUSD: 12}
+	tracker := newTestSpendTracker(repo, SpendConfig{DailyCapUSD: 10})
+	chunkRepo := newMockChunkRepo()
+	chunkRepo.chunks = []documentDomain.Chunk{{ID: "c1", DocumentID: "d1", Content: "We open at 9"}}
+	cfg := ServiceConfig{
+		Repo:         newMockDocumentRepo(),
+		ChunkRepo:    chunkRepo,
+		OpenAIClient: openai.NewClient("test-key", openai.WithBaseURL(server.URL), openai.WithUsageHook(tracker.Record)),
+		Spend:        tracker,
+	}
+
+	resp, err := NewService(cfg).QueryRAG(context.Background(), documentDomain.RAGQuery{Query: "when do you open?"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.Answer != unavailableAnswer || !slices.Contains(resp.Guardrails, "daily_spend_cap") || len(chats) != 0 {
+		t.Errorf("Expected the unavailable answer without calling OpenAI, got %+v after %d chats", resp, len(chats))
+	}
+
+	// A free fallback still answers.
+	cfg.FallbackModels = []ChatModel{{Name: "ollama:llama3", Model: "llama3", Client: openai.NewClient("", openai.WithBaseURL(server.URL))}}
+	resp, err = NewService(cfg).QueryRAG(context.Background(), documentDomain.RAGQuery{Query: "when do you open?"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.Model != "ollama:llama3" || !slices.Contains(resp.Guardrails, "daily_spend_cap") || len(chats) != 1 || chats[0] == "Bearer test-key" {
+		t.Errorf("Expected the Ollama fallback to answer, got %+v after chats %q", resp, chats)
+	}
+}
+
+func TestQueryRAGCapsContextCost(t *testing.T) {
+	var chats []string
+	server := spendServer(t, &chats)
+	defer server.Close()
+
+	tracker := newTestSpendTracker(newMockSpendRepo(), SpendConfig{MaxRequestUSD: 0.001})
+	chunkRepo := newMockChunkRepo()
+	chunkRepo.chunks = []documentDomain.Chunk{
+		{ID: "c1", DocumentID: "d1", Content: strings.Repeat("opening hours ", 200)},
+		{ID: "c2", DocumentID: "d2", Content: strings.Repeat("shipping times ", 200)},
+	}
+	svc := NewService(ServiceConfig{
+		Repo:         newMockDocumentRepo(),
+		ChunkRepo:    chunkRepo,
+		OpenAIClient: openai.NewClient("test-key", openai.WithBaseURL(server.URL)),
+		Spend:        tracker,
+	})
+
+	resp, err := svc.QueryRAG(context.Background(), documentDomain.RAGQuery{Query: "when do you open?"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !slices.Contains(resp.Guardrails, "cost_capped_context") {
+		t.Errorf("Expected the cost_capped_context guardrail, got %v", resp.Guardrails)
+	}
+	// gpt-3.5-turbo: 0.001 USD leaves room for well under both chunks.
+	if len(resp.RelevantChunks) != 1 || !strings.HasSuffix(resp.RelevantChunks[0].Content, "...") {
+		t.Errorf("Expected one truncated chunk in context, got %d chunks", len(resp.RelevantChunks))
+	}
+}
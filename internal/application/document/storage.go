@@ -0,0 +1,115 @@
+package document
+
+import (
+	"context"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+)
+
+// embeddingDimensionBytes is the size of one embedding dimension as stored.
+const embeddingDimensionBytes = 8
+
+func chunkUsage(chunks ...documentDomain.Chunk) documentDomain.StorageUsage {
+	var usage documentDomain.StorageUsage
+	for _, c := range chunks {
+		usage.Chunks++
+		usage.ChunkBytes += int64(len(c.Content))
+		usage.EmbeddingBytes += int64(len(c.Embedding) * embeddingDimensionBytes)
+	}
+	return usage
+}
+
+// recordStorage applies delta to the running totals. Accounting is best
+// effort: a failure is reported but never fails the operation that caused it.
+func (s *service) recordStorage(ctx context.Context, userID, documentID string, delta documentDomain.StorageUsage) {
+	if s.storageRepo == nil || delta == (documentDomain.StorageUsage{}) {
+		return
+	}
+	if err := s.storageRepo.Add(ctx, userID, documentID, delta); err != nil {
+		s.log.WarnContext(ctx, "failed to record storage", "error", err, "document_id", documentID)
+	}
+}
+
+// releaseChunkStorage removes the document's chunks from the totals.
+func (s *service) releaseChunkStorage(ctx context.Context, userID, documentID string) {
+	if s.storageRepo == nil {
+		return
+	}
+	usage, err := s.storageRepo.GetDocument(ctx, documentID)
+	if err != nil {
+		s.log.WarnContext(ctx, "failed to load storage", "error", err, "document_id", documentID)
+		return
+	}
+	if usage == nil {
+		return
+	}
+	s.recordStorage(ctx, userID, documentID, documentDomain.StorageUsage{
+		Chunks:         usage.Chunks,
+		ChunkBytes:     usage.ChunkBytes,
+		EmbeddingBytes: usage.EmbeddingBytes,
+	}.Negate())
+}
+
+// releaseDocumentStorage removes the document and its chunks from the totals.
+func (s *service) releaseDocumentStorage(ctx context.Context, userID, documentID string) {
+	if s.storageRepo == nil {
+		return
+	}
+	usage, err := s.storageRepo.GetDocument(ctx, documentID)
+	if err != nil {
+		s.log.WarnContext(ctx, "failed to load storage", "error", err, "document_id", documentID)
+		return
+	}
+	if usage != nil {
+		s.recordStorage(ctx, userID, documentID, usage.Negate())
+	}
+	if err := s.storageRepo.DeleteDocument(ctx, documentID); err != nil {
+		s.log.WarnContext(ctx, "failed to delete storage", "error", err, "document_id", documentID)
+	}
+}
+
+func (s *service) GetStorageUsage(ctx context.Context, userCtx documentDomain.UserContext) (*documentDomain.UserStorage, error) {
+	if s.storageRepo == nil {
+		return &documentDomain.UserStorage{UserID: userCtx.UserID}, nil
+	}
+
+	usage, err := s.storageRepo.GetUser(ctx, userCtx.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if usage == nil {
+		return &documentDomain.UserStorage{UserID: userCtx.UserID}, nil
+	}
+	return usage, nil
+}
+
+func (s *service) GetStorageSummary(ctx context.Context, userCtx documentDomain.UserContext) (*documentDomain.StorageSummary, error) {
+	if !userCtx.IsAdmin {
+		return nil, ErrForbidden
+	}
+
+	summary := &documentDomain.StorageSummary{Users: []documentDomain.UserStorage{}}
+	if s.storageRepo == nil {
+		return summary, nil
+	}
+
+	users, err := s.storageRepo.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	summary.Users = users
+	for _, u := range users {
+		summary.Total = summary.Total.Add(u.StorageUsage)
+	}
+	return summary, nil
+}
+
+func (s *service) RebuildStorage(ctx context.Context, userCtx documentDomain.UserContext) error {
+	if !userCtx.IsAdmin {
+		return ErrForbidden
+	}
+	if s.storageRepo == nil {
+		return nil
+	}
+	return s.storageRepo.Rebuild(ctx)
+}
@@ -0,0 +1,156 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+)
+
+// mockStorageRepo keeps storage totals in memory.
+type mockStorageRepo struct {
+	documents map[string]documentDomain.StorageUsage
+	users     map[string]documentDomain.StorageUsage
+}
+
+func newMockStorageRepo() *mockStorageRepo {
+	return &mockStorageRepo{
+		documents: make(map[string]documentDomain.StorageUsage),
+		users:     make(map[string]documentDomain.StorageUsage),
+	}
+}
+
+func (m *mockStorageRepo) Add(ctx context.Context, userID, documentID string, delta documentDomain.StorageUsage) error {
+	m.documents[documentID] = m.documents[documentID].Add(delta)
+	m.users[userID] = m.users[userID].Add(delta)
+	return nil
+}
+
+func (m *mockStorageRepo) GetDocument(ctx context.Context, documentID string) (*documentDomain.StorageUsage, error) {
+	usage, ok := m.documents[documentID]
+	if !ok {
+		return nil, nil
+	}
+	return &usage, nil
+}
+
+func (m *mockStorageRepo) DeleteDocument(ctx context.Context, documentID string) error {
+	delete(m.documents, documentID)
+	return nil
+}
+
+func (m *mockStorageRepo) GetUser(ctx context.Context, userID string) (*documentDomain.UserStorage, error) {
+	usage, ok := m.users[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &documentDomain.UserStorage{UserID: userID, StorageUsage: usage}, nil
+}
+
+func (m *mockStorageRepo) ListUsers(ctx context.Context) ([]documentDomain.UserStorage, error) {
+	users := []documentDomain.UserStorage{}
+	for id, usage := range m.users {
+		users = append(users, documentDomain.UserStorage{UserID: id, StorageUsage: usage})
+	}
+	return users, nil
+}
+
+func (m *mockStorageRepo) Rebuild(ctx context.Context) error {
+	return nil
+}
+
+func TestStorageAccountingFollowsDocument(t *testing.T) {
+	repo := newMockDocumentRepo()
+	storage := newMockStorageRepo()
+	svc := NewService(ServiceConfig{Repo: repo, ChunkRepo: newMockChunkRepo(), StorageRepo: storage})
+
+	ctx := context.Background()
+	userCtx := documentDomain.UserContext{UserID: "user-1"}
+
+	id, err := svc.CreateDocument(ctx, userCtx, &documentDomain.Document{Title: "guide", Content: "hello"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := storage.users["user-1"]; got.Documents != 1 || got.DocumentBytes != 5 {
+		t.Errorf("Expected 1 document of 5 bytes, got %+v", got)
+	}
+
+	if err := svc.UpdateDocument(ctx, userCtx, &documentDomain.Document{ID: id, Title: "guide", Content: "hello world"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := storage.users["user-1"]; got.Documents != 1 || got.DocumentBytes != 11 {
+		t.Errorf("Expected 1 document of 11 bytes, got %+v", got)
+	}
+
+	usage, err := svc.GetStorageUsage(ctx, userCtx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if usage.UserID != "user-1" || usage.DocumentBytes != 11 {
+		t.Errorf("Unexpected usage %+v", usage)
+	}
+
+	if err := svc.DeleteDocument(ctx, userCtx, id); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := storage.users["user-1"]; got != (documentDomain.StorageUsage{}) {
+		t.Errorf("Expected usage to return to zero, got %+v", got)
+	}
+	if _, ok := storage.documents[id]; ok {
+		t.Error("Expected document storage record to be removed")
+	}
+}
+
+func TestStorageAccountingDeleteChunk(t *testing.T) {
+	repo := newMockDocumentRepo()
+	repo.documents["doc-1"] = &documentDomain.Document{ID: "doc-1", UserID: "user-1"}
+	chunkRepo := newMockChunkRepo()
+	chunk := documentDomain.Chunk{ID: "c1", DocumentID: "doc-1", Content: "abcd", Embedding: []float64{0.1, 0.2}}
+	chunkRepo.chunks = []documentDomain.Chunk{chunk}
+	storage := newMockStorageRepo()
+	_ = storage.Add(context.Background(), "user-1", "doc-1", chunkUsage(chunk))
+
+	svc := NewService(ServiceConfig{Repo: repo, ChunkRepo: chunkRepo, StorageRepo: storage})
+	if err := svc.DeleteChunk(context.Background(), documentDomain.UserContext{UserID: "admin", IsAdmin: true}, "c1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if got := storage.users["user-1"]; got != (documentDomain.StorageUsage{}) {
+		t.Errorf("Expected chunk usage to be released, got %+v", got)
+	}
+}
+
+func TestChunkUsage(t *testing.T) {
+	usage := chunkUsage(
+		documentDomain.Chunk{Content: "abc", Embedding: make([]float64, 4)},
+		documentDomain.Chunk{Content: "de", Embedding: make([]float64, 4)},
+	)
+
+	if usage.Chunks != 2 || usage.ChunkBytes != 5 || usage.EmbeddingBytes != 64 {
+		t.Errorf("Unexpected usage %+v", usage)
+	}
+}
+
+func TestGetStorageSummary(t *testing.T) {
+	storage := newMockStorageRepo()
+	_ = storage.Add(context.Background(), "user-1", "doc-1", documentDomain.StorageUsage{Documents: 1, DocumentBytes: 10})
+	_ = storage.Add(context.Background(), "user-2", "doc-2", documentDomain.StorageUsage{Documents: 2, DocumentBytes: 5})
+	svc := NewService(ServiceConfig{Repo: newMockDocumentRepo(), StorageRepo: storage})
+
+	_, err := svc.GetStorageSummary(context.Background(), documentDomain.UserContext{UserID: "user-1"})
+	if !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden for non-admin, got %v", err)
+	}
+
+	summary, err := svc.GetStorageSummary(context.Background(), documentDomain.UserContext{UserID: "admin", IsAdmin: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(summary.Users) != 2 {
+		t.Errorf("Expected 2 users, got %d", len(summary.Users))
+	}
+	if summary.Total.Documents != 3 || summary.Total.DocumentBytes != 15 {
+		t.Errorf("Unexpected total %+v", summary.Total)
+	}
+}
@@ -0,0 +1,83 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/elprogramadorgt/lucidRAG/pkg/jsonschema"
+	"github.com/elprogramadorgt/lucidRAG/pkg/openai"
+)
+
+// structuredAttempts is how many replies the model gets to match the schema;
+// after the first, it is told what was wrong.
+const structuredAttempts = 2
+
+const structuredInstruction = `
+Reply ONLY with a JSON value that matches the response schema. When the context
+does not contain a value, use null or an empty value rather than inventing one.`
+
+// checkResponseSchema accepts the object schemas the completion API supports
+// for structured output.
+func checkResponseSchema(schema map[string]any) error {
+	if schema["type"] != "object" {
+		return fmt.Errorf("%w: top-level type must be \"object\"", ErrInvalidSchema)
+	}
+	if err := jsonschema.Check(schema); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	return nil
+}
+
+// generateStructured asks for an answer in the shape of schema and validates
+// it here, since the API only enforces schemas in strict mode, which most
+// hand-written schemas do not satisfy.
+func (s *service) generateStructured(ctx context.Context, models []ChatModel, messages []openai.ChatMessage, tools []openai.Tool, schema map[string]any, base *openai.CompletionOptions) (generation, json.RawMessage, error) {
+	conversation := append([]openai.ChatMessage{}, messages...)
+	conversation[0].Content += structuredInstruction
+
+	opts := &openai.CompletionOptions{}
+	if base != nil {
+		*opts = *base
+	}
+	opts.ResponseFormat = &openai.ResponseFormat{
+		Type:       "json_schema",
+		JSONSchema: &openai.JSONSchema{Name: "answer", Schema: schema},
+	}
+
+	var all generation
+	var lastErr error
+	for attempt := 0; attempt < structuredAttempts; attempt++ {
+		gen, err := s.generate(ctx, models, conversation, tools, opts)
+		all.answer, all.model = gen.answer, gen.model
+		all.toolsUsed = append(all.toolsUsed, gen.toolsUsed...)
+		all.toolResults = append(all.toolResults, gen.toolResults...)
+		if err != nil {
+			return all, nil, err
+		}
+
+		data, err := parseStructured(gen.answer, schema)
+		if err == nil {
+			return all, data, nil
+		}
+		lastErr = err
+
+		conversation = append(conversation,
+			openai.ChatMessage{Role: "assistant", Content: gen.answer},
+			openai.ChatMessage{Role: "user", Content: fmt.Sprintf("That reply is invalid: %v. Reply again with only the corrected JSON.", err)},
+		)
+	}
+
+	return all, nil, fmt.Errorf("%w: %v", ErrStructuredAnswer, lastErr)
+}
+
+func parseStructured(answer string, schema map[string]any) (json.RawMessage, error) {
+	var value any
+	if err := json.Unmarshal([]byte(answer), &value); err != nil {
+		return nil, fmt.Errorf("not valid JSON: %w", err)
+	}
+	if err := jsonschema.Validate(schema, value); err != nil {
+		return nil, err
+	}
+	return json.RawMessage(answer), nil
+}
@@ -0,0 +1,97 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elprogramadorgt/lucidRAG/pkg/openai"
+)
+
+var hoursSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"open":  map[string]any{"type": "boolean"},
+		"hours": map[string]any{"type": "string"},
+	},
+	"required": []any{"open"},
+}
+
+func structuredServer(t *testing.T, replies ...string) (*httptest.Server, *[]map[string]any) {
+	t.Helper()
+	var requests []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+
+		reply := replies[len(requests)-1]
+		body, _ := json.Marshal(map[string]any{
+			"choices": []any{map[string]any{"message": map[string]any{"role": "assistant", "content": reply}}},
+		})
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	return server, &requests
+}
+
+func TestGenerateStructuredRetriesInvalidReply(t *testing.T) {
+	server, requests := structuredServer(t, `{"hours":"9-5"}`, `{"open":true,"hours":"9-5"}`)
+	defer server.Close()
+
+	s := &service{openaiClient: openai.NewClient("test-key", openai.WithBaseURL(server.URL))}
+	messages := []openai.ChatMessage{{Role: "system", Content: "sys"}, {Role: "user", Content: "are you open?"}}
+
+	gen, data, err := s.generateStructured(context.Background(), s.chatModels(""), messages, nil, hoursSchema, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(data) != gen.answer || !strings.Contains(gen.answer, `"open":true`) {
+		t.Errorf("Expected the corrected answer as data, got %s", data)
+	}
+	if len(*requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(*requests))
+	}
+
+	format, _ := (*requests)[0]["response_format"].(map[string]any)
+	if format["type"] != "json_schema" {
+		t.Errorf("Expected json_schema response format, got %v", format)
+	}
+	retry, _ := (*requests)[1]["messages"].([]any)
+	last, _ := retry[len(retry)-1].(map[string]any)
+	if content, _ := last["content"].(string); !strings.Contains(content, "open") {
+		t.Errorf("Expected the retry to name the missing property, got %q", content)
+	}
+	if messages[0].Content != "sys" {
+		t.Errorf("Expected caller's messages to be left unchanged, got %q", messages[0].Content)
+	}
+}
+
+func TestGenerateStructuredGivesUp(t *testing.T) {
+	server, _ := structuredServer(t, `not json`, `{"open":"yes"}`)
+	defer server.Close()
+
+	s := &service{openaiClient: openai.NewClient("test-key", openai.WithBaseURL(server.URL))}
+	messages := []openai.ChatMessage{{Role: "system", Content: "sys"}, {Role: "user", Content: "are you open?"}}
+
+	_, _, err := s.generateStructured(context.Background(), s.chatModels(""), messages, nil, hoursSchema, nil)
+	if !errors.Is(err, ErrStructuredAnswer) {
+		t.Errorf("Expected ErrStructuredAnswer, got %v", err)
+	}
+}
+
+func TestCheckResponseSchema(t *testing.T) {
+	if err := checkResponseSchema(hoursSchema); err != nil {
+		t.Errorf("Expected valid schema, got %v", err)
+	}
+	if err := checkResponseSchema(map[string]any{"type": "array"}); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("Expected ErrInvalidSchema for a non-object schema, got %v", err)
+	}
+	if err := checkResponseSchema(map[string]any{"type": "object", "required": "open"}); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("Expected ErrInvalidSchema for a malformed schema, got %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package document
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/elprogramadorgt/lucidRAG/pkg/openai"
+)
+
+// maxToolRounds bounds how many times the model may call tools before it
+// has to answer with what it has.
+const maxToolRounds = 3
+
+// ToolRunner supplies the tools the answer model may call and executes them.
+// Run reports failures in its result so the model can recover.
+type ToolRunner interface {
+	Definitions(ctx context.Context) ([]openai.Tool, error)
+	Run(ctx context.Context, call openai.ToolCall) string
+}
+
+// toolDefinitions returns the tools to offer, or nil when none are set up.
+// A failing tool store degrades to a plain answer rather than an error.
+func (s *service) toolDefinitions(ctx context.Context) []openai.Tool {
+	if s.tools == nil {
+		return nil
+	}
+	defs, err := s.tools.Definitions(ctx)
+	if err != nil {
+		s.log.WarnContext(ctx, "failed to load tools", "error", err)
+		return nil
+	}
+	return defs
+}
+
+// generation is a generated answer and the tool calls made to reach it.
+type generation struct {
+	answer    string
+	toolsUsed []string
+	// toolResults are the tool outputs, which the answer may cite like
+	// retrieved chunks.
+	toolResults []string
+	// model names the chat model that answered.
+	model string
+}
+
+// completeWithTools runs the completion on model, executing any tool calls
+// it makes and feeding their results back until it answers.
+func (s *service) completeWithTools(ctx context.Context, model ChatModel, messages []openai.ChatMessage, tools []openai.Tool, opts *openai.CompletionOptions) (generation, error) {
+	conversation := make([]openai.ToolChatMessage, 0, len(messages)+2)
+	for _, m := range messages {
+		conversation = append(conversation, openai.ToolChatMessage{Role: m.Role, Content: m.Content})
+	}
+
+	var gen generation
+	for round := 0; round <= maxToolRounds; round++ {
+		offered := tools
+		if round == maxToolRounds {
+			offered = nil
+		}
+
+		reply, err := model.Client.CreateToolCompletion(ctx, conversation, model.Model, offered, opts)
+		if err != nil {
+			return gen, err
+		}
+		if len(reply.ToolCalls) == 0 {
+			gen.answer = reply.Content
+			return gen, nil
+		}
+
+		conversation = append(conversation, *reply)
+		for _, call := range reply.ToolCalls {
+			result := s.tools.Run(ctx, call)
+			gen.toolsUsed = append(gen.toolsUsed, call.Function.Name)
+			gen.toolResults = append(gen.toolResults, result)
+			conversation = append(conversation, openai.ToolChatMessage{
+				Role:       "tool",
+				Content:    result,
+				ToolCallID: call.ID,
+			})
+		}
+	}
+
+	return gen, fmt.Errorf("model kept calling tools after %d rounds", maxToolRounds)
+}
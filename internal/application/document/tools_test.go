@@ -0,0 +1,111 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elprogramadorgt/lucidRAG/pkg/openai"
+)
+
+type mockToolRunner struct {
+	calls []openai.ToolCall
+}
+
+func (m *mockToolRunner) Definitions(ctx context.Context) ([]openai.Tool, error) {
+	return []openai.Tool{{Type: "function", Function: openai.FunctionDefinition{Name: "calculator"}}}, nil
+}
+
+func (m *mockToolRunner) Run(ctx context.Context, call openai.ToolCall) string {
+	m.calls = append(m.calls, call)
+	return "6"
+}
+
+func TestCompleteWithToolsFeedsResultsBack(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []openai.ToolChatMessage `json:"messages"`
+			Tools    []openai.Tool            `json:"tools"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		requests++
+
+		w.Header().Set("Content-Type", "application/json")
+		if requests == 1 {
+			_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","tool_calls":[{"id":"call_1","type":"function","function":{"name":"calculator","arguments":"{\"expression\":\"2*3\"}"}}]}}]}`))
+			return
+		}
+		last := req.Messages[len(req.Messages)-1]
+		if last.Role != "tool" || last.ToolCallID != "call_1" || last.Content != "6" {
+			t.Errorf("Expected tool result to be sent back, got %+v", last)
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"It is 6."}}]}`))
+	}))
+	defer server.Close()
+
+	runner := &mockToolRunner{}
+	s := &service{
+		openaiClient: openai.NewClient("test-key", openai.WithBaseURL(server.URL)),
+		modelName:    "gpt-4o-mini",
+		tools:        runner,
+	}
+
+	messages := []openai.ChatMessage{{Role: "user", Content: "what is 2*3?"}}
+	gen, err := s.completeWithTools(context.Background(), s.chatModels("")[0], messages, s.toolDefinitions(context.Background()), nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if gen.answer != "It is 6." {
+		t.Errorf("Expected final answer, got %q", gen.answer)
+	}
+	if len(gen.toolsUsed) != 1 || gen.toolsUsed[0] != "calculator" {
+		t.Errorf("Expected calculator to be recorded, got %v", gen.toolsUsed)
+	}
+	if len(gen.toolResults) != 1 || gen.toolResults[0] != "6" {
+		t.Errorf("Expected tool result to be kept, got %v", gen.toolResults)
+	}
+	if len(runner.calls) != 1 {
+		t.Errorf("Expected 1 tool run, got %d", len(runner.calls))
+	}
+}
+
+func TestCompleteWithToolsStopsOfferingToolsAfterMaxRounds(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Tools []openai.Tool `json:"tools"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		requests++
+
+		w.Header().Set("Content-Type", "application/json")
+		if len(req.Tools) == 0 {
+			_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"done"}}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","tool_calls":[{"id":"c","type":"function","function":{"name":"calculator","arguments":"{}"}}]}}]}`))
+	}))
+	defer server.Close()
+
+	s := &service{
+		openaiClient: openai.NewClient("test-key", openai.WithBaseURL(server.URL)),
+		tools:        &mockToolRunner{},
+	}
+
+	gen, err := s.completeWithTools(context.Background(), s.chatModels("")[0], []openai.ChatMessage{{Role: "user", Content: "loop"}}, s.toolDefinitions(context.Background()), nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if gen.answer != "done" {
+		t.Errorf("Expected answer from the final tool-less round, got %q", gen.answer)
+	}
+	if requests != maxToolRounds+1 {
+		t.Errorf("Expected %d requests, got %d", maxToolRounds+1, requests)
+	}
+	if len(gen.toolsUsed) != maxToolRounds {
+		t.Errorf("Expected %d tool calls, got %d", maxToolRounds, len(gen.toolsUsed))
+	}
+}
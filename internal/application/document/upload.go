@@ -0,0 +1,95 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/extract"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrUploadsDisabled   = errors.New("file uploads are not configured")
+	ErrUnsupportedFile   = errors.New("unsupported file type")
+	ErrNoExtractableText = errors.New("no text could be extracted from the file")
+	ErrMalformedFile     = errors.New("file could not be read")
+)
+
+// uploadMetadata is stored as the document's metadata so reviewers can see
+// which pages were OCRed.
+type uploadMetadata struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Pages       int    `json:"pages"`
+	OCRPages    []int  `json:"ocr_pages,omitempty"`
+}
+
+func (s *service) UploadDocument(ctx context.Context, userCtx documentDomain.UserContext, upload documentDomain.Upload) (string, error) {
+	if s.extractor == nil {
+		return "", ErrUploadsDisabled
+	}
+
+	result, err := s.extractor.Extract(ctx, upload.Data, upload.ContentType, extract.Options{
+		Filename:      upload.Filename,
+		Language:      upload.Language,
+		PageLanguages: upload.PageLanguages,
+	})
+	switch {
+	case errors.Is(err, extract.ErrUnsupportedType):
+		return "", fmt.Errorf("%w: %v", ErrUnsupportedFile, err)
+	case errors.Is(err, extract.ErrNoText):
+		return "", ErrNoExtractableText
+	case errors.Is(err, extract.ErrMalformed):
+		return "", fmt.Errorf("%w: %v", ErrMalformedFile, err)
+	case err != nil:
+		return "", fmt.Errorf("extract %s: %w", upload.Filename, err)
+	}
+
+	source := upload.Source
+	if source == "" {
+		source = upload.Filename
+	}
+	// Pages are cleaned before they are joined, while running headers and
+	// footers can still be told apart.
+	s.cleanPages(ctx, source, result.Pages)
+
+	metadata, _ := json.Marshal(uploadMetadata{
+		Filename:    upload.Filename,
+		ContentType: result.ContentType,
+		Pages:       len(result.Pages),
+		OCRPages:    result.OCRPages(),
+	})
+
+	title := strings.TrimSpace(upload.Title)
+	if title == "" {
+		title = upload.Filename
+	}
+
+	doc := &documentDomain.Document{
+		Title:      title,
+		Content:    result.Text(),
+		Source:     source,
+		Metadata:   string(metadata),
+		Collection: upload.Collection,
+		Shareable:  upload.Shareable,
+	}
+	if s.files != nil {
+		doc.ID = primitive.NewObjectID().Hex()
+		file, err := s.storeFile(ctx, doc.ID, upload, result)
+		if err != nil {
+			s.log.WarnContext(ctx, "failed to keep the original upload", "error", err, "filename", upload.Filename)
+		}
+		doc.File = file
+	}
+
+	id, err := s.createDocument(ctx, userCtx, doc)
+	// A rejected or merged duplicate leaves its file unused.
+	if err != nil || id != doc.ID {
+		s.deleteFile(ctx, doc.File)
+	}
+	return id, err
+}
@@ -0,0 +1,56 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/extract"
+)
+
+func TestUploadDocumentOCR(t *testing.T) {
+	repo := newMockDocumentRepo()
+	ocr := func(ctx context.Context, stdin []byte, name string, args ...string) ([]byte, error) {
+		return []byte("Warranty covers parts for two years."), nil
+	}
+	svc := NewService(ServiceConfig{Repo: repo, Extractor: extract.New(extract.Config{OCR: true, Run: ocr})})
+
+	id, err := svc.UploadDocument(context.Background(), adminCtx, documentDomain.Upload{
+		Filename:    "warranty.png",
+		ContentType: "image/png",
+		Data:        []byte("\x89PNG"),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	doc := repo.documents[id]
+	if doc == nil || doc.Title != "warranty.png" || doc.Content != "Warranty covers parts for two years." {
+		t.Fatalf("Unexpected document %+v", doc)
+	}
+	var metadata uploadMetadata
+	if err := json.Unmarshal([]byte(doc.Metadata), &metadata); err != nil {
+		t.Fatalf("Expected JSON metadata, got %v", err)
+	}
+	if len(metadata.OCRPages) != 1 || metadata.ContentType != "image/png" {
+		t.Errorf("Expected OCR recorded in metadata, got %+v", metadata)
+	}
+}
+
+func TestUploadDocumentErrors(t *testing.T) {
+	if _, err := NewService(ServiceConfig{Repo: newMockDocumentRepo()}).UploadDocument(context.Background(), adminCtx, documentDomain.Upload{}); !errors.Is(err, ErrUploadsDisabled) {
+		t.Errorf("Expected ErrUploadsDisabled, got %v", err)
+	}
+
+	svc := NewService(ServiceConfig{Repo: newMockDocumentRepo(), Extractor: extract.New(extract.Config{})})
+	_, err := svc.UploadDocument(context.Background(), adminCtx, documentDomain.Upload{ContentType: "application/zip", Data: []byte("PK")})
+	if !errors.Is(err, ErrUnsupportedFile) {
+		t.Errorf("Expected ErrUnsupportedFile, got %v", err)
+	}
+	_, err = svc.UploadDocument(context.Background(), adminCtx, documentDomain.Upload{ContentType: "text/plain", Data: []byte("  ")})
+	if !errors.Is(err, ErrNoExtractableText) {
+		t.Errorf("Expected ErrNoExtractableText, got %v", err)
+	}
+}
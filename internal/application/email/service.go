@@ -0,0 +1,333 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	emailDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/email"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/elprogramadorgt/lucidRAG/pkg/mail"
+)
+
+var (
+	ErrInvalidEmail    = errors.New("invalid email")
+	ErrDraftNotFound   = errors.New("draft not found")
+	ErrDraftNotPending = errors.New("draft is no longer pending")
+	ErrInvalidDraft    = errors.New("invalid draft")
+	ErrSendFailed      = errors.New("failed to send email")
+)
+
+const (
+	maxBodyChars = 20000
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+// Mailer delivers an email and returns its Message-ID.
+type Mailer interface {
+	Send(ctx context.Context, msg mail.Message) (string, error)
+}
+
+type service struct {
+	drafts  emailDomain.DraftRepository
+	convSvc conversationDomain.Service
+	docSvc  documentDomain.Service
+	mailer  Mailer
+	log     *logger.Logger
+
+	mode emailDomain.ReplyMode
+	// lowConfidence is the confidence below which auto mode queues the
+	// answer instead of sending it.
+	lowConfidence float64
+	// address is the channel's own address; mail from it is never answered.
+	address string
+	// historyWindow is how many earlier messages go into the prompt.
+	historyWindow int
+}
+
+type ServiceConfig struct {
+	DraftRepo     emailDomain.DraftRepository
+	ConvSvc       conversationDomain.Service
+	DocSvc        documentDomain.Service
+	Mailer        Mailer
+	Mode          emailDomain.ReplyMode
+	LowConfidence float64
+	Address       string
+	HistoryWindow int
+	Log           *logger.Logger
+}
+
+func NewService(cfg ServiceConfig) emailDomain.Service {
+	return &service{
+		drafts:        cfg.DraftRepo,
+		convSvc:       cfg.ConvSvc,
+		docSvc:        cfg.DocSvc,
+		mailer:        cfg.Mailer,
+		log:           cfg.Log.With("service", "email"),
+		mode:          cfg.Mode,
+		lowConfidence: cfg.LowConfidence,
+		address:       strings.ToLower(cfg.Address),
+		historyWindow: cfg.HistoryWindow,
+	}
+}
+
+func (s *service) Receive(ctx context.Context, in emailDomain.Inbound) error {
+	from := strings.ToLower(strings.TrimSpace(in.From))
+	if !strings.Contains(from, "@") {
+		return fmt.Errorf("%w: missing sender", ErrInvalidEmail)
+	}
+	// Answering automatic mail, or our own, can start a reply loop.
+	if in.AutoSubmitted || from == s.address {
+		s.log.InfoContext(ctx, "ignored automatic email", "from", from)
+		return nil
+	}
+
+	content := messageText(in.Subject, in.Text)
+	if content == "" {
+		return nil
+	}
+
+	msg, err := s.convSvc.SaveThreadMessage(ctx, conversationDomain.ThreadMessage{
+		Channel:     conversationDomain.ChannelEmail,
+		ExternalID:  from,
+		From:        from,
+		ContactName: in.FromName,
+		Content:     content,
+	})
+	if err != nil {
+		return err
+	}
+
+	query := documentDomain.RAGQuery{
+		Query:     content,
+		TopK:      5,
+		Threshold: 0.7,
+		Channel:   documentDomain.ChannelEmail,
+	}
+	s.addHistory(ctx, &query, msg)
+
+	resp, err := s.docSvc.QueryRAG(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to query RAG: %w", err)
+	}
+
+	draft := &emailDomain.Draft{
+		ConversationID: msg.ConversationID,
+		To:             from,
+		Subject:        replySubject(in.Subject),
+		Question:       content,
+		Body:           resp.Answer,
+		Confidence:     resp.ConfidenceScore,
+		Status:         emailDomain.DraftPending,
+	}
+	if in.MessageID != "" {
+		draft.InReplyTo = in.MessageID
+		draft.References = append(append([]string{}, in.References...), in.MessageID)
+	}
+	if draft.ID, err = s.drafts.Create(ctx, draft); err != nil {
+		return err
+	}
+
+	if s.mode != emailDomain.ReplyModeAuto || resp.ConfidenceScore < s.lowConfidence {
+		s.log.InfoContext(ctx, "email answer queued for approval", "draft_id", draft.ID, "confidence", resp.ConfidenceScore)
+		return nil
+	}
+	// A failed send leaves the draft pending for an admin to retry.
+	if err := s.send(ctx, draft, ""); err != nil {
+		s.log.ErrorContext(ctx, "failed to send email answer", "error", err, "draft_id", draft.ID)
+	}
+	return nil
+}
+
+func (s *service) ListDrafts(ctx context.Context, status emailDomain.DraftStatus, limit, offset int) ([]emailDomain.Draft, int64, error) {
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.drafts.List(ctx, status, limit, offset)
+}
+
+func (s *service) GetDraft(ctx context.Context, id string) (*emailDomain.Draft, error) {
+	draft, err := s.drafts.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if draft == nil {
+		return nil, ErrDraftNotFound
+	}
+	return draft, nil
+}
+
+func (s *service) UpdateDraft(ctx context.Context, adminID, id, body string) (*emailDomain.Draft, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || utf8.RuneCountInString(body) > maxBodyChars {
+		return nil, fmt.Errorf("%w: body must be 1-%d characters", ErrInvalidDraft, maxBodyChars)
+	}
+
+	draft, err := s.pendingDraft(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	draft.Body = body
+	draft.ReviewedBy = adminID
+	if err := s.drafts.Update(ctx, draft); err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+func (s *service) ApproveDraft(ctx context.Context, adminID, id string) (*emailDomain.Draft, error) {
+	draft, err := s.pendingDraft(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.send(ctx, draft, adminID); err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+func (s *service) DiscardDraft(ctx context.Context, adminID, id string) error {
+	draft, err := s.pendingDraft(ctx, id)
+	if err != nil {
+		return err
+	}
+	draft.Status = emailDomain.DraftDiscarded
+	draft.ReviewedBy = adminID
+	return s.drafts.Update(ctx, draft)
+}
+
+func (s *service) pendingDraft(ctx context.Context, id string) (*emailDomain.Draft, error) {
+	draft, err := s.GetDraft(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if draft.Status != emailDomain.DraftPending {
+		return nil, ErrDraftNotPending
+	}
+	return draft, nil
+}
+
+// send delivers draft and records it as the conversation's answer. adminID
+// is empty for answers sent automatically.
+func (s *service) send(ctx context.Context, draft *emailDomain.Draft, adminID string) error {
+	_, err := s.mailer.Send(ctx, mail.Message{
+		To:         draft.To,
+		Subject:    draft.Subject,
+		Body:       draft.Body,
+		InReplyTo:  draft.InReplyTo,
+		References: draft.References,
+	})
+	if err != nil {
+		draft.Error = err.Error()
+		if updateErr := s.drafts.Update(ctx, draft); updateErr != nil {
+			s.log.WarnContext(ctx, "failed to record send error", "error", updateErr, "draft_id", draft.ID)
+		}
+		return fmt.Errorf("%w: %v", ErrSendFailed, err)
+	}
+
+	now := time.Now()
+	draft.Status = emailDomain.DraftSent
+	draft.Error = ""
+	draft.SentAt = &now
+	if adminID != "" {
+		draft.ReviewedBy = adminID
+	}
+	if err := s.drafts.Update(ctx, draft); err != nil {
+		return err
+	}
+	if _, err := s.convSvc.SaveOutgoingMessage(ctx, draft.ConversationID, draft.Body, draft.Body); err != nil {
+		s.log.ErrorContext(ctx, "failed to save outgoing message", "error", err, "conversation_id", draft.ConversationID)
+	}
+	return nil
+}
+
+// addHistory gives the query the conversation so far, without the message
+// being answered. Without history the question is still answered on its own.
+func (s *service) addHistory(ctx context.Context, query *documentDomain.RAGQuery, msg *conversationDomain.Message) {
+	limit := 0
+	if s.historyWindow > 0 {
+		limit = s.historyWindow + 1
+	}
+
+	history, err := s.convSvc.GetHistory(ctx, msg.ConversationID, limit)
+	if err != nil {
+		s.log.WarnContext(ctx, "failed to load conversation history", "error", err, "conversation_id", msg.ConversationID)
+		return
+	}
+
+	query.CustomerContext = history.Variables
+	if s.historyWindow <= 0 {
+		return
+	}
+
+	query.Summary = history.Summary
+	for _, m := range history.Messages {
+		if m.ID == msg.ID {
+			continue
+		}
+		role := "user"
+		if m.Direction == conversationDomain.DirectionOutgoing {
+			role = "assistant"
+		}
+		query.History = append(query.History, documentDomain.Turn{Role: role, Content: m.PromptText()})
+	}
+	if len(query.History) > s.historyWindow {
+		query.History = query.History[len(query.History)-s.historyWindow:]
+	}
+}
+
+var (
+	replyPrefix = regexp.MustCompile(`(?i)^\s*((re|aw|fw|fwd|rv|res)\s*:\s*)+`)
+	// quoteHeader matches the line mail clients put above a quoted reply,
+	// such as "On Mon, Jan 2, Ana wrote:" or "El lun, 2 ene, Ana escribió:".
+	quoteHeader = regexp.MustCompile(`(?i)^(on\s.+\swrote|el\s.+\sescribi[oó])\s*:\s*$`)
+)
+
+// messageText is what is stored and answered for an email: its new text
+// without quoted earlier messages or signature, preceded by the subject when
+// the email starts a thread.
+func messageText(subject, text string) string {
+	body := newText(text)
+	subject = strings.TrimSpace(subject)
+	if subject == "" || replyPrefix.MatchString(subject) {
+		return body
+	}
+	return strings.TrimSpace(subject + "\n\n" + body)
+}
+
+// newText cuts text at the first quoted line, quote header or signature
+// separator.
+func newText(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, ">") || quoteHeader.MatchString(trimmed) ||
+			line == "-- " || strings.HasPrefix(trimmed, "-----Original Message-----") {
+			lines = lines[:i]
+			break
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// replySubject prefixes subject with a single "Re: ".
+func replySubject(subject string) string {
+	subject = strings.TrimSpace(replyPrefix.ReplaceAllString(subject, ""))
+	if subject == "" {
+		return "Re: Your question"
+	}
+	return "Re: " + subject
+}
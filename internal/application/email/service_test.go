@@ -0,0 +1,240 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	emailDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/email"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/elprogramadorgt/lucidRAG/pkg/mail"
+)
+
+type mockDraftRepo struct {
+	drafts map[string]*emailDomain.Draft
+}
+
+func (m *mockDraftRepo) Create(ctx context.Context, d *emailDomain.Draft) (string, error) {
+	d.ID = "draft_1"
+	copied := *d
+	m.drafts[d.ID] = &copied
+	return d.ID, nil
+}
+
+func (m *mockDraftRepo) GetByID(ctx context.Context, id string) (*emailDomain.Draft, error) {
+	if d, ok := m.drafts[id]; ok {
+		copied := *d
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (m *mockDraftRepo) List(ctx context.Context, status emailDomain.DraftStatus, limit, offset int) ([]emailDomain.Draft, int64, error) {
+	return nil, 0, nil
+}
+
+func (m *mockDraftRepo) Update(ctx context.Context, d *emailDomain.Draft) error {
+	copied := *d
+	m.drafts[d.ID] = &copied
+	return nil
+}
+
+type mockMailer struct {
+	sent []mail.Message
+	err  error
+}
+
+func (m *mockMailer) Send(ctx context.Context, msg mail.Message) (string, error) {
+	if m.err != nil {
+		return "", m.err
+	}
+	m.sent = append(m.sent, msg)
+	return "<sent@example.com>", nil
+}
+
+type mockConvService struct {
+	conversationDomain.Service
+	saved    []conversationDomain.ThreadMessage
+	outgoing []string
+}
+
+func (m *mockConvService) SaveThreadMessage(ctx context.Context, msg conversationDomain.ThreadMessage) (*conversationDomain.Message, error) {
+	m.saved = append(m.saved, msg)
+	return &conversationDomain.Message{ID: "msg_1", ConversationID: "conv_1", Content: msg.Content}, nil
+}
+
+func (m *mockConvService) SaveOutgoingMessage(ctx context.Context, conversationID, content, ragAnswer string) (*conversationDomain.Message, error) {
+	m.outgoing = append(m.outgoing, content)
+	return &conversationDomain.Message{ID: "msg_2"}, nil
+}
+
+func (m *mockConvService) GetHistory(ctx context.Context, conversationID string, limit int) (*conversationDomain.History, error) {
+	return &conversationDomain.History{}, nil
+}
+
+type mockDocService struct {
+	documentDomain.Service
+	confidence float64
+	queries    []documentDomain.RAGQuery
+}
+
+func (m *mockDocService) QueryRAG(ctx context.Context, query documentDomain.RAGQuery) (*documentDomain.RAGResponse, error) {
+	m.queries = append(m.queries, query)
+	return &documentDomain.RAGResponse{Answer: "Use the reset link.", ConfidenceScore: m.confidence}, nil
+}
+
+func newTestService(mode emailDomain.ReplyMode, confidence float64) (emailDomain.Service, *mockDraftRepo, *mockMailer, *mockConvService) {
+	drafts := &mockDraftRepo{drafts: map[string]*emailDomain.Draft{}}
+	mailer := &mockMailer{}
+	convSvc := &mockConvService{}
+	svc := NewService(ServiceConfig{
+		DraftRepo:     drafts,
+		ConvSvc:       convSvc,
+		DocSvc:        &mockDocService{confidence: confidence},
+		Mailer:        mailer,
+		Mode:          mode,
+		LowConfidence: 0.5,
+		Address:       "support@example.com",
+		HistoryWindow: 5,
+		Log:           logger.New(logger.Options{Level: "error"}),
+	})
+	return svc, drafts, mailer, convSvc
+}
+
+var inbound = emailDomain.Inbound{
+	From:      "Ana@Example.com",
+	FromName:  "Ana",
+	Subject:   "Password reset",
+	Text:      "How do I reset my password?\n\nOn Mon, Jan 2, 2026 Support wrote:\n> Hello",
+	MessageID: "<abc@mail.example.com>",
+}
+
+func TestReceiveQueuesDraft(t *testing.T) {
+	svc, drafts, mailer, convSvc := newTestService(emailDomain.ReplyModeDraft, 0.9)
+
+	if err := svc.Receive(context.Background(), inbound); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(convSvc.saved) != 1 {
+		t.Fatalf("Expected 1 saved message, got %d", len(convSvc.saved))
+	}
+	saved := convSvc.saved[0]
+	if saved.ExternalID != "ana@example.com" || saved.Channel != conversationDomain.ChannelEmail {
+		t.Errorf("Unexpected thread message %+v", saved)
+	}
+	if saved.Content != "Password reset\n\nHow do I reset my password?" {
+		t.Errorf("Expected subject and new text only, got %q", saved.Content)
+	}
+
+	draft := drafts.drafts["draft_1"]
+	if draft == nil || draft.Status != emailDomain.DraftPending {
+		t.Fatalf("Expected pending draft, got %+v", draft)
+	}
+	if draft.Subject != "Re: Password reset" || draft.InReplyTo != "<abc@mail.example.com>" {
+		t.Errorf("Expected reply headers, got %+v", draft)
+	}
+	if len(mailer.sent) != 0 {
+		t.Errorf("Expected nothing sent in draft mode, got %d", len(mailer.sent))
+	}
+}
+
+func TestReceiveAutoReply(t *testing.T) {
+	svc, drafts, mailer, convSvc := newTestService(emailDomain.ReplyModeAuto, 0.9)
+
+	if err := svc.Receive(context.Background(), inbound); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(mailer.sent) != 1 || mailer.sent[0].To != "ana@example.com" {
+		t.Fatalf("Expected answer sent to sender, got %+v", mailer.sent)
+	}
+	if drafts.drafts["draft_1"].Status != emailDomain.DraftSent {
+		t.Errorf("Expected draft marked sent, got %s", drafts.drafts["draft_1"].Status)
+	}
+	if len(convSvc.outgoing) != 1 {
+		t.Errorf("Expected outgoing message saved, got %d", len(convSvc.outgoing))
+	}
+}
+
+func TestReceiveAutoReplyQueuesLowConfidence(t *testing.T) {
+	svc, drafts, mailer, _ := newTestService(emailDomain.ReplyModeAuto, 0.2)
+
+	_ = svc.Receive(context.Background(), inbound)
+	if len(mailer.sent) != 0 {
+		t.Error("Expected low-confidence answer not to be sent")
+	}
+	if drafts.drafts["draft_1"].Status != emailDomain.DraftPending {
+		t.Errorf("Expected pending draft, got %s", drafts.drafts["draft_1"].Status)
+	}
+}
+
+func TestReceiveIgnoresAutomaticMail(t *testing.T) {
+	svc, _, _, convSvc := newTestService(emailDomain.ReplyModeAuto, 0.9)
+
+	auto := inbound
+	auto.AutoSubmitted = true
+	_ = svc.Receive(context.Background(), auto)
+	own := inbound
+	own.From = "Support@example.com"
+	_ = svc.Receive(context.Background(), own)
+
+	if len(convSvc.saved) != 0 {
+		t.Errorf("Expected automatic mail to be ignored, got %d saved", len(convSvc.saved))
+	}
+}
+
+func TestApproveDraft(t *testing.T) {
+	svc, drafts, mailer, _ := newTestService(emailDomain.ReplyModeDraft, 0.9)
+	_ = svc.Receive(context.Background(), inbound)
+
+	if _, err := svc.UpdateDraft(context.Background(), "admin-1", "draft_1", "  "); !errors.Is(err, ErrInvalidDraft) {
+		t.Errorf("Expected ErrInvalidDraft, got %v", err)
+	}
+	if _, err := svc.UpdateDraft(context.Background(), "admin-1", "draft_1", "Hi Ana, use the reset link."); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	mailer.err = errors.New("connection refused")
+	if _, err := svc.ApproveDraft(context.Background(), "admin-1", "draft_1"); !errors.Is(err, ErrSendFailed) {
+		t.Errorf("Expected ErrSendFailed, got %v", err)
+	}
+	if d := drafts.drafts["draft_1"]; d.Status != emailDomain.DraftPending || d.Error == "" {
+		t.Errorf("Expected draft to stay pending with the error, got %+v", d)
+	}
+
+	mailer.err = nil
+	draft, err := svc.ApproveDraft(context.Background(), "admin-1", "draft_1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if draft.Status != emailDomain.DraftSent || draft.ReviewedBy != "admin-1" || draft.Error != "" {
+		t.Errorf("Unexpected draft %+v", draft)
+	}
+	if len(mailer.sent) != 1 || mailer.sent[0].Body != "Hi Ana, use the reset link." {
+		t.Errorf("Expected edited body sent, got %+v", mailer.sent)
+	}
+
+	if _, err := svc.ApproveDraft(context.Background(), "admin-1", "draft_1"); !errors.Is(err, ErrDraftNotPending) {
+		t.Errorf("Expected ErrDraftNotPending, got %v", err)
+	}
+	if err := svc.DiscardDraft(context.Background(), "admin-1", "missing"); !errors.Is(err, ErrDraftNotFound) {
+		t.Errorf("Expected ErrDraftNotFound, got %v", err)
+	}
+}
+
+func TestMessageText(t *testing.T) {
+	tests := []struct {
+		subject, text, want string
+	}{
+		{"Re: Password reset", "Thanks!\n-- \nAna", "Thanks!"},
+		{"", "Gracias\r\n\r\nEl lun, 2 ene 2026, Soporte escribió:\r\n> Hola", "Gracias"},
+		{"Billing", "", "Billing"},
+	}
+	for _, tt := range tests {
+		if got := messageText(tt.subject, tt.text); got != tt.want {
+			t.Errorf("messageText(%q, %q) = %q, want %q", tt.subject, tt.text, got, tt.want)
+		}
+	}
+}
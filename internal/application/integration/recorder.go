@@ -0,0 +1,81 @@
+package integration
+
+import (
+	"context"
+	"time"
+
+	integrationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/integration"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+)
+
+const recordTimeout = 5 * time.Second
+
+// Recorder turns domain events into trigger events that no-code tools poll.
+type Recorder struct {
+	repo integrationDomain.TriggerRepository
+	// lowConfidence is the confidence below which an answer is reported.
+	lowConfidence float64
+	log           *logger.Logger
+}
+
+func NewRecorder(repo integrationDomain.TriggerRepository, lowConfidence float64, log *logger.Logger) *Recorder {
+	return &Recorder{repo: repo, lowConfidence: lowConfidence, log: log}
+}
+
+func (r *Recorder) Subscribe(bus *events.Bus) {
+	bus.Subscribe(r.handle, events.NameMessageReceived, events.NameAnswerGenerated, events.NameSpendCapReached)
+}
+
+func (r *Recorder) handle(ctx context.Context, event events.Event) {
+	trigger := r.trigger(event)
+	if trigger == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), recordTimeout)
+		defer cancel()
+		if err := r.repo.Create(ctx, trigger); err != nil {
+			r.log.ErrorContext(ctx, "failed to record integration trigger", "error", err, "type", trigger.Type)
+		}
+	}()
+}
+
+// trigger maps event to its trigger event, or nil when it triggers nothing.
+func (r *Recorder) trigger(event events.Event) *integrationDomain.TriggerEvent {
+	switch e := event.(type) {
+	case events.MessageReceived:
+		return &integrationDomain.TriggerEvent{
+			Type:           integrationDomain.TriggerNewMessage,
+			ConversationID: e.ConversationID,
+			MessageID:      e.MessageID,
+			Channel:        e.Channel,
+			From:           e.From,
+			Content:        e.Content,
+			MessageType:    e.MessageType,
+			CreatedAt:      time.Now(),
+		}
+	case events.AnswerGenerated:
+		// A cache hit repeats an answer that was already reported.
+		if e.CacheHit || e.ConfidenceScore >= r.lowConfidence {
+			return nil
+		}
+		return &integrationDomain.TriggerEvent{
+			Type:       integrationDomain.TriggerLowConfidence,
+			Query:      e.Query,
+			Answer:     e.Answer,
+			Confidence: &e.ConfidenceScore,
+			CreatedAt:  time.Now(),
+		}
+	case events.SpendCapReached:
+		return &integrationDomain.TriggerEvent{
+			Type:      integrationDomain.TriggerSpendCap,
+			Day:       e.Day,
+			SpentUSD:  &e.SpentUSD,
+			CapUSD:    &e.CapUSD,
+			CreatedAt: time.Now(),
+		}
+	}
+	return nil
+}
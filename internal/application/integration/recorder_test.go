@@ -0,0 +1,36 @@
+package integration
+
+import (
+	"testing"
+
+	integrationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/integration"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+)
+
+func TestRecorderTriggers(t *testing.T) {
+	r := NewRecorder(nil, 0.5, nil)
+
+	msg := r.trigger(events.MessageReceived{MessageID: "msg-1", ConversationID: "conv-1", From: "15550100", Content: "hi"})
+	if msg == nil || msg.Type != integrationDomain.TriggerNewMessage || msg.Content != "hi" {
+		t.Errorf("Expected new_message trigger, got %+v", msg)
+	}
+
+	low := r.trigger(events.AnswerGenerated{Query: "q", Answer: "a", ConfidenceScore: 0})
+	if low == nil || low.Type != integrationDomain.TriggerLowConfidence || low.Confidence == nil || *low.Confidence != 0 {
+		t.Errorf("Expected low_confidence_answer trigger with confidence 0, got %+v", low)
+	}
+
+	if got := r.trigger(events.AnswerGenerated{ConfidenceScore: 0.8}); got != nil {
+		t.Errorf("Expected no trigger for a confident answer, got %+v", got)
+	}
+	if got := r.trigger(events.AnswerGenerated{ConfidenceScore: 0.1, CacheHit: true}); got != nil {
+		t.Errorf("Expected no trigger for a cache hit, got %+v", got)
+	}
+	spend := r.trigger(events.SpendCapReached{Day: "2026-10-17", SpentUSD: 50.2, CapUSD: 50})
+	if spend == nil || spend.Type != integrationDomain.TriggerSpendCap || spend.Day != "2026-10-17" || spend.CapUSD == nil || *spend.CapUSD != 50 {
+		t.Errorf("Expected spend_cap_reached trigger, got %+v", spend)
+	}
+	if got := r.trigger(events.DocumentCreated{DocumentID: "doc-1"}); got != nil {
+		t.Errorf("Expected no trigger for other events, got %+v", got)
+	}
+}
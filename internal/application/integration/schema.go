@@ -0,0 +1,211 @@
+package integration
+
+import (
+	"maps"
+	"slices"
+	"time"
+
+	integrationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/integration"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+)
+
+// CurrentSchemaVersion is the payload version new webhooks get.
+//
+// Payloads are versioned as a whole. Within a version they only grow: a
+// field keeps its name, type and meaning for as long as the version is
+// delivered, and new fields can appear at any time, so receivers must
+// ignore fields they do not know. Removing or renaming a field, or changing
+// its type or meaning, makes a new version; webhooks stay on the version
+// they were created with until an admin moves them.
+const CurrentSchemaVersion = 1
+
+// webhookEvent is how one event type is delivered in one payload version.
+type webhookEvent struct {
+	description string
+	// properties are the data's fields and their JSON Schemas. Every field
+	// is always present.
+	properties map[string]any
+	// data shapes an event of this type; it returns nil for other events.
+	data   func(event events.Event) any
+	sample any
+}
+
+type messageReceivedV1 struct {
+	MessageID      string `json:"message_id"`
+	ConversationID string `json:"conversation_id"`
+	Channel        string `json:"channel"`
+	From           string `json:"from"`
+	Content        string `json:"content"`
+	MessageType    string `json:"message_type"`
+	HumanMode      bool   `json:"human_mode"`
+}
+
+type messageSentV1 struct {
+	MessageID      string `json:"message_id"`
+	ConversationID string `json:"conversation_id"`
+	Content        string `json:"content"`
+	MessageType    string `json:"message_type"`
+	FromBot        bool   `json:"from_bot"`
+}
+
+type conversationAssignedV1 struct {
+	ConversationID  string `json:"conversation_id"`
+	AgentID         string `json:"agent_id"`
+	PreviousAgentID string `json:"previous_agent_id"`
+	Reason          string `json:"reason"`
+	RuleID          string `json:"rule_id"`
+	ActorID         string `json:"actor_id"`
+}
+
+type conversationStateChangedV1 struct {
+	ConversationID string `json:"conversation_id"`
+	From           string `json:"from"`
+	To             string `json:"to"`
+}
+
+// webhookSchemas are the event types each payload version delivers.
+var webhookSchemas = map[int]map[string]webhookEvent{
+	1: {
+		events.NameMessageReceived: {
+			description: "A contact wrote in a conversation.",
+			properties: map[string]any{
+				"message_id":      prop("string", "The message's ID"),
+				"conversation_id": prop("string", "The conversation's ID"),
+				"channel":         prop("string", "whatsapp, slack, email or widget"),
+				"from":            prop("string", "The sender in the channel, such as a phone number"),
+				"content":         prop("string", "The message text"),
+				"message_type":    prop("string", "text, image, audio and so on"),
+				"human_mode":      prop("boolean", "Whether an agent, not the bot, answers the conversation"),
+			},
+			data: func(event events.Event) any {
+				e, ok := event.(events.MessageReceived)
+				if !ok {
+					return nil
+				}
+				return messageReceivedV1{
+					MessageID: e.MessageID, ConversationID: e.ConversationID, Channel: e.Channel, From: e.From,
+					Content: e.Content, MessageType: e.MessageType, HumanMode: e.HumanMode,
+				}
+			},
+			sample: messageReceivedV1{
+				MessageID: "msg_sample", ConversationID: "conv_sample", Channel: "whatsapp", From: "50212345678",
+				Content: "Where is my order?", MessageType: "text",
+			},
+		},
+		events.NameMessageSent: {
+			description: "A reply was sent in a conversation, by the bot or an agent.",
+			properties: map[string]any{
+				"message_id":      prop("string", "The message's ID"),
+				"conversation_id": prop("string", "The conversation's ID"),
+				"content":         prop("string", "The message text"),
+				"message_type":    prop("string", "text"),
+				"from_bot":        prop("boolean", "Whether the bot wrote the reply"),
+			},
+			data: func(event events.Event) any {
+				e, ok := event.(events.MessageSent)
+				if !ok {
+					return nil
+				}
+				return messageSentV1{
+					MessageID: e.MessageID, ConversationID: e.ConversationID, Content: e.Content,
+					MessageType: e.MessageType, FromBot: e.FromBot,
+				}
+			},
+			sample: messageSentV1{
+				MessageID: "msg_sample_reply", ConversationID: "conv_sample", Content: "Your order ships tomorrow.",
+				MessageType: "text", FromBot: true,
+			},
+		},
+		events.NameConversationAssigned: {
+			description: "A conversation was handed off to an agent, moved to another one or handed back to the bot.",
+			properties: map[string]any{
+				"conversation_id":   prop("string", "The conversation's ID"),
+				"agent_id":          prop("string", "The agent now answering, empty when handed back to the bot"),
+				"previous_agent_id": prop("string", "The agent answering before, if any"),
+				"reason":            prop("string", "manual, rule, round_robin, fallback or released"),
+				"rule_id":           prop("string", "The assignment rule that matched, for reason rule"),
+				"actor_id":          prop("string", "The user who made the change"),
+			},
+			data: func(event events.Event) any {
+				e, ok := event.(events.ConversationAssigned)
+				if !ok {
+					return nil
+				}
+				return conversationAssignedV1{
+					ConversationID: e.ConversationID, AgentID: e.AgentID, PreviousAgentID: e.PreviousAgentID,
+					Reason: e.Reason, RuleID: e.RuleID, ActorID: e.ActorID,
+				}
+			},
+			sample: conversationAssignedV1{
+				ConversationID: "conv_sample", AgentID: "user_agent", Reason: "round_robin", ActorID: "user_admin",
+			},
+		},
+		events.NameConversationState: {
+			description: "A conversation moved between open, pending, resolved and closed.",
+			properties: map[string]any{
+				"conversation_id": prop("string", "The conversation's ID"),
+				"from":            prop("string", "The previous state"),
+				"to":              prop("string", "The new state"),
+			},
+			data: func(event events.Event) any {
+				e, ok := event.(events.ConversationStateChanged)
+				if !ok {
+					return nil
+				}
+				return conversationStateChangedV1{ConversationID: e.ConversationID, From: e.From, To: e.To}
+			},
+			sample: conversationStateChangedV1{ConversationID: "conv_sample", From: "open", To: "resolved"},
+		},
+	},
+}
+
+func prop(typ, description string) map[string]any {
+	return map[string]any{"type": typ, "description": description}
+}
+
+// WebhookEvents lists the events webhooks can subscribe to, in any version.
+func WebhookEvents() []string {
+	seen := map[string]bool{}
+	for _, schemas := range webhookSchemas {
+		for name := range schemas {
+			seen[name] = true
+		}
+	}
+	return slices.Sorted(maps.Keys(seen))
+}
+
+func (s *service) WebhookSchemas() []integrationDomain.WebhookSchema {
+	var schemas []integrationDomain.WebhookSchema
+	for _, version := range slices.Sorted(maps.Keys(webhookSchemas)) {
+		for _, name := range slices.Sorted(maps.Keys(webhookSchemas[version])) {
+			event := webhookSchemas[version][name]
+			schemas = append(schemas, integrationDomain.WebhookSchema{
+				Event:         name,
+				SchemaVersion: version,
+				Description:   event.description,
+				Schema: map[string]any{
+					"$schema":    "https://json-schema.org/draft/2020-12/schema",
+					"type":       "object",
+					"properties": event.properties,
+					"required":   slices.Sorted(maps.Keys(event.properties)),
+				},
+				Sample: event.sample,
+			})
+		}
+	}
+	return schemas
+}
+
+// payload shapes event for a webhook on version, or returns nil when the
+// version does not deliver it.
+func payload(id string, event events.Event, version int, at time.Time) *integrationDomain.WebhookPayload {
+	schema, ok := webhookSchemas[version][event.EventName()]
+	if !ok {
+		return nil
+	}
+	data := schema.data(event)
+	if data == nil {
+		return nil
+	}
+	return &integrationDomain.WebhookPayload{ID: id, Type: event.EventName(), SchemaVersion: version, CreatedAt: at, Data: data}
+}
@@ -0,0 +1,344 @@
+package integration
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	integrationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/integration"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+)
+
+var (
+	ErrInvalidAPIKey      = errors.New("invalid api key")
+	ErrAPIKeyNotFound     = errors.New("api key not found")
+	ErrInvalidRequest     = errors.New("invalid integration request")
+	ErrSendingUnavailable = errors.New("sending WhatsApp messages is not configured")
+)
+
+const (
+	// keyPrefix and widgetKeyPrefix mark LucidRAG keys so they are
+	// recognizable in secret scanners and tool configs, and tell the kinds
+	// apart.
+	keyPrefix       = "lrk_"
+	widgetKeyPrefix = "lrw_"
+	shownPrefixLen  = len(keyPrefix) + 6
+	maxKeyNameLen   = 100
+	maxOrigins      = 20
+	maxMessageChars = 4096
+	defaultLimit    = 50
+	maxLimit        = 100
+	// touchInterval bounds how often LastUsedAt is written for a busy key.
+	touchInterval = time.Minute
+)
+
+var phoneNumber = regexp.MustCompile(`^[0-9]{7,15}$`)
+
+var prefixes = map[integrationDomain.KeyKind]string{
+	integrationDomain.KeyKindIntegration: keyPrefix,
+	integrationDomain.KeyKindWidget:      widgetKeyPrefix,
+}
+
+// TextSender delivers a WhatsApp text message.
+type TextSender interface {
+	SendText(ctx context.Context, to, body string) error
+}
+
+type service struct {
+	keys     integrationDomain.APIKeyRepository
+	triggers integrationDomain.TriggerRepository
+	webhooks integrationDomain.WebhookRepository
+	poster   *webhookSender
+	convSvc  conversationDomain.Service
+	docSvc   documentDomain.Service
+	sender   TextSender
+	log      *logger.Logger
+}
+
+type ServiceConfig struct {
+	KeyRepo     integrationDomain.APIKeyRepository
+	TriggerRepo integrationDomain.TriggerRepository
+	WebhookRepo integrationDomain.WebhookRepository
+	ConvSvc     conversationDomain.Service
+	DocSvc      documentDomain.Service
+	// Sender is optional; without it the send-message action is refused.
+	Sender TextSender
+	// Log receives warnings about best-effort steps that failed; defaults
+	// to standard output.
+	Log *logger.Logger
+}
+
+func NewService(cfg ServiceConfig) integrationDomain.Service {
+	log := cfg.Log
+	if log == nil {
+		log = logger.New()
+	}
+	return &service{
+		keys:     cfg.KeyRepo,
+		triggers: cfg.TriggerRepo,
+		webhooks: cfg.WebhookRepo,
+		poster:   newWebhookSender(),
+		convSvc:  cfg.ConvSvc,
+		docSvc:   cfg.DocSvc,
+		sender:   cfg.Sender,
+		log:      log.With("service", "integration"),
+	}
+}
+
+func (s *service) CreateAPIKey(ctx context.Context, adminID string, settings integrationDomain.KeySettings) (*integrationDomain.APIKey, string, error) {
+	key := &integrationDomain.APIKey{Kind: settings.Kind, CreatedBy: adminID}
+	if key.Kind == "" {
+		key.Kind = integrationDomain.KeyKindIntegration
+	}
+	if _, ok := prefixes[key.Kind]; !ok {
+		return nil, "", fmt.Errorf("%w: kind must be integration or widget", ErrInvalidRequest)
+	}
+	if err := applySettings(key, settings); err != nil {
+		return nil, "", err
+	}
+
+	raw, err := newSecret(key)
+	if err != nil {
+		return nil, "", err
+	}
+	id, err := s.keys.Create(ctx, key)
+	if err != nil {
+		return nil, "", err
+	}
+	key.ID = id
+	return key, raw, nil
+}
+
+func (s *service) UpdateAPIKey(ctx context.Context, adminID, id string, settings integrationDomain.KeySettings) (*integrationDomain.APIKey, error) {
+	key, err := s.getKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applySettings(key, settings); err != nil {
+		return nil, err
+	}
+	if err := s.keys.Update(ctx, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (s *service) RotateAPIKey(ctx context.Context, adminID, id string) (*integrationDomain.APIKey, string, error) {
+	key, err := s.getKey(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	raw, err := newSecret(key)
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now()
+	key.RotatedAt = &now
+	if err := s.keys.Update(ctx, key); err != nil {
+		return nil, "", err
+	}
+	return key, raw, nil
+}
+
+func (s *service) getKey(ctx context.Context, id string) (*integrationDomain.APIKey, error) {
+	key, err := s.keys.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, ErrAPIKeyNotFound
+	}
+	if key.Kind == "" {
+		key.Kind = integrationDomain.KeyKindIntegration
+	}
+	return key, nil
+}
+
+// applySettings validates settings and sets them on key, whose kind must
+// already be set.
+func applySettings(key *integrationDomain.APIKey, settings integrationDomain.KeySettings) error {
+	name := strings.TrimSpace(settings.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxKeyNameLen {
+		return fmt.Errorf("%w: name must be 1-%d characters", ErrInvalidRequest, maxKeyNameLen)
+	}
+	if settings.Kind != "" && settings.Kind != key.Kind {
+		return fmt.Errorf("%w: a key's kind cannot be changed", ErrInvalidRequest)
+	}
+
+	var origins []string
+	if key.Kind == integrationDomain.KeyKindWidget {
+		var err error
+		if origins, err = normalizeOrigins(settings.AllowedOrigins); err != nil {
+			return err
+		}
+	} else if len(settings.AllowedOrigins) > 0 {
+		return fmt.Errorf("%w: only widget keys have allowed origins", ErrInvalidRequest)
+	}
+
+	key.Name = name
+	key.AllowedOrigins = origins
+	return nil
+}
+
+// normalizeOrigins checks that each origin is a scheme and host, with an
+// optional port, and returns them lowercased without duplicates.
+func normalizeOrigins(origins []string) ([]string, error) {
+	if len(origins) == 0 || len(origins) > maxOrigins {
+		return nil, fmt.Errorf("%w: widget keys need 1-%d allowed origins", ErrInvalidRequest, maxOrigins)
+	}
+	seen := make(map[string]bool, len(origins))
+	var normalized []string
+	for _, origin := range origins {
+		u, err := url.Parse(strings.TrimSpace(origin))
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" ||
+			(u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.User != nil {
+			return nil, fmt.Errorf("%w: %q is not an origin such as https://www.example.com", ErrInvalidRequest, origin)
+		}
+		o := strings.ToLower(u.Scheme + "://" + u.Host)
+		if !seen[o] {
+			seen[o] = true
+			normalized = append(normalized, o)
+		}
+	}
+	return normalized, nil
+}
+
+// newSecret gives key a new secret of its kind and returns it.
+func newSecret(key *integrationDomain.APIKey) (string, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	raw := prefixes[key.Kind] + hex.EncodeToString(secret)
+	key.Prefix = raw[:shownPrefixLen]
+	key.KeyHash = hashKey(raw)
+	return raw, nil
+}
+
+func (s *service) ListAPIKeys(ctx context.Context) ([]integrationDomain.APIKey, error) {
+	return s.keys.List(ctx)
+}
+
+func (s *service) DeleteAPIKey(ctx context.Context, adminID, id string) error {
+	deleted, err := s.keys.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (s *service) Authenticate(ctx context.Context, raw string) (*integrationDomain.APIKey, error) {
+	return s.authenticate(ctx, raw, integrationDomain.KeyKindIntegration)
+}
+
+func (s *service) AuthenticateWidget(ctx context.Context, raw string) (*integrationDomain.APIKey, error) {
+	return s.authenticate(ctx, raw, integrationDomain.KeyKindWidget)
+}
+
+func (s *service) authenticate(ctx context.Context, raw string, kind integrationDomain.KeyKind) (*integrationDomain.APIKey, error) {
+	if !strings.HasPrefix(raw, prefixes[kind]) {
+		return nil, ErrInvalidAPIKey
+	}
+	key, err := s.keys.GetByHash(ctx, hashKey(raw))
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > touchInterval {
+		if err := s.keys.TouchLastUsed(ctx, key.ID, now); err != nil {
+			s.log.WarnContext(ctx, "failed to record api key use", "error", err, "key_id", key.ID)
+		}
+	}
+	return key, nil
+}
+
+func (s *service) ListTriggers(ctx context.Context, triggerType integrationDomain.TriggerType, limit int) ([]integrationDomain.TriggerEvent, error) {
+	if limit <= 0 || limit > maxLimit {
+		limit = defaultLimit
+	}
+	return s.triggers.List(ctx, triggerType, limit)
+}
+
+// SendMessage sends a WhatsApp text and records it in the contact's
+// conversation, creating the conversation if needed.
+func (s *service) SendMessage(ctx context.Context, key *integrationDomain.APIKey, msg integrationDomain.SendMessage) (*integrationDomain.MessageSent, error) {
+	phone := normalizePhone(msg.Phone)
+	if !phoneNumber.MatchString(phone) {
+		return nil, fmt.Errorf("%w: phone must be an international number with 7-15 digits", ErrInvalidRequest)
+	}
+	text := strings.TrimSpace(msg.Text)
+	if text == "" || utf8.RuneCountInString(text) > maxMessageChars {
+		return nil, fmt.Errorf("%w: text must be 1-%d characters", ErrInvalidRequest, maxMessageChars)
+	}
+	if s.sender == nil {
+		return nil, ErrSendingUnavailable
+	}
+
+	conv, err := s.convSvc.GetOrCreateConversation(ctx, "", phone, strings.TrimSpace(msg.ContactName))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sender.SendText(ctx, phone, text); err != nil {
+		return nil, err
+	}
+	saved, err := s.convSvc.SaveOutgoingMessage(ctx, conv.ID, text, "")
+	if err != nil {
+		return nil, err
+	}
+	return &integrationDomain.MessageSent{ConversationID: conv.ID, MessageID: saved.ID}, nil
+}
+
+// CreateDocument adds a document on behalf of the key's creator. It is a
+// draft until an admin publishes it, so a leaked key cannot change answers.
+func (s *service) CreateDocument(ctx context.Context, key *integrationDomain.APIKey, title, content, source string) (string, error) {
+	title, content = strings.TrimSpace(title), strings.TrimSpace(content)
+	if title == "" || content == "" {
+		return "", fmt.Errorf("%w: title and content are required", ErrInvalidRequest)
+	}
+	if source == "" {
+		source = "integration"
+	}
+
+	return s.docSvc.CreateDocument(ctx, documentDomain.UserContext{UserID: key.CreatedBy}, &documentDomain.Document{
+		Title:    title,
+		Content:  content,
+		Source:   source,
+		IsActive: true,
+	})
+}
+
+func hashKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizePhone strips formatting so numbers match the ones WhatsApp
+// reports, which are digits only.
+func normalizePhone(phone string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		if strings.ContainsRune("+ -().", r) {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(phone))
+}
@@ -0,0 +1,275 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	integrationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/integration"
+)
+
+type mockKeyRepo struct {
+	keys    map[string]*integrationDomain.APIKey
+	touched int
+}
+
+func newMockKeyRepo() *mockKeyRepo {
+	return &mockKeyRepo{keys: map[string]*integrationDomain.APIKey{}}
+}
+
+func (m *mockKeyRepo) Create(ctx context.Context, key *integrationDomain.APIKey) (string, error) {
+	key.ID = "key-1"
+	m.keys[key.ID] = key
+	return key.ID, nil
+}
+
+func (m *mockKeyRepo) GetByID(ctx context.Context, id string) (*integrationDomain.APIKey, error) {
+	return m.keys[id], nil
+}
+
+func (m *mockKeyRepo) Update(ctx context.Context, key *integrationDomain.APIKey) error {
+	m.keys[key.ID] = key
+	return nil
+}
+
+func (m *mockKeyRepo) IncrementUsage(ctx context.Context, id string, sessions, questions int64) error {
+	m.keys[id].SessionCount += sessions
+	m.keys[id].QuestionCount += questions
+	return nil
+}
+
+func (m *mockKeyRepo) GetByHash(ctx context.Context, hash string) (*integrationDomain.APIKey, error) {
+	for _, key := range m.keys {
+		if key.KeyHash == hash {
+			return key, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockKeyRepo) List(ctx context.Context) ([]integrationDomain.APIKey, error) {
+	return nil, nil
+}
+
+func (m *mockKeyRepo) Delete(ctx context.Context, id string) (bool, error) {
+	_, ok := m.keys[id]
+	delete(m.keys, id)
+	return ok, nil
+}
+
+func (m *mockKeyRepo) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	m.touched++
+	m.keys[id].LastUsedAt = &at
+	return nil
+}
+
+// mockConvService implements the conversation methods SendMessage uses.
+type mockConvService struct {
+	conversationDomain.Service
+	saved []string
+}
+
+func (m *mockConvService) GetOrCreateConversation(ctx context.Context, userID, phoneNumber, contactName string) (*conversationDomain.Conversation, error) {
+	return &conversationDomain.Conversation{ID: "conv-" + phoneNumber, PhoneNumber: phoneNumber}, nil
+}
+
+func (m *mockConvService) SaveOutgoingMessage(ctx context.Context, conversationID, content, ragAnswer string) (*conversationDomain.Message, error) {
+	m.saved = append(m.saved, content)
+	return &conversationDomain.Message{ID: "msg-1", ConversationID: conversationID}, nil
+}
+
+// mockDocService implements the document method CreateDocument uses.
+type mockDocService struct {
+	documentDomain.Service
+	userCtx documentDomain.UserContext
+	doc     *documentDomain.Document
+}
+
+func (m *mockDocService) CreateDocument(ctx context.Context, userCtx documentDomain.UserContext, doc *documentDomain.Document) (string, error) {
+	m.userCtx, m.doc = userCtx, doc
+	return "doc-1", nil
+}
+
+type mockSender struct {
+	to, body string
+}
+
+func (m *mockSender) SendText(ctx context.Context, to, body string) error {
+	m.to, m.body = to, body
+	return nil
+}
+
+func TestCreateAndAuthenticateAPIKey(t *testing.T) {
+	repo := newMockKeyRepo()
+	svc := NewService(ServiceConfig{KeyRepo: repo})
+	ctx := context.Background()
+
+	key, raw, err := svc.CreateAPIKey(ctx, "admin-1", integrationDomain.KeySettings{Name: " Zapier "})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.HasPrefix(raw, "lrk_") || !strings.HasPrefix(raw, key.Prefix) {
+		t.Errorf("Expected lrk_ key starting with prefix %q, got %q", key.Prefix, raw)
+	}
+	if key.KeyHash == "" || strings.Contains(key.KeyHash, raw) {
+		t.Error("Expected only a hash of the key to be stored")
+	}
+	if key.Name != "Zapier" {
+		t.Errorf("Expected trimmed name, got %q", key.Name)
+	}
+
+	got, err := svc.Authenticate(ctx, raw)
+	if err != nil || got.ID != key.ID {
+		t.Fatalf("Expected key %s, got %+v (%v)", key.ID, got, err)
+	}
+	_, _ = svc.Authenticate(ctx, raw)
+	if repo.touched != 1 {
+		t.Errorf("Expected last use recorded once per interval, got %d writes", repo.touched)
+	}
+
+	if _, err := svc.Authenticate(ctx, raw+"x"); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("Expected ErrInvalidAPIKey, got %v", err)
+	}
+	if err := svc.DeleteAPIKey(ctx, "admin-1", key.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := svc.Authenticate(ctx, raw); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("Expected deleted key to be rejected, got %v", err)
+	}
+	if err := svc.DeleteAPIKey(ctx, "admin-1", key.ID); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("Expected ErrAPIKeyNotFound, got %v", err)
+	}
+}
+
+func TestWidgetKeysOnlyAuthenticateWidgets(t *testing.T) {
+	svc := NewService(ServiceConfig{KeyRepo: newMockKeyRepo()})
+	ctx := context.Background()
+
+	settings := integrationDomain.KeySettings{Name: "Marketing site", Kind: integrationDomain.KeyKindWidget}
+	if _, _, err := svc.CreateAPIKey(ctx, "admin-1", settings); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for a widget key without origins, got %v", err)
+	}
+	settings.AllowedOrigins = []string{"HTTPS://www.Example.com/", "https://www.example.com"}
+	key, raw, err := svc.CreateAPIKey(ctx, "admin-1", settings)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.HasPrefix(raw, "lrw_") || key.Kind != integrationDomain.KeyKindWidget {
+		t.Errorf("Expected lrw_ widget key, got %q (%s)", raw, key.Kind)
+	}
+
+	if _, err := svc.Authenticate(ctx, raw); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("Expected widget key to be rejected for integrations, got %v", err)
+	}
+	if got, err := svc.AuthenticateWidget(ctx, raw); err != nil || got.ID != key.ID {
+		t.Errorf("Expected widget key %s, got %+v (%v)", key.ID, got, err)
+	}
+	if len(key.AllowedOrigins) != 1 || key.AllowedOrigins[0] != "https://www.example.com" {
+		t.Errorf("Expected one normalized origin, got %v", key.AllowedOrigins)
+	}
+	if _, _, err := svc.CreateAPIKey(ctx, "admin-1", integrationDomain.KeySettings{Name: "Other", Kind: "admin"}); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for unknown kind, got %v", err)
+	}
+}
+
+func TestUpdateAndRotateAPIKey(t *testing.T) {
+	svc := NewService(ServiceConfig{KeyRepo: newMockKeyRepo()})
+	ctx := context.Background()
+	key, raw, _ := svc.CreateAPIKey(ctx, "admin-1", integrationDomain.KeySettings{
+		Name: "Site", Kind: integrationDomain.KeyKindWidget, AllowedOrigins: []string{"https://www.example.com"},
+	})
+
+	for _, origin := range []string{"www.example.com", "https://www.example.com/chat", "ftp://example.com"} {
+		settings := integrationDomain.KeySettings{Name: "Site", AllowedOrigins: []string{origin}}
+		if _, err := svc.UpdateAPIKey(ctx, "admin-1", key.ID, settings); !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("Expected ErrInvalidRequest for origin %q, got %v", origin, err)
+		}
+	}
+	if _, err := svc.UpdateAPIKey(ctx, "admin-1", key.ID, integrationDomain.KeySettings{
+		Name: "Site", Kind: integrationDomain.KeyKindIntegration, AllowedOrigins: []string{"https://example.com"},
+	}); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for a kind change, got %v", err)
+	}
+	updated, err := svc.UpdateAPIKey(ctx, "admin-1", key.ID, integrationDomain.KeySettings{
+		Name: "Site", AllowedOrigins: []string{"https://example.com", "http://localhost:3000"},
+	})
+	if err != nil || len(updated.AllowedOrigins) != 2 {
+		t.Fatalf("Expected 2 origins, got %+v (%v)", updated, err)
+	}
+
+	rotated, newRaw, err := svc.RotateAPIKey(ctx, "admin-1", key.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if newRaw == raw || !strings.HasPrefix(newRaw, "lrw_") || rotated.RotatedAt == nil {
+		t.Errorf("Expected a new widget secret, got %q (%+v)", newRaw, rotated)
+	}
+	if _, err := svc.AuthenticateWidget(ctx, raw); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("Expected the old secret to be rejected, got %v", err)
+	}
+	if got, err := svc.AuthenticateWidget(ctx, newRaw); err != nil || got.ID != key.ID {
+		t.Errorf("Expected the new secret to resolve key %s, got %+v (%v)", key.ID, got, err)
+	}
+	if _, _, err := svc.RotateAPIKey(ctx, "admin-1", "missing"); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("Expected ErrAPIKeyNotFound, got %v", err)
+	}
+}
+
+func TestSendMessage(t *testing.T) {
+	conv, sender := &mockConvService{}, &mockSender{}
+	svc := NewService(ServiceConfig{ConvSvc: conv, Sender: sender})
+	key := &integrationDomain.APIKey{ID: "key-1"}
+
+	sent, err := svc.SendMessage(context.Background(), key, integrationDomain.SendMessage{Phone: "+1 (555) 010-0100", Text: " Your order shipped "})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if sender.to != "15550100100" || sender.body != "Your order shipped" {
+		t.Errorf("Expected normalized phone and trimmed text, got %q %q", sender.to, sender.body)
+	}
+	if sent.ConversationID != "conv-15550100100" || len(conv.saved) != 1 {
+		t.Errorf("Expected message recorded in the contact's conversation, got %+v", sent)
+	}
+}
+
+func TestSendMessageValidation(t *testing.T) {
+	key := &integrationDomain.APIKey{ID: "key-1"}
+	ctx := context.Background()
+	svc := NewService(ServiceConfig{ConvSvc: &mockConvService{}, Sender: &mockSender{}})
+
+	if _, err := svc.SendMessage(ctx, key, integrationDomain.SendMessage{Phone: "call me", Text: "hi"}); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for bad phone, got %v", err)
+	}
+	if _, err := svc.SendMessage(ctx, key, integrationDomain.SendMessage{Phone: "15550100100", Text: " "}); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for empty text, got %v", err)
+	}
+
+	noSender := NewService(ServiceConfig{ConvSvc: &mockConvService{}})
+	if _, err := noSender.SendMessage(ctx, key, integrationDomain.SendMessage{Phone: "15550100100", Text: "hi"}); !errors.Is(err, ErrSendingUnavailable) {
+		t.Errorf("Expected ErrSendingUnavailable, got %v", err)
+	}
+}
+
+func TestCreateDocumentIsDraftOfKeyCreator(t *testing.T) {
+	docs := &mockDocService{}
+	svc := NewService(ServiceConfig{DocSvc: docs})
+	key := &integrationDomain.APIKey{ID: "key-1", CreatedBy: "admin-1"}
+
+	if _, err := svc.CreateDocument(context.Background(), key, "Returns", "30 days", ""); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if docs.userCtx.UserID != "admin-1" || docs.userCtx.IsAdmin {
+		t.Errorf("Expected non-admin context of the key creator, got %+v", docs.userCtx)
+	}
+	if docs.doc.Source != "integration" {
+		t.Errorf("Expected default source, got %q", docs.doc.Source)
+	}
+
+	if _, err := svc.CreateDocument(context.Background(), key, "", "x", ""); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest, got %v", err)
+	}
+}
@@ -0,0 +1,368 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	integrationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/integration"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+)
+
+var (
+	ErrWebhookNotFound = errors.New("webhook not found")
+	// errPrivateDestination refuses a connection to an address inside the
+	// deployment's network, such as loopback or a cloud metadata service.
+	errPrivateDestination = errors.New("destination is not a public address")
+)
+
+const (
+	webhookSecretPrefix = "whsec_"
+	maxWebhookNameLen   = 100
+	// attemptTimeout bounds one delivery attempt; deliveryTimeout bounds an
+	// event's delivery to one webhook, retries included.
+	attemptTimeout  = 10 * time.Second
+	deliveryTimeout = 2 * time.Minute
+	// dispatchWorkers is how many webhooks an event is delivered to at once.
+	dispatchWorkers = 8
+)
+
+// retryBackoff is the wait before each retry of a failed delivery.
+var retryBackoff = []time.Duration{2 * time.Second, 10 * time.Second}
+
+func (s *service) CreateWebhook(ctx context.Context, adminID string, settings integrationDomain.WebhookSettings) (*integrationDomain.Webhook, string, error) {
+	hook := &integrationDomain.Webhook{Active: true, CreatedBy: adminID}
+	if err := applyWebhookSettings(hook, settings); err != nil {
+		return nil, "", err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, "", err
+	}
+	hook.Secret = secret
+
+	id, err := s.webhooks.Create(ctx, hook)
+	if err != nil {
+		return nil, "", err
+	}
+	hook.ID = id
+	return hook, secret, nil
+}
+
+func (s *service) ListWebhooks(ctx context.Context) ([]integrationDomain.Webhook, error) {
+	return s.webhooks.List(ctx)
+}
+
+func (s *service) GetWebhook(ctx context.Context, id string) (*integrationDomain.Webhook, error) {
+	hook, err := s.webhooks.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if hook == nil {
+		return nil, ErrWebhookNotFound
+	}
+	return hook, nil
+}
+
+func (s *service) UpdateWebhook(ctx context.Context, id string, settings integrationDomain.WebhookSettings) (*integrationDomain.Webhook, error) {
+	hook, err := s.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyWebhookSettings(hook, settings); err != nil {
+		return nil, err
+	}
+	if err := s.webhooks.Update(ctx, hook); err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+func (s *service) DeleteWebhook(ctx context.Context, id string) error {
+	deleted, err := s.webhooks.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// TestWebhook delivers once, without retries, so integrators see the
+// outcome right away. Inactive webhooks can be tested too.
+func (s *service) TestWebhook(ctx context.Context, id, event string) (*integrationDomain.WebhookDelivery, error) {
+	hook, err := s.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if event == "" {
+		event = hook.Events[0]
+	}
+	schema, ok := webhookSchemas[hook.SchemaVersion][event]
+	if !ok {
+		return nil, fmt.Errorf("%w: schema version %d has no event %q", ErrInvalidRequest, hook.SchemaVersion, event)
+	}
+
+	payloadID, err := newPayloadID()
+	if err != nil {
+		return nil, err
+	}
+	p := &integrationDomain.WebhookPayload{ID: payloadID, Type: event, SchemaVersion: hook.SchemaVersion, CreatedAt: time.Now(), Test: true, Data: schema.sample}
+	delivery := s.poster.send(ctx, hook, p, nil)
+	if err := s.webhooks.RecordDelivery(ctx, hook.ID, time.Now(), delivery.StatusCode, delivery.Error); err != nil {
+		s.log.WarnContext(ctx, "failed to record webhook delivery", "error", err, "webhook_id", hook.ID)
+	}
+	return delivery, nil
+}
+
+// applyWebhookSettings validates settings and sets them on hook.
+func applyWebhookSettings(hook *integrationDomain.Webhook, settings integrationDomain.WebhookSettings) error {
+	name := strings.TrimSpace(settings.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxWebhookNameLen {
+		return fmt.Errorf("%w: name must be 1-%d characters", ErrInvalidRequest, maxWebhookNameLen)
+	}
+	u, err := url.Parse(strings.TrimSpace(settings.URL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+		return fmt.Errorf("%w: url must be an http(s) URL", ErrInvalidRequest)
+	}
+	if ip, err := netip.ParseAddr(u.Hostname()); strings.EqualFold(u.Hostname(), "localhost") || (err == nil && !publicAddr(ip)) {
+		return fmt.Errorf("%w: url must point to a public address", ErrInvalidRequest)
+	}
+
+	version := settings.SchemaVersion
+	if version == 0 {
+		version = CurrentSchemaVersion
+	}
+	schemas, ok := webhookSchemas[version]
+	if !ok {
+		return fmt.Errorf("%w: unknown schema_version %d", ErrInvalidRequest, settings.SchemaVersion)
+	}
+	var subscribed []string
+	for _, event := range settings.Events {
+		if _, ok := schemas[event]; !ok {
+			return fmt.Errorf("%w: schema version %d has no event %q", ErrInvalidRequest, version, event)
+		}
+		if !slices.Contains(subscribed, event) {
+			subscribed = append(subscribed, event)
+		}
+	}
+	if len(subscribed) == 0 {
+		return fmt.Errorf("%w: at least one event is required", ErrInvalidRequest)
+	}
+
+	hook.Name = name
+	hook.URL = u.String()
+	hook.Events = subscribed
+	hook.SchemaVersion = version
+	if settings.Active != nil {
+		hook.Active = *settings.Active
+	}
+	return nil
+}
+
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return webhookSecretPrefix + hex.EncodeToString(secret), nil
+}
+
+func newPayloadID() (string, error) {
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return "evt_" + hex.EncodeToString(id), nil
+}
+
+// webhookSender posts signed payloads.
+type webhookSender struct {
+	client *http.Client
+}
+
+// newWebhookSender makes a sender that only connects to public addresses.
+// The check runs on the address actually dialed, so a hostname resolving
+// to a private one, or a redirect to it, is refused too.
+func newWebhookSender() *webhookSender {
+	dialer := &net.Dialer{Timeout: attemptTimeout, Control: refusePrivate}
+	transport := &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: attemptTimeout}
+	return &webhookSender{client: &http.Client{Timeout: attemptTimeout, Transport: transport}}
+}
+
+func refusePrivate(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !publicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", errPrivateDestination, addrPort.Addr())
+	}
+	return nil
+}
+
+// publicAddr reports whether ip is reachable on the internet rather than
+// only from inside the deployment.
+func publicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast()
+}
+
+// send posts p to hook, retrying after each wait in backoff while the
+// receiver is unreachable, rate limits or fails with a 5xx. The body is
+// signed as X-LucidRAG-Signature: sha256=<hex HMAC-SHA256 of the body>.
+func (w *webhookSender) send(ctx context.Context, hook *integrationDomain.Webhook, p *integrationDomain.WebhookPayload, backoff []time.Duration) *integrationDomain.WebhookDelivery {
+	delivery := &integrationDomain.WebhookDelivery{WebhookID: hook.ID, PayloadID: p.ID, Event: p.Type, SchemaVersion: p.SchemaVersion}
+	start := time.Now()
+	defer func() { delivery.DurationMs = time.Since(start).Milliseconds() }()
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	mac := hmac.New(sha256.New, []byte(hook.Secret))
+	mac.Write(body)
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	for attempt := 0; ; attempt++ {
+		delivery.Attempts++
+		retry := w.post(ctx, hook.URL, body, signature, p, delivery)
+		if delivery.Delivered || !retry || attempt >= len(backoff) {
+			return delivery
+		}
+		select {
+		case <-ctx.Done():
+			return delivery
+		case <-time.After(backoff[attempt]):
+		}
+	}
+}
+
+// post makes one delivery attempt, recording its outcome, and reports
+// whether a failure is worth retrying.
+func (w *webhookSender) post(ctx context.Context, target string, body []byte, signature string, p *integrationDomain.WebhookPayload, delivery *integrationDomain.WebhookDelivery) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-LucidRAG-Event", p.Type)
+	req.Header.Set("X-LucidRAG-Delivery", p.ID)
+	req.Header.Set("X-LucidRAG-Schema-Version", strconv.Itoa(p.SchemaVersion))
+	req.Header.Set("X-LucidRAG-Signature", signature)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		delivery.StatusCode = 0
+		delivery.Error = fmt.Sprintf("request failed: %v", err)
+		return true
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// The response body is not kept: it is the receiver's to read, not
+	// something to show back to whoever set the URL.
+	delivery.StatusCode = resp.StatusCode
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		delivery.Delivered = true
+		delivery.Error = ""
+		return false
+	}
+	delivery.Error = fmt.Sprintf("status %d", resp.StatusCode)
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// WebhookDispatcher delivers domain events to the webhooks subscribed to
+// them.
+type WebhookDispatcher struct {
+	repo    integrationDomain.WebhookRepository
+	sender  *webhookSender
+	backoff []time.Duration
+	log     *logger.Logger
+}
+
+func NewWebhookDispatcher(repo integrationDomain.WebhookRepository, log *logger.Logger) *WebhookDispatcher {
+	return &WebhookDispatcher{repo: repo, sender: newWebhookSender(), backoff: retryBackoff, log: log}
+}
+
+func (d *WebhookDispatcher) Subscribe(bus *events.Bus) {
+	bus.Subscribe(d.handle, WebhookEvents()...)
+}
+
+func (d *WebhookDispatcher) handle(ctx context.Context, event events.Event) {
+	go d.dispatch(context.WithoutCancel(ctx), event)
+}
+
+// dispatch delivers event to each subscribed webhook in its schema
+// version, a few webhooks at a time. Each webhook has its own time budget,
+// so a slow receiver does not hold up or time out the others. Every
+// webhook gets the same payload ID for the event.
+func (d *WebhookDispatcher) dispatch(ctx context.Context, event events.Event) {
+	listCtx, cancel := context.WithTimeout(ctx, attemptTimeout)
+	hooks, err := d.repo.ListActive(listCtx, event.EventName())
+	cancel()
+	if err != nil {
+		d.log.ErrorContext(ctx, "failed to list webhooks", "error", err, "event", event.EventName())
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+	id, err := newPayloadID()
+	if err != nil {
+		d.log.ErrorContext(ctx, "failed to create webhook payload id", "error", err)
+		return
+	}
+
+	now := time.Now()
+	slots := make(chan struct{}, dispatchWorkers)
+	var wg sync.WaitGroup
+	for i := range hooks {
+		hook := &hooks[i]
+		p := payload(id, event, hook.SchemaVersion, now)
+		if p == nil {
+			continue
+		}
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-slots; wg.Done() }()
+			d.deliver(ctx, hook, p)
+		}()
+	}
+	wg.Wait()
+}
+
+// deliver sends p to hook within its own time budget and records the
+// outcome.
+func (d *WebhookDispatcher) deliver(ctx context.Context, hook *integrationDomain.Webhook, p *integrationDomain.WebhookPayload) {
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+	delivery := d.sender.send(ctx, hook, p, d.backoff)
+	if !delivery.Delivered {
+		d.log.WarnContext(ctx, "webhook delivery failed", "webhook_id", hook.ID, "event", p.Type, "attempts", delivery.Attempts, "error", delivery.Error)
+	}
+	if err := d.repo.RecordDelivery(ctx, hook.ID, time.Now(), delivery.StatusCode, delivery.Error); err != nil {
+		d.log.WarnContext(ctx, "failed to record webhook delivery", "error", err, "webhook_id", hook.ID)
+	}
+}
@@ -0,0 +1,324 @@
+package integration
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	integrationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/integration"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+)
+
+type mockWebhookRepo struct {
+	mu         sync.Mutex
+	hooks      map[string]*integrationDomain.Webhook
+	deliveries map[string]int
+}
+
+func newMockWebhookRepo(hooks ...integrationDomain.Webhook) *mockWebhookRepo {
+	m := &mockWebhookRepo{hooks: map[string]*integrationDomain.Webhook{}, deliveries: map[string]int{}}
+	for i := range hooks {
+		m.hooks[hooks[i].ID] = &hooks[i]
+	}
+	return m
+}
+
+func (m *mockWebhookRepo) Create(ctx context.Context, hook *integrationDomain.Webhook) (string, error) {
+	hook.ID = "hook-new"
+	m.hooks[hook.ID] = hook
+	return hook.ID, nil
+}
+
+func (m *mockWebhookRepo) GetByID(ctx context.Context, id string) (*integrationDomain.Webhook, error) {
+	return m.hooks[id], nil
+}
+
+func (m *mockWebhookRepo) List(ctx context.Context) ([]integrationDomain.Webhook, error) {
+	return nil, nil
+}
+
+func (m *mockWebhookRepo) ListActive(ctx context.Context, event string) ([]integrationDomain.Webhook, error) {
+	var hooks []integrationDomain.Webhook
+	for _, id := range slices.Sorted(maps.Keys(m.hooks)) {
+		if hook := m.hooks[id]; hook.Active && slices.Contains(hook.Events, event) {
+			hooks = append(hooks, *hook)
+		}
+	}
+	return hooks, nil
+}
+
+func (m *mockWebhookRepo) Update(ctx context.Context, hook *integrationDomain.Webhook) error {
+	m.hooks[hook.ID] = hook
+	return nil
+}
+
+func (m *mockWebhookRepo) Delete(ctx context.Context, id string) (bool, error) {
+	_, ok := m.hooks[id]
+	delete(m.hooks, id)
+	return ok, nil
+}
+
+func (m *mockWebhookRepo) RecordDelivery(ctx context.Context, id string, at time.Time, status int, errMsg string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deliveries[id] = status
+	return nil
+}
+
+// receiver records the webhook requests it gets, answering with the
+// statuses in turn and 200 after them.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+	status := http.StatusOK
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func active(b bool) *bool {
+	return &b
+}
+
+func TestWebhookSchemasMatchPayloads(t *testing.T) {
+	samples := map[string]events.Event{
+		events.NameMessageReceived:      events.MessageReceived{MessageID: "m", ConversationID: "c"},
+		events.NameMessageSent:          events.MessageSent{MessageID: "m", ConversationID: "c"},
+		events.NameConversationAssigned: events.ConversationAssigned{ConversationID: "c"},
+		events.NameConversationState:    events.ConversationStateChanged{ConversationID: "c"},
+	}
+	svc := &service{}
+
+	for _, schema := range svc.WebhookSchemas() {
+		properties := schema.Schema["properties"].(map[string]any)
+		event, ok := samples[schema.Event]
+		if !ok {
+			t.Errorf("%s: no sample event to check", schema.Event)
+			continue
+		}
+		p := payload("evt_1", event, schema.SchemaVersion, time.Now())
+		if p == nil {
+			t.Fatalf("%s v%d: expected a payload", schema.Event, schema.SchemaVersion)
+		}
+
+		// Both the delivered data and the sample have every field the
+		// schema lists, with its type, and nothing else.
+		for name, data := range map[string]any{"payload": p.Data, "sample": schema.Sample} {
+			raw, _ := json.Marshal(data)
+			var fields map[string]any
+			if err := json.Unmarshal(raw, &fields); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := slices.Sorted(maps.Keys(fields)), slices.Sorted(maps.Keys(properties)); !slices.Equal(got, want) {
+				t.Errorf("%s v%d %s: expected fields %v, got %v", schema.Event, schema.SchemaVersion, name, want, got)
+			}
+			for field, value := range fields {
+				want := properties[field].(map[string]any)["type"]
+				if got := jsonType(value); got != want {
+					t.Errorf("%s v%d %s: %s is %s, schema says %s", schema.Event, schema.SchemaVersion, name, field, got, want)
+				}
+			}
+		}
+	}
+	if got := WebhookEvents(); len(got) != len(samples) {
+		t.Errorf("Expected %d events, got %v", len(samples), got)
+	}
+}
+
+func jsonType(value any) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	}
+	return "unknown"
+}
+
+func TestWebhookSettings(t *testing.T) {
+	repo := newMockWebhookRepo()
+	svc := NewService(ServiceConfig{WebhookRepo: repo})
+	ctx := context.Background()
+
+	hook, secret, err := svc.CreateWebhook(ctx, "admin-1", integrationDomain.WebhookSettings{
+		Name: " CRM ", URL: "https://crm.example.com/hooks", Events: []string{"message.received", "message.received", "message.sent"},
+	})
+	if err != nil {
+		t.Fatalf("CreateWebhook failed: %v", err)
+	}
+	if hook.Name != "CRM" || len(hook.Events) != 2 || hook.SchemaVersion != CurrentSchemaVersion || !hook.Active || hook.CreatedBy != "admin-1" {
+		t.Errorf("Expected a normalized active webhook, got %+v", hook)
+	}
+	if len(secret) < 40 || secret != hook.Secret {
+		t.Errorf("Expected the signing secret returned, got %q", secret)
+	}
+
+	updated, err := svc.UpdateWebhook(ctx, hook.ID, integrationDomain.WebhookSettings{
+		Name: "CRM", URL: "https://crm.example.com/v2", Events: []string{"conversation.assigned"}, Active: active(false),
+	})
+	if err != nil {
+		t.Fatalf("UpdateWebhook failed: %v", err)
+	}
+	if updated.Active || updated.Secret != secret || updated.URL != "https://crm.example.com/v2" {
+		t.Errorf("Expected the webhook paused with its secret kept, got %+v", updated)
+	}
+
+	tests := []struct {
+		name     string
+		settings integrationDomain.WebhookSettings
+	}{
+		{"no name", integrationDomain.WebhookSettings{URL: "https://a.example.com", Events: []string{"message.sent"}}},
+		{"bad url", integrationDomain.WebhookSettings{Name: "x", URL: "ftp://a.example.com", Events: []string{"message.sent"}}},
+		{"credentials in url", integrationDomain.WebhookSettings{Name: "x", URL: "https://u:p@a.example.com", Events: []string{"message.sent"}}},
+		{"loopback url", integrationDomain.WebhookSettings{Name: "x", URL: "http://127.0.0.1:8080/hooks", Events: []string{"message.sent"}}},
+		{"metadata url", integrationDomain.WebhookSettings{Name: "x", URL: "http://169.254.169.254/latest", Events: []string{"message.sent"}}},
+		{"localhost url", integrationDomain.WebhookSettings{Name: "x", URL: "http://localhost/hooks", Events: []string{"message.sent"}}},
+		{"no events", integrationDomain.WebhookSettings{Name: "x", URL: "https://a.example.com"}},
+		{"unknown event", integrationDomain.WebhookSettings{Name: "x", URL: "https://a.example.com", Events: []string{"document.created"}}},
+		{"unknown version", integrationDomain.WebhookSettings{Name: "x", URL: "https://a.example.com", Events: []string{"message.sent"}, SchemaVersion: 99}},
+	}
+	for _, tt := range tests {
+		if _, _, err := svc.CreateWebhook(ctx, "admin-1", tt.settings); !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("%s: expected ErrInvalidRequest, got %v", tt.name, err)
+		}
+	}
+
+	if err := svc.DeleteWebhook(ctx, "missing"); !errors.Is(err, ErrWebhookNotFound) {
+		t.Errorf("Expected ErrWebhookNotFound, got %v", err)
+	}
+}
+
+func TestTestWebhookSignsSample(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusServiceUnavailable}}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	repo := newMockWebhookRepo(integrationDomain.Webhook{
+		ID: "hook-1", URL: server.URL, Events: []string{"conversation.state_changed"}, SchemaVersion: 1, Secret: "whsec_test",
+	})
+	svc := NewService(ServiceConfig{WebhookRepo: repo})
+	svc.(*service).poster.client = server.Client()
+	ctx := context.Background()
+
+	// A test delivery is not retried.
+	delivery, err := svc.TestWebhook(ctx, "hook-1", "")
+	if err != nil {
+		t.Fatalf("TestWebhook failed: %v", err)
+	}
+	if delivery.Delivered || delivery.Attempts != 1 || delivery.StatusCode != http.StatusServiceUnavailable || repo.deliveries["hook-1"] != http.StatusServiceUnavailable {
+		t.Errorf("Expected one failed attempt recorded, got %+v", delivery)
+	}
+
+	delivery, err = svc.TestWebhook(ctx, "hook-1", "message.sent")
+	if err != nil {
+		t.Fatalf("TestWebhook failed: %v", err)
+	}
+	if !delivery.Delivered || delivery.Event != "message.sent" {
+		t.Errorf("Expected the sample delivered, got %+v", delivery)
+	}
+
+	req, body := rc.requests[1], rc.bodies[1]
+	mac := hmac.New(sha256.New, []byte("whsec_test"))
+	mac.Write(body)
+	if got, want := req.Header.Get("X-LucidRAG-Signature"), "sha256="+hex.EncodeToString(mac.Sum(nil)); got != want {
+		t.Errorf("Expected signature %s, got %s", want, got)
+	}
+	if req.Header.Get("X-LucidRAG-Event") != "message.sent" || req.Header.Get("X-LucidRAG-Schema-Version") != "1" {
+		t.Errorf("Expected the event headers, got %v", req.Header)
+	}
+	var p struct {
+		ID            string         `json:"id"`
+		Type          string         `json:"type"`
+		SchemaVersion int            `json:"schema_version"`
+		Test          bool           `json:"test"`
+		Data          map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(body, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != req.Header.Get("X-LucidRAG-Delivery") || p.Type != "message.sent" || p.SchemaVersion != 1 || !p.Test || p.Data["from_bot"] != true {
+		t.Errorf("Expected the test sample payload, got %s", body)
+	}
+
+	if _, err := svc.TestWebhook(ctx, "hook-1", "document.created"); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("Expected ErrInvalidRequest for an unknown event, got %v", err)
+	}
+}
+
+func TestDispatcherRetriesAndFansOut(t *testing.T) {
+	flaky := &receiver{statuses: []int{http.StatusBadGateway, http.StatusTooManyRequests}}
+	flakyServer := httptest.NewServer(flaky)
+	defer flakyServer.Close()
+	broken := &receiver{statuses: []int{http.StatusBadRequest}}
+	brokenServer := httptest.NewServer(broken)
+	defer brokenServer.Close()
+
+	repo := newMockWebhookRepo(
+		integrationDomain.Webhook{ID: "a", URL: flakyServer.URL, Events: []string{"message.received"}, SchemaVersion: 1, Active: true, Secret: "a"},
+		integrationDomain.Webhook{ID: "b", URL: brokenServer.URL, Events: []string{"message.received"}, SchemaVersion: 1, Active: true, Secret: "b"},
+		integrationDomain.Webhook{ID: "paused", URL: brokenServer.URL, Events: []string{"message.received"}, SchemaVersion: 1, Secret: "c"},
+		integrationDomain.Webhook{ID: "other", URL: brokenServer.URL, Events: []string{"message.sent"}, SchemaVersion: 1, Active: true, Secret: "d"},
+	)
+	d := NewWebhookDispatcher(repo, logger.New(logger.Options{Level: "error"}))
+	d.sender.client = flakyServer.Client()
+	d.backoff = []time.Duration{time.Millisecond, time.Millisecond}
+
+	d.dispatch(context.Background(), events.MessageReceived{MessageID: "msg-1", ConversationID: "conv-1", Channel: "whatsapp", Content: "hi"})
+
+	if len(flaky.requests) != 3 || repo.deliveries["a"] != http.StatusOK {
+		t.Errorf("Expected 2 retries then success, got %d requests, status %d", len(flaky.requests), repo.deliveries["a"])
+	}
+	// A 4xx is the receiver refusing the payload; retrying will not help.
+	if len(broken.requests) != 1 || repo.deliveries["b"] != http.StatusBadRequest {
+		t.Errorf("Expected one attempt for a 400, got %d requests", len(broken.requests))
+	}
+	if _, ok := repo.deliveries["paused"]; ok {
+		t.Error("Expected paused webhooks skipped")
+	}
+
+	var first, second integrationDomain.WebhookPayload
+	_ = json.Unmarshal(flaky.bodies[2], &first)
+	_ = json.Unmarshal(broken.bodies[0], &second)
+	if first.ID == "" || first.ID != second.ID || first.Type != "message.received" || first.Test {
+		t.Errorf("Expected one payload ID per event, got %q and %q", first.ID, second.ID)
+	}
+}
+
+func TestWebhookRefusesPrivateDestinations(t *testing.T) {
+	rc := &receiver{}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	hook := &integrationDomain.Webhook{ID: "hook-1", URL: server.URL, Secret: "whsec_test"}
+	p := &integrationDomain.WebhookPayload{ID: "evt_1", Type: "message.sent", SchemaVersion: 1}
+	delivery := newWebhookSender().send(context.Background(), hook, p, nil)
+	if delivery.Delivered || len(rc.requests) != 0 || !strings.Contains(delivery.Error, errPrivateDestination.Error()) {
+		t.Errorf("Expected the loopback receiver refused, got %+v", delivery)
+	}
+}
@@ -0,0 +1,399 @@
+package product
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	productDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/product"
+)
+
+var (
+	ErrProductNotFound = errors.New("product not found")
+	ErrInvalidProduct  = errors.New("invalid product")
+	ErrDuplicateSKU    = errors.New("a product with that sku already exists")
+	// ErrInvalidCSV is a file that cannot be imported at all, such as one
+	// without a sku or name column.
+	ErrInvalidCSV = errors.New("invalid csv")
+)
+
+const (
+	maxNameLength        = 200
+	maxDescriptionLength = 4000
+	maxAttributes        = 50
+	maxAttributeLength   = 500
+	maxImages            = 10
+	maxImportRows        = 10000
+	// maxMatches bounds the products given to the model for one question.
+	maxMatches = 5
+	// searchCandidates is how many keyword hits are checked against the
+	// question.
+	searchCandidates = 10
+)
+
+var (
+	validSKU = regexp.MustCompile(`^[A-Z0-9][A-Z0-9._/-]{0,63}$`)
+	// skuToken finds SKU-like words in a question: letters and digits,
+	// possibly joined by . _ / -, with at least one digit.
+	skuToken      = regexp.MustCompile(`[A-Za-z0-9][A-Za-z0-9._/-]*[A-Za-z0-9]`)
+	validCurrency = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// Columns the CSV import reads into fields; any other column becomes an
+// attribute named after its header.
+const (
+	columnSKU         = "sku"
+	columnName        = "name"
+	columnDescription = "description"
+	columnPrice       = "price"
+	columnCurrency    = "currency"
+	columnStock       = "stock"
+	// columnImages holds image URLs separated by "|".
+	columnImages = "images"
+)
+
+type service struct {
+	repo productDomain.Repository
+}
+
+type ServiceConfig struct {
+	Repo productDomain.Repository
+}
+
+func NewService(cfg ServiceConfig) productDomain.Service {
+	return &service{
+		repo: cfg.Repo,
+	}
+}
+
+func (s *service) CreateProduct(ctx context.Context, adminID string, product *productDomain.Product) (string, error) {
+	if err := validate(product); err != nil {
+		return "", err
+	}
+	existing, err := s.repo.GetBySKU(ctx, product.SKU)
+	if err != nil {
+		return "", err
+	}
+	if existing != nil {
+		return "", ErrDuplicateSKU
+	}
+
+	product.ID = ""
+	product.CreatedBy = adminID
+	return s.repo.Create(ctx, product)
+}
+
+func (s *service) GetProduct(ctx context.Context, id string) (*productDomain.Product, error) {
+	product, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+	return product, nil
+}
+
+func (s *service) ListProducts(ctx context.Context, filter productDomain.Filter) ([]productDomain.Product, int64, error) {
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	filter.Query = strings.TrimSpace(filter.Query)
+	return s.repo.List(ctx, filter)
+}
+
+func (s *service) UpdateProduct(ctx context.Context, adminID string, product *productDomain.Product) error {
+	existing, err := s.GetProduct(ctx, product.ID)
+	if err != nil {
+		return err
+	}
+	if err := validate(product); err != nil {
+		return err
+	}
+	if product.SKU != existing.SKU {
+		other, err := s.repo.GetBySKU(ctx, product.SKU)
+		if err != nil {
+			return err
+		}
+		if other != nil {
+			return ErrDuplicateSKU
+		}
+	}
+	product.CreatedBy = existing.CreatedBy
+	product.CreatedAt = existing.CreatedAt
+	return s.repo.Update(ctx, product)
+}
+
+func (s *service) DeleteProduct(ctx context.Context, adminID, id string) error {
+	if _, err := s.GetProduct(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// ImportCSV reads a header row naming the columns, then one product per
+// row. Products are matched by SKU: new ones are created and existing ones
+// replaced, keeping their ID.
+func (s *service) ImportCSV(ctx context.Context, adminID string, r io.Reader) (*productDomain.ImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidCSV)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCSV, err)
+	}
+	columns := make([]string, len(header))
+	for i, name := range header {
+		columns[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+	}
+	if !slices.Contains(columns, columnSKU) || !slices.Contains(columns, columnName) {
+		return nil, fmt.Errorf("%w: the header needs sku and name columns", ErrInvalidCSV)
+	}
+
+	result := &productDomain.ImportResult{Errors: []productDomain.ImportError{}}
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if row-1 > maxImportRows {
+			return nil, fmt.Errorf("%w: at most %d rows can be imported at once", ErrInvalidCSV, maxImportRows)
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			result.Errors = append(result.Errors, productDomain.ImportError{Row: row, Error: parseErr.Err.Error()})
+			continue
+		}
+
+		product, err := productFromRow(header, columns, record)
+		if err == nil {
+			err = s.upsert(ctx, adminID, product, result)
+		}
+		if err != nil {
+			if !errors.Is(err, ErrInvalidProduct) {
+				return nil, err
+			}
+			result.Errors = append(result.Errors, productDomain.ImportError{Row: row, Error: err.Error()})
+		}
+	}
+	return result, nil
+}
+
+func (s *service) upsert(ctx context.Context, adminID string, product *productDomain.Product, result *productDomain.ImportResult) error {
+	if err := validate(product); err != nil {
+		return err
+	}
+	existing, err := s.repo.GetBySKU(ctx, product.SKU)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		product.CreatedBy = adminID
+		if _, err := s.repo.Create(ctx, product); err != nil {
+			return err
+		}
+		result.Created++
+		return nil
+	}
+
+	product.ID = existing.ID
+	product.CreatedBy = existing.CreatedBy
+	product.CreatedAt = existing.CreatedAt
+	if err := s.repo.Update(ctx, product); err != nil {
+		return err
+	}
+	result.Updated++
+	return nil
+}
+
+// productFromRow reads one CSV record. header keeps the attribute names as
+// written; columns are the lower-cased names known columns are found by.
+func productFromRow(header, columns, record []string) (*productDomain.Product, error) {
+	product := &productDomain.Product{}
+	for i, value := range record {
+		if i >= len(columns) {
+			break
+		}
+		value = strings.TrimSpace(value)
+		switch columns[i] {
+		case columnSKU:
+			product.SKU = value
+		case columnName:
+			product.Name = value
+		case columnDescription:
+			product.Description = value
+		case columnCurrency:
+			product.Currency = value
+		case columnPrice:
+			if value == "" {
+				continue
+			}
+			price, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", ""), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: price %q is not a number", ErrInvalidProduct, value)
+			}
+			product.Price = &price
+		case columnStock:
+			if value == "" {
+				continue
+			}
+			stock, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: stock %q is not a whole number", ErrInvalidProduct, value)
+			}
+			product.Stock = &stock
+		case columnImages:
+			for _, image := range strings.Split(value, "|") {
+				if image = strings.TrimSpace(image); image != "" {
+					product.Images = append(product.Images, image)
+				}
+			}
+		default:
+			name := strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+			if name == "" || value == "" {
+				continue
+			}
+			if product.Attributes == nil {
+				product.Attributes = map[string]string{}
+			}
+			product.Attributes[name] = value
+		}
+	}
+	return product, nil
+}
+
+// Match looks for products by SKU first, since a quoted SKU is exact, and
+// then by name. A keyword hit counts only when the question mentions at
+// least half of the significant words of the product's name, so a shared
+// word such as "shipping" does not pull in unrelated products.
+func (s *service) Match(ctx context.Context, query string) ([]productDomain.Product, error) {
+	var skus []string
+	for _, token := range skuToken.FindAllString(query, -1) {
+		if strings.ContainsFunc(token, unicode.IsDigit) && len(token) >= 3 {
+			skus = append(skus, strings.ToUpper(token))
+		}
+	}
+	if len(skus) > 0 {
+		products, err := s.repo.FindBySKUs(ctx, skus)
+		if err != nil {
+			return nil, err
+		}
+		if len(products) > 0 {
+			return products[:min(len(products), maxMatches)], nil
+		}
+	}
+
+	words := nameWords(query)
+	if len(words) == 0 {
+		return []productDomain.Product{}, nil
+	}
+	candidates, err := s.repo.Search(ctx, query, searchCandidates)
+	if err != nil {
+		return nil, err
+	}
+	asked := map[string]bool{}
+	for _, w := range words {
+		asked[w] = true
+	}
+	matches := []productDomain.Product{}
+	for _, product := range candidates {
+		name := nameWords(product.Name)
+		mentioned := 0
+		for _, w := range name {
+			if asked[w] {
+				mentioned++
+			}
+		}
+		if len(name) > 0 && mentioned*2 >= len(name) {
+			matches = append(matches, product)
+		}
+		if len(matches) == maxMatches {
+			break
+		}
+	}
+	return matches, nil
+}
+
+// stopWords are left out when comparing a question with product names.
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "you": true, "your": true, "how": true,
+	"much": true, "many": true, "what": true, "does": true, "have": true, "has": true, "are": true,
+	"cost": true, "price": true, "stock": true, "del": true, "las": true, "los": true, "con": true,
+	"para": true, "que": true, "una": true, "uno": true, "cuanto": true, "cuesta": true, "precio": true,
+}
+
+// nameWords lowercases text into its words of three or more letters,
+// without stop words and with a plural "s" dropped.
+func nameWords(text string) []string {
+	var words []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(w) > 3 && strings.HasSuffix(w, "s") {
+			w = strings.TrimSuffix(w, "s")
+		}
+		if utf8.RuneCountInString(w) < 3 || stopWords[w] {
+			continue
+		}
+		words = append(words, w)
+	}
+	return words
+}
+
+func validate(product *productDomain.Product) error {
+	product.SKU = strings.ToUpper(strings.TrimSpace(product.SKU))
+	product.Name = strings.TrimSpace(product.Name)
+	product.Description = strings.TrimSpace(product.Description)
+	product.Currency = strings.ToUpper(strings.TrimSpace(product.Currency))
+
+	switch {
+	case !validSKU.MatchString(product.SKU):
+		return fmt.Errorf("%w: sku must be 1-64 letters, digits, dots, dashes, slashes or underscores", ErrInvalidProduct)
+	case product.Name == "" || utf8.RuneCountInString(product.Name) > maxNameLength:
+		return fmt.Errorf("%w: name must be 1 to %d characters", ErrInvalidProduct, maxNameLength)
+	case utf8.RuneCountInString(product.Description) > maxDescriptionLength:
+		return fmt.Errorf("%w: description must be at most %d characters", ErrInvalidProduct, maxDescriptionLength)
+	case product.Price != nil && (*product.Price < 0 || math.IsNaN(*product.Price) || math.IsInf(*product.Price, 0)):
+		return fmt.Errorf("%w: price must not be negative", ErrInvalidProduct)
+	case product.Currency != "" && !validCurrency.MatchString(product.Currency):
+		return fmt.Errorf("%w: currency must be a three-letter ISO 4217 code", ErrInvalidProduct)
+	case product.Stock != nil && *product.Stock < 0:
+		return fmt.Errorf("%w: stock must not be negative", ErrInvalidProduct)
+	case len(product.Attributes) > maxAttributes:
+		return fmt.Errorf("%w: at most %d attributes", ErrInvalidProduct, maxAttributes)
+	case len(product.Images) > maxImages:
+		return fmt.Errorf("%w: at most %d images", ErrInvalidProduct, maxImages)
+	}
+	for name, value := range product.Attributes {
+		if strings.TrimSpace(name) == "" || utf8.RuneCountInString(value) > maxAttributeLength {
+			return fmt.Errorf("%w: attributes need a name and values of at most %d characters", ErrInvalidProduct, maxAttributeLength)
+		}
+	}
+	for _, image := range product.Images {
+		u, err := url.Parse(image)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: image %q must be an http(s) URL", ErrInvalidProduct, image)
+		}
+	}
+	return nil
+}
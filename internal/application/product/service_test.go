@@ -0,0 +1,196 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	productDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/product"
+)
+
+// memoryRepo keeps products in memory; Search returns every product whose
+// name shares a word with the text.
+type memoryRepo struct {
+	products []productDomain.Product
+}
+
+func (m *memoryRepo) Create(ctx context.Context, product *productDomain.Product) (string, error) {
+	product.ID = "product-" + strconv.Itoa(len(m.products)+1)
+	m.products = append(m.products, *product)
+	return product.ID, nil
+}
+
+func (m *memoryRepo) GetByID(ctx context.Context, id string) (*productDomain.Product, error) {
+	for _, p := range m.products {
+		if p.ID == id {
+			return &p, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryRepo) GetBySKU(ctx context.Context, sku string) (*productDomain.Product, error) {
+	for _, p := range m.products {
+		if p.SKU == sku {
+			return &p, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryRepo) FindBySKUs(ctx context.Context, skus []string) ([]productDomain.Product, error) {
+	found := []productDomain.Product{}
+	for _, sku := range skus {
+		if p, _ := m.GetBySKU(ctx, sku); p != nil {
+			found = append(found, *p)
+		}
+	}
+	return found, nil
+}
+
+func (m *memoryRepo) Search(ctx context.Context, text string, limit int) ([]productDomain.Product, error) {
+	found := []productDomain.Product{}
+	for _, p := range m.products {
+		for _, w := range strings.Fields(strings.ToLower(p.Name)) {
+			if strings.Contains(strings.ToLower(text), w) {
+				found = append(found, p)
+				break
+			}
+		}
+	}
+	return found, nil
+}
+
+func (m *memoryRepo) List(ctx context.Context, filter productDomain.Filter) ([]productDomain.Product, int64, error) {
+	return m.products, int64(len(m.products)), nil
+}
+
+func (m *memoryRepo) Update(ctx context.Context, product *productDomain.Product) error {
+	for i, p := range m.products {
+		if p.ID == product.ID {
+			m.products[i] = *product
+			return nil
+		}
+	}
+	return errors.New("not found")
+}
+
+func (m *memoryRepo) Delete(ctx context.Context, id string) error {
+	return nil
+}
+
+func price(v float64) *float64 { return &v }
+
+func TestCreateProductValidates(t *testing.T) {
+	svc := NewService(ServiceConfig{Repo: &memoryRepo{}})
+	ctx := context.Background()
+
+	if _, err := svc.CreateProduct(ctx, "admin-1", &productDomain.Product{SKU: "ts-01", Name: "T-shirt", Price: price(12.5), Currency: "usd"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := svc.CreateProduct(ctx, "admin-1", &productDomain.Product{SKU: "TS-01", Name: "Other"}); !errors.Is(err, ErrDuplicateSKU) {
+		t.Errorf("Expected ErrDuplicateSKU for a case-insensitive repeat, got %v", err)
+	}
+
+	invalid := []productDomain.Product{
+		{SKU: "", Name: "No SKU"},
+		{SKU: "BAD SKU", Name: "Space"},
+		{SKU: "A-1", Name: ""},
+		{SKU: "A-2", Name: "Negative", Price: price(-1)},
+		{SKU: "A-3", Name: "Currency", Price: price(1), Currency: "dollars"},
+		{SKU: "A-4", Name: "Image", Images: []string{"ftp://example.com/a.png"}},
+	}
+	for _, p := range invalid {
+		if _, err := svc.CreateProduct(ctx, "admin-1", &p); !errors.Is(err, ErrInvalidProduct) {
+			t.Errorf("Expected ErrInvalidProduct for %+v, got %v", p, err)
+		}
+	}
+}
+
+func TestImportCSV(t *testing.T) {
+	repo := &memoryRepo{}
+	svc := NewService(ServiceConfig{Repo: repo})
+	ctx := context.Background()
+	if _, err := svc.CreateProduct(ctx, "admin-1", &productDomain.Product{SKU: "TS-01", Name: "T-shirt"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	file := "\ufeffSKU,Name,Price,Currency,Stock,Color,Images\n" +
+		"ts-01,Cotton T-shirt,12.50,USD,4,Blue,https://example.com/a.png|https://example.com/b.png\n" +
+		"MUG-2,Coffee mug,\"1,200\",GTQ,0,,\n" +
+		"HAT-3,Hat,cheap,USD,1,,\n" +
+		",Nameless,1,USD,1,,\n"
+	result, err := svc.ImportCSV(ctx, "admin-1", strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Created != 1 || result.Updated != 1 || len(result.Errors) != 2 || result.Errors[0].Row != 4 || result.Errors[1].Row != 5 {
+		t.Fatalf("Unexpected result %+v", result)
+	}
+
+	shirt, _ := repo.GetBySKU(ctx, "TS-01")
+	if shirt.ID != "product-1" || shirt.Name != "Cotton T-shirt" || *shirt.Stock != 4 || shirt.Attributes["Color"] != "Blue" || len(shirt.Images) != 2 {
+		t.Errorf("Expected the existing product to be replaced, got %+v", shirt)
+	}
+	mug, _ := repo.GetBySKU(ctx, "MUG-2")
+	if mug == nil || *mug.Price != 1200 || len(mug.Attributes) != 0 {
+		t.Errorf("Unexpected mug %+v", mug)
+	}
+
+	for _, file := range []string{"", "name,price\nMug,1\n"} {
+		if _, err := svc.ImportCSV(ctx, "admin-1", strings.NewReader(file)); !errors.Is(err, ErrInvalidCSV) {
+			t.Errorf("Expected ErrInvalidCSV for %q, got %v", file, err)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	repo := &memoryRepo{}
+	svc := NewService(ServiceConfig{Repo: repo})
+	ctx := context.Background()
+	for _, p := range []productDomain.Product{
+		{SKU: "TS-01", Name: "Blue cotton T-shirt"},
+		{SKU: "MUG-2", Name: "Coffee mug"},
+		{SKU: "SHIP-1", Name: "Express shipping box"},
+	} {
+		if _, err := svc.CreateProduct(ctx, "admin-1", &p); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"Do you have mug-2 in stock?", []string{"MUG-2"}},
+		{"How much is the coffee mug?", []string{"MUG-2"}},
+		{"Is the blue cotton shirt available?", []string{"TS-01"}},
+		{"How long does shipping take?", nil},
+		{"Hello", nil},
+	}
+	for _, tt := range tests {
+		products, err := svc.Match(ctx, tt.query)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		var got []string
+		for _, p := range products {
+			got = append(got, p.SKU)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%q: expected %v, got %v", tt.query, tt.want, got)
+		}
+	}
+}
+
+func TestFacts(t *testing.T) {
+	stock := 0
+	p := productDomain.Product{SKU: "MUG-2", Name: "Coffee mug", Price: price(8), Currency: "USD", Stock: &stock,
+		Attributes: map[string]string{"size": "350 ml", "color": "white"}}
+	want := "Product: Coffee mug\nSKU: MUG-2\nPrice: 8.00 USD\nStock: out of stock\ncolor: white\nsize: 350 ml"
+	if got := p.Facts(); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
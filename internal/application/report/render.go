@@ -0,0 +1,70 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+
+	reportDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/report"
+	"github.com/elprogramadorgt/lucidRAG/pkg/pdf"
+)
+
+const timeLayout = "2006-01-02 15:04 MST"
+
+// CSV writes table as CSV with its header first. Cells that a spreadsheet
+// would run as a formula are prefixed with a quote, since questions and
+// topic labels come from customers.
+func CSV(table reportDomain.Table) []byte {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write(table.Header)
+	for _, row := range table.Rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = escapeFormula(cell)
+		}
+		_ = w.Write(cells)
+	}
+	w.Flush()
+	return buf.Bytes()
+}
+
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// PDF writes the report as one document, each table as a titled list of
+// rows with their values labelled by column.
+func PDF(report *reportDomain.Report, brand Brand) []byte {
+	color := pdf.HexColor(brand.Color)
+	grey := pdf.Color{R: 0.4, G: 0.4, B: 0.4}
+
+	doc := pdf.New(report.Name)
+	doc.Paragraph(brand.Name, pdf.Style{Size: 20, Bold: true, Color: color})
+	doc.Paragraph(report.Name, pdf.Style{Size: 12})
+	doc.Rule(color)
+	doc.Paragraph(fmt.Sprintf("Period: %s to %s\nGenerated: %s",
+		report.From.Format(timeLayout), report.To.Format(timeLayout), report.GeneratedAt.Format(timeLayout)), pdf.Style{Size: 9, Color: grey})
+	doc.Space(12)
+
+	for _, table := range report.Tables {
+		doc.Paragraph(table.Title, pdf.Style{Size: 12, Bold: true, Color: color})
+		if len(table.Rows) == 0 {
+			doc.Paragraph("None", pdf.Style{Size: 10, Color: grey})
+		}
+		for _, row := range table.Rows {
+			fields := make([]string, len(row))
+			for i, cell := range row {
+				fields[i] = table.Header[i] + ": " + cell
+			}
+			doc.Paragraph(strings.Join(fields, "  ·  "), pdf.Style{Size: 9})
+			doc.Space(4)
+		}
+		doc.Space(12)
+	}
+	return doc.Bytes()
+}
@@ -0,0 +1,418 @@
+// Package report exports analytics as CSV or PDF and emails them on a
+// schedule.
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	topicApp "github.com/elprogramadorgt/lucidRAG/internal/application/topic"
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	reportDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/report"
+	topicDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/topic"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	mailpkg "github.com/elprogramadorgt/lucidRAG/pkg/mail"
+)
+
+var (
+	ErrScheduleNotFound = errors.New("report schedule not found")
+	ErrInvalidSchedule  = errors.New("invalid report schedule")
+	ErrInvalidReport    = errors.New("invalid report")
+	ErrMailUnavailable  = errors.New("email sending is not configured")
+	ErrSendFailed       = errors.New("failed to send report")
+)
+
+const (
+	maxScheduleName = 100
+	maxRecipients   = 20
+	// maxGaps caps the knowledge gaps in one report.
+	maxGaps = 50
+)
+
+// Mailer delivers an email and returns its Message-ID.
+type Mailer interface {
+	Send(ctx context.Context, msg mailpkg.Message) (string, error)
+}
+
+// Brand is how PDF reports and subjects are branded.
+type Brand struct {
+	Name string
+	// Color is a #rrggbb color for headings.
+	Color string
+}
+
+type service struct {
+	repo          reportDomain.ScheduleRepository
+	convSvc       conversationDomain.Service
+	topicSvc      topicDomain.Service
+	queries       documentDomain.QueryLogRepository
+	mailer        Mailer
+	lowConfidence float64
+	brand         Brand
+	log           *logger.Logger
+	now           func() time.Time
+}
+
+type ServiceConfig struct {
+	Repo    reportDomain.ScheduleRepository
+	ConvSvc conversationDomain.Service
+	// TopicSvc is nil when topics are not clustered; reports then have no
+	// topics.
+	TopicSvc topicDomain.Service
+	Queries  documentDomain.QueryLogRepository
+	// Mailer is nil when SMTP is not configured; schedules can then be
+	// kept but not sent.
+	Mailer        Mailer
+	LowConfidence float64
+	Brand         Brand
+	Log           *logger.Logger
+}
+
+func NewService(cfg ServiceConfig) reportDomain.Service {
+	return &service{
+		repo:          cfg.Repo,
+		convSvc:       cfg.ConvSvc,
+		topicSvc:      cfg.TopicSvc,
+		queries:       cfg.Queries,
+		mailer:        cfg.Mailer,
+		lowConfidence: cfg.LowConfidence,
+		brand:         cfg.Brand,
+		log:           cfg.Log.With("component", "report"),
+		now:           time.Now,
+	}
+}
+
+func (s *service) ListSchedules(ctx context.Context) ([]reportDomain.Schedule, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *service) GetSchedule(ctx context.Context, id string) (*reportDomain.Schedule, error) {
+	schedule, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if schedule == nil {
+		return nil, ErrScheduleNotFound
+	}
+	return schedule, nil
+}
+
+func (s *service) CreateSchedule(ctx context.Context, schedule *reportDomain.Schedule) (string, error) {
+	if err := normalizeSchedule(schedule); err != nil {
+		return "", err
+	}
+	schedule.LastSentAt = nil
+	return s.repo.Create(ctx, schedule)
+}
+
+func (s *service) UpdateSchedule(ctx context.Context, schedule *reportDomain.Schedule) error {
+	existing, err := s.GetSchedule(ctx, schedule.ID)
+	if err != nil {
+		return err
+	}
+	if err := normalizeSchedule(schedule); err != nil {
+		return err
+	}
+
+	schedule.CreatedBy = existing.CreatedBy
+	schedule.CreatedAt = existing.CreatedAt
+	schedule.LastSentAt = existing.LastSentAt
+	return s.repo.Update(ctx, schedule)
+}
+
+func (s *service) DeleteSchedule(ctx context.Context, id string) error {
+	if _, err := s.GetSchedule(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *service) Send(ctx context.Context, id string) (*reportDomain.Delivery, error) {
+	schedule, err := s.GetSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.deliver(ctx, schedule, s.now())
+}
+
+// Generate builds a report of metrics for [from, to). A zero from or to
+// means the week up to to, or up to now.
+func (s *service) Generate(ctx context.Context, metrics []reportDomain.Metric, from, to time.Time) (*reportDomain.Report, error) {
+	if len(metrics) == 0 {
+		return nil, fmt.Errorf("%w: at least one metric is required", ErrInvalidReport)
+	}
+	for _, metric := range metrics {
+		if !metric.Valid() {
+			return nil, fmt.Errorf("%w: unknown metric %q", ErrInvalidReport, metric)
+		}
+	}
+	if to.IsZero() {
+		to = s.now()
+	}
+	if from.IsZero() {
+		from = periodStart(reportDomain.CadenceWeekly, to)
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: start_time must be before end_time", ErrInvalidReport)
+	}
+	return s.build(ctx, "Analytics report", metrics, from, to)
+}
+
+// SendDue sends every schedule due at the current hour that has not gone
+// out this hour yet. One failed schedule does not stop the others; the
+// failures are returned together.
+func (s *service) SendDue(ctx context.Context) error {
+	now := s.now().Truncate(time.Hour)
+	schedules, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range schedules {
+		if !due(&schedules[i], now) {
+			continue
+		}
+		if _, err := s.deliver(ctx, &schedules[i], now); err != nil {
+			errs = append(errs, fmt.Errorf("schedule %s: %w", schedules[i].ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// due reports whether schedule goes out at now, the start of an hour.
+func due(schedule *reportDomain.Schedule, now time.Time) bool {
+	if schedule.LastSentAt != nil && !schedule.LastSentAt.Before(now) {
+		return false
+	}
+	local := now.In(location(schedule.Timezone))
+	if local.Hour() != schedule.Hour {
+		return false
+	}
+	switch schedule.Cadence {
+	case reportDomain.CadenceWeekly:
+		return local.Weekday() == time.Weekday(schedule.Day)
+	case reportDomain.CadenceMonthly:
+		return local.Day() == schedule.Day
+	}
+	return true
+}
+
+// periodStart is the start of the day, week or month a report ending at
+// end covers, counted in end's location.
+func periodStart(cadence reportDomain.Cadence, end time.Time) time.Time {
+	switch cadence {
+	case reportDomain.CadenceWeekly:
+		return end.AddDate(0, 0, -7)
+	case reportDomain.CadenceMonthly:
+		return end.AddDate(0, -1, 0)
+	}
+	return end.AddDate(0, 0, -1)
+}
+
+func location(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// deliver emails schedule's report for the period ending at end to each
+// recipient. It counts as sent when any recipient got it.
+func (s *service) deliver(ctx context.Context, schedule *reportDomain.Schedule, end time.Time) (*reportDomain.Delivery, error) {
+	if s.mailer == nil {
+		return nil, ErrMailUnavailable
+	}
+	end = end.In(location(schedule.Timezone))
+	report, err := s.build(ctx, schedule.Name, schedule.Metrics, periodStart(schedule.Cadence, end), end)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := mailpkg.Message{
+		Subject:     schedule.Name,
+		Body:        summary(report, schedule),
+		Attachments: s.attachments(report, schedule.Format),
+	}
+	if s.brand.Name != "" {
+		msg.Subject += " - " + s.brand.Name
+	}
+
+	delivery := &reportDomain.Delivery{ScheduleID: schedule.ID, Recipients: []string{}, Format: schedule.Format, From: report.From, To: report.To}
+	var errs []error
+	for _, recipient := range schedule.Recipients {
+		msg.To = recipient
+		if _, err := s.mailer.Send(ctx, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", recipient, err))
+			continue
+		}
+		delivery.Recipients = append(delivery.Recipients, recipient)
+	}
+	var markErr error
+	if len(delivery.Recipients) > 0 {
+		delivery.SentAt = s.now()
+		markErr = s.repo.MarkSent(ctx, schedule.ID, delivery.SentAt)
+		s.log.InfoContext(ctx, "report sent", "schedule_id", schedule.ID, "recipients", len(delivery.Recipients), "format", schedule.Format)
+	}
+	if len(errs) > 0 {
+		return delivery, fmt.Errorf("%w: %w", ErrSendFailed, errors.Join(errs...))
+	}
+	return delivery, markErr
+}
+
+// build makes a report named name of metrics for the period [from, to),
+// its tables in the order of reportDomain.Metrics.
+func (s *service) build(ctx context.Context, name string, metrics []reportDomain.Metric, from, to time.Time) (*reportDomain.Report, error) {
+	report := &reportDomain.Report{Name: name, From: from, To: to, Tables: []reportDomain.Table{}, GeneratedAt: s.now().In(to.Location())}
+	for _, metric := range reportDomain.Metrics {
+		if !slices.Contains(metrics, metric) {
+			continue
+		}
+		table, err := s.table(ctx, metric, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", metric, err)
+		}
+		report.Tables = append(report.Tables, table)
+	}
+	return report, nil
+}
+
+func (s *service) table(ctx context.Context, metric reportDomain.Metric, from, to time.Time) (reportDomain.Table, error) {
+	switch metric {
+	case reportDomain.MetricSLA:
+		sla, err := s.convSvc.SLAReport(ctx, conversationDomain.UserContext{IsAdmin: true}, from, to)
+		if err != nil {
+			return reportDomain.Table{}, err
+		}
+		return SLATable(sla), nil
+	case reportDomain.MetricTopics:
+		if s.topicSvc == nil {
+			return TopicsTable(nil), nil
+		}
+		snapshot, err := s.topicSvc.Latest(ctx)
+		if err != nil && !errors.Is(err, topicApp.ErrSnapshotNotFound) {
+			return reportDomain.Table{}, err
+		}
+		return TopicsTable(snapshot), nil
+	case reportDomain.MetricQueries:
+		stats, err := s.queries.Stats(ctx, from, to, s.lowConfidence)
+		if err != nil {
+			return reportDomain.Table{}, err
+		}
+		return QueriesTable(stats), nil
+	case reportDomain.MetricKnowledgeGaps:
+		gaps, err := s.queries.Gaps(ctx, from, to, s.lowConfidence, maxGaps)
+		if err != nil {
+			return reportDomain.Table{}, err
+		}
+		return GapsTable(gaps), nil
+	}
+	return reportDomain.Table{}, fmt.Errorf("unknown metric %q", metric)
+}
+
+// attachments are the report's files: one CSV per table, or one PDF.
+func (s *service) attachments(report *reportDomain.Report, format reportDomain.Format) []mailpkg.Attachment {
+	day := report.To.Format(time.DateOnly)
+	if format == reportDomain.FormatPDF {
+		return []mailpkg.Attachment{{Filename: "report-" + day + ".pdf", ContentType: "application/pdf", Data: PDF(report, s.brand)}}
+	}
+	attachments := make([]mailpkg.Attachment, 0, len(report.Tables))
+	for _, table := range report.Tables {
+		attachments = append(attachments, mailpkg.Attachment{
+			Filename:    string(table.Metric) + "-" + day + ".csv",
+			ContentType: "text/csv; charset=utf-8",
+			Data:        CSV(table),
+		})
+	}
+	return attachments
+}
+
+func summary(report *reportDomain.Report, schedule *reportDomain.Schedule) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", report.Name)
+	fmt.Fprintf(&b, "Period: %s to %s\n", report.From.Format(timeLayout), report.To.Format(timeLayout))
+	for _, table := range report.Tables {
+		fmt.Fprintf(&b, "- %s: %d rows\n", table.Title, len(table.Rows))
+	}
+	fmt.Fprintf(&b, "\nThe report is attached as %s. It is sent %s to %d recipients.\n", strings.ToUpper(string(schedule.Format)), schedule.Cadence, len(schedule.Recipients))
+	return b.String()
+}
+
+// normalizeSchedule checks schedule and fills in its defaults: CSV, UTC
+// and, for daily reports, no day.
+func normalizeSchedule(schedule *reportDomain.Schedule) error {
+	schedule.Name = strings.TrimSpace(schedule.Name)
+	if schedule.Name == "" || utf8.RuneCountInString(schedule.Name) > maxScheduleName {
+		return fmt.Errorf("%w: name must be 1 to %d characters", ErrInvalidSchedule, maxScheduleName)
+	}
+
+	var metrics []reportDomain.Metric
+	for _, metric := range schedule.Metrics {
+		if !metric.Valid() {
+			return fmt.Errorf("%w: unknown metric %q", ErrInvalidSchedule, metric)
+		}
+		if !slices.Contains(metrics, metric) {
+			metrics = append(metrics, metric)
+		}
+	}
+	if len(metrics) == 0 {
+		return fmt.Errorf("%w: at least one metric is required", ErrInvalidSchedule)
+	}
+	schedule.Metrics = metrics
+
+	var recipients []string
+	for _, recipient := range schedule.Recipients {
+		addr, err := mail.ParseAddress(strings.TrimSpace(recipient))
+		if err != nil {
+			return fmt.Errorf("%w: invalid recipient %q", ErrInvalidSchedule, recipient)
+		}
+		if !slices.Contains(recipients, addr.Address) {
+			recipients = append(recipients, addr.Address)
+		}
+	}
+	if len(recipients) == 0 || len(recipients) > maxRecipients {
+		return fmt.Errorf("%w: 1 to %d recipients are required", ErrInvalidSchedule, maxRecipients)
+	}
+	schedule.Recipients = recipients
+
+	if schedule.Format == "" {
+		schedule.Format = reportDomain.FormatCSV
+	}
+	if schedule.Format != reportDomain.FormatCSV && schedule.Format != reportDomain.FormatPDF {
+		return fmt.Errorf("%w: format must be csv or pdf", ErrInvalidSchedule)
+	}
+	if schedule.Hour < 0 || schedule.Hour > 23 {
+		return fmt.Errorf("%w: hour must be 0 to 23", ErrInvalidSchedule)
+	}
+	switch schedule.Cadence {
+	case reportDomain.CadenceDaily:
+		schedule.Day = 0
+	case reportDomain.CadenceWeekly:
+		if schedule.Day < 0 || schedule.Day > 6 {
+			return fmt.Errorf("%w: day must be 0 (Sunday) to 6 for weekly reports", ErrInvalidSchedule)
+		}
+	case reportDomain.CadenceMonthly:
+		if schedule.Day < 1 || schedule.Day > 28 {
+			return fmt.Errorf("%w: day must be 1 to 28 for monthly reports", ErrInvalidSchedule)
+		}
+	default:
+		return fmt.Errorf("%w: cadence must be daily, weekly or monthly", ErrInvalidSchedule)
+	}
+
+	schedule.Timezone = strings.TrimSpace(schedule.Timezone)
+	if schedule.Timezone == "" {
+		schedule.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(schedule.Timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidSchedule, schedule.Timezone)
+	}
+	return nil
+}
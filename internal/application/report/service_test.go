@@ -0,0 +1,313 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	topicApp "github.com/elprogramadorgt/lucidRAG/internal/application/topic"
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	reportDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/report"
+	topicDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/topic"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	mailpkg "github.com/elprogramadorgt/lucidRAG/pkg/mail"
+)
+
+type mockScheduleRepo struct {
+	schedules map[string]*reportDomain.Schedule
+	sent      map[string]time.Time
+}
+
+func newMockScheduleRepo(schedules ...reportDomain.Schedule) *mockScheduleRepo {
+	m := &mockScheduleRepo{schedules: map[string]*reportDomain.Schedule{}, sent: map[string]time.Time{}}
+	for i := range schedules {
+		m.schedules[schedules[i].ID] = &schedules[i]
+	}
+	return m
+}
+
+func (m *mockScheduleRepo) Create(ctx context.Context, schedule *reportDomain.Schedule) (string, error) {
+	schedule.ID = "schedule_new"
+	m.schedules[schedule.ID] = schedule
+	return schedule.ID, nil
+}
+
+func (m *mockScheduleRepo) GetByID(ctx context.Context, id string) (*reportDomain.Schedule, error) {
+	return m.schedules[id], nil
+}
+
+func (m *mockScheduleRepo) List(ctx context.Context) ([]reportDomain.Schedule, error) {
+	schedules := []reportDomain.Schedule{}
+	for _, s := range m.schedules {
+		schedules = append(schedules, *s)
+	}
+	return schedules, nil
+}
+
+func (m *mockScheduleRepo) Update(ctx context.Context, schedule *reportDomain.Schedule) error {
+	m.schedules[schedule.ID] = schedule
+	return nil
+}
+
+func (m *mockScheduleRepo) Delete(ctx context.Context, id string) error {
+	delete(m.schedules, id)
+	return nil
+}
+
+func (m *mockScheduleRepo) MarkSent(ctx context.Context, id string, at time.Time) error {
+	m.sent[id] = at
+	return nil
+}
+
+type mockConvService struct {
+	conversationDomain.Service
+}
+
+func (m *mockConvService) SLAReport(ctx context.Context, userCtx conversationDomain.UserContext, from, to time.Time) (*conversationDomain.SLAReport, error) {
+	if !userCtx.IsAdmin {
+		return nil, errors.New("not admin")
+	}
+	return &conversationDomain.SLAReport{From: from, To: to, Agents: []conversationDomain.AgentSLA{{AgentID: "agent-a", Conversations: 4, Compliance: 0.75}}}, nil
+}
+
+type mockTopicService struct {
+	topicDomain.Service
+}
+
+func (m *mockTopicService) Latest(ctx context.Context) (*topicDomain.Snapshot, error) {
+	return nil, topicApp.ErrSnapshotNotFound
+}
+
+type mockQueryLogRepo struct {
+	documentDomain.QueryLogRepository
+}
+
+func (m *mockQueryLogRepo) Stats(ctx context.Context, start, end time.Time, lowConfidence float64) (*documentDomain.QueryStats, error) {
+	return &documentDomain.QueryStats{Total: 200, LowConfidence: 9, NoResults: 4}, nil
+}
+
+func (m *mockQueryLogRepo) Gaps(ctx context.Context, start, end time.Time, lowConfidence float64, limit int) ([]documentDomain.KnowledgeGap, error) {
+	return []documentDomain.KnowledgeGap{{Query: "=HYPERLINK(\"http://evil\")", Count: 3}}, nil
+}
+
+type mockMailer struct {
+	sent []mailpkg.Message
+	fail map[string]bool
+}
+
+func (m *mockMailer) Send(ctx context.Context, msg mailpkg.Message) (string, error) {
+	if m.fail[msg.To] {
+		return "", errors.New("mailbox unavailable")
+	}
+	m.sent = append(m.sent, msg)
+	return "<id@example.com>", nil
+}
+
+func newTestService(repo *mockScheduleRepo, mailer *mockMailer, now time.Time) *service {
+	cfg := ServiceConfig{
+		Repo: repo, ConvSvc: &mockConvService{}, TopicSvc: &mockTopicService{}, Queries: &mockQueryLogRepo{},
+		LowConfidence: 0.5, Brand: Brand{Name: "Acme", Color: "#336699"}, Log: logger.New(logger.Options{Level: "error"}),
+	}
+	if mailer != nil {
+		cfg.Mailer = mailer
+	}
+	svc := NewService(cfg).(*service)
+	svc.now = func() time.Time { return now }
+	return svc
+}
+
+func TestDue(t *testing.T) {
+	// Monday 2 March 2026, 14:00 UTC, 08:00 in Guatemala.
+	now := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC)
+	earlier := now.Add(-time.Hour)
+
+	tests := []struct {
+		name     string
+		schedule reportDomain.Schedule
+		want     bool
+	}{
+		{"daily at the hour", reportDomain.Schedule{Cadence: reportDomain.CadenceDaily, Hour: 14, Timezone: "UTC"}, true},
+		{"daily another hour", reportDomain.Schedule{Cadence: reportDomain.CadenceDaily, Hour: 8, Timezone: "UTC"}, false},
+		{"local hour", reportDomain.Schedule{Cadence: reportDomain.CadenceDaily, Hour: 8, Timezone: "America/Guatemala"}, true},
+		{"weekly on the day", reportDomain.Schedule{Cadence: reportDomain.CadenceWeekly, Hour: 14, Day: 1, Timezone: "UTC"}, true},
+		{"weekly another day", reportDomain.Schedule{Cadence: reportDomain.CadenceWeekly, Hour: 14, Day: 5, Timezone: "UTC"}, false},
+		{"monthly on the day", reportDomain.Schedule{Cadence: reportDomain.CadenceMonthly, Hour: 14, Day: 2, Timezone: "UTC"}, true},
+		{"monthly another day", reportDomain.Schedule{Cadence: reportDomain.CadenceMonthly, Hour: 14, Day: 1, Timezone: "UTC"}, false},
+		{"sent this hour", reportDomain.Schedule{Cadence: reportDomain.CadenceDaily, Hour: 14, Timezone: "UTC", LastSentAt: &now}, false},
+		{"sent last hour", reportDomain.Schedule{Cadence: reportDomain.CadenceDaily, Hour: 14, Timezone: "UTC", LastSentAt: &earlier}, true},
+	}
+	for _, tt := range tests {
+		if got := due(&tt.schedule, now); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestNormalizeSchedule(t *testing.T) {
+	schedule := &reportDomain.Schedule{
+		Name:       " Weekly ops ",
+		Metrics:    []reportDomain.Metric{"sla", "queries", "sla"},
+		Recipients: []string{"Ops <ops@example.com>", "ops@example.com", "cto@example.com"},
+		Cadence:    reportDomain.CadenceDaily,
+		Day:        3,
+	}
+	if err := normalizeSchedule(schedule); err != nil {
+		t.Fatalf("normalizeSchedule failed: %v", err)
+	}
+	if schedule.Name != "Weekly ops" || len(schedule.Metrics) != 2 || len(schedule.Recipients) != 2 ||
+		schedule.Format != reportDomain.FormatCSV || schedule.Timezone != "UTC" || schedule.Day != 0 {
+		t.Errorf("Expected a normalized schedule, got %+v", schedule)
+	}
+
+	valid := func() reportDomain.Schedule {
+		return reportDomain.Schedule{Name: "x", Metrics: []reportDomain.Metric{"sla"}, Recipients: []string{"a@example.com"}, Cadence: reportDomain.CadenceWeekly}
+	}
+	tests := []struct {
+		name   string
+		change func(s *reportDomain.Schedule)
+	}{
+		{"no name", func(s *reportDomain.Schedule) { s.Name = " " }},
+		{"unknown metric", func(s *reportDomain.Schedule) { s.Metrics = []reportDomain.Metric{"revenue"} }},
+		{"no recipients", func(s *reportDomain.Schedule) { s.Recipients = nil }},
+		{"bad recipient", func(s *reportDomain.Schedule) { s.Recipients = []string{"not an address"} }},
+		{"bad format", func(s *reportDomain.Schedule) { s.Format = "xlsx" }},
+		{"bad hour", func(s *reportDomain.Schedule) { s.Hour = 24 }},
+		{"bad weekday", func(s *reportDomain.Schedule) { s.Day = 7 }},
+		{"bad month day", func(s *reportDomain.Schedule) { s.Cadence = reportDomain.CadenceMonthly; s.Day = 31 }},
+		{"bad cadence", func(s *reportDomain.Schedule) { s.Cadence = "hourly" }},
+		{"bad timezone", func(s *reportDomain.Schedule) { s.Timezone = "Mars/Olympus" }},
+	}
+	for _, tt := range tests {
+		s := valid()
+		tt.change(&s)
+		if err := normalizeSchedule(&s); !errors.Is(err, ErrInvalidSchedule) {
+			t.Errorf("%s: expected ErrInvalidSchedule, got %v", tt.name, err)
+		}
+	}
+}
+
+func TestCSVEscapesFormulas(t *testing.T) {
+	table := reportDomain.Table{
+		Header: []string{"query", "count"},
+		Rows:   [][]string{{"=SUM(A1)", "3"}, {"-1 refunds, please", "2"}, {"plain", "1"}},
+	}
+	want := "query,count\n'=SUM(A1),3\n\"'-1 refunds, please\",2\nplain,1\n"
+	if got := string(CSV(table)); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestSendDue(t *testing.T) {
+	// Monday 2 March 2026, 08:20 UTC.
+	now := time.Date(2026, 3, 2, 8, 20, 0, 0, time.UTC)
+	repo := newMockScheduleRepo(
+		reportDomain.Schedule{
+			ID: "weekly", Name: "Weekly ops", Cadence: reportDomain.CadenceWeekly, Day: 1, Hour: 8, Timezone: "UTC",
+			Metrics:    []reportDomain.Metric{reportDomain.MetricKnowledgeGaps, reportDomain.MetricSLA, reportDomain.MetricTopics},
+			Recipients: []string{"ops@example.com", "bounce@example.com"}, Format: reportDomain.FormatCSV,
+		},
+		reportDomain.Schedule{
+			ID: "pdf", Name: "Daily PDF", Cadence: reportDomain.CadenceDaily, Hour: 8, Timezone: "UTC",
+			Metrics: []reportDomain.Metric{reportDomain.MetricQueries}, Recipients: []string{"cto@example.com"}, Format: reportDomain.FormatPDF,
+		},
+		reportDomain.Schedule{
+			ID: "later", Name: "Later", Cadence: reportDomain.CadenceDaily, Hour: 9, Timezone: "UTC",
+			Metrics: []reportDomain.Metric{reportDomain.MetricQueries}, Recipients: []string{"later@example.com"},
+		},
+	)
+	mailer := &mockMailer{fail: map[string]bool{"bounce@example.com": true}}
+	svc := newTestService(repo, mailer, now)
+
+	err := svc.SendDue(context.Background())
+	if !errors.Is(err, ErrSendFailed) || !strings.Contains(err.Error(), "bounce@example.com") {
+		t.Errorf("Expected the bounce reported, got %v", err)
+	}
+	if len(mailer.sent) != 2 {
+		t.Fatalf("Expected 2 emails, got %d", len(mailer.sent))
+	}
+	if _, ok := repo.sent["weekly"]; !ok {
+		t.Error("Expected the weekly schedule marked sent with one recipient reached")
+	}
+	if _, ok := repo.sent["later"]; ok {
+		t.Error("Expected the 09:00 schedule not sent")
+	}
+
+	for _, msg := range mailer.sent {
+		switch msg.To {
+		case "ops@example.com":
+			if msg.Subject != "Weekly ops - Acme" || len(msg.Attachments) != 3 {
+				t.Fatalf("Expected three CSVs, got %q with %d attachments", msg.Subject, len(msg.Attachments))
+			}
+			names := []string{msg.Attachments[0].Filename, msg.Attachments[1].Filename, msg.Attachments[2].Filename}
+			if names[0] != "sla-2026-03-02.csv" || names[1] != "topics-2026-03-02.csv" || names[2] != "knowledge_gaps-2026-03-02.csv" {
+				t.Errorf("Expected tables in metric order, got %v", names)
+			}
+			if !strings.Contains(string(msg.Attachments[2].Data), `'=HYPERLINK`) {
+				t.Errorf("Expected the formula escaped, got %s", msg.Attachments[2].Data)
+			}
+			if !strings.Contains(msg.Body, "2026-02-23 08:00 UTC to 2026-03-02 08:00 UTC") {
+				t.Errorf("Expected the week before the hour, got %q", msg.Body)
+			}
+		case "cto@example.com":
+			if len(msg.Attachments) != 1 || msg.Attachments[0].Filename != "report-2026-03-02.pdf" ||
+				!strings.HasPrefix(string(msg.Attachments[0].Data), "%PDF") {
+				t.Errorf("Expected one PDF, got %+v", msg.Attachments)
+			}
+		default:
+			t.Errorf("Unexpected recipient %q", msg.To)
+		}
+	}
+
+	// The next run in the same hour does not send again.
+	for id, at := range repo.sent {
+		repo.schedules[id].LastSentAt = &at
+	}
+	mailer.sent = nil
+	if err := svc.SendDue(context.Background()); err != nil {
+		t.Fatalf("SendDue failed: %v", err)
+	}
+	if len(mailer.sent) != 0 {
+		t.Errorf("Expected nothing sent twice, got %d emails", len(mailer.sent))
+	}
+}
+
+func TestSendWithoutMailer(t *testing.T) {
+	repo := newMockScheduleRepo(reportDomain.Schedule{ID: "s1", Name: "x", Cadence: reportDomain.CadenceDaily})
+	svc := newTestService(repo, nil, time.Now())
+
+	if _, err := svc.Send(context.Background(), "s1"); !errors.Is(err, ErrMailUnavailable) {
+		t.Errorf("Expected ErrMailUnavailable, got %v", err)
+	}
+	if _, err := svc.Send(context.Background(), "missing"); !errors.Is(err, ErrScheduleNotFound) {
+		t.Errorf("Expected ErrScheduleNotFound, got %v", err)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	now := time.Date(2026, 3, 2, 8, 20, 0, 0, time.UTC)
+	svc := newTestService(newMockScheduleRepo(), nil, now)
+	ctx := context.Background()
+
+	report, err := svc.Generate(ctx, []reportDomain.Metric{reportDomain.MetricQueries, reportDomain.MetricSLA}, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if !report.From.Equal(now.AddDate(0, 0, -7)) || !report.To.Equal(now) {
+		t.Errorf("Expected the last week, got %v to %v", report.From, report.To)
+	}
+	if len(report.Tables) != 2 || report.Tables[0].Metric != reportDomain.MetricSLA || report.Tables[1].Rows[0][0] != "200" {
+		t.Errorf("Expected the SLA and query tables, got %+v", report.Tables)
+	}
+
+	for _, metrics := range [][]reportDomain.Metric{nil, {"revenue"}} {
+		if _, err := svc.Generate(ctx, metrics, time.Time{}, time.Time{}); !errors.Is(err, ErrInvalidReport) {
+			t.Errorf("%v: expected ErrInvalidReport, got %v", metrics, err)
+		}
+	}
+	if _, err := svc.Generate(ctx, []reportDomain.Metric{reportDomain.MetricSLA}, now, now); !errors.Is(err, ErrInvalidReport) {
+		t.Errorf("Expected ErrInvalidReport for an empty period, got %v", err)
+	}
+}
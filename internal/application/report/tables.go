@@ -0,0 +1,85 @@
+package report
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	reportDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/report"
+	topicDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/topic"
+)
+
+// SLATable lays out an SLA report, one row per agent.
+func SLATable(report *conversationDomain.SLAReport) reportDomain.Table {
+	table := reportDomain.Table{
+		Metric: reportDomain.MetricSLA,
+		Title:  "SLA by agent",
+		Header: []string{
+			"agent_id", "conversations", "responded", "resolved",
+			"avg_first_response_seconds", "avg_resolution_seconds",
+			"first_response_breaches", "resolution_breaches", "breached", "compliance",
+		},
+		Rows: [][]string{},
+	}
+	for _, a := range report.Agents {
+		table.Rows = append(table.Rows, []string{
+			a.AgentID, itoa(a.Conversations), itoa(a.Responded), itoa(a.Resolved),
+			ftoa(a.AvgFirstResponseSeconds, 1), ftoa(a.AvgResolutionSeconds, 1),
+			itoa(a.FirstResponseBreaches), itoa(a.ResolutionBreaches), itoa(a.Breached), ftoa(a.Compliance, 4),
+		})
+	}
+	return table
+}
+
+// TopicsTable lays out a topic snapshot, one row per topic with its
+// examples joined by " | ".
+func TopicsTable(snapshot *topicDomain.Snapshot) reportDomain.Table {
+	table := reportDomain.Table{
+		Metric: reportDomain.MetricTopics,
+		Title:  "Question topics",
+		Header: []string{"topic", "questions", "share", "examples"},
+		Rows:   [][]string{},
+	}
+	if snapshot == nil {
+		return table
+	}
+	table.Title += " " + snapshot.From.UTC().Format(time.DateOnly) + " to " + snapshot.To.UTC().Format(time.DateOnly)
+	for _, t := range snapshot.Topics {
+		table.Rows = append(table.Rows, []string{t.Label, strconv.Itoa(t.Questions), ftoa(t.Share, 4), strings.Join(t.Examples, " | ")})
+	}
+	return table
+}
+
+// QueriesTable lays out query volume as a single row.
+func QueriesTable(stats *documentDomain.QueryStats) reportDomain.Table {
+	return reportDomain.Table{
+		Metric: reportDomain.MetricQueries,
+		Title:  "Queries",
+		Header: []string{"total", "low_confidence", "no_results"},
+		Rows:   [][]string{{itoa(stats.Total), itoa(stats.LowConfidence), itoa(stats.NoResults)}},
+	}
+}
+
+// GapsTable lays out knowledge gaps, most asked first.
+func GapsTable(gaps []documentDomain.KnowledgeGap) reportDomain.Table {
+	table := reportDomain.Table{
+		Metric: reportDomain.MetricKnowledgeGaps,
+		Title:  "Knowledge gaps",
+		Header: []string{"query", "count", "first_asked_at"},
+		Rows:   [][]string{},
+	}
+	for _, g := range gaps {
+		table.Rows = append(table.Rows, []string{g.Query, itoa(g.Count), g.FirstAskedAt.UTC().Format(time.RFC3339)})
+	}
+	return table
+}
+
+func itoa(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
+
+func ftoa(f float64, prec int) string {
+	return strconv.FormatFloat(f, 'f', prec, 64)
+}
@@ -0,0 +1,307 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	schedulerDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/scheduler"
+	"github.com/elprogramadorgt/lucidRAG/pkg/cron"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrDuplicateJob = errors.New("job already registered")
+	ErrInvalidJob   = errors.New("job name and function are required")
+)
+
+const (
+	defaultTimeout      = 5 * time.Minute
+	defaultTickInterval = 10 * time.Second
+)
+
+// Leader reports whether this instance is the elected leader.
+type Leader interface {
+	IsLeader() bool
+}
+
+// Pauser reports whether a job should skip its occurrences for now, such as
+// during maintenance.
+type Pauser interface {
+	Paused(job string) bool
+}
+
+// Func is the work a job performs. The context is cancelled when the job's
+// timeout elapses or the scheduler stops.
+type Func func(ctx context.Context) error
+
+type job struct {
+	name     string
+	schedule *cron.Schedule
+	timeout  time.Duration
+	fn       Func
+
+	// Guarded by Scheduler.mu.
+	next    time.Time
+	running bool
+	last    *schedulerDomain.Run
+}
+
+// Scheduler runs registered jobs on cron schedules. When a repository is
+// configured each occurrence is claimed through it first, so replicas sharing
+// the database run every occurrence exactly once.
+type Scheduler struct {
+	repo   schedulerDomain.Repository
+	leader Leader
+	pauser Pauser
+	log    *logger.Logger
+	owner  string
+	tick   time.Duration
+	loc    *time.Location
+
+	mu   sync.Mutex
+	jobs map[string]*job
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type Config struct {
+	// Repo provides distributed locking; nil runs every job locally.
+	Repo schedulerDomain.Repository
+	// Leader restricts runs to the elected leader; nil runs on every
+	// instance.
+	Leader Leader
+	// Pauser, when set, holds back the jobs it reports as paused; their
+	// missed occurrences are not replayed.
+	Pauser Pauser
+	Log    *logger.Logger
+	// Owner identifies this replica in locks. Defaults to the hostname plus
+	// a random suffix.
+	Owner string
+	// Location is the time zone cron expressions are evaluated in. Defaults
+	// to UTC.
+	Location *time.Location
+}
+
+func New(cfg Config) *Scheduler {
+	owner := cfg.Owner
+	if owner == "" {
+		host, _ := os.Hostname()
+		owner = host + "-" + primitive.NewObjectID().Hex()
+	}
+
+	loc := cfg.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		repo:   cfg.Repo,
+		leader: cfg.Leader,
+		pauser: cfg.Pauser,
+		log:    cfg.Log.With("component", "scheduler"),
+		owner:  owner,
+		tick:   defaultTickInterval,
+		loc:    loc,
+		jobs:   make(map[string]*job),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Register adds a job. spec is a five-field cron expression evaluated in the
+// scheduler's time zone; timeout of zero uses a five minute default.
+func (s *Scheduler) Register(name, spec string, timeout time.Duration, fn Func) error {
+	if name == "" || fn == nil {
+		return ErrInvalidJob
+	}
+
+	schedule, err := cron.Parse(spec)
+	if err != nil {
+		return err
+	}
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.jobs[name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateJob, name)
+	}
+	s.jobs[name] = &job{
+		name:     name,
+		schedule: schedule,
+		timeout:  timeout,
+		fn:       fn,
+		next:     schedule.Next(time.Now().In(s.loc)),
+	}
+	return nil
+}
+
+// Start checks for due jobs in the background until Stop is called.
+func (s *Scheduler) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.tick)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.runDue(now)
+			}
+		}
+	}()
+}
+
+// Stop cancels running jobs and waits for them to return.
+func (s *Scheduler) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// runDue starts jobs whose next occurrence has passed. Followers advance their
+// schedules without running anything, so a new leader starts from the next
+// occurrence rather than replaying missed ones.
+func (s *Scheduler) runDue(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	follower := s.leader != nil && !s.leader.IsLeader()
+	for _, j := range s.jobs {
+		if j.next.IsZero() || now.Before(j.next) {
+			continue
+		}
+		scheduledFor := j.next
+		j.next = j.schedule.Next(now.In(s.loc))
+
+		if follower || (s.pauser != nil && s.pauser.Paused(j.name)) {
+			continue
+		}
+
+		if j.running {
+			s.log.Warn("job still running, skipping occurrence", "job", j.name, "scheduled_for", scheduledFor)
+			continue
+		}
+		j.running = true
+
+		s.wg.Add(1)
+		go func(j *job) {
+			defer s.wg.Done()
+			s.run(j, scheduledFor)
+		}(j)
+	}
+}
+
+func (s *Scheduler) run(j *job, scheduledFor time.Time) {
+	defer func() {
+		s.mu.Lock()
+		j.running = false
+		s.mu.Unlock()
+	}()
+
+	start := time.Now()
+	if s.repo != nil {
+		ok, err := s.repo.Acquire(s.ctx, j.name, s.owner, scheduledFor, start.Add(j.timeout))
+		if err != nil {
+			s.log.Error("failed to acquire job lock", "job", j.name, "error", err)
+			return
+		}
+		if !ok {
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, j.timeout)
+	err := j.fn(ctx)
+	cancel()
+
+	run := schedulerDomain.Run{
+		StartedAt: start,
+		Duration:  time.Since(start),
+		Status:    schedulerDomain.RunStatusSuccess,
+	}
+	if err != nil {
+		run.Status = schedulerDomain.RunStatusFailed
+		run.Error = err.Error()
+		s.log.Error("job failed", "job", j.name, "error", err, "duration_ms", run.Duration.Milliseconds())
+	} else {
+		s.log.Info("job completed", "job", j.name, "duration_ms", run.Duration.Milliseconds())
+	}
+
+	s.mu.Lock()
+	j.last = &run
+	s.mu.Unlock()
+
+	if s.repo != nil {
+		// The run is recorded even when the scheduler is stopping.
+		recordCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := s.repo.Complete(recordCtx, j.name, s.owner, run); err != nil {
+			s.log.Error("failed to record job run", "job", j.name, "error", err)
+		}
+	}
+}
+
+// Jobs lists registered jobs with their last run. With a repository the last
+// run may have happened on another replica.
+func (s *Scheduler) Jobs(ctx context.Context) ([]schedulerDomain.JobStatus, error) {
+	states := map[string]schedulerDomain.JobState{}
+	if s.repo != nil {
+		list, err := s.repo.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, st := range list {
+			states[st.Name] = st
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	result := make([]schedulerDomain.JobStatus, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		status := schedulerDomain.JobStatus{
+			Name:      j.name,
+			Schedule:  j.schedule.String(),
+			NextRunAt: j.next,
+			Running:   j.running,
+		}
+
+		if st, ok := states[j.name]; ok {
+			status.Running = status.Running || st.LockedUntil.After(now)
+			status.LastRunAt = st.LastRunAt
+			status.LastDurationMs = st.LastDurationMs
+			status.LastStatus = st.LastStatus
+			status.LastError = st.LastError
+			status.LastRunBy = st.LastRunBy
+		} else if j.last != nil {
+			startedAt := j.last.StartedAt
+			status.LastRunAt = &startedAt
+			status.LastDurationMs = j.last.Duration.Milliseconds()
+			status.LastStatus = j.last.Status
+			status.LastError = j.last.Error
+			status.LastRunBy = s.owner
+		}
+
+		result = append(result, status)
+	}
+
+	sort.Slice(result, func(i, k int) bool { return result[i].Name < result[k].Name })
+	return result, nil
+}
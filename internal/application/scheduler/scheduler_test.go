@@ -0,0 +1,229 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	schedulerDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/scheduler"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+)
+
+// mockJobRepo mimics the Mongo repository's claim semantics in memory.
+type mockJobRepo struct {
+	mu     sync.Mutex
+	states map[string]*schedulerDomain.JobState
+}
+
+func newMockJobRepo() *mockJobRepo {
+	return &mockJobRepo{states: make(map[string]*schedulerDomain.JobState)}
+}
+
+func (m *mockJobRepo) Acquire(ctx context.Context, name, owner string, scheduledFor, lockedUntil time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	st, ok := m.states[name]
+	if ok && (!st.ScheduledFor.Before(scheduledFor) || st.LockedUntil.After(time.Now())) {
+		return false, nil
+	}
+	if !ok {
+		st = &schedulerDomain.JobState{Name: name}
+		m.states[name] = st
+	}
+	st.LockedBy = owner
+	st.LockedUntil = lockedUntil
+	st.ScheduledFor = scheduledFor
+	return true, nil
+}
+
+func (m *mockJobRepo) Complete(ctx context.Context, name, owner string, run schedulerDomain.Run) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	st := m.states[name]
+	startedAt := run.StartedAt
+	st.LockedBy = ""
+	st.LockedUntil = time.Now()
+	st.LastRunAt = &startedAt
+	st.LastStatus = run.Status
+	st.LastError = run.Error
+	st.LastRunBy = owner
+	return nil
+}
+
+func (m *mockJobRepo) List(ctx context.Context) ([]schedulerDomain.JobState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	states := []schedulerDomain.JobState{}
+	for _, st := range m.states {
+		states = append(states, *st)
+	}
+	return states, nil
+}
+
+func newTestScheduler(repo schedulerDomain.Repository, owner string) *Scheduler {
+	return New(Config{Repo: repo, Log: logger.New(logger.Options{Level: "error"}), Owner: owner})
+}
+
+func TestRegisterValidation(t *testing.T) {
+	s := newTestScheduler(nil, "test")
+	noop := func(ctx context.Context) error { return nil }
+
+	if err := s.Register("", "* * * * *", 0, noop); !errors.Is(err, ErrInvalidJob) {
+		t.Errorf("Expected ErrInvalidJob, got %v", err)
+	}
+	if err := s.Register("bad", "not a cron", 0, noop); err == nil {
+		t.Error("Expected error for invalid schedule")
+	}
+	if err := s.Register("job", "* * * * *", 0, noop); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := s.Register("job", "* * * * *", 0, noop); !errors.Is(err, ErrDuplicateJob) {
+		t.Errorf("Expected ErrDuplicateJob, got %v", err)
+	}
+}
+
+func TestRunDueRecordsLastRun(t *testing.T) {
+	s := newTestScheduler(nil, "replica-1")
+	if err := s.Register("failing", "* * * * *", time.Second, func(ctx context.Context) error {
+		return errors.New("boom")
+	}); err != nil {
+		t.Fatalf("Failed to register job: %v", err)
+	}
+
+	s.runDue(time.Now().Add(time.Minute))
+	s.wg.Wait()
+
+	jobs, err := s.Jobs(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(jobs) != 1 {
+		t.Fatalf("Expected 1 job, got %d", len(jobs))
+	}
+	if jobs[0].LastStatus != schedulerDomain.RunStatusFailed || jobs[0].LastError != "boom" {
+		t.Errorf("Expected failed run with error boom, got %+v", jobs[0])
+	}
+	if jobs[0].LastRunAt == nil {
+		t.Error("Expected last run time to be set")
+	}
+}
+
+func TestScheduleUsesLocation(t *testing.T) {
+	guatemala := time.FixedZone("CST", -6*60*60)
+	s := New(Config{Log: logger.New(logger.Options{Level: "error"}), Location: guatemala})
+	if err := s.Register("nightly", "0 3 * * *", time.Second, func(ctx context.Context) error {
+		return nil
+	}); err != nil {
+		t.Fatalf("Failed to register job: %v", err)
+	}
+
+	// 08:00 UTC is 02:00 in Guatemala, so the next run is an hour later.
+	now := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	s.jobs["nightly"].next = now
+	s.runDue(now)
+	s.wg.Wait()
+	next := s.jobs["nightly"].next
+	if want := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC); !next.Equal(want) {
+		t.Errorf("Expected next run at %v, got %v", want, next)
+	}
+}
+
+func TestSharedRepoRunsOccurrenceOnce(t *testing.T) {
+	repo := newMockJobRepo()
+	var mu sync.Mutex
+	runs := 0
+	count := func(ctx context.Context) error {
+		mu.Lock()
+		runs++
+		mu.Unlock()
+		return nil
+	}
+
+	a := newTestScheduler(repo, "replica-a")
+	b := newTestScheduler(repo, "replica-b")
+	for _, s := range []*Scheduler{a, b} {
+		if err := s.Register("retention", "* * * * *", time.Second, count); err != nil {
+			t.Fatalf("Failed to register job: %v", err)
+		}
+	}
+
+	// Both replicas see the same occurrence due.
+	a.jobs["retention"].next = b.jobs["retention"].next
+	due := a.jobs["retention"].next.Add(time.Second)
+
+	a.runDue(due)
+	a.wg.Wait()
+	b.runDue(due)
+	b.wg.Wait()
+
+	if runs != 1 {
+		t.Errorf("Expected job to run once across replicas, got %d", runs)
+	}
+
+	jobs, _ := b.Jobs(context.Background())
+	if len(jobs) != 1 || jobs[0].LastRunBy != "replica-a" {
+		t.Errorf("Expected last run by replica-a, got %+v", jobs)
+	}
+}
+
+type staticLeader bool
+
+func (l staticLeader) IsLeader() bool { return bool(l) }
+
+func TestFollowerSkipsJobs(t *testing.T) {
+	ran := false
+	s := New(Config{Leader: staticLeader(false), Log: logger.New(logger.Options{Level: "error"}), Owner: "follower"})
+	if err := s.Register("retention", "* * * * *", time.Second, func(ctx context.Context) error {
+		ran = true
+		return nil
+	}); err != nil {
+		t.Fatalf("Failed to register job: %v", err)
+	}
+
+	due := s.jobs["retention"].next
+	s.runDue(due.Add(time.Second))
+	s.wg.Wait()
+
+	if ran {
+		t.Error("Expected follower not to run the job")
+	}
+	if !s.jobs["retention"].next.After(due) {
+		t.Error("Expected follower to advance the next run")
+	}
+}
+
+type pausedJobs map[string]bool
+
+func (p pausedJobs) Paused(job string) bool { return p[job] }
+
+func TestPausedJobsSkipOccurrences(t *testing.T) {
+	var mu sync.Mutex
+	ran := map[string]bool{}
+	s := New(Config{Pauser: pausedJobs{"retention": true}, Log: logger.New(logger.Options{Level: "error"}), Owner: "replica"})
+	for _, name := range []string{"retention", "migration"} {
+		if err := s.Register(name, "* * * * *", time.Second, func(ctx context.Context) error {
+			mu.Lock()
+			ran[name] = true
+			mu.Unlock()
+			return nil
+		}); err != nil {
+			t.Fatalf("Failed to register job: %v", err)
+		}
+	}
+
+	due := s.jobs["retention"].next
+	s.runDue(due.Add(time.Second))
+	s.wg.Wait()
+
+	if ran["retention"] || !ran["migration"] {
+		t.Errorf("Expected only the unpaused job to run, got %v", ran)
+	}
+	if !s.jobs["retention"].next.After(due) {
+		t.Error("Expected the paused job to advance its next run")
+	}
+}
@@ -0,0 +1,118 @@
+package slack
+
+import (
+	"context"
+	"strings"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+)
+
+// Responder answers Slack thread messages with a RAG reply posted in the
+// thread. It subscribes to MessageReceived so HandleMessage only has to
+// store messages. Threads handed off to an agent are left to them.
+type Responder struct {
+	client  Client
+	convSvc conversationDomain.Service
+	docSvc  documentDomain.Service
+	log     *logger.Logger
+
+	// historyWindow is how many earlier thread messages go into the prompt.
+	historyWindow int
+}
+
+func NewResponder(client Client, convSvc conversationDomain.Service, docSvc documentDomain.Service, historyWindow int, log *logger.Logger) *Responder {
+	return &Responder{
+		client:        client,
+		convSvc:       convSvc,
+		docSvc:        docSvc,
+		log:           log.With("subscriber", "slack_responder"),
+		historyWindow: historyWindow,
+	}
+}
+
+// Subscribe registers the responder on bus.
+func (r *Responder) Subscribe(bus *events.Bus) {
+	bus.Subscribe(r.handle, events.NameMessageReceived)
+}
+
+func (r *Responder) handle(ctx context.Context, event events.Event) {
+	msg, ok := event.(events.MessageReceived)
+	if !ok || msg.Channel != conversationDomain.ChannelSlack || msg.HumanMode {
+		return
+	}
+	_, channel, threadTS, ok := parseThreadID(msg.ExternalID)
+	if !ok {
+		r.log.WarnContext(ctx, "slack message without a thread", "conversation_id", msg.ConversationID)
+		return
+	}
+
+	query := documentDomain.RAGQuery{
+		Query:     msg.Content,
+		TopK:      5,
+		Threshold: 0.7,
+		Channel:   documentDomain.ChannelSlack,
+	}
+	r.addHistory(ctx, &query, msg)
+
+	resp, err := r.docSvc.QueryRAG(ctx, query)
+	if err != nil {
+		r.log.ErrorContext(ctx, "failed to query RAG", "error", err, "conversation_id", msg.ConversationID)
+		return
+	}
+
+	if _, err := r.client.PostMessage(ctx, channel, threadTS, resp.Answer); err != nil {
+		r.log.ErrorContext(ctx, "failed to post slack reply", "error", err, "conversation_id", msg.ConversationID)
+		return
+	}
+	if _, err := r.convSvc.SaveOutgoingMessage(ctx, msg.ConversationID, resp.Answer, resp.Answer); err != nil {
+		r.log.ErrorContext(ctx, "failed to save outgoing message", "error", err, "conversation_id", msg.ConversationID)
+		return
+	}
+
+	r.log.InfoContext(ctx, "slack reply posted",
+		"conversation_id", msg.ConversationID,
+		"confidence", resp.ConfidenceScore,
+		"processing_time_ms", resp.ProcessingTimeMs,
+	)
+}
+
+// addHistory gives the query the thread so far, without the message being
+// answered. Without history the question is still answered on its own.
+func (r *Responder) addHistory(ctx context.Context, query *documentDomain.RAGQuery, msg events.MessageReceived) {
+	if r.historyWindow <= 0 {
+		return
+	}
+
+	history, err := r.convSvc.GetHistory(ctx, msg.ConversationID, r.historyWindow+1)
+	if err != nil {
+		r.log.WarnContext(ctx, "failed to load conversation history", "error", err, "conversation_id", msg.ConversationID)
+		return
+	}
+
+	query.Summary = history.Summary
+	query.CustomerContext = history.Variables
+	for _, m := range history.Messages {
+		if m.ID == msg.MessageID {
+			continue
+		}
+		role := "user"
+		if m.Direction == conversationDomain.DirectionOutgoing {
+			role = "assistant"
+		}
+		query.History = append(query.History, documentDomain.Turn{Role: role, Content: m.PromptText()})
+	}
+	if len(query.History) > r.historyWindow {
+		query.History = query.History[len(query.History)-r.historyWindow:]
+	}
+}
+
+func parseThreadID(id string) (teamID, channel, threadTS string, ok bool) {
+	parts := strings.Split(id, ":")
+	if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+		return "", "", "", false
+	}
+	return parts[0], parts[1], parts[2], true
+}
@@ -0,0 +1,176 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	slackDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/slack"
+	userDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/user"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/elprogramadorgt/lucidRAG/pkg/slack"
+)
+
+var ErrUnknownUser = errors.New("slack user has no lucidRAG account")
+
+// unlinkedNotice is sent to Slack users whose email matches no account.
+const unlinkedNotice = "I couldn't find a lucidRAG account with your Slack email. Ask an admin to invite you, then try again."
+
+// mention matches user mentions such as <@U024BE7LH>, which Slack puts in
+// front of questions asked by mentioning the app.
+var mention = regexp.MustCompile(`<@[A-Z0-9]+(\|[^>]*)?>`)
+
+// Client is the Slack Web API used by the service.
+type Client interface {
+	PostMessage(ctx context.Context, channel, threadTS, text string) (string, error)
+	UserEmail(ctx context.Context, userID string) (string, error)
+	Respond(ctx context.Context, responseURL, text string, ephemeral bool) error
+}
+
+// service answers Slack questions from the knowledge base. Thread messages
+// go through the conversation service like any other channel, and the
+// Responder posts the answer back.
+type service struct {
+	client  Client
+	links   slackDomain.LinkRepository
+	users   userDomain.Repository
+	convSvc conversationDomain.Service
+	docSvc  documentDomain.Service
+	log     *logger.Logger
+}
+
+type ServiceConfig struct {
+	Client   Client
+	LinkRepo slackDomain.LinkRepository
+	UserRepo userDomain.Repository
+	ConvSvc  conversationDomain.Service
+	DocSvc   documentDomain.Service
+	Log      *logger.Logger
+}
+
+func NewService(cfg ServiceConfig) slackDomain.Service {
+	return &service{
+		client:  cfg.Client,
+		links:   cfg.LinkRepo,
+		users:   cfg.UserRepo,
+		convSvc: cfg.ConvSvc,
+		docSvc:  cfg.DocSvc,
+		log:     cfg.Log.With("service", "slack"),
+	}
+}
+
+func (s *service) HandleMessage(ctx context.Context, msg slackDomain.Message) error {
+	// The app's own replies, edits and joins arrive as message events too.
+	if msg.BotID != "" || msg.Subtype != "" {
+		return nil
+	}
+	text := question(msg.Text)
+	if text == "" {
+		return nil
+	}
+	threadTS := msg.ThreadTS
+	if threadTS == "" {
+		threadTS = msg.TS
+	}
+
+	user, err := s.resolveUser(ctx, msg.TeamID, msg.UserID)
+	if errors.Is(err, ErrUnknownUser) {
+		_, postErr := s.client.PostMessage(ctx, msg.Channel, threadTS, unlinkedNotice)
+		return postErr
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = s.convSvc.SaveThreadMessage(ctx, conversationDomain.ThreadMessage{
+		Channel:     conversationDomain.ChannelSlack,
+		ExternalID:  threadID(msg.TeamID, msg.Channel, threadTS),
+		UserID:      user.ID,
+		From:        msg.UserID,
+		ContactName: strings.TrimSpace(user.FirstName + " " + user.LastName),
+		Content:     text,
+	})
+	return err
+}
+
+func (s *service) Ask(ctx context.Context, cmd slackDomain.Command) error {
+	text := strings.TrimSpace(cmd.Text)
+	if text == "" {
+		return s.client.Respond(ctx, cmd.ResponseURL, "Ask a question after the command, e.g. /ask how do I reset my password?", true)
+	}
+
+	if _, err := s.resolveUser(ctx, cmd.TeamID, cmd.UserID); err != nil {
+		if errors.Is(err, ErrUnknownUser) {
+			return s.client.Respond(ctx, cmd.ResponseURL, unlinkedNotice, true)
+		}
+		return err
+	}
+
+	resp, err := s.docSvc.QueryRAG(ctx, documentDomain.RAGQuery{
+		Query:     text,
+		TopK:      5,
+		Threshold: 0.7,
+		Channel:   documentDomain.ChannelSlack,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to query RAG: %w", err)
+	}
+	return s.client.Respond(ctx, cmd.ResponseURL, resp.Answer, true)
+}
+
+// resolveUser returns the lucidRAG user for a Slack user, matching by email
+// the first time. Unmatched users are looked up again next time, so they
+// can ask once an account is created for them.
+func (s *service) resolveUser(ctx context.Context, teamID, slackUserID string) (*userDomain.User, error) {
+	id := teamID + ":" + slackUserID
+	link, err := s.links.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if link != nil {
+		user, err := s.users.GetByID(ctx, link.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil && user.IsActive {
+			return user, nil
+		}
+		// The account was removed or deactivated; match the email again.
+	}
+
+	email, err := s.client.UserEmail(ctx, slackUserID)
+	if errors.Is(err, slack.ErrNoEmail) {
+		return nil, ErrUnknownUser
+	}
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.users.GetByEmail(ctx, strings.ToLower(email))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.IsActive {
+		return nil, ErrUnknownUser
+	}
+
+	link = &slackDomain.Link{SlackUserID: id, UserID: user.ID, Email: user.Email, LinkedAt: time.Now()}
+	if err := s.links.Upsert(ctx, link); err != nil {
+		s.log.WarnContext(ctx, "failed to save slack user link", "error", err, "slack_user_id", id)
+	}
+	return user, nil
+}
+
+// question strips app mentions from a message.
+func question(text string) string {
+	return strings.TrimSpace(mention.ReplaceAllString(text, ""))
+}
+
+// threadID is the conversation's external ID for a Slack thread.
+func threadID(teamID, channel, threadTS string) string {
+	return teamID + ":" + channel + ":" + threadTS
+}
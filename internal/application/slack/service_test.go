@@ -0,0 +1,244 @@
+package slack
+
+import (
+	"context"
+	"testing"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	slackDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/slack"
+	userDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/user"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/elprogramadorgt/lucidRAG/pkg/slack"
+)
+
+type posted struct {
+	channel, threadTS, text string
+}
+
+type mockClient struct {
+	emails    map[string]string
+	posts     []posted
+	responses []string
+}
+
+func (m *mockClient) PostMessage(ctx context.Context, channel, threadTS, text string) (string, error) {
+	m.posts = append(m.posts, posted{channel, threadTS, text})
+	return "1700000000.000200", nil
+}
+
+func (m *mockClient) UserEmail(ctx context.Context, userID string) (string, error) {
+	if email, ok := m.emails[userID]; ok {
+		return email, nil
+	}
+	return "", slack.ErrNoEmail
+}
+
+func (m *mockClient) Respond(ctx context.Context, responseURL, text string, ephemeral bool) error {
+	m.responses = append(m.responses, text)
+	return nil
+}
+
+type mockLinkRepo struct {
+	links map[string]*slackDomain.Link
+}
+
+func (m *mockLinkRepo) Get(ctx context.Context, id string) (*slackDomain.Link, error) {
+	return m.links[id], nil
+}
+
+func (m *mockLinkRepo) Upsert(ctx context.Context, link *slackDomain.Link) error {
+	m.links[link.SlackUserID] = link
+	return nil
+}
+
+type mockUserRepo struct {
+	userDomain.Repository
+	users []*userDomain.User
+}
+
+func (m *mockUserRepo) GetByID(ctx context.Context, id string) (*userDomain.User, error) {
+	for _, u := range m.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockUserRepo) GetByEmail(ctx context.Context, email string) (*userDomain.User, error) {
+	for _, u := range m.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+
+type mockConvService struct {
+	conversationDomain.Service
+	saved    []conversationDomain.ThreadMessage
+	outgoing []string
+	history  *conversationDomain.History
+}
+
+func (m *mockConvService) SaveThreadMessage(ctx context.Context, msg conversationDomain.ThreadMessage) (*conversationDomain.Message, error) {
+	m.saved = append(m.saved, msg)
+	return &conversationDomain.Message{ID: "msg_1", Content: msg.Content}, nil
+}
+
+func (m *mockConvService) SaveOutgoingMessage(ctx context.Context, conversationID, content, ragAnswer string) (*conversationDomain.Message, error) {
+	m.outgoing = append(m.outgoing, content)
+	return &conversationDomain.Message{ID: "msg_2"}, nil
+}
+
+func (m *mockConvService) GetHistory(ctx context.Context, conversationID string, limit int) (*conversationDomain.History, error) {
+	return m.history, nil
+}
+
+type mockDocService struct {
+	documentDomain.Service
+	queries []documentDomain.RAGQuery
+}
+
+func (m *mockDocService) QueryRAG(ctx context.Context, query documentDomain.RAGQuery) (*documentDomain.RAGResponse, error) {
+	m.queries = append(m.queries, query)
+	return &documentDomain.RAGResponse{Answer: "Use the reset link."}, nil
+}
+
+func newTestService() (slackDomain.Service, *mockClient, *mockLinkRepo, *mockConvService, *mockDocService) {
+	client := &mockClient{emails: map[string]string{"U1": "Ana@Example.com", "U2": "bob@example.com"}}
+	links := &mockLinkRepo{links: map[string]*slackDomain.Link{}}
+	users := &mockUserRepo{users: []*userDomain.User{
+		{ID: "user_1", Email: "ana@example.com", FirstName: "Ana", LastName: "Lopez", IsActive: true},
+	}}
+	convSvc := &mockConvService{}
+	docSvc := &mockDocService{}
+	svc := NewService(ServiceConfig{
+		Client:   client,
+		LinkRepo: links,
+		UserRepo: users,
+		ConvSvc:  convSvc,
+		DocSvc:   docSvc,
+		Log:      logger.New(logger.Options{Level: "error"}),
+	})
+	return svc, client, links, convSvc, docSvc
+}
+
+func TestHandleMessage(t *testing.T) {
+	svc, client, links, convSvc, _ := newTestService()
+
+	err := svc.HandleMessage(context.Background(), slackDomain.Message{
+		TeamID: "T1", Channel: "C1", UserID: "U1", Text: "<@UBOT> how do I reset my password?", TS: "1700000000.000100",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(convSvc.saved) != 1 {
+		t.Fatalf("Expected 1 saved message, got %d", len(convSvc.saved))
+	}
+	saved := convSvc.saved[0]
+	if saved.Content != "how do I reset my password?" {
+		t.Errorf("Expected mention stripped, got %q", saved.Content)
+	}
+	if saved.ExternalID != "T1:C1:1700000000.000100" || saved.UserID != "user_1" || saved.ContactName != "Ana Lopez" {
+		t.Errorf("Unexpected thread message %+v", saved)
+	}
+	if links.links["T1:U1"] == nil {
+		t.Error("Expected Slack user to be linked")
+	}
+	if len(client.posts) != 0 {
+		t.Errorf("Expected no posts before the answer, got %d", len(client.posts))
+	}
+
+	// Replies in the thread continue the same conversation.
+	_ = svc.HandleMessage(context.Background(), slackDomain.Message{
+		TeamID: "T1", Channel: "C1", UserID: "U1", Text: "and on mobile?", TS: "1700000000.000300", ThreadTS: "1700000000.000100",
+	})
+	if len(convSvc.saved) != 2 || convSvc.saved[1].ExternalID != saved.ExternalID {
+		t.Errorf("Expected reply in the same thread, got %+v", convSvc.saved)
+	}
+}
+
+func TestHandleMessageIgnoresBotsAndUnknownUsers(t *testing.T) {
+	svc, client, links, convSvc, _ := newTestService()
+
+	_ = svc.HandleMessage(context.Background(), slackDomain.Message{
+		TeamID: "T1", Channel: "C1", UserID: "U1", Text: "Use the reset link.", TS: "1", BotID: "B1",
+	})
+	if len(convSvc.saved) != 0 || len(client.posts) != 0 {
+		t.Error("Expected bot messages to be ignored")
+	}
+
+	err := svc.HandleMessage(context.Background(), slackDomain.Message{
+		TeamID: "T1", Channel: "C1", UserID: "U2", Text: "hello", TS: "2",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(convSvc.saved) != 0 {
+		t.Error("Expected no message saved for an unknown user")
+	}
+	if len(client.posts) != 1 || client.posts[0].text != unlinkedNotice || client.posts[0].threadTS != "2" {
+		t.Errorf("Expected unlinked notice in the thread, got %+v", client.posts)
+	}
+	if len(links.links) != 0 {
+		t.Error("Expected unknown users not to be linked")
+	}
+}
+
+func TestAsk(t *testing.T) {
+	svc, client, _, _, docSvc := newTestService()
+
+	if err := svc.Ask(context.Background(), slackDomain.Command{TeamID: "T1", UserID: "U1", Text: "reset password"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(docSvc.queries) != 1 || docSvc.queries[0].Channel != documentDomain.ChannelSlack {
+		t.Errorf("Expected a slack RAG query, got %+v", docSvc.queries)
+	}
+	if len(client.responses) != 1 || client.responses[0] != "Use the reset link." {
+		t.Errorf("Expected answer response, got %v", client.responses)
+	}
+}
+
+func TestResponder(t *testing.T) {
+	client := &mockClient{}
+	convSvc := &mockConvService{history: &conversationDomain.History{
+		Messages: []conversationDomain.Message{
+			{ID: "msg_0", Direction: conversationDomain.DirectionIncoming, Content: "how do I reset my password?"},
+			{ID: "msg_1", Direction: conversationDomain.DirectionIncoming, Content: "and on mobile?"},
+		},
+	}}
+	docSvc := &mockDocService{}
+	bus := events.NewBus()
+	NewResponder(client, convSvc, docSvc, 5, logger.New(logger.Options{Level: "error"})).Subscribe(bus)
+
+	// Messages from other channels are left to their own responders.
+	bus.Publish(context.Background(), events.MessageReceived{ConversationID: "conv_1", Channel: "whatsapp", Content: "hi"})
+	if len(docSvc.queries) != 0 {
+		t.Fatal("Expected WhatsApp message to be ignored")
+	}
+
+	bus.Publish(context.Background(), events.MessageReceived{
+		MessageID:      "msg_1",
+		ConversationID: "conv_1",
+		Channel:        conversationDomain.ChannelSlack,
+		ExternalID:     "T1:C1:1700000000.000100",
+		Content:        "and on mobile?",
+	})
+
+	if len(docSvc.queries) != 1 {
+		t.Fatalf("Expected 1 RAG query, got %d", len(docSvc.queries))
+	}
+	if history := docSvc.queries[0].History; len(history) != 1 || history[0].Content != "how do I reset my password?" {
+		t.Errorf("Expected earlier thread message as history, got %+v", history)
+	}
+	if len(client.posts) != 1 || client.posts[0].channel != "C1" || client.posts[0].threadTS != "1700000000.000100" {
+		t.Errorf("Expected reply in the thread, got %+v", client.posts)
+	}
+	if len(convSvc.outgoing) != 1 {
+		t.Errorf("Expected answer saved, got %d", len(convSvc.outgoing))
+	}
+}
@@ -0,0 +1,250 @@
+package system
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"time"
+
+	systemDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/system"
+	"github.com/elprogramadorgt/lucidRAG/pkg/asn"
+	"github.com/elprogramadorgt/lucidRAG/pkg/cache"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+)
+
+const (
+	defaultBurstWindow  = 10 * time.Second
+	defaultBlockFor     = 15 * time.Minute
+	defaultTravelWindow = time.Hour
+
+	defaultEventLimit = 50
+	maxEventLimit     = 500
+)
+
+var ErrInvalidIP = errors.New("invalid IP address")
+
+// AbuseGuard spots abuse of the public widget and webhook endpoints and
+// blocks its source for a while: IPs calling in bursts or from a blocked
+// network, and widget sessions reused from another country. Blocks and
+// counters live in the cache, so with Redis every replica enforces them.
+type AbuseGuard struct {
+	cache        cache.Cache
+	repo         systemDomain.SecurityEventRepository
+	asn          *asn.DB
+	log          *logger.Logger
+	burst        int
+	burstWindow  time.Duration
+	blockFor     time.Duration
+	travelWindow time.Duration
+	blockedASNs  []uint32
+	now          func() time.Time
+}
+
+type AbuseConfig struct {
+	Cache cache.Cache
+	Repo  systemDomain.SecurityEventRepository
+	// ASN maps IPs to their network and country. Without it blocked ASNs
+	// and impossible travel are not detected.
+	ASN *asn.DB
+	Log *logger.Logger
+	// More than BurstRequests from one IP within BurstWindow, 10 seconds
+	// by default, is a burst. Zero turns burst detection off.
+	BurstRequests int
+	BurstWindow   time.Duration
+	// BlockFor is how long a block lasts, 15 minutes by default.
+	BlockFor time.Duration
+	// TravelWindow is how long after its first use a widget session may
+	// not show up from another country, an hour by default.
+	TravelWindow time.Duration
+	BlockedASNs  []uint32
+}
+
+func NewAbuseGuard(cfg AbuseConfig) *AbuseGuard {
+	g := &AbuseGuard{
+		cache:        cfg.Cache,
+		repo:         cfg.Repo,
+		asn:          cfg.ASN,
+		log:          cfg.Log.With("component", "abuse"),
+		burst:        cfg.BurstRequests,
+		burstWindow:  cfg.BurstWindow,
+		blockFor:     cfg.BlockFor,
+		travelWindow: cfg.TravelWindow,
+		blockedASNs:  cfg.BlockedASNs,
+		now:          time.Now,
+	}
+	if g.burstWindow <= 0 {
+		g.burstWindow = defaultBurstWindow
+	}
+	if g.blockFor <= 0 {
+		g.blockFor = defaultBlockFor
+	}
+	if g.travelWindow <= 0 {
+		g.travelWindow = defaultTravelWindow
+	}
+	return g
+}
+
+// Check reports until when r's IP or session is blocked. A call from a
+// blocked network blocks its IP. A cache outage lets calls through.
+func (g *AbuseGuard) Check(ctx context.Context, r systemDomain.AbuseRequest) (time.Time, bool) {
+	if until, ok := g.blockedUntil(ctx, ipBlockKey(r.IP)); ok {
+		return until, true
+	}
+	if r.Session != "" {
+		if until, ok := g.blockedUntil(ctx, sessionBlockKey(r.Session)); ok {
+			return until, true
+		}
+	}
+
+	if len(g.blockedASNs) > 0 {
+		if network, ok := g.lookup(r.IP); ok && slices.Contains(g.blockedASNs, network.ASN) {
+			until := g.block(ctx, r, systemDomain.SecurityEvent{
+				Type:    systemDomain.SecurityEventBlockedASN,
+				Blocked: "ip",
+				Detail:  fmt.Sprintf("AS%d (%s) is on the blocked list", network.ASN, network.Name),
+			})
+			return until, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Observe counts r towards its IP's burst and, for a widget session, checks
+// where the session was used before. It reports the block it placed, if
+// any.
+func (g *AbuseGuard) Observe(ctx context.Context, r systemDomain.AbuseRequest) (time.Time, bool) {
+	if g.burst > 0 {
+		bucket := g.now().UnixNano() / int64(g.burstWindow)
+		n, err := g.cache.Incr(ctx, fmt.Sprintf("abuse:burst:%s:%s:%d", r.Scope, r.IP, bucket), g.burstWindow)
+		if err == nil && n > int64(g.burst) {
+			until := g.block(ctx, r, systemDomain.SecurityEvent{
+				Type:    systemDomain.SecurityEventBurst,
+				Blocked: "ip",
+				Detail:  fmt.Sprintf("more than %d requests in %s", g.burst, g.burstWindow),
+			})
+			return until, true
+		}
+	}
+
+	if r.Session != "" && g.asn != nil {
+		if until, ok := g.checkTravel(ctx, r); ok {
+			return until, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// checkTravel remembers the country a session is first used from and
+// blocks the session when it shows up from another one within the travel
+// window. Addresses the table does not know are left alone.
+func (g *AbuseGuard) checkTravel(ctx context.Context, r systemDomain.AbuseRequest) (time.Time, bool) {
+	network, ok := g.lookup(r.IP)
+	if !ok || network.Country == "" {
+		return time.Time{}, false
+	}
+	key := "abuse:session:" + sessionHash(r.Session)
+	first, err := g.cache.Get(ctx, key)
+	if errors.Is(err, cache.ErrMiss) {
+		if err := g.cache.Set(ctx, key, []byte(network.Country), g.travelWindow); err != nil {
+			g.log.WarnContext(ctx, "failed to remember session country", "error", err)
+		}
+		return time.Time{}, false
+	}
+	if err != nil || string(first) == network.Country {
+		return time.Time{}, false
+	}
+
+	until := g.block(ctx, r, systemDomain.SecurityEvent{
+		Type:    systemDomain.SecurityEventImpossibleTravel,
+		Blocked: "session",
+		Detail:  fmt.Sprintf("session used from %s, then from %s within %s", first, network.Country, g.travelWindow),
+	})
+	return until, true
+}
+
+// block blocks event's IP or r's session and records event.
+func (g *AbuseGuard) block(ctx context.Context, r systemDomain.AbuseRequest, event systemDomain.SecurityEvent) time.Time {
+	now := g.now()
+	until := now.Add(g.blockFor).Truncate(time.Second)
+	key := ipBlockKey(r.IP)
+	if event.Blocked == "session" {
+		key = sessionBlockKey(r.Session)
+	}
+	if err := g.cache.Set(ctx, key, []byte(until.Format(time.RFC3339)), g.blockFor); err != nil {
+		g.log.WarnContext(ctx, "failed to block abusive client", "error", err, "ip", r.IP)
+	}
+
+	event.Scope = r.Scope
+	event.IP = r.IP
+	if network, ok := g.lookup(r.IP); ok {
+		event.ASN, event.Country = network.ASN, network.Country
+	}
+	event.BlockedUntil = until
+	event.CreatedAt = now
+	g.log.WarnContext(ctx, "blocked abusive client", "type", event.Type, "scope", event.Scope, "ip", event.IP,
+		"blocked", event.Blocked, "detail", event.Detail)
+	if g.repo != nil {
+		if err := g.repo.Insert(ctx, &event); err != nil {
+			g.log.WarnContext(ctx, "failed to record security event", "error", err)
+		}
+	}
+	return until
+}
+
+func (g *AbuseGuard) blockedUntil(ctx context.Context, key string) (time.Time, bool) {
+	value, err := g.cache.Get(ctx, key)
+	if err != nil {
+		return time.Time{}, false
+	}
+	until, err := time.Parse(time.RFC3339, string(value))
+	if err != nil || !g.now().Before(until) {
+		return time.Time{}, false
+	}
+	return until, true
+}
+
+func (g *AbuseGuard) lookup(ip string) (asn.Record, bool) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return asn.Record{}, false
+	}
+	return g.asn.Lookup(addr)
+}
+
+// Unblock lifts the block on ip before it ends.
+func (g *AbuseGuard) Unblock(ctx context.Context, ip string) error {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidIP, ip)
+	}
+	return g.cache.Delete(ctx, ipBlockKey(addr.Unmap().String()))
+}
+
+// Events returns the security events feed, newest first.
+func (g *AbuseGuard) Events(ctx context.Context, filter systemDomain.SecurityEventFilter) ([]systemDomain.SecurityEvent, error) {
+	if filter.Limit <= 0 || filter.Limit > maxEventLimit {
+		filter.Limit = defaultEventLimit
+	}
+	if g.repo == nil {
+		return []systemDomain.SecurityEvent{}, nil
+	}
+	return g.repo.List(ctx, filter)
+}
+
+func ipBlockKey(ip string) string {
+	return "abuse:block:ip:" + ip
+}
+
+func sessionBlockKey(session string) string {
+	return "abuse:block:session:" + sessionHash(session)
+}
+
+// sessionHash keeps session tokens out of the cache.
+func sessionHash(session string) string {
+	sum := sha256.Sum256([]byte(session))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,152 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	systemDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/system"
+	"github.com/elprogramadorgt/lucidRAG/pkg/asn"
+	"github.com/elprogramadorgt/lucidRAG/pkg/cache"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+)
+
+type mockSecurityEventRepo struct {
+	events []systemDomain.SecurityEvent
+	filter systemDomain.SecurityEventFilter
+}
+
+func (m *mockSecurityEventRepo) Insert(ctx context.Context, event *systemDomain.SecurityEvent) error {
+	m.events = append(m.events, *event)
+	return nil
+}
+
+func (m *mockSecurityEventRepo) List(ctx context.Context, filter systemDomain.SecurityEventFilter) ([]systemDomain.SecurityEvent, error) {
+	m.filter = filter
+	return m.events, nil
+}
+
+const testASNTable = "104.131.0.0\t104.131.255.255\t14061\tUS\tDIGITALOCEAN-ASN\n" +
+	"81.2.69.0\t81.2.69.255\t20712\tGB\tANDREWS-ARNOLD\n" +
+	"89.160.20.0\t89.160.20.255\t29518\tSE\tBREDBAND2\n"
+
+func newTestAbuseGuard(t *testing.T, cfg AbuseConfig) (*AbuseGuard, *mockSecurityEventRepo) {
+	t.Helper()
+	db, err := asn.Load(strings.NewReader(testASNTable))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	mem := cache.NewMemory()
+	t.Cleanup(mem.Stop)
+	repo := &mockSecurityEventRepo{}
+	cfg.Cache, cfg.Repo, cfg.ASN = mem, repo, db
+	cfg.Log = logger.New(logger.Options{Level: "error"})
+	g := NewAbuseGuard(cfg)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	g.now = func() time.Time { return now }
+	return g, repo
+}
+
+func TestAbuseGuardBurst(t *testing.T) {
+	ctx := context.Background()
+	g, repo := newTestAbuseGuard(t, AbuseConfig{BurstRequests: 3})
+	r := systemDomain.AbuseRequest{Scope: "widget", IP: "81.2.69.160"}
+
+	for i := 0; i < 3; i++ {
+		if _, blocked := g.Observe(ctx, r); blocked {
+			t.Fatalf("Expected request %d let through", i+1)
+		}
+	}
+	until, blocked := g.Observe(ctx, r)
+	if !blocked {
+		t.Fatal("Expected the fourth request in the window to block the IP")
+	}
+	if want := g.now().Add(defaultBlockFor); !until.Equal(want) {
+		t.Errorf("Expected a block until %v, got %v", want, until)
+	}
+	if _, blocked := g.Check(ctx, r); !blocked {
+		t.Error("Expected the IP blocked")
+	}
+	if _, blocked := g.Check(ctx, systemDomain.AbuseRequest{Scope: "widget", IP: "81.2.69.161"}); blocked {
+		t.Error("Expected other IPs let through")
+	}
+
+	if len(repo.events) != 1 {
+		t.Fatalf("Expected one security event, got %d", len(repo.events))
+	}
+	event := repo.events[0]
+	if event.Type != systemDomain.SecurityEventBurst || event.Blocked != "ip" || event.ASN != 20712 || event.Country != "GB" {
+		t.Errorf("Unexpected event %+v", event)
+	}
+
+	if err := g.Unblock(ctx, "::ffff:81.2.69.160"); err != nil {
+		t.Fatalf("Unblock: %v", err)
+	}
+	if _, blocked := g.Check(ctx, r); blocked {
+		t.Error("Expected the IP let through after unblocking")
+	}
+	if err := g.Unblock(ctx, "nope"); !errors.Is(err, ErrInvalidIP) {
+		t.Errorf("Expected ErrInvalidIP, got %v", err)
+	}
+}
+
+func TestAbuseGuardImpossibleTravel(t *testing.T) {
+	ctx := context.Background()
+	g, repo := newTestAbuseGuard(t, AbuseConfig{})
+	home := systemDomain.AbuseRequest{Scope: "widget", IP: "89.160.20.112", Session: "session-token"}
+	away := systemDomain.AbuseRequest{Scope: "widget", IP: "104.131.20.5", Session: "session-token"}
+
+	if _, blocked := g.Observe(ctx, home); blocked {
+		t.Fatal("Expected the first use of a session let through")
+	}
+	if _, blocked := g.Observe(ctx, home); blocked {
+		t.Fatal("Expected the session let through from the same country")
+	}
+	if _, blocked := g.Observe(ctx, away); !blocked {
+		t.Fatal("Expected the session blocked when used from another country")
+	}
+	if _, blocked := g.Check(ctx, home); !blocked {
+		t.Error("Expected the session blocked from anywhere")
+	}
+	if _, blocked := g.Check(ctx, systemDomain.AbuseRequest{Scope: "widget", IP: home.IP}); blocked {
+		t.Error("Expected only the session blocked, not the IP")
+	}
+
+	if len(repo.events) != 1 || repo.events[0].Type != systemDomain.SecurityEventImpossibleTravel || repo.events[0].Country != "US" {
+		t.Errorf("Expected one impossible travel event from US, got %+v", repo.events)
+	}
+}
+
+func TestAbuseGuardBlockedASN(t *testing.T) {
+	ctx := context.Background()
+	g, repo := newTestAbuseGuard(t, AbuseConfig{BlockedASNs: []uint32{14061}})
+
+	if _, blocked := g.Check(ctx, systemDomain.AbuseRequest{Scope: "whatsapp", IP: "81.2.69.160"}); blocked {
+		t.Error("Expected other networks let through")
+	}
+	if _, blocked := g.Check(ctx, systemDomain.AbuseRequest{Scope: "whatsapp", IP: "104.131.20.5"}); !blocked {
+		t.Fatal("Expected the blocked network refused")
+	}
+	if len(repo.events) != 1 || repo.events[0].Type != systemDomain.SecurityEventBlockedASN || repo.events[0].ASN != 14061 {
+		t.Errorf("Expected one blocked ASN event, got %+v", repo.events)
+	}
+
+	// Later calls hit the IP block rather than recording another event.
+	g.Check(ctx, systemDomain.AbuseRequest{Scope: "whatsapp", IP: "104.131.20.5"})
+	if len(repo.events) != 1 {
+		t.Errorf("Expected no new event while blocked, got %d", len(repo.events))
+	}
+}
+
+func TestAbuseGuardEvents(t *testing.T) {
+	g, repo := newTestAbuseGuard(t, AbuseConfig{})
+
+	if _, err := g.Events(context.Background(), systemDomain.SecurityEventFilter{Limit: 10000}); err != nil {
+		t.Fatalf("Events: %v", err)
+	}
+	if repo.filter.Limit != defaultEventLimit {
+		t.Errorf("Expected an out of range limit replaced with %d, got %d", defaultEventLimit, repo.filter.Limit)
+	}
+}
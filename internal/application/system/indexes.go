@@ -0,0 +1,35 @@
+package system
+
+import (
+	systemDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/system"
+)
+
+// idIndex is the index every collection has on _id.
+const idIndex = "_id_"
+
+// AdviseIndexes reports the declared indexes missing from present, which
+// match by collection and keys, and the present indexes that are unused.
+func AdviseIndexes(declared, present []systemDomain.Index) systemDomain.IndexReport {
+	report := systemDomain.IndexReport{
+		Missing: []systemDomain.Index{},
+		Unused:  []systemDomain.Index{},
+		Indexes: present,
+	}
+	if report.Indexes == nil {
+		report.Indexes = []systemDomain.Index{}
+	}
+
+	existing := make(map[[2]string]bool, len(present))
+	for _, index := range present {
+		existing[[2]string{index.Collection, index.Keys}] = true
+		if index.Ops == 0 && index.Name != idIndex && !index.Unique && !index.TTL {
+			report.Unused = append(report.Unused, index)
+		}
+	}
+	for _, index := range declared {
+		if !existing[[2]string{index.Collection, index.Keys}] {
+			report.Missing = append(report.Missing, index)
+		}
+	}
+	return report
+}
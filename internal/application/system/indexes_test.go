@@ -0,0 +1,33 @@
+package system
+
+import (
+	"testing"
+
+	systemDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/system"
+)
+
+func TestAdviseIndexes(t *testing.T) {
+	declared := []systemDomain.Index{
+		{Collection: "documents", Name: "lucidrag_user_id_1_uploaded_at_-1", Keys: "user_id_1_uploaded_at_-1"},
+		{Collection: "messages", Name: "lucidrag_conversation_id_1_timestamp_-1", Keys: "conversation_id_1_timestamp_-1"},
+	}
+	present := []systemDomain.Index{
+		{Collection: "documents", Name: "_id_", Keys: "_id_1"},
+		{Collection: "documents", Name: "user_id_1_uploaded_at_-1", Keys: "user_id_1_uploaded_at_-1", Ops: 12},
+		{Collection: "documents", Name: "title_1", Keys: "title_1"},
+		{Collection: "integration_api_keys", Name: "key_hash_1", Keys: "key_hash_1", Unique: true},
+		{Collection: "logs", Name: "lucidrag_timestamp_1", Keys: "timestamp_1", TTL: true},
+	}
+
+	report := AdviseIndexes(declared, present)
+
+	if len(report.Missing) != 1 || report.Missing[0].Collection != "messages" {
+		t.Errorf("Expected the messages index missing, got %+v", report.Missing)
+	}
+	if len(report.Unused) != 1 || report.Unused[0].Name != "title_1" {
+		t.Errorf("Expected only title_1 unused, got %+v", report.Unused)
+	}
+	if len(report.Indexes) != len(present) {
+		t.Errorf("Expected %d indexes, got %d", len(present), len(report.Indexes))
+	}
+}
@@ -0,0 +1,148 @@
+package system
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	systemDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/system"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+)
+
+const (
+	defaultMaintenanceInterval = 10 * time.Second
+	// DefaultMaintenanceMessage is shown when maintenance is switched on
+	// without a message of its own.
+	DefaultMaintenanceMessage = "We are doing some maintenance and will be back shortly."
+	maxMaintenanceMessage     = 500
+)
+
+// MaintenanceMode holds the maintenance switch. It reloads the stored switch
+// every interval, so one flipped on another replica takes effect here
+// within that time; reads never touch the database.
+type MaintenanceMode struct {
+	repo     systemDomain.MaintenanceRepository
+	log      *logger.Logger
+	interval time.Duration
+	keepJobs []string
+
+	state atomic.Pointer[systemDomain.Maintenance]
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type MaintenanceConfig struct {
+	Repo systemDomain.MaintenanceRepository
+	Log  *logger.Logger
+	// Interval defaults to ten seconds.
+	Interval time.Duration
+	// KeepJobs are the scheduled jobs that keep running during
+	// maintenance, such as the work the window is for.
+	KeepJobs []string
+}
+
+func NewMaintenanceMode(cfg MaintenanceConfig) *MaintenanceMode {
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = defaultMaintenanceInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &MaintenanceMode{
+		repo:     cfg.Repo,
+		log:      cfg.Log.With("component", "maintenance"),
+		interval: interval,
+		keepJobs: cfg.KeepJobs,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	m.state.Store(&systemDomain.Maintenance{})
+	return m
+}
+
+// Start loads the switch at once, then on every interval until Stop is
+// called.
+func (m *MaintenanceMode) Start() {
+	m.reload()
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.ctx.Done():
+				return
+			case <-ticker.C:
+				m.reload()
+			}
+		}
+	}()
+}
+
+func (m *MaintenanceMode) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// reload keeps the last known switch when the store cannot be read, so a
+// database outage neither starts nor ends maintenance.
+func (m *MaintenanceMode) reload() {
+	ctx, cancel := context.WithTimeout(m.ctx, m.interval)
+	defer cancel()
+
+	stored, err := m.repo.Get(ctx)
+	if err != nil {
+		m.log.Warn("failed to load maintenance mode", "error", err)
+		return
+	}
+	if stored == nil {
+		stored = &systemDomain.Maintenance{}
+	}
+	if previous := m.state.Swap(stored); previous.Enabled != stored.Enabled {
+		m.log.Info("maintenance mode changed", "enabled", stored.Enabled, "by", stored.UpdatedBy)
+	}
+}
+
+// Enabled reports whether maintenance mode is on.
+func (m *MaintenanceMode) Enabled() bool {
+	return m.state.Load().Enabled
+}
+
+// Paused reports whether the scheduled job should skip its occurrences:
+// every job but those kept does while maintenance mode is on.
+func (m *MaintenanceMode) Paused(job string) bool {
+	return m.Enabled() && !slices.Contains(m.keepJobs, job)
+}
+
+// Status returns the switch as last loaded or set.
+func (m *MaintenanceMode) Status() systemDomain.Maintenance {
+	return *m.state.Load()
+}
+
+// Set switches maintenance mode on or off for every replica. A blank
+// message uses DefaultMaintenanceMessage; it is cut to 500 characters.
+func (m *MaintenanceMode) Set(ctx context.Context, enabled bool, message, by string) (*systemDomain.Maintenance, error) {
+	message = strings.TrimSpace(message)
+	if enabled && message == "" {
+		message = DefaultMaintenanceMessage
+	}
+	if runes := []rune(message); len(runes) > maxMaintenanceMessage {
+		message = string(runes[:maxMaintenanceMessage])
+	}
+
+	maintenance := &systemDomain.Maintenance{Enabled: enabled, Message: message, UpdatedBy: by}
+	if !enabled {
+		maintenance.Message = ""
+	}
+	if err := m.repo.Save(ctx, maintenance); err != nil {
+		return nil, err
+	}
+	m.state.Store(maintenance)
+	m.log.InfoContext(ctx, "maintenance mode changed", "enabled", enabled, "by", by)
+	return maintenance, nil
+}
@@ -0,0 +1,71 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	systemDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/system"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+)
+
+type mockMaintenanceRepo struct {
+	stored *systemDomain.Maintenance
+	err    error
+}
+
+func (m *mockMaintenanceRepo) Get(ctx context.Context) (*systemDomain.Maintenance, error) {
+	return m.stored, m.err
+}
+
+func (m *mockMaintenanceRepo) Save(ctx context.Context, maintenance *systemDomain.Maintenance) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.stored = maintenance
+	return nil
+}
+
+func TestMaintenanceMode(t *testing.T) {
+	repo := &mockMaintenanceRepo{}
+	m := NewMaintenanceMode(MaintenanceConfig{
+		Repo:     repo,
+		Log:      logger.New(logger.Options{Level: "error"}),
+		KeepJobs: []string{"embedding_migration"},
+	})
+
+	m.reload()
+	if m.Enabled() || m.Paused("chunk_gc") {
+		t.Fatal("Expected maintenance off when never set")
+	}
+
+	got, err := m.Set(context.Background(), true, "  ", "admin-1")
+	if err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if !got.Enabled || got.Message != DefaultMaintenanceMessage || repo.stored != got {
+		t.Fatalf("Expected the default message to be stored, got %+v", got)
+	}
+	if !m.Paused("chunk_gc") || m.Paused("embedding_migration") {
+		t.Error("Expected every job but the kept ones paused")
+	}
+
+	// Another replica switches it off.
+	repo.stored = &systemDomain.Maintenance{}
+	m.reload()
+	if m.Enabled() {
+		t.Error("Expected the stored switch to be picked up")
+	}
+
+	// An unreadable store keeps the last known switch.
+	repo.stored = &systemDomain.Maintenance{Enabled: true, Message: "Reindexing"}
+	m.reload()
+	repo.err = errors.New("db down")
+	m.reload()
+	if status := m.Status(); !status.Enabled || status.Message != "Reindexing" {
+		t.Errorf("Expected maintenance kept on, got %+v", status)
+	}
+	if _, err := m.Set(context.Background(), false, "", "admin-1"); err == nil || !m.Enabled() {
+		t.Error("Expected a failed save to leave the switch as it was")
+	}
+}
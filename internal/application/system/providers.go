@@ -0,0 +1,192 @@
+package system
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	systemDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/system"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+)
+
+const defaultProbeInterval = 5 * time.Minute
+
+// ModelLister lists the models a provider offers, such as an OpenAI
+// client.
+type ModelLister interface {
+	ListModels(ctx context.Context) ([]string, error)
+}
+
+// Provider is a model provider to probe. Prefix is how model names refer
+// to it, such as "ollama:"; the provider without one serves every other
+// name.
+type Provider struct {
+	Name   string
+	Prefix string
+	Client ModelLister
+}
+
+// ProviderProber lists each provider's models every interval, so admins
+// can see which providers are up and cannot pick models that are gone.
+// Every replica probes for itself.
+type ProviderProber struct {
+	providers []Provider
+	log       *logger.Logger
+	interval  time.Duration
+
+	mu       sync.RWMutex
+	statuses map[string]systemDomain.ProviderStatus
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type ProberConfig struct {
+	Providers []Provider
+	Log       *logger.Logger
+	// Interval defaults to five minutes.
+	Interval time.Duration
+}
+
+func NewProviderProber(cfg ProberConfig) *ProviderProber {
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = defaultProbeInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ProviderProber{
+		providers: cfg.Providers,
+		log:       cfg.Log.With("component", "provider_prober"),
+		interval:  interval,
+		statuses:  make(map[string]systemDomain.ProviderStatus),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// Start probes at once, then on every interval until Stop is called.
+func (p *ProviderProber) Start() {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.probeAll()
+
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.ctx.Done():
+				return
+			case <-ticker.C:
+				p.probeAll()
+			}
+		}
+	}()
+}
+
+func (p *ProviderProber) Stop() {
+	p.cancel()
+	p.wg.Wait()
+}
+
+func (p *ProviderProber) probeAll() {
+	for _, provider := range p.providers {
+		status := p.probe(provider)
+		if !status.Available {
+			p.log.Warn("model provider unavailable", "provider", provider.Name, "error", status.Error)
+		}
+		p.mu.Lock()
+		p.statuses[provider.Name] = status
+		p.mu.Unlock()
+	}
+}
+
+func (p *ProviderProber) probe(provider Provider) systemDomain.ProviderStatus {
+	ctx, cancel := context.WithTimeout(p.ctx, p.interval/2)
+	defer cancel()
+
+	start := time.Now()
+	models, err := provider.Client.ListModels(ctx)
+	status := systemDomain.ProviderStatus{
+		Name:      provider.Name,
+		LatencyMs: time.Since(start).Milliseconds(),
+		Models:    []string{},
+		CheckedAt: time.Now(),
+	}
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.Available = true
+	status.Models = slices.Sorted(slices.Values(models))
+	return status
+}
+
+// Statuses returns the latest probe of every provider probed so far, in
+// configuration order.
+func (p *ProviderProber) Statuses() []systemDomain.ProviderStatus {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	statuses := make([]systemDomain.ProviderStatus, 0, len(p.statuses))
+	for _, provider := range p.providers {
+		if status, ok := p.statuses[provider.Name]; ok {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+// Offers reports whether model's provider offered it at the latest probe.
+// A model without a prefix may come from any provider without one, such
+// as OpenAI or a self-hosted embedding server. A model whose provider has
+// not been probed, or whose latest probe failed, is given the benefit of
+// the doubt. Ollama names without a tag match the "latest" tag.
+func (p *ProviderProber) Offers(model string) bool {
+	providers, name := p.providersFor(model)
+	if len(providers) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(providers, func(provider Provider) bool {
+		return p.offers(provider, name)
+	})
+}
+
+func (p *ProviderProber) offers(provider Provider, name string) bool {
+	p.mu.RLock()
+	status, probed := p.statuses[provider.Name]
+	p.mu.RUnlock()
+	if !probed || !status.Available {
+		return true
+	}
+	if _, found := slices.BinarySearch(status.Models, name); found {
+		return true
+	}
+	if provider.Prefix != "" && !strings.Contains(name, ":") {
+		_, found := slices.BinarySearch(status.Models, name+":latest")
+		return found
+	}
+	return false
+}
+
+// providersFor returns the providers that may serve model and the model's
+// name there: the one whose prefix it has, else every one without a
+// prefix.
+func (p *ProviderProber) providersFor(model string) ([]Provider, string) {
+	for _, provider := range p.providers {
+		if provider.Prefix != "" {
+			if name, ok := strings.CutPrefix(model, provider.Prefix); ok {
+				return []Provider{provider}, name
+			}
+		}
+	}
+	var providers []Provider
+	for _, provider := range p.providers {
+		if provider.Prefix == "" {
+			providers = append(providers, provider)
+		}
+	}
+	return providers, model
+}
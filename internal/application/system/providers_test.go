@@ -0,0 +1,81 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+)
+
+type mockLister struct {
+	models []string
+	err    error
+}
+
+func (m *mockLister) ListModels(ctx context.Context) ([]string, error) {
+	return m.models, m.err
+}
+
+func TestProviderProber(t *testing.T) {
+	openai := &mockLister{models: []string{"gpt-4o", "gpt-3.5-turbo", "text-embedding-3-small"}}
+	ollama := &mockLister{models: []string{"llama3:latest", "mistral:7b"}}
+	p := NewProviderProber(ProberConfig{
+		Providers: []Provider{{Name: "openai", Client: openai}, {Name: "ollama", Prefix: "ollama:", Client: ollama}},
+		Log:       logger.New(logger.Options{Level: "error"}),
+	})
+
+	if !p.Offers("gpt-4-turbo") {
+		t.Error("Expected models allowed before the first probe")
+	}
+
+	p.probeAll()
+	statuses := p.Statuses()
+	if len(statuses) != 2 || statuses[0].Name != "openai" || !statuses[0].Available || len(statuses[0].Models) != 3 {
+		t.Fatalf("Expected both providers up, got %+v", statuses)
+	}
+
+	tests := []struct {
+		model string
+		want  bool
+	}{
+		{"gpt-4o", true},
+		{"gpt-4-turbo", false},
+		{"ollama:llama3", true},
+		{"ollama:llama3:latest", true},
+		{"ollama:mistral", false},
+		{"ollama:mistral:7b", true},
+	}
+	for _, tt := range tests {
+		if got := p.Offers(tt.model); got != tt.want {
+			t.Errorf("Offers(%q) = %v, expected %v", tt.model, got, tt.want)
+		}
+	}
+
+	ollama.err = errors.New("connection refused")
+	p.probeAll()
+	statuses = p.Statuses()
+	if statuses[1].Available || statuses[1].Error == "" || len(statuses[1].Models) != 0 {
+		t.Errorf("Expected ollama down with an error, got %+v", statuses[1])
+	}
+	if !p.Offers("ollama:mistral") {
+		t.Error("Expected models of a provider that is down to stay allowed")
+	}
+}
+
+func TestProviderProberUnprefixedProviders(t *testing.T) {
+	openai := &mockLister{models: []string{"gpt-4o"}}
+	embeddings := &mockLister{models: []string{"BAAI/bge-small-en-v1.5"}}
+	p := NewProviderProber(ProberConfig{
+		Providers: []Provider{{Name: "openai", Client: openai}, {Name: "embeddings", Client: embeddings}},
+		Log:       logger.New(logger.Options{Level: "error"}),
+	})
+	p.probeAll()
+
+	if !p.Offers("gpt-4o") || !p.Offers("BAAI/bge-small-en-v1.5") {
+		t.Error("Expected the models of either provider offered")
+	}
+	if p.Offers("intfloat/e5-large") {
+		t.Error("Expected a model neither provider lists rejected")
+	}
+}
@@ -0,0 +1,162 @@
+package system
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	systemDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/system"
+)
+
+// latencyBounds are the upper bounds of the latency histogram buckets, in
+// milliseconds. Each is a quarter above the last, so a percentile read
+// from them is within 25% of the true value.
+var latencyBounds = func() []float64 {
+	var bounds []float64
+	for b := 1.0; b < 120000; b *= 1.25 {
+		bounds = append(bounds, b)
+	}
+	return append(bounds, math.Inf(1))
+}()
+
+// Stat windows. The last hour is kept by the minute and the last day by the
+// hour, so the day window covers the current hour and the 23 before it.
+const (
+	WindowHour = "1h"
+	WindowDay  = "24h"
+)
+
+// slot aggregates the requests of one minute or hour.
+type slot struct {
+	start        int64
+	requests     int64
+	clientErrors int64
+	serverErrors int64
+	latency      []uint32
+}
+
+// add counts a request in the slot for start, first clearing what the slot
+// held for an earlier minute or hour. A request finishing after its slot
+// moved on is dropped.
+func (s *slot) add(start int64, status, bucket int) {
+	if start < s.start {
+		return
+	}
+	if s.start != start {
+		s.start = start
+		s.requests, s.clientErrors, s.serverErrors = 0, 0, 0
+		if s.latency == nil {
+			s.latency = make([]uint32, len(latencyBounds))
+		}
+		clear(s.latency)
+	}
+	s.requests++
+	switch {
+	case status >= 500:
+		s.serverErrors++
+	case status >= 400:
+		s.clientErrors++
+	}
+	s.latency[bucket]++
+}
+
+type routeKey struct {
+	method, route string
+}
+
+type routeSlots struct {
+	minutes [60]slot
+	hours   [24]slot
+}
+
+// RouteStats aggregates request counts, errors and latency per route in
+// memory. It covers this instance only and is reset on restart.
+type RouteStats struct {
+	mu     sync.Mutex
+	routes map[routeKey]*routeSlots
+}
+
+func NewRouteStats() *RouteStats {
+	return &RouteStats{routes: make(map[routeKey]*routeSlots)}
+}
+
+// Record adds a finished request. Route is the route pattern, such as
+// /api/v1/documents/:id, so paths with IDs share their route's stats.
+func (r *RouteStats) Record(method, route string, status int, latency time.Duration, now time.Time) {
+	bucket := sort.SearchFloat64s(latencyBounds, float64(latency.Microseconds())/1000)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rs, ok := r.routes[routeKey{method, route}]
+	if !ok {
+		rs = &routeSlots{}
+		r.routes[routeKey{method, route}] = rs
+	}
+	minute, hour := now.Unix()/60, now.Unix()/3600
+	rs.minutes[minute%60].add(minute, status, bucket)
+	rs.hours[hour%24].add(hour, status, bucket)
+}
+
+// Snapshot returns the stats of every route with requests in the window
+// (WindowHour or WindowDay) as of now, busiest first.
+func (r *RouteStats) Snapshot(window string, now time.Time) []systemDomain.RouteStat {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := make([]systemDomain.RouteStat, 0, len(r.routes))
+	for key, rs := range r.routes {
+		slots, oldest := rs.minutes[:], now.Unix()/60-59
+		if window == WindowDay {
+			slots, oldest = rs.hours[:], now.Unix()/3600-23
+		}
+
+		stat := systemDomain.RouteStat{Method: key.method, Route: key.route}
+		latency := make([]uint32, len(latencyBounds))
+		for _, s := range slots {
+			if s.start < oldest {
+				continue
+			}
+			stat.Requests += s.requests
+			stat.ClientErrors += s.clientErrors
+			stat.ServerErrors += s.serverErrors
+			for i, n := range s.latency {
+				latency[i] += n
+			}
+		}
+		if stat.Requests == 0 {
+			continue
+		}
+		stat.ErrorRate = float64(stat.ServerErrors) / float64(stat.Requests)
+		stat.P50Ms = percentile(latency, stat.Requests, 0.50)
+		stat.P95Ms = percentile(latency, stat.Requests, 0.95)
+		stat.P99Ms = percentile(latency, stat.Requests, 0.99)
+		stats = append(stats, stat)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Requests != stats[j].Requests {
+			return stats[i].Requests > stats[j].Requests
+		}
+		return stats[i].Route+stats[i].Method < stats[j].Route+stats[j].Method
+	})
+	return stats
+}
+
+// percentile returns the upper bound of the bucket holding the q-th
+// quantile. Requests slower than the largest bound report that bound.
+func percentile(counts []uint32, total int64, q float64) float64 {
+	rank := int64(math.Ceil(q * float64(total)))
+	var seen int64
+	for i, n := range counts {
+		seen += int64(n)
+		if seen >= rank {
+			if math.IsInf(latencyBounds[i], 1) {
+				return math.Round(latencyBounds[i-1]*100) / 100
+			}
+			return math.Round(latencyBounds[i]*100) / 100
+		}
+	}
+	return 0
+}
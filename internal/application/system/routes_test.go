@@ -0,0 +1,72 @@
+package system
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRouteStats(t *testing.T) {
+	stats := NewRouteStats()
+	now := time.Date(2026, 5, 1, 12, 30, 0, 0, time.UTC)
+
+	for i := 1; i <= 100; i++ {
+		status := 200
+		switch {
+		case i <= 2:
+			status = 500
+		case i <= 5:
+			status = 404
+		}
+		stats.Record("GET", "/api/v1/documents/:id", status, time.Duration(i)*time.Millisecond, now)
+	}
+	stats.Record("POST", "/api/v1/rag/query", 200, 2*time.Second, now)
+	// Outside the last hour, but inside the day.
+	stats.Record("POST", "/api/v1/rag/query", 500, time.Second, now.Add(-2*time.Hour))
+
+	hour := stats.Snapshot(WindowHour, now)
+	if len(hour) != 2 {
+		t.Fatalf("Expected 2 routes, got %d", len(hour))
+	}
+	docs := hour[0]
+	if docs.Route != "/api/v1/documents/:id" || docs.Requests != 100 {
+		t.Errorf("Expected the busiest route first, got %+v", docs)
+	}
+	if docs.ServerErrors != 2 || docs.ClientErrors != 3 || docs.ErrorRate != 0.02 {
+		t.Errorf("Expected 2 server and 3 client errors, got %+v", docs)
+	}
+	// Buckets are within 25% of the true latency.
+	for _, p := range []struct {
+		name      string
+		got, want float64
+	}{{"p50", docs.P50Ms, 50}, {"p95", docs.P95Ms, 95}, {"p99", docs.P99Ms, 99}} {
+		if p.got < p.want || p.got > p.want*1.25 {
+			t.Errorf("Expected %s near %vms, got %v", p.name, p.want, p.got)
+		}
+	}
+	if hour[1].Requests != 1 || hour[1].ServerErrors != 0 {
+		t.Errorf("Expected the older query left out of the hour, got %+v", hour[1])
+	}
+
+	day := stats.Snapshot(WindowDay, now)
+	if day[1].Route != "/api/v1/rag/query" || day[1].Requests != 2 || day[1].ServerErrors != 1 {
+		t.Errorf("Expected both queries in the day, got %+v", day[1])
+	}
+
+	if got := stats.Snapshot(WindowHour, now.Add(2*time.Hour)); len(got) != 0 {
+		t.Errorf("Expected no routes after an idle hour, got %+v", got)
+	}
+}
+
+func TestRouteStatsReusesSlots(t *testing.T) {
+	stats := NewRouteStats()
+	now := time.Date(2026, 5, 1, 12, 30, 0, 0, time.UTC)
+
+	stats.Record("GET", "/healthz", 500, time.Millisecond, now)
+	// Same minute of the next hour: the slot must start over.
+	stats.Record("GET", "/healthz", 200, time.Millisecond, now.Add(time.Hour))
+
+	got := stats.Snapshot(WindowHour, now.Add(time.Hour))
+	if len(got) != 1 || got[0].Requests != 1 || got[0].ServerErrors != 0 {
+		t.Errorf("Expected only the newer request, got %+v", got)
+	}
+}
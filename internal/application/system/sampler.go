@@ -0,0 +1,186 @@
+package system
+
+import (
+	"context"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	systemDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/system"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+)
+
+const defaultSampleInterval = time.Minute
+
+// DBPinger allows checking database connectivity
+type DBPinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Sampler records this instance's resource use every interval so the
+// admin dashboard can chart it without an external metrics system. Every
+// replica samples itself.
+type Sampler struct {
+	repo     systemDomain.MetricsRepository
+	db       DBPinger
+	requests *atomic.Int64
+	log      *logger.Logger
+	instance string
+	interval time.Duration
+	last     time.Time
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type SamplerConfig struct {
+	Repo systemDomain.MetricsRepository
+	DB   DBPinger
+	// Requests counts HTTP requests; the sampler resets it on every sample.
+	Requests *atomic.Int64
+	Log      *logger.Logger
+	Instance string
+	// Interval defaults to a minute.
+	Interval time.Duration
+}
+
+func NewSampler(cfg SamplerConfig) *Sampler {
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = defaultSampleInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Sampler{
+		repo:     cfg.Repo,
+		db:       cfg.DB,
+		requests: cfg.Requests,
+		log:      cfg.Log.With("component", "metrics_sampler"),
+		instance: cfg.Instance,
+		interval: interval,
+		last:     time.Now(),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start samples on every interval until Stop is called.
+func (s *Sampler) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+				s.record(time.Now())
+			}
+		}
+	}()
+}
+
+func (s *Sampler) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+func (s *Sampler) record(now time.Time) {
+	ctx, cancel := context.WithTimeout(s.ctx, s.interval/2)
+	defer cancel()
+
+	if err := s.repo.Insert(ctx, s.sample(ctx, now)); err != nil {
+		s.log.Error("failed to record metric sample", "error", err)
+	}
+}
+
+// sample measures the instance now. The request rate covers the time since
+// the previous sample.
+func (s *Sampler) sample(ctx context.Context, now time.Time) *systemDomain.MetricSample {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	sample := &systemDomain.MetricSample{
+		Instance:   s.instance,
+		Timestamp:  now,
+		MemAllocMB: float64(memStats.Alloc) / 1024 / 1024,
+		MemSysMB:   float64(memStats.Sys) / 1024 / 1024,
+		Goroutines: float64(runtime.NumGoroutine()),
+	}
+
+	pingStart := time.Now()
+	if err := s.db.Ping(ctx); err != nil {
+		sample.DBDown = true
+	} else {
+		sample.DBLatencyMs = float64(time.Since(pingStart).Microseconds()) / 1000
+	}
+
+	if elapsed := now.Sub(s.last).Minutes(); elapsed > 0 {
+		sample.RequestsPerMinute = float64(s.requests.Swap(0)) / elapsed
+	}
+	s.last = now
+	return sample
+}
+
+// Downsample averages each instance's samples over consecutive steps, so
+// long windows chart a bounded number of points. A step is marked DBDown
+// when any of its pings failed, and its latency averages the pings that
+// succeeded. Samples must be oldest first; so is the result.
+func Downsample(samples []systemDomain.MetricSample, step time.Duration) []systemDomain.MetricSample {
+	if step <= 0 {
+		return samples
+	}
+
+	type bucket struct {
+		sum            systemDomain.MetricSample
+		count, pingsOK int
+	}
+	type key struct {
+		instance string
+		start    time.Time
+	}
+	buckets := make(map[key]*bucket)
+	var order []key
+	for _, s := range samples {
+		k := key{s.Instance, s.Timestamp.Truncate(step)}
+		b, ok := buckets[k]
+		if !ok {
+			b = &bucket{sum: systemDomain.MetricSample{Instance: s.Instance, Timestamp: k.start}}
+			buckets[k] = b
+			order = append(order, k)
+		}
+		b.count++
+		b.sum.MemAllocMB += s.MemAllocMB
+		b.sum.MemSysMB += s.MemSysMB
+		b.sum.Goroutines += s.Goroutines
+		b.sum.RequestsPerMinute += s.RequestsPerMinute
+		if s.DBDown {
+			b.sum.DBDown = true
+		} else {
+			b.pingsOK++
+			b.sum.DBLatencyMs += s.DBLatencyMs
+		}
+	}
+
+	out := make([]systemDomain.MetricSample, 0, len(order))
+	for _, k := range order {
+		b := buckets[k]
+		n := float64(b.count)
+		avg := b.sum
+		avg.MemAllocMB /= n
+		avg.MemSysMB /= n
+		avg.Goroutines /= n
+		avg.RequestsPerMinute /= n
+		if b.pingsOK > 0 {
+			avg.DBLatencyMs /= float64(b.pingsOK)
+		}
+		out = append(out, avg)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Timestamp.Before(out[j].Timestamp) })
+	return out
+}
@@ -0,0 +1,91 @@
+package system
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	systemDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/system"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+)
+
+type mockPinger struct {
+	err error
+}
+
+func (m *mockPinger) Ping(ctx context.Context) error {
+	return m.err
+}
+
+type mockMetricsRepo struct {
+	systemDomain.MetricsRepository
+	samples []systemDomain.MetricSample
+}
+
+func (m *mockMetricsRepo) Insert(ctx context.Context, sample *systemDomain.MetricSample) error {
+	m.samples = append(m.samples, *sample)
+	return nil
+}
+
+func TestSamplerRecord(t *testing.T) {
+	repo := &mockMetricsRepo{}
+	pinger := &mockPinger{}
+	var requests atomic.Int64
+	s := NewSampler(SamplerConfig{
+		Repo: repo, DB: pinger, Requests: &requests, Instance: "api-1",
+		Log: logger.New(logger.Options{Level: "error"}),
+	})
+
+	requests.Add(90)
+	s.record(s.last.Add(30 * time.Second))
+	pinger.err = errors.New("no reachable servers")
+	s.record(s.last.Add(time.Minute))
+
+	if len(repo.samples) != 2 {
+		t.Fatalf("Expected 2 samples, got %d", len(repo.samples))
+	}
+	first, second := repo.samples[0], repo.samples[1]
+	if first.Instance != "api-1" || first.Goroutines <= 0 || first.MemSysMB <= 0 {
+		t.Errorf("Expected runtime stats for api-1, got %+v", first)
+	}
+	if first.RequestsPerMinute != 180 {
+		t.Errorf("Expected 180 requests per minute, got %v", first.RequestsPerMinute)
+	}
+	if first.DBDown || !second.DBDown {
+		t.Errorf("Expected the database down only in the second sample")
+	}
+	if second.RequestsPerMinute != 0 {
+		t.Errorf("Expected the request counter reset, got %v", second.RequestsPerMinute)
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	start := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	samples := []systemDomain.MetricSample{
+		{Instance: "a", Timestamp: start, MemAllocMB: 10, DBLatencyMs: 2, RequestsPerMinute: 4},
+		{Instance: "b", Timestamp: start.Add(time.Minute), MemAllocMB: 50},
+		{Instance: "a", Timestamp: start.Add(2 * time.Minute), MemAllocMB: 20, DBDown: true, RequestsPerMinute: 8},
+		{Instance: "a", Timestamp: start.Add(5 * time.Minute), MemAllocMB: 30, DBLatencyMs: 6},
+	}
+
+	got := Downsample(samples, 5*time.Minute)
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 points, got %d", len(got))
+	}
+	a := got[0]
+	if a.Instance != "a" || !a.Timestamp.Equal(start) || a.MemAllocMB != 15 || a.RequestsPerMinute != 6 {
+		t.Errorf("Expected instance a's first step averaged, got %+v", a)
+	}
+	if !a.DBDown || a.DBLatencyMs != 2 {
+		t.Errorf("Expected the failed ping flagged and left out of latency, got %+v", a)
+	}
+	if got[1].Instance != "b" || got[2].MemAllocMB != 30 {
+		t.Errorf("Expected instances kept apart and steps in order, got %+v", got)
+	}
+
+	if len(Downsample(samples, 0)) != len(samples) {
+		t.Errorf("Expected samples unchanged without a step")
+	}
+}
@@ -0,0 +1,67 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	bookingDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/booking"
+	toolDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/tool"
+)
+
+// Booker offers and books the slots of bookable resources.
+type Booker interface {
+	OfferSlots(ctx context.Context, resourceID, conversationID string) ([]bookingDomain.Slot, error)
+	Book(ctx context.Context, resourceID, conversationID, slot string) (*bookingDomain.Booking, error)
+}
+
+var bookingParameters = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"slot": map[string]any{
+			"type":        "string",
+			"description": "The slot the customer picked: its label as offered, e.g. Tue 21 Oct 10:00, or its start time in RFC 3339",
+		},
+	},
+	"required": []string{"slot"},
+}
+
+// offerSlots tells the model the next free slots of the tool's resource,
+// which contacts of the conversation asked in have been sent as buttons.
+func (r *Runner) offerSlots(ctx context.Context, tool *toolDomain.Tool) (string, error) {
+	if r.bookings == nil {
+		return "", errors.New("booking is not configured")
+	}
+	slots, err := r.bookings.OfferSlots(ctx, tool.ResourceID, toolDomain.ConversationFrom(ctx))
+	if err != nil {
+		return "", err
+	}
+	if len(slots) == 0 {
+		return "No free slots in the next two weeks.", nil
+	}
+
+	lines := make([]string, len(slots))
+	for i, slot := range slots {
+		lines[i] = fmt.Sprintf("- %s (%s)", slot.Label, slot.Start.Format(time.RFC3339))
+	}
+	return "Free slots, offered to the customer to pick from:\n" + strings.Join(lines, "\n"), nil
+}
+
+// book books the slot the customer picked for the conversation asked in.
+func (r *Runner) book(ctx context.Context, tool *toolDomain.Tool, args map[string]any) (string, error) {
+	if r.bookings == nil {
+		return "", errors.New("booking is not configured")
+	}
+	conversationID := toolDomain.ConversationFrom(ctx)
+	if conversationID == "" {
+		return "", errors.New("bookings can only be made in a conversation")
+	}
+	slot, _ := args["slot"].(string)
+	booking, err := r.bookings.Book(ctx, tool.ResourceID, conversationID, slot)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Booked for %s to %s.", booking.Start.Format(time.RFC3339), booking.End.Format(time.RFC3339)), nil
+}
@@ -0,0 +1,181 @@
+package tool
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// currentDate reports now in timezone, which defaults to UTC.
+func currentDate(now time.Time, timezone string) (string, error) {
+	loc := time.UTC
+	if timezone != "" {
+		l, err := time.LoadLocation(timezone)
+		if err != nil {
+			return "", err
+		}
+		loc = l
+	}
+	now = now.In(loc)
+	return fmt.Sprintf("%s (%s, %s)", now.Format(time.RFC3339), now.Weekday(), loc), nil
+}
+
+// calculate evaluates an arithmetic expression. Models are unreliable at
+// arithmetic, so totals and discounts are worked out here.
+func calculate(expr string) (string, error) {
+	if strings.TrimSpace(expr) == "" {
+		return "", errors.New("expression is required")
+	}
+	p := &exprParser{input: expr}
+	v, err := p.parseExpr()
+	if err != nil {
+		return "", err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return "", fmt.Errorf("unexpected %q at position %d", p.input[p.pos], p.pos+1)
+	}
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		return "", errors.New("result is not a number")
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64), nil
+}
+
+// exprParser is a recursive-descent parser for
+//
+//	expr   = term { ("+" | "-") term }
+//	term   = power { ("*" | "/" | "%") power }
+//	power  = unary [ "^" power ]
+//	unary  = [ "-" | "+" ] unary | number | "(" expr ")"
+type exprParser struct {
+	input string
+	pos   int
+	depth int
+}
+
+// maxDepth bounds nesting so a hostile expression cannot exhaust the stack.
+const maxDepth = 64
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *exprParser) peek() byte {
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return p.input[p.pos]
+	}
+	return 0
+}
+
+func (p *exprParser) parseExpr() (float64, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op := p.peek()
+		if op != '+' && op != '-' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseTerm()
+		if err != nil {
+			return 0, err
+		}
+		if op == '+' {
+			left += right
+		} else {
+			left -= right
+		}
+	}
+}
+
+func (p *exprParser) parseTerm() (float64, error) {
+	left, err := p.parsePower()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op := p.peek()
+		if op != '*' && op != '/' && op != '%' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parsePower()
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case op == '*':
+			left *= right
+		case right == 0:
+			return 0, errors.New("division by zero")
+		case op == '/':
+			left /= right
+		default:
+			left = math.Mod(left, right)
+		}
+	}
+}
+
+func (p *exprParser) parsePower() (float64, error) {
+	base, err := p.parseUnary()
+	if err != nil {
+		return 0, err
+	}
+	if p.peek() != '^' {
+		return base, nil
+	}
+	p.pos++
+	exp, err := p.parsePower()
+	if err != nil {
+		return 0, err
+	}
+	return math.Pow(base, exp), nil
+}
+
+func (p *exprParser) parseUnary() (float64, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return 0, errors.New("expression is nested too deeply")
+	}
+
+	switch c := p.peek(); {
+	case c == '-' || c == '+':
+		p.pos++
+		v, err := p.parseUnary()
+		if c == '-' {
+			v = -v
+		}
+		return v, err
+	case c == '(':
+		p.pos++
+		v, err := p.parseExpr()
+		if err != nil {
+			return 0, err
+		}
+		if p.peek() != ')' {
+			return 0, errors.New("missing closing parenthesis")
+		}
+		p.pos++
+		return v, nil
+	case c >= '0' && c <= '9' || c == '.':
+		start := p.pos
+		for p.pos < len(p.input) && (p.input[p.pos] >= '0' && p.input[p.pos] <= '9' || p.input[p.pos] == '.') {
+			p.pos++
+		}
+		return strconv.ParseFloat(p.input[start:p.pos], 64)
+	case c == 0:
+		return 0, errors.New("unexpected end of expression")
+	default:
+		return 0, fmt.Errorf("unexpected %q at position %d", c, p.pos+1)
+	}
+}
@@ -0,0 +1,166 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+
+	toolDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/tool"
+)
+
+const (
+	// maxLookupValue bounds an argument filled into a lookup URL.
+	maxLookupValue = 128
+	// lookupNotFound is what the model is told when the API has no record
+	// for the arguments, so it can ask the customer to check them.
+	lookupNotFound = "not found: no record matches these values"
+)
+
+// placeholder finds the {name} argument slots of a lookup URL.
+var placeholder = regexp.MustCompile(`\{([a-zA-Z0-9_]{1,64})\}`)
+
+// placeholders returns the argument names of a lookup URL, in order and
+// without repeats.
+func placeholders(template string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, m := range placeholder.FindAllStringSubmatch(template, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
+
+// lookupParameters is the schema of a lookup's arguments: one required
+// string per placeholder.
+func lookupParameters(template string) map[string]any {
+	properties := map[string]any{}
+	names := placeholders(template)
+	for _, name := range names {
+		properties[name] = map[string]any{
+			"type":        "string",
+			"description": "The " + strings.ReplaceAll(name, "_", " ") + " exactly as the customer gave it",
+		}
+	}
+	return map[string]any{
+		"type":       "object",
+		"properties": properties,
+		"required":   names,
+	}
+}
+
+// hostAllowed reports whether host is one of hosts, where "*.example.com"
+// allows any subdomain of example.com.
+func hostAllowed(hosts []string, host string) bool {
+	host = strings.ToLower(host)
+	for _, allowed := range hosts {
+		if suffix, ok := strings.CutPrefix(allowed, "*"); ok {
+			if strings.HasSuffix(host, suffix) {
+				return true
+			}
+			continue
+		}
+		if host == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupURL fills args into the tool's URL template, escaping each value
+// for the part of the URL it lands in.
+func lookupURL(template string, args map[string]any) (string, error) {
+	path, query, hasQuery := strings.Cut(template, "?")
+	var missing error
+	fill := func(part string, escape func(string) string) string {
+		return placeholder.ReplaceAllStringFunc(part, func(m string) string {
+			name := m[1 : len(m)-1]
+			value := strings.TrimSpace(fmt.Sprint(args[name]))
+			if args[name] == nil || value == "" {
+				missing = fmt.Errorf("missing argument %q", name)
+				return ""
+			}
+			if len(value) > maxLookupValue || strings.ContainsFunc(value, unicode.IsControl) {
+				missing = fmt.Errorf("invalid argument %q", name)
+				return ""
+			}
+			return escape(value)
+		})
+	}
+	filled := fill(path, url.PathEscape)
+	if hasQuery {
+		filled += "?" + fill(query, url.QueryEscape)
+	}
+	return filled, missing
+}
+
+// callLookup fetches the tool's URL with the arguments filled in and
+// returns the JSON response. The resolved URL is checked against the
+// allowed hosts again, and redirects may not leave them.
+func (r *Runner) callLookup(ctx context.Context, tool *toolDomain.Tool, args map[string]any, inv *toolDomain.Invocation) (string, error) {
+	target, err := lookupURL(tool.LookupURL, args)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", errors.New("invalid lookup url")
+	}
+	inv.URL = target
+	if !hostAllowed(r.lookupHosts, u.Hostname()) {
+		return "", fmt.Errorf("host %s is not allowed", u.Hostname())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-LucidRAG-Tool", tool.Name)
+	if tool.AuthValue != "" {
+		req.Header.Set(tool.AuthHeader, tool.AuthValue)
+	}
+
+	client := *r.httpClient
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 3 || !hostAllowed(r.lookupHosts, req.URL.Hostname()) {
+			return fmt.Errorf("redirect to %s is not allowed", req.URL.Hostname())
+		}
+		return nil
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("lookup request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	inv.StatusCode = resp.StatusCode
+
+	if resp.StatusCode == http.StatusNotFound {
+		return lookupNotFound, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("lookup returned status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponse+1))
+	if err != nil {
+		return "", fmt.Errorf("lookup response failed: %w", err)
+	}
+	if len(data) > maxWebhookResponse {
+		return "", fmt.Errorf("lookup response is over %d bytes", maxWebhookResponse)
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return "", errors.New("lookup response is not JSON")
+	}
+	return compact.String(), nil
+}
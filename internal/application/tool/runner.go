@@ -0,0 +1,156 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	toolDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/tool"
+	"github.com/elprogramadorgt/lucidRAG/pkg/openai"
+)
+
+// Runner offers the active tools to the answer model and executes the calls
+// it makes, recording each one as an Invocation.
+type Runner struct {
+	repo        toolDomain.Repository
+	invocations toolDomain.InvocationRepository
+	bookings    Booker
+	lookupHosts []string
+	httpClient  *http.Client
+	now         func() time.Time
+}
+
+// NewRunner builds a runner. bookings, when set, runs the booking tools;
+// lookup tools may only call lookupHosts (see hostAllowed).
+func NewRunner(repo toolDomain.Repository, invocations toolDomain.InvocationRepository, bookings Booker, lookupHosts []string) *Runner {
+	return &Runner{
+		repo:        repo,
+		invocations: invocations,
+		bookings:    bookings,
+		lookupHosts: lookupHosts,
+		// Each call is bounded by its tool's timeout instead.
+		httpClient: &http.Client{},
+		now:        time.Now,
+	}
+}
+
+var calculatorParameters = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"expression": map[string]any{
+			"type":        "string",
+			"description": "Arithmetic expression using + - * / % ^ and parentheses, e.g. (19.99 * 3) * 0.9",
+		},
+	},
+	"required": []string{"expression"},
+}
+
+var noParameters = map[string]any{
+	"type":       "object",
+	"properties": map[string]any{},
+}
+
+// Definitions returns the active tools in the chat completion format.
+func (r *Runner) Definitions(ctx context.Context) ([]openai.Tool, error) {
+	tools, err := r.repo.ListActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defs := make([]openai.Tool, 0, len(tools))
+	for _, t := range tools {
+		var params any = t.Parameters
+		switch t.Kind {
+		case toolDomain.KindCalculator:
+			params = calculatorParameters
+		case toolDomain.KindDate, toolDomain.KindBookingSlots:
+			params = noParameters
+		case toolDomain.KindBooking:
+			params = bookingParameters
+		case toolDomain.KindLookup:
+			params = lookupParameters(t.LookupURL)
+		}
+		defs = append(defs, openai.Tool{
+			Type: "function",
+			Function: openai.FunctionDefinition{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  params,
+			},
+		})
+	}
+	return defs, nil
+}
+
+// Run executes one tool call and returns what to tell the model. Failures
+// are reported to the model as text so it can answer without the tool.
+func (r *Runner) Run(ctx context.Context, call openai.ToolCall) string {
+	inv := &toolDomain.Invocation{
+		ToolName:       call.Function.Name,
+		ConversationID: toolDomain.ConversationFrom(ctx),
+		Arguments:      call.Function.Arguments,
+		CreatedAt:      r.now(),
+	}
+	start := time.Now()
+
+	result, err := r.run(ctx, call, inv)
+	inv.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		inv.Error = err.Error()
+		result = "error: " + err.Error()
+	} else {
+		inv.Result = result
+	}
+
+	if r.invocations != nil {
+		if err := r.invocations.Create(context.WithoutCancel(ctx), inv); err != nil {
+			fmt.Printf("warning: failed to record invocation of tool %s: %v\n", inv.ToolName, err)
+		}
+	}
+	return result
+}
+
+func (r *Runner) run(ctx context.Context, call openai.ToolCall, inv *toolDomain.Invocation) (string, error) {
+	tool, err := r.repo.GetByName(ctx, call.Function.Name)
+	if err != nil {
+		return "", err
+	}
+	if tool == nil || !tool.IsActive {
+		return "", fmt.Errorf("unknown tool %q", call.Function.Name)
+	}
+	inv.ToolID = tool.ID
+
+	args := map[string]any{}
+	if call.Function.Arguments != "" {
+		if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+			return "", errors.New("arguments are not a JSON object")
+		}
+	}
+
+	timeout := time.Duration(tool.TimeoutMs) * time.Millisecond
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	switch tool.Kind {
+	case toolDomain.KindCalculator:
+		expr, _ := args["expression"].(string)
+		return calculate(expr)
+	case toolDomain.KindDate:
+		return currentDate(r.now(), tool.Timezone)
+	case toolDomain.KindWebhook:
+		return r.callWebhook(ctx, tool, args, inv)
+	case toolDomain.KindLookup:
+		return r.callLookup(ctx, tool, args, inv)
+	case toolDomain.KindBookingSlots:
+		return r.offerSlots(ctx, tool)
+	case toolDomain.KindBooking:
+		return r.book(ctx, tool, args)
+	}
+	return "", fmt.Errorf("unsupported tool kind %q", tool.Kind)
+}
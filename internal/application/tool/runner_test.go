@@ -0,0 +1,224 @@
+package tool
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	bookingDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/booking"
+	toolDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/tool"
+	"github.com/elprogramadorgt/lucidRAG/pkg/openai"
+)
+
+func call(name, args string) openai.ToolCall {
+	return openai.ToolCall{ID: "call_1", Type: "function", Function: openai.FunctionCall{Name: name, Arguments: args}}
+}
+
+func TestCalculate(t *testing.T) {
+	tests := map[string]string{
+		"(19.99 * 3) * 0.9": "53.973",
+		"2 ^ 3 ^ 2":         "512",
+		"-4 + 10 % 4":       "-2",
+		"1/0":               "error",
+		"2 +":               "error",
+		"os.exit()":         "error",
+	}
+	for expr, want := range tests {
+		got, err := calculate(expr)
+		if want == "error" {
+			if err == nil {
+				t.Errorf("Expected error for %q, got %s", expr, got)
+			}
+			continue
+		}
+		if err != nil || got != want {
+			t.Errorf("Expected %s for %q, got %s (%v)", want, expr, got, err)
+		}
+	}
+}
+
+func TestRunBuiltinsAndAudit(t *testing.T) {
+	repo := newMockToolRepo(
+		toolDomain.Tool{ID: "t1", Name: "calc", Kind: toolDomain.KindCalculator, IsActive: true},
+		toolDomain.Tool{ID: "t2", Name: "today", Kind: toolDomain.KindDate, Timezone: "America/Guatemala", IsActive: true},
+		toolDomain.Tool{ID: "t3", Name: "disabled", Kind: toolDomain.KindCalculator},
+	)
+	audit := &mockInvocationRepo{}
+	runner := NewRunner(repo, audit, nil, nil)
+	runner.now = func() time.Time { return time.Date(2026, 3, 2, 18, 0, 0, 0, time.UTC) }
+
+	if got := runner.Run(context.Background(), call("calc", `{"expression":"12*3"}`)); got != "36" {
+		t.Errorf("Expected 36, got %s", got)
+	}
+	if got := runner.Run(context.Background(), call("today", `{}`)); got != "2026-03-02T12:00:00-06:00 (Monday, America/Guatemala)" {
+		t.Errorf("Unexpected date %s", got)
+	}
+	if got := runner.Run(context.Background(), call("disabled", `{"expression":"1"}`)); !strings.HasPrefix(got, "error:") {
+		t.Errorf("Expected inactive tool to be refused, got %s", got)
+	}
+
+	if len(audit.invocations) != 3 {
+		t.Fatalf("Expected 3 audited invocations, got %d", len(audit.invocations))
+	}
+	if audit.invocations[0].ToolID != "t1" || audit.invocations[0].Result != "36" {
+		t.Errorf("Unexpected audit record %+v", audit.invocations[0])
+	}
+	if audit.invocations[2].Error == "" {
+		t.Errorf("Expected the refused call to be audited with its error, got %+v", audit.invocations[2])
+	}
+
+	defs, err := runner.Definitions(context.Background())
+	if err != nil || len(defs) != 2 {
+		t.Fatalf("Expected the 2 active tools, got %d (%v)", len(defs), err)
+	}
+	if defs[0].Function.Name != "calc" || defs[0].Function.Parameters == nil {
+		t.Errorf("Expected calculator schema, got %+v", defs[0])
+	}
+}
+
+func TestRunWebhook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, []byte("s3cret"))
+		mac.Write(body)
+		if r.Header.Get("X-LucidRAG-Signature") != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+			t.Error("Expected a valid signature")
+		}
+		var req webhookRequest
+		if err := json.Unmarshal(body, &req); err != nil || req.Arguments["order_id"] != "A-100" {
+			t.Errorf("Unexpected webhook body %s", body)
+		}
+		w.Write([]byte(`{"status":"shipped"}`))
+	}))
+	defer server.Close()
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+	}))
+	defer slow.Close()
+
+	repo := newMockToolRepo(
+		toolDomain.Tool{ID: "t1", Name: "order_status", Kind: toolDomain.KindWebhook, WebhookURL: server.URL, WebhookSecret: "s3cret", IsActive: true},
+		toolDomain.Tool{ID: "t2", Name: "slow", Kind: toolDomain.KindWebhook, WebhookURL: slow.URL, TimeoutMs: 50, IsActive: true},
+	)
+	runner := NewRunner(repo, &mockInvocationRepo{}, nil, nil)
+
+	if got := runner.Run(context.Background(), call("order_status", `{"order_id":"A-100"}`)); got != `{"status":"shipped"}` {
+		t.Errorf("Expected webhook response, got %s", got)
+	}
+	if got := runner.Run(context.Background(), call("order_status", `not json`)); !strings.Contains(got, "not a JSON object") {
+		t.Errorf("Expected malformed arguments to be refused, got %s", got)
+	}
+	if got := runner.Run(context.Background(), call("slow", `{}`)); !strings.Contains(got, "deadline exceeded") {
+		t.Errorf("Expected timeout, got %s", got)
+	}
+}
+
+func TestRunLookup(t *testing.T) {
+	var redirect string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "k3y" {
+			t.Error("Expected the auth header")
+		}
+		switch r.URL.Path {
+		case "/orders/A 100/status":
+			w.Write([]byte(`{ "status": "shipped",
+				"eta": "2026-10-21" }`))
+		case "/orders/moved/status":
+			http.Redirect(w, r, redirect, http.StatusFound)
+		case "/orders/html/status":
+			w.Write([]byte(`<html></html>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	redirect = "http://localhost/elsewhere"
+
+	repo := newMockToolRepo(
+		toolDomain.Tool{ID: "t1", Name: "order_status", Kind: toolDomain.KindLookup, LookupURL: server.URL + "/orders/{order_number}/status",
+			AuthHeader: "X-Api-Key", AuthValue: "k3y", IsActive: true},
+		toolDomain.Tool{ID: "t2", Name: "other_host", Kind: toolDomain.KindLookup, LookupURL: "http://localhost/orders/{order_number}", IsActive: true},
+	)
+	audit := &mockInvocationRepo{}
+	runner := NewRunner(repo, audit, nil, []string{"127.0.0.1"})
+	ctx := toolDomain.WithConversation(context.Background(), "conv-1")
+
+	defs, _ := runner.Definitions(ctx)
+	if required := defs[0].Function.Parameters.(map[string]any)["required"]; len(required.([]string)) != 1 || required.([]string)[0] != "order_number" {
+		t.Errorf("Expected order_number to be required, got %v", defs[0].Function.Parameters)
+	}
+
+	tests := []struct {
+		tool, args, want string
+	}{
+		{"order_status", `{"order_number":"A 100"}`, `{"status":"shipped","eta":"2026-10-21"}`},
+		{"order_status", `{"order_number":"../admin"}`, lookupNotFound},
+		{"order_status", `{}`, `error: missing argument "order_number"`},
+		{"order_status", `{"order_number":"moved"}`, "error: lookup request failed"},
+		{"order_status", `{"order_number":"html"}`, "error: lookup response is not JSON"},
+		{"other_host", `{"order_number":"A-100"}`, "error: host localhost is not allowed"},
+	}
+	for _, tt := range tests {
+		if got := runner.Run(ctx, call(tt.tool, tt.args)); !strings.HasPrefix(got, tt.want) {
+			t.Errorf("%s %s: expected %q, got %q", tt.tool, tt.args, tt.want, got)
+		}
+	}
+
+	first := audit.invocations[0]
+	if first.URL != server.URL+"/orders/A%20100/status" || first.StatusCode != http.StatusOK || first.ConversationID != "conv-1" {
+		t.Errorf("Unexpected audit record %+v", first)
+	}
+}
+
+type mockBooker struct {
+	conversationID string
+	slot           string
+}
+
+func (m *mockBooker) OfferSlots(ctx context.Context, resourceID, conversationID string) ([]bookingDomain.Slot, error) {
+	m.conversationID = conversationID
+	start := time.Date(2026, 10, 20, 15, 0, 0, 0, time.UTC)
+	return []bookingDomain.Slot{{Start: start, End: start.Add(time.Hour), Label: "Tue 20 Oct 09:00"}}, nil
+}
+
+func (m *mockBooker) Book(ctx context.Context, resourceID, conversationID, slot string) (*bookingDomain.Booking, error) {
+	m.conversationID = conversationID
+	m.slot = slot
+	start := time.Date(2026, 10, 20, 15, 0, 0, 0, time.UTC)
+	return &bookingDomain.Booking{ResourceID: resourceID, Start: start, End: start.Add(time.Hour)}, nil
+}
+
+func TestRunBookingTools(t *testing.T) {
+	repo := newMockToolRepo(
+		toolDomain.Tool{ID: "t1", Name: "slots", Kind: toolDomain.KindBookingSlots, ResourceID: "res-1", IsActive: true},
+		toolDomain.Tool{ID: "t2", Name: "book", Kind: toolDomain.KindBooking, ResourceID: "res-1", IsActive: true},
+	)
+	booker := &mockBooker{}
+	runner := NewRunner(repo, &mockInvocationRepo{}, booker, nil)
+	ctx := toolDomain.WithConversation(context.Background(), "conv-1")
+
+	got := runner.Run(ctx, call("slots", `{}`))
+	if !strings.Contains(got, "Tue 20 Oct 09:00 (2026-10-20T15:00:00Z)") || booker.conversationID != "conv-1" {
+		t.Errorf("Expected the offered slots, got %s", got)
+	}
+	got = runner.Run(ctx, call("book", `{"slot":"Tue 20 Oct 09:00"}`))
+	if got != "Booked for 2026-10-20T15:00:00Z to 2026-10-20T16:00:00Z." || booker.slot != "Tue 20 Oct 09:00" {
+		t.Errorf("Unexpected booking result %s", got)
+	}
+
+	if got := runner.Run(context.Background(), call("book", `{"slot":"Tue 20 Oct 09:00"}`)); !strings.HasPrefix(got, "error:") {
+		t.Errorf("Expected booking outside a conversation to fail, got %s", got)
+	}
+	if got := NewRunner(repo, nil, nil, nil).Run(ctx, call("slots", `{}`)); !strings.HasPrefix(got, "error:") {
+		t.Errorf("Expected booking tools to fail without a booker, got %s", got)
+	}
+}
@@ -0,0 +1,229 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	toolDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/tool"
+)
+
+var (
+	ErrToolNotFound  = errors.New("tool not found")
+	ErrInvalidTool   = errors.New("invalid tool")
+	ErrDuplicateTool = errors.New("a tool with that name already exists")
+)
+
+const (
+	defaultTimeout = 5 * time.Second
+	maxTimeout     = 30 * time.Second
+)
+
+var (
+	// validName matches the function names the chat completion API accepts.
+	validName = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+	// validHeader matches HTTP header names.
+	validHeader = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]{1,64}$")
+)
+
+type service struct {
+	repo        toolDomain.Repository
+	invocations toolDomain.InvocationRepository
+	lookupHosts []string
+}
+
+type ServiceConfig struct {
+	Repo           toolDomain.Repository
+	InvocationRepo toolDomain.InvocationRepository
+	// LookupHosts are the hosts lookup tools may call; without any, lookup
+	// tools are rejected.
+	LookupHosts []string
+}
+
+func NewService(cfg ServiceConfig) toolDomain.Service {
+	return &service{
+		repo:        cfg.Repo,
+		invocations: cfg.InvocationRepo,
+		lookupHosts: cfg.LookupHosts,
+	}
+}
+
+func (s *service) CreateTool(ctx context.Context, adminID string, tool *toolDomain.Tool) (string, error) {
+	if err := validate(tool, s.lookupHosts); err != nil {
+		return "", err
+	}
+	existing, err := s.repo.GetByName(ctx, tool.Name)
+	if err != nil {
+		return "", err
+	}
+	if existing != nil {
+		return "", ErrDuplicateTool
+	}
+
+	tool.CreatedBy = adminID
+	return s.repo.Create(ctx, tool)
+}
+
+func (s *service) ListTools(ctx context.Context) ([]toolDomain.Tool, error) {
+	return s.repo.List(ctx)
+}
+
+// UpdateTool replaces a tool's definition. An empty webhook secret or lookup
+// auth value keeps the stored one, since secrets are never sent back to
+// clients.
+func (s *service) UpdateTool(ctx context.Context, adminID string, tool *toolDomain.Tool) error {
+	existing, err := s.repo.GetByID(ctx, tool.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrToolNotFound
+	}
+	if err := validate(tool, s.lookupHosts); err != nil {
+		return err
+	}
+	if tool.Name != existing.Name {
+		other, err := s.repo.GetByName(ctx, tool.Name)
+		if err != nil {
+			return err
+		}
+		if other != nil {
+			return ErrDuplicateTool
+		}
+	}
+
+	if tool.WebhookSecret == "" {
+		tool.WebhookSecret = existing.WebhookSecret
+	}
+	if tool.AuthValue == "" && tool.Kind == toolDomain.KindLookup {
+		tool.AuthValue = existing.AuthValue
+	}
+	tool.CreatedBy = existing.CreatedBy
+	tool.CreatedAt = existing.CreatedAt
+	return s.repo.Update(ctx, tool)
+}
+
+func (s *service) DeleteTool(ctx context.Context, adminID, id string) error {
+	existing, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrToolNotFound
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *service) ListInvocations(ctx context.Context, toolID string, limit, offset int) ([]toolDomain.Invocation, int64, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.invocations.List(ctx, toolID, limit, offset)
+}
+
+func validate(tool *toolDomain.Tool, lookupHosts []string) error {
+	tool.Name = strings.TrimSpace(tool.Name)
+	tool.Description = strings.TrimSpace(tool.Description)
+	if !validName.MatchString(tool.Name) {
+		return fmt.Errorf("%w: name must be 1-64 letters, digits, underscores or hyphens", ErrInvalidTool)
+	}
+	if tool.Description == "" {
+		return fmt.Errorf("%w: description is required", ErrInvalidTool)
+	}
+
+	switch tool.Kind {
+	case toolDomain.KindWebhook:
+		u, err := url.Parse(tool.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: webhook_url must be an http(s) URL", ErrInvalidTool)
+		}
+		if tool.Parameters == nil {
+			tool.Parameters = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+		if tool.Parameters["type"] != "object" {
+			return fmt.Errorf(`%w: parameters must be a JSON Schema with "type": "object"`, ErrInvalidTool)
+		}
+	case toolDomain.KindLookup:
+		if err := validateLookup(tool, lookupHosts); err != nil {
+			return err
+		}
+		tool.Parameters = nil
+		tool.WebhookURL = ""
+		tool.WebhookSecret = ""
+	case toolDomain.KindCalculator, toolDomain.KindDate, toolDomain.KindBookingSlots, toolDomain.KindBooking:
+		tool.Parameters = nil
+		tool.WebhookURL = ""
+		tool.WebhookSecret = ""
+	default:
+		return fmt.Errorf("%w: kind must be webhook, lookup, calculator, current_date, booking_slots or booking", ErrInvalidTool)
+	}
+	if tool.Kind != toolDomain.KindLookup {
+		tool.LookupURL = ""
+		tool.AuthHeader = ""
+		tool.AuthValue = ""
+	}
+
+	tool.ResourceID = strings.TrimSpace(tool.ResourceID)
+	isBooking := tool.Kind == toolDomain.KindBookingSlots || tool.Kind == toolDomain.KindBooking
+	if isBooking && tool.ResourceID == "" {
+		return fmt.Errorf("%w: resource_id is required for booking tools", ErrInvalidTool)
+	}
+	if !isBooking {
+		tool.ResourceID = ""
+	}
+
+	if tool.Kind == toolDomain.KindDate && tool.Timezone != "" {
+		if _, err := time.LoadLocation(tool.Timezone); err != nil {
+			return fmt.Errorf("%w: unknown timezone", ErrInvalidTool)
+		}
+	}
+
+	timeout := time.Duration(tool.TimeoutMs) * time.Millisecond
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	tool.TimeoutMs = int(min(timeout, maxTimeout).Milliseconds())
+	return nil
+}
+
+// validateLookup checks a lookup's URL template, which may only put
+// arguments in its path and query, against the allowed hosts.
+func validateLookup(tool *toolDomain.Tool, lookupHosts []string) error {
+	if len(lookupHosts) == 0 {
+		return fmt.Errorf("%w: lookup tools need TOOL_LOOKUP_ALLOWED_HOSTS", ErrInvalidTool)
+	}
+	tool.LookupURL = strings.TrimSpace(tool.LookupURL)
+	u, err := url.Parse(placeholder.ReplaceAllString(tool.LookupURL, "x"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+		return fmt.Errorf("%w: lookup_url must be an http(s) URL", ErrInvalidTool)
+	}
+	authority := tool.LookupURL[len(u.Scheme)+3:]
+	if i := strings.IndexAny(authority, "/?#"); i >= 0 {
+		authority = authority[:i]
+	}
+	if strings.Contains(authority, "{") {
+		return fmt.Errorf("%w: lookup_url may only have placeholders in its path and query", ErrInvalidTool)
+	}
+	if !hostAllowed(lookupHosts, u.Hostname()) {
+		return fmt.Errorf("%w: host %s is not in TOOL_LOOKUP_ALLOWED_HOSTS", ErrInvalidTool, u.Hostname())
+	}
+
+	tool.AuthHeader = strings.TrimSpace(tool.AuthHeader)
+	if tool.AuthHeader == "" {
+		tool.AuthHeader = "Authorization"
+	}
+	if !validHeader.MatchString(tool.AuthHeader) {
+		return fmt.Errorf("%w: auth_header must be a header name", ErrInvalidTool)
+	}
+	if strings.ContainsAny(tool.AuthValue, "\r\n") {
+		return fmt.Errorf("%w: auth_value must be a single line", ErrInvalidTool)
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	toolDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/tool"
+)
+
+type mockToolRepo struct {
+	tools map[string]*toolDomain.Tool
+}
+
+func newMockToolRepo(tools ...toolDomain.Tool) *mockToolRepo {
+	repo := &mockToolRepo{tools: map[string]*toolDomain.Tool{}}
+	for i := range tools {
+		repo.tools[tools[i].ID] = &tools[i]
+	}
+	return repo
+}
+
+func (m *mockToolRepo) Create(ctx context.Context, tool *toolDomain.Tool) (string, error) {
+	tool.ID = "tool_" + tool.Name
+	m.tools[tool.ID] = tool
+	return tool.ID, nil
+}
+
+func (m *mockToolRepo) GetByID(ctx context.Context, id string) (*toolDomain.Tool, error) {
+	return m.tools[id], nil
+}
+
+func (m *mockToolRepo) GetByName(ctx context.Context, name string) (*toolDomain.Tool, error) {
+	for _, t := range m.tools {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockToolRepo) List(ctx context.Context) ([]toolDomain.Tool, error) {
+	tools := []toolDomain.Tool{}
+	for _, t := range m.tools {
+		tools = append(tools, *t)
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+	return tools, nil
+}
+
+func (m *mockToolRepo) ListActive(ctx context.Context) ([]toolDomain.Tool, error) {
+	all, _ := m.List(ctx)
+	tools := []toolDomain.Tool{}
+	for _, t := range all {
+		if t.IsActive {
+			tools = append(tools, t)
+		}
+	}
+	return tools, nil
+}
+
+func (m *mockToolRepo) Update(ctx context.Context, tool *toolDomain.Tool) error {
+	m.tools[tool.ID] = tool
+	return nil
+}
+
+func (m *mockToolRepo) Delete(ctx context.Context, id string) error {
+	delete(m.tools, id)
+	return nil
+}
+
+type mockInvocationRepo struct {
+	invocations []toolDomain.Invocation
+}
+
+func (m *mockInvocationRepo) Create(ctx context.Context, inv *toolDomain.Invocation) error {
+	m.invocations = append(m.invocations, *inv)
+	return nil
+}
+
+func (m *mockInvocationRepo) List(ctx context.Context, toolID string, limit, offset int) ([]toolDomain.Invocation, int64, error) {
+	return m.invocations, int64(len(m.invocations)), nil
+}
+
+func (m *mockInvocationRepo) Failures(ctx context.Context, start, end time.Time) ([]toolDomain.FailureCount, error) {
+	return nil, nil
+}
+
+func TestCreateToolValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		tool toolDomain.Tool
+	}{
+		{"bad name", toolDomain.Tool{Name: "order status", Description: "d", Kind: toolDomain.KindCalculator}},
+		{"no description", toolDomain.Tool{Name: "calc", Kind: toolDomain.KindCalculator}},
+		{"bad kind", toolDomain.Tool{Name: "calc", Description: "d", Kind: "shell"}},
+		{"bad url", toolDomain.Tool{Name: "orders", Description: "d", Kind: toolDomain.KindWebhook, WebhookURL: "ftp://example.com"}},
+		{"bad schema", toolDomain.Tool{Name: "orders", Description: "d", Kind: toolDomain.KindWebhook, WebhookURL: "https://example.com", Parameters: map[string]any{"type": "string"}}},
+		{"bad timezone", toolDomain.Tool{Name: "today", Description: "d", Kind: toolDomain.KindDate, Timezone: "Mars/Olympus"}},
+		{"booking without resource", toolDomain.Tool{Name: "book", Description: "d", Kind: toolDomain.KindBooking}},
+		{"lookup host not allowed", toolDomain.Tool{Name: "orders", Description: "d", Kind: toolDomain.KindLookup, LookupURL: "https://evil.example.net/orders/{order_number}"}},
+		{"lookup placeholder in host", toolDomain.Tool{Name: "orders", Description: "d", Kind: toolDomain.KindLookup, LookupURL: "https://{tenant}.shop.example.com/orders"}},
+		{"lookup bad auth header", toolDomain.Tool{Name: "orders", Description: "d", Kind: toolDomain.KindLookup, LookupURL: "https://api.shop.example.com/orders/{id}", AuthHeader: "X Key"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewService(ServiceConfig{Repo: newMockToolRepo(), LookupHosts: []string{"api.shop.example.com", "*.shop.example.com"}})
+			if _, err := svc.CreateTool(context.Background(), "admin-1", &tt.tool); !errors.Is(err, ErrInvalidTool) {
+				t.Errorf("Expected ErrInvalidTool, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCreateToolDefaults(t *testing.T) {
+	repo := newMockToolRepo()
+	svc := NewService(ServiceConfig{Repo: repo})
+
+	tool := &toolDomain.Tool{Name: "order_status", Description: "Look up an order", Kind: toolDomain.KindWebhook, WebhookURL: "https://shop.example.com/hooks/orders", TimeoutMs: 120000}
+	id, err := svc.CreateTool(context.Background(), "admin-1", tool)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	created := repo.tools[id]
+	if created.TimeoutMs != 30000 || created.Parameters["type"] != "object" || created.CreatedBy != "admin-1" {
+		t.Errorf("Expected capped timeout, default schema and creator, got %+v", created)
+	}
+
+	dup := &toolDomain.Tool{Name: "order_status", Description: "again", Kind: toolDomain.KindCalculator}
+	if _, err := svc.CreateTool(context.Background(), "admin-1", dup); !errors.Is(err, ErrDuplicateTool) {
+		t.Errorf("Expected ErrDuplicateTool, got %v", err)
+	}
+}
+
+func TestUpdateToolKeepsSecret(t *testing.T) {
+	repo := newMockToolRepo(toolDomain.Tool{ID: "t1", Name: "orders", Description: "d", Kind: toolDomain.KindWebhook, WebhookURL: "https://example.com", WebhookSecret: "s3cret"})
+	svc := NewService(ServiceConfig{Repo: repo})
+
+	err := svc.UpdateTool(context.Background(), "admin-1", &toolDomain.Tool{ID: "t1", Name: "orders", Description: "new", Kind: toolDomain.KindWebhook, WebhookURL: "https://example.com/v2"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if repo.tools["t1"].WebhookSecret != "s3cret" || repo.tools["t1"].Description != "new" {
+		t.Errorf("Expected update to keep the secret, got %+v", repo.tools["t1"])
+	}
+
+	if err := svc.UpdateTool(context.Background(), "admin-1", &toolDomain.Tool{ID: "missing"}); !errors.Is(err, ErrToolNotFound) {
+		t.Errorf("Expected ErrToolNotFound, got %v", err)
+	}
+}
+
+func TestCreateLookupTool(t *testing.T) {
+	repo := newMockToolRepo()
+	tool := &toolDomain.Tool{
+		Name: "order_status", Description: "Look up an order by its number", Kind: toolDomain.KindLookup,
+		LookupURL: "https://api.shop.example.com/orders/{order_number}?expand=items", AuthValue: "Bearer k", WebhookURL: "https://ignored.example.com",
+	}
+
+	if _, err := NewService(ServiceConfig{Repo: repo}).CreateTool(context.Background(), "admin-1", tool); !errors.Is(err, ErrInvalidTool) {
+		t.Errorf("Expected lookups to be refused without allowed hosts, got %v", err)
+	}
+
+	svc := NewService(ServiceConfig{Repo: repo, LookupHosts: []string{"api.shop.example.com"}})
+	id, err := svc.CreateTool(context.Background(), "admin-1", tool)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	created := repo.tools[id]
+	if created.AuthHeader != "Authorization" || created.WebhookURL != "" {
+		t.Errorf("Expected the default auth header and no webhook, got %+v", created)
+	}
+
+	update := &toolDomain.Tool{ID: id, Name: "order_status", Description: "d", Kind: toolDomain.KindLookup, LookupURL: created.LookupURL}
+	if err := svc.UpdateTool(context.Background(), "admin-1", update); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if repo.tools[id].AuthValue != "Bearer k" {
+		t.Errorf("Expected update to keep the auth value, got %+v", repo.tools[id])
+	}
+}
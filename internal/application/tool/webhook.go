@@ -0,0 +1,63 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	toolDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/tool"
+)
+
+// maxWebhookResponse bounds what a webhook can put into the prompt.
+const maxWebhookResponse = 16 << 10
+
+type webhookRequest struct {
+	Tool      string         `json:"tool"`
+	Arguments map[string]any `json:"arguments"`
+}
+
+// callWebhook posts the call to the tool's endpoint and returns the response
+// body. With a secret set, the body is signed as
+// X-LucidRAG-Signature: sha256=<hex HMAC-SHA256 of the body>.
+func (r *Runner) callWebhook(ctx context.Context, tool *toolDomain.Tool, args map[string]any, inv *toolDomain.Invocation) (string, error) {
+	body, err := json.Marshal(webhookRequest{Tool: tool.Name, Arguments: args})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tool.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-LucidRAG-Tool", tool.Name)
+	inv.URL = tool.WebhookURL
+	if tool.WebhookSecret != "" {
+		mac := hmac.New(sha256.New, []byte(tool.WebhookSecret))
+		mac.Write(body)
+		req.Header.Set("X-LucidRAG-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	inv.StatusCode = resp.StatusCode
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponse))
+	if err != nil {
+		return "", fmt.Errorf("webhook response failed: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,64 @@
+package topic
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	topicDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/topic"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+)
+
+const (
+	recordTimeout = 5 * time.Second
+	// maxQuestionLength trims pasted documents down to what a topic label
+	// needs.
+	maxQuestionLength = 500
+)
+
+// Recorder keeps every question answered, cached answers included, for
+// topic clustering.
+type Recorder struct {
+	repo topicDomain.QuestionRepository
+	log  *logger.Logger
+}
+
+func NewRecorder(repo topicDomain.QuestionRepository, log *logger.Logger) *Recorder {
+	return &Recorder{repo: repo, log: log.With("subscriber", "topic_recorder")}
+}
+
+func (r *Recorder) Subscribe(bus *events.Bus) {
+	bus.Subscribe(r.handle, events.NameAnswerGenerated)
+}
+
+func (r *Recorder) handle(ctx context.Context, event events.Event) {
+	question := r.question(event)
+	if question == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), recordTimeout)
+		defer cancel()
+		if err := r.repo.Create(ctx, question); err != nil {
+			r.log.ErrorContext(ctx, "failed to record question", "error", err)
+		}
+	}()
+}
+
+// question returns the question to keep for event, or nil.
+func (r *Recorder) question(event events.Event) *topicDomain.Question {
+	answer, ok := event.(events.AnswerGenerated)
+	if !ok {
+		return nil
+	}
+	text := strings.TrimSpace(answer.Query)
+	if text == "" {
+		return nil
+	}
+	if runes := []rune(text); len(runes) > maxQuestionLength {
+		text = string(runes[:maxQuestionLength])
+	}
+	return &topicDomain.Question{Text: text, AskedAt: time.Now()}
+}
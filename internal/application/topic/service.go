@@ -0,0 +1,311 @@
+package topic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	topicDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/topic"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/elprogramadorgt/lucidRAG/pkg/openai"
+	"github.com/elprogramadorgt/lucidRAG/pkg/vectormath"
+)
+
+var (
+	ErrSnapshotNotFound  = errors.New("topic snapshot not found")
+	ErrNotConfigured     = errors.New("topic clustering needs OpenAI")
+	ErrTooFewQuestions   = errors.New("too few questions to cluster")
+	ErrClusteringRunning = errors.New("topic clustering is already running")
+)
+
+// Embedder makes the embeddings. *openai.Client and *tei.Client satisfy
+// it.
+type Embedder interface {
+	CreateEmbeddings(ctx context.Context, texts []string, model string) ([][]float64, error)
+}
+
+// Models make the embeddings and labels. *openai.Client satisfies it.
+type Models interface {
+	Embedder
+	CreateChatCompletion(ctx context.Context, messages []openai.ChatMessage, model string, opts *openai.CompletionOptions) (string, error)
+}
+
+const (
+	// maxQuestions bounds the questions clustered per run, newest first.
+	maxQuestions = 5000
+	// minQuestions is the fewest questions worth clustering.
+	minQuestions = 20
+	embedBatch   = 100
+	// labelQuestions are shown to the model to name a topic, and
+	// topicExamples kept with it.
+	labelQuestions = 10
+	topicExamples  = 5
+	defaultWindow  = 7 * 24 * time.Hour
+	defaultTopics  = 8
+)
+
+const labelPrompt = `These customer questions were grouped together. Name the topic they share
+in 2 to 5 words, in the language most of them are written in, such as
+"Refund status" or "Store opening hours". Reply with the name only.`
+
+type service struct {
+	questions      topicDomain.QuestionRepository
+	snapshots      topicDomain.SnapshotRepository
+	models         Models
+	embedder       Embedder
+	embeddingModel string
+	chatModel      string
+	window         time.Duration
+	maxTopics      int
+	log            *logger.Logger
+	running        sync.Mutex
+}
+
+type ServiceConfig struct {
+	QuestionRepo topicDomain.QuestionRepository
+	SnapshotRepo topicDomain.SnapshotRepository
+	// Models is nil when OpenAI is not configured; snapshots can still be
+	// read but none are made.
+	Models Models
+	// Embedder, when set, makes the embeddings instead of Models.
+	Embedder       Embedder
+	EmbeddingModel string
+	ChatModel      string
+	// Window is how far back questions are clustered. Defaults to a week.
+	Window time.Duration
+	// MaxTopics caps the topics per snapshot. Defaults to 8.
+	MaxTopics int
+	Log       *logger.Logger
+}
+
+func NewService(cfg ServiceConfig) topicDomain.Service {
+	window := cfg.Window
+	if window <= 0 {
+		window = defaultWindow
+	}
+	maxTopics := cfg.MaxTopics
+	if maxTopics < 2 {
+		maxTopics = defaultTopics
+	}
+	var embedder Embedder = cfg.Models
+	if cfg.Embedder != nil {
+		embedder = cfg.Embedder
+	}
+	return &service{
+		questions:      cfg.QuestionRepo,
+		snapshots:      cfg.SnapshotRepo,
+		models:         cfg.Models,
+		embedder:       embedder,
+		embeddingModel: cfg.EmbeddingModel,
+		chatModel:      cfg.ChatModel,
+		window:         window,
+		maxTopics:      maxTopics,
+		log:            cfg.Log.With("component", "topics"),
+	}
+}
+
+func (s *service) Latest(ctx context.Context) (*topicDomain.Snapshot, error) {
+	snapshot, err := s.snapshots.Latest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot == nil {
+		return nil, ErrSnapshotNotFound
+	}
+	return snapshot, nil
+}
+
+func (s *service) GetSnapshot(ctx context.Context, id string) (*topicDomain.Snapshot, error) {
+	snapshot, err := s.snapshots.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot == nil {
+		return nil, ErrSnapshotNotFound
+	}
+	return snapshot, nil
+}
+
+func (s *service) ListSnapshots(ctx context.Context, limit int) ([]topicDomain.Snapshot, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	return s.snapshots.List(ctx, limit)
+}
+
+// Cluster deletes the questions that left the window, embeds the new ones,
+// groups them with k-means and asks the chat model to name each group.
+func (s *service) Cluster(ctx context.Context) (*topicDomain.Snapshot, error) {
+	if s.models == nil {
+		return nil, ErrNotConfigured
+	}
+	if !s.running.TryLock() {
+		return nil, ErrClusteringRunning
+	}
+	defer s.running.Unlock()
+
+	now := time.Now()
+	from := now.Add(-s.window)
+	if _, err := s.questions.DeleteBefore(ctx, from); err != nil {
+		return nil, fmt.Errorf("failed to delete old questions: %w", err)
+	}
+	questions, err := s.questions.ListSince(ctx, from, maxQuestions)
+	if err != nil {
+		return nil, err
+	}
+	if len(questions) < minQuestions {
+		return nil, fmt.Errorf("%w: %d asked since %s, %d needed", ErrTooFewQuestions, len(questions), from.Format(time.RFC3339), minQuestions)
+	}
+	if err := s.embedQuestions(ctx, questions); err != nil {
+		return nil, err
+	}
+
+	vectors := make([][]float64, len(questions))
+	for i, q := range questions {
+		vectors[i] = q.Embedding
+	}
+	assignments, centroids := vectormath.KMeans(vectors, topicCount(len(questions), s.maxTopics))
+	if assignments == nil {
+		return nil, errors.New("question vectors differ in size")
+	}
+
+	members := make([][]int, len(centroids))
+	for i, c := range assignments {
+		members[c] = append(members[c], i)
+	}
+	snapshot := &topicDomain.Snapshot{From: from, To: now, Questions: len(questions), Topics: []topicDomain.Topic{}}
+	for c, indexes := range members {
+		if len(indexes) == 0 {
+			continue
+		}
+		// Closest to the center first.
+		sort.SliceStable(indexes, func(i, j int) bool {
+			return vectormath.CosineSimilarity(vectors[indexes[i]], centroids[c]) > vectormath.CosineSimilarity(vectors[indexes[j]], centroids[c])
+		})
+		texts := distinctTexts(questions, indexes, labelQuestions)
+		snapshot.Topics = append(snapshot.Topics, topicDomain.Topic{
+			Label:     s.label(ctx, texts),
+			Questions: len(indexes),
+			Share:     float64(len(indexes)) / float64(len(questions)),
+			Examples:  texts[:min(topicExamples, len(texts))],
+		})
+	}
+	sort.SliceStable(snapshot.Topics, func(i, j int) bool {
+		return snapshot.Topics[i].Questions > snapshot.Topics[j].Questions
+	})
+
+	if _, err := s.snapshots.Create(ctx, snapshot); err != nil {
+		return nil, err
+	}
+	s.log.InfoContext(ctx, "topics clustered", "questions", snapshot.Questions, "topics", len(snapshot.Topics))
+	return snapshot, nil
+}
+
+// embedQuestions fills in the vectors of questions not yet embedded with
+// the configured model, in batches, and stores them.
+func (s *service) embedQuestions(ctx context.Context, questions []topicDomain.Question) error {
+	var pending []int
+	for i, q := range questions {
+		if q.EmbeddingModel != s.embeddingModel || len(q.Embedding) == 0 {
+			pending = append(pending, i)
+		}
+	}
+	for start := 0; start < len(pending); start += embedBatch {
+		batch := pending[start:min(start+embedBatch, len(pending))]
+		texts := make([]string, len(batch))
+		for i, index := range batch {
+			texts[i] = questions[index].Text
+		}
+		embeddings, err := s.embedder.CreateEmbeddings(ctx, texts, s.embeddingModel)
+		if err != nil {
+			return fmt.Errorf("failed to embed questions: %w", err)
+		}
+		if len(embeddings) != len(batch) {
+			return fmt.Errorf("failed to embed questions: got %d embeddings for %d questions", len(embeddings), len(batch))
+		}
+		vectors := make(map[string][]float64, len(batch))
+		for i, index := range batch {
+			questions[index].Embedding = embeddings[i]
+			questions[index].EmbeddingModel = s.embeddingModel
+			vectors[questions[index].ID] = embeddings[i]
+		}
+		if err := s.questions.SetEmbeddings(ctx, s.embeddingModel, vectors); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// label names a topic from its questions. When the model fails, the most
+// central question stands in, so one failed call does not lose the run.
+func (s *service) label(ctx context.Context, texts []string) string {
+	var b strings.Builder
+	for _, text := range texts {
+		b.WriteString("- " + text + "\n")
+	}
+	label, err := s.models.CreateChatCompletion(ctx, []openai.ChatMessage{
+		{Role: "system", Content: labelPrompt},
+		{Role: "user", Content: b.String()},
+	}, s.chatModel, &openai.CompletionOptions{MaxTokens: 20})
+	label = strings.Trim(strings.TrimSpace(label), `"'.`)
+	if err != nil || label == "" {
+		if err != nil {
+			s.log.WarnContext(ctx, "failed to label topic", "error", err)
+		}
+		return texts[0]
+	}
+	return label
+}
+
+// topicCount picks how many topics to look for in n questions: the square
+// root of n/2, a common rule of thumb, at least 2 and at most limit.
+func topicCount(n, limit int) int {
+	k := int(math.Round(math.Sqrt(float64(n) / 2)))
+	return min(max(k, 2), limit)
+}
+
+// distinctTexts returns up to limit texts of the questions at indexes,
+// skipping repeats that differ only in case and spacing.
+func distinctTexts(questions []topicDomain.Question, indexes []int, limit int) []string {
+	seen := make(map[string]bool)
+	var texts []string
+	for _, i := range indexes {
+		key := strings.ToLower(strings.Join(strings.Fields(questions[i].Text), " "))
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		texts = append(texts, questions[i].Text)
+		if len(texts) == limit {
+			break
+		}
+	}
+	return texts
+}
+
+// ClusteringJob clusters the recent questions on a schedule. A quiet window
+// with too few questions is not a failure.
+type ClusteringJob struct {
+	svc topicDomain.Service
+}
+
+func NewClusteringJob(svc topicDomain.Service) *ClusteringJob {
+	return &ClusteringJob{svc: svc}
+}
+
+// Run makes a new snapshot from the questions in the window.
+func (j *ClusteringJob) Run(ctx context.Context) error {
+	_, err := j.svc.Cluster(ctx)
+	if errors.Is(err, ErrTooFewQuestions) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,264 @@
+package topic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	topicDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/topic"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/elprogramadorgt/lucidRAG/pkg/openai"
+)
+
+type mockQuestionRepo struct {
+	questions []topicDomain.Question
+	stored    map[string][]float64
+	deleted   time.Time
+}
+
+func (m *mockQuestionRepo) Create(ctx context.Context, question *topicDomain.Question) error {
+	m.questions = append(m.questions, *question)
+	return nil
+}
+
+func (m *mockQuestionRepo) ListSince(ctx context.Context, since time.Time, limit int) ([]topicDomain.Question, error) {
+	return append([]topicDomain.Question(nil), m.questions...), nil
+}
+
+func (m *mockQuestionRepo) SetEmbeddings(ctx context.Context, model string, vectors map[string][]float64) error {
+	if m.stored == nil {
+		m.stored = make(map[string][]float64)
+	}
+	for id, vector := range vectors {
+		m.stored[id] = vector
+	}
+	return nil
+}
+
+func (m *mockQuestionRepo) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	m.deleted = before
+	return 0, nil
+}
+
+type mockSnapshotRepo struct {
+	created *topicDomain.Snapshot
+}
+
+func (m *mockSnapshotRepo) Create(ctx context.Context, snapshot *topicDomain.Snapshot) (string, error) {
+	snapshot.ID = "snap-1"
+	m.created = snapshot
+	return snapshot.ID, nil
+}
+
+func (m *mockSnapshotRepo) GetByID(ctx context.Context, id string) (*topicDomain.Snapshot, error) {
+	if m.created != nil && m.created.ID == id {
+		return m.created, nil
+	}
+	return nil, nil
+}
+
+func (m *mockSnapshotRepo) Latest(ctx context.Context) (*topicDomain.Snapshot, error) {
+	return m.created, nil
+}
+
+func (m *mockSnapshotRepo) List(ctx context.Context, limit int) ([]topicDomain.Snapshot, error) {
+	return nil, nil
+}
+
+// mockModels embeds questions by keyword and labels a topic after the
+// keyword of its first question.
+type mockModels struct {
+	labelErr error
+	embedded int
+}
+
+func (m *mockModels) CreateEmbeddings(ctx context.Context, texts []string, model string) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		switch {
+		case strings.Contains(text, "refund"):
+			vectors[i] = []float64{1, 0.05 * float64(i%3), 0}
+		case strings.Contains(text, "open"):
+			vectors[i] = []float64{0, 1, 0.05 * float64(i%3)}
+		default:
+			vectors[i] = []float64{0.05 * float64(i%3), 0, 1}
+		}
+	}
+	m.embedded += len(texts)
+	return vectors, nil
+}
+
+func (m *mockModels) CreateChatCompletion(ctx context.Context, messages []openai.ChatMessage, model string, opts *openai.CompletionOptions) (string, error) {
+	if m.labelErr != nil {
+		return "", m.labelErr
+	}
+	switch content := messages[1].Content; {
+	case strings.Contains(content, "refund"):
+		return `"Refunds"`, nil
+	case strings.Contains(content, "open"):
+		return "Opening hours.", nil
+	default:
+		return "Shipping", nil
+	}
+}
+
+func askQuestions(repo *mockQuestionRepo, counts map[string]int) {
+	for prefix, n := range counts {
+		for i := range n {
+			repo.questions = append(repo.questions, topicDomain.Question{
+				ID:   fmt.Sprintf("%s-%d", prefix, i),
+				Text: fmt.Sprintf("%s question %d", prefix, i),
+			})
+		}
+	}
+}
+
+func newTestService(questions *mockQuestionRepo, snapshots *mockSnapshotRepo, models Models) topicDomain.Service {
+	return NewService(ServiceConfig{
+		QuestionRepo:   questions,
+		SnapshotRepo:   snapshots,
+		Models:         models,
+		EmbeddingModel: "text-embedding-3-small",
+		MaxTopics:      3,
+		Log:            logger.New(logger.Options{Level: "error"}),
+	})
+}
+
+func TestCluster(t *testing.T) {
+	questions := &mockQuestionRepo{}
+	askQuestions(questions, map[string]int{"refund": 12, "open": 8, "shipping": 4})
+	snapshots := &mockSnapshotRepo{}
+	models := &mockModels{}
+	svc := newTestService(questions, snapshots, models)
+
+	snapshot, err := svc.Cluster(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if snapshots.created != snapshot {
+		t.Fatal("Expected the snapshot to be saved")
+	}
+	if snapshot.Questions != 24 {
+		t.Errorf("Expected 24 questions, got %d", snapshot.Questions)
+	}
+	if time.Since(questions.deleted) < 7*24*time.Hour-time.Minute {
+		t.Errorf("Expected questions older than a week to be deleted, got cutoff %v", questions.deleted)
+	}
+	if len(questions.stored) != 24 || models.embedded != 24 {
+		t.Errorf("Expected 24 questions embedded and stored, got %d embedded and %d stored", models.embedded, len(questions.stored))
+	}
+
+	want := []struct {
+		label     string
+		questions int
+	}{{"Refunds", 12}, {"Opening hours", 8}, {"Shipping", 4}}
+	if len(snapshot.Topics) != len(want) {
+		t.Fatalf("Expected %d topics, got %+v", len(want), snapshot.Topics)
+	}
+	for i, w := range want {
+		topic := snapshot.Topics[i]
+		if topic.Label != w.label || topic.Questions != w.questions {
+			t.Errorf("Expected topic %d to be %q with %d questions, got %q with %d", i, w.label, w.questions, topic.Label, topic.Questions)
+		}
+		if len(topic.Examples) == 0 || len(topic.Examples) > topicExamples {
+			t.Errorf("Expected 1 to %d examples for %q, got %d", topicExamples, w.label, len(topic.Examples))
+		}
+	}
+	if share := snapshot.Topics[0].Share; share != 0.5 {
+		t.Errorf("Expected the first topic to hold half the questions, got %v", share)
+	}
+}
+
+func TestClusterReusesEmbeddings(t *testing.T) {
+	questions := &mockQuestionRepo{}
+	askQuestions(questions, map[string]int{"refund": 10, "open": 10})
+	for i := range questions.questions[:5] {
+		questions.questions[i].Embedding = []float64{1, 0, 0}
+		questions.questions[i].EmbeddingModel = "text-embedding-3-small"
+	}
+	models := &mockModels{}
+
+	if _, err := newTestService(questions, &mockSnapshotRepo{}, models).Cluster(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if models.embedded != 15 {
+		t.Errorf("Expected only the 15 new questions to be embedded, got %d", models.embedded)
+	}
+}
+
+func TestClusterLabelFallback(t *testing.T) {
+	questions := &mockQuestionRepo{}
+	askQuestions(questions, map[string]int{"refund": 20})
+	svc := newTestService(questions, &mockSnapshotRepo{}, &mockModels{labelErr: errors.New("rate limited")})
+
+	snapshot, err := svc.Cluster(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, topic := range snapshot.Topics {
+		if topic.Label != topic.Examples[0] {
+			t.Errorf("Expected the label to fall back to the first example, got %q", topic.Label)
+		}
+	}
+}
+
+func TestClusterErrors(t *testing.T) {
+	few := &mockQuestionRepo{}
+	askQuestions(few, map[string]int{"refund": minQuestions - 1})
+	snapshots := &mockSnapshotRepo{}
+
+	if _, err := newTestService(few, snapshots, &mockModels{}).Cluster(context.Background()); !errors.Is(err, ErrTooFewQuestions) {
+		t.Errorf("Expected ErrTooFewQuestions, got %v", err)
+	}
+	if _, err := newTestService(few, snapshots, nil).Cluster(context.Background()); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("Expected ErrNotConfigured, got %v", err)
+	}
+	if err := NewClusteringJob(newTestService(few, snapshots, &mockModels{})).Run(context.Background()); err != nil {
+		t.Errorf("Expected the job to skip a quiet window, got %v", err)
+	}
+	if snapshots.created != nil {
+		t.Error("Expected no snapshot to be saved")
+	}
+	if _, err := newTestService(few, snapshots, nil).Latest(context.Background()); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Errorf("Expected ErrSnapshotNotFound, got %v", err)
+	}
+}
+
+func TestTopicCount(t *testing.T) {
+	tests := []struct {
+		n, limit, want int
+	}{
+		{20, 8, 3},
+		{200, 8, 8},
+		{2, 8, 2},
+		{5000, 50, 50},
+	}
+	for _, tt := range tests {
+		if got := topicCount(tt.n, tt.limit); got != tt.want {
+			t.Errorf("topicCount(%d, %d) = %d, want %d", tt.n, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestRecorderQuestion(t *testing.T) {
+	r := NewRecorder(nil, logger.New(logger.Options{Level: "error"}))
+
+	q := r.question(events.AnswerGenerated{Query: "  when do you open?  ", CacheHit: true})
+	if q == nil || q.Text != "when do you open?" || q.AskedAt.IsZero() {
+		t.Errorf("Expected the trimmed question, got %+v", q)
+	}
+	long := r.question(events.AnswerGenerated{Query: strings.Repeat("é", maxQuestionLength+10)})
+	if long == nil || len([]rune(long.Text)) != maxQuestionLength {
+		t.Errorf("Expected the question cut to %d runes", maxQuestionLength)
+	}
+	if got := r.question(events.AnswerGenerated{Query: " "}); got != nil {
+		t.Errorf("Expected no question for a blank query, got %+v", got)
+	}
+	if got := r.question(events.DocumentCreated{DocumentID: "doc-1"}); got != nil {
+		t.Errorf("Expected no question for other events, got %+v", got)
+	}
+}
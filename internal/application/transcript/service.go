@@ -0,0 +1,311 @@
+package transcript
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	transcriptDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/transcript"
+	userDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/user"
+	mailpkg "github.com/elprogramadorgt/lucidRAG/pkg/mail"
+	"github.com/elprogramadorgt/lucidRAG/pkg/pdf"
+)
+
+var (
+	ErrInvalidFormat    = errors.New("format must be pdf or html")
+	ErrInvalidRecipient = errors.New("recipient must be contact or agent")
+	ErrNoContactEmail   = errors.New("the contact has no email address")
+	ErrMailUnavailable  = errors.New("email sending is not configured")
+	ErrSendFailed       = errors.New("failed to send transcript")
+)
+
+const (
+	// maxMessages caps a transcript; longer conversations keep their most
+	// recent messages.
+	maxMessages = 2000
+	pageSize    = 200
+	timeLayout  = "2006-01-02 15:04 UTC"
+)
+
+// Mailer delivers an email and returns its Message-ID.
+type Mailer interface {
+	Send(ctx context.Context, msg mailpkg.Message) (string, error)
+}
+
+// Brand is how transcripts are branded.
+type Brand struct {
+	Name string
+	// Color is a #rrggbb color for headings.
+	Color string
+}
+
+type service struct {
+	convSvc  conversationDomain.Service
+	userRepo userDomain.Repository
+	mailer   Mailer
+	brand    Brand
+}
+
+type ServiceConfig struct {
+	ConvSvc  conversationDomain.Service
+	UserRepo userDomain.Repository
+	// Mailer is nil when SMTP is not configured; transcripts can then only
+	// be downloaded.
+	Mailer Mailer
+	Brand  Brand
+}
+
+func NewService(cfg ServiceConfig) transcriptDomain.Service {
+	return &service{
+		convSvc:  cfg.ConvSvc,
+		userRepo: cfg.UserRepo,
+		mailer:   cfg.Mailer,
+		brand:    cfg.Brand,
+	}
+}
+
+// transcript is what both formats are rendered from.
+type transcript struct {
+	Brand       Brand
+	Contact     string
+	Channel     string
+	StartedAt   string
+	GeneratedAt string
+	Truncated   bool
+	Entries     []entry
+}
+
+type entry struct {
+	Time     string
+	Author   string
+	Outgoing bool
+	Text     string
+}
+
+func (s *service) Render(ctx context.Context, userCtx conversationDomain.UserContext, conversationID string, format transcriptDomain.Format) (*transcriptDomain.File, error) {
+	if !validFormat(format) {
+		return nil, ErrInvalidFormat
+	}
+	conv, err := s.convSvc.GetConversation(ctx, userCtx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	return s.render(ctx, userCtx, conv, format)
+}
+
+func (s *service) render(ctx context.Context, userCtx conversationDomain.UserContext, conv *conversationDomain.Conversation, format transcriptDomain.Format) (*transcriptDomain.File, error) {
+	t, err := s.build(ctx, userCtx, conv)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &transcriptDomain.File{Filename: "transcript-" + conv.ID + "." + string(format)}
+	if format == transcriptDomain.FormatPDF {
+		file.ContentType = "application/pdf"
+		file.Data = renderPDF(t)
+		return file, nil
+	}
+	var buf bytes.Buffer
+	if err := pageTemplate.Execute(&buf, t); err != nil {
+		return nil, fmt.Errorf("failed to render transcript: %w", err)
+	}
+	file.ContentType = "text/html; charset=utf-8"
+	file.Data = buf.Bytes()
+	return file, nil
+}
+
+func (s *service) Email(ctx context.Context, userCtx conversationDomain.UserContext, conversationID string, format transcriptDomain.Format, to transcriptDomain.Recipient) (*transcriptDomain.Delivery, error) {
+	if !validFormat(format) {
+		return nil, ErrInvalidFormat
+	}
+	if to != transcriptDomain.RecipientContact && to != transcriptDomain.RecipientAgent {
+		return nil, ErrInvalidRecipient
+	}
+	if s.mailer == nil {
+		return nil, ErrMailUnavailable
+	}
+	conv, err := s.convSvc.GetConversation(ctx, userCtx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	var address string
+	if to == transcriptDomain.RecipientContact {
+		address = contactEmail(conv)
+		if address == "" {
+			return nil, ErrNoContactEmail
+		}
+	} else {
+		user, err := s.userRepo.GetByID(ctx, userCtx.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, fmt.Errorf("user %s not found", userCtx.UserID)
+		}
+		address = user.Email
+	}
+
+	file, err := s.render(ctx, userCtx, conv, format)
+	if err != nil {
+		return nil, err
+	}
+
+	body := fmt.Sprintf("Hello,\n\nAttached is the transcript of your conversation with %s.\n\n%s\n", s.brand.Name, s.brand.Name)
+	if to == transcriptDomain.RecipientAgent {
+		body = fmt.Sprintf("Attached is the transcript of conversation %s, as requested.\n", conversationID)
+	}
+	_, err = s.mailer.Send(ctx, mailpkg.Message{
+		To:          address,
+		Subject:     "Conversation transcript - " + s.brand.Name,
+		Body:        body,
+		Attachments: []mailpkg.Attachment{{Filename: file.Filename, ContentType: file.ContentType, Data: file.Data}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSendFailed, err)
+	}
+	return &transcriptDomain.Delivery{Recipient: to, To: address, Format: format}, nil
+}
+
+func validFormat(format transcriptDomain.Format) bool {
+	return format == transcriptDomain.FormatPDF || format == transcriptDomain.FormatHTML
+}
+
+// contactEmail returns the contact's email address: the sender of an email
+// conversation, or an "email" variable such as one synced from the CRM.
+func contactEmail(conv *conversationDomain.Conversation) string {
+	candidate := conv.Variables["email"]
+	if conv.Channel == conversationDomain.ChannelEmail {
+		candidate = conv.ExternalID
+	}
+	addr, err := mail.ParseAddress(candidate)
+	if err != nil {
+		return ""
+	}
+	return addr.Address
+}
+
+// build loads the conversation's messages, oldest first.
+func (s *service) build(ctx context.Context, userCtx conversationDomain.UserContext, conv *conversationDomain.Conversation) (*transcript, error) {
+	var msgs []conversationDomain.Message
+	var total int64
+	for len(msgs) < maxMessages {
+		page, count, err := s.convSvc.GetMessages(ctx, userCtx, conv.ID, pageSize, len(msgs))
+		if err != nil {
+			return nil, err
+		}
+		total = count
+		msgs = append(msgs, page...)
+		if len(page) < pageSize {
+			break
+		}
+	}
+	slices.Reverse(msgs)
+
+	contact := conv.ContactName
+	for _, alt := range []string{conv.PhoneNumber, conv.ExternalID} {
+		if contact == "" {
+			contact = alt
+		}
+	}
+	channel := conv.Channel
+	if channel == "" {
+		channel = conversationDomain.ChannelWhatsApp
+	}
+
+	t := &transcript{
+		Brand:       s.brand,
+		Contact:     contact,
+		Channel:     channel,
+		StartedAt:   conv.CreatedAt.UTC().Format(timeLayout),
+		GeneratedAt: time.Now().UTC().Format(timeLayout),
+		Truncated:   total > int64(len(msgs)),
+		Entries:     make([]entry, 0, len(msgs)),
+	}
+	for _, m := range msgs {
+		e := entry{
+			Time:     m.Timestamp.UTC().Format(timeLayout),
+			Author:   contact,
+			Outgoing: m.Direction == conversationDomain.DirectionOutgoing,
+			Text:     strings.TrimSpace(m.Content),
+		}
+		if e.Outgoing {
+			e.Author = s.brand.Name
+		}
+		if m.Media != nil {
+			attachment := "[attachment]"
+			if m.Media.Description != "" {
+				attachment = "[photo: " + m.Media.Description + "]"
+			}
+			e.Text = strings.TrimSpace(e.Text + " " + attachment)
+		}
+		t.Entries = append(t.Entries, e)
+	}
+	return t, nil
+}
+
+func renderPDF(t *transcript) []byte {
+	brand := pdf.HexColor(t.Brand.Color)
+	grey := pdf.Color{R: 0.4, G: 0.4, B: 0.4}
+
+	doc := pdf.New("Conversation transcript - " + t.Contact)
+	doc.Paragraph(t.Brand.Name, pdf.Style{Size: 20, Bold: true, Color: brand})
+	doc.Paragraph("Conversation transcript", pdf.Style{Size: 12})
+	doc.Rule(brand)
+	doc.Paragraph(fmt.Sprintf("Contact: %s\nChannel: %s\nStarted: %s\nGenerated: %s",
+		t.Contact, t.Channel, t.StartedAt, t.GeneratedAt), pdf.Style{Size: 9, Color: grey})
+	if t.Truncated {
+		doc.Paragraph(fmt.Sprintf("Only the most recent %d messages are included.", len(t.Entries)), pdf.Style{Size: 9, Color: grey})
+	}
+	doc.Space(12)
+
+	for _, e := range t.Entries {
+		heading := pdf.Style{Size: 9, Bold: true}
+		if e.Outgoing {
+			heading.Color = brand
+		}
+		doc.Paragraph(e.Author+"  ·  "+e.Time, heading)
+		doc.Paragraph(e.Text, pdf.Style{Size: 10})
+		doc.Space(8)
+	}
+	return doc.Bytes()
+}
+
+var pageTemplate = template.Must(template.New("transcript").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Conversation transcript - {{.Contact}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; color: #222; max-width: 720px; margin: 32px auto; padding: 0 16px; }
+header { border-bottom: 2px solid {{.Brand.Color}}; margin-bottom: 16px; }
+h1 { color: {{.Brand.Color}}; margin: 0; }
+.meta { color: #666; font-size: 13px; }
+.entry { margin: 12px 0; }
+.author { font-weight: bold; font-size: 13px; }
+.outgoing .author { color: {{.Brand.Color}}; }
+.time { color: #666; font-weight: normal; }
+.text { white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Brand.Name}}</h1>
+<p>Conversation transcript</p>
+</header>
+<p class="meta">Contact: {{.Contact}}<br>Channel: {{.Channel}}<br>Started: {{.StartedAt}}<br>Generated: {{.GeneratedAt}}</p>
+{{if .Truncated}}<p class="meta">Only the most recent {{len .Entries}} messages are included.</p>{{end}}
+{{range .Entries}}<div class="entry{{if .Outgoing}} outgoing{{end}}">
+<div class="author">{{.Author}} <span class="time">· {{.Time}}</span></div>
+<div class="text">{{.Text}}</div>
+</div>
+{{end}}</body>
+</html>
+`))
@@ -0,0 +1,183 @@
+package transcript
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	convApp "github.com/elprogramadorgt/lucidRAG/internal/application/conversation"
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	transcriptDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/transcript"
+	userDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/user"
+	mailpkg "github.com/elprogramadorgt/lucidRAG/pkg/mail"
+)
+
+type mockConvService struct {
+	conversationDomain.Service
+	conv *conversationDomain.Conversation
+	// msgs is newest first, as the service returns them.
+	msgs []conversationDomain.Message
+}
+
+func (m *mockConvService) GetConversation(ctx context.Context, userCtx conversationDomain.UserContext, id string) (*conversationDomain.Conversation, error) {
+	if m.conv == nil || m.conv.ID != id {
+		return nil, convApp.ErrConversationNotFound
+	}
+	return m.conv, nil
+}
+
+func (m *mockConvService) GetMessages(ctx context.Context, userCtx conversationDomain.UserContext, id string, limit, offset int) ([]conversationDomain.Message, int64, error) {
+	end := min(offset+limit, len(m.msgs))
+	if offset > end {
+		offset = end
+	}
+	return m.msgs[offset:end], int64(len(m.msgs)), nil
+}
+
+type mockUserRepo struct {
+	userDomain.Repository
+}
+
+func (m *mockUserRepo) GetByID(ctx context.Context, id string) (*userDomain.User, error) {
+	return &userDomain.User{ID: id, Email: "agent@example.com"}, nil
+}
+
+type mockMailer struct {
+	sent []mailpkg.Message
+	err  error
+}
+
+func (m *mockMailer) Send(ctx context.Context, msg mailpkg.Message) (string, error) {
+	if m.err != nil {
+		return "", m.err
+	}
+	m.sent = append(m.sent, msg)
+	return "<id@example.com>", nil
+}
+
+func newTestService(mailer Mailer) (*service, *mockConvService) {
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	convSvc := &mockConvService{
+		conv: &conversationDomain.Conversation{
+			ID: "conv-1", ContactName: "Ana <script>", PhoneNumber: "+15550100",
+			CreatedAt: start, Variables: map[string]string{"email": "ana@example.com"},
+		},
+		msgs: []conversationDomain.Message{
+			{Direction: conversationDomain.DirectionOutgoing, Content: "The Pro plan is $20 (monthly).", Timestamp: start.Add(time.Minute)},
+			{Direction: conversationDomain.DirectionIncoming, Content: "How much is Pro?", Timestamp: start},
+		},
+	}
+	svc := NewService(ServiceConfig{
+		ConvSvc:  convSvc,
+		UserRepo: &mockUserRepo{},
+		Mailer:   mailer,
+		Brand:    Brand{Name: "Acme Support", Color: "#2563eb"},
+	}).(*service)
+	return svc, convSvc
+}
+
+func TestRenderHTML(t *testing.T) {
+	svc, _ := newTestService(nil)
+	userCtx := conversationDomain.UserContext{UserID: "u1"}
+
+	file, err := svc.Render(context.Background(), userCtx, "conv-1", transcriptDomain.FormatHTML)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	html := string(file.Data)
+	if file.Filename != "transcript-conv-1.html" {
+		t.Errorf("Expected filename transcript-conv-1.html, got %s", file.Filename)
+	}
+	if strings.Contains(html, "<script>") || !strings.Contains(html, "Ana &lt;script&gt;") {
+		t.Errorf("Expected the contact name escaped")
+	}
+	if !strings.Contains(html, "color: #2563eb") {
+		t.Errorf("Expected the brand color in the page")
+	}
+	if strings.Index(html, "How much is Pro?") > strings.Index(html, "The Pro plan is $20") {
+		t.Errorf("Expected messages oldest first")
+	}
+
+	if _, err := svc.Render(context.Background(), userCtx, "conv-1", "docx"); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("Expected ErrInvalidFormat, got %v", err)
+	}
+	if _, err := svc.Render(context.Background(), userCtx, "missing", transcriptDomain.FormatPDF); !errors.Is(err, convApp.ErrConversationNotFound) {
+		t.Errorf("Expected ErrConversationNotFound, got %v", err)
+	}
+}
+
+func TestRenderPDFTruncates(t *testing.T) {
+	svc, convSvc := newTestService(nil)
+	convSvc.msgs = make([]conversationDomain.Message, maxMessages+5)
+	for i := range convSvc.msgs {
+		convSvc.msgs[i] = conversationDomain.Message{Content: fmt.Sprintf("message %d", i)}
+	}
+
+	file, err := svc.Render(context.Background(), conversationDomain.UserContext{UserID: "u1"}, "conv-1", transcriptDomain.FormatPDF)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if file.ContentType != "application/pdf" || !bytes.HasPrefix(file.Data, []byte("%PDF-")) {
+		t.Errorf("Expected a PDF, got %s", file.ContentType)
+	}
+	if !bytes.Contains(file.Data, []byte("Only the most recent 2000 messages")) {
+		t.Errorf("Expected a truncation notice")
+	}
+	if bytes.Contains(file.Data, []byte(fmt.Sprintf("(message %d)", maxMessages))) {
+		t.Errorf("Expected the oldest messages left out")
+	}
+}
+
+func TestEmail(t *testing.T) {
+	mailer := &mockMailer{}
+	svc, convSvc := newTestService(mailer)
+	ctx := context.Background()
+	userCtx := conversationDomain.UserContext{UserID: "u1"}
+
+	delivery, err := svc.Email(ctx, userCtx, "conv-1", transcriptDomain.FormatPDF, transcriptDomain.RecipientContact)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if delivery.To != "ana@example.com" || mailer.sent[0].To != "ana@example.com" {
+		t.Errorf("Expected the contact's email variable, got %+v", delivery)
+	}
+	if a := mailer.sent[0].Attachments; len(a) != 1 || a[0].Filename != "transcript-conv-1.pdf" {
+		t.Errorf("Expected the PDF attached, got %+v", a)
+	}
+
+	if _, err := svc.Email(ctx, userCtx, "conv-1", transcriptDomain.FormatHTML, transcriptDomain.RecipientAgent); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if mailer.sent[1].To != "agent@example.com" {
+		t.Errorf("Expected the agent's address, got %s", mailer.sent[1].To)
+	}
+
+	convSvc.conv.Variables = nil
+	if _, err := svc.Email(ctx, userCtx, "conv-1", transcriptDomain.FormatPDF, transcriptDomain.RecipientContact); !errors.Is(err, ErrNoContactEmail) {
+		t.Errorf("Expected ErrNoContactEmail, got %v", err)
+	}
+	convSvc.conv.Channel, convSvc.conv.ExternalID = conversationDomain.ChannelEmail, "Ana <ana@mail.example.com>"
+	if delivery, err := svc.Email(ctx, userCtx, "conv-1", transcriptDomain.FormatPDF, transcriptDomain.RecipientContact); err != nil || delivery.To != "ana@mail.example.com" {
+		t.Errorf("Expected the email conversation's sender, got %+v (%v)", delivery, err)
+	}
+
+	if _, err := svc.Email(ctx, userCtx, "conv-1", transcriptDomain.FormatPDF, "someone"); !errors.Is(err, ErrInvalidRecipient) {
+		t.Errorf("Expected ErrInvalidRecipient, got %v", err)
+	}
+	mailer.err = errors.New("550 rejected")
+	if _, err := svc.Email(ctx, userCtx, "conv-1", transcriptDomain.FormatPDF, transcriptDomain.RecipientAgent); !errors.Is(err, ErrSendFailed) {
+		t.Errorf("Expected ErrSendFailed, got %v", err)
+	}
+}
+
+func TestEmailWithoutMailer(t *testing.T) {
+	svc, _ := newTestService(nil)
+	_, err := svc.Email(context.Background(), conversationDomain.UserContext{UserID: "u1"}, "conv-1", transcriptDomain.FormatPDF, transcriptDomain.RecipientAgent)
+	if !errors.Is(err, ErrMailUnavailable) {
+		t.Errorf("Expected ErrMailUnavailable, got %v", err)
+	}
+}
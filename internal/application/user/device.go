@@ -0,0 +1,68 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	mailpkg "github.com/elprogramadorgt/lucidRAG/pkg/mail"
+)
+
+const alertTimeout = 30 * time.Second
+
+// Mailer delivers an email and returns its Message-ID.
+type Mailer interface {
+	Send(ctx context.Context, msg mailpkg.Message) (string, error)
+}
+
+// DeviceAlerter emails admins when they sign in from a new device, since an
+// admin account can purge the whole knowledge base.
+type DeviceAlerter struct {
+	mailer Mailer
+	brand  string
+	log    *logger.Logger
+}
+
+// NewDeviceAlerter returns an alerter sending through mailer; brand names
+// the product in the subject.
+func NewDeviceAlerter(mailer Mailer, brand string, log *logger.Logger) *DeviceAlerter {
+	return &DeviceAlerter{mailer: mailer, brand: brand, log: log.With("subscriber", "device_alerter")}
+}
+
+func (a *DeviceAlerter) Subscribe(bus *events.Bus) {
+	bus.Subscribe(a.handle, events.NameAdminNewDevice)
+}
+
+func (a *DeviceAlerter) handle(ctx context.Context, event events.Event) {
+	sighting, ok := event.(events.AdminNewDevice)
+	if !ok || sighting.Email == "" {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), alertTimeout)
+		defer cancel()
+		if _, err := a.mailer.Send(ctx, a.message(sighting)); err != nil {
+			a.log.ErrorContext(ctx, "failed to send new device alert", "error", err, "user_id", sighting.UserID)
+		}
+	}()
+}
+
+func (a *DeviceAlerter) message(e events.AdminNewDevice) mailpkg.Message {
+	subject := "New sign-in to your admin account"
+	if a.brand != "" {
+		subject += " - " + a.brand
+	}
+	body := fmt.Sprintf(`Your admin account %s just signed in from a device it has not used before.
+
+Time: %s
+IP address: %s
+Browser: %s
+
+If this was you, there is nothing to do. If not, sign the session out under
+Active sessions and change your password right away.
+`, e.Email, e.At.UTC().Format("2006-01-02 15:04 UTC"), e.IP, e.UserAgent)
+	return mailpkg.Message{To: e.Email, Subject: subject, Body: body}
+}
@@ -0,0 +1,62 @@
+package user
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	mailpkg "github.com/elprogramadorgt/lucidRAG/pkg/mail"
+)
+
+type mockMailer struct {
+	sent chan mailpkg.Message
+}
+
+func (m *mockMailer) Send(ctx context.Context, msg mailpkg.Message) (string, error) {
+	m.sent <- msg
+	return "<id@example.com>", nil
+}
+
+func TestNewDevice(t *testing.T) {
+	a := NewDevice("Firefox", "device-1", "203.0.113.7")
+	if a.Fingerprint == "" || a.Fingerprint != NewDevice("Firefox", "device-1", "198.51.100.1").Fingerprint {
+		t.Error("Expected the fingerprint to ignore the IP")
+	}
+	if a.Fingerprint == NewDevice("Firefox", "device-2", "203.0.113.7").Fingerprint {
+		t.Error("Expected device IDs to set devices apart")
+	}
+	if a.Fingerprint == NewDevice("Chrome", "device-1", "203.0.113.7").Fingerprint {
+		t.Error("Expected user agents to set devices apart")
+	}
+	if got := NewDevice(strings.Repeat("x", 2000), "", "").UserAgent; len(got) != maxUserAgent {
+		t.Errorf("Expected the user agent capped at %d, got %d", maxUserAgent, len(got))
+	}
+}
+
+func TestDeviceAlerter(t *testing.T) {
+	mailer := &mockMailer{sent: make(chan mailpkg.Message, 1)}
+	bus := events.NewBus()
+	NewDeviceAlerter(mailer, "lucidRAG", logger.New(logger.Options{Level: "error"})).Subscribe(bus)
+
+	bus.Publish(context.Background(), events.AdminNewDevice{
+		UserID: "admin-1", Email: "admin@example.com", IP: "203.0.113.7", UserAgent: "Firefox",
+		At: time.Date(2024, 3, 13, 10, 0, 0, 0, time.UTC),
+	})
+
+	select {
+	case msg := <-mailer.sent:
+		if msg.To != "admin@example.com" || !strings.Contains(msg.Subject, "lucidRAG") {
+			t.Errorf("Unexpected message %+v", msg)
+		}
+		for _, want := range []string{"203.0.113.7", "Firefox", "2024-03-13 10:00 UTC"} {
+			if !strings.Contains(msg.Body, want) {
+				t.Errorf("Expected the body to mention %q, got %q", want, msg.Body)
+			}
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected an alert sent")
+	}
+}
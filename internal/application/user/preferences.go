@@ -0,0 +1,97 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	userDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/user"
+)
+
+var ErrInvalidPreferences = errors.New("invalid preferences")
+
+// GetPreferences returns the user's saved preferences, or the defaults when
+// none were saved yet. The defaults use the workspace time zone.
+func (s *service) GetPreferences(ctx context.Context, userID string) (*userDomain.Preferences, error) {
+	if s.prefsRepo == nil {
+		return s.defaultPreferences(userID), nil
+	}
+
+	prefs, err := s.prefsRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if prefs == nil {
+		return s.defaultPreferences(userID), nil
+	}
+	return prefs, nil
+}
+
+func (s *service) defaultPreferences(userID string) *userDomain.Preferences {
+	prefs := userDomain.DefaultPreferences(userID)
+	if s.timezone != "" {
+		prefs.Timezone = s.timezone
+	}
+	return prefs
+}
+
+func (s *service) UpdatePreferences(ctx context.Context, prefs *userDomain.Preferences) (*userDomain.Preferences, error) {
+	if err := validatePreferences(prefs); err != nil {
+		return nil, err
+	}
+	if s.prefsRepo == nil {
+		return nil, ErrInvalidPreferences
+	}
+
+	if err := s.prefsRepo.Upsert(ctx, prefs); err != nil {
+		return nil, err
+	}
+	return prefs, nil
+}
+
+func validatePreferences(prefs *userDomain.Preferences) error {
+	if prefs.UserID == "" {
+		return ErrInvalidPreferences
+	}
+
+	supported := false
+	for _, lang := range userDomain.SupportedLanguages {
+		if prefs.Language == lang {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return ErrInvalidPreferences
+	}
+
+	if prefs.Timezone == "" {
+		return ErrInvalidPreferences
+	}
+	if _, err := time.LoadLocation(prefs.Timezone); err != nil {
+		return ErrInvalidPreferences
+	}
+
+	switch prefs.DigestFrequency {
+	case userDomain.DigestNever, userDomain.DigestDaily, userDomain.DigestWeekly:
+	default:
+		return ErrInvalidPreferences
+	}
+
+	seen := make(map[userDomain.NotificationChannel]bool, len(prefs.NotificationChannels))
+	channels := make([]userDomain.NotificationChannel, 0, len(prefs.NotificationChannels))
+	for _, ch := range prefs.NotificationChannels {
+		switch ch {
+		case userDomain.NotificationEmail, userDomain.NotificationInApp, userDomain.NotificationWhatsApp:
+		default:
+			return ErrInvalidPreferences
+		}
+		if !seen[ch] {
+			seen[ch] = true
+			channels = append(channels, ch)
+		}
+	}
+	prefs.NotificationChannels = channels
+
+	return nil
+}
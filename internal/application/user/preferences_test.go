@@ -0,0 +1,126 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	userDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/user"
+)
+
+type mockPreferencesRepo struct {
+	prefs map[string]*userDomain.Preferences
+}
+
+func (m *mockPreferencesRepo) Get(ctx context.Context, userID string) (*userDomain.Preferences, error) {
+	return m.prefs[userID], nil
+}
+
+func (m *mockPreferencesRepo) Upsert(ctx context.Context, prefs *userDomain.Preferences) error {
+	m.prefs[prefs.UserID] = prefs
+	return nil
+}
+
+func newPreferencesService() (userDomain.Service, *mockPreferencesRepo) {
+	repo := &mockPreferencesRepo{prefs: make(map[string]*userDomain.Preferences)}
+	svc := NewService(ServiceConfig{
+		Repo:            newMockUserRepo(),
+		PreferencesRepo: repo,
+		JWTSecret:       "test-secret-key-that-is-long-enough",
+	})
+	return svc, repo
+}
+
+func TestGetPreferencesDefaults(t *testing.T) {
+	svc, _ := newPreferencesService()
+
+	prefs, err := svc.GetPreferences(context.Background(), "user-1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if prefs.UserID != "user-1" || prefs.Language != "en" || prefs.DigestFrequency != userDomain.DigestDaily {
+		t.Errorf("Expected default preferences, got %+v", prefs)
+	}
+	if !prefs.WantsChannel(userDomain.NotificationEmail) {
+		t.Error("Expected email notifications to be enabled by default")
+	}
+}
+
+func TestGetPreferencesDefaultTimezone(t *testing.T) {
+	repo := &mockPreferencesRepo{prefs: map[string]*userDomain.Preferences{
+		"user-2": {UserID: "user-2", Timezone: "Asia/Tokyo"},
+	}}
+	svc := NewService(ServiceConfig{
+		Repo:            newMockUserRepo(),
+		PreferencesRepo: repo,
+		JWTSecret:       "test-secret-key-that-is-long-enough",
+		DefaultTimezone: "America/Guatemala",
+	})
+
+	prefs, _ := svc.GetPreferences(context.Background(), "user-1")
+	if prefs.Timezone != "America/Guatemala" {
+		t.Errorf("Expected the workspace time zone for new users, got %s", prefs.Timezone)
+	}
+	prefs, _ = svc.GetPreferences(context.Background(), "user-2")
+	if prefs.Timezone != "Asia/Tokyo" {
+		t.Errorf("Expected the saved time zone to win, got %s", prefs.Timezone)
+	}
+}
+
+func TestUpdatePreferences(t *testing.T) {
+	svc, repo := newPreferencesService()
+	ctx := context.Background()
+
+	prefs := userDomain.DefaultPreferences("user-1")
+	prefs.Language = "es"
+	prefs.Timezone = "America/Guatemala"
+	prefs.NotificationChannels = []userDomain.NotificationChannel{
+		userDomain.NotificationInApp, userDomain.NotificationInApp, userDomain.NotificationWhatsApp,
+	}
+	prefs.DigestFrequency = userDomain.DigestWeekly
+
+	if _, err := svc.UpdatePreferences(ctx, prefs); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	saved := repo.prefs["user-1"]
+	if saved == nil {
+		t.Fatal("Expected preferences to be stored")
+	}
+	if len(saved.NotificationChannels) != 2 {
+		t.Errorf("Expected duplicate channels to be removed, got %v", saved.NotificationChannels)
+	}
+	if saved.Location().String() != "America/Guatemala" {
+		t.Errorf("Expected America/Guatemala location, got %s", saved.Location())
+	}
+
+	got, _ := svc.GetPreferences(ctx, "user-1")
+	if got.Language != "es" {
+		t.Errorf("Expected saved language es, got %s", got.Language)
+	}
+}
+
+func TestUpdatePreferencesValidation(t *testing.T) {
+	svc, _ := newPreferencesService()
+
+	tests := []struct {
+		name   string
+		mutate func(p *userDomain.Preferences)
+	}{
+		{"language", func(p *userDomain.Preferences) { p.Language = "xx" }},
+		{"timezone", func(p *userDomain.Preferences) { p.Timezone = "Mars/Olympus" }},
+		{"empty timezone", func(p *userDomain.Preferences) { p.Timezone = "" }},
+		{"digest", func(p *userDomain.Preferences) { p.DigestFrequency = "hourly" }},
+		{"channel", func(p *userDomain.Preferences) {
+			p.NotificationChannels = []userDomain.NotificationChannel{"pager"}
+		}},
+	}
+
+	for _, tt := range tests {
+		prefs := userDomain.DefaultPreferences("user-1")
+		tt.mutate(prefs)
+		if _, err := svc.UpdatePreferences(context.Background(), prefs); !errors.Is(err, ErrInvalidPreferences) {
+			t.Errorf("%s: expected ErrInvalidPreferences, got %v", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/pkg/cache"
+	"github.com/elprogramadorgt/lucidRAG/pkg/captcha"
+)
+
+// registrationWindow is the window the per-IP and per-domain registration
+// limits count over.
+const registrationWindow = time.Hour
+
+var (
+	// ErrHoneypot is a registration that filled in the hidden honeypot
+	// field, which only bots see.
+	ErrHoneypot             = errors.New("registration honeypot filled in")
+	ErrCaptchaFailed        = errors.New("captcha verification failed")
+	ErrTooManyRegistrations = errors.New("too many registrations")
+)
+
+// CaptchaVerifier checks the response a captcha widget gave a visitor.
+type CaptchaVerifier interface {
+	Provider() string
+	Verify(ctx context.Context, token, remoteIP string) error
+}
+
+// freeMailDomains are shared by so many people that a per-domain limit on
+// them would let anyone lock everyone else out of signing up.
+var freeMailDomains = map[string]bool{
+	"gmail.com": true, "googlemail.com": true, "outlook.com": true, "hotmail.com": true,
+	"live.com": true, "msn.com": true, "yahoo.com": true, "icloud.com": true, "me.com": true,
+	"aol.com": true, "proton.me": true, "protonmail.com": true, "gmx.com": true, "gmx.net": true,
+	"mail.com": true, "yandex.com": true, "zoho.com": true,
+}
+
+// RegistrationAttempt is what a sign-up form sends besides the account.
+type RegistrationAttempt struct {
+	Email        string
+	IP           string
+	CaptchaToken string
+	// Honeypot is the hidden field people leave empty.
+	Honeypot string
+}
+
+// CaptchaSettings tells the sign-up form which captcha to show, if any.
+type CaptchaSettings struct {
+	Provider string `json:"provider,omitempty"`
+	SiteKey  string `json:"site_key,omitempty"`
+}
+
+// RegistrationGuard keeps bots away from open registration: it refuses
+// filled-in honeypots, checks a captcha when one is set up and limits
+// sign-ups per IP and per email domain each hour.
+type RegistrationGuard struct {
+	cache     cache.Cache
+	captcha   CaptchaVerifier
+	siteKey   string
+	perIP     int
+	perDomain int
+	now       func() time.Time
+}
+
+type RegistrationGuardConfig struct {
+	Cache cache.Cache
+	// Captcha is nil when sign-ups need no captcha. SiteKey is handed to
+	// the form to render its widget.
+	Captcha CaptchaVerifier
+	SiteKey string
+	// PerIP and PerDomain cap registration attempts an hour from one IP and
+	// for one email domain; zero turns a limit off.
+	PerIP     int
+	PerDomain int
+}
+
+func NewRegistrationGuard(cfg RegistrationGuardConfig) *RegistrationGuard {
+	return &RegistrationGuard{
+		cache:     cfg.Cache,
+		captcha:   cfg.Captcha,
+		siteKey:   cfg.SiteKey,
+		perIP:     cfg.PerIP,
+		perDomain: cfg.PerDomain,
+		now:       time.Now,
+	}
+}
+
+// Captcha returns the captcha the sign-up form should show.
+func (g *RegistrationGuard) Captcha() CaptchaSettings {
+	if g.captcha == nil {
+		return CaptchaSettings{}
+	}
+	return CaptchaSettings{Provider: g.captcha.Provider(), SiteKey: g.siteKey}
+}
+
+// Check decides whether a registration attempt may go ahead. Attempts
+// count towards the IP limit before the captcha is checked, so solved
+// captchas do not buy extra sign-ups. They count towards the domain limit
+// only once the captcha passes, so junk attempts cannot use up a domain's
+// quota, and free-mail domains have no limit. A cache outage lifts the
+// limits.
+func (g *RegistrationGuard) Check(ctx context.Context, attempt RegistrationAttempt) error {
+	if strings.TrimSpace(attempt.Honeypot) != "" {
+		return ErrHoneypot
+	}
+
+	if g.exceeded(ctx, "ip:"+attempt.IP, g.perIP) {
+		return fmt.Errorf("%w from this IP", ErrTooManyRegistrations)
+	}
+
+	if g.captcha != nil {
+		if err := g.captcha.Verify(ctx, attempt.CaptchaToken, attempt.IP); err != nil {
+			if errors.Is(err, captcha.ErrFailed) {
+				return ErrCaptchaFailed
+			}
+			return err
+		}
+	}
+
+	_, domain, _ := strings.Cut(strings.ToLower(strings.TrimSpace(attempt.Email)), "@")
+	if domain != "" && !freeMailDomains[domain] && g.exceeded(ctx, "domain:"+domain, g.perDomain) {
+		return fmt.Errorf("%w for %s", ErrTooManyRegistrations, domain)
+	}
+	return nil
+}
+
+func (g *RegistrationGuard) exceeded(ctx context.Context, key string, limit int) bool {
+	if limit <= 0 || g.cache == nil {
+		return false
+	}
+	bucket := g.now().UnixNano() / int64(registrationWindow)
+	n, err := g.cache.Incr(ctx, fmt.Sprintf("register:%s:%d", key, bucket), registrationWindow)
+	return err == nil && n > int64(limit)
+}
@@ -0,0 +1,129 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/elprogramadorgt/lucidRAG/pkg/cache"
+	"github.com/elprogramadorgt/lucidRAG/pkg/captcha"
+)
+
+type mockCaptcha struct {
+	err   error
+	calls int
+}
+
+func (m *mockCaptcha) Provider() string { return captcha.Turnstile }
+
+func (m *mockCaptcha) Verify(ctx context.Context, token, remoteIP string) error {
+	m.calls++
+	if token != "solved" {
+		return fmt.Errorf("%w: invalid-input-response", captcha.ErrFailed)
+	}
+	return m.err
+}
+
+func newTestRegistrationGuard(t *testing.T, cfg RegistrationGuardConfig) *RegistrationGuard {
+	t.Helper()
+	mem := cache.NewMemory()
+	t.Cleanup(mem.Stop)
+	cfg.Cache = mem
+	return NewRegistrationGuard(cfg)
+}
+
+func TestRegistrationGuardHoneypot(t *testing.T) {
+	verifier := &mockCaptcha{}
+	g := newTestRegistrationGuard(t, RegistrationGuardConfig{Captcha: verifier, PerIP: 1})
+	ctx := context.Background()
+
+	err := g.Check(ctx, RegistrationAttempt{Email: "bot@example.com", IP: "203.0.113.7", Honeypot: "https://spam.example"})
+	if !errors.Is(err, ErrHoneypot) {
+		t.Errorf("Expected ErrHoneypot, got %v", err)
+	}
+	if verifier.calls != 0 {
+		t.Error("Expected no captcha check for a filled-in honeypot")
+	}
+	if err := g.Check(ctx, RegistrationAttempt{Email: "ana@example.com", IP: "203.0.113.7", CaptchaToken: "solved"}); err != nil {
+		t.Errorf("Expected honeypot hits not to count towards the limits, got %v", err)
+	}
+}
+
+func TestRegistrationGuardCaptcha(t *testing.T) {
+	verifier := &mockCaptcha{}
+	g := newTestRegistrationGuard(t, RegistrationGuardConfig{Captcha: verifier, SiteKey: "site-key"})
+	ctx := context.Background()
+
+	if got := g.Captcha(); got.Provider != captcha.Turnstile || got.SiteKey != "site-key" {
+		t.Errorf("Unexpected captcha settings %+v", got)
+	}
+	if err := g.Check(ctx, RegistrationAttempt{Email: "ana@example.com", IP: "203.0.113.7"}); !errors.Is(err, ErrCaptchaFailed) {
+		t.Errorf("Expected ErrCaptchaFailed without a captcha, got %v", err)
+	}
+	if err := g.Check(ctx, RegistrationAttempt{Email: "ana@example.com", IP: "203.0.113.7", CaptchaToken: "solved"}); err != nil {
+		t.Errorf("Expected a solved captcha accepted, got %v", err)
+	}
+
+	verifier.err = errors.New("siteverify returned status 502")
+	err := g.Check(ctx, RegistrationAttempt{Email: "ana@example.com", IP: "203.0.113.7", CaptchaToken: "solved"})
+	if err == nil || errors.Is(err, ErrCaptchaFailed) {
+		t.Errorf("Expected a provider outage reported as such, got %v", err)
+	}
+
+	if got := NewRegistrationGuard(RegistrationGuardConfig{}).Captcha(); got.Provider != "" {
+		t.Errorf("Expected no captcha when none is set up, got %+v", got)
+	}
+}
+
+func TestRegistrationGuardVelocity(t *testing.T) {
+	g := newTestRegistrationGuard(t, RegistrationGuardConfig{PerIP: 2, PerDomain: 3})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := g.Check(ctx, RegistrationAttempt{Email: fmt.Sprintf("u%d@spam.example", i), IP: "203.0.113.7"}); err != nil {
+			t.Fatalf("Expected attempt %d allowed, got %v", i+1, err)
+		}
+	}
+	if err := g.Check(ctx, RegistrationAttempt{Email: "u2@spam.example", IP: "203.0.113.7"}); !errors.Is(err, ErrTooManyRegistrations) {
+		t.Errorf("Expected the IP limited, got %v", err)
+	}
+
+	if err := g.Check(ctx, RegistrationAttempt{Email: "u3@Spam.Example", IP: "198.51.100.1"}); err != nil {
+		t.Fatalf("Expected another IP allowed, got %v", err)
+	}
+	if err := g.Check(ctx, RegistrationAttempt{Email: "u4@spam.example", IP: "198.51.100.2"}); !errors.Is(err, ErrTooManyRegistrations) {
+		t.Errorf("Expected the domain limited, got %v", err)
+	}
+	if err := g.Check(ctx, RegistrationAttempt{Email: "ana@example.com", IP: "198.51.100.3"}); err != nil {
+		t.Errorf("Expected other domains allowed, got %v", err)
+	}
+}
+
+func TestRegistrationGuardDomainQuota(t *testing.T) {
+	g := newTestRegistrationGuard(t, RegistrationGuardConfig{Captcha: &mockCaptcha{}, PerDomain: 2})
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		attempt := RegistrationAttempt{Email: fmt.Sprintf("junk%d@example.org", i), IP: fmt.Sprintf("203.0.113.%d", i)}
+		if err := g.Check(ctx, attempt); !errors.Is(err, ErrCaptchaFailed) {
+			t.Fatalf("Expected ErrCaptchaFailed, got %v", err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		attempt := RegistrationAttempt{Email: fmt.Sprintf("ana%d@example.org", i), IP: "198.51.100.1", CaptchaToken: "solved"}
+		if err := g.Check(ctx, attempt); err != nil {
+			t.Fatalf("Expected captcha failures not to use up the domain quota, got %v", err)
+		}
+	}
+	if err := g.Check(ctx, RegistrationAttempt{Email: "ana2@example.org", IP: "198.51.100.2", CaptchaToken: "solved"}); !errors.Is(err, ErrTooManyRegistrations) {
+		t.Errorf("Expected the domain limited, got %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		attempt := RegistrationAttempt{Email: fmt.Sprintf("u%d@Gmail.com", i), IP: fmt.Sprintf("192.0.2.%d", i), CaptchaToken: "solved"}
+		if err := g.Check(ctx, attempt); err != nil {
+			t.Errorf("Expected free-mail domains unlimited, got %v", err)
+		}
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	userDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/user"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+	"github.com/elprogramadorgt/lucidRAG/pkg/cache"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -15,6 +17,10 @@ var (
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrInvalidToken       = errors.New("invalid token")
 	ErrUserNotFound       = errors.New("user not found")
+	ErrSessionNotFound    = errors.New("session not found")
+	// ErrDeviceChanged is an admin token used from another device than it
+	// was issued to, with device binding on.
+	ErrDeviceChanged = errors.New("device changed")
 )
 
 type jwtClaims struct {
@@ -24,16 +30,47 @@ type jwtClaims struct {
 	jwt.RegisteredClaims
 }
 
+// userCacheTTL bounds how long a cached user lookup may be stale.
+const userCacheTTL = 5 * time.Minute
+
 type service struct {
-	repo      userDomain.Repository
-	jwtSecret []byte
-	jwtExpiry time.Duration
+	repo       userDomain.Repository
+	prefsRepo  userDomain.PreferencesRepository
+	sessions   userDomain.SessionRepository
+	devices    userDomain.DeviceRepository
+	cache      cache.Cache
+	jwtSecret  []byte
+	jwtExpiry  time.Duration
+	revalidate bool
+	events     *events.Bus
+	timezone   string
+	bindAdmin  bool
 }
 
 type ServiceConfig struct {
-	Repo      userDomain.Repository
-	JWTSecret string
-	JWTExpiry time.Duration
+	Repo            userDomain.Repository
+	PreferencesRepo userDomain.PreferencesRepository
+	Cache           cache.Cache
+	JWTSecret       string
+	JWTExpiry       time.Duration
+	// RevalidateTokens makes CheckSession confirm the user still exists, is
+	// active and has not revoked the token.
+	RevalidateTokens bool
+	Events           *events.Bus
+	// DefaultTimezone is the IANA time zone of users who never chose one.
+	// Empty means UTC.
+	DefaultTimezone string
+	// Sessions records a session per token issued, so users can see where
+	// they are signed in and sign devices out. Without it tokens are only
+	// revoked all at once.
+	Sessions userDomain.SessionRepository
+	// BindAdminDevices ties admin sessions to the device they started on.
+	// A token used from another device ends its session, and the admin
+	// signs in again. It needs Sessions.
+	BindAdminDevices bool
+	// Devices remembers the devices admins sign in from; a sign-in from a
+	// new one publishes AdminNewDevice.
+	Devices userDomain.DeviceRepository
 }
 
 func NewService(cfg ServiceConfig) userDomain.Service {
@@ -43,9 +80,17 @@ func NewService(cfg ServiceConfig) userDomain.Service {
 	}
 
 	return &service{
-		repo:      cfg.Repo,
-		jwtSecret: []byte(cfg.JWTSecret),
-		jwtExpiry: expiry,
+		repo:       cfg.Repo,
+		prefsRepo:  cfg.PreferencesRepo,
+		sessions:   cfg.Sessions,
+		devices:    cfg.Devices,
+		cache:      cfg.Cache,
+		jwtSecret:  []byte(cfg.JWTSecret),
+		jwtExpiry:  expiry,
+		revalidate: cfg.RevalidateTokens,
+		events:     cfg.Events,
+		timezone:   cfg.DefaultTimezone,
+		bindAdmin:  cfg.BindAdminDevices,
 	}
 }
 
@@ -77,6 +122,7 @@ func (s *service) Register(ctx context.Context, newUser userDomain.User) (*userD
 	}
 	user.ID = id
 
+	s.events.Publish(ctx, events.UserRegistered{UserID: id, Email: user.Email})
 	return user, nil
 }
 
@@ -94,26 +140,25 @@ func (s *service) Login(ctx context.Context, email, password string) (string, *u
 		return "", nil, ErrInvalidCredentials
 	}
 
-	claims := &jwtClaims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Role:   string(user.Role),
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.jwtExpiry)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Subject:   user.ID,
-		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenStr, err := token.SignedString(s.jwtSecret)
+	token, err := s.GenerateToken(ctx, user)
 	if err != nil {
 		return "", nil, err
 	}
-	return tokenStr, user, nil
+	return token, user, nil
 }
 
+// GetUser looks the user up through the cache when one is configured. Cached
+// users omit secrets such as the password hash, so code that writes users back
+// must load them from the repository instead.
 func (s *service) GetUser(ctx context.Context, id string) (*userDomain.User, error) {
+	key := "user:" + id
+	if s.cache != nil {
+		var cached userDomain.User
+		if ok, _ := cache.GetJSON(ctx, s.cache, key, &cached); ok {
+			return &cached, nil
+		}
+	}
+
 	user, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -121,6 +166,10 @@ func (s *service) GetUser(ctx context.Context, id string) (*userDomain.User, err
 	if user == nil {
 		return nil, ErrUserNotFound
 	}
+
+	if s.cache != nil {
+		_ = cache.SetJSON(ctx, s.cache, key, user, userCacheTTL)
+	}
 	return user, nil
 }
 
@@ -137,11 +186,17 @@ func (s *service) ValidateToken(tokenString string) (*userDomain.Claims, error)
 	}
 
 	if claims, ok := token.Claims.(*jwtClaims); ok && token.Valid {
-		return &userDomain.Claims{
+		result := &userDomain.Claims{
 			UserID: claims.UserID,
 			Email:  claims.Email,
 			Role:   claims.Role,
-		}, nil
+			// Tokens issued before sessions were recorded have none.
+			SessionID: claims.ID,
+		}
+		if claims.IssuedAt != nil {
+			result.IssuedAt = claims.IssuedAt.Time
+		}
+		return result, nil
 	}
 
 	return nil, ErrInvalidToken
@@ -185,20 +240,43 @@ func (s *service) RegisterOAuth(ctx context.Context, newUser userDomain.User, pr
 	}
 	user.ID = id
 
+	s.events.Publish(ctx, events.UserRegistered{UserID: id, Email: user.Email, Provider: provider})
 	return user, nil
 }
 
-func (s *service) GenerateToken(user *userDomain.User) (string, error) {
+// GenerateToken signs a token for user and, when sessions are recorded,
+// starts a session for it from the device in ctx.
+func (s *service) GenerateToken(ctx context.Context, user *userDomain.User) (string, error) {
+	now := time.Now()
 	claims := &jwtClaims{
 		UserID: user.ID,
 		Email:  user.Email,
 		Role:   string(user.Role),
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.jwtExpiry)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.jwtExpiry)),
+			IssuedAt:  jwt.NewNumericDate(now),
 			Subject:   user.ID,
 		},
 	}
+	device := DeviceFromContext(ctx)
+	if s.sessions != nil {
+		session := &userDomain.Session{
+			UserID:      user.ID,
+			UserAgent:   device.UserAgent,
+			IP:          device.IP,
+			CreatedAt:   now,
+			LastUsedAt:  now,
+			ExpiresAt:   now.Add(s.jwtExpiry),
+			Fingerprint: device.Fingerprint,
+		}
+		if err := s.sessions.Create(ctx, session); err != nil {
+			return "", err
+		}
+		claims.ID = session.ID
+	}
+	if user.Role == userDomain.RoleAdmin {
+		s.noteAdminDevice(ctx, user, device, now)
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(s.jwtSecret)
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	userDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/user"
+	"github.com/elprogramadorgt/lucidRAG/pkg/cache"
 )
 
 // mockUserRepo is a mock implementation of the user repository
@@ -63,6 +64,16 @@ func (m *mockUserRepo) Update(ctx context.Context, user *userDomain.User) error
 	return nil
 }
 
+func (m *mockUserRepo) ListByRole(ctx context.Context, role userDomain.Role) ([]userDomain.User, error) {
+	var users []userDomain.User
+	for _, user := range m.users {
+		if user.Role == role && user.IsActive {
+			users = append(users, *user)
+		}
+	}
+	return users, nil
+}
+
 func (m *mockUserRepo) Delete(ctx context.Context, id string) error {
 	if user, exists := m.users[id]; exists {
 		delete(m.emailIndex, user.Email)
@@ -357,6 +368,41 @@ func TestGetUser(t *testing.T) {
 	}
 }
 
+func TestGetUserUsesCache(t *testing.T) {
+	repo := newMockUserRepo()
+	c := cache.NewMemory()
+	defer c.Stop()
+	svc := NewService(ServiceConfig{
+		Repo:      repo,
+		Cache:     c,
+		JWTSecret: "test-secret-key-that-is-long-enough",
+	})
+
+	ctx := context.Background()
+	registered, err := svc.Register(ctx, userDomain.User{Email: "cached@example.com", PasswordHash: "password123"})
+	if err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+
+	if _, err := svc.GetUser(ctx, registered.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Remove from the repository; the cached copy should still be served.
+	_ = repo.Delete(ctx, registered.ID)
+
+	user, err := svc.GetUser(ctx, registered.ID)
+	if err != nil {
+		t.Fatalf("Expected cached user, got %v", err)
+	}
+	if user.Email != "cached@example.com" {
+		t.Errorf("Expected email cached@example.com, got %s", user.Email)
+	}
+	if user.PasswordHash != "" {
+		t.Error("Expected cached user to omit the password hash")
+	}
+}
+
 func TestGetUserNotFound(t *testing.T) {
 	repo := newMockUserRepo()
 	svc := NewService(ServiceConfig{
@@ -515,7 +561,7 @@ func TestGenerateToken(t *testing.T) {
 		Role:  userDomain.RoleAdmin,
 	}
 
-	token, err := svc.GenerateToken(user)
+	token, err := svc.GenerateToken(context.Background(), user)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
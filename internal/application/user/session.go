@@ -0,0 +1,310 @@
+package user
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	userDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/user"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+	"github.com/elprogramadorgt/lucidRAG/pkg/cache"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// sessionCacheTTL bounds how long a revocation or deactivation may go unseen
+// by replicas that do not share the cache.
+const sessionCacheTTL = time.Minute
+
+const (
+	// maxUserAgent caps the user agent stored with a session.
+	maxUserAgent = 512
+	maxDeviceID  = 128
+)
+
+// sessionState is the part of a user that CheckSession needs, cached apart
+// from the user itself because the revocation timestamp is not serialized.
+type sessionState struct {
+	IsActive      bool       `json:"is_active"`
+	InvalidBefore *time.Time `json:"invalid_before,omitempty"`
+}
+
+// Device is the client a session is started from.
+type Device struct {
+	UserAgent string
+	IP        string
+	// Fingerprint identifies the device across IP changes.
+	Fingerprint string
+}
+
+// NewDevice describes the client sending userAgent from ip. deviceID is the
+// random ID a browser keeps for itself, if it sends one; it sets apart
+// devices that share a user agent.
+func NewDevice(userAgent, deviceID, ip string) Device {
+	if len(userAgent) > maxUserAgent {
+		userAgent = userAgent[:maxUserAgent]
+	}
+	if len(deviceID) > maxDeviceID {
+		deviceID = deviceID[:maxDeviceID]
+	}
+	sum := sha256.Sum256([]byte(userAgent + "\n" + deviceID))
+	return Device{UserAgent: userAgent, IP: ip, Fingerprint: hex.EncodeToString(sum[:16])}
+}
+
+type deviceKey struct{}
+
+// ContextWithDevice records the device signing in, for the session
+// GenerateToken starts.
+func ContextWithDevice(ctx context.Context, device Device) context.Context {
+	return context.WithValue(ctx, deviceKey{}, device)
+}
+
+func DeviceFromContext(ctx context.Context) Device {
+	device, _ := ctx.Value(deviceKey{}).(Device)
+	return device
+}
+
+// CheckSession rejects claims whose session was revoked. With token
+// revalidation enabled, it also rejects claims whose user was deleted or
+// deactivated, or revoked their tokens after the claims were issued.
+func (s *service) CheckSession(ctx context.Context, claims *userDomain.Claims) error {
+	if claims.SessionID != "" && s.sessions != nil {
+		if err := s.checkSessionRecord(ctx, claims); err != nil {
+			return err
+		}
+	}
+	if !s.revalidate {
+		return nil
+	}
+
+	state, err := s.sessionState(ctx, claims.UserID)
+	if err != nil {
+		return err
+	}
+	if state == nil || !state.IsActive {
+		return ErrInvalidToken
+	}
+	if state.InvalidBefore != nil && claims.IssuedAt.Before(*state.InvalidBefore) {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+func (s *service) sessionState(ctx context.Context, userID string) (*sessionState, error) {
+	key := "session:" + userID
+	if s.cache != nil {
+		var cached sessionState
+		if ok, _ := cache.GetJSON(ctx, s.cache, key, &cached); ok {
+			return &cached, nil
+		}
+	}
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, nil
+	}
+
+	state := &sessionState{IsActive: user.IsActive, InvalidBefore: user.TokenInvalidBefore}
+	if s.cache != nil {
+		_ = cache.SetJSON(ctx, s.cache, key, state, sessionCacheTTL)
+	}
+	return state, nil
+}
+
+// checkSessionRecord confirms the claims' session still exists and, for a
+// device-bound admin session, that it is used from the device in ctx.
+// Lookups are cached like session state, and a session's last use is
+// recorded whenever the cache has to ask the database, so about once a
+// minute.
+func (s *service) checkSessionRecord(ctx context.Context, claims *userDomain.Claims) error {
+	key := "auth_session:" + claims.SessionID
+	bound := s.bindAdmin && claims.Role == string(userDomain.RoleAdmin)
+	fingerprint := DeviceFromContext(ctx).Fingerprint
+	if s.cache != nil {
+		if cached, err := s.cache.Get(ctx, key); err == nil && (!bound || len(cached) == 0 || string(cached) == fingerprint) {
+			return nil
+		}
+	}
+
+	session, err := s.sessions.Get(ctx, claims.SessionID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if session == nil || session.UserID != claims.UserID || !now.Before(session.ExpiresAt) {
+		return ErrInvalidToken
+	}
+	if session.Fingerprint == "" && fingerprint != "" {
+		if err := s.bindSession(ctx, claims, session, now); err != nil {
+			return err
+		}
+	}
+	if bound && session.Fingerprint != "" && session.Fingerprint != fingerprint {
+		if _, err := s.sessions.Delete(ctx, session.UserID, session.ID); err != nil {
+			return err
+		}
+		if s.cache != nil {
+			_ = s.cache.Delete(ctx, key)
+		}
+		return ErrDeviceChanged
+	}
+	_ = s.sessions.Touch(ctx, session.ID, now)
+	if s.cache != nil {
+		_ = s.cache.Set(ctx, key, []byte(session.Fingerprint), sessionCacheTTL)
+	}
+	return nil
+}
+
+// bindSession ties a session started without a device fingerprint, such as
+// one from an OAuth redirect, to the device in ctx, and treats that as the
+// admin's sign-in device. When another request bound it first, session
+// takes the stored fingerprint instead, so a different device is caught.
+func (s *service) bindSession(ctx context.Context, claims *userDomain.Claims, session *userDomain.Session, now time.Time) error {
+	device := DeviceFromContext(ctx)
+	bound, err := s.sessions.Bind(ctx, session.ID, device.Fingerprint)
+	if err != nil {
+		return err
+	}
+	if !bound {
+		stored, err := s.sessions.Get(ctx, session.ID)
+		if err != nil {
+			return err
+		}
+		if stored == nil {
+			return ErrInvalidToken
+		}
+		session.Fingerprint = stored.Fingerprint
+		return nil
+	}
+	session.Fingerprint = device.Fingerprint
+	if claims.Role == string(userDomain.RoleAdmin) {
+		s.noteAdminDevice(ctx, &userDomain.User{ID: claims.UserID, Email: claims.Email}, device, now)
+	}
+	return nil
+}
+
+// noteAdminDevice remembers the device an admin signed in from and
+// publishes AdminNewDevice the first time it is seen. Failing to remember
+// it does not fail the sign-in.
+func (s *service) noteAdminDevice(ctx context.Context, user *userDomain.User, device Device, at time.Time) {
+	if s.devices == nil || device.Fingerprint == "" {
+		return
+	}
+	known := &userDomain.KnownDevice{UserID: user.ID, Fingerprint: device.Fingerprint, UserAgent: device.UserAgent, IP: device.IP}
+	isNew, err := s.devices.Seen(ctx, known, at)
+	if err != nil || !isNew {
+		return
+	}
+	s.events.Publish(ctx, events.AdminNewDevice{
+		UserID: user.ID, Email: user.Email, IP: device.IP, UserAgent: device.UserAgent, At: at,
+	})
+}
+
+func (s *service) ListSessions(ctx context.Context, userID, currentID string) ([]userDomain.Session, error) {
+	if s.sessions == nil {
+		return []userDomain.Session{}, nil
+	}
+	sessions, err := s.sessions.ListByUser(ctx, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs the user's session out. Replicas that do not share
+// the cache may accept its token for up to sessionCacheTTL.
+func (s *service) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	if s.sessions == nil {
+		return ErrSessionNotFound
+	}
+	deleted, err := s.sessions.Delete(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrSessionNotFound
+	}
+	if s.cache != nil {
+		_ = s.cache.Delete(ctx, "auth_session:"+sessionID)
+	}
+	return nil
+}
+
+// ChangePassword replaces the user's password and revokes every token issued
+// before the change.
+func (s *service) ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) error {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(currentPassword)); err != nil {
+		return ErrInvalidCredentials
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	user.PasswordHash = string(hash)
+
+	return s.saveRevoked(ctx, user)
+}
+
+// SetActive activates or deactivates a user. Deactivation also revokes the
+// user's outstanding tokens.
+func (s *service) SetActive(ctx context.Context, userID string, active bool) (*userDomain.User, error) {
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	user.IsActive = active
+	if !active {
+		err = s.saveRevoked(ctx, user)
+	} else {
+		err = s.save(ctx, user)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// saveRevoked stores the user with tokens issued before now revoked, and
+// ends their sessions. Token issue times have second precision, so the
+// cutoff is truncated to keep a token issued right after the change valid.
+func (s *service) saveRevoked(ctx context.Context, user *userDomain.User) error {
+	cutoff := time.Now().Truncate(time.Second)
+	user.TokenInvalidBefore = &cutoff
+	if err := s.save(ctx, user); err != nil {
+		return err
+	}
+	if s.sessions == nil {
+		return nil
+	}
+	return s.sessions.DeleteByUser(ctx, user.ID)
+}
+
+func (s *service) save(ctx context.Context, user *userDomain.User) error {
+	if err := s.repo.Update(ctx, user); err != nil {
+		return err
+	}
+	if s.cache != nil {
+		_ = s.cache.Delete(ctx, "user:"+user.ID)
+		_ = s.cache.Delete(ctx, "session:"+user.ID)
+	}
+	return nil
+}
@@ -0,0 +1,457 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	userDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/user"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+	"github.com/elprogramadorgt/lucidRAG/pkg/cache"
+)
+
+func newSessionService(t *testing.T, revalidate bool) (userDomain.Service, *userDomain.User) {
+	t.Helper()
+	mem := cache.NewMemory()
+	t.Cleanup(mem.Stop)
+
+	svc := NewService(ServiceConfig{
+		Repo:             newMockUserRepo(),
+		Cache:            mem,
+		JWTSecret:        "test-secret-key-that-is-long-enough",
+		RevalidateTokens: revalidate,
+	})
+
+	user, err := svc.Register(context.Background(), userDomain.User{
+		Email:        "test@example.com",
+		PasswordHash: "password123",
+		FirstName:    "Test",
+		LastName:     "User",
+	})
+	if err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+	return svc, user
+}
+
+func TestCheckSessionDisabled(t *testing.T) {
+	svc, _ := newSessionService(t, false)
+
+	claims := &userDomain.Claims{UserID: "missing-user"}
+	if err := svc.CheckSession(context.Background(), claims); err != nil {
+		t.Errorf("Expected no error when revalidation is disabled, got %v", err)
+	}
+}
+
+func TestCheckSessionUnknownUser(t *testing.T) {
+	svc, _ := newSessionService(t, true)
+
+	claims := &userDomain.Claims{UserID: "missing-user", IssuedAt: time.Now()}
+	if err := svc.CheckSession(context.Background(), claims); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken, got %v", err)
+	}
+}
+
+func TestCheckSessionDeactivatedUser(t *testing.T) {
+	svc, user := newSessionService(t, true)
+	ctx := context.Background()
+
+	claims := &userDomain.Claims{UserID: user.ID, IssuedAt: time.Now().Add(-time.Hour)}
+	if err := svc.CheckSession(ctx, claims); err != nil {
+		t.Fatalf("Expected active session, got %v", err)
+	}
+
+	if _, err := svc.SetActive(ctx, user.ID, false); err != nil {
+		t.Fatalf("Failed to deactivate user: %v", err)
+	}
+	if err := svc.CheckSession(ctx, claims); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken after deactivation, got %v", err)
+	}
+
+	if _, err := svc.SetActive(ctx, user.ID, true); err != nil {
+		t.Fatalf("Failed to reactivate user: %v", err)
+	}
+	if err := svc.CheckSession(ctx, claims); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected token issued before deactivation to stay revoked, got %v", err)
+	}
+
+	fresh := &userDomain.Claims{UserID: user.ID, IssuedAt: time.Now()}
+	if err := svc.CheckSession(ctx, fresh); err != nil {
+		t.Errorf("Expected token issued after reactivation to be valid, got %v", err)
+	}
+}
+
+func TestChangePasswordRevokesTokens(t *testing.T) {
+	svc, user := newSessionService(t, true)
+	ctx := context.Background()
+
+	if err := svc.ChangePassword(ctx, user.ID, "wrong-password", "newpassword123"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("Expected ErrInvalidCredentials, got %v", err)
+	}
+
+	if err := svc.ChangePassword(ctx, user.ID, "password123", "newpassword123"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	old := &userDomain.Claims{UserID: user.ID, IssuedAt: time.Now().Add(-time.Hour)}
+	if err := svc.CheckSession(ctx, old); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken for token issued before change, got %v", err)
+	}
+
+	if _, _, err := svc.Login(ctx, "test@example.com", "newpassword123"); err != nil {
+		t.Errorf("Expected login with new password to succeed, got %v", err)
+	}
+}
+
+type mockSessionRepo struct {
+	sessions map[string]userDomain.Session
+	nextID   int
+}
+
+func newMockSessionRepo() *mockSessionRepo {
+	return &mockSessionRepo{sessions: map[string]userDomain.Session{}}
+}
+
+func (m *mockSessionRepo) Create(ctx context.Context, s *userDomain.Session) error {
+	m.nextID++
+	s.ID = fmt.Sprintf("session-%d", m.nextID)
+	m.sessions[s.ID] = *s
+	return nil
+}
+
+func (m *mockSessionRepo) Get(ctx context.Context, id string) (*userDomain.Session, error) {
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+func (m *mockSessionRepo) ListByUser(ctx context.Context, userID string, now time.Time) ([]userDomain.Session, error) {
+	var sessions []userDomain.Session
+	for _, s := range m.sessions {
+		if s.UserID == userID && now.Before(s.ExpiresAt) {
+			sessions = append(sessions, s)
+		}
+	}
+	return sessions, nil
+}
+
+func (m *mockSessionRepo) Touch(ctx context.Context, id string, at time.Time) error {
+	if s, ok := m.sessions[id]; ok {
+		s.LastUsedAt = at
+		m.sessions[id] = s
+	}
+	return nil
+}
+
+func (m *mockSessionRepo) Bind(ctx context.Context, id, fingerprint string) (bool, error) {
+	s, ok := m.sessions[id]
+	if !ok || s.Fingerprint != "" {
+		return false, nil
+	}
+	s.Fingerprint = fingerprint
+	m.sessions[id] = s
+	return true, nil
+}
+
+func (m *mockSessionRepo) Delete(ctx context.Context, userID, id string) (bool, error) {
+	s, ok := m.sessions[id]
+	if !ok || s.UserID != userID {
+		return false, nil
+	}
+	delete(m.sessions, id)
+	return true, nil
+}
+
+func (m *mockSessionRepo) DeleteByUser(ctx context.Context, userID string) error {
+	for id, s := range m.sessions {
+		if s.UserID == userID {
+			delete(m.sessions, id)
+		}
+	}
+	return nil
+}
+
+func newRecordedSessionService(t *testing.T) (userDomain.Service, *mockSessionRepo) {
+	t.Helper()
+	mem := cache.NewMemory()
+	t.Cleanup(mem.Stop)
+
+	sessions := newMockSessionRepo()
+	svc := NewService(ServiceConfig{
+		Repo:      newMockUserRepo(),
+		Cache:     mem,
+		JWTSecret: "test-secret-key-that-is-long-enough",
+		Sessions:  sessions,
+	})
+	if _, err := svc.Register(context.Background(), userDomain.User{
+		Email:        "test@example.com",
+		PasswordHash: "password123",
+	}); err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+	return svc, sessions
+}
+
+func TestLoginRecordsSession(t *testing.T) {
+	svc, sessions := newRecordedSessionService(t)
+	ctx := ContextWithDevice(context.Background(), Device{UserAgent: "Firefox", IP: "203.0.113.7"})
+
+	token, user, err := svc.Login(ctx, "test@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	claims, err := svc.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("Expected valid token, got %v", err)
+	}
+	session, _ := sessions.Get(ctx, claims.SessionID)
+	if session == nil || session.UserID != user.ID || session.UserAgent != "Firefox" || session.IP != "203.0.113.7" {
+		t.Fatalf("Expected the session recorded with its device, got %+v", session)
+	}
+
+	if _, _, err := svc.Login(ctx, "test@example.com", "password123"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	list, err := svc.ListSessions(ctx, user.ID, claims.SessionID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d", len(list))
+	}
+	current := 0
+	for _, s := range list {
+		if s.Current {
+			current++
+			if s.ID != claims.SessionID {
+				t.Errorf("Expected %s marked current, got %s", claims.SessionID, s.ID)
+			}
+		}
+	}
+	if current != 1 {
+		t.Errorf("Expected one current session, got %d", current)
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	svc, _ := newRecordedSessionService(t)
+	ctx := context.Background()
+
+	token, user, err := svc.Login(ctx, "test@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	claims, _ := svc.ValidateToken(token)
+	if err := svc.CheckSession(ctx, claims); err != nil {
+		t.Fatalf("Expected the session valid, got %v", err)
+	}
+
+	if err := svc.RevokeSession(ctx, "other-user", claims.SessionID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected ErrSessionNotFound for another user's session, got %v", err)
+	}
+	if err := svc.RevokeSession(ctx, user.ID, claims.SessionID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := svc.CheckSession(ctx, claims); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken after revocation, got %v", err)
+	}
+	if err := svc.RevokeSession(ctx, user.ID, claims.SessionID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected ErrSessionNotFound for a revoked session, got %v", err)
+	}
+
+	legacy := &userDomain.Claims{UserID: user.ID, IssuedAt: time.Now()}
+	if err := svc.CheckSession(ctx, legacy); err != nil {
+		t.Errorf("Expected tokens without a session accepted, got %v", err)
+	}
+}
+
+func TestChangePasswordEndsSessions(t *testing.T) {
+	svc, sessions := newRecordedSessionService(t)
+	ctx := context.Background()
+
+	_, user, err := svc.Login(ctx, "test@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := svc.ChangePassword(ctx, user.ID, "password123", "newpassword123"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if list, _ := sessions.ListByUser(ctx, user.ID, time.Now()); len(list) != 0 {
+		t.Errorf("Expected sessions ended, got %d", len(list))
+	}
+}
+
+type mockDeviceRepo struct {
+	known map[string]bool
+}
+
+func (m *mockDeviceRepo) Seen(ctx context.Context, device *userDomain.KnownDevice, at time.Time) (bool, error) {
+	key := device.UserID + "/" + device.Fingerprint
+	if m.known[key] {
+		return false, nil
+	}
+	m.known[key] = true
+	return true, nil
+}
+
+func TestBindAdminDevices(t *testing.T) {
+	mem := cache.NewMemory()
+	t.Cleanup(mem.Stop)
+	svc := NewService(ServiceConfig{
+		Repo:             newMockUserRepo(),
+		Cache:            mem,
+		JWTSecret:        "test-secret-key-that-is-long-enough",
+		Sessions:         newMockSessionRepo(),
+		BindAdminDevices: true,
+	})
+	laptop := ContextWithDevice(context.Background(), NewDevice("Firefox", "device-1", "203.0.113.7"))
+	phone := ContextWithDevice(context.Background(), NewDevice("Firefox", "device-2", "203.0.113.7"))
+	moved := ContextWithDevice(context.Background(), NewDevice("Firefox", "device-1", "198.51.100.1"))
+
+	admin := &userDomain.User{ID: "admin-1", Email: "admin@example.com", Role: userDomain.RoleAdmin}
+	token, err := svc.GenerateToken(laptop, admin)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	claims, _ := svc.ValidateToken(token)
+	if err := svc.CheckSession(laptop, claims); err != nil {
+		t.Fatalf("Expected the token valid on its device, got %v", err)
+	}
+	if err := svc.CheckSession(moved, claims); err != nil {
+		t.Errorf("Expected the token valid from another IP, got %v", err)
+	}
+	if err := svc.CheckSession(phone, claims); !errors.Is(err, ErrDeviceChanged) {
+		t.Fatalf("Expected ErrDeviceChanged on another device, got %v", err)
+	}
+	if err := svc.CheckSession(laptop, claims); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected the session ended after a device change, got %v", err)
+	}
+
+	user := &userDomain.User{ID: "user-1", Email: "ana@example.com", Role: userDomain.RoleUser}
+	token, _ = svc.GenerateToken(laptop, user)
+	claims, _ = svc.ValidateToken(token)
+	if err := svc.CheckSession(phone, claims); err != nil {
+		t.Errorf("Expected non-admin sessions unbound, got %v", err)
+	}
+}
+
+func TestAdminNewDeviceEvent(t *testing.T) {
+	bus := events.NewBus()
+	var seen []events.AdminNewDevice
+	bus.Subscribe(func(ctx context.Context, event events.Event) {
+		seen = append(seen, event.(events.AdminNewDevice))
+	}, events.NameAdminNewDevice)
+	svc := NewService(ServiceConfig{
+		Repo:      newMockUserRepo(),
+		JWTSecret: "test-secret-key-that-is-long-enough",
+		Events:    bus,
+		Devices:   &mockDeviceRepo{known: map[string]bool{}},
+	})
+	laptop := ContextWithDevice(context.Background(), NewDevice("Firefox", "device-1", "203.0.113.7"))
+	phone := ContextWithDevice(context.Background(), NewDevice("Safari", "", "198.51.100.1"))
+
+	admin := &userDomain.User{ID: "admin-1", Email: "admin@example.com", Role: userDomain.RoleAdmin}
+	for _, ctx := range []context.Context{laptop, laptop, phone} {
+		if _, err := svc.GenerateToken(ctx, admin); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if _, err := svc.GenerateToken(phone, &userDomain.User{ID: "user-1", Role: userDomain.RoleUser}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(seen) != 2 {
+		t.Fatalf("Expected an alert per new admin device, got %d", len(seen))
+	}
+	if seen[1].Email != "admin@example.com" || seen[1].IP != "198.51.100.1" || seen[1].UserAgent != "Safari" {
+		t.Errorf("Unexpected event %+v", seen[1])
+	}
+}
+
+func TestBindSessionStartedByRedirect(t *testing.T) {
+	bus := events.NewBus()
+	alerts := 0
+	bus.Subscribe(func(ctx context.Context, event events.Event) { alerts++ }, events.NameAdminNewDevice)
+	svc := NewService(ServiceConfig{
+		Repo:             newMockUserRepo(),
+		JWTSecret:        "test-secret-key-that-is-long-enough",
+		Events:           bus,
+		Sessions:         newMockSessionRepo(),
+		Devices:          &mockDeviceRepo{known: map[string]bool{}},
+		BindAdminDevices: true,
+	})
+	// An OAuth callback is a redirect without the device ID header.
+	redirect := NewDevice("Firefox", "", "203.0.113.7")
+	redirect.Fingerprint = ""
+	browser := ContextWithDevice(context.Background(), NewDevice("Firefox", "device-1", "203.0.113.7"))
+	other := ContextWithDevice(context.Background(), NewDevice("Firefox", "device-2", "203.0.113.7"))
+
+	admin := &userDomain.User{ID: "admin-1", Email: "admin@example.com", Role: userDomain.RoleAdmin}
+	token, err := svc.GenerateToken(ContextWithDevice(context.Background(), redirect), admin)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if alerts != 0 {
+		t.Errorf("Expected no alert before the device is known, got %d", alerts)
+	}
+	claims, _ := svc.ValidateToken(token)
+	for i := 0; i < 2; i++ {
+		if err := svc.CheckSession(browser, claims); err != nil {
+			t.Fatalf("Expected the session bound to the first device using it, got %v", err)
+		}
+	}
+	if alerts != 1 {
+		t.Errorf("Expected one alert when the session was bound, got %d", alerts)
+	}
+	if err := svc.CheckSession(other, claims); !errors.Is(err, ErrDeviceChanged) {
+		t.Errorf("Expected ErrDeviceChanged on another device, got %v", err)
+	}
+}
+
+// staleSessionRepo answers the first Get with the session as it was before
+// another request bound it.
+type staleSessionRepo struct {
+	*mockSessionRepo
+	stale bool
+}
+
+func (m *staleSessionRepo) Get(ctx context.Context, id string) (*userDomain.Session, error) {
+	session, err := m.mockSessionRepo.Get(ctx, id)
+	if session != nil && m.stale {
+		m.stale = false
+		session.Fingerprint = ""
+	}
+	return session, err
+}
+
+func TestBindSessionLosingRace(t *testing.T) {
+	sessions := &staleSessionRepo{mockSessionRepo: newMockSessionRepo()}
+	svc := NewService(ServiceConfig{
+		Repo:             newMockUserRepo(),
+		JWTSecret:        "test-secret-key-that-is-long-enough",
+		Sessions:         sessions,
+		BindAdminDevices: true,
+	})
+	redirect := NewDevice("Firefox", "", "203.0.113.7")
+	redirect.Fingerprint = ""
+	admin := &userDomain.User{ID: "admin-1", Email: "admin@example.com", Role: userDomain.RoleAdmin}
+	token, err := svc.GenerateToken(ContextWithDevice(context.Background(), redirect), admin)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	claims, _ := svc.ValidateToken(token)
+	if err := svc.CheckSession(ContextWithDevice(context.Background(), NewDevice("Firefox", "device-1", "203.0.113.7")), claims); err != nil {
+		t.Fatalf("Expected the session bound to the first device, got %v", err)
+	}
+
+	// device-2 read the session before device-1 bound it.
+	sessions.stale = true
+	other := ContextWithDevice(context.Background(), NewDevice("Firefox", "device-2", "203.0.113.7"))
+	if err := svc.CheckSession(other, claims); !errors.Is(err, ErrDeviceChanged) {
+		t.Errorf("Expected ErrDeviceChanged for the device that lost the bind, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+)
+
+// AttachmentSender uploads files and sends them from a business phone
+// number.
+type AttachmentSender interface {
+	UploadMedia(ctx context.Context, phoneNumberID string, data []byte, mimeType, filename string) (string, error)
+	SendDocument(ctx context.Context, phoneNumberID, to, mediaID, filename, caption string) error
+	SendImage(ctx context.Context, phoneNumberID, to, mediaID, caption string) error
+}
+
+type AttachmentReplier struct {
+	docs          documentDomain.Service
+	sender        AttachmentSender
+	phoneNumberID string
+}
+
+func NewAttachmentReplier(docs documentDomain.Service, sender AttachmentSender, phoneNumberID string) *AttachmentReplier {
+	return &AttachmentReplier{docs: docs, sender: sender, phoneNumberID: phoneNumberID}
+}
+
+// Reply sends the file behind the best-matching chunk: an image of its PDF
+// page, or the whole file. Only that chunk is considered, so a reply never
+// carries a file the answer did not mainly rest on.
+func (a *AttachmentReplier) Reply(ctx context.Context, to string, chunks []documentDomain.Chunk) (bool, error) {
+	if len(chunks) == 0 {
+		return false, nil
+	}
+	best := chunks[0]
+	for _, chunk := range chunks[1:] {
+		if chunk.Score > best.Score {
+			best = chunk
+		}
+	}
+
+	attachment, err := a.docs.Attachment(ctx, best)
+	if err != nil {
+		return false, fmt.Errorf("load attachment: %w", err)
+	}
+	if attachment == nil {
+		return false, nil
+	}
+
+	mediaID, err := a.sender.UploadMedia(ctx, a.phoneNumberID, attachment.Data, attachment.ContentType, attachment.Filename)
+	if err != nil {
+		return false, fmt.Errorf("upload attachment: %w", err)
+	}
+	if imageTypes[attachment.ContentType] {
+		err = a.sender.SendImage(ctx, a.phoneNumberID, to, mediaID, attachment.Caption)
+	} else {
+		err = a.sender.SendDocument(ctx, a.phoneNumberID, to, mediaID, attachment.Filename, attachment.Caption)
+	}
+	if err != nil {
+		return false, fmt.Errorf("send attachment: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,72 @@
+package whatsapp
+
+import (
+	"context"
+	"testing"
+
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+)
+
+// mockAttachmentDocs serves one attachment; other Service methods are
+// not used by the replier.
+type mockAttachmentDocs struct {
+	documentDomain.Service
+	attachment *documentDomain.Attachment
+	chunk      documentDomain.Chunk
+}
+
+func (m *mockAttachmentDocs) Attachment(ctx context.Context, chunk documentDomain.Chunk) (*documentDomain.Attachment, error) {
+	m.chunk = chunk
+	return m.attachment, nil
+}
+
+type mockAttachmentSender struct {
+	mockAudioSender
+	sent     string
+	filename string
+	caption  string
+}
+
+func (m *mockAttachmentSender) SendDocument(ctx context.Context, phoneNumberID, to, mediaID, filename, caption string) error {
+	m.sent, m.filename, m.caption = "document", filename, caption
+	return nil
+}
+
+func (m *mockAttachmentSender) SendImage(ctx context.Context, phoneNumberID, to, mediaID, caption string) error {
+	m.sent, m.caption = "image", caption
+	return nil
+}
+
+func TestAttachmentReply(t *testing.T) {
+	docs := &mockAttachmentDocs{attachment: &documentDomain.Attachment{
+		Data: []byte("%PDF"), ContentType: "application/pdf", Filename: "manual.pdf", Caption: "Manual",
+	}}
+	sender := &mockAttachmentSender{}
+	a := NewAttachmentReplier(docs, sender, "phone-1")
+
+	chunks := []documentDomain.Chunk{{ID: "c1", Score: 0.4}, {ID: "c2", Score: 0.9}}
+	sent, err := a.Reply(context.Background(), "15551234", chunks)
+	if err != nil || !sent {
+		t.Fatalf("expected the attachment to be sent, got %v, %v", sent, err)
+	}
+	if docs.chunk.ID != "c2" {
+		t.Errorf("expected the best chunk to be used, got %s", docs.chunk.ID)
+	}
+	if sender.sent != "document" || sender.filename != "manual.pdf" || sender.mimeType != "application/pdf" {
+		t.Errorf("expected manual.pdf sent as a document, got %s %s as %s", sender.sent, sender.filename, sender.mimeType)
+	}
+
+	docs.attachment = &documentDomain.Attachment{Data: []byte("\x89PNG"), ContentType: "image/png", Caption: "Manual, page 3"}
+	if sent, err := a.Reply(context.Background(), "15551234", chunks); err != nil || !sent || sender.sent != "image" {
+		t.Errorf("expected a page image, got %s: %v, %v", sender.sent, sent, err)
+	}
+
+	docs.attachment = nil
+	sender.sent = ""
+	if sent, err := a.Reply(context.Background(), "15551234", chunks); err != nil || sent || sender.sent != "" {
+		t.Errorf("expected nothing sent without a shareable file, got %s: %v, %v", sender.sent, sent, err)
+	}
+	if sent, _ := a.Reply(context.Background(), "15551234", nil); sent {
+		t.Error("expected nothing sent without chunks")
+	}
+}
@@ -0,0 +1,273 @@
+package whatsapp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	whatsappDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/whatsapp"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	whatsappClient "github.com/elprogramadorgt/lucidRAG/pkg/whatsapp"
+)
+
+var (
+	ErrFlowNotFound = errors.New("flow not found")
+	ErrInvalidFlow  = errors.New("invalid flow")
+	// ErrNoWhatsAppContact is a conversation a flow cannot be sent to, such
+	// as a Slack thread or an anonymized contact.
+	ErrNoWhatsAppContact = errors.New("conversation has no whatsapp contact")
+	// ErrFlowsUnavailable means no WhatsApp credentials are configured to
+	// send flows with.
+	ErrFlowsUnavailable = errors.New("whatsapp flows are not configured")
+)
+
+// WhatsApp limits the flow button's label and the message body.
+const (
+	maxFlowCTA  = 30
+	maxFlowBody = 1024
+)
+
+// Answers copied into conversation variables follow the same rules as
+// variables agents set.
+const (
+	maxFlowVariables  = 30
+	maxVariableLength = 200
+	flowTimeout       = 5 * time.Second
+)
+
+var (
+	flowFieldName    = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,79}$`)
+	flowVariableName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,39}$`)
+)
+
+// FlowSender sends flows from a business phone number.
+type FlowSender interface {
+	SendFlow(ctx context.Context, phoneNumberID, to string, flow whatsappClient.Flow) error
+}
+
+type FlowConfig struct {
+	Repo whatsappDomain.FlowRepository
+	// ConvSvc checks access to the conversation a flow is sent to and
+	// records it there.
+	ConvSvc conversationDomain.Service
+	// Sender delivers flows; without it they cannot be sent.
+	Sender        FlowSender
+	PhoneNumberID string
+}
+
+type flowService struct {
+	repo          whatsappDomain.FlowRepository
+	convSvc       conversationDomain.Service
+	sender        FlowSender
+	phoneNumberID string
+}
+
+func NewFlowService(cfg FlowConfig) whatsappDomain.FlowService {
+	return &flowService{
+		repo:          cfg.Repo,
+		convSvc:       cfg.ConvSvc,
+		sender:        cfg.Sender,
+		phoneNumberID: cfg.PhoneNumberID,
+	}
+}
+
+func (s *flowService) CreateFlow(ctx context.Context, adminID string, flow *whatsappDomain.Flow) (string, error) {
+	if err := validateFlow(flow); err != nil {
+		return "", err
+	}
+	flow.ID = ""
+	flow.CreatedBy = adminID
+	return s.repo.Create(ctx, flow)
+}
+
+func (s *flowService) ListFlows(ctx context.Context) ([]whatsappDomain.Flow, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *flowService) UpdateFlow(ctx context.Context, adminID string, flow *whatsappDomain.Flow) error {
+	existing, err := s.repo.GetByID(ctx, flow.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrFlowNotFound
+	}
+	if err := validateFlow(flow); err != nil {
+		return err
+	}
+	flow.CreatedBy = existing.CreatedBy
+	flow.CreatedAt = existing.CreatedAt
+	return s.repo.Update(ctx, flow)
+}
+
+func (s *flowService) DeleteFlow(ctx context.Context, adminID, id string) error {
+	existing, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrFlowNotFound
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+// SendFlow sends the flow to the conversation's contact and records its
+// body in the conversation. The flow token names the flow, so answers can
+// be mapped to variables when it comes back completed.
+func (s *flowService) SendFlow(ctx context.Context, userCtx conversationDomain.UserContext, id, conversationID string) error {
+	flow, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if flow == nil {
+		return ErrFlowNotFound
+	}
+	conv, err := s.convSvc.GetConversation(ctx, userCtx, conversationID)
+	if err != nil {
+		return err
+	}
+	if conv.PhoneNumber == "" || (conv.Channel != "" && conv.Channel != conversationDomain.ChannelWhatsApp) {
+		return ErrNoWhatsAppContact
+	}
+	if s.sender == nil {
+		return ErrFlowsUnavailable
+	}
+
+	token, err := flowToken(flow.ID)
+	if err != nil {
+		return err
+	}
+	err = s.sender.SendFlow(ctx, s.phoneNumberID, conv.PhoneNumber, whatsappClient.Flow{
+		ID: flow.FlowID, Token: token, CTA: flow.CTA, Body: flow.Body, Screen: flow.Screen,
+	})
+	if err != nil {
+		return fmt.Errorf("send flow: %w", err)
+	}
+	_, err = s.convSvc.SaveOutgoingMessage(ctx, conv.ID, flow.Body, "")
+	return err
+}
+
+func validateFlow(flow *whatsappDomain.Flow) error {
+	flow.Name = strings.TrimSpace(flow.Name)
+	flow.FlowID = strings.TrimSpace(flow.FlowID)
+	flow.Screen = strings.TrimSpace(flow.Screen)
+	flow.CTA = strings.TrimSpace(flow.CTA)
+	flow.Body = strings.TrimSpace(flow.Body)
+
+	switch {
+	case flow.Name == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidFlow)
+	case flow.FlowID == "":
+		return fmt.Errorf("%w: flow_id is required", ErrInvalidFlow)
+	case flow.CTA == "" || utf8.RuneCountInString(flow.CTA) > maxFlowCTA:
+		return fmt.Errorf("%w: cta must be 1 to %d characters", ErrInvalidFlow, maxFlowCTA)
+	case flow.Body == "" || utf8.RuneCountInString(flow.Body) > maxFlowBody:
+		return fmt.Errorf("%w: body must be 1 to %d characters", ErrInvalidFlow, maxFlowBody)
+	case len(flow.Variables) > maxFlowVariables:
+		return fmt.Errorf("%w: at most %d variables", ErrInvalidFlow, maxFlowVariables)
+	}
+	for field, variable := range flow.Variables {
+		if !flowFieldName.MatchString(field) || field == conversationDomain.FormToken {
+			return fmt.Errorf("%w: %q is not a valid field name", ErrInvalidFlow, field)
+		}
+		if !flowVariableName.MatchString(variable) {
+			return fmt.Errorf("%w: variable %q must be lowercase letters, digits and underscores, starting with a letter", ErrInvalidFlow, variable)
+		}
+	}
+	return nil
+}
+
+// flowToken identifies one sending of the flow flowID: its ID and a random
+// suffix, since WhatsApp expects tokens to be unique.
+func flowToken(flowID string) (string, error) {
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return flowID + ":" + hex.EncodeToString(nonce), nil
+}
+
+// FlowCollector copies the answers of completed flows into their
+// conversation's variables, following the variables of the flow they were
+// sent with. The answers themselves are stored on the message.
+type FlowCollector struct {
+	repo     whatsappDomain.FlowRepository
+	convRepo conversationDomain.ConversationRepository
+	log      *logger.Logger
+}
+
+func NewFlowCollector(repo whatsappDomain.FlowRepository, convRepo conversationDomain.ConversationRepository, log *logger.Logger) *FlowCollector {
+	return &FlowCollector{repo: repo, convRepo: convRepo, log: log.With("subscriber", "whatsapp_flows")}
+}
+
+// Subscribe registers the collector on bus.
+func (c *FlowCollector) Subscribe(bus *events.Bus) {
+	bus.Subscribe(c.handle, events.NameMessageReceived)
+}
+
+func (c *FlowCollector) handle(ctx context.Context, event events.Event) {
+	msg, ok := event.(events.MessageReceived)
+	if !ok || msg.MessageType != "flow" || len(msg.Form) == 0 {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), flowTimeout)
+		defer cancel()
+		if err := c.collect(ctx, msg.ConversationID, msg.Form); err != nil {
+			c.log.ErrorContext(ctx, "failed to save flow answers", "error", err, "conversation_id", msg.ConversationID)
+		}
+	}()
+}
+
+func (c *FlowCollector) collect(ctx context.Context, conversationID string, form map[string]any) error {
+	token, _ := form[conversationDomain.FormToken].(string)
+	flowID, _, ok := strings.Cut(token, ":")
+	if !ok {
+		return nil
+	}
+	flow, err := c.repo.GetByID(ctx, flowID)
+	if err != nil || flow == nil || len(flow.Variables) == 0 {
+		return err
+	}
+	conv, err := c.convRepo.GetByID(ctx, conversationID)
+	if err != nil || conv == nil {
+		return err
+	}
+
+	variables := maps.Clone(conv.Variables)
+	if variables == nil {
+		variables = map[string]string{}
+	}
+	for field, variable := range flow.Variables {
+		answer, ok := form[field]
+		if !ok {
+			continue
+		}
+		value := strings.Join(strings.Fields(conversationDomain.FormValue(answer)), " ")
+		if utf8.RuneCountInString(value) > maxVariableLength {
+			value = string([]rune(value)[:maxVariableLength])
+		}
+		if _, exists := variables[variable]; !exists && len(variables) >= maxFlowVariables {
+			continue
+		}
+		if value == "" {
+			delete(variables, variable)
+			continue
+		}
+		variables[variable] = value
+	}
+	if maps.Equal(variables, conv.Variables) {
+		return nil
+	}
+	return c.convRepo.UpdateVariables(ctx, conversationID, variables)
+}
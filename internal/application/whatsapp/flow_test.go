@@ -0,0 +1,206 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	whatsappDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/whatsapp"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	whatsappClient "github.com/elprogramadorgt/lucidRAG/pkg/whatsapp"
+)
+
+type mockFlowRepo struct {
+	flows map[string]whatsappDomain.Flow
+}
+
+func (m *mockFlowRepo) Create(ctx context.Context, flow *whatsappDomain.Flow) (string, error) {
+	flow.ID = "flow-1"
+	m.flows[flow.ID] = *flow
+	return flow.ID, nil
+}
+
+func (m *mockFlowRepo) GetByID(ctx context.Context, id string) (*whatsappDomain.Flow, error) {
+	flow, ok := m.flows[id]
+	if !ok {
+		return nil, nil
+	}
+	return &flow, nil
+}
+
+func (m *mockFlowRepo) List(ctx context.Context) ([]whatsappDomain.Flow, error) {
+	var flows []whatsappDomain.Flow
+	for _, flow := range m.flows {
+		flows = append(flows, flow)
+	}
+	return flows, nil
+}
+
+func (m *mockFlowRepo) Update(ctx context.Context, flow *whatsappDomain.Flow) error {
+	m.flows[flow.ID] = *flow
+	return nil
+}
+
+func (m *mockFlowRepo) Delete(ctx context.Context, id string) error {
+	delete(m.flows, id)
+	return nil
+}
+
+// mockFlowConvs keeps one conversation; the methods flows do not use are
+// left to the embedded interface.
+type mockFlowConvs struct {
+	conversationDomain.ConversationRepository
+	conv  conversationDomain.Conversation
+	saved []string
+}
+
+// mockFlowMessages serves a mockFlowConvs' conversation to the flow service.
+type mockFlowMessages struct {
+	conversationDomain.Service
+	convs *mockFlowConvs
+}
+
+func (m *mockFlowMessages) GetConversation(ctx context.Context, userCtx conversationDomain.UserContext, id string) (*conversationDomain.Conversation, error) {
+	if !userCtx.IsAdmin && m.convs.conv.UserID != userCtx.UserID {
+		return nil, errors.New("access denied")
+	}
+	conv := m.convs.conv
+	return &conv, nil
+}
+
+func (m *mockFlowConvs) GetByID(ctx context.Context, id string) (*conversationDomain.Conversation, error) {
+	conv := m.conv
+	return &conv, nil
+}
+
+func (m *mockFlowConvs) UpdateVariables(ctx context.Context, id string, variables map[string]string) error {
+	m.conv.Variables = variables
+	return nil
+}
+
+func (m *mockFlowMessages) SaveOutgoingMessage(ctx context.Context, conversationID, content, ragAnswer string) (*conversationDomain.Message, error) {
+	m.convs.saved = append(m.convs.saved, content)
+	return &conversationDomain.Message{Content: content}, nil
+}
+
+type mockFlowSender struct {
+	to   string
+	sent []whatsappClient.Flow
+}
+
+func (m *mockFlowSender) SendFlow(ctx context.Context, phoneNumberID, to string, flow whatsappClient.Flow) error {
+	m.to = to
+	m.sent = append(m.sent, flow)
+	return nil
+}
+
+func bookingFlow() *whatsappDomain.Flow {
+	return &whatsappDomain.Flow{
+		Name: " Booking ", FlowID: "1234", Screen: "BOOKING", CTA: "Book now", Body: "Pick a time that suits you",
+		Variables: map[string]string{"date": "booking_date", "services": "booking_services"},
+	}
+}
+
+func TestCreateFlowValidates(t *testing.T) {
+	svc := NewFlowService(FlowConfig{Repo: &mockFlowRepo{flows: map[string]whatsappDomain.Flow{}}})
+	ctx := context.Background()
+
+	id, err := svc.CreateFlow(ctx, "admin-1", bookingFlow())
+	if err != nil || id == "" {
+		t.Fatalf("Expected the flow to be created, got %q, %v", id, err)
+	}
+
+	invalid := []func(*whatsappDomain.Flow){
+		func(f *whatsappDomain.Flow) { f.FlowID = " " },
+		func(f *whatsappDomain.Flow) { f.CTA = strings.Repeat("a", maxFlowCTA+1) },
+		func(f *whatsappDomain.Flow) { f.Body = "" },
+		func(f *whatsappDomain.Flow) { f.Variables = map[string]string{"date": "Booking Date"} },
+		func(f *whatsappDomain.Flow) { f.Variables = map[string]string{"flow_token": "token"} },
+	}
+	for i, change := range invalid {
+		flow := bookingFlow()
+		change(flow)
+		if _, err := svc.CreateFlow(ctx, "admin-1", flow); !errors.Is(err, ErrInvalidFlow) {
+			t.Errorf("Case %d: expected ErrInvalidFlow, got %v", i, err)
+		}
+	}
+}
+
+func TestSendFlow(t *testing.T) {
+	repo := &mockFlowRepo{flows: map[string]whatsappDomain.Flow{}}
+	convs := &mockFlowConvs{conv: conversationDomain.Conversation{ID: "conv-1", PhoneNumber: "15551234"}}
+	sender := &mockFlowSender{}
+	svc := NewFlowService(FlowConfig{Repo: repo, ConvSvc: &mockFlowMessages{convs: convs}, Sender: sender, PhoneNumberID: "phone-1"})
+	ctx := context.Background()
+	admin := conversationDomain.UserContext{UserID: "admin-1", IsAdmin: true}
+
+	id, _ := svc.CreateFlow(ctx, "admin-1", bookingFlow())
+	if err := svc.SendFlow(ctx, admin, id, "conv-1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(sender.sent) != 1 || sender.to != "15551234" {
+		t.Fatalf("Expected the flow sent to the contact, got %+v to %q", sender.sent, sender.to)
+	}
+	sent := sender.sent[0]
+	if sent.ID != "1234" || sent.CTA != "Book now" || sent.Screen != "BOOKING" || !strings.HasPrefix(sent.Token, id+":") {
+		t.Errorf("Unexpected flow %+v", sent)
+	}
+	if len(convs.saved) != 1 || convs.saved[0] != "Pick a time that suits you" {
+		t.Errorf("Expected the flow recorded in the conversation, got %v", convs.saved)
+	}
+
+	if err := svc.SendFlow(ctx, admin, "missing", "conv-1"); !errors.Is(err, ErrFlowNotFound) {
+		t.Errorf("Expected ErrFlowNotFound, got %v", err)
+	}
+	if err := svc.SendFlow(ctx, conversationDomain.UserContext{UserID: "agent-1"}, id, "conv-1"); err == nil {
+		t.Error("Expected users without access to the conversation to be refused")
+	}
+
+	convs.conv.Channel = conversationDomain.ChannelSlack
+	if err := svc.SendFlow(ctx, admin, id, "conv-1"); !errors.Is(err, ErrNoWhatsAppContact) {
+		t.Errorf("Expected ErrNoWhatsAppContact, got %v", err)
+	}
+
+	convs.conv.Channel = conversationDomain.ChannelWhatsApp
+	unconfigured := NewFlowService(FlowConfig{Repo: repo, ConvSvc: &mockFlowMessages{convs: convs}})
+	if err := unconfigured.SendFlow(ctx, admin, id, "conv-1"); !errors.Is(err, ErrFlowsUnavailable) {
+		t.Errorf("Expected ErrFlowsUnavailable, got %v", err)
+	}
+}
+
+func TestFlowCollectorCopiesAnswers(t *testing.T) {
+	repo := &mockFlowRepo{flows: map[string]whatsappDomain.Flow{}}
+	repo.Create(context.Background(), bookingFlow())
+	convs := &mockFlowConvs{conv: conversationDomain.Conversation{ID: "conv-1", Variables: map[string]string{"plan": "pro"}}}
+	collector := NewFlowCollector(repo, convs, logger.New())
+
+	form := map[string]any{
+		"flow_token": "flow-1:abc123",
+		"date":       "2026-10-20",
+		"services":   []any{"cut", "color"},
+		"notes":      "not mapped",
+	}
+	if err := collector.collect(context.Background(), "conv-1", form); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := map[string]string{"plan": "pro", "booking_date": "2026-10-20", "booking_services": "cut, color"}
+	if len(convs.conv.Variables) != len(want) {
+		t.Fatalf("Expected variables %v, got %v", want, convs.conv.Variables)
+	}
+	for k, v := range want {
+		if convs.conv.Variables[k] != v {
+			t.Errorf("Expected %s=%q, got %q", k, v, convs.conv.Variables[k])
+		}
+	}
+
+	// Answers whose token names no flow are only kept on the message.
+	convs.conv.Variables = nil
+	if err := collector.collect(context.Background(), "conv-1", map[string]any{"flow_token": "unflagged", "date": "x"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if convs.conv.Variables != nil {
+		t.Errorf("Expected no variables from an unknown token, got %v", convs.conv.Variables)
+	}
+}
@@ -0,0 +1,60 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+)
+
+var ErrUnsupportedImage = errors.New("unsupported image format")
+
+// VisionModel answers a prompt about an image.
+type VisionModel interface {
+	DescribeImage(ctx context.Context, image []byte, mimeType, prompt, model string) (string, error)
+}
+
+var imageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+	"image/gif":  true,
+}
+
+const describePrompt = "A customer sent this photo to a support assistant. Describe what it shows " +
+	"in a few sentences, focusing on what would help answer their question. Transcribe any " +
+	"visible text such as product names, labels, error messages or codes exactly."
+
+type ImageDescriber struct {
+	media  MediaDownloader
+	vision VisionModel
+	model  string
+}
+
+func NewImageDescriber(media MediaDownloader, vision VisionModel, model string) *ImageDescriber {
+	return &ImageDescriber{media: media, vision: vision, model: model}
+}
+
+func (d *ImageDescriber) Describe(ctx context.Context, mediaID, caption string) (string, error) {
+	data, mimeType, err := d.media.DownloadMedia(ctx, mediaID)
+	if err != nil {
+		return "", fmt.Errorf("download media %s: %w", mediaID, err)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(mimeType)
+	if !imageTypes[mediaType] {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedImage, mimeType)
+	}
+
+	prompt := describePrompt
+	if caption = strings.TrimSpace(caption); caption != "" {
+		prompt += fmt.Sprintf("\n\nThe customer wrote: %q", caption)
+	}
+
+	description, err := d.vision.DescribeImage(ctx, data, mediaType, prompt, d.model)
+	if err != nil {
+		return "", fmt.Errorf("describe media %s: %w", mediaID, err)
+	}
+	return strings.TrimSpace(description), nil
+}
@@ -0,0 +1,59 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+)
+
+type mockVision struct {
+	prompt   string
+	mimeType string
+}
+
+func (m *mockVision) DescribeImage(ctx context.Context, image []byte, mimeType, prompt, model string) (string, error) {
+	m.prompt, m.mimeType = prompt, mimeType
+	return " A router label reading model AX-200. ", nil
+}
+
+func TestDescribe_IncludesCaption(t *testing.T) {
+	vision := &mockVision{}
+	d := NewImageDescriber(&mockMedia{data: []byte{0xff, 0xd8}, mimeType: "image/jpeg"}, vision, "")
+
+	description, err := d.Describe(context.Background(), "media-1", "how do I reset this?")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if description != "A router label reading model AX-200." {
+		t.Errorf("expected trimmed description, got %q", description)
+	}
+	if !strings.Contains(vision.prompt, `"how do I reset this?"`) {
+		t.Errorf("expected caption in prompt, got %q", vision.prompt)
+	}
+	if vision.mimeType != "image/jpeg" {
+		t.Errorf("expected image/jpeg, got %s", vision.mimeType)
+	}
+}
+
+func TestDescribe_UnsupportedFormat(t *testing.T) {
+	d := NewImageDescriber(&mockMedia{data: []byte("II*"), mimeType: "image/tiff"}, &mockVision{}, "")
+
+	if _, err := d.Describe(context.Background(), "media-1", ""); !errors.Is(err, ErrUnsupportedImage) {
+		t.Errorf("expected ErrUnsupportedImage, got %v", err)
+	}
+}
+
+func TestQueryFor_CombinesCaptionAndDescription(t *testing.T) {
+	msg := events.MessageReceived{Content: "is this covered?", MediaDescription: "A cracked phone screen."}
+
+	query := queryFor(msg)
+	if !strings.HasPrefix(query, "is this covered?") || !strings.Contains(query, "A cracked phone screen.") {
+		t.Errorf("expected caption and description in query, got %q", query)
+	}
+	if queryFor(events.MessageReceived{Content: " "}) != "" {
+		t.Error("expected empty query without content or description")
+	}
+}
@@ -0,0 +1,243 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	whatsappDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/whatsapp"
+)
+
+var ErrInvalidOnboarding = errors.New("invalid onboarding settings")
+
+// WhatsApp allows up to three reply buttons of up to 20 characters.
+const (
+	maxButtons     = 3
+	maxButtonTitle = 20
+)
+
+const (
+	defaultConsentAccept  = "I agree"
+	defaultConsentDecline = "No, thanks"
+)
+
+// Replies, besides the buttons' titles, that accept or decline consent.
+var (
+	acceptReplies  = []string{"yes", "y", "ok", "agree", "i agree", "si", "sí", "acepto"}
+	declineReplies = []string{"no", "n", "no thanks", "decline", "no acepto"}
+)
+
+// OnboardingSender sends onboarding messages from a business phone number.
+type OnboardingSender interface {
+	SendText(ctx context.Context, phoneNumberID, to, body string) error
+	SendButtons(ctx context.Context, phoneNumberID, to, body string, titles []string) error
+}
+
+type OnboardingConfig struct {
+	Repo     whatsappDomain.OnboardingRepository
+	ConvRepo conversationDomain.ConversationRepository
+	// ConvSvc records the messages sent to contacts in their conversation.
+	ConvSvc conversationDomain.Service
+	// Sender delivers the messages; without it they are only recorded.
+	Sender        OnboardingSender
+	PhoneNumberID string
+}
+
+type onboardingService struct {
+	repo          whatsappDomain.OnboardingRepository
+	convRepo      conversationDomain.ConversationRepository
+	convSvc       conversationDomain.Service
+	sender        OnboardingSender
+	phoneNumberID string
+}
+
+func NewOnboardingService(cfg OnboardingConfig) whatsappDomain.OnboardingService {
+	return &onboardingService{
+		repo:          cfg.Repo,
+		convRepo:      cfg.ConvRepo,
+		convSvc:       cfg.ConvSvc,
+		sender:        cfg.Sender,
+		phoneNumberID: cfg.PhoneNumberID,
+	}
+}
+
+// GetOnboarding returns the saved settings, or disabled ones with the
+// default consent buttons.
+func (s *onboardingService) GetOnboarding(ctx context.Context) (*whatsappDomain.Onboarding, error) {
+	onboarding, err := s.repo.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if onboarding == nil {
+		onboarding = &whatsappDomain.Onboarding{}
+	}
+	if onboarding.ConsentAccept == "" {
+		onboarding.ConsentAccept = defaultConsentAccept
+	}
+	if onboarding.ConsentDecline == "" {
+		onboarding.ConsentDecline = defaultConsentDecline
+	}
+	return onboarding, nil
+}
+
+func (s *onboardingService) SaveOnboarding(ctx context.Context, onboarding *whatsappDomain.Onboarding, adminID string) error {
+	onboarding.Welcome = strings.TrimSpace(onboarding.Welcome)
+	onboarding.ConsentPrompt = strings.TrimSpace(onboarding.ConsentPrompt)
+	onboarding.ConsentAccept = strings.TrimSpace(onboarding.ConsentAccept)
+	onboarding.ConsentDecline = strings.TrimSpace(onboarding.ConsentDecline)
+
+	options := make([]string, 0, len(onboarding.Options))
+	for _, option := range onboarding.Options {
+		if option = strings.TrimSpace(option); option != "" {
+			options = append(options, option)
+		}
+	}
+	onboarding.Options = options
+	if len(options) > maxButtons {
+		return fmt.Errorf("%w: at most %d options", ErrInvalidOnboarding, maxButtons)
+	}
+	for _, title := range append(options, onboarding.ConsentAccept, onboarding.ConsentDecline) {
+		if utf8.RuneCountInString(title) > maxButtonTitle {
+			return fmt.Errorf("%w: button %q is longer than %d characters", ErrInvalidOnboarding, title, maxButtonTitle)
+		}
+	}
+	if onboarding.Enabled && onboarding.Welcome == "" && !onboarding.RequireConsent {
+		return fmt.Errorf("%w: welcome is required", ErrInvalidOnboarding)
+	}
+	if onboarding.RequireConsent && onboarding.ConsentPrompt == "" {
+		return fmt.Errorf("%w: consent_prompt is required with require_consent", ErrInvalidOnboarding)
+	}
+	if len(options) > 0 && onboarding.Welcome == "" {
+		return fmt.Errorf("%w: options need a welcome to go under", ErrInvalidOnboarding)
+	}
+
+	onboarding.UpdatedBy = adminID
+	return s.repo.Save(ctx, onboarding)
+}
+
+func (s *onboardingService) Greet(ctx context.Context, conversationID, from, content string) (bool, error) {
+	conv, err := s.convRepo.GetByID(ctx, conversationID)
+	if err != nil {
+		return true, err
+	}
+	if conv == nil || conv.Onboarding == "" {
+		return true, nil
+	}
+	onboarding, err := s.GetOnboarding(ctx)
+	if err != nil {
+		return true, err
+	}
+
+	switch conv.Onboarding {
+	case conversationDomain.OnboardingPending:
+		return s.welcome(ctx, conv, from, onboarding)
+	case conversationDomain.OnboardingConsent:
+		// Consent may have stopped being required since it was asked for.
+		if !onboarding.Enabled || !onboarding.RequireConsent {
+			_, err := s.convRepo.SetOnboarding(ctx, conv.ID, conversationDomain.OnboardingConsent, "")
+			return true, err
+		}
+		return false, s.collectConsent(ctx, conv, from, content, onboarding)
+	}
+	return true, nil
+}
+
+// welcome greets a new contact, asking for consent when it is required.
+func (s *onboardingService) welcome(ctx context.Context, conv *conversationDomain.Conversation, from string, onboarding *whatsappDomain.Onboarding) (bool, error) {
+	askConsent := onboarding.Enabled && onboarding.RequireConsent
+	var next conversationDomain.OnboardingState
+	if askConsent {
+		next = conversationDomain.OnboardingConsent
+	}
+	// Only the first of several quick messages greets the contact.
+	claimed, err := s.convRepo.SetOnboarding(ctx, conv.ID, conversationDomain.OnboardingPending, next)
+	if err != nil || !claimed || !onboarding.Enabled {
+		return !askConsent, err
+	}
+
+	if onboarding.Welcome != "" {
+		if err := s.send(ctx, conv, from, onboarding.Welcome, onboarding.Options); err != nil {
+			return !askConsent, err
+		}
+	}
+	if askConsent {
+		return false, s.askConsent(ctx, conv, from, onboarding)
+	}
+	return onboarding.AnswerFirst, nil
+}
+
+func (s *onboardingService) askConsent(ctx context.Context, conv *conversationDomain.Conversation, from string, onboarding *whatsappDomain.Onboarding) error {
+	return s.send(ctx, conv, from, onboarding.ConsentPrompt, []string{onboarding.ConsentAccept, onboarding.ConsentDecline})
+}
+
+// collectConsent records a contact's answer to the consent request, or
+// asks again when the message is not one.
+func (s *onboardingService) collectConsent(ctx context.Context, conv *conversationDomain.Conversation, from, content string, onboarding *whatsappDomain.Onboarding) error {
+	reply := normalizeReply(content)
+	switch {
+	case reply == normalizeReply(onboarding.ConsentAccept) || slices.Contains(acceptReplies, reply):
+		if err := s.convRepo.SetConsent(ctx, conv.ID, conversationDomain.Consent{Granted: true, At: time.Now()}); err != nil {
+			return err
+		}
+		if _, err := s.convRepo.SetOnboarding(ctx, conv.ID, conversationDomain.OnboardingConsent, ""); err != nil {
+			return err
+		}
+		if onboarding.ConsentAccepted == "" {
+			return nil
+		}
+		return s.send(ctx, conv, from, onboarding.ConsentAccepted, nil)
+	case reply == normalizeReply(onboarding.ConsentDecline) || slices.Contains(declineReplies, reply):
+		if err := s.convRepo.SetConsent(ctx, conv.ID, conversationDomain.Consent{Granted: false, At: time.Now()}); err != nil {
+			return err
+		}
+		if onboarding.ConsentDeclined == "" {
+			return nil
+		}
+		return s.send(ctx, conv, from, onboarding.ConsentDeclined, nil)
+	}
+	return s.askConsent(ctx, conv, from, onboarding)
+}
+
+// send delivers body, with a reply button per title, and records it in
+// the conversation.
+func (s *onboardingService) send(ctx context.Context, conv *conversationDomain.Conversation, to, body string, titles []string) error {
+	body = render(body, conv.ContactName)
+	if s.sender != nil {
+		var err error
+		if len(titles) > 0 {
+			err = s.sender.SendButtons(ctx, s.phoneNumberID, to, body, titles)
+		} else {
+			err = s.sender.SendText(ctx, s.phoneNumberID, to, body)
+		}
+		if err != nil {
+			return fmt.Errorf("send onboarding message: %w", err)
+		}
+	}
+	_, err := s.convSvc.SaveOutgoingMessage(ctx, conv.ID, body, "")
+	return err
+}
+
+// render fills {name} in template with the contact's name. Without a name
+// the placeholder and the space before it are dropped, so "Hi {name}!"
+// reads "Hi!".
+func render(template, name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		template = strings.ReplaceAll(template, " {name}", "")
+	}
+	return strings.ReplaceAll(template, "{name}", name)
+}
+
+// normalizeReply lowercases reply and drops punctuation, so "Yes!" and
+// "yes" read the same.
+func normalizeReply(reply string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(reply), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}), " ")
+}
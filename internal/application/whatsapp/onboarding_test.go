@@ -0,0 +1,185 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	whatsappDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/whatsapp"
+)
+
+type mockOnboardingRepo struct {
+	onboarding *whatsappDomain.Onboarding
+}
+
+func (m *mockOnboardingRepo) Get(ctx context.Context) (*whatsappDomain.Onboarding, error) {
+	if m.onboarding == nil {
+		return nil, nil
+	}
+	saved := *m.onboarding
+	return &saved, nil
+}
+
+func (m *mockOnboardingRepo) Save(ctx context.Context, onboarding *whatsappDomain.Onboarding) error {
+	m.onboarding = onboarding
+	return nil
+}
+
+// mockOnboardingConvs keeps one conversation; the methods onboarding does
+// not use are left to the embedded interface.
+type mockOnboardingConvs struct {
+	conversationDomain.ConversationRepository
+	conv  conversationDomain.Conversation
+	saved []string
+}
+
+// mockOnboardingMessages records outgoing messages on a mockOnboardingConvs.
+type mockOnboardingMessages struct {
+	conversationDomain.Service
+	convs *mockOnboardingConvs
+}
+
+func (m *mockOnboardingConvs) GetByID(ctx context.Context, id string) (*conversationDomain.Conversation, error) {
+	conv := m.conv
+	return &conv, nil
+}
+
+func (m *mockOnboardingConvs) SetOnboarding(ctx context.Context, id string, from, to conversationDomain.OnboardingState) (bool, error) {
+	if m.conv.Onboarding != from {
+		return false, nil
+	}
+	m.conv.Onboarding = to
+	return true, nil
+}
+
+func (m *mockOnboardingConvs) SetConsent(ctx context.Context, id string, consent conversationDomain.Consent) error {
+	m.conv.Consent = &consent
+	return nil
+}
+
+func (m *mockOnboardingMessages) SaveOutgoingMessage(ctx context.Context, conversationID, content, ragAnswer string) (*conversationDomain.Message, error) {
+	m.convs.saved = append(m.convs.saved, content)
+	return &conversationDomain.Message{Content: content}, nil
+}
+
+type sentMessage struct {
+	body    string
+	buttons []string
+}
+
+type mockOnboardingSender struct {
+	sent []sentMessage
+}
+
+func (m *mockOnboardingSender) SendText(ctx context.Context, phoneNumberID, to, body string) error {
+	m.sent = append(m.sent, sentMessage{body: body})
+	return nil
+}
+
+func (m *mockOnboardingSender) SendButtons(ctx context.Context, phoneNumberID, to, body string, titles []string) error {
+	m.sent = append(m.sent, sentMessage{body: body, buttons: titles})
+	return nil
+}
+
+func newTestOnboarding(onboarding *whatsappDomain.Onboarding) (whatsappDomain.OnboardingService, *mockOnboardingConvs, *mockOnboardingSender) {
+	convs := &mockOnboardingConvs{conv: conversationDomain.Conversation{
+		ID: "conv-1", ContactName: "Ana", Onboarding: conversationDomain.OnboardingPending,
+	}}
+	sender := &mockOnboardingSender{}
+	svc := NewOnboardingService(OnboardingConfig{
+		Repo: &mockOnboardingRepo{onboarding: onboarding}, ConvRepo: convs, ConvSvc: &mockOnboardingMessages{convs: convs}, Sender: sender, PhoneNumberID: "phone-1",
+	})
+	return svc, convs, sender
+}
+
+func TestOnboardingWelcome(t *testing.T) {
+	svc, convs, sender := newTestOnboarding(&whatsappDomain.Onboarding{
+		Enabled: true, Welcome: "Hi {name}! How can we help?", Options: []string{"Hours", "Address"},
+	})
+
+	answer, err := svc.Greet(context.Background(), "conv-1", "15551234", "hello")
+	if err != nil || answer {
+		t.Fatalf("expected the welcome to replace the answer, got %v, %v", answer, err)
+	}
+	if len(sender.sent) != 1 || sender.sent[0].body != "Hi Ana! How can we help?" || len(sender.sent[0].buttons) != 2 {
+		t.Errorf("expected the welcome with its options, got %+v", sender.sent)
+	}
+	if len(convs.saved) != 1 || convs.conv.Onboarding != "" {
+		t.Errorf("expected the welcome recorded and onboarding ended, got %v %q", convs.saved, convs.conv.Onboarding)
+	}
+
+	// Later messages, and contacts from before onboarding, are just answered.
+	if answer, err := svc.Greet(context.Background(), "conv-1", "15551234", "Hours"); err != nil || !answer || len(sender.sent) != 1 {
+		t.Errorf("expected a greeted contact to be answered, got %v, %v", answer, err)
+	}
+}
+
+func TestOnboardingAnswerFirstAndDisabled(t *testing.T) {
+	svc, _, sender := newTestOnboarding(&whatsappDomain.Onboarding{Enabled: true, Welcome: "Welcome {name}!", AnswerFirst: true})
+	if answer, _ := svc.Greet(context.Background(), "conv-1", "15551234", "hours?"); !answer || len(sender.sent) != 1 {
+		t.Errorf("expected a welcome and an answer, got %v after %d messages", answer, len(sender.sent))
+	}
+
+	svc, convs, sender := newTestOnboarding(nil)
+	if answer, _ := svc.Greet(context.Background(), "conv-1", "15551234", "hours?"); !answer || len(sender.sent) != 0 {
+		t.Errorf("expected no welcome while disabled, got %v after %d messages", answer, len(sender.sent))
+	}
+	if convs.conv.Onboarding != "" {
+		t.Errorf("expected onboarding to end while disabled, got %q", convs.conv.Onboarding)
+	}
+}
+
+func TestOnboardingConsent(t *testing.T) {
+	svc, convs, sender := newTestOnboarding(&whatsappDomain.Onboarding{
+		Enabled: true, Welcome: "Welcome!", RequireConsent: true,
+		ConsentPrompt: "May we store your messages?", ConsentAccepted: "Thanks {name}, ask away.",
+	})
+	ctx := context.Background()
+
+	if answer, err := svc.Greet(ctx, "conv-1", "15551234", "hours?"); err != nil || answer {
+		t.Fatalf("expected no answer before consent, got %v, %v", answer, err)
+	}
+	if len(sender.sent) != 2 || sender.sent[1].buttons[0] != "I agree" || convs.conv.Onboarding != conversationDomain.OnboardingConsent {
+		t.Fatalf("expected a welcome and a consent request, got %+v", sender.sent)
+	}
+
+	if answer, _ := svc.Greet(ctx, "conv-1", "15551234", "what about my order"); answer || len(sender.sent) != 3 {
+		t.Errorf("expected the request repeated, got %v after %d messages", answer, len(sender.sent))
+	}
+	if answer, _ := svc.Greet(ctx, "conv-1", "15551234", "No!"); answer || convs.conv.Consent == nil || convs.conv.Consent.Granted {
+		t.Errorf("expected a declined consent, got %+v", convs.conv.Consent)
+	}
+	if answer, _ := svc.Greet(ctx, "conv-1", "15551234", "I agree"); answer || !convs.conv.Consent.Granted || convs.conv.Onboarding != "" {
+		t.Errorf("expected consent granted, got %+v %q", convs.conv.Consent, convs.conv.Onboarding)
+	}
+	if last := sender.sent[len(sender.sent)-1]; last.body != "Thanks Ana, ask away." {
+		t.Errorf("expected the acceptance message, got %q", last.body)
+	}
+	if answer, _ := svc.Greet(ctx, "conv-1", "15551234", "hours?"); !answer {
+		t.Error("expected answers after consent")
+	}
+}
+
+func TestSaveOnboardingValidation(t *testing.T) {
+	svc, _, _ := newTestOnboarding(nil)
+	invalid := []*whatsappDomain.Onboarding{
+		{Enabled: true},
+		{Enabled: true, Welcome: "Hi", Options: []string{"a", "b", "c", "d"}},
+		{Enabled: true, Welcome: "Hi", Options: []string{"This option is far too long"}},
+		{Enabled: true, RequireConsent: true},
+	}
+	for _, onboarding := range invalid {
+		if err := svc.SaveOnboarding(context.Background(), onboarding, "admin"); !errors.Is(err, ErrInvalidOnboarding) {
+			t.Errorf("expected ErrInvalidOnboarding for %+v, got %v", onboarding, err)
+		}
+	}
+
+	if err := svc.SaveOnboarding(context.Background(), &whatsappDomain.Onboarding{Enabled: true, Welcome: " Hi ", Options: []string{" Hours ", ""}}, "admin"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	saved, _ := svc.GetOnboarding(context.Background())
+	if saved.Welcome != "Hi" || len(saved.Options) != 1 || saved.UpdatedBy != "admin" || saved.ConsentAccept != "I agree" {
+		t.Errorf("unexpected settings %+v", saved)
+	}
+}
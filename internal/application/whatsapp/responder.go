@@ -0,0 +1,196 @@
+package whatsapp
+
+import (
+	"context"
+	"strings"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	toolDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/tool"
+	whatsappDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/whatsapp"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+)
+
+// Responder answers incoming WhatsApp text messages, transcribed voice notes
+// and described photos with a RAG reply. It subscribes to MessageReceived so the webhook
+// only has to store messages. When a voice replier is set, answers to voice
+// notes are also sent back as audio. When an attachment replier is set, the
+// file an answer came from follows it if its document is shareable. When an
+// onboarder is set, it greets new contacts before they are answered. When a
+// typing indicator is set, contacts see a reply being typed while it is
+// generated.
+// Conversations handed off to an agent are left to them.
+type Responder struct {
+	convSvc     conversationDomain.Service
+	docSvc      documentDomain.Service
+	voice       whatsappDomain.VoiceReplier
+	attachments whatsappDomain.AttachmentReplier
+	onboarding  whatsappDomain.Onboarder
+	typing      whatsappDomain.TypingIndicator
+	log         *logger.Logger
+
+	// historyWindow is how many earlier messages go into the prompt.
+	historyWindow int
+}
+
+func NewResponder(convSvc conversationDomain.Service, docSvc documentDomain.Service, voice whatsappDomain.VoiceReplier, attachments whatsappDomain.AttachmentReplier, onboarding whatsappDomain.Onboarder, typing whatsappDomain.TypingIndicator, historyWindow int, log *logger.Logger) *Responder {
+	return &Responder{
+		convSvc:       convSvc,
+		docSvc:        docSvc,
+		voice:         voice,
+		attachments:   attachments,
+		onboarding:    onboarding,
+		typing:        typing,
+		log:           log.With("subscriber", "whatsapp_responder"),
+		historyWindow: historyWindow,
+	}
+}
+
+// Subscribe registers the responder on bus.
+func (r *Responder) Subscribe(bus *events.Bus) {
+	bus.Subscribe(r.handle, events.NameMessageReceived)
+}
+
+func (r *Responder) handle(ctx context.Context, event events.Event) {
+	msg, ok := event.(events.MessageReceived)
+	if !ok || (msg.MessageType != "text" && msg.MessageType != "interactive" && msg.MessageType != "audio" && msg.MessageType != "image") {
+		return
+	}
+	query := queryFor(msg)
+	// Voice notes that could not be transcribed and uncaptioned photos that
+	// could not be described arrive without anything to answer.
+	if query == "" {
+		return
+	}
+	// Conversations created before channels were recorded are WhatsApp ones.
+	if msg.Channel != "" && msg.Channel != conversationDomain.ChannelWhatsApp {
+		return
+	}
+	// Agents answer conversations handed off to them.
+	if msg.HumanMode {
+		return
+	}
+
+	if r.onboarding != nil {
+		answer, err := r.onboarding.Greet(ctx, msg.ConversationID, msg.From, query)
+		if err != nil {
+			r.log.ErrorContext(ctx, "failed to onboard contact", "error", err, "conversation_id", msg.ConversationID)
+		}
+		if !answer {
+			return
+		}
+	}
+
+	ragQuery := documentDomain.RAGQuery{
+		Query:     query,
+		TopK:      5,
+		Threshold: 0.7,
+		Channel:   documentDomain.ChannelWhatsApp,
+	}
+	r.addHistory(ctx, &ragQuery, msg)
+
+	stopTyping := r.startTyping(ctx, msg)
+	// Tools such as booking act on the conversation being answered.
+	ragResponse, err := r.docSvc.QueryRAG(toolDomain.WithConversation(ctx, msg.ConversationID), ragQuery)
+	stopTyping()
+	if err != nil {
+		r.log.ErrorContext(ctx, "failed to query RAG", "error", err, "conversation_id", msg.ConversationID)
+		return
+	}
+
+	if _, err := r.convSvc.SaveOutgoingMessage(ctx, msg.ConversationID, ragResponse.Answer, ragResponse.Answer); err != nil {
+		r.log.ErrorContext(ctx, "failed to save outgoing message", "error", err, "conversation_id", msg.ConversationID)
+		return
+	}
+
+	r.log.InfoContext(ctx, "RAG response saved",
+		"conversation_id", msg.ConversationID,
+		"confidence", ragResponse.ConfidenceScore,
+		"processing_time_ms", ragResponse.ProcessingTimeMs,
+	)
+
+	// The text reply is already stored, so a failed voice reply only loses
+	// the audio copy.
+	if msg.MessageType == "audio" && r.voice != nil {
+		if err := r.voice.Reply(ctx, msg.From, ragResponse.Answer); err != nil {
+			r.log.ErrorContext(ctx, "failed to send voice reply", "error", err, "conversation_id", msg.ConversationID)
+		} else {
+			r.log.InfoContext(ctx, "voice reply sent", "conversation_id", msg.ConversationID)
+		}
+	}
+
+	if r.attachments != nil && len(ragResponse.RelevantChunks) > 0 {
+		sent, err := r.attachments.Reply(ctx, msg.From, ragResponse.RelevantChunks)
+		if err != nil {
+			r.log.ErrorContext(ctx, "failed to send attachment", "error", err, "conversation_id", msg.ConversationID)
+			return
+		}
+		if sent {
+			r.log.InfoContext(ctx, "attachment sent", "conversation_id", msg.ConversationID)
+		}
+	}
+}
+
+// addHistory gives the query the conversation so far, without the message
+// being answered, and the contact's variables. Without history the question
+// is still answered on its own.
+func (r *Responder) addHistory(ctx context.Context, query *documentDomain.RAGQuery, msg events.MessageReceived) {
+	limit := 0
+	if r.historyWindow > 0 {
+		limit = r.historyWindow + 1
+	}
+
+	history, err := r.convSvc.GetHistory(ctx, msg.ConversationID, limit)
+	if err != nil {
+		r.log.WarnContext(ctx, "failed to load conversation history", "error", err, "conversation_id", msg.ConversationID)
+		return
+	}
+
+	query.CustomerContext = history.Variables
+	if r.historyWindow <= 0 {
+		return
+	}
+
+	query.Summary = history.Summary
+	for _, m := range history.Messages {
+		if m.ID == msg.MessageID {
+			continue
+		}
+		role := "user"
+		if m.Direction == conversationDomain.DirectionOutgoing {
+			role = "assistant"
+		}
+		query.History = append(query.History, documentDomain.Turn{Role: role, Content: m.PromptText()})
+	}
+	if len(query.History) > r.historyWindow {
+		query.History = query.History[len(query.History)-r.historyWindow:]
+	}
+}
+
+// queryFor builds the RAG query for msg. For photos the caption is the
+// question and the description supplies what the photo shows.
+func queryFor(msg events.MessageReceived) string {
+	content := strings.TrimSpace(msg.Content)
+	description := strings.TrimSpace(msg.MediaDescription)
+	switch {
+	case description == "":
+		return content
+	case content == "":
+		return "The customer sent a photo. It shows: " + description
+	}
+	return content + "\n\nThe customer attached a photo. It shows: " + description
+}
+
+// startTyping shows the contact a reply is being typed, returning the
+// function that stops it. The answer goes out whether or not it shows.
+func (r *Responder) startTyping(ctx context.Context, msg events.MessageReceived) func() {
+	if r.typing == nil || msg.WhatsAppMsgID == "" {
+		return func() {}
+	}
+	stop, err := r.typing.Start(ctx, msg.WhatsAppMsgID)
+	if err != nil {
+		r.log.WarnContext(ctx, "failed to send typing indicator", "error", err, "conversation_id", msg.ConversationID)
+	}
+	return stop
+}
@@ -0,0 +1,22 @@
+package whatsapp
+
+import "context"
+
+// TextClient sends text messages from a business phone number.
+type TextClient interface {
+	SendText(ctx context.Context, phoneNumberID, to, body string) error
+}
+
+// TextSender sends text messages from one business phone number.
+type TextSender struct {
+	client        TextClient
+	phoneNumberID string
+}
+
+func NewTextSender(client TextClient, phoneNumberID string) *TextSender {
+	return &TextSender{client: client, phoneNumberID: phoneNumberID}
+}
+
+func (s *TextSender) SendText(ctx context.Context, to, body string) error {
+	return s.client.SendText(ctx, s.phoneNumberID, to, body)
+}
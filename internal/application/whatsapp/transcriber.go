@@ -0,0 +1,64 @@
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+)
+
+var ErrUnsupportedAudio = errors.New("unsupported audio format")
+
+// MediaDownloader fetches media sent to the business number.
+type MediaDownloader interface {
+	DownloadMedia(ctx context.Context, mediaID string) ([]byte, string, error)
+}
+
+// SpeechToText converts recorded speech to text.
+type SpeechToText interface {
+	CreateTranscription(ctx context.Context, audio io.Reader, filename string, model string) (string, error)
+}
+
+// audioExtensions maps the audio types WhatsApp delivers to extensions the
+// transcription API recognises.
+var audioExtensions = map[string]string{
+	"audio/ogg":  "ogg",
+	"audio/opus": "ogg",
+	"audio/mpeg": "mp3",
+	"audio/mp4":  "m4a",
+	"audio/aac":  "m4a",
+	"audio/wav":  "wav",
+	"audio/webm": "webm",
+}
+
+type Transcriber struct {
+	media MediaDownloader
+	stt   SpeechToText
+	model string
+}
+
+func NewTranscriber(media MediaDownloader, stt SpeechToText, model string) *Transcriber {
+	return &Transcriber{media: media, stt: stt, model: model}
+}
+
+func (t *Transcriber) Transcribe(ctx context.Context, mediaID string) (string, error) {
+	data, mimeType, err := t.media.DownloadMedia(ctx, mediaID)
+	if err != nil {
+		return "", fmt.Errorf("download media %s: %w", mediaID, err)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(mimeType)
+	ext, ok := audioExtensions[mediaType]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedAudio, mimeType)
+	}
+
+	text, err := t.stt.CreateTranscription(ctx, bytes.NewReader(data), "voice."+ext, t.model)
+	if err != nil {
+		return "", fmt.Errorf("transcribe media %s: %w", mediaID, err)
+	}
+	return strings.TrimSpace(text), nil
+}
@@ -0,0 +1,63 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+type mockMedia struct {
+	data     []byte
+	mimeType string
+	err      error
+}
+
+func (m *mockMedia) DownloadMedia(ctx context.Context, mediaID string) ([]byte, string, error) {
+	return m.data, m.mimeType, m.err
+}
+
+type mockSpeechToText struct {
+	filename string
+	model    string
+	audio    []byte
+}
+
+func (m *mockSpeechToText) CreateTranscription(ctx context.Context, audio io.Reader, filename string, model string) (string, error) {
+	m.filename, m.model = filename, model
+	m.audio, _ = io.ReadAll(audio)
+	return "  where is my order?\n", nil
+}
+
+func TestTranscribe_VoiceNote(t *testing.T) {
+	stt := &mockSpeechToText{}
+	tr := NewTranscriber(&mockMedia{data: []byte("OggS"), mimeType: "audio/ogg; codecs=opus"}, stt, "whisper-1")
+
+	text, err := tr.Transcribe(context.Background(), "media-1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if text != "where is my order?" {
+		t.Errorf("expected trimmed transcript, got %q", text)
+	}
+	if stt.filename != "voice.ogg" || stt.model != "whisper-1" || string(stt.audio) != "OggS" {
+		t.Errorf("unexpected transcription call: %s %s %q", stt.filename, stt.model, stt.audio)
+	}
+}
+
+func TestTranscribe_UnsupportedFormat(t *testing.T) {
+	tr := NewTranscriber(&mockMedia{data: []byte("#!AMR"), mimeType: "audio/amr"}, &mockSpeechToText{}, "")
+
+	if _, err := tr.Transcribe(context.Background(), "media-1"); !errors.Is(err, ErrUnsupportedAudio) {
+		t.Errorf("expected ErrUnsupportedAudio, got %v", err)
+	}
+}
+
+func TestTranscribe_DownloadError(t *testing.T) {
+	downloadErr := errors.New("expired")
+	tr := NewTranscriber(&mockMedia{err: downloadErr}, &mockSpeechToText{}, "")
+
+	if _, err := tr.Transcribe(context.Background(), "media-1"); !errors.Is(err, downloadErr) {
+		t.Errorf("expected download error, got %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package whatsapp
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// typingRefresh is how often the typing indicator is shown again while a
+// reply is generated. WhatsApp hides it after 25 seconds.
+const typingRefresh = 20 * time.Second
+
+// TypingSender shows a typing indicator from a business phone number.
+type TypingSender interface {
+	SendTyping(ctx context.Context, phoneNumberID, messageID string) error
+}
+
+type TypingIndicator struct {
+	sender        TypingSender
+	phoneNumberID string
+	refresh       time.Duration
+}
+
+func NewTypingIndicator(sender TypingSender, phoneNumberID string) *TypingIndicator {
+	return &TypingIndicator{sender: sender, phoneNumberID: phoneNumberID, refresh: typingRefresh}
+}
+
+// Start shows the indicator and keeps it up until stop is called. Only the
+// first send's error is reported; a failed refresh just lets it lapse.
+func (t *TypingIndicator) Start(ctx context.Context, messageID string) (func(), error) {
+	if err := t.sender.SendTyping(ctx, t.phoneNumberID, messageID); err != nil {
+		return func() {}, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(t.refresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_ = t.sender.SendTyping(ctx, t.phoneNumberID, messageID)
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}, nil
+}
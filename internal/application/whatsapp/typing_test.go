@@ -0,0 +1,61 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mockTypingSender struct {
+	mu        sync.Mutex
+	messageID string
+	sent      int
+	err       error
+}
+
+func (m *mockTypingSender) SendTyping(ctx context.Context, phoneNumberID, messageID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messageID = messageID
+	m.sent++
+	return m.err
+}
+
+func (m *mockTypingSender) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sent
+}
+
+func TestTypingIndicator(t *testing.T) {
+	sender := &mockTypingSender{}
+	typing := NewTypingIndicator(sender, "phone-1")
+	typing.refresh = 5 * time.Millisecond
+
+	stop, err := typing.Start(context.Background(), "wamid.in")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	stop()
+
+	sent := sender.count()
+	if sent < 2 || sender.messageID != "wamid.in" {
+		t.Errorf("Expected the indicator to be refreshed for wamid.in, got %d sends for %q", sent, sender.messageID)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if sender.count() != sent {
+		t.Error("Expected no refreshes after stop")
+	}
+}
+
+func TestTypingIndicatorSendError(t *testing.T) {
+	sender := &mockTypingSender{err: errors.New("unavailable")}
+	stop, err := NewTypingIndicator(sender, "phone-1").Start(context.Background(), "wamid.in")
+	if err == nil {
+		t.Error("Expected the send error")
+	}
+	stop()
+}
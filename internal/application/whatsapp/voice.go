@@ -0,0 +1,61 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrEmptyReply = errors.New("nothing to speak")
+
+// maxSpeechChars is the longest input the speech endpoint accepts.
+const maxSpeechChars = 4096
+
+// SpeechSynthesizer converts text to encoded audio.
+type SpeechSynthesizer interface {
+	CreateSpeech(ctx context.Context, text, voice, format, model string) ([]byte, error)
+}
+
+// AudioSender uploads audio and sends it from a business phone number.
+type AudioSender interface {
+	UploadMedia(ctx context.Context, phoneNumberID string, data []byte, mimeType, filename string) (string, error)
+	SendAudio(ctx context.Context, phoneNumberID, to, mediaID string) error
+}
+
+type VoiceReplier struct {
+	tts           SpeechSynthesizer
+	sender        AudioSender
+	phoneNumberID string
+	voice         string
+	model         string
+}
+
+func NewVoiceReplier(tts SpeechSynthesizer, sender AudioSender, phoneNumberID, voice, model string) *VoiceReplier {
+	return &VoiceReplier{tts: tts, sender: sender, phoneNumberID: phoneNumberID, voice: voice, model: model}
+}
+
+// Reply speaks text to the user as an Ogg/Opus voice message, the format
+// WhatsApp plays inline.
+func (v *VoiceReplier) Reply(ctx context.Context, to, text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return ErrEmptyReply
+	}
+	if runes := []rune(text); len(runes) > maxSpeechChars {
+		text = string(runes[:maxSpeechChars])
+	}
+
+	audio, err := v.tts.CreateSpeech(ctx, text, v.voice, "opus", v.model)
+	if err != nil {
+		return fmt.Errorf("synthesize reply: %w", err)
+	}
+	mediaID, err := v.sender.UploadMedia(ctx, v.phoneNumberID, audio, "audio/ogg", "reply.ogg")
+	if err != nil {
+		return fmt.Errorf("upload reply: %w", err)
+	}
+	if err := v.sender.SendAudio(ctx, v.phoneNumberID, to, mediaID); err != nil {
+		return fmt.Errorf("send reply: %w", err)
+	}
+	return nil
+}
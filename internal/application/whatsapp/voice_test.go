@@ -0,0 +1,67 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type mockTTS struct {
+	text   string
+	format string
+}
+
+func (m *mockTTS) CreateSpeech(ctx context.Context, text, voice, format, model string) ([]byte, error) {
+	m.text, m.format = text, format
+	return []byte("OggS"), nil
+}
+
+type mockAudioSender struct {
+	uploaded []byte
+	mimeType string
+	to       string
+	mediaID  string
+	sendErr  error
+}
+
+func (m *mockAudioSender) UploadMedia(ctx context.Context, phoneNumberID string, data []byte, mimeType, filename string) (string, error) {
+	m.uploaded, m.mimeType = data, mimeType
+	return "media-9", nil
+}
+
+func (m *mockAudioSender) SendAudio(ctx context.Context, phoneNumberID, to, mediaID string) error {
+	m.to, m.mediaID = to, mediaID
+	return m.sendErr
+}
+
+func TestVoiceReply(t *testing.T) {
+	tts := &mockTTS{}
+	sender := &mockAudioSender{}
+	v := NewVoiceReplier(tts, sender, "phone-1", "", "")
+
+	if err := v.Reply(context.Background(), "15551234", " Your order ships today. "); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if tts.text != "Your order ships today." || tts.format != "opus" {
+		t.Errorf("expected trimmed opus synthesis, got %q as %s", tts.text, tts.format)
+	}
+	if string(sender.uploaded) != "OggS" || sender.mimeType != "audio/ogg" {
+		t.Errorf("expected ogg upload, got %q as %s", sender.uploaded, sender.mimeType)
+	}
+	if sender.to != "15551234" || sender.mediaID != "media-9" {
+		t.Errorf("expected uploaded media sent to sender, got %s to %s", sender.mediaID, sender.to)
+	}
+}
+
+func TestVoiceReply_Errors(t *testing.T) {
+	v := NewVoiceReplier(&mockTTS{}, &mockAudioSender{}, "phone-1", "", "")
+	if err := v.Reply(context.Background(), "15551234", "  "); !errors.Is(err, ErrEmptyReply) {
+		t.Errorf("expected ErrEmptyReply, got %v", err)
+	}
+
+	sendErr := errors.New("recipient not reachable")
+	v = NewVoiceReplier(&mockTTS{}, &mockAudioSender{sendErr: sendErr}, "phone-1", "", "")
+	if err := v.Reply(context.Background(), "15551234", "hello"); !errors.Is(err, sendErr) {
+		t.Errorf("expected send error, got %v", err)
+	}
+}
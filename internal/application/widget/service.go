@@ -0,0 +1,250 @@
+package widget
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	integrationApp "github.com/elprogramadorgt/lucidRAG/internal/application/integration"
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	integrationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/integration"
+	widgetDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/widget"
+	"github.com/elprogramadorgt/lucidRAG/pkg/cache"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrInvalidKey       = errors.New("invalid widget key")
+	ErrOriginNotAllowed = errors.New("origin not allowed")
+	ErrInvalidSession   = errors.New("invalid or expired session")
+	ErrSessionLimit     = errors.New("session question limit reached")
+	ErrInvalidQuestion  = errors.New("invalid question")
+)
+
+const (
+	maxQuestionChars = 1000
+	// historyTurns is how many earlier turns of a session go into the
+	// prompt.
+	historyTurns = 6
+	// audience keeps session tokens from being accepted as user tokens, and
+	// the other way around, should the signing keys ever match.
+	audience = "widget"
+)
+
+// KeyAuthenticator resolves a widget key.
+type KeyAuthenticator interface {
+	AuthenticateWidget(ctx context.Context, key string) (*integrationDomain.APIKey, error)
+}
+
+type service struct {
+	keys    KeyAuthenticator
+	keyRepo integrationDomain.APIKeyRepository
+	docSvc  documentDomain.Service
+	cache   cache.Cache
+	secret  []byte
+
+	sessionTTL   time.Duration
+	maxQuestions int
+	log          *logger.Logger
+}
+
+type ServiceConfig struct {
+	Keys KeyAuthenticator
+	// KeyRepo looks up the key of a session, so revoked keys and removed
+	// origins take effect at once, and records usage.
+	KeyRepo integrationDomain.APIKeyRepository
+	DocSvc  documentDomain.Service
+	// Cache holds session question counts and recent turns; it must be
+	// shared between replicas for the caps to hold across them.
+	Cache cache.Cache
+	// Secret signs session tokens. A key for them is derived from it, so the
+	// JWT secret can be reused.
+	Secret       string
+	SessionTTL   time.Duration
+	MaxQuestions int
+	// Log receives warnings about best-effort steps that failed; defaults
+	// to standard output.
+	Log *logger.Logger
+}
+
+func NewService(cfg ServiceConfig) widgetDomain.Service {
+	secret := sha256.Sum256([]byte("widget-session:" + cfg.Secret))
+	log := cfg.Log
+	if log == nil {
+		log = logger.New()
+	}
+	return &service{
+		keys:         cfg.Keys,
+		keyRepo:      cfg.KeyRepo,
+		docSvc:       cfg.DocSvc,
+		cache:        cfg.Cache,
+		secret:       secret[:],
+		sessionTTL:   cfg.SessionTTL,
+		maxQuestions: cfg.MaxQuestions,
+		log:          log.With("service", "widget"),
+	}
+}
+
+type sessionClaims struct {
+	KeyID  string `json:"kid"`
+	Origin string `json:"origin"`
+	jwt.RegisteredClaims
+}
+
+func (s *service) StartSession(ctx context.Context, rawKey, origin string) (*widgetDomain.Session, string, error) {
+	key, err := s.keys.AuthenticateWidget(ctx, rawKey)
+	if errors.Is(err, integrationApp.ErrInvalidAPIKey) {
+		return nil, "", ErrInvalidKey
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	origin = normalizeOrigin(origin)
+	if !slices.Contains(key.AllowedOrigins, origin) {
+		return nil, "", ErrOriginNotAllowed
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, "", err
+	}
+	now := time.Now()
+	session := &widgetDomain.Session{
+		ID:           hex.EncodeToString(id),
+		KeyID:        key.ID,
+		Origin:       origin,
+		ExpiresAt:    now.Add(s.sessionTTL),
+		MaxQuestions: s.maxQuestions,
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, sessionClaims{
+		KeyID:  session.KeyID,
+		Origin: session.Origin,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        session.ID,
+			Audience:  jwt.ClaimStrings{audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(session.ExpiresAt),
+		},
+	}).SignedString(s.secret)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to sign session token: %w", err)
+	}
+	s.recordUsage(ctx, key.ID, 1, 0)
+	return session, token, nil
+}
+
+func (s *service) Ask(ctx context.Context, token, origin, question string) (*widgetDomain.Reply, error) {
+	session, err := s.parse(token)
+	if err != nil {
+		return nil, err
+	}
+	// A token copied out of the page cannot be used from elsewhere.
+	if normalizeOrigin(origin) != session.Origin {
+		return nil, ErrOriginNotAllowed
+	}
+	key, err := s.keyRepo.GetByID(ctx, session.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil || key.Kind != integrationDomain.KeyKindWidget {
+		return nil, ErrInvalidSession
+	}
+	if !slices.Contains(key.AllowedOrigins, session.Origin) {
+		return nil, ErrOriginNotAllowed
+	}
+
+	question = strings.TrimSpace(question)
+	if question == "" || utf8.RuneCountInString(question) > maxQuestionChars {
+		return nil, fmt.Errorf("%w: message must be 1-%d characters", ErrInvalidQuestion, maxQuestionChars)
+	}
+
+	ttl := time.Until(session.ExpiresAt)
+	asked, countErr := s.cache.Incr(ctx, "widget:questions:"+session.ID, ttl)
+	if countErr != nil {
+		// Like the rate limiter, fail open rather than take the widget down
+		// with the cache.
+		s.log.WarnContext(ctx, "failed to count widget question", "error", countErr, "session_id", session.ID)
+	} else if asked > int64(s.maxQuestions) {
+		return nil, ErrSessionLimit
+	}
+
+	historyKey := "widget:history:" + session.ID
+	var history []documentDomain.Turn
+	if _, err := cache.GetJSON(ctx, s.cache, historyKey, &history); err != nil {
+		s.log.WarnContext(ctx, "failed to load widget history", "error", err, "session_id", session.ID)
+	}
+
+	resp, err := s.docSvc.QueryRAG(ctx, documentDomain.RAGQuery{
+		Query:     question,
+		TopK:      5,
+		Threshold: 0.7,
+		Channel:   documentDomain.ChannelWeb,
+		History:   history,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	history = append(history,
+		documentDomain.Turn{Role: "user", Content: question},
+		documentDomain.Turn{Role: "assistant", Content: resp.Answer},
+	)
+	if len(history) > historyTurns {
+		history = history[len(history)-historyTurns:]
+	}
+	if err := cache.SetJSON(ctx, s.cache, historyKey, history, ttl); err != nil {
+		s.log.WarnContext(ctx, "failed to save widget history", "error", err, "session_id", session.ID)
+	}
+
+	s.recordUsage(ctx, key.ID, 0, 1)
+
+	remaining := s.maxQuestions - int(asked)
+	if countErr != nil {
+		remaining = s.maxQuestions
+	}
+	return &widgetDomain.Reply{Answer: resp.Answer, Remaining: remaining}, nil
+}
+
+// recordUsage adds to the key's usage counters. Usage is informational, so
+// failures only warn.
+func (s *service) recordUsage(ctx context.Context, keyID string, sessions, questions int64) {
+	if err := s.keyRepo.IncrementUsage(ctx, keyID, sessions, questions); err != nil {
+		s.log.WarnContext(ctx, "failed to record widget key usage", "error", err, "key_id", keyID)
+	}
+}
+
+// parse verifies a session token.
+func (s *service) parse(token string) (*widgetDomain.Session, error) {
+	parsed, err := jwt.ParseWithClaims(token, &sessionClaims{}, func(t *jwt.Token) (interface{}, error) {
+		return s.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithAudience(audience), jwt.WithExpirationRequired())
+	if err != nil || !parsed.Valid {
+		return nil, ErrInvalidSession
+	}
+	claims, ok := parsed.Claims.(*sessionClaims)
+	if !ok || claims.ID == "" {
+		return nil, ErrInvalidSession
+	}
+	return &widgetDomain.Session{
+		ID:           claims.ID,
+		KeyID:        claims.KeyID,
+		Origin:       claims.Origin,
+		ExpiresAt:    claims.ExpiresAt.Time,
+		MaxQuestions: s.maxQuestions,
+	}, nil
+}
+
+// normalizeOrigin makes a request's origin comparable with the normalized
+// origins stored on keys.
+func normalizeOrigin(origin string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(origin)), "/")
+}
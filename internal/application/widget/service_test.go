@@ -0,0 +1,155 @@
+package widget
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	integrationApp "github.com/elprogramadorgt/lucidRAG/internal/application/integration"
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	integrationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/integration"
+	widgetDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/widget"
+	"github.com/elprogramadorgt/lucidRAG/pkg/cache"
+)
+
+type mockKeys struct {
+	integrationDomain.APIKeyRepository
+	key                 *integrationDomain.APIKey
+	sessions, questions int64
+}
+
+func newMockKeys() *mockKeys {
+	return &mockKeys{key: &integrationDomain.APIKey{
+		ID:             "key-1",
+		Kind:           integrationDomain.KeyKindWidget,
+		AllowedOrigins: []string{"https://www.example.com"},
+	}}
+}
+
+func (m *mockKeys) AuthenticateWidget(ctx context.Context, key string) (*integrationDomain.APIKey, error) {
+	if key != "lrw_valid" || m.key == nil {
+		return nil, integrationApp.ErrInvalidAPIKey
+	}
+	return m.key, nil
+}
+
+func (m *mockKeys) GetByID(ctx context.Context, id string) (*integrationDomain.APIKey, error) {
+	if m.key == nil || m.key.ID != id {
+		return nil, nil
+	}
+	return m.key, nil
+}
+
+func (m *mockKeys) IncrementUsage(ctx context.Context, id string, sessions, questions int64) error {
+	m.sessions += sessions
+	m.questions += questions
+	return nil
+}
+
+type mockDocService struct {
+	documentDomain.Service
+	queries []documentDomain.RAGQuery
+}
+
+func (m *mockDocService) QueryRAG(ctx context.Context, query documentDomain.RAGQuery) (*documentDomain.RAGResponse, error) {
+	m.queries = append(m.queries, query)
+	return &documentDomain.RAGResponse{Answer: "Answer to " + query.Query}, nil
+}
+
+func newTestService(ttl time.Duration) (widgetDomain.Service, *mockKeys, *mockDocService) {
+	keys := newMockKeys()
+	docSvc := &mockDocService{}
+	return NewService(ServiceConfig{
+		Keys:         keys,
+		KeyRepo:      keys,
+		DocSvc:       docSvc,
+		Cache:        cache.NewMemory(),
+		Secret:       "jwt-secret",
+		SessionTTL:   ttl,
+		MaxQuestions: 2,
+	}), keys, docSvc
+}
+
+func TestStartSession(t *testing.T) {
+	svc, keys, _ := newTestService(time.Minute)
+	ctx := context.Background()
+
+	if _, _, err := svc.StartSession(ctx, "lrw_valid", "https://evil.example.net"); !errors.Is(err, ErrOriginNotAllowed) {
+		t.Errorf("Expected ErrOriginNotAllowed, got %v", err)
+	}
+	if _, _, err := svc.StartSession(ctx, "lrw_other", "https://www.example.com"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey, got %v", err)
+	}
+
+	session, token, err := svc.StartSession(ctx, "lrw_valid", "https://www.example.com")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if token == "" || session.KeyID != "key-1" || session.MaxQuestions != 2 {
+		t.Errorf("Unexpected session %+v", session)
+	}
+	if keys.sessions != 1 {
+		t.Errorf("Expected 1 recorded session, got %d", keys.sessions)
+	}
+}
+
+func TestAsk(t *testing.T) {
+	svc, keys, docSvc := newTestService(time.Minute)
+	ctx := context.Background()
+	_, token, _ := svc.StartSession(ctx, "lrw_valid", "https://www.example.com")
+
+	if _, err := svc.Ask(ctx, token+"x", "https://www.example.com", "hi"); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("Expected ErrInvalidSession for a tampered token, got %v", err)
+	}
+	if _, err := svc.Ask(ctx, token, "https://evil.example.net", "hi"); !errors.Is(err, ErrOriginNotAllowed) {
+		t.Errorf("Expected ErrOriginNotAllowed from another origin, got %v", err)
+	}
+
+	reply, err := svc.Ask(ctx, token, "https://www.example.com", "What are your prices?")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if reply.Remaining != 1 {
+		t.Errorf("Expected 1 remaining question, got %d", reply.Remaining)
+	}
+
+	if _, err := svc.Ask(ctx, token, "https://www.example.com", "And yearly?"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if history := docSvc.queries[1].History; len(history) != 2 || history[0].Content != "What are your prices?" {
+		t.Errorf("Expected the session's earlier turn as history, got %+v", history)
+	}
+
+	if _, err := svc.Ask(ctx, token, "https://www.example.com", "One more?"); !errors.Is(err, ErrSessionLimit) {
+		t.Errorf("Expected ErrSessionLimit, got %v", err)
+	}
+	if keys.questions != 2 {
+		t.Errorf("Expected 2 recorded questions, got %d", keys.questions)
+	}
+}
+
+func TestAskAfterKeyChanges(t *testing.T) {
+	svc, keys, _ := newTestService(time.Minute)
+	ctx := context.Background()
+	_, token, _ := svc.StartSession(ctx, "lrw_valid", "https://www.example.com")
+
+	keys.key.AllowedOrigins = []string{"https://shop.example.com"}
+	if _, err := svc.Ask(ctx, token, "https://www.example.com", "hi"); !errors.Is(err, ErrOriginNotAllowed) {
+		t.Errorf("Expected ErrOriginNotAllowed after the origin was removed, got %v", err)
+	}
+
+	keys.key = nil
+	if _, err := svc.Ask(ctx, token, "https://www.example.com", "hi"); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("Expected ErrInvalidSession after the key was revoked, got %v", err)
+	}
+}
+
+func TestAskExpiredSession(t *testing.T) {
+	svc, _, _ := newTestService(-time.Minute)
+	_, token, _ := svc.StartSession(context.Background(), "lrw_valid", "https://www.example.com")
+
+	if _, err := svc.Ask(context.Background(), token, "https://www.example.com", "hi"); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("Expected ErrInvalidSession for an expired token, got %v", err)
+	}
+}
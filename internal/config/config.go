@@ -2,17 +2,205 @@ package config
 
 import (
 	"fmt"
+	"net/mail"
+	"net/netip"
 	"os"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/pkg/asn"
+	"github.com/elprogramadorgt/lucidRAG/pkg/i18n"
 )
 
 // Config holds the application configuration
 type Config struct {
-	Server    ServerConfig
-	WhatsApp  WhatsAppConfig
-	RAG       RAGConfig
-	Database  DatabaseConfig
-	Auth      AuthConfig
+	Server     ServerConfig
+	WhatsApp   WhatsAppConfig
+	RAG        RAGConfig
+	Database   DatabaseConfig
+	Auth       AuthConfig
+	Cache      CacheConfig
+	Objects    ObjectStoreConfig
+	Extract    ExtractConfig
+	CRM        CRMConfig
+	Slack      SlackConfig
+	Email      EmailConfig
+	Widget     WidgetConfig
+	Transcript TranscriptConfig
+	SLA        SLAConfig
+	Topics     TopicsConfig
+	Retention  RetentionConfig
+	Digest     DigestConfig
+	Booking    BookingConfig
+	Tools      ToolsConfig
+	RateLimit  RateLimitConfig
+	Abuse      AbuseConfig
+	Register   RegisterConfig
+}
+
+// CacheConfig holds cache backend configuration
+type CacheConfig struct {
+	Driver        string
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+}
+
+// ObjectStoreConfig holds object storage configuration, used for backups
+type ObjectStoreConfig struct {
+	Driver      string
+	Dir         string
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+}
+
+// ExtractConfig holds text extraction configuration for uploaded files.
+// PDFs are read with poppler's pdftotext and pdftoppm and scans are OCRed
+// with tesseract; the paths default to looking the tools up on PATH.
+type ExtractConfig struct {
+	OCREnabled    bool
+	OCRLanguage   string
+	OCRMinChars   int
+	TesseractPath string
+	PdftotextPath string
+	PdftoppmPath  string
+}
+
+// CRMConfig holds CRM contact sync configuration. Connections are managed
+// through the admin API; EncryptionKey protects their stored credentials and
+// must stay the same for them to remain readable.
+type CRMConfig struct {
+	EncryptionKey string
+	SyncSchedule  string
+}
+
+// SlackConfig holds Slack app configuration. The channel is enabled when
+// both the signing secret and bot token are set.
+type SlackConfig struct {
+	SigningSecret string
+	BotToken      string
+}
+
+// EmailConfig holds email channel configuration. The channel is enabled when
+// InboundToken is set; answers are sent through the SMTP server as From.
+type EmailConfig struct {
+	InboundToken string
+	// ReplyMode is "draft" to queue every answer for approval or "auto" to
+	// send confident ones right away.
+	ReplyMode    string
+	From         string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+}
+
+// TranscriptConfig brands conversation transcripts. They are emailed
+// through the SMTP server in EmailConfig when SMTPHost is set.
+type TranscriptConfig struct {
+	BrandName string
+	// BrandColor is a #rrggbb color for headings.
+	BrandColor string
+}
+
+// SLAConfig holds the targets for conversations handed off to agents: how
+// soon they first reply and resolve them. Zero disables a target.
+type SLAConfig struct {
+	FirstResponseMinutes int
+	ResolutionHours      int
+}
+
+// TopicsConfig holds the clustering of recent questions into topics. The
+// job runs on Schedule when OpenAI is configured; questions older than
+// WindowDays are left out and deleted.
+type TopicsConfig struct {
+	Schedule   string
+	WindowDays int
+	MaxTopics  int
+}
+
+// DigestConfig holds when admins' email digests go out: at Hour in each
+// admin's own time zone, weekly ones on Weekday.
+type DigestConfig struct {
+	Hour    int
+	Weekday time.Weekday
+}
+
+// BookingConfig holds appointment booking configuration. Resources with a
+// Google Calendar are checked against it and booked into it with the service
+// account in CalendarCredentialsFile. Contacts are reminded of bookings
+// ReminderHours before they start; zero disables reminders.
+type BookingConfig struct {
+	CalendarCredentialsFile string
+	ReminderHours           int
+}
+
+// ToolsConfig holds answer tool configuration. Lookup tools may only call
+// LookupHosts: exact host names, or "*.example.com" for any subdomain.
+// Without any, lookup tools cannot be created.
+type ToolsConfig struct {
+	LookupHosts []string
+}
+
+// RateLimitConfig holds the API-wide rate limit: PerMinute requests per
+// signed-in user, or per IP for everyone else. RoleMultipliers scale it by
+// the user's role. Allow-listed IPs or CIDR ranges, user IDs and
+// integration API key IDs are not limited.
+type RateLimitConfig struct {
+	PerMinute       int
+	AllowIPs        []netip.Prefix
+	AllowUsers      []string
+	AllowAPIKeys    []string
+	RoleMultipliers map[string]float64
+}
+
+// AbuseConfig holds the abuse heuristics for the public widget and webhook
+// endpoints: more than BurstRequests from one IP within BurstWindow (zero
+// turns this off), a widget session used from another country within
+// TravelWindow, or a call from one of BlockedASNs blocks the IP or session
+// for BlockFor. ASNDatabase is an IP-to-ASN table, such as iptoasn.com's
+// ip2asn-combined.tsv, needed for the last two.
+type AbuseConfig struct {
+	BurstRequests int
+	BurstWindow   time.Duration
+	BlockFor      time.Duration
+	TravelWindow  time.Duration
+	ASNDatabase   string
+	BlockedASNs   []uint32
+}
+
+// RegisterConfig holds the bot defenses of open registration: at most
+// PerIP attempts an hour from one IP and PerDomain for one email domain
+// (zero turns a limit off), and a captcha when CaptchaProvider is
+// "hcaptcha" or "turnstile".
+type RegisterConfig struct {
+	PerIP           int
+	PerDomain       int
+	CaptchaProvider string
+	CaptchaSiteKey  string
+	CaptchaSecret   string
+}
+
+// RetentionConfig holds after how many days conversation data is
+// anonymized, by kind; zero keeps it. The job runs on Schedule.
+type RetentionConfig struct {
+	Schedule         string
+	ConversationDays int
+	MessageDays      int
+	NoteDays         int
+}
+
+// WidgetConfig holds public chat widget configuration. Allowed origins are
+// set per widget key; the caps bound what one visitor can ask.
+type WidgetConfig struct {
+	SessionTTL        time.Duration
+	MaxQuestions      int
+	SessionsPerMinute int
+	MessagesPerMinute int
 }
 
 // AuthConfig holds authentication configuration
@@ -21,15 +209,27 @@ type AuthConfig struct {
 	JWTExpiryHours int
 	CookieDomain   string
 	CookieSecure   bool
-	OAuth          OAuthConfig
+	// RevalidateTokens checks each request's user against the database so
+	// deactivation and password changes take effect before token expiry.
+	RevalidateTokens bool
+	// ConfirmTokenTTL is how long the token handed out with the preview of
+	// a destructive admin action stays valid.
+	ConfirmTokenTTL time.Duration
+	// BindAdminDevices makes admins sign in again when their token is used
+	// from another device than it was issued to.
+	BindAdminDevices bool
+	// AdminLoginAlerts emails admins on sign-ins from a new device. It needs
+	// SMTP.
+	AdminLoginAlerts bool
+	OAuth            OAuthConfig
 }
 
 // OAuthConfig holds OAuth provider configurations
 type OAuthConfig struct {
-	RedirectBaseURL    string
-	Google             OAuthProviderConfig
-	Facebook           OAuthProviderConfig
-	Apple              AppleOAuthConfig
+	RedirectBaseURL string
+	Google          OAuthProviderConfig
+	Facebook        OAuthProviderConfig
+	Apple           AppleOAuthConfig
 }
 
 // OAuthProviderConfig holds standard OAuth provider settings
@@ -50,27 +250,149 @@ type AppleOAuthConfig struct {
 
 // ServerConfig holds server-related configuration
 type ServerConfig struct {
-	Port         int
-	Host         string
-	Environment  string
+	Port             int
+	Host             string
+	Environment      string
+	LogRetentionDays int
+	// DefaultLanguage is the language of error messages and bot replies
+	// for requests that do not say, by Accept-Language, which they prefer.
+	DefaultLanguage string
+	// DefaultTimezone is the IANA time zone of the workspace: scheduled jobs
+	// run on its clock and users who never chose a time zone see times in
+	// it.
+	DefaultTimezone string
+	// ConversationAutoCloseDays closes conversations after that many days
+	// without messages; 0 never closes them.
+	ConversationAutoCloseDays int
+	// InstanceID names this replica in leader election and job locks.
+	// Empty means hostname plus a random suffix.
+	InstanceID string
+	// LeaderLeaseSeconds bounds how long the cluster may run without a
+	// leader after the current one dies.
+	LeaderLeaseSeconds int
+	// MetricsSampleSeconds is how often each replica records its memory,
+	// goroutines, database latency and request rate.
+	MetricsSampleSeconds int
+	// ProviderProbeSeconds is how often each replica lists the models of
+	// OpenAI and Ollama to check they are up.
+	ProviderProbeSeconds int
+	// RequestTimeoutSeconds bounds how long a handler may run. Uploads and
+	// document processing get UploadTimeoutSeconds and answer generation
+	// gets RAGTimeoutSeconds.
+	RequestTimeoutSeconds int
+	UploadTimeoutSeconds  int
+	RAGTimeoutSeconds     int
+	// BreakerFailures consecutive failures of OpenAI or WhatsApp make calls
+	// to it fail fast for BreakerCooldownSeconds.
+	BreakerFailures        int
+	BreakerCooldownSeconds int
+	// CompressMinBytes is the size from which responses are gzipped for
+	// clients that accept it; zero turns compression off.
+	CompressMinBytes int
+	// MaintenanceMode switches maintenance mode on at startup, for every
+	// replica, with MaintenanceMessage. Admins switch it off through the
+	// API.
+	MaintenanceMode    bool
+	MaintenanceMessage string
 }
 
 // WhatsAppConfig holds WhatsApp API configuration
 type WhatsAppConfig struct {
-	APIKey      string
-	PhoneNumberID string
-	BusinessAccountID string
+	APIKey             string
+	PhoneNumberID      string
+	BusinessAccountID  string
 	WebhookVerifyToken string
-	APIVersion  string
+	APIVersion         string
+
+	// VoiceReplies sends answers to voice notes back as audio as well as
+	// text. It needs PhoneNumberID to send from.
+	VoiceReplies bool
+	// Attachments sends the file an answer came from after it, when its
+	// document is shareable. It needs PhoneNumberID to send from.
+	Attachments bool
+	// TypingIndicator shows contacts that a reply is being typed while
+	// it is generated. It needs PhoneNumberID to send from.
+	TypingIndicator bool
 }
 
 // RAGConfig holds RAG-related configuration
 type RAGConfig struct {
-	OpenAIAPIKey   string
-	ModelName      string
-	EmbeddingModel string
-	ChunkSize      int
-	ChunkOverlap   int
+	OpenAIAPIKey     string
+	ModelName        string
+	EmbeddingModel   string
+	ChunkSize        int
+	ChunkOverlap     int
+	MaxContextTokens int
+	DedupThreshold   float64
+	AnswerCacheTTL   time.Duration
+
+	// EmbeddingServerURL, when set, makes embeddings on a self-hosted
+	// text-embeddings-inference server instead of OpenAI, so documents
+	// never leave the deployment. EmbeddingModel then defaults to the
+	// server's model. EmbeddingBatchSize of zero uses the server's limit.
+	EmbeddingServerURL    string
+	EmbeddingServerAPIKey string
+	EmbeddingBatchSize    int
+	// MaxDocumentMB bounds a document streamed to /documents/stream.
+	MaxDocumentMB int
+
+	// TranscriptionModel transcribes WhatsApp voice notes.
+	TranscriptionModel string
+	// VisionModel describes photos sent over WhatsApp.
+	VisionModel string
+	// SpeechModel and SpeechVoice read voice replies aloud.
+	SpeechModel string
+	SpeechVoice string
+	// TableRowsPerChunk caps the table and spreadsheet rows in one chunk.
+	TableRowsPerChunk int
+
+	// Guardrails applied to every generated answer. MaxAnswerChars and
+	// MinGroundedness of zero disable their checks.
+	MaxAnswerChars  int
+	BannedPhrases   []string
+	MinGroundedness float64
+	StripUnsourced  bool
+
+	// HistoryMessages is how many earlier messages of a conversation go
+	// into a reply prompt; older ones are folded into a rolling summary
+	// when SummarizeHistory is on.
+	HistoryMessages  int
+	SummarizeHistory bool
+
+	// LowConfidence is the confidence below which answers are reported to
+	// the low-confidence integration trigger.
+	LowConfidence float64
+
+	// FallbackModels answer, in order, when ModelName fails or its
+	// circuit breaker is open. An entry is an OpenAI model, or
+	// "ollama:<model>" for a model served at OllamaBaseURL.
+	FallbackModels []string
+	OllamaBaseURL  string
+	// AllowedModels are OpenAI models admins and API keys may ask for per
+	// query, besides ModelName and the fallbacks.
+	AllowedModels []string
+
+	// Spend limits, in USD and computed from token usage. DailySpendCapUSD
+	// limits answers to free models for the rest of a UTC day once reached;
+	// MaxRequestCostUSD shrinks the context of answers estimated to cost
+	// more. Zero disables either. ModelPrices adds to or replaces the
+	// built-in OpenAI prices.
+	DailySpendCapUSD  float64
+	MaxRequestCostUSD float64
+	ModelPrices       map[string]ModelPrice
+
+	// DuplicateDocuments says what happens to a new document that repeats
+	// an existing one: allow, reject, merge or link. DuplicateSimilarity,
+	// when above zero, also catches near-duplicates whose opening chunk is
+	// at least that similar.
+	DuplicateDocuments  string
+	DuplicateSimilarity float64
+}
+
+// ModelPrice is a model's price in USD per million tokens.
+type ModelPrice struct {
+	Input  float64
+	Output float64
 }
 
 // DatabaseConfig holds database configuration
@@ -81,6 +403,15 @@ type DatabaseConfig struct {
 	Name     string
 	User     string
 	Password string
+	// RetryWindowSeconds is how long repository operations are retried
+	// through transient errors, such as a primary election. 0 disables
+	// the retries.
+	RetryWindowSeconds int
+	// ReadPreference overrides the connection's read preference when set.
+	ReadPreference string
+	// ReadYourWrites reads conversations and messages from the primary,
+	// so they are never stale right after being saved.
+	ReadYourWrites bool
 }
 
 // Load reads configuration from environment variables
@@ -105,18 +436,354 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid RAG_CHUNK_OVERLAP: %w", err)
 	}
 
+	maxContextTokens, err := strconv.Atoi(getEnv("RAG_MAX_CONTEXT_TOKENS", "3000"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RAG_MAX_CONTEXT_TOKENS: %w", err)
+	}
+
+	dedupThreshold, err := strconv.ParseFloat(getEnv("RAG_DEDUP_THRESHOLD", "0.95"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RAG_DEDUP_THRESHOLD: %w", err)
+	}
+
 	jwtExpiry, err := strconv.Atoi(getEnv("JWT_EXPIRY_HOURS", "24"))
 	if err != nil {
 		return nil, fmt.Errorf("invalid JWT_EXPIRY_HOURS: %w", err)
 	}
 
+	confirmTTL, err := strconv.Atoi(getEnv("CONFIRM_TOKEN_TTL_MINUTES", "10"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CONFIRM_TOKEN_TTL_MINUTES: %w", err)
+	}
+
+	answerCacheTTL, err := strconv.Atoi(getEnv("RAG_ANSWER_CACHE_TTL", "300"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RAG_ANSWER_CACHE_TTL: %w", err)
+	}
+
+	logRetentionDays, err := strconv.Atoi(getEnv("LOG_RETENTION_DAYS", "30"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LOG_RETENTION_DAYS: %w", err)
+	}
+
+	autoCloseDays, err := strconv.Atoi(getEnv("CONVERSATION_AUTO_CLOSE_DAYS", "7"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CONVERSATION_AUTO_CLOSE_DAYS: %w", err)
+	}
+
+	slaFirstResponse, err := strconv.Atoi(getEnv("SLA_FIRST_RESPONSE_MINUTES", "15"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SLA_FIRST_RESPONSE_MINUTES: %w", err)
+	}
+
+	slaResolution, err := strconv.Atoi(getEnv("SLA_RESOLUTION_HOURS", "24"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SLA_RESOLUTION_HOURS: %w", err)
+	}
+
+	topicsWindow, err := strconv.Atoi(getEnv("TOPICS_WINDOW_DAYS", "7"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TOPICS_WINDOW_DAYS: %w", err)
+	}
+
+	topicsMax, err := strconv.Atoi(getEnv("TOPICS_MAX", "8"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TOPICS_MAX: %w", err)
+	}
+
+	digestHour, err := strconv.Atoi(getEnv("DIGEST_HOUR", "8"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DIGEST_HOUR: %w", err)
+	}
+
+	digestWeekday, err := parseWeekday(getEnv("DIGEST_WEEKDAY", "monday"))
+	if err != nil {
+		return nil, err
+	}
+
+	bookingReminder, err := strconv.Atoi(getEnv("BOOKING_REMINDER_HOURS", "24"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid BOOKING_REMINDER_HOURS: %w", err)
+	}
+
+	retentionConversations, err := strconv.Atoi(getEnv("RETENTION_CONVERSATION_DAYS", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RETENTION_CONVERSATION_DAYS: %w", err)
+	}
+
+	retentionMessages, err := strconv.Atoi(getEnv("RETENTION_MESSAGE_DAYS", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RETENTION_MESSAGE_DAYS: %w", err)
+	}
+
+	retentionNotes, err := strconv.Atoi(getEnv("RETENTION_NOTE_DAYS", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RETENTION_NOTE_DAYS: %w", err)
+	}
+
+	leaderLeaseSeconds, err := strconv.Atoi(getEnv("LEADER_LEASE_SECONDS", "15"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LEADER_LEASE_SECONDS: %w", err)
+	}
+
+	metricsSampleSeconds, err := strconv.Atoi(getEnv("METRICS_SAMPLE_SECONDS", "60"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid METRICS_SAMPLE_SECONDS: %w", err)
+	}
+
+	providerProbeSeconds, err := strconv.Atoi(getEnv("PROVIDER_PROBE_SECONDS", "300"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PROVIDER_PROBE_SECONDS: %w", err)
+	}
+
+	requestTimeout, err := strconv.Atoi(getEnv("REQUEST_TIMEOUT_SECONDS", "10"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid REQUEST_TIMEOUT_SECONDS: %w", err)
+	}
+
+	uploadTimeout, err := strconv.Atoi(getEnv("UPLOAD_TIMEOUT_SECONDS", "60"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid UPLOAD_TIMEOUT_SECONDS: %w", err)
+	}
+
+	ragTimeout, err := strconv.Atoi(getEnv("RAG_TIMEOUT_SECONDS", "30"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RAG_TIMEOUT_SECONDS: %w", err)
+	}
+
+	dbRetryWindow, err := strconv.Atoi(getEnv("DB_RETRY_WINDOW_SECONDS", "12"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DB_RETRY_WINDOW_SECONDS: %w", err)
+	}
+
+	breakerFailures, err := strconv.Atoi(getEnv("BREAKER_FAILURES", "5"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid BREAKER_FAILURES: %w", err)
+	}
+
+	breakerCooldown, err := strconv.Atoi(getEnv("BREAKER_COOLDOWN_SECONDS", "30"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid BREAKER_COOLDOWN_SECONDS: %w", err)
+	}
+
+	redisDB, err := strconv.Atoi(getEnv("REDIS_DB", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_DB: %w", err)
+	}
+
+	embeddingBatchSize, err := strconv.Atoi(getEnv("RAG_EMBEDDING_BATCH_SIZE", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RAG_EMBEDDING_BATCH_SIZE: %w", err)
+	}
+	// A self-hosted embedding server serves one model, found out at start.
+	embeddingServerURL := getEnv("RAG_EMBEDDING_SERVER_URL", "")
+	defaultEmbeddingModel := "text-embedding-ada-002"
+	if embeddingServerURL != "" {
+		defaultEmbeddingModel = ""
+	}
+
+	compressMinBytes, err := strconv.Atoi(getEnv("COMPRESS_MIN_BYTES", "1024"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid COMPRESS_MIN_BYTES: %w", err)
+	}
+
+	maxDocumentMB, err := strconv.Atoi(getEnv("RAG_MAX_DOCUMENT_MB", "256"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RAG_MAX_DOCUMENT_MB: %w", err)
+	}
+
+	tableRowsPerChunk, err := strconv.Atoi(getEnv("RAG_TABLE_ROWS_PER_CHUNK", "10"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RAG_TABLE_ROWS_PER_CHUNK: %w", err)
+	}
+
+	maxAnswerChars, err := strconv.Atoi(getEnv("RAG_MAX_ANSWER_CHARS", "4000"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RAG_MAX_ANSWER_CHARS: %w", err)
+	}
+
+	minGroundedness, err := strconv.ParseFloat(getEnv("RAG_MIN_GROUNDEDNESS", "0"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RAG_MIN_GROUNDEDNESS: %w", err)
+	}
+
+	historyMessages, err := strconv.Atoi(getEnv("RAG_HISTORY_MESSAGES", "20"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RAG_HISTORY_MESSAGES: %w", err)
+	}
+
+	lowConfidence, err := strconv.ParseFloat(getEnv("RAG_LOW_CONFIDENCE", "0.5"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RAG_LOW_CONFIDENCE: %w", err)
+	}
+
+	duplicateSimilarity, err := strconv.ParseFloat(getEnv("RAG_DUPLICATE_SIMILARITY", "0"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RAG_DUPLICATE_SIMILARITY: %w", err)
+	}
+
+	var bannedPhrases []string
+	for _, phrase := range strings.Split(getEnv("RAG_BANNED_PHRASES", ""), ",") {
+		if phrase = strings.TrimSpace(phrase); phrase != "" {
+			bannedPhrases = append(bannedPhrases, phrase)
+		}
+	}
+
+	var fallbackModels []string
+	for _, model := range strings.Split(getEnv("RAG_FALLBACK_MODELS", ""), ",") {
+		if model = strings.TrimSpace(model); model != "" {
+			fallbackModels = append(fallbackModels, model)
+		}
+	}
+
+	var lookupHosts []string
+	for _, host := range strings.Split(getEnv("TOOL_LOOKUP_ALLOWED_HOSTS", ""), ",") {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			lookupHosts = append(lookupHosts, host)
+		}
+	}
+
+	var allowedModels []string
+	for _, model := range strings.Split(getEnv("RAG_ALLOWED_MODELS", ""), ",") {
+		if model = strings.TrimSpace(model); model != "" {
+			allowedModels = append(allowedModels, model)
+		}
+	}
+
+	dailySpendCap, err := strconv.ParseFloat(getEnv("RAG_DAILY_SPEND_CAP_USD", "0"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RAG_DAILY_SPEND_CAP_USD: %w", err)
+	}
+
+	maxRequestCost, err := strconv.ParseFloat(getEnv("RAG_MAX_REQUEST_COST_USD", "0"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RAG_MAX_REQUEST_COST_USD: %w", err)
+	}
+
+	modelPrices, err := parseModelPrices(getEnv("RAG_MODEL_PRICES", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	ocrMinChars, err := strconv.Atoi(getEnv("OCR_MIN_CHARS", "32"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OCR_MIN_CHARS: %w", err)
+	}
+
 	cookieSecure := getEnv("COOKIE_SECURE", "false") == "true"
 
+	widgetSessionTTL, err := strconv.Atoi(getEnv("WIDGET_SESSION_TTL_MINUTES", "30"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid WIDGET_SESSION_TTL_MINUTES: %w", err)
+	}
+
+	widgetMaxQuestions, err := strconv.Atoi(getEnv("WIDGET_MAX_QUESTIONS", "20"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid WIDGET_MAX_QUESTIONS: %w", err)
+	}
+
+	widgetSessionsPerMinute, err := strconv.Atoi(getEnv("WIDGET_SESSIONS_PER_MINUTE", "10"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid WIDGET_SESSIONS_PER_MINUTE: %w", err)
+	}
+
+	widgetMessagesPerMinute, err := strconv.Atoi(getEnv("WIDGET_MESSAGES_PER_MINUTE", "20"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid WIDGET_MESSAGES_PER_MINUTE: %w", err)
+	}
+
+	rateLimit, err := strconv.Atoi(getEnv("RATE_LIMIT_PER_MINUTE", "100"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_PER_MINUTE: %w", err)
+	}
+
+	rateLimitIPs, err := parsePrefixes(getEnv("RATE_LIMIT_ALLOW_IPS", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	var rateLimitUsers []string
+	for _, id := range strings.Split(getEnv("RATE_LIMIT_ALLOW_USERS", ""), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			rateLimitUsers = append(rateLimitUsers, id)
+		}
+	}
+
+	var rateLimitKeys []string
+	for _, id := range strings.Split(getEnv("RATE_LIMIT_ALLOW_API_KEYS", ""), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			rateLimitKeys = append(rateLimitKeys, id)
+		}
+	}
+
+	roleMultipliers, err := parseRoleMultipliers(getEnv("RATE_LIMIT_ROLE_MULTIPLIERS", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	abuseBurst, err := strconv.Atoi(getEnv("ABUSE_BURST_REQUESTS", "30"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ABUSE_BURST_REQUESTS: %w", err)
+	}
+
+	abuseBurstSeconds, err := strconv.Atoi(getEnv("ABUSE_BURST_SECONDS", "10"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ABUSE_BURST_SECONDS: %w", err)
+	}
+
+	abuseBlock, err := strconv.Atoi(getEnv("ABUSE_BLOCK_MINUTES", "15"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ABUSE_BLOCK_MINUTES: %w", err)
+	}
+
+	abuseTravel, err := strconv.Atoi(getEnv("ABUSE_TRAVEL_MINUTES", "60"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ABUSE_TRAVEL_MINUTES: %w", err)
+	}
+
+	registerPerIP, err := strconv.Atoi(getEnv("REGISTER_PER_IP_PER_HOUR", "5"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid REGISTER_PER_IP_PER_HOUR: %w", err)
+	}
+
+	registerPerDomain, err := strconv.Atoi(getEnv("REGISTER_PER_DOMAIN_PER_HOUR", "20"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid REGISTER_PER_DOMAIN_PER_HOUR: %w", err)
+	}
+
+	var blockedASNs []uint32
+	for _, entry := range strings.Split(getEnv("ABUSE_BLOCKED_ASNS", ""), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		number, err := asn.ParseNumber(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ABUSE_BLOCKED_ASNS: %w", err)
+		}
+		blockedASNs = append(blockedASNs, number)
+	}
+
 	config := &Config{
 		Server: ServerConfig{
-			Port:        port,
-			Host:        getEnv("SERVER_HOST", "0.0.0.0"),
-			Environment: getEnv("ENVIRONMENT", "development"),
+			Port:                      port,
+			Host:                      getEnv("SERVER_HOST", "0.0.0.0"),
+			Environment:               getEnv("ENVIRONMENT", "development"),
+			LogRetentionDays:          logRetentionDays,
+			DefaultLanguage:           strings.ToLower(getEnv("DEFAULT_LANGUAGE", i18n.English)),
+			DefaultTimezone:           getEnv("DEFAULT_TIMEZONE", "UTC"),
+			ConversationAutoCloseDays: autoCloseDays,
+			InstanceID:                getEnv("INSTANCE_ID", ""),
+			LeaderLeaseSeconds:        leaderLeaseSeconds,
+			MetricsSampleSeconds:      metricsSampleSeconds,
+			ProviderProbeSeconds:      providerProbeSeconds,
+			RequestTimeoutSeconds:     requestTimeout,
+			UploadTimeoutSeconds:      uploadTimeout,
+			RAGTimeoutSeconds:         ragTimeout,
+			BreakerFailures:           breakerFailures,
+			BreakerCooldownSeconds:    breakerCooldown,
+			CompressMinBytes:          compressMinBytes,
+			MaintenanceMode:           getEnv("MAINTENANCE_MODE", "false") == "true",
+			MaintenanceMessage:        getEnv("MAINTENANCE_MESSAGE", ""),
 		},
 		WhatsApp: WhatsAppConfig{
 			APIKey:             getEnv("WHATSAPP_API_KEY", ""),
@@ -124,13 +791,52 @@ func Load() (*Config, error) {
 			BusinessAccountID:  getEnv("WHATSAPP_BUSINESS_ACCOUNT_ID", ""),
 			WebhookVerifyToken: getEnv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", ""),
 			APIVersion:         getEnv("WHATSAPP_API_VERSION", "v17.0"),
+
+			VoiceReplies:    getEnv("WHATSAPP_VOICE_REPLIES", "false") == "true",
+			Attachments:     getEnv("WHATSAPP_ATTACHMENTS", "false") == "true",
+			TypingIndicator: getEnv("WHATSAPP_TYPING_INDICATOR", "false") == "true",
 		},
 		RAG: RAGConfig{
-			OpenAIAPIKey:   getEnv("OPENAI_API_KEY", ""),
-			ModelName:      getEnv("RAG_MODEL_NAME", "gpt-3.5-turbo"),
-			EmbeddingModel: getEnv("RAG_EMBEDDING_MODEL", "text-embedding-ada-002"),
-			ChunkSize:      chunkSize,
-			ChunkOverlap:   chunkOverlap,
+			OpenAIAPIKey:     getEnv("OPENAI_API_KEY", ""),
+			ModelName:        getEnv("RAG_MODEL_NAME", "gpt-3.5-turbo"),
+			EmbeddingModel:   getEnv("RAG_EMBEDDING_MODEL", defaultEmbeddingModel),
+			ChunkSize:        chunkSize,
+			ChunkOverlap:     chunkOverlap,
+			MaxContextTokens: maxContextTokens,
+			DedupThreshold:   dedupThreshold,
+			AnswerCacheTTL:   time.Duration(answerCacheTTL) * time.Second,
+
+			EmbeddingServerURL:    embeddingServerURL,
+			EmbeddingServerAPIKey: getEnv("RAG_EMBEDDING_SERVER_API_KEY", ""),
+			EmbeddingBatchSize:    embeddingBatchSize,
+			MaxDocumentMB:         maxDocumentMB,
+
+			TranscriptionModel: getEnv("RAG_TRANSCRIPTION_MODEL", "whisper-1"),
+			VisionModel:        getEnv("RAG_VISION_MODEL", "gpt-4o-mini"),
+			SpeechModel:        getEnv("RAG_SPEECH_MODEL", "tts-1"),
+			SpeechVoice:        getEnv("RAG_SPEECH_VOICE", "alloy"),
+			TableRowsPerChunk:  tableRowsPerChunk,
+
+			MaxAnswerChars:  maxAnswerChars,
+			BannedPhrases:   bannedPhrases,
+			MinGroundedness: minGroundedness,
+			StripUnsourced:  getEnv("RAG_STRIP_UNSOURCED_CONTACTS", "true") == "true",
+
+			HistoryMessages:  historyMessages,
+			SummarizeHistory: getEnv("RAG_SUMMARIZE_HISTORY", "true") == "true",
+
+			LowConfidence: lowConfidence,
+
+			FallbackModels: fallbackModels,
+			OllamaBaseURL:  getEnv("OLLAMA_BASE_URL", "http://localhost:11434/v1"),
+			AllowedModels:  allowedModels,
+
+			DailySpendCapUSD:  dailySpendCap,
+			MaxRequestCostUSD: maxRequestCost,
+			ModelPrices:       modelPrices,
+
+			DuplicateDocuments:  getEnv("RAG_DUPLICATE_DOCUMENTS", "allow"),
+			DuplicateSimilarity: duplicateSimilarity,
 		},
 		Database: DatabaseConfig{
 			Type:     getEnv("DB_TYPE", "mongodb"),
@@ -139,12 +845,20 @@ func Load() (*Config, error) {
 			Name:     getEnv("DB_NAME", "lucidrag"),
 			User:     getEnv("DB_USER", "lucidrag"),
 			Password: getEnv("DB_PASSWORD", ""),
+
+			RetryWindowSeconds: dbRetryWindow,
+			ReadPreference:     getEnv("DB_READ_PREFERENCE", ""),
+			ReadYourWrites:     getEnv("DB_READ_YOUR_WRITES", "true") == "true",
 		},
 		Auth: AuthConfig{
-			JWTSecret:      getEnv("JWT_SECRET", ""),
-			JWTExpiryHours: jwtExpiry,
-			CookieDomain:   getEnv("COOKIE_DOMAIN", ""),
-			CookieSecure:   cookieSecure,
+			JWTSecret:        getEnv("JWT_SECRET", ""),
+			JWTExpiryHours:   jwtExpiry,
+			CookieDomain:     getEnv("COOKIE_DOMAIN", ""),
+			RevalidateTokens: getEnv("AUTH_REVALIDATE_TOKENS", "true") == "true",
+			CookieSecure:     cookieSecure,
+			ConfirmTokenTTL:  time.Duration(confirmTTL) * time.Minute,
+			BindAdminDevices: getEnv("AUTH_BIND_ADMIN_DEVICES", "false") == "true",
+			AdminLoginAlerts: getEnv("AUTH_ADMIN_LOGIN_ALERTS", "true") == "true",
 			OAuth: OAuthConfig{
 				RedirectBaseURL: getEnv("OAUTH_REDIRECT_BASE_URL", "http://localhost:4200"),
 				Google: OAuthProviderConfig{
@@ -166,6 +880,104 @@ func Load() (*Config, error) {
 				},
 			},
 		},
+		Cache: CacheConfig{
+			Driver:        getEnv("CACHE_DRIVER", "memory"),
+			RedisAddr:     getEnv("REDIS_ADDR", "localhost:6379"),
+			RedisPassword: getEnv("REDIS_PASSWORD", ""),
+			RedisDB:       redisDB,
+		},
+		Objects: ObjectStoreConfig{
+			Driver:      getEnv("OBJECT_STORE_DRIVER", "fs"),
+			Dir:         getEnv("OBJECT_STORE_DIR", "./data"),
+			S3Endpoint:  getEnv("S3_ENDPOINT", ""),
+			S3Region:    getEnv("S3_REGION", "us-east-1"),
+			S3Bucket:    getEnv("S3_BUCKET", ""),
+			S3AccessKey: getEnv("S3_ACCESS_KEY", ""),
+			S3SecretKey: getEnv("S3_SECRET_KEY", ""),
+		},
+		Extract: ExtractConfig{
+			OCREnabled:    getEnv("OCR_ENABLED", "true") == "true",
+			OCRLanguage:   getEnv("OCR_LANGUAGE", "eng"),
+			OCRMinChars:   ocrMinChars,
+			TesseractPath: getEnv("TESSERACT_PATH", "tesseract"),
+			PdftotextPath: getEnv("PDFTOTEXT_PATH", "pdftotext"),
+			PdftoppmPath:  getEnv("PDFTOPPM_PATH", "pdftoppm"),
+		},
+		CRM: CRMConfig{
+			EncryptionKey: getEnv("CRM_ENCRYPTION_KEY", ""),
+			SyncSchedule:  getEnv("CRM_SYNC_SCHEDULE", "*/15 * * * *"),
+		},
+		Slack: SlackConfig{
+			SigningSecret: getEnv("SLACK_SIGNING_SECRET", ""),
+			BotToken:      getEnv("SLACK_BOT_TOKEN", ""),
+		},
+		Email: EmailConfig{
+			InboundToken: getEnv("EMAIL_INBOUND_TOKEN", ""),
+			ReplyMode:    getEnv("EMAIL_REPLY_MODE", "draft"),
+			From:         getEnv("EMAIL_FROM", ""),
+			SMTPHost:     getEnv("SMTP_HOST", ""),
+			SMTPPort:     getEnv("SMTP_PORT", "587"),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		},
+		Widget: WidgetConfig{
+			SessionTTL:        time.Duration(widgetSessionTTL) * time.Minute,
+			MaxQuestions:      widgetMaxQuestions,
+			SessionsPerMinute: widgetSessionsPerMinute,
+			MessagesPerMinute: widgetMessagesPerMinute,
+		},
+		Transcript: TranscriptConfig{
+			BrandName:  getEnv("TRANSCRIPT_BRAND_NAME", "lucidRAG"),
+			BrandColor: getEnv("TRANSCRIPT_BRAND_COLOR", "#2563eb"),
+		},
+		SLA: SLAConfig{
+			FirstResponseMinutes: slaFirstResponse,
+			ResolutionHours:      slaResolution,
+		},
+		Topics: TopicsConfig{
+			Schedule:   getEnv("TOPICS_SCHEDULE", "0 5 * * *"),
+			WindowDays: topicsWindow,
+			MaxTopics:  topicsMax,
+		},
+		Retention: RetentionConfig{
+			Schedule:         getEnv("RETENTION_SCHEDULE", "30 2 * * *"),
+			ConversationDays: retentionConversations,
+			MessageDays:      retentionMessages,
+			NoteDays:         retentionNotes,
+		},
+		Digest: DigestConfig{
+			Hour:    digestHour,
+			Weekday: digestWeekday,
+		},
+		Booking: BookingConfig{
+			CalendarCredentialsFile: getEnv("GOOGLE_CALENDAR_CREDENTIALS_FILE", ""),
+			ReminderHours:           bookingReminder,
+		},
+		Tools: ToolsConfig{
+			LookupHosts: lookupHosts,
+		},
+		RateLimit: RateLimitConfig{
+			PerMinute:       rateLimit,
+			AllowIPs:        rateLimitIPs,
+			AllowUsers:      rateLimitUsers,
+			AllowAPIKeys:    rateLimitKeys,
+			RoleMultipliers: roleMultipliers,
+		},
+		Abuse: AbuseConfig{
+			BurstRequests: abuseBurst,
+			BurstWindow:   time.Duration(abuseBurstSeconds) * time.Second,
+			BlockFor:      time.Duration(abuseBlock) * time.Minute,
+			TravelWindow:  time.Duration(abuseTravel) * time.Minute,
+			ASNDatabase:   getEnv("ABUSE_ASN_DATABASE", ""),
+			BlockedASNs:   blockedASNs,
+		},
+		Register: RegisterConfig{
+			PerIP:           registerPerIP,
+			PerDomain:       registerPerDomain,
+			CaptchaProvider: strings.ToLower(getEnv("CAPTCHA_PROVIDER", "")),
+			CaptchaSiteKey:  getEnv("CAPTCHA_SITE_KEY", ""),
+			CaptchaSecret:   getEnv("CAPTCHA_SECRET", ""),
+		},
 	}
 
 	if err := config.Validate(); err != nil {
@@ -186,6 +998,179 @@ func (c *Config) Validate() error {
 		missing = append(missing, "WHATSAPP_WEBHOOK_VERIFY_TOKEN")
 	}
 
+	if (c.WhatsApp.VoiceReplies || c.WhatsApp.Attachments || c.WhatsApp.TypingIndicator) && c.WhatsApp.PhoneNumberID == "" {
+		missing = append(missing, "WHATSAPP_PHONE_NUMBER_ID")
+	}
+
+	if c.Cache.Driver != "memory" && c.Cache.Driver != "redis" {
+		return fmt.Errorf("invalid CACHE_DRIVER: %q", c.Cache.Driver)
+	}
+
+	switch c.Objects.Driver {
+	case "fs":
+	case "s3":
+		if c.Objects.S3Endpoint == "" {
+			missing = append(missing, "S3_ENDPOINT")
+		}
+		if c.Objects.S3Bucket == "" {
+			missing = append(missing, "S3_BUCKET")
+		}
+	default:
+		return fmt.Errorf("invalid OBJECT_STORE_DRIVER: %q", c.Objects.Driver)
+	}
+
+	if c.SLA.FirstResponseMinutes < 0 || c.SLA.ResolutionHours < 0 {
+		return fmt.Errorf("SLA_FIRST_RESPONSE_MINUTES and SLA_RESOLUTION_HOURS must not be negative")
+	}
+
+	if c.Topics.WindowDays < 1 {
+		return fmt.Errorf("TOPICS_WINDOW_DAYS must be at least 1")
+	}
+
+	if c.Topics.MaxTopics < 2 || c.Topics.MaxTopics > 50 {
+		return fmt.Errorf("TOPICS_MAX must be between 2 and 50")
+	}
+
+	if c.Digest.Hour < 0 || c.Digest.Hour > 23 {
+		return fmt.Errorf("DIGEST_HOUR must be between 0 and 23")
+	}
+
+	if c.Retention.ConversationDays < 0 || c.Retention.MessageDays < 0 || c.Retention.NoteDays < 0 {
+		return fmt.Errorf("RETENTION_CONVERSATION_DAYS, RETENTION_MESSAGE_DAYS and RETENTION_NOTE_DAYS must not be negative")
+	}
+
+	if c.Booking.ReminderHours < 0 {
+		return fmt.Errorf("BOOKING_REMINDER_HOURS must not be negative")
+	}
+	for _, host := range c.Tools.LookupHosts {
+		if strings.ContainsAny(strings.TrimPrefix(host, "*."), "/:*@ ") {
+			return fmt.Errorf("invalid TOOL_LOOKUP_ALLOWED_HOSTS entry %q: use a host name such as api.example.com or *.example.com", host)
+		}
+	}
+
+	if !i18n.Supported(c.Server.DefaultLanguage) {
+		return fmt.Errorf("DEFAULT_LANGUAGE must be %s or %s", i18n.English, i18n.Spanish)
+	}
+
+	if _, err := time.LoadLocation(c.Server.DefaultTimezone); c.Server.DefaultTimezone == "" || err != nil {
+		return fmt.Errorf("invalid DEFAULT_TIMEZONE: %q", c.Server.DefaultTimezone)
+	}
+
+	if c.Server.ConversationAutoCloseDays < 0 {
+		return fmt.Errorf("CONVERSATION_AUTO_CLOSE_DAYS must not be negative")
+	}
+
+	if c.Server.MetricsSampleSeconds < 10 {
+		return fmt.Errorf("METRICS_SAMPLE_SECONDS must be at least 10")
+	}
+
+	if c.Server.ProviderProbeSeconds < 30 {
+		return fmt.Errorf("PROVIDER_PROBE_SECONDS must be at least 30")
+	}
+
+	if c.Server.RequestTimeoutSeconds <= 0 || c.Server.UploadTimeoutSeconds <= 0 || c.Server.RAGTimeoutSeconds <= 0 {
+		return fmt.Errorf("REQUEST_TIMEOUT_SECONDS, UPLOAD_TIMEOUT_SECONDS and RAG_TIMEOUT_SECONDS must be positive")
+	}
+
+	for _, model := range c.RAG.FallbackModels {
+		provider, name, found := strings.Cut(model, ":")
+		if found && (provider != "ollama" || name == "") {
+			return fmt.Errorf("invalid RAG_FALLBACK_MODELS entry %q: use a model name or ollama:<model>", model)
+		}
+	}
+	for _, model := range c.RAG.AllowedModels {
+		if strings.Contains(model, ":") {
+			return fmt.Errorf("invalid RAG_ALLOWED_MODELS entry %q: use an OpenAI model name", model)
+		}
+	}
+
+	if c.Server.CompressMinBytes < 0 {
+		return fmt.Errorf("COMPRESS_MIN_BYTES must not be negative")
+	}
+
+	if c.RAG.MaxDocumentMB <= 0 {
+		return fmt.Errorf("RAG_MAX_DOCUMENT_MB must be positive")
+	}
+
+	if c.RAG.EmbeddingBatchSize < 0 {
+		return fmt.Errorf("RAG_EMBEDDING_BATCH_SIZE must not be negative")
+	}
+
+	if c.RAG.DailySpendCapUSD < 0 || c.RAG.MaxRequestCostUSD < 0 {
+		return fmt.Errorf("RAG_DAILY_SPEND_CAP_USD and RAG_MAX_REQUEST_COST_USD must not be negative")
+	}
+
+	if c.Database.RetryWindowSeconds < 0 {
+		return fmt.Errorf("DB_RETRY_WINDOW_SECONDS must not be negative")
+	}
+
+	switch c.Database.ReadPreference {
+	case "", "primary", "primaryPreferred", "secondary", "secondaryPreferred", "nearest":
+	default:
+		return fmt.Errorf("invalid DB_READ_PREFERENCE: %q", c.Database.ReadPreference)
+	}
+
+	switch c.RAG.DuplicateDocuments {
+	case "allow", "reject", "merge", "link":
+	default:
+		return fmt.Errorf("invalid RAG_DUPLICATE_DOCUMENTS: %q", c.RAG.DuplicateDocuments)
+	}
+	if c.RAG.DuplicateSimilarity < 0 || c.RAG.DuplicateSimilarity > 1 {
+		return fmt.Errorf("RAG_DUPLICATE_SIMILARITY must be between 0 and 1")
+	}
+
+	if c.Server.BreakerFailures <= 0 || c.Server.BreakerCooldownSeconds <= 0 {
+		return fmt.Errorf("BREAKER_FAILURES and BREAKER_COOLDOWN_SECONDS must be positive")
+	}
+
+	if c.Widget.SessionTTL <= 0 || c.Widget.MaxQuestions <= 0 ||
+		c.Widget.SessionsPerMinute <= 0 || c.Widget.MessagesPerMinute <= 0 {
+		return fmt.Errorf("widget session TTL, question cap and rate limits must be positive")
+	}
+
+	if c.RateLimit.PerMinute <= 0 {
+		return fmt.Errorf("RATE_LIMIT_PER_MINUTE must be positive")
+	}
+
+	if c.Abuse.BurstRequests < 0 || c.Abuse.BurstWindow <= 0 || c.Abuse.BlockFor <= 0 || c.Abuse.TravelWindow <= 0 {
+		return fmt.Errorf("ABUSE_BURST_REQUESTS must not be negative and the abuse windows must be positive")
+	}
+	if len(c.Abuse.BlockedASNs) > 0 && c.Abuse.ASNDatabase == "" {
+		return fmt.Errorf("ABUSE_BLOCKED_ASNS needs ABUSE_ASN_DATABASE")
+	}
+
+	if c.Register.PerIP < 0 || c.Register.PerDomain < 0 {
+		return fmt.Errorf("REGISTER_PER_IP_PER_HOUR and REGISTER_PER_DOMAIN_PER_HOUR must not be negative")
+	}
+	switch c.Register.CaptchaProvider {
+	case "":
+	case "hcaptcha", "turnstile":
+		if c.Register.CaptchaSiteKey == "" {
+			missing = append(missing, "CAPTCHA_SITE_KEY")
+		}
+		if c.Register.CaptchaSecret == "" {
+			missing = append(missing, "CAPTCHA_SECRET")
+		}
+	default:
+		return fmt.Errorf("invalid CAPTCHA_PROVIDER: %q (use hcaptcha or turnstile)", c.Register.CaptchaProvider)
+	}
+
+	if c.Email.ReplyMode != "draft" && c.Email.ReplyMode != "auto" {
+		return fmt.Errorf("invalid EMAIL_REPLY_MODE: %q", c.Email.ReplyMode)
+	}
+	if c.Email.InboundToken != "" && c.Email.SMTPHost == "" {
+		missing = append(missing, "SMTP_HOST")
+	}
+	if c.Email.InboundToken != "" || c.Email.SMTPHost != "" {
+		if _, err := mail.ParseAddress(c.Email.From); err != nil {
+			return fmt.Errorf("invalid EMAIL_FROM: %w", err)
+		}
+	}
+
+	if !isHexColor(c.Transcript.BrandColor) {
+		return fmt.Errorf("invalid TRANSCRIPT_BRAND_COLOR: %q", c.Transcript.BrandColor)
+	}
+
 	if len(missing) > 0 {
 		return fmt.Errorf("missing required environment variables: %v", missing)
 	}
@@ -193,10 +1178,99 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// isHexColor reports whether s is a #rrggbb color.
+func isHexColor(s string) bool {
+	if len(s) != 7 || s[0] != '#' {
+		return false
+	}
+	for _, c := range s[1:] {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
+
 // getEnv retrieves an environment variable or returns a default value
+// parseModelPrices reads comma-separated model=input/output entries, in USD
+// per million tokens. The output price may be left out for embedding
+// models.
+func parseModelPrices(value string) (map[string]ModelPrice, error) {
+	prices := make(map[string]ModelPrice)
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		model, price, _ := strings.Cut(entry, "=")
+		input, output, hasOutput := strings.Cut(price, "/")
+		if !hasOutput {
+			output = "0"
+		}
+		in, inErr := strconv.ParseFloat(strings.TrimSpace(input), 64)
+		out, outErr := strconv.ParseFloat(strings.TrimSpace(output), 64)
+		model = strings.TrimSpace(model)
+		if model == "" || inErr != nil || outErr != nil || in < 0 || out < 0 {
+			return nil, fmt.Errorf("invalid RAG_MODEL_PRICES entry %q: use model=input/output in USD per million tokens", entry)
+		}
+		prices[model] = ModelPrice{Input: in, Output: out}
+	}
+	return prices, nil
+}
+
+// parsePrefixes reads comma-separated IPs and CIDR ranges for
+// RATE_LIMIT_ALLOW_IPS; an IP is a range of one.
+func parsePrefixes(value string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RATE_LIMIT_ALLOW_IPS entry %q: use an IP or a CIDR range", entry)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// parseRoleMultipliers reads comma-separated role=multiplier entries, such
+// as admin=5,editor=2.
+func parseRoleMultipliers(value string) (map[string]float64, error) {
+	multipliers := make(map[string]float64)
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		role, factor, _ := strings.Cut(entry, "=")
+		m, err := strconv.ParseFloat(strings.TrimSpace(factor), 64)
+		role = strings.TrimSpace(role)
+		if role == "" || err != nil || m <= 0 {
+			return nil, fmt.Errorf("invalid RATE_LIMIT_ROLE_MULTIPLIERS entry %q: use role=multiplier with a multiplier above 0", entry)
+		}
+		multipliers[role] = m
+	}
+	return multipliers, nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+// parseWeekday reads DIGEST_WEEKDAY, an English day name in any case.
+func parseWeekday(name string) (time.Weekday, error) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(name, day.String()) {
+			return day, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid DIGEST_WEEKDAY: %q", name)
+}
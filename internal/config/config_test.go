@@ -3,6 +3,7 @@ package config
 import (
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoad(t *testing.T) {
@@ -50,6 +51,14 @@ func TestLoadDefaults(t *testing.T) {
 	if cfg.RAG.ChunkSize != 512 {
 		t.Errorf("Expected default chunk size 512, got %d", cfg.RAG.ChunkSize)
 	}
+
+	if cfg.RAG.MaxContextTokens != 3000 {
+		t.Errorf("Expected default max context tokens 3000, got %d", cfg.RAG.MaxContextTokens)
+	}
+
+	if cfg.RAG.DedupThreshold != 0.95 {
+		t.Errorf("Expected default dedup threshold 0.95, got %f", cfg.RAG.DedupThreshold)
+	}
 }
 
 func TestLoadMissingRequiredEnvVars(t *testing.T) {
@@ -202,3 +211,712 @@ func TestLoadInvalidJWTExpiry(t *testing.T) {
 		t.Errorf("Expected error to mention JWT_EXPIRY_HOURS, got: %v", err)
 	}
 }
+
+func TestLoadCacheConfig(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if cfg.Cache.Driver != "memory" {
+		t.Errorf("Expected default cache driver memory, got %s", cfg.Cache.Driver)
+	}
+	if cfg.RAG.AnswerCacheTTL != 5*time.Minute {
+		t.Errorf("Expected default answer cache TTL 5m, got %v", cfg.RAG.AnswerCacheTTL)
+	}
+
+	t.Setenv("CACHE_DRIVER", "memcached")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "CACHE_DRIVER") {
+		t.Errorf("Expected error to mention CACHE_DRIVER, got: %v", err)
+	}
+}
+
+func TestLoadObjectStoreConfig(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Objects.Driver != "fs" {
+		t.Errorf("Expected default object store driver fs, got %s", cfg.Objects.Driver)
+	}
+
+	t.Setenv("OBJECT_STORE_DRIVER", "s3")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "S3_BUCKET") {
+		t.Errorf("Expected error to mention S3_BUCKET, got: %v", err)
+	}
+
+	t.Setenv("OBJECT_STORE_DRIVER", "gcs")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "OBJECT_STORE_DRIVER") {
+		t.Errorf("Expected error to mention OBJECT_STORE_DRIVER, got: %v", err)
+	}
+}
+
+func TestLoadVoiceRepliesRequirePhoneNumber(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+	t.Setenv("WHATSAPP_VOICE_REPLIES", "true")
+
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "WHATSAPP_PHONE_NUMBER_ID") {
+		t.Errorf("Expected error to mention WHATSAPP_PHONE_NUMBER_ID, got: %v", err)
+	}
+
+	t.Setenv("WHATSAPP_PHONE_NUMBER_ID", "phone-1")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if !cfg.WhatsApp.VoiceReplies || cfg.RAG.SpeechModel != "tts-1" {
+		t.Errorf("Expected voice replies with default speech model, got %+v %s", cfg.WhatsApp.VoiceReplies, cfg.RAG.SpeechModel)
+	}
+}
+
+func TestLoadAttachmentsRequirePhoneNumber(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+	t.Setenv("WHATSAPP_ATTACHMENTS", "true")
+
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "WHATSAPP_PHONE_NUMBER_ID") {
+		t.Errorf("Expected error to mention WHATSAPP_PHONE_NUMBER_ID, got: %v", err)
+	}
+
+	t.Setenv("WHATSAPP_PHONE_NUMBER_ID", "phone-1")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if !cfg.WhatsApp.Attachments {
+		t.Error("Expected attachments to be enabled")
+	}
+}
+
+func TestLoadTypingIndicatorRequirePhoneNumber(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+	t.Setenv("WHATSAPP_TYPING_INDICATOR", "true")
+
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "WHATSAPP_PHONE_NUMBER_ID") {
+		t.Errorf("Expected error to mention WHATSAPP_PHONE_NUMBER_ID, got: %v", err)
+	}
+
+	t.Setenv("WHATSAPP_PHONE_NUMBER_ID", "phone-1")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if !cfg.WhatsApp.TypingIndicator {
+		t.Error("Expected the typing indicator to be enabled")
+	}
+}
+
+func TestLoadEmailConfig(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+	t.Setenv("EMAIL_INBOUND_TOKEN", "inbound")
+	t.Setenv("EMAIL_FROM", "Support <support@example.com>")
+
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "SMTP_HOST") {
+		t.Errorf("Expected error to mention SMTP_HOST, got: %v", err)
+	}
+
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Email.ReplyMode != "draft" || cfg.Email.SMTPPort != "587" {
+		t.Errorf("Expected draft mode on port 587, got %s %s", cfg.Email.ReplyMode, cfg.Email.SMTPPort)
+	}
+
+	t.Setenv("EMAIL_REPLY_MODE", "always")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "EMAIL_REPLY_MODE") {
+		t.Errorf("Expected error to mention EMAIL_REPLY_MODE, got: %v", err)
+	}
+}
+
+func TestLoadWidgetConfig(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Widget.SessionTTL != 30*time.Minute || cfg.Widget.MaxQuestions != 20 {
+		t.Errorf("Expected 30m sessions with 20 questions, got %v %d", cfg.Widget.SessionTTL, cfg.Widget.MaxQuestions)
+	}
+
+	t.Setenv("WIDGET_MAX_QUESTIONS", "0")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "widget") {
+		t.Errorf("Expected widget cap error, got: %v", err)
+	}
+}
+
+func TestLoadTranscriptConfig(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Transcript.BrandName != "lucidRAG" || cfg.Transcript.BrandColor != "#2563eb" {
+		t.Errorf("Expected default branding, got %+v", cfg.Transcript)
+	}
+
+	t.Setenv("TRANSCRIPT_BRAND_COLOR", "blue")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "TRANSCRIPT_BRAND_COLOR") {
+		t.Errorf("Expected error to mention TRANSCRIPT_BRAND_COLOR, got: %v", err)
+	}
+
+	t.Setenv("TRANSCRIPT_BRAND_COLOR", "#0F766E")
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "EMAIL_FROM") {
+		t.Errorf("Expected error to mention EMAIL_FROM, got: %v", err)
+	}
+}
+
+func TestLoadMetricsSampleSeconds(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Server.MetricsSampleSeconds != 60 {
+		t.Errorf("Expected 60 second samples, got %d", cfg.Server.MetricsSampleSeconds)
+	}
+
+	t.Setenv("METRICS_SAMPLE_SECONDS", "5")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "METRICS_SAMPLE_SECONDS") {
+		t.Errorf("Expected error to mention METRICS_SAMPLE_SECONDS, got: %v", err)
+	}
+}
+
+func TestLoadProviderProbeSeconds(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Server.ProviderProbeSeconds != 300 {
+		t.Errorf("Expected 300 second probes, got %d", cfg.Server.ProviderProbeSeconds)
+	}
+
+	t.Setenv("PROVIDER_PROBE_SECONDS", "10")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "PROVIDER_PROBE_SECONDS") {
+		t.Errorf("Expected error to mention PROVIDER_PROBE_SECONDS, got: %v", err)
+	}
+}
+
+func TestLoadRequestTimeouts(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Server.RequestTimeoutSeconds != 10 || cfg.Server.UploadTimeoutSeconds != 60 || cfg.Server.RAGTimeoutSeconds != 30 {
+		t.Errorf("Expected 10/60/30 second timeouts, got %d/%d/%d",
+			cfg.Server.RequestTimeoutSeconds, cfg.Server.UploadTimeoutSeconds, cfg.Server.RAGTimeoutSeconds)
+	}
+
+	t.Setenv("RAG_TIMEOUT_SECONDS", "0")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "RAG_TIMEOUT_SECONDS") {
+		t.Errorf("Expected error to mention RAG_TIMEOUT_SECONDS, got: %v", err)
+	}
+}
+
+func TestLoadBreaker(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Server.BreakerFailures != 5 || cfg.Server.BreakerCooldownSeconds != 30 {
+		t.Errorf("Expected 5 failures and a 30 second cooldown, got %d and %d",
+			cfg.Server.BreakerFailures, cfg.Server.BreakerCooldownSeconds)
+	}
+
+	t.Setenv("BREAKER_FAILURES", "0")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "BREAKER_FAILURES") {
+		t.Errorf("Expected error to mention BREAKER_FAILURES, got: %v", err)
+	}
+}
+
+func TestLoadConversationAutoClose(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Server.ConversationAutoCloseDays != 7 {
+		t.Errorf("Expected conversations to close after 7 days, got %d", cfg.Server.ConversationAutoCloseDays)
+	}
+
+	t.Setenv("CONVERSATION_AUTO_CLOSE_DAYS", "-1")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "CONVERSATION_AUTO_CLOSE_DAYS") {
+		t.Errorf("Expected error to mention CONVERSATION_AUTO_CLOSE_DAYS, got: %v", err)
+	}
+}
+
+func TestLoadDefaultLanguage(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Server.DefaultLanguage != "en" {
+		t.Errorf("Expected English by default, got %s", cfg.Server.DefaultLanguage)
+	}
+
+	t.Setenv("DEFAULT_LANGUAGE", "ES")
+	if cfg, err = Load(); err != nil || cfg.Server.DefaultLanguage != "es" {
+		t.Errorf("Expected Spanish, got %v (%v)", cfg, err)
+	}
+
+	t.Setenv("DEFAULT_LANGUAGE", "fr")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "DEFAULT_LANGUAGE") {
+		t.Errorf("Expected error to mention DEFAULT_LANGUAGE, got: %v", err)
+	}
+}
+
+func TestLoadDefaultTimezone(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Server.DefaultTimezone != "UTC" {
+		t.Errorf("Expected UTC by default, got %s", cfg.Server.DefaultTimezone)
+	}
+
+	t.Setenv("DEFAULT_TIMEZONE", "America/Guatemala")
+	if cfg, err = Load(); err != nil || cfg.Server.DefaultTimezone != "America/Guatemala" {
+		t.Errorf("Expected America/Guatemala, got %v (%v)", cfg, err)
+	}
+
+	t.Setenv("DEFAULT_TIMEZONE", "Mars/Olympus_Mons")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "DEFAULT_TIMEZONE") {
+		t.Errorf("Expected error to mention DEFAULT_TIMEZONE, got: %v", err)
+	}
+}
+
+func TestLoadTopics(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Topics.Schedule != "0 5 * * *" || cfg.Topics.WindowDays != 7 || cfg.Topics.MaxTopics != 8 {
+		t.Errorf("Expected topic defaults, got %+v", cfg.Topics)
+	}
+
+	t.Setenv("TOPICS_WINDOW_DAYS", "0")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "TOPICS_WINDOW_DAYS") {
+		t.Errorf("Expected error to mention TOPICS_WINDOW_DAYS, got: %v", err)
+	}
+
+	t.Setenv("TOPICS_WINDOW_DAYS", "30")
+	t.Setenv("TOPICS_MAX", "1")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "TOPICS_MAX") {
+		t.Errorf("Expected error to mention TOPICS_MAX, got: %v", err)
+	}
+}
+
+func TestLoadDigest(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Digest.Hour != 8 || cfg.Digest.Weekday != time.Monday {
+		t.Errorf("Expected digests at 8 on Monday, got %+v", cfg.Digest)
+	}
+
+	t.Setenv("DIGEST_WEEKDAY", "Friday")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Digest.Weekday != time.Friday {
+		t.Errorf("Expected Friday, got %v", cfg.Digest.Weekday)
+	}
+
+	t.Setenv("DIGEST_WEEKDAY", "fri")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "DIGEST_WEEKDAY") {
+		t.Errorf("Expected error to mention DIGEST_WEEKDAY, got: %v", err)
+	}
+
+	t.Setenv("DIGEST_WEEKDAY", "")
+	t.Setenv("DIGEST_HOUR", "24")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "DIGEST_HOUR") {
+		t.Errorf("Expected error to mention DIGEST_HOUR, got: %v", err)
+	}
+}
+
+func TestLoadRetention(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Retention.ConversationDays != 0 || cfg.Retention.MessageDays != 0 || cfg.Retention.NoteDays != 0 {
+		t.Errorf("Expected data to be kept by default, got %+v", cfg.Retention)
+	}
+
+	t.Setenv("RETENTION_MESSAGE_DAYS", "180")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Retention.MessageDays != 180 {
+		t.Errorf("Expected 180 message days, got %d", cfg.Retention.MessageDays)
+	}
+
+	t.Setenv("RETENTION_NOTE_DAYS", "-1")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "RETENTION_NOTE_DAYS") {
+		t.Errorf("Expected error to mention RETENTION_NOTE_DAYS, got: %v", err)
+	}
+}
+
+func TestLoadBooking(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Booking.ReminderHours != 24 || cfg.Booking.CalendarCredentialsFile != "" {
+		t.Errorf("Expected booking defaults, got %+v", cfg.Booking)
+	}
+
+	t.Setenv("BOOKING_REMINDER_HOURS", "-2")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "BOOKING_REMINDER_HOURS") {
+		t.Errorf("Expected error to mention BOOKING_REMINDER_HOURS, got: %v", err)
+	}
+}
+
+func TestLoadToolLookupHosts(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+	t.Setenv("TOOL_LOOKUP_ALLOWED_HOSTS", " API.shop.example.com, *.orders.example.com ,")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if len(cfg.Tools.LookupHosts) != 2 || cfg.Tools.LookupHosts[0] != "api.shop.example.com" || cfg.Tools.LookupHosts[1] != "*.orders.example.com" {
+		t.Errorf("Unexpected lookup hosts %v", cfg.Tools.LookupHosts)
+	}
+
+	t.Setenv("TOOL_LOOKUP_ALLOWED_HOSTS", "https://api.example.com/orders")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "TOOL_LOOKUP_ALLOWED_HOSTS") {
+		t.Errorf("Expected error to mention TOOL_LOOKUP_ALLOWED_HOSTS, got: %v", err)
+	}
+}
+
+func TestLoadSLA(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.SLA.FirstResponseMinutes != 15 || cfg.SLA.ResolutionHours != 24 {
+		t.Errorf("Expected 15 minutes and 24 hours, got %d and %d", cfg.SLA.FirstResponseMinutes, cfg.SLA.ResolutionHours)
+	}
+
+	t.Setenv("SLA_RESOLUTION_HOURS", "-1")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "SLA_RESOLUTION_HOURS") {
+		t.Errorf("Expected error to mention SLA_RESOLUTION_HOURS, got: %v", err)
+	}
+}
+
+func TestLoadDatabaseRetryWindow(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Database.RetryWindowSeconds != 12 {
+		t.Errorf("Expected a 12 second retry window, got %d", cfg.Database.RetryWindowSeconds)
+	}
+
+	t.Setenv("DB_RETRY_WINDOW_SECONDS", "-1")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "DB_RETRY_WINDOW_SECONDS") {
+		t.Errorf("Expected error to mention DB_RETRY_WINDOW_SECONDS, got: %v", err)
+	}
+}
+
+func TestLoadReadPreference(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+	t.Setenv("DB_READ_PREFERENCE", "secondaryPreferred")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Database.ReadPreference != "secondaryPreferred" || !cfg.Database.ReadYourWrites {
+		t.Errorf("Expected secondaryPreferred with read-your-writes, got %q and %v",
+			cfg.Database.ReadPreference, cfg.Database.ReadYourWrites)
+	}
+
+	t.Setenv("DB_READ_PREFERENCE", "secondary-preferred")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "DB_READ_PREFERENCE") {
+		t.Errorf("Expected error to mention DB_READ_PREFERENCE, got: %v", err)
+	}
+}
+
+func TestLoadDuplicateDocuments(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.RAG.DuplicateDocuments != "allow" || cfg.RAG.DuplicateSimilarity != 0 {
+		t.Errorf("Expected duplicates allowed by default, got %q at %v",
+			cfg.RAG.DuplicateDocuments, cfg.RAG.DuplicateSimilarity)
+	}
+
+	t.Setenv("RAG_DUPLICATE_DOCUMENTS", "ignore")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "RAG_DUPLICATE_DOCUMENTS") {
+		t.Errorf("Expected error to mention RAG_DUPLICATE_DOCUMENTS, got: %v", err)
+	}
+
+	t.Setenv("RAG_DUPLICATE_DOCUMENTS", "link")
+	t.Setenv("RAG_DUPLICATE_SIMILARITY", "1.5")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "RAG_DUPLICATE_SIMILARITY") {
+		t.Errorf("Expected error to mention RAG_DUPLICATE_SIMILARITY, got: %v", err)
+	}
+}
+
+func TestLoadFallbackModels(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+	t.Setenv("RAG_FALLBACK_MODELS", "gpt-3.5-turbo, ollama:llama3")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if len(cfg.RAG.FallbackModels) != 2 || cfg.RAG.FallbackModels[1] != "ollama:llama3" {
+		t.Errorf("Expected 2 fallback models, got %v", cfg.RAG.FallbackModels)
+	}
+
+	t.Setenv("RAG_FALLBACK_MODELS", "anthropic:claude")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "RAG_FALLBACK_MODELS") {
+		t.Errorf("Expected error to mention RAG_FALLBACK_MODELS, got: %v", err)
+	}
+}
+
+func TestLoadAllowedModels(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+	t.Setenv("RAG_ALLOWED_MODELS", "gpt-4o, gpt-4o-mini,")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if len(cfg.RAG.AllowedModels) != 2 || cfg.RAG.AllowedModels[1] != "gpt-4o-mini" {
+		t.Errorf("Expected 2 allowed models, got %v", cfg.RAG.AllowedModels)
+	}
+
+	t.Setenv("RAG_ALLOWED_MODELS", "ollama:llama3")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "RAG_ALLOWED_MODELS") {
+		t.Errorf("Expected error to mention RAG_ALLOWED_MODELS, got: %v", err)
+	}
+}
+
+func TestLoadSpendLimits(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+	t.Setenv("RAG_DAILY_SPEND_CAP_USD", "25")
+	t.Setenv("RAG_MAX_REQUEST_COST_USD", "0.01")
+	t.Setenv("RAG_MODEL_PRICES", "gpt-4o=2.5/10, text-embedding-3-small=0.02,")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.RAG.DailySpendCapUSD != 25 || cfg.RAG.MaxRequestCostUSD != 0.01 {
+		t.Errorf("Expected limits 25 and 0.01, got %v and %v", cfg.RAG.DailySpendCapUSD, cfg.RAG.MaxRequestCostUSD)
+	}
+	if got := cfg.RAG.ModelPrices["gpt-4o"]; got != (ModelPrice{Input: 2.5, Output: 10}) {
+		t.Errorf("Expected gpt-4o price 2.5/10, got %+v", got)
+	}
+	if got := cfg.RAG.ModelPrices["text-embedding-3-small"]; got != (ModelPrice{Input: 0.02}) {
+		t.Errorf("Expected embedding price 0.02/0, got %+v", got)
+	}
+
+	t.Setenv("RAG_MODEL_PRICES", "gpt-4o=cheap")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "RAG_MODEL_PRICES") {
+		t.Errorf("Expected error to mention RAG_MODEL_PRICES, got: %v", err)
+	}
+
+	t.Setenv("RAG_MODEL_PRICES", "")
+	t.Setenv("RAG_DAILY_SPEND_CAP_USD", "-1")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "RAG_DAILY_SPEND_CAP_USD") {
+		t.Errorf("Expected error to mention RAG_DAILY_SPEND_CAP_USD, got: %v", err)
+	}
+}
+
+func TestLoadRateLimit(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+	t.Setenv("RATE_LIMIT_ALLOW_IPS", " 10.0.0.7, 192.168.1.9/24 ,::ffff:172.16.0.1")
+	t.Setenv("RATE_LIMIT_ALLOW_USERS", "user-1, ")
+	t.Setenv("RATE_LIMIT_ROLE_MULTIPLIERS", "admin=5, editor=1.5")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.RateLimit.PerMinute != 100 {
+		t.Errorf("Expected 100 requests per minute by default, got %d", cfg.RateLimit.PerMinute)
+	}
+	var ips []string
+	for _, p := range cfg.RateLimit.AllowIPs {
+		ips = append(ips, p.String())
+	}
+	if strings.Join(ips, " ") != "10.0.0.7/32 192.168.1.0/24 172.16.0.1/32" {
+		t.Errorf("Unexpected allowed IPs %v", ips)
+	}
+	if len(cfg.RateLimit.AllowUsers) != 1 || len(cfg.RateLimit.AllowAPIKeys) != 0 {
+		t.Errorf("Unexpected allow-lists %+v", cfg.RateLimit)
+	}
+	if cfg.RateLimit.RoleMultipliers["admin"] != 5 || cfg.RateLimit.RoleMultipliers["editor"] != 1.5 {
+		t.Errorf("Unexpected role multipliers %v", cfg.RateLimit.RoleMultipliers)
+	}
+
+	t.Setenv("RATE_LIMIT_ALLOW_IPS", "office")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "RATE_LIMIT_ALLOW_IPS") {
+		t.Errorf("Expected error to mention RATE_LIMIT_ALLOW_IPS, got: %v", err)
+	}
+
+	t.Setenv("RATE_LIMIT_ALLOW_IPS", "")
+	t.Setenv("RATE_LIMIT_ROLE_MULTIPLIERS", "admin=0")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "RATE_LIMIT_ROLE_MULTIPLIERS") {
+		t.Errorf("Expected error to mention RATE_LIMIT_ROLE_MULTIPLIERS, got: %v", err)
+	}
+}
+
+func TestLoadAbuse(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Abuse.BurstRequests != 30 || cfg.Abuse.BurstWindow != 10*time.Second ||
+		cfg.Abuse.BlockFor != 15*time.Minute || cfg.Abuse.TravelWindow != time.Hour {
+		t.Errorf("Unexpected abuse defaults %+v", cfg.Abuse)
+	}
+
+	t.Setenv("ABUSE_BLOCKED_ASNS", "AS14061, 16276")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "ABUSE_ASN_DATABASE") {
+		t.Errorf("Expected error to mention ABUSE_ASN_DATABASE, got: %v", err)
+	}
+
+	t.Setenv("ABUSE_ASN_DATABASE", "/data/ip2asn-combined.tsv")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if len(cfg.Abuse.BlockedASNs) != 2 || cfg.Abuse.BlockedASNs[0] != 14061 || cfg.Abuse.BlockedASNs[1] != 16276 {
+		t.Errorf("Unexpected blocked ASNs %v", cfg.Abuse.BlockedASNs)
+	}
+
+	t.Setenv("ABUSE_BLOCKED_ASNS", "hosting")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "ABUSE_BLOCKED_ASNS") {
+		t.Errorf("Expected error to mention ABUSE_BLOCKED_ASNS, got: %v", err)
+	}
+}
+
+func TestLoadRegister(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Register.PerIP != 5 || cfg.Register.PerDomain != 20 || cfg.Register.CaptchaProvider != "" {
+		t.Errorf("Unexpected registration defaults %+v", cfg.Register)
+	}
+
+	t.Setenv("CAPTCHA_PROVIDER", "Turnstile")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "CAPTCHA_SECRET") {
+		t.Errorf("Expected error to mention CAPTCHA_SECRET, got: %v", err)
+	}
+
+	t.Setenv("CAPTCHA_SITE_KEY", "0x4AAA")
+	t.Setenv("CAPTCHA_SECRET", "0x4BBB")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Register.CaptchaProvider != "turnstile" {
+		t.Errorf("Expected turnstile, got %q", cfg.Register.CaptchaProvider)
+	}
+
+	t.Setenv("CAPTCHA_PROVIDER", "recaptcha")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "CAPTCHA_PROVIDER") {
+		t.Errorf("Expected error to mention CAPTCHA_PROVIDER, got: %v", err)
+	}
+}
+
+func TestLoadAdminDevices(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "testpassword")
+	t.Setenv("WHATSAPP_WEBHOOK_VERIFY_TOKEN", "testtoken")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Auth.BindAdminDevices || !cfg.Auth.AdminLoginAlerts {
+		t.Errorf("Expected binding off and alerts on by default, got %+v", cfg.Auth)
+	}
+
+	t.Setenv("AUTH_BIND_ADMIN_DEVICES", "true")
+	t.Setenv("AUTH_ADMIN_LOGIN_ALERTS", "false")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if !cfg.Auth.BindAdminDevices || cfg.Auth.AdminLoginAlerts {
+		t.Errorf("Expected binding on and alerts off, got %+v", cfg.Auth)
+	}
+}
@@ -0,0 +1,28 @@
+package backup
+
+import "time"
+
+// FormatVersion is written into every backup; restore rejects other versions.
+const FormatVersion = 1
+
+type Kind string
+
+const (
+	KindDocument Kind = "document"
+	KindChunk    Kind = "chunk"
+	KindRule     Kind = "rule"
+	// KindManifest marks the last line of an archive.
+	KindManifest Kind = "manifest"
+)
+
+// Manifest describes a backup. The archive ends with a copy of it whose
+// SHA256 covers every line before it, uncompressed.
+type Manifest struct {
+	ID        string         `json:"id"`
+	Version   int            `json:"version"`
+	CreatedAt time.Time      `json:"created_at"`
+	CreatedBy string         `json:"created_by,omitempty"`
+	Counts    map[Kind]int64 `json:"counts"`
+	SHA256    string         `json:"sha256"`
+	SizeBytes int64          `json:"size_bytes,omitempty"`
+}
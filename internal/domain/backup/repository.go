@@ -0,0 +1,20 @@
+package backup
+
+import (
+	"context"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+)
+
+// Repository reads and replaces the knowledge base: documents, chunks with
+// their embeddings, and retrieval rules.
+type Repository interface {
+	EachDocument(ctx context.Context, fn func(*document.Document) error) error
+	EachChunk(ctx context.Context, fn func(*document.Chunk) error) error
+	EachRule(ctx context.Context, fn func(*document.RetrievalRule) error) error
+	// Clear deletes every document, chunk and rule.
+	Clear(ctx context.Context) error
+	InsertDocuments(ctx context.Context, docs []document.Document) error
+	InsertChunks(ctx context.Context, chunks []document.Chunk) error
+	InsertRules(ctx context.Context, rules []document.RetrievalRule) error
+}
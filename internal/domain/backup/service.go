@@ -0,0 +1,17 @@
+package backup
+
+import (
+	"context"
+	"io"
+)
+
+type Service interface {
+	Create(ctx context.Context, adminID string) (*Manifest, error)
+	List(ctx context.Context) ([]Manifest, error)
+	// Open returns the compressed archive of a stored backup.
+	Open(ctx context.Context, id string) (io.ReadCloser, error)
+	Restore(ctx context.Context, adminID, id string) (*Manifest, error)
+	// RestoreFile restores an uploaded archive. open is called once to
+	// verify the archive and again to apply it.
+	RestoreFile(ctx context.Context, adminID string, open func() (io.ReadCloser, error)) (*Manifest, error)
+}
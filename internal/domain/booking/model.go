@@ -0,0 +1,77 @@
+package booking
+
+import "time"
+
+type Status string
+
+const (
+	StatusConfirmed Status = "confirmed"
+	StatusCancelled Status = "cancelled"
+)
+
+// Hours is a weekly window a resource can be booked in, as wall clock
+// times such as "09:00" and "17:30" in the resource's time zone.
+type Hours struct {
+	Weekday time.Weekday `json:"weekday" bson:"weekday"`
+	Start   string       `json:"start" bson:"start"`
+	End     string       `json:"end" bson:"end"`
+}
+
+// Resource is something customers book time with, such as a consultant or
+// a meeting room. Its slots are cut from Hours, SlotMinutes long, minus
+// confirmed bookings and, when CalendarID is set, the busy times of that
+// Google Calendar, which bookings are also added to.
+type Resource struct {
+	ID          string `json:"id" bson:"_id,omitempty"`
+	Name        string `json:"name" bson:"name"`
+	Description string `json:"description,omitempty" bson:"description,omitempty"`
+	// Timezone is the IANA zone Hours are in; empty means UTC.
+	Timezone    string    `json:"timezone,omitempty" bson:"timezone,omitempty"`
+	SlotMinutes int       `json:"slot_minutes" bson:"slot_minutes"`
+	Hours       []Hours   `json:"hours" bson:"hours"`
+	CalendarID  string    `json:"calendar_id,omitempty" bson:"calendar_id,omitempty"`
+	IsActive    bool      `json:"is_active" bson:"is_active"`
+	CreatedBy   string    `json:"created_by" bson:"created_by"`
+	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// Booking is an appointment with a resource. Bookings made from a
+// conversation keep its ID, and the contact is reminded on WhatsApp before
+// the start.
+type Booking struct {
+	ID             string    `json:"id" bson:"_id,omitempty"`
+	ResourceID     string    `json:"resource_id" bson:"resource_id"`
+	ConversationID string    `json:"conversation_id,omitempty" bson:"conversation_id,omitempty"`
+	ContactName    string    `json:"contact_name,omitempty" bson:"contact_name,omitempty"`
+	PhoneNumber    string    `json:"phone_number,omitempty" bson:"phone_number,omitempty"`
+	Start          time.Time `json:"start" bson:"start"`
+	End            time.Time `json:"end" bson:"end"`
+	Status         Status    `json:"status" bson:"status"`
+	Notes          string    `json:"notes,omitempty" bson:"notes,omitempty"`
+	// CalendarEventID is the event the booking added to the resource's
+	// calendar.
+	CalendarEventID string     `json:"calendar_event_id,omitempty" bson:"calendar_event_id,omitempty"`
+	RemindedAt      *time.Time `json:"reminded_at,omitempty" bson:"reminded_at,omitempty"`
+	CreatedBy       string     `json:"created_by,omitempty" bson:"created_by,omitempty"`
+	CreatedAt       time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" bson:"updated_at"`
+}
+
+// Slot is a free period of a resource. Label names it in the resource's
+// time zone, short enough for a WhatsApp reply button.
+type Slot struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Label string    `json:"label"`
+}
+
+// Filter selects bookings; zero fields match all.
+type Filter struct {
+	ResourceID     string
+	ConversationID string
+	Status         Status
+	// From and To bound the start of the bookings.
+	From time.Time
+	To   time.Time
+}
@@ -0,0 +1,29 @@
+package booking
+
+import (
+	"context"
+	"time"
+)
+
+type ResourceRepository interface {
+	Create(ctx context.Context, resource *Resource) (string, error)
+	GetByID(ctx context.Context, id string) (*Resource, error)
+	List(ctx context.Context) ([]Resource, error)
+	Update(ctx context.Context, resource *Resource) error
+	Delete(ctx context.Context, id string) error
+}
+
+type Repository interface {
+	Create(ctx context.Context, booking *Booking) (string, error)
+	GetByID(ctx context.Context, id string) (*Booking, error)
+	// List returns the matching bookings, earliest first.
+	List(ctx context.Context, filter Filter) ([]Booking, error)
+	Update(ctx context.Context, booking *Booking) error
+	// Overlapping returns the confirmed bookings of resourceID that overlap
+	// [start, end).
+	Overlapping(ctx context.Context, resourceID string, start, end time.Time) ([]Booking, error)
+	// DueReminders returns the confirmed bookings starting in [from, to)
+	// whose contact has not been reminded.
+	DueReminders(ctx context.Context, from, to time.Time) ([]Booking, error)
+	MarkReminded(ctx context.Context, id string, at time.Time) error
+}
@@ -0,0 +1,32 @@
+package booking
+
+import (
+	"context"
+	"time"
+)
+
+type Service interface {
+	CreateResource(ctx context.Context, adminID string, resource *Resource) (string, error)
+	ListResources(ctx context.Context) ([]Resource, error)
+	UpdateResource(ctx context.Context, adminID string, resource *Resource) error
+	DeleteResource(ctx context.Context, adminID, id string) error
+	// Slots returns the free slots of a resource in [from, to).
+	Slots(ctx context.Context, resourceID string, from, to time.Time) ([]Slot, error)
+
+	CreateBooking(ctx context.Context, userID string, booking *Booking) (string, error)
+	GetBooking(ctx context.Context, id string) (*Booking, error)
+	ListBookings(ctx context.Context, filter Filter) ([]Booking, error)
+	// UpdateBooking moves a booking to booking.Start and replaces its
+	// contact and notes.
+	UpdateBooking(ctx context.Context, userID string, booking *Booking) error
+	CancelBooking(ctx context.Context, userID, id string) error
+
+	// OfferSlots returns the next free slots of a resource and, for a
+	// WhatsApp conversation, sends them to the contact as reply buttons.
+	OfferSlots(ctx context.Context, resourceID, conversationID string) ([]Slot, error)
+	// Book books the slot a contact picked, given as its label or start
+	// time, for the conversation's contact.
+	Book(ctx context.Context, resourceID, conversationID, slot string) (*Booking, error)
+	// SendReminders reminds the contacts of bookings starting soon.
+	SendReminders(ctx context.Context) error
+}
@@ -0,0 +1,21 @@
+package cluster
+
+import "time"
+
+// Lease is the shared record of which instance holds a named leadership.
+type Lease struct {
+	Name       string    `json:"name" bson:"_id"`
+	Holder     string    `json:"holder" bson:"holder"`
+	AcquiredAt time.Time `json:"acquired_at" bson:"acquired_at"`
+	RenewedAt  time.Time `json:"renewed_at" bson:"renewed_at"`
+	ExpiresAt  time.Time `json:"expires_at" bson:"expires_at"`
+}
+
+// LeaderStatus describes the current leader as seen by one instance.
+type LeaderStatus struct {
+	Instance       string     `json:"instance"`
+	IsLeader       bool       `json:"is_leader"`
+	Leader         string     `json:"leader,omitempty"`
+	LeaderSince    *time.Time `json:"leader_since,omitempty"`
+	LeaseExpiresAt *time.Time `json:"lease_expires_at,omitempty"`
+}
@@ -0,0 +1,15 @@
+package cluster
+
+import (
+	"context"
+	"time"
+)
+
+type LeaseRepository interface {
+	// Acquire takes or renews the lease for holder until expiresAt. It fails
+	// while another holder's lease is unexpired.
+	Acquire(ctx context.Context, name, holder string, expiresAt time.Time) (bool, error)
+	// Release expires the lease if holder still holds it.
+	Release(ctx context.Context, name, holder string) error
+	Get(ctx context.Context, name string) (*Lease, error)
+}
@@ -0,0 +1,7 @@
+package cluster
+
+import "context"
+
+type Service interface {
+	Leader(ctx context.Context) (*LeaderStatus, error)
+}
@@ -0,0 +1,39 @@
+package conversation
+
+import "time"
+
+type EventType string
+
+const (
+	EventMessageCreated      EventType = "message.created"
+	EventConversationUpdated EventType = "conversation.updated"
+	EventPresenceUpdated     EventType = "presence.updated"
+)
+
+// Event is pushed to live inbox subscribers whenever a message is stored, a
+// conversation changes or an agent's presence does.
+type Event struct {
+	Type           EventType     `json:"type"`
+	ConversationID string        `json:"conversation_id,omitempty"`
+	Conversation   *Conversation `json:"conversation,omitempty"`
+	Message        *Message      `json:"message,omitempty"`
+	Presence       *Presence     `json:"presence,omitempty"`
+	Timestamp      time.Time     `json:"timestamp"`
+}
+
+type PresenceStatus string
+
+const (
+	PresenceOnline  PresenceStatus = "online"
+	PresenceAway    PresenceStatus = "away"
+	PresenceOffline PresenceStatus = "offline"
+)
+
+// Presence is whether an agent has the live inbox open, and if so whether
+// they are at it. Agents are online while connected unless they set
+// themselves away, and offline once their last stream closes.
+type Presence struct {
+	AgentID string         `json:"agent_id"`
+	Status  PresenceStatus `json:"status"`
+	Since   time.Time      `json:"since"`
+}
@@ -1,6 +1,12 @@
 package conversation
 
-import "time"
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
 
 type MessageDirection string
 
@@ -9,15 +15,256 @@ const (
 	DirectionOutgoing MessageDirection = "outgoing"
 )
 
+const (
+	ChannelWhatsApp = "whatsapp"
+	ChannelSlack    = "slack"
+	ChannelEmail    = "email"
+)
+
 type Conversation struct {
 	ID            string    `json:"id" bson:"_id,omitempty"`
 	UserID        string    `json:"user_id" bson:"user_id"`
+	Channel       string    `json:"channel" bson:"channel,omitempty"`
 	PhoneNumber   string    `json:"phone_number" bson:"phone_number"`
 	ContactName   string    `json:"contact_name" bson:"contact_name"`
 	LastMessageAt time.Time `json:"last_message_at" bson:"last_message_at"`
 	MessageCount  int       `json:"message_count" bson:"message_count"`
 	CreatedAt     time.Time `json:"created_at" bson:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at" bson:"updated_at"`
+	// ExternalID identifies conversations that are not keyed by phone number
+	// in their channel, such as a Slack thread.
+	ExternalID string `json:"external_id,omitempty" bson:"external_id,omitempty"`
+
+	// Summary condenses the messages up to SummaryThrough so prompts can
+	// use it instead of them; later messages are used verbatim.
+	Summary        string    `json:"summary,omitempty" bson:"summary,omitempty"`
+	SummaryThrough time.Time `json:"summary_through,omitempty" bson:"summary_through,omitempty"`
+	// Variables is structured context about the contact, such as plan or
+	// region, that replies are personalized with.
+	Variables map[string]string `json:"variables,omitempty" bson:"variables,omitempty"`
+	// Onboarding is where a new WhatsApp contact is in the welcome flow;
+	// Consent is their answer to the consent request, if they gave one.
+	Onboarding OnboardingState `json:"onboarding,omitempty" bson:"onboarding,omitempty"`
+	Consent    *Consent        `json:"consent,omitempty" bson:"consent,omitempty"`
+	// State is where the conversation is in its lifecycle; StateChangedAt
+	// is when it got there. Conversations from before states were tracked
+	// have none and are open.
+	State          State     `json:"state" bson:"state,omitempty"`
+	StateChangedAt time.Time `json:"state_changed_at,omitempty" bson:"state_changed_at,omitempty"`
+	// Mode is who answers the contact. In ModeHuman the bot stays quiet and
+	// AgentID, the user the conversation was handed off to, replies; SLA
+	// tracks them against the SLA targets.
+	Mode    Mode   `json:"mode" bson:"mode,omitempty"`
+	AgentID string `json:"agent_id,omitempty" bson:"agent_id,omitempty"`
+	SLA     *SLA   `json:"sla,omitempty" bson:"sla,omitempty"`
+	// AnonymizedAt is when the retention policy cleared the contact's
+	// details from the conversation.
+	AnonymizedAt *time.Time `json:"anonymized_at,omitempty" bson:"anonymized_at,omitempty"`
+	// Tags are the names of the tags agents or tag rules put on the
+	// conversation.
+	Tags []string `json:"tags,omitempty" bson:"tags,omitempty"`
+
+	// UnreadCount is the number of incoming messages the requesting user has
+	// not read yet. It is computed per request and never stored.
+	UnreadCount int64 `json:"unread_count" bson:"-"`
+	// Notes is only populated on the conversation detail response.
+	Notes []Note `json:"notes,omitempty" bson:"-"`
+}
+
+// OnboardingState tracks a new WhatsApp contact through the welcome flow.
+// Conversations that finished it, or that predate it, have none.
+type OnboardingState string
+
+const (
+	// OnboardingPending is a contact who has not been greeted yet.
+	OnboardingPending OnboardingState = "pending"
+	// OnboardingConsent is a contact asked for consent who has not given
+	// it yet; they are not answered until they do.
+	OnboardingConsent OnboardingState = "consent"
+)
+
+// State is a conversation's lifecycle state. Agents move conversations
+// between states; inactive ones are closed automatically, and a new message
+// from the contact reopens them.
+type State string
+
+const (
+	StateOpen State = "open"
+	// StatePending is waiting on the contact or a third party.
+	StatePending  State = "pending"
+	StateResolved State = "resolved"
+	StateClosed   State = "closed"
+)
+
+// States lists the lifecycle states in order.
+var States = []State{StateOpen, StatePending, StateResolved, StateClosed}
+
+// Valid reports whether s is a known state.
+func (s State) Valid() bool {
+	for _, state := range States {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// Mode says who answers a conversation. Conversations that were never
+// handed off have none and are answered by the bot.
+type Mode string
+
+const (
+	ModeBot   Mode = "bot"
+	ModeHuman Mode = "human"
+)
+
+// SLATargets are how long agents have to first reply to a conversation
+// handed off to them and to resolve it. Zero means no target.
+type SLATargets struct {
+	FirstResponse time.Duration
+	Resolution    time.Duration
+}
+
+// SLA tracks a handed-off conversation against the targets in force at the
+// handoff. A reply or resolution after its due time, or none by then, is a
+// breach. Zero due times have no target.
+type SLA struct {
+	AgentID               string     `json:"agent_id" bson:"agent_id"`
+	StartedAt             time.Time  `json:"started_at" bson:"started_at"`
+	FirstResponseDue      time.Time  `json:"first_response_due,omitempty" bson:"first_response_due,omitempty"`
+	FirstResponseAt       *time.Time `json:"first_response_at,omitempty" bson:"first_response_at,omitempty"`
+	FirstResponseBreached bool       `json:"first_response_breached" bson:"first_response_breached"`
+	ResolutionDue         time.Time  `json:"resolution_due,omitempty" bson:"resolution_due,omitempty"`
+	ResolvedAt            *time.Time `json:"resolved_at,omitempty" bson:"resolved_at,omitempty"`
+	ResolutionBreached    bool       `json:"resolution_breached" bson:"resolution_breached"`
+}
+
+// AgentSLA is how one agent did against the SLA targets.
+type AgentSLA struct {
+	AgentID string `json:"agent_id" bson:"_id"`
+	// Conversations were handed off to the agent; Responded and Resolved
+	// of them got a first reply and were resolved or closed by them.
+	Conversations int64 `json:"conversations" bson:"conversations"`
+	Responded     int64 `json:"responded" bson:"responded"`
+	Resolved      int64 `json:"resolved" bson:"resolved"`
+	// The averages cover the conversations that got there.
+	AvgFirstResponseSeconds float64 `json:"avg_first_response_seconds" bson:"avg_first_response_seconds"`
+	AvgResolutionSeconds    float64 `json:"avg_resolution_seconds" bson:"avg_resolution_seconds"`
+	FirstResponseBreaches   int64   `json:"first_response_breaches" bson:"first_response_breaches"`
+	ResolutionBreaches      int64   `json:"resolution_breaches" bson:"resolution_breaches"`
+	// Breached counts conversations with either breach; Compliance is the
+	// share without one.
+	Breached   int64   `json:"breached" bson:"breached"`
+	Compliance float64 `json:"compliance" bson:"-"`
+}
+
+// SLAReport is how agents did against the SLA targets for conversations
+// handed off between From and To.
+type SLAReport struct {
+	FirstResponseTargetSeconds int64      `json:"first_response_target_seconds"`
+	ResolutionTargetSeconds    int64      `json:"resolution_target_seconds"`
+	From                       time.Time  `json:"from"`
+	To                         time.Time  `json:"to"`
+	Agents                     []AgentSLA `json:"agents"`
+}
+
+// Consent records a contact's answer to the consent request.
+type Consent struct {
+	Granted bool      `json:"granted" bson:"granted"`
+	At      time.Time `json:"at" bson:"at"`
+}
+
+// ThreadMessage is an incoming message from a channel whose conversations
+// are threads owned by a lucidRAG user, such as Slack.
+type ThreadMessage struct {
+	Channel    string
+	ExternalID string
+	UserID     string
+	// From identifies the sender in the channel; ContactName is how they
+	// are shown.
+	From        string
+	ContactName string
+	Content     string
+}
+
+// ConversationFilter narrows conversation listings. Query matches contact
+// name, phone number, or any conversation listed in ConversationIDs (the
+// conversations whose messages matched the query). Times bound the last
+// message time. SLABreached keeps conversations with a breached SLA, Tags
+// those with every tag listed.
+type ConversationFilter struct {
+	Query           string
+	ConversationIDs []string
+	Channel         string
+	State           State
+	Mode            Mode
+	SLABreached     bool
+	Tags            []string
+	StartTime       time.Time
+	EndTime         time.Time
+	UserID          string
+	Limit           int
+	Offset          int
+}
+
+// HasCriteria reports whether the filter restricts results beyond ownership
+// and pagination.
+func (f ConversationFilter) HasCriteria() bool {
+	return f.Query != "" || f.Channel != "" || f.State != "" || f.Mode != "" || f.SLABreached ||
+		len(f.Tags) > 0 || !f.StartTime.IsZero() || !f.EndTime.IsZero()
+}
+
+// SavedFilter is a set of listing filters a user saved under a name to
+// apply again. Saved filters are private to the user who saved them.
+type SavedFilter struct {
+	ID          string    `json:"id" bson:"_id,omitempty"`
+	UserID      string    `json:"user_id" bson:"user_id"`
+	Name        string    `json:"name" bson:"name"`
+	Query       string    `json:"q,omitempty" bson:"query,omitempty"`
+	Channel     string    `json:"channel,omitempty" bson:"channel,omitempty"`
+	State       State     `json:"state,omitempty" bson:"state,omitempty"`
+	Mode        Mode      `json:"mode,omitempty" bson:"mode,omitempty"`
+	SLABreached bool      `json:"sla_breached,omitempty" bson:"sla_breached,omitempty"`
+	Tags        []string  `json:"tags,omitempty" bson:"tags,omitempty"`
+	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// ApplyTo fills in the criteria filter leaves unset from the saved filter,
+// so a listing can narrow or override a saved one.
+func (s SavedFilter) ApplyTo(filter *ConversationFilter) {
+	if filter.Query == "" {
+		filter.Query = s.Query
+	}
+	if filter.Channel == "" {
+		filter.Channel = s.Channel
+	}
+	if filter.State == "" {
+		filter.State = s.State
+	}
+	if filter.Mode == "" {
+		filter.Mode = s.Mode
+	}
+	filter.SLABreached = filter.SLABreached || s.SLABreached
+	for _, tag := range s.Tags {
+		if !slices.Contains(filter.Tags, tag) {
+			filter.Tags = append(filter.Tags, tag)
+		}
+	}
+}
+
+// Stats counts conversations by lifecycle state.
+type Stats struct {
+	Total  int64           `json:"total"`
+	States map[State]int64 `json:"states"`
+}
+
+// ReadMarker records when a user last read a conversation.
+type ReadMarker struct {
+	ID             string    `json:"id" bson:"_id,omitempty"`
+	UserID         string    `json:"user_id" bson:"user_id"`
+	ConversationID string    `json:"conversation_id" bson:"conversation_id"`
+	LastReadAt     time.Time `json:"last_read_at" bson:"last_read_at"`
 }
 
 type Message struct {
@@ -29,6 +276,253 @@ type Message struct {
 	MessageType    string           `json:"message_type" bson:"message_type"`
 	RAGQueryID     string           `json:"rag_query_id,omitempty" bson:"rag_query_id,omitempty"`
 	RAGAnswer      string           `json:"rag_answer,omitempty" bson:"rag_answer,omitempty"`
-	Timestamp      time.Time        `json:"timestamp" bson:"timestamp"`
-	CreatedAt      time.Time        `json:"created_at" bson:"created_at"`
+	Media          *Media           `json:"media,omitempty" bson:"media,omitempty"`
+	// Form holds the answers of a completed WhatsApp Flow, as the flow
+	// returned them, keyed by field name.
+	Form      map[string]any `json:"form,omitempty" bson:"form,omitempty"`
+	Timestamp time.Time      `json:"timestamp" bson:"timestamp"`
+	CreatedAt time.Time      `json:"created_at" bson:"created_at"`
+	// AnonymizedAt is when the retention policy cleared the message's text.
+	AnonymizedAt *time.Time `json:"anonymized_at,omitempty" bson:"anonymized_at,omitempty"`
+}
+
+// maxPromptChars trims long messages when they are quoted in prompts.
+const maxPromptChars = 1000
+
+// PromptText is the message as a model should read it: its content plus
+// what an attached photo shows and a completed form's answers, trimmed to a
+// prompt-friendly length.
+func (m Message) PromptText() string {
+	text := strings.TrimSpace(m.Content)
+	if m.Media != nil && m.Media.Description != "" {
+		text = strings.TrimSpace(text + " [photo: " + m.Media.Description + "]")
+	}
+	if answers := FormAnswers(m.Form); len(answers) > 0 {
+		text = strings.TrimSpace(text + " [form: " + strings.Join(answers, "; ") + "]")
+	}
+	if runes := []rune(text); len(runes) > maxPromptChars {
+		text = string(runes[:maxPromptChars]) + "…"
+	}
+	return text
+}
+
+// FormToken is the field a completed WhatsApp Flow echoes the token it was
+// sent with in.
+const FormToken = "flow_token"
+
+// FormAnswers lists a form's answers as "field: value", sorted by field,
+// without the flow token.
+func FormAnswers(form map[string]any) []string {
+	answers := make([]string, 0, len(form))
+	for _, field := range slices.Sorted(maps.Keys(form)) {
+		if field == FormToken {
+			continue
+		}
+		answers = append(answers, field+": "+FormValue(form[field]))
+	}
+	return answers
+}
+
+// FormValue renders one form answer as text. Lists, such as the options
+// ticked in a checkbox group, are joined with commas.
+func FormValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, FormValue(item))
+		}
+		return strings.Join(parts, ", ")
+	}
+	return fmt.Sprint(value)
+}
+
+// History is what a reply needs to know about the conversation so far: the
+// rolling summary, the recent messages it does not cover (oldest first) and
+// the contact's variables.
+type History struct {
+	Summary   string
+	Messages  []Message
+	Variables map[string]string
+}
+
+// Media references an attachment kept by the channel, such as a WhatsApp
+// media ID, along with what the models made of it.
+type Media struct {
+	ID       string `json:"id" bson:"id"`
+	MimeType string `json:"mime_type,omitempty" bson:"mime_type,omitempty"`
+	// Description is the vision model's account of an image.
+	Description string `json:"description,omitempty" bson:"description,omitempty"`
+}
+
+// Note is an internal comment on a conversation, optionally anchored to one
+// of its messages. Notes are never sent to the contact.
+type Note struct {
+	ID             string    `json:"id" bson:"_id,omitempty"`
+	ConversationID string    `json:"conversation_id" bson:"conversation_id"`
+	MessageID      string    `json:"message_id,omitempty" bson:"message_id,omitempty"`
+	AuthorID       string    `json:"author_id" bson:"author_id"`
+	Content        string    `json:"content" bson:"content"`
+	CreatedAt      time.Time `json:"created_at" bson:"created_at"`
+	// AnonymizedAt is when the retention policy cleared the note's text.
+	AnonymizedAt *time.Time `json:"anonymized_at,omitempty" bson:"anonymized_at,omitempty"`
+}
+
+// CannedResponse is a reply agents reuse, picked by typing its shortcut.
+// Its text may hold placeholders such as {contact_name} or a conversation
+// variable like {plan}, filled in when it is rendered for a conversation.
+// Canned responses are shared by all agents.
+type CannedResponse struct {
+	ID        string    `json:"id" bson:"_id,omitempty"`
+	Shortcut  string    `json:"shortcut" bson:"shortcut"`
+	Title     string    `json:"title" bson:"title"`
+	Text      string    `json:"text" bson:"text"`
+	CreatedBy string    `json:"created_by" bson:"created_by"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// Tag labels conversations, by name. Agents tag conversations by hand;
+// a tag with keywords is also put on every conversation whose contact
+// writes one of them, as a whole word or phrase in any case, such as
+// "refund" for a billing tag.
+type Tag struct {
+	ID   string `json:"id" bson:"_id,omitempty"`
+	Name string `json:"name" bson:"name"`
+	// Color is a #rrggbb color the UI shows the tag in.
+	Color     string    `json:"color,omitempty" bson:"color,omitempty"`
+	Keywords  []string  `json:"keywords,omitempty" bson:"keywords,omitempty"`
+	CreatedBy string    `json:"created_by" bson:"created_by"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// AssignmentRule routes conversations handed off without an agent that
+// have any of Tags to the online agent among AgentIDs whose turn it is.
+// Rules are tried by ascending Priority; when none has an online agent,
+// the conversation goes round-robin to any online agent.
+type AssignmentRule struct {
+	ID        string    `json:"id" bson:"_id,omitempty"`
+	Name      string    `json:"name" bson:"name"`
+	Tags      []string  `json:"tags" bson:"tags"`
+	AgentIDs  []string  `json:"agent_ids" bson:"agent_ids"`
+	Priority  int       `json:"priority" bson:"priority"`
+	CreatedBy string    `json:"created_by" bson:"created_by"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// Matches reports whether the rule applies to a conversation with tags.
+func (r AssignmentRule) Matches(tags []string) bool {
+	return slices.ContainsFunc(r.Tags, func(tag string) bool { return slices.Contains(tags, tag) })
+}
+
+// AssignmentReason is how a conversation came to its agent.
+type AssignmentReason string
+
+const (
+	// AssignmentManual is an agent picked by whoever handed the
+	// conversation off or reassigned it.
+	AssignmentManual AssignmentReason = "manual"
+	// AssignmentRuleMatch is an agent picked by an assignment rule.
+	AssignmentRuleMatch AssignmentReason = "rule"
+	// AssignmentRoundRobin is the online agent whose turn it was.
+	AssignmentRoundRobin AssignmentReason = "round_robin"
+	// AssignmentFallback is the requester, when no agent was online to
+	// route to.
+	AssignmentFallback AssignmentReason = "fallback"
+	// AssignmentReleased is a conversation handed back to the bot.
+	AssignmentReleased AssignmentReason = "released"
+)
+
+// Assignment records a conversation changing agents, for its assignment
+// history. AgentID is empty when it went back to the bot.
+type Assignment struct {
+	ID              string           `json:"id" bson:"_id,omitempty"`
+	ConversationID  string           `json:"conversation_id" bson:"conversation_id"`
+	AgentID         string           `json:"agent_id,omitempty" bson:"agent_id,omitempty"`
+	PreviousAgentID string           `json:"previous_agent_id,omitempty" bson:"previous_agent_id,omitempty"`
+	Reason          AssignmentReason `json:"reason" bson:"reason"`
+	RuleID          string           `json:"rule_id,omitempty" bson:"rule_id,omitempty"`
+	// ActorID is the user who handed off or reassigned the conversation.
+	ActorID   string    `json:"actor_id" bson:"actor_id"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// RenderedResponse is a canned response filled in for a conversation.
+// Missing lists the placeholders the conversation had no value for; they
+// are left in the text for the agent to complete.
+type RenderedResponse struct {
+	ID       string   `json:"id"`
+	Shortcut string   `json:"shortcut"`
+	Text     string   `json:"text"`
+	Missing  []string `json:"missing,omitempty"`
+}
+
+// PurgeResult counts a user's conversations, and their messages, that a
+// purge removed or, in a dry run, would remove.
+type PurgeResult struct {
+	Conversations int64 `json:"conversations"`
+	Messages      int64 `json:"messages"`
+}
+
+// Retention is after how many days conversation data is anonymized, by
+// kind; zero keeps that kind. Anonymizing clears what identifies the
+// contact or what was said but keeps the records, so counts, states and
+// SLAs still add up.
+type Retention struct {
+	// ConversationDays clears the contact's details from conversations
+	// inactive that long.
+	ConversationDays int
+	MessageDays      int
+	NoteDays         int
+}
+
+// Collections that retention policies apply to.
+const (
+	RetentionConversations = "conversations"
+	RetentionMessages      = "messages"
+	RetentionNotes         = "notes"
+)
+
+// RetentionCounts is where one collection stands against a retention
+// cutoff.
+type RetentionCounts struct {
+	Anonymized int64 `json:"anonymized"`
+	// Overdue records are past the cutoff but not anonymized yet.
+	Overdue int64 `json:"overdue"`
+	// OldestRetained is when the oldest record that still holds its data
+	// was last active.
+	OldestRetained *time.Time `json:"oldest_retained,omitempty"`
+}
+
+// RetentionPolicy is one collection's retention rule and how it is being
+// kept.
+type RetentionPolicy struct {
+	Collection string `json:"collection"`
+	// Days is zero when the collection is kept as is.
+	Days int `json:"days"`
+	// Fields are what anonymizing clears.
+	Fields []string   `json:"fields"`
+	Cutoff *time.Time `json:"cutoff,omitempty"`
+	RetentionCounts
+}
+
+// RetentionReport is how the retention policies are being kept. It is
+// Compliant when no record is overdue.
+type RetentionReport struct {
+	GeneratedAt time.Time         `json:"generated_at"`
+	Compliant   bool              `json:"compliant"`
+	Policies    []RetentionPolicy `json:"policies"`
+}
+
+// RetentionResult counts the records one retention run anonymized.
+type RetentionResult struct {
+	Conversations int64 `json:"conversations"`
+	Messages      int64 `json:"messages"`
+	Notes         int64 `json:"notes"`
 }
@@ -1,6 +1,7 @@
 package conversation
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -124,3 +125,22 @@ func TestMessageDirectionComparison(t *testing.T) {
 		t.Error("Outgoing message should have DirectionOutgoing")
 	}
 }
+
+func TestMessagePromptText(t *testing.T) {
+	msg := Message{Content: " Is this in stock? ", Media: &Media{Description: "a red mug"}}
+	if got := msg.PromptText(); got != "Is this in stock? [photo: a red mug]" {
+		t.Errorf("Expected content with photo description, got %q", got)
+	}
+
+	form := Message{Content: "Sent", Form: map[string]any{
+		"flow_token": "flow-1:abc", "date": "2026-10-20", "services": []any{"cut", "color"}, "guests": float64(2),
+	}}
+	if got := form.PromptText(); got != "Sent [form: date: 2026-10-20; guests: 2; services: cut, color]" {
+		t.Errorf("Expected content with form answers, got %q", got)
+	}
+
+	long := Message{Content: strings.Repeat("a", maxPromptChars+10)}
+	if got := []rune(long.PromptText()); len(got) != maxPromptChars+1 {
+		t.Errorf("Expected text trimmed to %d characters plus ellipsis, got %d", maxPromptChars, len(got))
+	}
+}
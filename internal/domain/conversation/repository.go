@@ -1,17 +1,62 @@
 package conversation
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type ConversationRepository interface {
 	Create(ctx context.Context, conv *Conversation) (string, error)
 	GetByID(ctx context.Context, id string) (*Conversation, error)
 	GetByPhoneNumber(ctx context.Context, phoneNumber string) (*Conversation, error)
+	GetByExternalID(ctx context.Context, channel, externalID string) (*Conversation, error)
 	List(ctx context.Context, limit, offset int) ([]Conversation, error)
 	ListByUser(ctx context.Context, userID string, limit, offset int) ([]Conversation, error)
 	UpdateLastMessage(ctx context.Context, id string) error
 	IncrementMessageCount(ctx context.Context, id string) error
+	// UpdateSummary stores a rolling summary covering the messages up to
+	// and including through.
+	UpdateSummary(ctx context.Context, id, summary string, through time.Time) error
+	UpdateVariables(ctx context.Context, id string, variables map[string]string) error
+	SetTags(ctx context.Context, id string, tags []string) error
+	// AddTags adds the tags the conversation does not have yet.
+	AddTags(ctx context.Context, id string, tags []string) error
+	// RenameTag renames a tag on every conversation that has it; an empty
+	// to removes it.
+	RenameTag(ctx context.Context, from, to string) error
+	// SetOnboarding moves a conversation from one onboarding state to
+	// another, reporting false when it was no longer in from. An empty to
+	// ends onboarding.
+	SetOnboarding(ctx context.Context, id string, from, to OnboardingState) (bool, error)
+	SetConsent(ctx context.Context, id string, consent Consent) error
+	SetState(ctx context.Context, id string, state State) error
+	// SetMode hands a conversation to mode and agentID, starting sla when
+	// it is not nil.
+	SetMode(ctx context.Context, id string, mode Mode, agentID string, sla *SLA) error
+	UpdateSLA(ctx context.Context, id string, sla SLA) error
+	// FlagSLABreaches flags the SLAs whose first reply or resolution is
+	// overdue as of now, returning how many it flagged.
+	FlagSLABreaches(ctx context.Context, now time.Time) (int64, error)
+	// SLAByAgent reports per agent on the SLAs started between from and
+	// to, leaving Compliance to the caller.
+	SLAByAgent(ctx context.Context, from, to time.Time) ([]AgentSLA, error)
+	// CloseInactive closes conversations whose last message is older than
+	// before, returning how many it closed.
+	CloseInactive(ctx context.Context, before time.Time) (int64, error)
+	// CountByState counts the conversations matching filter by state,
+	// ignoring its state and pagination.
+	CountByState(ctx context.Context, filter ConversationFilter) (map[State]int64, error)
+	// AnonymizeInactive clears the contact's details from conversations
+	// whose last message is older than before, returning how many it
+	// cleared.
+	AnonymizeInactive(ctx context.Context, before time.Time) (int64, error)
+	// RetentionCounts reports on anonymized conversations and, when before
+	// is set, those inactive since before that are not.
+	RetentionCounts(ctx context.Context, before time.Time) (*RetentionCounts, error)
 	Count(ctx context.Context) (int64, error)
 	CountByUser(ctx context.Context, userID string) (int64, error)
+	Search(ctx context.Context, filter ConversationFilter) ([]Conversation, int64, error)
+	Delete(ctx context.Context, id string) error
 }
 
 type MessageRepository interface {
@@ -19,4 +64,82 @@ type MessageRepository interface {
 	GetByID(ctx context.Context, id string) (*Message, error)
 	GetByConversationID(ctx context.Context, conversationID string, limit, offset int) ([]Message, error)
 	CountByConversation(ctx context.Context, conversationID string) (int64, error)
+	CountIncomingSince(ctx context.Context, conversationID string, since time.Time) (int64, error)
+	// ListAfter returns up to limit messages newer than after, oldest first.
+	ListAfter(ctx context.Context, conversationID string, after time.Time, limit int) ([]Message, error)
+	// SearchConversationIDs returns up to limit conversations with a message
+	// matching query, only userID's when it is set.
+	SearchConversationIDs(ctx context.Context, userID, query string, limit int) ([]string, error)
+	// AnonymizeBefore clears the text of messages sent before before.
+	AnonymizeBefore(ctx context.Context, before time.Time) (int64, error)
+	RetentionCounts(ctx context.Context, before time.Time) (*RetentionCounts, error)
+	DeleteByConversation(ctx context.Context, conversationID string) error
+}
+
+type ReadMarkerRepository interface {
+	MarkRead(ctx context.Context, userID, conversationID string, at time.Time) error
+	GetByUser(ctx context.Context, userID string, conversationIDs []string) (map[string]time.Time, error)
+	DeleteByConversation(ctx context.Context, conversationID string) error
+}
+
+type NoteRepository interface {
+	Create(ctx context.Context, note *Note) (string, error)
+	ListByConversation(ctx context.Context, conversationID string) ([]Note, error)
+	// AnonymizeBefore clears the text of notes written before before.
+	AnonymizeBefore(ctx context.Context, before time.Time) (int64, error)
+	RetentionCounts(ctx context.Context, before time.Time) (*RetentionCounts, error)
+	DeleteByConversation(ctx context.Context, conversationID string) error
+}
+
+type CannedResponseRepository interface {
+	Create(ctx context.Context, response *CannedResponse) (string, error)
+	GetByID(ctx context.Context, id string) (*CannedResponse, error)
+	GetByShortcut(ctx context.Context, shortcut string) (*CannedResponse, error)
+	// List returns the canned responses whose shortcut or title contains
+	// query, or all of them when it is empty, by shortcut.
+	List(ctx context.Context, query string) ([]CannedResponse, error)
+	Update(ctx context.Context, response *CannedResponse) error
+	Delete(ctx context.Context, id string) error
+}
+
+type TagRepository interface {
+	Create(ctx context.Context, tag *Tag) (string, error)
+	GetByID(ctx context.Context, id string) (*Tag, error)
+	GetByName(ctx context.Context, name string) (*Tag, error)
+	// List returns every tag, by name.
+	List(ctx context.Context) ([]Tag, error)
+	Update(ctx context.Context, tag *Tag) error
+	Delete(ctx context.Context, id string) error
+}
+
+type SavedFilterRepository interface {
+	Create(ctx context.Context, filter *SavedFilter) (string, error)
+	GetByID(ctx context.Context, id string) (*SavedFilter, error)
+	// ListByUser returns the user's saved filters, by name.
+	ListByUser(ctx context.Context, userID string) ([]SavedFilter, error)
+	Update(ctx context.Context, filter *SavedFilter) error
+	Delete(ctx context.Context, id string) error
+	// RenameTag renames a tag in every saved filter that has it; an empty
+	// to removes it.
+	RenameTag(ctx context.Context, from, to string) error
+}
+
+type AssignmentRuleRepository interface {
+	Create(ctx context.Context, rule *AssignmentRule) (string, error)
+	GetByID(ctx context.Context, id string) (*AssignmentRule, error)
+	// List returns every rule, by priority then name.
+	List(ctx context.Context) ([]AssignmentRule, error)
+	Update(ctx context.Context, rule *AssignmentRule) error
+	Delete(ctx context.Context, id string) error
+	// RenameTag renames a tag in every rule that has it; an empty to
+	// removes it.
+	RenameTag(ctx context.Context, from, to string) error
+}
+
+type AssignmentRepository interface {
+	Create(ctx context.Context, assignment *Assignment) (string, error)
+	// ListByConversation returns a conversation's assignments, oldest
+	// first.
+	ListByConversation(ctx context.Context, conversationID string) ([]Assignment, error)
+	DeleteByConversation(ctx context.Context, conversationID string) error
 }
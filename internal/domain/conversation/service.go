@@ -1,6 +1,9 @@
 package conversation
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type UserContext struct {
 	UserID  string
@@ -9,10 +12,87 @@ type UserContext struct {
 
 type Service interface {
 	GetOrCreateConversation(ctx context.Context, userID, phoneNumber, contactName string) (*Conversation, error)
-	ListConversations(ctx context.Context, userCtx UserContext, limit, offset int) ([]Conversation, int64, error)
+	ListConversations(ctx context.Context, userCtx UserContext, filter ConversationFilter) ([]Conversation, int64, error)
 	GetConversation(ctx context.Context, userCtx UserContext, id string) (*Conversation, error)
 
 	SaveIncomingMessage(ctx context.Context, phoneNumber, contactName, whatsappMsgID, content, msgType string) (*Message, error)
+	// SaveIncomingMedia saves a message that carries an attachment; content
+	// is its caption or transcript.
+	SaveIncomingMedia(ctx context.Context, phoneNumber, contactName, whatsappMsgID, content, msgType string, media Media) (*Message, error)
+	// SaveIncomingForm saves a completed WhatsApp Flow, with its answers,
+	// as a message of type "flow".
+	SaveIncomingForm(ctx context.Context, phoneNumber, contactName, whatsappMsgID, content string, form map[string]any) (*Message, error)
+	// SaveThreadMessage saves a message to the conversation for its thread,
+	// starting one owned by msg.UserID if needed.
+	SaveThreadMessage(ctx context.Context, msg ThreadMessage) (*Message, error)
 	SaveOutgoingMessage(ctx context.Context, conversationID, content, ragAnswer string) (*Message, error)
 	GetMessages(ctx context.Context, userCtx UserContext, conversationID string, limit, offset int) ([]Message, int64, error)
+	// GetHistory returns the conversation's summary and up to limit of the
+	// most recent messages it does not cover, for building reply prompts.
+	GetHistory(ctx context.Context, conversationID string, limit int) (*History, error)
+	MarkRead(ctx context.Context, userCtx UserContext, conversationID string) error
+	AddNote(ctx context.Context, userCtx UserContext, note *Note) (*Note, error)
+	// SetVariables merges variables into the conversation's; an empty value
+	// removes its key. It returns the resulting set.
+	SetVariables(ctx context.Context, userCtx UserContext, conversationID string, variables map[string]string) (map[string]string, error)
+	// SetState moves a conversation to another lifecycle state.
+	SetState(ctx context.Context, userCtx UserContext, conversationID string, state State) (*Conversation, error)
+	// SetMode hands a conversation off to an agent, or back to the bot.
+	// Without an agent it is routed by the assignment rules.
+	SetMode(ctx context.Context, userCtx UserContext, conversationID string, mode Mode, agentID string) (*Conversation, error)
+	// Reassign moves a handed-off conversation to agentID, or routes it to
+	// another agent when agentID is empty.
+	Reassign(ctx context.Context, userCtx UserContext, conversationID, agentID string) (*Conversation, error)
+	// ListAssignments returns a conversation's assignment history.
+	ListAssignments(ctx context.Context, userCtx UserContext, conversationID string) ([]Assignment, error)
+	ListAssignmentRules(ctx context.Context, userCtx UserContext) ([]AssignmentRule, error)
+	CreateAssignmentRule(ctx context.Context, userCtx UserContext, rule *AssignmentRule) (string, error)
+	UpdateAssignmentRule(ctx context.Context, userCtx UserContext, rule *AssignmentRule) error
+	DeleteAssignmentRule(ctx context.Context, userCtx UserContext, id string) error
+	// SLAReport reports per agent on the SLAs of conversations handed off
+	// between from and to.
+	SLAReport(ctx context.Context, userCtx UserContext, from, to time.Time) (*SLAReport, error)
+	// Stats counts the conversations matching filter by state.
+	Stats(ctx context.Context, userCtx UserContext, filter ConversationFilter) (*Stats, error)
+	CreateCannedResponse(ctx context.Context, userCtx UserContext, response *CannedResponse) (string, error)
+	ListCannedResponses(ctx context.Context, userCtx UserContext, query string) ([]CannedResponse, error)
+	UpdateCannedResponse(ctx context.Context, userCtx UserContext, response *CannedResponse) error
+	DeleteCannedResponse(ctx context.Context, userCtx UserContext, id string) error
+	// RenderCannedResponse fills in the canned response with shortcut for
+	// a conversation.
+	RenderCannedResponse(ctx context.Context, userCtx UserContext, conversationID, shortcut string) (*RenderedResponse, error)
+	// ListTags returns every tag, for any user to pick from.
+	ListTags(ctx context.Context, userCtx UserContext) ([]Tag, error)
+	CreateTag(ctx context.Context, userCtx UserContext, tag *Tag) (string, error)
+	// UpdateTag changes a tag; renaming it renames it on the conversations
+	// that have it.
+	UpdateTag(ctx context.Context, userCtx UserContext, tag *Tag) error
+	// DeleteTag deletes a tag and takes it off every conversation.
+	DeleteTag(ctx context.Context, userCtx UserContext, id string) error
+	// SetTags replaces the conversation's tags, returning them.
+	SetTags(ctx context.Context, userCtx UserContext, conversationID string, tags []string) ([]string, error)
+	// AutoTag puts the tags whose keywords text mentions on the
+	// conversation, returning those it matched.
+	AutoTag(ctx context.Context, conversationID, text string) ([]string, error)
+	ListSavedFilters(ctx context.Context, userCtx UserContext) ([]SavedFilter, error)
+	GetSavedFilter(ctx context.Context, userCtx UserContext, id string) (*SavedFilter, error)
+	CreateSavedFilter(ctx context.Context, userCtx UserContext, filter *SavedFilter) (string, error)
+	UpdateSavedFilter(ctx context.Context, userCtx UserContext, filter *SavedFilter) error
+	DeleteSavedFilter(ctx context.Context, userCtx UserContext, id string) error
+	// PurgeUserConversations deletes every conversation userID owns, with
+	// its messages, notes and read markers. A dry run only counts them.
+	PurgeUserConversations(ctx context.Context, userCtx UserContext, userID string, dryRun bool) (*PurgeResult, error)
+	// ApplyRetention anonymizes the data past its retention policy.
+	ApplyRetention(ctx context.Context) (*RetentionResult, error)
+	// RetentionReport reports how the retention policies are being kept.
+	RetentionReport(ctx context.Context, userCtx UserContext) (*RetentionReport, error)
+
+	// Subscribe registers a live listener for events visible to userCtx. The
+	// returned function must be called to release the subscription. An
+	// admin's subscription also makes them present as an agent.
+	Subscribe(userCtx UserContext) (<-chan Event, func())
+	// SetPresence sets a connected agent online or away.
+	SetPresence(ctx context.Context, userCtx UserContext, status PresenceStatus) (*Presence, error)
+	// ListPresence returns the agents connected to the live inbox.
+	ListPresence(ctx context.Context, userCtx UserContext) ([]Presence, error)
 }
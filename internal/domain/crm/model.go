@@ -0,0 +1,59 @@
+package crm
+
+import "time"
+
+type Provider string
+
+const (
+	ProviderHubSpot    Provider = "hubspot"
+	ProviderSalesforce Provider = "salesforce"
+)
+
+// Providers lists the supported CRMs.
+var Providers = []Provider{ProviderHubSpot, ProviderSalesforce}
+
+// Credentials authenticate against a CRM. HubSpot uses a private app Token;
+// Salesforce uses a connected app's ClientID and ClientSecret with the
+// client credentials flow. They are write-only and stored encrypted.
+type Credentials struct {
+	Token        string `json:"token,omitempty"`
+	ClientID     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// Connection configures contact sync with one CRM. Contacts are matched by
+// phone number.
+type Connection struct {
+	Provider Provider `json:"provider" bson:"_id"`
+	IsActive bool     `json:"is_active" bson:"is_active"`
+	// InstanceURL is the Salesforce org, e.g. https://acme.my.salesforce.com.
+	InstanceURL string `json:"instance_url,omitempty" bson:"instance_url,omitempty"`
+	// SummaryField is the contact field the conversation summary is written
+	// to; empty skips it.
+	SummaryField string `json:"summary_field,omitempty" bson:"summary_field,omitempty"`
+	// Attributes maps contact fields to the conversation variables they are
+	// copied into, e.g. {"plan_tier__c": "plan"}.
+	Attributes map[string]string `json:"attributes,omitempty" bson:"attributes,omitempty"`
+	// Exports maps contact fields to the conversation variables written to
+	// them, such as answers collected with a WhatsApp Flow, e.g.
+	// {"Budget__c": "lead_budget"}.
+	Exports map[string]string `json:"exports,omitempty" bson:"exports,omitempty"`
+
+	Credentials       Credentials `json:"-" bson:"-"`
+	SealedCredentials string      `json:"-" bson:"credentials"`
+	HasCredentials    bool        `json:"has_credentials" bson:"-"`
+
+	LastSyncAt *time.Time `json:"last_sync_at,omitempty" bson:"last_sync_at,omitempty"`
+	LastError  string     `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	UpdatedBy  string     `json:"updated_by" bson:"updated_by"`
+	UpdatedAt  time.Time  `json:"updated_at" bson:"updated_at"`
+}
+
+// Contact is what is pushed to the CRM for a conversation.
+type Contact struct {
+	Phone   string
+	Name    string
+	Summary string
+	// Fields are written to the contact as they are, keyed by field name.
+	Fields map[string]string
+}
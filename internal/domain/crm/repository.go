@@ -0,0 +1,15 @@
+package crm
+
+import (
+	"context"
+	"time"
+)
+
+type Repository interface {
+	Get(ctx context.Context, provider Provider) (*Connection, error)
+	List(ctx context.Context) ([]Connection, error)
+	Upsert(ctx context.Context, conn *Connection) error
+	Delete(ctx context.Context, provider Provider) error
+	// RecordSync stores the outcome of a sync; syncErr is empty on success.
+	RecordSync(ctx context.Context, provider Provider, at time.Time, syncErr string) error
+}
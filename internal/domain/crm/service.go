@@ -0,0 +1,13 @@
+package crm
+
+import "context"
+
+type Service interface {
+	ListConnections(ctx context.Context) ([]Connection, error)
+	SaveConnection(ctx context.Context, adminID string, conn *Connection) error
+	DeleteConnection(ctx context.Context, adminID string, provider Provider) error
+	// Sync pushes conversations with new messages since the last sync to
+	// every active CRM and copies mapped contact fields back into their
+	// variables.
+	Sync(ctx context.Context) error
+}
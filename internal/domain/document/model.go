@@ -1,32 +1,537 @@
 package document
 
-import "time"
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusDraft     Status = "draft"
+	StatusReview    Status = "review"
+	StatusPublished Status = "published"
+)
+
+// CanTransition reports whether the approval workflow allows moving a
+// document from one status to another.
+func CanTransition(from, to Status) bool {
+	if from == "" {
+		from = StatusPublished
+	}
+	switch from {
+	case StatusDraft:
+		return to == StatusReview
+	case StatusReview:
+		return to == StatusPublished || to == StatusDraft
+	case StatusPublished:
+		return to == StatusDraft
+	}
+	return false
+}
 
 type Document struct {
+	ID         string     `json:"id" bson:"_id,omitempty"`
+	UserID     string     `json:"user_id" bson:"user_id"`
+	Title      string     `json:"title" bson:"title"`
+	Content    string     `json:"content" bson:"content"`
+	Source     string     `json:"source" bson:"source"`
+	UploadedAt time.Time  `json:"uploaded_at" bson:"uploaded_at"`
+	UpdatedAt  time.Time  `json:"updated_at" bson:"updated_at"`
+	IsActive   bool       `json:"is_active" bson:"is_active"`
+	Metadata   string     `json:"metadata" bson:"metadata"`
+	PublishAt  *time.Time `json:"publish_at,omitempty" bson:"publish_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" bson:"expires_at"`
+	Status     Status     `json:"status" bson:"status,omitempty"`
+	// Collection names the collection whose embedding space the
+	// document's chunks use; empty means the default space.
+	Collection string `json:"collection,omitempty" bson:"collection,omitempty"`
+	// ContentHash fingerprints the normalized content, to spot duplicates.
+	ContentHash string `json:"content_hash,omitempty" bson:"content_hash,omitempty"`
+	// DuplicateOf links a duplicate to the document it repeats. Linked
+	// documents have no chunks of their own.
+	DuplicateOf string `json:"duplicate_of,omitempty" bson:"duplicate_of,omitempty"`
+	// Version counts the document's updates. An update naming a version
+	// applies only if the document is still at it.
+	Version int64 `json:"version" bson:"version"`
+	// Truncated marks a streamed document whose Content keeps only the
+	// start of its text; its chunks cover all of it.
+	Truncated bool `json:"truncated,omitempty" bson:"truncated"`
+
+	DocumentMeta `bson:",inline"`
+
+	// File is the original uploaded file, when it was kept.
+	File *StoredFile `json:"file,omitempty" bson:"file,omitempty"`
+	// Shareable lets chat replies attach File, or the page an answer
+	// came from, for the customer to see.
+	Shareable bool `json:"shareable" bson:"shareable"`
+}
+
+// StoredFile locates a document's original file in object storage.
+type StoredFile struct {
+	Key         string `json:"-" bson:"key"`
+	Filename    string `json:"filename" bson:"filename"`
+	ContentType string `json:"content_type" bson:"content_type"`
+	Size        int64  `json:"size" bson:"size"`
+	// PageOffsets holds, for PDFs, the byte offset in the document's
+	// content at which each page's text starts.
+	PageOffsets []int `json:"-" bson:"page_offsets,omitempty"`
+}
+
+// Page returns the page, counted from 1, holding the content at offset,
+// or 0 when the file has no pages.
+func (f *StoredFile) Page(offset int) int {
+	page := 0
+	for i, start := range f.PageOffsets {
+		if start > offset {
+			break
+		}
+		page = i + 1
+	}
+	return page
+}
+
+// Attachment is a file to send alongside an answer.
+type Attachment struct {
+	Data        []byte
+	ContentType string
+	Filename    string
+	Caption     string
+}
+
+// ArticleDraft is an article to preview: Content as written, or, when only
+// DocumentID is set, that document as stored.
+type ArticleDraft struct {
+	DocumentID string
+	Content    string
+}
+
+// ArticlePreview shows an article as readers and retrieval will see it.
+type ArticlePreview struct {
+	// HTML is the rendered Markdown, with raw HTML escaped.
+	HTML string `json:"html"`
+	// Valid is false when Problems include an error.
+	Valid    bool              `json:"valid"`
+	Problems []MarkdownProblem `json:"problems"`
+	// Chunks are the chunks the article would be split into now.
+	Chunks []ChunkPreview `json:"chunks"`
+}
+
+// MarkdownProblem is something wrong with an article's Markdown on Line,
+// from 1. Severity is "error" or "warning".
+type MarkdownProblem struct {
+	Line     int    `json:"line"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// ChunkPreview is a chunk an article would produce, with its estimated
+// token count.
+type ChunkPreview struct {
+	Index   int        `json:"index"`
+	Kind    ChunkKind  `json:"kind"`
+	Content string     `json:"content"`
+	Tokens  int        `json:"tokens"`
+	Table   *TableInfo `json:"table,omitempty"`
+}
+
+// Download is how to fetch a document's original file: a short-lived URL
+// when the storage can sign one, or else Body to stream, which the caller
+// closes.
+type Download struct {
+	URL         string
+	ExpiresAt   time.Time
+	Body        io.ReadCloser
+	Filename    string
+	ContentType string
+	Size        int64
+}
+
+// DocumentMeta is structured metadata about a document, for filtering
+// lists and citing the document in answers.
+type DocumentMeta struct {
+	Author string `json:"author,omitempty" bson:"author"`
+	// Language is an ISO 639-1 code, optionally with a region, such as
+	// "es" or "en-US".
+	Language string `json:"language,omitempty" bson:"language"`
+	// URL is the canonical location of the original.
+	URL        string `json:"url,omitempty" bson:"url"`
+	Department string `json:"department,omitempty" bson:"department"`
+	// EffectiveAt is when what the document says takes effect, such as
+	// the start of a policy. Unlike PublishAt it does not hide it before.
+	EffectiveAt *time.Time `json:"effective_at,omitempty" bson:"effective_at"`
+}
+
+// DocumentFilter narrows a document list. Empty fields match everything.
+type DocumentFilter struct {
+	UserID     string
+	Author     string
+	Language   string
+	Department string
+	// EffectiveOn keeps documents in effect at that time: effective by
+	// then and not yet expired.
+	EffectiveOn time.Time
+}
+
+// DocumentPatch is a partial update of a document: nil fields are left
+// as they are.
+type DocumentPatch struct {
+	// Version, when not zero, is the version the patch was made against.
+	Version   int64
+	Title     *string
+	Content   *string
+	Source    *string
+	Metadata  *string
+	IsActive  *bool
+	PublishAt *time.Time
+	ExpiresAt *time.Time
+
+	Author      *string
+	Language    *string
+	URL         *string
+	Department  *string
+	EffectiveAt *time.Time
+	Shareable   *bool
+}
+
+// IsEmpty reports whether the patch changes nothing.
+func (p DocumentPatch) IsEmpty() bool {
+	return p.Title == nil && p.Content == nil && p.Source == nil && p.Metadata == nil &&
+		p.IsActive == nil && p.PublishAt == nil && p.ExpiresAt == nil &&
+		p.Author == nil && p.Language == nil && p.URL == nil && p.Department == nil && p.EffectiveAt == nil &&
+		p.Shareable == nil
+}
+
+// Apply copies the patch's fields onto doc.
+func (p DocumentPatch) Apply(doc *Document) {
+	if p.Title != nil {
+		doc.Title = *p.Title
+	}
+	if p.Content != nil {
+		doc.Content = *p.Content
+	}
+	if p.Source != nil {
+		doc.Source = *p.Source
+	}
+	if p.Metadata != nil {
+		doc.Metadata = *p.Metadata
+	}
+	if p.IsActive != nil {
+		doc.IsActive = *p.IsActive
+	}
+	if p.PublishAt != nil {
+		doc.PublishAt = p.PublishAt
+	}
+	if p.ExpiresAt != nil {
+		doc.ExpiresAt = p.ExpiresAt
+	}
+	if p.Author != nil {
+		doc.Author = *p.Author
+	}
+	if p.Language != nil {
+		doc.Language = *p.Language
+	}
+	if p.URL != nil {
+		doc.URL = *p.URL
+	}
+	if p.Department != nil {
+		doc.Department = *p.Department
+	}
+	if p.EffectiveAt != nil {
+		doc.EffectiveAt = p.EffectiveAt
+	}
+	if p.Shareable != nil {
+		doc.Shareable = *p.Shareable
+	}
+}
+
+// DuplicatePolicy says what happens to a new document that duplicates an
+// existing one in the same collection.
+type DuplicatePolicy string
+
+const (
+	// DuplicateAllow keeps duplicates like any other document.
+	DuplicateAllow DuplicatePolicy = "allow"
+	// DuplicateReject refuses them.
+	DuplicateReject DuplicatePolicy = "reject"
+	// DuplicateMerge folds them into the existing document, creating
+	// nothing.
+	DuplicateMerge DuplicatePolicy = "merge"
+	// DuplicateLink keeps them, linked to the existing document, without
+	// chunks of their own.
+	DuplicateLink DuplicatePolicy = "link"
+)
+
+// IsValid reports whether p is a known policy.
+func (p DuplicatePolicy) IsValid() bool {
+	switch p {
+	case DuplicateAllow, DuplicateReject, DuplicateMerge, DuplicateLink:
+		return true
+	}
+	return false
+}
+
+// IsAvailable reports whether the document is inside its publication window.
+func (d Document) IsAvailable(now time.Time) bool {
+	if d.PublishAt != nil && d.PublishAt.After(now) {
+		return false
+	}
+	if d.ExpiresAt != nil && !d.ExpiresAt.After(now) {
+		return false
+	}
+	return true
+}
+
+// IsRetrievable reports whether the document's chunks may be used to answer
+// queries. Documents created before the approval workflow have no status and
+// count as published.
+func (d Document) IsRetrievable(now time.Time) bool {
+	if d.Status != "" && d.Status != StatusPublished {
+		return false
+	}
+	return d.IsAvailable(now)
+}
+
+type Chunk struct {
 	ID         string    `json:"id" bson:"_id,omitempty"`
-	UserID     string    `json:"user_id" bson:"user_id"`
-	Title      string    `json:"title" bson:"title"`
+	DocumentID string    `json:"document_id" bson:"document_id"`
+	ChunkIndex int       `json:"chunk_index" bson:"chunk_index"`
 	Content    string    `json:"content" bson:"content"`
-	Source     string    `json:"source" bson:"source"`
-	UploadedAt time.Time `json:"uploaded_at" bson:"uploaded_at"`
-	UpdatedAt  time.Time `json:"updated_at" bson:"updated_at"`
-	IsActive   bool      `json:"is_active" bson:"is_active"`
-	Metadata   string    `json:"metadata" bson:"metadata"`
+	Embedding  []float64 `json:"embedding,omitempty" bson:"embedding"`
+	Score      float64   `json:"score,omitempty" bson:"-"`
+	Pinned     bool      `json:"pinned,omitempty" bson:"-"`
+	Hidden     bool      `json:"hidden,omitempty" bson:"hidden,omitempty"`
+	CreatedAt  time.Time `json:"created_at" bson:"created_at"`
+
+	// Kind is ChunkKindTable for chunks holding rows of a table, which
+	// Table then describes. Older chunks have no kind and are prose.
+	Kind  ChunkKind  `json:"kind,omitempty" bson:"kind,omitempty"`
+	Table *TableInfo `json:"table,omitempty" bson:"table,omitempty"`
+	// ContentHash fingerprints Kind and Content, so that updating the
+	// document keeps the chunks whose text did not change, with their IDs
+	// and vectors. Older chunks have none and are hashed when compared.
+	ContentHash string `json:"content_hash,omitempty" bson:"content_hash,omitempty"`
+
+	// Collection, EmbeddingModel and Dimensions place Embedding in its
+	// embedding space. Chunks from before collections have none of them
+	// and belong to the default space.
+	Collection     string `json:"collection,omitempty" bson:"collection,omitempty"`
+	EmbeddingModel string `json:"embedding_model,omitempty" bson:"embedding_model,omitempty"`
+	Dimensions     int    `json:"dimensions,omitempty" bson:"dimensions,omitempty"`
+
+	// Source describes the chunk's document in answers, for citing it.
+	Source *ChunkSource `json:"source,omitempty" bson:"-"`
 }
 
-type Chunk struct {
-	ID          string    `json:"id" bson:"_id,omitempty"`
-	DocumentID  string    `json:"document_id" bson:"document_id"`
-	ChunkIndex  int       `json:"chunk_index" bson:"chunk_index"`
-	Content     string    `json:"content" bson:"content"`
-	Embedding   []float64 `json:"embedding" bson:"embedding"`
+// ChunkSource is what a citation shows about a chunk's document.
+type ChunkSource struct {
+	Title string `json:"title"`
+	DocumentMeta
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type ChunkKind string
+
+const (
+	ChunkKindText  ChunkKind = "text"
+	ChunkKindTable ChunkKind = "table"
+)
+
+// TableInfo locates a table chunk within its document. Rows are numbered
+// from 1, excluding the header.
+type TableInfo struct {
+	Index    int      `json:"index" bson:"index"`
+	Columns  []string `json:"columns" bson:"columns"`
+	FirstRow int      `json:"first_row" bson:"first_row"`
+	LastRow  int      `json:"last_row" bson:"last_row"`
+}
+
+type RuleAction string
+
+const (
+	RuleActionPin   RuleAction = "pin"
+	RuleActionBoost RuleAction = "boost"
+)
+
+// RetrievalRule adjusts retrieval for queries that mention any of its
+// keywords. Pin rules always include the targeted chunks; boost rules add
+// Boost (negative for a penalty) to their similarity scores. A rule without
+// keywords applies to every query.
+type RetrievalRule struct {
+	ID          string     `json:"id" bson:"_id,omitempty"`
+	Name        string     `json:"name" bson:"name"`
+	Keywords    []string   `json:"keywords" bson:"keywords"`
+	ChunkIDs    []string   `json:"chunk_ids,omitempty" bson:"chunk_ids,omitempty"`
+	DocumentIDs []string   `json:"document_ids,omitempty" bson:"document_ids,omitempty"`
+	Action      RuleAction `json:"action" bson:"action"`
+	Boost       float64    `json:"boost,omitempty" bson:"boost,omitempty"`
+	IsActive    bool       `json:"is_active" bson:"is_active"`
+	CreatedBy   string     `json:"created_by" bson:"created_by"`
+	CreatedAt   time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" bson:"updated_at"`
+}
+
+// FAQShortcut answers short questions that mention any of its keywords
+// with a fixed answer, without retrieval or a model call. It suits
+// questions such as opening hours or the address, whose answer never
+// changes between askings.
+type FAQShortcut struct {
+	ID       string   `json:"id" bson:"_id,omitempty"`
+	Name     string   `json:"name" bson:"name"`
+	Keywords []string `json:"keywords" bson:"keywords"`
+	Answer   string   `json:"answer" bson:"answer"`
+	// Priority picks among shortcuts matching the same question; the
+	// highest wins.
+	Priority  int       `json:"priority" bson:"priority"`
+	IsActive  bool      `json:"is_active" bson:"is_active"`
+	CreatedBy string    `json:"created_by" bson:"created_by"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// GlossaryEntry makes Term and its Synonyms interchangeable in questions:
+// one asking with any of them is matched and searched as if it used all of
+// them. It suits jargon and abbreviations, such as "f/x" for "foreign
+// exchange", that documents spell out differently from customers.
+type GlossaryEntry struct {
+	ID        string    `json:"id" bson:"_id,omitempty"`
+	Term      string    `json:"term" bson:"term"`
+	Synonyms  []string  `json:"synonyms" bson:"synonyms"`
+	CreatedBy string    `json:"created_by" bson:"created_by"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// CleaningProfile strips boilerplate from the text of new documents whose
+// source matches it before they are chunked, so running headers, menus
+// and page numbers do not crowd the content out of retrieval.
+type CleaningProfile struct {
+	ID   string `json:"id" bson:"_id,omitempty"`
+	Name string `json:"name" bson:"name"`
+	// Sources are patterns for a document's source in which * stands for
+	// any text, such as "https://help.example.com/*" or "*.pdf". The
+	// active profile with the longest matching pattern applies.
+	Sources []string `json:"sources" bson:"sources"`
+	// RepeatedLines strips lines repeated at the top or bottom of most
+	// pages, such as running headers and footers.
+	RepeatedLines bool `json:"repeated_lines" bson:"repeated_lines"`
+	// PageNumbers strips page numbers at the top or bottom of pages.
+	PageNumbers bool `json:"page_numbers" bson:"page_numbers"`
+	// Navigation strips menus, breadcrumbs and cookie and copyright
+	// notices left over from web pages.
+	Navigation bool `json:"navigation" bson:"navigation"`
+	// StopPhrases strips every line containing one of them, ignoring case.
+	StopPhrases []string  `json:"stop_phrases,omitempty" bson:"stop_phrases,omitempty"`
+	IsActive    bool      `json:"is_active" bson:"is_active"`
+	CreatedBy   string    `json:"created_by" bson:"created_by"`
 	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// Channel is where an answer will be shown; it selects a FormatProfile.
+type Channel string
+
+const (
+	ChannelWeb      Channel = "web"
+	ChannelWhatsApp Channel = "whatsapp"
+	ChannelAPI      Channel = "api"
+	ChannelSlack    Channel = "slack"
+	ChannelEmail    Channel = "email"
+)
+
+// Channels lists the channels that have a format profile.
+var Channels = []Channel{ChannelWeb, ChannelWhatsApp, ChannelAPI, ChannelSlack, ChannelEmail}
+
+type CitationStyle string
+
+const (
+	// CitationNone hides source numbers from the answer.
+	CitationNone CitationStyle = "none"
+	// CitationInline marks statements with [n] after the source they use.
+	CitationInline CitationStyle = "inline"
+	// CitationFootnotes lists the sources used at the end of the answer.
+	CitationFootnotes CitationStyle = "footnotes"
+)
+
+type EmojiPolicy string
+
+const (
+	EmojiAllow EmojiPolicy = "allow"
+	EmojiNone  EmojiPolicy = "none"
+)
+
+// FormatProfile shapes answers for one channel.
+type FormatProfile struct {
+	Channel Channel `json:"channel" bson:"_id"`
+	// MaxTokens caps the completion; zero leaves it to the model.
+	MaxTokens int           `json:"max_tokens" bson:"max_tokens"`
+	Markdown  bool          `json:"markdown" bson:"markdown"`
+	Citations CitationStyle `json:"citations" bson:"citations"`
+	Emoji     EmojiPolicy   `json:"emoji" bson:"emoji"`
+	UpdatedBy string        `json:"updated_by,omitempty" bson:"updated_by,omitempty"`
+	UpdatedAt time.Time     `json:"updated_at,omitempty" bson:"updated_at"`
+}
+
+// DefaultFormatProfile returns the profile used for a channel nobody has
+// configured: short plain text for WhatsApp, markdown with citations for the
+// web UI, unadorned text for API integrations, and plain text with source
+// footnotes for Slack, whose mrkdwn is not Markdown, and for email.
+func DefaultFormatProfile(channel Channel) *FormatProfile {
+	switch channel {
+	case ChannelWhatsApp:
+		return &FormatProfile{Channel: channel, MaxTokens: 300, Markdown: false, Citations: CitationNone, Emoji: EmojiAllow}
+	case ChannelAPI:
+		return &FormatProfile{Channel: channel, Markdown: false, Citations: CitationNone, Emoji: EmojiNone}
+	case ChannelSlack:
+		return &FormatProfile{Channel: channel, MaxTokens: 500, Markdown: false, Citations: CitationFootnotes, Emoji: EmojiAllow}
+	case ChannelEmail:
+		return &FormatProfile{Channel: channel, MaxTokens: 700, Markdown: false, Citations: CitationFootnotes, Emoji: EmojiNone}
+	default:
+		return &FormatProfile{Channel: ChannelWeb, MaxTokens: 800, Markdown: true, Citations: CitationInline, Emoji: EmojiNone}
+	}
 }
 
 type RAGQuery struct {
 	Query     string  `json:"query"`
 	TopK      int     `json:"top_k"`
 	Threshold float64 `json:"threshold"`
+
+	// ResponseSchema, when set, is a JSON Schema the answer must match.
+	// The answer is then returned as JSON in RAGResponse.Data.
+	ResponseSchema map[string]any `json:"response_schema,omitempty"`
+	// Channel selects the format profile; empty means ChannelWeb.
+	Channel Channel `json:"channel,omitempty"`
+	// Summary and History carry the conversation the query continues: a
+	// rolling summary of older messages and the recent ones, oldest first.
+	Summary string `json:"summary,omitempty"`
+	History []Turn `json:"history,omitempty"`
+	// CustomerContext is what is known about the customer asking, such as
+	// plan or region, so the answer can be tailored to them.
+	CustomerContext map[string]string `json:"customer_context,omitempty"`
+	// Collection selects the collection to search; empty searches the
+	// default space.
+	Collection string `json:"collection,omitempty"`
+	// Language is the ISO 639-1 code of the language for built-in replies,
+	// such as the one when nothing relevant is found. Empty, or a language
+	// without translations, guesses it from the query.
+	Language string `json:"language,omitempty"`
+
+	// Model, Temperature, TopP and MaxTokens override the configured
+	// generation settings for this query. Model must be the configured
+	// model, a fallback or an allowed model; nil and zero keep the
+	// defaults.
+	Model       string   `json:"model,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	MaxTokens   int      `json:"max_tokens,omitempty"`
+}
+
+// Turn is an earlier message of a conversation. Role is "user" or
+// "assistant".
+type Turn struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
 }
 
 type RAGResponse struct {
@@ -34,4 +539,398 @@ type RAGResponse struct {
 	RelevantChunks   []Chunk `json:"relevant_chunks"`
 	ConfidenceScore  float64 `json:"confidence_score"`
 	ProcessingTimeMs int64   `json:"processing_time_ms"`
+
+	// Model names the chat model that wrote the answer; it differs from
+	// the configured model when a fallback answered.
+	Model string `json:"model,omitempty"`
+	// ToolsUsed names the tools called while answering, in call order.
+	ToolsUsed []string `json:"tools_used,omitempty"`
+	// Products lists the SKUs of the catalog products the answer was
+	// given.
+	Products []string `json:"products,omitempty"`
+	// Data is the validated structured answer for queries with a
+	// ResponseSchema. It is empty when no answer could be generated.
+	Data json.RawMessage `json:"data,omitempty"`
+	// Groundedness is the share of the answer supported by the retrieved
+	// chunks and tool results, from 0 to 1.
+	Groundedness float64 `json:"groundedness"`
+	// Guardrails lists the corrections applied to the answer, such as
+	// "stripped_url" or "truncated".
+	Guardrails []string `json:"guardrails,omitempty"`
+	// Shortcut is the ID of the FAQ shortcut that answered, if one did.
+	Shortcut string `json:"shortcut,omitempty"`
+	// QueryID identifies the query in the query log, when it was logged.
+	QueryID string `json:"query_id,omitempty"`
+}
+
+// QueryLogRetention is how long logged queries are kept.
+const QueryLogRetention = 30 * 24 * time.Hour
+
+// QueryOutcome is how a logged query was answered.
+type QueryOutcome string
+
+const (
+	OutcomeAnswered QueryOutcome = "answered"
+	OutcomeCached   QueryOutcome = "cached"
+	OutcomeShortcut QueryOutcome = "shortcut"
+	// OutcomeNoResults is the built-in reply when retrieval found nothing.
+	OutcomeNoResults QueryOutcome = "no_results"
+	// OutcomeUnavailable is the built-in reply while OpenAI is failing.
+	OutcomeUnavailable QueryOutcome = "unavailable"
+)
+
+// QueryHit is a chunk retrieval returned for a logged query.
+type QueryHit struct {
+	ChunkID    string  `json:"chunk_id" bson:"chunk_id"`
+	DocumentID string  `json:"document_id" bson:"document_id"`
+	ChunkIndex int     `json:"chunk_index" bson:"chunk_index"`
+	Score      float64 `json:"score" bson:"score"`
+	Pinned     bool    `json:"pinned,omitempty" bson:"pinned,omitempty"`
+	// InContext is set for the hits that made it into the prompt; the
+	// others were dropped as duplicates or over the token budget.
+	InContext bool `json:"in_context" bson:"in_context"`
+}
+
+// QueryLog records a RAG query with what retrieval found for it, so an
+// answer can be explained after the fact.
+type QueryLog struct {
+	ID         string       `json:"id" bson:"_id,omitempty"`
+	Query      string       `json:"query" bson:"query"`
+	Channel    Channel      `json:"channel" bson:"channel"`
+	Collection string       `json:"collection,omitempty" bson:"collection,omitempty"`
+	TopK       int          `json:"top_k" bson:"top_k"`
+	Threshold  float64      `json:"threshold" bson:"threshold"`
+	Outcome    QueryOutcome `json:"outcome" bson:"outcome"`
+	// Hits are the chunks retrieval returned, after retrieval rules, best
+	// first. Cached and shortcut answers have none.
+	Hits             []QueryHit `json:"hits" bson:"hits"`
+	Answer           string     `json:"answer" bson:"answer"`
+	Model            string     `json:"model,omitempty" bson:"model,omitempty"`
+	ConfidenceScore  float64    `json:"confidence_score" bson:"confidence_score"`
+	Groundedness     float64    `json:"groundedness" bson:"groundedness"`
+	ToolsUsed        []string   `json:"tools_used,omitempty" bson:"tools_used,omitempty"`
+	Shortcut         string     `json:"shortcut,omitempty" bson:"shortcut,omitempty"`
+	ProcessingTimeMs int64      `json:"processing_time_ms" bson:"processing_time_ms"`
+	CreatedAt        time.Time  `json:"created_at" bson:"created_at"`
+}
+
+// ModelPrice is what a model costs, in US dollars per million tokens.
+type ModelPrice struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// DailySpend is the model usage of one UTC day and what it cost.
+type DailySpend struct {
+	// Day is the date, as YYYY-MM-DD.
+	Day              string  `json:"day" bson:"_id"`
+	CostUSD          float64 `json:"cost_usd" bson:"cost_usd"`
+	PromptTokens     int64   `json:"prompt_tokens" bson:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens" bson:"completion_tokens"`
+	// CapReachedAt is when the day's spend first reached the daily cap.
+	CapReachedAt *time.Time `json:"cap_reached_at,omitempty" bson:"cap_reached_at,omitempty"`
+}
+
+// QueryReplay compares a logged query with the same query answered again
+// against the current index.
+type QueryReplay struct {
+	Original QueryLog `json:"original"`
+	Replay   QueryLog `json:"replay"`
+	// Added are the chunks retrieved now but not originally, Removed the
+	// ones retrieved originally but not now.
+	Added   []QueryHit `json:"added"`
+	Removed []QueryHit `json:"removed"`
+	// Rescored are the chunks retrieved both times whose score or place
+	// in the prompt changed.
+	Rescored       []HitChange `json:"rescored"`
+	OutcomeChanged bool        `json:"outcome_changed"`
+	AnswerChanged  bool        `json:"answer_changed"`
+}
+
+// HitChange is how a chunk retrieved by both runs of a replay changed.
+type HitChange struct {
+	ChunkID         string  `json:"chunk_id"`
+	DocumentID      string  `json:"document_id"`
+	ScoreBefore     float64 `json:"score_before"`
+	ScoreAfter      float64 `json:"score_after"`
+	InContextBefore bool    `json:"in_context_before"`
+	InContextAfter  bool    `json:"in_context_after"`
+}
+
+// QueryLogFilter narrows the query log. Zero fields match everything.
+type QueryLogFilter struct {
+	// Query matches queries containing it, ignoring case.
+	Query   string
+	Outcome QueryOutcome
+	Channel Channel
+	// DocumentID and ChunkID keep the queries that retrieved them.
+	DocumentID string
+	ChunkID    string
+	StartTime  time.Time
+	EndTime    time.Time
+	Limit      int
+	Offset     int
+}
+
+// QueryStats counts the queries logged in a period.
+type QueryStats struct {
+	Total int64 `json:"total" bson:"total"`
+	// LowConfidence counts the queries answered with less confidence than
+	// the workspace reports, NoResults those retrieval found nothing for.
+	LowConfidence int64 `json:"low_confidence" bson:"low_confidence"`
+	NoResults     int64 `json:"no_results" bson:"no_results"`
+}
+
+// KnowledgeGap is a question the knowledge base answers poorly or not at
+// all, grouped ignoring case.
+type KnowledgeGap struct {
+	Query        string    `json:"query" bson:"query"`
+	Count        int64     `json:"count" bson:"count"`
+	FirstAskedAt time.Time `json:"first_asked_at" bson:"first_asked_at"`
+}
+
+// StorageUsage counts what a user's or a document's knowledge occupies.
+// Embedding bytes assume eight bytes per dimension.
+type StorageUsage struct {
+	Documents      int64 `json:"documents" bson:"documents"`
+	DocumentBytes  int64 `json:"document_bytes" bson:"document_bytes"`
+	Chunks         int64 `json:"chunks" bson:"chunks"`
+	ChunkBytes     int64 `json:"chunk_bytes" bson:"chunk_bytes"`
+	EmbeddingBytes int64 `json:"embedding_bytes" bson:"embedding_bytes"`
+}
+
+// Add returns the field-wise sum of u and other.
+func (u StorageUsage) Add(other StorageUsage) StorageUsage {
+	return StorageUsage{
+		Documents:      u.Documents + other.Documents,
+		DocumentBytes:  u.DocumentBytes + other.DocumentBytes,
+		Chunks:         u.Chunks + other.Chunks,
+		ChunkBytes:     u.ChunkBytes + other.ChunkBytes,
+		EmbeddingBytes: u.EmbeddingBytes + other.EmbeddingBytes,
+	}
+}
+
+// Negate returns the delta that undoes u.
+func (u StorageUsage) Negate() StorageUsage {
+	return StorageUsage{
+		Documents:      -u.Documents,
+		DocumentBytes:  -u.DocumentBytes,
+		Chunks:         -u.Chunks,
+		ChunkBytes:     -u.ChunkBytes,
+		EmbeddingBytes: -u.EmbeddingBytes,
+	}
+}
+
+type UserStorage struct {
+	UserID       string `json:"user_id" bson:"_id"`
+	StorageUsage `bson:",inline"`
+	UpdatedAt    time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+type StorageSummary struct {
+	Total StorageUsage  `json:"total"`
+	Users []UserStorage `json:"users"`
+}
+
+// InterchangeFormat names a JSON Lines layout used by other RAG tooling for
+// moving chunks and their embeddings.
+type InterchangeFormat string
+
+const (
+	// FormatLangChain writes one LangChain Document per line, with
+	// page_content, metadata and an embedding field.
+	FormatLangChain InterchangeFormat = "langchain"
+	// FormatLlamaIndex writes one LlamaIndex TextNode per line, linked to its
+	// source document through the SOURCE relationship.
+	FormatLlamaIndex InterchangeFormat = "llamaindex"
+)
+
+type ImportResult struct {
+	Documents   int      `json:"documents"`
+	Chunks      int      `json:"chunks"`
+	DocumentIDs []string `json:"document_ids"`
+}
+
+// Upload is a file to create a document from. Language and PageLanguages
+// are OCR hints for scanned files, as tesseract language codes.
+type Upload struct {
+	Filename      string
+	ContentType   string
+	Data          []byte
+	Title         string
+	Source        string
+	Language      string
+	PageLanguages map[int]string
+	Collection    string
+	// Shareable keeps the file for chat replies to attach.
+	Shareable bool
+}
+
+// Ingest is a plain-text document whose body is read as a stream and
+// chunked as it arrives, for bodies too large to hold in memory.
+type Ingest struct {
+	Title      string
+	Source     string
+	Metadata   string
+	Collection string
+	Body       io.Reader
+
+	DocumentMeta
+}
+
+type IngestResult struct {
+	ID     string `json:"id"`
+	Chunks int    `json:"chunks"`
+	Bytes  int64  `json:"bytes"`
+}
+
+// EmbeddingIndex records which models made the chunk vectors. A migration
+// stages a second vector on every chunk while queries keep reading the
+// first.
+type EmbeddingIndex struct {
+	// ActiveModel made the vectors queries are answered from. It is empty
+	// until the first migration, meaning the configured model.
+	ActiveModel string `json:"active_model" bson:"active_model"`
+	// StagedModel is the model a migration is staging vectors for.
+	StagedModel string `json:"staged_model,omitempty" bson:"staged_model"`
+	// Promoting is set while staged vectors replace the active ones after
+	// a switch; queries read the staged vectors meanwhile.
+	Promoting bool `json:"promoting,omitempty" bson:"promoting"`
+}
+
+type MigrationStatus string
+
+const (
+	MigrationRunning   MigrationStatus = "running"
+	MigrationCompleted MigrationStatus = "completed"
+	MigrationFailed    MigrationStatus = "failed"
+	MigrationCancelled MigrationStatus = "cancelled"
+)
+
+// EmbeddingMigration re-embeds every chunk with ToModel in the background.
+// Queries use FromModel until every chunk has a ToModel vector, then switch
+// to it at once.
+type EmbeddingMigration struct {
+	ID        string          `json:"id" bson:"_id,omitempty"`
+	FromModel string          `json:"from_model" bson:"from_model"`
+	ToModel   string          `json:"to_model" bson:"to_model"`
+	Status    MigrationStatus `json:"status" bson:"status"`
+	// Done counts the chunks re-embedded so far, out of Total. Total grows
+	// when documents are added during the migration.
+	Done  int64 `json:"done" bson:"done"`
+	Total int64 `json:"total" bson:"total"`
+	// Error is the last failure. Runs are retried until FailedRuns
+	// consecutive ones fail, which fails the migration.
+	Error       string     `json:"error,omitempty" bson:"error,omitempty"`
+	FailedRuns  int        `json:"failed_runs,omitempty" bson:"failed_runs,omitempty"`
+	RequestedBy string     `json:"requested_by" bson:"requested_by"`
+	CreatedAt   time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" bson:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+}
+
+// EmbeddingStatus is the embedding model in use and the latest migration.
+type EmbeddingStatus struct {
+	ActiveModel string              `json:"active_model"`
+	Migration   *EmbeddingMigration `json:"migration"`
+}
+
+// SimilarDocument is a document related to another by the average of their
+// chunk vectors.
+type SimilarDocument struct {
+	Document   Document `json:"document"`
+	Similarity float64  `json:"similarity"`
+	// NearDuplicate is set when Similarity reaches the configured
+	// duplicate similarity.
+	NearDuplicate bool `json:"near_duplicate"`
+}
+
+// ProjectionMethod is how an embedding projection places chunks.
+type ProjectionMethod string
+
+const (
+	// ProjectionPCA places chunks on their sample's first two principal
+	// components.
+	ProjectionPCA ProjectionMethod = "pca"
+	// ProjectionRaw returns the vectors themselves, for projecting them in
+	// the client, such as with UMAP.
+	ProjectionRaw ProjectionMethod = "raw"
+)
+
+// ProjectionQuery selects the chunks of an embedding projection.
+type ProjectionQuery struct {
+	// Collection is the collection whose space to sample; empty samples
+	// the default space.
+	Collection string
+	Method     ProjectionMethod
+	// Sample is how many chunks to pick at random.
+	Sample int
+}
+
+// EmbeddingProjection is a random sample of one embedding space's chunks,
+// labelled with their documents, for plotting how the knowledge base
+// clusters and which topics it covers.
+type EmbeddingProjection struct {
+	Method     ProjectionMethod `json:"method"`
+	Collection string           `json:"collection,omitempty"`
+	Model      string           `json:"model"`
+	// ExplainedVariance is the share of the sample's variance along each
+	// axis of a PCA projection.
+	ExplainedVariance []float64        `json:"explained_variance,omitempty"`
+	Points            []ProjectedChunk `json:"points"`
+}
+
+// ProjectedChunk is one chunk of an embedding projection. Vector holds its
+// x and y for PCA and its whole embedding for raw projections.
+type ProjectedChunk struct {
+	ChunkID       string    `json:"chunk_id"`
+	DocumentID    string    `json:"document_id"`
+	DocumentTitle string    `json:"document_title"`
+	ChunkIndex    int       `json:"chunk_index"`
+	Vector        []float64 `json:"vector"`
+}
+
+// Collection groups documents that share an embedding space, so each group
+// can use the model that suits it, such as a multilingual or a code model.
+// Documents outside any collection use the default space, whose model
+// embedding migrations change.
+type Collection struct {
+	Name           string `json:"name" bson:"_id"`
+	Description    string `json:"description,omitempty" bson:"description,omitempty"`
+	EmbeddingModel string `json:"embedding_model" bson:"embedding_model"`
+	// Dimensions shortens the model's vectors, for models that support
+	// it; zero keeps the model's own size.
+	Dimensions int       `json:"dimensions,omitempty" bson:"dimensions,omitempty"`
+	UpdatedBy  string    `json:"updated_by,omitempty" bson:"updated_by,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// EmbeddingSpace identifies vectors that can be compared with each other:
+// those of one collection, made by one model at one size. Collection is
+// empty for the default space.
+type EmbeddingSpace struct {
+	Collection string
+	Model      string
+	Dimensions int
+}
+
+// PurgeResult counts a user's documents, and their chunks, that a purge
+// removed or, in a dry run, would remove.
+type PurgeResult struct {
+	Documents int64 `json:"documents"`
+	Chunks    int64 `json:"chunks"`
+}
+
+// ChunkGCResult lists chunks left behind by deleted documents and documents
+// left without chunks, and what a collection did about them.
+type ChunkGCResult struct {
+	DryRun bool `json:"dry_run"`
+	// OrphanDocumentIDs name deleted documents that chunks still refer to.
+	OrphanDocumentIDs []string `json:"orphan_document_ids"`
+	// OrphanChunks counts those chunks, which are removed.
+	OrphanChunks int64 `json:"orphan_chunks"`
+	// UnchunkedDocumentIDs name documents with content but no chunks.
+	UnchunkedDocumentIDs []string `json:"unchunked_document_ids"`
+	// Rechunked counts the unchunked documents chunked again.
+	Rechunked int `json:"rechunked"`
 }
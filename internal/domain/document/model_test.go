@@ -131,3 +131,64 @@ func TestChunkZeroValue(t *testing.T) {
 		t.Errorf("Expected ChunkIndex 0, got %d", chunk.ChunkIndex)
 	}
 }
+
+func TestDocumentIsAvailable(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name string
+		doc  Document
+		want bool
+	}{
+		{"no schedule", Document{}, true},
+		{"published", Document{PublishAt: &past}, true},
+		{"scheduled", Document{PublishAt: &future}, false},
+		{"expired", Document{ExpiresAt: &past}, false},
+		{"within window", Document{PublishAt: &past, ExpiresAt: &future}, true},
+		{"expires now", Document{ExpiresAt: &now}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.doc.IsAvailable(now); got != tt.want {
+				t.Errorf("Expected IsAvailable %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to Status
+		want     bool
+	}{
+		{StatusDraft, StatusReview, true},
+		{StatusDraft, StatusPublished, false},
+		{StatusReview, StatusPublished, true},
+		{StatusReview, StatusDraft, true},
+		{StatusPublished, StatusDraft, true},
+		{StatusPublished, StatusReview, false},
+		{"", StatusDraft, true},
+	}
+
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestDocumentIsRetrievable(t *testing.T) {
+	now := time.Now()
+	if !(Document{}).IsRetrievable(now) {
+		t.Error("Expected legacy document without status to be retrievable")
+	}
+	if (Document{Status: StatusReview}).IsRetrievable(now) {
+		t.Error("Expected document under review not to be retrievable")
+	}
+	if !(Document{Status: StatusPublished}).IsRetrievable(now) {
+		t.Error("Expected published document to be retrievable")
+	}
+}
@@ -1,21 +1,179 @@
 package document
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Repository interface {
 	Create(ctx context.Context, doc *Document) (string, error)
 	GetByID(ctx context.Context, id string) (*Document, error)
 	List(ctx context.Context, limit, offset int) ([]Document, error)
 	ListByUser(ctx context.Context, userID string, limit, offset int) ([]Document, error)
-	Update(ctx context.Context, doc *Document) error
+	// Update stores doc if the stored document is still at doc.Version,
+	// bumping the version. It reports false if another update came first.
+	Update(ctx context.Context, doc *Document) (bool, error)
 	Delete(ctx context.Context, id string) error
 	Count(ctx context.Context) (int64, error)
 	CountByUser(ctx context.Context, userID string) (int64, error)
+	// ListFiltered returns the active documents matching filter, newest
+	// first, with their total.
+	ListFiltered(ctx context.Context, filter DocumentFilter, limit, offset int) ([]Document, int64, error)
+	ListUnavailableIDs(ctx context.Context, now time.Time) ([]string, error)
+	UpdateStatus(ctx context.Context, id string, status Status) error
+	ListByStatus(ctx context.Context, status Status, limit, offset int) ([]Document, error)
+	CountByStatus(ctx context.Context, status Status) (int64, error)
+	CountByCollection(ctx context.Context, collection string) (int64, error)
+	// ListUnchunked returns up to limit active documents with content but
+	// no chunks, last updated before before.
+	ListUnchunked(ctx context.Context, before time.Time, limit int) ([]Document, error)
+	// GetByContentHash returns an active document of collection with the
+	// content hash that is not itself a duplicate, or nil.
+	GetByContentHash(ctx context.Context, collection, hash string) (*Document, error)
+	// ListDuplicates returns the active documents linked to id, oldest
+	// first.
+	ListDuplicates(ctx context.Context, id string) ([]Document, error)
+	// SetDuplicateOf links a document to another; an empty original
+	// unlinks it.
+	SetDuplicateOf(ctx context.Context, id, original string) error
 }
 
 type ChunkRepository interface {
 	CreateBatch(ctx context.Context, chunks []Chunk) error
+	GetByID(ctx context.Context, id string) (*Chunk, error)
 	GetByDocumentID(ctx context.Context, documentID string) ([]Chunk, error)
+	ListByDocumentID(ctx context.Context, documentID string, limit, offset int, withEmbeddings bool) ([]Chunk, error)
+	CountByDocumentID(ctx context.Context, documentID string) (int64, error)
+	Delete(ctx context.Context, id string) error
 	DeleteByDocumentID(ctx context.Context, documentID string) error
-	Search(ctx context.Context, embedding []float64, topK int, threshold float64) ([]Chunk, error)
+	DeleteByIDs(ctx context.Context, ids []string) error
+	// UpdatePlacement stores the index, table position, hash and
+	// visibility of chunks kept across an update of their document.
+	UpdatePlacement(ctx context.Context, chunks []Chunk) error
+	SetHiddenByDocumentID(ctx context.Context, documentID string, hidden bool) error
+	SyncHidden(ctx context.Context, hiddenDocumentIDs []string) error
+	// ListOrphanDocumentIDs returns the IDs of missing documents that
+	// chunks still refer to.
+	ListOrphanDocumentIDs(ctx context.Context) ([]string, error)
+	// Search ranks the chunk vectors of space against embedding, which
+	// was made in that space.
+	Search(ctx context.Context, space EmbeddingSpace, embedding []float64, topK int, threshold float64) ([]Chunk, error)
+	// ListVectors returns the ID, document, index and vector of every
+	// visible chunk of space.
+	ListVectors(ctx context.Context, space EmbeddingSpace) ([]Chunk, error)
+	// Sample returns up to size visible chunks of space picked at random,
+	// with their vectors.
+	Sample(ctx context.Context, space EmbeddingSpace, size int) ([]Chunk, error)
+
+	EmbeddingIndex(ctx context.Context) (*EmbeddingIndex, error)
+	// The staging methods below cover the default space only; collections
+	// keep their own models.
+
+	// StageModel starts staging vectors for model, discarding any staged
+	// for another one. active names the model of the current vectors when
+	// none is recorded yet.
+	StageModel(ctx context.Context, active, model string) error
+	// ListUnstaged returns up to limit chunks with no vector for the
+	// staged model.
+	ListUnstaged(ctx context.Context, limit int) ([]Chunk, error)
+	CountUnstaged(ctx context.Context) (int64, error)
+	// SetStaged stores staged vectors by chunk ID.
+	SetStaged(ctx context.Context, vectors map[string][]float64) error
+	// ActivateStaged switches queries to the staged model in a single
+	// write, then moves the staged vectors into place. It resumes an
+	// interrupted promotion.
+	ActivateStaged(ctx context.Context) error
+}
+
+type EmbeddingMigrationRepository interface {
+	Create(ctx context.Context, migration *EmbeddingMigration) (string, error)
+	// Latest returns the most recently created migration, or nil.
+	Latest(ctx context.Context) (*EmbeddingMigration, error)
+	// Update saves a migration that is still running. It reports false,
+	// saving nothing, once the migration has ended, such as when it was
+	// cancelled meanwhile.
+	Update(ctx context.Context, migration *EmbeddingMigration) (bool, error)
+}
+
+type RuleRepository interface {
+	Create(ctx context.Context, rule *RetrievalRule) (string, error)
+	GetByID(ctx context.Context, id string) (*RetrievalRule, error)
+	List(ctx context.Context) ([]RetrievalRule, error)
+	ListActive(ctx context.Context) ([]RetrievalRule, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type ShortcutRepository interface {
+	Create(ctx context.Context, shortcut *FAQShortcut) (string, error)
+	GetByID(ctx context.Context, id string) (*FAQShortcut, error)
+	List(ctx context.Context) ([]FAQShortcut, error)
+	ListActive(ctx context.Context) ([]FAQShortcut, error)
+	Update(ctx context.Context, shortcut *FAQShortcut) error
+	Delete(ctx context.Context, id string) error
+}
+
+type GlossaryRepository interface {
+	Create(ctx context.Context, entry *GlossaryEntry) (string, error)
+	GetByID(ctx context.Context, id string) (*GlossaryEntry, error)
+	List(ctx context.Context) ([]GlossaryEntry, error)
+	Update(ctx context.Context, entry *GlossaryEntry) error
+	Delete(ctx context.Context, id string) error
+}
+
+type CleaningProfileRepository interface {
+	Create(ctx context.Context, profile *CleaningProfile) (string, error)
+	GetByID(ctx context.Context, id string) (*CleaningProfile, error)
+	List(ctx context.Context) ([]CleaningProfile, error)
+	Update(ctx context.Context, profile *CleaningProfile) error
+	Delete(ctx context.Context, id string) error
+}
+
+// StorageRepository keeps running storage totals per document and per user.
+type StorageRepository interface {
+	// Add applies delta to the document's and its owner's totals.
+	Add(ctx context.Context, userID, documentID string, delta StorageUsage) error
+	GetDocument(ctx context.Context, documentID string) (*StorageUsage, error)
+	DeleteDocument(ctx context.Context, documentID string) error
+	GetUser(ctx context.Context, userID string) (*UserStorage, error)
+	ListUsers(ctx context.Context) ([]UserStorage, error)
+	// Rebuild recomputes every total from the documents and chunks.
+	Rebuild(ctx context.Context) error
+}
+
+type CollectionRepository interface {
+	Get(ctx context.Context, name string) (*Collection, error)
+	List(ctx context.Context) ([]Collection, error)
+	Upsert(ctx context.Context, collection *Collection) error
+	Delete(ctx context.Context, name string) error
+}
+
+type FormatProfileRepository interface {
+	Get(ctx context.Context, channel Channel) (*FormatProfile, error)
+	List(ctx context.Context) ([]FormatProfile, error)
+	Upsert(ctx context.Context, profile *FormatProfile) error
+}
+
+// QueryLogRepository keeps answered RAG queries with their retrieval hits.
+type QueryLogRepository interface {
+	Create(ctx context.Context, entry *QueryLog) (string, error)
+	GetByID(ctx context.Context, id string) (*QueryLog, error)
+	// List returns the entries matching filter, newest first, with their
+	// total.
+	List(ctx context.Context, filter QueryLogFilter) ([]QueryLog, int64, error)
+	// Stats counts the entries logged in [start, end); answers below
+	// lowConfidence count as low confidence.
+	Stats(ctx context.Context, start, end time.Time, lowConfidence float64) (*QueryStats, error)
+	// Gaps returns up to limit questions first logged in [start, end) that
+	// found nothing or were answered below lowConfidence, most asked first.
+	Gaps(ctx context.Context, start, end time.Time, lowConfidence float64, limit int) ([]KnowledgeGap, error)
+}
+
+// SpendRepository keeps the daily totals of model usage.
+type SpendRepository interface {
+	// Add adds usage to the day's totals and returns them.
+	Add(ctx context.Context, day string, promptTokens, completionTokens int, costUSD float64) (*DailySpend, error)
+	Get(ctx context.Context, day string) (*DailySpend, error)
+	// MarkCapReached records when the day reached the daily cap. It reports
+	// false when the day was already marked.
+	MarkCapReached(ctx context.Context, day string, at time.Time) (bool, error)
 }
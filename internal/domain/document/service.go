@@ -1,17 +1,113 @@
 package document
 
-import "context"
+import (
+	"context"
+	"io"
+)
 
 type UserContext struct {
-	UserID  string
-	IsAdmin bool
+	UserID   string
+	IsAdmin  bool
+	IsEditor bool
 }
 
 type Service interface {
 	CreateDocument(ctx context.Context, userCtx UserContext, doc *Document) (string, error)
+	// UploadDocument creates a document from the text extracted from a file.
+	UploadDocument(ctx context.Context, userCtx UserContext, upload Upload) (string, error)
+	// IngestDocument creates a document from a text body too large to
+	// buffer, embedding its chunks as the body is read.
+	IngestDocument(ctx context.Context, userCtx UserContext, ingest Ingest) (*IngestResult, error)
 	GetDocument(ctx context.Context, userCtx UserContext, id string) (*Document, error)
-	ListDocuments(ctx context.Context, userCtx UserContext, limit, offset int) ([]Document, int64, error)
+	// SimilarDocuments returns up to limit documents the user can read,
+	// most similar to document id first.
+	SimilarDocuments(ctx context.Context, userCtx UserContext, id string, limit int) ([]SimilarDocument, error)
+	// ListDocuments lists the documents matching filter; users other than
+	// admins only see their own.
+	ListDocuments(ctx context.Context, userCtx UserContext, filter DocumentFilter, limit, offset int) ([]Document, int64, error)
 	UpdateDocument(ctx context.Context, userCtx UserContext, doc *Document) error
+	// PatchDocument changes only the fields patch sets and returns the
+	// updated document.
+	PatchDocument(ctx context.Context, userCtx UserContext, id string, patch DocumentPatch) (*Document, error)
 	DeleteDocument(ctx context.Context, userCtx UserContext, id string) error
+	// PurgeUserDocuments deletes every document userID owns, with its
+	// chunks. A dry run only counts them.
+	PurgeUserDocuments(ctx context.Context, userCtx UserContext, userID string, dryRun bool) (*PurgeResult, error)
+	ChangeStatus(ctx context.Context, userCtx UserContext, id string, status Status) error
+	ListPendingReview(ctx context.Context, userCtx UserContext, limit, offset int) ([]Document, int64, error)
+	ListChunks(ctx context.Context, userCtx UserContext, documentID string, limit, offset int, withEmbeddings bool) ([]Chunk, int64, error)
+	DeleteChunk(ctx context.Context, userCtx UserContext, id string) error
+	// ExportChunks writes chunks with their embeddings to w, for one document
+	// or, when documentID is empty, for all of them.
+	ExportChunks(ctx context.Context, userCtx UserContext, format InterchangeFormat, documentID string, w io.Writer) error
+	// ImportChunks creates documents from a pre-chunked corpus, keeping the
+	// embeddings it carries.
+	ImportChunks(ctx context.Context, userCtx UserContext, format InterchangeFormat, r io.Reader) (*ImportResult, error)
 	QueryRAG(ctx context.Context, query RAGQuery) (*RAGResponse, error)
+	// Attachment returns the file to send with an answer drawn from chunk:
+	// the page of a PDF it comes from, or else the whole file. It returns
+	// nil when the chunk's document is not shareable or has no file.
+	Attachment(ctx context.Context, chunk Chunk) (*Attachment, error)
+	// Download returns the original file of a document the user can see.
+	Download(ctx context.Context, userCtx UserContext, id string) (*Download, error)
+	// PreviewArticle renders a draft's Markdown, reports its problems and
+	// shows the chunks it would be split into, without saving anything.
+	PreviewArticle(ctx context.Context, userCtx UserContext, draft ArticleDraft) (*ArticlePreview, error)
+
+	GetStorageUsage(ctx context.Context, userCtx UserContext) (*UserStorage, error)
+	GetStorageSummary(ctx context.Context, userCtx UserContext) (*StorageSummary, error)
+	RebuildStorage(ctx context.Context, userCtx UserContext) error
+	// CollectChunkGarbage removes chunks whose document is gone and chunks
+	// documents that have none. A dry run only reports them.
+	CollectChunkGarbage(ctx context.Context, userCtx UserContext, dryRun bool) (*ChunkGCResult, error)
+
+	GetEmbeddingStatus(ctx context.Context, userCtx UserContext) (*EmbeddingStatus, error)
+	// StartEmbeddingMigration requests re-embedding every chunk with model;
+	// the migration job does the work.
+	StartEmbeddingMigration(ctx context.Context, userCtx UserContext, model string) (*EmbeddingMigration, error)
+	CancelEmbeddingMigration(ctx context.Context, userCtx UserContext) error
+	// ProjectEmbeddings samples chunk vectors of one embedding space, with
+	// their documents, for plotting the knowledge base.
+	ProjectEmbeddings(ctx context.Context, userCtx UserContext, query ProjectionQuery) (*EmbeddingProjection, error)
+
+	ListCollections(ctx context.Context, userCtx UserContext) ([]Collection, error)
+	// SaveCollection creates or updates a collection. Its embedding space
+	// cannot change while it holds documents.
+	SaveCollection(ctx context.Context, userCtx UserContext, collection *Collection) error
+	DeleteCollection(ctx context.Context, userCtx UserContext, name string) error
+
+	CreateRetrievalRule(ctx context.Context, userCtx UserContext, rule *RetrievalRule) (string, error)
+	ListRetrievalRules(ctx context.Context, userCtx UserContext) ([]RetrievalRule, error)
+	DeleteRetrievalRule(ctx context.Context, userCtx UserContext, id string) error
+
+	// FAQ shortcuts are checked before retrieval; a match answers at once.
+	CreateFAQShortcut(ctx context.Context, userCtx UserContext, shortcut *FAQShortcut) (string, error)
+	ListFAQShortcuts(ctx context.Context, userCtx UserContext) ([]FAQShortcut, error)
+	UpdateFAQShortcut(ctx context.Context, userCtx UserContext, shortcut *FAQShortcut) error
+	DeleteFAQShortcut(ctx context.Context, userCtx UserContext, id string) error
+
+	// Glossary entries expand questions before shortcuts, retrieval rules
+	// and search see them.
+	CreateGlossaryEntry(ctx context.Context, userCtx UserContext, entry *GlossaryEntry) (string, error)
+	ListGlossaryEntries(ctx context.Context, userCtx UserContext) ([]GlossaryEntry, error)
+	UpdateGlossaryEntry(ctx context.Context, userCtx UserContext, entry *GlossaryEntry) error
+	DeleteGlossaryEntry(ctx context.Context, userCtx UserContext, id string) error
+
+	// Cleaning profiles strip boilerplate from new content by its source.
+	CreateCleaningProfile(ctx context.Context, userCtx UserContext, profile *CleaningProfile) (string, error)
+	ListCleaningProfiles(ctx context.Context, userCtx UserContext) ([]CleaningProfile, error)
+	UpdateCleaningProfile(ctx context.Context, userCtx UserContext, profile *CleaningProfile) error
+	DeleteCleaningProfile(ctx context.Context, userCtx UserContext, id string) error
+
+	// ListFormatProfiles returns the profile in effect for every channel.
+	ListFormatProfiles(ctx context.Context, userCtx UserContext) ([]FormatProfile, error)
+	SaveFormatProfile(ctx context.Context, userCtx UserContext, profile *FormatProfile) error
+
+	// ListQueryLogs returns logged RAG queries, newest first, with the
+	// total matching filter.
+	ListQueryLogs(ctx context.Context, userCtx UserContext, filter QueryLogFilter) ([]QueryLog, int64, error)
+	GetQueryLog(ctx context.Context, userCtx UserContext, id string) (*QueryLog, error)
+	// ReplayQuery answers a logged query again against the current index
+	// and compares the runs.
+	ReplayQuery(ctx context.Context, userCtx UserContext, id string) (*QueryReplay, error)
 }
@@ -0,0 +1,58 @@
+package email
+
+import "time"
+
+// ReplyMode decides what happens to the answer drafted for an email.
+type ReplyMode string
+
+const (
+	// ReplyModeDraft queues every answer for an admin to approve.
+	ReplyModeDraft ReplyMode = "draft"
+	// ReplyModeAuto sends confident answers right away and queues the rest.
+	ReplyModeAuto ReplyMode = "auto"
+)
+
+// Inbound is a received email.
+type Inbound struct {
+	From     string
+	FromName string
+	Subject  string
+	// Text is the plain-text body, including any quoted earlier messages.
+	Text      string
+	MessageID string
+	// References lists the Message-IDs of the thread the email replies to.
+	References []string
+	// AutoSubmitted marks out-of-office and other automatic mail, which is
+	// never answered.
+	AutoSubmitted bool
+}
+
+type DraftStatus string
+
+const (
+	DraftPending   DraftStatus = "pending"
+	DraftSent      DraftStatus = "sent"
+	DraftDiscarded DraftStatus = "discarded"
+)
+
+// Draft is an answer to an email, sent on approval or, in auto mode, right
+// away.
+type Draft struct {
+	ID             string `json:"id" bson:"_id,omitempty"`
+	ConversationID string `json:"conversation_id" bson:"conversation_id"`
+	To             string `json:"to" bson:"to"`
+	Subject        string `json:"subject" bson:"subject"`
+	// Question is the email text the answer was drafted for.
+	Question   string      `json:"question" bson:"question"`
+	Body       string      `json:"body" bson:"body"`
+	Confidence float64     `json:"confidence" bson:"confidence"`
+	InReplyTo  string      `json:"in_reply_to,omitempty" bson:"in_reply_to,omitempty"`
+	References []string    `json:"references,omitempty" bson:"references,omitempty"`
+	Status     DraftStatus `json:"status" bson:"status"`
+	// Error is why the last send attempt failed; the draft stays pending.
+	Error      string     `json:"error,omitempty" bson:"error,omitempty"`
+	ReviewedBy string     `json:"reviewed_by,omitempty" bson:"reviewed_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" bson:"updated_at"`
+	SentAt     *time.Time `json:"sent_at,omitempty" bson:"sent_at,omitempty"`
+}
@@ -0,0 +1,11 @@
+package email
+
+import "context"
+
+type DraftRepository interface {
+	Create(ctx context.Context, draft *Draft) (string, error)
+	GetByID(ctx context.Context, id string) (*Draft, error)
+	// List returns drafts with status, newest first, and how many there are.
+	List(ctx context.Context, status DraftStatus, limit, offset int) ([]Draft, int64, error)
+	Update(ctx context.Context, draft *Draft) error
+}
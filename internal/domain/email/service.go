@@ -0,0 +1,15 @@
+package email
+
+import "context"
+
+type Service interface {
+	// Receive saves msg to the sender's conversation and drafts an answer.
+	Receive(ctx context.Context, msg Inbound) error
+	ListDrafts(ctx context.Context, status DraftStatus, limit, offset int) ([]Draft, int64, error)
+	GetDraft(ctx context.Context, id string) (*Draft, error)
+	// UpdateDraft replaces a pending draft's body.
+	UpdateDraft(ctx context.Context, adminID, id, body string) (*Draft, error)
+	// ApproveDraft sends a pending draft.
+	ApproveDraft(ctx context.Context, adminID, id string) (*Draft, error)
+	DiscardDraft(ctx context.Context, adminID, id string) error
+}
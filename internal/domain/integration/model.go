@@ -0,0 +1,167 @@
+package integration
+
+import "time"
+
+// KeyKind is what an API key may be used for.
+type KeyKind string
+
+const (
+	// KeyKindIntegration keys are secret and authenticate the integration
+	// endpoints. Keys created before kinds existed have no kind and are
+	// integration keys.
+	KeyKindIntegration KeyKind = "integration"
+	// KeyKindWidget keys are embedded in public web pages and can only start
+	// anonymous chat sessions.
+	KeyKindWidget KeyKind = "widget"
+)
+
+// APIKey authenticates no-code tools such as Zapier and Make, or the public
+// chat widget. Only a hash of the key is stored; the key itself is shown
+// once, when it is created.
+type APIKey struct {
+	ID   string  `json:"id" bson:"_id,omitempty"`
+	Name string  `json:"name" bson:"name"`
+	Kind KeyKind `json:"kind" bson:"kind,omitempty"`
+	// Prefix is the start of the key, so admins can tell keys apart.
+	Prefix     string     `json:"prefix" bson:"prefix"`
+	KeyHash    string     `json:"-" bson:"key_hash"`
+	CreatedBy  string     `json:"created_by" bson:"created_by"`
+	CreatedAt  time.Time  `json:"created_at" bson:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" bson:"last_used_at,omitempty"`
+	RotatedAt  *time.Time `json:"rotated_at,omitempty" bson:"rotated_at,omitempty"`
+
+	// AllowedOrigins lists the pages a widget key may be used from, such as
+	// https://www.example.com. Widget keys without any cannot be used.
+	AllowedOrigins []string `json:"allowed_origins,omitempty" bson:"allowed_origins,omitempty"`
+	// SessionCount and QuestionCount track a widget key's usage.
+	SessionCount  int64 `json:"session_count" bson:"session_count"`
+	QuestionCount int64 `json:"question_count" bson:"question_count"`
+}
+
+// KeySettings are the admin-editable parts of an API key. Kind is fixed
+// when the key is created.
+type KeySettings struct {
+	Name           string   `json:"name"`
+	Kind           KeyKind  `json:"kind"`
+	AllowedOrigins []string `json:"allowed_origins"`
+}
+
+type TriggerType string
+
+const (
+	TriggerNewMessage    TriggerType = "new_message"
+	TriggerLowConfidence TriggerType = "low_confidence_answer"
+	TriggerSpendCap      TriggerType = "spend_cap_reached"
+)
+
+// TriggerEvent is one item of a polling trigger. IDs are unique and newer
+// events sort first, which is what polling tools deduplicate on.
+type TriggerEvent struct {
+	ID   string      `json:"id" bson:"_id,omitempty"`
+	Type TriggerType `json:"type" bson:"type"`
+
+	// Set for new_message.
+	ConversationID string `json:"conversation_id,omitempty" bson:"conversation_id,omitempty"`
+	MessageID      string `json:"message_id,omitempty" bson:"message_id,omitempty"`
+	Channel        string `json:"channel,omitempty" bson:"channel,omitempty"`
+	From           string `json:"from,omitempty" bson:"from,omitempty"`
+	Content        string `json:"content,omitempty" bson:"content,omitempty"`
+	MessageType    string `json:"message_type,omitempty" bson:"message_type,omitempty"`
+
+	// Set for low_confidence_answer.
+	Query      string   `json:"query,omitempty" bson:"query,omitempty"`
+	Answer     string   `json:"answer,omitempty" bson:"answer,omitempty"`
+	Confidence *float64 `json:"confidence,omitempty" bson:"confidence,omitempty"`
+
+	// Set for spend_cap_reached.
+	Day      string   `json:"day,omitempty" bson:"day,omitempty"`
+	SpentUSD *float64 `json:"spent_usd,omitempty" bson:"spent_usd,omitempty"`
+	CapUSD   *float64 `json:"cap_usd,omitempty" bson:"cap_usd,omitempty"`
+
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// SendMessage is the send-message action: a WhatsApp text to Phone.
+type SendMessage struct {
+	Phone       string `json:"phone"`
+	ContactName string `json:"contact_name,omitempty"`
+	Text        string `json:"text"`
+}
+
+// MessageSent is the result of the send-message action.
+type MessageSent struct {
+	ConversationID string `json:"conversation_id"`
+	MessageID      string `json:"message_id"`
+}
+
+// Webhook posts domain events to an integrator's URL as they happen. Each
+// request is signed with Secret and carries the payload in SchemaVersion,
+// so an integration keeps working when newer versions are added.
+type Webhook struct {
+	ID     string   `json:"id" bson:"_id,omitempty"`
+	Name   string   `json:"name" bson:"name"`
+	URL    string   `json:"url" bson:"url"`
+	Events []string `json:"events" bson:"events"`
+	// SchemaVersion is the payload version the integration was built
+	// against.
+	SchemaVersion int    `json:"schema_version" bson:"schema_version"`
+	Active        bool   `json:"active" bson:"active"`
+	Secret        string `json:"-" bson:"secret"`
+	CreatedBy     string `json:"created_by" bson:"created_by"`
+	// LastDeliveryAt, LastStatus and LastError describe the latest
+	// delivery attempt, test deliveries included.
+	LastDeliveryAt *time.Time `json:"last_delivery_at,omitempty" bson:"last_delivery_at,omitempty"`
+	LastStatus     int        `json:"last_status,omitempty" bson:"last_status,omitempty"`
+	LastError      string     `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" bson:"updated_at"`
+}
+
+// WebhookSettings are the admin-editable parts of a webhook. A zero
+// SchemaVersion means the current one; a nil Active leaves a webhook as it
+// was, and new ones active.
+type WebhookSettings struct {
+	Name          string   `json:"name"`
+	URL           string   `json:"url"`
+	Events        []string `json:"events"`
+	SchemaVersion int      `json:"schema_version"`
+	Active        *bool    `json:"active"`
+}
+
+// WebhookPayload is the body of every webhook request. Data is the event
+// in the shape WebhookSchema describes for Type and SchemaVersion.
+type WebhookPayload struct {
+	// ID is unique per event, so receivers can drop retried deliveries.
+	ID            string    `json:"id"`
+	Type          string    `json:"type"`
+	SchemaVersion int       `json:"schema_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	// Test is set on sample events sent from the test-delivery endpoint.
+	Test bool `json:"test,omitempty"`
+	Data any  `json:"data"`
+}
+
+// WebhookSchema is the JSON Schema of one event type's data in one payload
+// version, with a sample of it.
+type WebhookSchema struct {
+	Event         string         `json:"event"`
+	SchemaVersion int            `json:"schema_version"`
+	Description   string         `json:"description"`
+	Schema        map[string]any `json:"schema"`
+	Sample        any            `json:"sample"`
+}
+
+// WebhookDelivery is the outcome of posting one payload to a webhook.
+type WebhookDelivery struct {
+	WebhookID     string `json:"webhook_id"`
+	PayloadID     string `json:"payload_id"`
+	Event         string `json:"event"`
+	SchemaVersion int    `json:"schema_version"`
+	Delivered     bool   `json:"delivered"`
+	// StatusCode is the receiver's response status, 0 when there was no
+	// response.
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Attempts   int    `json:"attempts"`
+	DurationMs int64  `json:"duration_ms"`
+}
@@ -0,0 +1,37 @@
+package integration
+
+import (
+	"context"
+	"time"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *APIKey) (string, error)
+	GetByID(ctx context.Context, id string) (*APIKey, error)
+	GetByHash(ctx context.Context, hash string) (*APIKey, error)
+	List(ctx context.Context) ([]APIKey, error)
+	// Update saves the key's name, allowed origins and secret; usage
+	// counters are only changed by IncrementUsage.
+	Update(ctx context.Context, key *APIKey) error
+	Delete(ctx context.Context, id string) (bool, error)
+	TouchLastUsed(ctx context.Context, id string, at time.Time) error
+	IncrementUsage(ctx context.Context, id string, sessions, questions int64) error
+}
+
+type TriggerRepository interface {
+	Create(ctx context.Context, event *TriggerEvent) error
+	// List returns up to limit events of one type, newest first.
+	List(ctx context.Context, triggerType TriggerType, limit int) ([]TriggerEvent, error)
+}
+
+type WebhookRepository interface {
+	Create(ctx context.Context, hook *Webhook) (string, error)
+	GetByID(ctx context.Context, id string) (*Webhook, error)
+	List(ctx context.Context) ([]Webhook, error)
+	// ListActive returns the active webhooks subscribed to event.
+	ListActive(ctx context.Context, event string) ([]Webhook, error)
+	// Update saves the webhook's settings and secret.
+	Update(ctx context.Context, hook *Webhook) error
+	Delete(ctx context.Context, id string) (bool, error)
+	RecordDelivery(ctx context.Context, id string, at time.Time, status int, errMsg string) error
+}
@@ -0,0 +1,37 @@
+package integration
+
+import "context"
+
+type Service interface {
+	// CreateAPIKey returns the new key's record and the key itself, which
+	// cannot be retrieved again.
+	CreateAPIKey(ctx context.Context, adminID string, settings KeySettings) (*APIKey, string, error)
+	ListAPIKeys(ctx context.Context) ([]APIKey, error)
+	UpdateAPIKey(ctx context.Context, adminID, id string, settings KeySettings) (*APIKey, error)
+	// RotateAPIKey replaces the key's secret, which stops the old one from
+	// working, and returns the new one.
+	RotateAPIKey(ctx context.Context, adminID, id string) (*APIKey, string, error)
+	// DeleteAPIKey revokes the key.
+	DeleteAPIKey(ctx context.Context, adminID, id string) error
+	// Authenticate resolves an integration key.
+	Authenticate(ctx context.Context, key string) (*APIKey, error)
+	// AuthenticateWidget resolves a widget key.
+	AuthenticateWidget(ctx context.Context, key string) (*APIKey, error)
+
+	ListTriggers(ctx context.Context, triggerType TriggerType, limit int) ([]TriggerEvent, error)
+	SendMessage(ctx context.Context, key *APIKey, msg SendMessage) (*MessageSent, error)
+	CreateDocument(ctx context.Context, key *APIKey, title, content, source string) (string, error)
+
+	// CreateWebhook returns the new webhook and its signing secret, which
+	// cannot be retrieved again.
+	CreateWebhook(ctx context.Context, adminID string, settings WebhookSettings) (*Webhook, string, error)
+	ListWebhooks(ctx context.Context) ([]Webhook, error)
+	GetWebhook(ctx context.Context, id string) (*Webhook, error)
+	UpdateWebhook(ctx context.Context, id string, settings WebhookSettings) (*Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	// TestWebhook posts a sample of event, or of the webhook's first event
+	// when empty, in the webhook's schema version.
+	TestWebhook(ctx context.Context, id, event string) (*WebhookDelivery, error)
+	// WebhookSchemas lists the payload schemas of every event and version.
+	WebhookSchemas() []WebhookSchema
+}
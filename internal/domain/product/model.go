@@ -0,0 +1,76 @@
+package product
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Product is an item of the catalog. Questions naming its SKU or name are
+// answered from these fields rather than from retrieved chunks, so prices
+// and stock are exact.
+type Product struct {
+	ID string `json:"id" bson:"_id,omitempty"`
+	// SKU is unique and stored upper case.
+	SKU         string `json:"sku" bson:"sku"`
+	Name        string `json:"name" bson:"name"`
+	Description string `json:"description,omitempty" bson:"description,omitempty"`
+	// Price is nil when the product has none listed.
+	Price    *float64 `json:"price,omitempty" bson:"price,omitempty"`
+	Currency string   `json:"currency,omitempty" bson:"currency,omitempty"`
+	// Stock is the units available, nil when it is not tracked.
+	Stock      *int              `json:"stock,omitempty" bson:"stock,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty" bson:"attributes,omitempty"`
+	Images     []string          `json:"images,omitempty" bson:"images,omitempty"`
+	CreatedBy  string            `json:"created_by,omitempty" bson:"created_by,omitempty"`
+	CreatedAt  time.Time         `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at" bson:"updated_at"`
+}
+
+// Facts is the product as the model is given it: one field per line, with
+// attributes in name order.
+func (p Product) Facts() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Product: %s\nSKU: %s\n", p.Name, p.SKU)
+	if p.Price != nil {
+		price := strconv.FormatFloat(*p.Price, 'f', 2, 64)
+		fmt.Fprintf(&b, "Price: %s\n", strings.TrimSpace(price+" "+p.Currency))
+	}
+	if p.Stock != nil {
+		if *p.Stock > 0 {
+			fmt.Fprintf(&b, "Stock: %d in stock\n", *p.Stock)
+		} else {
+			b.WriteString("Stock: out of stock\n")
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(p.Attributes)) {
+		fmt.Fprintf(&b, "%s: %s\n", name, p.Attributes[name])
+	}
+	if p.Description != "" {
+		fmt.Fprintf(&b, "Description: %s\n", p.Description)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// Filter selects products; Query matches SKU, name and description.
+type Filter struct {
+	Query  string
+	Limit  int
+	Offset int
+}
+
+// ImportError is a CSV row that could not be imported.
+type ImportError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// ImportResult counts what a CSV import did.
+type ImportResult struct {
+	Created int           `json:"created"`
+	Updated int           `json:"updated"`
+	Errors  []ImportError `json:"errors"`
+}
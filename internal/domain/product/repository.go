@@ -0,0 +1,17 @@
+package product
+
+import "context"
+
+type Repository interface {
+	Create(ctx context.Context, product *Product) (string, error)
+	GetByID(ctx context.Context, id string) (*Product, error)
+	GetBySKU(ctx context.Context, sku string) (*Product, error)
+	// FindBySKUs returns the products with any of skus.
+	FindBySKUs(ctx context.Context, skus []string) ([]Product, error)
+	// Search returns up to limit products whose name or description match
+	// text, best match first.
+	Search(ctx context.Context, text string, limit int) ([]Product, error)
+	List(ctx context.Context, filter Filter) ([]Product, int64, error)
+	Update(ctx context.Context, product *Product) error
+	Delete(ctx context.Context, id string) error
+}
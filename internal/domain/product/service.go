@@ -0,0 +1,20 @@
+package product
+
+import (
+	"context"
+	"io"
+)
+
+type Service interface {
+	CreateProduct(ctx context.Context, adminID string, product *Product) (string, error)
+	GetProduct(ctx context.Context, id string) (*Product, error)
+	ListProducts(ctx context.Context, filter Filter) ([]Product, int64, error)
+	UpdateProduct(ctx context.Context, adminID string, product *Product) error
+	DeleteProduct(ctx context.Context, adminID, id string) error
+	// ImportCSV creates or updates, by SKU, a product per row of a CSV
+	// file. Rows that fail are reported and skipped.
+	ImportCSV(ctx context.Context, adminID string, r io.Reader) (*ImportResult, error)
+	// Match returns the products a question is about: those whose SKU it
+	// quotes or, failing that, whose name it mentions.
+	Match(ctx context.Context, query string) ([]Product, error)
+}
@@ -0,0 +1,96 @@
+package report
+
+import (
+	"slices"
+	"time"
+)
+
+// Metric is a set of figures a report can include.
+type Metric string
+
+const (
+	// MetricSLA is how each agent did against the SLA targets.
+	MetricSLA Metric = "sla"
+	// MetricTopics is the latest grouping of questions into topics.
+	MetricTopics Metric = "topics"
+	// MetricQueries is the query volume and how many answers had low
+	// confidence or no results.
+	MetricQueries Metric = "queries"
+	// MetricKnowledgeGaps is the questions first asked in the period that
+	// got no results or low-confidence answers.
+	MetricKnowledgeGaps Metric = "knowledge_gaps"
+)
+
+// Metrics lists the metrics in the order reports show them.
+var Metrics = []Metric{MetricSLA, MetricTopics, MetricQueries, MetricKnowledgeGaps}
+
+// Valid reports whether m is a known metric.
+func (m Metric) Valid() bool {
+	return slices.Contains(Metrics, m)
+}
+
+// Format is how a scheduled report is attached: one CSV file per metric,
+// or a single PDF.
+type Format string
+
+const (
+	FormatCSV Format = "csv"
+	FormatPDF Format = "pdf"
+)
+
+// Cadence is how often a scheduled report is sent. Each report covers the
+// day, week or month up to the hour it is sent.
+type Cadence string
+
+const (
+	CadenceDaily   Cadence = "daily"
+	CadenceWeekly  Cadence = "weekly"
+	CadenceMonthly Cadence = "monthly"
+)
+
+// Table is one metric's figures: a header row and the rows under it.
+type Table struct {
+	Metric Metric     `json:"metric"`
+	Title  string     `json:"title"`
+	Header []string   `json:"header"`
+	Rows   [][]string `json:"rows"`
+}
+
+// Report is the tables of some metrics for the period [From, To).
+type Report struct {
+	Name        string    `json:"name"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	Tables      []Table   `json:"tables"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// Schedule emails a report of Metrics to Recipients every day, week or
+// month, at Hour in Timezone. Day is the weekday of weekly reports, 0 for
+// Sunday, and the day of the month of monthly ones.
+type Schedule struct {
+	ID         string   `json:"id" bson:"_id,omitempty"`
+	Name       string   `json:"name" bson:"name"`
+	Metrics    []Metric `json:"metrics" bson:"metrics"`
+	Recipients []string `json:"recipients" bson:"recipients"`
+	Cadence    Cadence  `json:"cadence" bson:"cadence"`
+	Format     Format   `json:"format" bson:"format"`
+	Hour       int      `json:"hour" bson:"hour"`
+	Day        int      `json:"day" bson:"day"`
+	Timezone   string   `json:"timezone" bson:"timezone"`
+	// LastSentAt is when the report last went out, on schedule or not.
+	LastSentAt *time.Time `json:"last_sent_at,omitempty" bson:"last_sent_at,omitempty"`
+	CreatedBy  string     `json:"created_by" bson:"created_by"`
+	CreatedAt  time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" bson:"updated_at"`
+}
+
+// Delivery is a report sent for a schedule.
+type Delivery struct {
+	ScheduleID string    `json:"schedule_id"`
+	Recipients []string  `json:"recipients"`
+	Format     Format    `json:"format"`
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+	SentAt     time.Time `json:"sent_at"`
+}
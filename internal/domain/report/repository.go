@@ -0,0 +1,17 @@
+package report
+
+import (
+	"context"
+	"time"
+)
+
+type ScheduleRepository interface {
+	Create(ctx context.Context, schedule *Schedule) (string, error)
+	GetByID(ctx context.Context, id string) (*Schedule, error)
+	// List returns every schedule, by name.
+	List(ctx context.Context) ([]Schedule, error)
+	Update(ctx context.Context, schedule *Schedule) error
+	Delete(ctx context.Context, id string) error
+	// MarkSent records that the schedule's report went out at at.
+	MarkSent(ctx context.Context, id string, at time.Time) error
+}
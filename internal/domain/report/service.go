@@ -0,0 +1,22 @@
+package report
+
+import (
+	"context"
+	"time"
+)
+
+type Service interface {
+	ListSchedules(ctx context.Context) ([]Schedule, error)
+	GetSchedule(ctx context.Context, id string) (*Schedule, error)
+	CreateSchedule(ctx context.Context, schedule *Schedule) (string, error)
+	UpdateSchedule(ctx context.Context, schedule *Schedule) error
+	DeleteSchedule(ctx context.Context, id string) error
+	// Send emails a schedule's report now, covering the period its cadence
+	// covers up to now.
+	Send(ctx context.Context, id string) (*Delivery, error)
+	// Generate builds a report of metrics for [from, to) without sending
+	// it.
+	Generate(ctx context.Context, metrics []Metric, from, to time.Time) (*Report, error)
+	// SendDue emails the reports of the schedules due this hour.
+	SendDue(ctx context.Context) error
+}
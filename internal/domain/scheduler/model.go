@@ -0,0 +1,45 @@
+package scheduler
+
+import "time"
+
+type RunStatus string
+
+const (
+	RunStatusSuccess RunStatus = "success"
+	RunStatusFailed  RunStatus = "failed"
+)
+
+// JobState is the shared record of a job across replicas: who holds its lock,
+// which occurrence was last claimed, and how the last run went.
+type JobState struct {
+	Name           string     `json:"name" bson:"_id"`
+	LockedBy       string     `json:"locked_by,omitempty" bson:"locked_by,omitempty"`
+	LockedUntil    time.Time  `json:"locked_until" bson:"locked_until"`
+	ScheduledFor   time.Time  `json:"scheduled_for" bson:"scheduled_for"`
+	LastRunAt      *time.Time `json:"last_run_at,omitempty" bson:"last_run_at,omitempty"`
+	LastDurationMs int64      `json:"last_duration_ms" bson:"last_duration_ms"`
+	LastStatus     RunStatus  `json:"last_status,omitempty" bson:"last_status,omitempty"`
+	LastError      string     `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	LastRunBy      string     `json:"last_run_by,omitempty" bson:"last_run_by,omitempty"`
+}
+
+// Run is the outcome of one job execution.
+type Run struct {
+	StartedAt time.Time
+	Duration  time.Duration
+	Status    RunStatus
+	Error     string
+}
+
+// JobStatus describes a registered job for the admin API.
+type JobStatus struct {
+	Name           string     `json:"name"`
+	Schedule       string     `json:"schedule"`
+	NextRunAt      time.Time  `json:"next_run_at"`
+	Running        bool       `json:"running"`
+	LastRunAt      *time.Time `json:"last_run_at,omitempty"`
+	LastDurationMs int64      `json:"last_duration_ms"`
+	LastStatus     RunStatus  `json:"last_status,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	LastRunBy      string     `json:"last_run_by,omitempty"`
+}
@@ -0,0 +1,16 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+)
+
+type Repository interface {
+	// Acquire claims the occurrence of a job scheduled for scheduledFor. It
+	// fails when another owner holds an unexpired lock or the occurrence was
+	// already claimed.
+	Acquire(ctx context.Context, name, owner string, scheduledFor, lockedUntil time.Time) (bool, error)
+	// Complete records the run and releases the lock held by owner.
+	Complete(ctx context.Context, name, owner string, run Run) error
+	List(ctx context.Context) ([]JobState, error)
+}
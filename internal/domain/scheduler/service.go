@@ -0,0 +1,7 @@
+package scheduler
+
+import "context"
+
+type Service interface {
+	Jobs(ctx context.Context) ([]JobStatus, error)
+}
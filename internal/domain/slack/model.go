@@ -0,0 +1,38 @@
+package slack
+
+import "time"
+
+// Link maps a Slack user to the lucidRAG user with the same email, so Slack
+// conversations are owned by and visible to that user.
+type Link struct {
+	// SlackUserID is "team:user", since user IDs are only unique per team.
+	SlackUserID string    `json:"slack_user_id" bson:"_id"`
+	UserID      string    `json:"user_id" bson:"user_id"`
+	Email       string    `json:"email" bson:"email"`
+	LinkedAt    time.Time `json:"linked_at" bson:"linked_at"`
+}
+
+// Message is a question asked in Slack, by mentioning the app in a channel
+// or in a direct message.
+type Message struct {
+	TeamID  string
+	Channel string
+	UserID  string
+	Text    string
+	TS      string
+	// ThreadTS is the thread the message was posted in; empty when it
+	// starts one.
+	ThreadTS string
+	// BotID and Subtype are set on messages the app should not answer,
+	// such as its own replies and edits.
+	BotID   string
+	Subtype string
+}
+
+// Command is a slash command invocation.
+type Command struct {
+	TeamID      string
+	UserID      string
+	Text        string
+	ResponseURL string
+}
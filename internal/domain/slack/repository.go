@@ -0,0 +1,8 @@
+package slack
+
+import "context"
+
+type LinkRepository interface {
+	Get(ctx context.Context, slackUserID string) (*Link, error)
+	Upsert(ctx context.Context, link *Link) error
+}
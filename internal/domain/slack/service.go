@@ -0,0 +1,12 @@
+package slack
+
+import "context"
+
+type Service interface {
+	// HandleMessage saves msg to the conversation for its thread, which
+	// answers it in the thread.
+	HandleMessage(ctx context.Context, msg Message) error
+	// Ask answers cmd once, without a conversation, to the command's
+	// response URL.
+	Ask(ctx context.Context, cmd Command) error
+}
@@ -30,3 +30,189 @@ type LogStats struct {
 	StartTime   time.Time        `json:"start_time"`
 	EndTime     time.Time        `json:"end_time"`
 }
+
+// Overview is the admin dashboard summary. Query volume and error rate come
+// from the persisted application log, so they only cover retained logs.
+type Overview struct {
+	Users           int64        `json:"users"`
+	Documents       int64        `json:"documents"`
+	Chunks          int64        `json:"chunks"`
+	Conversations   int64        `json:"conversations"`
+	MessagesToday   int64        `json:"messages_today"`
+	RAGQueries24h   int64        `json:"rag_queries_24h"`
+	Requests24h     int64        `json:"requests_24h"`
+	ServerErrors24h int64        `json:"server_errors_24h"`
+	ErrorRate24h    float64      `json:"error_rate_24h"`
+	Storage         StorageStats `json:"storage"`
+	GeneratedAt     time.Time    `json:"generated_at"`
+
+	// OpenConversations and PendingConversations count the conversations
+	// not yet resolved or closed.
+	OpenConversations    int64 `json:"open_conversations"`
+	PendingConversations int64 `json:"pending_conversations"`
+}
+
+// StorageStats reports database size as returned by dbStats.
+type StorageStats struct {
+	DataBytes    int64 `json:"data_bytes"`
+	StorageBytes int64 `json:"storage_bytes"`
+	IndexBytes   int64 `json:"index_bytes"`
+}
+
+// MetricsRetention is how long metric samples are kept.
+const MetricsRetention = 7 * 24 * time.Hour
+
+// MetricSample is one instance's resource use at a point in time. In
+// history responses each sample averages the samples of its step.
+type MetricSample struct {
+	ID         string    `json:"-" bson:"_id,omitempty"`
+	Instance   string    `json:"instance" bson:"instance"`
+	Timestamp  time.Time `json:"timestamp" bson:"timestamp"`
+	MemAllocMB float64   `json:"mem_alloc_mb" bson:"mem_alloc_mb"`
+	MemSysMB   float64   `json:"mem_sys_mb" bson:"mem_sys_mb"`
+	Goroutines float64   `json:"goroutines" bson:"goroutines"`
+	// DBLatencyMs is the time a database ping took. It is zero when DBDown
+	// is set.
+	DBLatencyMs float64 `json:"db_latency_ms" bson:"db_latency_ms"`
+	DBDown      bool    `json:"db_down,omitempty" bson:"db_down,omitempty"`
+	// RequestsPerMinute is the HTTP request rate since the previous sample.
+	RequestsPerMinute float64 `json:"requests_per_minute" bson:"requests_per_minute"`
+}
+
+// RouteStat summarizes one route's requests over a window. Latency
+// percentiles are approximate, and ErrorRate counts server errors only.
+type RouteStat struct {
+	Method       string  `json:"method"`
+	Route        string  `json:"route"`
+	Requests     int64   `json:"requests"`
+	ClientErrors int64   `json:"client_errors"`
+	ServerErrors int64   `json:"server_errors"`
+	ErrorRate    float64 `json:"error_rate"`
+	P50Ms        float64 `json:"p50_ms"`
+	P95Ms        float64 `json:"p95_ms"`
+	P99Ms        float64 `json:"p99_ms"`
+}
+
+// Index is a database index. Ops counts the queries that used it since
+// Since, when the server started counting, which resets on restart.
+type Index struct {
+	Collection string     `json:"collection"`
+	Name       string     `json:"name"`
+	Keys       string     `json:"keys"`
+	Unique     bool       `json:"unique,omitempty"`
+	TTL        bool       `json:"ttl,omitempty"`
+	Ops        int64      `json:"ops"`
+	Since      *time.Time `json:"since,omitempty"`
+}
+
+// IndexReport compares the indexes the repositories declare with those in
+// the database.
+type IndexReport struct {
+	// Missing lists declared indexes with no index on the same keys.
+	Missing []Index `json:"missing"`
+	// Unused lists indexes no query has used. Unique and TTL indexes are
+	// left out, as they do their work on writes.
+	Unused  []Index `json:"unused"`
+	Indexes []Index `json:"indexes"`
+}
+
+// ProviderStatus is the latest probe of a model provider, such as OpenAI or
+// Ollama. Models lists what the provider offered then; it is empty when
+// the probe failed.
+type ProviderStatus struct {
+	Name      string    `json:"name"`
+	Available bool      `json:"available"`
+	LatencyMs int64     `json:"latency_ms"`
+	Models    []string  `json:"models"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Maintenance is the maintenance-mode switch shared by every replica. While
+// it is on, non-admin requests are answered with 503 and Message, and
+// scheduled jobs are paused.
+type Maintenance struct {
+	Enabled   bool      `json:"enabled" bson:"enabled"`
+	Message   string    `json:"message,omitempty" bson:"message,omitempty"`
+	UpdatedBy string    `json:"updated_by,omitempty" bson:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// RateLimitClient is who the API-wide rate limit counts requests for: a
+// signed-in user, or an IP for everyone else.
+type RateLimitClient struct {
+	IP     string `json:"ip,omitempty"`
+	UserID string `json:"user_id,omitempty"`
+}
+
+// Key names the client's counters.
+func (c RateLimitClient) Key() string {
+	if c.UserID != "" {
+		return "user:" + c.UserID
+	}
+	return "ip:" + c.IP
+}
+
+// RateLimitUsage is a client's use of the API-wide rate limit in the
+// current window.
+type RateLimitUsage struct {
+	Client   RateLimitClient `json:"client"`
+	Requests int64           `json:"requests"`
+	// Limit is the requests allowed per window, after the user's role
+	// multiplier.
+	Limit    int       `json:"limit"`
+	ResetsAt time.Time `json:"resets_at"`
+	// Exempt is set for allow-listed clients, whose requests are not
+	// counted.
+	Exempt bool `json:"exempt"`
+}
+
+// SecurityEventRetention is how long security events are kept.
+const SecurityEventRetention = 30 * 24 * time.Hour
+
+type SecurityEventType string
+
+const (
+	// SecurityEventBurst is an IP calling a public endpoint faster than
+	// any visitor or provider would.
+	SecurityEventBurst SecurityEventType = "burst"
+	// SecurityEventImpossibleTravel is a widget session used from another
+	// country soon after it was started.
+	SecurityEventImpossibleTravel SecurityEventType = "impossible_travel"
+	// SecurityEventBlockedASN is a call from a network on the blocked ASN
+	// list, such as a hosting provider.
+	SecurityEventBlockedASN SecurityEventType = "blocked_asn"
+)
+
+// SecurityEvent is abuse detected on a public endpoint and the temporary
+// block it led to.
+type SecurityEvent struct {
+	ID   string            `json:"id" bson:"_id,omitempty"`
+	Type SecurityEventType `json:"type" bson:"type"`
+	// Scope names the endpoints called, such as "widget" or "webhook".
+	Scope   string `json:"scope" bson:"scope"`
+	IP      string `json:"ip" bson:"ip"`
+	ASN     uint32 `json:"asn,omitempty" bson:"asn,omitempty"`
+	Country string `json:"country,omitempty" bson:"country,omitempty"`
+	Detail  string `json:"detail" bson:"detail"`
+	// Blocked is what was blocked: "ip" or "session".
+	Blocked      string    `json:"blocked" bson:"blocked"`
+	BlockedUntil time.Time `json:"blocked_until" bson:"blocked_until"`
+	CreatedAt    time.Time `json:"created_at" bson:"created_at"`
+}
+
+// SecurityEventFilter narrows the security events feed. Zero fields match
+// everything.
+type SecurityEventFilter struct {
+	Type  SecurityEventType
+	IP    string
+	Limit int
+}
+
+// AbuseRequest is a call to a public endpoint, as abuse detection sees it.
+type AbuseRequest struct {
+	Scope string
+	IP    string
+	// Session is the widget session token the call carries, if any.
+	Session string
+}
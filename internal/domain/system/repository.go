@@ -1,6 +1,9 @@
 package system
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type LogRepository interface {
 	Insert(ctx context.Context, entry *LogEntry) error
@@ -8,3 +11,38 @@ type LogRepository interface {
 	Stats(ctx context.Context) (*LogStats, error)
 	DeleteOlderThan(ctx context.Context, days int) (int64, error)
 }
+
+type OverviewRepository interface {
+	// Overview counts what the dashboard shows as of now. "Today" starts at
+	// midnight in now's location.
+	Overview(ctx context.Context, now time.Time) (*Overview, error)
+}
+
+type MetricsRepository interface {
+	Insert(ctx context.Context, sample *MetricSample) error
+	// Since returns the samples taken at or after since, oldest first.
+	Since(ctx context.Context, since time.Time) ([]MetricSample, error)
+}
+
+type IndexRepository interface {
+	// EnsureIndexes creates the declared indexes that are missing. An
+	// index on the same keys under another name counts as present.
+	EnsureIndexes(ctx context.Context) error
+	// Declared returns the indexes the repositories rely on.
+	Declared() []Index
+	// List returns the indexes of the declared collections with their
+	// usage.
+	List(ctx context.Context) ([]Index, error)
+}
+
+type MaintenanceRepository interface {
+	// Get returns nil when the switch was never set.
+	Get(ctx context.Context) (*Maintenance, error)
+	Save(ctx context.Context, maintenance *Maintenance) error
+}
+
+type SecurityEventRepository interface {
+	Insert(ctx context.Context, event *SecurityEvent) error
+	// List returns the matching events, newest first.
+	List(ctx context.Context, filter SecurityEventFilter) ([]SecurityEvent, error)
+}
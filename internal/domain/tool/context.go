@@ -0,0 +1,17 @@
+package tool
+
+import "context"
+
+type contextKey struct{}
+
+// WithConversation returns ctx carrying the conversation a query is
+// answered in, so tools that act on it, such as booking, know which.
+func WithConversation(ctx context.Context, conversationID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, conversationID)
+}
+
+// ConversationFrom returns the conversation ctx carries, or "".
+func ConversationFrom(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
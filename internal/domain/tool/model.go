@@ -0,0 +1,74 @@
+package tool
+
+import "time"
+
+type Kind string
+
+const (
+	// KindWebhook posts the arguments to a customer endpoint and returns
+	// its response, e.g. for order-status lookups.
+	KindWebhook    Kind = "webhook"
+	KindCalculator Kind = "calculator"
+	KindDate       Kind = "current_date"
+	// KindBookingSlots offers a resource's next free slots, as reply
+	// buttons on WhatsApp, and KindBooking books the one picked.
+	KindBookingSlots Kind = "booking_slots"
+	KindBooking      Kind = "booking"
+	// KindLookup fetches a record, such as an order, from a customer API
+	// by filling the arguments into LookupURL.
+	KindLookup Kind = "lookup"
+)
+
+// Tool is a function the answer model may call before replying.
+type Tool struct {
+	ID          string `json:"id" bson:"_id,omitempty"`
+	Name        string `json:"name" bson:"name"`
+	Description string `json:"description" bson:"description"`
+	Kind        Kind   `json:"kind" bson:"kind"`
+	// Parameters is the JSON Schema of a webhook's arguments. Built-in
+	// kinds define their own.
+	Parameters map[string]any `json:"parameters,omitempty" bson:"parameters,omitempty"`
+	WebhookURL string         `json:"webhook_url,omitempty" bson:"webhook_url,omitempty"`
+	// WebhookSecret signs webhook calls. It is write-only.
+	WebhookSecret string `json:"-" bson:"webhook_secret,omitempty"`
+	// LookupURL is a lookup's endpoint, with a {name} placeholder per
+	// argument, e.g. https://api.example.com/orders/{order_number}.
+	LookupURL string `json:"lookup_url,omitempty" bson:"lookup_url,omitempty"`
+	// AuthHeader names the header a lookup sends AuthValue in, such as
+	// "Authorization". AuthValue is write-only.
+	AuthHeader string `json:"auth_header,omitempty" bson:"auth_header,omitempty"`
+	AuthValue  string `json:"-" bson:"auth_value,omitempty"`
+	// ResourceID is the bookable resource of the booking kinds.
+	ResourceID string `json:"resource_id,omitempty" bson:"resource_id,omitempty"`
+	// Timezone is the IANA zone current_date reports in.
+	Timezone  string    `json:"timezone,omitempty" bson:"timezone,omitempty"`
+	TimeoutMs int       `json:"timeout_ms" bson:"timeout_ms"`
+	IsActive  bool      `json:"is_active" bson:"is_active"`
+	CreatedBy string    `json:"created_by" bson:"created_by"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// Invocation is the audit record of one tool call.
+type Invocation struct {
+	ID       string `json:"id" bson:"_id,omitempty"`
+	ToolID   string `json:"tool_id" bson:"tool_id"`
+	ToolName string `json:"tool_name" bson:"tool_name"`
+	// ConversationID is the conversation the call was made for, if any.
+	ConversationID string `json:"conversation_id,omitempty" bson:"conversation_id,omitempty"`
+	Arguments      string `json:"arguments" bson:"arguments"`
+	// URL and StatusCode record the request of a lookup or webhook call.
+	URL        string    `json:"url,omitempty" bson:"url,omitempty"`
+	StatusCode int       `json:"status_code,omitempty" bson:"status_code,omitempty"`
+	Result     string    `json:"result,omitempty" bson:"result,omitempty"`
+	Error      string    `json:"error,omitempty" bson:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms" bson:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at" bson:"created_at"`
+}
+
+// FailureCount is how often a tool's webhook failed in a period.
+type FailureCount struct {
+	ToolName  string `json:"tool_name" bson:"_id"`
+	Failures  int64  `json:"failures" bson:"failures"`
+	LastError string `json:"last_error" bson:"last_error"`
+}
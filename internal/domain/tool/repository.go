@@ -0,0 +1,26 @@
+package tool
+
+import (
+	"context"
+	"time"
+)
+
+type Repository interface {
+	Create(ctx context.Context, tool *Tool) (string, error)
+	GetByID(ctx context.Context, id string) (*Tool, error)
+	GetByName(ctx context.Context, name string) (*Tool, error)
+	List(ctx context.Context) ([]Tool, error)
+	ListActive(ctx context.Context) ([]Tool, error)
+	Update(ctx context.Context, tool *Tool) error
+	Delete(ctx context.Context, id string) error
+}
+
+type InvocationRepository interface {
+	Create(ctx context.Context, inv *Invocation) error
+	// List returns the newest invocations first, for one tool when toolID
+	// is set.
+	List(ctx context.Context, toolID string, limit, offset int) ([]Invocation, int64, error)
+	// Failures counts the failed invocations in [start, end) by tool, most
+	// failing first.
+	Failures(ctx context.Context, start, end time.Time) ([]FailureCount, error)
+}
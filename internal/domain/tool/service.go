@@ -0,0 +1,11 @@
+package tool
+
+import "context"
+
+type Service interface {
+	CreateTool(ctx context.Context, adminID string, tool *Tool) (string, error)
+	ListTools(ctx context.Context) ([]Tool, error)
+	UpdateTool(ctx context.Context, adminID string, tool *Tool) error
+	DeleteTool(ctx context.Context, adminID, id string) error
+	ListInvocations(ctx context.Context, toolID string, limit, offset int) ([]Invocation, int64, error)
+}
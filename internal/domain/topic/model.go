@@ -0,0 +1,35 @@
+package topic
+
+import "time"
+
+// Question is a question asked of the knowledge base, kept for a while so
+// recent questions can be grouped into topics.
+type Question struct {
+	ID   string `json:"id" bson:"_id,omitempty"`
+	Text string `json:"text" bson:"text"`
+	// Embedding is made by the clustering job with EmbeddingModel, so
+	// asking a question costs no extra embedding.
+	Embedding      []float64 `json:"-" bson:"embedding,omitempty"`
+	EmbeddingModel string    `json:"-" bson:"embedding_model,omitempty"`
+	AskedAt        time.Time `json:"asked_at" bson:"asked_at"`
+}
+
+// Topic is a group of similar questions.
+type Topic struct {
+	Label     string  `json:"label" bson:"label"`
+	Questions int     `json:"questions" bson:"questions"`
+	Share     float64 `json:"share" bson:"share"`
+	// Examples are the questions closest to the topic's center.
+	Examples []string `json:"examples" bson:"examples"`
+}
+
+// Snapshot is the topics of the questions asked between From and To, most
+// asked first.
+type Snapshot struct {
+	ID        string    `json:"id" bson:"_id,omitempty"`
+	From      time.Time `json:"from" bson:"from"`
+	To        time.Time `json:"to" bson:"to"`
+	Questions int       `json:"questions" bson:"questions"`
+	Topics    []Topic   `json:"topics" bson:"topics"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
@@ -0,0 +1,25 @@
+package topic
+
+import (
+	"context"
+	"time"
+)
+
+type QuestionRepository interface {
+	Create(ctx context.Context, question *Question) error
+	// ListSince returns up to limit questions asked since since, newest
+	// first.
+	ListSince(ctx context.Context, since time.Time, limit int) ([]Question, error)
+	// SetEmbeddings stores vectors made with model by question ID.
+	SetEmbeddings(ctx context.Context, model string, vectors map[string][]float64) error
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type SnapshotRepository interface {
+	Create(ctx context.Context, snapshot *Snapshot) (string, error)
+	GetByID(ctx context.Context, id string) (*Snapshot, error)
+	// Latest returns the newest snapshot, or nil.
+	Latest(ctx context.Context) (*Snapshot, error)
+	// List returns up to limit snapshots, newest first.
+	List(ctx context.Context, limit int) ([]Snapshot, error)
+}
@@ -0,0 +1,12 @@
+package topic
+
+import "context"
+
+type Service interface {
+	// Cluster groups the questions of the recent window into labelled
+	// topics and saves them as a snapshot.
+	Cluster(ctx context.Context) (*Snapshot, error)
+	Latest(ctx context.Context) (*Snapshot, error)
+	GetSnapshot(ctx context.Context, id string) (*Snapshot, error)
+	ListSnapshots(ctx context.Context, limit int) ([]Snapshot, error)
+}
@@ -0,0 +1,31 @@
+package transcript
+
+type Format string
+
+const (
+	FormatPDF  Format = "pdf"
+	FormatHTML Format = "html"
+)
+
+// Recipient is who a transcript is emailed to: the conversation's contact
+// or the agent asking for it.
+type Recipient string
+
+const (
+	RecipientContact Recipient = "contact"
+	RecipientAgent   Recipient = "agent"
+)
+
+// File is a rendered transcript.
+type File struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Delivery is a transcript that was emailed.
+type Delivery struct {
+	Recipient Recipient `json:"recipient"`
+	To        string    `json:"to"`
+	Format    Format    `json:"format"`
+}
@@ -0,0 +1,14 @@
+package transcript
+
+import (
+	"context"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+)
+
+type Service interface {
+	// Render returns the conversation as a branded transcript.
+	Render(ctx context.Context, userCtx conversationDomain.UserContext, conversationID string, format Format) (*File, error)
+	// Email renders the transcript and emails it to the recipient.
+	Email(ctx context.Context, userCtx conversationDomain.UserContext, conversationID string, format Format, to Recipient) (*Delivery, error)
+}
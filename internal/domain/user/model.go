@@ -5,8 +5,9 @@ import "time"
 type Role string
 
 const (
-	RoleUser  Role = "user"
-	RoleAdmin Role = "admin"
+	RoleUser   Role = "user"
+	RoleEditor Role = "editor"
+	RoleAdmin  Role = "admin"
 )
 
 type User struct {
@@ -19,6 +20,9 @@ type User struct {
 	IsActive     bool      `json:"is_active" bson:"is_active"`
 	OAuthProvider   string `json:"oauth_provider,omitempty" bson:"oauth_provider,omitempty"`
 	OAuthProviderID string `json:"-" bson:"oauth_provider_id,omitempty"`
+	// TokenInvalidBefore revokes every token issued before it. It is set on
+	// password change and deactivation.
+	TokenInvalidBefore *time.Time `json:"-" bson:"token_invalid_before,omitempty"`
 	CreatedAt    time.Time `json:"created_at" bson:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at" bson:"updated_at"`
 }
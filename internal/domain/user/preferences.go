@@ -0,0 +1,62 @@
+package user
+
+import "time"
+
+type NotificationChannel string
+
+const (
+	NotificationEmail    NotificationChannel = "email"
+	NotificationInApp    NotificationChannel = "in_app"
+	NotificationWhatsApp NotificationChannel = "whatsapp"
+)
+
+type DigestFrequency string
+
+const (
+	DigestNever  DigestFrequency = "never"
+	DigestDaily  DigestFrequency = "daily"
+	DigestWeekly DigestFrequency = "weekly"
+)
+
+// SupportedLanguages lists the UI languages a user can select.
+var SupportedLanguages = []string{"en", "es", "fr", "de", "pt", "zh"}
+
+// Preferences holds per-user settings read by the UI and by subsystems that
+// notify users, such as alerting and email digests.
+type Preferences struct {
+	UserID               string                `json:"user_id" bson:"_id"`
+	Language             string                `json:"language" bson:"language"`
+	Timezone             string                `json:"timezone" bson:"timezone"`
+	NotificationChannels []NotificationChannel `json:"notification_channels" bson:"notification_channels"`
+	DigestFrequency      DigestFrequency       `json:"digest_frequency" bson:"digest_frequency"`
+	UpdatedAt            time.Time             `json:"updated_at" bson:"updated_at"`
+}
+
+// DefaultPreferences returns the settings used for users who never saved any.
+func DefaultPreferences(userID string) *Preferences {
+	return &Preferences{
+		UserID:               userID,
+		Language:             "en",
+		Timezone:             "UTC",
+		NotificationChannels: []NotificationChannel{NotificationEmail},
+		DigestFrequency:      DigestDaily,
+	}
+}
+
+// WantsChannel reports whether the user opted into notifications on ch.
+func (p *Preferences) WantsChannel(ch NotificationChannel) bool {
+	for _, c := range p.NotificationChannels {
+		if c == ch {
+			return true
+		}
+	}
+	return false
+}
+
+// Location returns the user's time zone, falling back to UTC.
+func (p *Preferences) Location() *time.Location {
+	if loc, err := time.LoadLocation(p.Timezone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
@@ -1,10 +1,41 @@
 package user
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Repository interface {
 	Create(ctx context.Context, user *User) (string, error)
 	GetByID(ctx context.Context, id string) (*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
 	Update(ctx context.Context, user *User) error
+	// ListByRole returns the active users with role.
+	ListByRole(ctx context.Context, role Role) ([]User, error)
+}
+
+type PreferencesRepository interface {
+	Get(ctx context.Context, userID string) (*Preferences, error)
+	Upsert(ctx context.Context, prefs *Preferences) error
+}
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *Session) error
+	Get(ctx context.Context, id string) (*Session, error)
+	// ListByUser returns the user's sessions that have not expired by now,
+	// most recently used first.
+	ListByUser(ctx context.Context, userID string, now time.Time) ([]Session, error)
+	Touch(ctx context.Context, id string, at time.Time) error
+	// Bind sets the session's device fingerprint unless it already has one,
+	// reporting whether it did.
+	Bind(ctx context.Context, id, fingerprint string) (bool, error)
+	// Delete removes the user's session id, reporting whether there was one.
+	Delete(ctx context.Context, userID, id string) (bool, error)
+	DeleteByUser(ctx context.Context, userID string) error
+}
+
+type DeviceRepository interface {
+	// Seen records that the user signed in from device at, reporting
+	// whether the device is new to them.
+	Seen(ctx context.Context, device *KnownDevice, at time.Time) (bool, error)
 }
@@ -1,11 +1,17 @@
 package user
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Claims struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
-	Role   string `json:"role"`
+	UserID   string    `json:"user_id"`
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	IssuedAt time.Time `json:"issued_at"`
+	// SessionID is the session the token was issued for, if any.
+	SessionID string `json:"session_id,omitempty"`
 }
 
 type Service interface {
@@ -15,5 +21,13 @@ type Service interface {
 	GetUser(ctx context.Context, id string) (*User, error)
 	GetUserByEmail(ctx context.Context, email string) (*User, error)
 	ValidateToken(token string) (*Claims, error)
-	GenerateToken(user *User) (string, error)
+	CheckSession(ctx context.Context, claims *Claims) error
+	GenerateToken(ctx context.Context, user *User) (string, error)
+	GetPreferences(ctx context.Context, userID string) (*Preferences, error)
+	UpdatePreferences(ctx context.Context, prefs *Preferences) (*Preferences, error)
+	ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) error
+	SetActive(ctx context.Context, userID string, active bool) (*User, error)
+	// ListSessions returns the user's active sessions, marking currentID.
+	ListSessions(ctx context.Context, userID, currentID string) ([]Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) error
 }
@@ -0,0 +1,30 @@
+package user
+
+import "time"
+
+// Session is a signed-in device: one per token issued at sign-in. Revoking
+// it signs the device out.
+type Session struct {
+	ID         string    `json:"id" bson:"_id,omitempty"`
+	UserID     string    `json:"-" bson:"user_id"`
+	UserAgent  string    `json:"user_agent" bson:"user_agent"`
+	IP         string    `json:"ip" bson:"ip"`
+	CreatedAt  time.Time `json:"created_at" bson:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at" bson:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at" bson:"expires_at"`
+	// Fingerprint identifies the device, for binding admin sessions to it.
+	Fingerprint string `json:"-" bson:"fingerprint,omitempty"`
+	// Current marks the session the request listing sessions was made with.
+	Current bool `json:"current" bson:"-"`
+}
+
+// KnownDevice is a device a user has signed in from, remembered so admins
+// are alerted to sign-ins from new ones.
+type KnownDevice struct {
+	UserID      string    `json:"user_id" bson:"user_id"`
+	Fingerprint string    `json:"fingerprint" bson:"fingerprint"`
+	UserAgent   string    `json:"user_agent" bson:"user_agent"`
+	IP          string    `json:"ip" bson:"ip"`
+	FirstSeenAt time.Time `json:"first_seen_at" bson:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at" bson:"last_seen_at"`
+}
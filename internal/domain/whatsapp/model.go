@@ -1,7 +1,56 @@
 package whatsapp
 
+import "time"
+
 type HookInput struct {
 	Mode        string `json:"hub.mode"`
 	Challenge   string `json:"hub.challenge"`
 	VerifyToken string `json:"hub.verify_token"`
 }
+
+// Onboarding is the welcome first-time contacts get and the consent they
+// may be asked for before they are answered. Welcome and the consent
+// messages may use {name} for the contact's name.
+type Onboarding struct {
+	Enabled bool   `json:"enabled" bson:"enabled"`
+	Welcome string `json:"welcome" bson:"welcome"`
+	// Options are offered as reply buttons under the welcome, at most
+	// three; tapping one asks it as a question.
+	Options []string `json:"options,omitempty" bson:"options,omitempty"`
+	// AnswerFirst also answers the first message after the welcome;
+	// otherwise the welcome is its only reply.
+	AnswerFirst bool `json:"answer_first" bson:"answer_first"`
+
+	// RequireConsent holds every answer until the contact accepts
+	// ConsentPrompt, with its accept button or by replying yes.
+	RequireConsent  bool   `json:"require_consent" bson:"require_consent"`
+	ConsentPrompt   string `json:"consent_prompt,omitempty" bson:"consent_prompt,omitempty"`
+	ConsentAccept   string `json:"consent_accept,omitempty" bson:"consent_accept,omitempty"`
+	ConsentDecline  string `json:"consent_decline,omitempty" bson:"consent_decline,omitempty"`
+	ConsentAccepted string `json:"consent_accepted,omitempty" bson:"consent_accepted,omitempty"`
+	ConsentDeclined string `json:"consent_declined,omitempty" bson:"consent_declined,omitempty"`
+
+	UpdatedBy string    `json:"updated_by,omitempty" bson:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// Flow is a WhatsApp Flow, a form built in WhatsApp Manager, that agents
+// send contacts to capture structured answers, such as a booking or a
+// lead's budget. The contact opens it from a button labelled CTA under
+// Body. Variables maps the flow's fields to the conversation variables
+// their answers are copied into, e.g. {"budget": "lead_budget"}, so
+// replies, tools and CRM sync can use them.
+type Flow struct {
+	ID   string `json:"id" bson:"_id,omitempty"`
+	Name string `json:"name" bson:"name"`
+	// FlowID is the flow's ID in WhatsApp Manager; Screen is the one it
+	// opens on, or its first screen when empty.
+	FlowID    string            `json:"flow_id" bson:"flow_id"`
+	Screen    string            `json:"screen,omitempty" bson:"screen,omitempty"`
+	CTA       string            `json:"cta" bson:"cta"`
+	Body      string            `json:"body" bson:"body"`
+	Variables map[string]string `json:"variables,omitempty" bson:"variables,omitempty"`
+	CreatedBy string            `json:"created_by" bson:"created_by"`
+	CreatedAt time.Time         `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at" bson:"updated_at"`
+}
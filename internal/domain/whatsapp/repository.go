@@ -5,3 +5,17 @@ import "context"
 type Repository interface {
 	FindByNumber(ctx context.Context, number string) (string, error)
 }
+
+type OnboardingRepository interface {
+	// Get returns the saved onboarding settings, or nil.
+	Get(ctx context.Context) (*Onboarding, error)
+	Save(ctx context.Context, onboarding *Onboarding) error
+}
+
+type FlowRepository interface {
+	Create(ctx context.Context, flow *Flow) (string, error)
+	GetByID(ctx context.Context, id string) (*Flow, error)
+	List(ctx context.Context) ([]Flow, error)
+	Update(ctx context.Context, flow *Flow) error
+	Delete(ctx context.Context, id string) error
+}
@@ -1,5 +1,70 @@
 package whatsapp
 
+import (
+	"context"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+)
+
 type Service interface {
 	VerifyWebhook(req HookInput, expectedToken string) (string, error)
 }
+
+// Transcriber turns a voice note, identified by its WhatsApp media ID, into
+// text.
+type Transcriber interface {
+	Transcribe(ctx context.Context, mediaID string) (string, error)
+}
+
+// ImageDescriber describes a photo, identified by its WhatsApp media ID, in
+// enough detail to answer questions about it. caption is what the sender
+// wrote alongside it.
+type ImageDescriber interface {
+	Describe(ctx context.Context, mediaID, caption string) (string, error)
+}
+
+// VoiceReplier reads an answer aloud to a WhatsApp user as an audio message.
+type VoiceReplier interface {
+	Reply(ctx context.Context, to, text string) error
+}
+
+// AttachmentReplier sends a WhatsApp user the file behind an answer, drawn
+// from its relevant chunks, when the document may be shared. It reports
+// whether anything was sent.
+type AttachmentReplier interface {
+	Reply(ctx context.Context, to string, chunks []documentDomain.Chunk) (bool, error)
+}
+
+// TypingIndicator shows a WhatsApp user that a reply to their message,
+// identified by its WhatsApp message ID, is being written. The indicator
+// stays up until the returned stop function is called.
+type TypingIndicator interface {
+	Start(ctx context.Context, messageID string) (stop func(), err error)
+}
+
+// Onboarder greets first-time contacts and collects their consent. Greet
+// handles a contact's message before it is answered and reports whether
+// it should still be answered.
+type Onboarder interface {
+	Greet(ctx context.Context, conversationID, from, content string) (bool, error)
+}
+
+// OnboardingService manages the onboarding settings admins edit and
+// greets contacts with them.
+type OnboardingService interface {
+	Onboarder
+	GetOnboarding(ctx context.Context) (*Onboarding, error)
+	SaveOnboarding(ctx context.Context, onboarding *Onboarding, adminID string) error
+}
+
+// FlowService manages the WhatsApp Flows admins define and sends them to
+// contacts. SendFlow sends one to a conversation's contact on behalf of
+// userCtx, who must have access to the conversation.
+type FlowService interface {
+	CreateFlow(ctx context.Context, adminID string, flow *Flow) (string, error)
+	ListFlows(ctx context.Context) ([]Flow, error)
+	UpdateFlow(ctx context.Context, adminID string, flow *Flow) error
+	DeleteFlow(ctx context.Context, adminID, id string) error
+	SendFlow(ctx context.Context, userCtx conversationDomain.UserContext, id, conversationID string) error
+}
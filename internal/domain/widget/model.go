@@ -0,0 +1,21 @@
+package widget
+
+import "time"
+
+// Session is an anonymous chat session started by the public widget with a
+// widget key. It is identified by a signed token, so nothing is stored for
+// it besides its question count and recent turns.
+type Session struct {
+	ID           string    `json:"id"`
+	KeyID        string    `json:"-"`
+	Origin       string    `json:"-"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	MaxQuestions int       `json:"max_questions"`
+}
+
+// Reply answers a widget question.
+type Reply struct {
+	Answer string `json:"answer"`
+	// Remaining is how many more questions the session may ask.
+	Remaining int `json:"remaining"`
+}
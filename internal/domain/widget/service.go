@@ -0,0 +1,12 @@
+package widget
+
+import "context"
+
+type Service interface {
+	// StartSession checks the widget key and the page's origin and returns
+	// the session with its token.
+	StartSession(ctx context.Context, key, origin string) (*Session, string, error)
+	// Ask answers question for the session identified by token, taking the
+	// session's earlier questions into account.
+	Ask(ctx context.Context, token, origin, question string) (*Reply, error)
+}
@@ -0,0 +1,62 @@
+// Package events is an in-process bus for domain events. Services publish
+// what happened; subsystems such as caches, audit logging and outbound
+// integrations subscribe without the publisher knowing about them.
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// Event is a domain event. Name identifies the event type to subscribers.
+type Event interface {
+	EventName() string
+}
+
+// Handler reacts to a published event. Handlers run synchronously on the
+// publisher's goroutine, so slow work should be handed off.
+type Handler func(ctx context.Context, event Event)
+
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+	all      []Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[string][]Handler)}
+}
+
+// Subscribe registers handler for events with the given names.
+func (b *Bus) Subscribe(handler Handler, names ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, name := range names {
+		b.handlers[name] = append(b.handlers[name], handler)
+	}
+}
+
+// SubscribeAll registers handler for every event.
+func (b *Bus) SubscribeAll(handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.all = append(b.all, handler)
+}
+
+// Publish delivers event to its subscribers in registration order. Publishing
+// on a nil bus is a no-op so services can treat the bus as optional.
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	handlers := make([]Handler, 0, len(b.handlers[event.EventName()])+len(b.all))
+	handlers = append(handlers, b.handlers[event.EventName()]...)
+	handlers = append(handlers, b.all...)
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(ctx, event)
+	}
+}
@@ -0,0 +1,53 @@
+package events
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBusDeliversByName(t *testing.T) {
+	bus := NewBus()
+
+	var docs, all []string
+	bus.Subscribe(func(ctx context.Context, event Event) {
+		docs = append(docs, event.EventName())
+	}, NameDocumentCreated, NameDocumentDeleted)
+	bus.SubscribeAll(func(ctx context.Context, event Event) {
+		all = append(all, event.EventName())
+	})
+
+	ctx := context.Background()
+	bus.Publish(ctx, DocumentCreated{DocumentID: "doc-1"})
+	bus.Publish(ctx, UserRegistered{UserID: "user-1"})
+	bus.Publish(ctx, DocumentDeleted{DocumentID: "doc-1"})
+
+	if len(docs) != 2 || docs[0] != NameDocumentCreated || docs[1] != NameDocumentDeleted {
+		t.Errorf("Expected document events only, got %v", docs)
+	}
+	if len(all) != 3 {
+		t.Errorf("Expected 3 events for catch-all subscriber, got %d", len(all))
+	}
+}
+
+func TestBusPublishFromHandler(t *testing.T) {
+	bus := NewBus()
+
+	var answered bool
+	bus.Subscribe(func(ctx context.Context, event Event) {
+		bus.Publish(ctx, AnswerGenerated{Query: "hi"})
+	}, NameMessageReceived)
+	bus.Subscribe(func(ctx context.Context, event Event) {
+		answered = true
+	}, NameAnswerGenerated)
+
+	bus.Publish(context.Background(), MessageReceived{MessageID: "msg-1"})
+
+	if !answered {
+		t.Error("Expected nested publish to reach its subscriber")
+	}
+}
+
+func TestNilBusPublish(t *testing.T) {
+	var bus *Bus
+	bus.Publish(context.Background(), DocumentCreated{DocumentID: "doc-1"})
+}
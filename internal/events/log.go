@@ -0,0 +1,44 @@
+package events
+
+import (
+	"context"
+
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+)
+
+// LogHandler records every event in the application log as an audit trail.
+// Message and answer bodies are left out.
+func LogHandler(log *logger.Logger) Handler {
+	return func(ctx context.Context, event Event) {
+		args := []any{"event", event.EventName()}
+		switch e := event.(type) {
+		case DocumentCreated:
+			args = append(args, "document_id", e.DocumentID, "user_id", e.UserID)
+		case DocumentUpdated:
+			args = append(args, "document_id", e.DocumentID, "actor_id", e.ActorID)
+		case DocumentStatusChanged:
+			args = append(args, "document_id", e.DocumentID, "actor_id", e.ActorID, "from", e.From, "to", e.To)
+		case DocumentDeleted:
+			args = append(args, "document_id", e.DocumentID, "actor_id", e.ActorID)
+		case KnowledgeChanged:
+			args = append(args, "reason", e.Reason, "actor_id", e.ActorID)
+		case MessageReceived:
+			args = append(args, "message_id", e.MessageID, "conversation_id", e.ConversationID, "channel", e.Channel)
+		case AnswerGenerated:
+			args = append(args, "confidence", e.ConfidenceScore, "cache_hit", e.CacheHit, "processing_time_ms", e.ProcessingTimeMs)
+		case UserRegistered:
+			args = append(args, "user_id", e.UserID, "provider", e.Provider)
+		case AdminNewDevice:
+			args = append(args, "user_id", e.UserID, "ip", e.IP)
+		case SpendCapReached:
+			args = append(args, "day", e.Day, "spent_usd", e.SpentUSD, "cap_usd", e.CapUSD)
+		case ConversationStateChanged:
+			args = append(args, "conversation_id", e.ConversationID, "from", e.From, "to", e.To)
+		case MessageSent:
+			args = append(args, "message_id", e.MessageID, "conversation_id", e.ConversationID, "from_bot", e.FromBot)
+		case ConversationAssigned:
+			args = append(args, "conversation_id", e.ConversationID, "agent_id", e.AgentID, "previous_agent_id", e.PreviousAgentID, "reason", e.Reason, "rule_id", e.RuleID, "actor_id", e.ActorID)
+		}
+		log.InfoContext(ctx, "domain_event", args...)
+	}
+}
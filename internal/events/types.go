@@ -0,0 +1,163 @@
+package events
+
+import "time"
+
+const (
+	NameDocumentCreated       = "document.created"
+	NameDocumentUpdated       = "document.updated"
+	NameDocumentStatusChanged = "document.status_changed"
+	NameDocumentDeleted       = "document.deleted"
+	NameKnowledgeChanged      = "knowledge.changed"
+	NameMessageReceived       = "message.received"
+	NameAnswerGenerated       = "answer.generated"
+	NameUserRegistered        = "user.registered"
+	NameSpendCapReached       = "spend.cap_reached"
+	NameConversationAssigned  = "conversation.assigned"
+	NameConversationState     = "conversation.state_changed"
+	NameMessageSent           = "message.sent"
+	NameAdminNewDevice        = "user.admin_new_device"
+)
+
+type DocumentCreated struct {
+	DocumentID string `json:"document_id"`
+	UserID     string `json:"user_id"`
+	Title      string `json:"title"`
+	Status     string `json:"status"`
+}
+
+func (DocumentCreated) EventName() string { return NameDocumentCreated }
+
+type DocumentUpdated struct {
+	DocumentID     string `json:"document_id"`
+	ActorID        string `json:"actor_id"`
+	ContentChanged bool   `json:"content_changed"`
+}
+
+func (DocumentUpdated) EventName() string { return NameDocumentUpdated }
+
+type DocumentStatusChanged struct {
+	DocumentID string `json:"document_id"`
+	ActorID    string `json:"actor_id"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+}
+
+func (DocumentStatusChanged) EventName() string { return NameDocumentStatusChanged }
+
+type DocumentDeleted struct {
+	DocumentID string `json:"document_id"`
+	ActorID    string `json:"actor_id"`
+}
+
+func (DocumentDeleted) EventName() string { return NameDocumentDeleted }
+
+// KnowledgeChanged reports retrieval changes that are not tied to a document
+// lifecycle event, such as a pruned chunk or an edited retrieval rule.
+type KnowledgeChanged struct {
+	Reason     string `json:"reason"`
+	ActorID    string `json:"actor_id,omitempty"`
+	DocumentID string `json:"document_id,omitempty"`
+}
+
+func (KnowledgeChanged) EventName() string { return NameKnowledgeChanged }
+
+type MessageReceived struct {
+	MessageID      string `json:"message_id"`
+	ConversationID string `json:"conversation_id"`
+	Channel        string `json:"channel"`
+	From           string `json:"from"`
+	Content        string `json:"content"`
+	MessageType    string `json:"message_type"`
+	// ExternalID is the conversation's thread in its channel, for channels
+	// that are not keyed by phone number.
+	ExternalID string `json:"external_id,omitempty"`
+	// WhatsAppMsgID is the message's ID in WhatsApp, for WhatsApp messages.
+	WhatsAppMsgID string `json:"whatsapp_msg_id,omitempty"`
+	// MediaDescription describes an attached image, when there is one.
+	MediaDescription string `json:"media_description,omitempty"`
+	// Form holds the answers of a completed WhatsApp Flow.
+	Form map[string]any `json:"form,omitempty"`
+	// HumanMode is set when an agent answers the conversation instead of
+	// the bot.
+	HumanMode bool `json:"human_mode,omitempty"`
+}
+
+func (MessageReceived) EventName() string { return NameMessageReceived }
+
+type AnswerGenerated struct {
+	Query            string  `json:"query"`
+	Answer           string  `json:"answer"`
+	ConfidenceScore  float64 `json:"confidence_score"`
+	ChunkCount       int     `json:"chunk_count"`
+	CacheHit         bool    `json:"cache_hit"`
+	ProcessingTimeMs int64   `json:"processing_time_ms"`
+}
+
+func (AnswerGenerated) EventName() string { return NameAnswerGenerated }
+
+type UserRegistered struct {
+	UserID   string `json:"user_id"`
+	Email    string `json:"email"`
+	Provider string `json:"provider,omitempty"`
+}
+
+func (UserRegistered) EventName() string { return NameUserRegistered }
+
+// SpendCapReached is published once per UTC day, when model spend first
+// reaches the daily cap.
+type SpendCapReached struct {
+	Day      string  `json:"day"`
+	SpentUSD float64 `json:"spent_usd"`
+	CapUSD   float64 `json:"cap_usd"`
+}
+
+func (SpendCapReached) EventName() string { return NameSpendCapReached }
+
+// AdminNewDevice is published when an admin signs in from a device they
+// have not used before.
+type AdminNewDevice struct {
+	UserID    string    `json:"user_id"`
+	Email     string    `json:"email"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	At        time.Time `json:"at"`
+}
+
+func (AdminNewDevice) EventName() string { return NameAdminNewDevice }
+
+// ConversationAssigned is published when a conversation is handed off to an
+// agent, moves to another one or goes back to the bot, in which case
+// AgentID is empty.
+type ConversationAssigned struct {
+	ConversationID  string `json:"conversation_id"`
+	AgentID         string `json:"agent_id,omitempty"`
+	PreviousAgentID string `json:"previous_agent_id,omitempty"`
+	Reason          string `json:"reason"`
+	RuleID          string `json:"rule_id,omitempty"`
+	ActorID         string `json:"actor_id"`
+}
+
+func (ConversationAssigned) EventName() string { return NameConversationAssigned }
+
+// ConversationStateChanged is published when a conversation moves between
+// open, pending, resolved and closed.
+type ConversationStateChanged struct {
+	ConversationID string `json:"conversation_id"`
+	From           string `json:"from"`
+	To             string `json:"to"`
+}
+
+func (ConversationStateChanged) EventName() string { return NameConversationState }
+
+// MessageSent is published when a reply is stored for a conversation,
+// whether the bot or an agent wrote it.
+type MessageSent struct {
+	MessageID      string `json:"message_id"`
+	ConversationID string `json:"conversation_id"`
+	Content        string `json:"content"`
+	MessageType    string `json:"message_type"`
+	// FromBot is set when the reply is a RAG answer.
+	FromBot bool `json:"from_bot,omitempty"`
+}
+
+func (MessageSent) EventName() string { return NameMessageSent }
@@ -0,0 +1,143 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AssignmentRuleRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewAssignmentRuleRepo(client *DbClient) *AssignmentRuleRepo {
+	return &AssignmentRuleRepo{
+		collection: client.DB.Collection("assignment_rules"),
+		retry:      client.retry,
+	}
+}
+
+func (r *AssignmentRuleRepo) Create(ctx context.Context, rule *conversation.AssignmentRule) (string, error) {
+	rule.CreatedAt = time.Now()
+	rule.UpdatedAt = time.Now()
+
+	if rule.ID == "" {
+		rule.ID = primitive.NewObjectID().Hex()
+	}
+
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, rule)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return rule.ID, nil
+}
+
+func (r *AssignmentRuleRepo) GetByID(ctx context.Context, id string) (*conversation.AssignmentRule, error) {
+	var rule conversation.AssignmentRule
+	err := r.retry.findOne(ctx, r.collection, bson.M{"_id": id}, &rule)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *AssignmentRuleRepo) List(ctx context.Context) ([]conversation.AssignmentRule, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "priority", Value: 1}, {Key: "name", Value: 1}})
+
+	var rules []conversation.AssignmentRule
+	if err := r.retry.findAll(ctx, r.collection, bson.M{}, &rules, opts); err != nil {
+		return nil, err
+	}
+
+	if rules == nil {
+		rules = []conversation.AssignmentRule{}
+	}
+
+	return rules, nil
+}
+
+func (r *AssignmentRuleRepo) Update(ctx context.Context, rule *conversation.AssignmentRule) error {
+	rule.UpdatedAt = time.Now()
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": rule.ID}, rule)
+		return err
+	})
+}
+
+func (r *AssignmentRuleRepo) Delete(ctx context.Context, id string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+		return err
+	})
+}
+
+func (r *AssignmentRuleRepo) RenameTag(ctx context.Context, from, to string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		return renameTag(ctx, r.collection, from, to)
+	})
+}
+
+type AssignmentRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewAssignmentRepo(client *DbClient) *AssignmentRepo {
+	return &AssignmentRepo{
+		collection: client.DB.Collection("conversation_assignments"),
+		retry:      client.retry,
+	}
+}
+
+func (r *AssignmentRepo) Create(ctx context.Context, assignment *conversation.Assignment) (string, error) {
+	assignment.CreatedAt = time.Now()
+
+	if assignment.ID == "" {
+		assignment.ID = primitive.NewObjectID().Hex()
+	}
+
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, assignment)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return assignment.ID, nil
+}
+
+func (r *AssignmentRepo) ListByConversation(ctx context.Context, conversationID string) ([]conversation.Assignment, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	var assignments []conversation.Assignment
+	if err := r.retry.findAll(ctx, r.collection, bson.M{"conversation_id": conversationID}, &assignments, opts); err != nil {
+		return nil, err
+	}
+
+	if assignments == nil {
+		assignments = []conversation.Assignment{}
+	}
+
+	return assignments, nil
+}
+
+func (r *AssignmentRepo) DeleteByConversation(ctx context.Context, conversationID string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.DeleteMany(ctx, bson.M{"conversation_id": conversationID})
+		return err
+	})
+}
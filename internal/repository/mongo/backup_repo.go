@@ -0,0 +1,108 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// BackupRepo reads and replaces the collections that make up the knowledge
+// base.
+type BackupRepo struct {
+	documents *mongo.Collection
+	chunks    *mongo.Collection
+	rules     *mongo.Collection
+	retry     retrier
+}
+
+func NewBackupRepo(client *DbClient) *BackupRepo {
+	return &BackupRepo{
+		documents: client.DB.Collection("documents"),
+		chunks:    client.DB.Collection("chunks"),
+		rules:     client.DB.Collection("retrieval_rules"),
+		retry:     client.retry,
+	}
+}
+
+// each calls fn for every item in collection. Only opening the cursor is
+// retried; once items have gone to fn, starting over would repeat them.
+func each[T any](ctx context.Context, retry retrier, collection *mongo.Collection, fn func(*T) error) error {
+	var cursor *mongo.Cursor
+	err := retry.read(ctx, func(ctx context.Context) error {
+		var err error
+		cursor, err = collection.Find(ctx, bson.M{})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cursor.Close(ctx) }()
+
+	for cursor.Next(ctx) {
+		var item T
+		if err := cursor.Decode(&item); err != nil {
+			return err
+		}
+		if err := fn(&item); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func insertAll[T any](ctx context.Context, collection *mongo.Collection, items []T) error {
+	if len(items) == 0 {
+		return nil
+	}
+	docs := make([]any, len(items))
+	for i := range items {
+		docs[i] = items[i]
+	}
+	_, err := collection.InsertMany(ctx, docs)
+	return err
+}
+
+func (r *BackupRepo) EachDocument(ctx context.Context, fn func(*document.Document) error) error {
+	return each(ctx, r.retry, r.documents, fn)
+}
+
+func (r *BackupRepo) EachChunk(ctx context.Context, fn func(*document.Chunk) error) error {
+	return each(ctx, r.retry, r.chunks, fn)
+}
+
+func (r *BackupRepo) EachRule(ctx context.Context, fn func(*document.RetrievalRule) error) error {
+	return each(ctx, r.retry, r.rules, fn)
+}
+
+func (r *BackupRepo) Clear(ctx context.Context) error {
+	for _, c := range []*mongo.Collection{r.documents, r.chunks, r.rules} {
+		err := r.retry.write(ctx, func(ctx context.Context) error {
+			_, err := c.DeleteMany(ctx, bson.M{})
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *BackupRepo) InsertDocuments(ctx context.Context, docs []document.Document) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		return insertAll(ctx, r.documents, docs)
+	})
+}
+
+func (r *BackupRepo) InsertChunks(ctx context.Context, chunks []document.Chunk) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		return insertAll(ctx, r.chunks, chunks)
+	})
+}
+
+func (r *BackupRepo) InsertRules(ctx context.Context, rules []document.RetrievalRule) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		return insertAll(ctx, r.rules, rules)
+	})
+}
@@ -0,0 +1,199 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/booking"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type BookingResourceRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewBookingResourceRepo(client *DbClient) *BookingResourceRepo {
+	return &BookingResourceRepo{
+		collection: client.DB.Collection("booking_resources"),
+		retry:      client.retry,
+	}
+}
+
+func (r *BookingResourceRepo) Create(ctx context.Context, resource *booking.Resource) (string, error) {
+	resource.CreatedAt = time.Now()
+	resource.UpdatedAt = time.Now()
+
+	if resource.ID == "" {
+		resource.ID = primitive.NewObjectID().Hex()
+	}
+
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, resource)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return resource.ID, nil
+}
+
+func (r *BookingResourceRepo) GetByID(ctx context.Context, id string) (*booking.Resource, error) {
+	var resource booking.Resource
+	err := r.retry.findOne(ctx, r.collection, bson.M{"_id": id}, &resource)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &resource, nil
+}
+
+func (r *BookingResourceRepo) List(ctx context.Context) ([]booking.Resource, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+	var resources []booking.Resource
+	if err := r.retry.findAll(ctx, r.collection, bson.M{}, &resources, opts); err != nil {
+		return nil, err
+	}
+
+	if resources == nil {
+		resources = []booking.Resource{}
+	}
+
+	return resources, nil
+}
+
+func (r *BookingResourceRepo) Update(ctx context.Context, resource *booking.Resource) error {
+	resource.UpdatedAt = time.Now()
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": resource.ID}, resource)
+		return err
+	})
+}
+
+func (r *BookingResourceRepo) Delete(ctx context.Context, id string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+		return err
+	})
+}
+
+type BookingRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewBookingRepo(client *DbClient) *BookingRepo {
+	return &BookingRepo{
+		collection: client.DB.Collection("bookings"),
+		retry:      client.retry,
+	}
+}
+
+func (r *BookingRepo) Create(ctx context.Context, b *booking.Booking) (string, error) {
+	b.CreatedAt = time.Now()
+	b.UpdatedAt = time.Now()
+
+	if b.ID == "" {
+		b.ID = primitive.NewObjectID().Hex()
+	}
+
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, b)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return b.ID, nil
+}
+
+func (r *BookingRepo) GetByID(ctx context.Context, id string) (*booking.Booking, error) {
+	var b booking.Booking
+	err := r.retry.findOne(ctx, r.collection, bson.M{"_id": id}, &b)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *BookingRepo) List(ctx context.Context, filter booking.Filter) ([]booking.Booking, error) {
+	query := bson.M{}
+	if filter.ResourceID != "" {
+		query["resource_id"] = filter.ResourceID
+	}
+	if filter.ConversationID != "" {
+		query["conversation_id"] = filter.ConversationID
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	start := bson.M{}
+	if !filter.From.IsZero() {
+		start["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		start["$lt"] = filter.To
+	}
+	if len(start) > 0 {
+		query["start"] = start
+	}
+	return r.find(ctx, query)
+}
+
+func (r *BookingRepo) Update(ctx context.Context, b *booking.Booking) error {
+	b.UpdatedAt = time.Now()
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": b.ID}, b)
+		return err
+	})
+}
+
+func (r *BookingRepo) Overlapping(ctx context.Context, resourceID string, start, end time.Time) ([]booking.Booking, error) {
+	return r.find(ctx, bson.M{
+		"resource_id": resourceID,
+		"status":      booking.StatusConfirmed,
+		"start":       bson.M{"$lt": end},
+		"end":         bson.M{"$gt": start},
+	})
+}
+
+func (r *BookingRepo) DueReminders(ctx context.Context, from, to time.Time) ([]booking.Booking, error) {
+	return r.find(ctx, bson.M{
+		"status":      booking.StatusConfirmed,
+		"start":       bson.M{"$gte": from, "$lt": to},
+		"reminded_at": bson.M{"$exists": false},
+	})
+}
+
+func (r *BookingRepo) MarkReminded(ctx context.Context, id string, at time.Time) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"reminded_at": at}})
+		return err
+	})
+}
+
+func (r *BookingRepo) find(ctx context.Context, query bson.M) ([]booking.Booking, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "start", Value: 1}})
+
+	var bookings []booking.Booking
+	if err := r.retry.findAll(ctx, r.collection, query, &bookings, opts); err != nil {
+		return nil, err
+	}
+
+	if bookings == nil {
+		bookings = []booking.Booking{}
+	}
+
+	return bookings, nil
+}
@@ -0,0 +1,99 @@
+package mongo
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CannedResponseRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewCannedResponseRepo(client *DbClient) *CannedResponseRepo {
+	return &CannedResponseRepo{
+		collection: client.DB.Collection("canned_responses"),
+		retry:      client.retry,
+	}
+}
+
+func (r *CannedResponseRepo) Create(ctx context.Context, response *conversation.CannedResponse) (string, error) {
+	response.CreatedAt = time.Now()
+	response.UpdatedAt = time.Now()
+
+	if response.ID == "" {
+		response.ID = primitive.NewObjectID().Hex()
+	}
+
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, response)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return response.ID, nil
+}
+
+func (r *CannedResponseRepo) GetByID(ctx context.Context, id string) (*conversation.CannedResponse, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *CannedResponseRepo) GetByShortcut(ctx context.Context, shortcut string) (*conversation.CannedResponse, error) {
+	return r.findOne(ctx, bson.M{"shortcut": shortcut})
+}
+
+func (r *CannedResponseRepo) findOne(ctx context.Context, filter bson.M) (*conversation.CannedResponse, error) {
+	var response conversation.CannedResponse
+	err := r.retry.findOne(ctx, r.collection, filter, &response)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (r *CannedResponseRepo) List(ctx context.Context, query string) ([]conversation.CannedResponse, error) {
+	filter := bson.M{}
+	if query != "" {
+		pattern := bson.M{"$regex": regexp.QuoteMeta(query), "$options": "i"}
+		filter["$or"] = bson.A{bson.M{"shortcut": pattern}, bson.M{"title": pattern}}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "shortcut", Value: 1}})
+
+	var responses []conversation.CannedResponse
+	if err := r.retry.findAll(ctx, r.collection, filter, &responses, opts); err != nil {
+		return nil, err
+	}
+
+	if responses == nil {
+		responses = []conversation.CannedResponse{}
+	}
+
+	return responses, nil
+}
+
+func (r *CannedResponseRepo) Update(ctx context.Context, response *conversation.CannedResponse) error {
+	response.UpdatedAt = time.Now()
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": response.ID}, response)
+		return err
+	})
+}
+
+func (r *CannedResponseRepo) Delete(ctx context.Context, id string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+		return err
+	})
+}
@@ -9,15 +9,49 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// stagedField holds the vectors an embedding migration stages.
+const stagedField = "staged_embedding"
+
+// embeddingIndexID is the single document of the embedding_index collection.
+const embeddingIndexID = "chunks"
+
+// inDefaultSpace matches the collection of chunks outside any collection.
+var inDefaultSpace = bson.M{"$in": bson.A{nil, ""}}
+
+// spaceFilter matches the visible chunks of space.
+func spaceFilter(space document.EmbeddingSpace) bson.M {
+	filter := bson.M{"hidden": bson.M{"$ne": true}}
+	if space.Collection == "" {
+		filter["collection"] = inDefaultSpace
+		return filter
+	}
+	filter["collection"] = space.Collection
+	filter["embedding_model"] = space.Model
+	if space.Dimensions > 0 {
+		filter["dimensions"] = space.Dimensions
+	}
+	return filter
+}
+
+// unstaged matches the default-space chunks still missing a staged vector.
+func unstaged() bson.M {
+	return bson.M{"collection": inDefaultSpace, stagedField: bson.M{"$exists": false}}
+}
+
 type ChunkRepo struct {
 	collection *mongo.Collection
+	index      *mongo.Collection
+	retry      retrier
 }
 
 func NewChunkRepo(client *DbClient) *ChunkRepo {
 	return &ChunkRepo{
 		collection: client.DB.Collection("chunks"),
+		index:      client.DB.Collection("embedding_index"),
+		retry:      client.retry,
 	}
 }
 
@@ -35,19 +69,48 @@ func (r *ChunkRepo) CreateBatch(ctx context.Context, chunks []document.Chunk) er
 		docs[i] = chunk
 	}
 
-	_, err := r.collection.InsertMany(ctx, docs)
-	return err
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertMany(ctx, docs)
+		return err
+	})
 }
 
 func (r *ChunkRepo) GetByDocumentID(ctx context.Context, documentID string) ([]document.Chunk, error) {
-	cursor, err := r.collection.Find(ctx, bson.M{"document_id": documentID})
+	var chunks []document.Chunk
+	if err := r.retry.findAll(ctx, r.collection, bson.M{"document_id": documentID}, &chunks); err != nil {
+		return nil, err
+	}
+
+	if chunks == nil {
+		chunks = []document.Chunk{}
+	}
+
+	return chunks, nil
+}
+
+func (r *ChunkRepo) GetByID(ctx context.Context, id string) (*document.Chunk, error) {
+	var chunk document.Chunk
+	err := r.retry.findOne(ctx, r.collection, bson.M{"_id": id}, &chunk)
 	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
 		return nil, err
 	}
-	defer func() { _ = cursor.Close(ctx) }()
+	return &chunk, nil
+}
+
+func (r *ChunkRepo) ListByDocumentID(ctx context.Context, documentID string, limit, offset int, withEmbeddings bool) ([]document.Chunk, error) {
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset)).
+		SetSort(bson.D{{Key: "chunk_index", Value: 1}})
+	if !withEmbeddings {
+		opts.SetProjection(bson.M{"embedding": 0, stagedField: 0})
+	}
 
 	var chunks []document.Chunk
-	if err := cursor.All(ctx, &chunks); err != nil {
+	if err := r.retry.findAll(ctx, r.collection, bson.M{"document_id": documentID}, &chunks, opts); err != nil {
 		return nil, err
 	}
 
@@ -58,20 +121,99 @@ func (r *ChunkRepo) GetByDocumentID(ctx context.Context, documentID string) ([]d
 	return chunks, nil
 }
 
+func (r *ChunkRepo) CountByDocumentID(ctx context.Context, documentID string) (int64, error) {
+	return r.retry.count(ctx, r.collection, bson.M{"document_id": documentID})
+}
+
+func (r *ChunkRepo) Delete(ctx context.Context, id string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+		return err
+	})
+}
+
 func (r *ChunkRepo) DeleteByDocumentID(ctx context.Context, documentID string) error {
-	_, err := r.collection.DeleteMany(ctx, bson.M{"document_id": documentID})
-	return err
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.DeleteMany(ctx, bson.M{"document_id": documentID})
+		return err
+	})
+}
+
+func (r *ChunkRepo) DeleteByIDs(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+		return err
+	})
+}
+
+func (r *ChunkRepo) UpdatePlacement(ctx context.Context, chunks []document.Chunk) error {
+	if len(chunks) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, 0, len(chunks))
+	for _, c := range chunks {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": c.ID}).
+			SetUpdate(bson.M{"$set": bson.M{
+				"chunk_index":  c.ChunkIndex,
+				"table":        c.Table,
+				"hidden":       c.Hidden,
+				"content_hash": c.ContentHash,
+			}}))
+	}
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		return err
+	})
 }
 
-func (r *ChunkRepo) Search(ctx context.Context, embedding []float64, topK int, threshold float64) ([]document.Chunk, error) {
-	cursor, err := r.collection.Find(ctx, bson.M{})
+func (r *ChunkRepo) SetHiddenByDocumentID(ctx context.Context, documentID string, hidden bool) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateMany(ctx, bson.M{"document_id": documentID}, bson.M{"$set": bson.M{"hidden": hidden}})
+		return err
+	})
+}
+
+func (r *ChunkRepo) SyncHidden(ctx context.Context, hiddenDocumentIDs []string) error {
+	if hiddenDocumentIDs == nil {
+		hiddenDocumentIDs = []string{}
+	}
+
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateMany(ctx,
+			bson.M{"document_id": bson.M{"$in": hiddenDocumentIDs}, "hidden": bson.M{"$ne": true}},
+			bson.M{"$set": bson.M{"hidden": true}},
+		)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateMany(ctx,
+			bson.M{"document_id": bson.M{"$nin": hiddenDocumentIDs}, "hidden": true},
+			bson.M{"$set": bson.M{"hidden": false}},
+		)
+		return err
+	})
+}
+
+func (r *ChunkRepo) Search(ctx context.Context, space document.EmbeddingSpace, embedding []float64, topK int, threshold float64) ([]document.Chunk, error) {
+	field, err := r.vectorField(ctx, space)
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = cursor.Close(ctx) }()
+	skip := stagedField
+	if field == stagedField {
+		skip = "embedding"
+	}
 
-	var allChunks []document.Chunk
-	if err := cursor.All(ctx, &allChunks); err != nil {
+	var allChunks []stagedChunk
+	if err := r.retry.findAll(ctx, r.collection, spaceFilter(space), &allChunks, options.Find().SetProjection(bson.M{skip: 0})); err != nil {
 		return nil, err
 	}
 
@@ -82,14 +224,247 @@ func (r *ChunkRepo) Search(ctx context.Context, embedding []float64, topK int, t
 	vectors := make([][]float64, len(allChunks))
 	for i, chunk := range allChunks {
 		vectors[i] = chunk.Embedding
+		if field == stagedField {
+			vectors[i] = chunk.Staged
+		}
 	}
 
 	topResults := vectormath.TopKBySimilarity(embedding, vectors, topK, threshold)
 
 	results := make([]document.Chunk, len(topResults))
 	for i, scored := range topResults {
-		results[i] = allChunks[scored.Index]
+		results[i] = allChunks[scored.Index].Chunk
+		results[i].Embedding = vectors[scored.Index]
+		results[i].Score = scored.Score
 	}
 
 	return results, nil
 }
+
+// stagedChunk is a chunk with its staged vector.
+// vectorField names the field holding space's vectors.
+func (r *ChunkRepo) vectorField(ctx context.Context, space document.EmbeddingSpace) (string, error) {
+	if space.Collection != "" {
+		return "embedding", nil
+	}
+	index, err := r.EmbeddingIndex(ctx)
+	if err != nil {
+		return "", err
+	}
+	// While a promotion copies the staged vectors into place, only the
+	// staged ones are all from the new model.
+	if index.Promoting && space.Model == index.ActiveModel {
+		return stagedField, nil
+	}
+	return "embedding", nil
+}
+
+func (r *ChunkRepo) ListVectors(ctx context.Context, space document.EmbeddingSpace) ([]document.Chunk, error) {
+	field, err := r.vectorField(ctx, space)
+	if err != nil {
+		return nil, err
+	}
+	filter := spaceFilter(space)
+	filter[field] = bson.M{"$exists": true}
+	opts := options.Find().SetProjection(bson.M{"document_id": 1, "chunk_index": 1, field: 1})
+
+	var listed []stagedChunk
+	if err := r.retry.findAll(ctx, r.collection, filter, &listed, opts); err != nil {
+		return nil, err
+	}
+	chunks := make([]document.Chunk, len(listed))
+	for i, chunk := range listed {
+		chunks[i] = chunk.Chunk
+		if field == stagedField {
+			chunks[i].Embedding = chunk.Staged
+		}
+	}
+	return chunks, nil
+}
+
+func (r *ChunkRepo) Sample(ctx context.Context, space document.EmbeddingSpace, size int) ([]document.Chunk, error) {
+	field, err := r.vectorField(ctx, space)
+	if err != nil {
+		return nil, err
+	}
+	match := spaceFilter(space)
+	match[field] = bson.M{"$exists": true}
+
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$sample": bson.M{"size": size}},
+		{"$project": bson.M{"content": 0, "table": 0}},
+	}
+	var sampled []stagedChunk
+	err = r.retry.read(ctx, func(ctx context.Context) error {
+		cursor, err := r.collection.Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = cursor.Close(ctx) }()
+		return cursor.All(ctx, &sampled)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make([]document.Chunk, len(sampled))
+	for i, chunk := range sampled {
+		chunks[i] = chunk.Chunk
+		if field == stagedField {
+			chunks[i].Embedding = chunk.Staged
+		}
+	}
+	return chunks, nil
+}
+
+type stagedChunk struct {
+	document.Chunk `bson:",inline"`
+	Staged         []float64 `bson:"staged_embedding,omitempty"`
+}
+
+func (r *ChunkRepo) EmbeddingIndex(ctx context.Context) (*document.EmbeddingIndex, error) {
+	var index document.EmbeddingIndex
+	err := r.retry.findOne(ctx, r.index, bson.M{"_id": embeddingIndexID}, &index)
+	if err == mongo.ErrNoDocuments {
+		return &document.EmbeddingIndex{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &index, nil
+}
+
+func (r *ChunkRepo) StageModel(ctx context.Context, active, model string) error {
+	index, err := r.EmbeddingIndex(ctx)
+	if err != nil {
+		return err
+	}
+	if index.StagedModel != model {
+		err := r.retry.write(ctx, func(ctx context.Context) error {
+			_, err := r.collection.UpdateMany(ctx, bson.M{stagedField: bson.M{"$exists": true}}, bson.M{"$unset": bson.M{stagedField: ""}})
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if index.ActiveModel != "" {
+		active = index.ActiveModel
+	}
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.index.UpdateOne(ctx, bson.M{"_id": embeddingIndexID},
+			bson.M{"$set": bson.M{"active_model": active, "staged_model": model, "promoting": false}},
+			options.Update().SetUpsert(true))
+		return err
+	})
+}
+
+func (r *ChunkRepo) ListUnstaged(ctx context.Context, limit int) ([]document.Chunk, error) {
+	opts := options.Find().SetLimit(int64(limit)).SetProjection(bson.M{"embedding": 0})
+	chunks := []document.Chunk{}
+	if err := r.retry.findAll(ctx, r.collection, unstaged(), &chunks, opts); err != nil {
+		return nil, err
+	}
+	return chunks, nil
+}
+
+func (r *ChunkRepo) CountUnstaged(ctx context.Context) (int64, error) {
+	return r.retry.count(ctx, r.collection, unstaged())
+}
+
+func (r *ChunkRepo) SetStaged(ctx context.Context, vectors map[string][]float64) error {
+	if len(vectors) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, 0, len(vectors))
+	for id, vector := range vectors {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id}).
+			SetUpdate(bson.M{"$set": bson.M{stagedField: vector}}))
+	}
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		return err
+	})
+}
+
+func (r *ChunkRepo) ActivateStaged(ctx context.Context) error {
+	index, err := r.EmbeddingIndex(ctx)
+	if err != nil {
+		return err
+	}
+	if !index.Promoting {
+		if index.StagedModel == "" {
+			return nil
+		}
+		err := r.retry.write(ctx, func(ctx context.Context) error {
+			_, err := r.index.UpdateOne(ctx, bson.M{"_id": embeddingIndexID},
+				bson.M{"$set": bson.M{"active_model": index.StagedModel, "promoting": true}})
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	err = r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateMany(ctx, bson.M{stagedField: bson.M{"$exists": true}},
+			mongo.Pipeline{{{Key: "$set", Value: bson.M{
+				"embedding":       "$" + stagedField,
+				"embedding_model": bson.M{"$literal": index.StagedModel},
+				"dimensions":      bson.M{"$size": "$" + stagedField},
+			}}}})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	err = r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.index.UpdateOne(ctx, bson.M{"_id": embeddingIndexID},
+			bson.M{"$set": bson.M{"staged_model": "", "promoting": false}})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateMany(ctx, bson.M{stagedField: bson.M{"$exists": true}}, bson.M{"$unset": bson.M{stagedField: ""}})
+		return err
+	})
+}
+
+func (r *ChunkRepo) ListOrphanDocumentIDs(ctx context.Context) ([]string, error) {
+	pipeline := []bson.M{
+		{"$group": bson.M{"_id": "$document_id"}},
+		{"$lookup": bson.M{
+			"from":         "documents",
+			"localField":   "_id",
+			"foreignField": "_id",
+			"pipeline":     bson.A{bson.M{"$project": bson.M{"_id": 1}}},
+			"as":           "document",
+		}},
+		{"$match": bson.M{"document": bson.M{"$size": 0}}},
+	}
+
+	var rows []struct {
+		ID string `bson:"_id"`
+	}
+	err := r.retry.read(ctx, func(ctx context.Context) error {
+		cursor, err := r.collection.Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = cursor.Close(ctx) }()
+		return cursor.All(ctx, &rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	return ids, nil
+}
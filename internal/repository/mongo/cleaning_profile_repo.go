@@ -0,0 +1,85 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CleaningProfileRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewCleaningProfileRepo(client *DbClient) *CleaningProfileRepo {
+	return &CleaningProfileRepo{
+		collection: client.DB.Collection("cleaning_profiles"),
+		retry:      client.retry,
+	}
+}
+
+func (r *CleaningProfileRepo) Create(ctx context.Context, profile *document.CleaningProfile) (string, error) {
+	profile.CreatedAt = time.Now()
+	profile.UpdatedAt = time.Now()
+
+	if profile.ID == "" {
+		profile.ID = primitive.NewObjectID().Hex()
+	}
+
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, profile)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return profile.ID, nil
+}
+
+func (r *CleaningProfileRepo) GetByID(ctx context.Context, id string) (*document.CleaningProfile, error) {
+	var profile document.CleaningProfile
+	err := r.retry.findOne(ctx, r.collection, bson.M{"_id": id}, &profile)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (r *CleaningProfileRepo) List(ctx context.Context) ([]document.CleaningProfile, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+	var profiles []document.CleaningProfile
+	if err := r.retry.findAll(ctx, r.collection, bson.M{}, &profiles, opts); err != nil {
+		return nil, err
+	}
+
+	if profiles == nil {
+		profiles = []document.CleaningProfile{}
+	}
+
+	return profiles, nil
+}
+
+func (r *CleaningProfileRepo) Update(ctx context.Context, profile *document.CleaningProfile) error {
+	profile.UpdatedAt = time.Now()
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": profile.ID}, profile)
+		return err
+	})
+}
+
+func (r *CleaningProfileRepo) Delete(ctx context.Context, id string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+		return err
+	})
+}
@@ -6,22 +6,67 @@ import (
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 type DbClient struct {
 	client *mongo.Client
 	DB     *mongo.Database
+	retry  retrier
+	// readYourWrites sends conversation reads to the primary.
+	readYourWrites bool
 }
 
-func NewClient(ctx context.Context, uri, dbName string) (*DbClient, error) {
-	mc, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+// ClientOptions tune how the repositories talk to the database.
+type ClientOptions struct {
+	// RetryWindow is how long transient errors, such as a primary
+	// stepping down, are retried; zero disables this.
+	RetryWindow time.Duration
+	// ReadPreference, such as "secondaryPreferred", overrides the one in
+	// the URI when set.
+	ReadPreference string
+	// ReadYourWrites keeps conversation and message reads on the primary
+	// whatever the read preference, so a message shows up in the request
+	// right after the one that saved it.
+	ReadYourWrites bool
+}
+
+func NewClient(ctx context.Context, uri, dbName string, opts ClientOptions) (*DbClient, error) {
+	clientOpts := options.Client().ApplyURI(uri)
+	if opts.ReadPreference != "" {
+		mode, err := readpref.ModeFromString(opts.ReadPreference)
+		if err != nil {
+			return nil, err
+		}
+		rp, err := readpref.New(mode)
+		if err != nil {
+			return nil, err
+		}
+		clientOpts.SetReadPreference(rp)
+	}
+
+	mc, err := mongo.Connect(ctx, clientOpts)
 	if err != nil {
 		return nil, err
 	}
 	if err := mc.Ping(ctx, nil); err != nil {
 		return nil, err
 	}
-	return &DbClient{client: mc, DB: mc.Database(dbName)}, nil
+	return &DbClient{
+		client:         mc,
+		DB:             mc.Database(dbName),
+		retry:          retrier{window: opts.RetryWindow},
+		readYourWrites: opts.ReadYourWrites,
+	}, nil
+}
+
+// conversationCollection returns a conversation data collection, reading
+// from the primary when read-your-writes is on.
+func (c *DbClient) conversationCollection(name string) *mongo.Collection {
+	if !c.readYourWrites {
+		return c.DB.Collection(name)
+	}
+	return c.DB.Collection(name, options.Collection().SetReadPreference(readpref.Primary()))
 }
 
 func (c *DbClient) Ping(ctx context.Context) error {
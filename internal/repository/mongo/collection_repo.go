@@ -0,0 +1,59 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CollectionRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewCollectionRepo(client *DbClient) *CollectionRepo {
+	return &CollectionRepo{
+		collection: client.DB.Collection("document_collections"),
+		retry:      client.retry,
+	}
+}
+
+func (r *CollectionRepo) Get(ctx context.Context, name string) (*document.Collection, error) {
+	var c document.Collection
+	err := r.retry.findOne(ctx, r.collection, bson.M{"_id": name}, &c)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *CollectionRepo) List(ctx context.Context) ([]document.Collection, error) {
+	collections := []document.Collection{}
+	if err := r.retry.findAll(ctx, r.collection, bson.M{}, &collections, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})); err != nil {
+		return nil, err
+	}
+	return collections, nil
+}
+
+func (r *CollectionRepo) Upsert(ctx context.Context, c *document.Collection) error {
+	c.UpdatedAt = time.Now()
+
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": c.Name}, c, options.Replace().SetUpsert(true))
+		return err
+	})
+}
+
+func (r *CollectionRepo) Delete(ctx context.Context, name string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.DeleteOne(ctx, bson.M{"_id": name})
+		return err
+	})
+}
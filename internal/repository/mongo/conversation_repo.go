@@ -2,6 +2,7 @@ package mongo
 
 import (
 	"context"
+	"regexp"
 	"time"
 
 	"github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
@@ -13,11 +14,13 @@ import (
 
 type ConversationRepo struct {
 	collection *mongo.Collection
+	retry      retrier
 }
 
 func NewConversationRepo(client *DbClient) *ConversationRepo {
 	return &ConversationRepo{
-		collection: client.DB.Collection("conversations"),
+		collection: client.conversationCollection("conversations"),
+		retry:      client.retry,
 	}
 }
 
@@ -30,7 +33,10 @@ func (r *ConversationRepo) Create(ctx context.Context, conv *conversation.Conver
 		conv.ID = primitive.NewObjectID().Hex()
 	}
 
-	_, err := r.collection.InsertOne(ctx, conv)
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, conv)
+		return err
+	})
 	if err != nil {
 		return "", err
 	}
@@ -40,7 +46,7 @@ func (r *ConversationRepo) Create(ctx context.Context, conv *conversation.Conver
 
 func (r *ConversationRepo) GetByID(ctx context.Context, id string) (*conversation.Conversation, error) {
 	var conv conversation.Conversation
-	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&conv)
+	err := r.retry.findOne(ctx, r.collection, bson.M{"_id": id}, &conv)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil
@@ -52,7 +58,19 @@ func (r *ConversationRepo) GetByID(ctx context.Context, id string) (*conversatio
 
 func (r *ConversationRepo) GetByPhoneNumber(ctx context.Context, phoneNumber string) (*conversation.Conversation, error) {
 	var conv conversation.Conversation
-	err := r.collection.FindOne(ctx, bson.M{"phone_number": phoneNumber}).Decode(&conv)
+	err := r.retry.findOne(ctx, r.collection, bson.M{"phone_number": phoneNumber}, &conv)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &conv, nil
+}
+
+func (r *ConversationRepo) GetByExternalID(ctx context.Context, channel, externalID string) (*conversation.Conversation, error) {
+	var conv conversation.Conversation
+	err := r.retry.findOne(ctx, r.collection, bson.M{"channel": channel, "external_id": externalID}, &conv)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil
@@ -68,14 +86,8 @@ func (r *ConversationRepo) List(ctx context.Context, limit, offset int) ([]conve
 		SetSkip(int64(offset)).
 		SetSort(bson.D{{Key: "last_message_at", Value: -1}})
 
-	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
-	if err != nil {
-		return nil, err
-	}
-	defer func() { _ = cursor.Close(ctx) }()
-
 	var convs []conversation.Conversation
-	if err := cursor.All(ctx, &convs); err != nil {
+	if err := r.retry.findAll(ctx, r.collection, bson.M{}, &convs, opts); err != nil {
 		return nil, err
 	}
 
@@ -87,33 +99,130 @@ func (r *ConversationRepo) List(ctx context.Context, limit, offset int) ([]conve
 }
 
 func (r *ConversationRepo) UpdateLastMessage(ctx context.Context, id string) error {
-	_, err := r.collection.UpdateOne(
-		ctx,
-		bson.M{"_id": id},
-		bson.M{
-			"$set": bson.M{
-				"last_message_at": time.Now(),
-				"updated_at":      time.Now(),
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(
+			ctx,
+			bson.M{"_id": id},
+			bson.M{
+				"$set": bson.M{
+					"last_message_at": time.Now(),
+					"updated_at":      time.Now(),
+				},
 			},
-		},
-	)
-	return err
+		)
+		return err
+	})
+}
+
+func (r *ConversationRepo) UpdateSummary(ctx context.Context, id, summary string, through time.Time) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(
+			ctx,
+			bson.M{"_id": id},
+			bson.M{
+				"$set": bson.M{
+					"summary":         summary,
+					"summary_through": through,
+					"updated_at":      time.Now(),
+				},
+			},
+		)
+		return err
+	})
+}
+
+func (r *ConversationRepo) SetOnboarding(ctx context.Context, id string, from, to conversation.OnboardingState) (bool, error) {
+	update := bson.M{"$set": bson.M{"onboarding": to, "updated_at": time.Now()}}
+	if to == "" {
+		update = bson.M{"$unset": bson.M{"onboarding": ""}, "$set": bson.M{"updated_at": time.Now()}}
+	}
+
+	var matched bool
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "onboarding": from}, update)
+		if err != nil {
+			return err
+		}
+		matched = result.MatchedCount > 0
+		return nil
+	})
+	return matched, err
+}
+
+func (r *ConversationRepo) SetConsent(ctx context.Context, id string, consent conversation.Consent) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(
+			ctx,
+			bson.M{"_id": id},
+			bson.M{"$set": bson.M{"consent": consent, "updated_at": time.Now()}},
+		)
+		return err
+	})
+}
+
+func (r *ConversationRepo) UpdateVariables(ctx context.Context, id string, variables map[string]string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(
+			ctx,
+			bson.M{"_id": id},
+			bson.M{
+				"$set": bson.M{
+					"variables":  variables,
+					"updated_at": time.Now(),
+				},
+			},
+		)
+		return err
+	})
+}
+
+func (r *ConversationRepo) SetTags(ctx context.Context, id string, tags []string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(
+			ctx,
+			bson.M{"_id": id},
+			bson.M{"$set": bson.M{"tags": tags, "updated_at": time.Now()}},
+		)
+		return err
+	})
+}
+
+func (r *ConversationRepo) AddTags(ctx context.Context, id string, tags []string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(
+			ctx,
+			bson.M{"_id": id},
+			bson.M{
+				"$addToSet": bson.M{"tags": bson.M{"$each": tags}},
+				"$set":      bson.M{"updated_at": time.Now()},
+			},
+		)
+		return err
+	})
+}
+
+func (r *ConversationRepo) RenameTag(ctx context.Context, from, to string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		return renameTag(ctx, r.collection, from, to)
+	})
 }
 
 func (r *ConversationRepo) IncrementMessageCount(ctx context.Context, id string) error {
-	_, err := r.collection.UpdateOne(
-		ctx,
-		bson.M{"_id": id},
-		bson.M{
-			"$inc": bson.M{"message_count": 1},
-			"$set": bson.M{"updated_at": time.Now()},
-		},
-	)
-	return err
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(
+			ctx,
+			bson.M{"_id": id},
+			bson.M{
+				"$inc": bson.M{"message_count": 1},
+				"$set": bson.M{"updated_at": time.Now()},
+			},
+		)
+		return err
+	})
 }
 
 func (r *ConversationRepo) Count(ctx context.Context) (int64, error) {
-	return r.collection.CountDocuments(ctx, bson.M{})
+	return r.retry.count(ctx, r.collection, bson.M{})
 }
 
 func (r *ConversationRepo) ListByUser(ctx context.Context, userID string, limit, offset int) ([]conversation.Conversation, error) {
@@ -122,14 +231,8 @@ func (r *ConversationRepo) ListByUser(ctx context.Context, userID string, limit,
 		SetSkip(int64(offset)).
 		SetSort(bson.D{{Key: "last_message_at", Value: -1}})
 
-	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
-	if err != nil {
-		return nil, err
-	}
-	defer func() { _ = cursor.Close(ctx) }()
-
 	var convs []conversation.Conversation
-	if err := cursor.All(ctx, &convs); err != nil {
+	if err := r.retry.findAll(ctx, r.collection, bson.M{"user_id": userID}, &convs, opts); err != nil {
 		return nil, err
 	}
 
@@ -141,5 +244,277 @@ func (r *ConversationRepo) ListByUser(ctx context.Context, userID string, limit,
 }
 
 func (r *ConversationRepo) CountByUser(ctx context.Context, userID string) (int64, error) {
-	return r.collection.CountDocuments(ctx, bson.M{"user_id": userID})
+	return r.retry.count(ctx, r.collection, bson.M{"user_id": userID})
+}
+
+func (r *ConversationRepo) Search(ctx context.Context, filter conversation.ConversationFilter) ([]conversation.Conversation, int64, error) {
+	query := searchQuery(filter)
+	if filter.State != "" {
+		query["state"] = stateQuery(filter.State)
+	}
+
+	total, err := r.retry.count(ctx, r.collection, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetLimit(int64(filter.Limit)).
+		SetSkip(int64(filter.Offset)).
+		SetSort(bson.D{{Key: "last_message_at", Value: -1}})
+
+	var convs []conversation.Conversation
+	if err := r.retry.findAll(ctx, r.collection, query, &convs, opts); err != nil {
+		return nil, 0, err
+	}
+
+	if convs == nil {
+		convs = []conversation.Conversation{}
+	}
+
+	return convs, total, nil
+}
+
+func (r *ConversationRepo) SetState(ctx context.Context, id string, state conversation.State) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(
+			ctx,
+			bson.M{"_id": id},
+			bson.M{"$set": bson.M{"state": state, "state_changed_at": time.Now(), "updated_at": time.Now()}},
+		)
+		return err
+	})
+}
+
+func (r *ConversationRepo) CloseInactive(ctx context.Context, before time.Time) (int64, error) {
+	var closed int64
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		result, err := r.collection.UpdateMany(
+			ctx,
+			bson.M{"last_message_at": bson.M{"$lt": before}, "state": bson.M{"$ne": conversation.StateClosed}},
+			bson.M{"$set": bson.M{"state": conversation.StateClosed, "state_changed_at": time.Now(), "updated_at": time.Now()}},
+		)
+		if err != nil {
+			return err
+		}
+		closed = result.ModifiedCount
+		return nil
+	})
+	return closed, err
+}
+
+// AnonymizeInactive clears the contact's number, name, thread ID, summary
+// and variables. A contact who writes again starts a new conversation.
+func (r *ConversationRepo) AnonymizeInactive(ctx context.Context, before time.Time) (int64, error) {
+	return anonymizeBefore(ctx, r.retry, r.collection, "last_message_at", before,
+		bson.M{"phone_number": "", "contact_name": "", "updated_at": time.Now()},
+		bson.M{"external_id": "", "summary": "", "summary_through": "", "variables": ""},
+	)
+}
+
+func (r *ConversationRepo) RetentionCounts(ctx context.Context, before time.Time) (*conversation.RetentionCounts, error) {
+	return retentionCounts(ctx, r.retry, r.collection, "last_message_at", before)
+}
+
+func (r *ConversationRepo) SetMode(ctx context.Context, id string, mode conversation.Mode, agentID string, sla *conversation.SLA) error {
+	set := bson.M{"mode": mode, "updated_at": time.Now()}
+	update := bson.M{"$set": set}
+	if agentID != "" {
+		set["agent_id"] = agentID
+	} else {
+		update["$unset"] = bson.M{"agent_id": ""}
+	}
+	if sla != nil {
+		set["sla"] = sla
+	}
+
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+		return err
+	})
+}
+
+func (r *ConversationRepo) UpdateSLA(ctx context.Context, id string, sla conversation.SLA) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(
+			ctx,
+			bson.M{"_id": id},
+			bson.M{"$set": bson.M{"sla": sla, "updated_at": time.Now()}},
+		)
+		return err
+	})
+}
+
+func (r *ConversationRepo) FlagSLABreaches(ctx context.Context, now time.Time) (int64, error) {
+	flags := []struct {
+		at, due, breached string
+	}{
+		{"sla.first_response_at", "sla.first_response_due", "sla.first_response_breached"},
+		{"sla.resolved_at", "sla.resolution_due", "sla.resolution_breached"},
+	}
+
+	var flagged int64
+	for _, f := range flags {
+		err := r.retry.write(ctx, func(ctx context.Context) error {
+			result, err := r.collection.UpdateMany(
+				ctx,
+				bson.M{f.at: nil, f.due: bson.M{"$lt": now}, f.breached: false},
+				bson.M{"$set": bson.M{f.breached: true, "updated_at": time.Now()}},
+			)
+			if err != nil {
+				return err
+			}
+			flagged += result.ModifiedCount
+			return nil
+		})
+		if err != nil {
+			return flagged, err
+		}
+	}
+	return flagged, nil
+}
+
+func (r *ConversationRepo) SLAByAgent(ctx context.Context, from, to time.Time) ([]conversation.AgentSLA, error) {
+	seconds := func(end string) bson.M {
+		return bson.M{"$cond": bson.A{
+			bson.M{"$gt": bson.A{end, nil}},
+			bson.M{"$divide": bson.A{bson.M{"$subtract": bson.A{end, "$sla.started_at"}}, 1000}},
+			nil,
+		}}
+	}
+	count := func(cond any) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{cond, 1, 0}}}
+	}
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"sla.started_at": bson.M{"$gte": from, "$lte": to}}},
+		{"$group": bson.M{
+			"_id":                        "$sla.agent_id",
+			"conversations":              bson.M{"$sum": 1},
+			"responded":                  count(bson.M{"$gt": bson.A{"$sla.first_response_at", nil}}),
+			"resolved":                   count(bson.M{"$gt": bson.A{"$sla.resolved_at", nil}}),
+			"avg_first_response_seconds": bson.M{"$avg": seconds("$sla.first_response_at")},
+			"avg_resolution_seconds":     bson.M{"$avg": seconds("$sla.resolved_at")},
+			"first_response_breaches":    count("$sla.first_response_breached"),
+			"resolution_breaches":        count("$sla.resolution_breached"),
+			"breached":                   count(bson.M{"$or": bson.A{"$sla.first_response_breached", "$sla.resolution_breached"}}),
+		}},
+		{"$sort": bson.M{"_id": 1}},
+	}
+
+	agents := []conversation.AgentSLA{}
+	err := r.retry.read(ctx, func(ctx context.Context) error {
+		cursor, err := r.collection.Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = cursor.Close(ctx) }()
+		return cursor.All(ctx, &agents)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return agents, nil
+}
+
+func (r *ConversationRepo) CountByState(ctx context.Context, filter conversation.ConversationFilter) (map[conversation.State]int64, error) {
+	pipeline := []bson.M{
+		{"$match": searchQuery(filter)},
+		{"$group": bson.M{
+			"_id":   bson.M{"$ifNull": bson.A{"$state", conversation.StateOpen}},
+			"count": bson.M{"$sum": 1},
+		}},
+	}
+
+	var results []struct {
+		State conversation.State `bson:"_id"`
+		Count int64              `bson:"count"`
+	}
+	err := r.retry.read(ctx, func(ctx context.Context) error {
+		cursor, err := r.collection.Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = cursor.Close(ctx) }()
+		return cursor.All(ctx, &results)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[conversation.State]int64, len(results))
+	for _, result := range results {
+		counts[result.State] = result.Count
+	}
+	return counts, nil
+}
+
+func (r *ConversationRepo) Delete(ctx context.Context, id string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+		return err
+	})
+}
+
+// searchQuery matches the conversations filter selects, apart from its
+// state.
+func searchQuery(filter conversation.ConversationFilter) bson.M {
+	query := bson.M{}
+
+	if filter.UserID != "" {
+		query["user_id"] = filter.UserID
+	}
+	if filter.Channel != "" {
+		if filter.Channel == conversation.ChannelWhatsApp {
+			// Conversations created before channels were tracked are WhatsApp.
+			query["channel"] = bson.M{"$in": bson.A{filter.Channel, nil}}
+		} else {
+			query["channel"] = filter.Channel
+		}
+	}
+	if filter.Mode == conversation.ModeBot {
+		query["mode"] = bson.M{"$in": bson.A{filter.Mode, nil}}
+	} else if filter.Mode != "" {
+		query["mode"] = filter.Mode
+	}
+	if len(filter.Tags) > 0 {
+		query["tags"] = bson.M{"$all": filter.Tags}
+	}
+	if filter.SLABreached {
+		query["$and"] = bson.A{bson.M{"$or": bson.A{
+			bson.M{"sla.first_response_breached": true},
+			bson.M{"sla.resolution_breached": true},
+		}}}
+	}
+	if !filter.StartTime.IsZero() || !filter.EndTime.IsZero() {
+		bounds := bson.M{}
+		if !filter.StartTime.IsZero() {
+			bounds["$gte"] = filter.StartTime
+		}
+		if !filter.EndTime.IsZero() {
+			bounds["$lte"] = filter.EndTime
+		}
+		query["last_message_at"] = bounds
+	}
+	if filter.Query != "" {
+		pattern := bson.M{"$regex": regexp.QuoteMeta(filter.Query), "$options": "i"}
+		or := bson.A{
+			bson.M{"contact_name": pattern},
+			bson.M{"phone_number": pattern},
+		}
+		if len(filter.ConversationIDs) > 0 {
+			or = append(or, bson.M{"_id": bson.M{"$in": filter.ConversationIDs}})
+		}
+		query["$or"] = or
+	}
+	return query
+}
+
+// stateQuery matches conversations in state. Conversations created before
+// states were tracked are open.
+func stateQuery(state conversation.State) any {
+	if state == conversation.StateOpen {
+		return bson.M{"$in": bson.A{state, nil}}
+	}
+	return state
 }
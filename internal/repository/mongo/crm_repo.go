@@ -0,0 +1,71 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/crm"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CRMRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewCRMRepo(client *DbClient) *CRMRepo {
+	return &CRMRepo{
+		collection: client.DB.Collection("crm_connections"),
+		retry:      client.retry,
+	}
+}
+
+func (r *CRMRepo) Get(ctx context.Context, provider crm.Provider) (*crm.Connection, error) {
+	var conn crm.Connection
+	err := r.retry.findOne(ctx, r.collection, bson.M{"_id": provider}, &conn)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &conn, nil
+}
+
+func (r *CRMRepo) List(ctx context.Context) ([]crm.Connection, error) {
+	var conns []crm.Connection
+	if err := r.retry.findAll(ctx, r.collection, bson.M{}, &conns); err != nil {
+		return nil, err
+	}
+	return conns, nil
+}
+
+func (r *CRMRepo) Upsert(ctx context.Context, conn *crm.Connection) error {
+	conn.UpdatedAt = time.Now()
+
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": conn.Provider}, conn, options.Replace().SetUpsert(true))
+		return err
+	})
+}
+
+func (r *CRMRepo) Delete(ctx context.Context, provider crm.Provider) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.DeleteOne(ctx, bson.M{"_id": provider})
+		return err
+	})
+}
+
+func (r *CRMRepo) RecordSync(ctx context.Context, provider crm.Provider, at time.Time, syncErr string) error {
+	set := bson.M{"last_error": syncErr}
+	// A failed sync is retried from the same point.
+	if syncErr == "" {
+		set["last_sync_at"] = at
+	}
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(ctx, bson.M{"_id": provider}, bson.M{"$set": set})
+		return err
+	})
+}
@@ -0,0 +1,45 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/user"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// knownDeviceRetention is how long a device unused for stays known; signing
+// in from it afterwards alerts again.
+const knownDeviceRetention = 180 * 24 * time.Hour
+
+type DeviceRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewDeviceRepo(client *DbClient) *DeviceRepo {
+	return &DeviceRepo{
+		collection: client.DB.Collection("known_devices"),
+		retry:      client.retry,
+	}
+}
+
+func (r *DeviceRepo) Seen(ctx context.Context, d *user.KnownDevice, at time.Time) (bool, error) {
+	filter := bson.M{"user_id": d.UserID, "fingerprint": d.Fingerprint}
+	update := bson.M{
+		"$set":         bson.M{"user_agent": d.UserAgent, "ip": d.IP, "last_seen_at": at},
+		"$setOnInsert": bson.M{"first_seen_at": at},
+	}
+	var created bool
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		res, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+		if err != nil {
+			return err
+		}
+		created = res.UpsertedCount > 0
+		return nil
+	})
+	return created, err
+}
@@ -13,11 +13,13 @@ import (
 
 type DocumentRepo struct {
 	collection *mongo.Collection
+	retry      retrier
 }
 
 func NewDocumentRepo(client *DbClient) *DocumentRepo {
 	return &DocumentRepo{
 		collection: client.DB.Collection("documents"),
+		retry:      client.retry,
 	}
 }
 
@@ -25,12 +27,16 @@ func (r *DocumentRepo) Create(ctx context.Context, doc *document.Document) (stri
 	doc.UploadedAt = time.Now()
 	doc.UpdatedAt = time.Now()
 	doc.IsActive = true
+	doc.Version = 1
 
 	if doc.ID == "" {
 		doc.ID = primitive.NewObjectID().Hex()
 	}
 
-	_, err := r.collection.InsertOne(ctx, doc)
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, doc)
+		return err
+	})
 	if err != nil {
 		return "", err
 	}
@@ -40,7 +46,7 @@ func (r *DocumentRepo) Create(ctx context.Context, doc *document.Document) (stri
 
 func (r *DocumentRepo) GetByID(ctx context.Context, id string) (*document.Document, error) {
 	var doc document.Document
-	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&doc)
+	err := r.retry.findOne(ctx, r.collection, bson.M{"_id": id}, &doc)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil
@@ -56,14 +62,8 @@ func (r *DocumentRepo) List(ctx context.Context, limit, offset int) ([]document.
 		SetSkip(int64(offset)).
 		SetSort(bson.D{{Key: "uploaded_at", Value: -1}})
 
-	cursor, err := r.collection.Find(ctx, bson.M{"is_active": true}, opts)
-	if err != nil {
-		return nil, err
-	}
-	defer func() { _ = cursor.Close(ctx) }()
-
 	var docs []document.Document
-	if err := cursor.All(ctx, &docs); err != nil {
+	if err := r.retry.findAll(ctx, r.collection, bson.M{"is_active": true}, &docs, opts); err != nil {
 		return nil, err
 	}
 
@@ -74,28 +74,45 @@ func (r *DocumentRepo) List(ctx context.Context, limit, offset int) ([]document.
 	return docs, nil
 }
 
-func (r *DocumentRepo) Update(ctx context.Context, doc *document.Document) error {
+func (r *DocumentRepo) Update(ctx context.Context, doc *document.Document) (bool, error) {
+	filter := bson.M{"_id": doc.ID, "version": doc.Version}
+	if doc.Version == 0 {
+		// Documents stored before versioning have no version field.
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	}
+	expected := doc.Version
+	doc.Version++
 	doc.UpdatedAt = time.Now()
 
-	_, err := r.collection.UpdateOne(
-		ctx,
-		bson.M{"_id": doc.ID},
-		bson.M{"$set": doc},
-	)
-	return err
+	var matched bool
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": doc})
+		if err != nil {
+			return err
+		}
+		matched = result.MatchedCount > 0
+		return nil
+	})
+	if err != nil || !matched {
+		doc.Version = expected
+		return false, err
+	}
+	return true, nil
 }
 
 func (r *DocumentRepo) Delete(ctx context.Context, id string) error {
-	_, err := r.collection.UpdateOne(
-		ctx,
-		bson.M{"_id": id},
-		bson.M{"$set": bson.M{"is_active": false, "updated_at": time.Now()}},
-	)
-	return err
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(
+			ctx,
+			bson.M{"_id": id},
+			bson.M{"$set": bson.M{"is_active": false, "updated_at": time.Now()}},
+		)
+		return err
+	})
 }
 
 func (r *DocumentRepo) Count(ctx context.Context) (int64, error) {
-	return r.collection.CountDocuments(ctx, bson.M{"is_active": true})
+	return r.retry.count(ctx, r.collection, bson.M{"is_active": true})
 }
 
 func (r *DocumentRepo) ListByUser(ctx context.Context, userID string, limit, offset int) ([]document.Document, error) {
@@ -104,14 +121,107 @@ func (r *DocumentRepo) ListByUser(ctx context.Context, userID string, limit, off
 		SetSkip(int64(offset)).
 		SetSort(bson.D{{Key: "uploaded_at", Value: -1}})
 
-	cursor, err := r.collection.Find(ctx, bson.M{"is_active": true, "user_id": userID}, opts)
+	var docs []document.Document
+	if err := r.retry.findAll(ctx, r.collection, bson.M{"is_active": true, "user_id": userID}, &docs, opts); err != nil {
+		return nil, err
+	}
+
+	if docs == nil {
+		docs = []document.Document{}
+	}
+
+	return docs, nil
+}
+
+func (r *DocumentRepo) CountByUser(ctx context.Context, userID string) (int64, error) {
+	return r.retry.count(ctx, r.collection, bson.M{"is_active": true, "user_id": userID})
+}
+
+func (r *DocumentRepo) ListFiltered(ctx context.Context, filter document.DocumentFilter, limit, offset int) ([]document.Document, int64, error) {
+	query := bson.M{"is_active": true}
+	if filter.UserID != "" {
+		query["user_id"] = filter.UserID
+	}
+	if filter.Author != "" {
+		query["author"] = filter.Author
+	}
+	if filter.Language != "" {
+		query["language"] = filter.Language
+	}
+	if filter.Department != "" {
+		query["department"] = filter.Department
+	}
+	if !filter.EffectiveOn.IsZero() {
+		query["$and"] = bson.A{
+			bson.M{"$or": bson.A{bson.M{"effective_at": nil}, bson.M{"effective_at": bson.M{"$lte": filter.EffectiveOn}}}},
+			bson.M{"$or": bson.A{bson.M{"expires_at": nil}, bson.M{"expires_at": bson.M{"$gt": filter.EffectiveOn}}}},
+		}
+	}
+
+	total, err := r.retry.count(ctx, r.collection, query)
 	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset)).
+		SetSort(bson.D{{Key: "uploaded_at", Value: -1}})
+
+	var docs []document.Document
+	if err := r.retry.findAll(ctx, r.collection, query, &docs, opts); err != nil {
+		return nil, 0, err
+	}
+	if docs == nil {
+		docs = []document.Document{}
+	}
+	return docs, total, nil
+}
+
+func (r *DocumentRepo) ListUnavailableIDs(ctx context.Context, now time.Time) ([]string, error) {
+	filter := bson.M{
+		"is_active": true,
+		"$or": bson.A{
+			bson.M{"publish_at": bson.M{"$gt": now}},
+			bson.M{"expires_at": bson.M{"$lte": now}},
+			bson.M{"status": bson.M{"$in": bson.A{document.StatusDraft, document.StatusReview}}},
+		},
+	}
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+
+	var results []struct {
+		ID string `bson:"_id"`
+	}
+	if err := r.retry.findAll(ctx, r.collection, filter, &results, opts); err != nil {
 		return nil, err
 	}
-	defer func() { _ = cursor.Close(ctx) }()
+
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.ID
+	}
+	return ids, nil
+}
+
+func (r *DocumentRepo) UpdateStatus(ctx context.Context, id string, status document.Status) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(
+			ctx,
+			bson.M{"_id": id},
+			bson.M{"$set": bson.M{"status": status, "updated_at": time.Now()}},
+		)
+		return err
+	})
+}
+
+func (r *DocumentRepo) ListByStatus(ctx context.Context, status document.Status, limit, offset int) ([]document.Document, error) {
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset)).
+		SetSort(bson.D{{Key: "updated_at", Value: 1}})
 
 	var docs []document.Document
-	if err := cursor.All(ctx, &docs); err != nil {
+	if err := r.retry.findAll(ctx, r.collection, bson.M{"is_active": true, "status": status}, &docs, opts); err != nil {
 		return nil, err
 	}
 
@@ -122,6 +232,89 @@ func (r *DocumentRepo) ListByUser(ctx context.Context, userID string, limit, off
 	return docs, nil
 }
 
-func (r *DocumentRepo) CountByUser(ctx context.Context, userID string) (int64, error) {
-	return r.collection.CountDocuments(ctx, bson.M{"is_active": true, "user_id": userID})
+func (r *DocumentRepo) CountByStatus(ctx context.Context, status document.Status) (int64, error) {
+	return r.retry.count(ctx, r.collection, bson.M{"is_active": true, "status": status})
+}
+
+func (r *DocumentRepo) CountByCollection(ctx context.Context, collection string) (int64, error) {
+	return r.retry.count(ctx, r.collection, bson.M{"collection": collection})
+}
+
+func (r *DocumentRepo) ListUnchunked(ctx context.Context, before time.Time, limit int) ([]document.Document, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{
+			"is_active":    true,
+			"content":      bson.M{"$nin": bson.A{"", nil}},
+			"updated_at":   bson.M{"$lt": before},
+			"duplicate_of": bson.M{"$exists": false},
+		}},
+		{"$lookup": bson.M{
+			"from":         "chunks",
+			"localField":   "_id",
+			"foreignField": "document_id",
+			"pipeline":     bson.A{bson.M{"$limit": 1}, bson.M{"$project": bson.M{"_id": 1}}},
+			"as":           "chunks",
+		}},
+		{"$match": bson.M{"chunks": bson.M{"$size": 0}}},
+		{"$limit": limit},
+		{"$project": bson.M{"chunks": 0}},
+	}
+
+	docs := []document.Document{}
+	err := r.retry.read(ctx, func(ctx context.Context) error {
+		cursor, err := r.collection.Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = cursor.Close(ctx) }()
+		return cursor.All(ctx, &docs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+func (r *DocumentRepo) GetByContentHash(ctx context.Context, collection, hash string) (*document.Document, error) {
+	filter := bson.M{
+		"is_active":    true,
+		"content_hash": hash,
+		"collection":   collection,
+		"duplicate_of": bson.M{"$exists": false},
+	}
+	if collection == "" {
+		filter["collection"] = inDefaultSpace
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "uploaded_at", Value: 1}})
+
+	var doc document.Document
+	err := r.retry.findOne(ctx, r.collection, filter, &doc, opts)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func (r *DocumentRepo) ListDuplicates(ctx context.Context, id string) ([]document.Document, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "uploaded_at", Value: 1}})
+
+	docs := []document.Document{}
+	if err := r.retry.findAll(ctx, r.collection, bson.M{"is_active": true, "duplicate_of": id}, &docs, opts); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+func (r *DocumentRepo) SetDuplicateOf(ctx context.Context, id, original string) error {
+	update := bson.M{"$set": bson.M{"duplicate_of": original}}
+	if original == "" {
+		update = bson.M{"$unset": bson.M{"duplicate_of": ""}}
+	}
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+		return err
+	})
 }
@@ -0,0 +1,92 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/email"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type EmailDraftRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewEmailDraftRepo(client *DbClient) *EmailDraftRepo {
+	return &EmailDraftRepo{
+		collection: client.DB.Collection("email_drafts"),
+		retry:      client.retry,
+	}
+}
+
+func (r *EmailDraftRepo) Create(ctx context.Context, d *email.Draft) (string, error) {
+	d.CreatedAt = time.Now()
+	d.UpdatedAt = d.CreatedAt
+
+	if d.ID == "" {
+		d.ID = primitive.NewObjectID().Hex()
+	}
+
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, d)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return d.ID, nil
+}
+
+func (r *EmailDraftRepo) GetByID(ctx context.Context, id string) (*email.Draft, error) {
+	var d email.Draft
+	err := r.retry.findOne(ctx, r.collection, bson.M{"_id": id}, &d)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *EmailDraftRepo) List(ctx context.Context, status email.DraftStatus, limit, offset int) ([]email.Draft, int64, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	total, err := r.retry.count(ctx, r.collection, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset))
+
+	var drafts []email.Draft
+	if err := r.retry.findAll(ctx, r.collection, filter, &drafts, opts); err != nil {
+		return nil, 0, err
+	}
+
+	if drafts == nil {
+		drafts = []email.Draft{}
+	}
+
+	return drafts, total, nil
+}
+
+func (r *EmailDraftRepo) Update(ctx context.Context, d *email.Draft) error {
+	d.UpdatedAt = time.Now()
+
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": d.ID}, d)
+		return err
+	})
+}
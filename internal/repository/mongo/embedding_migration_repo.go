@@ -0,0 +1,66 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type EmbeddingMigrationRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewEmbeddingMigrationRepo(client *DbClient) *EmbeddingMigrationRepo {
+	return &EmbeddingMigrationRepo{
+		collection: client.DB.Collection("embedding_migrations"),
+		retry:      client.retry,
+	}
+}
+
+func (r *EmbeddingMigrationRepo) Create(ctx context.Context, migration *document.EmbeddingMigration) (string, error) {
+	migration.ID = primitive.NewObjectID().Hex()
+	now := time.Now()
+	migration.CreatedAt = now
+	migration.UpdatedAt = now
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, migration)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return migration.ID, nil
+}
+
+func (r *EmbeddingMigrationRepo) Latest(ctx context.Context) (*document.EmbeddingMigration, error) {
+	var migration document.EmbeddingMigration
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	err := r.retry.findOne(ctx, r.collection, bson.M{}, &migration, opts)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &migration, nil
+}
+
+func (r *EmbeddingMigrationRepo) Update(ctx context.Context, migration *document.EmbeddingMigration) (bool, error) {
+	migration.UpdatedAt = time.Now()
+	var result *mongo.UpdateResult
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = r.collection.ReplaceOne(ctx, bson.M{"_id": migration.ID, "status": document.MigrationRunning}, migration)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
@@ -0,0 +1,52 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type FormatProfileRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewFormatProfileRepo(client *DbClient) *FormatProfileRepo {
+	return &FormatProfileRepo{
+		collection: client.DB.Collection("rag_format_profiles"),
+		retry:      client.retry,
+	}
+}
+
+func (r *FormatProfileRepo) Get(ctx context.Context, channel document.Channel) (*document.FormatProfile, error) {
+	var profile document.FormatProfile
+	err := r.retry.findOne(ctx, r.collection, bson.M{"_id": channel}, &profile)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (r *FormatProfileRepo) List(ctx context.Context) ([]document.FormatProfile, error) {
+	var profiles []document.FormatProfile
+	if err := r.retry.findAll(ctx, r.collection, bson.M{}, &profiles); err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+func (r *FormatProfileRepo) Upsert(ctx context.Context, profile *document.FormatProfile) error {
+	profile.UpdatedAt = time.Now()
+
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": profile.Channel}, profile, options.Replace().SetUpsert(true))
+		return err
+	})
+}
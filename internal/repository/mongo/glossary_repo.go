@@ -0,0 +1,85 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type GlossaryRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewGlossaryRepo(client *DbClient) *GlossaryRepo {
+	return &GlossaryRepo{
+		collection: client.DB.Collection("glossary"),
+		retry:      client.retry,
+	}
+}
+
+func (r *GlossaryRepo) Create(ctx context.Context, entry *document.GlossaryEntry) (string, error) {
+	entry.CreatedAt = time.Now()
+	entry.UpdatedAt = time.Now()
+
+	if entry.ID == "" {
+		entry.ID = primitive.NewObjectID().Hex()
+	}
+
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, entry)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return entry.ID, nil
+}
+
+func (r *GlossaryRepo) GetByID(ctx context.Context, id string) (*document.GlossaryEntry, error) {
+	var entry document.GlossaryEntry
+	err := r.retry.findOne(ctx, r.collection, bson.M{"_id": id}, &entry)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *GlossaryRepo) List(ctx context.Context) ([]document.GlossaryEntry, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "term", Value: 1}})
+
+	var entries []document.GlossaryEntry
+	if err := r.retry.findAll(ctx, r.collection, bson.M{}, &entries, opts); err != nil {
+		return nil, err
+	}
+
+	if entries == nil {
+		entries = []document.GlossaryEntry{}
+	}
+
+	return entries, nil
+}
+
+func (r *GlossaryRepo) Update(ctx context.Context, entry *document.GlossaryEntry) error {
+	entry.UpdatedAt = time.Now()
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": entry.ID}, entry)
+		return err
+	})
+}
+
+func (r *GlossaryRepo) Delete(ctx context.Context, id string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+		return err
+	})
+}
@@ -0,0 +1,272 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/system"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// indexPrefix namespaces the names of the indexes this application
+// creates, telling them apart from ones added by hand.
+const indexPrefix = "lucidrag_"
+
+// namespaceNotFound is the error code for a collection that does not
+// exist yet.
+const namespaceNotFound = 26
+
+type indexSpec struct {
+	collection string
+	keys       bson.D
+	unique     bool
+	// ttl, when set, expires documents that long after the indexed time.
+	ttl time.Duration
+}
+
+// indexSpecs are the indexes the repositories' queries rely on.
+var indexSpecs = []indexSpec{
+	{collection: "documents", keys: bson.D{{Key: "user_id", Value: 1}, {Key: "uploaded_at", Value: -1}}},
+	{collection: "documents", keys: bson.D{{Key: "is_active", Value: 1}, {Key: "uploaded_at", Value: -1}}},
+	{collection: "documents", keys: bson.D{{Key: "status", Value: 1}, {Key: "updated_at", Value: 1}}},
+	{collection: "documents", keys: bson.D{{Key: "collection", Value: 1}}},
+	{collection: "documents", keys: bson.D{{Key: "content_hash", Value: 1}}},
+	{collection: "documents", keys: bson.D{{Key: "duplicate_of", Value: 1}}},
+	{collection: "documents", keys: bson.D{{Key: "department", Value: 1}, {Key: "uploaded_at", Value: -1}}},
+	{collection: "chunks", keys: bson.D{{Key: "document_id", Value: 1}, {Key: "chunk_index", Value: 1}}},
+	{collection: "chunks", keys: bson.D{{Key: "collection", Value: 1}, {Key: "embedding_model", Value: 1}}},
+	{collection: "conversations", keys: bson.D{{Key: "phone_number", Value: 1}}},
+	{collection: "conversations", keys: bson.D{{Key: "user_id", Value: 1}, {Key: "last_message_at", Value: -1}}},
+	{collection: "conversations", keys: bson.D{{Key: "channel", Value: 1}, {Key: "external_id", Value: 1}}},
+	{collection: "conversations", keys: bson.D{{Key: "last_message_at", Value: -1}}},
+	{collection: "conversations", keys: bson.D{{Key: "state", Value: 1}, {Key: "last_message_at", Value: -1}}},
+	{collection: "conversations", keys: bson.D{{Key: "sla.started_at", Value: 1}}},
+	{collection: "conversations", keys: bson.D{{Key: "anonymized_at", Value: 1}, {Key: "last_message_at", Value: 1}}},
+	{collection: "conversations", keys: bson.D{{Key: "tags", Value: 1}, {Key: "last_message_at", Value: -1}}},
+	{collection: "canned_responses", keys: bson.D{{Key: "shortcut", Value: 1}}, unique: true},
+	{collection: "glossary", keys: bson.D{{Key: "term", Value: 1}}},
+	{collection: "cleaning_profiles", keys: bson.D{{Key: "name", Value: 1}}},
+	{collection: "conversation_tags", keys: bson.D{{Key: "name", Value: 1}}, unique: true},
+	{collection: "saved_filters", keys: bson.D{{Key: "user_id", Value: 1}, {Key: "name", Value: 1}}},
+	{collection: "assignment_rules", keys: bson.D{{Key: "priority", Value: 1}, {Key: "name", Value: 1}}},
+	{collection: "conversation_assignments", keys: bson.D{{Key: "conversation_id", Value: 1}, {Key: "created_at", Value: 1}}},
+	{collection: "report_schedules", keys: bson.D{{Key: "name", Value: 1}}},
+	{collection: "whatsapp_flows", keys: bson.D{{Key: "name", Value: 1}}},
+	{collection: "booking_resources", keys: bson.D{{Key: "name", Value: 1}}},
+	{collection: "products", keys: bson.D{{Key: "sku", Value: 1}}, unique: true},
+	{collection: "products", keys: bson.D{{Key: "name", Value: "text"}, {Key: "description", Value: "text"}}},
+	{collection: "bookings", keys: bson.D{{Key: "resource_id", Value: 1}, {Key: "status", Value: 1}, {Key: "start", Value: 1}}},
+	{collection: "bookings", keys: bson.D{{Key: "status", Value: 1}, {Key: "start", Value: 1}}},
+	{collection: "bookings", keys: bson.D{{Key: "conversation_id", Value: 1}, {Key: "start", Value: 1}}},
+	{collection: "topic_questions", keys: bson.D{{Key: "asked_at", Value: -1}}},
+	{collection: "topic_snapshots", keys: bson.D{{Key: "created_at", Value: -1}}},
+	{collection: "messages", keys: bson.D{{Key: "conversation_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+	{collection: "messages", keys: bson.D{{Key: "content", Value: "text"}}},
+	{collection: "messages", keys: bson.D{{Key: "anonymized_at", Value: 1}, {Key: "timestamp", Value: 1}}},
+	{collection: "conversation_notes", keys: bson.D{{Key: "anonymized_at", Value: 1}, {Key: "created_at", Value: 1}}},
+	{collection: "users", keys: bson.D{{Key: "email", Value: 1}}},
+	{collection: "user_sessions", keys: bson.D{{Key: "user_id", Value: 1}, {Key: "last_used_at", Value: -1}}},
+	// Sessions are dropped shortly after their token expires.
+	{collection: "user_sessions", keys: bson.D{{Key: "expires_at", Value: 1}}, ttl: time.Minute},
+	{collection: "known_devices", keys: bson.D{{Key: "user_id", Value: 1}, {Key: "fingerprint", Value: 1}}, unique: true},
+	{collection: "known_devices", keys: bson.D{{Key: "last_seen_at", Value: 1}}, ttl: knownDeviceRetention},
+	{collection: "logs", keys: bson.D{{Key: "timestamp", Value: -1}}},
+	{collection: "logs", keys: bson.D{{Key: "level", Value: 1}}},
+	{collection: "logs", keys: bson.D{{Key: "request_id", Value: 1}}},
+	{collection: "integration_api_keys", keys: bson.D{{Key: "key_hash", Value: 1}}, unique: true},
+	{collection: "integration_triggers", keys: bson.D{{Key: "type", Value: 1}, {Key: "_id", Value: -1}}},
+	{collection: "integration_triggers", keys: bson.D{{Key: "created_at", Value: 1}}, ttl: triggerRetention},
+	{collection: "integration_webhooks", keys: bson.D{{Key: "events", Value: 1}, {Key: "active", Value: 1}}},
+	{collection: "metric_samples", keys: bson.D{{Key: "timestamp", Value: 1}}, ttl: system.MetricsRetention},
+	{collection: "rag_queries", keys: bson.D{{Key: "created_at", Value: 1}}, ttl: document.QueryLogRetention},
+	{collection: "rag_queries", keys: bson.D{{Key: "hits.document_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	{collection: "rag_queries", keys: bson.D{{Key: "hits.chunk_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	{collection: "security_events", keys: bson.D{{Key: "created_at", Value: 1}}, ttl: system.SecurityEventRetention},
+	{collection: "security_events", keys: bson.D{{Key: "type", Value: 1}, {Key: "created_at", Value: -1}}},
+	{collection: "security_events", keys: bson.D{{Key: "ip", Value: 1}, {Key: "created_at", Value: -1}}},
+}
+
+// keysName renders index keys the way Mongo names indexes by default,
+// such as "user_id_1_uploaded_at_-1".
+func keysName(keys bson.D) string {
+	parts := make([]string, 0, len(keys)*2)
+	for _, key := range keys {
+		parts = append(parts, key.Key, fmt.Sprint(key.Value))
+	}
+	return strings.Join(parts, "_")
+}
+
+type IndexRepo struct {
+	db    *mongo.Database
+	retry retrier
+}
+
+func NewIndexRepo(client *DbClient) *IndexRepo {
+	return &IndexRepo{db: client.DB, retry: client.retry}
+}
+
+func (r *IndexRepo) Declared() []system.Index {
+	indexes := make([]system.Index, len(indexSpecs))
+	for i, spec := range indexSpecs {
+		indexes[i] = system.Index{
+			Collection: spec.collection,
+			Name:       indexPrefix + keysName(spec.keys),
+			Keys:       keysName(spec.keys),
+			Unique:     spec.unique,
+			TTL:        spec.ttl > 0,
+		}
+	}
+	return indexes
+}
+
+func (r *IndexRepo) EnsureIndexes(ctx context.Context) error {
+	var errs []error
+	for _, collection := range r.collections() {
+		existing, err := r.listIndexes(ctx, collection)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", collection, err))
+			continue
+		}
+		present := make(map[string]bool, len(existing))
+		for _, index := range existing {
+			present[index.Keys] = true
+		}
+
+		var models []mongo.IndexModel
+		for _, spec := range indexSpecs {
+			if spec.collection != collection || present[keysName(spec.keys)] {
+				continue
+			}
+			opts := options.Index().SetName(indexPrefix + keysName(spec.keys))
+			if spec.unique {
+				opts.SetUnique(true)
+			}
+			if spec.ttl > 0 {
+				opts.SetExpireAfterSeconds(int32(spec.ttl.Seconds()))
+			}
+			models = append(models, mongo.IndexModel{Keys: spec.keys, Options: opts})
+		}
+		if len(models) == 0 {
+			continue
+		}
+		err = r.retry.write(ctx, func(ctx context.Context) error {
+			_, err := r.db.Collection(collection).Indexes().CreateMany(ctx, models)
+			return err
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", collection, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *IndexRepo) List(ctx context.Context) ([]system.Index, error) {
+	var indexes []system.Index
+	for _, collection := range r.collections() {
+		existing, err := r.listIndexes(ctx, collection)
+		if err != nil {
+			return nil, err
+		}
+		if len(existing) == 0 {
+			continue
+		}
+		usage, err := r.indexStats(ctx, collection)
+		if err != nil {
+			return nil, err
+		}
+		for _, index := range existing {
+			if stat, ok := usage[index.Name]; ok {
+				index.Ops = stat.Accesses.Ops
+				since := stat.Accesses.Since
+				index.Since = &since
+			}
+			indexes = append(indexes, index)
+		}
+	}
+	return indexes, nil
+}
+
+// collections returns the declared collections in declaration order.
+func (r *IndexRepo) collections() []string {
+	var collections []string
+	seen := map[string]bool{}
+	for _, spec := range indexSpecs {
+		if !seen[spec.collection] {
+			seen[spec.collection] = true
+			collections = append(collections, spec.collection)
+		}
+	}
+	return collections
+}
+
+func (r *IndexRepo) listIndexes(ctx context.Context, collection string) ([]system.Index, error) {
+	var specs []struct {
+		Name               string `bson:"name"`
+		Key                bson.D `bson:"key"`
+		Unique             bool   `bson:"unique"`
+		ExpireAfterSeconds *int64 `bson:"expireAfterSeconds"`
+	}
+	err := r.retry.read(ctx, func(ctx context.Context) error {
+		cursor, err := r.db.Collection(collection).Indexes().List(ctx)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = cursor.Close(ctx) }()
+		return cursor.All(ctx, &specs)
+	})
+	if err != nil {
+		var cmdErr mongo.CommandError
+		if errors.As(err, &cmdErr) && cmdErr.Code == namespaceNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	indexes := make([]system.Index, len(specs))
+	for i, spec := range specs {
+		indexes[i] = system.Index{
+			Collection: collection,
+			Name:       spec.Name,
+			Keys:       keysName(spec.Key),
+			Unique:     spec.Unique,
+			TTL:        spec.ExpireAfterSeconds != nil,
+		}
+	}
+	return indexes, nil
+}
+
+type indexStat struct {
+	Name     string `bson:"name"`
+	Accesses struct {
+		Ops   int64     `bson:"ops"`
+		Since time.Time `bson:"since"`
+	} `bson:"accesses"`
+}
+
+// indexStats returns each index's usage by name. On a replica set or
+// sharded cluster the counts of the member that answers are used.
+func (r *IndexRepo) indexStats(ctx context.Context, collection string) (map[string]indexStat, error) {
+	var stats []indexStat
+	err := r.retry.read(ctx, func(ctx context.Context) error {
+		cursor, err := r.db.Collection(collection).Aggregate(ctx, mongo.Pipeline{{{Key: "$indexStats", Value: bson.M{}}}})
+		if err != nil {
+			return err
+		}
+		defer func() { _ = cursor.Close(ctx) }()
+		return cursor.All(ctx, &stats)
+	})
+	if err != nil {
+		return nil, err
+	}
+	usage := make(map[string]indexStat, len(stats))
+	for _, stat := range stats {
+		usage[stat.Name] = stat
+	}
+	return usage, nil
+}
@@ -0,0 +1,240 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/integration"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// triggerRetention is how long trigger events stay pollable. Polling tools
+// check every few minutes, so a week covers long outages.
+const triggerRetention = 7 * 24 * time.Hour
+
+type APIKeyRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewAPIKeyRepo(client *DbClient) *APIKeyRepo {
+	return &APIKeyRepo{collection: client.DB.Collection("integration_api_keys"), retry: client.retry}
+}
+
+func (r *APIKeyRepo) Create(ctx context.Context, key *integration.APIKey) (string, error) {
+	key.CreatedAt = time.Now()
+	if key.ID == "" {
+		key.ID = primitive.NewObjectID().Hex()
+	}
+
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, key)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return key.ID, nil
+}
+
+func (r *APIKeyRepo) GetByID(ctx context.Context, id string) (*integration.APIKey, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *APIKeyRepo) GetByHash(ctx context.Context, hash string) (*integration.APIKey, error) {
+	return r.findOne(ctx, bson.M{"key_hash": hash})
+}
+
+func (r *APIKeyRepo) findOne(ctx context.Context, filter bson.M) (*integration.APIKey, error) {
+	var key integration.APIKey
+	err := r.retry.findOne(ctx, r.collection, filter, &key)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *APIKeyRepo) List(ctx context.Context) ([]integration.APIKey, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	keys := []integration.APIKey{}
+	if err := r.retry.findAll(ctx, r.collection, bson.M{}, &keys, opts); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *APIKeyRepo) Update(ctx context.Context, key *integration.APIKey) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(ctx, bson.M{"_id": key.ID}, bson.M{"$set": bson.M{
+			"name":            key.Name,
+			"allowed_origins": key.AllowedOrigins,
+			"prefix":          key.Prefix,
+			"key_hash":        key.KeyHash,
+			"rotated_at":      key.RotatedAt,
+		}})
+		return err
+	})
+}
+
+func (r *APIKeyRepo) Delete(ctx context.Context, id string) (bool, error) {
+	var result *mongo.DeleteResult
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = r.collection.DeleteOne(ctx, bson.M{"_id": id})
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+func (r *APIKeyRepo) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_used_at": at}})
+		return err
+	})
+}
+
+func (r *APIKeyRepo) IncrementUsage(ctx context.Context, id string, sessions, questions int64) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$inc": bson.M{
+			"session_count":  sessions,
+			"question_count": questions,
+		}})
+		return err
+	})
+}
+
+type TriggerRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewTriggerRepo(client *DbClient) *TriggerRepo {
+	return &TriggerRepo{collection: client.DB.Collection("integration_triggers"), retry: client.retry}
+}
+
+func (r *TriggerRepo) Create(ctx context.Context, event *integration.TriggerEvent) error {
+	if event.ID == "" {
+		event.ID = primitive.NewObjectID().Hex()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, event)
+		return err
+	})
+}
+
+func (r *TriggerRepo) List(ctx context.Context, triggerType integration.TriggerType, limit int) ([]integration.TriggerEvent, error) {
+	// Object IDs start with their creation time, so they order events.
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(int64(limit))
+	events := []integration.TriggerEvent{}
+	if err := r.retry.findAll(ctx, r.collection, bson.M{"type": triggerType}, &events, opts); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+type WebhookRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewWebhookRepo(client *DbClient) *WebhookRepo {
+	return &WebhookRepo{collection: client.DB.Collection("integration_webhooks"), retry: client.retry}
+}
+
+func (r *WebhookRepo) Create(ctx context.Context, hook *integration.Webhook) (string, error) {
+	hook.CreatedAt = time.Now()
+	hook.UpdatedAt = time.Now()
+	if hook.ID == "" {
+		hook.ID = primitive.NewObjectID().Hex()
+	}
+
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, hook)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return hook.ID, nil
+}
+
+func (r *WebhookRepo) GetByID(ctx context.Context, id string) (*integration.Webhook, error) {
+	var hook integration.Webhook
+	err := r.retry.findOne(ctx, r.collection, bson.M{"_id": id}, &hook)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &hook, nil
+}
+
+func (r *WebhookRepo) List(ctx context.Context) ([]integration.Webhook, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *WebhookRepo) ListActive(ctx context.Context, event string) ([]integration.Webhook, error) {
+	return r.find(ctx, bson.M{"active": true, "events": event})
+}
+
+func (r *WebhookRepo) find(ctx context.Context, filter bson.M) ([]integration.Webhook, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	hooks := []integration.Webhook{}
+	if err := r.retry.findAll(ctx, r.collection, filter, &hooks, opts); err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+func (r *WebhookRepo) Update(ctx context.Context, hook *integration.Webhook) error {
+	hook.UpdatedAt = time.Now()
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(ctx, bson.M{"_id": hook.ID}, bson.M{"$set": bson.M{
+			"name":           hook.Name,
+			"url":            hook.URL,
+			"events":         hook.Events,
+			"schema_version": hook.SchemaVersion,
+			"active":         hook.Active,
+			"secret":         hook.Secret,
+			"updated_at":     hook.UpdatedAt,
+		}})
+		return err
+	})
+}
+
+func (r *WebhookRepo) Delete(ctx context.Context, id string) (bool, error) {
+	var result *mongo.DeleteResult
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = r.collection.DeleteOne(ctx, bson.M{"_id": id})
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+func (r *WebhookRepo) RecordDelivery(ctx context.Context, id string, at time.Time, status int, errMsg string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+			"last_delivery_at": at,
+			"last_status":      status,
+			"last_error":       errMsg,
+		}})
+		return err
+	})
+}
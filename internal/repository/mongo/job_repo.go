@@ -0,0 +1,81 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/scheduler"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type JobRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewJobRepo(client *DbClient) *JobRepo {
+	return &JobRepo{
+		collection: client.DB.Collection("scheduler_jobs"),
+		retry:      client.retry,
+	}
+}
+
+// Acquire relies on the upsert failing with a duplicate key when the job
+// document exists but does not match the filter, meaning the lock is held or
+// the occurrence was already claimed.
+func (r *JobRepo) Acquire(ctx context.Context, name, owner string, scheduledFor, lockedUntil time.Time) (bool, error) {
+	filter := bson.M{
+		"_id":           name,
+		"scheduled_for": bson.M{"$lt": scheduledFor},
+		"locked_until":  bson.M{"$lte": time.Now()},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"locked_by":     owner,
+			"locked_until":  lockedUntil,
+			"scheduled_for": scheduledFor,
+		},
+	}
+
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+		return err
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *JobRepo) Complete(ctx context.Context, name, owner string, run scheduler.Run) error {
+	filter := bson.M{"_id": name, "locked_by": owner}
+	update := bson.M{
+		"$set": bson.M{
+			"locked_until":     time.Now(),
+			"last_run_at":      run.StartedAt,
+			"last_duration_ms": run.Duration.Milliseconds(),
+			"last_status":      run.Status,
+			"last_error":       run.Error,
+			"last_run_by":      owner,
+		},
+		"$unset": bson.M{"locked_by": ""},
+	}
+
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(ctx, filter, update)
+		return err
+	})
+}
+
+func (r *JobRepo) List(ctx context.Context) ([]scheduler.JobState, error) {
+	states := []scheduler.JobState{}
+	if err := r.retry.findAll(ctx, r.collection, bson.M{}, &states, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})); err != nil {
+		return nil, err
+	}
+	return states, nil
+}
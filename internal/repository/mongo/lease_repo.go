@@ -0,0 +1,88 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/cluster"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type LeaseRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewLeaseRepo(client *DbClient) *LeaseRepo {
+	return &LeaseRepo{
+		collection: client.DB.Collection("leases"),
+		retry:      client.retry,
+	}
+}
+
+// Acquire first tries to renew a lease the holder already has, then to take
+// over an expired one. Like JobRepo.Acquire, the takeover upsert fails with a
+// duplicate key when the lease exists and is held by someone else.
+func (r *LeaseRepo) Acquire(ctx context.Context, name, holder string, expiresAt time.Time) (bool, error) {
+	now := time.Now()
+
+	var res *mongo.UpdateResult
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		var err error
+		res, err = r.collection.UpdateOne(ctx,
+			bson.M{"_id": name, "holder": holder},
+			bson.M{"$set": bson.M{"renewed_at": now, "expires_at": expiresAt}},
+		)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	if res.MatchedCount > 0 {
+		return true, nil
+	}
+
+	err = r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(ctx,
+			bson.M{"_id": name, "expires_at": bson.M{"$lte": now}},
+			bson.M{"$set": bson.M{
+				"holder":      holder,
+				"acquired_at": now,
+				"renewed_at":  now,
+				"expires_at":  expiresAt,
+			}},
+			options.Update().SetUpsert(true),
+		)
+		return err
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *LeaseRepo) Release(ctx context.Context, name, holder string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(ctx,
+			bson.M{"_id": name, "holder": holder},
+			bson.M{"$set": bson.M{"expires_at": time.Now()}},
+		)
+		return err
+	})
+}
+
+func (r *LeaseRepo) Get(ctx context.Context, name string) (*cluster.Lease, error) {
+	var lease cluster.Lease
+	if err := r.retry.findOne(ctx, r.collection, bson.M{"_id": name}, &lease); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &lease, nil
+}
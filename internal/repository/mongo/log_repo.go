@@ -12,27 +12,22 @@ import (
 )
 
 type LogRepo struct {
-	col *mongo.Collection
+	col   *mongo.Collection
+	retry retrier
 }
 
 func NewLogRepo(client *DbClient) *LogRepo {
-	col := client.DB.Collection("logs")
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	_, _ = col.Indexes().CreateMany(ctx, []mongo.IndexModel{
-		{Keys: bson.D{{Key: "timestamp", Value: -1}}},
-		{Keys: bson.D{{Key: "level", Value: 1}}},
-		{Keys: bson.D{{Key: "request_id", Value: 1}}},
-	})
-	return &LogRepo{col: col}
+	return &LogRepo{col: client.DB.Collection("logs"), retry: client.retry}
 }
 
 func (r *LogRepo) Insert(ctx context.Context, entry *system.LogEntry) error {
 	if entry.ID == "" {
 		entry.ID = primitive.NewObjectID().Hex()
 	}
-	_, err := r.col.InsertOne(ctx, entry)
-	return err
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.col.InsertOne(ctx, entry)
+		return err
+	})
 }
 
 func (r *LogRepo) List(ctx context.Context, filter system.LogFilter) ([]system.LogEntry, int64, error) {
@@ -63,7 +58,7 @@ func (r *LogRepo) List(ctx context.Context, filter system.LogFilter) ([]system.L
 		query["source"] = filter.Source
 	}
 
-	total, err := r.col.CountDocuments(ctx, query)
+	total, err := r.retry.count(ctx, r.col, query)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -78,21 +73,15 @@ func (r *LogRepo) List(ctx context.Context, filter system.LogFilter) ([]system.L
 		SetLimit(int64(limit)).
 		SetSkip(int64(filter.Offset))
 
-	cursor, err := r.col.Find(ctx, query, opts)
-	if err != nil {
-		return nil, 0, err
-	}
-	defer func() { _ = cursor.Close(ctx) }()
-
 	var entries []system.LogEntry
-	if err := cursor.All(ctx, &entries); err != nil {
+	if err := r.retry.findAll(ctx, r.col, query, &entries, opts); err != nil {
 		return nil, 0, err
 	}
 	return entries, total, nil
 }
 
 func (r *LogRepo) Stats(ctx context.Context) (*system.LogStats, error) {
-	total, err := r.col.CountDocuments(ctx, bson.M{})
+	total, err := r.retry.count(ctx, r.col, bson.M{})
 	if err != nil {
 		return nil, err
 	}
@@ -100,26 +89,33 @@ func (r *LogRepo) Stats(ctx context.Context) (*system.LogStats, error) {
 	pipeline := []bson.M{
 		{"$group": bson.M{"_id": "$level", "count": bson.M{"$sum": 1}}},
 	}
-	cursor, err := r.col.Aggregate(ctx, pipeline)
-	if err != nil {
-		return nil, err
-	}
-	defer func() { _ = cursor.Close(ctx) }()
-
-	levelCounts := make(map[string]int64)
-	for cursor.Next(ctx) {
-		var result struct {
-			ID    string `bson:"_id"`
-			Count int64  `bson:"count"`
+	var levelCounts map[string]int64
+	err = r.retry.read(ctx, func(ctx context.Context) error {
+		cursor, err := r.col.Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
 		}
-		if err := cursor.Decode(&result); err == nil {
-			levelCounts[result.ID] = result.Count
+		defer func() { _ = cursor.Close(ctx) }()
+
+		levelCounts = make(map[string]int64)
+		for cursor.Next(ctx) {
+			var result struct {
+				ID    string `bson:"_id"`
+				Count int64  `bson:"count"`
+			}
+			if err := cursor.Decode(&result); err == nil {
+				levelCounts[result.ID] = result.Count
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	var oldest, newest system.LogEntry
-	_ = r.col.FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.D{{Key: "timestamp", Value: 1}})).Decode(&oldest)
-	_ = r.col.FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.D{{Key: "timestamp", Value: -1}})).Decode(&newest)
+	_ = r.retry.findOne(ctx, r.col, bson.M{}, &oldest, options.FindOne().SetSort(bson.D{{Key: "timestamp", Value: 1}}))
+	_ = r.retry.findOne(ctx, r.col, bson.M{}, &newest, options.FindOne().SetSort(bson.D{{Key: "timestamp", Value: -1}}))
 
 	return &system.LogStats{
 		TotalCount:  total,
@@ -131,7 +127,12 @@ func (r *LogRepo) Stats(ctx context.Context) (*system.LogStats, error) {
 
 func (r *LogRepo) DeleteOlderThan(ctx context.Context, days int) (int64, error) {
 	cutoff := time.Now().AddDate(0, 0, -days)
-	result, err := r.col.DeleteMany(ctx, bson.M{"timestamp": bson.M{"$lt": cutoff}})
+	var result *mongo.DeleteResult
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = r.col.DeleteMany(ctx, bson.M{"timestamp": bson.M{"$lt": cutoff}})
+		return err
+	})
 	if err != nil {
 		return 0, err
 	}
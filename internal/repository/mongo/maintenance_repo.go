@@ -0,0 +1,47 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/system"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maintenanceID is the one document the maintenance switch is kept in.
+const maintenanceID = "default"
+
+type MaintenanceRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewMaintenanceRepo(client *DbClient) *MaintenanceRepo {
+	return &MaintenanceRepo{
+		collection: client.DB.Collection("maintenance"),
+		retry:      client.retry,
+	}
+}
+
+func (r *MaintenanceRepo) Get(ctx context.Context) (*system.Maintenance, error) {
+	var maintenance system.Maintenance
+	err := r.retry.findOne(ctx, r.collection, bson.M{"_id": maintenanceID}, &maintenance)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &maintenance, nil
+}
+
+func (r *MaintenanceRepo) Save(ctx context.Context, maintenance *system.Maintenance) error {
+	maintenance.UpdatedAt = time.Now()
+
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": maintenanceID}, maintenance, options.Replace().SetUpsert(true))
+		return err
+	})
+}
@@ -13,12 +13,11 @@ import (
 
 type MessageRepo struct {
 	collection *mongo.Collection
+	retry      retrier
 }
 
 func NewMessageRepo(client *DbClient) *MessageRepo {
-	return &MessageRepo{
-		collection: client.DB.Collection("messages"),
-	}
+	return &MessageRepo{collection: client.conversationCollection("messages"), retry: client.retry}
 }
 
 func (r *MessageRepo) Create(ctx context.Context, msg *conversation.Message) (string, error) {
@@ -28,7 +27,10 @@ func (r *MessageRepo) Create(ctx context.Context, msg *conversation.Message) (st
 		msg.ID = primitive.NewObjectID().Hex()
 	}
 
-	_, err := r.collection.InsertOne(ctx, msg)
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, msg)
+		return err
+	})
 	if err != nil {
 		return "", err
 	}
@@ -38,7 +40,7 @@ func (r *MessageRepo) Create(ctx context.Context, msg *conversation.Message) (st
 
 func (r *MessageRepo) GetByID(ctx context.Context, id string) (*conversation.Message, error) {
 	var msg conversation.Message
-	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&msg)
+	err := r.retry.findOne(ctx, r.collection, bson.M{"_id": id}, &msg)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil
@@ -54,14 +56,8 @@ func (r *MessageRepo) GetByConversationID(ctx context.Context, conversationID st
 		SetSkip(int64(offset)).
 		SetSort(bson.D{{Key: "timestamp", Value: -1}})
 
-	cursor, err := r.collection.Find(ctx, bson.M{"conversation_id": conversationID}, opts)
-	if err != nil {
-		return nil, err
-	}
-	defer func() { _ = cursor.Close(ctx) }()
-
 	var msgs []conversation.Message
-	if err := cursor.All(ctx, &msgs); err != nil {
+	if err := r.retry.findAll(ctx, r.collection, bson.M{"conversation_id": conversationID}, &msgs, opts); err != nil {
 		return nil, err
 	}
 
@@ -73,5 +69,104 @@ func (r *MessageRepo) GetByConversationID(ctx context.Context, conversationID st
 }
 
 func (r *MessageRepo) CountByConversation(ctx context.Context, conversationID string) (int64, error) {
-	return r.collection.CountDocuments(ctx, bson.M{"conversation_id": conversationID})
+	return r.retry.count(ctx, r.collection, bson.M{"conversation_id": conversationID})
+}
+
+func (r *MessageRepo) CountIncomingSince(ctx context.Context, conversationID string, since time.Time) (int64, error) {
+	filter := bson.M{
+		"conversation_id": conversationID,
+		"direction":       conversation.DirectionIncoming,
+	}
+	if !since.IsZero() {
+		filter["timestamp"] = bson.M{"$gt": since}
+	}
+	return r.retry.count(ctx, r.collection, filter)
+}
+
+func (r *MessageRepo) ListAfter(ctx context.Context, conversationID string, after time.Time, limit int) ([]conversation.Message, error) {
+	filter := bson.M{"conversation_id": conversationID}
+	if !after.IsZero() {
+		filter["timestamp"] = bson.M{"$gt": after}
+	}
+
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "timestamp", Value: 1}})
+
+	var msgs []conversation.Message
+	if err := r.retry.findAll(ctx, r.collection, filter, &msgs, opts); err != nil {
+		return nil, err
+	}
+
+	return msgs, nil
+}
+
+// SearchConversationIDs returns up to limit conversations having a message
+// matching query in the content text index, only userID's when it is set.
+// The matches are grouped in an aggregation rather than with Distinct, so a
+// common word cannot outgrow the 16 MB Distinct result.
+func (r *MessageRepo) SearchConversationIDs(ctx context.Context, userID, query string, limit int) ([]string, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"$text": bson.M{"$search": query}}},
+		{"$group": bson.M{"_id": "$conversation_id"}},
+	}
+	if userID != "" {
+		pipeline = append(pipeline,
+			bson.M{"$lookup": bson.M{
+				"from": "conversations",
+				"let":  bson.M{"id": "$_id"},
+				"pipeline": bson.A{
+					bson.M{"$match": bson.M{"$expr": bson.M{"$and": bson.A{
+						bson.M{"$eq": bson.A{"$_id", "$$id"}},
+						bson.M{"$eq": bson.A{"$user_id", userID}},
+					}}}},
+					bson.M{"$project": bson.M{"_id": 1}},
+				},
+				"as": "owned",
+			}},
+			bson.M{"$match": bson.M{"owned": bson.M{"$ne": bson.A{}}}},
+		)
+	}
+	pipeline = append(pipeline, bson.M{"$limit": limit})
+
+	var matches []struct {
+		ID string `bson:"_id"`
+	}
+	err := r.retry.read(ctx, func(ctx context.Context) error {
+		cursor, err := r.collection.Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = cursor.Close(ctx) }()
+		return cursor.All(ctx, &matches)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(matches))
+	for _, m := range matches {
+		ids = append(ids, m.ID)
+	}
+	return ids, nil
+}
+
+// AnonymizeBefore clears the content, the answer and what a photo showed,
+// keeping each message's direction, type and times.
+func (r *MessageRepo) AnonymizeBefore(ctx context.Context, before time.Time) (int64, error) {
+	return anonymizeBefore(ctx, r.retry, r.collection, "timestamp", before,
+		bson.M{"content": ""},
+		bson.M{"rag_answer": "", "media.description": ""},
+	)
+}
+
+func (r *MessageRepo) RetentionCounts(ctx context.Context, before time.Time) (*conversation.RetentionCounts, error) {
+	return retentionCounts(ctx, r.retry, r.collection, "timestamp", before)
+}
+
+func (r *MessageRepo) DeleteByConversation(ctx context.Context, conversationID string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.DeleteMany(ctx, bson.M{"conversation_id": conversationID})
+		return err
+	})
 }
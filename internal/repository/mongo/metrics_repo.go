@@ -0,0 +1,40 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/system"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MetricsRepo struct {
+	col   *mongo.Collection
+	retry retrier
+}
+
+func NewMetricsRepo(client *DbClient) *MetricsRepo {
+	return &MetricsRepo{col: client.DB.Collection("metric_samples"), retry: client.retry}
+}
+
+func (r *MetricsRepo) Insert(ctx context.Context, sample *system.MetricSample) error {
+	if sample.ID == "" {
+		sample.ID = primitive.NewObjectID().Hex()
+	}
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.col.InsertOne(ctx, sample)
+		return err
+	})
+}
+
+func (r *MetricsRepo) Since(ctx context.Context, since time.Time) ([]system.MetricSample, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+	var samples []system.MetricSample
+	if err := r.retry.findAll(ctx, r.col, bson.M{"timestamp": bson.M{"$gte": since}}, &samples, opts); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
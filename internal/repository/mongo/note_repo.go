@@ -0,0 +1,72 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type NoteRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewNoteRepo(client *DbClient) *NoteRepo {
+	return &NoteRepo{
+		collection: client.DB.Collection("conversation_notes"),
+		retry:      client.retry,
+	}
+}
+
+func (r *NoteRepo) Create(ctx context.Context, note *conversation.Note) (string, error) {
+	note.CreatedAt = time.Now()
+
+	if note.ID == "" {
+		note.ID = primitive.NewObjectID().Hex()
+	}
+
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, note)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return note.ID, nil
+}
+
+func (r *NoteRepo) ListByConversation(ctx context.Context, conversationID string) ([]conversation.Note, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	var notes []conversation.Note
+	if err := r.retry.findAll(ctx, r.collection, bson.M{"conversation_id": conversationID}, &notes, opts); err != nil {
+		return nil, err
+	}
+
+	if notes == nil {
+		notes = []conversation.Note{}
+	}
+
+	return notes, nil
+}
+
+func (r *NoteRepo) AnonymizeBefore(ctx context.Context, before time.Time) (int64, error) {
+	return anonymizeBefore(ctx, r.retry, r.collection, "created_at", before, bson.M{"content": ""}, nil)
+}
+
+func (r *NoteRepo) RetentionCounts(ctx context.Context, before time.Time) (*conversation.RetentionCounts, error) {
+	return retentionCounts(ctx, r.retry, r.collection, "created_at", before)
+}
+
+func (r *NoteRepo) DeleteByConversation(ctx context.Context, conversationID string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.DeleteMany(ctx, bson.M{"conversation_id": conversationID})
+		return err
+	})
+}
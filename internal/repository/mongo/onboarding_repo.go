@@ -0,0 +1,47 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/whatsapp"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// onboardingID is the one document the onboarding settings are kept in.
+const onboardingID = "default"
+
+type OnboardingRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewOnboardingRepo(client *DbClient) *OnboardingRepo {
+	return &OnboardingRepo{
+		collection: client.DB.Collection("whatsapp_onboarding"),
+		retry:      client.retry,
+	}
+}
+
+func (r *OnboardingRepo) Get(ctx context.Context) (*whatsapp.Onboarding, error) {
+	var onboarding whatsapp.Onboarding
+	err := r.retry.findOne(ctx, r.collection, bson.M{"_id": onboardingID}, &onboarding)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &onboarding, nil
+}
+
+func (r *OnboardingRepo) Save(ctx context.Context, onboarding *whatsapp.Onboarding) error {
+	onboarding.UpdatedAt = time.Now()
+
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": onboardingID}, onboarding, options.Replace().SetUpsert(true))
+		return err
+	})
+}
@@ -0,0 +1,89 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/system"
+	"github.com/elprogramadorgt/lucidRAG/internal/events"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// OverviewRepo reads across collections for the admin dashboard.
+type OverviewRepo struct {
+	db    *mongo.Database
+	retry retrier
+}
+
+func NewOverviewRepo(client *DbClient) *OverviewRepo {
+	return &OverviewRepo{db: client.DB, retry: client.retry}
+}
+
+type overviewCount struct {
+	collection string
+	filter     bson.M
+	dest       *int64
+}
+
+func (r *OverviewRepo) Overview(ctx context.Context, now time.Time) (*system.Overview, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	since := now.Add(-24 * time.Hour)
+
+	overview := &system.Overview{GeneratedAt: now}
+	counts := []overviewCount{
+		{"users", bson.M{}, &overview.Users},
+		{"documents", bson.M{}, &overview.Documents},
+		{"chunks", bson.M{}, &overview.Chunks},
+		{"conversations", bson.M{}, &overview.Conversations},
+		{"conversations", bson.M{"state": stateQuery(conversation.StateOpen)}, &overview.OpenConversations},
+		{"conversations", bson.M{"state": conversation.StatePending}, &overview.PendingConversations},
+		{"messages", bson.M{"created_at": bson.M{"$gte": today}}, &overview.MessagesToday},
+		{"logs", bson.M{
+			"timestamp":   bson.M{"$gte": since},
+			"message":     "domain_event",
+			"attrs.event": events.NameAnswerGenerated,
+		}, &overview.RAGQueries24h},
+		{"logs", bson.M{
+			"timestamp": bson.M{"$gte": since},
+			"message":   "request",
+		}, &overview.Requests24h},
+		{"logs", bson.M{
+			"timestamp":    bson.M{"$gte": since},
+			"message":      "request",
+			"attrs.status": bson.M{"$gte": 500},
+		}, &overview.ServerErrors24h},
+	}
+
+	for _, c := range counts {
+		n, err := r.retry.count(ctx, r.db.Collection(c.collection), c.filter)
+		if err != nil {
+			return nil, err
+		}
+		*c.dest = n
+	}
+
+	if overview.Requests24h > 0 {
+		overview.ErrorRate24h = float64(overview.ServerErrors24h) / float64(overview.Requests24h)
+	}
+
+	var stats struct {
+		DataSize    float64 `bson:"dataSize"`
+		StorageSize float64 `bson:"storageSize"`
+		IndexSize   float64 `bson:"indexSize"`
+	}
+	err := r.retry.read(ctx, func(ctx context.Context) error {
+		return r.db.RunCommand(ctx, bson.D{{Key: "dbStats", Value: 1}}).Decode(&stats)
+	})
+	if err != nil {
+		return nil, err
+	}
+	overview.Storage = system.StorageStats{
+		DataBytes:    int64(stats.DataSize),
+		StorageBytes: int64(stats.StorageSize),
+		IndexBytes:   int64(stats.IndexSize),
+	}
+
+	return overview, nil
+}
@@ -0,0 +1,44 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/user"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PreferencesRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewPreferencesRepo(client *DbClient) *PreferencesRepo {
+	return &PreferencesRepo{
+		collection: client.DB.Collection("user_preferences"),
+		retry:      client.retry,
+	}
+}
+
+func (r *PreferencesRepo) Get(ctx context.Context, userID string) (*user.Preferences, error) {
+	var prefs user.Preferences
+	err := r.retry.findOne(ctx, r.collection, bson.M{"_id": userID}, &prefs)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &prefs, nil
+}
+
+func (r *PreferencesRepo) Upsert(ctx context.Context, prefs *user.Preferences) error {
+	prefs.UpdatedAt = time.Now()
+
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": prefs.UserID}, prefs, options.Replace().SetUpsert(true))
+		return err
+	})
+}
@@ -0,0 +1,127 @@
+package mongo
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/product"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ProductRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewProductRepo(client *DbClient) *ProductRepo {
+	return &ProductRepo{
+		collection: client.DB.Collection("products"),
+		retry:      client.retry,
+	}
+}
+
+func (r *ProductRepo) Create(ctx context.Context, p *product.Product) (string, error) {
+	p.CreatedAt = time.Now()
+	p.UpdatedAt = time.Now()
+
+	if p.ID == "" {
+		p.ID = primitive.NewObjectID().Hex()
+	}
+
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, p)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return p.ID, nil
+}
+
+func (r *ProductRepo) GetByID(ctx context.Context, id string) (*product.Product, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *ProductRepo) GetBySKU(ctx context.Context, sku string) (*product.Product, error) {
+	return r.findOne(ctx, bson.M{"sku": sku})
+}
+
+func (r *ProductRepo) findOne(ctx context.Context, filter bson.M) (*product.Product, error) {
+	var p product.Product
+	err := r.retry.findOne(ctx, r.collection, filter, &p)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *ProductRepo) FindBySKUs(ctx context.Context, skus []string) ([]product.Product, error) {
+	return r.find(ctx, bson.M{"sku": bson.M{"$in": skus}}, options.Find().SetSort(bson.D{{Key: "sku", Value: 1}}))
+}
+
+func (r *ProductRepo) Search(ctx context.Context, text string, limit int) ([]product.Product, error) {
+	opts := options.Find().
+		SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}}).
+		SetLimit(int64(limit))
+	return r.find(ctx, bson.M{"$text": bson.M{"$search": text}}, opts)
+}
+
+func (r *ProductRepo) List(ctx context.Context, filter product.Filter) ([]product.Product, int64, error) {
+	query := bson.M{}
+	if filter.Query != "" {
+		pattern := bson.M{"$regex": regexp.QuoteMeta(filter.Query), "$options": "i"}
+		query["$or"] = bson.A{bson.M{"sku": pattern}, bson.M{"name": pattern}, bson.M{"description": pattern}}
+	}
+
+	total, err := r.retry.count(ctx, r.collection, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "name", Value: 1}}).
+		SetLimit(int64(filter.Limit)).
+		SetSkip(int64(filter.Offset))
+	products, err := r.find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	return products, total, nil
+}
+
+func (r *ProductRepo) Update(ctx context.Context, p *product.Product) error {
+	p.UpdatedAt = time.Now()
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": p.ID}, p)
+		return err
+	})
+}
+
+func (r *ProductRepo) Delete(ctx context.Context, id string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+		return err
+	})
+}
+
+func (r *ProductRepo) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]product.Product, error) {
+	var products []product.Product
+	if err := r.retry.findAll(ctx, r.collection, filter, &products, opts); err != nil {
+		return nil, err
+	}
+
+	if products == nil {
+		products = []product.Product{}
+	}
+
+	return products, nil
+}
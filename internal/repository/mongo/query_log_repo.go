@@ -0,0 +1,182 @@
+package mongo
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// QueryLogRepo keeps RAG queries with their retrieval hits. Entries expire
+// after document.QueryLogRetention.
+type QueryLogRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewQueryLogRepo(client *DbClient) *QueryLogRepo {
+	return &QueryLogRepo{
+		collection: client.DB.Collection("rag_queries"),
+		retry:      client.retry,
+	}
+}
+
+func (r *QueryLogRepo) Create(ctx context.Context, entry *document.QueryLog) (string, error) {
+	if entry.ID == "" {
+		entry.ID = primitive.NewObjectID().Hex()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, entry)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return entry.ID, nil
+}
+
+func (r *QueryLogRepo) GetByID(ctx context.Context, id string) (*document.QueryLog, error) {
+	var entry document.QueryLog
+	err := r.retry.findOne(ctx, r.collection, bson.M{"_id": id}, &entry)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *QueryLogRepo) List(ctx context.Context, filter document.QueryLogFilter) ([]document.QueryLog, int64, error) {
+	query := bson.M{}
+	if filter.Query != "" {
+		query["query"] = bson.M{"$regex": regexp.QuoteMeta(filter.Query), "$options": "i"}
+	}
+	if filter.Outcome != "" {
+		query["outcome"] = filter.Outcome
+	}
+	if filter.Channel != "" {
+		query["channel"] = filter.Channel
+	}
+	if filter.DocumentID != "" {
+		query["hits.document_id"] = filter.DocumentID
+	}
+	if filter.ChunkID != "" {
+		query["hits.chunk_id"] = filter.ChunkID
+	}
+	if !filter.StartTime.IsZero() || !filter.EndTime.IsZero() {
+		createdAt := bson.M{}
+		if !filter.StartTime.IsZero() {
+			createdAt["$gte"] = filter.StartTime
+		}
+		if !filter.EndTime.IsZero() {
+			createdAt["$lte"] = filter.EndTime
+		}
+		query["created_at"] = createdAt
+	}
+
+	total, err := r.retry.count(ctx, r.collection, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetLimit(int64(filter.Limit)).
+		SetSkip(int64(filter.Offset)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	var entries []document.QueryLog
+	if err := r.retry.findAll(ctx, r.collection, query, &entries, opts); err != nil {
+		return nil, 0, err
+	}
+	if entries == nil {
+		entries = []document.QueryLog{}
+	}
+	return entries, total, nil
+}
+
+func (r *QueryLogRepo) Stats(ctx context.Context, start, end time.Time, lowConfidence float64) (*document.QueryStats, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"created_at": bson.M{"$gte": start, "$lt": end}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":            nil,
+			"total":          bson.M{"$sum": 1},
+			"low_confidence": bson.M{"$sum": bson.M{"$cond": bson.A{lowConfidenceExpr(lowConfidence), 1, 0}}},
+			"no_results": bson.M{"$sum": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$outcome", document.OutcomeNoResults}}, 1, 0,
+			}}},
+		}}},
+	}
+
+	var results []document.QueryStats
+	err := r.retry.read(ctx, func(ctx context.Context) error {
+		cursor, err := r.collection.Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		return cursor.All(ctx, &results)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return &document.QueryStats{}, nil
+	}
+	return &results[0], nil
+}
+
+// Gaps groups the poorly answered questions logged before end, so that
+// one also asked before start, within the log's retention, is not new.
+func (r *QueryLogRepo) Gaps(ctx context.Context, start, end time.Time, lowConfidence float64, limit int) ([]document.KnowledgeGap, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"created_at": bson.M{"$lt": end},
+			"$or": bson.A{
+				bson.M{"outcome": document.OutcomeNoResults},
+				bson.M{"outcome": document.OutcomeAnswered, "confidence_score": bson.M{"$lt": lowConfidence}},
+			},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":            bson.M{"$toLower": bson.M{"$trim": bson.M{"input": "$query"}}},
+			"query":          bson.M{"$first": "$query"},
+			"count":          bson.M{"$sum": 1},
+			"first_asked_at": bson.M{"$min": "$created_at"},
+		}}},
+		{{Key: "$match", Value: bson.M{"first_asked_at": bson.M{"$gte": start}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "first_asked_at", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	var gaps []document.KnowledgeGap
+	err := r.retry.read(ctx, func(ctx context.Context) error {
+		cursor, err := r.collection.Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		return cursor.All(ctx, &gaps)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if gaps == nil {
+		gaps = []document.KnowledgeGap{}
+	}
+	return gaps, nil
+}
+
+// lowConfidenceExpr matches a generated answer below lowConfidence; cached
+// and built-in replies carry no confidence of their own.
+func lowConfidenceExpr(lowConfidence float64) bson.M {
+	return bson.M{"$and": bson.A{
+		bson.M{"$eq": bson.A{"$outcome", document.OutcomeAnswered}},
+		bson.M{"$lt": bson.A{"$confidence_score", lowConfidence}},
+	}}
+}
@@ -0,0 +1,68 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ReadMarkerRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewReadMarkerRepo(client *DbClient) *ReadMarkerRepo {
+	return &ReadMarkerRepo{
+		collection: client.DB.Collection("conversation_reads"),
+		retry:      client.retry,
+	}
+}
+
+func (r *ReadMarkerRepo) MarkRead(ctx context.Context, userID, conversationID string, at time.Time) error {
+	filter := bson.M{"_id": userID + ":" + conversationID}
+	update := bson.M{
+		"$set": bson.M{
+			"user_id":         userID,
+			"conversation_id": conversationID,
+		},
+		"$max": bson.M{"last_read_at": at},
+	}
+
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+		return err
+	})
+}
+
+func (r *ReadMarkerRepo) GetByUser(ctx context.Context, userID string, conversationIDs []string) (map[string]time.Time, error) {
+	result := make(map[string]time.Time, len(conversationIDs))
+	if len(conversationIDs) == 0 {
+		return result, nil
+	}
+
+	filter := bson.M{
+		"user_id":         userID,
+		"conversation_id": bson.M{"$in": conversationIDs},
+	}
+	var markers []conversation.ReadMarker
+	if err := r.retry.findAll(ctx, r.collection, filter, &markers); err != nil {
+		return nil, err
+	}
+
+	for _, m := range markers {
+		result[m.ConversationID] = m.LastReadAt
+	}
+
+	return result, nil
+}
+
+func (r *ReadMarkerRepo) DeleteByConversation(ctx context.Context, conversationID string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.DeleteMany(ctx, bson.M{"conversation_id": conversationID})
+		return err
+	})
+}
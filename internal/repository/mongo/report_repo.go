@@ -0,0 +1,95 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/report"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ReportScheduleRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewReportScheduleRepo(client *DbClient) *ReportScheduleRepo {
+	return &ReportScheduleRepo{
+		collection: client.DB.Collection("report_schedules"),
+		retry:      client.retry,
+	}
+}
+
+func (r *ReportScheduleRepo) Create(ctx context.Context, schedule *report.Schedule) (string, error) {
+	schedule.CreatedAt = time.Now()
+	schedule.UpdatedAt = time.Now()
+
+	if schedule.ID == "" {
+		schedule.ID = primitive.NewObjectID().Hex()
+	}
+
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, schedule)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return schedule.ID, nil
+}
+
+func (r *ReportScheduleRepo) GetByID(ctx context.Context, id string) (*report.Schedule, error) {
+	var schedule report.Schedule
+	err := r.retry.findOne(ctx, r.collection, bson.M{"_id": id}, &schedule)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (r *ReportScheduleRepo) List(ctx context.Context) ([]report.Schedule, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+	var schedules []report.Schedule
+	if err := r.retry.findAll(ctx, r.collection, bson.M{}, &schedules, opts); err != nil {
+		return nil, err
+	}
+
+	if schedules == nil {
+		schedules = []report.Schedule{}
+	}
+
+	return schedules, nil
+}
+
+func (r *ReportScheduleRepo) Update(ctx context.Context, schedule *report.Schedule) error {
+	schedule.UpdatedAt = time.Now()
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": schedule.ID}, schedule)
+		return err
+	})
+}
+
+func (r *ReportScheduleRepo) Delete(ctx context.Context, id string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+		return err
+	})
+}
+
+func (r *ReportScheduleRepo) MarkSent(ctx context.Context, id string, at time.Time) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(ctx,
+			bson.M{"_id": id},
+			bson.M{"$set": bson.M{"last_sent_at": at}},
+		)
+		return err
+	})
+}
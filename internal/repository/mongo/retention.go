@@ -0,0 +1,66 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// anonymizeBefore sets and unsets fields on the documents of col not
+// anonymized yet whose field is older than before, stamping them
+// anonymized.
+func anonymizeBefore(ctx context.Context, retry retrier, col *mongo.Collection, field string, before time.Time, set, unset bson.M) (int64, error) {
+	set["anonymized_at"] = time.Now()
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	var anonymized int64
+	err := retry.write(ctx, func(ctx context.Context) error {
+		result, err := col.UpdateMany(ctx, bson.M{field: bson.M{"$lt": before}, "anonymized_at": bson.M{"$exists": false}}, update)
+		if err != nil {
+			return err
+		}
+		anonymized = result.ModifiedCount
+		return nil
+	})
+	return anonymized, err
+}
+
+// retentionCounts reports on the documents of col anonymized so far and,
+// when before is set, on those whose field is older than before that are
+// not.
+func retentionCounts(ctx context.Context, retry retrier, col *mongo.Collection, field string, before time.Time) (*conversation.RetentionCounts, error) {
+	counts := &conversation.RetentionCounts{}
+	var err error
+	counts.Anonymized, err = retry.count(ctx, col, bson.M{"anonymized_at": bson.M{"$exists": true}})
+	if err != nil {
+		return nil, err
+	}
+	if !before.IsZero() {
+		counts.Overdue, err = retry.count(ctx, col, bson.M{field: bson.M{"$lt": before}, "anonymized_at": bson.M{"$exists": false}})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var oldest bson.M
+	opts := options.FindOne().SetSort(bson.D{{Key: field, Value: 1}}).SetProjection(bson.M{field: 1})
+	if err := retry.findOne(ctx, col, bson.M{"anonymized_at": bson.M{"$exists": false}}, &oldest, opts); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return counts, nil
+		}
+		return nil, err
+	}
+	if at, ok := oldest[field].(primitive.DateTime); ok {
+		t := at.Time()
+		counts.OldestRetained = &t
+	}
+	return counts, nil
+}
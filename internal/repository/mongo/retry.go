@@ -0,0 +1,118 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	retryBaseDelay = 100 * time.Millisecond
+	retryMaxDelay  = 2 * time.Second
+)
+
+// stateChangeCodes are the server errors for a node that is not, or no
+// longer, the primary, or is shutting down. The operation was not applied.
+var stateChangeCodes = []int{
+	91,    // ShutdownInProgress
+	189,   // PrimarySteppedDown
+	10107, // NotWritablePrimary
+	11600, // InterruptedAtShutdown
+	11602, // InterruptedDueToReplStateChange
+	13435, // NotPrimaryNoSecondaryOk
+	13436, // NotPrimaryOrSecondary
+}
+
+func isStateChange(err error) bool {
+	var se mongo.ServerError
+	if !errors.As(err, &se) {
+		return false
+	}
+	for _, code := range stateChangeCodes {
+		if se.HasErrorCode(code) {
+			return true
+		}
+	}
+	return false
+}
+
+// retrier retries repository operations through transient errors, so a
+// failover delays requests instead of failing them. The driver retries an
+// operation once; this keeps going, with backoff, for up to window.
+type retrier struct {
+	window time.Duration
+}
+
+// read runs a read, retrying on network errors and state changes.
+func (r retrier) read(ctx context.Context, op func(ctx context.Context) error) error {
+	return r.do(ctx, op, func(err error) bool {
+		return mongo.IsNetworkError(err) || isStateChange(err)
+	})
+}
+
+// write runs a write, retrying only on errors that show it was not
+// applied. A network error may hide a write that succeeded, so it is left
+// to the driver's own retry.
+func (r retrier) write(ctx context.Context, op func(ctx context.Context) error) error {
+	return r.do(ctx, op, isStateChange)
+}
+
+func (r retrier) do(ctx context.Context, op func(ctx context.Context) error, transient func(error) bool) error {
+	deadline := time.Now().Add(r.window)
+	delay := retryBaseDelay
+	for {
+		err := op(ctx)
+		if err == nil || !transient(err) || ctx.Err() != nil {
+			return err
+		}
+
+		// Full jitter keeps instances from retrying in lockstep.
+		wait := rand.N(delay) + time.Millisecond
+		if time.Now().Add(wait).After(deadline) {
+			return err
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		delay = min(delay*2, retryMaxDelay)
+	}
+}
+
+// findOne runs a retried FindOne, decoding the match into result.
+func (r retrier) findOne(ctx context.Context, col *mongo.Collection, filter, result any, opts ...*options.FindOneOptions) error {
+	return r.read(ctx, func(ctx context.Context) error {
+		return col.FindOne(ctx, filter, opts...).Decode(result)
+	})
+}
+
+// findAll runs a retried Find, decoding every match into results, a
+// pointer to a slice.
+func (r retrier) findAll(ctx context.Context, col *mongo.Collection, filter, results any, opts ...*options.FindOptions) error {
+	return r.read(ctx, func(ctx context.Context) error {
+		cursor, err := col.Find(ctx, filter, opts...)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = cursor.Close(ctx) }()
+		return cursor.All(ctx, results)
+	})
+}
+
+// count runs a retried CountDocuments.
+func (r retrier) count(ctx context.Context, col *mongo.Collection, filter any) (int64, error) {
+	var n int64
+	err := r.read(ctx, func(ctx context.Context) error {
+		var err error
+		n, err = col.CountDocuments(ctx, filter)
+		return err
+	})
+	return n, err
+}
@@ -0,0 +1,59 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var errStepDown = mongo.CommandError{Code: 189, Message: "primary stepped down"}
+
+func TestRetrierRetriesStateChanges(t *testing.T) {
+	r := retrier{window: time.Second}
+	calls := 0
+	err := r.write(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errStepDown
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("Expected success on the third call, got %v after %d calls", err, calls)
+	}
+}
+
+func TestRetrierGivesUp(t *testing.T) {
+	other := errors.New("duplicate key")
+	calls := 0
+	err := retrier{window: time.Second}.read(context.Background(), func(ctx context.Context) error {
+		calls++
+		return other
+	})
+	if err != other || calls != 1 {
+		t.Errorf("Expected a permanent error to be returned at once, got %v after %d calls", err, calls)
+	}
+
+	calls = 0
+	err = retrier{}.read(context.Background(), func(ctx context.Context) error {
+		calls++
+		return errStepDown
+	})
+	if !isStateChange(err) || calls != 1 {
+		t.Errorf("Expected no retries without a window, got %v after %d calls", err, calls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls = 0
+	err = retrier{window: time.Minute}.read(ctx, func(ctx context.Context) error {
+		calls++
+		cancel()
+		return errStepDown
+	})
+	if !isStateChange(err) || calls != 1 {
+		t.Errorf("Expected a cancelled context to stop retries, got %v after %d calls", err, calls)
+	}
+}
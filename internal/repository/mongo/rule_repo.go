@@ -0,0 +1,85 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RuleRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewRuleRepo(client *DbClient) *RuleRepo {
+	return &RuleRepo{
+		collection: client.DB.Collection("retrieval_rules"),
+		retry:      client.retry,
+	}
+}
+
+func (r *RuleRepo) Create(ctx context.Context, rule *document.RetrievalRule) (string, error) {
+	rule.CreatedAt = time.Now()
+	rule.UpdatedAt = time.Now()
+
+	if rule.ID == "" {
+		rule.ID = primitive.NewObjectID().Hex()
+	}
+
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, rule)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return rule.ID, nil
+}
+
+func (r *RuleRepo) GetByID(ctx context.Context, id string) (*document.RetrievalRule, error) {
+	var rule document.RetrievalRule
+	err := r.retry.findOne(ctx, r.collection, bson.M{"_id": id}, &rule)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *RuleRepo) List(ctx context.Context) ([]document.RetrievalRule, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *RuleRepo) ListActive(ctx context.Context) ([]document.RetrievalRule, error) {
+	return r.find(ctx, bson.M{"is_active": true})
+}
+
+func (r *RuleRepo) find(ctx context.Context, filter bson.M) ([]document.RetrievalRule, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	var rules []document.RetrievalRule
+	if err := r.retry.findAll(ctx, r.collection, filter, &rules, opts); err != nil {
+		return nil, err
+	}
+
+	if rules == nil {
+		rules = []document.RetrievalRule{}
+	}
+
+	return rules, nil
+}
+
+func (r *RuleRepo) Delete(ctx context.Context, id string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+		return err
+	})
+}
@@ -0,0 +1,46 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/system"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SecurityEventRepo struct {
+	col   *mongo.Collection
+	retry retrier
+}
+
+func NewSecurityEventRepo(client *DbClient) *SecurityEventRepo {
+	return &SecurityEventRepo{col: client.DB.Collection("security_events"), retry: client.retry}
+}
+
+func (r *SecurityEventRepo) Insert(ctx context.Context, event *system.SecurityEvent) error {
+	if event.ID == "" {
+		event.ID = primitive.NewObjectID().Hex()
+	}
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.col.InsertOne(ctx, event)
+		return err
+	})
+}
+
+func (r *SecurityEventRepo) List(ctx context.Context, filter system.SecurityEventFilter) ([]system.SecurityEvent, error) {
+	query := bson.M{}
+	if filter.Type != "" {
+		query["type"] = filter.Type
+	}
+	if filter.IP != "" {
+		query["ip"] = filter.IP
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(int64(filter.Limit))
+	events := []system.SecurityEvent{}
+	if err := r.retry.findAll(ctx, r.col, query, &events, opts); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
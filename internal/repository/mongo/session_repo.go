@@ -0,0 +1,97 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/user"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SessionRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewSessionRepo(client *DbClient) *SessionRepo {
+	return &SessionRepo{
+		collection: client.DB.Collection("user_sessions"),
+		retry:      client.retry,
+	}
+}
+
+func (r *SessionRepo) Create(ctx context.Context, s *user.Session) error {
+	if s.ID == "" {
+		s.ID = primitive.NewObjectID().Hex()
+	}
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, s)
+		return err
+	})
+}
+
+func (r *SessionRepo) Get(ctx context.Context, id string) (*user.Session, error) {
+	var s user.Session
+	err := r.retry.findOne(ctx, r.collection, bson.M{"_id": id}, &s)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *SessionRepo) ListByUser(ctx context.Context, userID string, now time.Time) ([]user.Session, error) {
+	sessions := []user.Session{}
+	filter := bson.M{"user_id": userID, "expires_at": bson.M{"$gt": now}}
+	opts := options.Find().SetSort(bson.D{{Key: "last_used_at", Value: -1}})
+	if err := r.retry.findAll(ctx, r.collection, filter, &sessions, opts); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *SessionRepo) Touch(ctx context.Context, id string, at time.Time) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_used_at": at}})
+		return err
+	})
+}
+
+func (r *SessionRepo) Bind(ctx context.Context, id, fingerprint string) (bool, error) {
+	filter := bson.M{"_id": id, "fingerprint": bson.M{"$in": bson.A{nil, ""}}}
+	var matched int64
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		res, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"fingerprint": fingerprint}})
+		if err != nil {
+			return err
+		}
+		matched = res.MatchedCount
+		return nil
+	})
+	return matched > 0, err
+}
+
+func (r *SessionRepo) Delete(ctx context.Context, userID, id string) (bool, error) {
+	var deleted int64
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		res, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+		if err != nil {
+			return err
+		}
+		deleted = res.DeletedCount
+		return nil
+	})
+	return deleted > 0, err
+}
+
+func (r *SessionRepo) DeleteByUser(ctx context.Context, userID string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+		return err
+	})
+}
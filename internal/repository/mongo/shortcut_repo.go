@@ -0,0 +1,93 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ShortcutRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewShortcutRepo(client *DbClient) *ShortcutRepo {
+	return &ShortcutRepo{
+		collection: client.DB.Collection("faq_shortcuts"),
+		retry:      client.retry,
+	}
+}
+
+func (r *ShortcutRepo) Create(ctx context.Context, shortcut *document.FAQShortcut) (string, error) {
+	shortcut.CreatedAt = time.Now()
+	shortcut.UpdatedAt = time.Now()
+
+	if shortcut.ID == "" {
+		shortcut.ID = primitive.NewObjectID().Hex()
+	}
+
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, shortcut)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return shortcut.ID, nil
+}
+
+func (r *ShortcutRepo) GetByID(ctx context.Context, id string) (*document.FAQShortcut, error) {
+	var shortcut document.FAQShortcut
+	err := r.retry.findOne(ctx, r.collection, bson.M{"_id": id}, &shortcut)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &shortcut, nil
+}
+
+func (r *ShortcutRepo) List(ctx context.Context) ([]document.FAQShortcut, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *ShortcutRepo) ListActive(ctx context.Context) ([]document.FAQShortcut, error) {
+	return r.find(ctx, bson.M{"is_active": true})
+}
+
+func (r *ShortcutRepo) find(ctx context.Context, filter bson.M) ([]document.FAQShortcut, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "priority", Value: -1}, {Key: "created_at", Value: -1}})
+
+	var shortcuts []document.FAQShortcut
+	if err := r.retry.findAll(ctx, r.collection, filter, &shortcuts, opts); err != nil {
+		return nil, err
+	}
+
+	if shortcuts == nil {
+		shortcuts = []document.FAQShortcut{}
+	}
+
+	return shortcuts, nil
+}
+
+func (r *ShortcutRepo) Update(ctx context.Context, shortcut *document.FAQShortcut) error {
+	shortcut.UpdatedAt = time.Now()
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": shortcut.ID}, shortcut)
+		return err
+	})
+}
+
+func (r *ShortcutRepo) Delete(ctx context.Context, id string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+		return err
+	})
+}
@@ -0,0 +1,41 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/slack"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SlackLinkRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewSlackLinkRepo(client *DbClient) *SlackLinkRepo {
+	return &SlackLinkRepo{
+		collection: client.DB.Collection("slack_user_links"),
+		retry:      client.retry,
+	}
+}
+
+func (r *SlackLinkRepo) Get(ctx context.Context, slackUserID string) (*slack.Link, error) {
+	var link slack.Link
+	err := r.retry.findOne(ctx, r.collection, bson.M{"_id": slackUserID}, &link)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *SlackLinkRepo) Upsert(ctx context.Context, link *slack.Link) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": link.SlackUserID}, link, options.Replace().SetUpsert(true))
+		return err
+	})
+}
@@ -0,0 +1,69 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SpendRepo keeps one document of model usage per day, keyed by date.
+type SpendRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewSpendRepo(client *DbClient) *SpendRepo {
+	return &SpendRepo{
+		collection: client.DB.Collection("model_spend"),
+		retry:      client.retry,
+	}
+}
+
+func (r *SpendRepo) Add(ctx context.Context, day string, promptTokens, completionTokens int, costUSD float64) (*document.DailySpend, error) {
+	update := bson.M{"$inc": bson.M{
+		"cost_usd":          costUSD,
+		"prompt_tokens":     promptTokens,
+		"completion_tokens": completionTokens,
+	}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var spend document.DailySpend
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		return r.collection.FindOneAndUpdate(ctx, bson.M{"_id": day}, update, opts).Decode(&spend)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &spend, nil
+}
+
+func (r *SpendRepo) Get(ctx context.Context, day string) (*document.DailySpend, error) {
+	var spend document.DailySpend
+	err := r.retry.findOne(ctx, r.collection, bson.M{"_id": day}, &spend)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &spend, nil
+}
+
+func (r *SpendRepo) MarkCapReached(ctx context.Context, day string, at time.Time) (bool, error) {
+	var result *mongo.UpdateResult
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = r.collection.UpdateOne(ctx,
+			bson.M{"_id": day, "cap_reached_at": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"cap_reached_at": at}})
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
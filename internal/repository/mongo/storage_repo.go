@@ -0,0 +1,232 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type StorageRepo struct {
+	documents *mongo.Collection
+	users     *mongo.Collection
+	db        *mongo.Database
+	retry     retrier
+}
+
+func NewStorageRepo(client *DbClient) *StorageRepo {
+	return &StorageRepo{
+		documents: client.DB.Collection("storage_documents"),
+		users:     client.DB.Collection("storage_users"),
+		db:        client.DB,
+		retry:     client.retry,
+	}
+}
+
+func usageInc(delta document.StorageUsage) bson.M {
+	return bson.M{
+		"documents":       delta.Documents,
+		"document_bytes":  delta.DocumentBytes,
+		"chunks":          delta.Chunks,
+		"chunk_bytes":     delta.ChunkBytes,
+		"embedding_bytes": delta.EmbeddingBytes,
+	}
+}
+
+func (r *StorageRepo) Add(ctx context.Context, userID, documentID string, delta document.StorageUsage) error {
+	upsert := options.Update().SetUpsert(true)
+	inc := usageInc(delta)
+
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.documents.UpdateOne(ctx,
+			bson.M{"_id": documentID},
+			bson.M{"$inc": inc, "$set": bson.M{"user_id": userID}},
+			upsert,
+		)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.users.UpdateOne(ctx,
+			bson.M{"_id": userID},
+			bson.M{"$inc": inc, "$set": bson.M{"updated_at": time.Now()}},
+			upsert,
+		)
+		return err
+	})
+}
+
+func (r *StorageRepo) GetDocument(ctx context.Context, documentID string) (*document.StorageUsage, error) {
+	var usage document.StorageUsage
+	if err := r.retry.findOne(ctx, r.documents, bson.M{"_id": documentID}, &usage); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &usage, nil
+}
+
+func (r *StorageRepo) DeleteDocument(ctx context.Context, documentID string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.documents.DeleteOne(ctx, bson.M{"_id": documentID})
+		return err
+	})
+}
+
+func (r *StorageRepo) GetUser(ctx context.Context, userID string) (*document.UserStorage, error) {
+	var usage document.UserStorage
+	if err := r.retry.findOne(ctx, r.users, bson.M{"_id": userID}, &usage); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &usage, nil
+}
+
+func (r *StorageRepo) ListUsers(ctx context.Context) ([]document.UserStorage, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "document_bytes", Value: -1}})
+	users := []document.UserStorage{}
+	if err := r.retry.findAll(ctx, r.users, bson.M{}, &users, opts); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// Rebuild scans documents and chunks once and replaces the stored totals.
+// Changes made while it runs may be lost, so it is meant for backfilling or
+// repairing drift during a quiet period.
+func (r *StorageRepo) Rebuild(ctx context.Context) error {
+	var byDocument map[string]*document.StorageUsage
+	var owners map[string]string
+	err := r.retry.read(ctx, func(ctx context.Context) error {
+		docCursor, err := r.db.Collection("documents").Aggregate(ctx, []bson.M{
+			{"$project": bson.M{
+				"user_id": 1,
+				"bytes":   bson.M{"$strLenBytes": bson.M{"$ifNull": bson.A{"$content", ""}}},
+			}},
+		})
+		if err != nil {
+			return err
+		}
+		defer func() { _ = docCursor.Close(ctx) }()
+
+		byDocument = map[string]*document.StorageUsage{}
+		owners = map[string]string{}
+		for docCursor.Next(ctx) {
+			var row struct {
+				ID     string `bson:"_id"`
+				UserID string `bson:"user_id"`
+				Bytes  int64  `bson:"bytes"`
+			}
+			if err := docCursor.Decode(&row); err != nil {
+				return err
+			}
+			owners[row.ID] = row.UserID
+			byDocument[row.ID] = &document.StorageUsage{Documents: 1, DocumentBytes: row.Bytes}
+		}
+		return docCursor.Err()
+	})
+	if err != nil {
+		return err
+	}
+
+	// Each chunk row sets its document's totals, so a retried scan only
+	// sets them again.
+	err = r.retry.read(ctx, func(ctx context.Context) error {
+		chunkCursor, err := r.db.Collection("chunks").Aggregate(ctx, []bson.M{
+			{"$group": bson.M{
+				"_id":         "$document_id",
+				"chunks":      bson.M{"$sum": 1},
+				"chunk_bytes": bson.M{"$sum": bson.M{"$strLenBytes": bson.M{"$ifNull": bson.A{"$content", ""}}}},
+				"embedding_bytes": bson.M{"$sum": bson.M{"$multiply": bson.A{
+					bson.M{"$size": bson.M{"$ifNull": bson.A{"$embedding", bson.A{}}}}, 8,
+				}}},
+			}},
+		})
+		if err != nil {
+			return err
+		}
+		defer func() { _ = chunkCursor.Close(ctx) }()
+
+		for chunkCursor.Next(ctx) {
+			var row struct {
+				ID             string `bson:"_id"`
+				Chunks         int64  `bson:"chunks"`
+				ChunkBytes     int64  `bson:"chunk_bytes"`
+				EmbeddingBytes int64  `bson:"embedding_bytes"`
+			}
+			if err := chunkCursor.Decode(&row); err != nil {
+				return err
+			}
+			// Chunks of deleted documents have no owner to charge.
+			usage, ok := byDocument[row.ID]
+			if !ok {
+				continue
+			}
+			usage.Chunks = row.Chunks
+			usage.ChunkBytes = row.ChunkBytes
+			usage.EmbeddingBytes = row.EmbeddingBytes
+		}
+		return chunkCursor.Err()
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	byUser := map[string]document.StorageUsage{}
+	docRecords := make([]any, 0, len(byDocument))
+	for id, usage := range byDocument {
+		byUser[owners[id]] = byUser[owners[id]].Add(*usage)
+		record := usageInc(*usage)
+		record["_id"] = id
+		record["user_id"] = owners[id]
+		docRecords = append(docRecords, record)
+	}
+	userRecords := make([]any, 0, len(byUser))
+	for userID, usage := range byUser {
+		userRecords = append(userRecords, document.UserStorage{UserID: userID, StorageUsage: usage, UpdatedAt: now})
+	}
+
+	err = r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.documents.DeleteMany(ctx, bson.M{})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if len(docRecords) > 0 {
+		err = r.retry.write(ctx, func(ctx context.Context) error {
+			_, err := r.documents.InsertMany(ctx, docRecords)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	err = r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.users.DeleteMany(ctx, bson.M{})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if len(userRecords) > 0 {
+		err = r.retry.write(ctx, func(ctx context.Context) error {
+			_, err := r.users.InsertMany(ctx, userRecords)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TagRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewTagRepo(client *DbClient) *TagRepo {
+	return &TagRepo{
+		collection: client.DB.Collection("conversation_tags"),
+		retry:      client.retry,
+	}
+}
+
+func (r *TagRepo) Create(ctx context.Context, tag *conversation.Tag) (string, error) {
+	tag.CreatedAt = time.Now()
+	tag.UpdatedAt = time.Now()
+
+	if tag.ID == "" {
+		tag.ID = primitive.NewObjectID().Hex()
+	}
+
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, tag)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return tag.ID, nil
+}
+
+func (r *TagRepo) GetByID(ctx context.Context, id string) (*conversation.Tag, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *TagRepo) GetByName(ctx context.Context, name string) (*conversation.Tag, error) {
+	return r.findOne(ctx, bson.M{"name": name})
+}
+
+func (r *TagRepo) findOne(ctx context.Context, filter bson.M) (*conversation.Tag, error) {
+	var tag conversation.Tag
+	err := r.retry.findOne(ctx, r.collection, filter, &tag)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (r *TagRepo) List(ctx context.Context) ([]conversation.Tag, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+	var tags []conversation.Tag
+	if err := r.retry.findAll(ctx, r.collection, bson.M{}, &tags, opts); err != nil {
+		return nil, err
+	}
+
+	if tags == nil {
+		tags = []conversation.Tag{}
+	}
+
+	return tags, nil
+}
+
+func (r *TagRepo) Update(ctx context.Context, tag *conversation.Tag) error {
+	tag.UpdatedAt = time.Now()
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": tag.ID}, tag)
+		return err
+	})
+}
+
+func (r *TagRepo) Delete(ctx context.Context, id string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+		return err
+	})
+}
+
+type SavedFilterRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewSavedFilterRepo(client *DbClient) *SavedFilterRepo {
+	return &SavedFilterRepo{
+		collection: client.DB.Collection("saved_filters"),
+		retry:      client.retry,
+	}
+}
+
+func (r *SavedFilterRepo) Create(ctx context.Context, filter *conversation.SavedFilter) (string, error) {
+	filter.CreatedAt = time.Now()
+	filter.UpdatedAt = time.Now()
+
+	if filter.ID == "" {
+		filter.ID = primitive.NewObjectID().Hex()
+	}
+
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, filter)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return filter.ID, nil
+}
+
+func (r *SavedFilterRepo) GetByID(ctx context.Context, id string) (*conversation.SavedFilter, error) {
+	var filter conversation.SavedFilter
+	err := r.retry.findOne(ctx, r.collection, bson.M{"_id": id}, &filter)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &filter, nil
+}
+
+func (r *SavedFilterRepo) ListByUser(ctx context.Context, userID string) ([]conversation.SavedFilter, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+	var filters []conversation.SavedFilter
+	if err := r.retry.findAll(ctx, r.collection, bson.M{"user_id": userID}, &filters, opts); err != nil {
+		return nil, err
+	}
+
+	if filters == nil {
+		filters = []conversation.SavedFilter{}
+	}
+
+	return filters, nil
+}
+
+func (r *SavedFilterRepo) Update(ctx context.Context, filter *conversation.SavedFilter) error {
+	filter.UpdatedAt = time.Now()
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": filter.ID}, filter)
+		return err
+	})
+}
+
+func (r *SavedFilterRepo) Delete(ctx context.Context, id string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+		return err
+	})
+}
+
+func (r *SavedFilterRepo) RenameTag(ctx context.Context, from, to string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		return renameTag(ctx, r.collection, from, to)
+	})
+}
+
+// renameTag renames a tag in the tags array of every document in col that
+// has it, or takes it out when to is empty. A document that already has
+// to just loses from.
+func renameTag(ctx context.Context, col *mongo.Collection, from, to string) error {
+	if to != "" {
+		_, err := col.UpdateMany(ctx,
+			bson.M{"tags": from},
+			bson.M{"$addToSet": bson.M{"tags": to}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := col.UpdateMany(ctx,
+		bson.M{"tags": from},
+		bson.M{"$pull": bson.M{"tags": from}},
+	)
+	return err
+}
@@ -0,0 +1,185 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/tool"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ToolRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewToolRepo(client *DbClient) *ToolRepo {
+	return &ToolRepo{
+		collection: client.DB.Collection("rag_tools"),
+		retry:      client.retry,
+	}
+}
+
+func (r *ToolRepo) Create(ctx context.Context, t *tool.Tool) (string, error) {
+	t.CreatedAt = time.Now()
+	t.UpdatedAt = time.Now()
+
+	if t.ID == "" {
+		t.ID = primitive.NewObjectID().Hex()
+	}
+
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, t)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return t.ID, nil
+}
+
+func (r *ToolRepo) GetByID(ctx context.Context, id string) (*tool.Tool, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *ToolRepo) GetByName(ctx context.Context, name string) (*tool.Tool, error) {
+	return r.findOne(ctx, bson.M{"name": name})
+}
+
+func (r *ToolRepo) findOne(ctx context.Context, filter bson.M) (*tool.Tool, error) {
+	var t tool.Tool
+	err := r.retry.findOne(ctx, r.collection, filter, &t)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *ToolRepo) List(ctx context.Context) ([]tool.Tool, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *ToolRepo) ListActive(ctx context.Context) ([]tool.Tool, error) {
+	return r.find(ctx, bson.M{"is_active": true})
+}
+
+func (r *ToolRepo) find(ctx context.Context, filter bson.M) ([]tool.Tool, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+	var tools []tool.Tool
+	if err := r.retry.findAll(ctx, r.collection, filter, &tools, opts); err != nil {
+		return nil, err
+	}
+
+	if tools == nil {
+		tools = []tool.Tool{}
+	}
+
+	return tools, nil
+}
+
+func (r *ToolRepo) Update(ctx context.Context, t *tool.Tool) error {
+	t.UpdatedAt = time.Now()
+
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": t.ID}, t)
+		return err
+	})
+}
+
+func (r *ToolRepo) Delete(ctx context.Context, id string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+		return err
+	})
+}
+
+type ToolInvocationRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewToolInvocationRepo(client *DbClient) *ToolInvocationRepo {
+	return &ToolInvocationRepo{
+		collection: client.DB.Collection("rag_tool_invocations"),
+		retry:      client.retry,
+	}
+}
+
+func (r *ToolInvocationRepo) Create(ctx context.Context, inv *tool.Invocation) error {
+	if inv.ID == "" {
+		inv.ID = primitive.NewObjectID().Hex()
+	}
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, inv)
+		return err
+	})
+}
+
+func (r *ToolInvocationRepo) List(ctx context.Context, toolID string, limit, offset int) ([]tool.Invocation, int64, error) {
+	filter := bson.M{}
+	if toolID != "" {
+		filter["tool_id"] = toolID
+	}
+
+	total, err := r.retry.count(ctx, r.collection, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset))
+
+	var invocations []tool.Invocation
+	if err := r.retry.findAll(ctx, r.collection, filter, &invocations, opts); err != nil {
+		return nil, 0, err
+	}
+
+	if invocations == nil {
+		invocations = []tool.Invocation{}
+	}
+
+	return invocations, total, nil
+}
+
+func (r *ToolInvocationRepo) Failures(ctx context.Context, start, end time.Time) ([]tool.FailureCount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"created_at": bson.M{"$gte": start, "$lt": end},
+			"error":      bson.M{"$nin": bson.A{nil, ""}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "created_at", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":        "$tool_name",
+			"failures":   bson.M{"$sum": 1},
+			"last_error": bson.M{"$last": "$error"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "failures", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+
+	var failures []tool.FailureCount
+	err := r.retry.read(ctx, func(ctx context.Context) error {
+		cursor, err := r.collection.Aggregate(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = cursor.Close(ctx) }()
+		return cursor.All(ctx, &failures)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if failures == nil {
+		failures = []tool.FailureCount{}
+	}
+	return failures, nil
+}
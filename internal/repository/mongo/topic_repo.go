@@ -0,0 +1,142 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/topic"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// QuestionRepo keeps recent questions for topic clustering.
+type QuestionRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewQuestionRepo(client *DbClient) *QuestionRepo {
+	return &QuestionRepo{
+		collection: client.DB.Collection("topic_questions"),
+		retry:      client.retry,
+	}
+}
+
+func (r *QuestionRepo) Create(ctx context.Context, question *topic.Question) error {
+	if question.ID == "" {
+		question.ID = primitive.NewObjectID().Hex()
+	}
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, question)
+		return err
+	})
+}
+
+func (r *QuestionRepo) ListSince(ctx context.Context, since time.Time, limit int) ([]topic.Question, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "asked_at", Value: -1}}).
+		SetLimit(int64(limit))
+
+	var questions []topic.Question
+	if err := r.retry.findAll(ctx, r.collection, bson.M{"asked_at": bson.M{"$gte": since}}, &questions, opts); err != nil {
+		return nil, err
+	}
+	if questions == nil {
+		questions = []topic.Question{}
+	}
+	return questions, nil
+}
+
+func (r *QuestionRepo) SetEmbeddings(ctx context.Context, model string, vectors map[string][]float64) error {
+	if len(vectors) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, 0, len(vectors))
+	for id, vector := range vectors {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id}).
+			SetUpdate(bson.M{"$set": bson.M{"embedding": vector, "embedding_model": model}}))
+	}
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		return err
+	})
+}
+
+func (r *QuestionRepo) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	var result *mongo.DeleteResult
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = r.collection.DeleteMany(ctx, bson.M{"asked_at": bson.M{"$lt": before}})
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// TopicSnapshotRepo stores the topics found by each clustering run.
+type TopicSnapshotRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewTopicSnapshotRepo(client *DbClient) *TopicSnapshotRepo {
+	return &TopicSnapshotRepo{
+		collection: client.DB.Collection("topic_snapshots"),
+		retry:      client.retry,
+	}
+}
+
+func (r *TopicSnapshotRepo) Create(ctx context.Context, snapshot *topic.Snapshot) (string, error) {
+	snapshot.CreatedAt = time.Now()
+	if snapshot.ID == "" {
+		snapshot.ID = primitive.NewObjectID().Hex()
+	}
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, snapshot)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return snapshot.ID, nil
+}
+
+func (r *TopicSnapshotRepo) GetByID(ctx context.Context, id string) (*topic.Snapshot, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *TopicSnapshotRepo) Latest(ctx context.Context) (*topic.Snapshot, error) {
+	return r.findOne(ctx, bson.M{}, options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+}
+
+func (r *TopicSnapshotRepo) findOne(ctx context.Context, filter bson.M, opts ...*options.FindOneOptions) (*topic.Snapshot, error) {
+	var snapshot topic.Snapshot
+	err := r.retry.findOne(ctx, r.collection, filter, &snapshot, opts...)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+func (r *TopicSnapshotRepo) List(ctx context.Context, limit int) ([]topic.Snapshot, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(int64(limit))
+
+	var snapshots []topic.Snapshot
+	if err := r.retry.findAll(ctx, r.collection, bson.M{}, &snapshots, opts); err != nil {
+		return nil, err
+	}
+	if snapshots == nil {
+		snapshots = []topic.Snapshot{}
+	}
+	return snapshots, nil
+}
@@ -12,11 +12,13 @@ import (
 
 type UserRepo struct {
 	collection *mongo.Collection
+	retry      retrier
 }
 
 func NewUserRepo(client *DbClient) *UserRepo {
 	return &UserRepo{
 		collection: client.DB.Collection("users"),
+		retry:      client.retry,
 	}
 }
 
@@ -28,7 +30,10 @@ func (r *UserRepo) Create(ctx context.Context, u *user.User) (string, error) {
 		u.ID = primitive.NewObjectID().Hex()
 	}
 
-	_, err := r.collection.InsertOne(ctx, u)
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, u)
+		return err
+	})
 	if err != nil {
 		return "", err
 	}
@@ -38,7 +43,7 @@ func (r *UserRepo) Create(ctx context.Context, u *user.User) (string, error) {
 
 func (r *UserRepo) GetByID(ctx context.Context, id string) (*user.User, error) {
 	var u user.User
-	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&u)
+	err := r.retry.findOne(ctx, r.collection, bson.M{"_id": id}, &u)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil
@@ -50,7 +55,7 @@ func (r *UserRepo) GetByID(ctx context.Context, id string) (*user.User, error) {
 
 func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*user.User, error) {
 	var u user.User
-	err := r.collection.FindOne(ctx, bson.M{"email": email}).Decode(&u)
+	err := r.retry.findOne(ctx, r.collection, bson.M{"email": email}, &u)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil
@@ -63,10 +68,21 @@ func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*user.User, er
 func (r *UserRepo) Update(ctx context.Context, u *user.User) error {
 	u.UpdatedAt = time.Now()
 
-	_, err := r.collection.UpdateOne(
-		ctx,
-		bson.M{"_id": u.ID},
-		bson.M{"$set": u},
-	)
-	return err
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.UpdateOne(
+			ctx,
+			bson.M{"_id": u.ID},
+			bson.M{"$set": u},
+		)
+		return err
+	})
+}
+
+func (r *UserRepo) ListByRole(ctx context.Context, role user.Role) ([]user.User, error) {
+	var users []user.User
+	filter := bson.M{"role": role, "is_active": true}
+	if err := r.retry.findAll(ctx, r.collection, filter, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
 }
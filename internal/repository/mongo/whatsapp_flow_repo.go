@@ -0,0 +1,85 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/whatsapp"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WhatsAppFlowRepo struct {
+	collection *mongo.Collection
+	retry      retrier
+}
+
+func NewWhatsAppFlowRepo(client *DbClient) *WhatsAppFlowRepo {
+	return &WhatsAppFlowRepo{
+		collection: client.DB.Collection("whatsapp_flows"),
+		retry:      client.retry,
+	}
+}
+
+func (r *WhatsAppFlowRepo) Create(ctx context.Context, flow *whatsapp.Flow) (string, error) {
+	flow.CreatedAt = time.Now()
+	flow.UpdatedAt = time.Now()
+
+	if flow.ID == "" {
+		flow.ID = primitive.NewObjectID().Hex()
+	}
+
+	err := r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.InsertOne(ctx, flow)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return flow.ID, nil
+}
+
+func (r *WhatsAppFlowRepo) GetByID(ctx context.Context, id string) (*whatsapp.Flow, error) {
+	var flow whatsapp.Flow
+	err := r.retry.findOne(ctx, r.collection, bson.M{"_id": id}, &flow)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &flow, nil
+}
+
+func (r *WhatsAppFlowRepo) List(ctx context.Context) ([]whatsapp.Flow, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+
+	var flows []whatsapp.Flow
+	if err := r.retry.findAll(ctx, r.collection, bson.M{}, &flows, opts); err != nil {
+		return nil, err
+	}
+
+	if flows == nil {
+		flows = []whatsapp.Flow{}
+	}
+
+	return flows, nil
+}
+
+func (r *WhatsAppFlowRepo) Update(ctx context.Context, flow *whatsapp.Flow) error {
+	flow.UpdatedAt = time.Now()
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": flow.ID}, flow)
+		return err
+	})
+}
+
+func (r *WhatsAppFlowRepo) Delete(ctx context.Context, id string) error {
+	return r.retry.write(ctx, func(ctx context.Context) error {
+		_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+		return err
+	})
+}
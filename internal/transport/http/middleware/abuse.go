@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/system"
+	"github.com/gin-gonic/gin"
+)
+
+// AbuseDetector spots abuse of public endpoints and blocks its source.
+type AbuseDetector interface {
+	Check(ctx context.Context, r system.AbuseRequest) (time.Time, bool)
+	Observe(ctx context.Context, r system.AbuseRequest) (time.Time, bool)
+}
+
+// AbuseGuard turns away blocked clients of the public endpoints it guards
+// with 403 until their block ends. scope names the endpoints in security
+// events. With failuresOnly, only requests answered with a 4xx count
+// towards a burst, so that providers delivering webhooks in bulk are not
+// taken for abuse while clients probing with bad signatures are.
+func AbuseGuard(detector AbuseDetector, scope string, failuresOnly bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := system.AbuseRequest{Scope: scope, IP: c.ClientIP()}
+		r.Session, _ = strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+
+		ctx := c.Request.Context()
+		if until, blocked := detector.Check(ctx, r); blocked {
+			abortBlocked(c, until)
+			return
+		}
+		if failuresOnly {
+			c.Next()
+			if status := c.Writer.Status(); status >= 400 && status < 500 {
+				detector.Observe(ctx, r)
+			}
+			return
+		}
+		if until, blocked := detector.Observe(ctx, r); blocked {
+			abortBlocked(c, until)
+			return
+		}
+		c.Next()
+	}
+}
+
+func abortBlocked(c *gin.Context, until time.Time) {
+	seconds := math.Ceil(time.Until(until).Seconds())
+	c.Header("Retry-After", strconv.Itoa(max(int(seconds), 1)))
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		"error":         "too many suspicious requests, try again later",
+		"blocked_until": until,
+	})
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/system"
+	"github.com/gin-gonic/gin"
+)
+
+// countingDetector blocks the client once it has observed more than limit
+// requests.
+type countingDetector struct {
+	limit    int
+	observed []system.AbuseRequest
+	until    time.Time
+}
+
+func (d *countingDetector) Check(ctx context.Context, r system.AbuseRequest) (time.Time, bool) {
+	return d.until, len(d.observed) > d.limit
+}
+
+func (d *countingDetector) Observe(ctx context.Context, r system.AbuseRequest) (time.Time, bool) {
+	d.observed = append(d.observed, r)
+	return d.until, len(d.observed) > d.limit
+}
+
+func TestAbuseGuard(t *testing.T) {
+	detector := &countingDetector{limit: 2, until: time.Now().Add(90 * time.Second)}
+	router := setupTestRouter()
+	router.Use(AbuseGuard(detector, "widget", false))
+	router.POST("/messages", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	var codes []int
+	for i := 0; i < 4; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/messages", nil)
+		req.Header.Set("Authorization", "Bearer session-1")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		codes = append(codes, resp.Code)
+		if resp.Code == http.StatusForbidden && resp.Header().Get("Retry-After") != "90" {
+			t.Errorf("Expected Retry-After 90, got %q", resp.Header().Get("Retry-After"))
+		}
+	}
+
+	want := []int{http.StatusOK, http.StatusOK, http.StatusForbidden, http.StatusForbidden}
+	for i := range want {
+		if codes[i] != want[i] {
+			t.Fatalf("Expected statuses %v, got %v", want, codes)
+		}
+	}
+	if detector.observed[0].Session != "session-1" || detector.observed[0].Scope != "widget" {
+		t.Errorf("Unexpected request observed: %+v", detector.observed[0])
+	}
+}
+
+func TestAbuseGuardFailuresOnly(t *testing.T) {
+	detector := &countingDetector{limit: 1, until: time.Now().Add(time.Minute)}
+	router := setupTestRouter()
+	router.Use(AbuseGuard(detector, "webhook", true))
+	router.POST("/webhook", func(c *gin.Context) {
+		if c.GetHeader("X-Signature") != "good" {
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	send := func(signature string) int {
+		req := httptest.NewRequest(http.MethodPost, "/webhook", nil)
+		req.Header.Set("X-Signature", signature)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	for i := 0; i < 5; i++ {
+		if code := send("good"); code != http.StatusOK {
+			t.Fatalf("Expected signed deliveries let through, got %d", code)
+		}
+	}
+	if len(detector.observed) != 0 {
+		t.Errorf("Expected successful deliveries not observed, got %d", len(detector.observed))
+	}
+
+	send("bad")
+	send("bad")
+	if code := send("good"); code != http.StatusForbidden {
+		t.Errorf("Expected the client blocked after repeated failures, got %d", code)
+	}
+}
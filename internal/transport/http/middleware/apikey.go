@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	integrationApp "github.com/elprogramadorgt/lucidRAG/internal/application/integration"
+	integrationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/integration"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+const apiKeyHeader = "X-API-Key"
+
+// APIKeyAuthenticator resolves an integration API key.
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (*integrationDomain.APIKey, error)
+}
+
+// APIKeyAuth authenticates integration requests by their X-API-Key header.
+// Requests act as the admin who created the key.
+func APIKeyAuth(auth APIKeyAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw := c.GetHeader(apiKeyHeader)
+		if raw == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "api key required"})
+			return
+		}
+
+		key, err := auth.Authenticate(c.Request.Context(), raw)
+		if err != nil {
+			if errors.Is(err, integrationApp.ErrInvalidAPIKey) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid api key"})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to verify api key"})
+			return
+		}
+
+		c.Set("api_key", key)
+		c.Set("user_id", key.CreatedBy)
+		c.Request = c.Request.WithContext(logger.ContextWithUserID(c.Request.Context(), key.CreatedBy))
+		c.Next()
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	integrationApp "github.com/elprogramadorgt/lucidRAG/internal/application/integration"
+	integrationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/integration"
+	"github.com/gin-gonic/gin"
+)
+
+type mockAuthenticator struct{}
+
+func (mockAuthenticator) Authenticate(ctx context.Context, key string) (*integrationDomain.APIKey, error) {
+	if key != "lrk_valid" {
+		return nil, integrationApp.ErrInvalidAPIKey
+	}
+	return &integrationDomain.APIKey{ID: "key-1", CreatedBy: "admin-1"}, nil
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(APIKeyAuth(mockAuthenticator{}))
+	r.GET("/hook", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("user_id"))
+	})
+
+	tests := []struct {
+		name     string
+		key      string
+		expected int
+	}{
+		{"missing key", "", http.StatusUnauthorized},
+		{"invalid key", "lrk_nope", http.StatusUnauthorized},
+		{"valid key", "lrk_valid", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/hook", nil)
+			if tt.key != "" {
+				req.Header.Set("X-API-Key", tt.key)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, w.Code)
+			}
+			if tt.expected == http.StatusOK && w.Body.String() != "admin-1" {
+				t.Errorf("Expected requests to act as the key's creator, got %q", w.Body.String())
+			}
+		})
+	}
+}
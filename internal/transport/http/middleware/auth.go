@@ -1,35 +1,24 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
+	userApp "github.com/elprogramadorgt/lucidRAG/internal/application/user"
 	userDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/user"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
 	"github.com/gin-gonic/gin"
 )
 
 const cookieName = "lucidrag_token"
 
+// deviceIDHeader carries the random ID the admin panel keeps per browser.
+const deviceIDHeader = "X-Device-ID"
+
 func AuthMiddleware(userSvc userDomain.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var token string
-
-		// First, try to get token from cookie (primary method for browser clients)
-		if cookieToken, err := c.Cookie(cookieName); err == nil && cookieToken != "" {
-			token = cookieToken
-		}
-
-		// Fall back to Authorization header (for API clients)
-		if token == "" {
-			authHeader := c.GetHeader("Authorization")
-			if authHeader != "" {
-				parts := strings.Split(authHeader, " ")
-				if len(parts) == 2 && strings.ToLower(parts[0]) == "bearer" {
-					token = parts[1]
-				}
-			}
-		}
-
+		token := requestToken(c)
 		if token == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
 			return
@@ -41,13 +30,47 @@ func AuthMiddleware(userSvc userDomain.Service) gin.HandlerFunc {
 			return
 		}
 
+		device := userApp.NewDevice(c.Request.UserAgent(), c.GetHeader(deviceIDHeader), c.ClientIP())
+		if err := userSvc.CheckSession(userApp.ContextWithDevice(c.Request.Context(), device), claims); err != nil {
+			if errors.Is(err, userApp.ErrDeviceChanged) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "device changed, sign in again", "reauthenticate": true})
+				return
+			}
+			if errors.Is(err, userApp.ErrInvalidToken) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to verify session"})
+			return
+		}
+
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
 		c.Set("user_role", claims.Role)
+		c.Set("session_id", claims.SessionID)
+		c.Request = c.Request.WithContext(logger.ContextWithUserID(c.Request.Context(), claims.UserID))
 		c.Next()
 	}
 }
 
+// requestToken returns the session token of a request, or "".
+func requestToken(c *gin.Context) string {
+	// First, try to get token from cookie (primary method for browser clients)
+	if cookieToken, err := c.Cookie(cookieName); err == nil && cookieToken != "" {
+		return cookieToken
+	}
+
+	// Fall back to Authorization header (for API clients)
+	authHeader := c.GetHeader("Authorization")
+	if authHeader != "" {
+		parts := strings.Split(authHeader, " ")
+		if len(parts) == 2 && strings.ToLower(parts[0]) == "bearer" {
+			return parts[1]
+		}
+	}
+	return ""
+}
+
 func RequireRole(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userRole := c.GetString("user_role")
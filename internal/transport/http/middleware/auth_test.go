@@ -5,15 +5,21 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	userApp "github.com/elprogramadorgt/lucidRAG/internal/application/user"
 	userDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/user"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
 	"github.com/gin-gonic/gin"
 )
 
 // mockUserService is a mock implementation of user.Service
 type mockUserService struct {
-	validateTokenFunc func(token string) (*userDomain.Claims, error)
+	validateTokenFunc  func(token string) (*userDomain.Claims, error)
+	checkSessionFunc   func(ctx context.Context, claims *userDomain.Claims) error
+	getPreferencesFunc func(ctx context.Context, userID string) (*userDomain.Preferences, error)
+	getUserFunc        func(ctx context.Context, id string) (*userDomain.User, error)
 }
 
 func (m *mockUserService) Register(ctx context.Context, newUser userDomain.User) (*userDomain.User, error) {
@@ -29,6 +35,9 @@ func (m *mockUserService) Login(ctx context.Context, email, password string) (st
 }
 
 func (m *mockUserService) GetUser(ctx context.Context, id string) (*userDomain.User, error) {
+	if m.getUserFunc != nil {
+		return m.getUserFunc(ctx, id)
+	}
 	return nil, nil
 }
 
@@ -43,10 +52,44 @@ func (m *mockUserService) ValidateToken(token string) (*userDomain.Claims, error
 	return nil, errors.New("invalid token")
 }
 
-func (m *mockUserService) GenerateToken(user *userDomain.User) (string, error) {
+func (m *mockUserService) CheckSession(ctx context.Context, claims *userDomain.Claims) error {
+	if m.checkSessionFunc != nil {
+		return m.checkSessionFunc(ctx, claims)
+	}
+	return nil
+}
+
+func (m *mockUserService) GenerateToken(ctx context.Context, user *userDomain.User) (string, error) {
 	return "", nil
 }
 
+func (m *mockUserService) GetPreferences(ctx context.Context, userID string) (*userDomain.Preferences, error) {
+	if m.getPreferencesFunc != nil {
+		return m.getPreferencesFunc(ctx, userID)
+	}
+	return userDomain.DefaultPreferences(userID), nil
+}
+
+func (m *mockUserService) UpdatePreferences(ctx context.Context, prefs *userDomain.Preferences) (*userDomain.Preferences, error) {
+	return prefs, nil
+}
+
+func (m *mockUserService) ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) error {
+	return nil
+}
+
+func (m *mockUserService) SetActive(ctx context.Context, userID string, active bool) (*userDomain.User, error) {
+	return nil, nil
+}
+
+func (m *mockUserService) ListSessions(ctx context.Context, userID, currentID string) ([]userDomain.Session, error) {
+	return nil, nil
+}
+
+func (m *mockUserService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	return nil
+}
+
 func setupTestRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	return gin.New()
@@ -90,6 +133,32 @@ func TestAuthMiddlewareWithValidCookie(t *testing.T) {
 	}
 }
 
+func TestAuthMiddlewareSetsContextUser(t *testing.T) {
+	mockSvc := &mockUserService{
+		validateTokenFunc: func(token string) (*userDomain.Claims, error) {
+			return &userDomain.Claims{UserID: "user-123", Role: "user"}, nil
+		},
+	}
+
+	router := setupTestRouter()
+	router.Use(RequestID(), AuthMiddleware(mockSvc))
+	var requestID, userID string
+	router.GET("/protected", func(c *gin.Context) {
+		requestID = logger.RequestID(c.Request.Context())
+		userID = logger.UserID(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	req, _ := http.NewRequest("GET", "/protected", nil)
+	req.Header.Set("Authorization", "Bearer valid-token")
+	req.Header.Set("X-Request-ID", "req-1")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if requestID != "req-1" || userID != "user-123" {
+		t.Errorf("Expected req-1 and user-123 in the context, got %q and %q", requestID, userID)
+	}
+}
+
 func TestAuthMiddlewareWithValidBearer(t *testing.T) {
 	mockSvc := &mockUserService{
 		validateTokenFunc: func(token string) (*userDomain.Claims, error) {
@@ -330,3 +399,94 @@ func TestRequireRoleMultipleRoles(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthMiddlewareRevokedSession(t *testing.T) {
+	mockSvc := &mockUserService{
+		validateTokenFunc: func(token string) (*userDomain.Claims, error) {
+			return &userDomain.Claims{UserID: "user-123", Role: "user"}, nil
+		},
+		checkSessionFunc: func(ctx context.Context, claims *userDomain.Claims) error {
+			return userApp.ErrInvalidToken
+		},
+	}
+
+	router := setupTestRouter()
+	router.Use(AuthMiddleware(mockSvc))
+	router.GET("/protected", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	req, _ := http.NewRequest("GET", "/protected", nil)
+	req.Header.Set("Authorization", "Bearer revoked-token")
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", resp.Code)
+	}
+}
+
+func TestAuthMiddlewareSessionCheckFailure(t *testing.T) {
+	mockSvc := &mockUserService{
+		validateTokenFunc: func(token string) (*userDomain.Claims, error) {
+			return &userDomain.Claims{UserID: "user-123", Role: "user"}, nil
+		},
+		checkSessionFunc: func(ctx context.Context, claims *userDomain.Claims) error {
+			return errors.New("database unavailable")
+		},
+	}
+
+	router := setupTestRouter()
+	router.Use(AuthMiddleware(mockSvc))
+	router.GET("/protected", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	req, _ := http.NewRequest("GET", "/protected", nil)
+	req.Header.Set("Authorization", "Bearer valid-token")
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", resp.Code)
+	}
+}
+
+func TestAuthMiddlewareDeviceChanged(t *testing.T) {
+	var device userApp.Device
+	mockSvc := &mockUserService{
+		validateTokenFunc: func(token string) (*userDomain.Claims, error) {
+			return &userDomain.Claims{UserID: "admin-1", Role: "admin", SessionID: "session-1"}, nil
+		},
+		checkSessionFunc: func(ctx context.Context, claims *userDomain.Claims) error {
+			device = userApp.DeviceFromContext(ctx)
+			return userApp.ErrDeviceChanged
+		},
+	}
+
+	router := setupTestRouter()
+	router.Use(AuthMiddleware(mockSvc))
+	router.GET("/protected", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	req, _ := http.NewRequest("GET", "/protected", nil)
+	req.Header.Set("Authorization", "Bearer stolen-token")
+	req.Header.Set("User-Agent", "curl/8.0")
+	req.Header.Set("X-Device-ID", "device-2")
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", resp.Code)
+	}
+	if !strings.Contains(resp.Body.String(), `"reauthenticate":true`) {
+		t.Errorf("Expected the client told to sign in again, got %s", resp.Body.String())
+	}
+	if device.Fingerprint != userApp.NewDevice("curl/8.0", "device-2", "").Fingerprint {
+		t.Error("Expected the session checked against the requesting device")
+	}
+}
@@ -1,8 +1,9 @@
 package middleware
 
 import (
-	"context"
 	"net/http"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
@@ -18,8 +19,7 @@ func RequestID() gin.HandlerFunc {
 		}
 		c.Set("request_id", requestID)
 		c.Writer.Header().Set("X-Request-ID", requestID)
-		ctx := context.WithValue(c.Request.Context(), logger.RequestIDKey, requestID)
-		c.Request = c.Request.WithContext(ctx)
+		c.Request = c.Request.WithContext(logger.ContextWithRequestID(c.Request.Context(), requestID))
 		c.Next()
 	}
 }
@@ -29,8 +29,9 @@ func Logger(log *logger.Logger) gin.HandlerFunc {
 		start := time.Now()
 		path := c.Request.URL.Path
 		c.Next()
-		log.Info("request",
-			"id", c.GetString("request_id"),
+		// The request and user IDs come from the context, as the user is
+		// only known once authentication has run.
+		log.InfoContext(c.Request.Context(), "request",
 			"method", c.Request.Method,
 			"path", path,
 			"status", c.Writer.Status(),
@@ -39,6 +40,59 @@ func Logger(log *logger.Logger) gin.HandlerFunc {
 	}
 }
 
+// CountRequests adds every request to counter, for the request rate in the
+// metrics history.
+func CountRequests(counter *atomic.Int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		counter.Add(1)
+		c.Next()
+	}
+}
+
+// RouteRecorder aggregates finished requests by route.
+type RouteRecorder interface {
+	Record(method, route string, status int, latency time.Duration, now time.Time)
+}
+
+// RecordRoutes reports every request's status and latency to recorder under
+// its route pattern. Requests that match no route share one entry.
+func RecordRoutes(recorder RouteRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		now := time.Now()
+		recorder.Record(c.Request.Method, route, c.Writer.Status(), now.Sub(start), now)
+	}
+}
+
+// PublicCORS lets any origin call the routes under prefix without
+// credentials, for endpoints embedded in other sites. The handlers check
+// the origin against the key the request uses. It must run before CORS,
+// which answers preflight requests for everything else.
+func PublicCORS(prefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.HasPrefix(c.Request.URL.Path, prefix) {
+			c.Next()
+			return
+		}
+		if origin := c.Request.Header.Get("Origin"); origin != "" {
+			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+			c.Writer.Header().Add("Vary", "Origin")
+		}
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		if c.Request.Method == "OPTIONS" {
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
+
 func CORS(origins []string) gin.HandlerFunc {
 	allowed := make(map[string]bool, len(origins))
 	for _, o := range origins {
@@ -50,7 +104,7 @@ func CORS(origins []string) gin.HandlerFunc {
 			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
 			c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
 		}
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID, X-Confirm-Token, X-Device-ID")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(http.StatusNoContent)
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
 	"github.com/gin-gonic/gin"
@@ -178,8 +179,8 @@ func TestCORS(t *testing.T) {
 		}
 
 		headers := resp.Header().Get("Access-Control-Allow-Headers")
-		if headers != "Content-Type, Authorization, X-Request-ID" {
-			t.Errorf("Expected headers 'Content-Type, Authorization, X-Request-ID', got '%s'", headers)
+		if headers != "Content-Type, Authorization, X-Request-ID, X-Confirm-Token, X-Device-ID" {
+			t.Errorf("Expected headers 'Content-Type, Authorization, X-Request-ID, X-Confirm-Token, X-Device-ID', got '%s'", headers)
 		}
 	})
 
@@ -216,3 +217,76 @@ func TestCORSEmptyOrigins(t *testing.T) {
 		t.Errorf("Expected no allowed origin for empty config, got '%s'", allowedOrigin)
 	}
 }
+
+func TestPublicCORS(t *testing.T) {
+	router := setupCommonTestRouter()
+	router.Use(PublicCORS("/public/"))
+	router.Use(CORS([]string{"http://localhost:4200"}))
+	router.POST("/public/chat", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	req, _ := http.NewRequest("OPTIONS", "/public/chat", nil)
+	req.Header.Set("Origin", "https://www.example.com")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", resp.Code)
+	}
+	if got := resp.Header().Get("Access-Control-Allow-Origin"); got != "https://www.example.com" {
+		t.Errorf("Expected widget origin allowed, got '%s'", got)
+	}
+	if got := resp.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("Expected no credentials for public routes, got '%s'", got)
+	}
+
+	req, _ = http.NewRequest("OPTIONS", "/api/private", nil)
+	req.Header.Set("Origin", "https://www.example.com")
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if got := resp.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Expected widget origin refused outside the prefix, got '%s'", got)
+	}
+}
+
+type recordedRequest struct {
+	method, route string
+	status        int
+}
+
+type mockRouteRecorder struct {
+	requests []recordedRequest
+}
+
+func (m *mockRouteRecorder) Record(method, route string, status int, latency time.Duration, now time.Time) {
+	m.requests = append(m.requests, recordedRequest{method, route, status})
+}
+
+func TestRecordRoutes(t *testing.T) {
+	recorder := &mockRouteRecorder{}
+	router := setupCommonTestRouter()
+	router.Use(RecordRoutes(recorder))
+	router.GET("/documents/:id", func(c *gin.Context) {
+		c.String(http.StatusInternalServerError, "boom")
+	})
+
+	for _, path := range []string{"/documents/abc", "/missing"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	want := []recordedRequest{
+		{"GET", "/documents/:id", http.StatusInternalServerError},
+		{"GET", "unmatched", http.StatusNotFound},
+	}
+	if len(recorder.requests) != len(want) {
+		t.Fatalf("Expected %d recorded requests, got %d", len(want), len(recorder.requests))
+	}
+	for i, w := range want {
+		if recorder.requests[i] != w {
+			t.Errorf("Expected %+v, got %+v", w, recorder.requests[i])
+		}
+	}
+}
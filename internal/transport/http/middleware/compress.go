@@ -0,0 +1,228 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	gzipWriters = sync.Pool{New: func() any { w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression); return w }}
+	zlibWriters = sync.Pool{New: func() any { w, _ := zlib.NewWriterLevel(io.Discard, zlib.DefaultCompression); return w }}
+)
+
+// compressibleTypes are the content types worth compressing; images,
+// archives and PDFs already are.
+var compressibleTypes = []string{
+	"application/json",
+	"application/x-ndjson",
+	"application/javascript",
+	"application/xml",
+	"image/svg+xml",
+	"text/",
+}
+
+// Compress gzips or deflates responses for clients that accept it, once
+// they reach minSize bytes; smaller ones are sent as they are, as the
+// framing would outweigh the saving. Routes whose pattern starts with an
+// exclude prefix, upgrades and server-sent events are never compressed,
+// so streams reach the client as soon as they are flushed.
+func Compress(minSize int, exclude []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		encoding := acceptedEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" || c.GetHeader("Upgrade") != "" || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+		route := c.FullPath()
+		for _, prefix := range exclude {
+			if strings.HasPrefix(route, prefix) {
+				c.Next()
+				return
+			}
+		}
+
+		original := c.Writer
+		cw := &compressWriter{ResponseWriter: original, encoding: encoding, minSize: minSize}
+		c.Writer = cw
+		c.Next()
+		cw.finish()
+		c.Writer = original
+	}
+}
+
+// acceptedEncoding picks gzip, else deflate, from an Accept-Encoding
+// header, skipping codings refused with q=0.
+func acceptedEncoding(header string) string {
+	accepted := map[string]bool{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, _ = strconv.ParseFloat(v, 64)
+		}
+		accepted[strings.ToLower(strings.TrimSpace(name))] = q > 0
+	}
+	for _, encoding := range []string{"gzip", "deflate"} {
+		if accepted[encoding] || (accepted["*"] && !refused(accepted, encoding)) {
+			return encoding
+		}
+	}
+	return ""
+}
+
+func refused(accepted map[string]bool, encoding string) bool {
+	ok, listed := accepted[encoding]
+	return listed && !ok
+}
+
+// compressWriter holds back the first minSize bytes of a response to see
+// whether it is worth compressing, then either compresses the rest or
+// passes it through. Headers are only sent once that is decided.
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	minSize  int
+
+	buf     []byte
+	decided bool
+	enc     interface {
+		io.WriteCloser
+		Flush() error
+	}
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		if len(w.buf)+len(data) < w.minSize {
+			w.buf = append(w.buf, data...)
+			return len(data), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	if w.enc != nil {
+		return w.enc.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written reports a held-back response as under way, as it is to the
+// handler.
+func (w *compressWriter) Written() bool {
+	return len(w.buf) > 0 || w.ResponseWriter.Written()
+}
+
+func (w *compressWriter) WriteHeaderNow() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Flush sends what was written so far, compressing it if it may be; a
+// flushing handler is streaming and will likely write more.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		_ = w.decide(true)
+	}
+	if w.enc != nil {
+		_ = w.enc.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide picks whether to compress, large saying whether the response
+// reached minSize, and writes what was held back.
+func (w *compressWriter) decide(large bool) error {
+	w.decided = true
+	if large && w.compressible() {
+		header := w.Header()
+		header.Set("Content-Encoding", w.encoding)
+		header.Add("Vary", "Accept-Encoding")
+		header.Del("Content-Length")
+		// A tag names the uncompressed body; the compressed one is a
+		// different representation.
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+		if w.encoding == "gzip" {
+			gz := gzipWriters.Get().(*gzip.Writer)
+			gz.Reset(w.ResponseWriter)
+			w.enc = gz
+		} else {
+			// HTTP's deflate coding is zlib-framed, not raw DEFLATE.
+			zw := zlibWriters.Get().(*zlib.Writer)
+			zw.Reset(w.ResponseWriter)
+			w.enc = zw
+		}
+	}
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+	buf := w.buf
+	w.buf = nil
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+func (w *compressWriter) compressible() bool {
+	status := w.Status()
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		return false
+	}
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	contentType := header.Get("Content-Type")
+	if strings.HasPrefix(contentType, "text/event-stream") {
+		return false
+	}
+	for _, prefix := range compressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// finish writes a response that stayed under minSize as it is, and ends
+// a compressed one.
+func (w *compressWriter) finish() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	if w.enc == nil {
+		return
+	}
+	_ = w.enc.Close()
+	switch enc := w.enc.(type) {
+	case *gzip.Writer:
+		gzipWriters.Put(enc)
+	case *zlib.Writer:
+		zlibWriters.Put(enc)
+	}
+	w.enc = nil
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat("lorem ipsum ", 200)
+	router := setupCommonTestRouter()
+	router.Use(Compress(1024, []string{"/api/stream"}))
+	router.GET("/api/large", func(c *gin.Context) {
+		c.Header("ETag", `"1-abc"`)
+		c.JSON(http.StatusOK, gin.H{"text": large})
+	})
+	router.GET("/api/small", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"status": "ok"}) })
+	router.GET("/api/pdf", func(c *gin.Context) { c.Data(http.StatusOK, "application/pdf", []byte(large)) })
+	router.GET("/api/events", func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		c.String(http.StatusOK, large)
+	})
+	router.GET("/api/stream/:id", func(c *gin.Context) { c.String(http.StatusOK, large) })
+
+	get := func(path, accept string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Accept-Encoding", accept)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := get("/api/large", "br, gzip;q=0.8")
+	if resp.Header().Get("Content-Encoding") != "gzip" || resp.Header().Get("ETag") != `W/"1-abc"` {
+		t.Fatalf("Expected a gzipped response with a weak ETag, got %v", resp.Header())
+	}
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatalf("Expected a gzip body, got %v", err)
+	}
+	body, _ := io.ReadAll(gz)
+	if !strings.Contains(string(body), large) {
+		t.Error("Expected the whole body after decompressing")
+	}
+
+	resp = get("/api/large", "gzip;q=0, deflate")
+	if resp.Header().Get("Content-Encoding") != "deflate" {
+		t.Fatalf("Expected deflate when gzip is refused, got %q", resp.Header().Get("Content-Encoding"))
+	}
+	zr, err := zlib.NewReader(resp.Body)
+	if err != nil {
+		t.Fatalf("Expected a zlib-framed deflate body, got %v", err)
+	}
+	body, _ = io.ReadAll(zr)
+	if !strings.Contains(string(body), large) {
+		t.Error("Expected the whole body after inflating")
+	}
+
+	for _, tt := range []struct{ path, accept string }{
+		{"/api/large", ""},
+		{"/api/small", "gzip"},
+		{"/api/pdf", "gzip"},
+		{"/api/events", "gzip"},
+		{"/api/stream/1", "gzip"},
+	} {
+		resp := get(tt.path, tt.accept)
+		if resp.Header().Get("Content-Encoding") != "" || resp.Code != http.StatusOK || resp.Body.Len() == 0 {
+			t.Errorf("%s: expected an uncompressed 200, got %d %v", tt.path, resp.Code, resp.Header())
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/elprogramadorgt/lucidRAG/pkg/i18n"
+	"github.com/gin-gonic/gin"
+)
+
+// Language picks the language of each response from its Accept-Language
+// header, or def, and carries it in the request context for handlers and
+// the answers they generate. Error messages in JSON responses are
+// translated on the way out, so handlers keep writing them in English.
+func Language(def string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lang := i18n.Negotiate(c.GetHeader("Accept-Language"), def)
+		c.Request = c.Request.WithContext(i18n.WithLanguage(c.Request.Context(), lang))
+		c.Header("Content-Language", lang)
+		c.Writer.Header().Add("Vary", "Accept-Language")
+
+		if lang == i18n.English {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		c.Writer = &translatingWriter{ResponseWriter: original, lang: lang}
+		c.Next()
+		c.Writer = original
+	}
+}
+
+// translatingWriter translates the message of {"error": "..."} bodies
+// written with an error status. gin writes a JSON body in one call.
+type translatingWriter struct {
+	gin.ResponseWriter
+	lang string
+}
+
+func (w *translatingWriter) Write(data []byte) (int, error) {
+	if translated, ok := w.translate(data); ok {
+		if _, err := w.ResponseWriter.Write(translated); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *translatingWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *translatingWriter) translate(data []byte) ([]byte, bool) {
+	if w.Status() < http.StatusBadRequest || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		return nil, false
+	}
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, false
+	}
+	var msg string
+	if err := json.Unmarshal(body["error"], &msg); err != nil {
+		return nil, false
+	}
+	translated := i18n.Translate(w.lang, msg)
+	if translated == msg {
+		return nil, false
+	}
+	body["error"], _ = json.Marshal(translated)
+	out, err := json.Marshal(body)
+	if err != nil {
+		return nil, false
+	}
+	return out, true
+}
+
+func (w *translatingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
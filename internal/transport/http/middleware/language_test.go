@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/elprogramadorgt/lucidRAG/pkg/i18n"
+	"github.com/gin-gonic/gin"
+)
+
+func TestLanguage(t *testing.T) {
+	router := setupCommonTestRouter()
+	router.Use(Language(i18n.English))
+	router.GET("/denied", func(c *gin.Context) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "access denied", "code": 42})
+	})
+	router.GET("/detail", func(c *gin.Context) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid shortcut: name is required"})
+	})
+	router.GET("/lang", func(c *gin.Context) {
+		c.String(http.StatusOK, i18n.FromContext(c.Request.Context()))
+	})
+
+	tests := []struct {
+		name     string
+		path     string
+		accept   string
+		wantLang string
+		wantErr  string
+	}{
+		{"spanish error", "/denied", "es-GT,es;q=0.9", "es", "acceso denegado"},
+		{"english by default", "/denied", "", "en", "access denied"},
+		{"untranslated detail", "/detail", "es", "es", "invalid shortcut: name is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept-Language", tt.accept)
+			}
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if got := resp.Header().Get("Content-Language"); got != tt.wantLang {
+				t.Errorf("Expected Content-Language %s, got %s", tt.wantLang, got)
+			}
+			var body map[string]any
+			if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Failed to decode body %q: %v", resp.Body.String(), err)
+			}
+			if body["error"] != tt.wantErr {
+				t.Errorf("Expected error %q, got %v", tt.wantErr, body["error"])
+			}
+		})
+	}
+
+	req, _ := http.NewRequest("GET", "/lang", nil)
+	req.Header.Set("Accept-Language", "es")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Body.String() != i18n.Spanish {
+		t.Errorf("Expected the request context to carry es, got %q", resp.Body.String())
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/system"
+	userDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/user"
+	"github.com/gin-gonic/gin"
+)
+
+// maintenanceRetryAfter is the Retry-After, in seconds, of requests turned
+// away during maintenance.
+const maintenanceRetryAfter = "120"
+
+// MaintenanceSwitch reports the maintenance-mode switch.
+type MaintenanceSwitch interface {
+	Status() system.Maintenance
+}
+
+// Maintenance answers requests with 503 and the maintenance message while
+// maintenance mode is on, except those from admins and those under an
+// exempt prefix, such as health checks and sign-in. The token is only
+// looked at to tell admins apart; routes still authenticate as usual.
+func Maintenance(mode MaintenanceSwitch, userSvc userDomain.Service, exempt []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := mode.Status()
+		if !status.Enabled {
+			c.Next()
+			return
+		}
+		for _, prefix := range exempt {
+			if strings.HasPrefix(c.Request.URL.Path, prefix) {
+				c.Next()
+				return
+			}
+		}
+		if token := requestToken(c); token != "" {
+			if claims, err := userSvc.ValidateToken(token); err == nil && claims.Role == "admin" {
+				c.Next()
+				return
+			}
+		}
+
+		c.Header("Retry-After", maintenanceRetryAfter)
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": status.Message, "maintenance": true})
+	}
+}
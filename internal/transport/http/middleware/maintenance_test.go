@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/system"
+	userDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/user"
+	"github.com/gin-gonic/gin"
+)
+
+type staticMaintenance system.Maintenance
+
+func (m staticMaintenance) Status() system.Maintenance { return system.Maintenance(m) }
+
+func TestMaintenance(t *testing.T) {
+	userSvc := &mockUserService{
+		validateTokenFunc: func(token string) (*userDomain.Claims, error) {
+			switch token {
+			case "admin-token":
+				return &userDomain.Claims{UserID: "admin-1", Role: "admin"}, nil
+			case "user-token":
+				return &userDomain.Claims{UserID: "user-1", Role: "user"}, nil
+			}
+			return nil, errors.New("invalid token")
+		},
+	}
+	on := staticMaintenance{Enabled: true, Message: "Back at noon"}
+
+	tests := []struct {
+		name   string
+		mode   staticMaintenance
+		path   string
+		token  string
+		status int
+	}{
+		{"off", staticMaintenance{}, "/api/v1/documents", "", http.StatusOK},
+		{"anonymous", on, "/api/v1/documents", "", http.StatusServiceUnavailable},
+		{"user", on, "/api/v1/documents", "user-token", http.StatusServiceUnavailable},
+		{"invalid token", on, "/api/v1/documents", "bad-token", http.StatusServiceUnavailable},
+		{"admin", on, "/api/v1/documents", "admin-token", http.StatusOK},
+		{"exempt", on, "/api/v1/auth/login", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := setupTestRouter()
+			router.Use(Maintenance(tt.mode, userSvc, []string{"/healthz", "/api/v1/auth/"}))
+			router.GET("/*path", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d", tt.status, resp.Code)
+			}
+			if tt.status == http.StatusServiceUnavailable {
+				if !strings.Contains(resp.Body.String(), "Back at noon") || resp.Header().Get("Retry-After") == "" {
+					t.Errorf("Expected the message and Retry-After, got %s %v", resp.Body.String(), resp.Header())
+				}
+			}
+		})
+	}
+}
@@ -1,103 +1,241 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
-	"sync"
+	"net/netip"
+	"strconv"
 	"time"
 
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/system"
+	userDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/user"
+	"github.com/elprogramadorgt/lucidRAG/pkg/cache"
 	"github.com/gin-gonic/gin"
 )
 
-type RateLimiter struct {
-	requests map[string][]time.Time
-	mu       sync.RWMutex
-	limit    int
-	window   time.Duration
-	stopCh   chan struct{}
+// Limiter decides whether a client identified by key may make a request.
+type Limiter interface {
+	Allow(key string) bool
 }
 
-func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
-	rl := &RateLimiter{
-		requests: make(map[string][]time.Time),
-		limit:    limit,
-		window:   window,
-		stopCh:   make(chan struct{}),
+// CacheRateLimiter counts requests per fixed window in a shared cache, so the
+// limit holds across replicas when the cache is Redis.
+type CacheRateLimiter struct {
+	cache  cache.Cache
+	limit  int
+	window time.Duration
+}
+
+func NewCacheRateLimiter(c cache.Cache, limit int, window time.Duration) *CacheRateLimiter {
+	return &CacheRateLimiter{cache: c, limit: limit, window: window}
+}
+
+// Allow fails open when the cache is unreachable, so a cache outage does not
+// take the API down with it.
+func (rl *CacheRateLimiter) Allow(key string) bool {
+	return rl.allowN(key, rl.limit)
+}
+
+// allowN is Allow with a limit other than the limiter's own.
+func (rl *CacheRateLimiter) allowN(key string, limit int) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	n, err := rl.cache.Incr(ctx, rl.counterKey(key, time.Now()), rl.window)
+	if err != nil {
+		return true
 	}
+	return n <= int64(limit)
+}
 
-	go rl.cleanup()
+// Count returns the requests counted for key in the current window and
+// when the window ends.
+func (rl *CacheRateLimiter) Count(ctx context.Context, key string) (int64, time.Time, error) {
+	now := time.Now()
+	resetsAt := time.Unix(0, (now.UnixNano()/int64(rl.window)+1)*int64(rl.window))
+	data, err := rl.cache.Get(ctx, rl.counterKey(key, now))
+	if errors.Is(err, cache.ErrMiss) {
+		return 0, resetsAt, nil
+	}
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	n, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return n, resetsAt, nil
+}
 
-	return rl
+// Reset clears the requests counted for key in the current window.
+func (rl *CacheRateLimiter) Reset(ctx context.Context, key string) error {
+	return rl.cache.Delete(ctx, rl.counterKey(key, time.Now()))
 }
 
-// Stop gracefully stops the rate limiter cleanup goroutine
-func (rl *RateLimiter) Stop() {
-	close(rl.stopCh)
+func (rl *CacheRateLimiter) counterKey(key string, now time.Time) string {
+	return fmt.Sprintf("ratelimit:%s:%d", key, now.UnixNano()/int64(rl.window))
 }
 
-func (rl *RateLimiter) cleanup() {
-	ticker := time.NewTicker(time.Minute)
-	defer ticker.Stop()
+// RateLimit limits requests per client IP.
+func RateLimit(limiter Limiter) gin.HandlerFunc {
+	return RateLimitBy(limiter, (*gin.Context).ClientIP)
+}
 
-	for {
-		select {
-		case <-rl.stopCh:
+// RateLimitBy limits requests per the key returned for them. Limiters that
+// share a cache need keys that do not collide, such as a route name followed
+// by the client IP.
+func RateLimitBy(limiter Limiter, key func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !limiter.Allow(key(c)) {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error": "rate limit exceeded",
+			})
 			return
-		case <-ticker.C:
-			rl.mu.Lock()
-			now := time.Now()
-			for ip, times := range rl.requests {
-				var valid []time.Time
-				for _, t := range times {
-					if now.Sub(t) < rl.window {
-						valid = append(valid, t)
-					}
-				}
-				if len(valid) == 0 {
-					delete(rl.requests, ip)
-				} else {
-					rl.requests[ip] = valid
-				}
-			}
-			rl.mu.Unlock()
 		}
+
+		c.Next()
 	}
 }
 
-func (rl *RateLimiter) Allow(ip string) bool {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
+// RatePolicy exempts clients from the API-wide rate limit and scales it by
+// role. AllowAPIKeys holds integration API key IDs.
+type RatePolicy struct {
+	AllowIPs        []netip.Prefix
+	AllowUsers      []string
+	AllowAPIKeys    []string
+	RoleMultipliers map[string]float64
+}
 
-	now := time.Now()
-	windowStart := now.Add(-rl.window)
+// ClientRateLimiter applies the API-wide rate limit per signed-in user, or
+// per IP for everyone else, as set by a RatePolicy.
+type ClientRateLimiter struct {
+	limiter *CacheRateLimiter
+	policy  RatePolicy
+	users   map[string]bool
+	keys    map[string]bool
+	userSvc userDomain.Service
+	apiKeys APIKeyAuthenticator
+}
 
-	times := rl.requests[ip]
-	var valid []time.Time
-	for _, t := range times {
-		if t.After(windowStart) {
-			valid = append(valid, t)
+// NewClientRateLimiter returns a limiter counting with limiter. The session
+// token is only looked at to tell users and their roles apart, and API
+// keys only when a request is over the limit, to check the allow-list;
+// routes still authenticate as usual. apiKeys may be nil.
+func NewClientRateLimiter(limiter *CacheRateLimiter, policy RatePolicy, userSvc userDomain.Service, apiKeys APIKeyAuthenticator) *ClientRateLimiter {
+	rl := &ClientRateLimiter{
+		limiter: limiter,
+		policy:  policy,
+		users:   make(map[string]bool, len(policy.AllowUsers)),
+		keys:    make(map[string]bool, len(policy.AllowAPIKeys)),
+		userSvc: userSvc,
+		apiKeys: apiKeys,
+	}
+	for _, id := range policy.AllowUsers {
+		rl.users[id] = true
+	}
+	for _, id := range policy.AllowAPIKeys {
+		rl.keys[id] = true
+	}
+	return rl
+}
+
+// Usage reports client's requests in the current window, against the
+// limit for a user's role.
+func (rl *ClientRateLimiter) Usage(ctx context.Context, client system.RateLimitClient) (*system.RateLimitUsage, error) {
+	n, resetsAt, err := rl.limiter.Count(ctx, client.Key())
+	if err != nil {
+		return nil, err
+	}
+	role := ""
+	if client.UserID != "" {
+		u, err := rl.userSvc.GetUser(ctx, client.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if u != nil {
+			role = string(u.Role)
 		}
 	}
+	return &system.RateLimitUsage{
+		Client:   client,
+		Requests: n,
+		Limit:    rl.limit(role),
+		ResetsAt: resetsAt,
+		Exempt:   rl.exempt(client),
+	}, nil
+}
 
-	if len(valid) >= rl.limit {
-		rl.requests[ip] = valid
+// Reset clears client's requests in the current window.
+func (rl *ClientRateLimiter) Reset(ctx context.Context, client system.RateLimitClient) error {
+	return rl.limiter.Reset(ctx, client.Key())
+}
+
+func (rl *ClientRateLimiter) exempt(client system.RateLimitClient) bool {
+	if client.UserID != "" {
+		return rl.users[client.UserID]
+	}
+	addr, err := netip.ParseAddr(client.IP)
+	if err != nil {
 		return false
 	}
+	addr = addr.Unmap()
+	for _, prefix := range rl.policy.AllowIPs {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// limit is the requests allowed per window for role.
+func (rl *ClientRateLimiter) limit(role string) int {
+	m, ok := rl.policy.RoleMultipliers[role]
+	if !ok {
+		return rl.limiter.limit
+	}
+	return max(1, int(math.Ceil(float64(rl.limiter.limit)*m)))
+}
 
-	rl.requests[ip] = append(valid, now)
-	return true
+// allowedKey reports whether the request's API key is allow-listed.
+func (rl *ClientRateLimiter) allowedKey(c *gin.Context) bool {
+	raw := c.GetHeader(apiKeyHeader)
+	if raw == "" || rl.apiKeys == nil || len(rl.keys) == 0 {
+		return false
+	}
+	key, err := rl.apiKeys.Authenticate(c.Request.Context(), raw)
+	return err == nil && rl.keys[key.ID]
 }
 
-func RateLimit(limiter *RateLimiter) gin.HandlerFunc {
+// ClientRateLimit limits requests with rl. Allow-listed IPs are exempt
+// whoever signs in from them.
+func ClientRateLimit(rl *ClientRateLimiter) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ip := c.ClientIP()
+		ip := system.RateLimitClient{IP: c.ClientIP()}
+		if rl.exempt(ip) {
+			c.Next()
+			return
+		}
 
-		if !limiter.Allow(ip) {
+		client, role := ip, ""
+		if token := requestToken(c); token != "" {
+			if claims, err := rl.userSvc.ValidateToken(token); err == nil {
+				client, role = system.RateLimitClient{UserID: claims.UserID}, claims.Role
+			}
+		}
+		if rl.exempt(client) {
+			c.Next()
+			return
+		}
+
+		if !rl.limiter.allowN(client.Key(), rl.limit(role)) && !rl.allowedKey(c) {
 			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
 				"error": "rate limit exceeded",
 			})
 			return
 		}
-
 		c.Next()
 	}
 }
@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/system"
+	userDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/user"
+	"github.com/elprogramadorgt/lucidRAG/pkg/cache"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCacheRateLimiter(t *testing.T) {
+	c := cache.NewMemory()
+	defer c.Stop()
+	limiter := NewCacheRateLimiter(c, 2, time.Minute)
+
+	if !limiter.Allow("1.2.3.4") || !limiter.Allow("1.2.3.4") {
+		t.Fatal("Expected first two requests to be allowed")
+	}
+	if limiter.Allow("1.2.3.4") {
+		t.Error("Expected third request to be limited")
+	}
+	if !limiter.Allow("5.6.7.8") {
+		t.Error("Expected other clients to have their own budget")
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	c := cache.NewMemory()
+	defer c.Stop()
+
+	router := setupTestRouter()
+	router.Use(RateLimit(NewCacheRateLimiter(c, 1, time.Minute)))
+	router.GET("/test", func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+
+	codes := make([]int, 0, 2)
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("GET", "/test", nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		codes = append(codes, resp.Code)
+	}
+
+	if codes[0] != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", codes[0])
+	}
+	if codes[1] != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429, got %d", codes[1])
+	}
+}
+
+func TestClientRateLimit(t *testing.T) {
+	c := cache.NewMemory()
+	defer c.Stop()
+	userSvc := &mockUserService{
+		validateTokenFunc: func(token string) (*userDomain.Claims, error) {
+			switch token {
+			case "admin-token":
+				return &userDomain.Claims{UserID: "admin-1", Role: "admin"}, nil
+			case "user-token":
+				return &userDomain.Claims{UserID: "user-1", Role: "user"}, nil
+			case "vip-token":
+				return &userDomain.Claims{UserID: "vip-1", Role: "user"}, nil
+			}
+			return nil, errors.New("invalid token")
+		},
+	}
+	rl := NewClientRateLimiter(NewCacheRateLimiter(c, 2, time.Minute), RatePolicy{
+		AllowIPs:        []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		AllowUsers:      []string{"vip-1"},
+		AllowAPIKeys:    []string{"key-1"},
+		RoleMultipliers: map[string]float64{"admin": 2},
+	}, userSvc, mockAuthenticator{})
+
+	router := setupTestRouter()
+	router.Use(ClientRateLimit(rl))
+	router.GET("/test", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+	allowed := func(ip, token, key string, n int) int {
+		ok := 0
+		for i := 0; i < n; i++ {
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.RemoteAddr = ip + ":1234"
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			if key != "" {
+				req.Header.Set(apiKeyHeader, key)
+			}
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+			if resp.Code == http.StatusOK {
+				ok++
+			}
+		}
+		return ok
+	}
+
+	tests := []struct {
+		name  string
+		ip    string
+		token string
+		key   string
+		want  int
+	}{
+		{"anonymous", "192.0.2.1", "", "", 2},
+		{"user counted apart from the IP", "192.0.2.1", "user-token", "", 2},
+		{"invalid token counted by IP", "192.0.2.2", "bad-token", "", 2},
+		{"admin multiplier", "192.0.2.3", "admin-token", "", 4},
+		{"allowed IP", "10.1.2.3", "", "", 5},
+		{"allowed user", "192.0.2.4", "vip-token", "", 5},
+		{"allowed API key", "192.0.2.5", "", "lrk_valid", 5},
+		{"other API key", "192.0.2.6", "", "lrk_nope", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := allowed(tt.ip, tt.token, tt.key, 5); got != tt.want {
+				t.Errorf("Expected %d of 5 requests allowed, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClientRateLimiterUsageAndReset(t *testing.T) {
+	c := cache.NewMemory()
+	defer c.Stop()
+	users := &mockUserService{getUserFunc: func(ctx context.Context, id string) (*userDomain.User, error) {
+		return &userDomain.User{ID: id, Role: userDomain.RoleAdmin}, nil
+	}}
+	policy := RatePolicy{AllowUsers: []string{"vip-1"}, RoleMultipliers: map[string]float64{"admin": 2}}
+	rl := NewClientRateLimiter(NewCacheRateLimiter(c, 3, time.Minute), policy, users, nil)
+	ctx := context.Background()
+	client := system.RateLimitClient{IP: "192.0.2.1"}
+
+	rl.limiter.Allow(client.Key())
+	rl.limiter.Allow(client.Key())
+	usage, err := rl.Usage(ctx, client)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if usage.Requests != 2 || usage.Limit != 3 || usage.Exempt || !usage.ResetsAt.After(time.Now()) {
+		t.Errorf("Unexpected usage %+v", usage)
+	}
+
+	if err := rl.Reset(ctx, client); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if usage, _ := rl.Usage(ctx, client); usage.Requests != 0 {
+		t.Errorf("Expected counters cleared, got %d requests", usage.Requests)
+	}
+	if usage, _ := rl.Usage(ctx, system.RateLimitClient{UserID: "admin-1"}); usage.Limit != 6 {
+		t.Errorf("Expected the admin multiplier in the limit, got %d", usage.Limit)
+	}
+	if usage, _ := rl.Usage(ctx, system.RateLimitClient{UserID: "vip-1"}); !usage.Exempt {
+		t.Error("Expected allow-listed user reported exempt")
+	}
+}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// timeoutGrace is how long past a request's timeout the connection stays
+// open, so the 504 can still be written.
+const timeoutGrace = 5 * time.Second
+
+// TimeoutRule sets the timeout of the routes whose pattern starts with
+// Prefix, only for requests with Method when it is set. Zero means no
+// timeout, for streams and long transfers that manage their own deadlines.
+type TimeoutRule struct {
+	Method  string
+	Prefix  string
+	Timeout time.Duration
+}
+
+// Timeout cancels the request context once the route's timeout passes:
+// the longest matching rule's, or def. Handlers stop cooperatively, when
+// the calls they are blocked on see the cancellation; whatever they write
+// after that is discarded and the client gets a 504 instead. The
+// connection deadlines are moved to match, so routes allowed longer than
+// the server's write timeout are not cut off by it.
+func Timeout(def time.Duration, rules []TimeoutRule) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := routeTimeout(c.Request.Method, c.FullPath(), def, rules)
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		deadline, _ := ctx.Deadline()
+		rc := http.NewResponseController(c.Writer)
+		_ = rc.SetReadDeadline(deadline)
+		_ = rc.SetWriteDeadline(deadline.Add(timeoutGrace))
+
+		original := c.Writer
+		tw := &timeoutWriter{ResponseWriter: original, ctx: ctx}
+		c.Writer = tw
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		c.Writer = original
+		if tw.timedOut || (ctx.Err() != nil && !original.Written()) {
+			c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{"error": "request timed out"})
+		}
+	}
+}
+
+func routeTimeout(method, route string, def time.Duration, rules []TimeoutRule) time.Duration {
+	timeout, matched := def, -1
+	for _, rule := range rules {
+		if rule.Method != "" && rule.Method != method {
+			continue
+		}
+		if strings.HasPrefix(route, rule.Prefix) && len(rule.Prefix) > matched {
+			timeout, matched = rule.Timeout, len(rule.Prefix)
+		}
+	}
+	return timeout
+}
+
+// timeoutWriter discards a response begun after the request timed out, so
+// the handler's error for the cancelled context gives way to the 504. A
+// response already under way is left to finish.
+type timeoutWriter struct {
+	gin.ResponseWriter
+	ctx      context.Context
+	timedOut bool
+}
+
+func (w *timeoutWriter) expired() bool {
+	if !w.timedOut && w.ctx.Err() != nil && !w.ResponseWriter.Written() {
+		w.timedOut = true
+	}
+	return w.timedOut
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	if w.expired() {
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	if w.expired() {
+		return
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	if w.expired() {
+		return len(data), nil
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	if w.expired() {
+		return len(s), nil
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *timeoutWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
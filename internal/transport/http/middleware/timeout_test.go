@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTimeout(t *testing.T) {
+	router := setupCommonTestRouter()
+	router.Use(Timeout(20*time.Millisecond, []TimeoutRule{
+		{Prefix: "/api/slow", Timeout: time.Second},
+		{Prefix: "/api/slow/stream", Timeout: 0},
+		{Method: http.MethodPost, Prefix: "/api/upload", Timeout: time.Second},
+	}))
+	wait := func(d time.Duration) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			select {
+			case <-c.Request.Context().Done():
+				c.JSON(http.StatusInternalServerError, gin.H{"error": c.Request.Context().Err().Error()})
+			case <-time.After(d):
+				c.JSON(http.StatusOK, gin.H{"status": "done"})
+			}
+		}
+	}
+	router.GET("/api/fast", wait(0))
+	router.GET("/api/hung", wait(time.Second))
+	router.GET("/api/slow/:id", wait(50*time.Millisecond))
+	router.POST("/api/upload", wait(50*time.Millisecond))
+	router.GET("/api/upload", wait(50*time.Millisecond))
+	router.GET("/api/slow/stream/:id", func(c *gin.Context) {
+		if _, ok := c.Request.Context().Deadline(); ok {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+	}{
+		{"GET", "/api/fast", http.StatusOK},
+		{"GET", "/api/hung", http.StatusGatewayTimeout},
+		{"GET", "/api/slow/1", http.StatusOK},
+		{"GET", "/api/slow/stream/1", http.StatusOK},
+		{"POST", "/api/upload", http.StatusOK},
+		{"GET", "/api/upload", http.StatusGatewayTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.path, nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != tt.code {
+				t.Errorf("Expected status %d, got %d", tt.code, resp.Code)
+			}
+			if tt.code == http.StatusGatewayTimeout && !strings.Contains(resp.Body.String(), "request timed out") {
+				t.Errorf("Expected the timeout error, got %s", resp.Body.String())
+			}
+		})
+	}
+}
+
+func TestTimeoutKeepsStartedResponse(t *testing.T) {
+	router := setupCommonTestRouter()
+	router.Use(Timeout(20*time.Millisecond, nil))
+	router.GET("/partial", func(c *gin.Context) {
+		c.String(http.StatusOK, "first ")
+		<-c.Request.Context().Done()
+		c.String(http.StatusOK, "second")
+	})
+
+	req, _ := http.NewRequest("GET", "/partial", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK || resp.Body.String() != "first second" {
+		t.Errorf("Expected the started response to finish, got %d %q", resp.Code, resp.Body.String())
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"time"
+
+	userDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/user"
+	"github.com/elprogramadorgt/lucidRAG/pkg/tz"
+	"github.com/gin-gonic/gin"
+)
+
+// Timezone carries the time zone of each request in its context: the
+// authenticated user's preferred one, or def for anonymous requests and
+// users whose preferences cannot be read. The user is looked up only when a
+// handler needs the zone, after authentication has run.
+func Timezone(userSvc userDomain.Service, def *time.Location) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		c.Request = c.Request.WithContext(tz.WithResolver(ctx, func() *time.Location {
+			userID := c.GetString("user_id")
+			if userID == "" {
+				return def
+			}
+			prefs, err := userSvc.GetPreferences(ctx, userID)
+			if err != nil {
+				return def
+			}
+			return prefs.Location()
+		}))
+		c.Next()
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	userDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/user"
+	"github.com/elprogramadorgt/lucidRAG/pkg/tz"
+	"github.com/gin-gonic/gin"
+)
+
+func TestTimezone(t *testing.T) {
+	lookups := 0
+	userSvc := &mockUserService{
+		getPreferencesFunc: func(ctx context.Context, userID string) (*userDomain.Preferences, error) {
+			lookups++
+			if userID == "broken" {
+				return nil, errors.New("database unavailable")
+			}
+			prefs := userDomain.DefaultPreferences(userID)
+			prefs.Timezone = "America/Guatemala"
+			return prefs, nil
+		},
+	}
+	def, _ := time.LoadLocation("Europe/Madrid")
+
+	router := setupCommonTestRouter()
+	router.Use(Timezone(userSvc, def))
+	router.Use(func(c *gin.Context) {
+		if user := c.GetHeader("X-Test-User"); user != "" {
+			c.Set("user_id", user)
+		}
+	})
+	router.GET("/tz", func(c *gin.Context) {
+		c.String(http.StatusOK, tz.FromContext(c.Request.Context()).String())
+	})
+	router.GET("/none", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name string
+		user string
+		want string
+	}{
+		{"user preference", "user-1", "America/Guatemala"},
+		{"anonymous", "", "Europe/Madrid"},
+		{"preferences unavailable", "broken", "Europe/Madrid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/tz", nil)
+			if tt.user != "" {
+				req.Header.Set("X-Test-User", tt.user)
+			}
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if got := resp.Body.String(); got != tt.want {
+				t.Errorf("Expected time zone %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	lookups = 0
+	req, _ := http.NewRequest("GET", "/none", nil)
+	req.Header.Set("X-Test-User", "user-1")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if lookups != 0 {
+		t.Errorf("Expected no preference lookup when the zone is unused, got %d", lookups)
+	}
+}
@@ -0,0 +1,145 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/confirm"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// Purge scopes name the kinds of user data PurgeUserData removes.
+const (
+	scopeDocuments     = "documents"
+	scopeConversations = "conversations"
+)
+
+type Handler struct {
+	docSvc  documentDomain.Service
+	convSvc conversationDomain.Service
+	confirm *confirm.Signer
+	log     *logger.Logger
+}
+
+func NewHandler(docSvc documentDomain.Service, convSvc conversationDomain.Service, signer *confirm.Signer, log *logger.Logger) *Handler {
+	return &Handler{
+		docSvc:  docSvc,
+		convSvc: convSvc,
+		confirm: signer,
+		log:     log.With("handler", "admin"),
+	}
+}
+
+type purgeResponse struct {
+	UserID        string                          `json:"user_id"`
+	DryRun        bool                            `json:"dry_run"`
+	Documents     *documentDomain.PurgeResult     `json:"documents,omitempty"`
+	Conversations *conversationDomain.PurgeResult `json:"conversations,omitempty"`
+	// Confirmation is handed out with a dry run; sending its token back in
+	// the X-Confirm-Token header carries the purge out.
+	Confirmation *confirm.Token `json:"confirmation,omitempty"`
+}
+
+// PurgeUserData deletes a user's documents and/or conversations in bulk,
+// as listed in the scope query parameter. Without a confirmation token,
+// or with dry_run=true, it only counts what would be deleted and hands out
+// a token; the purge runs when the token comes back and the counts are
+// still the same.
+func (h *Handler) PurgeUserData(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+	userID := ctx.Param("id")
+	token := ctx.GetHeader(confirm.Header)
+	dryRun := ctx.Query("dry_run") == "true" || token == ""
+
+	var scopes []string
+	for _, scope := range strings.Split(ctx.Query("scope"), ",") {
+		switch scope = strings.TrimSpace(scope); scope {
+		case "":
+		case scopeDocuments, scopeConversations:
+			if !slices.Contains(scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		default:
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "unknown scope: " + scope})
+			return
+		}
+	}
+	if len(scopes) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "scope is required: documents, conversations or both"})
+		return
+	}
+	slices.Sort(scopes)
+	action := confirm.Action{Name: "user_data_purge", Actor: adminID, Target: userID + ":" + strings.Join(scopes, ",")}
+
+	preview, ok := h.purge(ctx, adminID, userID, scopes, true)
+	if !ok {
+		return
+	}
+	if dryRun {
+		confirmation, err := h.confirm.Issue(action, preview)
+		if err != nil {
+			h.log.ErrorContext(ctx.Request.Context(), "failed to issue confirmation token", "error", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to preview purge"})
+			return
+		}
+		preview.Confirmation = &confirmation
+		ctx.JSON(http.StatusOK, preview)
+		return
+	}
+	if err := h.confirm.Verify(ctx.Request.Context(), token, action, preview); err != nil {
+		writeConfirmError(ctx, err)
+		return
+	}
+
+	resp, ok := h.purge(ctx, adminID, userID, scopes, false)
+	if !ok {
+		return
+	}
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "user_data_purge", "admin_id", adminID, "user_id", userID,
+		"scope", strings.Join(scopes, ","))
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// purge counts, or with dryRun false deletes, the user's data in scopes.
+// It writes the error response itself when that fails.
+func (h *Handler) purge(ctx *gin.Context, adminID, userID string, scopes []string, dryRun bool) (*purgeResponse, bool) {
+	resp := &purgeResponse{UserID: userID, DryRun: dryRun}
+	if slices.Contains(scopes, scopeDocuments) {
+		result, err := h.docSvc.PurgeUserDocuments(ctx.Request.Context(),
+			documentDomain.UserContext{UserID: adminID, IsAdmin: true}, userID, dryRun)
+		if err != nil {
+			h.log.ErrorContext(ctx.Request.Context(), "failed to purge user documents", "error", err, "user_id", userID)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to purge documents"})
+			return nil, false
+		}
+		resp.Documents = result
+	}
+	if slices.Contains(scopes, scopeConversations) {
+		result, err := h.convSvc.PurgeUserConversations(ctx.Request.Context(),
+			conversationDomain.UserContext{UserID: adminID, IsAdmin: true}, userID, dryRun)
+		if err != nil {
+			h.log.ErrorContext(ctx.Request.Context(), "failed to purge user conversations", "error", err, "user_id", userID)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to purge conversations"})
+			return nil, false
+		}
+		resp.Conversations = result
+	}
+	return resp, true
+}
+
+// writeConfirmError answers a call whose confirmation token was refused.
+func writeConfirmError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, confirm.ErrStale):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error() + "; preview again"})
+	case errors.Is(err, confirm.ErrInvalidToken), errors.Is(err, confirm.ErrExpiredToken):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check confirmation token"})
+	}
+}
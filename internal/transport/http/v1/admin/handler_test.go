@@ -0,0 +1,135 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/confirm"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// mockDocService implements the document method PurgeUserData uses.
+type mockDocService struct {
+	documentDomain.Service
+	dryRun *bool
+	chunks int64
+}
+
+func (m *mockDocService) PurgeUserDocuments(ctx context.Context, userCtx documentDomain.UserContext, userID string, dryRun bool) (*documentDomain.PurgeResult, error) {
+	m.dryRun = &dryRun
+	if m.chunks == 0 {
+		m.chunks = 7
+	}
+	return &documentDomain.PurgeResult{Documents: 2, Chunks: m.chunks}, nil
+}
+
+// mockConvService implements the conversation method PurgeUserData uses.
+type mockConvService struct {
+	conversationDomain.Service
+	called bool
+}
+
+func (m *mockConvService) PurgeUserConversations(ctx context.Context, userCtx conversationDomain.UserContext, userID string, dryRun bool) (*conversationDomain.PurgeResult, error) {
+	m.called = true
+	return &conversationDomain.PurgeResult{Conversations: 1, Messages: 3}, nil
+}
+
+func setupTestRouter(docSvc documentDomain.Service, convSvc conversationDomain.Service) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "admin-1")
+		c.Set("user_role", "admin")
+	})
+	Register(r.Group("/admin"), NewHandler(docSvc, convSvc, confirm.New("test-secret", 0, nil), logger.New(logger.Options{Level: "error"})))
+	return r
+}
+
+func TestPurgeUserData(t *testing.T) {
+	docSvc, convSvc := &mockDocService{}, &mockConvService{}
+	router := setupTestRouter(docSvc, convSvc)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/admin/users/user-1/data?scope=documents&dry_run=true", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var resp purgeResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if !resp.DryRun || resp.Documents == nil || resp.Documents.Chunks != 7 || resp.Conversations != nil {
+		t.Errorf("Expected a dry run over documents only, got %+v", resp)
+	}
+	if docSvc.dryRun == nil || !*docSvc.dryRun || convSvc.called {
+		t.Error("Expected only the documents to be counted")
+	}
+
+	if resp.Confirmation == nil || resp.Confirmation.Token == "" {
+		t.Error("Expected a confirmation token with the preview")
+	}
+}
+
+func TestPurgeUserDataConfirmation(t *testing.T) {
+	docSvc, convSvc := &mockDocService{}, &mockConvService{}
+	router := setupTestRouter(docSvc, convSvc)
+	purge := func(query, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, "/admin/users/user-1/data"+query, nil)
+		if token != "" {
+			req.Header.Set(confirm.Header, token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Without a token nothing is deleted.
+	w := purge("?scope=documents,conversations", "")
+	var preview purgeResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &preview); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if w.Code != http.StatusOK || !preview.DryRun || !*docSvc.dryRun || preview.Confirmation == nil {
+		t.Fatalf("Expected a preview with a token, got status %d: %s", w.Code, w.Body.String())
+	}
+	token := preview.Confirmation.Token
+
+	if w := purge("?scope=documents", token); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected a token for other scopes refused, got status %d", w.Code)
+	}
+	if w := purge("?scope=conversations,documents", "garbage"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected an invalid token refused, got status %d", w.Code)
+	}
+
+	w = purge("?scope=conversations,documents", token)
+	var resp purgeResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || resp.DryRun || *docSvc.dryRun || resp.Confirmation != nil {
+		t.Errorf("Expected both scopes to be purged, got status %d: %s", w.Code, w.Body.String())
+	}
+
+	// Documents added since the preview make the token stale.
+	docSvc.chunks = 9
+	if w := purge("?scope=documents,conversations", token); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d for a stale preview, got %d", http.StatusConflict, w.Code)
+	}
+}
+
+func TestPurgeUserDataInvalidScope(t *testing.T) {
+	router := setupTestRouter(&mockDocService{}, &mockConvService{})
+
+	for _, query := range []string{"", "?scope=", "?scope=documents,users"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/admin/users/user-1/data"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %q, got %d", http.StatusBadRequest, query, w.Code)
+		}
+	}
+}
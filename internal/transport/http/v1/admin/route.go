@@ -0,0 +1,7 @@
+package admin
+
+import "github.com/gin-gonic/gin"
+
+func Register(rg *gin.RouterGroup, handler *Handler) {
+	rg.DELETE("/users/:id/data", handler.PurgeUserData)
+}
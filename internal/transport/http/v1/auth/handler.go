@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"net/http"
 
@@ -12,21 +13,35 @@ import (
 
 const cookieName = "lucidrag_token"
 
+// registrationRetryAfter is the Retry-After, in seconds, of registrations
+// turned away by the velocity limits, which count per hour.
+const registrationRetryAfter = "3600"
+
 type CookieConfig struct {
 	Domain      string
 	Secure      bool
 	ExpiryHours int
 }
 
+// RegistrationGuard keeps bots away from registration.
+type RegistrationGuard interface {
+	Check(ctx context.Context, attempt userApp.RegistrationAttempt) error
+	Captcha() userApp.CaptchaSettings
+}
+
 type Handler struct {
 	svc          userDomain.Service
+	registration RegistrationGuard
 	log          *logger.Logger
 	cookieConfig CookieConfig
 }
 
-func NewHandler(svc userDomain.Service, log *logger.Logger, cookieCfg CookieConfig) *Handler {
+// NewHandler returns the auth handler. registration may be nil to let
+// every well-formed registration through.
+func NewHandler(svc userDomain.Service, registration RegistrationGuard, log *logger.Logger, cookieCfg CookieConfig) *Handler {
 	return &Handler{
 		svc:          svc,
+		registration: registration,
 		log:          log.With("handler", "auth"),
 		cookieConfig: cookieCfg,
 	}
@@ -59,11 +74,34 @@ func (h *Handler) clearAuthCookie(ctx *gin.Context) {
 	)
 }
 
+// deviceIDHeader carries the random ID the admin panel keeps per browser.
+const deviceIDHeader = "X-Device-ID"
+
+// deviceContext returns the request context carrying the device signing in,
+// for the session a new token starts.
+func deviceContext(ctx *gin.Context) context.Context {
+	device := userApp.NewDevice(ctx.Request.UserAgent(), ctx.GetHeader(deviceIDHeader), ctx.ClientIP())
+	return userApp.ContextWithDevice(ctx.Request.Context(), device)
+}
+
+// redirectDeviceContext is deviceContext for sign-ins that end in a browser
+// redirect, such as OAuth callbacks. Those requests carry no device ID, so
+// the session is left unbound and takes the fingerprint of the first
+// request made with it.
+func redirectDeviceContext(ctx *gin.Context) context.Context {
+	device := userApp.NewDevice(ctx.Request.UserAgent(), "", ctx.ClientIP())
+	device.Fingerprint = ""
+	return userApp.ContextWithDevice(ctx.Request.Context(), device)
+}
+
 type registerRequest struct {
 	Email     string `json:"email" binding:"required,email"`
 	Password  string `json:"password" binding:"required,min=8"`
 	FirstName string `json:"first_name" binding:"required"`
 	LastName  string `json:"last_name" binding:"required"`
+	// Website is the honeypot: the form hides it, so only bots fill it in.
+	Website      string `json:"website"`
+	CaptchaToken string `json:"captcha_token"`
 }
 
 type loginRequest struct {
@@ -78,10 +116,13 @@ type authResponse struct {
 func (h *Handler) Register(ctx *gin.Context) {
 	var req registerRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		h.log.Warn("registration_attempt", "status", "invalid_request", "ip", ctx.ClientIP(), "error", err.Error())
+		h.log.WarnContext(ctx.Request.Context(), "registration_attempt", "status", "invalid_request", "ip", ctx.ClientIP(), "error", err.Error())
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
 		return
 	}
+	if !h.checkRegistration(ctx, req) {
+		return
+	}
 
 	user, err := h.svc.Register(ctx.Request.Context(), userDomain.User{
 		Email:        req.Email,
@@ -91,55 +132,107 @@ func (h *Handler) Register(ctx *gin.Context) {
 	})
 	if err != nil {
 		if errors.Is(err, userApp.ErrEmailExists) {
-			h.log.Warn("registration_attempt", "status", "failed", "email", req.Email, "ip", ctx.ClientIP(), "reason", "email_exists")
+			h.log.WarnContext(ctx.Request.Context(), "registration_attempt", "status", "failed", "email", req.Email, "ip", ctx.ClientIP(), "reason", "email_exists")
 			ctx.JSON(http.StatusConflict, gin.H{"error": "email already registered"})
 			return
 		}
-		h.log.Error("registration_attempt", "status", "error", "email", req.Email, "ip", ctx.ClientIP(), "error", err.Error())
+		h.log.ErrorContext(ctx.Request.Context(), "registration_attempt", "status", "error", "email", req.Email, "ip", ctx.ClientIP(), "error", err.Error())
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to register user"})
 		return
 	}
 
-	token, _, err := h.svc.Login(ctx.Request.Context(), req.Email, req.Password)
+	token, _, err := h.svc.Login(deviceContext(ctx), req.Email, req.Password)
 	if err != nil {
-		h.log.Error("registration_attempt", "status", "partial", "user_id", user.ID, "email", user.Email, "ip", ctx.ClientIP(), "error", "token_generation_failed")
+		h.log.ErrorContext(ctx.Request.Context(), "registration_attempt", "status", "partial", "user_id", user.ID, "email", user.Email, "ip", ctx.ClientIP(), "error", "token_generation_failed")
 		ctx.JSON(http.StatusCreated, authResponse{User: user})
 		return
 	}
 
 	h.setAuthCookie(ctx, token)
-	h.log.Info("registration_attempt", "status", "success", "user_id", user.ID, "email", user.Email, "ip", ctx.ClientIP())
+	h.log.InfoContext(ctx.Request.Context(), "registration_attempt", "status", "success", "user_id", user.ID, "email", user.Email, "ip", ctx.ClientIP())
 	ctx.JSON(http.StatusCreated, authResponse{User: user})
 }
 
+// checkRegistration runs the registration guard and answers the request
+// when it turns the attempt away. Honeypot hits get the same answer as a
+// malformed body, so bots learn nothing from it.
+func (h *Handler) checkRegistration(ctx *gin.Context, req registerRequest) bool {
+	if h.registration == nil {
+		return true
+	}
+	err := h.registration.Check(ctx.Request.Context(), userApp.RegistrationAttempt{
+		Email:        req.Email,
+		IP:           ctx.ClientIP(),
+		CaptchaToken: req.CaptchaToken,
+		Honeypot:     req.Website,
+	})
+	if err == nil {
+		return true
+	}
+
+	switch {
+	case errors.Is(err, userApp.ErrHoneypot):
+		h.log.WarnContext(ctx.Request.Context(), "registration_attempt", "status", "blocked", "email", req.Email, "ip", ctx.ClientIP(), "reason", "honeypot")
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+	case errors.Is(err, userApp.ErrCaptchaFailed):
+		h.log.WarnContext(ctx.Request.Context(), "registration_attempt", "status", "blocked", "email", req.Email, "ip", ctx.ClientIP(), "reason", "captcha_failed")
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "captcha verification failed"})
+	case errors.Is(err, userApp.ErrTooManyRegistrations):
+		h.log.WarnContext(ctx.Request.Context(), "registration_attempt", "status", "blocked", "email", req.Email, "ip", ctx.ClientIP(), "reason", "velocity", "detail", err.Error())
+		ctx.Header("Retry-After", registrationRetryAfter)
+		ctx.JSON(http.StatusTooManyRequests, gin.H{"error": "too many registrations, try again later"})
+	default:
+		h.log.ErrorContext(ctx.Request.Context(), "registration_attempt", "status", "error", "email", req.Email, "ip", ctx.ClientIP(), "error", err.Error())
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "captcha verification unavailable"})
+	}
+	return false
+}
+
+// GetCaptcha tells the sign-up form which captcha widget to render, if any.
+func (h *Handler) GetCaptcha(ctx *gin.Context) {
+	if h.registration == nil {
+		ctx.JSON(http.StatusOK, userApp.CaptchaSettings{})
+		return
+	}
+	ctx.JSON(http.StatusOK, h.registration.Captcha())
+}
+
 func (h *Handler) Login(ctx *gin.Context) {
 	var req loginRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		h.log.Warn("login_attempt", "status", "invalid_request", "ip", ctx.ClientIP(), "error", err.Error())
+		h.log.WarnContext(ctx.Request.Context(), "login_attempt", "status", "invalid_request", "ip", ctx.ClientIP(), "error", err.Error())
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
 		return
 	}
 
-	token, user, err := h.svc.Login(ctx.Request.Context(), req.Email, req.Password)
+	token, user, err := h.svc.Login(deviceContext(ctx), req.Email, req.Password)
 	if err != nil {
 		if errors.Is(err, userApp.ErrInvalidCredentials) {
-			h.log.Warn("login_attempt", "status", "failed", "email", req.Email, "ip", ctx.ClientIP(), "reason", "invalid_credentials")
+			h.log.WarnContext(ctx.Request.Context(), "login_attempt", "status", "failed", "email", req.Email, "ip", ctx.ClientIP(), "reason", "invalid_credentials")
 			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
 			return
 		}
-		h.log.Error("login_attempt", "status", "error", "email", req.Email, "ip", ctx.ClientIP(), "error", err.Error())
+		h.log.ErrorContext(ctx.Request.Context(), "login_attempt", "status", "error", "email", req.Email, "ip", ctx.ClientIP(), "error", err.Error())
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "login failed"})
 		return
 	}
 
 	h.setAuthCookie(ctx, token)
-	h.log.Info("login_attempt", "status", "success", "email", req.Email, "ip", ctx.ClientIP())
+	h.log.InfoContext(ctx.Request.Context(), "login_attempt", "status", "success", "email", req.Email, "ip", ctx.ClientIP())
 	ctx.JSON(http.StatusOK, authResponse{User: user})
 }
 
+// Logout clears the cookie and ends the session it carried.
 func (h *Handler) Logout(ctx *gin.Context) {
+	if token, err := ctx.Cookie(cookieName); err == nil && token != "" {
+		if claims, err := h.svc.ValidateToken(token); err == nil && claims.SessionID != "" {
+			if err := h.svc.RevokeSession(ctx.Request.Context(), claims.UserID, claims.SessionID); err != nil && !errors.Is(err, userApp.ErrSessionNotFound) {
+				h.log.WarnContext(ctx.Request.Context(), "logout", "status", "error", "user_id", claims.UserID, "error", err.Error())
+			}
+		}
+	}
 	h.clearAuthCookie(ctx)
-	h.log.Info("logout", "ip", ctx.ClientIP())
+	h.log.InfoContext(ctx.Request.Context(), "logout", "ip", ctx.ClientIP())
 	ctx.JSON(http.StatusOK, gin.H{"message": "logged out"})
 }
 
@@ -156,10 +249,197 @@ func (h *Handler) Me(ctx *gin.Context) {
 			ctx.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
 			return
 		}
-		h.log.Error("failed to get user", "error", err)
+		h.log.ErrorContext(ctx.Request.Context(), "failed to get user", "error", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user"})
 		return
 	}
 
 	ctx.JSON(http.StatusOK, user)
 }
+
+type updatePreferencesRequest struct {
+	Language             *string                           `json:"language"`
+	Timezone             *string                           `json:"timezone"`
+	NotificationChannels *[]userDomain.NotificationChannel `json:"notification_channels"`
+	DigestFrequency      *userDomain.DigestFrequency       `json:"digest_frequency"`
+}
+
+func (h *Handler) GetPreferences(ctx *gin.Context) {
+	userID := ctx.GetString("user_id")
+	if userID == "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	prefs, err := h.svc.GetPreferences(ctx.Request.Context(), userID)
+	if err != nil {
+		h.log.ErrorContext(ctx.Request.Context(), "failed to get preferences", "error", err, "user_id", userID)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get preferences"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, prefs)
+}
+
+// UpdatePreferences applies the fields present in the request on top of the
+// user's current preferences.
+func (h *Handler) UpdatePreferences(ctx *gin.Context) {
+	userID := ctx.GetString("user_id")
+	if userID == "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req updatePreferencesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	prefs, err := h.svc.GetPreferences(ctx.Request.Context(), userID)
+	if err != nil {
+		h.log.ErrorContext(ctx.Request.Context(), "failed to get preferences", "error", err, "user_id", userID)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update preferences"})
+		return
+	}
+
+	if req.Language != nil {
+		prefs.Language = *req.Language
+	}
+	if req.Timezone != nil {
+		prefs.Timezone = *req.Timezone
+	}
+	if req.NotificationChannels != nil {
+		prefs.NotificationChannels = *req.NotificationChannels
+	}
+	if req.DigestFrequency != nil {
+		prefs.DigestFrequency = *req.DigestFrequency
+	}
+
+	updated, err := h.svc.UpdatePreferences(ctx.Request.Context(), prefs)
+	if err != nil {
+		if errors.Is(err, userApp.ErrInvalidPreferences) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid preferences"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to update preferences", "error", err, "user_id", userID)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update preferences"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, updated)
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=8"`
+}
+
+// ChangePassword updates the caller's password. Existing tokens are revoked,
+// so a fresh one is issued for the current session.
+func (h *Handler) ChangePassword(ctx *gin.Context) {
+	userID := ctx.GetString("user_id")
+	if userID == "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req changePasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := h.svc.ChangePassword(ctx.Request.Context(), userID, req.CurrentPassword, req.NewPassword); err != nil {
+		if errors.Is(err, userApp.ErrInvalidCredentials) {
+			h.log.WarnContext(ctx.Request.Context(), "password_change", "status", "failed", "user_id", userID, "ip", ctx.ClientIP(), "reason", "invalid_credentials")
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "current password is incorrect"})
+			return
+		}
+		if errors.Is(err, userApp.ErrUserNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "password_change", "status", "error", "user_id", userID, "error", err.Error())
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change password"})
+		return
+	}
+
+	user, err := h.svc.GetUser(ctx.Request.Context(), userID)
+	if err == nil {
+		if token, err := h.svc.GenerateToken(deviceContext(ctx), user); err == nil {
+			h.setAuthCookie(ctx, token)
+		}
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "password_change", "status", "success", "user_id", userID, "ip", ctx.ClientIP())
+	ctx.JSON(http.StatusOK, gin.H{"message": "password changed"})
+}
+
+func (h *Handler) ListSessions(ctx *gin.Context) {
+	userID := ctx.GetString("user_id")
+	sessions, err := h.svc.ListSessions(ctx.Request.Context(), userID, ctx.GetString("session_id"))
+	if err != nil {
+		h.log.ErrorContext(ctx.Request.Context(), "failed to list sessions", "error", err, "user_id", userID)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list sessions"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// RevokeSession signs one of the user's devices out. Revoking the session
+// the request was made with also clears its cookie.
+func (h *Handler) RevokeSession(ctx *gin.Context) {
+	userID := ctx.GetString("user_id")
+	sessionID := ctx.Param("id")
+	if err := h.svc.RevokeSession(ctx.Request.Context(), userID, sessionID); err != nil {
+		if errors.Is(err, userApp.ErrSessionNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to revoke session", "error", err, "user_id", userID)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke session"})
+		return
+	}
+
+	if sessionID == ctx.GetString("session_id") {
+		h.clearAuthCookie(ctx)
+	}
+	h.log.InfoContext(ctx.Request.Context(), "session_revoke", "user_id", userID, "session_id", sessionID, "ip", ctx.ClientIP())
+	ctx.JSON(http.StatusOK, gin.H{"message": "session revoked"})
+}
+
+type setUserStatusRequest struct {
+	IsActive *bool `json:"is_active" binding:"required"`
+}
+
+// SetUserStatus lets an admin activate or deactivate a user account.
+func (h *Handler) SetUserStatus(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+	id := ctx.Param("id")
+
+	var req setUserStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if id == adminID && !*req.IsActive {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "cannot deactivate your own account"})
+		return
+	}
+
+	user, err := h.svc.SetActive(ctx.Request.Context(), id, *req.IsActive)
+	if err != nil {
+		if errors.Is(err, userApp.ErrUserNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to update user status", "error", err, "user_id", id)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user status"})
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "user_status_update", "admin_id", adminID, "user_id", id, "is_active", *req.IsActive)
+	ctx.JSON(http.StatusOK, user)
+}
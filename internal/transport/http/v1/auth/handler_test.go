@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	userApp "github.com/elprogramadorgt/lucidRAG/internal/application/user"
@@ -16,9 +19,14 @@ import (
 
 // mockUserServiceHandler is a mock implementation for handler testing
 type mockUserServiceHandler struct {
-	registerFunc  func(ctx context.Context, newUser userDomain.User) (*userDomain.User, error)
-	loginFunc     func(ctx context.Context, email, password string) (string, *userDomain.User, error)
-	getUserFunc   func(ctx context.Context, id string) (*userDomain.User, error)
+	registerFunc    func(ctx context.Context, newUser userDomain.User) (*userDomain.User, error)
+	loginFunc       func(ctx context.Context, email, password string) (string, *userDomain.User, error)
+	getUserFunc     func(ctx context.Context, id string) (*userDomain.User, error)
+	updatePrefsFunc func(ctx context.Context, prefs *userDomain.Preferences) (*userDomain.Preferences, error)
+	changePassFunc  func(ctx context.Context, userID, currentPassword, newPassword string) error
+	setActiveFunc   func(ctx context.Context, userID string, active bool) (*userDomain.User, error)
+	sessions        []userDomain.Session
+	revoked         []string
 }
 
 func (m *mockUserServiceHandler) Register(ctx context.Context, newUser userDomain.User) (*userDomain.User, error) {
@@ -68,10 +76,57 @@ func (m *mockUserServiceHandler) ValidateToken(token string) (*userDomain.Claims
 	return nil, nil
 }
 
-func (m *mockUserServiceHandler) GenerateToken(user *userDomain.User) (string, error) {
+func (m *mockUserServiceHandler) CheckSession(ctx context.Context, claims *userDomain.Claims) error {
+	return nil
+}
+
+func (m *mockUserServiceHandler) GenerateToken(ctx context.Context, user *userDomain.User) (string, error) {
 	return "mock-token", nil
 }
 
+func (m *mockUserServiceHandler) GetPreferences(ctx context.Context, userID string) (*userDomain.Preferences, error) {
+	return userDomain.DefaultPreferences(userID), nil
+}
+
+func (m *mockUserServiceHandler) UpdatePreferences(ctx context.Context, prefs *userDomain.Preferences) (*userDomain.Preferences, error) {
+	if m.updatePrefsFunc != nil {
+		return m.updatePrefsFunc(ctx, prefs)
+	}
+	return prefs, nil
+}
+
+func (m *mockUserServiceHandler) ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) error {
+	if m.changePassFunc != nil {
+		return m.changePassFunc(ctx, userID, currentPassword, newPassword)
+	}
+	return nil
+}
+
+func (m *mockUserServiceHandler) SetActive(ctx context.Context, userID string, active bool) (*userDomain.User, error) {
+	if m.setActiveFunc != nil {
+		return m.setActiveFunc(ctx, userID, active)
+	}
+	return &userDomain.User{ID: userID, IsActive: active}, nil
+}
+
+func (m *mockUserServiceHandler) ListSessions(ctx context.Context, userID, currentID string) ([]userDomain.Session, error) {
+	sessions := append([]userDomain.Session(nil), m.sessions...)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+	return sessions, nil
+}
+
+func (m *mockUserServiceHandler) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	for _, session := range m.sessions {
+		if session.ID == sessionID && session.UserID == userID {
+			m.revoked = append(m.revoked, sessionID)
+			return nil
+		}
+	}
+	return userApp.ErrSessionNotFound
+}
+
 func setupHandlerTestRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	return gin.New()
@@ -81,6 +136,7 @@ func createTestHandler(mockSvc *mockUserServiceHandler) *Handler {
 	log := logger.New(logger.Options{Level: "error"})
 	return NewHandler(
 		mockSvc,
+		nil,
 		log,
 		CookieConfig{
 			Domain:      "localhost",
@@ -347,9 +403,331 @@ func TestCookieConfig(t *testing.T) {
 func TestNewHandler(t *testing.T) {
 	mockSvc := &mockUserServiceHandler{}
 	log := logger.New(logger.Options{Level: "error"})
-	handler := NewHandler(mockSvc, log, CookieConfig{})
+	handler := NewHandler(mockSvc, nil, log, CookieConfig{})
 
 	if handler == nil {
 		t.Fatal("Expected handler to be created")
 	}
 }
+
+func TestGetPreferencesReturnsDefaults(t *testing.T) {
+	handler := createTestHandler(&mockUserServiceHandler{})
+	router := setupHandlerTestRouter()
+	router.GET("/auth/me/preferences", func(c *gin.Context) {
+		c.Set("user_id", "user-123")
+		handler.GetPreferences(c)
+	})
+
+	req, _ := http.NewRequest("GET", "/auth/me/preferences", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.Code)
+	}
+
+	var prefs userDomain.Preferences
+	if err := json.Unmarshal(resp.Body.Bytes(), &prefs); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if prefs.Language != "en" || prefs.Timezone != "UTC" {
+		t.Errorf("Expected default preferences, got %+v", prefs)
+	}
+}
+
+func TestUpdatePreferencesMergesFields(t *testing.T) {
+	var saved *userDomain.Preferences
+	mockSvc := &mockUserServiceHandler{
+		updatePrefsFunc: func(ctx context.Context, prefs *userDomain.Preferences) (*userDomain.Preferences, error) {
+			saved = prefs
+			return prefs, nil
+		},
+	}
+	handler := createTestHandler(mockSvc)
+	router := setupHandlerTestRouter()
+	router.PUT("/auth/me/preferences", func(c *gin.Context) {
+		c.Set("user_id", "user-123")
+		handler.UpdatePreferences(c)
+	})
+
+	body := bytes.NewBufferString(`{"timezone":"America/Guatemala","notification_channels":[]}`)
+	req, _ := http.NewRequest("PUT", "/auth/me/preferences", body)
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.Code)
+	}
+	if saved == nil {
+		t.Fatal("Expected preferences to be saved")
+	}
+	if saved.Timezone != "America/Guatemala" {
+		t.Errorf("Expected timezone America/Guatemala, got %s", saved.Timezone)
+	}
+	if saved.Language != "en" {
+		t.Errorf("Expected language to be kept, got %s", saved.Language)
+	}
+	if len(saved.NotificationChannels) != 0 {
+		t.Errorf("Expected notifications to be cleared, got %v", saved.NotificationChannels)
+	}
+}
+
+func TestUpdatePreferencesInvalid(t *testing.T) {
+	mockSvc := &mockUserServiceHandler{
+		updatePrefsFunc: func(ctx context.Context, prefs *userDomain.Preferences) (*userDomain.Preferences, error) {
+			return nil, userApp.ErrInvalidPreferences
+		},
+	}
+	handler := createTestHandler(mockSvc)
+	router := setupHandlerTestRouter()
+	router.PUT("/auth/me/preferences", func(c *gin.Context) {
+		c.Set("user_id", "user-123")
+		handler.UpdatePreferences(c)
+	})
+
+	req, _ := http.NewRequest("PUT", "/auth/me/preferences", bytes.NewBufferString(`{"language":"xx"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", resp.Code)
+	}
+}
+
+func TestChangePasswordIssuesNewCookie(t *testing.T) {
+	mockSvc := &mockUserServiceHandler{}
+	handler := createTestHandler(mockSvc)
+	router := setupHandlerTestRouter()
+	router.PUT("/auth/me/password", func(c *gin.Context) {
+		c.Set("user_id", "user-123")
+		handler.ChangePassword(c)
+	})
+
+	body := bytes.NewBufferString(`{"current_password":"password123","new_password":"newpassword123"}`)
+	req, _ := http.NewRequest("PUT", "/auth/me/password", body)
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.Code)
+	}
+
+	found := false
+	for _, cookie := range resp.Result().Cookies() {
+		if cookie.Name == cookieName && cookie.Value == "mock-token" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected a fresh auth cookie to be set")
+	}
+}
+
+func TestChangePasswordWrongCurrent(t *testing.T) {
+	mockSvc := &mockUserServiceHandler{
+		changePassFunc: func(ctx context.Context, userID, currentPassword, newPassword string) error {
+			return userApp.ErrInvalidCredentials
+		},
+	}
+	handler := createTestHandler(mockSvc)
+	router := setupHandlerTestRouter()
+	router.PUT("/auth/me/password", func(c *gin.Context) {
+		c.Set("user_id", "user-123")
+		handler.ChangePassword(c)
+	})
+
+	body := bytes.NewBufferString(`{"current_password":"wrong","new_password":"newpassword123"}`)
+	req, _ := http.NewRequest("PUT", "/auth/me/password", body)
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401, got %d", resp.Code)
+	}
+}
+
+func TestSetUserStatus(t *testing.T) {
+	var gotActive *bool
+	mockSvc := &mockUserServiceHandler{
+		setActiveFunc: func(ctx context.Context, userID string, active bool) (*userDomain.User, error) {
+			gotActive = &active
+			return &userDomain.User{ID: userID, IsActive: active}, nil
+		},
+	}
+	handler := createTestHandler(mockSvc)
+	router := setupHandlerTestRouter()
+	router.PATCH("/users/:id/status", func(c *gin.Context) {
+		c.Set("user_id", "admin-1")
+		handler.SetUserStatus(c)
+	})
+
+	req, _ := http.NewRequest("PATCH", "/users/user-123/status", bytes.NewBufferString(`{"is_active":false}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.Code)
+	}
+	if gotActive == nil || *gotActive {
+		t.Error("Expected user to be deactivated")
+	}
+
+	req, _ = http.NewRequest("PATCH", "/users/admin-1/status", bytes.NewBufferString(`{"is_active":false}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for self-deactivation, got %d", resp.Code)
+	}
+}
+
+type mockRegistrationGuard struct {
+	attempt userApp.RegistrationAttempt
+}
+
+func (m *mockRegistrationGuard) Check(ctx context.Context, attempt userApp.RegistrationAttempt) error {
+	m.attempt = attempt
+	switch {
+	case attempt.Honeypot != "":
+		return userApp.ErrHoneypot
+	case attempt.CaptchaToken == "":
+		return userApp.ErrCaptchaFailed
+	case attempt.CaptchaToken == "outage":
+		return errors.New("siteverify returned status 502")
+	case strings.HasSuffix(attempt.Email, "@spam.example"):
+		return fmt.Errorf("%w for spam.example", userApp.ErrTooManyRegistrations)
+	}
+	return nil
+}
+
+func (m *mockRegistrationGuard) Captcha() userApp.CaptchaSettings {
+	return userApp.CaptchaSettings{Provider: "turnstile", SiteKey: "site-key"}
+}
+
+func TestRegisterGuarded(t *testing.T) {
+	registered := 0
+	mockSvc := &mockUserServiceHandler{
+		registerFunc: func(ctx context.Context, newUser userDomain.User) (*userDomain.User, error) {
+			registered++
+			return &userDomain.User{ID: "user-1", Email: newUser.Email}, nil
+		},
+	}
+	guard := &mockRegistrationGuard{}
+	handler := NewHandler(mockSvc, guard, logger.New(logger.Options{Level: "error"}), CookieConfig{})
+	router := setupHandlerTestRouter()
+	router.POST("/register", handler.Register)
+	router.GET("/captcha", handler.GetCaptcha)
+
+	tests := []struct {
+		name   string
+		extra  string
+		email  string
+		status int
+	}{
+		{"honeypot", `"website":"https://spam.example","captcha_token":"ok"`, "bot@example.com", http.StatusBadRequest},
+		{"no captcha", `"captcha_token":""`, "ana@example.com", http.StatusBadRequest},
+		{"captcha outage", `"captcha_token":"outage"`, "ana@example.com", http.StatusServiceUnavailable},
+		{"velocity", `"captcha_token":"ok"`, "u9@spam.example", http.StatusTooManyRequests},
+		{"human", `"captcha_token":"ok"`, "ana@example.com", http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"email":"` + tt.email + `","password":"password123","first_name":"Ana","last_name":"Lopez",` + tt.extra + `}`
+			req, _ := http.NewRequest("POST", "/register", bytes.NewBufferString(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, resp.Code, resp.Body.String())
+			}
+		})
+	}
+	if registered != 1 {
+		t.Errorf("Expected only the human registered, got %d registrations", registered)
+	}
+
+	req, _ := http.NewRequest("GET", "/captcha", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	var settings userApp.CaptchaSettings
+	if err := json.Unmarshal(resp.Body.Bytes(), &settings); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if settings.Provider != "turnstile" || settings.SiteKey != "site-key" {
+		t.Errorf("Unexpected captcha settings %+v", settings)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	mockSvc := &mockUserServiceHandler{sessions: []userDomain.Session{
+		{ID: "session-1", UserID: "user-123", UserAgent: "Firefox"},
+		{ID: "session-2", UserID: "user-123", UserAgent: "Safari"},
+	}}
+	handler := createTestHandler(mockSvc)
+	router := setupHandlerTestRouter()
+	sessions := router.Group("/users/me/sessions", func(c *gin.Context) {
+		c.Set("user_id", "user-123")
+		c.Set("session_id", "session-1")
+	})
+	RegisterSessions(sessions, handler)
+
+	req, _ := http.NewRequest("GET", "/users/me/sessions", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.Code)
+	}
+	var listed struct {
+		Sessions []userDomain.Session `json:"sessions"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &listed); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(listed.Sessions) != 2 || !listed.Sessions[0].Current || listed.Sessions[1].Current {
+		t.Errorf("Expected session-1 marked current, got %+v", listed.Sessions)
+	}
+
+	req, _ = http.NewRequest("DELETE", "/users/me/sessions/session-2", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.Code)
+	}
+	if len(resp.Result().Cookies()) != 0 {
+		t.Error("Expected the cookie kept when revoking another session")
+	}
+
+	req, _ = http.NewRequest("DELETE", "/users/me/sessions/missing", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", resp.Code)
+	}
+
+	req, _ = http.NewRequest("DELETE", "/users/me/sessions/session-1", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	cleared := false
+	for _, cookie := range resp.Result().Cookies() {
+		if cookie.Name == cookieName && cookie.MaxAge < 0 {
+			cleared = true
+		}
+	}
+	if !cleared {
+		t.Error("Expected the cookie cleared when revoking the current session")
+	}
+	if len(mockSvc.revoked) != 2 {
+		t.Errorf("Expected 2 sessions revoked, got %v", mockSvc.revoked)
+	}
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/pkg/cache"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newTestJWKSServer(t *testing.T, kid string, key *rsa.PublicKey, hits *int32) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		json.NewEncoder(w).Encode(jsonWebKeySet{Keys: []jsonWebKey{{
+			Kty: "RSA",
+			Kid: kid,
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+}
+
+func signTestIDToken(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return signed
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	var hits int32
+	srv := newTestJWKSServer(t, "key-1", &key.PublicKey, &hits)
+	defer srv.Close()
+
+	mem := cache.NewMemory()
+	defer mem.Stop()
+	source := newKeySource(srv.URL, mem)
+
+	token := signTestIDToken(t, key, "key-1", jwt.MapClaims{
+		"iss":   appleIssuer,
+		"aud":   "apple-client-id",
+		"sub":   "user-123",
+		"email": "test@example.com",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	})
+
+	for i := 0; i < 2; i++ {
+		claims, err := source.verifyIDToken(context.Background(), token, appleIssuer, "apple-client-id")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if claims["sub"] != "user-123" {
+			t.Errorf("Expected sub user-123, got %v", claims["sub"])
+		}
+	}
+
+	if hits != 1 {
+		t.Errorf("Expected key set to be fetched once, got %d", hits)
+	}
+}
+
+func TestVerifyIDTokenRejectsWrongAudience(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	var hits int32
+	srv := newTestJWKSServer(t, "key-1", &key.PublicKey, &hits)
+	defer srv.Close()
+
+	source := newKeySource(srv.URL, nil)
+	token := signTestIDToken(t, key, "key-1", jwt.MapClaims{
+		"iss": appleIssuer,
+		"aud": "someone-else",
+		"sub": "user-123",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+
+	if _, err := source.verifyIDToken(context.Background(), token, appleIssuer, "apple-client-id"); err == nil {
+		t.Error("Expected error for wrong audience")
+	}
+}
+
+func TestVerifyIDTokenRejectsUnknownSigner(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	var hits int32
+	srv := newTestJWKSServer(t, "key-1", &key.PublicKey, &hits)
+	defer srv.Close()
+
+	source := newKeySource(srv.URL, nil)
+	claims := jwt.MapClaims{
+		"iss": appleIssuer,
+		"aud": "apple-client-id",
+		"sub": "user-123",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+
+	if _, err := source.verifyIDToken(context.Background(), signTestIDToken(t, other, "key-1", claims), appleIssuer, "apple-client-id"); err == nil {
+		t.Error("Expected error for token signed by another key")
+	}
+	if _, err := source.verifyIDToken(context.Background(), signTestIDToken(t, key, "key-2", claims), appleIssuer, "apple-client-id"); err == nil {
+		t.Error("Expected error for unknown key id")
+	}
+}
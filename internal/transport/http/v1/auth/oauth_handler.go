@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -14,23 +15,39 @@ import (
 
 	"github.com/elprogramadorgt/lucidRAG/internal/config"
 	userDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/user"
+	"github.com/elprogramadorgt/lucidRAG/pkg/cache"
 	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	oauthStateCookie    = "oauth_state"
+	oauthStateKeyPrefix = "oauth_state:"
+	// oauthStateTTL is how long a user has to complete a provider sign-in.
+	oauthStateTTL = 10 * time.Minute
+)
+
 type OAuthHandler struct {
 	userSvc      userDomain.Service
 	log          *logger.Logger
 	oauthConfig  config.OAuthConfig
 	cookieConfig CookieConfig
+	appleKeys    *keySource
+	states       cache.Cache
 }
 
-func NewOAuthHandler(userSvc userDomain.Service, log *logger.Logger, oauthCfg config.OAuthConfig, cookieCfg CookieConfig) *OAuthHandler {
+// NewOAuthHandler creates the OAuth handler. sharedCache stores provider
+// signing keys and pending OAuth states so a callback may land on any replica.
+// It may be nil, in which case keys are fetched on every sign-in and the state
+// is checked against the browser cookie alone.
+func NewOAuthHandler(userSvc userDomain.Service, log *logger.Logger, oauthCfg config.OAuthConfig, cookieCfg CookieConfig, sharedCache cache.Cache) *OAuthHandler {
 	return &OAuthHandler{
 		userSvc:      userSvc,
 		log:          log.With("handler", "oauth"),
 		oauthConfig:  oauthCfg,
 		cookieConfig: cookieCfg,
+		appleKeys:    newKeySource(appleKeysURL, sharedCache),
+		states:       sharedCache,
 	}
 }
 
@@ -68,6 +85,69 @@ func generateState() (string, error) {
 	return base64.URLEncoding.EncodeToString(b), nil
 }
 
+// beginState creates the state for a sign-in with provider, records it in the
+// shared cache and binds it to the browser with a cookie.
+func (h *OAuthHandler) beginState(ctx *gin.Context, provider string) (string, error) {
+	state, err := generateState()
+	if err != nil {
+		return "", err
+	}
+
+	if h.states != nil {
+		if err := h.states.Set(ctx.Request.Context(), oauthStateKeyPrefix+state, []byte(provider), oauthStateTTL); err != nil {
+			return "", err
+		}
+	}
+
+	ctx.SetCookie(oauthStateCookie, state, int(oauthStateTTL.Seconds()), "/", h.cookieConfig.Domain, h.cookieConfig.Secure, true)
+	return state, nil
+}
+
+// consumeState reports whether state was issued for provider and has not been
+// used yet. With a shared cache the state is single-use and a cookie sent by
+// the browser must match it; requireCookie is false only for Apple, whose
+// form_post callback is a cross-site POST that drops the Lax cookie. Without a
+// cache the cookie is the only record of the state.
+func (h *OAuthHandler) consumeState(ctx *gin.Context, provider, state string, requireCookie bool) bool {
+	cookie, cookieErr := ctx.Cookie(oauthStateCookie)
+	ctx.SetCookie(oauthStateCookie, "", -1, "/", h.cookieConfig.Domain, h.cookieConfig.Secure, true)
+
+	if state == "" {
+		return false
+	}
+	if cookieErr == nil && cookie != state {
+		return false
+	}
+
+	if h.states == nil {
+		return cookieErr == nil
+	}
+	if cookieErr != nil && requireCookie {
+		return false
+	}
+
+	key := oauthStateKeyPrefix + state
+	stored, err := h.states.Get(ctx.Request.Context(), key)
+	if err != nil {
+		if !errors.Is(err, cache.ErrMiss) {
+			h.log.ErrorContext(ctx.Request.Context(), "failed to load oauth state", "provider", provider, "error", err)
+		}
+		return false
+	}
+	if string(stored) != provider {
+		return false
+	}
+	// Get then Delete would let two replicas both accept the state before
+	// either deletes it; only the first to count its use goes through.
+	used, err := h.states.Incr(ctx.Request.Context(), key+":used", oauthStateTTL)
+	if err != nil {
+		h.log.ErrorContext(ctx.Request.Context(), "failed to mark oauth state used", "provider", provider, "error", err)
+		return false
+	}
+	_ = h.states.Delete(ctx.Request.Context(), key)
+	return used == 1
+}
+
 // Google OAuth
 
 func (h *OAuthHandler) GoogleLogin(ctx *gin.Context) {
@@ -76,16 +156,13 @@ func (h *OAuthHandler) GoogleLogin(ctx *gin.Context) {
 		return
 	}
 
-	state, err := generateState()
+	state, err := h.beginState(ctx, "google")
 	if err != nil {
-		h.log.Error("failed to generate state", "error", err)
+		h.log.ErrorContext(ctx.Request.Context(), "failed to generate state", "error", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to initiate OAuth"})
 		return
 	}
 
-	// Store state in cookie for verification
-	ctx.SetCookie("oauth_state", state, 600, "/", h.cookieConfig.Domain, h.cookieConfig.Secure, true)
-
 	redirectURL := fmt.Sprintf("%s/api/v1/auth/oauth/google/callback", h.oauthConfig.RedirectBaseURL)
 	authURL := fmt.Sprintf(
 		"https://accounts.google.com/o/oauth2/v2/auth?client_id=%s&redirect_uri=%s&response_type=code&scope=email%%20profile&state=%s",
@@ -104,10 +181,8 @@ func (h *OAuthHandler) GoogleCallback(ctx *gin.Context) {
 	}
 
 	// Verify state
-	state := ctx.Query("state")
-	storedState, err := ctx.Cookie("oauth_state")
-	if err != nil || state != storedState {
-		h.log.Warn("oauth_callback", "provider", "google", "error", "invalid state")
+	if !h.consumeState(ctx, "google", ctx.Query("state"), true) {
+		h.log.WarnContext(ctx.Request.Context(), "oauth_callback", "provider", "google", "error", "invalid state")
 		h.redirectWithError(ctx, "Invalid OAuth state")
 		return
 	}
@@ -122,7 +197,7 @@ func (h *OAuthHandler) GoogleCallback(ctx *gin.Context) {
 	redirectURL := fmt.Sprintf("%s/api/v1/auth/oauth/google/callback", h.oauthConfig.RedirectBaseURL)
 	tokenResp, err := h.exchangeGoogleCode(code, redirectURL)
 	if err != nil {
-		h.log.Error("google_token_exchange", "error", err)
+		h.log.ErrorContext(ctx.Request.Context(), "google_token_exchange", "error", err)
 		h.redirectWithError(ctx, "Failed to authenticate with Google")
 		return
 	}
@@ -130,7 +205,7 @@ func (h *OAuthHandler) GoogleCallback(ctx *gin.Context) {
 	// Get user info
 	userInfo, err := h.getGoogleUserInfo(tokenResp.AccessToken)
 	if err != nil {
-		h.log.Error("google_userinfo", "error", err)
+		h.log.ErrorContext(ctx.Request.Context(), "google_userinfo", "error", err)
 		h.redirectWithError(ctx, "Failed to get user info from Google")
 		return
 	}
@@ -195,9 +270,9 @@ func (h *OAuthHandler) getGoogleUserInfo(accessToken string) (*OAuthUserInfo, er
 	}
 
 	var data struct {
-		ID        string `json:"id"`
-		Email     string `json:"email"`
-		GivenName string `json:"given_name"`
+		ID         string `json:"id"`
+		Email      string `json:"email"`
+		GivenName  string `json:"given_name"`
 		FamilyName string `json:"family_name"`
 	}
 	if err := json.Unmarshal(body, &data); err != nil {
@@ -221,15 +296,13 @@ func (h *OAuthHandler) FacebookLogin(ctx *gin.Context) {
 		return
 	}
 
-	state, err := generateState()
+	state, err := h.beginState(ctx, "facebook")
 	if err != nil {
-		h.log.Error("failed to generate state", "error", err)
+		h.log.ErrorContext(ctx.Request.Context(), "failed to generate state", "error", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to initiate OAuth"})
 		return
 	}
 
-	ctx.SetCookie("oauth_state", state, 600, "/", h.cookieConfig.Domain, h.cookieConfig.Secure, true)
-
 	redirectURL := fmt.Sprintf("%s/api/v1/auth/oauth/facebook/callback", h.oauthConfig.RedirectBaseURL)
 	authURL := fmt.Sprintf(
 		"https://www.facebook.com/v18.0/dialog/oauth?client_id=%s&redirect_uri=%s&scope=email&state=%s",
@@ -247,10 +320,8 @@ func (h *OAuthHandler) FacebookCallback(ctx *gin.Context) {
 		return
 	}
 
-	state := ctx.Query("state")
-	storedState, err := ctx.Cookie("oauth_state")
-	if err != nil || state != storedState {
-		h.log.Warn("oauth_callback", "provider", "facebook", "error", "invalid state")
+	if !h.consumeState(ctx, "facebook", ctx.Query("state"), true) {
+		h.log.WarnContext(ctx.Request.Context(), "oauth_callback", "provider", "facebook", "error", "invalid state")
 		h.redirectWithError(ctx, "Invalid OAuth state")
 		return
 	}
@@ -264,14 +335,14 @@ func (h *OAuthHandler) FacebookCallback(ctx *gin.Context) {
 	redirectURL := fmt.Sprintf("%s/api/v1/auth/oauth/facebook/callback", h.oauthConfig.RedirectBaseURL)
 	accessToken, err := h.exchangeFacebookCode(code, redirectURL)
 	if err != nil {
-		h.log.Error("facebook_token_exchange", "error", err)
+		h.log.ErrorContext(ctx.Request.Context(), "facebook_token_exchange", "error", err)
 		h.redirectWithError(ctx, "Failed to authenticate with Facebook")
 		return
 	}
 
 	userInfo, err := h.getFacebookUserInfo(accessToken)
 	if err != nil {
-		h.log.Error("facebook_userinfo", "error", err)
+		h.log.ErrorContext(ctx.Request.Context(), "facebook_userinfo", "error", err)
 		h.redirectWithError(ctx, "Failed to get user info from Facebook")
 		return
 	}
@@ -360,15 +431,13 @@ func (h *OAuthHandler) AppleLogin(ctx *gin.Context) {
 		return
 	}
 
-	state, err := generateState()
+	state, err := h.beginState(ctx, "apple")
 	if err != nil {
-		h.log.Error("failed to generate state", "error", err)
+		h.log.ErrorContext(ctx.Request.Context(), "failed to generate state", "error", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to initiate OAuth"})
 		return
 	}
 
-	ctx.SetCookie("oauth_state", state, 600, "/", h.cookieConfig.Domain, h.cookieConfig.Secure, true)
-
 	redirectURL := fmt.Sprintf("%s/api/v1/auth/oauth/apple/callback", h.oauthConfig.RedirectBaseURL)
 	authURL := fmt.Sprintf(
 		"https://appleid.apple.com/auth/authorize?client_id=%s&redirect_uri=%s&response_type=code&scope=email%%20name&response_mode=form_post&state=%s",
@@ -392,9 +461,8 @@ func (h *OAuthHandler) AppleCallback(ctx *gin.Context) {
 		state = ctx.Query("state")
 	}
 
-	storedState, err := ctx.Cookie("oauth_state")
-	if err != nil || state != storedState {
-		h.log.Warn("oauth_callback", "provider", "apple", "error", "invalid state")
+	if !h.consumeState(ctx, "apple", state, false) {
+		h.log.WarnContext(ctx.Request.Context(), "oauth_callback", "provider", "apple", "error", "invalid state")
 		h.redirectWithError(ctx, "Invalid OAuth state")
 		return
 	}
@@ -424,7 +492,7 @@ func (h *OAuthHandler) AppleCallback(ctx *gin.Context) {
 	// Exchange code for tokens and get ID token claims
 	userInfo, err := h.exchangeAppleCode(ctx.Request.Context(), code, appleUser.Name.FirstName, appleUser.Name.LastName)
 	if err != nil {
-		h.log.Error("apple_token_exchange", "error", err)
+		h.log.ErrorContext(ctx.Request.Context(), "apple_token_exchange", "error", err)
 		h.redirectWithError(ctx, "Failed to authenticate with Apple")
 		return
 	}
@@ -479,10 +547,9 @@ func (h *OAuthHandler) exchangeAppleCode(ctx context.Context, code, firstName, l
 		return nil, fmt.Errorf("apple error: %s", tokenResp.Error)
 	}
 
-	// Parse ID token to get user info (simplified - should verify signature in production)
-	claims, err := parseJWTClaims(tokenResp.IDToken)
+	claims, err := h.appleKeys.verifyIDToken(ctx, tokenResp.IDToken, appleIssuer, h.oauthConfig.Apple.ClientID)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("apple id token: %w", err)
 	}
 
 	email, _ := claims["email"].(string)
@@ -503,31 +570,11 @@ func (h *OAuthHandler) generateAppleClientSecret() string {
 	return h.oauthConfig.Apple.PrivateKey
 }
 
-// parseJWTClaims extracts claims from a JWT without verification (for demo purposes)
-func parseJWTClaims(tokenString string) (map[string]interface{}, error) {
-	parts := strings.Split(tokenString, ".")
-	if len(parts) != 3 {
-		return nil, fmt.Errorf("invalid token format")
-	}
-
-	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
-	if err != nil {
-		return nil, err
-	}
-
-	var claims map[string]interface{}
-	if err := json.Unmarshal(payload, &claims); err != nil {
-		return nil, err
-	}
-
-	return claims, nil
-}
-
 // Common OAuth user handling
 
 func (h *OAuthHandler) handleOAuthUser(ctx *gin.Context, userInfo *OAuthUserInfo) {
 	if userInfo.Email == "" {
-		h.log.Warn("oauth_user", "provider", userInfo.Provider, "error", "no email provided")
+		h.log.WarnContext(ctx.Request.Context(), "oauth_user", "provider", userInfo.Provider, "error", "no email provided")
 		h.redirectWithError(ctx, "Email is required for registration")
 		return
 	}
@@ -551,19 +598,19 @@ func (h *OAuthHandler) handleOAuthUser(ctx *gin.Context, userInfo *OAuthUserInfo
 			LastName:  lastName,
 		}, userInfo.Provider, userInfo.ID)
 		if err != nil {
-			h.log.Error("oauth_register", "provider", userInfo.Provider, "error", err)
+			h.log.ErrorContext(ctx.Request.Context(), "oauth_register", "provider", userInfo.Provider, "error", err)
 			h.redirectWithError(ctx, "Failed to create account")
 			return
 		}
-		h.log.Info("oauth_register", "provider", userInfo.Provider, "user_id", user.ID, "email", user.Email)
+		h.log.InfoContext(ctx.Request.Context(), "oauth_register", "provider", userInfo.Provider, "user_id", user.ID, "email", user.Email)
 	} else {
-		h.log.Info("oauth_login", "provider", userInfo.Provider, "user_id", user.ID, "email", user.Email)
+		h.log.InfoContext(ctx.Request.Context(), "oauth_login", "provider", userInfo.Provider, "user_id", user.ID, "email", user.Email)
 	}
 
 	// Generate JWT token
-	token, err := h.userSvc.GenerateToken(user)
+	token, err := h.userSvc.GenerateToken(redirectDeviceContext(ctx), user)
 	if err != nil {
-		h.log.Error("oauth_token", "error", err)
+		h.log.ErrorContext(ctx.Request.Context(), "oauth_token", "error", err)
 		h.redirectWithError(ctx, "Failed to generate session")
 		return
 	}
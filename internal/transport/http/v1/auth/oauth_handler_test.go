@@ -7,10 +7,14 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
 
+	userApp "github.com/elprogramadorgt/lucidRAG/internal/application/user"
 	"github.com/elprogramadorgt/lucidRAG/internal/config"
 	userDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/user"
+	"github.com/elprogramadorgt/lucidRAG/pkg/cache"
 	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
 	"github.com/gin-gonic/gin"
 )
@@ -19,7 +23,7 @@ import (
 type mockUserServiceOAuth struct {
 	getUserByEmailFunc func(ctx context.Context, email string) (*userDomain.User, error)
 	registerOAuthFunc  func(ctx context.Context, newUser userDomain.User, provider, providerID string) (*userDomain.User, error)
-	generateTokenFunc  func(user *userDomain.User) (string, error)
+	generateTokenFunc  func(ctx context.Context, user *userDomain.User) (string, error)
 }
 
 func (m *mockUserServiceOAuth) Register(ctx context.Context, newUser userDomain.User) (*userDomain.User, error) {
@@ -60,13 +64,41 @@ func (m *mockUserServiceOAuth) ValidateToken(token string) (*userDomain.Claims,
 	return nil, nil
 }
 
-func (m *mockUserServiceOAuth) GenerateToken(user *userDomain.User) (string, error) {
+func (m *mockUserServiceOAuth) CheckSession(ctx context.Context, claims *userDomain.Claims) error {
+	return nil
+}
+
+func (m *mockUserServiceOAuth) GenerateToken(ctx context.Context, user *userDomain.User) (string, error) {
 	if m.generateTokenFunc != nil {
-		return m.generateTokenFunc(user)
+		return m.generateTokenFunc(ctx, user)
 	}
 	return "mock-jwt-token", nil
 }
 
+func (m *mockUserServiceOAuth) GetPreferences(ctx context.Context, userID string) (*userDomain.Preferences, error) {
+	return userDomain.DefaultPreferences(userID), nil
+}
+
+func (m *mockUserServiceOAuth) UpdatePreferences(ctx context.Context, prefs *userDomain.Preferences) (*userDomain.Preferences, error) {
+	return prefs, nil
+}
+
+func (m *mockUserServiceOAuth) ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) error {
+	return nil
+}
+
+func (m *mockUserServiceOAuth) SetActive(ctx context.Context, userID string, active bool) (*userDomain.User, error) {
+	return nil, nil
+}
+
+func (m *mockUserServiceOAuth) ListSessions(ctx context.Context, userID, currentID string) ([]userDomain.Session, error) {
+	return nil, nil
+}
+
+func (m *mockUserServiceOAuth) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	return nil
+}
+
 func setupOAuthTestRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	return gin.New()
@@ -102,6 +134,7 @@ func createTestOAuthHandler(mockSvc *mockUserServiceOAuth) *OAuthHandler {
 			Secure:      false,
 			ExpiryHours: 24,
 		},
+		nil,
 	)
 }
 
@@ -149,6 +182,7 @@ func TestGetProvidersDisabled(t *testing.T) {
 			Apple:           config.AppleOAuthConfig{Enabled: false},
 		},
 		CookieConfig{},
+		nil,
 	)
 
 	router := setupOAuthTestRouter()
@@ -182,6 +216,7 @@ func TestGoogleLoginDisabled(t *testing.T) {
 			Google: config.OAuthProviderConfig{Enabled: false},
 		},
 		CookieConfig{},
+		nil,
 	)
 
 	router := setupOAuthTestRouter()
@@ -233,6 +268,7 @@ func TestFacebookLoginDisabled(t *testing.T) {
 			Facebook: config.OAuthProviderConfig{Enabled: false},
 		},
 		CookieConfig{},
+		nil,
 	)
 
 	router := setupOAuthTestRouter()
@@ -279,6 +315,7 @@ func TestAppleLoginDisabled(t *testing.T) {
 			Apple: config.AppleOAuthConfig{Enabled: false},
 		},
 		CookieConfig{},
+		nil,
 	)
 
 	router := setupOAuthTestRouter()
@@ -363,42 +400,102 @@ func TestGoogleCallbackNoCode(t *testing.T) {
 	}
 }
 
-func TestParseJWTClaims(t *testing.T) {
-	// Create a test JWT payload
-	payload := map[string]interface{}{
-		"sub":   "user-123",
-		"email": "test@example.com",
+func createTestOAuthHandlerWithCache(c cache.Cache) *OAuthHandler {
+	h := createTestOAuthHandler(&mockUserServiceOAuth{})
+	h.states = c
+	h.appleKeys = newKeySource(appleKeysURL, c)
+	return h
+}
+
+func stateTestContext(cookie string) *gin.Context {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request, _ = http.NewRequest("GET", "/callback", nil)
+	if cookie != "" {
+		ctx.Request.AddCookie(&http.Cookie{Name: oauthStateCookie, Value: cookie})
+	}
+	return ctx
+}
+
+func TestOAuthStateSharedBetweenReplicas(t *testing.T) {
+	shared := cache.NewMemory()
+	replicaA := createTestOAuthHandlerWithCache(shared)
+	replicaB := createTestOAuthHandlerWithCache(shared)
+
+	state, err := replicaA.beginState(stateTestContext(""), "google")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !replicaB.consumeState(stateTestContext(state), "google", state, true) {
+		t.Error("Expected state issued by another replica to be accepted")
+	}
+	if replicaB.consumeState(stateTestContext(state), "google", state, true) {
+		t.Error("Expected state to be single-use")
 	}
-	payloadBytes, _ := json.Marshal(payload)
-	encodedPayload := base64.RawURLEncoding.EncodeToString(payloadBytes)
+}
 
-	// Create a mock JWT (header.payload.signature)
-	mockJWT := "eyJhbGciOiJSUzI1NiJ9." + encodedPayload + ".mock-signature"
+func TestOAuthStateSingleUseUnderRace(t *testing.T) {
+	shared := cache.NewMemory()
+	replicas := []*OAuthHandler{createTestOAuthHandlerWithCache(shared), createTestOAuthHandlerWithCache(shared)}
 
-	claims, err := parseJWTClaims(mockJWT)
+	state, err := replicas[0].beginState(stateTestContext(""), "google")
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
 
-	if claims["sub"] != "user-123" {
-		t.Errorf("Expected sub user-123, got %v", claims["sub"])
+	var accepted atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if replicas[i%2].consumeState(stateTestContext(state), "google", state, true) {
+				accepted.Add(1)
+			}
+		}()
 	}
-	if claims["email"] != "test@example.com" {
-		t.Errorf("Expected email test@example.com, got %v", claims["email"])
+	wg.Wait()
+	if accepted.Load() != 1 {
+		t.Errorf("Expected the state accepted once, got %d", accepted.Load())
 	}
 }
 
-func TestParseJWTClaimsInvalidFormat(t *testing.T) {
-	_, err := parseJWTClaims("invalid-jwt")
-	if err == nil {
-		t.Error("Expected error for invalid JWT format")
+func TestOAuthStateRejectsOtherProvider(t *testing.T) {
+	h := createTestOAuthHandlerWithCache(cache.NewMemory())
+
+	state, _ := h.beginState(stateTestContext(""), "facebook")
+	if h.consumeState(stateTestContext(state), "google", state, true) {
+		t.Error("Expected state issued for facebook to be rejected for google")
 	}
 }
 
-func TestParseJWTClaimsInvalidBase64(t *testing.T) {
-	_, err := parseJWTClaims("header.!!!invalid-base64!!!.signature")
-	if err == nil {
-		t.Error("Expected error for invalid base64")
+func TestOAuthStateCookie(t *testing.T) {
+	h := createTestOAuthHandlerWithCache(cache.NewMemory())
+
+	state, _ := h.beginState(stateTestContext(""), "google")
+	if h.consumeState(stateTestContext(""), "google", state, true) {
+		t.Error("Expected missing cookie to be rejected when required")
+	}
+
+	state, _ = h.beginState(stateTestContext(""), "google")
+	if h.consumeState(stateTestContext("other-state"), "google", state, true) {
+		t.Error("Expected mismatched cookie to be rejected")
+	}
+
+	state, _ = h.beginState(stateTestContext(""), "apple")
+	if !h.consumeState(stateTestContext(""), "apple", state, false) {
+		t.Error("Expected apple form_post callback without cookie to be accepted")
+	}
+}
+
+func TestOAuthStateWithoutCache(t *testing.T) {
+	h := createTestOAuthHandler(&mockUserServiceOAuth{})
+
+	if !h.consumeState(stateTestContext("test-state"), "google", "test-state", true) {
+		t.Error("Expected matching cookie to be accepted")
+	}
+	if h.consumeState(stateTestContext(""), "apple", "test-state", false) {
+		t.Error("Expected state without cookie to be rejected when there is no cache")
 	}
 }
 
@@ -489,3 +586,30 @@ func containsAt(s, substr string, start int) bool {
 	}
 	return false
 }
+
+func TestOAuthSignInLeavesSessionUnbound(t *testing.T) {
+	var device userApp.Device
+	mockSvc := &mockUserServiceOAuth{
+		getUserByEmailFunc: func(ctx context.Context, email string) (*userDomain.User, error) {
+			return &userDomain.User{ID: "admin-1", Email: email, Role: userDomain.RoleAdmin}, nil
+		},
+		generateTokenFunc: func(ctx context.Context, user *userDomain.User) (string, error) {
+			device = userApp.DeviceFromContext(ctx)
+			return "mock-jwt-token", nil
+		},
+	}
+	handler := createTestOAuthHandler(mockSvc)
+
+	resp := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(resp)
+	ctx.Request, _ = http.NewRequest("GET", "/callback", nil)
+	ctx.Request.Header.Set("User-Agent", "Firefox")
+	handler.handleOAuthUser(ctx, &OAuthUserInfo{ID: "google-1", Email: "admin@example.com", Provider: "google"})
+
+	if !contains(resp.Header().Get("Location"), "success=true") {
+		t.Fatalf("Expected a successful sign-in, got %s", resp.Header().Get("Location"))
+	}
+	if device.UserAgent != "Firefox" || device.Fingerprint != "" {
+		t.Errorf("Expected the session started unbound, got %+v", device)
+	}
+}
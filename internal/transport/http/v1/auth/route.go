@@ -6,12 +6,26 @@ func Register(rg *gin.RouterGroup, handler *Handler, authMiddleware gin.HandlerF
 	auth := rg.Group("/auth")
 	{
 		auth.POST("/register", handler.Register)
+		auth.GET("/captcha", handler.GetCaptcha)
 		auth.POST("/login", handler.Login)
 		auth.POST("/logout", handler.Logout)
 		auth.GET("/me", authMiddleware, handler.Me)
+		auth.GET("/me/preferences", authMiddleware, handler.GetPreferences)
+		auth.PUT("/me/preferences", authMiddleware, handler.UpdatePreferences)
+		auth.PUT("/me/password", authMiddleware, handler.ChangePassword)
 	}
 }
 
+// RegisterSessions mounts the signed-in user's own sessions.
+func RegisterSessions(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("", handler.ListSessions)
+	rg.DELETE("/:id", handler.RevokeSession)
+}
+
+func RegisterUsers(rg *gin.RouterGroup, handler *Handler) {
+	rg.PATCH("/:id/status", handler.SetUserStatus)
+}
+
 func RegisterOAuth(rg *gin.RouterGroup, handler *OAuthHandler) {
 	oauth := rg.Group("/auth/oauth")
 	{
@@ -0,0 +1,122 @@
+package backup
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	backupApp "github.com/elprogramadorgt/lucidRAG/internal/application/backup"
+	backupDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/backup"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	svc backupDomain.Service
+	log *logger.Logger
+}
+
+func NewHandler(svc backupDomain.Service, log *logger.Logger) *Handler {
+	return &Handler{
+		svc: svc,
+		log: log.With("handler", "backup"),
+	}
+}
+
+// extendDeadlines lets backup transfers outlive the server's read and write
+// timeouts, which are sized for ordinary API calls.
+func extendDeadlines(ctx *gin.Context) {
+	rc := http.NewResponseController(ctx.Writer)
+	_ = rc.SetReadDeadline(time.Time{})
+	_ = rc.SetWriteDeadline(time.Time{})
+}
+
+func (h *Handler) writeError(ctx *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, backupApp.ErrBackupNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "backup not found"})
+	case errors.Is(err, backupApp.ErrCorruptBackup), errors.Is(err, backupApp.ErrUnsupportedVersion):
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		h.log.ErrorContext(ctx.Request.Context(), "failed to "+action, "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to " + action})
+	}
+}
+
+func (h *Handler) List(ctx *gin.Context) {
+	backups, err := h.svc.List(ctx.Request.Context())
+	if err != nil {
+		h.writeError(ctx, err, "list backups")
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"backups": backups})
+}
+
+func (h *Handler) Create(ctx *gin.Context) {
+	extendDeadlines(ctx)
+	adminID := ctx.GetString("user_id")
+
+	manifest, err := h.svc.Create(ctx.Request.Context(), adminID)
+	if err != nil {
+		h.writeError(ctx, err, "create backup")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "backup_create", "admin_id", adminID, "backup_id", manifest.ID, "size_bytes", manifest.SizeBytes)
+	ctx.JSON(http.StatusCreated, manifest)
+}
+
+func (h *Handler) Download(ctx *gin.Context) {
+	extendDeadlines(ctx)
+	adminID := ctx.GetString("user_id")
+	id := ctx.Param("id")
+
+	rc, err := h.svc.Open(ctx.Request.Context(), id)
+	if err != nil {
+		h.writeError(ctx, err, "download backup")
+		return
+	}
+	defer func() { _ = rc.Close() }()
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "backup_download", "admin_id", adminID, "backup_id", id)
+	ctx.DataFromReader(http.StatusOK, -1, "application/gzip", rc, map[string]string{
+		"Content-Disposition": `attachment; filename="lucidrag-backup-` + id + `.jsonl.gz"`,
+	})
+}
+
+func (h *Handler) Restore(ctx *gin.Context) {
+	extendDeadlines(ctx)
+	adminID := ctx.GetString("user_id")
+
+	manifest, err := h.svc.Restore(ctx.Request.Context(), adminID, ctx.Param("id"))
+	if err != nil {
+		h.writeError(ctx, err, "restore backup")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "backup_restore", "admin_id", adminID, "backup_id", manifest.ID)
+	ctx.JSON(http.StatusOK, gin.H{"message": "backup restored", "backup": manifest})
+}
+
+func (h *Handler) RestoreUpload(ctx *gin.Context) {
+	extendDeadlines(ctx)
+	adminID := ctx.GetString("user_id")
+
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+
+	manifest, err := h.svc.RestoreFile(ctx.Request.Context(), adminID, func() (io.ReadCloser, error) {
+		return file.Open()
+	})
+	if err != nil {
+		h.writeError(ctx, err, "restore backup")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "backup_restore_upload", "admin_id", adminID, "backup_id", manifest.ID, "filename", file.Filename)
+	ctx.JSON(http.StatusOK, gin.H{"message": "backup restored", "backup": manifest})
+}
@@ -0,0 +1,140 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	backupApp "github.com/elprogramadorgt/lucidRAG/internal/application/backup"
+	backupDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/backup"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// mockService implements backupDomain.Service for testing
+type mockService struct {
+	listFn    func(ctx context.Context) ([]backupDomain.Manifest, error)
+	openFn    func(ctx context.Context, id string) (io.ReadCloser, error)
+	restoreFn func(ctx context.Context, adminID, id string) (*backupDomain.Manifest, error)
+}
+
+func (m *mockService) Create(ctx context.Context, adminID string) (*backupDomain.Manifest, error) {
+	return &backupDomain.Manifest{ID: "b1", CreatedBy: adminID}, nil
+}
+
+func (m *mockService) List(ctx context.Context) ([]backupDomain.Manifest, error) {
+	if m.listFn != nil {
+		return m.listFn(ctx)
+	}
+	return []backupDomain.Manifest{}, nil
+}
+
+func (m *mockService) Open(ctx context.Context, id string) (io.ReadCloser, error) {
+	if m.openFn != nil {
+		return m.openFn(ctx, id)
+	}
+	return nil, backupApp.ErrBackupNotFound
+}
+
+func (m *mockService) Restore(ctx context.Context, adminID, id string) (*backupDomain.Manifest, error) {
+	if m.restoreFn != nil {
+		return m.restoreFn(ctx, adminID, id)
+	}
+	return &backupDomain.Manifest{ID: id}, nil
+}
+
+func (m *mockService) RestoreFile(ctx context.Context, adminID string, open func() (io.ReadCloser, error)) (*backupDomain.Manifest, error) {
+	return &backupDomain.Manifest{ID: "uploaded"}, nil
+}
+
+func setupTestRouter(svc backupDomain.Service) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "admin-1")
+		c.Set("user_role", "admin")
+	})
+	Register(r.Group("/backups"), NewHandler(svc, logger.New(logger.Options{Level: "error"})))
+	return r
+}
+
+func TestCreateBackup(t *testing.T) {
+	router := setupTestRouter(&mockService{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/backups", nil))
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	var manifest backupDomain.Manifest
+	if err := json.Unmarshal(w.Body.Bytes(), &manifest); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if manifest.CreatedBy != "admin-1" {
+		t.Errorf("Expected created_by admin-1, got %s", manifest.CreatedBy)
+	}
+}
+
+func TestDownloadBackup(t *testing.T) {
+	router := setupTestRouter(&mockService{
+		openFn: func(ctx context.Context, id string) (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader("archive")), nil
+		},
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/backups/b1/download", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if w.Body.String() != "archive" {
+		t.Errorf("Expected archive body, got %q", w.Body.String())
+	}
+	if !strings.Contains(w.Header().Get("Content-Disposition"), "b1") {
+		t.Errorf("Expected filename to include the id, got %q", w.Header().Get("Content-Disposition"))
+	}
+}
+
+func TestDownloadBackupNotFound(t *testing.T) {
+	router := setupTestRouter(&mockService{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/backups/missing/download", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestRestoreCorruptBackup(t *testing.T) {
+	router := setupTestRouter(&mockService{
+		restoreFn: func(ctx context.Context, adminID, id string) (*backupDomain.Manifest, error) {
+			return nil, fmt.Errorf("%w: checksum mismatch", backupApp.ErrCorruptBackup)
+		},
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/backups/b1/restore", nil))
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+}
+
+func TestRestoreUploadRequiresFile(t *testing.T) {
+	router := setupTestRouter(&mockService{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/backups/restore", nil))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,11 @@
+package backup
+
+import "github.com/gin-gonic/gin"
+
+func Register(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("", handler.List)
+	rg.POST("", handler.Create)
+	rg.POST("/restore", handler.RestoreUpload)
+	rg.GET("/:id/download", handler.Download)
+	rg.POST("/:id/restore", handler.Restore)
+}
@@ -0,0 +1,274 @@
+package booking
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	bookingApp "github.com/elprogramadorgt/lucidRAG/internal/application/booking"
+	bookingDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/booking"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/elprogramadorgt/lucidRAG/pkg/tz"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultSlotRange is how far ahead slots are listed without a "to".
+const defaultSlotRange = 7 * 24 * time.Hour
+
+type Handler struct {
+	svc bookingDomain.Service
+	log *logger.Logger
+}
+
+func NewHandler(svc bookingDomain.Service, log *logger.Logger) *Handler {
+	return &Handler{
+		svc: svc,
+		log: log.With("handler", "booking"),
+	}
+}
+
+type resourceRequest struct {
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	Timezone    string                `json:"timezone"`
+	SlotMinutes int                   `json:"slot_minutes"`
+	Hours       []bookingDomain.Hours `json:"hours"`
+	CalendarID  string                `json:"calendar_id"`
+	IsActive    bool                  `json:"is_active"`
+}
+
+func (r resourceRequest) resource() *bookingDomain.Resource {
+	return &bookingDomain.Resource{
+		Name: r.Name, Description: r.Description, Timezone: r.Timezone, SlotMinutes: r.SlotMinutes,
+		Hours: r.Hours, CalendarID: r.CalendarID, IsActive: r.IsActive,
+	}
+}
+
+// bookingRequest takes start as RFC 3339 or as a local time in the user's
+// time zone.
+type bookingRequest struct {
+	ResourceID     string `json:"resource_id"`
+	ConversationID string `json:"conversation_id"`
+	ContactName    string `json:"contact_name"`
+	PhoneNumber    string `json:"phone_number"`
+	Start          string `json:"start" binding:"required"`
+	Notes          string `json:"notes"`
+}
+
+// bind reads the request into a booking, answering 400 when it cannot.
+func bind(ctx *gin.Context) (*bookingDomain.Booking, bool) {
+	var req bookingRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return nil, false
+	}
+	start, err := tz.Parse(req.Start, tz.FromContext(ctx.Request.Context()))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "start must be RFC 3339 or a local time"})
+		return nil, false
+	}
+	return &bookingDomain.Booking{
+		ResourceID: req.ResourceID, ConversationID: req.ConversationID, ContactName: req.ContactName,
+		PhoneNumber: req.PhoneNumber, Start: start, Notes: req.Notes,
+	}, true
+}
+
+// timeQuery parses the query parameter name, answering 400 when it is
+// malformed. A missing one returns the zero time.
+func timeQuery(ctx *gin.Context, name string) (time.Time, bool) {
+	value := ctx.Query(name)
+	if value == "" {
+		return time.Time{}, true
+	}
+	t, err := tz.Parse(value, tz.FromContext(ctx.Request.Context()))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": name + " must be RFC 3339 or a local time"})
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func (h *Handler) writeError(ctx *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, bookingApp.ErrInvalidResource), errors.Is(err, bookingApp.ErrInvalidBooking):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, bookingApp.ErrResourceNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "resource not found"})
+	case errors.Is(err, bookingApp.ErrBookingNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "booking not found"})
+	case errors.Is(err, bookingApp.ErrResourceInUse), errors.Is(err, bookingApp.ErrSlotUnavailable):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		h.log.ErrorContext(ctx.Request.Context(), "failed to "+action, "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to " + action})
+	}
+}
+
+func (h *Handler) ListResources(ctx *gin.Context) {
+	resources, err := h.svc.ListResources(ctx.Request.Context())
+	if err != nil {
+		h.writeError(ctx, err, "list resources")
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"resources": resources})
+}
+
+func (h *Handler) CreateResource(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+
+	var req resourceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	id, err := h.svc.CreateResource(ctx.Request.Context(), adminID, req.resource())
+	if err != nil {
+		h.writeError(ctx, err, "create resource")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "booking_resource_create", "admin_id", adminID, "resource_id", id)
+	ctx.JSON(http.StatusCreated, gin.H{"id": id, "message": "resource created"})
+}
+
+func (h *Handler) UpdateResource(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+
+	var req resourceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	resource := req.resource()
+	resource.ID = ctx.Param("id")
+	if err := h.svc.UpdateResource(ctx.Request.Context(), adminID, resource); err != nil {
+		h.writeError(ctx, err, "update resource")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "booking_resource_update", "admin_id", adminID, "resource_id", resource.ID, "is_active", resource.IsActive)
+	ctx.JSON(http.StatusOK, resource)
+}
+
+func (h *Handler) DeleteResource(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+	id := ctx.Param("id")
+
+	if err := h.svc.DeleteResource(ctx.Request.Context(), adminID, id); err != nil {
+		h.writeError(ctx, err, "delete resource")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "booking_resource_delete", "admin_id", adminID, "resource_id", id)
+	ctx.JSON(http.StatusOK, gin.H{"message": "resource deleted"})
+}
+
+// Slots lists a resource's free slots from "from", default now, to "to",
+// default a week later.
+func (h *Handler) Slots(ctx *gin.Context) {
+	from, ok := timeQuery(ctx, "from")
+	if !ok {
+		return
+	}
+	to, ok := timeQuery(ctx, "to")
+	if !ok {
+		return
+	}
+	if from.IsZero() {
+		from = time.Now()
+	}
+	if to.IsZero() {
+		to = from.Add(defaultSlotRange)
+	}
+
+	slots, err := h.svc.Slots(ctx.Request.Context(), ctx.Param("id"), from, to)
+	if err != nil {
+		h.writeError(ctx, err, "list slots")
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"slots": slots})
+}
+
+func (h *Handler) List(ctx *gin.Context) {
+	from, ok := timeQuery(ctx, "from")
+	if !ok {
+		return
+	}
+	to, ok := timeQuery(ctx, "to")
+	if !ok {
+		return
+	}
+
+	bookings, err := h.svc.ListBookings(ctx.Request.Context(), bookingDomain.Filter{
+		ResourceID:     ctx.Query("resource_id"),
+		ConversationID: ctx.Query("conversation_id"),
+		Status:         bookingDomain.Status(ctx.Query("status")),
+		From:           from,
+		To:             to,
+	})
+	if err != nil {
+		h.writeError(ctx, err, "list bookings")
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"bookings": bookings, "total": len(bookings)})
+}
+
+func (h *Handler) Create(ctx *gin.Context) {
+	booking, ok := bind(ctx)
+	if !ok {
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	id, err := h.svc.CreateBooking(ctx.Request.Context(), userID, booking)
+	if err != nil {
+		h.writeError(ctx, err, "create booking")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "booking created", "booking_id", id, "resource_id", booking.ResourceID, "user_id", userID)
+	ctx.JSON(http.StatusCreated, booking)
+}
+
+func (h *Handler) Get(ctx *gin.Context) {
+	booking, err := h.svc.GetBooking(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		h.writeError(ctx, err, "get booking")
+		return
+	}
+	ctx.JSON(http.StatusOK, booking)
+}
+
+// Update moves a booking and replaces its contact and notes.
+func (h *Handler) Update(ctx *gin.Context) {
+	booking, ok := bind(ctx)
+	if !ok {
+		return
+	}
+
+	userID := ctx.GetString("user_id")
+	booking.ID = ctx.Param("id")
+	if err := h.svc.UpdateBooking(ctx.Request.Context(), userID, booking); err != nil {
+		h.writeError(ctx, err, "update booking")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "booking updated", "booking_id", booking.ID, "user_id", userID)
+	ctx.JSON(http.StatusOK, booking)
+}
+
+// Cancel cancels a booking; it is kept with status cancelled.
+func (h *Handler) Cancel(ctx *gin.Context) {
+	userID := ctx.GetString("user_id")
+	id := ctx.Param("id")
+
+	if err := h.svc.CancelBooking(ctx.Request.Context(), userID, id); err != nil {
+		h.writeError(ctx, err, "cancel booking")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "booking cancelled", "booking_id", id, "user_id", userID)
+	ctx.JSON(http.StatusOK, gin.H{"message": "booking cancelled"})
+}
@@ -0,0 +1,107 @@
+package booking
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	bookingApp "github.com/elprogramadorgt/lucidRAG/internal/application/booking"
+	bookingDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/booking"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/elprogramadorgt/lucidRAG/pkg/tz"
+	"github.com/gin-gonic/gin"
+)
+
+// mockService records the booking it is given; the methods these tests do
+// not use are left to the embedded interface.
+type mockService struct {
+	bookingDomain.Service
+	created  *bookingDomain.Booking
+	from, to time.Time
+}
+
+func (m *mockService) CreateBooking(ctx context.Context, userID string, booking *bookingDomain.Booking) (string, error) {
+	if m.created != nil {
+		return "", bookingApp.ErrSlotUnavailable
+	}
+	m.created = booking
+	booking.ID = "booking-1"
+	return booking.ID, nil
+}
+
+func (m *mockService) Slots(ctx context.Context, resourceID string, from, to time.Time) ([]bookingDomain.Slot, error) {
+	if resourceID != "res-1" {
+		return nil, bookingApp.ErrResourceNotFound
+	}
+	m.from, m.to = from, to
+	return []bookingDomain.Slot{}, nil
+}
+
+func setupTestRouter(svc bookingDomain.Service, loc *time.Location) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "agent-1")
+		c.Request = c.Request.WithContext(tz.WithLocation(c.Request.Context(), loc))
+	})
+	Register(r.Group("/bookings"), NewHandler(svc, logger.New(logger.Options{Level: "error"})))
+	return r
+}
+
+func TestCreateBookingReadsLocalTimes(t *testing.T) {
+	loc := time.FixedZone("CST", -6*60*60)
+	svc := &mockService{}
+	router := setupTestRouter(svc, loc)
+
+	body := `{"resource_id":"res-1","contact_name":"Ana","start":"2026-10-20T09:00"}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/bookings", strings.NewReader(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if !svc.created.Start.Equal(time.Date(2026, 10, 20, 15, 0, 0, 0, time.UTC)) || svc.created.ContactName != "Ana" {
+		t.Errorf("Unexpected booking %+v", svc.created)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/bookings", strings.NewReader(body)))
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a taken slot, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/bookings", strings.NewReader(`{"resource_id":"res-1","start":"tomorrow"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unreadable start, got %d", w.Code)
+	}
+}
+
+func TestSlotsDefaultsToAWeek(t *testing.T) {
+	svc := &mockService{}
+	router := setupTestRouter(svc, time.UTC)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/bookings/resources/res-1/slots?from=2026-10-19", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Slots []bookingDomain.Slot `json:"slots"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Slots == nil {
+		t.Errorf("Expected an empty slot list, got %s", w.Body.String())
+	}
+	if svc.to.Sub(svc.from) != 7*24*time.Hour {
+		t.Errorf("Expected a week of slots, got %v to %v", svc.from, svc.to)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/bookings/resources/missing/slots", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown resource, got %d", w.Code)
+	}
+}
@@ -0,0 +1,22 @@
+package booking
+
+import "github.com/gin-gonic/gin"
+
+// RegisterResources registers the admin routes that manage bookable
+// resources.
+func RegisterResources(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("", handler.ListResources)
+	rg.POST("", handler.CreateResource)
+	rg.PUT("/:id", handler.UpdateResource)
+	rg.DELETE("/:id", handler.DeleteResource)
+}
+
+// Register registers the routes agents book with.
+func Register(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("/resources/:id/slots", handler.Slots)
+	rg.GET("", handler.List)
+	rg.POST("", handler.Create)
+	rg.GET("/:id", handler.Get)
+	rg.PUT("/:id", handler.Update)
+	rg.DELETE("/:id", handler.Cancel)
+}
@@ -0,0 +1,162 @@
+package conversation
+
+import (
+	"errors"
+	"net/http"
+
+	convApp "github.com/elprogramadorgt/lucidRAG/internal/application/conversation"
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	"github.com/gin-gonic/gin"
+)
+
+// assignmentError answers a failed assignment change, reporting whether err
+// was one.
+func assignmentError(ctx *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, convApp.ErrInvalidAssignmentRule):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, convApp.ErrNotHandedOff), errors.Is(err, convApp.ErrNoAgentAvailable):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, convApp.ErrAssignmentRuleNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "assignment rule not found"})
+	case errors.Is(err, convApp.ErrConversationNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "conversation not found"})
+	case errors.Is(err, convApp.ErrForbidden):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+	default:
+		return false
+	}
+	return true
+}
+
+type reassignRequest struct {
+	AgentID string `json:"agent_id"`
+}
+
+// Reassign moves a handed-off conversation to agent_id, or to the next
+// online agent by the assignment rules when it is left out.
+func (h *Handler) Reassign(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	var req reassignRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	userCtx := getUserContext(ctx)
+	conv, err := h.svc.Reassign(ctx.Request.Context(), userCtx, id, req.AgentID)
+	if err != nil {
+		if !assignmentError(ctx, err) {
+			h.log.ErrorContext(ctx.Request.Context(), "failed to reassign conversation", "error", err, "conversation_id", id)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reassign conversation"})
+		}
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "conversation_reassign", "admin_id", userCtx.UserID, "conversation_id", id, "agent_id", conv.AgentID)
+	ctx.JSON(http.StatusOK, conv)
+}
+
+// ListAssignments returns who a conversation was assigned to over time.
+func (h *Handler) ListAssignments(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	assignments, err := h.svc.ListAssignments(ctx.Request.Context(), getUserContext(ctx), id)
+	if err != nil {
+		if !assignmentError(ctx, err) {
+			h.log.ErrorContext(ctx.Request.Context(), "failed to list assignments", "error", err, "conversation_id", id)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list assignments"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"assignments": assignments, "total": len(assignments)})
+}
+
+type assignmentRuleRequest struct {
+	Name     string   `json:"name" binding:"required"`
+	Tags     []string `json:"tags" binding:"required"`
+	AgentIDs []string `json:"agent_ids" binding:"required"`
+	Priority int      `json:"priority"`
+}
+
+func (r assignmentRuleRequest) rule() *conversationDomain.AssignmentRule {
+	return &conversationDomain.AssignmentRule{Name: r.Name, Tags: r.Tags, AgentIDs: r.AgentIDs, Priority: r.Priority}
+}
+
+func (h *Handler) ListAssignmentRules(ctx *gin.Context) {
+	rules, err := h.svc.ListAssignmentRules(ctx.Request.Context(), getUserContext(ctx))
+	if err != nil {
+		if !assignmentError(ctx, err) {
+			h.log.ErrorContext(ctx.Request.Context(), "failed to list assignment rules", "error", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list assignment rules"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"rules": rules, "total": len(rules)})
+}
+
+func (h *Handler) CreateAssignmentRule(ctx *gin.Context) {
+	var req assignmentRuleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	userCtx := getUserContext(ctx)
+	id, err := h.svc.CreateAssignmentRule(ctx.Request.Context(), userCtx, req.rule())
+	if err != nil {
+		if !assignmentError(ctx, err) {
+			h.log.ErrorContext(ctx.Request.Context(), "failed to create assignment rule", "error", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create assignment rule"})
+		}
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "assignment_rule_create", "admin_id", userCtx.UserID, "rule_id", id)
+	ctx.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "assignment rule created successfully",
+	})
+}
+
+func (h *Handler) UpdateAssignmentRule(ctx *gin.Context) {
+	var req assignmentRuleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	id := ctx.Param("id")
+	userCtx := getUserContext(ctx)
+	rule := req.rule()
+	rule.ID = id
+	if err := h.svc.UpdateAssignmentRule(ctx.Request.Context(), userCtx, rule); err != nil {
+		if !assignmentError(ctx, err) {
+			h.log.ErrorContext(ctx.Request.Context(), "failed to update assignment rule", "error", err, "id", id)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update assignment rule"})
+		}
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "assignment_rule_update", "admin_id", userCtx.UserID, "rule_id", id)
+	ctx.JSON(http.StatusOK, rule)
+}
+
+func (h *Handler) DeleteAssignmentRule(ctx *gin.Context) {
+	id := ctx.Param("id")
+	userCtx := getUserContext(ctx)
+
+	if err := h.svc.DeleteAssignmentRule(ctx.Request.Context(), userCtx, id); err != nil {
+		if !assignmentError(ctx, err) {
+			h.log.ErrorContext(ctx.Request.Context(), "failed to delete assignment rule", "error", err, "id", id)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete assignment rule"})
+		}
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "assignment_rule_delete", "admin_id", userCtx.UserID, "rule_id", id)
+	ctx.JSON(http.StatusOK, gin.H{"message": "assignment rule deleted successfully"})
+}
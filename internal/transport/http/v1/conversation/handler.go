@@ -2,15 +2,23 @@ package conversation
 
 import (
 	"errors"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	convApp "github.com/elprogramadorgt/lucidRAG/internal/application/conversation"
+	reportApp "github.com/elprogramadorgt/lucidRAG/internal/application/report"
 	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
 	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/elprogramadorgt/lucidRAG/pkg/tz"
 	"github.com/gin-gonic/gin"
 )
 
+// streamHeartbeatInterval keeps idle event streams alive through proxies.
+const streamHeartbeatInterval = 25 * time.Second
+
 type Handler struct {
 	svc conversationDomain.Service
 	log *logger.Logger
@@ -37,15 +45,22 @@ func (h *Handler) ListConversations(ctx *gin.Context) {
 	offset, _ := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
 	userCtx := getUserContext(ctx)
 
-	convs, total, err := h.svc.ListConversations(ctx.Request.Context(), userCtx, limit, offset)
+	filter, ok := parseFilter(ctx)
+	if !ok || !h.applySavedFilter(ctx, &filter) {
+		return
+	}
+	filter.Limit = limit
+	filter.Offset = offset
+
+	convs, total, err := h.svc.ListConversations(ctx.Request.Context(), userCtx, filter)
 	if err != nil {
-		h.log.Error("failed to list conversations", "error", err)
+		h.log.ErrorContext(ctx.Request.Context(), "failed to list conversations", "error", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list conversations"})
 		return
 	}
 
 	if userCtx.IsAdmin {
-		h.log.Info("admin_activity", "action", "conversation_list", "admin_id", userCtx.UserID, "result_count", len(convs))
+		h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "conversation_list", "admin_id", userCtx.UserID, "result_count", len(convs))
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
@@ -56,6 +71,66 @@ func (h *Handler) ListConversations(ctx *gin.Context) {
 	})
 }
 
+// parseFilter reads the listing filters from the query string, responding
+// with 400 and returning false when the state is unknown.
+func parseFilter(ctx *gin.Context) (conversationDomain.ConversationFilter, bool) {
+	filter := conversationDomain.ConversationFilter{
+		Query:   ctx.Query("q"),
+		Channel: ctx.Query("channel"),
+		State:   conversationDomain.State(ctx.Query("state")),
+	}
+	if filter.State != "" && !filter.State.Valid() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "state must be open, pending, resolved or closed"})
+		return filter, false
+	}
+	filter.Mode = conversationDomain.Mode(ctx.Query("mode"))
+	if filter.Mode != "" && filter.Mode != conversationDomain.ModeBot && filter.Mode != conversationDomain.ModeHuman {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "mode must be bot or human"})
+		return filter, false
+	}
+	filter.SLABreached = ctx.Query("sla_breached") == "true"
+	for _, tags := range ctx.QueryArray("tag") {
+		for _, tag := range strings.Split(tags, ",") {
+			if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+				filter.Tags = append(filter.Tags, tag)
+			}
+		}
+	}
+	loc := tz.FromContext(ctx.Request.Context())
+	if start := ctx.Query("start_time"); start != "" {
+		if t, err := tz.Parse(start, loc); err == nil {
+			filter.StartTime = t
+		}
+	}
+	if end := ctx.Query("end_time"); end != "" {
+		if t, err := tz.Parse(end, loc); err == nil {
+			filter.EndTime = t
+		}
+	}
+	return filter, true
+}
+
+// Stats counts the conversations matching the listing filters by state.
+func (h *Handler) Stats(ctx *gin.Context) {
+	filter, ok := parseFilter(ctx)
+	if !ok || !h.applySavedFilter(ctx, &filter) {
+		return
+	}
+
+	userCtx := getUserContext(ctx)
+	stats, err := h.svc.Stats(ctx.Request.Context(), userCtx, filter)
+	if err != nil {
+		h.log.ErrorContext(ctx.Request.Context(), "failed to count conversations", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count conversations"})
+		return
+	}
+
+	if userCtx.IsAdmin {
+		h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "conversation_stats", "admin_id", userCtx.UserID)
+	}
+	ctx.JSON(http.StatusOK, stats)
+}
+
 func (h *Handler) GetConversation(ctx *gin.Context) {
 	id := ctx.Param("id")
 	if id == "" {
@@ -74,13 +149,13 @@ func (h *Handler) GetConversation(ctx *gin.Context) {
 			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
 			return
 		}
-		h.log.Error("failed to get conversation", "error", err, "id", id)
+		h.log.ErrorContext(ctx.Request.Context(), "failed to get conversation", "error", err, "id", id)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get conversation"})
 		return
 	}
 
 	if userCtx.IsAdmin && conv.UserID != userCtx.UserID {
-		h.log.Info("admin_activity", "action", "conversation_view", "admin_id", userCtx.UserID, "conversation_id", id, "owner_id", conv.UserID)
+		h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "conversation_view", "admin_id", userCtx.UserID, "conversation_id", id, "owner_id", conv.UserID)
 	}
 
 	ctx.JSON(http.StatusOK, conv)
@@ -107,13 +182,13 @@ func (h *Handler) GetMessages(ctx *gin.Context) {
 			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
 			return
 		}
-		h.log.Error("failed to get messages", "error", err, "conversation_id", id)
+		h.log.ErrorContext(ctx.Request.Context(), "failed to get messages", "error", err, "conversation_id", id)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get messages"})
 		return
 	}
 
 	if userCtx.IsAdmin {
-		h.log.Info("admin_activity", "action", "messages_view", "admin_id", userCtx.UserID, "conversation_id", id, "message_count", len(msgs))
+		h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "messages_view", "admin_id", userCtx.UserID, "conversation_id", id, "message_count", len(msgs))
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
@@ -123,3 +198,520 @@ func (h *Handler) GetMessages(ctx *gin.Context) {
 		"offset":   offset,
 	})
 }
+
+func (h *Handler) MarkRead(ctx *gin.Context) {
+	id := ctx.Param("id")
+	if id == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "conversation id is required"})
+		return
+	}
+
+	userCtx := getUserContext(ctx)
+	if err := h.svc.MarkRead(ctx.Request.Context(), userCtx, id); err != nil {
+		if errors.Is(err, convApp.ErrConversationNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "conversation not found"})
+			return
+		}
+		if errors.Is(err, convApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to mark conversation read", "error", err, "conversation_id", id)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to mark conversation read"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "conversation marked as read"})
+}
+
+type addNoteRequest struct {
+	Content   string `json:"content" binding:"required"`
+	MessageID string `json:"message_id"`
+}
+
+func (h *Handler) AddNote(ctx *gin.Context) {
+	id := ctx.Param("id")
+	if id == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "conversation id is required"})
+		return
+	}
+
+	var req addNoteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	userCtx := getUserContext(ctx)
+	note, err := h.svc.AddNote(ctx.Request.Context(), userCtx, &conversationDomain.Note{
+		ConversationID: id,
+		MessageID:      req.MessageID,
+		Content:        req.Content,
+	})
+	if err != nil {
+		if errors.Is(err, convApp.ErrInvalidNote) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "note content is required"})
+			return
+		}
+		if errors.Is(err, convApp.ErrConversationNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "conversation not found"})
+			return
+		}
+		if errors.Is(err, convApp.ErrMessageNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "message not found"})
+			return
+		}
+		if errors.Is(err, convApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to add note", "error", err, "conversation_id", id)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add note"})
+		return
+	}
+
+	if userCtx.IsAdmin {
+		h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "conversation_note", "admin_id", userCtx.UserID, "conversation_id", id, "note_id", note.ID)
+	}
+	ctx.JSON(http.StatusCreated, note)
+}
+
+type setVariablesRequest struct {
+	Variables map[string]string `json:"variables" binding:"required"`
+}
+
+// SetVariables merges structured customer context into a conversation.
+// Replies to the conversation are tailored with it.
+func (h *Handler) SetVariables(ctx *gin.Context) {
+	id := ctx.Param("id")
+	if id == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "conversation id is required"})
+		return
+	}
+
+	var req setVariablesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	userCtx := getUserContext(ctx)
+	vars, err := h.svc.SetVariables(ctx.Request.Context(), userCtx, id, req.Variables)
+	if err != nil {
+		if errors.Is(err, convApp.ErrInvalidVariables) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, convApp.ErrConversationNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "conversation not found"})
+			return
+		}
+		if errors.Is(err, convApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to set variables", "error", err, "conversation_id", id)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set variables"})
+		return
+	}
+
+	if userCtx.IsAdmin {
+		h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "conversation_variables", "admin_id", userCtx.UserID, "conversation_id", id)
+	}
+	ctx.JSON(http.StatusOK, gin.H{"variables": vars})
+}
+
+type setStateRequest struct {
+	State conversationDomain.State `json:"state" binding:"required"`
+}
+
+// SetState moves a conversation to another lifecycle state.
+func (h *Handler) SetState(ctx *gin.Context) {
+	id := ctx.Param("id")
+	if id == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "conversation id is required"})
+		return
+	}
+
+	var req setStateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	userCtx := getUserContext(ctx)
+	conv, err := h.svc.SetState(ctx.Request.Context(), userCtx, id, req.State)
+	if err != nil {
+		if errors.Is(err, convApp.ErrInvalidState) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "state must be open, pending, resolved or closed"})
+			return
+		}
+		if errors.Is(err, convApp.ErrConversationNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "conversation not found"})
+			return
+		}
+		if errors.Is(err, convApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to set conversation state", "error", err, "conversation_id", id)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set conversation state"})
+		return
+	}
+
+	if userCtx.IsAdmin {
+		h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "conversation_state", "admin_id", userCtx.UserID, "conversation_id", id, "state", req.State)
+	}
+	ctx.JSON(http.StatusOK, conv)
+}
+
+type setModeRequest struct {
+	Mode    conversationDomain.Mode `json:"mode" binding:"required"`
+	AgentID string                  `json:"agent_id"`
+}
+
+// SetMode hands a conversation off to agent_id, or when it is left out to
+// the online agent the assignment rules route it to, or back to the bot.
+func (h *Handler) SetMode(ctx *gin.Context) {
+	id := ctx.Param("id")
+	if id == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "conversation id is required"})
+		return
+	}
+
+	var req setModeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	userCtx := getUserContext(ctx)
+	conv, err := h.svc.SetMode(ctx.Request.Context(), userCtx, id, req.Mode, req.AgentID)
+	if err != nil {
+		if errors.Is(err, convApp.ErrInvalidMode) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "mode must be bot or human"})
+			return
+		}
+		if errors.Is(err, convApp.ErrConversationNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "conversation not found"})
+			return
+		}
+		if errors.Is(err, convApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to set conversation mode", "error", err, "conversation_id", id)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set conversation mode"})
+		return
+	}
+
+	if userCtx.IsAdmin {
+		h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "conversation_mode", "admin_id", userCtx.UserID, "conversation_id", id, "mode", req.Mode, "agent_id", conv.AgentID)
+	}
+	ctx.JSON(http.StatusOK, conv)
+}
+
+// SLAReport reports per agent on the SLAs of conversations handed off
+// between start_time and end_time, the last 30 days by default, as JSON or
+// with format=csv as a CSV file.
+func (h *Handler) SLAReport(ctx *gin.Context) {
+	format := ctx.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+		return
+	}
+
+	var from, to time.Time
+	loc := tz.FromContext(ctx.Request.Context())
+	if start := ctx.Query("start_time"); start != "" {
+		t, err := tz.Parse(start, loc)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "start_time must be RFC 3339 or a local time"})
+			return
+		}
+		from = t
+	}
+	if end := ctx.Query("end_time"); end != "" {
+		t, err := tz.Parse(end, loc)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "end_time must be RFC 3339 or a local time"})
+			return
+		}
+		to = t
+	}
+
+	userCtx := getUserContext(ctx)
+	report, err := h.svc.SLAReport(ctx.Request.Context(), userCtx, from, to)
+	if err != nil {
+		if errors.Is(err, convApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to build sla report", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build sla report"})
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "sla_report", "admin_id", userCtx.UserID, "agent_count", len(report.Agents), "format", format)
+	if format == "csv" {
+		ctx.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "sla-" + report.To.Format(time.DateOnly) + ".csv"}))
+		ctx.Data(http.StatusOK, "text/csv; charset=utf-8", reportApp.CSV(reportApp.SLATable(report)))
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
+
+// RetentionReport shows how the data retention policies are being kept,
+// for compliance reviews.
+func (h *Handler) RetentionReport(ctx *gin.Context) {
+	userCtx := getUserContext(ctx)
+	report, err := h.svc.RetentionReport(ctx.Request.Context(), userCtx)
+	if err != nil {
+		if errors.Is(err, convApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to build retention report", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build retention report"})
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "retention_report", "admin_id", userCtx.UserID, "compliant", report.Compliant)
+	ctx.JSON(http.StatusOK, report)
+}
+
+// Stream pushes conversation events to the client as server-sent events until
+// the client disconnects.
+func (h *Handler) Stream(ctx *gin.Context) {
+	userCtx := getUserContext(ctx)
+	if !userCtx.IsAdmin {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		return
+	}
+
+	// The stream outlives the server write timeout, so lift the deadline for
+	// this response only.
+	_ = http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{})
+
+	events, unsubscribe := h.svc.Subscribe(userCtx)
+	defer unsubscribe()
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "conversation_stream_open", "admin_id", userCtx.UserID)
+
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.SSEvent("ready", gin.H{"status": "connected"})
+	ctx.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			ctx.SSEvent(string(event.Type), event)
+		case <-heartbeat.C:
+			ctx.SSEvent("ping", gin.H{"timestamp": time.Now()})
+		}
+		ctx.Writer.Flush()
+	}
+}
+
+type setPresenceRequest struct {
+	Status conversationDomain.PresenceStatus `json:"status" binding:"required"`
+}
+
+// SetPresence sets the requesting agent online or away. The agent must have
+// the live stream open.
+func (h *Handler) SetPresence(ctx *gin.Context) {
+	var req setPresenceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	userCtx := getUserContext(ctx)
+	presence, err := h.svc.SetPresence(ctx.Request.Context(), userCtx, req.Status)
+	if err != nil {
+		if errors.Is(err, convApp.ErrInvalidPresence) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "status must be online or away"})
+			return
+		}
+		if errors.Is(err, convApp.ErrNotConnected) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "open the conversation stream first"})
+			return
+		}
+		if errors.Is(err, convApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to set presence", "error", err, "agent_id", userCtx.UserID)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set presence"})
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "agent_presence", "admin_id", userCtx.UserID, "status", presence.Status)
+	ctx.JSON(http.StatusOK, presence)
+}
+
+// ListPresence lists the agents with the live stream open.
+func (h *Handler) ListPresence(ctx *gin.Context) {
+	presence, err := h.svc.ListPresence(ctx.Request.Context(), getUserContext(ctx))
+	if err != nil {
+		if errors.Is(err, convApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to list presence", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list presence"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"agents": presence})
+}
+
+type cannedResponseRequest struct {
+	Shortcut string `json:"shortcut" binding:"required"`
+	Title    string `json:"title"`
+	Text     string `json:"text" binding:"required"`
+}
+
+func (r cannedResponseRequest) response() *conversationDomain.CannedResponse {
+	return &conversationDomain.CannedResponse{Shortcut: r.Shortcut, Title: r.Title, Text: r.Text}
+}
+
+// ListCannedResponses lists canned responses, only those whose shortcut or
+// title contains q when it is given.
+func (h *Handler) ListCannedResponses(ctx *gin.Context) {
+	responses, err := h.svc.ListCannedResponses(ctx.Request.Context(), getUserContext(ctx), ctx.Query("q"))
+	if err != nil {
+		if errors.Is(err, convApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to list canned responses", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list canned responses"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"canned_responses": responses, "total": len(responses)})
+}
+
+func (h *Handler) CreateCannedResponse(ctx *gin.Context) {
+	var req cannedResponseRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	userCtx := getUserContext(ctx)
+	id, err := h.svc.CreateCannedResponse(ctx.Request.Context(), userCtx, req.response())
+	if err != nil {
+		switch {
+		case errors.Is(err, convApp.ErrInvalidCannedResponse):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, convApp.ErrDuplicateCannedResponse):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, convApp.ErrForbidden):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		default:
+			h.log.ErrorContext(ctx.Request.Context(), "failed to create canned response", "error", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create canned response"})
+		}
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "canned_response_create", "admin_id", userCtx.UserID, "canned_response_id", id)
+	ctx.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "canned response created successfully",
+	})
+}
+
+func (h *Handler) UpdateCannedResponse(ctx *gin.Context) {
+	var req cannedResponseRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	id := ctx.Param("id")
+	userCtx := getUserContext(ctx)
+	response := req.response()
+	response.ID = id
+	if err := h.svc.UpdateCannedResponse(ctx.Request.Context(), userCtx, response); err != nil {
+		switch {
+		case errors.Is(err, convApp.ErrInvalidCannedResponse):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, convApp.ErrDuplicateCannedResponse):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, convApp.ErrCannedResponseNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "canned response not found"})
+		case errors.Is(err, convApp.ErrForbidden):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		default:
+			h.log.ErrorContext(ctx.Request.Context(), "failed to update canned response", "error", err, "id", id)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update canned response"})
+		}
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "canned_response_update", "admin_id", userCtx.UserID, "canned_response_id", id)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) DeleteCannedResponse(ctx *gin.Context) {
+	id := ctx.Param("id")
+	userCtx := getUserContext(ctx)
+
+	if err := h.svc.DeleteCannedResponse(ctx.Request.Context(), userCtx, id); err != nil {
+		if errors.Is(err, convApp.ErrCannedResponseNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "canned response not found"})
+			return
+		}
+		if errors.Is(err, convApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to delete canned response", "error", err, "id", id)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete canned response"})
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "canned_response_delete", "admin_id", userCtx.UserID, "canned_response_id", id)
+	ctx.JSON(http.StatusOK, gin.H{"message": "canned response deleted successfully"})
+}
+
+// RenderCannedResponse fills in the canned response with the given shortcut
+// for a conversation, for an agent to send or edit.
+func (h *Handler) RenderCannedResponse(ctx *gin.Context) {
+	id := ctx.Param("id")
+	shortcut := ctx.Param("shortcut")
+
+	rendered, err := h.svc.RenderCannedResponse(ctx.Request.Context(), getUserContext(ctx), id, shortcut)
+	if err != nil {
+		if errors.Is(err, convApp.ErrCannedResponseNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "canned response not found"})
+			return
+		}
+		if errors.Is(err, convApp.ErrConversationNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "conversation not found"})
+			return
+		}
+		if errors.Is(err, convApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to render canned response", "error", err, "conversation_id", id, "shortcut", shortcut)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to render canned response"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rendered)
+}
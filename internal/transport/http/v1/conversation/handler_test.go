@@ -4,24 +4,45 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	convApp "github.com/elprogramadorgt/lucidRAG/internal/application/conversation"
 	convDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
 	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/elprogramadorgt/lucidRAG/pkg/tz"
 	"github.com/gin-gonic/gin"
 )
 
 type mockConversationService struct {
-	listConversationsFunc func(ctx context.Context, userCtx convDomain.UserContext, limit, offset int) ([]convDomain.Conversation, int64, error)
+	listConversationsFunc func(ctx context.Context, userCtx convDomain.UserContext, filter convDomain.ConversationFilter) ([]convDomain.Conversation, int64, error)
 	getConversationFunc   func(ctx context.Context, userCtx convDomain.UserContext, id string) (*convDomain.Conversation, error)
 	getMessagesFunc       func(ctx context.Context, userCtx convDomain.UserContext, conversationID string, limit, offset int) ([]convDomain.Message, int64, error)
+	addNoteFunc           func(ctx context.Context, userCtx convDomain.UserContext, note *convDomain.Note) (*convDomain.Note, error)
+	markReadFunc          func(ctx context.Context, userCtx convDomain.UserContext, conversationID string) error
+	subscribeFunc         func(userCtx convDomain.UserContext) (<-chan convDomain.Event, func())
+	setVariablesFunc      func(ctx context.Context, userCtx convDomain.UserContext, conversationID string, variables map[string]string) (map[string]string, error)
+	setStateFunc          func(ctx context.Context, userCtx convDomain.UserContext, conversationID string, state convDomain.State) (*convDomain.Conversation, error)
+	statsFunc             func(ctx context.Context, userCtx convDomain.UserContext, filter convDomain.ConversationFilter) (*convDomain.Stats, error)
+	setModeFunc           func(ctx context.Context, userCtx convDomain.UserContext, conversationID string, mode convDomain.Mode, agentID string) (*convDomain.Conversation, error)
+	slaReportFunc         func(ctx context.Context, userCtx convDomain.UserContext, from, to time.Time) (*convDomain.SLAReport, error)
+	setPresenceFunc       func(userCtx convDomain.UserContext, status convDomain.PresenceStatus) (*convDomain.Presence, error)
+	createCannedFunc      func(ctx context.Context, userCtx convDomain.UserContext, response *convDomain.CannedResponse) (string, error)
+	renderCannedFunc      func(ctx context.Context, userCtx convDomain.UserContext, conversationID, shortcut string) (*convDomain.RenderedResponse, error)
+	retentionReportFunc   func(ctx context.Context, userCtx convDomain.UserContext) (*convDomain.RetentionReport, error)
+	setTagsFunc           func(ctx context.Context, userCtx convDomain.UserContext, conversationID string, tags []string) ([]string, error)
+	getSavedFilterFunc    func(ctx context.Context, userCtx convDomain.UserContext, id string) (*convDomain.SavedFilter, error)
+	reassignFunc          func(ctx context.Context, userCtx convDomain.UserContext, conversationID, agentID string) (*convDomain.Conversation, error)
 }
 
-func (m *mockConversationService) ListConversations(ctx context.Context, userCtx convDomain.UserContext, limit, offset int) ([]convDomain.Conversation, int64, error) {
+func (m *mockConversationService) ListConversations(ctx context.Context, userCtx convDomain.UserContext, filter convDomain.ConversationFilter) ([]convDomain.Conversation, int64, error) {
 	if m.listConversationsFunc != nil {
-		return m.listConversationsFunc(ctx, userCtx, limit, offset)
+		return m.listConversationsFunc(ctx, userCtx, filter)
 	}
 	return []convDomain.Conversation{}, 0, nil
 }
@@ -40,6 +61,10 @@ func (m *mockConversationService) GetMessages(ctx context.Context, userCtx convD
 	return []convDomain.Message{}, 0, nil
 }
 
+func (m *mockConversationService) GetHistory(ctx context.Context, conversationID string, limit int) (*convDomain.History, error) {
+	return &convDomain.History{}, nil
+}
+
 func (m *mockConversationService) CreateConversation(ctx context.Context, conv *convDomain.Conversation) error {
 	return nil
 }
@@ -60,10 +85,209 @@ func (m *mockConversationService) SaveIncomingMessage(ctx context.Context, phone
 	return nil, nil
 }
 
+func (m *mockConversationService) SaveIncomingMedia(ctx context.Context, phoneNumber, contactName, whatsappMsgID, content, msgType string, media convDomain.Media) (*convDomain.Message, error) {
+	return nil, nil
+}
+
+func (m *mockConversationService) SaveIncomingForm(ctx context.Context, phoneNumber, contactName, whatsappMsgID, content string, form map[string]any) (*convDomain.Message, error) {
+	return nil, nil
+}
+
+func (m *mockConversationService) SaveThreadMessage(ctx context.Context, msg convDomain.ThreadMessage) (*convDomain.Message, error) {
+	return nil, nil
+}
+
 func (m *mockConversationService) SaveOutgoingMessage(ctx context.Context, conversationID, content, ragAnswer string) (*convDomain.Message, error) {
 	return nil, nil
 }
 
+func (m *mockConversationService) MarkRead(ctx context.Context, userCtx convDomain.UserContext, conversationID string) error {
+	if m.markReadFunc != nil {
+		return m.markReadFunc(ctx, userCtx, conversationID)
+	}
+	return nil
+}
+
+func (m *mockConversationService) AddNote(ctx context.Context, userCtx convDomain.UserContext, note *convDomain.Note) (*convDomain.Note, error) {
+	if m.addNoteFunc != nil {
+		return m.addNoteFunc(ctx, userCtx, note)
+	}
+	return note, nil
+}
+
+func (m *mockConversationService) SetVariables(ctx context.Context, userCtx convDomain.UserContext, conversationID string, variables map[string]string) (map[string]string, error) {
+	if m.setVariablesFunc != nil {
+		return m.setVariablesFunc(ctx, userCtx, conversationID, variables)
+	}
+	return variables, nil
+}
+
+func (m *mockConversationService) SetState(ctx context.Context, userCtx convDomain.UserContext, conversationID string, state convDomain.State) (*convDomain.Conversation, error) {
+	if m.setStateFunc != nil {
+		return m.setStateFunc(ctx, userCtx, conversationID, state)
+	}
+	return &convDomain.Conversation{ID: conversationID, State: state}, nil
+}
+
+func (m *mockConversationService) Stats(ctx context.Context, userCtx convDomain.UserContext, filter convDomain.ConversationFilter) (*convDomain.Stats, error) {
+	if m.statsFunc != nil {
+		return m.statsFunc(ctx, userCtx, filter)
+	}
+	return &convDomain.Stats{States: map[convDomain.State]int64{}}, nil
+}
+
+func (m *mockConversationService) SetMode(ctx context.Context, userCtx convDomain.UserContext, conversationID string, mode convDomain.Mode, agentID string) (*convDomain.Conversation, error) {
+	if m.setModeFunc != nil {
+		return m.setModeFunc(ctx, userCtx, conversationID, mode, agentID)
+	}
+	return &convDomain.Conversation{ID: conversationID, Mode: mode, AgentID: agentID}, nil
+}
+
+func (m *mockConversationService) Reassign(ctx context.Context, userCtx convDomain.UserContext, conversationID, agentID string) (*convDomain.Conversation, error) {
+	if m.reassignFunc != nil {
+		return m.reassignFunc(ctx, userCtx, conversationID, agentID)
+	}
+	return &convDomain.Conversation{ID: conversationID, Mode: convDomain.ModeHuman, AgentID: agentID}, nil
+}
+
+func (m *mockConversationService) ListAssignments(ctx context.Context, userCtx convDomain.UserContext, conversationID string) ([]convDomain.Assignment, error) {
+	return []convDomain.Assignment{}, nil
+}
+
+func (m *mockConversationService) ListAssignmentRules(ctx context.Context, userCtx convDomain.UserContext) ([]convDomain.AssignmentRule, error) {
+	return []convDomain.AssignmentRule{}, nil
+}
+
+func (m *mockConversationService) CreateAssignmentRule(ctx context.Context, userCtx convDomain.UserContext, rule *convDomain.AssignmentRule) (string, error) {
+	return "rule-1", nil
+}
+
+func (m *mockConversationService) UpdateAssignmentRule(ctx context.Context, userCtx convDomain.UserContext, rule *convDomain.AssignmentRule) error {
+	return nil
+}
+
+func (m *mockConversationService) DeleteAssignmentRule(ctx context.Context, userCtx convDomain.UserContext, id string) error {
+	return nil
+}
+
+func (m *mockConversationService) SLAReport(ctx context.Context, userCtx convDomain.UserContext, from, to time.Time) (*convDomain.SLAReport, error) {
+	if m.slaReportFunc != nil {
+		return m.slaReportFunc(ctx, userCtx, from, to)
+	}
+	return &convDomain.SLAReport{Agents: []convDomain.AgentSLA{}}, nil
+}
+
+func (m *mockConversationService) SetPresence(ctx context.Context, userCtx convDomain.UserContext, status convDomain.PresenceStatus) (*convDomain.Presence, error) {
+	if m.setPresenceFunc != nil {
+		return m.setPresenceFunc(userCtx, status)
+	}
+	return &convDomain.Presence{AgentID: userCtx.UserID, Status: status}, nil
+}
+
+func (m *mockConversationService) ListPresence(ctx context.Context, userCtx convDomain.UserContext) ([]convDomain.Presence, error) {
+	return []convDomain.Presence{}, nil
+}
+
+func (m *mockConversationService) CreateCannedResponse(ctx context.Context, userCtx convDomain.UserContext, response *convDomain.CannedResponse) (string, error) {
+	if m.createCannedFunc != nil {
+		return m.createCannedFunc(ctx, userCtx, response)
+	}
+	return "canned-1", nil
+}
+
+func (m *mockConversationService) ListCannedResponses(ctx context.Context, userCtx convDomain.UserContext, query string) ([]convDomain.CannedResponse, error) {
+	return []convDomain.CannedResponse{}, nil
+}
+
+func (m *mockConversationService) UpdateCannedResponse(ctx context.Context, userCtx convDomain.UserContext, response *convDomain.CannedResponse) error {
+	return nil
+}
+
+func (m *mockConversationService) DeleteCannedResponse(ctx context.Context, userCtx convDomain.UserContext, id string) error {
+	return nil
+}
+
+func (m *mockConversationService) RenderCannedResponse(ctx context.Context, userCtx convDomain.UserContext, conversationID, shortcut string) (*convDomain.RenderedResponse, error) {
+	if m.renderCannedFunc != nil {
+		return m.renderCannedFunc(ctx, userCtx, conversationID, shortcut)
+	}
+	return nil, convApp.ErrCannedResponseNotFound
+}
+
+func (m *mockConversationService) ListTags(ctx context.Context, userCtx convDomain.UserContext) ([]convDomain.Tag, error) {
+	return []convDomain.Tag{}, nil
+}
+
+func (m *mockConversationService) CreateTag(ctx context.Context, userCtx convDomain.UserContext, tag *convDomain.Tag) (string, error) {
+	return "tag-1", nil
+}
+
+func (m *mockConversationService) UpdateTag(ctx context.Context, userCtx convDomain.UserContext, tag *convDomain.Tag) error {
+	return nil
+}
+
+func (m *mockConversationService) DeleteTag(ctx context.Context, userCtx convDomain.UserContext, id string) error {
+	return nil
+}
+
+func (m *mockConversationService) SetTags(ctx context.Context, userCtx convDomain.UserContext, conversationID string, tags []string) ([]string, error) {
+	if m.setTagsFunc != nil {
+		return m.setTagsFunc(ctx, userCtx, conversationID, tags)
+	}
+	return tags, nil
+}
+
+func (m *mockConversationService) AutoTag(ctx context.Context, conversationID, text string) ([]string, error) {
+	return nil, nil
+}
+
+func (m *mockConversationService) ListSavedFilters(ctx context.Context, userCtx convDomain.UserContext) ([]convDomain.SavedFilter, error) {
+	return []convDomain.SavedFilter{}, nil
+}
+
+func (m *mockConversationService) GetSavedFilter(ctx context.Context, userCtx convDomain.UserContext, id string) (*convDomain.SavedFilter, error) {
+	if m.getSavedFilterFunc != nil {
+		return m.getSavedFilterFunc(ctx, userCtx, id)
+	}
+	return nil, convApp.ErrSavedFilterNotFound
+}
+
+func (m *mockConversationService) CreateSavedFilter(ctx context.Context, userCtx convDomain.UserContext, filter *convDomain.SavedFilter) (string, error) {
+	return "filter-1", nil
+}
+
+func (m *mockConversationService) UpdateSavedFilter(ctx context.Context, userCtx convDomain.UserContext, filter *convDomain.SavedFilter) error {
+	return nil
+}
+
+func (m *mockConversationService) DeleteSavedFilter(ctx context.Context, userCtx convDomain.UserContext, id string) error {
+	return nil
+}
+
+func (m *mockConversationService) PurgeUserConversations(ctx context.Context, userCtx convDomain.UserContext, userID string, dryRun bool) (*convDomain.PurgeResult, error) {
+	return &convDomain.PurgeResult{}, nil
+}
+
+func (m *mockConversationService) ApplyRetention(ctx context.Context) (*convDomain.RetentionResult, error) {
+	return &convDomain.RetentionResult{}, nil
+}
+
+func (m *mockConversationService) RetentionReport(ctx context.Context, userCtx convDomain.UserContext) (*convDomain.RetentionReport, error) {
+	if m.retentionReportFunc != nil {
+		return m.retentionReportFunc(ctx, userCtx)
+	}
+	return nil, convApp.ErrForbidden
+}
+
+func (m *mockConversationService) Subscribe(userCtx convDomain.UserContext) (<-chan convDomain.Event, func()) {
+	if m.subscribeFunc != nil {
+		return m.subscribeFunc(userCtx)
+	}
+	ch := make(chan convDomain.Event)
+	close(ch)
+	return ch, func() {}
+}
+
 func setupTestRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	return gin.New()
@@ -76,7 +300,7 @@ func createTestHandler(mockSvc *mockConversationService) *Handler {
 
 func TestListConversations(t *testing.T) {
 	mockSvc := &mockConversationService{
-		listConversationsFunc: func(ctx context.Context, userCtx convDomain.UserContext, limit, offset int) ([]convDomain.Conversation, int64, error) {
+		listConversationsFunc: func(ctx context.Context, userCtx convDomain.UserContext, filter convDomain.ConversationFilter) ([]convDomain.Conversation, int64, error) {
 			return []convDomain.Conversation{
 				{ID: "conv-1", PhoneNumber: "+1234567890"},
 				{ID: "conv-2", PhoneNumber: "+0987654321"},
@@ -118,9 +342,9 @@ func TestListConversations(t *testing.T) {
 func TestListConversationsWithPagination(t *testing.T) {
 	var capturedLimit, capturedOffset int
 	mockSvc := &mockConversationService{
-		listConversationsFunc: func(ctx context.Context, userCtx convDomain.UserContext, limit, offset int) ([]convDomain.Conversation, int64, error) {
-			capturedLimit = limit
-			capturedOffset = offset
+		listConversationsFunc: func(ctx context.Context, userCtx convDomain.UserContext, filter convDomain.ConversationFilter) ([]convDomain.Conversation, int64, error) {
+			capturedLimit = filter.Limit
+			capturedOffset = filter.Offset
 			return []convDomain.Conversation{}, 0, nil
 		},
 	}
@@ -148,7 +372,7 @@ func TestListConversationsWithPagination(t *testing.T) {
 
 func TestListConversationsError(t *testing.T) {
 	mockSvc := &mockConversationService{
-		listConversationsFunc: func(ctx context.Context, userCtx convDomain.UserContext, limit, offset int) ([]convDomain.Conversation, int64, error) {
+		listConversationsFunc: func(ctx context.Context, userCtx convDomain.UserContext, filter convDomain.ConversationFilter) ([]convDomain.Conversation, int64, error) {
 			return nil, 0, errors.New("database error")
 		},
 	}
@@ -291,3 +515,750 @@ func TestGetUserContextNonAdmin(t *testing.T) {
 		t.Error("Expected IsAdmin to be false for user role")
 	}
 }
+
+func TestStreamPushesEvents(t *testing.T) {
+	unsubscribed := false
+	mockSvc := &mockConversationService{
+		subscribeFunc: func(userCtx convDomain.UserContext) (<-chan convDomain.Event, func()) {
+			ch := make(chan convDomain.Event, 1)
+			ch <- convDomain.Event{
+				Type:           convDomain.EventMessageCreated,
+				ConversationID: "conv-1",
+				Message:        &convDomain.Message{ID: "msg-1", Content: "Hello"},
+			}
+			close(ch)
+			return ch, func() { unsubscribed = true }
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.GET("/conversations/stream", func(c *gin.Context) {
+		c.Set("user_id", "admin-123")
+		c.Set("user_role", "admin")
+		handler.Stream(c)
+	})
+
+	req, _ := http.NewRequest("GET", "/conversations/stream", nil)
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.Code)
+	}
+	if ct := resp.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Errorf("Expected event-stream content type, got %s", ct)
+	}
+	body := resp.Body.String()
+	if !strings.Contains(body, "event:message.created") {
+		t.Errorf("Expected message.created event in stream, got %q", body)
+	}
+	if !strings.Contains(body, "msg-1") {
+		t.Errorf("Expected message payload in stream, got %q", body)
+	}
+	if !unsubscribed {
+		t.Error("Expected subscription to be released")
+	}
+}
+
+func TestStreamForbiddenForNonAdmin(t *testing.T) {
+	handler := createTestHandler(&mockConversationService{})
+
+	router := setupTestRouter()
+	router.GET("/conversations/stream", func(c *gin.Context) {
+		c.Set("user_id", "user-123")
+		c.Set("user_role", "user")
+		handler.Stream(c)
+	})
+
+	req, _ := http.NewRequest("GET", "/conversations/stream", nil)
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", resp.Code)
+	}
+}
+
+func TestMarkRead(t *testing.T) {
+	var capturedID, capturedUser string
+	mockSvc := &mockConversationService{
+		markReadFunc: func(ctx context.Context, userCtx convDomain.UserContext, conversationID string) error {
+			capturedID = conversationID
+			capturedUser = userCtx.UserID
+			return nil
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.POST("/conversations/:id/read", func(c *gin.Context) {
+		c.Set("user_id", "admin-123")
+		c.Set("user_role", "admin")
+		handler.MarkRead(c)
+	})
+
+	req, _ := http.NewRequest("POST", "/conversations/conv-1/read", nil)
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.Code)
+	}
+	if capturedID != "conv-1" {
+		t.Errorf("Expected conversation conv-1, got %s", capturedID)
+	}
+	if capturedUser != "admin-123" {
+		t.Errorf("Expected user admin-123, got %s", capturedUser)
+	}
+}
+
+func TestMarkReadNotFound(t *testing.T) {
+	mockSvc := &mockConversationService{
+		markReadFunc: func(ctx context.Context, userCtx convDomain.UserContext, conversationID string) error {
+			return convApp.ErrConversationNotFound
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.POST("/conversations/:id/read", func(c *gin.Context) {
+		c.Set("user_id", "admin-123")
+		c.Set("user_role", "admin")
+		handler.MarkRead(c)
+	})
+
+	req, _ := http.NewRequest("POST", "/conversations/missing/read", nil)
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", resp.Code)
+	}
+}
+
+func TestAddNote(t *testing.T) {
+	var captured *convDomain.Note
+	mockSvc := &mockConversationService{
+		addNoteFunc: func(ctx context.Context, userCtx convDomain.UserContext, note *convDomain.Note) (*convDomain.Note, error) {
+			captured = note
+			note.ID = "note-1"
+			note.AuthorID = userCtx.UserID
+			return note, nil
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.POST("/conversations/:id/notes", func(c *gin.Context) {
+		c.Set("user_id", "admin-123")
+		c.Set("user_role", "admin")
+		handler.AddNote(c)
+	})
+
+	body := strings.NewReader(`{"content":"Customer is a VIP","message_id":"msg-1"}`)
+	req, _ := http.NewRequest("POST", "/conversations/conv-1/notes", body)
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusCreated {
+		t.Errorf("Expected status 201, got %d", resp.Code)
+	}
+	if captured == nil || captured.ConversationID != "conv-1" || captured.MessageID != "msg-1" {
+		t.Errorf("Expected note for conv-1/msg-1, got %+v", captured)
+	}
+}
+
+func TestAddNoteMissingContent(t *testing.T) {
+	handler := createTestHandler(&mockConversationService{})
+
+	router := setupTestRouter()
+	router.POST("/conversations/:id/notes", func(c *gin.Context) {
+		c.Set("user_id", "admin-123")
+		c.Set("user_role", "admin")
+		handler.AddNote(c)
+	})
+
+	req, _ := http.NewRequest("POST", "/conversations/conv-1/notes", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", resp.Code)
+	}
+}
+
+func TestSetVariablesInvalid(t *testing.T) {
+	mockSvc := &mockConversationService{
+		setVariablesFunc: func(ctx context.Context, userCtx convDomain.UserContext, conversationID string, variables map[string]string) (map[string]string, error) {
+			return nil, fmt.Errorf("%w: bad name", convApp.ErrInvalidVariables)
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.PUT("/conversations/:id/variables", func(c *gin.Context) {
+		c.Set("user_id", "admin-123")
+		c.Set("user_role", "admin")
+		handler.SetVariables(c)
+	})
+
+	req, _ := http.NewRequest("PUT", "/conversations/conv-1/variables", strings.NewReader(`{"variables":{"Plan Type":"Pro"}}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", resp.Code)
+	}
+}
+
+func TestListConversationsWithSearchFilters(t *testing.T) {
+	var captured convDomain.ConversationFilter
+	mockSvc := &mockConversationService{
+		listConversationsFunc: func(ctx context.Context, userCtx convDomain.UserContext, filter convDomain.ConversationFilter) ([]convDomain.Conversation, int64, error) {
+			captured = filter
+			return []convDomain.Conversation{}, 0, nil
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.GET("/conversations", func(c *gin.Context) {
+		c.Set("user_id", "admin-123")
+		c.Set("user_role", "admin")
+		handler.ListConversations(c)
+	})
+
+	req, _ := http.NewRequest("GET", "/conversations?q=refund&channel=whatsapp&start_time=2024-01-01T00:00:00Z&end_time=bogus", nil)
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.Code)
+	}
+	if captured.Query != "refund" {
+		t.Errorf("Expected query refund, got %s", captured.Query)
+	}
+	if captured.Channel != "whatsapp" {
+		t.Errorf("Expected channel whatsapp, got %s", captured.Channel)
+	}
+	if captured.StartTime.Year() != 2024 {
+		t.Errorf("Expected start time in 2024, got %v", captured.StartTime)
+	}
+	if !captured.EndTime.IsZero() {
+		t.Errorf("Expected invalid end time to be ignored, got %v", captured.EndTime)
+	}
+}
+
+func TestListConversationsWithSavedFilter(t *testing.T) {
+	var captured convDomain.ConversationFilter
+	mockSvc := &mockConversationService{
+		listConversationsFunc: func(ctx context.Context, userCtx convDomain.UserContext, filter convDomain.ConversationFilter) ([]convDomain.Conversation, int64, error) {
+			captured = filter
+			return []convDomain.Conversation{}, 0, nil
+		},
+		getSavedFilterFunc: func(ctx context.Context, userCtx convDomain.UserContext, id string) (*convDomain.SavedFilter, error) {
+			if id != "filter-1" || userCtx.UserID != "admin-123" {
+				return nil, convApp.ErrSavedFilterNotFound
+			}
+			return &convDomain.SavedFilter{ID: id, Channel: "whatsapp", State: convDomain.StateOpen, Tags: []string{"vip"}}, nil
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.GET("/conversations", func(c *gin.Context) {
+		c.Set("user_id", "admin-123")
+		c.Set("user_role", "admin")
+		handler.ListConversations(c)
+	})
+
+	req, _ := http.NewRequest("GET", "/conversations?filter_id=filter-1&state=pending&tag=Billing,urgent&tag=vip", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.Code)
+	}
+	if captured.Channel != "whatsapp" || captured.State != convDomain.StatePending {
+		t.Errorf("Expected the saved channel and the requested state, got %+v", captured)
+	}
+	if strings.Join(captured.Tags, ",") != "billing,urgent,vip" {
+		t.Errorf("Expected tags billing, urgent and vip, got %v", captured.Tags)
+	}
+
+	req, _ = http.NewRequest("GET", "/conversations?filter_id=someone-elses", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown saved filter, got %d", resp.Code)
+	}
+}
+
+func TestSetTags(t *testing.T) {
+	mockSvc := &mockConversationService{
+		setTagsFunc: func(ctx context.Context, userCtx convDomain.UserContext, conversationID string, tags []string) ([]string, error) {
+			if slices.Contains(tags, "unknown") {
+				return nil, fmt.Errorf("%w: %q does not exist", convApp.ErrInvalidTag, "unknown")
+			}
+			return tags, nil
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.PUT("/conversations/:id/tags", func(c *gin.Context) {
+		c.Set("user_id", "admin-123")
+		c.Set("user_role", "admin")
+		handler.SetTags(c)
+	})
+
+	tests := []struct {
+		body string
+		want int
+	}{
+		{`{"tags":["vip","billing"]}`, http.StatusOK},
+		{`{"tags":[]}`, http.StatusOK},
+		{`{"tags":["unknown"]}`, http.StatusBadRequest},
+		{`{}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("PUT", "/conversations/conv-1/tags", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d", tt.body, tt.want, resp.Code)
+		}
+	}
+}
+
+func TestReassign(t *testing.T) {
+	mockSvc := &mockConversationService{
+		reassignFunc: func(ctx context.Context, userCtx convDomain.UserContext, conversationID, agentID string) (*convDomain.Conversation, error) {
+			switch conversationID {
+			case "bot":
+				return nil, convApp.ErrNotHandedOff
+			case "missing":
+				return nil, convApp.ErrConversationNotFound
+			}
+			if agentID == "" {
+				agentID = "agent-2"
+			}
+			return &convDomain.Conversation{ID: conversationID, Mode: convDomain.ModeHuman, AgentID: agentID}, nil
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.PUT("/conversations/:id/assignee", func(c *gin.Context) {
+		c.Set("user_id", "admin-123")
+		c.Set("user_role", "admin")
+		handler.Reassign(c)
+	})
+
+	tests := []struct {
+		id, body string
+		want     int
+		agent    string
+	}{
+		{"conv-1", `{"agent_id":"agent-1"}`, http.StatusOK, "agent-1"},
+		{"conv-1", `{}`, http.StatusOK, "agent-2"},
+		{"bot", `{}`, http.StatusConflict, ""},
+		{"missing", `{}`, http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("PUT", "/conversations/"+tt.id+"/assignee", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != tt.want {
+			t.Errorf("%s %s: expected status %d, got %d", tt.id, tt.body, tt.want, resp.Code)
+			continue
+		}
+		if tt.agent != "" && !strings.Contains(resp.Body.String(), `"agent_id":"`+tt.agent+`"`) {
+			t.Errorf("%s %s: expected agent %s, got %s", tt.id, tt.body, tt.agent, resp.Body.String())
+		}
+	}
+}
+
+func TestSetState(t *testing.T) {
+	var captured convDomain.State
+	mockSvc := &mockConversationService{
+		setStateFunc: func(ctx context.Context, userCtx convDomain.UserContext, conversationID string, state convDomain.State) (*convDomain.Conversation, error) {
+			captured = state
+			if !state.Valid() {
+				return nil, fmt.Errorf("%w: %q", convApp.ErrInvalidState, state)
+			}
+			return &convDomain.Conversation{ID: conversationID, State: state}, nil
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.PUT("/conversations/:id/state", func(c *gin.Context) {
+		c.Set("user_id", "admin-123")
+		c.Set("user_role", "admin")
+		handler.SetState(c)
+	})
+
+	req, _ := http.NewRequest("PUT", "/conversations/conv-1/state", strings.NewReader(`{"state":"resolved"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.Code)
+	}
+	if captured != convDomain.StateResolved {
+		t.Errorf("Expected state resolved, got %s", captured)
+	}
+
+	req, _ = http.NewRequest("PUT", "/conversations/conv-1/state", strings.NewReader(`{"state":"archived"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", resp.Code)
+	}
+}
+
+func TestStats(t *testing.T) {
+	var captured convDomain.ConversationFilter
+	mockSvc := &mockConversationService{
+		statsFunc: func(ctx context.Context, userCtx convDomain.UserContext, filter convDomain.ConversationFilter) (*convDomain.Stats, error) {
+			captured = filter
+			return &convDomain.Stats{Total: 3, States: map[convDomain.State]int64{convDomain.StateOpen: 3}}, nil
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.GET("/conversations/stats", func(c *gin.Context) {
+		c.Set("user_id", "admin-123")
+		c.Set("user_role", "admin")
+		handler.Stats(c)
+	})
+
+	req, _ := http.NewRequest("GET", "/conversations/stats?channel=slack", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.Code)
+	}
+	if captured.Channel != "slack" {
+		t.Errorf("Expected channel slack, got %s", captured.Channel)
+	}
+	if !strings.Contains(resp.Body.String(), `"open":3`) {
+		t.Errorf("Expected counts by state, got %s", resp.Body.String())
+	}
+
+	req, _ = http.NewRequest("GET", "/conversations/stats?state=archived", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown state, got %d", resp.Code)
+	}
+}
+
+func TestSetMode(t *testing.T) {
+	var captured convDomain.Mode
+	mockSvc := &mockConversationService{
+		setModeFunc: func(ctx context.Context, userCtx convDomain.UserContext, conversationID string, mode convDomain.Mode, agentID string) (*convDomain.Conversation, error) {
+			captured = mode
+			if mode != convDomain.ModeBot && mode != convDomain.ModeHuman {
+				return nil, fmt.Errorf("%w: %q", convApp.ErrInvalidMode, mode)
+			}
+			return &convDomain.Conversation{ID: conversationID, Mode: mode, AgentID: agentID}, nil
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.PUT("/conversations/:id/mode", func(c *gin.Context) {
+		c.Set("user_id", "admin-123")
+		c.Set("user_role", "admin")
+		handler.SetMode(c)
+	})
+
+	req, _ := http.NewRequest("PUT", "/conversations/conv-1/mode", strings.NewReader(`{"mode":"human","agent_id":"agent-1"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.Code)
+	}
+	if captured != convDomain.ModeHuman {
+		t.Errorf("Expected mode human, got %s", captured)
+	}
+
+	req, _ = http.NewRequest("PUT", "/conversations/conv-1/mode", strings.NewReader(`{"mode":"robot"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", resp.Code)
+	}
+}
+
+func TestSLAReport(t *testing.T) {
+	var capturedFrom time.Time
+	mockSvc := &mockConversationService{
+		slaReportFunc: func(ctx context.Context, userCtx convDomain.UserContext, from, to time.Time) (*convDomain.SLAReport, error) {
+			capturedFrom = from
+			return &convDomain.SLAReport{Agents: []convDomain.AgentSLA{{AgentID: "agent-1", Conversations: 4, Compliance: 0.75}}}, nil
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.GET("/analytics/sla", func(c *gin.Context) {
+		c.Set("user_id", "admin-123")
+		c.Set("user_role", "admin")
+		handler.SLAReport(c)
+	})
+
+	req, _ := http.NewRequest("GET", "/analytics/sla?start_time=2024-01-01T00:00:00Z", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.Code)
+	}
+	if capturedFrom.Year() != 2024 {
+		t.Errorf("Expected start time in 2024, got %v", capturedFrom)
+	}
+	if !strings.Contains(resp.Body.String(), `"compliance":0.75`) {
+		t.Errorf("Expected the agent's compliance, got %s", resp.Body.String())
+	}
+
+	req, _ = http.NewRequest("GET", "/analytics/sla?end_time=yesterday", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid end time, got %d", resp.Code)
+	}
+
+	guatemala := time.FixedZone("CST", -6*60*60)
+	req, _ = http.NewRequest("GET", "/analytics/sla?start_time=2024-01-01", nil)
+	req = req.WithContext(tz.WithLocation(req.Context(), guatemala))
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if want := time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC); !capturedFrom.Equal(want) {
+		t.Errorf("Expected a local date to start at %v, got %v", want, capturedFrom)
+	}
+
+	req, _ = http.NewRequest("GET", "/analytics/sla?format=csv", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK || !strings.HasPrefix(resp.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("Expected a CSV file, got %d %s", resp.Code, resp.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(resp.Header().Get("Content-Disposition"), "sla-") || !strings.Contains(resp.Body.String(), "\nagent-1,4,") {
+		t.Errorf("Expected the agent's row, got %s", resp.Body.String())
+	}
+
+	req, _ = http.NewRequest("GET", "/analytics/sla?format=xlsx", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown format, got %d", resp.Code)
+	}
+}
+
+func TestSetPresence(t *testing.T) {
+	mockSvc := &mockConversationService{
+		setPresenceFunc: func(userCtx convDomain.UserContext, status convDomain.PresenceStatus) (*convDomain.Presence, error) {
+			switch {
+			case status != convDomain.PresenceOnline && status != convDomain.PresenceAway:
+				return nil, convApp.ErrInvalidPresence
+			case userCtx.UserID == "admin-offline":
+				return nil, convApp.ErrNotConnected
+			}
+			return &convDomain.Presence{AgentID: userCtx.UserID, Status: status}, nil
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	tests := []struct {
+		name   string
+		userID string
+		body   string
+		want   int
+	}{
+		{"away", "admin-123", `{"status":"away"}`, http.StatusOK},
+		{"unknown status", "admin-123", `{"status":"busy"}`, http.StatusBadRequest},
+		{"missing status", "admin-123", `{}`, http.StatusBadRequest},
+		{"not connected", "admin-offline", `{"status":"online"}`, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := setupTestRouter()
+			router.PUT("/conversations/presence", func(c *gin.Context) {
+				c.Set("user_id", tt.userID)
+				c.Set("user_role", "admin")
+				handler.SetPresence(c)
+			})
+
+			req, _ := http.NewRequest("PUT", "/conversations/presence", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, resp.Code)
+			}
+		})
+	}
+}
+
+func TestCreateCannedResponse(t *testing.T) {
+	mockSvc := &mockConversationService{
+		createCannedFunc: func(ctx context.Context, userCtx convDomain.UserContext, response *convDomain.CannedResponse) (string, error) {
+			if response.Shortcut == "refund" {
+				return "", convApp.ErrDuplicateCannedResponse
+			}
+			return "canned-1", nil
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"created", `{"shortcut":"hours","text":"We open at 9."}`, http.StatusCreated},
+		{"duplicate", `{"shortcut":"refund","text":"On its way."}`, http.StatusConflict},
+		{"missing text", `{"shortcut":"hours"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := setupTestRouter()
+			router.POST("/canned-responses", func(c *gin.Context) {
+				c.Set("user_id", "admin-123")
+				c.Set("user_role", "admin")
+				handler.CreateCannedResponse(c)
+			})
+
+			req, _ := http.NewRequest("POST", "/canned-responses", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != tt.want {
+				t.Errorf("Expected status %d, got %d", tt.want, resp.Code)
+			}
+		})
+	}
+}
+
+func TestRenderCannedResponse(t *testing.T) {
+	mockSvc := &mockConversationService{
+		renderCannedFunc: func(ctx context.Context, userCtx convDomain.UserContext, conversationID, shortcut string) (*convDomain.RenderedResponse, error) {
+			if shortcut != "refund" {
+				return nil, convApp.ErrCannedResponseNotFound
+			}
+			return &convDomain.RenderedResponse{ID: "canned-1", Shortcut: shortcut, Text: "Hi Ana, {order_id} is on its way.", Missing: []string{"order_id"}}, nil
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.GET("/conversations/:id/canned-responses/:shortcut", func(c *gin.Context) {
+		c.Set("user_id", "admin-123")
+		c.Set("user_role", "admin")
+		handler.RenderCannedResponse(c)
+	})
+
+	req, _ := http.NewRequest("GET", "/conversations/conv-1/canned-responses/refund", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.Code)
+	}
+	var rendered convDomain.RenderedResponse
+	if err := json.Unmarshal(resp.Body.Bytes(), &rendered); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(rendered.Missing) != 1 || rendered.Missing[0] != "order_id" {
+		t.Errorf("Expected order_id to be missing, got %+v", rendered)
+	}
+
+	req, _ = http.NewRequest("GET", "/conversations/conv-1/canned-responses/unknown", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", resp.Code)
+	}
+}
+
+func TestRetentionReport(t *testing.T) {
+	mockSvc := &mockConversationService{
+		retentionReportFunc: func(ctx context.Context, userCtx convDomain.UserContext) (*convDomain.RetentionReport, error) {
+			if !userCtx.IsAdmin {
+				return nil, convApp.ErrForbidden
+			}
+			return &convDomain.RetentionReport{Policies: []convDomain.RetentionPolicy{{
+				Collection:      convDomain.RetentionMessages,
+				Days:            180,
+				RetentionCounts: convDomain.RetentionCounts{Anonymized: 12, Overdue: 3},
+			}}}, nil
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	tests := []struct {
+		name     string
+		role     string
+		wantCode int
+	}{
+		{"admin", "admin", http.StatusOK},
+		{"user", "user", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := setupTestRouter()
+			router.GET("/compliance/retention", func(c *gin.Context) {
+				c.Set("user_id", "user-123")
+				c.Set("user_role", tt.role)
+				handler.RetentionReport(c)
+			})
+
+			req, _ := http.NewRequest("GET", "/compliance/retention", nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != tt.wantCode {
+				t.Errorf("Expected status %d, got %d", tt.wantCode, resp.Code)
+			}
+			if tt.wantCode == http.StatusOK && !strings.Contains(resp.Body.String(), `"overdue":3`) {
+				t.Errorf("Expected the policy's counts inline, got %s", resp.Body.String())
+			}
+		})
+	}
+}
@@ -4,6 +4,57 @@ import "github.com/gin-gonic/gin"
 
 func Register(rg *gin.RouterGroup, handler *Handler) {
 	rg.GET("", handler.ListConversations)
+	rg.GET("/stream", handler.Stream)
+	rg.GET("/stats", handler.Stats)
+	rg.GET("/presence", handler.ListPresence)
+	rg.PUT("/presence", handler.SetPresence)
+	rg.GET("/filters", handler.ListSavedFilters)
+	rg.POST("/filters", handler.CreateSavedFilter)
+	rg.PUT("/filters/:id", handler.UpdateSavedFilter)
+	rg.DELETE("/filters/:id", handler.DeleteSavedFilter)
 	rg.GET("/:id", handler.GetConversation)
 	rg.GET("/:id/messages", handler.GetMessages)
+	rg.POST("/:id/read", handler.MarkRead)
+	rg.POST("/:id/notes", handler.AddNote)
+	rg.PUT("/:id/variables", handler.SetVariables)
+	rg.PUT("/:id/tags", handler.SetTags)
+	rg.PUT("/:id/state", handler.SetState)
+	rg.PUT("/:id/mode", handler.SetMode)
+	rg.PUT("/:id/assignee", handler.Reassign)
+	rg.GET("/:id/assignments", handler.ListAssignments)
+	rg.GET("/:id/canned-responses/:shortcut", handler.RenderCannedResponse)
+}
+
+// RegisterCannedResponses mounts the canned responses agents reply with.
+func RegisterCannedResponses(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("", handler.ListCannedResponses)
+	rg.POST("", handler.CreateCannedResponse)
+	rg.PUT("/:id", handler.UpdateCannedResponse)
+	rg.DELETE("/:id", handler.DeleteCannedResponse)
+}
+
+// RegisterTags mounts the tags conversations are labelled with.
+func RegisterTags(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("", handler.ListTags)
+	rg.POST("", handler.CreateTag)
+	rg.PUT("/:id", handler.UpdateTag)
+	rg.DELETE("/:id", handler.DeleteTag)
+}
+
+// RegisterAssignmentRules mounts the rules handoffs are routed by.
+func RegisterAssignmentRules(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("", handler.ListAssignmentRules)
+	rg.POST("", handler.CreateAssignmentRule)
+	rg.PUT("/:id", handler.UpdateAssignmentRule)
+	rg.DELETE("/:id", handler.DeleteAssignmentRule)
+}
+
+// RegisterAnalytics mounts the conversation analytics reports.
+func RegisterAnalytics(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("/sla", handler.SLAReport)
+}
+
+// RegisterCompliance mounts the compliance reports.
+func RegisterCompliance(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("/retention", handler.RetentionReport)
 }
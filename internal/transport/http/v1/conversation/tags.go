@@ -0,0 +1,270 @@
+package conversation
+
+import (
+	"errors"
+	"net/http"
+
+	convApp "github.com/elprogramadorgt/lucidRAG/internal/application/conversation"
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	"github.com/gin-gonic/gin"
+)
+
+type tagRequest struct {
+	Name     string   `json:"name" binding:"required"`
+	Color    string   `json:"color"`
+	Keywords []string `json:"keywords"`
+}
+
+func (r tagRequest) tag() *conversationDomain.Tag {
+	return &conversationDomain.Tag{Name: r.Name, Color: r.Color, Keywords: r.Keywords}
+}
+
+// tagError answers a failed tag change, reporting whether err was one.
+func (h *Handler) tagError(ctx *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, convApp.ErrInvalidTag):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, convApp.ErrDuplicateTag):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, convApp.ErrTagNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "tag not found"})
+	case errors.Is(err, convApp.ErrConversationNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "conversation not found"})
+	case errors.Is(err, convApp.ErrForbidden):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+	default:
+		return false
+	}
+	return true
+}
+
+func (h *Handler) ListTags(ctx *gin.Context) {
+	tags, err := h.svc.ListTags(ctx.Request.Context(), getUserContext(ctx))
+	if err != nil {
+		h.log.ErrorContext(ctx.Request.Context(), "failed to list tags", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list tags"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"tags": tags, "total": len(tags)})
+}
+
+func (h *Handler) CreateTag(ctx *gin.Context) {
+	var req tagRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	userCtx := getUserContext(ctx)
+	id, err := h.svc.CreateTag(ctx.Request.Context(), userCtx, req.tag())
+	if err != nil {
+		if !h.tagError(ctx, err) {
+			h.log.ErrorContext(ctx.Request.Context(), "failed to create tag", "error", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create tag"})
+		}
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "tag_create", "admin_id", userCtx.UserID, "tag_id", id)
+	ctx.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "tag created successfully",
+	})
+}
+
+// UpdateTag changes a tag. A new name is carried over to the conversations
+// and saved filters that had the old one.
+func (h *Handler) UpdateTag(ctx *gin.Context) {
+	var req tagRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	id := ctx.Param("id")
+	userCtx := getUserContext(ctx)
+	tag := req.tag()
+	tag.ID = id
+	if err := h.svc.UpdateTag(ctx.Request.Context(), userCtx, tag); err != nil {
+		if !h.tagError(ctx, err) {
+			h.log.ErrorContext(ctx.Request.Context(), "failed to update tag", "error", err, "id", id)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update tag"})
+		}
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "tag_update", "admin_id", userCtx.UserID, "tag_id", id)
+	ctx.JSON(http.StatusOK, tag)
+}
+
+// DeleteTag deletes a tag and takes it off every conversation.
+func (h *Handler) DeleteTag(ctx *gin.Context) {
+	id := ctx.Param("id")
+	userCtx := getUserContext(ctx)
+
+	if err := h.svc.DeleteTag(ctx.Request.Context(), userCtx, id); err != nil {
+		if !h.tagError(ctx, err) {
+			h.log.ErrorContext(ctx.Request.Context(), "failed to delete tag", "error", err, "id", id)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete tag"})
+		}
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "tag_delete", "admin_id", userCtx.UserID, "tag_id", id)
+	ctx.JSON(http.StatusOK, gin.H{"message": "tag deleted successfully"})
+}
+
+type setTagsRequest struct {
+	Tags []string `json:"tags" binding:"required"`
+}
+
+// SetTags replaces the tags on a conversation; an empty list removes them
+// all.
+func (h *Handler) SetTags(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	var req setTagsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	userCtx := getUserContext(ctx)
+	tags, err := h.svc.SetTags(ctx.Request.Context(), userCtx, id, req.Tags)
+	if err != nil {
+		if !h.tagError(ctx, err) {
+			h.log.ErrorContext(ctx.Request.Context(), "failed to set tags", "error", err, "conversation_id", id)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set tags"})
+		}
+		return
+	}
+
+	if userCtx.IsAdmin {
+		h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "conversation_tags", "admin_id", userCtx.UserID, "conversation_id", id)
+	}
+	ctx.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+type savedFilterRequest struct {
+	Name        string                   `json:"name" binding:"required"`
+	Query       string                   `json:"q"`
+	Channel     string                   `json:"channel"`
+	State       conversationDomain.State `json:"state"`
+	Mode        conversationDomain.Mode  `json:"mode"`
+	SLABreached bool                     `json:"sla_breached"`
+	Tags        []string                 `json:"tags"`
+}
+
+func (r savedFilterRequest) filter() *conversationDomain.SavedFilter {
+	return &conversationDomain.SavedFilter{
+		Name:        r.Name,
+		Query:       r.Query,
+		Channel:     r.Channel,
+		State:       r.State,
+		Mode:        r.Mode,
+		SLABreached: r.SLABreached,
+		Tags:        r.Tags,
+	}
+}
+
+// savedFilterError answers a failed saved filter change, reporting whether
+// err was one.
+func savedFilterError(ctx *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, convApp.ErrInvalidSavedFilter):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, convApp.ErrSavedFilterNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "saved filter not found"})
+	default:
+		return false
+	}
+	return true
+}
+
+// applySavedFilter fills in the listing filter from the saved filter named
+// by filter_id, if any, answering the request itself when it cannot.
+func (h *Handler) applySavedFilter(ctx *gin.Context, filter *conversationDomain.ConversationFilter) bool {
+	id := ctx.Query("filter_id")
+	if id == "" {
+		return true
+	}
+	saved, err := h.svc.GetSavedFilter(ctx.Request.Context(), getUserContext(ctx), id)
+	if err != nil {
+		if !savedFilterError(ctx, err) {
+			h.log.ErrorContext(ctx.Request.Context(), "failed to load saved filter", "error", err, "id", id)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load saved filter"})
+		}
+		return false
+	}
+	saved.ApplyTo(filter)
+	return true
+}
+
+// ListSavedFilters lists the requesting user's saved filters.
+func (h *Handler) ListSavedFilters(ctx *gin.Context) {
+	filters, err := h.svc.ListSavedFilters(ctx.Request.Context(), getUserContext(ctx))
+	if err != nil {
+		h.log.ErrorContext(ctx.Request.Context(), "failed to list saved filters", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list saved filters"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"filters": filters, "total": len(filters)})
+}
+
+func (h *Handler) CreateSavedFilter(ctx *gin.Context) {
+	var req savedFilterRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	id, err := h.svc.CreateSavedFilter(ctx.Request.Context(), getUserContext(ctx), req.filter())
+	if err != nil {
+		if !savedFilterError(ctx, err) {
+			h.log.ErrorContext(ctx.Request.Context(), "failed to create saved filter", "error", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create saved filter"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "saved filter created successfully",
+	})
+}
+
+func (h *Handler) UpdateSavedFilter(ctx *gin.Context) {
+	var req savedFilterRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	id := ctx.Param("id")
+	filter := req.filter()
+	filter.ID = id
+	if err := h.svc.UpdateSavedFilter(ctx.Request.Context(), getUserContext(ctx), filter); err != nil {
+		if !savedFilterError(ctx, err) {
+			h.log.ErrorContext(ctx.Request.Context(), "failed to update saved filter", "error", err, "id", id)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update saved filter"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, filter)
+}
+
+func (h *Handler) DeleteSavedFilter(ctx *gin.Context) {
+	id := ctx.Param("id")
+	if err := h.svc.DeleteSavedFilter(ctx.Request.Context(), getUserContext(ctx), id); err != nil {
+		if !savedFilterError(ctx, err) {
+			h.log.ErrorContext(ctx.Request.Context(), "failed to delete saved filter", "error", err, "id", id)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete saved filter"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "saved filter deleted successfully"})
+}
@@ -0,0 +1,117 @@
+package crm
+
+import (
+	"errors"
+	"net/http"
+
+	crmApp "github.com/elprogramadorgt/lucidRAG/internal/application/crm"
+	crmDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/crm"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	svc crmDomain.Service
+	log *logger.Logger
+}
+
+func NewHandler(svc crmDomain.Service, log *logger.Logger) *Handler {
+	return &Handler{
+		svc: svc,
+		log: log.With("handler", "crm"),
+	}
+}
+
+// connectionRequest is the writable part of a connection. It carries the
+// credentials, which Connection never serializes.
+type connectionRequest struct {
+	IsActive     bool                  `json:"is_active"`
+	InstanceURL  string                `json:"instance_url"`
+	SummaryField string                `json:"summary_field"`
+	Attributes   map[string]string     `json:"attributes"`
+	Exports      map[string]string     `json:"exports"`
+	Credentials  crmDomain.Credentials `json:"credentials"`
+}
+
+func (h *Handler) writeError(ctx *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, crmApp.ErrInvalidConnection):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, crmApp.ErrConnectionNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "crm connection not found"})
+	case errors.Is(err, crmApp.ErrNoEncryptionKey):
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		h.log.ErrorContext(ctx.Request.Context(), "failed to "+action, "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to " + action})
+	}
+}
+
+func (h *Handler) List(ctx *gin.Context) {
+	conns, err := h.svc.ListConnections(ctx.Request.Context())
+	if err != nil {
+		h.writeError(ctx, err, "list crm connections")
+		return
+	}
+	if conns == nil {
+		conns = []crmDomain.Connection{}
+	}
+	ctx.JSON(http.StatusOK, gin.H{"connections": conns, "providers": crmDomain.Providers})
+}
+
+func (h *Handler) Save(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+
+	var req connectionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	conn := &crmDomain.Connection{
+		Provider:     crmDomain.Provider(ctx.Param("provider")),
+		IsActive:     req.IsActive,
+		InstanceURL:  req.InstanceURL,
+		SummaryField: req.SummaryField,
+		Attributes:   req.Attributes,
+		Exports:      req.Exports,
+		Credentials:  req.Credentials,
+	}
+	credentialsChanged := req.Credentials != (crmDomain.Credentials{})
+	if err := h.svc.SaveConnection(ctx.Request.Context(), adminID, conn); err != nil {
+		h.writeError(ctx, err, "save crm connection")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "crm_connection_save", "admin_id", adminID, "provider", conn.Provider,
+		"is_active", conn.IsActive, "credentials_changed", credentialsChanged)
+	conn.HasCredentials = true
+	ctx.JSON(http.StatusOK, conn)
+}
+
+func (h *Handler) Delete(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+	provider := crmDomain.Provider(ctx.Param("provider"))
+
+	if err := h.svc.DeleteConnection(ctx.Request.Context(), adminID, provider); err != nil {
+		h.writeError(ctx, err, "delete crm connection")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "crm_connection_delete", "admin_id", adminID, "provider", provider)
+	ctx.JSON(http.StatusOK, gin.H{"message": "crm connection deleted"})
+}
+
+// Sync runs a sync now instead of waiting for the scheduled one.
+func (h *Handler) Sync(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+
+	if err := h.svc.Sync(ctx.Request.Context()); err != nil {
+		h.log.WarnContext(ctx.Request.Context(), "crm sync failed", "error", err)
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "crm_sync", "admin_id", adminID)
+	ctx.JSON(http.StatusOK, gin.H{"message": "crm sync finished"})
+}
@@ -0,0 +1,117 @@
+package crm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	crmApp "github.com/elprogramadorgt/lucidRAG/internal/application/crm"
+	crmDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/crm"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// mockService implements crmDomain.Service for testing
+type mockService struct {
+	saveFn func(ctx context.Context, adminID string, conn *crmDomain.Connection) error
+}
+
+func (m *mockService) ListConnections(ctx context.Context) ([]crmDomain.Connection, error) {
+	return []crmDomain.Connection{{
+		Provider: crmDomain.ProviderHubSpot, HasCredentials: true,
+		Credentials: crmDomain.Credentials{Token: "hidden"}, SealedCredentials: "v1:sealed",
+	}}, nil
+}
+
+func (m *mockService) SaveConnection(ctx context.Context, adminID string, conn *crmDomain.Connection) error {
+	if m.saveFn != nil {
+		return m.saveFn(ctx, adminID, conn)
+	}
+	return nil
+}
+
+func (m *mockService) DeleteConnection(ctx context.Context, adminID string, provider crmDomain.Provider) error {
+	return crmApp.ErrConnectionNotFound
+}
+
+func (m *mockService) Sync(ctx context.Context) error {
+	return nil
+}
+
+func setupTestRouter(svc crmDomain.Service) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "admin-1")
+		c.Set("user_role", "admin")
+	})
+	Register(r.Group("/crm"), NewHandler(svc, logger.New(logger.Options{Level: "error"})))
+	return r
+}
+
+func TestSaveConnectionPassesCredentials(t *testing.T) {
+	var got *crmDomain.Connection
+	router := setupTestRouter(&mockService{
+		saveFn: func(ctx context.Context, adminID string, conn *crmDomain.Connection) error {
+			got = conn
+			return nil
+		},
+	})
+
+	body := `{"is_active":true,"credentials":{"token":"pat-secret"},"attributes":{"plan_tier":"plan"}}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/crm/hubspot", strings.NewReader(body)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if got == nil || got.Provider != crmDomain.ProviderHubSpot || got.Credentials.Token != "pat-secret" {
+		t.Errorf("Expected hubspot connection with token, got %+v", got)
+	}
+	if strings.Contains(w.Body.String(), "pat-secret") {
+		t.Errorf("Expected credentials to be omitted from response, got %s", w.Body.String())
+	}
+}
+
+func TestSaveConnectionInvalid(t *testing.T) {
+	router := setupTestRouter(&mockService{
+		saveFn: func(ctx context.Context, adminID string, conn *crmDomain.Connection) error {
+			return fmt.Errorf("%w: provider must be hubspot or salesforce", crmApp.ErrInvalidConnection)
+		},
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/crm/pipedrive", strings.NewReader(`{}`)))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestListConnectionsHidesCredentials(t *testing.T) {
+	router := setupTestRouter(&mockService{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/crm", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if strings.Contains(w.Body.String(), "hidden") || strings.Contains(w.Body.String(), "v1:sealed") {
+		t.Errorf("Expected credentials to be omitted, got %s", w.Body.String())
+	}
+}
+
+func TestDeleteConnectionNotFound(t *testing.T) {
+	router := setupTestRouter(&mockService{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/crm/salesforce", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,10 @@
+package crm
+
+import "github.com/gin-gonic/gin"
+
+func Register(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("", handler.List)
+	rg.POST("/sync", handler.Sync)
+	rg.PUT("/:provider", handler.Save)
+	rg.DELETE("/:provider", handler.Delete)
+}
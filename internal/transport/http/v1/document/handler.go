@@ -1,25 +1,37 @@
 package document
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"io"
+	"mime"
 	"net/http"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	docApp "github.com/elprogramadorgt/lucidRAG/internal/application/document"
 	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/confirm"
 	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/elprogramadorgt/lucidRAG/pkg/tz"
 	"github.com/gin-gonic/gin"
 )
 
 type Handler struct {
-	svc documentDomain.Service
-	log *logger.Logger
+	svc     documentDomain.Service
+	confirm *confirm.Signer
+	log     *logger.Logger
 }
 
-func NewHandler(svc documentDomain.Service, log *logger.Logger) *Handler {
+func NewHandler(svc documentDomain.Service, signer *confirm.Signer, log *logger.Logger) *Handler {
 	return &Handler{
-		svc: svc,
-		log: log.With("handler", "document"),
+		svc:     svc,
+		confirm: signer,
+		log:     log.With("handler", "document"),
 	}
 }
 
@@ -27,8 +39,9 @@ func getUserContext(ctx *gin.Context) documentDomain.UserContext {
 	userID := ctx.GetString("user_id")
 	role := ctx.GetString("user_role")
 	return documentDomain.UserContext{
-		UserID:  userID,
-		IsAdmin: role == "admin",
+		UserID:   userID,
+		IsAdmin:  role == "admin",
+		IsEditor: role == "editor",
 	}
 }
 
@@ -43,14 +56,29 @@ func (h *Handler) List(ctx *gin.Context) {
 		return
 	}
 
-	docs, total, err := h.svc.ListDocuments(ctx.Request.Context(), userCtx, limit, offset)
+	filter := documentDomain.DocumentFilter{
+		UserID:     ctx.Query("user_id"),
+		Author:     ctx.Query("author"),
+		Language:   ctx.Query("language"),
+		Department: ctx.Query("department"),
+	}
+	if on := ctx.Query("effective_on"); on != "" {
+		t, err := tz.Parse(on, tz.FromContext(ctx.Request.Context()))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "effective_on must be a date or RFC 3339 time"})
+			return
+		}
+		filter.EffectiveOn = t
+	}
+
+	docs, total, err := h.svc.ListDocuments(ctx.Request.Context(), userCtx, filter, limit, offset)
 	if err != nil {
-		h.log.Error("failed to list documents", "error", err)
+		h.log.ErrorContext(ctx.Request.Context(), "failed to list documents", "error", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list documents"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
+	writeCached(ctx, "", gin.H{
 		"documents": docs,
 		"total":     total,
 		"limit":     limit,
@@ -69,19 +97,118 @@ func (h *Handler) getByID(ctx *gin.Context, userCtx documentDomain.UserContext,
 			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
 			return
 		}
-		h.log.Error("failed to get document", "error", err, "id", id)
+		h.log.ErrorContext(ctx.Request.Context(), "failed to get document", "error", err, "id", id)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get document"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, doc)
+	writeCached(ctx, strconv.FormatInt(doc.Version, 10)+"-", doc)
+}
+
+// setETag tags a response with the document version it reflects.
+func setETag(ctx *gin.Context, version int64) {
+	ctx.Header("ETag", strconv.Quote(strconv.FormatInt(version, 10)))
+}
+
+// writeCached answers 200 with body as JSON, tagged with a hash of it
+// after prefix, or 304 when If-None-Match already names that tag. Hashing
+// the body rather than using the version catches changes that keep the
+// version, such as status changes; a document's prefix is its version, so
+// the tag still works for If-Match.
+func writeCached(ctx *gin.Context, prefix string, body any) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to encode response"})
+		return
+	}
+	sum := sha256.Sum256(data)
+	etag := strconv.Quote(prefix + hex.EncodeToString(sum[:8]))
+
+	ctx.Header("ETag", etag)
+	ctx.Header("Cache-Control", "private, no-cache")
+	if matchesETag(ctx.GetHeader("If-None-Match"), etag) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+	ctx.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+// matchesETag reports whether an If-None-Match header names etag, weakly
+// compared as RFC 9110 asks for.
+func matchesETag(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// expectedVersion returns the version an update was made against: the
+// body's, or else the one in the If-Match header. Zero means neither was
+// given.
+func expectedVersion(ctx *gin.Context, bodyVersion int64) (int64, error) {
+	if bodyVersion != 0 {
+		return bodyVersion, nil
+	}
+	match := ctx.GetHeader("If-Match")
+	if match == "" {
+		return 0, nil
+	}
+	tag := strings.Trim(strings.TrimPrefix(match, "W/"), `"`)
+	// Tags from a GET carry a hash after the version.
+	tag, _, _ = strings.Cut(tag, "-")
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, errors.New("invalid If-Match header")
+	}
+	return version, nil
+}
+
+// versionConflict answers an update that lost to another one with the
+// document as it now stands.
+func (h *Handler) versionConflict(ctx *gin.Context, userCtx documentDomain.UserContext, id string) {
+	body := gin.H{"error": "document was changed by another update"}
+	if doc, err := h.svc.GetDocument(ctx.Request.Context(), userCtx, id); err == nil {
+		setETag(ctx, doc.Version)
+		body["version"] = doc.Version
+		body["document"] = doc
+	}
+	ctx.JSON(http.StatusConflict, body)
+}
+
+// documentMetaRequest holds a document's structured metadata.
+type documentMetaRequest struct {
+	Author      string     `json:"author"`
+	Language    string     `json:"language"`
+	URL         string     `json:"url"`
+	Department  string     `json:"department"`
+	EffectiveAt *time.Time `json:"effective_at"`
+}
+
+func (r documentMetaRequest) meta() documentDomain.DocumentMeta {
+	return documentDomain.DocumentMeta{
+		Author:      r.Author,
+		Language:    r.Language,
+		URL:         r.URL,
+		Department:  r.Department,
+		EffectiveAt: r.EffectiveAt,
+	}
 }
 
 type createDocumentRequest struct {
-	Title    string `json:"title" binding:"required"`
-	Content  string `json:"content" binding:"required"`
-	Source   string `json:"source"`
-	Metadata string `json:"metadata"`
+	documentMetaRequest
+	Title     string     `json:"title" binding:"required"`
+	Content   string     `json:"content" binding:"required"`
+	Source    string     `json:"source"`
+	Metadata  string     `json:"metadata"`
+	PublishAt *time.Time `json:"publish_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	// Collection places the document in a collection's embedding space.
+	Collection string `json:"collection"`
+	// Shareable lets the document's file be sent along with answers.
+	Shareable bool `json:"shareable"`
 }
 
 func (h *Handler) Create(ctx *gin.Context) {
@@ -93,37 +220,268 @@ func (h *Handler) Create(ctx *gin.Context) {
 
 	userCtx := getUserContext(ctx)
 	doc := &documentDomain.Document{
-		Title:    req.Title,
-		Content:  req.Content,
-		Source:   req.Source,
-		Metadata: req.Metadata,
+		Title:      req.Title,
+		Content:    req.Content,
+		Source:     req.Source,
+		Metadata:   req.Metadata,
+		PublishAt:  req.PublishAt,
+		ExpiresAt:  req.ExpiresAt,
+		Collection: req.Collection,
+		Shareable:  req.Shareable,
+
+		DocumentMeta: req.meta(),
 	}
 
 	id, err := h.svc.CreateDocument(ctx.Request.Context(), userCtx, doc)
 	if err != nil {
-		h.log.Error("failed to create document", "error", err)
+		if errors.Is(err, docApp.ErrInvalidSchedule) || errors.Is(err, docApp.ErrCollectionNotFound) ||
+			errors.Is(err, docApp.ErrInvalidMetadata) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, docApp.ErrDuplicateDocument) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to create document", "error", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create document"})
 		return
 	}
 
 	if userCtx.IsAdmin {
-		h.log.Info("admin_activity", "action", "document_create", "admin_id", userCtx.UserID, "document_id", id, "title", req.Title)
+		h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "document_create", "admin_id", userCtx.UserID, "document_id", id, "title", req.Title)
 	} else {
-		h.log.Info("document_create", "user_id", userCtx.UserID, "document_id", id, "title", req.Title)
+		h.log.InfoContext(ctx.Request.Context(), "document_create", "user_id", userCtx.UserID, "document_id", id, "title", req.Title)
 	}
-	ctx.JSON(http.StatusCreated, gin.H{
+	if doc.DuplicateOf == id {
+		ctx.JSON(http.StatusOK, gin.H{
+			"id":           id,
+			"duplicate_of": doc.DuplicateOf,
+			"message":      "document merged into an existing one",
+		})
+		return
+	}
+	resp := gin.H{
 		"id":      id,
 		"message": "document created successfully",
+	}
+	if doc.DuplicateOf != "" {
+		resp["duplicate_of"] = doc.DuplicateOf
+	}
+	ctx.JSON(http.StatusCreated, resp)
+}
+
+// maxUploadBytes bounds a single uploaded file.
+const maxUploadBytes = 64 << 20
+
+// ocrLanguage matches Tesseract language codes, joined with "+" for mixed
+// pages, e.g. "eng" or "chi_sim+eng". The OCR tool receives it as an
+// argument, so nothing else is passed through.
+var ocrLanguage = regexp.MustCompile(`^[A-Za-z_]+(\+[A-Za-z_]+)*$`)
+
+var errOCRLanguage = errors.New("language must be OCR language codes like eng or deu+eng")
+
+// Upload creates a document from a multipart "file": PDF, image, text,
+// CSV or XLSX. Spreadsheet rows become table chunks. Scanned PDFs and
+// images are OCRed; "language" sets the OCR language and "page_languages"
+// overrides it per page, e.g. "3:deu,4:fra". "collection" places the
+// document in a collection.
+func (h *Handler) Upload(ctx *gin.Context) {
+	extendDeadlines(ctx)
+	userCtx := getUserContext(ctx)
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxUploadBytes)
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file too large"})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	language := ctx.PostForm("language")
+	if language != "" && !ocrLanguage.MatchString(language) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": errOCRLanguage.Error()})
+		return
+	}
+	pageLanguages, err := parsePageLanguages(ctx.PostForm("page_languages"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+		return
+	}
+	defer func() { _ = f.Close() }()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+		return
+	}
+
+	id, err := h.svc.UploadDocument(ctx.Request.Context(), userCtx, documentDomain.Upload{
+		Filename:      file.Filename,
+		ContentType:   file.Header.Get("Content-Type"),
+		Data:          data,
+		Title:         ctx.PostForm("title"),
+		Source:        ctx.PostForm("source"),
+		Language:      language,
+		PageLanguages: pageLanguages,
+		Collection:    ctx.PostForm("collection"),
+		Shareable:     ctx.PostForm("shareable") == "true",
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, docApp.ErrUnsupportedFile):
+			ctx.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+		case errors.Is(err, docApp.ErrCollectionNotFound):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, docApp.ErrDuplicateDocument):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, docApp.ErrNoExtractableText), errors.Is(err, docApp.ErrMalformedFile):
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errors.Is(err, docApp.ErrUploadsDisabled):
+			ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			h.log.ErrorContext(ctx.Request.Context(), "failed to upload document", "error", err, "filename", file.Filename)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to upload document"})
+		}
+		return
+	}
+
+	if userCtx.IsAdmin {
+		h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "document_upload", "admin_id", userCtx.UserID, "document_id", id, "filename", file.Filename)
+	} else {
+		h.log.InfoContext(ctx.Request.Context(), "document_upload", "user_id", userCtx.UserID, "document_id", id, "filename", file.Filename)
+	}
+	ctx.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "document uploaded successfully",
 	})
 }
 
+// maxIngestFieldBytes bounds a form field sent before the streamed file.
+const maxIngestFieldBytes = 64 << 10
+
+// Ingest creates a document from a large plain-text body without holding
+// it in memory: the text is chunked and embedded as it is read. The body
+// is either the raw request body, with fields such as "title" in the
+// query string, or multipart form fields followed by a "file" part.
+func (h *Handler) Ingest(ctx *gin.Context) {
+	extendDeadlines(ctx)
+	userCtx := getUserContext(ctx)
+
+	fields := ctx.Request.URL.Query()
+	var body io.Reader = ctx.Request.Body
+	if mr, err := ctx.Request.MultipartReader(); err == nil {
+		body = nil
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				break
+			}
+			if part.FormName() == "file" {
+				body = part
+				break
+			}
+			value, err := io.ReadAll(io.LimitReader(part, maxIngestFieldBytes))
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid multipart body"})
+				return
+			}
+			fields.Set(part.FormName(), string(value))
+		}
+		if body == nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+			return
+		}
+	}
+
+	title := strings.TrimSpace(fields.Get("title"))
+	if title == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "title is required"})
+		return
+	}
+
+	result, err := h.svc.IngestDocument(ctx.Request.Context(), userCtx, documentDomain.Ingest{
+		Title:      title,
+		Source:     fields.Get("source"),
+		Metadata:   fields.Get("metadata"),
+		Collection: fields.Get("collection"),
+		Body:       body,
+
+		DocumentMeta: documentDomain.DocumentMeta{
+			Author:     fields.Get("author"),
+			Language:   fields.Get("language"),
+			URL:        fields.Get("url"),
+			Department: fields.Get("department"),
+		},
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, docApp.ErrDocumentTooLarge):
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		case errors.Is(err, docApp.ErrCollectionNotFound), errors.Is(err, docApp.ErrInvalidMetadata):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, docApp.ErrEmptyDocument):
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errors.Is(err, docApp.ErrIngestUnavailable):
+			ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			h.log.ErrorContext(ctx.Request.Context(), "failed to ingest document", "error", err, "title", title)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to ingest document"})
+		}
+		return
+	}
+
+	if userCtx.IsAdmin {
+		h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "document_ingest", "admin_id", userCtx.UserID, "document_id", result.ID, "bytes", result.Bytes)
+	} else {
+		h.log.InfoContext(ctx.Request.Context(), "document_ingest", "user_id", userCtx.UserID, "document_id", result.ID, "bytes", result.Bytes)
+	}
+	ctx.JSON(http.StatusCreated, result)
+}
+
+// parsePageLanguages reads "page:lang" pairs separated by commas.
+func parsePageLanguages(raw string) (map[int]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	languages := map[int]string{}
+	for _, pair := range strings.Split(raw, ",") {
+		page, lang, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		n, err := strconv.Atoi(strings.TrimSpace(page))
+		lang = strings.TrimSpace(lang)
+		if !ok || err != nil || n < 1 || lang == "" {
+			return nil, errors.New("page_languages must look like 3:deu,4:fra")
+		}
+		if !ocrLanguage.MatchString(lang) {
+			return nil, errOCRLanguage
+		}
+		languages[n] = lang
+	}
+	return languages, nil
+}
+
 type updateDocumentRequest struct {
-	ID       string `json:"id" binding:"required"`
-	Title    string `json:"title" binding:"required"`
-	Content  string `json:"content" binding:"required"`
-	Source   string `json:"source"`
-	Metadata string `json:"metadata"`
-	IsActive bool   `json:"is_active"`
+	documentMetaRequest
+	ID        string     `json:"id" binding:"required"`
+	Title     string     `json:"title" binding:"required"`
+	Content   string     `json:"content" binding:"required"`
+	Source    string     `json:"source"`
+	Metadata  string     `json:"metadata"`
+	IsActive  bool       `json:"is_active"`
+	Shareable bool       `json:"shareable"`
+	PublishAt *time.Time `json:"publish_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	// Version, or an If-Match header, guards against overwriting a newer
+	// update.
+	Version int64 `json:"version"`
 }
 
 func (h *Handler) Update(ctx *gin.Context) {
@@ -132,19 +490,121 @@ func (h *Handler) Update(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
 		return
 	}
+	version, err := expectedVersion(ctx, req.Version)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	userCtx := getUserContext(ctx)
 	doc := &documentDomain.Document{
-		ID:       req.ID,
-		Title:    req.Title,
-		Content:  req.Content,
-		Source:   req.Source,
-		Metadata: req.Metadata,
-		IsActive: req.IsActive,
+		ID:        req.ID,
+		Title:     req.Title,
+		Content:   req.Content,
+		Source:    req.Source,
+		Metadata:  req.Metadata,
+		IsActive:  req.IsActive,
+		Shareable: req.Shareable,
+		PublishAt: req.PublishAt,
+		ExpiresAt: req.ExpiresAt,
+		Version:   version,
+
+		DocumentMeta: req.meta(),
+	}
+
+	err = h.svc.UpdateDocument(ctx.Request.Context(), userCtx, doc)
+	if err != nil {
+		if errors.Is(err, docApp.ErrVersionConflict) {
+			h.versionConflict(ctx, userCtx, req.ID)
+			return
+		}
+		if errors.Is(err, docApp.ErrInvalidSchedule) || errors.Is(err, docApp.ErrInvalidMetadata) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, docApp.ErrDocumentNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "document not found"})
+			return
+		}
+		if errors.Is(err, docApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to update document", "error", err, "id", req.ID)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update document"})
+		return
+	}
+
+	if userCtx.IsAdmin {
+		h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "document_update", "admin_id", userCtx.UserID, "document_id", req.ID)
+	} else {
+		h.log.InfoContext(ctx.Request.Context(), "document_update", "user_id", userCtx.UserID, "document_id", req.ID)
+	}
+	setETag(ctx, doc.Version)
+	ctx.JSON(http.StatusOK, gin.H{"message": "document updated successfully", "version": doc.Version})
+}
+
+// patchDocumentRequest holds the fields a patch changes; omitted ones are
+// left as they are.
+type patchDocumentRequest struct {
+	Title     *string    `json:"title"`
+	Content   *string    `json:"content"`
+	Source    *string    `json:"source"`
+	Metadata  *string    `json:"metadata"`
+	IsActive  *bool      `json:"is_active"`
+	Shareable *bool      `json:"shareable"`
+	PublishAt *time.Time `json:"publish_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	Version   int64      `json:"version"`
+
+	Author      *string    `json:"author"`
+	Language    *string    `json:"language"`
+	URL         *string    `json:"url"`
+	Department  *string    `json:"department"`
+	EffectiveAt *time.Time `json:"effective_at"`
+}
+
+func (h *Handler) Patch(ctx *gin.Context) {
+	var req patchDocumentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	version, err := expectedVersion(ctx, req.Version)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	err := h.svc.UpdateDocument(ctx.Request.Context(), userCtx, doc)
+	id := ctx.Param("id")
+	userCtx := getUserContext(ctx)
+	doc, err := h.svc.PatchDocument(ctx.Request.Context(), userCtx, id, documentDomain.DocumentPatch{
+		Version:   version,
+		Title:     req.Title,
+		Content:   req.Content,
+		Source:    req.Source,
+		Metadata:  req.Metadata,
+		IsActive:  req.IsActive,
+		Shareable: req.Shareable,
+		PublishAt: req.PublishAt,
+		ExpiresAt: req.ExpiresAt,
+
+		Author:      req.Author,
+		Language:    req.Language,
+		URL:         req.URL,
+		Department:  req.Department,
+		EffectiveAt: req.EffectiveAt,
+	})
 	if err != nil {
+		if errors.Is(err, docApp.ErrVersionConflict) {
+			h.versionConflict(ctx, userCtx, id)
+			return
+		}
+		if errors.Is(err, docApp.ErrInvalidPatch) || errors.Is(err, docApp.ErrInvalidSchedule) ||
+			errors.Is(err, docApp.ErrInvalidMetadata) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		if errors.Is(err, docApp.ErrDocumentNotFound) {
 			ctx.JSON(http.StatusNotFound, gin.H{"error": "document not found"})
 			return
@@ -153,17 +613,18 @@ func (h *Handler) Update(ctx *gin.Context) {
 			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
 			return
 		}
-		h.log.Error("failed to update document", "error", err, "id", req.ID)
+		h.log.ErrorContext(ctx.Request.Context(), "failed to patch document", "error", err, "id", id)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update document"})
 		return
 	}
 
 	if userCtx.IsAdmin {
-		h.log.Info("admin_activity", "action", "document_update", "admin_id", userCtx.UserID, "document_id", req.ID)
+		h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "document_update", "admin_id", userCtx.UserID, "document_id", id)
 	} else {
-		h.log.Info("document_update", "user_id", userCtx.UserID, "document_id", req.ID)
+		h.log.InfoContext(ctx.Request.Context(), "document_update", "user_id", userCtx.UserID, "document_id", id)
 	}
-	ctx.JSON(http.StatusOK, gin.H{"message": "document updated successfully"})
+	setETag(ctx, doc.Version)
+	ctx.JSON(http.StatusOK, doc)
 }
 
 func (h *Handler) Delete(ctx *gin.Context) {
@@ -184,15 +645,791 @@ func (h *Handler) Delete(ctx *gin.Context) {
 			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
 			return
 		}
-		h.log.Error("failed to delete document", "error", err, "id", id)
+		h.log.ErrorContext(ctx.Request.Context(), "failed to delete document", "error", err, "id", id)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete document"})
 		return
 	}
 
 	if userCtx.IsAdmin {
-		h.log.Info("admin_activity", "action", "document_delete", "admin_id", userCtx.UserID, "document_id", id)
+		h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "document_delete", "admin_id", userCtx.UserID, "document_id", id)
 	} else {
-		h.log.Info("document_delete", "user_id", userCtx.UserID, "document_id", id)
+		h.log.InfoContext(ctx.Request.Context(), "document_delete", "user_id", userCtx.UserID, "document_id", id)
 	}
 	ctx.JSON(http.StatusOK, gin.H{"message": "document deleted successfully"})
 }
+
+// Download hands out a document's original file: a short-lived signed URL,
+// as JSON or as a redirect with redirect=true, when object storage signs
+// them, or else the file itself.
+func (h *Handler) Download(ctx *gin.Context) {
+	id := ctx.Param("id")
+	userCtx := getUserContext(ctx)
+	download, err := h.svc.Download(ctx.Request.Context(), userCtx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, docApp.ErrDocumentNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "document not found"})
+		case errors.Is(err, docApp.ErrNoFile):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "document has no original file"})
+		case errors.Is(err, docApp.ErrForbidden):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		default:
+			h.log.ErrorContext(ctx.Request.Context(), "failed to download document", "error", err, "id", id)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to download document"})
+		}
+		return
+	}
+
+	method := "signed_url"
+	if download.URL == "" {
+		method = "stream"
+	}
+	if userCtx.IsAdmin {
+		h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "document_download", "admin_id", userCtx.UserID, "document_id", id, "method", method)
+	} else {
+		h.log.InfoContext(ctx.Request.Context(), "document_download", "user_id", userCtx.UserID, "document_id", id, "method", method)
+	}
+
+	if download.URL == "" {
+		defer func() { _ = download.Body.Close() }()
+		disposition := mime.FormatMediaType("attachment", map[string]string{"filename": download.Filename})
+		ctx.DataFromReader(http.StatusOK, download.Size, download.ContentType, download.Body, map[string]string{"Content-Disposition": disposition})
+		return
+	}
+	if ctx.Query("redirect") == "true" {
+		ctx.Redirect(http.StatusFound, download.URL)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"url":          download.URL,
+		"expires_at":   download.ExpiresAt,
+		"filename":     download.Filename,
+		"content_type": download.ContentType,
+		"size":         download.Size,
+	})
+}
+
+// SimilarDocuments suggests documents related to one, flagging likely
+// duplicates.
+func (h *Handler) SimilarDocuments(ctx *gin.Context) {
+	id := ctx.Param("id")
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "5"))
+
+	similar, err := h.svc.SimilarDocuments(ctx.Request.Context(), getUserContext(ctx), id, limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, docApp.ErrDocumentNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "document not found"})
+		case errors.Is(err, docApp.ErrForbidden):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		default:
+			h.log.ErrorContext(ctx.Request.Context(), "failed to find similar documents", "error", err, "document_id", id)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to find similar documents"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"documents": similar})
+}
+
+type previewRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// PreviewArticle renders a draft and shows how it would be chunked, without
+// saving it.
+func (h *Handler) PreviewArticle(ctx *gin.Context) {
+	var req previewRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	h.preview(ctx, documentDomain.ArticleDraft{Content: req.Content})
+}
+
+// PreviewDocument renders a stored document and shows how it is chunked
+// now.
+func (h *Handler) PreviewDocument(ctx *gin.Context) {
+	h.preview(ctx, documentDomain.ArticleDraft{DocumentID: ctx.Param("id")})
+}
+
+func (h *Handler) preview(ctx *gin.Context, draft documentDomain.ArticleDraft) {
+	preview, err := h.svc.PreviewArticle(ctx.Request.Context(), getUserContext(ctx), draft)
+	if err != nil {
+		switch {
+		case errors.Is(err, docApp.ErrInvalidDraft):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, docApp.ErrDocumentNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "document not found"})
+		case errors.Is(err, docApp.ErrForbidden):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		default:
+			h.log.ErrorContext(ctx.Request.Context(), "failed to preview article", "error", err, "document_id", draft.DocumentID)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to preview article"})
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, preview)
+}
+
+func (h *Handler) ListChunks(ctx *gin.Context) {
+	id := ctx.Param("id")
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	withEmbeddings := ctx.Query("include_embeddings") == "true"
+	userCtx := getUserContext(ctx)
+
+	chunks, total, err := h.svc.ListChunks(ctx.Request.Context(), userCtx, id, limit, offset, withEmbeddings)
+	if err != nil {
+		if errors.Is(err, docApp.ErrDocumentNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "document not found"})
+			return
+		}
+		if errors.Is(err, docApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to list chunks", "error", err, "document_id", id)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list chunks"})
+		return
+	}
+
+	if userCtx.IsAdmin {
+		h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "chunk_list", "admin_id", userCtx.UserID, "document_id", id, "result_count", len(chunks))
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"chunks": chunks,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+func (h *Handler) DeleteChunk(ctx *gin.Context) {
+	id := ctx.Param("id")
+	userCtx := getUserContext(ctx)
+
+	err := h.svc.DeleteChunk(ctx.Request.Context(), userCtx, id)
+	if err != nil {
+		if errors.Is(err, docApp.ErrChunkNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "chunk not found"})
+			return
+		}
+		if errors.Is(err, docApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to delete chunk", "error", err, "id", id)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete chunk"})
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "chunk_delete", "admin_id", userCtx.UserID, "chunk_id", id)
+	ctx.JSON(http.StatusOK, gin.H{"message": "chunk deleted successfully"})
+}
+
+// maxImportBytes bounds an uploaded corpus.
+const maxImportBytes = 512 << 20
+
+// extendDeadlines lets bulk transfers outlive the server's read and write
+// timeouts, which are sized for ordinary API calls.
+func extendDeadlines(ctx *gin.Context) {
+	rc := http.NewResponseController(ctx.Writer)
+	_ = rc.SetReadDeadline(time.Time{})
+	_ = rc.SetWriteDeadline(time.Time{})
+}
+
+func (h *Handler) ExportChunks(ctx *gin.Context) {
+	extendDeadlines(ctx)
+	userCtx := getUserContext(ctx)
+	format := documentDomain.InterchangeFormat(ctx.DefaultQuery("format", string(documentDomain.FormatLangChain)))
+	documentID := ctx.Query("document_id")
+
+	ctx.Header("Content-Type", "application/x-ndjson")
+	ctx.Header("Content-Disposition", `attachment; filename="chunks-`+string(format)+`.jsonl"`)
+	err := h.svc.ExportChunks(ctx.Request.Context(), userCtx, format, documentID, ctx.Writer)
+	if err != nil {
+		if ctx.Writer.Written() {
+			h.log.ErrorContext(ctx.Request.Context(), "chunk export interrupted", "error", err, "format", format)
+			return
+		}
+		ctx.Header("Content-Type", "")
+		ctx.Header("Content-Disposition", "")
+		switch {
+		case errors.Is(err, docApp.ErrForbidden):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		case errors.Is(err, docApp.ErrUnsupportedFormat):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "format must be langchain or llamaindex"})
+		case errors.Is(err, docApp.ErrDocumentNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "document not found"})
+		default:
+			h.log.ErrorContext(ctx.Request.Context(), "failed to export chunks", "error", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export chunks"})
+		}
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "chunks_export", "admin_id", userCtx.UserID, "format", format, "document_id", documentID)
+}
+
+// ImportChunks accepts a JSONL corpus either as the raw request body or as
+// a multipart "file" field.
+func (h *Handler) ImportChunks(ctx *gin.Context) {
+	extendDeadlines(ctx)
+	userCtx := getUserContext(ctx)
+	format := documentDomain.InterchangeFormat(ctx.DefaultQuery("format", string(documentDomain.FormatLangChain)))
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportBytes)
+	var body io.Reader = ctx.Request.Body
+	if file, err := ctx.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+			return
+		}
+		defer func() { _ = f.Close() }()
+		body = f
+	}
+
+	result, err := h.svc.ImportChunks(ctx.Request.Context(), userCtx, format, body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.Is(err, docApp.ErrForbidden):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		case errors.Is(err, docApp.ErrUnsupportedFormat):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "format must be langchain or llamaindex"})
+		case errors.Is(err, docApp.ErrInvalidImport):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.As(err, &tooLarge):
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "corpus too large"})
+		default:
+			h.log.ErrorContext(ctx.Request.Context(), "failed to import chunks", "error", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to import chunks"})
+		}
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "chunks_import", "admin_id", userCtx.UserID, "format", format, "documents", result.Documents, "chunks", result.Chunks)
+	ctx.JSON(http.StatusCreated, result)
+}
+
+type changeStatusRequest struct {
+	Status string `json:"status" binding:"required"`
+}
+
+func (h *Handler) ChangeStatus(ctx *gin.Context) {
+	id := ctx.Param("id")
+	var req changeStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	userCtx := getUserContext(ctx)
+	err := h.svc.ChangeStatus(ctx.Request.Context(), userCtx, id, documentDomain.Status(req.Status))
+	if err != nil {
+		if errors.Is(err, docApp.ErrDocumentNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "document not found"})
+			return
+		}
+		if errors.Is(err, docApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		if errors.Is(err, docApp.ErrInvalidStatus) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "invalid status transition"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to change document status", "error", err, "id", id)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change document status"})
+		return
+	}
+
+	if userCtx.IsAdmin {
+		h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "document_status", "admin_id", userCtx.UserID, "document_id", id, "status", req.Status)
+	} else {
+		h.log.InfoContext(ctx.Request.Context(), "document_status", "user_id", userCtx.UserID, "document_id", id, "status", req.Status)
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "document status updated", "status": req.Status})
+}
+
+func (h *Handler) ListPendingReview(ctx *gin.Context) {
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	offset, _ := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	userCtx := getUserContext(ctx)
+
+	docs, total, err := h.svc.ListPendingReview(ctx.Request.Context(), userCtx, limit, offset)
+	if err != nil {
+		if errors.Is(err, docApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to list documents pending review", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list documents"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"documents": docs,
+		"total":     total,
+		"limit":     limit,
+		"offset":    offset,
+	})
+}
+
+// GetStorageUsage returns the caller's own storage usage.
+func (h *Handler) GetStorageUsage(ctx *gin.Context) {
+	usage, err := h.svc.GetStorageUsage(ctx.Request.Context(), getUserContext(ctx))
+	if err != nil {
+		h.log.ErrorContext(ctx.Request.Context(), "failed to get storage usage", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get storage usage"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, usage)
+}
+
+func (h *Handler) GetStorageSummary(ctx *gin.Context) {
+	userCtx := getUserContext(ctx)
+	summary, err := h.svc.GetStorageSummary(ctx.Request.Context(), userCtx)
+	if err != nil {
+		if errors.Is(err, docApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to get storage summary", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get storage summary"})
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "storage_view", "admin_id", userCtx.UserID)
+	ctx.JSON(http.StatusOK, summary)
+}
+
+func (h *Handler) RebuildStorage(ctx *gin.Context) {
+	userCtx := getUserContext(ctx)
+	if err := h.svc.RebuildStorage(ctx.Request.Context(), userCtx); err != nil {
+		if errors.Is(err, docApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to rebuild storage totals", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to rebuild storage totals"})
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "storage_rebuild", "admin_id", userCtx.UserID)
+	ctx.JSON(http.StatusOK, gin.H{"message": "storage totals rebuilt"})
+}
+
+type chunkGCResponse struct {
+	*documentDomain.ChunkGCResult
+	Confirmation *confirm.Token `json:"confirmation,omitempty"`
+}
+
+// CollectChunkGarbage removes orphaned chunks and chunks documents left
+// without any. Without a confirmation token, or with dry_run=true, it only
+// reports them and hands out a token; the collection runs when the token
+// comes back and the report is still the same.
+func (h *Handler) CollectChunkGarbage(ctx *gin.Context) {
+	userCtx := getUserContext(ctx)
+	token := ctx.GetHeader(confirm.Header)
+	dryRun := ctx.Query("dry_run") == "true" || token == ""
+	action := confirm.Action{Name: "chunk_gc", Actor: userCtx.UserID}
+
+	preview, err := h.svc.CollectChunkGarbage(ctx.Request.Context(), userCtx, true)
+	if err != nil {
+		h.chunkGCFailed(ctx, err)
+		return
+	}
+	if dryRun {
+		confirmation, err := h.confirm.Issue(action, preview)
+		if err != nil {
+			h.chunkGCFailed(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, chunkGCResponse{ChunkGCResult: preview, Confirmation: &confirmation})
+		return
+	}
+	if err := h.confirm.Verify(ctx.Request.Context(), token, action, preview); err != nil {
+		writeConfirmError(ctx, err)
+		return
+	}
+
+	result, err := h.svc.CollectChunkGarbage(ctx.Request.Context(), userCtx, false)
+	if err != nil {
+		h.chunkGCFailed(ctx, err)
+		return
+	}
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "chunk_gc", "admin_id", userCtx.UserID,
+		"orphan_chunks", result.OrphanChunks, "rechunked", result.Rechunked)
+	ctx.JSON(http.StatusOK, chunkGCResponse{ChunkGCResult: result})
+}
+
+func (h *Handler) chunkGCFailed(ctx *gin.Context, err error) {
+	if errors.Is(err, docApp.ErrForbidden) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		return
+	}
+	h.log.ErrorContext(ctx.Request.Context(), "failed to collect chunk garbage", "error", err)
+	ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to collect chunk garbage"})
+}
+
+// writeConfirmError answers a call whose confirmation token was refused.
+func writeConfirmError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, confirm.ErrStale):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error() + "; preview again"})
+	case errors.Is(err, confirm.ErrInvalidToken), errors.Is(err, confirm.ErrExpiredToken):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check confirmation token"})
+	}
+}
+
+func (h *Handler) GetEmbeddingStatus(ctx *gin.Context) {
+	status, err := h.svc.GetEmbeddingStatus(ctx.Request.Context(), getUserContext(ctx))
+	if err != nil {
+		if errors.Is(err, docApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to get embedding status", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get embedding status"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, status)
+}
+
+type startMigrationRequest struct {
+	Model string `json:"model" binding:"required"`
+}
+
+// migrationPreview is what starting an embedding migration would do: every
+// chunk is embedded again with ToModel.
+type migrationPreview struct {
+	FromModel    string         `json:"from_model"`
+	ToModel      string         `json:"to_model"`
+	Confirmation *confirm.Token `json:"confirmation,omitempty"`
+}
+
+// StartEmbeddingMigration re-embeds every chunk with another model. Without
+// a confirmation token it only previews the switch and hands out a token;
+// the migration starts when the token comes back and the active model is
+// still the same.
+func (h *Handler) StartEmbeddingMigration(ctx *gin.Context) {
+	var req startMigrationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	userCtx := getUserContext(ctx)
+	token := ctx.GetHeader(confirm.Header)
+	action := confirm.Action{Name: "embedding_migration_start", Actor: userCtx.UserID, Target: req.Model}
+
+	status, err := h.svc.GetEmbeddingStatus(ctx.Request.Context(), userCtx)
+	if err != nil {
+		if errors.Is(err, docApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to get embedding status", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start embedding migration"})
+		return
+	}
+	preview := migrationPreview{FromModel: status.ActiveModel, ToModel: req.Model}
+	if token == "" {
+		confirmation, err := h.confirm.Issue(action, preview)
+		if err != nil {
+			h.log.ErrorContext(ctx.Request.Context(), "failed to issue confirmation token", "error", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start embedding migration"})
+			return
+		}
+		preview.Confirmation = &confirmation
+		ctx.JSON(http.StatusOK, preview)
+		return
+	}
+	if err := h.confirm.Verify(ctx.Request.Context(), token, action, preview); err != nil {
+		writeConfirmError(ctx, err)
+		return
+	}
+
+	migration, err := h.svc.StartEmbeddingMigration(ctx.Request.Context(), userCtx, req.Model)
+	if err != nil {
+		switch {
+		case errors.Is(err, docApp.ErrForbidden):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		case errors.Is(err, docApp.ErrInvalidMigration):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, docApp.ErrMigrationRunning):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			h.log.ErrorContext(ctx.Request.Context(), "failed to start embedding migration", "error", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start embedding migration"})
+		}
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "embedding_migration_start", "admin_id", userCtx.UserID,
+		"from_model", migration.FromModel, "to_model", migration.ToModel)
+	ctx.JSON(http.StatusAccepted, migration)
+}
+
+func (h *Handler) CancelEmbeddingMigration(ctx *gin.Context) {
+	userCtx := getUserContext(ctx)
+	if err := h.svc.CancelEmbeddingMigration(ctx.Request.Context(), userCtx); err != nil {
+		switch {
+		case errors.Is(err, docApp.ErrForbidden):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		case errors.Is(err, docApp.ErrNoMigration):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			h.log.ErrorContext(ctx.Request.Context(), "failed to cancel embedding migration", "error", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel embedding migration"})
+		}
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "embedding_migration_cancel", "admin_id", userCtx.UserID)
+	ctx.JSON(http.StatusOK, gin.H{"message": "embedding migration cancelled"})
+}
+
+// ProjectEmbeddings exports a sample of chunk vectors with their documents
+// for plotting the knowledge base.
+func (h *Handler) ProjectEmbeddings(ctx *gin.Context) {
+	query := documentDomain.ProjectionQuery{
+		Collection: ctx.Query("collection"),
+		Method:     documentDomain.ProjectionMethod(ctx.Query("method")),
+	}
+	if sample := ctx.Query("sample"); sample != "" {
+		n, err := strconv.Atoi(sample)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "sample must be a number"})
+			return
+		}
+		query.Sample = n
+	}
+
+	projection, err := h.svc.ProjectEmbeddings(ctx.Request.Context(), getUserContext(ctx), query)
+	if err != nil {
+		switch {
+		case errors.Is(err, docApp.ErrForbidden):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		case errors.Is(err, docApp.ErrInvalidProjection):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, docApp.ErrCollectionNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "collection not found"})
+		default:
+			h.log.ErrorContext(ctx.Request.Context(), "failed to project embeddings", "error", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to project embeddings"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, projection)
+}
+
+type collectionRequest struct {
+	Description    string `json:"description"`
+	EmbeddingModel string `json:"embedding_model" binding:"required"`
+	Dimensions     int    `json:"dimensions"`
+}
+
+func (h *Handler) ListCollections(ctx *gin.Context) {
+	userCtx := getUserContext(ctx)
+
+	collections, err := h.svc.ListCollections(ctx.Request.Context(), userCtx)
+	if err != nil {
+		if errors.Is(err, docApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to list collections", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list collections"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"collections": collections, "total": len(collections)})
+}
+
+func (h *Handler) SaveCollection(ctx *gin.Context) {
+	var req collectionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	userCtx := getUserContext(ctx)
+	collection := &documentDomain.Collection{
+		Name:           ctx.Param("name"),
+		Description:    req.Description,
+		EmbeddingModel: req.EmbeddingModel,
+		Dimensions:     req.Dimensions,
+	}
+
+	if err := h.svc.SaveCollection(ctx.Request.Context(), userCtx, collection); err != nil {
+		switch {
+		case errors.Is(err, docApp.ErrForbidden):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		case errors.Is(err, docApp.ErrInvalidCollection):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, docApp.ErrCollectionInUse):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			h.log.ErrorContext(ctx.Request.Context(), "failed to save collection", "error", err, "collection", collection.Name)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save collection"})
+		}
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "collection_save", "admin_id", userCtx.UserID,
+		"collection", collection.Name, "embedding_model", collection.EmbeddingModel)
+	ctx.JSON(http.StatusOK, collection)
+}
+
+func (h *Handler) DeleteCollection(ctx *gin.Context) {
+	name := ctx.Param("name")
+	userCtx := getUserContext(ctx)
+
+	if err := h.svc.DeleteCollection(ctx.Request.Context(), userCtx, name); err != nil {
+		switch {
+		case errors.Is(err, docApp.ErrForbidden):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		case errors.Is(err, docApp.ErrCollectionNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "collection not found"})
+		case errors.Is(err, docApp.ErrCollectionInUse):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			h.log.ErrorContext(ctx.Request.Context(), "failed to delete collection", "error", err, "collection", name)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete collection"})
+		}
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "collection_delete", "admin_id", userCtx.UserID, "collection", name)
+	ctx.JSON(http.StatusOK, gin.H{"message": "collection deleted successfully"})
+}
+
+type cleaningProfileRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Sources       []string `json:"sources" binding:"required"`
+	RepeatedLines bool     `json:"repeated_lines"`
+	PageNumbers   bool     `json:"page_numbers"`
+	Navigation    bool     `json:"navigation"`
+	StopPhrases   []string `json:"stop_phrases"`
+	// IsActive only applies to updates; omitted, the profile is active.
+	IsActive *bool `json:"is_active"`
+}
+
+func (r cleaningProfileRequest) profile() *documentDomain.CleaningProfile {
+	return &documentDomain.CleaningProfile{
+		Name:          r.Name,
+		Sources:       r.Sources,
+		RepeatedLines: r.RepeatedLines,
+		PageNumbers:   r.PageNumbers,
+		Navigation:    r.Navigation,
+		StopPhrases:   r.StopPhrases,
+		IsActive:      r.IsActive == nil || *r.IsActive,
+	}
+}
+
+func (h *Handler) ListCleaningProfiles(ctx *gin.Context) {
+	userCtx := getUserContext(ctx)
+
+	profiles, err := h.svc.ListCleaningProfiles(ctx.Request.Context(), userCtx)
+	if err != nil {
+		if errors.Is(err, docApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to list cleaning profiles", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list cleaning profiles"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"profiles": profiles, "total": len(profiles)})
+}
+
+func (h *Handler) CreateCleaningProfile(ctx *gin.Context) {
+	var req cleaningProfileRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	userCtx := getUserContext(ctx)
+	id, err := h.svc.CreateCleaningProfile(ctx.Request.Context(), userCtx, req.profile())
+	if err != nil {
+		switch {
+		case errors.Is(err, docApp.ErrForbidden):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		case errors.Is(err, docApp.ErrInvalidCleaningProfile):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			h.log.ErrorContext(ctx.Request.Context(), "failed to create cleaning profile", "error", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create cleaning profile"})
+		}
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "cleaning_profile_create", "admin_id", userCtx.UserID, "profile_id", id)
+	ctx.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "cleaning profile created successfully",
+	})
+}
+
+func (h *Handler) UpdateCleaningProfile(ctx *gin.Context) {
+	var req cleaningProfileRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	id := ctx.Param("id")
+	userCtx := getUserContext(ctx)
+	profile := req.profile()
+	profile.ID = id
+	if err := h.svc.UpdateCleaningProfile(ctx.Request.Context(), userCtx, profile); err != nil {
+		switch {
+		case errors.Is(err, docApp.ErrForbidden):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		case errors.Is(err, docApp.ErrInvalidCleaningProfile):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, docApp.ErrCleaningProfileNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "cleaning profile not found"})
+		default:
+			h.log.ErrorContext(ctx.Request.Context(), "failed to update cleaning profile", "error", err, "id", id)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update cleaning profile"})
+		}
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "cleaning_profile_update", "admin_id", userCtx.UserID, "profile_id", id)
+	ctx.JSON(http.StatusOK, profile)
+}
+
+func (h *Handler) DeleteCleaningProfile(ctx *gin.Context) {
+	id := ctx.Param("id")
+	userCtx := getUserContext(ctx)
+
+	if err := h.svc.DeleteCleaningProfile(ctx.Request.Context(), userCtx, id); err != nil {
+		switch {
+		case errors.Is(err, docApp.ErrForbidden):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		case errors.Is(err, docApp.ErrCleaningProfileNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "cleaning profile not found"})
+		default:
+			h.log.ErrorContext(ctx.Request.Context(), "failed to delete cleaning profile", "error", err, "id", id)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete cleaning profile"})
+		}
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "cleaning_profile_delete", "admin_id", userCtx.UserID, "profile_id", id)
+	ctx.JSON(http.StatusOK, gin.H{"message": "cleaning profile deleted successfully"})
+}
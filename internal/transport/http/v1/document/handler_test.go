@@ -5,27 +5,49 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	docApp "github.com/elprogramadorgt/lucidRAG/internal/application/document"
 	docDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
+	"github.com/elprogramadorgt/lucidRAG/pkg/confirm"
 	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
 	"github.com/gin-gonic/gin"
 )
 
 type mockDocumentService struct {
-	listDocumentsFunc  func(ctx context.Context, userCtx docDomain.UserContext, limit, offset int) ([]docDomain.Document, int64, error)
+	listDocumentsFunc  func(ctx context.Context, userCtx docDomain.UserContext, filter docDomain.DocumentFilter, limit, offset int) ([]docDomain.Document, int64, error)
 	getDocumentFunc    func(ctx context.Context, userCtx docDomain.UserContext, id string) (*docDomain.Document, error)
 	createDocumentFunc func(ctx context.Context, userCtx docDomain.UserContext, doc *docDomain.Document) (string, error)
 	updateDocumentFunc func(ctx context.Context, userCtx docDomain.UserContext, doc *docDomain.Document) error
+	patchDocumentFunc  func(ctx context.Context, userCtx docDomain.UserContext, id string, patch docDomain.DocumentPatch) (*docDomain.Document, error)
 	deleteDocumentFunc func(ctx context.Context, userCtx docDomain.UserContext, id string) error
+	listChunksFunc     func(ctx context.Context, userCtx docDomain.UserContext, documentID string, limit, offset int, withEmbeddings bool) ([]docDomain.Chunk, int64, error)
+	deleteChunkFunc    func(ctx context.Context, userCtx docDomain.UserContext, id string) error
+	changeStatusFunc   func(ctx context.Context, userCtx docDomain.UserContext, id string, status docDomain.Status) error
+	storageUsageFunc   func(ctx context.Context, userCtx docDomain.UserContext) (*docDomain.UserStorage, error)
+	storageSummaryFunc func(ctx context.Context, userCtx docDomain.UserContext) (*docDomain.StorageSummary, error)
+	exportChunksFunc   func(ctx context.Context, userCtx docDomain.UserContext, format docDomain.InterchangeFormat, documentID string, w io.Writer) error
+	importChunksFunc   func(ctx context.Context, userCtx docDomain.UserContext, format docDomain.InterchangeFormat, r io.Reader) (*docDomain.ImportResult, error)
+	uploadDocumentFunc func(ctx context.Context, userCtx docDomain.UserContext, upload docDomain.Upload) (string, error)
+	ingestDocumentFunc func(ctx context.Context, userCtx docDomain.UserContext, ingest docDomain.Ingest) (*docDomain.IngestResult, error)
+	startMigrationFunc func(ctx context.Context, userCtx docDomain.UserContext, model string) (*docDomain.EmbeddingMigration, error)
+	saveCollectionFunc func(ctx context.Context, userCtx docDomain.UserContext, collection *docDomain.Collection) error
+	similarFunc        func(ctx context.Context, userCtx docDomain.UserContext, id string, limit int) ([]docDomain.SimilarDocument, error)
+	downloadFunc       func(ctx context.Context, userCtx docDomain.UserContext, id string) (*docDomain.Download, error)
+	projectFunc        func(ctx context.Context, userCtx docDomain.UserContext, query docDomain.ProjectionQuery) (*docDomain.EmbeddingProjection, error)
+	previewFunc        func(ctx context.Context, userCtx docDomain.UserContext, draft docDomain.ArticleDraft) (*docDomain.ArticlePreview, error)
 }
 
-func (m *mockDocumentService) ListDocuments(ctx context.Context, userCtx docDomain.UserContext, limit, offset int) ([]docDomain.Document, int64, error) {
+func (m *mockDocumentService) ListDocuments(ctx context.Context, userCtx docDomain.UserContext, filter docDomain.DocumentFilter, limit, offset int) ([]docDomain.Document, int64, error) {
 	if m.listDocumentsFunc != nil {
-		return m.listDocumentsFunc(ctx, userCtx, limit, offset)
+		return m.listDocumentsFunc(ctx, userCtx, filter, limit, offset)
 	}
 	return []docDomain.Document{}, 0, nil
 }
@@ -51,6 +73,15 @@ func (m *mockDocumentService) UpdateDocument(ctx context.Context, userCtx docDom
 	return nil
 }
 
+func (m *mockDocumentService) PatchDocument(ctx context.Context, userCtx docDomain.UserContext, id string, patch docDomain.DocumentPatch) (*docDomain.Document, error) {
+	if m.patchDocumentFunc != nil {
+		return m.patchDocumentFunc(ctx, userCtx, id, patch)
+	}
+	doc := &docDomain.Document{ID: id}
+	patch.Apply(doc)
+	return doc, nil
+}
+
 func (m *mockDocumentService) DeleteDocument(ctx context.Context, userCtx docDomain.UserContext, id string) error {
 	if m.deleteDocumentFunc != nil {
 		return m.deleteDocumentFunc(ctx, userCtx, id)
@@ -58,10 +89,234 @@ func (m *mockDocumentService) DeleteDocument(ctx context.Context, userCtx docDom
 	return nil
 }
 
+func (m *mockDocumentService) ChangeStatus(ctx context.Context, userCtx docDomain.UserContext, id string, status docDomain.Status) error {
+	if m.changeStatusFunc != nil {
+		return m.changeStatusFunc(ctx, userCtx, id, status)
+	}
+	return nil
+}
+
+func (m *mockDocumentService) ListPendingReview(ctx context.Context, userCtx docDomain.UserContext, limit, offset int) ([]docDomain.Document, int64, error) {
+	return []docDomain.Document{}, 0, nil
+}
+
+func (m *mockDocumentService) ListChunks(ctx context.Context, userCtx docDomain.UserContext, documentID string, limit, offset int, withEmbeddings bool) ([]docDomain.Chunk, int64, error) {
+	if m.listChunksFunc != nil {
+		return m.listChunksFunc(ctx, userCtx, documentID, limit, offset, withEmbeddings)
+	}
+	return []docDomain.Chunk{}, 0, nil
+}
+
+func (m *mockDocumentService) DeleteChunk(ctx context.Context, userCtx docDomain.UserContext, id string) error {
+	if m.deleteChunkFunc != nil {
+		return m.deleteChunkFunc(ctx, userCtx, id)
+	}
+	return nil
+}
+
+func (m *mockDocumentService) ExportChunks(ctx context.Context, userCtx docDomain.UserContext, format docDomain.InterchangeFormat, documentID string, w io.Writer) error {
+	if m.exportChunksFunc != nil {
+		return m.exportChunksFunc(ctx, userCtx, format, documentID, w)
+	}
+	return nil
+}
+
+func (m *mockDocumentService) ImportChunks(ctx context.Context, userCtx docDomain.UserContext, format docDomain.InterchangeFormat, r io.Reader) (*docDomain.ImportResult, error) {
+	if m.importChunksFunc != nil {
+		return m.importChunksFunc(ctx, userCtx, format, r)
+	}
+	return &docDomain.ImportResult{DocumentIDs: []string{}}, nil
+}
+
+func (m *mockDocumentService) IngestDocument(ctx context.Context, userCtx docDomain.UserContext, ingest docDomain.Ingest) (*docDomain.IngestResult, error) {
+	if m.ingestDocumentFunc != nil {
+		return m.ingestDocumentFunc(ctx, userCtx, ingest)
+	}
+	return &docDomain.IngestResult{ID: "doc-123"}, nil
+}
+
+func (m *mockDocumentService) UploadDocument(ctx context.Context, userCtx docDomain.UserContext, upload docDomain.Upload) (string, error) {
+	if m.uploadDocumentFunc != nil {
+		return m.uploadDocumentFunc(ctx, userCtx, upload)
+	}
+	return "doc-123", nil
+}
+
+func (m *mockDocumentService) GetEmbeddingStatus(ctx context.Context, userCtx docDomain.UserContext) (*docDomain.EmbeddingStatus, error) {
+	return &docDomain.EmbeddingStatus{ActiveModel: "text-embedding-ada-002"}, nil
+}
+
+func (m *mockDocumentService) StartEmbeddingMigration(ctx context.Context, userCtx docDomain.UserContext, model string) (*docDomain.EmbeddingMigration, error) {
+	if m.startMigrationFunc != nil {
+		return m.startMigrationFunc(ctx, userCtx, model)
+	}
+	return &docDomain.EmbeddingMigration{ID: "mig-1", ToModel: model, Status: docDomain.MigrationRunning}, nil
+}
+
+func (m *mockDocumentService) PurgeUserDocuments(ctx context.Context, userCtx docDomain.UserContext, userID string, dryRun bool) (*docDomain.PurgeResult, error) {
+	return &docDomain.PurgeResult{}, nil
+}
+
+func (m *mockDocumentService) CollectChunkGarbage(ctx context.Context, userCtx docDomain.UserContext, dryRun bool) (*docDomain.ChunkGCResult, error) {
+	if !userCtx.IsAdmin {
+		return nil, docApp.ErrForbidden
+	}
+	return &docDomain.ChunkGCResult{DryRun: dryRun, OrphanDocumentIDs: []string{"gone"}, OrphanChunks: 2}, nil
+}
+
+func (m *mockDocumentService) CancelEmbeddingMigration(ctx context.Context, userCtx docDomain.UserContext) error {
+	return nil
+}
+
+func (m *mockDocumentService) SimilarDocuments(ctx context.Context, userCtx docDomain.UserContext, id string, limit int) ([]docDomain.SimilarDocument, error) {
+	if m.similarFunc != nil {
+		return m.similarFunc(ctx, userCtx, id, limit)
+	}
+	return []docDomain.SimilarDocument{}, nil
+}
+
+func (m *mockDocumentService) ProjectEmbeddings(ctx context.Context, userCtx docDomain.UserContext, query docDomain.ProjectionQuery) (*docDomain.EmbeddingProjection, error) {
+	if m.projectFunc != nil {
+		return m.projectFunc(ctx, userCtx, query)
+	}
+	return &docDomain.EmbeddingProjection{Method: query.Method, Points: []docDomain.ProjectedChunk{}}, nil
+}
+
+func (m *mockDocumentService) ListCollections(ctx context.Context, userCtx docDomain.UserContext) ([]docDomain.Collection, error) {
+	return []docDomain.Collection{}, nil
+}
+
+func (m *mockDocumentService) SaveCollection(ctx context.Context, userCtx docDomain.UserContext, collection *docDomain.Collection) error {
+	if m.saveCollectionFunc != nil {
+		return m.saveCollectionFunc(ctx, userCtx, collection)
+	}
+	return nil
+}
+
+func (m *mockDocumentService) DeleteCollection(ctx context.Context, userCtx docDomain.UserContext, name string) error {
+	return nil
+}
+
 func (m *mockDocumentService) QueryRAG(ctx context.Context, query docDomain.RAGQuery) (*docDomain.RAGResponse, error) {
 	return nil, nil
 }
 
+func (m *mockDocumentService) GetStorageUsage(ctx context.Context, userCtx docDomain.UserContext) (*docDomain.UserStorage, error) {
+	if m.storageUsageFunc != nil {
+		return m.storageUsageFunc(ctx, userCtx)
+	}
+	return &docDomain.UserStorage{UserID: userCtx.UserID}, nil
+}
+
+func (m *mockDocumentService) GetStorageSummary(ctx context.Context, userCtx docDomain.UserContext) (*docDomain.StorageSummary, error) {
+	if m.storageSummaryFunc != nil {
+		return m.storageSummaryFunc(ctx, userCtx)
+	}
+	return &docDomain.StorageSummary{}, nil
+}
+
+func (m *mockDocumentService) RebuildStorage(ctx context.Context, userCtx docDomain.UserContext) error {
+	return nil
+}
+
+func (m *mockDocumentService) CreateRetrievalRule(ctx context.Context, userCtx docDomain.UserContext, rule *docDomain.RetrievalRule) (string, error) {
+	return "rule-123", nil
+}
+
+func (m *mockDocumentService) ListRetrievalRules(ctx context.Context, userCtx docDomain.UserContext) ([]docDomain.RetrievalRule, error) {
+	return []docDomain.RetrievalRule{}, nil
+}
+
+func (m *mockDocumentService) DeleteRetrievalRule(ctx context.Context, userCtx docDomain.UserContext, id string) error {
+	return nil
+}
+
+func (m *mockDocumentService) CreateFAQShortcut(ctx context.Context, userCtx docDomain.UserContext, shortcut *docDomain.FAQShortcut) (string, error) {
+	return "", nil
+}
+
+func (m *mockDocumentService) ListFAQShortcuts(ctx context.Context, userCtx docDomain.UserContext) ([]docDomain.FAQShortcut, error) {
+	return nil, nil
+}
+
+func (m *mockDocumentService) UpdateFAQShortcut(ctx context.Context, userCtx docDomain.UserContext, shortcut *docDomain.FAQShortcut) error {
+	return nil
+}
+
+func (m *mockDocumentService) DeleteFAQShortcut(ctx context.Context, userCtx docDomain.UserContext, id string) error {
+	return nil
+}
+
+func (m *mockDocumentService) CreateGlossaryEntry(ctx context.Context, userCtx docDomain.UserContext, entry *docDomain.GlossaryEntry) (string, error) {
+	return "", nil
+}
+
+func (m *mockDocumentService) ListGlossaryEntries(ctx context.Context, userCtx docDomain.UserContext) ([]docDomain.GlossaryEntry, error) {
+	return nil, nil
+}
+
+func (m *mockDocumentService) UpdateGlossaryEntry(ctx context.Context, userCtx docDomain.UserContext, entry *docDomain.GlossaryEntry) error {
+	return nil
+}
+
+func (m *mockDocumentService) DeleteGlossaryEntry(ctx context.Context, userCtx docDomain.UserContext, id string) error {
+	return nil
+}
+
+func (m *mockDocumentService) CreateCleaningProfile(ctx context.Context, userCtx docDomain.UserContext, profile *docDomain.CleaningProfile) (string, error) {
+	return "", nil
+}
+
+func (m *mockDocumentService) ListCleaningProfiles(ctx context.Context, userCtx docDomain.UserContext) ([]docDomain.CleaningProfile, error) {
+	return nil, nil
+}
+
+func (m *mockDocumentService) UpdateCleaningProfile(ctx context.Context, userCtx docDomain.UserContext, profile *docDomain.CleaningProfile) error {
+	return nil
+}
+
+func (m *mockDocumentService) DeleteCleaningProfile(ctx context.Context, userCtx docDomain.UserContext, id string) error {
+	return nil
+}
+
+func (m *mockDocumentService) ListFormatProfiles(ctx context.Context, userCtx docDomain.UserContext) ([]docDomain.FormatProfile, error) {
+	return nil, nil
+}
+
+func (m *mockDocumentService) SaveFormatProfile(ctx context.Context, userCtx docDomain.UserContext, profile *docDomain.FormatProfile) error {
+	return nil
+}
+
+func (m *mockDocumentService) ListQueryLogs(ctx context.Context, userCtx docDomain.UserContext, filter docDomain.QueryLogFilter) ([]docDomain.QueryLog, int64, error) {
+	return nil, 0, nil
+}
+
+func (m *mockDocumentService) GetQueryLog(ctx context.Context, userCtx docDomain.UserContext, id string) (*docDomain.QueryLog, error) {
+	return nil, nil
+}
+
+func (m *mockDocumentService) ReplayQuery(ctx context.Context, userCtx docDomain.UserContext, id string) (*docDomain.QueryReplay, error) {
+	return nil, nil
+}
+
+func (m *mockDocumentService) Attachment(ctx context.Context, chunk docDomain.Chunk) (*docDomain.Attachment, error) {
+	return nil, nil
+}
+
+func (m *mockDocumentService) PreviewArticle(ctx context.Context, userCtx docDomain.UserContext, draft docDomain.ArticleDraft) (*docDomain.ArticlePreview, error) {
+	if m.previewFunc != nil {
+		return m.previewFunc(ctx, userCtx, draft)
+	}
+	return &docDomain.ArticlePreview{}, nil
+}
+
+func (m *mockDocumentService) Download(ctx context.Context, userCtx docDomain.UserContext, id string) (*docDomain.Download, error) {
+	if m.downloadFunc != nil {
+		return m.downloadFunc(ctx, userCtx, id)
+	}
+	return nil, docApp.ErrNoFile
+}
+
 func setupTestRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	return gin.New()
@@ -69,12 +324,12 @@ func setupTestRouter() *gin.Engine {
 
 func createTestHandler(mockSvc *mockDocumentService) *Handler {
 	log := logger.New(logger.Options{Level: "error"})
-	return NewHandler(mockSvc, log)
+	return NewHandler(mockSvc, confirm.New("test-secret", 0, nil), log)
 }
 
 func TestListDocuments(t *testing.T) {
 	mockSvc := &mockDocumentService{
-		listDocumentsFunc: func(ctx context.Context, userCtx docDomain.UserContext, limit, offset int) ([]docDomain.Document, int64, error) {
+		listDocumentsFunc: func(ctx context.Context, userCtx docDomain.UserContext, filter docDomain.DocumentFilter, limit, offset int) ([]docDomain.Document, int64, error) {
 			return []docDomain.Document{
 				{ID: "doc-1", Title: "Document 1"},
 				{ID: "doc-2", Title: "Document 2"},
@@ -113,6 +368,43 @@ func TestListDocuments(t *testing.T) {
 	}
 }
 
+func TestListDocumentsFilters(t *testing.T) {
+	var got docDomain.DocumentFilter
+	mockSvc := &mockDocumentService{
+		listDocumentsFunc: func(ctx context.Context, userCtx docDomain.UserContext, filter docDomain.DocumentFilter, limit, offset int) ([]docDomain.Document, int64, error) {
+			got = filter
+			return []docDomain.Document{}, 0, nil
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.GET("/documents", func(c *gin.Context) {
+		c.Set("user_id", "admin-1")
+		c.Set("user_role", "admin")
+		handler.List(c)
+	})
+
+	req, _ := http.NewRequest("GET", "/documents?language=es&department=support&author=Ana&effective_on=2025-03-01", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.Code)
+	}
+	want := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	if got.Language != "es" || got.Department != "support" || got.Author != "Ana" || !got.EffectiveOn.Equal(want) {
+		t.Errorf("Expected the query filters to be passed on, got %+v", got)
+	}
+
+	req, _ = http.NewRequest("GET", "/documents?effective_on=soon", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a bad date, got %d", resp.Code)
+	}
+}
+
 func TestListDocumentsWithID(t *testing.T) {
 	mockSvc := &mockDocumentService{
 		getDocumentFunc: func(ctx context.Context, userCtx docDomain.UserContext, id string) (*docDomain.Document, error) {
@@ -141,9 +433,56 @@ func TestListDocumentsWithID(t *testing.T) {
 	}
 }
 
+func TestConditionalGet(t *testing.T) {
+	doc := &docDomain.Document{ID: "doc-1", Title: "Policies", Version: 3, Status: docDomain.StatusPublished}
+	mockSvc := &mockDocumentService{
+		getDocumentFunc: func(ctx context.Context, userCtx docDomain.UserContext, id string) (*docDomain.Document, error) {
+			copied := *doc
+			return &copied, nil
+		},
+		listDocumentsFunc: func(ctx context.Context, userCtx docDomain.UserContext, filter docDomain.DocumentFilter, limit, offset int) ([]docDomain.Document, int64, error) {
+			return []docDomain.Document{*doc}, 1, nil
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.GET("/documents", handler.List)
+	get := func(url, ifNoneMatch string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", url, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	for _, url := range []string{"/documents?id=doc-1", "/documents"} {
+		first := get(url, "")
+		etag := first.Header().Get("ETag")
+		if first.Code != http.StatusOK || etag == "" {
+			t.Fatalf("%s: expected 200 with an ETag, got %d %q", url, first.Code, etag)
+		}
+		if resp := get(url, `"other", W/`+etag); resp.Code != http.StatusNotModified || resp.Body.Len() != 0 {
+			t.Errorf("%s: expected 304 with no body, got %d", url, resp.Code)
+		}
+
+		doc.Status = docDomain.StatusDraft
+		if resp := get(url, etag); resp.Code != http.StatusOK || resp.Header().Get("ETag") == etag {
+			t.Errorf("%s: expected a new ETag after a status change, got %d", url, resp.Code)
+		}
+		doc.Status = docDomain.StatusPublished
+	}
+
+	if tag := get("/documents?id=doc-1", "").Header().Get("ETag"); !strings.HasPrefix(tag, `"3-`) {
+		t.Errorf("Expected the document's ETag to start with its version, got %s", tag)
+	}
+}
+
 func TestListDocumentsError(t *testing.T) {
 	mockSvc := &mockDocumentService{
-		listDocumentsFunc: func(ctx context.Context, userCtx docDomain.UserContext, limit, offset int) ([]docDomain.Document, int64, error) {
+		listDocumentsFunc: func(ctx context.Context, userCtx docDomain.UserContext, filter docDomain.DocumentFilter, limit, offset int) ([]docDomain.Document, int64, error) {
 			return nil, 0, errors.New("database error")
 		},
 	}
@@ -202,6 +541,51 @@ func TestCreateDocument(t *testing.T) {
 	}
 }
 
+func TestCreateDocumentDuplicates(t *testing.T) {
+	tests := []struct {
+		name   string
+		create func(doc *docDomain.Document) (string, error)
+		status int
+	}{
+		{"rejected", func(doc *docDomain.Document) (string, error) {
+			return "", fmt.Errorf("%w of document doc-1", docApp.ErrDuplicateDocument)
+		}, http.StatusConflict},
+		{"merged", func(doc *docDomain.Document) (string, error) {
+			doc.DuplicateOf = "doc-1"
+			return "doc-1", nil
+		}, http.StatusOK},
+		{"linked", func(doc *docDomain.Document) (string, error) {
+			doc.DuplicateOf = "doc-1"
+			return "doc-2", nil
+		}, http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockDocumentService{
+				createDocumentFunc: func(ctx context.Context, userCtx docDomain.UserContext, doc *docDomain.Document) (string, error) {
+					return tt.create(doc)
+				},
+			}
+			handler := createTestHandler(mockSvc)
+			router := setupTestRouter()
+			router.POST("/documents", handler.Create)
+
+			req, _ := http.NewRequest("POST", "/documents", bytes.NewBufferString(`{"title": "Copy", "content": "Returns are free"}`))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, resp.Code, resp.Body.String())
+			}
+			if tt.status != http.StatusConflict && !strings.Contains(resp.Body.String(), `"duplicate_of":"doc-1"`) {
+				t.Errorf("Expected duplicate_of in the response, got %s", resp.Body.String())
+			}
+		})
+	}
+}
+
 func TestCreateDocumentInvalidBody(t *testing.T) {
 	mockSvc := &mockDocumentService{}
 	handler := createTestHandler(mockSvc)
@@ -252,6 +636,111 @@ func TestUpdateDocument(t *testing.T) {
 	}
 }
 
+func TestPatchDocument(t *testing.T) {
+	var got docDomain.DocumentPatch
+	mockSvc := &mockDocumentService{
+		patchDocumentFunc: func(ctx context.Context, userCtx docDomain.UserContext, id string, patch docDomain.DocumentPatch) (*docDomain.Document, error) {
+			if id == "missing" {
+				return nil, docApp.ErrDocumentNotFound
+			}
+			if patch.IsEmpty() {
+				return nil, docApp.ErrInvalidPatch
+			}
+			got = patch
+			return &docDomain.Document{ID: id, Title: *patch.Title, IsActive: true}, nil
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.PATCH("/documents/:id", func(c *gin.Context) {
+		c.Set("user_id", "user-123")
+		c.Set("user_role", "user")
+		handler.Patch(c)
+	})
+
+	tests := []struct {
+		path, body string
+		want       int
+	}{
+		{"/documents/doc-123", `{"title": "Renamed"}`, http.StatusOK},
+		{"/documents/doc-123", `{}`, http.StatusBadRequest},
+		{"/documents/doc-123", `{"title": 5}`, http.StatusBadRequest},
+		{"/documents/missing", `{"title": "Renamed"}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("PATCH", tt.path, bytes.NewBufferString(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		if resp.Code != tt.want {
+			t.Errorf("Expected status %d for %s, got %d", tt.want, tt.body, resp.Code)
+		}
+	}
+	if got.Title == nil || *got.Title != "Renamed" || got.Content != nil || got.IsActive != nil {
+		t.Errorf("Expected only the title to be patched, got %+v", got)
+	}
+}
+
+func TestUpdateDocumentVersionConflict(t *testing.T) {
+	var expected int64
+	mockSvc := &mockDocumentService{
+		updateDocumentFunc: func(ctx context.Context, userCtx docDomain.UserContext, doc *docDomain.Document) error {
+			expected = doc.Version
+			if doc.Version != 0 && doc.Version != 4 {
+				return docApp.ErrVersionConflict
+			}
+			doc.Version = 5
+			return nil
+		},
+		getDocumentFunc: func(ctx context.Context, userCtx docDomain.UserContext, id string) (*docDomain.Document, error) {
+			return &docDomain.Document{ID: id, Title: "Current", Version: 4}, nil
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.PUT("/documents", func(c *gin.Context) {
+		c.Set("user_id", "admin-1")
+		c.Set("user_role", "admin")
+		handler.Update(c)
+	})
+
+	tests := []struct {
+		body, ifMatch string
+		want          int
+		wantVersion   int64
+	}{
+		{`{"id": "doc-123", "title": "T", "content": "C", "version": 3}`, "", http.StatusConflict, 3},
+		{`{"id": "doc-123", "title": "T", "content": "C"}`, `"4"`, http.StatusOK, 4},
+		{`{"id": "doc-123", "title": "T", "content": "C"}`, `"4-0123456789abcdef"`, http.StatusOK, 4},
+		{`{"id": "doc-123", "title": "T", "content": "C"}`, `W/"2"`, http.StatusConflict, 2},
+		{`{"id": "doc-123", "title": "T", "content": "C"}`, `"abc"`, http.StatusBadRequest, 0},
+		{`{"id": "doc-123", "title": "T", "content": "C"}`, "", http.StatusOK, 0},
+	}
+	for _, tt := range tests {
+		expected = 0
+		req, _ := http.NewRequest("PUT", "/documents", bytes.NewBufferString(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		if tt.ifMatch != "" {
+			req.Header.Set("If-Match", tt.ifMatch)
+		}
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != tt.want || expected != tt.wantVersion {
+			t.Errorf("Expected status %d at version %d for %s %s, got %d at %d", tt.want, tt.wantVersion, tt.body, tt.ifMatch, resp.Code, expected)
+		}
+		if resp.Code == http.StatusConflict {
+			var body map[string]any
+			_ = json.Unmarshal(resp.Body.Bytes(), &body)
+			if body["version"] != float64(4) || resp.Header().Get("ETag") != `"4"` {
+				t.Errorf("Expected the current version 4 in the conflict, got %v", body)
+			}
+		}
+	}
+}
+
 func TestUpdateDocumentNotFound(t *testing.T) {
 	mockSvc := &mockDocumentService{
 		updateDocumentFunc: func(ctx context.Context, userCtx docDomain.UserContext, doc *docDomain.Document) error {
@@ -407,3 +896,753 @@ func TestGetUserContextNonAdmin(t *testing.T) {
 		t.Error("Expected IsAdmin to be false for user role")
 	}
 }
+
+func TestListChunks(t *testing.T) {
+	var gotEmbeddings bool
+	mockSvc := &mockDocumentService{
+		listChunksFunc: func(ctx context.Context, userCtx docDomain.UserContext, documentID string, limit, offset int, withEmbeddings bool) ([]docDomain.Chunk, int64, error) {
+			gotEmbeddings = withEmbeddings
+			return []docDomain.Chunk{{ID: "c1", DocumentID: documentID}}, 1, nil
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.GET("/documents/:id/chunks", func(c *gin.Context) {
+		c.Set("user_id", "admin-1")
+		c.Set("user_role", "admin")
+		handler.ListChunks(c)
+	})
+
+	req, _ := http.NewRequest("GET", "/documents/doc-1/chunks", nil)
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.Code)
+	}
+	if gotEmbeddings {
+		t.Error("Expected embeddings to be omitted by default")
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(resp.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if result["total"].(float64) != 1 {
+		t.Errorf("Expected total 1, got %v", result["total"])
+	}
+}
+
+func TestPreviewArticle(t *testing.T) {
+	var got docDomain.ArticleDraft
+	mockSvc := &mockDocumentService{
+		previewFunc: func(ctx context.Context, userCtx docDomain.UserContext, draft docDomain.ArticleDraft) (*docDomain.ArticlePreview, error) {
+			got = draft
+			if draft.DocumentID == "missing" {
+				return nil, docApp.ErrDocumentNotFound
+			}
+			return &docDomain.ArticlePreview{HTML: "<h1>Returns</h1>\n", Valid: true}, nil
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.POST("/documents/preview", func(c *gin.Context) {
+		c.Set("user_id", "user-1")
+		handler.PreviewArticle(c)
+	})
+	router.GET("/documents/:id/preview", func(c *gin.Context) {
+		c.Set("user_id", "user-1")
+		handler.PreviewDocument(c)
+	})
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/documents/preview", strings.NewReader(`{"content":"# Returns"}`)))
+	if resp.Code != http.StatusOK || got.Content != "# Returns" || !strings.Contains(resp.Body.String(), `"valid":true`) {
+		t.Errorf("Expected the draft previewed, got %d: %s", resp.Code, resp.Body.String())
+	}
+
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/documents/preview", strings.NewReader(`{}`)))
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without content, got %d", resp.Code)
+	}
+
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/documents/missing/preview", nil))
+	if resp.Code != http.StatusNotFound || got.DocumentID != "missing" {
+		t.Errorf("Expected 404 for a missing document, got %d", resp.Code)
+	}
+}
+
+func TestDeleteChunkNotFound(t *testing.T) {
+	mockSvc := &mockDocumentService{
+		deleteChunkFunc: func(ctx context.Context, userCtx docDomain.UserContext, id string) error {
+			return docApp.ErrChunkNotFound
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.DELETE("/chunks/:id", func(c *gin.Context) {
+		c.Set("user_id", "admin-1")
+		c.Set("user_role", "admin")
+		handler.DeleteChunk(c)
+	})
+
+	req, _ := http.NewRequest("DELETE", "/chunks/c1", nil)
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", resp.Code)
+	}
+}
+
+func TestChangeStatusInvalidTransition(t *testing.T) {
+	mockSvc := &mockDocumentService{
+		changeStatusFunc: func(ctx context.Context, userCtx docDomain.UserContext, id string, status docDomain.Status) error {
+			return docApp.ErrInvalidStatus
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.POST("/documents/:id/status", func(c *gin.Context) {
+		c.Set("user_id", "admin-1")
+		c.Set("user_role", "admin")
+		handler.ChangeStatus(c)
+	})
+
+	req, _ := http.NewRequest("POST", "/documents/doc-1/status", bytes.NewBufferString(`{"status":"published"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusConflict {
+		t.Errorf("Expected status 409, got %d", resp.Code)
+	}
+}
+
+func TestGetUserContextEditor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Set("user_id", "user-123")
+	ctx.Set("user_role", "editor")
+
+	userCtx := getUserContext(ctx)
+
+	if !userCtx.IsEditor || userCtx.IsAdmin {
+		t.Error("Expected editor role to map to IsEditor only")
+	}
+}
+
+func TestGetStorageUsage(t *testing.T) {
+	mockSvc := &mockDocumentService{
+		storageUsageFunc: func(ctx context.Context, userCtx docDomain.UserContext) (*docDomain.UserStorage, error) {
+			return &docDomain.UserStorage{UserID: userCtx.UserID, StorageUsage: docDomain.StorageUsage{Documents: 2}}, nil
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.GET("/documents/storage", func(c *gin.Context) {
+		c.Set("user_id", "user-1")
+		handler.GetStorageUsage(c)
+	})
+
+	req, _ := http.NewRequest("GET", "/documents/storage", nil)
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.Code)
+	}
+
+	var result docDomain.UserStorage
+	if err := json.Unmarshal(resp.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if result.UserID != "user-1" || result.Documents != 2 {
+		t.Errorf("Unexpected usage %+v", result)
+	}
+}
+
+func TestGetStorageSummaryForbidden(t *testing.T) {
+	mockSvc := &mockDocumentService{
+		storageSummaryFunc: func(ctx context.Context, userCtx docDomain.UserContext) (*docDomain.StorageSummary, error) {
+			return nil, docApp.ErrForbidden
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.GET("/system/storage", func(c *gin.Context) {
+		c.Set("user_id", "user-1")
+		handler.GetStorageSummary(c)
+	})
+
+	req, _ := http.NewRequest("GET", "/system/storage", nil)
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", resp.Code)
+	}
+}
+
+func TestExportChunks(t *testing.T) {
+	mockSvc := &mockDocumentService{
+		exportChunksFunc: func(ctx context.Context, userCtx docDomain.UserContext, format docDomain.InterchangeFormat, documentID string, w io.Writer) error {
+			if format != docDomain.FormatLlamaIndex {
+				t.Errorf("Expected llamaindex format, got %s", format)
+			}
+			_, err := io.WriteString(w, `{"id_":"c1","text":"hello"}`+"\n")
+			return err
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.GET("/chunks/export", func(c *gin.Context) {
+		c.Set("user_id", "admin-1")
+		c.Set("user_role", "admin")
+		handler.ExportChunks(c)
+	})
+
+	req, _ := http.NewRequest("GET", "/chunks/export?format=llamaindex", nil)
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.Code)
+	}
+	if !strings.Contains(resp.Body.String(), `"id_":"c1"`) {
+		t.Errorf("Expected exported line, got %s", resp.Body.String())
+	}
+}
+
+func TestExportChunksUnsupportedFormat(t *testing.T) {
+	mockSvc := &mockDocumentService{
+		exportChunksFunc: func(ctx context.Context, userCtx docDomain.UserContext, format docDomain.InterchangeFormat, documentID string, w io.Writer) error {
+			return docApp.ErrUnsupportedFormat
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.GET("/chunks/export", func(c *gin.Context) {
+		c.Set("user_id", "admin-1")
+		c.Set("user_role", "admin")
+		handler.ExportChunks(c)
+	})
+
+	req, _ := http.NewRequest("GET", "/chunks/export?format=haystack", nil)
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", resp.Code)
+	}
+	if ct := resp.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("Expected JSON error, got content type %q", ct)
+	}
+}
+
+func TestImportChunksInvalid(t *testing.T) {
+	mockSvc := &mockDocumentService{
+		importChunksFunc: func(ctx context.Context, userCtx docDomain.UserContext, format docDomain.InterchangeFormat, r io.Reader) (*docDomain.ImportResult, error) {
+			body, _ := io.ReadAll(r)
+			if string(body) != "not json" {
+				t.Errorf("Expected raw body to reach the service, got %q", body)
+			}
+			return nil, fmt.Errorf("%w: line 1: bad json", docApp.ErrInvalidImport)
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.POST("/chunks/import", func(c *gin.Context) {
+		c.Set("user_id", "admin-1")
+		c.Set("user_role", "admin")
+		handler.ImportChunks(c)
+	})
+
+	req, _ := http.NewRequest("POST", "/chunks/import?format=langchain", strings.NewReader("not json"))
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", resp.Code)
+	}
+}
+
+func newUploadRequest(t *testing.T, fields map[string]string, filename, content string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for k, v := range fields {
+		_ = form.WriteField(k, v)
+	}
+	if filename != "" {
+		part, _ := form.CreateFormFile("file", filename)
+		_, _ = part.Write([]byte(content))
+	}
+	_ = form.Close()
+
+	req, _ := http.NewRequest("POST", "/documents/upload", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+func TestUploadDocument(t *testing.T) {
+	var got docDomain.Upload
+	mockSvc := &mockDocumentService{
+		uploadDocumentFunc: func(ctx context.Context, userCtx docDomain.UserContext, upload docDomain.Upload) (string, error) {
+			got = upload
+			return "doc-9", nil
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.POST("/documents/upload", func(c *gin.Context) {
+		c.Set("user_id", "user-1")
+		handler.Upload(c)
+	})
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, newUploadRequest(t, map[string]string{
+		"language":       "spa",
+		"page_languages": "2:deu, 3:fra",
+	}, "scan.pdf", "%PDF-1.7"))
+
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", resp.Code)
+	}
+	if got.Filename != "scan.pdf" || string(got.Data) != "%PDF-1.7" || got.Language != "spa" {
+		t.Errorf("Unexpected upload %+v", got)
+	}
+	if got.PageLanguages[2] != "deu" || got.PageLanguages[3] != "fra" {
+		t.Errorf("Expected page language hints, got %v", got.PageLanguages)
+	}
+}
+
+func TestUploadDocumentErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		fields   map[string]string
+		filename string
+		err      error
+		status   int
+	}{
+		{"missing file", nil, "", nil, http.StatusBadRequest},
+		{"bad page languages", map[string]string{"page_languages": "two:deu"}, "scan.pdf", nil, http.StatusBadRequest},
+		{"bad language", map[string]string{"language": "eng --tessdata-dir /tmp"}, "scan.pdf", nil, http.StatusBadRequest},
+		{"bad page language", map[string]string{"page_languages": "2:../deu"}, "scan.pdf", nil, http.StatusBadRequest},
+		{"unsupported", nil, "archive.zip", docApp.ErrUnsupportedFile, http.StatusUnsupportedMediaType},
+		{"no text", nil, "blank.pdf", docApp.ErrNoExtractableText, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockDocumentService{
+				uploadDocumentFunc: func(ctx context.Context, userCtx docDomain.UserContext, upload docDomain.Upload) (string, error) {
+					return "", tt.err
+				},
+			}
+			handler := createTestHandler(mockSvc)
+
+			router := setupTestRouter()
+			router.POST("/documents/upload", handler.Upload)
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, newUploadRequest(t, tt.fields, tt.filename, "data"))
+
+			if resp.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, resp.Code)
+			}
+		})
+	}
+}
+
+func TestIngestDocument(t *testing.T) {
+	var got docDomain.Ingest
+	var text string
+	mockSvc := &mockDocumentService{
+		ingestDocumentFunc: func(ctx context.Context, userCtx docDomain.UserContext, ingest docDomain.Ingest) (*docDomain.IngestResult, error) {
+			got = ingest
+			data, _ := io.ReadAll(ingest.Body)
+			text = string(data)
+			if strings.Contains(text, "huge") {
+				return nil, docApp.ErrDocumentTooLarge
+			}
+			return &docDomain.IngestResult{ID: "doc-9", Chunks: 1, Bytes: int64(len(data))}, nil
+		},
+	}
+	handler := createTestHandler(mockSvc)
+
+	router := setupTestRouter()
+	router.POST("/documents/stream", handler.Ingest)
+
+	req, _ := http.NewRequest("POST", "/documents/stream?title=Export&language=es", strings.NewReader("raw body"))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", resp.Code)
+	}
+	if got.Title != "Export" || got.Language != "es" || text != "raw body" {
+		t.Errorf("Expected the raw body with query fields, got %+v reading %q", got, text)
+	}
+
+	req = newUploadRequest(t, map[string]string{"title": "Form export", "collection": "faq"}, "export.txt", "form body")
+	req.URL.Path = "/documents/stream"
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", resp.Code)
+	}
+	if got.Title != "Form export" || got.Collection != "faq" || text != "form body" {
+		t.Errorf("Expected the file part with form fields, got %+v reading %q", got, text)
+	}
+
+	req, _ = http.NewRequest("POST", "/documents/stream", strings.NewReader("untitled"))
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without a title, got %d", resp.Code)
+	}
+
+	req, _ = http.NewRequest("POST", "/documents/stream?title=Big", strings.NewReader("huge"))
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413, got %d", resp.Code)
+	}
+}
+
+func TestStartEmbeddingMigration(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		err    error
+		status int
+	}{
+		{"started", `{"model": "text-embedding-3-small"}`, nil, http.StatusAccepted},
+		{"missing body", ``, nil, http.StatusBadRequest},
+		{"invalid", `{"model": "nope"}`, docApp.ErrInvalidMigration, http.StatusBadRequest},
+		{"running", `{"model": "text-embedding-3-small"}`, docApp.ErrMigrationRunning, http.StatusConflict},
+		{"forbidden", `{"model": "text-embedding-3-small"}`, docApp.ErrForbidden, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockDocumentService{
+				startMigrationFunc: func(ctx context.Context, userCtx docDomain.UserContext, model string) (*docDomain.EmbeddingMigration, error) {
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					return &docDomain.EmbeddingMigration{ID: "mig-1", ToModel: model, Status: docDomain.MigrationRunning}, nil
+				},
+			}
+			handler := createTestHandler(mockSvc)
+
+			router := setupTestRouter()
+			router.POST("/system/embeddings/migration", handler.StartEmbeddingMigration)
+			start := func(token string) *httptest.ResponseRecorder {
+				req, _ := http.NewRequest("POST", "/system/embeddings/migration", strings.NewReader(tt.body))
+				req.Header.Set("Content-Type", "application/json")
+				if token != "" {
+					req.Header.Set(confirm.Header, token)
+				}
+				resp := httptest.NewRecorder()
+				router.ServeHTTP(resp, req)
+				return resp
+			}
+
+			resp := start("")
+			if resp.Code == http.StatusOK {
+				var preview migrationPreview
+				if err := json.Unmarshal(resp.Body.Bytes(), &preview); err != nil || preview.Confirmation == nil {
+					t.Fatalf("Expected a preview with a token, got %s", resp.Body.String())
+				}
+				if preview.FromModel != "text-embedding-ada-002" {
+					t.Errorf("Expected the active model in the preview, got %+v", preview)
+				}
+				resp = start(preview.Confirmation.Token)
+			}
+			if resp.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, resp.Code, resp.Body.String())
+			}
+		})
+	}
+}
+
+func TestStartEmbeddingMigrationNeedsMatchingToken(t *testing.T) {
+	started := false
+	mockSvc := &mockDocumentService{
+		startMigrationFunc: func(ctx context.Context, userCtx docDomain.UserContext, model string) (*docDomain.EmbeddingMigration, error) {
+			started = true
+			return &docDomain.EmbeddingMigration{ID: "mig-1", ToModel: model}, nil
+		},
+	}
+	handler := createTestHandler(mockSvc)
+	router := setupTestRouter()
+	router.POST("/system/embeddings/migration", handler.StartEmbeddingMigration)
+
+	signer := confirm.New("test-secret", 0, nil)
+	other, _ := signer.Issue(confirm.Action{Name: "embedding_migration_start", Target: "text-embedding-3-large"},
+		migrationPreview{FromModel: "text-embedding-ada-002", ToModel: "text-embedding-3-large"})
+
+	req, _ := http.NewRequest("POST", "/system/embeddings/migration", strings.NewReader(`{"model": "text-embedding-3-small"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(confirm.Header, other.Token)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusBadRequest || started {
+		t.Errorf("Expected a token for another model refused, got status %d", resp.Code)
+	}
+}
+
+func TestSimilarDocuments(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"found", nil, http.StatusOK},
+		{"not found", docApp.ErrDocumentNotFound, http.StatusNotFound},
+		{"forbidden", docApp.ErrForbidden, http.StatusForbidden},
+		{"failure", errors.New("database down"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var capturedLimit int
+			mockSvc := &mockDocumentService{
+				similarFunc: func(ctx context.Context, userCtx docDomain.UserContext, id string, limit int) ([]docDomain.SimilarDocument, error) {
+					capturedLimit = limit
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					return []docDomain.SimilarDocument{
+						{Document: docDomain.Document{ID: "doc-2", Title: "Returns"}, Similarity: 0.97, NearDuplicate: true},
+					}, nil
+				},
+			}
+			handler := createTestHandler(mockSvc)
+
+			router := setupTestRouter()
+			router.GET("/documents/:id/similar", handler.SimilarDocuments)
+
+			req, _ := http.NewRequest("GET", "/documents/doc-1/similar?limit=3", nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, resp.Code, resp.Body.String())
+			}
+			if capturedLimit != 3 {
+				t.Errorf("Expected limit 3, got %d", capturedLimit)
+			}
+			if tt.status == http.StatusOK && !strings.Contains(resp.Body.String(), `"near_duplicate":true`) {
+				t.Errorf("Expected the duplicate flag, got %s", resp.Body.String())
+			}
+		})
+	}
+}
+
+func TestProjectEmbeddings(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		err    error
+		status int
+	}{
+		{"projected", "?method=pca&sample=100&collection=support", nil, http.StatusOK},
+		{"bad sample", "?sample=many", nil, http.StatusBadRequest},
+		{"invalid", "?method=tsne", docApp.ErrInvalidProjection, http.StatusBadRequest},
+		{"unknown collection", "?collection=nope", docApp.ErrCollectionNotFound, http.StatusNotFound},
+		{"forbidden", "", docApp.ErrForbidden, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var captured docDomain.ProjectionQuery
+			mockSvc := &mockDocumentService{
+				projectFunc: func(ctx context.Context, userCtx docDomain.UserContext, query docDomain.ProjectionQuery) (*docDomain.EmbeddingProjection, error) {
+					captured = query
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					return &docDomain.EmbeddingProjection{Method: query.Method, Points: []docDomain.ProjectedChunk{
+						{ChunkID: "c1", DocumentID: "d1", DocumentTitle: "Refunds", Vector: []float64{0.5, -1}},
+					}}, nil
+				},
+			}
+			handler := createTestHandler(mockSvc)
+
+			router := setupTestRouter()
+			router.GET("/system/embeddings/projection", handler.ProjectEmbeddings)
+
+			req, _ := http.NewRequest("GET", "/system/embeddings/projection"+tt.query, nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, resp.Code, resp.Body.String())
+			}
+			if tt.status == http.StatusOK {
+				if captured.Method != docDomain.ProjectionPCA || captured.Sample != 100 || captured.Collection != "support" {
+					t.Errorf("Expected the query to reach the service, got %+v", captured)
+				}
+				if !strings.Contains(resp.Body.String(), `"document_title":"Refunds"`) {
+					t.Errorf("Expected labelled points, got %s", resp.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestSaveCollection(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		err    error
+		status int
+	}{
+		{"saved", `{"embedding_model": "text-embedding-3-large", "dimensions": 1024}`, nil, http.StatusOK},
+		{"missing model", `{"dimensions": 1024}`, nil, http.StatusBadRequest},
+		{"invalid", `{"embedding_model": "nope"}`, docApp.ErrInvalidCollection, http.StatusBadRequest},
+		{"in use", `{"embedding_model": "other"}`, docApp.ErrCollectionInUse, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var saved *docDomain.Collection
+			mockSvc := &mockDocumentService{
+				saveCollectionFunc: func(ctx context.Context, userCtx docDomain.UserContext, collection *docDomain.Collection) error {
+					saved = collection
+					return tt.err
+				},
+			}
+			handler := createTestHandler(mockSvc)
+
+			router := setupTestRouter()
+			router.PUT("/collections/:name", handler.SaveCollection)
+
+			req, _ := http.NewRequest("PUT", "/collections/multilingual", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, resp.Code, resp.Body.String())
+			}
+			if tt.status == http.StatusOK && (saved == nil || saved.Name != "multilingual" || saved.Dimensions != 1024) {
+				t.Errorf("Expected the collection named from the path, got %+v", saved)
+			}
+		})
+	}
+}
+
+func TestCollectChunkGarbage(t *testing.T) {
+	handler := createTestHandler(&mockDocumentService{})
+	router := setupTestRouter()
+	router.POST("/chunks/gc", func(c *gin.Context) {
+		c.Set("user_id", "admin-1")
+		c.Set("user_role", "admin")
+		handler.CollectChunkGarbage(c)
+	})
+	collect := func(token string) chunkGCResponse {
+		t.Helper()
+		req, _ := http.NewRequest("POST", "/chunks/gc", nil)
+		if token != "" {
+			req.Header.Set(confirm.Header, token)
+		}
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		if resp.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.Code)
+		}
+		var result chunkGCResponse
+		if err := json.Unmarshal(resp.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		return result
+	}
+
+	preview := collect("")
+	if !preview.DryRun || preview.OrphanChunks != 2 || preview.Confirmation == nil {
+		t.Fatalf("Expected a dry run report with a token, got %+v", preview)
+	}
+	result := collect(preview.Confirmation.Token)
+	if result.DryRun || result.Confirmation != nil {
+		t.Errorf("Expected the collection to run, got %+v", result)
+	}
+}
+
+func TestDownload(t *testing.T) {
+	signed := &docDomain.Download{URL: "https://s3.example.com/bucket/documents/doc-1/original?X-Amz-Signature=abc", Filename: "policy.pdf", ContentType: "application/pdf", Size: 4}
+	tests := []struct {
+		name     string
+		query    string
+		download *docDomain.Download
+		err      error
+		status   int
+	}{
+		{"signed url", "", signed, nil, http.StatusOK},
+		{"redirect", "?redirect=true", signed, nil, http.StatusFound},
+		{"stream", "", &docDomain.Download{Body: io.NopCloser(strings.NewReader("%PDF")), Filename: "policy.pdf", ContentType: "application/pdf", Size: 4}, nil, http.StatusOK},
+		{"no file", "", nil, docApp.ErrNoFile, http.StatusNotFound},
+		{"forbidden", "", nil, docApp.ErrForbidden, http.StatusForbidden},
+		{"failure", "", nil, errors.New("storage down"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := &mockDocumentService{
+				downloadFunc: func(ctx context.Context, userCtx docDomain.UserContext, id string) (*docDomain.Download, error) {
+					return tt.download, tt.err
+				},
+			}
+			handler := createTestHandler(mockSvc)
+
+			router := setupTestRouter()
+			router.GET("/documents/:id/download", handler.Download)
+
+			req, _ := http.NewRequest("GET", "/documents/doc-1/download"+tt.query, nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			if resp.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, resp.Code, resp.Body.String())
+			}
+			switch tt.name {
+			case "signed url":
+				if !strings.Contains(resp.Body.String(), `"url":"https://s3.example.com/`) {
+					t.Errorf("Expected the signed URL, got %s", resp.Body.String())
+				}
+			case "redirect":
+				if resp.Header().Get("Location") != signed.URL {
+					t.Errorf("Expected a redirect to the signed URL, got %q", resp.Header().Get("Location"))
+				}
+			case "stream":
+				if resp.Body.String() != "%PDF" || resp.Header().Get("Content-Disposition") != `attachment; filename=policy.pdf` {
+					t.Errorf("Expected the file as an attachment, got %q with %q", resp.Body.String(), resp.Header().Get("Content-Disposition"))
+				}
+			}
+		})
+	}
+}
@@ -5,6 +5,49 @@ import "github.com/gin-gonic/gin"
 func Register(rg *gin.RouterGroup, handler *Handler) {
 	rg.GET("", handler.List)
 	rg.POST("", handler.Create)
+	rg.POST("/upload", handler.Upload)
+	rg.POST("/stream", handler.Ingest)
 	rg.PUT("", handler.Update)
 	rg.DELETE("", handler.Delete)
+	rg.GET("/pending-review", handler.ListPendingReview)
+	rg.GET("/storage", handler.GetStorageUsage)
+	rg.POST("/preview", handler.PreviewArticle)
+	rg.PATCH("/:id", handler.Patch)
+	rg.GET("/:id/chunks", handler.ListChunks)
+	rg.GET("/:id/preview", handler.PreviewDocument)
+	rg.GET("/:id/similar", handler.SimilarDocuments)
+	rg.GET("/:id/download", handler.Download)
+	rg.POST("/:id/status", handler.ChangeStatus)
+}
+
+func RegisterChunks(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("/export", handler.ExportChunks)
+	rg.POST("/import", handler.ImportChunks)
+	rg.POST("/gc", handler.CollectChunkGarbage)
+	rg.DELETE("/:id", handler.DeleteChunk)
+}
+
+func RegisterStorage(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("", handler.GetStorageSummary)
+	rg.POST("/rebuild", handler.RebuildStorage)
+}
+
+func RegisterEmbeddings(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("", handler.GetEmbeddingStatus)
+	rg.POST("/migration", handler.StartEmbeddingMigration)
+	rg.DELETE("/migration", handler.CancelEmbeddingMigration)
+	rg.GET("/projection", handler.ProjectEmbeddings)
+}
+
+func RegisterCollections(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("", handler.ListCollections)
+	rg.PUT("/:name", handler.SaveCollection)
+	rg.DELETE("/:name", handler.DeleteCollection)
+}
+
+func RegisterCleaningProfiles(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("", handler.ListCleaningProfiles)
+	rg.POST("", handler.CreateCleaningProfile)
+	rg.PUT("/:id", handler.UpdateCleaningProfile)
+	rg.DELETE("/:id", handler.DeleteCleaningProfile)
 }
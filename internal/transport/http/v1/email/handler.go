@@ -0,0 +1,198 @@
+package email
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	emailApp "github.com/elprogramadorgt/lucidRAG/internal/application/email"
+	emailDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/email"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// maxInboundBytes bounds inbound webhook bodies, attachments included.
+const maxInboundBytes = 32 << 20
+
+// answerTimeout bounds answering one email after the webhook has been
+// acknowledged.
+const answerTimeout = 2 * time.Minute
+
+type Handler struct {
+	svc          emailDomain.Service
+	inboundToken string
+	log          *logger.Logger
+}
+
+func NewHandler(svc emailDomain.Service, inboundToken string, log *logger.Logger) *Handler {
+	return &Handler{
+		svc:          svc,
+		inboundToken: inboundToken,
+		log:          log.With("handler", "email"),
+	}
+}
+
+func (h *Handler) writeError(ctx *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, emailApp.ErrDraftNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "draft not found"})
+	case errors.Is(err, emailApp.ErrDraftNotPending):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, emailApp.ErrInvalidDraft):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, emailApp.ErrSendFailed):
+		h.log.ErrorContext(ctx.Request.Context(), "failed to "+action, "error", err)
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	default:
+		h.log.ErrorContext(ctx.Request.Context(), "failed to "+action, "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to " + action})
+	}
+}
+
+// HandleInbound receives emails from an inbound parse webhook (SendGrid's
+// Inbound Parse format). The webhook URL carries the shared token, since
+// such webhooks are not signed. Emails are answered after the webhook is
+// acknowledged.
+func (h *Handler) HandleInbound(ctx *gin.Context) {
+	if subtle.ConstantTimeCompare([]byte(ctx.Query("token")), []byte(h.inboundToken)) != 1 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxInboundBytes)
+	if err := ctx.Request.ParseMultipartForm(1 << 20); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid inbound email"})
+		return
+	}
+
+	in, err := parseInbound(ctx.Request.PostFormValue("from"), ctx.Request.PostFormValue("subject"),
+		ctx.Request.PostFormValue("text"), ctx.Request.PostFormValue("headers"))
+	if err != nil {
+		h.log.WarnContext(ctx.Request.Context(), "rejected inbound email", "error", err)
+		// A malformed email will not parse on retry either.
+		ctx.JSON(http.StatusOK, gin.H{"status": "ignored"})
+		return
+	}
+
+	base := context.WithoutCancel(ctx.Request.Context())
+	go func() {
+		ctx, cancel := context.WithTimeout(base, answerTimeout)
+		defer cancel()
+		if err := h.svc.Receive(ctx, in); err != nil {
+			h.log.ErrorContext(ctx, "failed to handle inbound email", "error", err, "from", in.From)
+		}
+	}()
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "received"})
+}
+
+// parseInbound reads the sender and threading headers of an inbound email;
+// headers is the raw header block.
+func parseInbound(from, subject, text, headers string) (emailDomain.Inbound, error) {
+	addr, err := mail.ParseAddress(from)
+	if err != nil {
+		return emailDomain.Inbound{}, err
+	}
+	in := emailDomain.Inbound{
+		From:     addr.Address,
+		FromName: addr.Name,
+		Subject:  subject,
+		Text:     text,
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(strings.TrimRight(headers, "\r\n") + "\r\n\r\n"))
+	if err != nil {
+		// Threading and loop detection are lost, but the email can still be
+		// answered.
+		return in, nil
+	}
+	in.MessageID = strings.TrimSpace(parsed.Header.Get("Message-ID"))
+	in.References = strings.Fields(parsed.Header.Get("References"))
+	auto := strings.ToLower(parsed.Header.Get("Auto-Submitted"))
+	precedence := strings.ToLower(parsed.Header.Get("Precedence"))
+	in.AutoSubmitted = (auto != "" && auto != "no") || precedence == "bulk" || precedence == "auto_reply" ||
+		precedence == "list" || parsed.Header.Get("X-Autoreply") != ""
+	return in, nil
+}
+
+func (h *Handler) ListDrafts(ctx *gin.Context) {
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	status := emailDomain.DraftStatus(ctx.DefaultQuery("status", string(emailDomain.DraftPending)))
+
+	drafts, total, err := h.svc.ListDrafts(ctx.Request.Context(), status, limit, offset)
+	if err != nil {
+		h.writeError(ctx, err, "list drafts")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"drafts": drafts,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+func (h *Handler) GetDraft(ctx *gin.Context) {
+	draft, err := h.svc.GetDraft(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		h.writeError(ctx, err, "get draft")
+		return
+	}
+	ctx.JSON(http.StatusOK, draft)
+}
+
+type updateDraftRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+func (h *Handler) UpdateDraft(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+
+	var req updateDraftRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	draft, err := h.svc.UpdateDraft(ctx.Request.Context(), adminID, ctx.Param("id"), req.Body)
+	if err != nil {
+		h.writeError(ctx, err, "update draft")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "email_draft_update", "admin_id", adminID, "draft_id", draft.ID)
+	ctx.JSON(http.StatusOK, draft)
+}
+
+func (h *Handler) ApproveDraft(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+
+	draft, err := h.svc.ApproveDraft(ctx.Request.Context(), adminID, ctx.Param("id"))
+	if err != nil {
+		h.writeError(ctx, err, "send draft")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "email_draft_approve", "admin_id", adminID, "draft_id", draft.ID)
+	ctx.JSON(http.StatusOK, draft)
+}
+
+func (h *Handler) DiscardDraft(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+	id := ctx.Param("id")
+
+	if err := h.svc.DiscardDraft(ctx.Request.Context(), adminID, id); err != nil {
+		h.writeError(ctx, err, "discard draft")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "email_draft_discard", "admin_id", adminID, "draft_id", id)
+	ctx.JSON(http.StatusOK, gin.H{"message": "draft discarded"})
+}
@@ -0,0 +1,128 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	emailApp "github.com/elprogramadorgt/lucidRAG/internal/application/email"
+	emailDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/email"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// mockService implements emailDomain.Service for testing
+type mockService struct {
+	emailDomain.Service
+	received chan emailDomain.Inbound
+}
+
+func (m *mockService) Receive(ctx context.Context, in emailDomain.Inbound) error {
+	m.received <- in
+	return nil
+}
+
+func (m *mockService) ApproveDraft(ctx context.Context, adminID, id string) (*emailDomain.Draft, error) {
+	if id == "sent" {
+		return nil, emailApp.ErrDraftNotPending
+	}
+	return &emailDomain.Draft{ID: id, Status: emailDomain.DraftSent, ReviewedBy: adminID}, nil
+}
+
+func setupTestRouter() (*gin.Engine, *mockService) {
+	gin.SetMode(gin.TestMode)
+	svc := &mockService{received: make(chan emailDomain.Inbound, 1)}
+	handler := NewHandler(svc, "inbound-token", logger.New(logger.Options{Level: "error"}))
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "admin-1")
+		c.Set("user_role", "admin")
+	})
+	RegisterInbound(r.Group(""), handler)
+	RegisterDrafts(r.Group("/email/drafts"), handler)
+	return r, svc
+}
+
+func inboundRequest(t *testing.T, token string, fields map[string]string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for k, v := range fields {
+		if err := w.WriteField(k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = w.Close()
+	req := httptest.NewRequest(http.MethodPost, "/email/inbound?token="+token, &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+func TestHandleInbound(t *testing.T) {
+	router, svc := setupTestRouter()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, inboundRequest(t, "inbound-token", map[string]string{
+		"from":    "Ana Lopez <ana@example.com>",
+		"subject": "Password reset",
+		"text":    "How do I reset my password?",
+		"headers": "Message-ID: <abc@mail.example.com>\nReferences: <a@x> <b@x>\nSubject: Password reset\n",
+	}))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	select {
+	case in := <-svc.received:
+		if in.From != "ana@example.com" || in.FromName != "Ana Lopez" || in.MessageID != "<abc@mail.example.com>" {
+			t.Errorf("Unexpected inbound email %+v", in)
+		}
+		if len(in.References) != 2 || in.AutoSubmitted {
+			t.Errorf("Expected references and no auto-submitted flag, got %+v", in)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected email to be received")
+	}
+}
+
+func TestHandleInboundRejectsBadToken(t *testing.T) {
+	router, _ := setupTestRouter()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, inboundRequest(t, "wrong", map[string]string{"from": "ana@example.com"}))
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestParseInboundAutoSubmitted(t *testing.T) {
+	in, err := parseInbound("ana@example.com", "Out of office", "Back Monday", "Auto-Submitted: auto-replied\r\n")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !in.AutoSubmitted {
+		t.Error("Expected auto-reply to be flagged")
+	}
+}
+
+func TestApproveDraft(t *testing.T) {
+	router, _ := setupTestRouter()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/email/drafts/draft_1/approve", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"reviewed_by":"admin-1"`) {
+		t.Errorf("Expected approved draft, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/email/drafts/sent/approve", nil))
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+}
@@ -0,0 +1,19 @@
+package email
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterInbound mounts the inbound webhook, which authenticates with its
+// token instead of a user session.
+func RegisterInbound(rg *gin.RouterGroup, handler *Handler) {
+	rg.POST("/email/inbound", handler.HandleInbound)
+}
+
+func RegisterDrafts(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("", handler.ListDrafts)
+	rg.GET("/:id", handler.GetDraft)
+	rg.PUT("/:id", handler.UpdateDraft)
+	rg.POST("/:id/approve", handler.ApproveDraft)
+	rg.DELETE("/:id", handler.DiscardDraft)
+}
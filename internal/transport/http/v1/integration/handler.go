@@ -0,0 +1,194 @@
+package integration
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	integrationApp "github.com/elprogramadorgt/lucidRAG/internal/application/integration"
+	integrationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/integration"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	svc integrationDomain.Service
+	log *logger.Logger
+}
+
+func NewHandler(svc integrationDomain.Service, log *logger.Logger) *Handler {
+	return &Handler{
+		svc: svc,
+		log: log.With("handler", "integration"),
+	}
+}
+
+func (h *Handler) writeError(ctx *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, integrationApp.ErrInvalidRequest):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, integrationApp.ErrAPIKeyNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "api key not found"})
+	case errors.Is(err, integrationApp.ErrWebhookNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+	case errors.Is(err, integrationApp.ErrSendingUnavailable):
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		h.log.ErrorContext(ctx.Request.Context(), "failed to "+action, "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to " + action})
+	}
+}
+
+// apiKey is the key the request authenticated with.
+func apiKey(ctx *gin.Context) *integrationDomain.APIKey {
+	key, _ := ctx.MustGet("api_key").(*integrationDomain.APIKey)
+	return key
+}
+
+type keyRequest struct {
+	Name           string                    `json:"name" binding:"required"`
+	Kind           integrationDomain.KeyKind `json:"kind"`
+	AllowedOrigins []string                  `json:"allowed_origins"`
+}
+
+func (r keyRequest) settings() integrationDomain.KeySettings {
+	return integrationDomain.KeySettings{Name: r.Name, Kind: r.Kind, AllowedOrigins: r.AllowedOrigins}
+}
+
+func (h *Handler) ListKeys(ctx *gin.Context) {
+	keys, err := h.svc.ListAPIKeys(ctx.Request.Context())
+	if err != nil {
+		h.writeError(ctx, err, "list api keys")
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"keys": keys})
+}
+
+// CreateKey returns the key once; only its hash is kept.
+func (h *Handler) CreateKey(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+
+	var req keyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	key, raw, err := h.svc.CreateAPIKey(ctx.Request.Context(), adminID, req.settings())
+	if err != nil {
+		h.writeError(ctx, err, "create api key")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "api_key_create", "admin_id", adminID, "key_id", key.ID, "prefix", key.Prefix, "kind", key.Kind)
+	ctx.JSON(http.StatusCreated, gin.H{"api_key": key, "key": raw})
+}
+
+func (h *Handler) UpdateKey(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+
+	var req keyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	key, err := h.svc.UpdateAPIKey(ctx.Request.Context(), adminID, ctx.Param("id"), req.settings())
+	if err != nil {
+		h.writeError(ctx, err, "update api key")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "api_key_update", "admin_id", adminID, "key_id", key.ID)
+	ctx.JSON(http.StatusOK, gin.H{"api_key": key})
+}
+
+// RotateKey returns the new key once; the old one stops working.
+func (h *Handler) RotateKey(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+
+	key, raw, err := h.svc.RotateAPIKey(ctx.Request.Context(), adminID, ctx.Param("id"))
+	if err != nil {
+		h.writeError(ctx, err, "rotate api key")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "api_key_rotate", "admin_id", adminID, "key_id", key.ID, "prefix", key.Prefix)
+	ctx.JSON(http.StatusOK, gin.H{"api_key": key, "key": raw})
+}
+
+func (h *Handler) DeleteKey(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+	id := ctx.Param("id")
+
+	if err := h.svc.DeleteAPIKey(ctx.Request.Context(), adminID, id); err != nil {
+		h.writeError(ctx, err, "delete api key")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "api_key_delete", "admin_id", adminID, "key_id", id)
+	ctx.JSON(http.StatusOK, gin.H{"message": "api key deleted"})
+}
+
+// Me identifies the key, for tools that test a connection before using it.
+func (h *Handler) Me(ctx *gin.Context) {
+	key := apiKey(ctx)
+	ctx.JSON(http.StatusOK, gin.H{"id": key.ID, "name": key.Name, "prefix": key.Prefix})
+}
+
+// Trigger lists recent events of one type as a bare JSON array, newest
+// first, which is the shape polling triggers expect.
+func (h *Handler) Trigger(triggerType integrationDomain.TriggerType) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		limit, _ := strconv.Atoi(ctx.Query("limit"))
+
+		events, err := h.svc.ListTriggers(ctx.Request.Context(), triggerType, limit)
+		if err != nil {
+			h.writeError(ctx, err, "list trigger events")
+			return
+		}
+		ctx.JSON(http.StatusOK, events)
+	}
+}
+
+func (h *Handler) SendMessage(ctx *gin.Context) {
+	var req integrationDomain.SendMessage
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	key := apiKey(ctx)
+	sent, err := h.svc.SendMessage(ctx.Request.Context(), key, req)
+	if err != nil {
+		h.writeError(ctx, err, "send message")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "integration_action", "action", "send_message", "key_id", key.ID, "conversation_id", sent.ConversationID)
+	ctx.JSON(http.StatusCreated, sent)
+}
+
+type createDocumentRequest struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	Source  string `json:"source"`
+}
+
+func (h *Handler) CreateDocument(ctx *gin.Context) {
+	var req createDocumentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	key := apiKey(ctx)
+	id, err := h.svc.CreateDocument(ctx.Request.Context(), key, req.Title, req.Content, req.Source)
+	if err != nil {
+		h.writeError(ctx, err, "create document")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "integration_action", "action", "create_document", "key_id", key.ID, "document_id", id)
+	ctx.JSON(http.StatusCreated, gin.H{"id": id, "status": "draft"})
+}
@@ -0,0 +1,288 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	integrationApp "github.com/elprogramadorgt/lucidRAG/internal/application/integration"
+	integrationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/integration"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// mockService implements integrationDomain.Service for testing
+type mockService struct {
+	listTriggersFn func(ctx context.Context, triggerType integrationDomain.TriggerType, limit int) ([]integrationDomain.TriggerEvent, error)
+	sendFn         func(ctx context.Context, key *integrationDomain.APIKey, msg integrationDomain.SendMessage) (*integrationDomain.MessageSent, error)
+}
+
+func (m *mockService) CreateAPIKey(ctx context.Context, adminID string, settings integrationDomain.KeySettings) (*integrationDomain.APIKey, string, error) {
+	return &integrationDomain.APIKey{ID: "key-1", Name: settings.Name, Kind: settings.Kind, Prefix: "lrk_abcdef", KeyHash: "hash"}, "lrk_abcdef123", nil
+}
+
+func (m *mockService) UpdateAPIKey(ctx context.Context, adminID, id string, settings integrationDomain.KeySettings) (*integrationDomain.APIKey, error) {
+	if len(settings.AllowedOrigins) == 0 {
+		return nil, integrationApp.ErrInvalidRequest
+	}
+	return &integrationDomain.APIKey{ID: id, Name: settings.Name, AllowedOrigins: settings.AllowedOrigins}, nil
+}
+
+func (m *mockService) RotateAPIKey(ctx context.Context, adminID, id string) (*integrationDomain.APIKey, string, error) {
+	if id != "key-1" {
+		return nil, "", integrationApp.ErrAPIKeyNotFound
+	}
+	return &integrationDomain.APIKey{ID: id, Prefix: "lrw_fedcba", KeyHash: "new-hash"}, "lrw_fedcba456", nil
+}
+
+func (m *mockService) ListAPIKeys(ctx context.Context) ([]integrationDomain.APIKey, error) {
+	return []integrationDomain.APIKey{}, nil
+}
+
+func (m *mockService) DeleteAPIKey(ctx context.Context, adminID, id string) error {
+	return nil
+}
+
+func (m *mockService) Authenticate(ctx context.Context, key string) (*integrationDomain.APIKey, error) {
+	return &integrationDomain.APIKey{ID: "key-1", CreatedBy: "admin-1"}, nil
+}
+
+func (m *mockService) AuthenticateWidget(ctx context.Context, key string) (*integrationDomain.APIKey, error) {
+	return nil, integrationApp.ErrInvalidAPIKey
+}
+
+func (m *mockService) ListTriggers(ctx context.Context, triggerType integrationDomain.TriggerType, limit int) ([]integrationDomain.TriggerEvent, error) {
+	if m.listTriggersFn != nil {
+		return m.listTriggersFn(ctx, triggerType, limit)
+	}
+	return []integrationDomain.TriggerEvent{}, nil
+}
+
+func (m *mockService) SendMessage(ctx context.Context, key *integrationDomain.APIKey, msg integrationDomain.SendMessage) (*integrationDomain.MessageSent, error) {
+	if m.sendFn != nil {
+		return m.sendFn(ctx, key, msg)
+	}
+	return &integrationDomain.MessageSent{ConversationID: "conv-1", MessageID: "msg-1"}, nil
+}
+
+func (m *mockService) CreateDocument(ctx context.Context, key *integrationDomain.APIKey, title, content, source string) (string, error) {
+	return "doc-1", nil
+}
+
+func (m *mockService) CreateWebhook(ctx context.Context, adminID string, settings integrationDomain.WebhookSettings) (*integrationDomain.Webhook, string, error) {
+	if len(settings.Events) == 0 {
+		return nil, "", integrationApp.ErrInvalidRequest
+	}
+	return &integrationDomain.Webhook{ID: "hook-1", Name: settings.Name, URL: settings.URL, Events: settings.Events, SchemaVersion: 1, Active: true, Secret: "whsec_abc", CreatedBy: adminID}, "whsec_abc", nil
+}
+
+func (m *mockService) ListWebhooks(ctx context.Context) ([]integrationDomain.Webhook, error) {
+	return []integrationDomain.Webhook{}, nil
+}
+
+func (m *mockService) GetWebhook(ctx context.Context, id string) (*integrationDomain.Webhook, error) {
+	if id != "hook-1" {
+		return nil, integrationApp.ErrWebhookNotFound
+	}
+	return &integrationDomain.Webhook{ID: id, Secret: "whsec_abc"}, nil
+}
+
+func (m *mockService) UpdateWebhook(ctx context.Context, id string, settings integrationDomain.WebhookSettings) (*integrationDomain.Webhook, error) {
+	hook, err := m.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if settings.Active != nil {
+		hook.Active = *settings.Active
+	}
+	return hook, nil
+}
+
+func (m *mockService) DeleteWebhook(ctx context.Context, id string) error {
+	_, err := m.GetWebhook(ctx, id)
+	return err
+}
+
+func (m *mockService) TestWebhook(ctx context.Context, id, event string) (*integrationDomain.WebhookDelivery, error) {
+	if _, err := m.GetWebhook(ctx, id); err != nil {
+		return nil, err
+	}
+	if event == "" {
+		event = "message.received"
+	}
+	return &integrationDomain.WebhookDelivery{WebhookID: id, Event: event, SchemaVersion: 1, StatusCode: 500, Error: "status 500", Attempts: 1}, nil
+}
+
+func (m *mockService) WebhookSchemas() []integrationDomain.WebhookSchema {
+	return []integrationDomain.WebhookSchema{{Event: "message.received", SchemaVersion: 1}}
+}
+
+func setupTestRouter(svc integrationDomain.Service) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	h := NewHandler(svc, logger.New(logger.Options{Level: "error"}))
+	RegisterKeys(r.Group("/keys", func(c *gin.Context) { c.Set("user_id", "admin-1") }), h)
+	RegisterWebhooks(r.Group("/webhooks", func(c *gin.Context) { c.Set("user_id", "admin-1") }), h)
+	Register(r.Group("/integrations", func(c *gin.Context) {
+		c.Set("api_key", &integrationDomain.APIKey{ID: "key-1", CreatedBy: "admin-1"})
+	}), h)
+	return r
+}
+
+func TestTriggerReturnsBareArray(t *testing.T) {
+	var gotType integrationDomain.TriggerType
+	var gotLimit int
+	router := setupTestRouter(&mockService{
+		listTriggersFn: func(ctx context.Context, triggerType integrationDomain.TriggerType, limit int) ([]integrationDomain.TriggerEvent, error) {
+			gotType, gotLimit = triggerType, limit
+			return []integrationDomain.TriggerEvent{{ID: "evt-1", Type: triggerType, Query: "q"}}, nil
+		},
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/integrations/triggers/low-confidence-answer?limit=10", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var items []integrationDomain.TriggerEvent
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatalf("Expected a JSON array, got %s", w.Body.String())
+	}
+	if len(items) != 1 || items[0].ID != "evt-1" || gotType != integrationDomain.TriggerLowConfidence || gotLimit != 10 {
+		t.Errorf("Unexpected trigger listing %+v (type %s, limit %d)", items, gotType, gotLimit)
+	}
+}
+
+func TestSendMessageUnavailable(t *testing.T) {
+	router := setupTestRouter(&mockService{
+		sendFn: func(ctx context.Context, key *integrationDomain.APIKey, msg integrationDomain.SendMessage) (*integrationDomain.MessageSent, error) {
+			return nil, integrationApp.ErrSendingUnavailable
+		},
+	})
+
+	w := httptest.NewRecorder()
+	body := strings.NewReader(`{"phone":"15550100","text":"hi"}`)
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/integrations/actions/send-message", body))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+}
+
+func TestCreateKeyShowsKeyOnce(t *testing.T) {
+	router := setupTestRouter(&mockService{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/keys", strings.NewReader(`{"name":"Zapier"}`)))
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"key":"lrk_abcdef123"`) || strings.Contains(w.Body.String(), "hash") {
+		t.Errorf("Expected the key but not its hash, got %s", w.Body.String())
+	}
+}
+
+func TestRotateAndUpdateKey(t *testing.T) {
+	router := setupTestRouter(&mockService{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/keys/key-1/rotate", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"key":"lrw_fedcba456"`) || strings.Contains(w.Body.String(), "new-hash") {
+		t.Errorf("Expected the new key but not its hash, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/keys/missing/rotate", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/keys/key-1", strings.NewReader(`{"name":"Site","allowed_origins":[]}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestCreateWebhookShowsSecretOnce(t *testing.T) {
+	router := setupTestRouter(&mockService{})
+
+	body := `{"name":"CRM","url":"https://crm.example.com/hooks","events":["message.received"]}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	var resp struct {
+		Webhook map[string]any `json:"webhook"`
+		Secret  string         `json:"secret"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if resp.Secret != "whsec_abc" {
+		t.Errorf("Expected the secret, got %q", resp.Secret)
+	}
+	if _, ok := resp.Webhook["secret"]; ok {
+		t.Error("Expected the webhook itself not to carry its secret")
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/webhooks/hook-1", nil))
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "whsec_") {
+		t.Errorf("Expected the webhook without its secret, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(`{"name":"CRM","url":"https://crm.example.com","events":[]}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d without events, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestTestWebhook(t *testing.T) {
+	router := setupTestRouter(&mockService{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/webhooks/hook-1/test", strings.NewReader(`{"event":"message.sent"}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var resp struct {
+		Delivery integrationDomain.WebhookDelivery `json:"delivery"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if resp.Delivery.Event != "message.sent" || resp.Delivery.Delivered || resp.Delivery.StatusCode != 500 {
+		t.Errorf("Expected the failed delivery reported, got %+v", resp.Delivery)
+	}
+
+	// The body is optional.
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/webhooks/hook-1/test", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d without a body, got %d", http.StatusOK, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/webhooks/missing/test", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestWebhookSchemas(t *testing.T) {
+	router := setupTestRouter(&mockService{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/webhooks/schemas", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"event":"message.received"`) {
+		t.Errorf("Expected the schemas, got %d %s", w.Code, w.Body.String())
+	}
+}
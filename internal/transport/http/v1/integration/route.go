@@ -0,0 +1,37 @@
+package integration
+
+import (
+	integrationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/integration"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterKeys mounts API key management, for admins.
+func RegisterKeys(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("", handler.ListKeys)
+	rg.POST("", handler.CreateKey)
+	rg.PUT("/:id", handler.UpdateKey)
+	rg.POST("/:id/rotate", handler.RotateKey)
+	rg.DELETE("/:id", handler.DeleteKey)
+}
+
+// RegisterWebhooks mounts outbound webhook management, for admins.
+func RegisterWebhooks(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("", handler.ListWebhooks)
+	rg.POST("", handler.CreateWebhook)
+	rg.GET("/schemas", handler.WebhookSchemas)
+	rg.GET("/:id", handler.GetWebhook)
+	rg.PUT("/:id", handler.UpdateWebhook)
+	rg.DELETE("/:id", handler.DeleteWebhook)
+	rg.POST("/:id/test", handler.TestWebhook)
+}
+
+// Register mounts the triggers and actions no-code tools call with an API
+// key.
+func Register(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("/me", handler.Me)
+	rg.GET("/triggers/new-message", handler.Trigger(integrationDomain.TriggerNewMessage))
+	rg.GET("/triggers/low-confidence-answer", handler.Trigger(integrationDomain.TriggerLowConfidence))
+	rg.GET("/triggers/spend-cap-reached", handler.Trigger(integrationDomain.TriggerSpendCap))
+	rg.POST("/actions/send-message", handler.SendMessage)
+	rg.POST("/actions/create-document", handler.CreateDocument)
+}
@@ -0,0 +1,122 @@
+package integration
+
+import (
+	"net/http"
+
+	integrationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/integration"
+	"github.com/gin-gonic/gin"
+)
+
+type webhookRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	URL           string   `json:"url" binding:"required"`
+	Events        []string `json:"events" binding:"required"`
+	SchemaVersion int      `json:"schema_version"`
+	Active        *bool    `json:"active"`
+}
+
+func (r webhookRequest) settings() integrationDomain.WebhookSettings {
+	return integrationDomain.WebhookSettings{Name: r.Name, URL: r.URL, Events: r.Events, SchemaVersion: r.SchemaVersion, Active: r.Active}
+}
+
+func (h *Handler) ListWebhooks(ctx *gin.Context) {
+	hooks, err := h.svc.ListWebhooks(ctx.Request.Context())
+	if err != nil {
+		h.writeError(ctx, err, "list webhooks")
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"webhooks": hooks})
+}
+
+func (h *Handler) GetWebhook(ctx *gin.Context) {
+	hook, err := h.svc.GetWebhook(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		h.writeError(ctx, err, "get webhook")
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"webhook": hook})
+}
+
+// CreateWebhook returns the signing secret once.
+func (h *Handler) CreateWebhook(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+
+	var req webhookRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	hook, secret, err := h.svc.CreateWebhook(ctx.Request.Context(), adminID, req.settings())
+	if err != nil {
+		h.writeError(ctx, err, "create webhook")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "webhook_create", "admin_id", adminID, "webhook_id", hook.ID, "events", hook.Events)
+	ctx.JSON(http.StatusCreated, gin.H{"webhook": hook, "secret": secret})
+}
+
+func (h *Handler) UpdateWebhook(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+
+	var req webhookRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	hook, err := h.svc.UpdateWebhook(ctx.Request.Context(), ctx.Param("id"), req.settings())
+	if err != nil {
+		h.writeError(ctx, err, "update webhook")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "webhook_update", "admin_id", adminID, "webhook_id", hook.ID, "active", hook.Active)
+	ctx.JSON(http.StatusOK, gin.H{"webhook": hook})
+}
+
+func (h *Handler) DeleteWebhook(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+	id := ctx.Param("id")
+
+	if err := h.svc.DeleteWebhook(ctx.Request.Context(), id); err != nil {
+		h.writeError(ctx, err, "delete webhook")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "webhook_delete", "admin_id", adminID, "webhook_id", id)
+	ctx.JSON(http.StatusOK, gin.H{"message": "webhook deleted"})
+}
+
+type testWebhookRequest struct {
+	Event string `json:"event"`
+}
+
+// TestWebhook posts a sample event to the webhook and reports how the
+// receiver answered. A failed delivery is still a 200; the outcome is in
+// the body.
+func (h *Handler) TestWebhook(ctx *gin.Context) {
+	var req testWebhookRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+	}
+
+	delivery, err := h.svc.TestWebhook(ctx.Request.Context(), ctx.Param("id"), req.Event)
+	if err != nil {
+		h.writeError(ctx, err, "test webhook")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "webhook_test", "admin_id", ctx.GetString("user_id"), "webhook_id", delivery.WebhookID, "event", delivery.Event, "delivered", delivery.Delivered)
+	ctx.JSON(http.StatusOK, gin.H{"delivery": delivery})
+}
+
+// WebhookSchemas lists the JSON Schema of every event's data in every
+// payload version.
+func (h *Handler) WebhookSchemas(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"schemas": h.svc.WebhookSchemas()})
+}
@@ -0,0 +1,171 @@
+package product
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	productApp "github.com/elprogramadorgt/lucidRAG/internal/application/product"
+	productDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/product"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportBytes bounds an uploaded CSV file.
+const maxImportBytes = 32 << 20
+
+type Handler struct {
+	svc productDomain.Service
+	log *logger.Logger
+}
+
+func NewHandler(svc productDomain.Service, log *logger.Logger) *Handler {
+	return &Handler{
+		svc: svc,
+		log: log.With("handler", "product"),
+	}
+}
+
+type productRequest struct {
+	SKU         string            `json:"sku"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Price       *float64          `json:"price"`
+	Currency    string            `json:"currency"`
+	Stock       *int              `json:"stock"`
+	Attributes  map[string]string `json:"attributes"`
+	Images      []string          `json:"images"`
+}
+
+func (r productRequest) product() *productDomain.Product {
+	return &productDomain.Product{
+		SKU: r.SKU, Name: r.Name, Description: r.Description, Price: r.Price, Currency: r.Currency,
+		Stock: r.Stock, Attributes: r.Attributes, Images: r.Images,
+	}
+}
+
+func (h *Handler) writeError(ctx *gin.Context, err error, action string) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.Is(err, productApp.ErrInvalidProduct), errors.Is(err, productApp.ErrInvalidCSV):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, productApp.ErrProductNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+	case errors.Is(err, productApp.ErrDuplicateSKU):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.As(err, &tooLarge):
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file too large"})
+	default:
+		h.log.ErrorContext(ctx.Request.Context(), "failed to "+action, "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to " + action})
+	}
+}
+
+// List returns products, optionally filtered by "q" on SKU, name and
+// description.
+func (h *Handler) List(ctx *gin.Context) {
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+
+	products, total, err := h.svc.ListProducts(ctx.Request.Context(), productDomain.Filter{Query: ctx.Query("q"), Limit: limit, Offset: offset})
+	if err != nil {
+		h.writeError(ctx, err, "list products")
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"products": products,
+		"total":    total,
+		"limit":    limit,
+		"offset":   offset,
+	})
+}
+
+func (h *Handler) Get(ctx *gin.Context) {
+	product, err := h.svc.GetProduct(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		h.writeError(ctx, err, "get product")
+		return
+	}
+	ctx.JSON(http.StatusOK, product)
+}
+
+func (h *Handler) Create(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+
+	var req productRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	product := req.product()
+	id, err := h.svc.CreateProduct(ctx.Request.Context(), adminID, product)
+	if err != nil {
+		h.writeError(ctx, err, "create product")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "product_create", "admin_id", adminID, "product_id", id, "sku", product.SKU)
+	ctx.JSON(http.StatusCreated, gin.H{"id": id, "message": "product created"})
+}
+
+func (h *Handler) Update(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+
+	var req productRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	product := req.product()
+	product.ID = ctx.Param("id")
+	if err := h.svc.UpdateProduct(ctx.Request.Context(), adminID, product); err != nil {
+		h.writeError(ctx, err, "update product")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "product_update", "admin_id", adminID, "product_id", product.ID, "sku", product.SKU)
+	ctx.JSON(http.StatusOK, product)
+}
+
+func (h *Handler) Delete(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+	id := ctx.Param("id")
+
+	if err := h.svc.DeleteProduct(ctx.Request.Context(), adminID, id); err != nil {
+		h.writeError(ctx, err, "delete product")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "product_delete", "admin_id", adminID, "product_id", id)
+	ctx.JSON(http.StatusOK, gin.H{"message": "product deleted"})
+}
+
+// Import accepts a CSV file either as the raw request body or as a
+// multipart "file" field.
+func (h *Handler) Import(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportBytes)
+	var body io.Reader = ctx.Request.Body
+	if file, err := ctx.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+			return
+		}
+		defer func() { _ = f.Close() }()
+		body = f
+	}
+
+	result, err := h.svc.ImportCSV(ctx.Request.Context(), adminID, body)
+	if err != nil {
+		h.writeError(ctx, err, "import products")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "product_import", "admin_id", adminID, "created", result.Created, "updated", result.Updated, "errors", len(result.Errors))
+	ctx.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,107 @@
+package product
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	productApp "github.com/elprogramadorgt/lucidRAG/internal/application/product"
+	productDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/product"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// mockService records what it is given; the methods these tests do not use
+// are left to the embedded interface.
+type mockService struct {
+	productDomain.Service
+	imported string
+}
+
+func (m *mockService) CreateProduct(ctx context.Context, adminID string, product *productDomain.Product) (string, error) {
+	if product.SKU == "TAKEN" {
+		return "", productApp.ErrDuplicateSKU
+	}
+	if product.Name == "" {
+		return "", fmt.Errorf("%w: name must be 1 to 200 characters", productApp.ErrInvalidProduct)
+	}
+	return "product-1", nil
+}
+
+func (m *mockService) ImportCSV(ctx context.Context, adminID string, r io.Reader) (*productDomain.ImportResult, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	m.imported = string(data)
+	return &productDomain.ImportResult{Created: 1, Errors: []productDomain.ImportError{}}, nil
+}
+
+func setupTestRouter(svc productDomain.Service) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "admin-1")
+	})
+	Register(r.Group("/products"), NewHandler(svc, logger.New(logger.Options{Level: "error"})))
+	return r
+}
+
+func TestCreateProduct(t *testing.T) {
+	router := setupTestRouter(&mockService{})
+
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"sku":"TS-01","name":"T-shirt","price":12.5,"currency":"USD"}`, http.StatusCreated},
+		{`{"sku":"TS-01"}`, http.StatusBadRequest},
+		{`{"sku":"TAKEN","name":"T-shirt"}`, http.StatusConflict},
+		{`not json`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(tt.body)))
+		if w.Code != tt.code {
+			t.Errorf("%s: expected %d, got %d: %s", tt.body, tt.code, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestImportAcceptsRawAndMultipartFiles(t *testing.T) {
+	const file = "sku,name\nTS-01,T-shirt\n"
+	svc := &mockService{}
+	router := setupTestRouter(svc)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/products/import", strings.NewReader(file)))
+	if w.Code != http.StatusOK || svc.imported != file {
+		t.Fatalf("Expected the raw body to be imported, got %d and %q", w.Code, svc.imported)
+	}
+
+	svc.imported = ""
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "products.csv")
+	part.Write([]byte(file))
+	form.Close()
+	req := httptest.NewRequest(http.MethodPost, "/products/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || svc.imported != file {
+		t.Fatalf("Expected the uploaded file to be imported, got %d and %q", w.Code, svc.imported)
+	}
+
+	var result productDomain.ImportResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil || result.Created != 1 {
+		t.Errorf("Unexpected result %s", w.Body.String())
+	}
+}
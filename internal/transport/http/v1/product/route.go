@@ -0,0 +1,13 @@
+package product
+
+import "github.com/gin-gonic/gin"
+
+// Register registers the admin routes that manage the product catalog.
+func Register(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("", handler.List)
+	rg.POST("", handler.Create)
+	rg.POST("/import", handler.Import)
+	rg.GET("/:id", handler.Get)
+	rg.PUT("/:id", handler.Update)
+	rg.DELETE("/:id", handler.Delete)
+}
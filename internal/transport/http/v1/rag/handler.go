@@ -3,10 +3,12 @@ package rag
 import (
 	"errors"
 	"net/http"
+	"strconv"
 
 	docApp "github.com/elprogramadorgt/lucidRAG/internal/application/document"
 	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
 	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/elprogramadorgt/lucidRAG/pkg/tz"
 	"github.com/gin-gonic/gin"
 )
 
@@ -26,6 +28,28 @@ type queryRequest struct {
 	Query     string  `json:"query" binding:"required"`
 	TopK      int     `json:"top_k"`
 	Threshold float64 `json:"threshold"`
+
+	ResponseSchema  map[string]any    `json:"response_schema"`
+	Channel         string            `json:"channel"`
+	CustomerContext map[string]string `json:"customer_context"`
+	Collection      string            `json:"collection"`
+
+	// Generation overrides, for admins and API keys only.
+	Model       string   `json:"model"`
+	Temperature *float64 `json:"temperature"`
+	TopP        *float64 `json:"top_p"`
+	MaxTokens   int      `json:"max_tokens"`
+}
+
+func (r queryRequest) overrides() bool {
+	return r.Model != "" || r.Temperature != nil || r.TopP != nil || r.MaxTokens != 0
+}
+
+// canOverride reports whether the caller may override generation
+// settings: admins, and integrations authenticated by an API key.
+func canOverride(ctx *gin.Context) bool {
+	_, apiKey := ctx.Get("api_key")
+	return apiKey || ctx.GetString("user_role") == "admin"
 }
 
 func (h *Handler) Query(ctx *gin.Context) {
@@ -34,25 +58,53 @@ func (h *Handler) Query(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
 		return
 	}
+	if req.overrides() && !canOverride(ctx) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "only admins and API keys may override model settings"})
+		return
+	}
 
 	query := documentDomain.RAGQuery{
 		Query:     req.Query,
 		TopK:      req.TopK,
 		Threshold: req.Threshold,
+
+		ResponseSchema:  req.ResponseSchema,
+		Channel:         documentDomain.Channel(req.Channel),
+		CustomerContext: req.CustomerContext,
+		Collection:      req.Collection,
+
+		Model:       req.Model,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		MaxTokens:   req.MaxTokens,
 	}
 
 	response, err := h.svc.QueryRAG(ctx.Request.Context(), query)
 	if err != nil {
 		if errors.Is(err, docApp.ErrInvalidQuery) {
+			// Overrides are checked by the service; say which one is wrong.
+			if req.overrides() {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid query"})
 			return
 		}
-		h.log.Error("failed to process RAG query", "error", err)
+		if errors.Is(err, docApp.ErrInvalidSchema) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, docApp.ErrStructuredAnswer) {
+			h.log.WarnContext(ctx.Request.Context(), "structured answer rejected", "error", err)
+			ctx.JSON(http.StatusBadGateway, gin.H{"error": "could not produce an answer matching the response schema"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to process RAG query", "error", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process query"})
 		return
 	}
 
-	h.log.Info("RAG query processed",
+	h.log.InfoContext(ctx.Request.Context(), "RAG query processed",
 		"request_id", ctx.GetString("request_id"),
 		"query_length", len(req.Query),
 		"processing_time_ms", response.ProcessingTimeMs,
@@ -60,3 +112,500 @@ func (h *Handler) Query(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, response)
 }
+
+func getUserContext(ctx *gin.Context) documentDomain.UserContext {
+	userID := ctx.GetString("user_id")
+	role := ctx.GetString("user_role")
+	return documentDomain.UserContext{
+		UserID:  userID,
+		IsAdmin: role == "admin",
+	}
+}
+
+type createRuleRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Keywords    []string `json:"keywords"`
+	ChunkIDs    []string `json:"chunk_ids"`
+	DocumentIDs []string `json:"document_ids"`
+	Action      string   `json:"action" binding:"required"`
+	Boost       float64  `json:"boost"`
+}
+
+func (h *Handler) ListRules(ctx *gin.Context) {
+	userCtx := getUserContext(ctx)
+
+	rules, err := h.svc.ListRetrievalRules(ctx.Request.Context(), userCtx)
+	if err != nil {
+		if errors.Is(err, docApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to list retrieval rules", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list rules"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"rules": rules, "total": len(rules)})
+}
+
+func (h *Handler) CreateRule(ctx *gin.Context) {
+	var req createRuleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	userCtx := getUserContext(ctx)
+	rule := &documentDomain.RetrievalRule{
+		Name:        req.Name,
+		Keywords:    req.Keywords,
+		ChunkIDs:    req.ChunkIDs,
+		DocumentIDs: req.DocumentIDs,
+		Action:      documentDomain.RuleAction(req.Action),
+		Boost:       req.Boost,
+	}
+
+	id, err := h.svc.CreateRetrievalRule(ctx.Request.Context(), userCtx, rule)
+	if err != nil {
+		if errors.Is(err, docApp.ErrInvalidRule) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule"})
+			return
+		}
+		if errors.Is(err, docApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to create retrieval rule", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create rule"})
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "retrieval_rule_create", "admin_id", userCtx.UserID, "rule_id", id, "rule_action", req.Action)
+	ctx.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "rule created successfully",
+	})
+}
+
+func (h *Handler) DeleteRule(ctx *gin.Context) {
+	id := ctx.Param("id")
+	userCtx := getUserContext(ctx)
+
+	err := h.svc.DeleteRetrievalRule(ctx.Request.Context(), userCtx, id)
+	if err != nil {
+		if errors.Is(err, docApp.ErrRuleNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "rule not found"})
+			return
+		}
+		if errors.Is(err, docApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to delete retrieval rule", "error", err, "id", id)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete rule"})
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "retrieval_rule_delete", "admin_id", userCtx.UserID, "rule_id", id)
+	ctx.JSON(http.StatusOK, gin.H{"message": "rule deleted successfully"})
+}
+
+type shortcutRequest struct {
+	Name     string   `json:"name" binding:"required"`
+	Keywords []string `json:"keywords" binding:"required"`
+	Answer   string   `json:"answer" binding:"required"`
+	Priority int      `json:"priority"`
+	// IsActive only applies to updates; omitted, the shortcut is active.
+	IsActive *bool `json:"is_active"`
+}
+
+func (r shortcutRequest) shortcut() *documentDomain.FAQShortcut {
+	return &documentDomain.FAQShortcut{
+		Name:     r.Name,
+		Keywords: r.Keywords,
+		Answer:   r.Answer,
+		Priority: r.Priority,
+		IsActive: r.IsActive == nil || *r.IsActive,
+	}
+}
+
+func (h *Handler) ListShortcuts(ctx *gin.Context) {
+	userCtx := getUserContext(ctx)
+
+	shortcuts, err := h.svc.ListFAQShortcuts(ctx.Request.Context(), userCtx)
+	if err != nil {
+		if errors.Is(err, docApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to list faq shortcuts", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list shortcuts"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"shortcuts": shortcuts, "total": len(shortcuts)})
+}
+
+func (h *Handler) CreateShortcut(ctx *gin.Context) {
+	var req shortcutRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	userCtx := getUserContext(ctx)
+	id, err := h.svc.CreateFAQShortcut(ctx.Request.Context(), userCtx, req.shortcut())
+	if err != nil {
+		if errors.Is(err, docApp.ErrInvalidShortcut) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, docApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to create faq shortcut", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create shortcut"})
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "faq_shortcut_create", "admin_id", userCtx.UserID, "shortcut_id", id)
+	ctx.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "shortcut created successfully",
+	})
+}
+
+func (h *Handler) UpdateShortcut(ctx *gin.Context) {
+	var req shortcutRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	id := ctx.Param("id")
+	userCtx := getUserContext(ctx)
+	shortcut := req.shortcut()
+	shortcut.ID = id
+	if err := h.svc.UpdateFAQShortcut(ctx.Request.Context(), userCtx, shortcut); err != nil {
+		switch {
+		case errors.Is(err, docApp.ErrInvalidShortcut):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, docApp.ErrShortcutNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "shortcut not found"})
+		case errors.Is(err, docApp.ErrForbidden):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		default:
+			h.log.ErrorContext(ctx.Request.Context(), "failed to update faq shortcut", "error", err, "id", id)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update shortcut"})
+		}
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "faq_shortcut_update", "admin_id", userCtx.UserID, "shortcut_id", id)
+	ctx.JSON(http.StatusOK, shortcut)
+}
+
+func (h *Handler) DeleteShortcut(ctx *gin.Context) {
+	id := ctx.Param("id")
+	userCtx := getUserContext(ctx)
+
+	err := h.svc.DeleteFAQShortcut(ctx.Request.Context(), userCtx, id)
+	if err != nil {
+		if errors.Is(err, docApp.ErrShortcutNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "shortcut not found"})
+			return
+		}
+		if errors.Is(err, docApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to delete faq shortcut", "error", err, "id", id)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete shortcut"})
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "faq_shortcut_delete", "admin_id", userCtx.UserID, "shortcut_id", id)
+	ctx.JSON(http.StatusOK, gin.H{"message": "shortcut deleted successfully"})
+}
+
+type glossaryRequest struct {
+	Term     string   `json:"term" binding:"required"`
+	Synonyms []string `json:"synonyms" binding:"required"`
+}
+
+func (h *Handler) ListGlossary(ctx *gin.Context) {
+	userCtx := getUserContext(ctx)
+
+	entries, err := h.svc.ListGlossaryEntries(ctx.Request.Context(), userCtx)
+	if err != nil {
+		if errors.Is(err, docApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to list glossary", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list glossary"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"entries": entries, "total": len(entries)})
+}
+
+func (h *Handler) CreateGlossaryEntry(ctx *gin.Context) {
+	var req glossaryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	userCtx := getUserContext(ctx)
+	entry := &documentDomain.GlossaryEntry{Term: req.Term, Synonyms: req.Synonyms}
+	id, err := h.svc.CreateGlossaryEntry(ctx.Request.Context(), userCtx, entry)
+	if err != nil {
+		switch {
+		case errors.Is(err, docApp.ErrInvalidGlossaryEntry):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, docApp.ErrDuplicateGlossaryTerm):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, docApp.ErrForbidden):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		default:
+			h.log.ErrorContext(ctx.Request.Context(), "failed to create glossary entry", "error", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create glossary entry"})
+		}
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "glossary_create", "admin_id", userCtx.UserID, "entry_id", id)
+	ctx.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "glossary entry created successfully",
+	})
+}
+
+func (h *Handler) UpdateGlossaryEntry(ctx *gin.Context) {
+	var req glossaryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	id := ctx.Param("id")
+	userCtx := getUserContext(ctx)
+	entry := &documentDomain.GlossaryEntry{ID: id, Term: req.Term, Synonyms: req.Synonyms}
+	if err := h.svc.UpdateGlossaryEntry(ctx.Request.Context(), userCtx, entry); err != nil {
+		switch {
+		case errors.Is(err, docApp.ErrInvalidGlossaryEntry):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, docApp.ErrDuplicateGlossaryTerm):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, docApp.ErrGlossaryEntryNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "glossary entry not found"})
+		case errors.Is(err, docApp.ErrForbidden):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		default:
+			h.log.ErrorContext(ctx.Request.Context(), "failed to update glossary entry", "error", err, "id", id)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update glossary entry"})
+		}
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "glossary_update", "admin_id", userCtx.UserID, "entry_id", id)
+	ctx.JSON(http.StatusOK, entry)
+}
+
+func (h *Handler) DeleteGlossaryEntry(ctx *gin.Context) {
+	id := ctx.Param("id")
+	userCtx := getUserContext(ctx)
+
+	err := h.svc.DeleteGlossaryEntry(ctx.Request.Context(), userCtx, id)
+	if err != nil {
+		if errors.Is(err, docApp.ErrGlossaryEntryNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "glossary entry not found"})
+			return
+		}
+		if errors.Is(err, docApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to delete glossary entry", "error", err, "id", id)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete glossary entry"})
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "glossary_delete", "admin_id", userCtx.UserID, "entry_id", id)
+	ctx.JSON(http.StatusOK, gin.H{"message": "glossary entry deleted successfully"})
+}
+
+type formatProfileRequest struct {
+	MaxTokens int    `json:"max_tokens"`
+	Markdown  bool   `json:"markdown"`
+	Citations string `json:"citations" binding:"required"`
+	Emoji     string `json:"emoji" binding:"required"`
+}
+
+func (h *Handler) ListFormats(ctx *gin.Context) {
+	userCtx := getUserContext(ctx)
+
+	profiles, err := h.svc.ListFormatProfiles(ctx.Request.Context(), userCtx)
+	if err != nil {
+		if errors.Is(err, docApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to list format profiles", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list format profiles"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"profiles": profiles})
+}
+
+func (h *Handler) SaveFormat(ctx *gin.Context) {
+	var req formatProfileRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	userCtx := getUserContext(ctx)
+	profile := &documentDomain.FormatProfile{
+		Channel:   documentDomain.Channel(ctx.Param("channel")),
+		MaxTokens: req.MaxTokens,
+		Markdown:  req.Markdown,
+		Citations: documentDomain.CitationStyle(req.Citations),
+		Emoji:     documentDomain.EmojiPolicy(req.Emoji),
+	}
+
+	if err := h.svc.SaveFormatProfile(ctx.Request.Context(), userCtx, profile); err != nil {
+		if errors.Is(err, docApp.ErrInvalidProfile) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, docApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to save format profile", "error", err, "channel", profile.Channel)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save format profile"})
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "format_profile_update", "admin_id", userCtx.UserID, "channel", profile.Channel)
+	ctx.JSON(http.StatusOK, profile)
+}
+
+// parseQueryLogFilter reads the query log filters, writing a 400 and
+// returning false when one is invalid.
+func parseQueryLogFilter(ctx *gin.Context) (documentDomain.QueryLogFilter, bool) {
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	filter := documentDomain.QueryLogFilter{
+		Query:      ctx.Query("q"),
+		Outcome:    documentDomain.QueryOutcome(ctx.Query("outcome")),
+		Channel:    documentDomain.Channel(ctx.Query("channel")),
+		DocumentID: ctx.Query("document_id"),
+		ChunkID:    ctx.Query("chunk_id"),
+		Limit:      limit,
+		Offset:     offset,
+	}
+	loc := tz.FromContext(ctx.Request.Context())
+	if start := ctx.Query("start_time"); start != "" {
+		t, err := tz.Parse(start, loc)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "start_time must be RFC 3339 or a local time"})
+			return filter, false
+		}
+		filter.StartTime = t
+	}
+	if end := ctx.Query("end_time"); end != "" {
+		t, err := tz.Parse(end, loc)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "end_time must be RFC 3339 or a local time"})
+			return filter, false
+		}
+		filter.EndTime = t
+	}
+	return filter, true
+}
+
+// ListQueryLogs browses past queries with what retrieval found for each.
+func (h *Handler) ListQueryLogs(ctx *gin.Context) {
+	filter, ok := parseQueryLogFilter(ctx)
+	if !ok {
+		return
+	}
+
+	userCtx := getUserContext(ctx)
+	entries, total, err := h.svc.ListQueryLogs(ctx.Request.Context(), userCtx, filter)
+	if err != nil {
+		if errors.Is(err, docApp.ErrInvalidQuery) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, docApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to list query logs", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list queries"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"queries": entries,
+		"total":   total,
+		"limit":   filter.Limit,
+		"offset":  filter.Offset,
+	})
+}
+
+func (h *Handler) GetQueryLog(ctx *gin.Context) {
+	id := ctx.Param("id")
+	userCtx := getUserContext(ctx)
+
+	entry, err := h.svc.GetQueryLog(ctx.Request.Context(), userCtx, id)
+	if err != nil {
+		if errors.Is(err, docApp.ErrQueryLogNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "query not found"})
+			return
+		}
+		if errors.Is(err, docApp.ErrForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to get query log", "error", err, "id", id)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get query"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, entry)
+}
+
+// Replay answers a logged query again and reports what changed.
+func (h *Handler) Replay(ctx *gin.Context) {
+	id := ctx.Param("query_id")
+	userCtx := getUserContext(ctx)
+
+	replay, err := h.svc.ReplayQuery(ctx.Request.Context(), userCtx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, docApp.ErrQueryLogNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "query not found"})
+		case errors.Is(err, docApp.ErrForbidden):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		case errors.Is(err, docApp.ErrInvalidQuery):
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errors.Is(err, docApp.ErrReplayUnavailable):
+			ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "RAG service is not configured"})
+		default:
+			h.log.ErrorContext(ctx.Request.Context(), "failed to replay query", "error", err, "query_id", id)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to replay query"})
+		}
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "rag_query_replay", "admin_id", userCtx.UserID, "query_id", id,
+		"answer_changed", replay.AnswerChanged, "added", len(replay.Added), "removed", len(replay.Removed))
+	ctx.JSON(http.StatusOK, replay)
+}
@@ -5,3 +5,37 @@ import "github.com/gin-gonic/gin"
 func Register(rg *gin.RouterGroup, handler *Handler) {
 	rg.POST("/query", handler.Query)
 }
+
+func RegisterRules(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("", handler.ListRules)
+	rg.POST("", handler.CreateRule)
+	rg.DELETE("/:id", handler.DeleteRule)
+}
+
+func RegisterShortcuts(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("", handler.ListShortcuts)
+	rg.POST("", handler.CreateShortcut)
+	rg.PUT("/:id", handler.UpdateShortcut)
+	rg.DELETE("/:id", handler.DeleteShortcut)
+}
+
+func RegisterGlossary(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("", handler.ListGlossary)
+	rg.POST("", handler.CreateGlossaryEntry)
+	rg.PUT("/:id", handler.UpdateGlossaryEntry)
+	rg.DELETE("/:id", handler.DeleteGlossaryEntry)
+}
+
+func RegisterFormats(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("", handler.ListFormats)
+	rg.PUT("/:channel", handler.SaveFormat)
+}
+
+func RegisterQueryLog(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("", handler.ListQueryLogs)
+	rg.GET("/:id", handler.GetQueryLog)
+}
+
+func RegisterReplay(rg *gin.RouterGroup, handler *Handler) {
+	rg.POST("/:query_id", handler.Replay)
+}
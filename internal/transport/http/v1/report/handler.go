@@ -0,0 +1,211 @@
+package report
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	reportApp "github.com/elprogramadorgt/lucidRAG/internal/application/report"
+	reportDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/report"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/elprogramadorgt/lucidRAG/pkg/tz"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	svc reportDomain.Service
+	log *logger.Logger
+}
+
+func NewHandler(svc reportDomain.Service, log *logger.Logger) *Handler {
+	return &Handler{
+		svc: svc,
+		log: log.With("handler", "report"),
+	}
+}
+
+func (h *Handler) writeError(ctx *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, reportApp.ErrScheduleNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "report schedule not found"})
+	case errors.Is(err, reportApp.ErrInvalidSchedule), errors.Is(err, reportApp.ErrInvalidReport):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, reportApp.ErrMailUnavailable):
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	case errors.Is(err, reportApp.ErrSendFailed):
+		h.log.ErrorContext(ctx.Request.Context(), "failed to "+action, "error", err)
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": "failed to send report"})
+	default:
+		h.log.ErrorContext(ctx.Request.Context(), "failed to "+action, "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to " + action})
+	}
+}
+
+// Export builds a report of the comma-separated metrics for start_time to
+// end_time, as JSON or, for a single metric, as CSV.
+func (h *Handler) Export(ctx *gin.Context) {
+	var from, to time.Time
+	loc := tz.FromContext(ctx.Request.Context())
+	if start := ctx.Query("start_time"); start != "" {
+		t, err := tz.Parse(start, loc)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "start_time must be RFC 3339 or a local time"})
+			return
+		}
+		from = t
+	}
+	if end := ctx.Query("end_time"); end != "" {
+		t, err := tz.Parse(end, loc)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "end_time must be RFC 3339 or a local time"})
+			return
+		}
+		to = t
+	}
+
+	var metrics []reportDomain.Metric
+	for _, m := range strings.Split(ctx.Query("metrics"), ",") {
+		if m = strings.TrimSpace(m); m != "" {
+			metrics = append(metrics, reportDomain.Metric(m))
+		}
+	}
+	format := ctx.DefaultQuery("format", "json")
+	switch {
+	case format != "json" && format != string(reportDomain.FormatCSV):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+		return
+	case format == string(reportDomain.FormatCSV) && len(metrics) > 1:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "csv export takes a single metric"})
+		return
+	}
+
+	report, err := h.svc.Generate(ctx.Request.Context(), metrics, from, to)
+	if err != nil {
+		h.writeError(ctx, err, "build report")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "report_export", "admin_id", ctx.GetString("user_id"), "metrics", metrics, "format", format)
+	if format == string(reportDomain.FormatCSV) {
+		table := report.Tables[0]
+		writeCSV(ctx, string(table.Metric)+"-"+report.To.Format(time.DateOnly)+".csv", table)
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
+
+// writeCSV answers with table as a CSV file download named filename.
+func writeCSV(ctx *gin.Context, filename string, table reportDomain.Table) {
+	ctx.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	ctx.Data(http.StatusOK, "text/csv; charset=utf-8", reportApp.CSV(table))
+}
+
+type scheduleRequest struct {
+	Name       string                `json:"name" binding:"required"`
+	Metrics    []reportDomain.Metric `json:"metrics" binding:"required"`
+	Recipients []string              `json:"recipients" binding:"required"`
+	Cadence    reportDomain.Cadence  `json:"cadence" binding:"required"`
+	Format     reportDomain.Format   `json:"format"`
+	Hour       int                   `json:"hour"`
+	Day        int                   `json:"day"`
+	Timezone   string                `json:"timezone"`
+}
+
+func (r scheduleRequest) schedule() *reportDomain.Schedule {
+	return &reportDomain.Schedule{
+		Name:       r.Name,
+		Metrics:    r.Metrics,
+		Recipients: r.Recipients,
+		Cadence:    r.Cadence,
+		Format:     r.Format,
+		Hour:       r.Hour,
+		Day:        r.Day,
+		Timezone:   r.Timezone,
+	}
+}
+
+func (h *Handler) ListSchedules(ctx *gin.Context) {
+	schedules, err := h.svc.ListSchedules(ctx.Request.Context())
+	if err != nil {
+		h.writeError(ctx, err, "list report schedules")
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"schedules": schedules, "total": len(schedules)})
+}
+
+func (h *Handler) GetSchedule(ctx *gin.Context) {
+	schedule, err := h.svc.GetSchedule(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		h.writeError(ctx, err, "get report schedule")
+		return
+	}
+	ctx.JSON(http.StatusOK, schedule)
+}
+
+func (h *Handler) CreateSchedule(ctx *gin.Context) {
+	var req scheduleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	adminID := ctx.GetString("user_id")
+	schedule := req.schedule()
+	schedule.CreatedBy = adminID
+	id, err := h.svc.CreateSchedule(ctx.Request.Context(), schedule)
+	if err != nil {
+		h.writeError(ctx, err, "create report schedule")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "report_schedule_create", "admin_id", adminID, "schedule_id", id)
+	ctx.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "report schedule created successfully",
+	})
+}
+
+func (h *Handler) UpdateSchedule(ctx *gin.Context) {
+	var req scheduleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	id := ctx.Param("id")
+	schedule := req.schedule()
+	schedule.ID = id
+	if err := h.svc.UpdateSchedule(ctx.Request.Context(), schedule); err != nil {
+		h.writeError(ctx, err, "update report schedule")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "report_schedule_update", "admin_id", ctx.GetString("user_id"), "schedule_id", id)
+	ctx.JSON(http.StatusOK, schedule)
+}
+
+func (h *Handler) DeleteSchedule(ctx *gin.Context) {
+	id := ctx.Param("id")
+	if err := h.svc.DeleteSchedule(ctx.Request.Context(), id); err != nil {
+		h.writeError(ctx, err, "delete report schedule")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "report_schedule_delete", "admin_id", ctx.GetString("user_id"), "schedule_id", id)
+	ctx.JSON(http.StatusOK, gin.H{"message": "report schedule deleted successfully"})
+}
+
+// Send emails a schedule's report now instead of waiting for its cadence.
+func (h *Handler) Send(ctx *gin.Context) {
+	id := ctx.Param("id")
+	delivery, err := h.svc.Send(ctx.Request.Context(), id)
+	if err != nil {
+		h.writeError(ctx, err, "send report")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "report_send", "admin_id", ctx.GetString("user_id"), "schedule_id", id, "recipients", len(delivery.Recipients))
+	ctx.JSON(http.StatusOK, delivery)
+}
@@ -0,0 +1,204 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	reportApp "github.com/elprogramadorgt/lucidRAG/internal/application/report"
+	reportDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/report"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// mockService implements reportDomain.Service for testing
+type mockService struct {
+	created *reportDomain.Schedule
+	metrics []reportDomain.Metric
+	sendErr error
+}
+
+func (m *mockService) ListSchedules(ctx context.Context) ([]reportDomain.Schedule, error) {
+	return []reportDomain.Schedule{{ID: "s1", Name: "Weekly ops"}}, nil
+}
+
+func (m *mockService) GetSchedule(ctx context.Context, id string) (*reportDomain.Schedule, error) {
+	if id != "s1" {
+		return nil, reportApp.ErrScheduleNotFound
+	}
+	return &reportDomain.Schedule{ID: id, Name: "Weekly ops"}, nil
+}
+
+func (m *mockService) CreateSchedule(ctx context.Context, schedule *reportDomain.Schedule) (string, error) {
+	if schedule.Cadence == "hourly" {
+		return "", fmt.Errorf("%w: cadence must be daily, weekly or monthly", reportApp.ErrInvalidSchedule)
+	}
+	m.created = schedule
+	return "s2", nil
+}
+
+func (m *mockService) UpdateSchedule(ctx context.Context, schedule *reportDomain.Schedule) error {
+	_, err := m.GetSchedule(ctx, schedule.ID)
+	return err
+}
+
+func (m *mockService) DeleteSchedule(ctx context.Context, id string) error {
+	_, err := m.GetSchedule(ctx, id)
+	return err
+}
+
+func (m *mockService) Send(ctx context.Context, id string) (*reportDomain.Delivery, error) {
+	if m.sendErr != nil {
+		return nil, m.sendErr
+	}
+	return &reportDomain.Delivery{ScheduleID: id, Recipients: []string{"ops@example.com"}}, nil
+}
+
+func (m *mockService) Generate(ctx context.Context, metrics []reportDomain.Metric, from, to time.Time) (*reportDomain.Report, error) {
+	m.metrics = metrics
+	if len(metrics) == 0 {
+		return nil, fmt.Errorf("%w: at least one metric is required", reportApp.ErrInvalidReport)
+	}
+	report := &reportDomain.Report{To: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)}
+	for _, metric := range metrics {
+		report.Tables = append(report.Tables, reportDomain.Table{Metric: metric, Header: []string{"total"}, Rows: [][]string{{"200"}}})
+	}
+	return report, nil
+}
+
+func (m *mockService) SendDue(ctx context.Context) error {
+	return nil
+}
+
+func setupTestRouter(svc reportDomain.Service) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "admin-1")
+		c.Set("user_role", "admin")
+	})
+	Register(r.Group("/reports"), NewHandler(svc, logger.New(logger.Options{Level: "error"})))
+	return r
+}
+
+func TestExport(t *testing.T) {
+	svc := &mockService{}
+	router := setupTestRouter(svc)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/reports?metrics=queries,+sla", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if len(svc.metrics) != 2 || svc.metrics[1] != reportDomain.MetricSLA {
+		t.Errorf("Expected both metrics passed on, got %v", svc.metrics)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/reports?metrics=queries&format=csv", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "total\n200\n" {
+		t.Errorf("Expected the CSV file, got %d %q", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename=queries-2026-03-02.csv` {
+		t.Errorf("Expected a download named for the metric, got %q", got)
+	}
+
+	for _, path := range []string{
+		"/reports?metrics=queries,sla&format=csv",
+		"/reports?metrics=queries&format=xlsx",
+		"/reports?metrics=queries&start_time=yesterday",
+		"/reports",
+	} {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", path, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+func TestCreateSchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{"created", `{"name":"Weekly ops","metrics":["sla"],"recipients":["ops@example.com"],"cadence":"weekly","day":1,"hour":8}`, http.StatusCreated},
+		{"invalid", `{"name":"Weekly ops","metrics":["sla"],"recipients":["ops@example.com"],"cadence":"hourly"}`, http.StatusBadRequest},
+		{"missing fields", `{"name":"Weekly ops"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &mockService{}
+			router := setupTestRouter(svc)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/reports/schedules", bytes.NewBufferString(tt.body)))
+			if w.Code != tt.wantCode {
+				t.Fatalf("Expected status %d, got %d", tt.wantCode, w.Code)
+			}
+			if tt.wantCode == http.StatusCreated && (svc.created.CreatedBy != "admin-1" || svc.created.Day != 1) {
+				t.Errorf("Expected the schedule created by the admin, got %+v", svc.created)
+			}
+		})
+	}
+}
+
+func TestScheduleNotFound(t *testing.T) {
+	router := setupTestRouter(&mockService{})
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/reports/schedules/missing", nil),
+		httptest.NewRequest(http.MethodPut, "/reports/schedules/missing", bytes.NewBufferString(`{"name":"x","metrics":["sla"],"recipients":["a@example.com"],"cadence":"daily"}`)),
+		httptest.NewRequest(http.MethodDelete, "/reports/schedules/missing", nil),
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status %d, got %d", req.Method, http.StatusNotFound, w.Code)
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{"sent", nil, http.StatusOK},
+		{"not found", reportApp.ErrScheduleNotFound, http.StatusNotFound},
+		{"no mailer", reportApp.ErrMailUnavailable, http.StatusServiceUnavailable},
+		{"send failed", fmt.Errorf("%w: %w", reportApp.ErrSendFailed, errors.New("mailbox unavailable")), http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := setupTestRouter(&mockService{sendErr: tt.err})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/reports/schedules/s1/send", nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("Expected status %d, got %d", tt.wantCode, w.Code)
+			}
+			if tt.err != nil {
+				return
+			}
+			var delivery reportDomain.Delivery
+			if err := json.Unmarshal(w.Body.Bytes(), &delivery); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if delivery.ScheduleID != "s1" || !strings.Contains(strings.Join(delivery.Recipients, ","), "ops@example.com") {
+				t.Errorf("Expected the delivery, got %+v", delivery)
+			}
+		})
+	}
+}
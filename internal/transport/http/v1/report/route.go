@@ -0,0 +1,13 @@
+package report
+
+import "github.com/gin-gonic/gin"
+
+func Register(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("", handler.Export)
+	rg.GET("/schedules", handler.ListSchedules)
+	rg.POST("/schedules", handler.CreateSchedule)
+	rg.GET("/schedules/:id", handler.GetSchedule)
+	rg.PUT("/schedules/:id", handler.UpdateSchedule)
+	rg.DELETE("/schedules/:id", handler.DeleteSchedule)
+	rg.POST("/schedules/:id/send", handler.Send)
+}
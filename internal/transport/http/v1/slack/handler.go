@@ -0,0 +1,165 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	slackDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/slack"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/elprogramadorgt/lucidRAG/pkg/slack"
+	"github.com/gin-gonic/gin"
+)
+
+// maxBodyBytes bounds request bodies, which are read whole to verify their
+// signature.
+const maxBodyBytes = 1 << 20
+
+// answerTimeout bounds answering one question after Slack has been
+// acknowledged; Slack expects the acknowledgement within 3 seconds.
+const answerTimeout = 2 * time.Minute
+
+type Handler struct {
+	svc           slackDomain.Service
+	signingSecret string
+	log           *logger.Logger
+}
+
+func NewHandler(svc slackDomain.Service, signingSecret string, log *logger.Logger) *Handler {
+	return &Handler{
+		svc:           svc,
+		signingSecret: signingSecret,
+		log:           log.With("handler", "slack"),
+	}
+}
+
+type eventEnvelope struct {
+	Type      string      `json:"type"`
+	Challenge string      `json:"challenge"`
+	TeamID    string      `json:"team_id"`
+	Event     *slackEvent `json:"event"`
+}
+
+type slackEvent struct {
+	Type        string `json:"type"`
+	ChannelType string `json:"channel_type"`
+	Channel     string `json:"channel"`
+	User        string `json:"user"`
+	Text        string `json:"text"`
+	TS          string `json:"ts"`
+	ThreadTS    string `json:"thread_ts"`
+	BotID       string `json:"bot_id"`
+	Subtype     string `json:"subtype"`
+}
+
+// HandleEvent receives Events API callbacks. App mentions and direct
+// messages are answered in their thread.
+func (h *Handler) HandleEvent(ctx *gin.Context) {
+	body, ok := h.verifiedBody(ctx)
+	if !ok {
+		return
+	}
+
+	var envelope eventEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid event payload"})
+		return
+	}
+
+	if envelope.Type == "url_verification" {
+		ctx.JSON(http.StatusOK, gin.H{"challenge": envelope.Challenge})
+		return
+	}
+
+	// Slack retries events it did not see acknowledged in time; the first
+	// delivery is already being answered.
+	if ctx.GetHeader("X-Slack-Retry-Num") != "" {
+		ctx.Status(http.StatusOK)
+		return
+	}
+
+	if envelope.Type == "event_callback" && envelope.Event != nil && answerable(envelope.Event) {
+		e := envelope.Event
+		msg := slackDomain.Message{
+			TeamID:   envelope.TeamID,
+			Channel:  e.Channel,
+			UserID:   e.User,
+			Text:     e.Text,
+			TS:       e.TS,
+			ThreadTS: e.ThreadTS,
+			BotID:    e.BotID,
+			Subtype:  e.Subtype,
+		}
+		h.async(ctx, "failed to handle slack message", func(ctx context.Context) error {
+			return h.svc.HandleMessage(ctx, msg)
+		})
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+// HandleCommand receives slash commands. The answer is sent to the
+// command's response URL, so Slack gets an immediate acknowledgement.
+func (h *Handler) HandleCommand(ctx *gin.Context) {
+	body, ok := h.verifiedBody(ctx)
+	if !ok {
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid command payload"})
+		return
+	}
+
+	cmd := slackDomain.Command{
+		TeamID:      form.Get("team_id"),
+		UserID:      form.Get("user_id"),
+		Text:        form.Get("text"),
+		ResponseURL: form.Get("response_url"),
+	}
+	h.async(ctx, "failed to answer slack command", func(ctx context.Context) error {
+		return h.svc.Ask(ctx, cmd)
+	})
+
+	ctx.JSON(http.StatusOK, gin.H{"response_type": "ephemeral", "text": "Looking that up…"})
+}
+
+// verifiedBody reads the request body and checks its Slack signature,
+// responding with an error when it is missing or invalid.
+func (h *Handler) verifiedBody(ctx *gin.Context) ([]byte, bool) {
+	body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxBodyBytes))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+		return nil, false
+	}
+
+	if err := slack.Verify(h.signingSecret, ctx.Request.Header, body, time.Now()); err != nil {
+		h.log.WarnContext(ctx.Request.Context(), "rejected slack request", "error", err)
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+		return nil, false
+	}
+	return body, true
+}
+
+// async runs fn after the request is acknowledged, keeping the request's
+// values but not its cancellation.
+func (h *Handler) async(ctx *gin.Context, failure string, fn func(ctx context.Context) error) {
+	base := context.WithoutCancel(ctx.Request.Context())
+	go func() {
+		ctx, cancel := context.WithTimeout(base, answerTimeout)
+		defer cancel()
+		if err := fn(ctx); err != nil {
+			h.log.ErrorContext(ctx, failure, "error", err)
+		}
+	}()
+}
+
+// answerable reports whether e is a question for the app: a mention in a
+// channel or a direct message.
+func answerable(e *slackEvent) bool {
+	return e.Type == "app_mention" || (e.Type == "message" && e.ChannelType == "im")
+}
@@ -0,0 +1,129 @@
+package slack
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	slackDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/slack"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/elprogramadorgt/lucidRAG/pkg/slack"
+	"github.com/gin-gonic/gin"
+)
+
+const testSecret = "signing-secret"
+
+// mockService implements slackDomain.Service for testing
+type mockService struct {
+	messages chan slackDomain.Message
+	commands chan slackDomain.Command
+}
+
+func (m *mockService) HandleMessage(ctx context.Context, msg slackDomain.Message) error {
+	m.messages <- msg
+	return nil
+}
+
+func (m *mockService) Ask(ctx context.Context, cmd slackDomain.Command) error {
+	m.commands <- cmd
+	return nil
+}
+
+func setupTestRouter() (*gin.Engine, *mockService) {
+	gin.SetMode(gin.TestMode)
+	svc := &mockService{messages: make(chan slackDomain.Message, 1), commands: make(chan slackDomain.Command, 1)}
+	r := gin.New()
+	Register(r.Group(""), NewHandler(svc, testSecret, logger.New(logger.Options{Level: "error"})))
+	return r, svc
+}
+
+func signedRequest(path, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	for k, v := range slack.Sign(testSecret, []byte(body), time.Now()) {
+		req.Header[k] = v
+	}
+	return req
+}
+
+func TestHandleEventRejectsBadSignature(t *testing.T) {
+	router, _ := setupTestRouter()
+
+	req := httptest.NewRequest(http.MethodPost, "/slack/events", strings.NewReader(`{"type":"url_verification","challenge":"abc"}`))
+	req.Header.Set("X-Slack-Request-Timestamp", "1")
+	req.Header.Set("X-Slack-Signature", "v0=bad")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestHandleEventURLVerification(t *testing.T) {
+	router, _ := setupTestRouter()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, signedRequest("/slack/events", `{"type":"url_verification","challenge":"abc"}`))
+
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"challenge":"abc"`) {
+		t.Errorf("Expected challenge echoed, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandleEventAppMention(t *testing.T) {
+	router, svc := setupTestRouter()
+
+	body := `{"type":"event_callback","team_id":"T1","event":{"type":"app_mention","channel":"C1","user":"U1","text":"<@UBOT> vpn?","ts":"1.2","thread_ts":"1.1"}}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, signedRequest("/slack/events", body))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	select {
+	case msg := <-svc.messages:
+		if msg.TeamID != "T1" || msg.Channel != "C1" || msg.ThreadTS != "1.1" || msg.Text != "<@UBOT> vpn?" {
+			t.Errorf("Unexpected message %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected message to be handled")
+	}
+}
+
+func TestHandleEventIgnoresChannelMessages(t *testing.T) {
+	router, svc := setupTestRouter()
+
+	body := `{"type":"event_callback","team_id":"T1","event":{"type":"message","channel_type":"channel","channel":"C1","user":"U1","text":"lunch?","ts":"1.2"}}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, signedRequest("/slack/events", body))
+
+	select {
+	case msg := <-svc.messages:
+		t.Errorf("Expected channel message to be ignored, got %+v", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestHandleCommand(t *testing.T) {
+	router, svc := setupTestRouter()
+
+	form := url.Values{"team_id": {"T1"}, "user_id": {"U1"}, "text": {"vpn?"}, "response_url": {"https://hooks.slack.com/x"}}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, signedRequest("/slack/commands", form.Encode()))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	select {
+	case cmd := <-svc.commands:
+		if cmd.Text != "vpn?" || cmd.ResponseURL != "https://hooks.slack.com/x" {
+			t.Errorf("Unexpected command %+v", cmd)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected command to be answered")
+	}
+}
@@ -0,0 +1,15 @@
+package slack
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Register mounts the Slack endpoints. They authenticate with the app's
+// signing secret instead of a user session.
+func Register(rg *gin.RouterGroup, handler *Handler) {
+	slack := rg.Group("/slack")
+	{
+		slack.POST("/events", handler.HandleEvent)
+		slack.POST("/commands", handler.HandleCommand)
+	}
+}
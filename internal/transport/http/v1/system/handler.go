@@ -2,13 +2,19 @@ package system
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"runtime"
 	"strconv"
+	"strings"
 	"time"
 
+	systemApp "github.com/elprogramadorgt/lucidRAG/internal/application/system"
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/cluster"
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/scheduler"
 	"github.com/elprogramadorgt/lucidRAG/internal/domain/system"
 	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/elprogramadorgt/lucidRAG/pkg/tz"
 	"github.com/gin-gonic/gin"
 )
 
@@ -17,9 +23,47 @@ type DBPinger interface {
 	Ping(ctx context.Context) error
 }
 
+// ProviderStatuses reports the latest probe of each model provider.
+type ProviderStatuses interface {
+	Statuses() []system.ProviderStatus
+}
+
+// MaintenanceSwitch reads and flips maintenance mode.
+type MaintenanceSwitch interface {
+	Status() system.Maintenance
+	Set(ctx context.Context, enabled bool, message, by string) (*system.Maintenance, error)
+}
+
+// RouteStats reports per-route request stats for a window.
+type RouteStats interface {
+	Snapshot(window string, now time.Time) []system.RouteStat
+}
+
+// RateLimits inspects and resets clients' API-wide rate-limit counters.
+type RateLimits interface {
+	Usage(ctx context.Context, client system.RateLimitClient) (*system.RateLimitUsage, error)
+	Reset(ctx context.Context, client system.RateLimitClient) error
+}
+
+// AbuseGuard reads the security events feed and lifts IP blocks.
+type AbuseGuard interface {
+	Events(ctx context.Context, filter system.SecurityEventFilter) ([]system.SecurityEvent, error)
+	Unblock(ctx context.Context, ip string) error
+}
+
 type HandlerConfig struct {
 	Repo        system.LogRepository
+	Overview    system.OverviewRepository
+	Metrics     system.MetricsRepository
+	Routes      RouteStats
+	Indexes     system.IndexRepository
 	DB          DBPinger
+	Jobs        scheduler.Service
+	Cluster     cluster.Service
+	Providers   ProviderStatuses
+	Maintenance MaintenanceSwitch
+	RateLimits  RateLimits
+	Abuse       AbuseGuard
 	Log         *logger.Logger
 	StartTime   time.Time
 	Environment string
@@ -28,7 +72,17 @@ type HandlerConfig struct {
 
 type Handler struct {
 	repo        system.LogRepository
+	overview    system.OverviewRepository
+	metrics     system.MetricsRepository
+	routes      RouteStats
+	indexes     system.IndexRepository
 	db          DBPinger
+	jobs        scheduler.Service
+	cluster     cluster.Service
+	providers   ProviderStatuses
+	maintenance MaintenanceSwitch
+	rateLimits  RateLimits
+	abuse       AbuseGuard
 	log         *logger.Logger
 	startTime   time.Time
 	environment string
@@ -42,7 +96,17 @@ func NewHandler(cfg HandlerConfig) *Handler {
 	}
 	return &Handler{
 		repo:        cfg.Repo,
+		overview:    cfg.Overview,
+		metrics:     cfg.Metrics,
+		routes:      cfg.Routes,
+		indexes:     cfg.Indexes,
 		db:          cfg.DB,
+		jobs:        cfg.Jobs,
+		cluster:     cfg.Cluster,
+		providers:   cfg.Providers,
+		maintenance: cfg.Maintenance,
+		rateLimits:  cfg.RateLimits,
+		abuse:       cfg.Abuse,
 		log:         cfg.Log.With("handler", "system"),
 		startTime:   cfg.StartTime,
 		environment: cfg.Environment,
@@ -65,25 +129,26 @@ func (h *Handler) ListLogs(ctx *gin.Context) {
 	if offset, _ := strconv.Atoi(ctx.DefaultQuery("offset", "0")); offset > 0 {
 		filter.Offset = offset
 	}
+	loc := tz.FromContext(ctx.Request.Context())
 	if start := ctx.Query("start_time"); start != "" {
-		if t, err := time.Parse(time.RFC3339, start); err == nil {
+		if t, err := tz.Parse(start, loc); err == nil {
 			filter.StartTime = t
 		}
 	}
 	if end := ctx.Query("end_time"); end != "" {
-		if t, err := time.Parse(time.RFC3339, end); err == nil {
+		if t, err := tz.Parse(end, loc); err == nil {
 			filter.EndTime = t
 		}
 	}
 
 	logs, total, err := h.repo.List(ctx.Request.Context(), filter)
 	if err != nil {
-		h.log.Error("failed to list logs", "error", err)
+		h.log.ErrorContext(ctx.Request.Context(), "failed to list logs", "error", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list logs"})
 		return
 	}
 
-	h.log.Info("admin_activity", "action", "logs_view", "admin_id", adminID, "filter_level", filter.Level, "result_count", len(logs))
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "logs_view", "admin_id", adminID, "filter_level", filter.Level, "result_count", len(logs))
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"logs":   logs,
@@ -97,12 +162,12 @@ func (h *Handler) GetStats(ctx *gin.Context) {
 	adminID := ctx.GetString("user_id")
 	stats, err := h.repo.Stats(ctx.Request.Context())
 	if err != nil {
-		h.log.Error("failed to get log stats", "error", err)
+		h.log.ErrorContext(ctx.Request.Context(), "failed to get log stats", "error", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get stats"})
 		return
 	}
 
-	h.log.Info("admin_activity", "action", "logs_stats", "admin_id", adminID)
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "logs_stats", "admin_id", adminID)
 	ctx.JSON(http.StatusOK, stats)
 }
 
@@ -115,31 +180,295 @@ func (h *Handler) CleanupLogs(ctx *gin.Context) {
 
 	deleted, err := h.repo.DeleteOlderThan(ctx.Request.Context(), days)
 	if err != nil {
-		h.log.Error("failed to cleanup logs", "error", err)
+		h.log.ErrorContext(ctx.Request.Context(), "failed to cleanup logs", "error", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cleanup logs"})
 		return
 	}
 
-	h.log.Info("admin_activity", "action", "logs_cleanup", "admin_id", adminID, "deleted_count", deleted, "days", days)
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "logs_cleanup", "admin_id", adminID, "deleted_count", deleted, "days", days)
 	ctx.JSON(http.StatusOK, gin.H{"deleted": deleted, "days": days})
 }
 
+func (h *Handler) ListJobs(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+	if h.jobs == nil {
+		ctx.JSON(http.StatusOK, gin.H{"jobs": []scheduler.JobStatus{}})
+		return
+	}
+
+	jobs, err := h.jobs.Jobs(ctx.Request.Context())
+	if err != nil {
+		h.log.ErrorContext(ctx.Request.Context(), "failed to list jobs", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list jobs"})
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "jobs_view", "admin_id", adminID)
+	ctx.JSON(http.StatusOK, gin.H{"jobs": jobs})
+}
+
+func (h *Handler) GetMaintenance(ctx *gin.Context) {
+	if h.maintenance == nil {
+		ctx.JSON(http.StatusOK, system.Maintenance{})
+		return
+	}
+	ctx.JSON(http.StatusOK, h.maintenance.Status())
+}
+
+type setMaintenanceRequest struct {
+	Enabled *bool  `json:"enabled" binding:"required"`
+	Message string `json:"message"`
+}
+
+func (h *Handler) SetMaintenance(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+	if h.maintenance == nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "maintenance mode not available"})
+		return
+	}
+
+	var req setMaintenanceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "enabled is required"})
+		return
+	}
+
+	maintenance, err := h.maintenance.Set(ctx.Request.Context(), *req.Enabled, req.Message, adminID)
+	if err != nil {
+		h.log.ErrorContext(ctx.Request.Context(), "failed to set maintenance mode", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set maintenance mode"})
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "maintenance_set", "admin_id", adminID, "enabled", maintenance.Enabled)
+	ctx.JSON(http.StatusOK, maintenance)
+}
+
+// rateLimitClient reads the client a rate-limit call is about: one of the
+// ip and user_id query parameters.
+func rateLimitClient(ctx *gin.Context) (system.RateLimitClient, bool) {
+	client := system.RateLimitClient{IP: strings.TrimSpace(ctx.Query("ip")), UserID: strings.TrimSpace(ctx.Query("user_id"))}
+	if (client.IP == "") == (client.UserID == "") {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "one of ip or user_id is required"})
+		return client, false
+	}
+	return client, true
+}
+
+func (h *Handler) GetRateLimit(ctx *gin.Context) {
+	if h.rateLimits == nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "rate limits not available"})
+		return
+	}
+	client, ok := rateLimitClient(ctx)
+	if !ok {
+		return
+	}
+
+	usage, err := h.rateLimits.Usage(ctx.Request.Context(), client)
+	if err != nil {
+		h.log.ErrorContext(ctx.Request.Context(), "failed to get rate limit usage", "error", err, "client", client.Key())
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get rate limit usage"})
+		return
+	}
+	ctx.JSON(http.StatusOK, usage)
+}
+
+func (h *Handler) ResetRateLimit(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+	if h.rateLimits == nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "rate limits not available"})
+		return
+	}
+	client, ok := rateLimitClient(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.rateLimits.Reset(ctx.Request.Context(), client); err != nil {
+		h.log.ErrorContext(ctx.Request.Context(), "failed to reset rate limit", "error", err, "client", client.Key())
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset rate limit"})
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "rate_limit_reset", "admin_id", adminID, "client", client.Key())
+	ctx.JSON(http.StatusOK, gin.H{"message": "rate limit reset"})
+}
+
+func (h *Handler) ListSecurityEvents(ctx *gin.Context) {
+	if h.abuse == nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "abuse detection not available"})
+		return
+	}
+	filter := system.SecurityEventFilter{
+		Type: system.SecurityEventType(ctx.Query("type")),
+		IP:   strings.TrimSpace(ctx.Query("ip")),
+	}
+	filter.Limit, _ = strconv.Atoi(ctx.Query("limit"))
+
+	events, err := h.abuse.Events(ctx.Request.Context(), filter)
+	if err != nil {
+		h.log.ErrorContext(ctx.Request.Context(), "failed to list security events", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list security events"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"events": events})
+}
+
+func (h *Handler) UnblockIP(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+	if h.abuse == nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "abuse detection not available"})
+		return
+	}
+	ip := strings.TrimSpace(ctx.Query("ip"))
+	if ip == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ip is required"})
+		return
+	}
+
+	if err := h.abuse.Unblock(ctx.Request.Context(), ip); err != nil {
+		if errors.Is(err, systemApp.ErrInvalidIP) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to unblock IP", "error", err, "ip", ip)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unblock IP"})
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "ip_unblock", "admin_id", adminID, "ip", ip)
+	ctx.JSON(http.StatusOK, gin.H{"message": "IP unblocked"})
+}
+
+func (h *Handler) GetOverview(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+	if h.overview == nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "overview not available"})
+		return
+	}
+
+	overview, err := h.overview.Overview(ctx.Request.Context(), time.Now().In(tz.FromContext(ctx.Request.Context())))
+	if err != nil {
+		h.log.ErrorContext(ctx.Request.Context(), "failed to get overview", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get overview"})
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "overview_view", "admin_id", adminID)
+	ctx.JSON(http.StatusOK, overview)
+}
+
+// maxHistoryPoints bounds how many points per instance a metrics history
+// returns; longer windows are averaged over wider steps.
+const maxHistoryPoints = 288
+
+// GetMetricsHistory returns the metric samples of the window, such as 24h
+// or 7d, averaged per instance over steps of at least a minute.
+func (h *Handler) GetMetricsHistory(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+	if h.metrics == nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "metrics history not available"})
+		return
+	}
+
+	window, err := parseWindow(ctx.DefaultQuery("window", "24h"))
+	if err != nil || window < time.Minute || window > system.MetricsRetention {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "window must be between 1m and 7d"})
+		return
+	}
+	step := max((window / maxHistoryPoints).Truncate(time.Minute), time.Minute)
+
+	since := time.Now().Add(-window)
+	samples, err := h.metrics.Since(ctx.Request.Context(), since)
+	if err != nil {
+		h.log.ErrorContext(ctx.Request.Context(), "failed to get metrics history", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get metrics history"})
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "metrics_history_view", "admin_id", adminID, "window", window.String())
+	ctx.JSON(http.StatusOK, gin.H{
+		"window":       window.String(),
+		"step_seconds": int64(step.Seconds()),
+		"since":        since,
+		"samples":      systemApp.Downsample(samples, step),
+	})
+}
+
+// GetRouteStats returns request counts, error rates and latency
+// percentiles per route for the last hour or day, as seen by this instance.
+func (h *Handler) GetRouteStats(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+	if h.routes == nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "route stats not available"})
+		return
+	}
+
+	window := ctx.DefaultQuery("window", systemApp.WindowHour)
+	if window != systemApp.WindowHour && window != systemApp.WindowDay {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "window must be 1h or 24h"})
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "route_stats_view", "admin_id", adminID, "window", window)
+	ctx.JSON(http.StatusOK, gin.H{
+		"window": window,
+		"routes": h.routes.Snapshot(window, time.Now()),
+	})
+}
+
+// GetIndexReport compares the indexes the repositories rely on with those
+// in the database, listing missing and unused ones.
+func (h *Handler) GetIndexReport(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+	if h.indexes == nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "index report not available"})
+		return
+	}
+
+	present, err := h.indexes.List(ctx.Request.Context())
+	if err != nil {
+		h.log.ErrorContext(ctx.Request.Context(), "failed to list indexes", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list indexes"})
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "index_report_view", "admin_id", adminID)
+	ctx.JSON(http.StatusOK, systemApp.AdviseIndexes(h.indexes.Declared(), present))
+}
+
+// parseWindow parses a Go duration, or a whole number of days such as "7d".
+func parseWindow(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
 type ServerInfo struct {
-	Status      string            `json:"status"`
-	Environment string            `json:"environment"`
-	Version     string            `json:"version"`
-	Uptime      string            `json:"uptime"`
-	UptimeSecs  int64             `json:"uptime_seconds"`
-	StartedAt   time.Time         `json:"started_at"`
-	Database    DatabaseStatus    `json:"database"`
-	Runtime     RuntimeInfo       `json:"runtime"`
-	Endpoints   []EndpointInfo    `json:"endpoints"`
+	Status      string                  `json:"status"`
+	Environment string                  `json:"environment"`
+	Version     string                  `json:"version"`
+	Uptime      string                  `json:"uptime"`
+	UptimeSecs  int64                   `json:"uptime_seconds"`
+	StartedAt   time.Time               `json:"started_at"`
+	Database    DatabaseStatus          `json:"database"`
+	Runtime     RuntimeInfo             `json:"runtime"`
+	Cluster     *cluster.LeaderStatus   `json:"cluster,omitempty"`
+	Providers   []system.ProviderStatus `json:"providers,omitempty"`
+	Maintenance *system.Maintenance     `json:"maintenance,omitempty"`
+	Endpoints   []EndpointInfo          `json:"endpoints"`
 }
 
 type DatabaseStatus struct {
-	Status     string `json:"status"`
-	Latency    string `json:"latency,omitempty"`
-	LatencyMs  int64  `json:"latency_ms,omitempty"`
+	Status    string `json:"status"`
+	Latency   string `json:"latency,omitempty"`
+	LatencyMs int64  `json:"latency_ms,omitempty"`
 }
 
 type RuntimeInfo struct {
@@ -186,19 +515,134 @@ func (h *Handler) GetServerInfo(ctx *gin.Context) {
 		MemSysMB:     int64(memStats.Sys / 1024 / 1024),
 	}
 
+	// Leader election, when running with more than one replica
+	var leader *cluster.LeaderStatus
+	if h.cluster != nil {
+		status, err := h.cluster.Leader(ctx.Request.Context())
+		if err != nil {
+			h.log.WarnContext(ctx.Request.Context(), "failed to get leader status", "error", err)
+		}
+		leader = status
+	}
+
 	// API endpoints info
 	endpoints := []EndpointInfo{
 		{Path: "/healthz", Method: "GET", Description: "Liveness probe"},
-		{Path: "/readyz", Method: "GET", Description: "Readiness probe (checks DB)"},
+		{Path: "/readyz", Method: "GET", Description: "Readiness probe (checks DB, reports circuit breakers)"},
 		{Path: "/api/v1/auth/register", Method: "POST", Description: "User registration"},
 		{Path: "/api/v1/auth/login", Method: "POST", Description: "User login"},
 		{Path: "/api/v1/auth/me", Method: "GET", Description: "Current user info"},
+		{Path: "/api/v1/auth/me/preferences", Method: "GET/PUT", Description: "User preferences and notification settings"},
+		{Path: "/api/v1/auth/me/password", Method: "PUT", Description: "Change password (revokes other sessions)"},
+		{Path: "/api/v1/users/me/sessions", Method: "GET", Description: "List the current user's active sessions"},
+		{Path: "/api/v1/users/me/sessions/:id", Method: "DELETE", Description: "Sign one of the current user's sessions out"},
+		{Path: "/api/v1/users/:id/status", Method: "PATCH", Description: "Activate or deactivate user (admin)"},
+		{Path: "/api/v1/admin/users/:id/data", Method: "DELETE", Description: "Purge a user's documents and/or conversations; previews and hands out a confirmation token without X-Confirm-Token (admin)"},
 		{Path: "/api/v1/documents", Method: "GET/POST/PUT/DELETE", Description: "Document CRUD"},
+		{Path: "/api/v1/documents/:id", Method: "PATCH", Description: "Partial document update"},
+		{Path: "/api/v1/documents/upload", Method: "POST", Description: "Create a document from a PDF, image, text, CSV or XLSX file (OCR for scans)"},
+		{Path: "/api/v1/documents/preview", Method: "POST", Description: "Render a Markdown draft, check it and show its chunks"},
+		{Path: "/api/v1/documents/:id/preview", Method: "GET", Description: "Preview a stored document's Markdown and chunks"},
+		{Path: "/api/v1/documents/:id/chunks", Method: "GET", Description: "Document chunk inspection"},
+		{Path: "/api/v1/documents/:id/similar", Method: "GET", Description: "Related documents by averaged chunk embeddings"},
+		{Path: "/api/v1/documents/:id/download", Method: "GET", Description: "Download a document's original file"},
+		{Path: "/api/v1/documents/:id/status", Method: "POST", Description: "Document approval workflow"},
+		{Path: "/api/v1/documents/pending-review", Method: "GET", Description: "Documents awaiting review"},
+		{Path: "/api/v1/documents/storage", Method: "GET", Description: "Own storage usage"},
+		{Path: "/api/v1/chunks/:id", Method: "DELETE", Description: "Delete chunk (admin)"},
+		{Path: "/api/v1/chunks/export", Method: "GET", Description: "Export chunks and embeddings as LangChain or LlamaIndex JSONL (admin)"},
+		{Path: "/api/v1/chunks/import", Method: "POST", Description: "Import a pre-embedded LangChain or LlamaIndex JSONL corpus (admin)"},
+		{Path: "/api/v1/chunks/gc", Method: "POST", Description: "Remove orphaned chunks and re-chunk documents without chunks; previews without X-Confirm-Token (admin)"},
 		{Path: "/api/v1/conversations", Method: "GET", Description: "Conversation list"},
+		{Path: "/api/v1/conversations/stream", Method: "GET", Description: "Live conversation events (SSE)"},
+		{Path: "/api/v1/conversations/stats", Method: "GET", Description: "Conversation counts by state"},
+		{Path: "/api/v1/conversations/presence", Method: "GET", Description: "List connected agents"},
+		{Path: "/api/v1/conversations/presence", Method: "PUT", Description: "Set agent online or away"},
+		{Path: "/api/v1/conversations/:id/canned-responses/:shortcut", Method: "GET", Description: "Render a canned response for a conversation"},
+		{Path: "/api/v1/canned-responses", Method: "GET/POST/PUT/DELETE", Description: "Canned responses for agents (admin)"},
+		{Path: "/api/v1/tags", Method: "GET/POST/PUT/DELETE", Description: "Conversation tags and their auto-tagging keywords (changes admin only)"},
+		{Path: "/api/v1/conversations/filters", Method: "GET/POST/PUT/DELETE", Description: "Own saved conversation filters"},
+		{Path: "/api/v1/conversations/:id/tags", Method: "PUT", Description: "Tag a conversation"},
+		{Path: "/api/v1/conversations/:id/read", Method: "POST", Description: "Mark conversation as read"},
+		{Path: "/api/v1/conversations/:id/notes", Method: "POST", Description: "Internal conversation notes"},
+		{Path: "/api/v1/conversations/:id/variables", Method: "PUT", Description: "Customer context for replies"},
+		{Path: "/api/v1/conversations/:id/state", Method: "PUT", Description: "Open, pend, resolve or close a conversation"},
+		{Path: "/api/v1/conversations/:id/mode", Method: "PUT", Description: "Hand a conversation off to an agent or back to the bot"},
+		{Path: "/api/v1/conversations/:id/assignee", Method: "PUT", Description: "Reassign a handed-off conversation (admin)"},
+		{Path: "/api/v1/conversations/:id/assignments", Method: "GET", Description: "Conversation assignment history"},
+		{Path: "/api/v1/assignment-rules", Method: "GET/POST/PUT/DELETE", Description: "Tag rules handoffs are routed by (admin)"},
+		{Path: "/api/v1/analytics/sla", Method: "GET", Description: "Per-agent SLA report (admin)"},
+		{Path: "/api/v1/analytics/topics", Method: "GET", Description: "Latest question topics (admin)"},
+		{Path: "/api/v1/analytics/topics", Method: "POST", Description: "Cluster recent questions into topics (admin)"},
+		{Path: "/api/v1/analytics/topics/snapshots", Method: "GET", Description: "List topic snapshots (admin)"},
+		{Path: "/api/v1/analytics/topics/snapshots/:id", Method: "GET", Description: "Get a topic snapshot (admin)"},
+		{Path: "/api/v1/reports", Method: "GET", Description: "Export analytics as JSON or CSV (admin)"},
+		{Path: "/api/v1/reports/schedules", Method: "GET/POST/PUT/DELETE", Description: "Scheduled report emails (admin)"},
+		{Path: "/api/v1/reports/schedules/:id/send", Method: "POST", Description: "Send a scheduled report now (admin)"},
+		{Path: "/api/v1/compliance/retention", Method: "GET", Description: "Data retention compliance report (admin)"},
+		{Path: "/api/v1/conversations/:id/transcript", Method: "GET", Description: "Download a PDF or HTML transcript"},
+		{Path: "/api/v1/conversations/:id/transcript/email", Method: "POST", Description: "Email a transcript to the contact or agent"},
+		{Path: "/api/v1/crm", Method: "GET", Description: "CRM connections"},
+		{Path: "/api/v1/crm/:provider", Method: "PUT", Description: "Configure CRM contact sync"},
+		{Path: "/api/v1/crm/:provider", Method: "DELETE", Description: "Remove a CRM connection"},
+		{Path: "/api/v1/crm/sync", Method: "POST", Description: "Run CRM contact sync now"},
+		{Path: "/api/v1/integrations/keys", Method: "GET", Description: "Integration API keys"},
+		{Path: "/api/v1/integrations/keys", Method: "POST", Description: "Create an integration API key"},
+		{Path: "/api/v1/integrations/keys/:id", Method: "PUT", Description: "Update an API key's name or allowed origins"},
+		{Path: "/api/v1/integrations/keys/:id/rotate", Method: "POST", Description: "Rotate an API key's secret"},
+		{Path: "/api/v1/integrations/keys/:id", Method: "DELETE", Description: "Revoke an integration API key"},
+		{Path: "/api/v1/integrations/webhooks", Method: "GET/POST/PUT/DELETE", Description: "Outbound event webhooks (admin)"},
+		{Path: "/api/v1/integrations/webhooks/schemas", Method: "GET", Description: "Versioned webhook payload schemas (admin)"},
+		{Path: "/api/v1/integrations/webhooks/:id/test", Method: "POST", Description: "Send a sample event to a webhook (admin)"},
+		{Path: "/api/v1/integrations/triggers/new-message", Method: "GET", Description: "Polling trigger for new messages (API key)"},
+		{Path: "/api/v1/integrations/triggers/low-confidence-answer", Method: "GET", Description: "Polling trigger for low-confidence answers (API key)"},
+		{Path: "/api/v1/integrations/triggers/spend-cap-reached", Method: "GET", Description: "Polling trigger for the daily model spend cap (API key)"},
+		{Path: "/api/v1/integrations/actions/send-message", Method: "POST", Description: "Send a WhatsApp message (API key)"},
+		{Path: "/api/v1/integrations/actions/create-document", Method: "POST", Description: "Create a draft document (API key)"},
 		{Path: "/api/v1/rag/query", Method: "POST", Description: "RAG query endpoint"},
+		{Path: "/api/v1/rag/rules", Method: "GET/POST/DELETE", Description: "Retrieval pin/boost rules (admin)"},
+		{Path: "/api/v1/rag/shortcuts", Method: "GET/POST/PUT/DELETE", Description: "FAQ shortcuts answered without retrieval (admin)"},
+		{Path: "/api/v1/rag/glossary", Method: "GET/POST/PUT/DELETE", Description: "Synonyms queries are expanded with (admin)"},
+		{Path: "/api/v1/rag/tools", Method: "GET/POST/PUT/DELETE", Description: "Tools the answer model may call, and their call log (admin)"},
+		{Path: "/api/v1/rag/formats", Method: "GET/PUT", Description: "Per-channel answer format profiles (admin)"},
+		{Path: "/api/v1/rag/queries", Method: "GET", Description: "Logged RAG queries with their retrieved chunks (admin)"},
+		{Path: "/api/v1/rag/replay/:query_id", Method: "POST", Description: "Re-run a logged query and diff its retrieval and answer (admin)"},
 		{Path: "/api/v1/whatsapp/webhook", Method: "GET/POST", Description: "WhatsApp webhook"},
+		{Path: "/api/v1/whatsapp/onboarding", Method: "GET/PUT", Description: "WhatsApp welcome and consent settings (admin)"},
+		{Path: "/api/v1/whatsapp/flows", Method: "GET/POST/PUT/DELETE", Description: "WhatsApp Flows and their variable mapping (admin)"},
+		{Path: "/api/v1/whatsapp/flows/:id/send", Method: "POST", Description: "Send a WhatsApp Flow to a conversation's contact"},
+		{Path: "/api/v1/bookings/resources", Method: "GET/POST/PUT/DELETE", Description: "Bookable resources and their hours (admin)"},
+		{Path: "/api/v1/bookings", Method: "GET/POST/PUT/DELETE", Description: "Bookings and the free slots of resources"},
+		{Path: "/api/v1/products", Method: "GET/POST/PUT/DELETE", Description: "Product catalog and CSV import (admin)"},
+		{Path: "/api/v1/slack/events", Method: "POST", Description: "Slack Events API callbacks (signed by Slack)"},
+		{Path: "/api/v1/slack/commands", Method: "POST", Description: "Slack slash command (signed by Slack)"},
+		{Path: "/api/v1/email/inbound", Method: "POST", Description: "Inbound email webhook (token)"},
+		{Path: "/api/v1/public/chat/sessions", Method: "POST", Description: "Start an anonymous widget chat session (widget key)"},
+		{Path: "/api/v1/public/chat/messages", Method: "POST", Description: "Ask a question in a widget chat session (session token)"},
+		{Path: "/api/v1/email/drafts", Method: "GET/PUT/POST/DELETE", Description: "Review, edit, send or discard email answer drafts (admin)"},
 		{Path: "/api/v1/system/logs", Method: "GET/DELETE", Description: "System logs (admin)"},
-		{Path: "/api/v1/system/info", Method: "GET", Description: "Server info (admin)"},
+		{Path: "/api/v1/system/overview", Method: "GET", Description: "Dashboard counts, query volume and error rate (admin)"},
+		{Path: "/api/v1/system/storage", Method: "GET", Description: "Storage usage per user (admin)"},
+		{Path: "/api/v1/system/storage/rebuild", Method: "POST", Description: "Recompute storage totals (admin)"},
+		{Path: "/api/v1/system/jobs", Method: "GET", Description: "Scheduled jobs and last run status (admin)"},
+		{Path: "/api/v1/system/maintenance", Method: "GET/PUT", Description: "Maintenance mode: 503 for non-admins and paused jobs (admin)"},
+		{Path: "/api/v1/system/rate-limits", Method: "GET/DELETE", Description: "Inspect or reset a client's rate-limit counters by ip or user_id (admin)"},
+		{Path: "/api/v1/system/security/events", Method: "GET", Description: "Abuse detected on the widget and webhooks, newest first (admin)"},
+		{Path: "/api/v1/system/security/blocks", Method: "DELETE", Description: "Lift an automatic IP block early by ip (admin)"},
+		{Path: "/api/v1/system/backups", Method: "GET/POST", Description: "List or create knowledge-base backups (admin)"},
+		{Path: "/api/v1/system/backups/:id/download", Method: "GET", Description: "Download a backup archive (admin)"},
+		{Path: "/api/v1/system/backups/:id/restore", Method: "POST", Description: "Restore a stored backup (admin)"},
+		{Path: "/api/v1/system/backups/restore", Method: "POST", Description: "Restore an uploaded backup archive (admin)"},
+		{Path: "/api/v1/system/info", Method: "GET", Description: "Server info with model provider availability (admin)"},
+		{Path: "/api/v1/system/metrics/history", Method: "GET", Description: "Sampled memory, goroutines, DB latency and request rate (admin)"},
+		{Path: "/api/v1/system/metrics/routes", Method: "GET", Description: "Per-route request counts, error rates and latency percentiles (admin)"},
+		{Path: "/api/v1/system/indexes", Method: "GET", Description: "Missing and unused database indexes (admin)"},
+		{Path: "/api/v1/system/embeddings", Method: "GET", Description: "Active embedding model and migration progress (admin)"},
+		{Path: "/api/v1/system/embeddings/migration", Method: "POST/DELETE", Description: "Start, after a confirmed preview, or cancel re-embedding with a new model (admin)"},
+		{Path: "/api/v1/system/embeddings/projection", Method: "GET", Description: "Sample of chunk vectors projected to 2D with document labels (admin)"},
+		{Path: "/api/v1/collections", Method: "GET", Description: "Document collections and their embedding models (admin)"},
+		{Path: "/api/v1/collections/:name", Method: "PUT/DELETE", Description: "Create, update or delete a collection (admin)"},
+		{Path: "/api/v1/cleaning-profiles", Method: "GET/POST/PUT/DELETE", Description: "Boilerplate stripped from new content by source (admin)"},
 	}
 
 	info := ServerInfo{
@@ -210,10 +654,18 @@ func (h *Handler) GetServerInfo(ctx *gin.Context) {
 		StartedAt:   h.startTime,
 		Database:    dbStatus,
 		Runtime:     runtimeInfo,
+		Cluster:     leader,
 		Endpoints:   endpoints,
 	}
+	if h.providers != nil {
+		info.Providers = h.providers.Statuses()
+	}
+	if h.maintenance != nil {
+		maintenance := h.maintenance.Status()
+		info.Maintenance = &maintenance
+	}
 
-	h.log.Info("admin_activity", "action", "server_info_view", "admin_id", adminID)
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "server_info_view", "admin_id", adminID)
 	ctx.JSON(http.StatusOK, info)
 }
 
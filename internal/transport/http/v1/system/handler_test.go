@@ -6,9 +6,13 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	systemApp "github.com/elprogramadorgt/lucidRAG/internal/application/system"
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/cluster"
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/scheduler"
 	"github.com/elprogramadorgt/lucidRAG/internal/domain/system"
 	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
 	"github.com/gin-gonic/gin"
@@ -58,6 +62,80 @@ func (m *mockDBPinger) Ping(ctx context.Context) error {
 	return nil
 }
 
+type mockJobs struct {
+	jobsFn func(ctx context.Context) ([]scheduler.JobStatus, error)
+}
+
+func (m *mockJobs) Jobs(ctx context.Context) ([]scheduler.JobStatus, error) {
+	if m.jobsFn != nil {
+		return m.jobsFn(ctx)
+	}
+	return []scheduler.JobStatus{}, nil
+}
+
+type mockOverview struct {
+	overviewFn func(ctx context.Context, now time.Time) (*system.Overview, error)
+}
+
+func (m *mockOverview) Overview(ctx context.Context, now time.Time) (*system.Overview, error) {
+	if m.overviewFn != nil {
+		return m.overviewFn(ctx, now)
+	}
+	return &system.Overview{GeneratedAt: now}, nil
+}
+
+type mockMetrics struct {
+	since time.Time
+}
+
+func (m *mockMetrics) Insert(ctx context.Context, sample *system.MetricSample) error {
+	return nil
+}
+
+func (m *mockMetrics) Since(ctx context.Context, since time.Time) ([]system.MetricSample, error) {
+	m.since = since
+	return []system.MetricSample{
+		{Instance: "api-1", Timestamp: since.Add(time.Minute), Goroutines: 10},
+		{Instance: "api-1", Timestamp: since.Add(2 * time.Minute), Goroutines: 20},
+	}, nil
+}
+
+type mockRouteStats struct{}
+
+func (m *mockRouteStats) Snapshot(window string, now time.Time) []system.RouteStat {
+	return []system.RouteStat{{Method: "GET", Route: "/api/v1/documents", Requests: 10, P95Ms: 12.5}}
+}
+
+type mockIndexes struct {
+	present []system.Index
+}
+
+func (m *mockIndexes) EnsureIndexes(ctx context.Context) error {
+	return nil
+}
+
+func (m *mockIndexes) Declared() []system.Index {
+	return []system.Index{
+		{Collection: "chunks", Name: "lucidrag_document_id_1_chunk_index_1", Keys: "document_id_1_chunk_index_1"},
+		{Collection: "messages", Name: "lucidrag_conversation_id_1_timestamp_-1", Keys: "conversation_id_1_timestamp_-1"},
+	}
+}
+
+func (m *mockIndexes) List(ctx context.Context) ([]system.Index, error) {
+	return m.present, nil
+}
+
+type mockCluster struct {
+	leaderFn func(ctx context.Context) (*cluster.LeaderStatus, error)
+}
+
+func (m *mockCluster) Leader(ctx context.Context) (*cluster.LeaderStatus, error) {
+	if m.leaderFn != nil {
+		return m.leaderFn(ctx)
+	}
+	return &cluster.LeaderStatus{}, nil
+}
+
 func setupTestRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	return gin.New()
@@ -388,6 +466,141 @@ func TestGetServerInfo(t *testing.T) {
 	}
 }
 
+func TestGetServerInfoLeader(t *testing.T) {
+	log := logger.New(logger.Options{Level: "error"})
+	handler := NewHandler(HandlerConfig{
+		Repo: &mockLogRepository{},
+		DB:   &mockDBPinger{},
+		Cluster: &mockCluster{
+			leaderFn: func(ctx context.Context) (*cluster.LeaderStatus, error) {
+				return &cluster.LeaderStatus{Instance: "api-1", Leader: "api-0"}, nil
+			},
+		},
+		Log: log,
+	})
+
+	router := setupTestRouter()
+	router.GET("/info", func(c *gin.Context) {
+		c.Set("user_id", "admin-123")
+		handler.GetServerInfo(c)
+	})
+
+	req, _ := http.NewRequest("GET", "/info", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	var result ServerInfo
+	if err := json.Unmarshal(resp.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if result.Cluster == nil {
+		t.Fatal("Expected cluster info")
+	}
+	if result.Cluster.Leader != "api-0" || result.Cluster.Instance != "api-1" || result.Cluster.IsLeader {
+		t.Errorf("Unexpected cluster info %+v", result.Cluster)
+	}
+}
+
+type mockProviders []system.ProviderStatus
+
+func (m mockProviders) Statuses() []system.ProviderStatus { return m }
+
+func TestGetServerInfoProviders(t *testing.T) {
+	handler := NewHandler(HandlerConfig{
+		Repo: &mockLogRepository{},
+		DB:   &mockDBPinger{},
+		Providers: mockProviders{
+			{Name: "openai", Available: true, Models: []string{"gpt-4o"}},
+			{Name: "ollama", Error: "connection refused", Models: []string{}},
+		},
+		Log: logger.New(logger.Options{Level: "error"}),
+	})
+
+	router := setupTestRouter()
+	router.GET("/info", func(c *gin.Context) {
+		c.Set("user_id", "admin-123")
+		handler.GetServerInfo(c)
+	})
+
+	req, _ := http.NewRequest("GET", "/info", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	var result ServerInfo
+	if err := json.Unmarshal(resp.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(result.Providers) != 2 || !result.Providers[0].Available || result.Providers[1].Available {
+		t.Errorf("Expected openai up and ollama down, got %+v", result.Providers)
+	}
+}
+
+type mockMaintenance struct {
+	state system.Maintenance
+	err   error
+}
+
+func (m *mockMaintenance) Status() system.Maintenance { return m.state }
+
+func (m *mockMaintenance) Set(ctx context.Context, enabled bool, message, by string) (*system.Maintenance, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.state = system.Maintenance{Enabled: enabled, Message: message, UpdatedBy: by}
+	return &m.state, nil
+}
+
+func TestSetMaintenance(t *testing.T) {
+	maintenance := &mockMaintenance{}
+	handler := NewHandler(HandlerConfig{
+		Repo:        &mockLogRepository{},
+		Maintenance: maintenance,
+		Log:         logger.New(logger.Options{Level: "error"}),
+	})
+
+	router := setupTestRouter()
+	router.Use(func(c *gin.Context) { c.Set("user_id", "admin-123") })
+	router.GET("/maintenance", handler.GetMaintenance)
+	router.PUT("/maintenance", handler.SetMaintenance)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"missing enabled", `{"message":"Reindexing"}`, http.StatusBadRequest},
+		{"on", `{"enabled":true,"message":"Reindexing"}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("PUT", "/maintenance", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		if resp.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.status, resp.Code)
+		}
+	}
+
+	req, _ := http.NewRequest("GET", "/maintenance", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	var result system.Maintenance
+	if err := json.Unmarshal(resp.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if !result.Enabled || result.Message != "Reindexing" || result.UpdatedBy != "admin-123" {
+		t.Errorf("Expected maintenance on by admin-123, got %+v", result)
+	}
+
+	maintenance.err = errors.New("db down")
+	req, _ = http.NewRequest("PUT", "/maintenance", strings.NewReader(`{"enabled":false}`))
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 when the switch cannot be saved, got %d", resp.Code)
+	}
+}
+
 func TestGetServerInfoDBDisconnected(t *testing.T) {
 	db := &mockDBPinger{
 		pingFn: func(ctx context.Context) error {
@@ -460,3 +673,329 @@ func TestServerInfoStruct(t *testing.T) {
 		t.Errorf("Expected 1 endpoint, got %d", len(info.Endpoints))
 	}
 }
+
+func TestListJobs(t *testing.T) {
+	log := logger.New(logger.Options{Level: "error"})
+	handler := NewHandler(HandlerConfig{
+		Repo: &mockLogRepository{},
+		DB:   &mockDBPinger{},
+		Jobs: &mockJobs{
+			jobsFn: func(ctx context.Context) ([]scheduler.JobStatus, error) {
+				return []scheduler.JobStatus{
+					{Name: "log_retention", Schedule: "0 3 * * *", LastStatus: scheduler.RunStatusSuccess},
+				}, nil
+			},
+		},
+		Log: log,
+	})
+
+	router := setupTestRouter()
+	router.GET("/jobs", func(c *gin.Context) {
+		c.Set("user_id", "admin-123")
+		handler.ListJobs(c)
+	})
+
+	req, _ := http.NewRequest("GET", "/jobs", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.Code)
+	}
+
+	var result struct {
+		Jobs []scheduler.JobStatus `json:"jobs"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(result.Jobs) != 1 || result.Jobs[0].Name != "log_retention" {
+		t.Errorf("Expected log_retention job, got %+v", result.Jobs)
+	}
+}
+
+func TestGetOverview(t *testing.T) {
+	log := logger.New(logger.Options{Level: "error"})
+	handler := NewHandler(HandlerConfig{
+		Repo: &mockLogRepository{},
+		DB:   &mockDBPinger{},
+		Overview: &mockOverview{
+			overviewFn: func(ctx context.Context, now time.Time) (*system.Overview, error) {
+				return &system.Overview{Users: 3, Documents: 10, Requests24h: 200, ServerErrors24h: 4, ErrorRate24h: 0.02}, nil
+			},
+		},
+		Log: log,
+	})
+
+	router := setupTestRouter()
+	router.GET("/overview", func(c *gin.Context) {
+		c.Set("user_id", "admin-123")
+		handler.GetOverview(c)
+	})
+
+	req, _ := http.NewRequest("GET", "/overview", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.Code)
+	}
+
+	var result system.Overview
+	if err := json.Unmarshal(resp.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if result.Users != 3 || result.Documents != 10 || result.ErrorRate24h != 0.02 {
+		t.Errorf("Unexpected overview %+v", result)
+	}
+}
+
+func TestGetOverviewError(t *testing.T) {
+	log := logger.New(logger.Options{Level: "error"})
+	handler := NewHandler(HandlerConfig{
+		Repo: &mockLogRepository{},
+		DB:   &mockDBPinger{},
+		Overview: &mockOverview{
+			overviewFn: func(ctx context.Context, now time.Time) (*system.Overview, error) {
+				return nil, errors.New("db error")
+			},
+		},
+		Log: log,
+	})
+
+	router := setupTestRouter()
+	router.GET("/overview", handler.GetOverview)
+
+	req, _ := http.NewRequest("GET", "/overview", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", resp.Code)
+	}
+}
+
+func TestGetMetricsHistory(t *testing.T) {
+	metrics := &mockMetrics{}
+	handler := NewHandler(HandlerConfig{
+		Repo:    &mockLogRepository{},
+		DB:      &mockDBPinger{},
+		Metrics: metrics,
+		Log:     logger.New(logger.Options{Level: "error"}),
+	})
+	router := setupTestRouter()
+	router.GET("/metrics/history", handler.GetMetricsHistory)
+
+	tests := []struct {
+		query    string
+		want     int
+		step     int64
+		duration time.Duration
+	}{
+		{"", http.StatusOK, 300, 24 * time.Hour},
+		{"?window=7d", http.StatusOK, 2100, 7 * 24 * time.Hour},
+		{"?window=1h", http.StatusOK, 60, time.Hour},
+		{"?window=8d", http.StatusBadRequest, 0, 0},
+		{"?window=30s", http.StatusBadRequest, 0, 0},
+		{"?window=day", http.StatusBadRequest, 0, 0},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", "/metrics/history"+tt.query, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		if resp.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d", tt.query, tt.want, resp.Code)
+			continue
+		}
+		if tt.want != http.StatusOK {
+			continue
+		}
+		var result struct {
+			StepSeconds int64                 `json:"step_seconds"`
+			Samples     []system.MetricSample `json:"samples"`
+		}
+		if err := json.Unmarshal(resp.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if result.StepSeconds != tt.step {
+			t.Errorf("%s: expected a %ds step, got %d", tt.query, tt.step, result.StepSeconds)
+		}
+		if got := time.Since(metrics.since); got < tt.duration || got > tt.duration+time.Minute {
+			t.Errorf("%s: expected samples since %v ago, got %v", tt.query, tt.duration, got)
+		}
+		if len(result.Samples) == 0 {
+			t.Errorf("%s: expected samples", tt.query)
+		}
+	}
+}
+
+func TestGetRouteStats(t *testing.T) {
+	handler := NewHandler(HandlerConfig{
+		Repo:   &mockLogRepository{},
+		DB:     &mockDBPinger{},
+		Routes: &mockRouteStats{},
+		Log:    logger.New(logger.Options{Level: "error"}),
+	})
+	router := setupTestRouter()
+	router.GET("/metrics/routes", handler.GetRouteStats)
+
+	req, _ := http.NewRequest("GET", "/metrics/routes?window=24h", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.Code)
+	}
+	var result struct {
+		Window string             `json:"window"`
+		Routes []system.RouteStat `json:"routes"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if result.Window != "24h" || len(result.Routes) != 1 || result.Routes[0].P95Ms != 12.5 {
+		t.Errorf("Unexpected route stats %+v", result)
+	}
+
+	req, _ = http.NewRequest("GET", "/metrics/routes?window=7d", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", resp.Code)
+	}
+}
+
+func TestGetIndexReport(t *testing.T) {
+	handler := NewHandler(HandlerConfig{
+		Repo: &mockLogRepository{},
+		DB:   &mockDBPinger{},
+		Indexes: &mockIndexes{present: []system.Index{
+			{Collection: "chunks", Name: "document_id_1_chunk_index_1", Keys: "document_id_1_chunk_index_1", Ops: 3},
+			{Collection: "chunks", Name: "content_1", Keys: "content_1"},
+		}},
+		Log: logger.New(logger.Options{Level: "error"}),
+	})
+	router := setupTestRouter()
+	router.GET("/indexes", handler.GetIndexReport)
+
+	req, _ := http.NewRequest("GET", "/indexes", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.Code)
+	}
+	var report system.IndexReport
+	if err := json.Unmarshal(resp.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(report.Missing) != 1 || report.Missing[0].Collection != "messages" {
+		t.Errorf("Expected the messages index missing, got %+v", report.Missing)
+	}
+	if len(report.Unused) != 1 || report.Unused[0].Name != "content_1" {
+		t.Errorf("Expected content_1 unused, got %+v", report.Unused)
+	}
+}
+
+type mockRateLimits struct {
+	reset *system.RateLimitClient
+}
+
+func (m *mockRateLimits) Usage(ctx context.Context, client system.RateLimitClient) (*system.RateLimitUsage, error) {
+	return &system.RateLimitUsage{Client: client, Requests: 42, Limit: 100}, nil
+}
+
+func (m *mockRateLimits) Reset(ctx context.Context, client system.RateLimitClient) error {
+	m.reset = &client
+	return nil
+}
+
+func TestRateLimits(t *testing.T) {
+	limits := &mockRateLimits{}
+	handler := NewHandler(HandlerConfig{RateLimits: limits, Log: logger.New(logger.Options{Level: "error"})})
+	router := setupTestRouter()
+	router.GET("/rate-limits", handler.GetRateLimit)
+	router.DELETE("/rate-limits", handler.ResetRateLimit)
+
+	for _, query := range []string{"", "?ip=1.2.3.4&user_id=user-1"} {
+		req, _ := http.NewRequest("GET", "/rate-limits"+query, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %q, got %d", query, resp.Code)
+		}
+	}
+
+	req, _ := http.NewRequest("GET", "/rate-limits?user_id=user-1", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	var usage system.RateLimitUsage
+	if err := json.Unmarshal(resp.Body.Bytes(), &usage); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if resp.Code != http.StatusOK || usage.Client.UserID != "user-1" || usage.Requests != 42 {
+		t.Errorf("Unexpected usage %d %+v", resp.Code, usage)
+	}
+
+	req, _ = http.NewRequest("DELETE", "/rate-limits?ip=1.2.3.4", nil)
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK || limits.reset == nil || limits.reset.IP != "1.2.3.4" {
+		t.Errorf("Expected the IP's counters reset, got status %d", resp.Code)
+	}
+}
+
+type mockAbuseGuard struct {
+	filter    system.SecurityEventFilter
+	unblocked string
+}
+
+func (m *mockAbuseGuard) Events(ctx context.Context, filter system.SecurityEventFilter) ([]system.SecurityEvent, error) {
+	m.filter = filter
+	return []system.SecurityEvent{{ID: "e1", Type: system.SecurityEventBurst, IP: "1.2.3.4", Blocked: "ip"}}, nil
+}
+
+func (m *mockAbuseGuard) Unblock(ctx context.Context, ip string) error {
+	if ip == "nope" {
+		return systemApp.ErrInvalidIP
+	}
+	m.unblocked = ip
+	return nil
+}
+
+func TestSecurityEvents(t *testing.T) {
+	guard := &mockAbuseGuard{}
+	handler := NewHandler(HandlerConfig{Abuse: guard, Log: logger.New(logger.Options{Level: "error"})})
+	router := setupTestRouter()
+	router.GET("/security/events", handler.ListSecurityEvents)
+	router.DELETE("/security/blocks", handler.UnblockIP)
+
+	req, _ := http.NewRequest("GET", "/security/events?type=burst&limit=20", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	var body struct {
+		Events []system.SecurityEvent `json:"events"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if resp.Code != http.StatusOK || len(body.Events) != 1 || body.Events[0].IP != "1.2.3.4" {
+		t.Errorf("Unexpected events %d %+v", resp.Code, body.Events)
+	}
+	if guard.filter.Type != system.SecurityEventBurst || guard.filter.Limit != 20 {
+		t.Errorf("Unexpected filter %+v", guard.filter)
+	}
+
+	for query, want := range map[string]int{"": http.StatusBadRequest, "?ip=nope": http.StatusBadRequest, "?ip=1.2.3.4": http.StatusOK} {
+		req, _ := http.NewRequest("DELETE", "/security/blocks"+query, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		if resp.Code != want {
+			t.Errorf("Expected status %d for %q, got %d", want, query, resp.Code)
+		}
+	}
+	if guard.unblocked != "1.2.3.4" {
+		t.Errorf("Expected 1.2.3.4 unblocked, got %q", guard.unblocked)
+	}
+}
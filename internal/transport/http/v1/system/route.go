@@ -4,7 +4,18 @@ import "github.com/gin-gonic/gin"
 
 func Register(rg *gin.RouterGroup, handler *Handler) {
 	rg.GET("/info", handler.GetServerInfo)
+	rg.GET("/overview", handler.GetOverview)
+	rg.GET("/metrics/history", handler.GetMetricsHistory)
+	rg.GET("/metrics/routes", handler.GetRouteStats)
+	rg.GET("/indexes", handler.GetIndexReport)
 	rg.GET("/logs", handler.ListLogs)
 	rg.GET("/logs/stats", handler.GetStats)
 	rg.DELETE("/logs", handler.CleanupLogs)
+	rg.GET("/jobs", handler.ListJobs)
+	rg.GET("/maintenance", handler.GetMaintenance)
+	rg.PUT("/maintenance", handler.SetMaintenance)
+	rg.GET("/rate-limits", handler.GetRateLimit)
+	rg.DELETE("/rate-limits", handler.ResetRateLimit)
+	rg.GET("/security/events", handler.ListSecurityEvents)
+	rg.DELETE("/security/blocks", handler.UnblockIP)
 }
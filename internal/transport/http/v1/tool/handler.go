@@ -0,0 +1,156 @@
+package tool
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	toolApp "github.com/elprogramadorgt/lucidRAG/internal/application/tool"
+	toolDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/tool"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	svc toolDomain.Service
+	log *logger.Logger
+}
+
+func NewHandler(svc toolDomain.Service, log *logger.Logger) *Handler {
+	return &Handler{
+		svc: svc,
+		log: log.With("handler", "tool"),
+	}
+}
+
+// toolRequest is the writable part of a tool. It carries the webhook secret
+// and lookup auth value, which Tool never serializes.
+type toolRequest struct {
+	Name          string          `json:"name"`
+	Description   string          `json:"description"`
+	Kind          toolDomain.Kind `json:"kind"`
+	Parameters    map[string]any  `json:"parameters"`
+	WebhookURL    string          `json:"webhook_url"`
+	WebhookSecret string          `json:"webhook_secret"`
+	LookupURL     string          `json:"lookup_url"`
+	AuthHeader    string          `json:"auth_header"`
+	AuthValue     string          `json:"auth_value"`
+	Timezone      string          `json:"timezone"`
+	ResourceID    string          `json:"resource_id"`
+	TimeoutMs     int             `json:"timeout_ms"`
+	IsActive      bool            `json:"is_active"`
+}
+
+func (r toolRequest) tool() *toolDomain.Tool {
+	return &toolDomain.Tool{
+		Name:          r.Name,
+		Description:   r.Description,
+		Kind:          r.Kind,
+		Parameters:    r.Parameters,
+		WebhookURL:    r.WebhookURL,
+		WebhookSecret: r.WebhookSecret,
+		LookupURL:     r.LookupURL,
+		AuthHeader:    r.AuthHeader,
+		AuthValue:     r.AuthValue,
+		Timezone:      r.Timezone,
+		ResourceID:    r.ResourceID,
+		TimeoutMs:     r.TimeoutMs,
+		IsActive:      r.IsActive,
+	}
+}
+
+func (h *Handler) writeError(ctx *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, toolApp.ErrInvalidTool):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, toolApp.ErrDuplicateTool):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, toolApp.ErrToolNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "tool not found"})
+	default:
+		h.log.ErrorContext(ctx.Request.Context(), "failed to "+action, "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to " + action})
+	}
+}
+
+func (h *Handler) List(ctx *gin.Context) {
+	tools, err := h.svc.ListTools(ctx.Request.Context())
+	if err != nil {
+		h.writeError(ctx, err, "list tools")
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"tools": tools})
+}
+
+func (h *Handler) Create(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+
+	var req toolRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	t := req.tool()
+	id, err := h.svc.CreateTool(ctx.Request.Context(), adminID, t)
+	if err != nil {
+		h.writeError(ctx, err, "create tool")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "tool_create", "admin_id", adminID, "tool_id", id, "tool_name", t.Name, "kind", t.Kind)
+	ctx.JSON(http.StatusCreated, gin.H{"id": id, "message": "tool created"})
+}
+
+func (h *Handler) Update(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+
+	var req toolRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	t := req.tool()
+	t.ID = ctx.Param("id")
+	if err := h.svc.UpdateTool(ctx.Request.Context(), adminID, t); err != nil {
+		h.writeError(ctx, err, "update tool")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "tool_update", "admin_id", adminID, "tool_id", t.ID, "is_active", t.IsActive)
+	ctx.JSON(http.StatusOK, gin.H{"message": "tool updated"})
+}
+
+func (h *Handler) Delete(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+	id := ctx.Param("id")
+
+	if err := h.svc.DeleteTool(ctx.Request.Context(), adminID, id); err != nil {
+		h.writeError(ctx, err, "delete tool")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "tool_delete", "admin_id", adminID, "tool_id", id)
+	ctx.JSON(http.StatusOK, gin.H{"message": "tool deleted"})
+}
+
+// Invocations lists the audit log of tool calls, newest first, optionally
+// for a single tool.
+func (h *Handler) Invocations(ctx *gin.Context) {
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+
+	invocations, total, err := h.svc.ListInvocations(ctx.Request.Context(), ctx.Query("tool_id"), limit, offset)
+	if err != nil {
+		h.writeError(ctx, err, "list tool invocations")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"invocations": invocations,
+		"total":       total,
+		"limit":       limit,
+		"offset":      offset,
+	})
+}
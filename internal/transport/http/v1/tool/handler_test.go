@@ -0,0 +1,148 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	toolApp "github.com/elprogramadorgt/lucidRAG/internal/application/tool"
+	toolDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/tool"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// mockService implements toolDomain.Service for testing
+type mockService struct {
+	createFn func(ctx context.Context, adminID string, tool *toolDomain.Tool) (string, error)
+	updateFn func(ctx context.Context, adminID string, tool *toolDomain.Tool) error
+}
+
+func (m *mockService) CreateTool(ctx context.Context, adminID string, tool *toolDomain.Tool) (string, error) {
+	if m.createFn != nil {
+		return m.createFn(ctx, adminID, tool)
+	}
+	return "tool-1", nil
+}
+
+func (m *mockService) ListTools(ctx context.Context) ([]toolDomain.Tool, error) {
+	return []toolDomain.Tool{{ID: "tool-1", Name: "calculator", Kind: toolDomain.KindCalculator, WebhookSecret: "hidden"}}, nil
+}
+
+func (m *mockService) UpdateTool(ctx context.Context, adminID string, tool *toolDomain.Tool) error {
+	if m.updateFn != nil {
+		return m.updateFn(ctx, adminID, tool)
+	}
+	return nil
+}
+
+func (m *mockService) DeleteTool(ctx context.Context, adminID, id string) error {
+	return nil
+}
+
+func (m *mockService) ListInvocations(ctx context.Context, toolID string, limit, offset int) ([]toolDomain.Invocation, int64, error) {
+	return []toolDomain.Invocation{{ID: "inv-1", ToolID: toolID}}, 1, nil
+}
+
+func setupTestRouter(svc toolDomain.Service) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "admin-1")
+		c.Set("user_role", "admin")
+	})
+	Register(r.Group("/tools"), NewHandler(svc, logger.New(logger.Options{Level: "error"})))
+	return r
+}
+
+func TestCreateToolPassesSecret(t *testing.T) {
+	var got *toolDomain.Tool
+	router := setupTestRouter(&mockService{
+		createFn: func(ctx context.Context, adminID string, tool *toolDomain.Tool) (string, error) {
+			got = tool
+			return "tool-1", nil
+		},
+	})
+
+	body := `{"name":"order_status","description":"Look up an order","kind":"webhook","webhook_url":"https://shop.example/tools","webhook_secret":"s3cret","is_active":true}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/tools", strings.NewReader(body)))
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	if got == nil || got.WebhookSecret != "s3cret" || got.Kind != toolDomain.KindWebhook {
+		t.Errorf("Expected webhook tool with secret, got %+v", got)
+	}
+}
+
+func TestCreateToolInvalid(t *testing.T) {
+	router := setupTestRouter(&mockService{
+		createFn: func(ctx context.Context, adminID string, tool *toolDomain.Tool) (string, error) {
+			return "", fmt.Errorf("%w: webhook_url is required", toolApp.ErrInvalidTool)
+		},
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/tools", strings.NewReader(`{"name":"x","kind":"webhook"}`)))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestUpdateToolNotFound(t *testing.T) {
+	router := setupTestRouter(&mockService{
+		updateFn: func(ctx context.Context, adminID string, tool *toolDomain.Tool) error {
+			if tool.ID != "missing" {
+				t.Errorf("Expected id from path, got %s", tool.ID)
+			}
+			return toolApp.ErrToolNotFound
+		},
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/tools/missing", strings.NewReader(`{"name":"x"}`)))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestListToolsHidesSecret(t *testing.T) {
+	router := setupTestRouter(&mockService{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tools", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if strings.Contains(w.Body.String(), "hidden") {
+		t.Errorf("Expected webhook secret to be omitted, got %s", w.Body.String())
+	}
+}
+
+func TestListInvocationsFiltersByTool(t *testing.T) {
+	router := setupTestRouter(&mockService{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tools/invocations?tool_id=tool-1", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var resp struct {
+		Invocations []toolDomain.Invocation `json:"invocations"`
+		Total       int64                   `json:"total"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if resp.Total != 1 || resp.Invocations[0].ToolID != "tool-1" {
+		t.Errorf("Expected invocations for tool-1, got %+v", resp)
+	}
+}
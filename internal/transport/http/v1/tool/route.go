@@ -0,0 +1,11 @@
+package tool
+
+import "github.com/gin-gonic/gin"
+
+func Register(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("", handler.List)
+	rg.POST("", handler.Create)
+	rg.GET("/invocations", handler.Invocations)
+	rg.PUT("/:id", handler.Update)
+	rg.DELETE("/:id", handler.Delete)
+}
@@ -0,0 +1,118 @@
+package topic
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	reportApp "github.com/elprogramadorgt/lucidRAG/internal/application/report"
+	topicApp "github.com/elprogramadorgt/lucidRAG/internal/application/topic"
+	topicDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/topic"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	svc topicDomain.Service
+	log *logger.Logger
+}
+
+func NewHandler(svc topicDomain.Service, log *logger.Logger) *Handler {
+	return &Handler{
+		svc: svc,
+		log: log.With("handler", "topic"),
+	}
+}
+
+func (h *Handler) writeError(ctx *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, topicApp.ErrSnapshotNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "topic snapshot not found"})
+	case errors.Is(err, topicApp.ErrTooFewQuestions):
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, topicApp.ErrClusteringRunning):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, topicApp.ErrNotConfigured):
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		h.log.ErrorContext(ctx.Request.Context(), "failed to "+action, "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to " + action})
+	}
+}
+
+// csvFormat reports whether the format query parameter asks for CSV,
+// answering 400 and false for an unknown format.
+func csvFormat(ctx *gin.Context) (csv, ok bool) {
+	switch ctx.DefaultQuery("format", "json") {
+	case "json":
+		return false, true
+	case "csv":
+		return true, true
+	}
+	ctx.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+	return false, false
+}
+
+// writeSnapshot answers with snapshot as JSON or as a CSV file.
+func writeSnapshot(ctx *gin.Context, snapshot *topicDomain.Snapshot, csv bool) {
+	if !csv {
+		ctx.JSON(http.StatusOK, snapshot)
+		return
+	}
+	filename := "topics-" + snapshot.To.UTC().Format(time.DateOnly) + ".csv"
+	ctx.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	ctx.Data(http.StatusOK, "text/csv; charset=utf-8", reportApp.CSV(reportApp.TopicsTable(snapshot)))
+}
+
+// Latest returns the topics of the newest snapshot, as JSON or with
+// format=csv as a CSV file.
+func (h *Handler) Latest(ctx *gin.Context) {
+	csv, ok := csvFormat(ctx)
+	if !ok {
+		return
+	}
+	snapshot, err := h.svc.Latest(ctx.Request.Context())
+	if err != nil {
+		h.writeError(ctx, err, "get topics")
+		return
+	}
+	writeSnapshot(ctx, snapshot, csv)
+}
+
+func (h *Handler) ListSnapshots(ctx *gin.Context) {
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	snapshots, err := h.svc.ListSnapshots(ctx.Request.Context(), limit)
+	if err != nil {
+		h.writeError(ctx, err, "list topic snapshots")
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"snapshots": snapshots})
+}
+
+func (h *Handler) GetSnapshot(ctx *gin.Context) {
+	csv, ok := csvFormat(ctx)
+	if !ok {
+		return
+	}
+	snapshot, err := h.svc.GetSnapshot(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		h.writeError(ctx, err, "get topic snapshot")
+		return
+	}
+	writeSnapshot(ctx, snapshot, csv)
+}
+
+// Cluster runs topic clustering now instead of waiting for the schedule.
+func (h *Handler) Cluster(ctx *gin.Context) {
+	adminID := ctx.GetString("user_id")
+	snapshot, err := h.svc.Cluster(ctx.Request.Context())
+	if err != nil {
+		h.writeError(ctx, err, "cluster topics")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "topics_cluster", "admin_id", adminID, "snapshot_id", snapshot.ID, "topics", len(snapshot.Topics))
+	ctx.JSON(http.StatusCreated, snapshot)
+}
@@ -0,0 +1,191 @@
+package topic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	topicApp "github.com/elprogramadorgt/lucidRAG/internal/application/topic"
+	topicDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/topic"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// mockService implements topicDomain.Service for testing
+type mockService struct {
+	clusterFn func(ctx context.Context) (*topicDomain.Snapshot, error)
+	latestFn  func(ctx context.Context) (*topicDomain.Snapshot, error)
+	limit     int
+}
+
+func (m *mockService) Cluster(ctx context.Context) (*topicDomain.Snapshot, error) {
+	if m.clusterFn != nil {
+		return m.clusterFn(ctx)
+	}
+	return &topicDomain.Snapshot{ID: "snap-1", Topics: []topicDomain.Topic{{Label: "Refunds"}}}, nil
+}
+
+func (m *mockService) Latest(ctx context.Context) (*topicDomain.Snapshot, error) {
+	if m.latestFn != nil {
+		return m.latestFn(ctx)
+	}
+	return nil, topicApp.ErrSnapshotNotFound
+}
+
+func (m *mockService) GetSnapshot(ctx context.Context, id string) (*topicDomain.Snapshot, error) {
+	if id != "snap-1" {
+		return nil, topicApp.ErrSnapshotNotFound
+	}
+	return &topicDomain.Snapshot{ID: id}, nil
+}
+
+func (m *mockService) ListSnapshots(ctx context.Context, limit int) ([]topicDomain.Snapshot, error) {
+	m.limit = limit
+	return []topicDomain.Snapshot{{ID: "snap-1"}}, nil
+}
+
+func setupTestRouter(svc topicDomain.Service) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", "admin-1")
+		c.Set("user_role", "admin")
+	})
+	Register(r.Group("/analytics/topics"), NewHandler(svc, logger.New(logger.Options{Level: "error"})))
+	return r
+}
+
+func TestLatest(t *testing.T) {
+	router := setupTestRouter(&mockService{
+		latestFn: func(ctx context.Context) (*topicDomain.Snapshot, error) {
+			return &topicDomain.Snapshot{ID: "snap-1", Questions: 40, Topics: []topicDomain.Topic{{Label: "Refunds", Questions: 30}}}, nil
+		},
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/analytics/topics", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var snapshot topicDomain.Snapshot
+	if err := json.Unmarshal(w.Body.Bytes(), &snapshot); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(snapshot.Topics) != 1 || snapshot.Topics[0].Label != "Refunds" {
+		t.Errorf("Expected the Refunds topic, got %+v", snapshot.Topics)
+	}
+}
+
+func TestLatestNotFound(t *testing.T) {
+	router := setupTestRouter(&mockService{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/analytics/topics", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestLatestCSV(t *testing.T) {
+	router := setupTestRouter(&mockService{
+		latestFn: func(ctx context.Context) (*topicDomain.Snapshot, error) {
+			return &topicDomain.Snapshot{ID: "snap-1", Topics: []topicDomain.Topic{{Label: "Refunds", Questions: 30, Share: 0.75, Examples: []string{"Where is my refund?", "-5 dollars?"}}}}, nil
+		},
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/analytics/topics?format=csv", nil))
+
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("Expected a CSV file, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	want := "topic,questions,share,examples\nRefunds,30,0.7500,Where is my refund? | -5 dollars?\n"
+	if w.Body.String() != want {
+		t.Errorf("Expected %q, got %q", want, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/analytics/topics/snapshots/snap-1?format=pdf", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an unknown format, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestCluster(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{"created", nil, http.StatusCreated},
+		{"too few questions", fmt.Errorf("%w: 3 asked", topicApp.ErrTooFewQuestions), http.StatusUnprocessableEntity},
+		{"already running", topicApp.ErrClusteringRunning, http.StatusConflict},
+		{"not configured", topicApp.ErrNotConfigured, http.StatusServiceUnavailable},
+		{"internal error", fmt.Errorf("database down"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &mockService{}
+			if tt.err != nil {
+				svc.clusterFn = func(ctx context.Context) (*topicDomain.Snapshot, error) {
+					return nil, tt.err
+				}
+			}
+			router := setupTestRouter(svc)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/analytics/topics", nil))
+
+			if w.Code != tt.wantCode {
+				t.Errorf("Expected status %d, got %d", tt.wantCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestListSnapshots(t *testing.T) {
+	svc := &mockService{}
+	router := setupTestRouter(svc)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/analytics/topics/snapshots?limit=3", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if svc.limit != 3 {
+		t.Errorf("Expected limit 3, got %d", svc.limit)
+	}
+	var resp struct {
+		Snapshots []topicDomain.Snapshot `json:"snapshots"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(resp.Snapshots) != 1 {
+		t.Errorf("Expected 1 snapshot, got %d", len(resp.Snapshots))
+	}
+}
+
+func TestGetSnapshot(t *testing.T) {
+	router := setupTestRouter(&mockService{})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/analytics/topics/snapshots/snap-1", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/analytics/topics/snapshots/missing", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,10 @@
+package topic
+
+import "github.com/gin-gonic/gin"
+
+func Register(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("", handler.Latest)
+	rg.POST("", handler.Cluster)
+	rg.GET("/snapshots", handler.ListSnapshots)
+	rg.GET("/snapshots/:id", handler.GetSnapshot)
+}
@@ -0,0 +1,100 @@
+package transcript
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+
+	convApp "github.com/elprogramadorgt/lucidRAG/internal/application/conversation"
+	transcriptApp "github.com/elprogramadorgt/lucidRAG/internal/application/transcript"
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	transcriptDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/transcript"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	svc transcriptDomain.Service
+	log *logger.Logger
+}
+
+func NewHandler(svc transcriptDomain.Service, log *logger.Logger) *Handler {
+	return &Handler{
+		svc: svc,
+		log: log.With("handler", "transcript"),
+	}
+}
+
+func getUserContext(ctx *gin.Context) conversationDomain.UserContext {
+	return conversationDomain.UserContext{
+		UserID:  ctx.GetString("user_id"),
+		IsAdmin: ctx.GetString("user_role") == "admin",
+	}
+}
+
+func (h *Handler) writeError(ctx *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, convApp.ErrConversationNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "conversation not found"})
+	case errors.Is(err, convApp.ErrForbidden):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+	case errors.Is(err, transcriptApp.ErrInvalidFormat),
+		errors.Is(err, transcriptApp.ErrInvalidRecipient),
+		errors.Is(err, transcriptApp.ErrNoContactEmail):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, transcriptApp.ErrMailUnavailable):
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	case errors.Is(err, transcriptApp.ErrSendFailed):
+		h.log.ErrorContext(ctx.Request.Context(), "failed to "+action, "error", err)
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": "failed to send transcript"})
+	default:
+		h.log.ErrorContext(ctx.Request.Context(), "failed to "+action, "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to " + action})
+	}
+}
+
+// Download returns the transcript as a file in the format query parameter.
+func (h *Handler) Download(ctx *gin.Context) {
+	userCtx := getUserContext(ctx)
+	id := ctx.Param("id")
+	format := transcriptDomain.Format(ctx.DefaultQuery("format", string(transcriptDomain.FormatPDF)))
+
+	file, err := h.svc.Render(ctx.Request.Context(), userCtx, id, format)
+	if err != nil {
+		h.writeError(ctx, err, "render transcript")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "transcript_export", "user_id", userCtx.UserID, "conversation_id", id, "format", format)
+	ctx.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": file.Filename}))
+	ctx.Data(http.StatusOK, file.ContentType, file.Data)
+}
+
+type emailRequest struct {
+	Recipient transcriptDomain.Recipient `json:"recipient" binding:"required"`
+	Format    transcriptDomain.Format    `json:"format"`
+}
+
+// Email sends the transcript to the conversation's contact or to the
+// requesting agent.
+func (h *Handler) Email(ctx *gin.Context) {
+	var req emailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	if req.Format == "" {
+		req.Format = transcriptDomain.FormatPDF
+	}
+
+	userCtx := getUserContext(ctx)
+	id := ctx.Param("id")
+	delivery, err := h.svc.Email(ctx.Request.Context(), userCtx, id, req.Format, req.Recipient)
+	if err != nil {
+		h.writeError(ctx, err, "email transcript")
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "transcript_email", "user_id", userCtx.UserID, "conversation_id", id, "recipient", delivery.Recipient, "format", delivery.Format)
+	ctx.JSON(http.StatusOK, delivery)
+}
@@ -0,0 +1,96 @@
+package transcript
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	convApp "github.com/elprogramadorgt/lucidRAG/internal/application/conversation"
+	transcriptApp "github.com/elprogramadorgt/lucidRAG/internal/application/transcript"
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	transcriptDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/transcript"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// mockService implements transcriptDomain.Service for testing
+type mockService struct{}
+
+func (m *mockService) Render(ctx context.Context, userCtx conversationDomain.UserContext, id string, format transcriptDomain.Format) (*transcriptDomain.File, error) {
+	if id != "conv-1" {
+		return nil, convApp.ErrConversationNotFound
+	}
+	if format != transcriptDomain.FormatPDF {
+		return nil, transcriptApp.ErrInvalidFormat
+	}
+	return &transcriptDomain.File{Filename: "transcript-conv-1.pdf", ContentType: "application/pdf", Data: []byte("%PDF-1.4")}, nil
+}
+
+func (m *mockService) Email(ctx context.Context, userCtx conversationDomain.UserContext, id string, format transcriptDomain.Format, to transcriptDomain.Recipient) (*transcriptDomain.Delivery, error) {
+	if to == transcriptDomain.RecipientContact {
+		return nil, transcriptApp.ErrNoContactEmail
+	}
+	return &transcriptDomain.Delivery{Recipient: to, To: "agent@example.com", Format: format}, nil
+}
+
+func setupTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("user_id", "u1") })
+	Register(r.Group("/conversations"), NewHandler(&mockService{}, logger.New(logger.Options{Level: "error"})))
+	return r
+}
+
+func TestDownload(t *testing.T) {
+	router := setupTestRouter()
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/conversations/conv-1/transcript", http.StatusOK},
+		{"/conversations/conv-1/transcript?format=docx", http.StatusBadRequest},
+		{"/conversations/missing/transcript", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.want, w.Code)
+		}
+		if tt.want == http.StatusOK {
+			if got := w.Header().Get("Content-Disposition"); got != `attachment; filename=transcript-conv-1.pdf` {
+				t.Errorf("Expected an attachment disposition, got %q", got)
+			}
+			if w.Header().Get("Content-Type") != "application/pdf" {
+				t.Errorf("Expected application/pdf, got %q", w.Header().Get("Content-Type"))
+			}
+		}
+	}
+}
+
+func TestEmail(t *testing.T) {
+	router := setupTestRouter()
+
+	tests := []struct {
+		body string
+		want int
+	}{
+		{`{"recipient":"agent"}`, http.StatusOK},
+		{`{"recipient":"contact","format":"html"}`, http.StatusBadRequest},
+		{`{}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/conversations/conv-1/transcript/email", strings.NewReader(tt.body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d", tt.body, tt.want, w.Code)
+		}
+		if tt.want == http.StatusOK && !strings.Contains(w.Body.String(), `"format":"pdf"`) {
+			t.Errorf("Expected PDF by default, got %s", w.Body.String())
+		}
+	}
+}
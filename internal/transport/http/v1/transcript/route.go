@@ -0,0 +1,9 @@
+package transcript
+
+import "github.com/gin-gonic/gin"
+
+// Register mounts the transcript routes on the conversations group.
+func Register(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("/:id/transcript", handler.Download)
+	rg.POST("/:id/transcript/email", handler.Email)
+}
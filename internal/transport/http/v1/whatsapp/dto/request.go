@@ -44,17 +44,50 @@ type Profile struct {
 }
 
 type Message struct {
-	From      string       `json:"from"`
-	ID        string       `json:"id"`
-	Timestamp string       `json:"timestamp"`
-	Type      string       `json:"type"`
-	Text      *TextMessage `json:"text,omitempty"`
+	From      string        `json:"from"`
+	ID        string        `json:"id"`
+	Timestamp string        `json:"timestamp"`
+	Type      string        `json:"type"`
+	Text      *TextMessage  `json:"text,omitempty"`
+	Audio     *MediaMessage `json:"audio,omitempty"`
+	Image     *MediaMessage `json:"image,omitempty"`
+	// Interactive is a tapped reply button or a completed flow.
+	Interactive *InteractiveMessage `json:"interactive,omitempty"`
 }
 
 type TextMessage struct {
 	Body string `json:"body"`
 }
 
+type MediaMessage struct {
+	ID       string `json:"id"`
+	MimeType string `json:"mime_type"`
+	// Voice is set for voice notes recorded in the app rather than
+	// forwarded audio files.
+	Voice bool `json:"voice,omitempty"`
+	// Caption is the text sent along with an image.
+	Caption string `json:"caption,omitempty"`
+}
+
+type InteractiveMessage struct {
+	Type        string       `json:"type"`
+	ButtonReply *ButtonReply `json:"button_reply,omitempty"`
+	NfmReply    *NfmReply    `json:"nfm_reply,omitempty"`
+}
+
+type ButtonReply struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// NfmReply is a completed WhatsApp Flow. ResponseJSON holds its answers,
+// and the token it was sent with, as a JSON object.
+type NfmReply struct {
+	Name         string `json:"name"`
+	Body         string `json:"body"`
+	ResponseJSON string `json:"response_json"`
+}
+
 type Status struct {
 	ID          string `json:"id"`
 	Status      string `json:"status"`
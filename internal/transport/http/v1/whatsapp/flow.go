@@ -0,0 +1,149 @@
+package whatsapp
+
+import (
+	"errors"
+	"net/http"
+
+	convApp "github.com/elprogramadorgt/lucidRAG/internal/application/conversation"
+	whatsappApp "github.com/elprogramadorgt/lucidRAG/internal/application/whatsapp"
+	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
+	whatsappDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/whatsapp"
+	"github.com/gin-gonic/gin"
+)
+
+type flowRequest struct {
+	Name      string            `json:"name" binding:"required"`
+	FlowID    string            `json:"flow_id" binding:"required"`
+	Screen    string            `json:"screen"`
+	CTA       string            `json:"cta" binding:"required"`
+	Body      string            `json:"body" binding:"required"`
+	Variables map[string]string `json:"variables"`
+}
+
+func (r flowRequest) flow() *whatsappDomain.Flow {
+	return &whatsappDomain.Flow{
+		Name: r.Name, FlowID: r.FlowID, Screen: r.Screen, CTA: r.CTA, Body: r.Body, Variables: r.Variables,
+	}
+}
+
+type sendFlowRequest struct {
+	ConversationID string `json:"conversation_id" binding:"required"`
+}
+
+// flowError answers a failed flow request, reporting whether err was one
+// it knows.
+func (h *Handler) flowError(ctx *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, whatsappApp.ErrInvalidFlow), errors.Is(err, whatsappApp.ErrNoWhatsAppContact):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, whatsappApp.ErrFlowNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "flow not found"})
+	case errors.Is(err, convApp.ErrConversationNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "conversation not found"})
+	case errors.Is(err, convApp.ErrForbidden):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+	case errors.Is(err, whatsappApp.ErrFlowsUnavailable):
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
+
+func (h *Handler) ListFlows(ctx *gin.Context) {
+	flows, err := h.flows.ListFlows(ctx.Request.Context())
+	if err != nil {
+		h.log.ErrorContext(ctx.Request.Context(), "failed to list flows", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list flows"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"flows": flows, "total": len(flows)})
+}
+
+func (h *Handler) CreateFlow(ctx *gin.Context) {
+	var req flowRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	adminID := ctx.GetString("user_id")
+	id, err := h.flows.CreateFlow(ctx.Request.Context(), adminID, req.flow())
+	if err != nil {
+		if !h.flowError(ctx, err) {
+			h.log.ErrorContext(ctx.Request.Context(), "failed to create flow", "error", err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create flow"})
+		}
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "whatsapp_flow_create", "admin_id", adminID, "flow_id", id)
+	ctx.JSON(http.StatusCreated, gin.H{
+		"id":      id,
+		"message": "flow created successfully",
+	})
+}
+
+func (h *Handler) UpdateFlow(ctx *gin.Context) {
+	var req flowRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	id := ctx.Param("id")
+	adminID := ctx.GetString("user_id")
+	flow := req.flow()
+	flow.ID = id
+	if err := h.flows.UpdateFlow(ctx.Request.Context(), adminID, flow); err != nil {
+		if !h.flowError(ctx, err) {
+			h.log.ErrorContext(ctx.Request.Context(), "failed to update flow", "error", err, "id", id)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update flow"})
+		}
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "whatsapp_flow_update", "admin_id", adminID, "flow_id", id)
+	ctx.JSON(http.StatusOK, flow)
+}
+
+func (h *Handler) DeleteFlow(ctx *gin.Context) {
+	id := ctx.Param("id")
+	adminID := ctx.GetString("user_id")
+	if err := h.flows.DeleteFlow(ctx.Request.Context(), adminID, id); err != nil {
+		if !h.flowError(ctx, err) {
+			h.log.ErrorContext(ctx.Request.Context(), "failed to delete flow", "error", err, "id", id)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete flow"})
+		}
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "whatsapp_flow_delete", "admin_id", adminID, "flow_id", id)
+	ctx.JSON(http.StatusOK, gin.H{"message": "flow deleted successfully"})
+}
+
+// SendFlow sends a flow to the contact of a conversation the user has
+// access to.
+func (h *Handler) SendFlow(ctx *gin.Context) {
+	var req sendFlowRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	id := ctx.Param("id")
+	userCtx := conversationDomain.UserContext{
+		UserID:  ctx.GetString("user_id"),
+		IsAdmin: ctx.GetString("user_role") == "admin",
+	}
+	if err := h.flows.SendFlow(ctx.Request.Context(), userCtx, id, req.ConversationID); err != nil {
+		if !h.flowError(ctx, err) {
+			h.log.ErrorContext(ctx.Request.Context(), "failed to send flow", "error", err, "id", id, "conversation_id", req.ConversationID)
+			ctx.JSON(http.StatusBadGateway, gin.H{"error": "failed to send flow"})
+		}
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "whatsapp flow sent", "flow_id", id, "conversation_id", req.ConversationID, "user_id", userCtx.UserID)
+	ctx.JSON(http.StatusOK, gin.H{"message": "flow sent successfully"})
+}
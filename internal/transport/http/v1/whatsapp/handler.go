@@ -1,10 +1,12 @@
 package whatsapp
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 
+	whatsappApp "github.com/elprogramadorgt/lucidRAG/internal/application/whatsapp"
 	conversationDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/conversation"
-	documentDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/document"
 	whatsappDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/whatsapp"
 	"github.com/elprogramadorgt/lucidRAG/internal/transport/http/v1/whatsapp/dto"
 	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
@@ -14,15 +16,27 @@ import (
 type Handler struct {
 	svc                whatsappDomain.Service
 	convSvc            conversationDomain.Service
-	docSvc             documentDomain.Service
+	transcriber        whatsappDomain.Transcriber
+	imageDescriber     whatsappDomain.ImageDescriber
+	onboarding         whatsappDomain.OnboardingService
+	flows              whatsappDomain.FlowService
 	webhookVerifyToken string
 	log                *logger.Logger
 }
 
 type HandlerConfig struct {
-	WhatsAppSvc        whatsappDomain.Service
-	ConversationSvc    conversationDomain.Service
-	DocumentSvc        documentDomain.Service
+	WhatsAppSvc     whatsappDomain.Service
+	ConversationSvc conversationDomain.Service
+	// Transcriber turns voice notes into text; without it audio messages
+	// are ignored.
+	Transcriber whatsappDomain.Transcriber
+	// ImageDescriber describes incoming photos; without it image messages
+	// are ignored.
+	ImageDescriber whatsappDomain.ImageDescriber
+	// Onboarding serves the onboarding settings endpoints.
+	Onboarding whatsappDomain.OnboardingService
+	// Flows serves the WhatsApp Flow endpoints.
+	Flows              whatsappDomain.FlowService
 	WebhookVerifyToken string
 	Log                *logger.Logger
 }
@@ -31,7 +45,10 @@ func NewHandler(cfg HandlerConfig) *Handler {
 	return &Handler{
 		svc:                cfg.WhatsAppSvc,
 		convSvc:            cfg.ConversationSvc,
-		docSvc:             cfg.DocumentSvc,
+		transcriber:        cfg.Transcriber,
+		imageDescriber:     cfg.ImageDescriber,
+		onboarding:         cfg.Onboarding,
+		flows:              cfg.Flows,
 		webhookVerifyToken: cfg.WebhookVerifyToken,
 		log:                cfg.Log.With("handler", "whatsapp"),
 	}
@@ -40,14 +57,14 @@ func NewHandler(cfg HandlerConfig) *Handler {
 func (h *Handler) HandleWebhookVerification(ctx *gin.Context) {
 	var request dto.HookRequest
 	if err := ctx.ShouldBindQuery(&request); err != nil {
-		h.log.Error("failed to bind query", "error", err)
+		h.log.ErrorContext(ctx.Request.Context(), "failed to bind query", "error", err)
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "Bad Request"})
 		return
 	}
 
 	challenge, err := h.svc.VerifyWebhook(mapToHookInput(request), h.webhookVerifyToken)
 	if err != nil {
-		h.log.Warn("webhook verification failed", "error", err)
+		h.log.WarnContext(ctx.Request.Context(), "webhook verification failed", "error", err)
 		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		return
 	}
@@ -58,13 +75,13 @@ func (h *Handler) HandleWebhookVerification(ctx *gin.Context) {
 func (h *Handler) HandleIncomingMessage(ctx *gin.Context) {
 	var payload dto.WebhookPayload
 	if err := ctx.ShouldBindJSON(&payload); err != nil {
-		h.log.Error("failed to parse webhook payload", "error", err)
+		h.log.ErrorContext(ctx.Request.Context(), "failed to parse webhook payload", "error", err)
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "Bad Request"})
 		return
 	}
 
 	if payload.Object != "whatsapp_business_account" {
-		h.log.Warn("unexpected webhook object type", "object", payload.Object)
+		h.log.WarnContext(ctx.Request.Context(), "unexpected webhook object type", "object", payload.Object)
 		ctx.JSON(http.StatusOK, gin.H{"status": "ignored"})
 		return
 	}
@@ -89,7 +106,7 @@ func (h *Handler) processMessage(ctx *gin.Context, msg dto.Message, contacts []d
 		}
 	}
 
-	h.log.Info("received message",
+	h.log.InfoContext(ctx.Request.Context(), "received message",
 		"request_id", ctx.GetString("request_id"),
 		"from", msg.From,
 		"sender_name", senderName,
@@ -97,63 +114,148 @@ func (h *Handler) processMessage(ctx *gin.Context, msg dto.Message, contacts []d
 		"message_id", msg.ID,
 	)
 
-	if msg.Type != "text" || msg.Text == nil {
+	content, media, ok := h.messageContent(ctx, msg)
+	if !ok {
 		return
 	}
 
-	content := msg.Text.Body
-
 	if h.convSvc == nil {
-		h.log.Debug("conversation service not configured, skipping message persistence")
+		h.log.DebugContext(ctx.Request.Context(), "conversation service not configured, skipping message persistence")
 		return
 	}
 
-	savedMsg, err := h.convSvc.SaveIncomingMessage(
-		ctx.Request.Context(),
-		msg.From,
-		senderName,
-		msg.ID,
-		content,
-		msg.Type,
+	var (
+		savedMsg *conversationDomain.Message
+		err      error
 	)
+	if form := formAnswers(msg); form != nil {
+		savedMsg, err = h.convSvc.SaveIncomingForm(ctx.Request.Context(), msg.From, senderName, msg.ID, content, form)
+	} else if media != nil {
+		savedMsg, err = h.convSvc.SaveIncomingMedia(ctx.Request.Context(), msg.From, senderName, msg.ID, content, msg.Type, *media)
+	} else {
+		savedMsg, err = h.convSvc.SaveIncomingMessage(ctx.Request.Context(), msg.From, senderName, msg.ID, content, msg.Type)
+	}
 	if err != nil {
-		h.log.Error("failed to save incoming message", "error", err)
+		h.log.ErrorContext(ctx.Request.Context(), "failed to save incoming message", "error", err)
 		return
 	}
 
-	h.log.Info("message saved", "message_id", savedMsg.ID, "conversation_id", savedMsg.ConversationID)
+	h.log.InfoContext(ctx.Request.Context(), "message saved", "message_id", savedMsg.ID, "conversation_id", savedMsg.ConversationID)
+}
 
-	if h.docSvc == nil {
-		h.log.Debug("document service not configured, skipping RAG query")
-		return
+// messageContent returns the text to store for msg and, for media messages,
+// the attachment to record with it. Voice notes are stored as their
+// transcript, or with empty content when transcription fails, so the
+// conversation still shows that one arrived. Photos keep their caption as
+// content and the model's description on the attachment.
+func (h *Handler) messageContent(ctx *gin.Context, msg dto.Message) (string, *conversationDomain.Media, bool) {
+	switch {
+	case msg.Type == "text" && msg.Text != nil:
+		return msg.Text.Body, nil, true
+	case msg.Type == "interactive" && msg.Interactive != nil && msg.Interactive.ButtonReply != nil:
+		// A tapped reply button reads as if its title had been typed.
+		return msg.Interactive.ButtonReply.Title, nil, true
+	case msg.Type == "interactive" && msg.Interactive != nil && msg.Interactive.NfmReply != nil:
+		// A completed flow keeps the text WhatsApp shows for it; its
+		// answers are read by formAnswers.
+		return msg.Interactive.NfmReply.Body, nil, true
+	case msg.Type == "audio" && msg.Audio != nil:
+		if h.transcriber == nil {
+			h.log.DebugContext(ctx.Request.Context(), "transcriber not configured, skipping audio message", "message_id", msg.ID)
+			return "", nil, false
+		}
+		text, err := h.transcriber.Transcribe(ctx.Request.Context(), msg.Audio.ID)
+		if err != nil {
+			h.log.ErrorContext(ctx.Request.Context(), "failed to transcribe audio message", "error", err, "message_id", msg.ID)
+			return "", nil, true
+		}
+		h.log.InfoContext(ctx.Request.Context(), "audio message transcribed", "message_id", msg.ID, "length", len(text))
+		return text, nil, true
+	case msg.Type == "image" && msg.Image != nil:
+		if h.imageDescriber == nil {
+			h.log.DebugContext(ctx.Request.Context(), "image describer not configured, skipping image message", "message_id", msg.ID)
+			return "", nil, false
+		}
+		media := &conversationDomain.Media{ID: msg.Image.ID, MimeType: msg.Image.MimeType}
+		description, err := h.imageDescriber.Describe(ctx.Request.Context(), msg.Image.ID, msg.Image.Caption)
+		if err != nil {
+			h.log.ErrorContext(ctx.Request.Context(), "failed to describe image message", "error", err, "message_id", msg.ID)
+			return msg.Image.Caption, media, true
+		}
+		media.Description = description
+		h.log.InfoContext(ctx.Request.Context(), "image message described", "message_id", msg.ID, "length", len(description))
+		return msg.Image.Caption, media, true
 	}
+	return "", nil, false
+}
 
-	ragQuery := documentDomain.RAGQuery{
-		Query:     content,
-		TopK:      5,
-		Threshold: 0.7,
+// formAnswers returns the answers of a completed flow, or nil for other
+// messages. Answers that are not a JSON object are kept under "response".
+func formAnswers(msg dto.Message) map[string]any {
+	if msg.Type != "interactive" || msg.Interactive == nil || msg.Interactive.NfmReply == nil {
+		return nil
 	}
+	raw := msg.Interactive.NfmReply.ResponseJSON
+	var form map[string]any
+	if err := json.Unmarshal([]byte(raw), &form); err != nil || form == nil {
+		return map[string]any{"response": raw}
+	}
+	return form
+}
 
-	ragResponse, err := h.docSvc.QueryRAG(ctx.Request.Context(), ragQuery)
+type onboardingRequest struct {
+	Enabled         bool     `json:"enabled"`
+	Welcome         string   `json:"welcome"`
+	Options         []string `json:"options"`
+	AnswerFirst     bool     `json:"answer_first"`
+	RequireConsent  bool     `json:"require_consent"`
+	ConsentPrompt   string   `json:"consent_prompt"`
+	ConsentAccept   string   `json:"consent_accept"`
+	ConsentDecline  string   `json:"consent_decline"`
+	ConsentAccepted string   `json:"consent_accepted"`
+	ConsentDeclined string   `json:"consent_declined"`
+}
+
+func (h *Handler) GetOnboarding(ctx *gin.Context) {
+	onboarding, err := h.onboarding.GetOnboarding(ctx.Request.Context())
 	if err != nil {
-		h.log.Error("failed to query RAG", "error", err)
+		h.log.ErrorContext(ctx.Request.Context(), "failed to get onboarding settings", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get onboarding settings"})
 		return
 	}
+	ctx.JSON(http.StatusOK, onboarding)
+}
 
-	_, err = h.convSvc.SaveOutgoingMessage(
-		ctx.Request.Context(),
-		savedMsg.ConversationID,
-		ragResponse.Answer,
-		ragResponse.Answer,
-	)
-	if err != nil {
-		h.log.Error("failed to save outgoing message", "error", err)
+func (h *Handler) SaveOnboarding(ctx *gin.Context) {
+	var req onboardingRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
 		return
 	}
 
-	h.log.Info("RAG response saved",
-		"conversation_id", savedMsg.ConversationID,
-		"confidence", ragResponse.ConfidenceScore,
-		"processing_time_ms", ragResponse.ProcessingTimeMs,
-	)
+	adminID := ctx.GetString("user_id")
+	onboarding := &whatsappDomain.Onboarding{
+		Enabled:         req.Enabled,
+		Welcome:         req.Welcome,
+		Options:         req.Options,
+		AnswerFirst:     req.AnswerFirst,
+		RequireConsent:  req.RequireConsent,
+		ConsentPrompt:   req.ConsentPrompt,
+		ConsentAccept:   req.ConsentAccept,
+		ConsentDecline:  req.ConsentDecline,
+		ConsentAccepted: req.ConsentAccepted,
+		ConsentDeclined: req.ConsentDeclined,
+	}
+	if err := h.onboarding.SaveOnboarding(ctx.Request.Context(), onboarding, adminID); err != nil {
+		if errors.Is(err, whatsappApp.ErrInvalidOnboarding) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.log.ErrorContext(ctx.Request.Context(), "failed to save onboarding settings", "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save onboarding settings"})
+		return
+	}
+
+	h.log.InfoContext(ctx.Request.Context(), "admin_activity", "action", "whatsapp_onboarding_update", "admin_id", adminID, "enabled", onboarding.Enabled)
+	ctx.JSON(http.StatusOK, onboarding)
 }
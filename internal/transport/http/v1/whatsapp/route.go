@@ -11,3 +11,22 @@ func Register(rg *gin.RouterGroup, handler *Handler) {
 		whatsapp.POST("/webhook", handler.HandleIncomingMessage)
 	}
 }
+
+// RegisterOnboarding mounts the onboarding settings, for admins.
+func RegisterOnboarding(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("", handler.GetOnboarding)
+	rg.PUT("", handler.SaveOnboarding)
+}
+
+// RegisterFlows mounts the WhatsApp Flows, for admins to manage.
+func RegisterFlows(rg *gin.RouterGroup, handler *Handler) {
+	rg.GET("", handler.ListFlows)
+	rg.POST("", handler.CreateFlow)
+	rg.PUT("/:id", handler.UpdateFlow)
+	rg.DELETE("/:id", handler.DeleteFlow)
+}
+
+// RegisterFlowSends mounts sending WhatsApp Flows to contacts, for agents.
+func RegisterFlowSends(rg *gin.RouterGroup, handler *Handler) {
+	rg.POST("/:id/send", handler.SendFlow)
+}
@@ -0,0 +1,105 @@
+package widget
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	widgetApp "github.com/elprogramadorgt/lucidRAG/internal/application/widget"
+	widgetDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/widget"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	svc widgetDomain.Service
+	log *logger.Logger
+}
+
+func NewHandler(svc widgetDomain.Service, log *logger.Logger) *Handler {
+	return &Handler{
+		svc: svc,
+		log: log.With("handler", "widget"),
+	}
+}
+
+func (h *Handler) writeError(ctx *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, widgetApp.ErrInvalidKey):
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid widget key"})
+	case errors.Is(err, widgetApp.ErrInvalidSession):
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired session"})
+	case errors.Is(err, widgetApp.ErrOriginNotAllowed):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "origin not allowed"})
+	case errors.Is(err, widgetApp.ErrSessionLimit):
+		ctx.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.Is(err, widgetApp.ErrInvalidQuestion):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.log.ErrorContext(ctx.Request.Context(), "failed to "+action, "error", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to " + action})
+	}
+}
+
+type startSessionRequest struct {
+	Key string `json:"key" binding:"required"`
+}
+
+func (h *Handler) StartSession(ctx *gin.Context) {
+	var req startSessionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	session, token, err := h.svc.StartSession(ctx.Request.Context(), req.Key, requestOrigin(ctx))
+	if err != nil {
+		h.writeError(ctx, err, "start session")
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"session": session, "token": token})
+}
+
+// requestOrigin returns the page the request came from. Browsers omit the
+// Origin header in some same-origin requests, so the Referer's scheme and
+// host are used instead.
+func requestOrigin(ctx *gin.Context) string {
+	if origin := ctx.GetHeader("Origin"); origin != "" {
+		return origin
+	}
+	ref, err := url.Parse(ctx.GetHeader("Referer"))
+	if err != nil || ref.Scheme == "" || ref.Host == "" {
+		return ""
+	}
+	return ref.Scheme + "://" + ref.Host
+}
+
+type askRequest struct {
+	Message string `json:"message" binding:"required"`
+}
+
+// Ask answers a message for the session whose token is in the
+// Authorization header.
+func (h *Handler) Ask(ctx *gin.Context) {
+	token, ok := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "session token required"})
+		return
+	}
+
+	var req askRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	reply, err := h.svc.Ask(ctx.Request.Context(), token, requestOrigin(ctx), req.Message)
+	if err != nil {
+		h.writeError(ctx, err, "answer message")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, reply)
+}
@@ -0,0 +1,97 @@
+package widget
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	widgetApp "github.com/elprogramadorgt/lucidRAG/internal/application/widget"
+	widgetDomain "github.com/elprogramadorgt/lucidRAG/internal/domain/widget"
+	"github.com/elprogramadorgt/lucidRAG/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// mockService implements widgetDomain.Service for testing
+type mockService struct {
+	origin string
+}
+
+func (m *mockService) StartSession(ctx context.Context, key, origin string) (*widgetDomain.Session, string, error) {
+	m.origin = origin
+	if key != "lrw_valid" {
+		return nil, "", widgetApp.ErrInvalidKey
+	}
+	return &widgetDomain.Session{ID: "s1", MaxQuestions: 20}, "token-1", nil
+}
+
+func (m *mockService) Ask(ctx context.Context, token, origin, question string) (*widgetDomain.Reply, error) {
+	if token != "token-1" {
+		return nil, widgetApp.ErrInvalidSession
+	}
+	return nil, widgetApp.ErrSessionLimit
+}
+
+func setupTestRouter(svc widgetDomain.Service) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	pass := func(c *gin.Context) { c.Next() }
+	Register(r.Group(""), NewHandler(svc, logger.New(logger.Options{Level: "error"})), pass, pass)
+	return r
+}
+
+func TestStartSession(t *testing.T) {
+	svc := &mockService{}
+	router := setupTestRouter(svc)
+
+	req := httptest.NewRequest(http.MethodPost, "/public/chat/sessions", strings.NewReader(`{"key":"lrw_valid"}`))
+	req.Header.Set("Origin", "https://www.example.com")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"token":"token-1"`) {
+		t.Errorf("Expected session token, got %d %s", w.Code, w.Body.String())
+	}
+	if svc.origin != "https://www.example.com" {
+		t.Errorf("Expected request origin passed on, got %q", svc.origin)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/public/chat/sessions", strings.NewReader(`{"key":"lrw_valid"}`))
+	req.Header.Set("Referer", "https://shop.example.com/pricing?plan=pro")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if svc.origin != "https://shop.example.com" {
+		t.Errorf("Expected origin taken from the Referer, got %q", svc.origin)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/public/chat/sessions", strings.NewReader(`{"key":"lrw_other"}`))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestAsk(t *testing.T) {
+	router := setupTestRouter(&mockService{})
+
+	tests := []struct {
+		auth string
+		want int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer other", http.StatusUnauthorized},
+		{"Bearer token-1", http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/public/chat/messages", strings.NewReader(`{"message":"hi"}`))
+		if tt.auth != "" {
+			req.Header.Set("Authorization", tt.auth)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("Authorization %q: expected status %d, got %d", tt.auth, tt.want, w.Code)
+		}
+	}
+}
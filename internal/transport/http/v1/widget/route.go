@@ -0,0 +1,15 @@
+package widget
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Register mounts the public chat endpoints. They take no user session, so
+// each is rate limited on its own, by sessionLimit and messageLimit.
+func Register(rg *gin.RouterGroup, handler *Handler, sessionLimit, messageLimit gin.HandlerFunc) {
+	chat := rg.Group("/public/chat")
+	{
+		chat.POST("/sessions", sessionLimit, handler.StartSession)
+		chat.POST("/messages", messageLimit, handler.Ask)
+	}
+}
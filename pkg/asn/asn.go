@@ -0,0 +1,119 @@
+// Package asn maps IP addresses to the autonomous system (ASN) and country
+// announcing them, from an IP-to-ASN table such as iptoasn.com's
+// ip2asn-combined.tsv.
+package asn
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Record is the network an address belongs to.
+type Record struct {
+	ASN uint32 `json:"asn"`
+	// Country is an ISO 3166 alpha-2 code, or "" when unknown.
+	Country string `json:"country,omitempty"`
+	Name    string `json:"name,omitempty"`
+}
+
+type entry struct {
+	start, end netip.Addr
+	record     Record
+}
+
+// DB is a loaded table. It is read-only and safe for concurrent use.
+type DB struct {
+	entries []entry
+}
+
+// Open loads the table at path.
+func Open(path string) (*DB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// Load reads a table of tab-separated lines: first address, last address,
+// AS number, country code and AS name. Ranges announced by no one, with AS
+// number 0, are left out.
+func Load(r io.Reader) (*DB, error) {
+	db := &DB{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("line %d: expected at least 3 tab-separated fields", line)
+		}
+		start, err := netip.ParseAddr(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		end, err := netip.ParseAddr(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		number, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid AS number %q", line, fields[2])
+		}
+		if number == 0 {
+			continue
+		}
+
+		record := Record{ASN: uint32(number)}
+		if len(fields) > 3 && fields[3] != "None" {
+			record.Country = fields[3]
+		}
+		if len(fields) > 4 {
+			record.Name = fields[4]
+		}
+		db.entries = append(db.entries, entry{start: start.Unmap(), end: end.Unmap(), record: record})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(db.entries, func(i, j int) bool { return db.entries[i].start.Less(db.entries[j].start) })
+	return db, nil
+}
+
+// Lookup returns the network addr belongs to.
+func (db *DB) Lookup(addr netip.Addr) (Record, bool) {
+	if db == nil {
+		return Record{}, false
+	}
+	addr = addr.Unmap()
+	// The last range starting at or before addr is the only one that can
+	// hold it.
+	i := sort.Search(len(db.entries), func(i int) bool { return addr.Less(db.entries[i].start) }) - 1
+	if i < 0 || db.entries[i].end.Less(addr) {
+		return Record{}, false
+	}
+	return db.entries[i].record, true
+}
+
+// ParseNumber reads an AS number written as 14061 or AS14061.
+func ParseNumber(s string) (uint32, error) {
+	s = strings.TrimSpace(s)
+	if len(s) > 2 && strings.EqualFold(s[:2], "AS") {
+		s = s[2:]
+	}
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("invalid AS number %q", s)
+	}
+	return uint32(n), nil
+}
@@ -0,0 +1,68 @@
+package asn
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+const table = `1.0.0.0	1.0.0.255	13335	US	CLOUDFLARENET
+1.0.1.0	1.0.3.255	0	None	Not routed
+104.131.0.0	104.131.255.255	14061	US	DIGITALOCEAN-ASN
+2a03:b0c0::	2a03:b0c0:ffff:ffff:ffff:ffff:ffff:ffff	14061	DE	DIGITALOCEAN-ASN
+81.2.69.0	81.2.69.255	20712	GB	ANDREWS-ARNOLD
+`
+
+func TestLookup(t *testing.T) {
+	db, err := Load(strings.NewReader(table))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		ip      string
+		asn     uint32
+		country string
+		found   bool
+	}{
+		{"1.0.0.7", 13335, "US", true},
+		{"1.0.2.1", 0, "", false},
+		{"104.131.20.5", 14061, "US", true},
+		{"::ffff:81.2.69.160", 20712, "GB", true},
+		{"2a03:b0c0:2:d0::1", 14061, "DE", true},
+		{"81.2.70.1", 0, "", false},
+		{"0.0.0.1", 0, "", false},
+	}
+	for _, tt := range tests {
+		record, found := db.Lookup(netip.MustParseAddr(tt.ip))
+		if found != tt.found || record.ASN != tt.asn || record.Country != tt.country {
+			t.Errorf("Lookup(%s) = %+v, %v; want AS%d %s, %v", tt.ip, record, found, tt.asn, tt.country, tt.found)
+		}
+	}
+
+	var none *DB
+	if _, found := none.Lookup(netip.MustParseAddr("1.0.0.7")); found {
+		t.Error("Expected a nil table to find nothing")
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for _, bad := range []string{"1.0.0.0\t1.0.0.255", "1.0.0.0\tnope\t13335", "1.0.0.0\t1.0.0.255\tAS1"} {
+		if _, err := Load(strings.NewReader(bad)); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	for in, want := range map[string]uint32{"14061": 14061, "AS14061": 14061, " as16276 ": 16276} {
+		if got, err := ParseNumber(in); err != nil || got != want {
+			t.Errorf("ParseNumber(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "AS", "AS0", "cloud"} {
+		if _, err := ParseNumber(bad); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
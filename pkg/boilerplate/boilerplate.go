@@ -0,0 +1,270 @@
+// Package boilerplate strips text that surrounds a document's content
+// rather than belonging to it: running headers and footers, page numbers,
+// and the menus and notices that come along with web pages.
+package boilerplate
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Rules says what Strip removes. The zero value removes nothing.
+type Rules struct {
+	// RepeatedLines removes lines repeated near the top or bottom of most
+	// pages, such as running headers and footers. It needs at least
+	// minRepeatPages pages.
+	RepeatedLines bool
+	// PageNumbers removes lines near the top or bottom of a page that hold
+	// only a page number, such as "12", "- 12 -" or "Page 12 of 40".
+	PageNumbers bool
+	// Navigation removes menus and breadcrumbs, "skip to content" links,
+	// and cookie and copyright notices.
+	Navigation bool
+	// StopPhrases removes every line containing one of them, ignoring
+	// case.
+	StopPhrases []string
+}
+
+// IsZero reports whether r removes nothing.
+func (r Rules) IsZero() bool {
+	return !r.RepeatedLines && !r.PageNumbers && !r.Navigation && len(r.StopPhrases) == 0
+}
+
+const (
+	// edgeLines is how many non-blank lines at each end of a page may be
+	// a header, footer or page number.
+	edgeLines = 3
+	// minRepeatPages is the fewest pages in which a line must repeat to be
+	// taken for a header or footer rather than content.
+	minRepeatPages = 3
+)
+
+var (
+	pageNumber = regexp.MustCompile(`(?i)^[\s\-–—]*(?:(?:page|pg\.?|p\.|página|pagina)\s*)?\d{1,4}(?:\s*(?:of|/|de)\s*\d{1,4})?[\s\-–—]*$`)
+	digits     = regexp.MustCompile(`\d+`)
+	// tableDelimiter is the row under a Markdown table's header, whose
+	// rows would otherwise pass for menus.
+	tableDelimiter = regexp.MustCompile(`^\s*\|?\s*:?-{3,}`)
+)
+
+// navigationLines are whole lines that only make sense on a web page.
+var navigationLines = map[string]bool{
+	"skip to content":      true,
+	"skip to main content": true,
+	"skip navigation":      true,
+	"back to top":          true,
+	"toggle navigation":    true,
+	"main menu":            true,
+	"menu":                 true,
+	"search":               true,
+	"share":                true,
+	"share this":           true,
+	"print":                true,
+	"print this page":      true,
+}
+
+// noticePhrases mark cookie and copyright notices wherever they appear in
+// a line.
+var noticePhrases = []string{
+	"all rights reserved",
+	"we use cookies",
+	"this site uses cookies",
+	"this website uses cookies",
+	"accept cookies",
+	"cookie policy",
+	"cookie settings",
+}
+
+// menuSeparators split menus and breadcrumbs into their items.
+var menuSeparators = []string{"|", "»", "›", " > ", "•", "·"}
+
+// Strip removes what rules select from pages, the text of one document in
+// page order, and returns the pages left and how many lines it removed.
+// Blank lines left in a row by a removal are merged into one.
+func Strip(pages []string, rules Rules) ([]string, int) {
+	if rules.IsZero() {
+		return pages, 0
+	}
+
+	lines := make([][]string, len(pages))
+	for i, page := range pages {
+		lines[i] = strings.Split(page, "\n")
+	}
+	var repeated map[string]bool
+	if rules.RepeatedLines && len(pages) >= minRepeatPages {
+		repeated = repeatedEdges(lines)
+	}
+	phrases := make([]string, 0, len(rules.StopPhrases))
+	for _, p := range rules.StopPhrases {
+		if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+			phrases = append(phrases, p)
+		}
+	}
+
+	out := make([]string, len(pages))
+	removed := 0
+	for i, page := range lines {
+		edge := edgeIndexes(page)
+		drop := make([]bool, len(page))
+		n := 0
+		for j, line := range page {
+			trimmed := strings.TrimSpace(line)
+			if trimmed == "" {
+				continue
+			}
+			switch {
+			case edge[j] && repeated[lineKey(trimmed)],
+				edge[j] && rules.PageNumbers && pageNumber.MatchString(trimmed),
+				rules.Navigation && isNavigation(page, j),
+				containsAny(strings.ToLower(trimmed), phrases):
+				drop[j] = true
+				n++
+			}
+		}
+		if n == 0 {
+			out[i] = pages[i]
+			continue
+		}
+		out[i] = join(page, drop)
+		removed += n
+	}
+	return out, removed
+}
+
+// repeatedEdges returns the keys of the lines found near the edge of at
+// least half the pages, and of no fewer than minRepeatPages.
+func repeatedEdges(pages [][]string) map[string]bool {
+	counts := make(map[string]int)
+	for _, page := range pages {
+		seen := make(map[string]bool)
+		for j, isEdge := range edgeIndexes(page) {
+			if !isEdge {
+				continue
+			}
+			key := lineKey(strings.TrimSpace(page[j]))
+			if key != "" && !seen[key] {
+				seen[key] = true
+				counts[key]++
+			}
+		}
+	}
+
+	need := max(minRepeatPages, (len(pages)+1)/2)
+	repeated := make(map[string]bool)
+	for key, n := range counts {
+		if n >= need {
+			repeated[key] = true
+		}
+	}
+	return repeated
+}
+
+// edgeIndexes marks the first and last edgeLines non-blank lines of page,
+// or a third of them each on short pages, so the middle always stays.
+func edgeIndexes(page []string) []bool {
+	nonBlank := 0
+	for _, line := range page {
+		if strings.TrimSpace(line) != "" {
+			nonBlank++
+		}
+	}
+	limit := min(edgeLines, nonBlank/3)
+
+	edge := make([]bool, len(page))
+	for j, n := 0, 0; j < len(page) && n < limit; j++ {
+		if strings.TrimSpace(page[j]) != "" {
+			edge[j] = true
+			n++
+		}
+	}
+	for j, n := len(page)-1, 0; j >= 0 && n < limit; j-- {
+		if strings.TrimSpace(page[j]) != "" {
+			edge[j] = true
+			n++
+		}
+	}
+	return edge
+}
+
+// lineKey is line compared across pages: lowercase, with spaces collapsed
+// and numbers replaced, so "Page 3 of 9" and "Page 4 of 9" repeat.
+func lineKey(line string) string {
+	line = digits.ReplaceAllString(strings.ToLower(line), "#")
+	return strings.Join(strings.Fields(line), " ")
+}
+
+// isNavigation reports whether page[j] is a menu, a breadcrumb, a web
+// page control or a cookie or copyright notice.
+func isNavigation(page []string, j int) bool {
+	line := strings.TrimSpace(page[j])
+	lower := strings.ToLower(line)
+	if navigationLines[strings.TrimRightFunc(lower, unicode.IsPunct)] {
+		return true
+	}
+	if strings.HasPrefix(lower, "©") || strings.HasPrefix(lower, "copyright ©") || containsAny(lower, noticePhrases) {
+		return true
+	}
+	if strings.HasPrefix(line, "|") || strings.HasSuffix(line, "|") || nearTableDelimiter(page, j) {
+		return false
+	}
+	return isMenu(line)
+}
+
+// isMenu reports whether line is three or more short items split by one
+// of menuSeparators, like "Home | Pricing | Contact".
+func isMenu(line string) bool {
+	for _, sep := range menuSeparators {
+		items := strings.Split(line, sep)
+		if len(items) < 3 {
+			continue
+		}
+		short := true
+		for _, item := range items {
+			if n := len(strings.Fields(item)); n == 0 || n > 3 {
+				short = false
+				break
+			}
+		}
+		if short {
+			return true
+		}
+	}
+	return false
+}
+
+func nearTableDelimiter(page []string, j int) bool {
+	return (j > 0 && tableDelimiter.MatchString(page[j-1])) ||
+		(j+1 < len(page) && tableDelimiter.MatchString(page[j+1]))
+}
+
+func containsAny(s string, phrases []string) bool {
+	for _, p := range phrases {
+		if strings.Contains(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// join rebuilds page without the dropped lines, merging the blank lines
+// left in a row and trimming those left at either end.
+func join(page []string, drop []bool) string {
+	kept := make([]string, 0, len(page))
+	blank := true
+	for j, line := range page {
+		if drop[j] {
+			continue
+		}
+		isBlank := strings.TrimSpace(line) == ""
+		if isBlank && blank {
+			continue
+		}
+		kept = append(kept, line)
+		blank = isBlank
+	}
+	for len(kept) > 0 && strings.TrimSpace(kept[len(kept)-1]) == "" {
+		kept = kept[:len(kept)-1]
+	}
+	return strings.Join(kept, "\n")
+}
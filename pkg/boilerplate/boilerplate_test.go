@@ -0,0 +1,89 @@
+package boilerplate
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestStripRepeatedLinesAndPageNumbers(t *testing.T) {
+	bodies := [][2]string{
+		{"Welcome aboard.", "Read this first."},
+		{"Leave is accrued monthly.", "Ask HR for details."},
+		{"Expenses need receipts.", "Submit them weekly."},
+		{"Laptops are provided.", "Return them on exit."},
+	}
+	pages := make([]string, 4)
+	for i := range pages {
+		pages[i] = fmt.Sprintf("ACME Corp — Employee Handbook\n\n%s\n%s\nSee the intranet.\n\nConfidential\nPage %d of 4",
+			bodies[i][0], bodies[i][1], i+1)
+	}
+
+	got, removed := Strip(pages, Rules{RepeatedLines: true, PageNumbers: true})
+	if removed != 12 {
+		t.Errorf("Expected 12 lines removed, got %d", removed)
+	}
+	want := "Leave is accrued monthly.\nAsk HR for details.\nSee the intranet."
+	if got[1] != want {
+		t.Errorf("Expected %q, got %q", want, got[1])
+	}
+}
+
+func TestStripRepeatedLinesNeedsPages(t *testing.T) {
+	pages := []string{"Header\nOne", "Header\nTwo"}
+	got, removed := Strip(pages, Rules{RepeatedLines: true})
+	if removed != 0 || got[0] != pages[0] {
+		t.Errorf("Expected two pages left alone, got %q (%d removed)", got, removed)
+	}
+}
+
+func TestStripPageNumbersOnlyAtEdges(t *testing.T) {
+	page := "- 7 -\nIntro\nThe total is\n42\nas listed.\nEnd\nNext\n12"
+	got, _ := Strip([]string{page}, Rules{PageNumbers: true})
+	want := "Intro\nThe total is\n42\nas listed.\nEnd\nNext"
+	if got[0] != want {
+		t.Errorf("Expected %q, got %q", want, got[0])
+	}
+}
+
+func TestStripNavigation(t *testing.T) {
+	page := `Skip to content
+Home | Pricing | Blog | Contact
+Home > Help > Billing
+
+# Refunds
+
+Refunds take five days.
+
+| Plan | Price | Seats |
+| --- | --- | --- |
+Basic | 10 | 1
+
+We use cookies to improve your experience.
+© 2024 Acme. All rights reserved.
+Back to top`
+
+	got, removed := Strip([]string{page}, Rules{Navigation: true})
+	want := "# Refunds\n\nRefunds take five days.\n\n| Plan | Price | Seats |\n| --- | --- | --- |\nBasic | 10 | 1"
+	if got[0] != want {
+		t.Errorf("Expected %q, got %q", want, got[0])
+	}
+	if removed != 6 {
+		t.Errorf("Expected 6 lines removed, got %d", removed)
+	}
+}
+
+func TestStripStopPhrases(t *testing.T) {
+	page := "Opening hours are 9 to 5.\nSubscribe to our NEWSLETTER today!\nClosed on Sundays."
+	got, _ := Strip([]string{page}, Rules{StopPhrases: []string{" subscribe to our newsletter ", ""}})
+	if want := "Opening hours are 9 to 5.\nClosed on Sundays."; got[0] != want {
+		t.Errorf("Expected %q, got %q", want, got[0])
+	}
+}
+
+func TestStripZeroRules(t *testing.T) {
+	pages := []string{"Home | Pricing | Blog\n1"}
+	got, removed := Strip(pages, Rules{})
+	if removed != 0 || got[0] != pages[0] {
+		t.Errorf("Expected nothing removed, got %q", got)
+	}
+}
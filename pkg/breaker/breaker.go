@@ -0,0 +1,149 @@
+// Package breaker is a circuit breaker for calls to an external API. After
+// enough consecutive failures it rejects calls outright for a cooldown,
+// then lets a single trial call through to see whether the API is back.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ErrOpen = errors.New("circuit breaker open")
+
+const (
+	defaultThreshold = 5
+	defaultCooldown  = 30 * time.Second
+)
+
+type State string
+
+const (
+	StateClosed   State = "closed"
+	StateOpen     State = "open"
+	StateHalfOpen State = "half_open"
+)
+
+// Status is a breaker's state for health output.
+type Status struct {
+	State    State      `json:"state"`
+	Failures int        `json:"failures"`
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+}
+
+type Breaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	// trial is set while the half-open trial call is in flight.
+	trial bool
+}
+
+// New returns a closed breaker that opens after threshold consecutive
+// failures and stays open for cooldown. Zero values use 5 and 30s.
+func New(name string, threshold int, cooldown time.Duration) *Breaker {
+	if threshold <= 0 {
+		threshold = defaultThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = defaultCooldown
+	}
+	return &Breaker{name: name, threshold: threshold, cooldown: cooldown, now: time.Now, state: StateClosed}
+}
+
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// Allow reports whether a call may be made. Every allowed call must be
+// followed by Done.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.cooldown {
+		b.state = StateHalfOpen
+	}
+	if b.state == StateOpen || (b.state == StateHalfOpen && b.trial) {
+		return fmt.Errorf("%w: %s", ErrOpen, b.name)
+	}
+	if b.state == StateHalfOpen {
+		b.trial = true
+	}
+	return nil
+}
+
+// Done records the outcome of an allowed call.
+func (b *Breaker) Done(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+	if success {
+		b.state, b.failures = StateClosed, 0
+		return
+	}
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= b.threshold {
+		b.state, b.openedAt = StateOpen, b.now()
+	}
+}
+
+// release ends an allowed call without an outcome.
+func (b *Breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+func (b *Breaker) Status() Status {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := Status{State: b.state, Failures: b.failures}
+	if b.state != StateClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
+
+// Transport guards next with the breaker. Network errors, timeouts, 429s
+// and 5xx responses count as failures; a call the caller cancelled does
+// not count either way.
+func (b *Breaker) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{breaker: b, next: next}
+}
+
+type transport struct {
+	breaker *Breaker
+	next    http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(req)
+	switch {
+	case err != nil && errors.Is(req.Context().Err(), context.Canceled):
+		t.breaker.release()
+	case err != nil:
+		t.breaker.Done(false)
+	default:
+		t.breaker.Done(resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500)
+	}
+	return resp, err
+}
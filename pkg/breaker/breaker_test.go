@@ -0,0 +1,83 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBreakerStates(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := New("openai", 2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("Expected call %d allowed, got %v", i, err)
+		}
+		b.Done(false)
+	}
+	if b.Status().State != StateOpen {
+		t.Fatalf("Expected open after 2 failures, got %s", b.Status().State)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Errorf("Expected ErrOpen during the cooldown, got %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("Expected a trial call after the cooldown, got %v", err)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Errorf("Expected one trial call at a time, got %v", err)
+	}
+	b.Done(false)
+	if b.Status().State != StateOpen {
+		t.Errorf("Expected a failed trial to reopen, got %s", b.Status().State)
+	}
+
+	now = now.Add(time.Minute)
+	_ = b.Allow()
+	b.Done(true)
+	if status := b.Status(); status.State != StateClosed || status.Failures != 0 || status.OpenedAt != nil {
+		t.Errorf("Expected a successful trial to close, got %+v", status)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	b := New("whatsapp", 2, time.Minute)
+	client := &http.Client{Transport: b.Transport(nil)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if _, err := client.Do(req); err == nil {
+		t.Fatal("Expected the cancelled call to fail")
+	}
+	if b.Status().Failures != 0 {
+		t.Errorf("Expected a cancelled call not to count, got %d failures", b.Status().Failures)
+	}
+
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(server.URL)
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		if i == 2 && !errors.Is(err, ErrOpen) {
+			t.Errorf("Expected ErrOpen after two 500s, got %v", err)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("Expected 2 requests to reach the server, got %d", requests)
+	}
+}
@@ -0,0 +1,57 @@
+// Package cache provides a small key/value cache with per-entry TTLs, backed
+// either by process memory or by Redis when state must be shared between
+// replicas.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrMiss is returned by Get when the key is absent or expired.
+var ErrMiss = errors.New("cache: miss")
+
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores value under key. A ttl of zero keeps the entry until it is
+	// deleted or evicted.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+	// Incr atomically increments the counter at key and returns the new
+	// value. A missing counter starts at zero and expires after ttl.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// AddMember adds member to the set at key and has the whole set expire
+	// after ttl, so a set stays while members keep being added.
+	AddMember(ctx context.Context, key, member string, ttl time.Duration) error
+	// RemoveMember takes member out of the set at key.
+	RemoveMember(ctx context.Context, key, member string) error
+	// Members returns the members of the set at key, in no order. A
+	// missing set has none.
+	Members(ctx context.Context, key string) ([]string, error)
+}
+
+// GetJSON decodes the value at key into v. It reports false on a miss.
+func GetJSON(ctx context.Context, c Cache, key string, v any) (bool, error) {
+	data, err := c.Get(ctx, key)
+	if errors.Is(err, ErrMiss) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SetJSON encodes v as JSON and stores it under key.
+func SetJSON(ctx context.Context, c Cache, key string, v any, ttl time.Duration) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.Set(ctx, key, data, ttl)
+}
@@ -0,0 +1,178 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+func (e entry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+type memberSet struct {
+	members   map[string]struct{}
+	expiresAt time.Time
+}
+
+func (s memberSet) expired(now time.Time) bool {
+	return !s.expiresAt.IsZero() && now.After(s.expiresAt)
+}
+
+// Memory is an in-process Cache. Expired entries are skipped on read and
+// swept periodically until Stop is called.
+type Memory struct {
+	mu      sync.RWMutex
+	entries map[string]entry
+	sets    map[string]memberSet
+	stopCh  chan struct{}
+}
+
+func NewMemory() *Memory {
+	m := &Memory{
+		entries: make(map[string]entry),
+		sets:    make(map[string]memberSet),
+		stopCh:  make(chan struct{}),
+	}
+
+	go m.cleanup()
+
+	return m
+}
+
+// Stop gracefully stops the cleanup goroutine
+func (m *Memory) Stop() {
+	close(m.stopCh)
+}
+
+func (m *Memory) cleanup() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case <-ticker.C:
+			now := time.Now()
+			m.mu.Lock()
+			for key, e := range m.entries {
+				if e.expired(now) {
+					delete(m.entries, key)
+				}
+			}
+			for key, set := range m.sets {
+				if set.expired(now) {
+					delete(m.sets, key)
+				}
+			}
+			m.mu.Unlock()
+		}
+	}
+}
+
+func expiry(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
+
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.RLock()
+	e, ok := m.entries[key]
+	m.mu.RUnlock()
+
+	if !ok || e.expired(time.Now()) {
+		return nil, ErrMiss
+	}
+	return e.value, nil
+}
+
+func (m *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	stored := make([]byte, len(value))
+	copy(stored, value)
+
+	m.mu.Lock()
+	m.entries[key] = entry{value: stored, expiresAt: expiry(ttl)}
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *Memory) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	delete(m.entries, key)
+	delete(m.sets, key)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *Memory) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int64
+	e, ok := m.entries[key]
+	if ok && !e.expired(time.Now()) {
+		current, err := strconv.ParseInt(string(e.value), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		n = current
+	} else {
+		e = entry{expiresAt: expiry(ttl)}
+	}
+
+	n++
+	e.value = []byte(strconv.FormatInt(n, 10))
+	m.entries[key] = e
+	return n, nil
+}
+
+func (m *Memory) AddMember(ctx context.Context, key, member string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	set, ok := m.sets[key]
+	if !ok || set.expired(time.Now()) {
+		set = memberSet{members: make(map[string]struct{})}
+	}
+	set.members[member] = struct{}{}
+	set.expiresAt = expiry(ttl)
+	m.sets[key] = set
+	return nil
+}
+
+func (m *Memory) RemoveMember(ctx context.Context, key, member string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if set, ok := m.sets[key]; ok {
+		delete(set.members, member)
+		if len(set.members) == 0 {
+			delete(m.sets, key)
+		}
+	}
+	return nil
+}
+
+func (m *Memory) Members(ctx context.Context, key string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	set, ok := m.sets[key]
+	if !ok || set.expired(time.Now()) {
+		return nil, nil
+	}
+	members := make([]string, 0, len(set.members))
+	for member := range set.members {
+		members = append(members, member)
+	}
+	return members, nil
+}
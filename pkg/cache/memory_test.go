@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemorySetGetDelete(t *testing.T) {
+	c := NewMemory()
+	defer c.Stop()
+	ctx := context.Background()
+
+	if _, err := c.Get(ctx, "missing"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Expected ErrMiss, got %v", err)
+	}
+
+	if err := c.Set(ctx, "key", []byte("value"), time.Minute); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	got, err := c.Get(ctx, "key")
+	if err != nil || string(got) != "value" {
+		t.Errorf("Expected value, got %q (%v)", got, err)
+	}
+
+	_ = c.Delete(ctx, "key")
+	if _, err := c.Get(ctx, "key"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Expected ErrMiss after delete, got %v", err)
+	}
+}
+
+func TestMemoryExpiry(t *testing.T) {
+	c := NewMemory()
+	defer c.Stop()
+	ctx := context.Background()
+
+	_ = c.Set(ctx, "short", []byte("x"), time.Millisecond)
+	_ = c.Set(ctx, "forever", []byte("y"), 0)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := c.Get(ctx, "short"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Expected expired entry to miss, got %v", err)
+	}
+	if _, err := c.Get(ctx, "forever"); err != nil {
+		t.Errorf("Expected entry without ttl to persist, got %v", err)
+	}
+}
+
+func TestMemoryIncr(t *testing.T) {
+	c := NewMemory()
+	defer c.Stop()
+	ctx := context.Background()
+
+	for i := int64(1); i <= 3; i++ {
+		n, err := c.Incr(ctx, "counter", time.Minute)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if n != i {
+			t.Errorf("Expected %d, got %d", i, n)
+		}
+	}
+
+	_, _ = c.Incr(ctx, "window", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if n, _ := c.Incr(ctx, "window", time.Millisecond); n != 1 {
+		t.Errorf("Expected counter to restart after expiry, got %d", n)
+	}
+}
+
+func TestMemoryMembers(t *testing.T) {
+	c := NewMemory()
+	defer c.Stop()
+	ctx := context.Background()
+
+	_ = c.AddMember(ctx, "set", "a", time.Minute)
+	_ = c.AddMember(ctx, "set", "b", time.Minute)
+	_ = c.AddMember(ctx, "set", "a", time.Minute)
+	members, err := c.Members(ctx, "set")
+	if err != nil || len(members) != 2 {
+		t.Errorf("Expected 2 members, got %v (%v)", members, err)
+	}
+
+	_ = c.RemoveMember(ctx, "set", "a")
+	if members, _ := c.Members(ctx, "set"); len(members) != 1 || members[0] != "b" {
+		t.Errorf("Expected only b left, got %v", members)
+	}
+
+	_ = c.AddMember(ctx, "short", "a", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if members, _ := c.Members(ctx, "short"); len(members) != 0 {
+		t.Errorf("Expected an expired set to be empty, got %v", members)
+	}
+}
+
+func TestJSONHelpers(t *testing.T) {
+	c := NewMemory()
+	defer c.Stop()
+	ctx := context.Background()
+
+	type payload struct {
+		Name string `json:"name"`
+	}
+
+	var out payload
+	if ok, err := GetJSON(ctx, c, "p", &out); ok || err != nil {
+		t.Errorf("Expected miss without error, got %v %v", ok, err)
+	}
+
+	if err := SetJSON(ctx, c, "p", payload{Name: "lucid"}, time.Minute); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if ok, err := GetJSON(ctx, c, "p", &out); !ok || err != nil || out.Name != "lucid" {
+		t.Errorf("Expected cached payload, got %+v (%v, %v)", out, ok, err)
+	}
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type RedisOptions struct {
+	Addr     string
+	Password string
+	DB       int
+	// Prefix namespaces every key, so several deployments can share a server.
+	Prefix string
+}
+
+// Redis is a Cache shared by every replica connected to the same server.
+type Redis struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedis(ctx context.Context, opts RedisOptions) (*Redis, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     opts.Addr,
+		Password: opts.Password,
+		DB:       opts.DB,
+	})
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+
+	return &Redis{client: client, prefix: opts.Prefix}, nil
+}
+
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := r.client.Get(ctx, r.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMiss
+	}
+	return data, err
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.client.Set(ctx, r.prefix+key, value, ttl).Err()
+}
+
+func (r *Redis) Delete(ctx context.Context, key string) error {
+	return r.client.Del(ctx, r.prefix+key).Err()
+}
+
+// incrScript increments a counter and gives it an expiry in the same step,
+// so a crash between the two cannot leave a counter that never expires.
+// Only a counter without an expiry gets one, so fixed windows do not slide.
+var incrScript = redis.NewScript(`
+local n = redis.call("INCR", KEYS[1])
+if tonumber(ARGV[1]) > 0 and redis.call("PTTL", KEYS[1]) == -1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return n`)
+
+func (r *Redis) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return incrScript.Run(ctx, r.client, []string{r.prefix + key}, ttl.Milliseconds()).Int64()
+}
+
+func (r *Redis) AddMember(ctx context.Context, key, member string, ttl time.Duration) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SAdd(ctx, r.prefix+key, member)
+		if ttl > 0 {
+			pipe.PExpire(ctx, r.prefix+key, ttl)
+		}
+		return nil
+	})
+	return err
+}
+
+func (r *Redis) RemoveMember(ctx context.Context, key, member string) error {
+	return r.client.SRem(ctx, r.prefix+key, member).Err()
+}
+
+func (r *Redis) Members(ctx context.Context, key string) ([]string, error) {
+	return r.client.SMembers(ctx, r.prefix+key).Result()
+}
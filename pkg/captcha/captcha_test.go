@@ -0,0 +1,60 @@
+package captcha
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("Failed to parse form: %v", err)
+		}
+		if r.PostForm.Get("secret") != "site-secret" || r.PostForm.Get("remoteip") != "203.0.113.7" {
+			t.Errorf("Unexpected form %v", r.PostForm)
+		}
+		if r.PostForm.Get("response") == "good" {
+			w.Write([]byte(`{"success":true}`))
+			return
+		}
+		w.Write([]byte(`{"success":false,"error-codes":["invalid-input-response"]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Turnstile, "site-secret", WithVerifyURL(server.URL))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	ctx := context.Background()
+	if err := client.Verify(ctx, "good", "203.0.113.7"); err != nil {
+		t.Errorf("Expected the response accepted, got %v", err)
+	}
+	if err := client.Verify(ctx, "bad", "203.0.113.7"); !errors.Is(err, ErrFailed) {
+		t.Errorf("Expected ErrFailed, got %v", err)
+	}
+	if err := client.Verify(ctx, "", "203.0.113.7"); !errors.Is(err, ErrFailed) {
+		t.Errorf("Expected ErrFailed without a response, got %v", err)
+	}
+}
+
+func TestVerifyUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(HCaptcha, "site-secret", WithVerifyURL(server.URL))
+	err := client.Verify(context.Background(), "good", "")
+	if err == nil || errors.Is(err, ErrFailed) {
+		t.Errorf("Expected an outage error other than ErrFailed, got %v", err)
+	}
+}
+
+func TestNewClientUnknownProvider(t *testing.T) {
+	if _, err := NewClient("recaptcha", "secret"); err == nil {
+		t.Error("Expected an error for an unknown provider")
+	}
+}
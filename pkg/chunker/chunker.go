@@ -8,6 +8,9 @@ import (
 type Chunker struct {
 	ChunkSize    int
 	ChunkOverlap int
+	// MaxTableRows caps the rows in a table chunk; zero leaves only
+	// ChunkSize as the limit.
+	MaxTableRows int
 }
 
 func New(chunkSize, chunkOverlap int) *Chunker {
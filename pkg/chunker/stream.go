@@ -0,0 +1,67 @@
+package chunker
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// maxWordBytes bounds a single word, so a body without whitespace cannot
+// grow the buffer without limit.
+const maxWordBytes = 1 << 20
+
+// Stream splits text read from a reader into the same chunks as Chunk,
+// holding only the current chunk's words in memory. Tables are not
+// recognised, as ChunkStructured would need the whole text.
+type Stream struct {
+	scanner *bufio.Scanner
+	size    int
+	overlap int
+	words   []string
+	// fresh counts the words read since the last chunk was returned.
+	fresh int
+	done  bool
+}
+
+// NewStream returns a Stream reading from r.
+func (c *Chunker) NewStream(r io.Reader) *Stream {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxWordBytes)
+	scanner.Split(bufio.ScanWords)
+	return &Stream{
+		scanner: scanner,
+		size:    c.ChunkSize,
+		overlap: c.ChunkOverlap,
+	}
+}
+
+// Next returns the next chunk, or io.EOF once the text is used up.
+func (s *Stream) Next() (string, error) {
+	for !s.done {
+		if len(s.words) >= s.size {
+			chunk := strings.Join(s.words[:s.size], " ")
+			step := max(s.size-s.overlap, 1)
+			s.words = append(s.words[:0], s.words[step:]...)
+			s.fresh = 0
+			return chunk, nil
+		}
+		if !s.scanner.Scan() {
+			if err := s.scanner.Err(); err != nil {
+				return "", err
+			}
+			s.done = true
+			break
+		}
+		s.words = append(s.words, s.scanner.Text())
+		s.fresh++
+	}
+
+	// The last chunk holds whatever followed the one before; words that
+	// chunk already held as overlap are not repeated on their own.
+	if s.fresh == 0 {
+		return "", io.EOF
+	}
+	chunk := strings.Join(s.words, " ")
+	s.words, s.fresh = nil, 0
+	return chunk, nil
+}
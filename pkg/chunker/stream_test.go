@@ -0,0 +1,38 @@
+package chunker
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestStreamMatchesChunk(t *testing.T) {
+	for _, words := range []int{0, 1, 7, 10, 11, 24, 25, 103} {
+		var b strings.Builder
+		for i := range words {
+			fmt.Fprintf(&b, "word%d\n\t ", i)
+		}
+		text := b.String()
+
+		for _, c := range []*Chunker{New(10, 0), New(10, 3), New(5, 4)} {
+			want := c.Chunk(text)
+			var got []string
+			stream := c.NewStream(strings.NewReader(text))
+			for {
+				chunk, err := stream.Next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				got = append(got, chunk)
+			}
+			if strings.Join(got, "|") != strings.Join(want, "|") {
+				t.Errorf("%d words, size %d overlap %d: expected %q, got %q", words, c.ChunkSize, c.ChunkOverlap, want, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package chunker
+
+import (
+	"regexp"
+	"strings"
+)
+
+type Kind string
+
+const (
+	KindText  Kind = "text"
+	KindTable Kind = "table"
+)
+
+// Table describes the rows a table chunk holds.
+type Table struct {
+	// Index numbers the tables in the text, from 0.
+	Index   int
+	Columns []string
+	// FirstRow and LastRow are the data rows covered, from 1.
+	FirstRow int
+	LastRow  int
+}
+
+type StructuredChunk struct {
+	Content string
+	Kind    Kind
+	Table   *Table
+}
+
+// minLayoutRows is how many consecutive aligned lines it takes to treat
+// plain text as a table rather than prose that happens to contain wide
+// gaps.
+const minLayoutRows = 3
+
+var (
+	cellGap       = regexp.MustCompile(`\t|\s{2,}`)
+	separatorCell = regexp.MustCompile(`^:?-{3,}:?$`)
+)
+
+// ChunkStructured splits text like Chunk, except that tables are kept out of
+// the word stream. Each table is serialized as markdown, with its header
+// repeated in every chunk, and split only between rows. Tables are
+// recognised as markdown pipe tables or as runs of lines whose columns are
+// separated by tabs or wide gaps, as pdftotext -layout produces. Prose also
+// starts a new chunk at every markdown heading, so an edit to one section
+// leaves the chunks of the others as they were.
+func (c *Chunker) ChunkStructured(text string) []StructuredChunk {
+	var (
+		chunks []StructuredChunk
+		prose  []string
+		tables int
+	)
+	flushProse := func() {
+		for _, content := range c.Chunk(strings.Join(prose, "\n")) {
+			chunks = append(chunks, StructuredChunk{Content: content, Kind: KindText})
+		}
+		prose = prose[:0]
+	}
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); {
+		rows, n := tableAt(lines, i)
+		if n == 0 {
+			if isHeading(lines[i]) {
+				flushProse()
+			}
+			prose = append(prose, lines[i])
+			i++
+			continue
+		}
+		flushProse()
+		chunks = append(chunks, c.tableChunks(rows, tables)...)
+		tables++
+		i += n
+	}
+	flushProse()
+	return chunks
+}
+
+// isHeading reports whether line is a markdown ATX heading, such as
+// "## Returns".
+func isHeading(line string) bool {
+	line = strings.TrimLeft(line, " ")
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	return level >= 1 && level <= 6 && (len(line) == level || line[level] == ' ' || line[level] == '\t')
+}
+
+// tableAt returns the rows of a table starting at lines[start] and how many
+// lines it spans, or zero lines when none starts there.
+func tableAt(lines []string, start int) ([][]string, int) {
+	if cells := pipeCells(lines[start]); cells != nil {
+		var rows [][]string
+		n := 0
+		for ; start+n < len(lines); n++ {
+			cells := pipeCells(lines[start+n])
+			if cells == nil {
+				break
+			}
+			if !isSeparatorRow(cells) {
+				rows = append(rows, cells)
+			}
+		}
+		if len(rows) >= 2 {
+			return rows, n
+		}
+		return nil, 0
+	}
+
+	first := layoutCells(lines[start])
+	if len(first) < 2 {
+		return nil, 0
+	}
+	rows := [][]string{first}
+	for n := 1; start+n < len(lines); n++ {
+		cells := layoutCells(lines[start+n])
+		if len(cells) != len(first) {
+			break
+		}
+		rows = append(rows, cells)
+	}
+	if len(rows) < minLayoutRows {
+		return nil, 0
+	}
+	return rows, len(rows)
+}
+
+func pipeCells(line string) []string {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "|") || strings.Count(line, "|") < 3 {
+		return nil
+	}
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = strings.TrimSuffix(line, "|")
+	}
+
+	// Escaped pipes belong to the cell.
+	var (
+		cells []string
+		cell  strings.Builder
+	)
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteString(`\|`)
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+func layoutCells(line string) []string {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil
+	}
+	return cellGap.Split(line, -1)
+}
+
+func isSeparatorRow(cells []string) bool {
+	for _, cell := range cells {
+		if !separatorCell.MatchString(cell) {
+			return false
+		}
+	}
+	return true
+}
+
+// tableChunks groups the data rows of a table into chunks of at most
+// ChunkSize words, counting the header each chunk repeats, and at most
+// MaxTableRows rows when that is set.
+func (c *Chunker) tableChunks(rows [][]string, index int) []StructuredChunk {
+	header, data := rows[0], rows[1:]
+	headerWords := len(tokenize(strings.Join(header, " ")))
+
+	var chunks []StructuredChunk
+	for first := 0; first < len(data); {
+		last, words := first, headerWords
+		for last < len(data) {
+			rowWords := len(tokenize(strings.Join(data[last], " ")))
+			if last > first && (words+rowWords > c.ChunkSize || (c.MaxTableRows > 0 && last-first >= c.MaxTableRows)) {
+				break
+			}
+			words += rowWords
+			last++
+		}
+		chunks = append(chunks, StructuredChunk{
+			Content: markdownTable(header, data[first:last]),
+			Kind:    KindTable,
+			Table: &Table{
+				Index:    index,
+				Columns:  header,
+				FirstRow: first + 1,
+				LastRow:  last,
+			},
+		})
+		first = last
+	}
+	return chunks
+}
+
+func markdownTable(header []string, rows [][]string) string {
+	var b strings.Builder
+	writeRow := func(cells []string) {
+		b.WriteString("|")
+		for i := range header {
+			cell := ""
+			if i < len(cells) {
+				cell = escapePipes(cells[i])
+			}
+			b.WriteString(" " + cell + " |")
+		}
+		b.WriteString("\n")
+	}
+
+	writeRow(header)
+	b.WriteString("|" + strings.Repeat(" --- |", len(header)) + "\n")
+	for _, row := range rows {
+		writeRow(row)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// escapePipes escapes the pipes in a cell, leaving ones that already are.
+func escapePipes(cell string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(cell, `\|`, "|"), "|", `\|`)
+}
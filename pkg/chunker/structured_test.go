@@ -0,0 +1,102 @@
+package chunker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChunkStructuredLayoutTable(t *testing.T) {
+	text := `Prices below apply from March.
+
+Item          Size     Price
+Espresso      Small    2.50
+Latte         Large    4.10
+Cold brew     Medium   3.75
+
+Prices include tax.`
+
+	chunks := New(512, 0).ChunkStructured(text)
+	if len(chunks) != 3 {
+		t.Fatalf("Expected prose, table and prose chunks, got %d: %+v", len(chunks), chunks)
+	}
+	if chunks[0].Kind != KindText || chunks[2].Kind != KindText {
+		t.Errorf("Expected prose around the table, got %s and %s", chunks[0].Kind, chunks[2].Kind)
+	}
+
+	table := chunks[1]
+	if table.Kind != KindTable || table.Table == nil {
+		t.Fatalf("Expected a table chunk, got %+v", table)
+	}
+	if !strings.Contains(table.Content, "| Latte | Large | 4.10 |") {
+		t.Errorf("Expected rows serialized as markdown, got:\n%s", table.Content)
+	}
+	if strings.Join(table.Table.Columns, ",") != "Item,Size,Price" || table.Table.FirstRow != 1 || table.Table.LastRow != 3 {
+		t.Errorf("Unexpected table metadata %+v", table.Table)
+	}
+}
+
+func TestChunkStructuredSplitsLargeTablesByRow(t *testing.T) {
+	text := `| SKU | Name | Stock |
+|-----|------|-------|
+| A1 | Red widget | 10 |
+| A2 | Blue widget | 0 |
+| A3 | Green widget | 7 |`
+
+	chunks := New(8, 0).ChunkStructured(text)
+	if len(chunks) != 3 {
+		t.Fatalf("Expected one chunk per row, got %d: %+v", len(chunks), chunks)
+	}
+	for i, c := range chunks {
+		if !strings.HasPrefix(c.Content, "| SKU | Name | Stock |\n| --- | --- | --- |") {
+			t.Errorf("Expected header repeated in chunk %d, got:\n%s", i, c.Content)
+		}
+		if c.Table.FirstRow != i+1 || c.Table.LastRow != i+1 || c.Table.Index != 0 {
+			t.Errorf("Unexpected table metadata in chunk %d: %+v", i, c.Table)
+		}
+	}
+}
+
+func TestChunkStructuredLeavesProseAlone(t *testing.T) {
+	text := "First sentence.  Second sentence after two spaces.\nA single line is not a table."
+
+	chunks := New(512, 0).ChunkStructured(text)
+	if len(chunks) != 1 || chunks[0].Kind != KindText {
+		t.Errorf("Expected a single prose chunk, got %+v", chunks)
+	}
+}
+
+func TestChunkStructuredSplitsProseAtHeadings(t *testing.T) {
+	text := "Intro line.\n## Shipping\nOrders ship in two days.\n#hashtag stays inline.\n### Returns\nThirty days."
+
+	chunks := New(512, 0).ChunkStructured(text)
+	want := []string{"Intro line.", "## Shipping Orders ship in two days. #hashtag stays inline.", "### Returns Thirty days."}
+	if len(chunks) != len(want) {
+		t.Fatalf("Expected %d chunks, got %+v", len(want), chunks)
+	}
+	for i, c := range chunks {
+		if c.Content != want[i] {
+			t.Errorf("Chunk %d: expected %q, got %q", i, want[i], c.Content)
+		}
+	}
+}
+
+func TestChunkStructuredMaxTableRows(t *testing.T) {
+	text := `| SKU | Name |
+| --- | --- |
+| A1 | Red \| blue widget |
+| A2 | Green widget |
+| A3 | Yellow widget |`
+
+	c := New(512, 0)
+	c.MaxTableRows = 2
+	chunks := c.ChunkStructured(text)
+	if len(chunks) != 2 {
+		t.Fatalf("Expected two row groups, got %d: %+v", len(chunks), chunks)
+	}
+	if chunks[0].Table.LastRow != 2 || chunks[1].Table.FirstRow != 3 {
+		t.Errorf("Unexpected row ranges %+v and %+v", chunks[0].Table, chunks[1].Table)
+	}
+	if !strings.Contains(chunks[0].Content, `| A1 | Red \| blue widget |`) || len(chunks[0].Table.Columns) != 2 {
+		t.Errorf("Expected escaped pipe kept inside its cell, got:\n%s", chunks[0].Content)
+	}
+}
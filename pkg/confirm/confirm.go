@@ -0,0 +1,168 @@
+// Package confirm issues and checks the tokens that confirm destructive
+// admin actions. Calling such an action without a token only previews it
+// and hands out a token bound to the admin, the action, its target and
+// what the preview showed; calling it again with the token carries it out.
+// Tokens are signed rather than stored, so any instance can check them;
+// the ones used are recorded in a cache until they expire, so each works
+// once.
+package confirm
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/pkg/cache"
+)
+
+// Header carries the token on the confirming call.
+const Header = "X-Confirm-Token"
+
+// DefaultTTL is how long a token stays valid when New is given no TTL.
+const DefaultTTL = 10 * time.Minute
+
+// version is the token format. Tokens of another version are refused, so
+// changing what a token covers only costs admins a new preview.
+const version = "v2"
+
+var (
+	ErrInvalidToken = errors.New("invalid confirmation token")
+	ErrExpiredToken = errors.New("confirmation token expired")
+	// ErrStale is returned when what the action would do changed since
+	// the token was issued, for example when another admin acted first.
+	ErrStale = errors.New("the preview changed since the confirmation token was issued")
+)
+
+// Action names what a token confirms.
+type Action struct {
+	// Name is the kind of action, such as "user_data_purge".
+	Name string
+	// Actor is the admin the token is issued to.
+	Actor string
+	// Target is what the action applies to, such as a user ID and the
+	// kinds of data to delete.
+	Target string
+}
+
+// Token is a confirmation handed out with a preview.
+type Token struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type Signer struct {
+	key  []byte
+	ttl  time.Duration
+	used cache.Cache
+	now  func() time.Time
+}
+
+// New returns a Signer whose tokens last ttl. The signing key is derived
+// from secret, so secret can be shared with other uses. Tokens are marked
+// used in used, shared by all instances; with a nil used a token can be
+// replayed until it expires.
+func New(secret string, ttl time.Duration, used cache.Cache) *Signer {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("lucidrag confirmation tokens"))
+	return &Signer{key: mac.Sum(nil), ttl: ttl, used: used, now: time.Now}
+}
+
+// Issue returns a token confirming action as previewed.
+func (s *Signer) Issue(action Action, preview any) (Token, error) {
+	digest, err := previewDigest(preview)
+	if err != nil {
+		return Token{}, err
+	}
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return Token{}, err
+	}
+	expires := s.now().Add(s.ttl).Truncate(time.Second)
+	payload := strings.Join([]string{version, strconv.FormatInt(expires.Unix(), 10), hex.EncodeToString(id), actionDigest(action), digest}, ".")
+	return Token{Token: payload + "." + s.sign(payload), ExpiresAt: expires}, nil
+}
+
+// Verify checks that token was issued by s for action, has not expired and
+// that preview, taken again just now, matches the one it was issued for,
+// then marks it used. A token already used is invalid.
+func (s *Signer) Verify(ctx context.Context, token string, action Action, preview any) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 6 || parts[0] != version {
+		return ErrInvalidToken
+	}
+	payload := strings.Join(parts[:5], ".")
+	if !hmac.Equal([]byte(s.sign(payload)), []byte(parts[5])) {
+		return ErrInvalidToken
+	}
+	if parts[3] != actionDigest(action) {
+		return fmt.Errorf("%w: it was issued for another action", ErrInvalidToken)
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	remaining := time.Unix(expires, 0).Sub(s.now())
+	if remaining <= 0 {
+		return ErrExpiredToken
+	}
+
+	digest, err := previewDigest(preview)
+	if err != nil {
+		return err
+	}
+	if parts[4] != digest {
+		return ErrStale
+	}
+	return s.markUsed(ctx, parts[2], remaining)
+}
+
+// markUsed records the token id as used for the rest of its lifetime. The
+// count is atomic, so of two calls racing with one token only the first
+// gets through.
+func (s *Signer) markUsed(ctx context.Context, id string, remaining time.Duration) error {
+	if s.used == nil {
+		return nil
+	}
+	n, err := s.used.Incr(ctx, "confirm:used:"+id, remaining)
+	if err != nil {
+		return fmt.Errorf("record confirmation token: %w", err)
+	}
+	if n > 1 {
+		return fmt.Errorf("%w: it was already used", ErrInvalidToken)
+	}
+	return nil
+}
+
+func (s *Signer) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func actionDigest(action Action) string {
+	sum := sha256.Sum256([]byte(action.Name + "\x00" + action.Actor + "\x00" + action.Target))
+	return hex.EncodeToString(sum[:12])
+}
+
+// previewDigest fingerprints preview by its JSON, which encodes struct
+// fields in order and map keys sorted, so equal previews match.
+func previewDigest(preview any) (string, error) {
+	data, err := json.Marshal(preview)
+	if err != nil {
+		return "", fmt.Errorf("encode preview: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:12]), nil
+}
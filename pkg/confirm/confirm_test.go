@@ -0,0 +1,96 @@
+package confirm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/pkg/cache"
+)
+
+type preview struct {
+	Documents int `json:"documents"`
+}
+
+func TestIssueAndVerify(t *testing.T) {
+	ctx := context.Background()
+	s := New("secret", time.Minute, nil)
+	purge := Action{Name: "user_data_purge", Actor: "admin-1", Target: "user-1:documents"}
+
+	token, err := s.Issue(purge, preview{Documents: 3})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if token.ExpiresAt.Before(time.Now()) {
+		t.Errorf("Expected an expiry in the future, got %v", token.ExpiresAt)
+	}
+	if err := s.Verify(ctx, token.Token, purge, preview{Documents: 3}); err != nil {
+		t.Errorf("Expected the token to confirm the purge, got %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		action  Action
+		preview preview
+		want    error
+	}{
+		{"other admin", token.Token, Action{Name: purge.Name, Actor: "admin-2", Target: purge.Target}, preview{Documents: 3}, ErrInvalidToken},
+		{"other target", token.Token, Action{Name: purge.Name, Actor: purge.Actor, Target: "user-2:documents"}, preview{Documents: 3}, ErrInvalidToken},
+		{"other action", token.Token, Action{Name: "chunk_gc", Actor: purge.Actor, Target: purge.Target}, preview{Documents: 3}, ErrInvalidToken},
+		{"changed preview", token.Token, purge, preview{Documents: 4}, ErrStale},
+		{"tampered", strings.Replace(token.Token, ".", ".9", 1), purge, preview{Documents: 3}, ErrInvalidToken},
+		{"old version", "v1" + strings.TrimPrefix(token.Token, "v2"), purge, preview{Documents: 3}, ErrInvalidToken},
+		{"garbage", "not-a-token", purge, preview{Documents: 3}, ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.Verify(ctx, tt.token, tt.action, tt.preview); !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+
+	if err := New("other-secret", time.Minute, nil).Verify(ctx, token.Token, purge, preview{Documents: 3}); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected a token signed with another secret refused, got %v", err)
+	}
+}
+
+func TestVerifyExpired(t *testing.T) {
+	ctx := context.Background()
+	s := New("secret", time.Minute, nil)
+	action := Action{Name: "chunk_gc", Actor: "admin-1"}
+	token, _ := s.Issue(action, preview{})
+
+	s.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if err := s.Verify(ctx, token.Token, action, preview{}); !errors.Is(err, ErrExpiredToken) {
+		t.Errorf("Expected ErrExpiredToken, got %v", err)
+	}
+}
+
+func TestVerifyOnce(t *testing.T) {
+	ctx := context.Background()
+	used := cache.NewMemory()
+	t.Cleanup(used.Stop)
+	s := New("secret", time.Minute, used)
+	action := Action{Name: "chunk_gc", Actor: "admin-1"}
+	token, _ := s.Issue(action, preview{})
+
+	if err := s.Verify(ctx, token.Token, action, preview{}); err != nil {
+		t.Fatalf("Expected the first use accepted, got %v", err)
+	}
+	// Another instance sharing the cache refuses it too.
+	if err := New("secret", time.Minute, used).Verify(ctx, token.Token, action, preview{}); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected a replayed token refused, got %v", err)
+	}
+
+	again, _ := s.Issue(action, preview{})
+	if again.Token == token.Token {
+		t.Fatal("Expected each preview to hand out a new token")
+	}
+	if err := s.Verify(ctx, again.Token, action, preview{}); err != nil {
+		t.Errorf("Expected a new token accepted, got %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+// Package extract turns uploaded files into plain text. PDFs are read with
+// poppler's command-line tools, and pages without a usable text layer, as
+// well as images, are run through tesseract OCR.
+package extract
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported file type")
+	ErrNoText          = errors.New("no text found")
+	ErrMalformed       = errors.New("malformed file")
+)
+
+// Runner runs an external command, feeding it stdin, and returns its
+// standard output.
+type Runner func(ctx context.Context, stdin []byte, name string, args ...string) ([]byte, error)
+
+type Config struct {
+	// OCR enables tesseract for images and near-empty PDF pages.
+	OCR bool
+	// Language is the default tesseract language, e.g. "eng" or "eng+spa".
+	Language string
+	// MinChars is the number of non-space characters below which a PDF
+	// page is treated as scanned.
+	MinChars int
+	// DPI is the resolution PDF pages are rendered at for OCR.
+	DPI int
+
+	TesseractPath string
+	PdftotextPath string
+	PdftoppmPath  string
+
+	// Run executes the tools above; it defaults to os/exec.
+	Run Runner
+}
+
+// Options are per-file extraction hints.
+type Options struct {
+	// Filename is used to recognise the file when its content type is
+	// missing or generic.
+	Filename string
+	// Language overrides Config.Language for this file.
+	Language string
+	// PageLanguages overrides the language for individual pages, numbered
+	// from 1.
+	PageLanguages map[int]string
+}
+
+type Page struct {
+	Number   int    `json:"number"`
+	Text     string `json:"-"`
+	OCR      bool   `json:"ocr,omitempty"`
+	Language string `json:"language,omitempty"`
+}
+
+type Result struct {
+	ContentType string
+	Pages       []Page
+}
+
+// Text joins the pages, separated by blank lines.
+func (r *Result) Text() string {
+	parts := make([]string, 0, len(r.Pages))
+	for _, p := range r.Pages {
+		if text := strings.TrimSpace(p.Text); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// OCRPages returns the numbers of the pages whose text came from OCR.
+func (r *Result) OCRPages() []int {
+	var pages []int
+	for _, p := range r.Pages {
+		if p.OCR {
+			pages = append(pages, p.Number)
+		}
+	}
+	return pages
+}
+
+type Extractor struct {
+	cfg Config
+}
+
+func New(cfg Config) *Extractor {
+	if cfg.Language == "" {
+		cfg.Language = "eng"
+	}
+	if cfg.MinChars <= 0 {
+		cfg.MinChars = 32
+	}
+	if cfg.DPI <= 0 {
+		cfg.DPI = 300
+	}
+	if cfg.TesseractPath == "" {
+		cfg.TesseractPath = "tesseract"
+	}
+	if cfg.PdftotextPath == "" {
+		cfg.PdftotextPath = "pdftotext"
+	}
+	if cfg.PdftoppmPath == "" {
+		cfg.PdftoppmPath = "pdftoppm"
+	}
+	if cfg.Run == nil {
+		cfg.Run = runCommand
+	}
+	return &Extractor{cfg: cfg}
+}
+
+// extensionTypes maps the file extensions whose content types browsers and
+// clients commonly get wrong.
+var extensionTypes = map[string]string{
+	".csv":  mimeCSV,
+	".tsv":  mimeTSV,
+	".xlsx": mimeXLSX,
+	".md":   "text/markdown",
+	".pdf":  "application/pdf",
+}
+
+// Extract reads data as contentType. When the type is missing or generic
+// it is taken from the file extension, or sniffed from the data.
+func (e *Extractor) Extract(ctx context.Context, data []byte, contentType string, opts Options) (*Result, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "", "application/octet-stream", "application/zip", "text/plain", "application/vnd.ms-excel":
+		if t, ok := extensionTypes[strings.ToLower(filepath.Ext(opts.Filename))]; ok {
+			mediaType = t
+		} else if mediaType == "" || mediaType == "application/octet-stream" {
+			mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(data))
+		}
+	}
+
+	var (
+		pages []Page
+		err   error
+	)
+	switch {
+	case mediaType == "application/pdf":
+		pages, err = e.extractPDF(ctx, data, opts)
+	case strings.HasPrefix(mediaType, "image/"):
+		if !e.cfg.OCR {
+			return nil, fmt.Errorf("%w: %s (OCR is disabled)", ErrUnsupportedType, mediaType)
+		}
+		var page Page
+		page, err = e.ocr(ctx, data, 1, opts)
+		pages = []Page{page}
+	case mediaType == mimeCSV || mediaType == mimeTSV:
+		var comma rune
+		if mediaType == mimeTSV {
+			comma = '\t'
+		}
+		var sheets []sheet
+		if sheets, err = parseCSV(data, comma); err == nil {
+			pages = sheetPages(sheets)
+		}
+	case mediaType == mimeXLSX:
+		var sheets []sheet
+		if sheets, err = parseXLSX(data); err == nil {
+			pages = sheetPages(sheets)
+		}
+	case mediaType == "text/plain" || mediaType == "text/markdown":
+		pages = []Page{{Number: 1, Text: string(data)}}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, mediaType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{ContentType: mediaType, Pages: pages}
+	if strings.TrimSpace(result.Text()) == "" {
+		return nil, ErrNoText
+	}
+	return result, nil
+}
+
+func (e *Extractor) ocr(ctx context.Context, image []byte, number int, opts Options) (Page, error) {
+	lang := pageLanguage(e.cfg.Language, opts, number)
+	out, err := e.cfg.Run(ctx, image, e.cfg.TesseractPath, "stdin", "stdout", "-l", lang)
+	if err != nil {
+		return Page{}, fmt.Errorf("ocr page %d: %w", number, err)
+	}
+	return Page{Number: number, Text: string(out), OCR: true, Language: lang}, nil
+}
+
+func pageLanguage(fallback string, opts Options, number int) string {
+	if lang := opts.PageLanguages[number]; lang != "" {
+		return lang
+	}
+	if opts.Language != "" {
+		return opts.Language
+	}
+	return fallback
+}
+
+// nearEmpty reports whether text has fewer than minChars visible characters.
+func nearEmpty(text string, minChars int) bool {
+	n := 0
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			n++
+			if n >= minChars {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func runCommand(ctx context.Context, stdin []byte, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return stdout.Bytes(), nil
+}
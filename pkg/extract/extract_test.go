@@ -0,0 +1,98 @@
+package extract
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// fakeTools stands in for pdftotext, pdftoppm and tesseract.
+type fakeTools struct {
+	pdfText   string
+	languages []string
+}
+
+func (f *fakeTools) run(ctx context.Context, stdin []byte, name string, args ...string) ([]byte, error) {
+	switch name {
+	case "pdftotext":
+		return []byte(f.pdfText), nil
+	case "pdftoppm":
+		prefix := args[len(args)-1]
+		return nil, os.WriteFile(prefix+".png", []byte("page "+args[1]), 0o600)
+	case "tesseract":
+		f.languages = append(f.languages, args[len(args)-1])
+		return []byte("scanned " + string(stdin)), nil
+	}
+	return nil, errors.New("unexpected command " + name)
+}
+
+func TestExtractPDFOCRsScannedPages(t *testing.T) {
+	tools := &fakeTools{pdfText: "Chapter one has a real text layer with plenty of words.\f  \f"}
+	e := New(Config{OCR: true, Run: tools.run})
+
+	result, err := e.Extract(context.Background(), []byte("%PDF-1.7"), "", Options{
+		Language:      "eng",
+		PageLanguages: map[int]string{2: "deu"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.ContentType != "application/pdf" || len(result.Pages) != 2 {
+		t.Fatalf("Expected 2 pdf pages, got %s with %d", result.ContentType, len(result.Pages))
+	}
+	if result.Pages[0].OCR || !result.Pages[1].OCR {
+		t.Errorf("Expected only page 2 to be OCRed, got %+v", result.Pages)
+	}
+	if result.Pages[1].Text != "scanned page 2" {
+		t.Errorf("Expected rendered page 2 to be OCRed, got %q", result.Pages[1].Text)
+	}
+	if len(tools.languages) != 1 || tools.languages[0] != "deu" {
+		t.Errorf("Expected page language hint deu, got %v", tools.languages)
+	}
+	if !strings.HasPrefix(result.Text(), "Chapter one") || len(result.OCRPages()) != 1 {
+		t.Errorf("Unexpected text %q", result.Text())
+	}
+}
+
+func TestRenderPage(t *testing.T) {
+	image, err := New(Config{Run: (&fakeTools{}).run}).RenderPage(context.Background(), []byte("%PDF-1.7"), 3)
+	if err != nil || string(image) != "page 3" {
+		t.Errorf("Expected page 3 to be rendered, got %q, %v", image, err)
+	}
+}
+
+func TestExtractWithoutOCR(t *testing.T) {
+	tools := &fakeTools{pdfText: " \f"}
+	e := New(Config{Run: tools.run})
+
+	if _, err := e.Extract(context.Background(), []byte("%PDF-1.7"), "application/pdf", Options{}); !errors.Is(err, ErrNoText) {
+		t.Errorf("Expected ErrNoText, got %v", err)
+	}
+	if _, err := e.Extract(context.Background(), []byte("\x89PNG\r\n\x1a\n"), "", Options{}); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Expected ErrUnsupportedType for images, got %v", err)
+	}
+}
+
+func TestExtractImageAndText(t *testing.T) {
+	tools := &fakeTools{}
+	e := New(Config{OCR: true, Language: "spa", Run: tools.run})
+
+	result, err := e.Extract(context.Background(), []byte("receipt"), "image/jpeg", Options{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Text() != "scanned receipt" || tools.languages[0] != "spa" {
+		t.Errorf("Expected OCR with default language, got %q in %v", result.Text(), tools.languages)
+	}
+
+	result, err = e.Extract(context.Background(), []byte("plain notes"), "text/plain; charset=utf-8", Options{})
+	if err != nil || result.Text() != "plain notes" {
+		t.Errorf("Expected plain text passthrough, got %v, %v", result, err)
+	}
+
+	if _, err := e.Extract(context.Background(), []byte("PK\x03\x04"), "application/zip", Options{}); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Expected ErrUnsupportedType, got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package extract
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// extractPDF reads the text layer page by page and OCRs the pages that have
+// next to none, which is what a scanned page looks like.
+func (e *Extractor) extractPDF(ctx context.Context, data []byte, opts Options) ([]Page, error) {
+	dir, err := os.MkdirTemp("", "extract-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	input := filepath.Join(dir, "input.pdf")
+	if err := os.WriteFile(input, data, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write pdf: %w", err)
+	}
+
+	out, err := e.cfg.Run(ctx, nil, e.cfg.PdftotextPath, "-layout", "-enc", "UTF-8", input, "-")
+	if err != nil {
+		return nil, fmt.Errorf("read pdf text: %w", err)
+	}
+
+	// pdftotext ends every page with a form feed.
+	texts := strings.Split(string(out), "\f")
+	if len(texts) > 1 && strings.TrimSpace(texts[len(texts)-1]) == "" {
+		texts = texts[:len(texts)-1]
+	}
+
+	pages := make([]Page, 0, len(texts))
+	for i, text := range texts {
+		number := i + 1
+		if !e.cfg.OCR || !nearEmpty(text, e.cfg.MinChars) {
+			pages = append(pages, Page{Number: number, Text: text})
+			continue
+		}
+
+		image, err := e.renderPage(ctx, dir, input, number)
+		if err != nil {
+			return nil, err
+		}
+		page, err := e.ocr(ctx, image, number, opts)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, page)
+	}
+	return pages, nil
+}
+
+// previewDPI is the resolution of page previews, enough to read a diagram
+// on a phone.
+const previewDPI = 110
+
+// RenderPage renders page number of a PDF, counted from 1, as a color PNG
+// for people to look at.
+func (e *Extractor) RenderPage(ctx context.Context, data []byte, number int) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "extract-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	input := filepath.Join(dir, "input.pdf")
+	if err := os.WriteFile(input, data, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write pdf: %w", err)
+	}
+
+	prefix := filepath.Join(dir, "preview")
+	n := strconv.Itoa(number)
+	if _, err := e.cfg.Run(ctx, nil, e.cfg.PdftoppmPath,
+		"-f", n, "-l", n, "-r", strconv.Itoa(previewDPI), "-png", "-singlefile", input, prefix,
+	); err != nil {
+		return nil, fmt.Errorf("render page %d: %w", number, err)
+	}
+	image, err := os.ReadFile(prefix + ".png")
+	if err != nil {
+		return nil, fmt.Errorf("render page %d: %w", number, err)
+	}
+	return image, nil
+}
+
+func (e *Extractor) renderPage(ctx context.Context, dir, input string, number int) ([]byte, error) {
+	prefix := filepath.Join(dir, "page-"+strconv.Itoa(number))
+	n := strconv.Itoa(number)
+	if _, err := e.cfg.Run(ctx, nil, e.cfg.PdftoppmPath,
+		"-f", n, "-l", n, "-r", strconv.Itoa(e.cfg.DPI), "-gray", "-png", "-singlefile", input, prefix,
+	); err != nil {
+		return nil, fmt.Errorf("render page %d: %w", number, err)
+	}
+
+	image, err := os.ReadFile(prefix + ".png")
+	if err != nil {
+		return nil, fmt.Errorf("render page %d: %w", number, err)
+	}
+	return image, nil
+}
@@ -0,0 +1,299 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	mimeCSV  = "text/csv"
+	mimeTSV  = "text/tab-separated-values"
+	mimeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// sheet is one table of a spreadsheet, header row first.
+type sheet struct {
+	name string
+	rows [][]string
+}
+
+// sheetPages renders each sheet as a markdown table on its own page, which
+// the chunker turns into row-group chunks that repeat the header.
+func sheetPages(sheets []sheet) []Page {
+	var tables []sheet
+	for _, s := range sheets {
+		if rows := trimRows(s.rows); len(rows) >= 2 {
+			tables = append(tables, sheet{name: s.name, rows: rows})
+		}
+	}
+
+	pages := make([]Page, 0, len(tables))
+	for i, s := range tables {
+		text := markdownTable(s.rows)
+		if len(tables) > 1 && s.name != "" {
+			text = "## " + s.name + "\n\n" + text
+		}
+		pages = append(pages, Page{Number: i + 1, Text: text})
+	}
+	return pages
+}
+
+// trimRows drops blank rows and the empty columns spreadsheets often carry
+// past the data, and names unnamed header cells.
+func trimRows(rows [][]string) [][]string {
+	var kept [][]string
+	width := 0
+	for _, row := range rows {
+		last := -1
+		for i, cell := range row {
+			if strings.TrimSpace(cell) != "" {
+				last = i
+			}
+		}
+		if last < 0 {
+			continue
+		}
+		kept = append(kept, row)
+		width = max(width, last+1)
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+
+	for i, row := range kept {
+		cells := make([]string, width)
+		for j := range cells {
+			if j < len(row) {
+				cells[j] = strings.Join(strings.Fields(row[j]), " ")
+			}
+			if i == 0 && cells[j] == "" {
+				cells[j] = "Column " + strconv.Itoa(j+1)
+			}
+		}
+		kept[i] = cells
+	}
+	return kept
+}
+
+func markdownTable(rows [][]string) string {
+	var b strings.Builder
+	for i, row := range rows {
+		b.WriteString("|")
+		for _, cell := range row {
+			b.WriteString(" " + strings.ReplaceAll(cell, "|", `\|`) + " |")
+		}
+		b.WriteString("\n")
+		if i == 0 {
+			b.WriteString("|" + strings.Repeat(" --- |", len(row)) + "\n")
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func parseCSV(data []byte, comma rune) ([]sheet, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if comma == 0 {
+		comma = sniffDelimiter(data)
+	}
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return []sheet{{rows: rows}}, nil
+}
+
+// sniffDelimiter picks the most frequent of the usual delimiters on the
+// first line; spreadsheets in many locales export with semicolons.
+func sniffDelimiter(data []byte) rune {
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	best, bestCount := ',', 0
+	for _, d := range []rune{',', ';', '\t', '|'} {
+		if n := bytes.Count(line, []byte(string(d))); n > bestCount {
+			best, bestCount = d, n
+		}
+	}
+	return best
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxText is a run of text that may be split into rich-text runs.
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, r := range t.Runs {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// parseXLSX reads every worksheet of an Office Open XML workbook. Cells are
+// taken as stored, so dates appear as spreadsheet serial numbers.
+func parseXLSX(data []byte) ([]sheet, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+
+	var workbook xlsxWorkbook
+	if err := decodeZipXML(zr, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	var rels xlsxRelationships
+	if err := decodeZipXML(zr, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	targets := map[string]string{}
+	for _, r := range rels.Relationships {
+		targets[r.ID] = r.Target
+	}
+
+	var shared xlsxSharedStrings
+	if err := decodeZipXML(zr, "xl/sharedStrings.xml", &shared); err != nil && !isMissing(err) {
+		return nil, err
+	}
+
+	var sheets []sheet
+	for _, s := range workbook.Sheets {
+		target := targets[s.RID]
+		if target == "" {
+			continue
+		}
+		name := path.Join("xl", target)
+		if strings.HasPrefix(target, "/") {
+			name = strings.TrimPrefix(target, "/")
+		}
+
+		var ws xlsxWorksheet
+		if err := decodeZipXML(zr, name, &ws); err != nil {
+			return nil, err
+		}
+
+		var rows [][]string
+		for _, row := range ws.Rows {
+			var cells []string
+			for i, c := range row.Cells {
+				col := columnIndex(c.Ref)
+				if col < 0 {
+					col = i
+				}
+				for len(cells) <= col {
+					cells = append(cells, "")
+				}
+				cells[col] = cellValue(c.Type, c.Value, c.Inline, shared.Items)
+			}
+			rows = append(rows, cells)
+		}
+		sheets = append(sheets, sheet{name: s.Name, rows: rows})
+	}
+	return sheets, nil
+}
+
+func cellValue(typ, value string, inline xlsxText, shared []xlsxText) string {
+	switch typ {
+	case "s":
+		if i, err := strconv.Atoi(value); err == nil && i >= 0 && i < len(shared) {
+			return shared[i].String()
+		}
+		return ""
+	case "inlineStr":
+		return inline.String()
+	case "b":
+		if value == "1" {
+			return "TRUE"
+		}
+		return "FALSE"
+	}
+	return value
+}
+
+// columnIndex converts the letters of a cell reference such as "AB12" to a
+// zero-based column.
+func columnIndex(ref string) int {
+	col := 0
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+		n++
+	}
+	if n == 0 {
+		return -1
+	}
+	return col - 1
+}
+
+type missingFileError string
+
+func (e missingFileError) Error() string { return "missing " + string(e) }
+
+func (e missingFileError) Unwrap() error { return ErrMalformed }
+
+func isMissing(err error) bool {
+	var missing missingFileError
+	return errors.As(err, &missing)
+}
+
+func decodeZipXML(zr *zip.Reader, name string, v any) error {
+	f, err := zr.Open(name)
+	if err != nil {
+		return missingFileError(name)
+	}
+	defer func() { _ = f.Close() }()
+
+	if err := xml.NewDecoder(io.LimitReader(f, 256<<20)).Decode(v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrMalformed, name, err)
+	}
+	return nil
+}
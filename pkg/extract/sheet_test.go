@@ -0,0 +1,61 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestExtractCSV(t *testing.T) {
+	data := "\xef\xbb\xbfSKU;Name;Price;\nA1;Red | blue widget;2,50;\n;;;\nA2;Green widget;3,10;\n"
+
+	result, err := New(Config{}).Extract(context.Background(), []byte(data), "application/octet-stream", Options{Filename: "prices.CSV"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := "| SKU | Name | Price |\n| --- | --- | --- |\n| A1 | Red \\| blue widget | 2,50 |\n| A2 | Green widget | 3,10 |"
+	if result.ContentType != mimeCSV || result.Text() != want {
+		t.Errorf("Expected markdown table, got %s:\n%s", result.ContentType, result.Text())
+	}
+}
+
+func TestExtractXLSX(t *testing.T) {
+	files := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Products" sheetId="1" r:id="rId1"/><sheet name="Empty" sheetId="2" r:id="rId2"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships>
+<Relationship Id="rId1" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Target="/xl/worksheets/sheet2.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst><si><t>Item</t></si><si><t>Price</t></si><si><r><t>Cold </t></r><r><t>brew</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row>
+<row r="2"><c r="A2" t="s"><v>2</v></c><c r="C2"><v>3.75</v></c></row>
+<row r="3"><c r="A3" t="inlineStr"><is><t>Latte</t></is></c><c r="B3" t="b"><v>1</v></c><c r="C3"><v>4.1</v></c></row>
+</sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet><sheetData/></worksheet>`,
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+	zw.Close()
+
+	result, err := New(Config{}).Extract(context.Background(), buf.Bytes(), "", Options{Filename: "menu.xlsx"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := "| Item | Column 2 | Price |\n| --- | --- | --- |\n| Cold brew |  | 3.75 |\n| Latte | TRUE | 4.1 |"
+	if len(result.Pages) != 1 || result.Text() != want {
+		t.Errorf("Expected the Products sheet as a table, got:\n%s", result.Text())
+	}
+}
+
+func TestExtractMalformedXLSX(t *testing.T) {
+	_, err := New(Config{}).Extract(context.Background(), []byte("PK\x03\x04 not really"), mimeXLSX, Options{})
+	if !errors.Is(err, ErrMalformed) {
+		t.Errorf("Expected ErrMalformed, got %v", err)
+	}
+}
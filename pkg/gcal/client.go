@@ -0,0 +1,293 @@
+// Package gcal is a client for the parts of the Google Calendar API a
+// booking system needs: free/busy lookups and creating, moving and deleting
+// events. It authenticates as a service account, which must be given access
+// to each calendar it uses.
+package gcal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	defaultBaseURL = "https://www.googleapis.com/calendar/v3"
+	defaultTimeout = 15 * time.Second
+	calendarScope  = "https://www.googleapis.com/auth/calendar"
+	maxResponse    = 1 << 20
+	// tokenSlack renews the access token this long before it expires.
+	tokenSlack = time.Minute
+)
+
+// Credentials is the part of a service account key file the client uses.
+type Credentials struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+type Client struct {
+	email      string
+	tokenURI   string
+	key        any
+	baseURL    string
+	httpClient *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+type Option func(*Client)
+
+func WithBaseURL(url string) Option {
+	return func(c *Client) {
+		c.baseURL = url
+	}
+}
+
+// NewClient builds a client from the JSON key file of a service account.
+func NewClient(keyFile []byte, opts ...Option) (*Client, error) {
+	var creds Credentials
+	if err := json.Unmarshal(keyFile, &creds); err != nil {
+		return nil, fmt.Errorf("invalid service account key: %w", err)
+	}
+	if creds.ClientEmail == "" || creds.PrivateKey == "" {
+		return nil, errors.New("service account key has no client_email or private_key")
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(creds.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid service account private key: %w", err)
+	}
+	if creds.TokenURI == "" {
+		creds.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+
+	c := &Client{
+		email:    creds.ClientEmail,
+		tokenURI: creds.TokenURI,
+		key:      key,
+		baseURL:  defaultBaseURL,
+		httpClient: &http.Client{
+			Timeout: defaultTimeout,
+		},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Interval is a busy period of a calendar.
+type Interval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Event is a calendar event to create or move.
+type Event struct {
+	Summary     string
+	Description string
+	Start       time.Time
+	End         time.Time
+}
+
+type eventTime struct {
+	DateTime string `json:"dateTime"`
+}
+
+type eventBody struct {
+	Summary     string    `json:"summary,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Start       eventTime `json:"start"`
+	End         eventTime `json:"end"`
+}
+
+func (e Event) body() eventBody {
+	return eventBody{
+		Summary:     e.Summary,
+		Description: e.Description,
+		Start:       eventTime{DateTime: e.Start.Format(time.RFC3339)},
+		End:         eventTime{DateTime: e.End.Format(time.RFC3339)},
+	}
+}
+
+// Busy returns the periods calendarID is busy between from and to.
+func (c *Client) Busy(ctx context.Context, calendarID string, from, to time.Time) ([]Interval, error) {
+	req := map[string]any{
+		"timeMin": from.UTC().Format(time.RFC3339),
+		"timeMax": to.UTC().Format(time.RFC3339),
+		"items":   []map[string]string{{"id": calendarID}},
+	}
+	var resp struct {
+		Calendars map[string]struct {
+			Busy   []Interval `json:"busy"`
+			Errors []struct {
+				Reason string `json:"reason"`
+			} `json:"errors"`
+		} `json:"calendars"`
+	}
+	if err := c.call(ctx, http.MethodPost, "/freeBusy", req, &resp); err != nil {
+		return nil, err
+	}
+	cal, ok := resp.Calendars[calendarID]
+	if !ok {
+		return nil, fmt.Errorf("calendar %s not returned", calendarID)
+	}
+	if len(cal.Errors) > 0 {
+		return nil, fmt.Errorf("calendar %s: %s", calendarID, cal.Errors[0].Reason)
+	}
+	return cal.Busy, nil
+}
+
+// CreateEvent adds event to calendarID and returns its ID.
+func (c *Client) CreateEvent(ctx context.Context, calendarID string, event Event) (string, error) {
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := c.call(ctx, http.MethodPost, "/calendars/"+url.PathEscape(calendarID)+"/events", event.body(), &created); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// UpdateEvent replaces the time and text of the event eventID.
+func (c *Client) UpdateEvent(ctx context.Context, calendarID, eventID string, event Event) error {
+	return c.call(ctx, http.MethodPatch, "/calendars/"+url.PathEscape(calendarID)+"/events/"+url.PathEscape(eventID), event.body(), nil)
+}
+
+// DeleteEvent removes the event eventID. Events already gone are not an
+// error.
+func (c *Client) DeleteEvent(ctx context.Context, calendarID, eventID string) error {
+	err := c.call(ctx, http.MethodDelete, "/calendars/"+url.PathEscape(calendarID)+"/events/"+url.PathEscape(eventID), nil, nil)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusGone || apiErr.StatusCode == http.StatusNotFound) {
+		return nil
+	}
+	return err
+}
+
+// APIError is a response the Calendar API rejected a request with.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("google calendar error (status %d): %s", e.StatusCode, e.Message)
+}
+
+func (c *Client) call(ctx context.Context, method, path string, in, out any) error {
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponse))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		message := strings.TrimSpace(string(data))
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error.Message != "" {
+			message = apiErr.Error.Message
+		}
+		return &APIError{StatusCode: resp.StatusCode, Message: message}
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// accessToken returns a cached access token, exchanging a signed assertion
+// for a new one when it is about to expire.
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Now().Add(tokenSlack).Before(c.expires) {
+		return c.token, nil
+	}
+
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   c.email,
+		"scope": calendarScope,
+		"aud":   c.tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(c.key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign assertion: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+		Error       string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponse)).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || token.AccessToken == "" {
+		return "", fmt.Errorf("google token exchange failed (status %d): %s", resp.StatusCode, token.Error)
+	}
+	c.token = token.AccessToken
+	c.expires = now.Add(time.Duration(token.ExpiresIn) * time.Second)
+	return c.token, nil
+}
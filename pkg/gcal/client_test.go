@@ -0,0 +1,117 @@
+package gcal
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func testKeyFile(t *testing.T, tokenURI string) []byte {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	block := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	data, _ := json.Marshal(Credentials{ClientEmail: "booking@example.iam.gserviceaccount.com", PrivateKey: string(block), TokenURI: tokenURI})
+	return data
+}
+
+func TestBusyAndEvents(t *testing.T) {
+	tokens := 0
+	var created map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			tokens++
+			if r.FormValue("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" || r.FormValue("assertion") == "" {
+				t.Errorf("Unexpected token request %v", r.Form)
+			}
+			json.NewEncoder(w).Encode(map[string]any{"access_token": "access-1", "expires_in": 3600})
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer access-1" {
+			t.Errorf("Expected the access token on %s", r.URL.Path)
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "POST /freeBusy":
+			w.Write([]byte(`{"calendars":{"team@example.com":{"busy":[{"start":"2026-10-20T15:00:00Z","end":"2026-10-20T16:00:00Z"}]}}}`))
+		case "POST /calendars/team@example.com/events":
+			json.NewDecoder(r.Body).Decode(&created)
+			w.Write([]byte(`{"id":"event-1"}`))
+		case "DELETE /calendars/team@example.com/events/event-1":
+			w.WriteHeader(http.StatusGone)
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(testKeyFile(t, server.URL+"/token"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	ctx := context.Background()
+	from := time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)
+
+	busy, err := client.Busy(ctx, "team@example.com", from, from.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(busy) != 1 || busy[0].Start.Hour() != 15 || busy[0].End.Sub(busy[0].Start) != time.Hour {
+		t.Errorf("Unexpected busy periods %+v", busy)
+	}
+
+	id, err := client.CreateEvent(ctx, "team@example.com", Event{Summary: "Consultation", Start: from.Add(10 * time.Hour), End: from.Add(11 * time.Hour)})
+	if err != nil || id != "event-1" {
+		t.Fatalf("Expected event-1, got %q, %v", id, err)
+	}
+	if created["summary"] != "Consultation" || created["start"].(map[string]any)["dateTime"] != "2026-10-20T10:00:00Z" {
+		t.Errorf("Unexpected event %v", created)
+	}
+
+	// Events already deleted from the calendar are not an error.
+	if err := client.DeleteEvent(ctx, "team@example.com", "event-1"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if tokens != 1 {
+		t.Errorf("Expected the access token to be reused, got %d exchanges", tokens)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			json.NewEncoder(w).Encode(map[string]any{"access_token": "access-1", "expires_in": 3600})
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"error":{"code":403,"message":"Requires writer access"}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(testKeyFile(t, server.URL+"/token"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	_, err = client.CreateEvent(context.Background(), "team@example.com", Event{Start: time.Now(), End: time.Now()})
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.StatusCode != http.StatusForbidden || apiErr.Message != "Requires writer access" {
+		t.Errorf("Expected the API error, got %v", err)
+	}
+}
+
+func TestNewClientRejectsInvalidKeys(t *testing.T) {
+	for _, key := range []string{`not json`, `{"client_email":"a@b"}`, `{"client_email":"a@b","private_key":"nope"}`} {
+		if _, err := NewClient([]byte(key)); err == nil {
+			t.Errorf("Expected an error for %s", key)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+// Package guardrail checks generated answers against the sources they were
+// generated from before they reach a user.
+package guardrail
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Actions reported in Result.Actions.
+const (
+	ActionStrippedURL   = "stripped_url"
+	ActionStrippedPhone = "stripped_phone"
+	ActionBannedPhrase  = "banned_phrase"
+	ActionUngrounded    = "ungrounded"
+	ActionTruncated     = "truncated"
+)
+
+// DefaultFallback replaces answers that fail the groundedness check or that
+// nothing is left of.
+const DefaultFallback = "I couldn't find enough information in the knowledge base to answer that reliably."
+
+// Policy configures the checks. The zero value only scores groundedness.
+type Policy struct {
+	// StripUnsourced removes URLs and phone numbers that do not appear in
+	// any source.
+	StripUnsourced bool
+	// BannedPhrases drops every sentence containing one of them, ignoring
+	// case.
+	BannedPhrases []string
+	// MinGroundedness replaces answers scoring below it with Fallback.
+	// Zero disables the check.
+	MinGroundedness float64
+	// MaxChars truncates longer answers at a sentence or word boundary.
+	// Zero means no limit.
+	MaxChars int
+	Fallback string
+}
+
+type Result struct {
+	Answer string
+	// Groundedness is the share of the answer's sentences whose content
+	// words mostly appear in the sources, from 0 to 1.
+	Groundedness float64
+	Actions      []string
+}
+
+var (
+	urlPattern   = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>()\[\]"']+`)
+	phonePattern = regexp.MustCompile(`\+?\d[\d\s().-]{5,}\d`)
+	datePattern  = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	spaceRun     = regexp.MustCompile(`[ \t]{2,}`)
+	emptyParens  = regexp.MustCompile(`\(\s*\)|\[\s*\]`)
+)
+
+// groundedShare is how many of a sentence's content words must appear in
+// the sources for it to count as grounded.
+const groundedShare = 0.5
+
+// Apply runs the policy over answer. Sources are the texts the answer may
+// draw on: retrieved chunks and tool results.
+func (p Policy) Apply(answer string, sources []string) Result {
+	fallback := p.Fallback
+	if fallback == "" {
+		fallback = DefaultFallback
+	}
+
+	res := Result{Answer: answer}
+	joined := strings.Join(sources, "\n")
+
+	if p.StripUnsourced {
+		res.Answer = p.stripURLs(res.Answer, joined, &res)
+		res.Answer = p.stripPhones(res.Answer, joined, &res)
+	}
+
+	if len(p.BannedPhrases) > 0 {
+		res.Answer = p.dropBanned(res.Answer, &res)
+	}
+
+	res.Groundedness = Groundedness(res.Answer, joined)
+	if p.MinGroundedness > 0 && res.Groundedness < p.MinGroundedness {
+		res.Answer = fallback
+		res.Actions = append(res.Actions, ActionUngrounded)
+	}
+
+	if strings.TrimSpace(res.Answer) == "" {
+		res.Answer = fallback
+	}
+
+	if p.MaxChars > 0 && utf8.RuneCountInString(res.Answer) > p.MaxChars {
+		res.Answer = truncate(res.Answer, p.MaxChars)
+		res.Actions = append(res.Actions, ActionTruncated)
+	}
+
+	return res
+}
+
+func (p Policy) stripURLs(answer, sources string, res *Result) string {
+	normalizedSources := strings.ToLower(sources)
+	stripped := false
+	answer = urlPattern.ReplaceAllStringFunc(answer, func(raw string) string {
+		u := strings.TrimRight(raw, ".,;:!?")
+		if strings.Contains(normalizedSources, normalizeURL(u)) {
+			return raw
+		}
+		stripped = true
+		return raw[len(u):]
+	})
+	if stripped {
+		res.Actions = append(res.Actions, ActionStrippedURL)
+		answer = tidy(answer)
+	}
+	return answer
+}
+
+// normalizeURL drops the parts that commonly differ between a source and
+// a model's rendering of the same link.
+func normalizeURL(u string) string {
+	u = strings.ToLower(u)
+	u = strings.TrimPrefix(u, "https://")
+	u = strings.TrimPrefix(u, "http://")
+	u = strings.TrimPrefix(u, "www.")
+	return strings.TrimRight(u, "/")
+}
+
+func (p Policy) stripPhones(answer, sources string, res *Result) string {
+	var known []string
+	for _, m := range phonePattern.FindAllString(sources, -1) {
+		if d := digits(m); len(d) >= 7 {
+			known = append(known, d)
+		}
+	}
+
+	stripped := false
+	answer = phonePattern.ReplaceAllStringFunc(answer, func(raw string) string {
+		d := digits(raw)
+		if len(d) < 7 || len(d) > 15 || !phoneLike(raw) {
+			return raw
+		}
+		for _, k := range known {
+			// Allow a country code on either side.
+			if strings.HasSuffix(k, d) || strings.HasSuffix(d, k) {
+				return raw
+			}
+		}
+		stripped = true
+		return ""
+	})
+	if stripped {
+		res.Actions = append(res.Actions, ActionStrippedPhone)
+		answer = tidy(answer)
+	}
+	return answer
+}
+
+// phoneLike tells phone numbers from other digit runs the pattern matches,
+// such as dates or space-separated lists of sizes.
+func phoneLike(raw string) bool {
+	raw = strings.TrimSpace(raw)
+	if datePattern.MatchString(raw) {
+		return false
+	}
+	if strings.HasPrefix(raw, "+") || strings.ContainsAny(raw, "(-.") {
+		return true
+	}
+	return !strings.ContainsAny(raw, " \t\n")
+}
+
+func (p Policy) dropBanned(answer string, res *Result) string {
+	sentences := splitSentences(answer)
+	kept := sentences[:0]
+	dropped := false
+	for _, s := range sentences {
+		lower := strings.ToLower(s)
+		banned := false
+		for _, phrase := range p.BannedPhrases {
+			if phrase != "" && strings.Contains(lower, strings.ToLower(phrase)) {
+				banned = true
+				break
+			}
+		}
+		if banned {
+			dropped = true
+			continue
+		}
+		kept = append(kept, s)
+	}
+	if !dropped {
+		return answer
+	}
+	res.Actions = append(res.Actions, ActionBannedPhrase)
+	return strings.TrimSpace(strings.Join(kept, ""))
+}
+
+// Groundedness scores answer against sources; see Result.Groundedness. An
+// answer without content words scores 1.
+func Groundedness(answer, sources string) float64 {
+	known := make(map[string]bool)
+	for _, w := range contentWords(sources) {
+		known[w] = true
+	}
+
+	total, grounded := 0, 0
+	for _, sentence := range splitSentences(answer) {
+		words := contentWords(sentence)
+		if len(words) == 0 {
+			continue
+		}
+		total++
+		hits := 0
+		for _, w := range words {
+			if known[w] {
+				hits++
+			}
+		}
+		if float64(hits)/float64(len(words)) >= groundedShare {
+			grounded++
+		}
+	}
+
+	if total == 0 {
+		return 1
+	}
+	return float64(grounded) / float64(total)
+}
+
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "are": true, "but": true, "not": true,
+	"you": true, "your": true, "our": true, "can": true, "has": true, "have": true,
+	"was": true, "were": true, "will": true, "with": true, "this": true, "that": true,
+	"from": true, "they": true, "them": true, "there": true, "their": true, "what": true,
+	"which": true, "when": true, "where": true, "who": true, "how": true, "all": true,
+	"any": true, "also": true, "its": true, "into": true, "than": true, "then": true,
+	"these": true, "those": true, "would": true, "could": true, "should": true,
+	"about": true, "please": true, "may": true, "yes": true, "here": true,
+}
+
+func contentWords(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	words := fields[:0]
+	for _, f := range fields {
+		if utf8.RuneCountInString(f) < 3 || stopWords[f] {
+			continue
+		}
+		words = append(words, f)
+	}
+	return words
+}
+
+// splitSentences splits after sentence-ending punctuation and newlines,
+// keeping the separators so the parts join back into the original text.
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	runes := []rune(text)
+	for i, r := range runes {
+		end := r == '\n'
+		if r == '.' || r == '!' || r == '?' {
+			end = i+1 == len(runes) || unicode.IsSpace(runes[i+1])
+		}
+		if end {
+			sentences = append(sentences, string(runes[start:i+1]))
+			start = i + 1
+		}
+	}
+	if start < len(runes) {
+		sentences = append(sentences, string(runes[start:]))
+	}
+	return sentences
+}
+
+func truncate(text string, maxChars int) string {
+	runes := []rune(text)
+	// Leave room for the ellipsis.
+	cut := string(runes[:maxChars-1])
+
+	if i := strings.LastIndexAny(cut, ".!?\n"); i >= len(cut)/2 {
+		return strings.TrimSpace(cut[:i+1])
+	}
+	if i := strings.LastIndexAny(cut, " \t"); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimSpace(cut) + "…"
+}
+
+func digits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// tidy cleans up what removing a link or number leaves behind.
+func tidy(text string) string {
+	text = emptyParens.ReplaceAllString(text, "")
+	text = spaceRun.ReplaceAllString(text, " ")
+	text = strings.ReplaceAll(text, " .", ".")
+	text = strings.ReplaceAll(text, " ,", ",")
+	return strings.TrimSpace(text)
+}
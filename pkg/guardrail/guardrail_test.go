@@ -0,0 +1,107 @@
+package guardrail
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+var storeSources = []string{
+	"Store hours: Monday to Friday 9 AM - 6 PM. Call us at +1 (555) 123-4567.",
+	"Returns are accepted within 30 days. See https://shop.example.com/returns for details.",
+}
+
+func TestStripUnsourcedKeepsSourcedContacts(t *testing.T) {
+	p := Policy{StripUnsourced: true}
+	answer := "Returns are accepted within 30 days (https://shop.example.com/returns/). Call 555-123-4567."
+
+	res := p.Apply(answer, storeSources)
+
+	if res.Answer != answer {
+		t.Errorf("Expected sourced URL and phone to be kept, got %q", res.Answer)
+	}
+	if len(res.Actions) != 0 {
+		t.Errorf("Expected no actions, got %v", res.Actions)
+	}
+}
+
+func TestStripUnsourcedRemovesInventedContacts(t *testing.T) {
+	p := Policy{StripUnsourced: true}
+	answer := "Returns are accepted within 30 days, see https://returns.example.org/form. Or call +1 555 987-6543."
+
+	res := p.Apply(answer, storeSources)
+
+	if strings.Contains(res.Answer, "example.org") || strings.Contains(res.Answer, "987") {
+		t.Errorf("Expected invented URL and phone to be removed, got %q", res.Answer)
+	}
+	if !slices.Contains(res.Actions, ActionStrippedURL) || !slices.Contains(res.Actions, ActionStrippedPhone) {
+		t.Errorf("Expected both strip actions, got %v", res.Actions)
+	}
+}
+
+func TestStripUnsourcedLeavesOtherNumbers(t *testing.T) {
+	p := Policy{StripUnsourced: true}
+	answer := "Your order shipped on 2024-03-15. We stock sizes 38 39 40 41 42."
+
+	res := p.Apply(answer, nil)
+
+	if res.Answer != answer {
+		t.Errorf("Expected dates and size lists to be kept, got %q", res.Answer)
+	}
+}
+
+func TestBannedPhrasesDropSentences(t *testing.T) {
+	p := Policy{BannedPhrases: []string{"guaranteed refund"}}
+
+	res := p.Apply("Returns are accepted within 30 days. You get a Guaranteed Refund every time!", storeSources)
+
+	if res.Answer != "Returns are accepted within 30 days." {
+		t.Errorf("Expected banned sentence to be dropped, got %q", res.Answer)
+	}
+	if !slices.Contains(res.Actions, ActionBannedPhrase) {
+		t.Errorf("Expected banned_phrase action, got %v", res.Actions)
+	}
+}
+
+func TestGroundedness(t *testing.T) {
+	sources := strings.Join(storeSources, "\n")
+
+	if g := Groundedness("The store is open Monday to Friday from 9 AM to 6 PM.", sources); g != 1 {
+		t.Errorf("Expected grounded answer to score 1, got %f", g)
+	}
+	mixed := "Returns are accepted within 30 days. Shipping to Antarctica takes fourteen weeks."
+	if g := Groundedness(mixed, sources); g != 0.5 {
+		t.Errorf("Expected half-grounded answer to score 0.5, got %f", g)
+	}
+}
+
+func TestMinGroundednessFallsBack(t *testing.T) {
+	p := Policy{MinGroundedness: 0.5, Fallback: "Not sure."}
+
+	res := p.Apply("Penguins deliver parcels on Sundays.", storeSources)
+
+	if res.Answer != "Not sure." {
+		t.Errorf("Expected fallback, got %q", res.Answer)
+	}
+	if !slices.Contains(res.Actions, ActionUngrounded) {
+		t.Errorf("Expected ungrounded action, got %v", res.Actions)
+	}
+}
+
+func TestMaxChars(t *testing.T) {
+	p := Policy{MaxChars: 40}
+
+	res := p.Apply("Returns are accepted within 30 days. Refunds take five business days.", storeSources)
+	if res.Answer != "Returns are accepted within 30 days." {
+		t.Errorf("Expected cut at the sentence end, got %q", res.Answer)
+	}
+
+	res = p.Apply("Returnsareacceptedwithin thirty days and refunds take five business days", storeSources)
+	if n := utf8.RuneCountInString(res.Answer); n > 40 || !strings.HasSuffix(res.Answer, "…") {
+		t.Errorf("Expected word-boundary cut with ellipsis within 40 characters, got %q (%d)", res.Answer, n)
+	}
+	if !slices.Contains(res.Actions, ActionTruncated) {
+		t.Errorf("Expected truncated action, got %v", res.Actions)
+	}
+}
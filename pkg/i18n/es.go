@@ -0,0 +1,59 @@
+package i18n
+
+// spanish holds the Spanish translations of the messages users see: the
+// bot's built-in replies and the API's most common errors. Errors that
+// carry details, such as validation errors, stay in English.
+var spanish = map[string]string{
+	// Bot replies.
+	"I couldn't find any relevant information in the knowledge base to answer your question.":                       "No encontré información relevante en la base de conocimiento para responder tu pregunta.",
+	"I couldn't find enough information in the knowledge base to answer that reliably.":                             "No encontré suficiente información en la base de conocimiento para responder eso con certeza.",
+	"I can't answer right now because the assistant is temporarily unavailable. Please try again in a few minutes.": "No puedo responder en este momento porque el asistente no está disponible temporalmente. Por favor, inténtalo de nuevo en unos minutos.",
+
+	// Authentication and access.
+	"access denied":              "acceso denegado",
+	"api key required":           "se requiere una clave de API",
+	"authentication required":    "se requiere autenticación",
+	"insufficient permissions":   "permisos insuficientes",
+	"invalid api key":            "clave de API inválida",
+	"invalid email or password":  "correo o contraseña inválidos",
+	"invalid or expired session": "sesión inválida o expirada",
+	"invalid or expired token":   "token inválido o expirado",
+	"invalid token":              "token inválido",
+	"login failed":               "no se pudo iniciar sesión",
+	"session token required":     "se requiere un token de sesión",
+	"unauthorized":               "no autorizado",
+
+	// Requests.
+	"file is required":     "el archivo es obligatorio",
+	"file too large":       "el archivo es demasiado grande",
+	"id is required":       "el id es obligatorio",
+	"invalid query":        "consulta inválida",
+	"invalid request":      "solicitud inválida",
+	"invalid request body": "cuerpo de la solicitud inválido",
+	"origin not allowed":   "origen no permitido",
+	"rate limit exceeded":  "se excedió el límite de solicitudes",
+	"request timed out":    "la solicitud excedió el tiempo de espera",
+	"invalid widget key":   "clave de widget inválida",
+
+	// Time ranges.
+	"start_time must be RFC 3339 or a local time": "start_time debe estar en formato RFC 3339 o ser una hora local",
+	"end_time must be RFC 3339 or a local time":   "end_time debe estar en formato RFC 3339 o ser una hora local",
+
+	// Resources.
+	"canned response not found":   "respuesta predefinida no encontrada",
+	"conversation id is required": "el id de la conversación es obligatorio",
+	"conversation not found":      "conversación no encontrada",
+	"document not found":          "documento no encontrado",
+	"message not found":           "mensaje no encontrado",
+	"note content is required":    "el contenido de la nota es obligatorio",
+	"rule not found":              "regla no encontrada",
+	"shortcut not found":          "atajo no encontrado",
+	"tool not found":              "herramienta no encontrada",
+	"user not found":              "usuario no encontrado",
+
+	// Conversations.
+	"mode must be bot or human":                       "el modo debe ser bot o human",
+	"open the conversation stream first":              "primero abre el flujo de conversaciones",
+	"state must be open, pending, resolved or closed": "el estado debe ser open, pending, resolved o closed",
+	"status must be online or away":                   "el estado debe ser online o away",
+}
@@ -0,0 +1,131 @@
+// Package i18n translates user-facing messages. Messages are written in
+// English where they are produced and looked up by that text in each
+// language's catalog, so a message missing from a catalog stays in English.
+package i18n
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Supported languages, as ISO 639-1 codes.
+const (
+	English = "en"
+	Spanish = "es"
+)
+
+// catalogs maps each language but English to its translations, keyed by
+// the English text.
+var catalogs = map[string]map[string]string{
+	Spanish: spanish,
+}
+
+// Supported reports whether lang, an ISO 639-1 code, has a catalog.
+func Supported(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok || lang == English
+}
+
+// Translate returns msg in lang, or msg itself when lang has no
+// translation for it.
+func Translate(lang, msg string) string {
+	if translated, ok := catalogs[lang][msg]; ok {
+		return translated
+	}
+	return msg
+}
+
+type contextKey struct{}
+
+// WithLanguage returns ctx carrying lang, the language to answer in.
+func WithLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, contextKey{}, lang)
+}
+
+// FromContext returns the language ctx carries, or English.
+func FromContext(ctx context.Context) string {
+	if lang, ok := ctx.Value(contextKey{}).(string); ok && lang != "" {
+		return lang
+	}
+	return English
+}
+
+// Negotiate picks the supported language the client prefers most in an
+// Accept-Language header, such as "es-GT,es;q=0.9,en;q=0.8". Regions are
+// ignored, so "es-GT" selects Spanish. It returns fallback when the header
+// names no supported language.
+func Negotiate(header, fallback string) string {
+	type choice struct {
+		lang string
+		q    float64
+	}
+	var choices []choice
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if !Supported(lang) {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			choices = append(choices, choice{lang, q})
+		}
+	}
+	if len(choices) == 0 {
+		return fallback
+	}
+	sort.SliceStable(choices, func(i, j int) bool { return choices[i].q > choices[j].q })
+	return choices[0].lang
+}
+
+// Common words that tell Spanish and English apart in short messages.
+var (
+	spanishWords = wordSet("el la los las de del que y en por para con una un es son cómo como qué cuál cuándo dónde cuánto hola gracias buenos buenas tienen tiene puedo quiero necesito mi mis su está están hay sí mañana hoy horario precio")
+	englishWords = wordSet("the an is are was what how when where which who do does can could you your i my me to of and in for with have has hello hi thanks thank please want need today tomorrow hours price")
+)
+
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
+
+// Guess tells from common words whether text, such as a question from a
+// contact, is Spanish or English. It returns fallback when the words do
+// not settle it.
+func Guess(text, fallback string) string {
+	var es, en int
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		if spanishWords[w] {
+			es++
+		}
+		if englishWords[w] {
+			en++
+		}
+	}
+	// Accents and inverted marks only appear in Spanish.
+	if strings.ContainsAny(text, "¿¡ñÑáéíóúÁÉÍÓÚ") {
+		es++
+	}
+	switch {
+	case es > en:
+		return Spanish
+	case en > es:
+		return English
+	}
+	return fallback
+}
@@ -0,0 +1,67 @@
+package i18n
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"es-GT,es;q=0.9,en;q=0.8", Spanish},
+		{"en-US,en;q=0.9,es;q=0.8", English},
+		{"fr-FR,es;q=0.5", Spanish},
+		{"es;q=0.2,en;q=0.7", English},
+		{"es;q=0", "en"},
+		{"fr, de", "en"},
+		{"", "en"},
+	}
+	for _, tt := range tests {
+		if got := Negotiate(tt.header, English); got != tt.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+	if got := Negotiate("", Spanish); got != Spanish {
+		t.Errorf("Expected the fallback without a header, got %q", got)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	if got := Translate(Spanish, "access denied"); got != "acceso denegado" {
+		t.Errorf("Expected a Spanish translation, got %q", got)
+	}
+	if got := Translate(Spanish, "something new"); got != "something new" {
+		t.Errorf("Expected an untranslated message to stay in English, got %q", got)
+	}
+	if got := Translate(English, "access denied"); got != "access denied" {
+		t.Errorf("Expected English to be left alone, got %q", got)
+	}
+}
+
+func TestGuess(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"¿Cuál es el horario de la tienda?", Spanish},
+		{"hola, necesito ayuda con mi pedido", Spanish},
+		{"What are your opening hours?", English},
+		{"iPhone 15", "fallback"},
+	}
+	for _, tt := range tests {
+		if got := Guess(tt.text, "fallback"); got != tt.want {
+			t.Errorf("Guess(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	if got := FromContext(context.Background()); got != English {
+		t.Errorf("Expected English by default, got %q", got)
+	}
+	if got := FromContext(WithLanguage(context.Background(), Spanish)); got != Spanish {
+		t.Errorf("Expected Spanish, got %q", got)
+	}
+}
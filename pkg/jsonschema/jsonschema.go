@@ -0,0 +1,284 @@
+// Package jsonschema validates decoded JSON against the subset of JSON
+// Schema that structured model output uses: type, properties, required,
+// additionalProperties, items, enum and the basic numeric, string and
+// array bounds. Unknown keywords are ignored.
+package jsonschema
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidSchema = errors.New("invalid schema")
+	ErrMismatch      = errors.New("value does not match schema")
+)
+
+var knownTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true,
+	"integer": true, "boolean": true, "null": true,
+}
+
+// Check reports whether schema is one Validate can apply. It rejects
+// malformed keywords instead of silently accepting anything.
+func Check(schema map[string]any) error {
+	return check(schema, "$")
+}
+
+func check(schema map[string]any, path string) error {
+	if t, ok := schema["type"]; ok {
+		types, ok := typeList(t)
+		if !ok {
+			return fmt.Errorf("%w: %s: type must be a string or a list of strings", ErrInvalidSchema, path)
+		}
+		for _, name := range types {
+			if !knownTypes[name] {
+				return fmt.Errorf("%w: %s: unknown type %q", ErrInvalidSchema, path, name)
+			}
+		}
+	}
+
+	if props, ok := schema["properties"]; ok {
+		m, ok := props.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%w: %s: properties must be an object", ErrInvalidSchema, path)
+		}
+		for name, sub := range m {
+			subSchema, ok := sub.(map[string]any)
+			if !ok {
+				return fmt.Errorf("%w: %s.%s: schema must be an object", ErrInvalidSchema, path, name)
+			}
+			if err := check(subSchema, path+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+
+	if req, ok := schema["required"]; ok {
+		list, ok := req.([]any)
+		if !ok {
+			return fmt.Errorf("%w: %s: required must be a list", ErrInvalidSchema, path)
+		}
+		for _, name := range list {
+			if _, ok := name.(string); !ok {
+				return fmt.Errorf("%w: %s: required must list property names", ErrInvalidSchema, path)
+			}
+		}
+	}
+
+	if items, ok := schema["items"]; ok {
+		subSchema, ok := items.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%w: %s: items must be an object", ErrInvalidSchema, path)
+		}
+		if err := check(subSchema, path+"[]"); err != nil {
+			return err
+		}
+	}
+
+	if additional, ok := schema["additionalProperties"]; ok {
+		switch a := additional.(type) {
+		case bool:
+		case map[string]any:
+			if err := check(a, path+".*"); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%w: %s: additionalProperties must be a boolean or an object", ErrInvalidSchema, path)
+		}
+	}
+
+	if enum, ok := schema["enum"]; ok {
+		if _, ok := enum.([]any); !ok {
+			return fmt.Errorf("%w: %s: enum must be a list", ErrInvalidSchema, path)
+		}
+	}
+
+	for _, key := range []string{"minimum", "maximum", "minLength", "maxLength", "minItems", "maxItems"} {
+		if v, ok := schema[key]; ok {
+			if _, ok := v.(float64); !ok {
+				return fmt.Errorf("%w: %s: %s must be a number", ErrInvalidSchema, path, key)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Validate reports the first place where value, as decoded by
+// encoding/json into an any, does not match schema.
+func Validate(schema map[string]any, value any) error {
+	return validate(schema, value, "$")
+}
+
+func validate(schema map[string]any, value any, path string) error {
+	if t, ok := schema["type"]; ok {
+		types, _ := typeList(t)
+		matched := false
+		for _, name := range types {
+			if hasType(value, name) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%w: %s: expected %v, got %s", ErrMismatch, path, t, typeName(value))
+		}
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, allowed := range enum {
+			if reflect.DeepEqual(allowed, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: %s: value is not one of %v", ErrMismatch, path, enum)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		return validateObject(schema, v, path)
+	case []any:
+		if lo, ok := schema["minItems"].(float64); ok && float64(len(v)) < lo {
+			return fmt.Errorf("%w: %s: expected at least %g items", ErrMismatch, path, lo)
+		}
+		if hi, ok := schema["maxItems"].(float64); ok && float64(len(v)) > hi {
+			return fmt.Errorf("%w: %s: expected at most %g items", ErrMismatch, path, hi)
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				if err := validate(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		n := float64(utf8.RuneCountInString(v))
+		if lo, ok := schema["minLength"].(float64); ok && n < lo {
+			return fmt.Errorf("%w: %s: expected at least %g characters", ErrMismatch, path, lo)
+		}
+		if hi, ok := schema["maxLength"].(float64); ok && n > hi {
+			return fmt.Errorf("%w: %s: expected at most %g characters", ErrMismatch, path, hi)
+		}
+	case float64:
+		if lo, ok := schema["minimum"].(float64); ok && v < lo {
+			return fmt.Errorf("%w: %s: %g is below the minimum %g", ErrMismatch, path, v, lo)
+		}
+		if hi, ok := schema["maximum"].(float64); ok && v > hi {
+			return fmt.Errorf("%w: %s: %g is above the maximum %g", ErrMismatch, path, v, hi)
+		}
+	}
+
+	return nil
+}
+
+func validateObject(schema map[string]any, obj map[string]any, path string) error {
+	if required, ok := schema["required"].([]any); ok {
+		for _, name := range required {
+			key, _ := name.(string)
+			if _, ok := obj[key]; !ok {
+				return fmt.Errorf("%w: %s: missing required property %q", ErrMismatch, path, key)
+			}
+		}
+	}
+
+	props, _ := schema["properties"].(map[string]any)
+
+	// Walk keys in order so the reported error is deterministic.
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if sub, ok := props[key].(map[string]any); ok {
+			if err := validate(sub, obj[key], path+"."+key); err != nil {
+				return err
+			}
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				return fmt.Errorf("%w: %s: unexpected property %q", ErrMismatch, path, key)
+			}
+		case map[string]any:
+			if err := validate(additional, obj[key], path+"."+key); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func typeList(t any) ([]string, bool) {
+	switch v := t.(type) {
+	case string:
+		return []string{v}, true
+	case []any:
+		types := make([]string, 0, len(v))
+		for _, item := range v {
+			name, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			types = append(types, name)
+		}
+		return types, true
+	}
+	return nil, false
+}
+
+func hasType(value any, name string) bool {
+	switch name {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return false
+}
+
+func typeName(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,92 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func decode(t *testing.T, s string) any {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("Failed to decode %s: %v", s, err)
+	}
+	return v
+}
+
+func schemaOf(t *testing.T, s string) map[string]any {
+	t.Helper()
+	m, ok := decode(t, s).(map[string]any)
+	if !ok {
+		t.Fatalf("Expected an object schema, got %s", s)
+	}
+	return m
+}
+
+const productSchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string", "minLength": 1},
+		"price": {"type": "number", "minimum": 0},
+		"stock": {"type": "integer"},
+		"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 3},
+		"status": {"enum": ["available", "sold_out"]},
+		"sku": {"type": ["string", "null"]}
+	},
+	"required": ["name", "price"],
+	"additionalProperties": false
+}`
+
+func TestValidate(t *testing.T) {
+	schema := schemaOf(t, productSchema)
+	if err := Check(schema); err != nil {
+		t.Fatalf("Expected valid schema, got %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		ok    bool
+	}{
+		{"valid", `{"name":"Mug","price":9.5,"stock":3,"tags":["kitchen"],"status":"available","sku":null}`, true},
+		{"missing required", `{"name":"Mug"}`, false},
+		{"wrong type", `{"name":"Mug","price":"9.5"}`, false},
+		{"fractional integer", `{"name":"Mug","price":1,"stock":1.5}`, false},
+		{"below minimum", `{"name":"Mug","price":-1}`, false},
+		{"empty string", `{"name":"","price":1}`, false},
+		{"bad item", `{"name":"Mug","price":1,"tags":[1]}`, false},
+		{"too many items", `{"name":"Mug","price":1,"tags":["a","b","c","d"]}`, false},
+		{"not in enum", `{"name":"Mug","price":1,"status":"maybe"}`, false},
+		{"extra property", `{"name":"Mug","price":1,"color":"red"}`, false},
+		{"not an object", `["Mug"]`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(schema, decode(t, tt.value))
+			if tt.ok && err != nil {
+				t.Errorf("Expected valid, got %v", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrMismatch) {
+				t.Errorf("Expected ErrMismatch, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckRejectsMalformedSchemas(t *testing.T) {
+	schemas := []string{
+		`{"type":"text"}`,
+		`{"type":"object","properties":{"a":"string"}}`,
+		`{"type":"object","required":"a"}`,
+		`{"type":"array","items":[{"type":"string"}]}`,
+		`{"type":"string","maxLength":"10"}`,
+	}
+
+	for _, s := range schemas {
+		if err := Check(schemaOf(t, s)); !errors.Is(err, ErrInvalidSchema) {
+			t.Errorf("Expected ErrInvalidSchema for %s, got %v", s, err)
+		}
+	}
+}
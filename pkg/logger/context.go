@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+)
+
+// contextHandler adds the request and user IDs carried by a record's
+// context, so the *Context methods correlate a request's log lines without
+// every caller passing them. IDs already given as attributes, by With or
+// WithContext, are not repeated.
+type contextHandler struct {
+	slog.Handler
+	// hasRequestID and hasUserID record the keys added with WithAttrs.
+	hasRequestID bool
+	hasUserID    bool
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		hasRequestID, hasUserID := h.hasRequestID, h.hasUserID
+		r.Attrs(func(a slog.Attr) bool {
+			hasRequestID = hasRequestID || a.Key == string(RequestIDKey)
+			hasUserID = hasUserID || a.Key == string(UserIDKey)
+			return true
+		})
+		if id := RequestID(ctx); id != "" && !hasRequestID {
+			r.AddAttrs(slog.String(string(RequestIDKey), id))
+		}
+		if id := UserID(ctx); id != "" && !hasUserID {
+			r.AddAttrs(slog.String(string(UserIDKey), id))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := &contextHandler{
+		Handler:      h.Handler.WithAttrs(attrs),
+		hasRequestID: h.hasRequestID,
+		hasUserID:    h.hasUserID,
+	}
+	for _, a := range attrs {
+		next.hasRequestID = next.hasRequestID || a.Key == string(RequestIDKey)
+		next.hasUserID = next.hasUserID || a.Key == string(UserIDKey)
+	}
+	return next
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{
+		Handler:      h.Handler.WithGroup(name),
+		hasRequestID: h.hasRequestID,
+		hasUserID:    h.hasUserID,
+	}
+}
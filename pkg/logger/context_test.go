@@ -0,0 +1,48 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestContextMethodsRecordIDs(t *testing.T) {
+	store := &mockLogStore{}
+	log := New(Options{Store: store})
+
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	ctx = ContextWithUserID(ctx, "user-1")
+	log.InfoContext(ctx, "with context")
+	log.WithContext(ctx).InfoContext(ctx, "with context twice")
+	log.Info("without context")
+	if err := log.Close(context.Background()); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	if len(store.entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(store.entries))
+	}
+	for _, entry := range store.entries[:2] {
+		if entry.RequestID != "req-1" || entry.UserID != "user-1" {
+			t.Errorf("%q: expected req-1 and user-1, got %q and %q", entry.Message, entry.RequestID, entry.UserID)
+		}
+	}
+	if entry := store.entries[2]; entry.RequestID != "" || entry.UserID != "" {
+		t.Errorf("expected no IDs without a context, got %q and %q", entry.RequestID, entry.UserID)
+	}
+}
+
+func TestContextHandlerSkipsGivenIDs(t *testing.T) {
+	inner := &mockHandler{enabled: true}
+	log := slog.New(&contextHandler{Handler: inner})
+
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	log.With("request_id", "req-1").InfoContext(ctx, "once")
+
+	if len(inner.records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(inner.records))
+	}
+	if n := inner.records[0].NumAttrs(); n != 0 {
+		t.Errorf("expected the request ID not to be added again, got %d attrs", n)
+	}
+}
@@ -3,7 +3,6 @@ package logger
 import (
 	"context"
 	"log/slog"
-	"time"
 
 	"github.com/elprogramadorgt/lucidRAG/internal/domain/system"
 )
@@ -13,16 +12,30 @@ type LogStore interface {
 	Insert(ctx context.Context, entry *system.LogEntry) error
 }
 
-// MultiHandler writes logs to multiple handlers.
+// MultiHandler writes logs to multiple handlers and queues them for the store.
 type MultiHandler struct {
 	handlers []slog.Handler
 	store    LogStore
+	writer   *storeWriter
 	attrs    []slog.Attr
 	groups   []string
 }
 
 func NewMultiHandler(handlers []slog.Handler, store LogStore) *MultiHandler {
-	return &MultiHandler{handlers: handlers, store: store}
+	h := &MultiHandler{handlers: handlers, store: store}
+	if store != nil {
+		h.writer = newStoreWriter(store)
+	}
+	return h
+}
+
+// Close flushes entries still queued for the store. Handlers derived with
+// WithAttrs or WithGroup share the queue, so closing any of them closes all.
+func (h *MultiHandler) Close(ctx context.Context) error {
+	if h.writer == nil {
+		return nil
+	}
+	return h.writer.close(ctx)
 }
 
 func (h *MultiHandler) Enabled(ctx context.Context, level slog.Level) bool {
@@ -43,8 +56,8 @@ func (h *MultiHandler) Handle(ctx context.Context, r slog.Record) error {
 		}
 	}
 
-	if h.store != nil {
-		go h.persistLog(r)
+	if h.writer != nil {
+		h.writer.enqueue(h.newEntry(r))
 	}
 	return nil
 }
@@ -57,6 +70,7 @@ func (h *MultiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	return &MultiHandler{
 		handlers: newHandlers,
 		store:    h.store,
+		writer:   h.writer,
 		attrs:    append(h.attrs, attrs...),
 		groups:   h.groups,
 	}
@@ -70,12 +84,20 @@ func (h *MultiHandler) WithGroup(name string) slog.Handler {
 	return &MultiHandler{
 		handlers: newHandlers,
 		store:    h.store,
+		writer:   h.writer,
 		attrs:    h.attrs,
 		groups:   append(h.groups, name),
 	}
 }
 
+// persistLog writes r to the store synchronously.
 func (h *MultiHandler) persistLog(r slog.Record) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	_ = h.store.Insert(ctx, h.newEntry(r))
+}
+
+func (h *MultiHandler) newEntry(r slog.Record) *system.LogEntry {
 	entry := &system.LogEntry{
 		Level:     levelToString(r.Level),
 		Message:   r.Message,
@@ -91,10 +113,7 @@ func (h *MultiHandler) persistLog(r slog.Record) {
 		h.addAttr(entry, a)
 		return true
 	})
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	_ = h.store.Insert(ctx, entry)
+	return entry
 }
 
 func (h *MultiHandler) addAttr(entry *system.LogEntry, attr slog.Attr) {
@@ -265,6 +265,47 @@ func TestPersistLogWithAttributes(t *testing.T) {
 	}
 }
 
+func TestMultiHandlerCloseFlushesQueue(t *testing.T) {
+	store := &mockLogStore{}
+	mh := NewMultiHandler([]slog.Handler{&mockHandler{enabled: true}}, store)
+	child := mh.WithAttrs([]slog.Attr{slog.String("source", "test")})
+
+	for i := 0; i < 3; i++ {
+		record := slog.Record{Time: time.Now(), Message: "queued", Level: slog.LevelInfo}
+		if err := child.Handle(context.Background(), record); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	if err := mh.Close(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(store.entries) != 3 {
+		t.Fatalf("Expected 3 entries after close, got %d", len(store.entries))
+	}
+	if store.entries[0].Source != "test" {
+		t.Errorf("Expected source 'test', got '%s'", store.entries[0].Source)
+	}
+
+	record := slog.Record{Time: time.Now(), Message: "late", Level: slog.LevelInfo}
+	if err := mh.Handle(context.Background(), record); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(store.entries) != 3 {
+		t.Errorf("Expected entries after close to be dropped, got %d", len(store.entries))
+	}
+	if mh.writer.dropped.Load() != 1 {
+		t.Errorf("Expected 1 dropped entry, got %d", mh.writer.dropped.Load())
+	}
+}
+
+func TestMultiHandlerCloseWithoutStore(t *testing.T) {
+	mh := NewMultiHandler([]slog.Handler{&mockHandler{enabled: true}}, nil)
+	if err := mh.Close(context.Background()); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
 func TestAddAttr(t *testing.T) {
 	mh := &MultiHandler{}
 
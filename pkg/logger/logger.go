@@ -24,6 +24,7 @@ const (
 type Logger struct {
 	log   *slog.Logger
 	level *slog.LevelVar
+	store *MultiHandler
 }
 
 type Options struct {
@@ -68,16 +69,17 @@ func New(opts ...Options) *Logger {
 		stdoutHandler = slog.NewTextHandler(os.Stdout, handlerOpts)
 	}
 
-	var handler slog.Handler
+	var handler slog.Handler = stdoutHandler
+	var store *MultiHandler
 	if opt.Store != nil {
-		handler = NewMultiHandler([]slog.Handler{stdoutHandler}, opt.Store)
-	} else {
-		handler = stdoutHandler
+		store = NewMultiHandler([]slog.Handler{stdoutHandler}, opt.Store)
+		handler = store
 	}
 
 	return &Logger{
-		log:   slog.New(handler),
+		log:   slog.New(&contextHandler{Handler: handler}),
 		level: levelVar,
+		store: store,
 	}
 }
 
@@ -188,6 +190,7 @@ func (l *Logger) With(args ...any) *Logger {
 	return &Logger{
 		log:   l.log.With(args...),
 		level: l.level,
+		store: l.store,
 	}
 }
 
@@ -196,6 +199,7 @@ func (l *Logger) WithGroup(name string) *Logger {
 	return &Logger{
 		log:   l.log.WithGroup(name),
 		level: l.level,
+		store: l.store,
 	}
 }
 
@@ -205,13 +209,48 @@ func (l *Logger) WithError(err error) *Logger {
 }
 
 // WithContext extracts known context values and returns a new Logger with them.
+// The *Context methods add them on their own; this suits a logger passed on
+// to code that has no context.
 func (l *Logger) WithContext(ctx context.Context) *Logger {
 	logger := l
-	if requestID, ok := ctx.Value(RequestIDKey).(string); ok {
+	if requestID := RequestID(ctx); requestID != "" {
 		logger = logger.With("request_id", requestID)
 	}
-	if userID, ok := ctx.Value(UserIDKey).(string); ok {
+	if userID := UserID(ctx); userID != "" {
 		logger = logger.With("user_id", userID)
 	}
 	return logger
 }
+
+// ContextWithRequestID returns a copy of ctx carrying requestID, for the
+// *Context log methods to record.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, RequestIDKey, requestID)
+}
+
+// ContextWithUserID returns a copy of ctx carrying userID, for the *Context
+// log methods to record.
+func ContextWithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, UserIDKey, userID)
+}
+
+// RequestID returns the request ID carried by ctx, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(RequestIDKey).(string)
+	return id
+}
+
+// UserID returns the user ID carried by ctx, or "".
+func UserID(ctx context.Context) string {
+	id, _ := ctx.Value(UserIDKey).(string)
+	return id
+}
+
+// Close flushes log entries still queued for the store, waiting at most until
+// ctx is done. Entries logged afterwards are only written to stdout.
+func (l *Logger) Close(ctx context.Context) error {
+	if l.store == nil {
+		return nil
+	}
+	return l.store.Close(ctx)
+}
@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/internal/domain/system"
+)
+
+const (
+	// storeQueueSize is how many entries may wait for the store before new
+	// ones are dropped.
+	storeQueueSize = 1024
+	storeTimeout   = 5 * time.Second
+)
+
+// storeWriter persists log entries from a single background goroutine so a
+// slow store cannot pile up goroutines, and so pending entries can be flushed
+// before the process exits.
+type storeWriter struct {
+	store   LogStore
+	entries chan *system.LogEntry
+	done    chan struct{}
+	dropped atomic.Int64
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func newStoreWriter(store LogStore) *storeWriter {
+	w := &storeWriter{
+		store:   store,
+		entries: make(chan *system.LogEntry, storeQueueSize),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *storeWriter) run() {
+	defer close(w.done)
+	for entry := range w.entries {
+		w.write(entry)
+	}
+}
+
+func (w *storeWriter) write(entry *system.LogEntry) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	_ = w.store.Insert(ctx, entry)
+}
+
+// enqueue never blocks: the entry is dropped when the queue is full or the
+// writer is closed.
+func (w *storeWriter) enqueue(entry *system.LogEntry) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		w.dropped.Add(1)
+		return
+	}
+	select {
+	case w.entries <- entry:
+	default:
+		w.dropped.Add(1)
+	}
+}
+
+// close stops accepting entries and waits until queued ones are written or
+// ctx is done.
+func (w *storeWriter) close(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.entries)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
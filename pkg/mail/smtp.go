@@ -0,0 +1,248 @@
+// Package mail sends plain-text email, optionally with attachments, over
+// SMTP.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// Message is a plain-text email. InReplyTo and References thread it as a
+// reply in the recipient's mail client.
+type Message struct {
+	To          string
+	Subject     string
+	Body        string
+	InReplyTo   string
+	References  []string
+	Attachments []Attachment
+}
+
+// Attachment is a file sent along with a message.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+type SMTPClient struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     mail.Address
+	timeout  time.Duration
+}
+
+// NewSMTPClient returns a client for host:port that authenticates with
+// username and password when username is set. Port 465 uses implicit TLS;
+// other ports upgrade with STARTTLS when the server offers it.
+func NewSMTPClient(host, port, username, password string, from mail.Address) *SMTPClient {
+	return &SMTPClient{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+		timeout:  defaultTimeout,
+	}
+}
+
+// Send delivers msg and returns the Message-ID it was sent with.
+func (c *SMTPClient) Send(ctx context.Context, msg Message) (string, error) {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return "", fmt.Errorf("invalid recipient: %w", err)
+	}
+
+	messageID, err := c.newMessageID()
+	if err != nil {
+		return "", err
+	}
+	data, err := Build(c.from, *to, messageID, msg, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, c.host)
+	if err != nil {
+		_ = conn.Close()
+		return "", fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	if _, isTLS := conn.(*tls.Conn); !isTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: c.host}); err != nil {
+				return "", fmt.Errorf("failed to start TLS: %w", err)
+			}
+		}
+	}
+	if c.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.username, c.password, c.host)); err != nil {
+			return "", fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	if err := client.Mail(c.from.Address); err != nil {
+		return "", fmt.Errorf("sender rejected: %w", err)
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return "", fmt.Errorf("recipient rejected: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return "", fmt.Errorf("failed to start message: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return "", fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("message rejected: %w", err)
+	}
+	return messageID, client.Quit()
+}
+
+func (c *SMTPClient) dial(ctx context.Context) (net.Conn, error) {
+	addr := net.JoinHostPort(c.host, c.port)
+	if c.port == "465" {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: c.host}}
+		return dialer.DialContext(ctx, "tcp", addr)
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", addr)
+}
+
+func (c *SMTPClient) newMessageID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	domain := c.host
+	if at := strings.LastIndex(c.from.Address, "@"); at >= 0 {
+		domain = c.from.Address[at+1:]
+	}
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">", nil
+}
+
+// Build renders msg as an RFC 5322 message with a quoted-printable UTF-8
+// body. Messages with attachments are sent as multipart/mixed.
+func Build(from, to mail.Address, messageID string, msg Message, date time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		// Header values come from user input; a line break would start a
+		// new header.
+		value = strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+		buf.WriteString(name + ": " + value + "\r\n")
+	}
+
+	header("From", from.String())
+	header("To", to.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", date.Format(time.RFC1123Z))
+	header("Message-ID", messageID)
+	if msg.InReplyTo != "" {
+		header("In-Reply-To", msg.InReplyTo)
+	}
+	if len(msg.References) > 0 {
+		header("References", strings.Join(msg.References, " "))
+	}
+	header("MIME-Version", "1.0")
+
+	if len(msg.Attachments) == 0 {
+		header("Content-Type", "text/plain; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeText(&buf, msg.Body); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&buf)
+	header("Content-Type", mime.FormatMediaType("multipart/mixed", map[string]string{"boundary": mw.Boundary()}))
+	buf.WriteString("\r\n")
+
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := writeText(part, msg.Body); err != nil {
+		return nil, err
+	}
+	for _, a := range msg.Attachments {
+		filename := strings.NewReplacer("\r", "", "\n", "", `"`, "").Replace(a.Filename)
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {a.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": filename})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBase64(part, a.Data); err != nil {
+			return nil, fmt.Errorf("failed to encode attachment: %w", err)
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeText writes body quoted-printable with CRLF line endings.
+func writeText(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	body = strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n")
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return fmt.Errorf("failed to encode body: %w", err)
+	}
+	if err := qp.Close(); err != nil {
+		return fmt.Errorf("failed to encode body: %w", err)
+	}
+	return nil
+}
+
+// writeBase64 writes data base64 encoded in 76-character lines.
+func writeBase64(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err := io.WriteString(w, encoded[:76]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err := io.WriteString(w, encoded+"\r\n")
+	return err
+}
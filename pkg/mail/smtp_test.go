@@ -0,0 +1,89 @@
+package mail
+
+import (
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuild(t *testing.T) {
+	from := mail.Address{Name: "Support", Address: "support@example.com"}
+	to := mail.Address{Address: "ana@example.com"}
+	msg := Message{
+		To:         "ana@example.com",
+		Subject:    "Re: Contraseña\r\nBcc: evil@example.com",
+		Body:       "Hola Ana,\nUse the reset link.",
+		InReplyTo:  "<abc@mail.example.com>",
+		References: []string{"<abc@mail.example.com>"},
+	}
+
+	data, err := Build(from, to, "<id@example.com>", msg, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatalf("Expected a parseable message, got %v", err)
+	}
+	if got := parsed.Header.Get("Bcc"); got != "" {
+		t.Errorf("Expected no injected header, got Bcc %q", got)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || !strings.HasPrefix(subject, "Re: Contraseña") {
+		t.Errorf("Expected encoded subject, got %q (%v)", subject, err)
+	}
+	if parsed.Header.Get("In-Reply-To") != "<abc@mail.example.com>" {
+		t.Errorf("Expected In-Reply-To header, got %q", parsed.Header.Get("In-Reply-To"))
+	}
+	if !strings.Contains(string(data), "Hola Ana,\r\nUse the reset link.") {
+		t.Errorf("Expected CRLF body, got %q", data)
+	}
+}
+
+func TestBuildWithAttachment(t *testing.T) {
+	from := mail.Address{Address: "support@example.com"}
+	to := mail.Address{Address: "ana@example.com"}
+	msg := Message{
+		Subject: "Transcript",
+		Body:    "Attached.",
+		Attachments: []Attachment{
+			{Filename: "transcript.pdf", ContentType: "application/pdf", Data: []byte("%PDF-1.4 test")},
+		},
+	}
+
+	data, err := Build(from, to, "<id@example.com>", msg, time.Now())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	parsed, err := mail.ReadMessage(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatalf("Expected a parseable message, got %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("Expected multipart/mixed, got %q (%v)", mediaType, err)
+	}
+
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	if part, err := reader.NextPart(); err != nil || !strings.HasPrefix(part.Header.Get("Content-Type"), "text/plain") {
+		t.Fatalf("Expected the text body first, got %v", err)
+	}
+	part, err := reader.NextPart()
+	if err != nil {
+		t.Fatalf("Expected an attachment part, got %v", err)
+	}
+	if part.FileName() != "transcript.pdf" {
+		t.Errorf("Expected filename transcript.pdf, got %q", part.FileName())
+	}
+	encoded, _ := io.ReadAll(part)
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encoded), "\r\n", ""))
+	if err != nil || string(decoded) != "%PDF-1.4 test" {
+		t.Errorf("Expected the attachment data back, got %q (%v)", decoded, err)
+	}
+}
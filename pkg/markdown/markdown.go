@@ -0,0 +1,370 @@
+// Package markdown renders the Markdown knowledge base articles are written
+// in to HTML, and reports what will not come out as the author meant. It
+// covers headings, paragraphs, lists, block quotes, fenced code, pipe
+// tables, rules, emphasis, inline code, links and images. Raw HTML is
+// escaped, and links may only point to http(s), mailto or relative URLs.
+package markdown
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+type Severity string
+
+const (
+	// SeverityError marks Markdown that renders wrongly or is refused,
+	// such as an unclosed code block or a javascript: link.
+	SeverityError Severity = "error"
+	// SeverityWarning marks Markdown that renders, but likely not as
+	// intended.
+	SeverityWarning Severity = "warning"
+)
+
+// Problem is something wrong with the Markdown on Line, from 1.
+type Problem struct {
+	Line     int      `json:"line"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+var (
+	headingLine   = regexp.MustCompile(`^(#{1,6})(?:\s+(.*?))?\s*$`)
+	unspacedHead  = regexp.MustCompile(`^#{1,6}[^#\s]`)
+	ruleLine      = regexp.MustCompile(`^\s{0,3}(?:(?:\*\s*){3,}|(?:-\s*){3,}|(?:_\s*){3,})$`)
+	listItem      = regexp.MustCompile(`^\s{0,3}([-*+]|\d{1,9}[.)])\s+(.*)$`)
+	separatorCell = regexp.MustCompile(`^:?-{3,}:?$`)
+	strong        = regexp.MustCompile(`\*\*([^*\s](?:[^*]*[^*\s])?)\*\*`)
+	emphasis      = regexp.MustCompile(`(^|[^\w*])\*([^*\s](?:[^*]*[^*\s])?)\*`)
+	underscore    = regexp.MustCompile(`(^|\W)_([^_\s](?:[^_]*[^_\s])?)_(\W|$)`)
+	rawTag        = regexp.MustCompile(`</?[a-zA-Z][a-zA-Z0-9-]*(\s[^<>]*)?/?>`)
+	urlScheme     = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9+.-]*):`)
+)
+
+// Render returns src as HTML, with the problems found in line order.
+func Render(src string) (string, []Problem) {
+	r := &renderer{}
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	r.blocks(lines, 1)
+	slices.SortStableFunc(r.problems, func(a, b Problem) int { return a.Line - b.Line })
+	return r.out.String(), r.problems
+}
+
+type renderer struct {
+	out      strings.Builder
+	problems []Problem
+	// heading is the level of the last heading, to catch skipped levels.
+	heading int
+}
+
+func (r *renderer) problem(line int, severity Severity, format string, args ...any) {
+	r.problems = append(r.problems, Problem{Line: line, Severity: severity, Message: fmt.Sprintf(format, args...)})
+}
+
+// blocks renders lines, the first of which is line first of the source.
+func (r *renderer) blocks(lines []string, first int) {
+	var para []string
+	paraLine := 0
+	flush := func() {
+		if len(para) > 0 {
+			r.out.WriteString("<p>" + r.inline(strings.Join(para, "\n"), paraLine) + "</p>\n")
+			para = nil
+		}
+	}
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		lineNo := first + i
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			flush()
+			i++
+
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			flush()
+			i = r.fence(lines, i, first)
+
+		case headingLine.MatchString(trimmed):
+			flush()
+			m := headingLine.FindStringSubmatch(trimmed)
+			level := len(m[1])
+			text := strings.TrimSpace(strings.TrimRight(m[2], "#"))
+			if text == "" {
+				r.problem(lineNo, SeverityWarning, "heading is empty")
+			}
+			if r.heading > 0 && level > r.heading+1 {
+				r.problem(lineNo, SeverityWarning, "heading jumps from level %d to level %d", r.heading, level)
+			}
+			r.heading = level
+			fmt.Fprintf(&r.out, "<h%d>%s</h%d>\n", level, r.inline(text, lineNo), level)
+			i++
+
+		case ruleLine.MatchString(line):
+			flush()
+			r.out.WriteString("<hr>\n")
+			i++
+
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			var quoted []string
+			j := i
+			for ; j < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[j]), ">"); j++ {
+				text := strings.TrimPrefix(strings.TrimSpace(lines[j]), ">")
+				quoted = append(quoted, strings.TrimPrefix(text, " "))
+			}
+			r.out.WriteString("<blockquote>\n")
+			r.blocks(quoted, lineNo)
+			r.out.WriteString("</blockquote>\n")
+			i = j
+
+		case listItem.MatchString(line):
+			flush()
+			i = r.list(lines, i, first)
+
+		case strings.Contains(line, "|") && i+1 < len(lines) && isSeparator(cells(lines[i+1])):
+			flush()
+			i = r.table(lines, i, first)
+
+		default:
+			if unspacedHead.MatchString(trimmed) {
+				r.problem(lineNo, SeverityWarning, "a heading needs a space after the #")
+			}
+			if len(para) == 0 {
+				paraLine = lineNo
+			}
+			para = append(para, trimmed)
+			i++
+		}
+	}
+	flush()
+}
+
+// fence renders the fenced code block opening at lines[i] and returns the
+// index after it.
+func (r *renderer) fence(lines []string, i, first int) int {
+	opening := strings.TrimSpace(lines[i])
+	marker := opening[:3]
+	lang := strings.TrimSpace(strings.TrimLeft(opening, marker[:1]))
+
+	j := i + 1
+	for ; j < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[j]), marker); j++ {
+	}
+	if j == len(lines) {
+		r.problem(first+i, SeverityError, "code block is never closed")
+	}
+
+	class := ""
+	if lang != "" {
+		class = ` class="language-` + html.EscapeString(strings.Fields(lang)[0]) + `"`
+	}
+	code := strings.Join(lines[i+1:j], "\n")
+	if code != "" {
+		code += "\n"
+	}
+	fmt.Fprintf(&r.out, "<pre><code%s>%s</code></pre>\n", class, html.EscapeString(code))
+	return min(j+1, len(lines))
+}
+
+// list renders the list starting at lines[i] and returns the index after
+// it. Indented lines continue the item above them.
+func (r *renderer) list(lines []string, i, first int) int {
+	ordered := !strings.ContainsAny(listItem.FindStringSubmatch(lines[i])[1], "-*+")
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+	r.out.WriteString("<" + tag + ">\n")
+
+	var item []string
+	itemLine := 0
+	flush := func() {
+		if item != nil {
+			r.out.WriteString("<li>" + r.inline(strings.Join(item, "\n"), itemLine) + "</li>\n")
+		}
+	}
+	j := i
+	for ; j < len(lines); j++ {
+		line := lines[j]
+		if m := listItem.FindStringSubmatch(line); m != nil && !ruleLine.MatchString(line) {
+			if strings.ContainsAny(m[1], "-*+") == ordered {
+				break
+			}
+			flush()
+			item, itemLine = []string{strings.TrimSpace(m[2])}, first+j
+			continue
+		}
+		if strings.TrimSpace(line) == "" || !strings.HasPrefix(line, "  ") && !strings.HasPrefix(line, "\t") {
+			break
+		}
+		item = append(item, strings.TrimSpace(line))
+	}
+	flush()
+	r.out.WriteString("</" + tag + ">\n")
+	return j
+}
+
+// table renders the pipe table whose header is lines[i] and returns the
+// index after it.
+func (r *renderer) table(lines []string, i, first int) int {
+	header := cells(lines[i])
+	r.out.WriteString("<table>\n<thead>\n<tr>")
+	for _, cell := range header {
+		r.out.WriteString("<th>" + r.inline(cell, first+i) + "</th>")
+	}
+	r.out.WriteString("</tr>\n</thead>\n<tbody>\n")
+
+	j := i + 2
+	for ; j < len(lines) && strings.Contains(lines[j], "|"); j++ {
+		row := cells(lines[j])
+		if len(row) != len(header) {
+			r.problem(first+j, SeverityWarning, "table row has %d cells, the header has %d", len(row), len(header))
+		}
+		r.out.WriteString("<tr>")
+		for k := range header {
+			cell := ""
+			if k < len(row) {
+				cell = row[k]
+			}
+			r.out.WriteString("<td>" + r.inline(cell, first+j) + "</td>")
+		}
+		r.out.WriteString("</tr>\n")
+	}
+	r.out.WriteString("</tbody>\n</table>\n")
+	return j
+}
+
+// cells splits a pipe table row, honouring escaped pipes.
+func cells(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+	parts := strings.Split(strings.ReplaceAll(line, `\|`, "\x00"), "|")
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(strings.ReplaceAll(part, "\x00", "|"))
+	}
+	return parts
+}
+
+func isSeparator(row []string) bool {
+	if len(row) == 0 {
+		return false
+	}
+	for _, cell := range row {
+		if !separatorCell.MatchString(cell) {
+			return false
+		}
+	}
+	return true
+}
+
+// inline renders the spans of text, which starts on line.
+func (r *renderer) inline(text string, line int) string {
+	// at is the line of text[pos].
+	at := func(pos int) int {
+		return line + strings.Count(text[:pos], "\n")
+	}
+	if loc := rawTag.FindStringIndex(text); loc != nil {
+		r.problem(at(loc[0]), SeverityWarning, "raw HTML is shown as text")
+	}
+
+	var b strings.Builder
+	plain := 0
+	emit := func(end int) {
+		b.WriteString(r.emphasis(text[plain:end], at(plain)))
+	}
+	for i := 0; i < len(text); {
+		switch {
+		case text[i] == '`':
+			end := strings.IndexByte(text[i+1:], '`')
+			if end < 0 {
+				r.problem(at(i), SeverityWarning, "inline code is never closed")
+				i++
+				continue
+			}
+			emit(i)
+			b.WriteString("<code>" + html.EscapeString(text[i+1:i+1+end]) + "</code>")
+			i += end + 2
+			plain = i
+
+		case text[i] == '[' || strings.HasPrefix(text[i:], "!["):
+			image := text[i] == '!'
+			start := i
+			if image {
+				start++
+			}
+			label, target, n, ok := r.link(text[start:], at(i))
+			if !ok {
+				i++
+				continue
+			}
+			emit(i)
+			switch {
+			case target == "":
+				b.WriteString(r.emphasis(label, at(i)))
+			case image:
+				fmt.Fprintf(&b, `<img src="%s" alt="%s">`, html.EscapeString(target), html.EscapeString(label))
+			default:
+				fmt.Fprintf(&b, `<a href="%s" rel="nofollow noopener">%s</a>`, html.EscapeString(target), r.emphasis(label, at(i)))
+			}
+			i = start + n
+			plain = i
+
+		default:
+			i++
+		}
+	}
+	emit(len(text))
+	return b.String()
+}
+
+// link reads "[label](target)" at the start of text and returns its parts
+// and length. A refused target comes back empty, so only the label is
+// shown; ok is false when text does not start with a link at all.
+func (r *renderer) link(text string, line int) (label, target string, n int, ok bool) {
+	mid := strings.Index(text, "](")
+	if mid < 0 || strings.IndexByte(text, ']') != mid || strings.Contains(text[:mid], "\n") {
+		return "", "", 0, false
+	}
+	end := strings.IndexByte(text[mid+2:], ')')
+	if end < 0 {
+		r.problem(line, SeverityError, "link is missing its closing parenthesis")
+		return "", "", 0, false
+	}
+	label = text[1:mid]
+	target = strings.TrimSpace(text[mid+2 : mid+2+end])
+	n = mid + 3 + end
+
+	if target == "" {
+		r.problem(line, SeverityWarning, "link %q has no URL", label)
+		return label, "", n, true
+	}
+	if m := urlScheme.FindStringSubmatch(target); m != nil {
+		switch strings.ToLower(m[1]) {
+		case "http", "https", "mailto":
+		default:
+			r.problem(line, SeverityError, "%s: links are not allowed", strings.ToLower(m[1]))
+			return label, "", n, true
+		}
+	}
+	return label, target, n, true
+}
+
+// emphasis escapes text, which starts on line, and renders its bold and
+// italic spans, reporting bold markers left unpaired.
+func (r *renderer) emphasis(text string, line int) string {
+	out := html.EscapeString(text)
+	out = strong.ReplaceAllString(out, "<strong>$1</strong>")
+	out = emphasis.ReplaceAllString(out, "$1<em>$2</em>")
+	out = underscore.ReplaceAllString(out, "$1<em>$2</em>$3")
+	if i := strings.Index(out, "**"); i >= 0 {
+		r.problem(line+strings.Count(out[:i], "\n"), SeverityWarning, "bold text is never closed")
+	}
+	return out
+}
@@ -0,0 +1,110 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	src := strings.Join([]string{
+		"# Returns",
+		"",
+		"Items can be returned **within 30 days** of _delivery_.",
+		"See [the policy](https://shop.example.com/returns) or `RET-1`.",
+		"",
+		"## Steps",
+		"1. Open your order",
+		"2. Pick the items",
+		"   and a reason",
+		"",
+		"- Refunds take 5 days",
+		"",
+		"> Store credit is instant.",
+		"",
+		"| Item | Days |",
+		"| --- | --- |",
+		"| Shoes | 30 |",
+		"",
+		"```json",
+		`{"a": "<b>"}`,
+		"```",
+		"---",
+	}, "\n")
+
+	got, problems := Render(src)
+	want := strings.Join([]string{
+		"<h1>Returns</h1>",
+		"<p>Items can be returned <strong>within 30 days</strong> of <em>delivery</em>.",
+		`See <a href="https://shop.example.com/returns" rel="nofollow noopener">the policy</a> or <code>RET-1</code>.</p>`,
+		"<h2>Steps</h2>",
+		"<ol>",
+		"<li>Open your order</li>",
+		"<li>Pick the items",
+		"and a reason</li>",
+		"</ol>",
+		"<ul>",
+		"<li>Refunds take 5 days</li>",
+		"</ul>",
+		"<blockquote>",
+		"<p>Store credit is instant.</p>",
+		"</blockquote>",
+		"<table>",
+		"<thead>",
+		"<tr><th>Item</th><th>Days</th></tr>",
+		"</thead>",
+		"<tbody>",
+		"<tr><td>Shoes</td><td>30</td></tr>",
+		"</tbody>",
+		"</table>",
+		`<pre><code class="language-json">{&#34;a&#34;: &#34;&lt;b&gt;&#34;}`,
+		"</code></pre>",
+		"<hr>",
+		"",
+	}, "\n")
+	if got != want {
+		t.Errorf("Unexpected HTML:\n%s\nwant:\n%s", got, want)
+	}
+	if len(problems) != 0 {
+		t.Errorf("Expected no problems, got %+v", problems)
+	}
+}
+
+func TestRenderReportsProblems(t *testing.T) {
+	src := strings.Join([]string{
+		"# Title",
+		"### Skipped a level",
+		"Click [here](javascript:alert(1)) or [there]().",
+		"<script>alert(1)</script>",
+		"**never closed",
+		"#NoSpace",
+		"| a | b |",
+		"| --- | --- |",
+		"| 1 |",
+		"```",
+		"never closed",
+	}, "\n")
+
+	got, problems := Render(src)
+	if strings.Contains(got, "<script>") || strings.Contains(got, "javascript:") {
+		t.Errorf("Expected unsafe markup to be neutralized, got %s", got)
+	}
+
+	want := []Problem{
+		{2, SeverityWarning, "heading jumps from level 1 to level 3"},
+		{3, SeverityError, "javascript: links are not allowed"},
+		{3, SeverityWarning, `link "there" has no URL`},
+		{4, SeverityWarning, "raw HTML is shown as text"},
+		{5, SeverityWarning, "bold text is never closed"},
+		{6, SeverityWarning, "a heading needs a space after the #"},
+		{9, SeverityWarning, "table row has 1 cells, the header has 2"},
+		{10, SeverityError, "code block is never closed"},
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("Expected %d problems, got %+v", len(want), problems)
+	}
+	for i := range want {
+		if problems[i] != want[i] {
+			t.Errorf("Problem %d: expected %+v, got %+v", i, want[i], problems[i])
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestFSRoundTrip(t *testing.T) {
+	store, err := NewFS(t.TempDir())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "backups/a.txt", strings.NewReader("first"), 5); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := store.Put(ctx, "other/b.txt", strings.NewReader("second"), 6); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	rc, err := store.Get(ctx, "backups/a.txt")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	data, _ := io.ReadAll(rc)
+	_ = rc.Close()
+	if string(data) != "first" {
+		t.Errorf("Expected first, got %s", data)
+	}
+
+	keys, err := store.List(ctx, "backups/")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "backups/a.txt" {
+		t.Errorf("Expected [backups/a.txt], got %v", keys)
+	}
+
+	if err := store.Delete(ctx, "backups/a.txt"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := store.Get(ctx, "backups/a.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestFSRejectsEscapingKeys(t *testing.T) {
+	store, _ := NewFS(t.TempDir())
+
+	for _, key := range []string{"", "/etc/passwd", "../secret", "a/../../b", "a//b"} {
+		if err := store.Put(context.Background(), key, strings.NewReader("x"), 1); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Expected ErrInvalidKey for %q, got %v", key, err)
+		}
+	}
+}
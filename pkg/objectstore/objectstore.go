@@ -0,0 +1,48 @@
+// Package objectstore stores opaque blobs by key, either on the local
+// filesystem or in an S3-compatible bucket.
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by Get when no object exists under the key.
+var ErrNotFound = errors.New("objectstore: not found")
+
+// ErrInvalidKey is returned for keys that are empty or try to leave the
+// store, such as "../x".
+var ErrInvalidKey = errors.New("objectstore: invalid key")
+
+type Store interface {
+	// Put stores size bytes read from r under key, replacing any existing
+	// object.
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// List returns the keys that start with prefix, sorted.
+	List(ctx context.Context, prefix string) ([]string, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Presigner is a Store that can hand out URLs reading an object directly,
+// without the caller's credentials. S3 is one; the filesystem is not.
+type Presigner interface {
+	// PresignGet returns a URL that reads key until expires has passed. A
+	// non-empty filename makes browsers save the download under it.
+	PresignGet(key, filename string, expires time.Duration) (string, error)
+}
+
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
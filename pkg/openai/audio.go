@@ -0,0 +1,76 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+)
+
+type transcriptionResponse struct {
+	Text string `json:"text"`
+}
+
+// CreateTranscription converts speech to text. filename is sent with the
+// audio and its extension tells the API how the audio is encoded.
+func (c *Client) CreateTranscription(ctx context.Context, audio io.Reader, filename string, model string) (string, error) {
+	if model == "" {
+		model = "whisper-1"
+	}
+
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	if err := form.WriteField("model", model); err != nil {
+		return "", fmt.Errorf("failed to build request: %w", err)
+	}
+	if err := form.WriteField("response_format", "json"); err != nil {
+		return "", fmt.Errorf("failed to build request: %w", err)
+	}
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		return "", fmt.Errorf("failed to build request: %w", err)
+	}
+	if _, err := io.Copy(part, audio); err != nil {
+		return "", fmt.Errorf("failed to read audio: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return "", fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/audio/transcriptions", &buf)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr apiError
+		if err := json.Unmarshal(body, &apiErr); err == nil && apiErr.Error.Message != "" {
+			return "", fmt.Errorf("OpenAI API error: %s (type: %s)", apiErr.Error.Message, apiErr.Error.Type)
+		}
+		return "", fmt.Errorf("OpenAI API error: status %d", resp.StatusCode)
+	}
+
+	var transcription transcriptionResponse
+	if err := json.Unmarshal(body, &transcription); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return transcription.Text, nil
+}
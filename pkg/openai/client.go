@@ -1,8 +1,11 @@
 package openai
 
 import (
+	"context"
 	"net/http"
 	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/pkg/breaker"
 )
 
 const (
@@ -14,8 +17,19 @@ type Client struct {
 	apiKey     string
 	baseURL    string
 	httpClient *http.Client
+	usageHook  UsageHook
+}
+
+// Usage is the tokens a call was billed for.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
 }
 
+// UsageHook receives the usage of every successful call, with the model
+// the call asked for.
+type UsageHook func(ctx context.Context, model string, usage Usage)
+
 type Option func(*Client)
 
 func WithBaseURL(url string) Option {
@@ -30,6 +44,28 @@ func WithTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithBreaker makes calls fail fast with breaker.ErrOpen while the API
+// keeps failing.
+func WithBreaker(b *breaker.Breaker) Option {
+	return func(c *Client) {
+		c.httpClient.Transport = b.Transport(c.httpClient.Transport)
+	}
+}
+
+// WithUsageHook reports the token usage of every call to hook, for
+// tracking spend.
+func WithUsageHook(hook UsageHook) Option {
+	return func(c *Client) {
+		c.usageHook = hook
+	}
+}
+
+func (c *Client) reportUsage(ctx context.Context, model string, usage Usage) {
+	if c.usageHook != nil {
+		c.usageHook(ctx, model, usage)
+	}
+}
+
 func NewClient(apiKey string, opts ...Option) *Client {
 	c := &Client{
 		apiKey:  apiKey,
@@ -17,8 +17,11 @@ type ChatMessage struct {
 type chatCompletionRequest struct {
 	Model       string        `json:"model"`
 	Messages    []ChatMessage `json:"messages"`
-	Temperature float64       `json:"temperature,omitempty"`
+	Temperature *float64      `json:"temperature,omitempty"`
+	TopP        *float64      `json:"top_p,omitempty"`
 	MaxTokens   int           `json:"max_tokens,omitempty"`
+
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
 }
 
 type chatCompletionResponse struct {
@@ -34,16 +37,32 @@ type chatCompletionResponse struct {
 		} `json:"message"`
 		FinishReason string `json:"finish_reason"`
 	} `json:"choices"`
-	Usage struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage"`
+	Usage Usage `json:"usage"`
 }
 
 type CompletionOptions struct {
-	Temperature float64
+	// Temperature and TopP, when set, override the model's sampling
+	// defaults; they are pointers so that zero can be asked for.
+	Temperature *float64
+	TopP        *float64
 	MaxTokens   int
+	// ResponseFormat constrains the reply, e.g. to JSON matching a schema.
+	ResponseFormat *ResponseFormat
+}
+
+// ResponseFormat selects structured output. Type is "json_object" or
+// "json_schema"; the latter requires JSONSchema.
+type ResponseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+type JSONSchema struct {
+	Name   string `json:"name"`
+	Schema any    `json:"schema"`
+	// Strict makes the API enforce the schema exactly; it only accepts
+	// schemas that list every property as required and forbid extras.
+	Strict bool `json:"strict,omitempty"`
 }
 
 func (c *Client) CreateChatCompletion(ctx context.Context, messages []ChatMessage, model string, opts *CompletionOptions) (string, error) {
@@ -58,7 +77,9 @@ func (c *Client) CreateChatCompletion(ctx context.Context, messages []ChatMessag
 
 	if opts != nil {
 		reqBody.Temperature = opts.Temperature
+		reqBody.TopP = opts.TopP
 		reqBody.MaxTokens = opts.MaxTokens
+		reqBody.ResponseFormat = opts.ResponseFormat
 	}
 
 	jsonBody, err := json.Marshal(reqBody)
@@ -98,6 +119,7 @@ func (c *Client) CreateChatCompletion(ctx context.Context, messages []ChatMessag
 		return "", fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	c.reportUsage(ctx, model, chatResp.Usage)
 	if len(chatResp.Choices) == 0 {
 		return "", fmt.Errorf("no completion returned")
 	}
@@ -10,8 +10,9 @@ import (
 )
 
 type embeddingRequest struct {
-	Model string `json:"model"`
-	Input string `json:"input"`
+	Model      string `json:"model"`
+	Input      string `json:"input"`
+	Dimensions int    `json:"dimensions,omitempty"`
 }
 
 type embeddingResponse struct {
@@ -37,13 +38,20 @@ type apiError struct {
 }
 
 func (c *Client) CreateEmbedding(ctx context.Context, text string, model string) ([]float64, error) {
+	return c.CreateEmbeddingWithDimensions(ctx, text, model, 0)
+}
+
+// CreateEmbeddingWithDimensions asks for vectors shortened to dimensions,
+// which text-embedding-3 models support. Zero keeps the model's size.
+func (c *Client) CreateEmbeddingWithDimensions(ctx context.Context, text string, model string, dimensions int) ([]float64, error) {
 	if model == "" {
 		model = "text-embedding-ada-002"
 	}
 
 	reqBody := embeddingRequest{
-		Model: model,
-		Input: text,
+		Model:      model,
+		Input:      text,
+		Dimensions: dimensions,
 	}
 
 	jsonBody, err := json.Marshal(reqBody)
@@ -83,6 +91,7 @@ func (c *Client) CreateEmbedding(ctx context.Context, text string, model string)
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	c.reportUsage(ctx, model, Usage{PromptTokens: embResp.Usage.PromptTokens})
 	if len(embResp.Data) == 0 {
 		return nil, fmt.Errorf("no embedding returned")
 	}
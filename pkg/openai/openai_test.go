@@ -3,8 +3,10 @@ package openai
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -307,7 +309,9 @@ func TestCreateChatCompletionWithOptions(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var req chatCompletionRequest
 		json.NewDecoder(r.Body).Decode(&req)
-		capturedTemp = req.Temperature
+		if req.Temperature != nil {
+			capturedTemp = *req.Temperature
+		}
 		capturedMaxTokens = req.MaxTokens
 
 		response := chatCompletionResponse{
@@ -336,8 +340,9 @@ func TestCreateChatCompletionWithOptions(t *testing.T) {
 	}
 
 	messages := []ChatMessage{{Role: "user", Content: "test"}}
+	temperature := 0.7
 	opts := &CompletionOptions{
-		Temperature: 0.7,
+		Temperature: &temperature,
 		MaxTokens:   100,
 	}
 	_, err := client.CreateChatCompletion(context.Background(), messages, "gpt-4", opts)
@@ -353,6 +358,42 @@ func TestCreateChatCompletionWithOptions(t *testing.T) {
 	}
 }
 
+func TestCreateChatCompletionWithResponseFormat(t *testing.T) {
+	var captured map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&captured)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"{\"open\":true}"}}]}`))
+	}))
+	defer server.Close()
+
+	client := &Client{
+		apiKey:     "test-key",
+		baseURL:    server.URL,
+		httpClient: http.DefaultClient,
+	}
+
+	opts := &CompletionOptions{ResponseFormat: &ResponseFormat{
+		Type:       "json_schema",
+		JSONSchema: &JSONSchema{Name: "answer", Schema: map[string]any{"type": "object"}},
+	}}
+	answer, err := client.CreateChatCompletion(context.Background(), []ChatMessage{{Role: "user", Content: "open?"}}, "", opts)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if answer != `{"open":true}` {
+		t.Errorf("Expected JSON answer, got %s", answer)
+	}
+
+	format, ok := captured["response_format"].(map[string]any)
+	if !ok || format["type"] != "json_schema" {
+		t.Fatalf("Expected json_schema response_format, got %v", captured["response_format"])
+	}
+	if schema, ok := format["json_schema"].(map[string]any); !ok || schema["name"] != "answer" {
+		t.Errorf("Expected named schema, got %v", format["json_schema"])
+	}
+}
+
 func TestCreateChatCompletionNoChoices(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		response := chatCompletionResponse{
@@ -397,15 +438,252 @@ func TestChatMessageStruct(t *testing.T) {
 }
 
 func TestCompletionOptionsStruct(t *testing.T) {
+	temperature := 0.5
 	opts := CompletionOptions{
-		Temperature: 0.5,
+		Temperature: &temperature,
 		MaxTokens:   500,
 	}
 
-	if opts.Temperature != 0.5 {
-		t.Errorf("Expected temperature 0.5, got %f", opts.Temperature)
+	if *opts.Temperature != 0.5 {
+		t.Errorf("Expected temperature 0.5, got %f", *opts.Temperature)
 	}
 	if opts.MaxTokens != 500 {
 		t.Errorf("Expected maxTokens 500, got %d", opts.MaxTokens)
 	}
 }
+
+func TestCreateTranscription(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/audio/transcriptions" {
+			t.Errorf("Expected path /audio/transcriptions, got %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Error("Expected Authorization header")
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("Expected multipart body, got %v", err)
+		}
+		if r.FormValue("model") != "whisper-1" {
+			t.Errorf("Expected default model whisper-1, got %s", r.FormValue("model"))
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("Expected file part, got %v", err)
+		}
+		data, _ := io.ReadAll(file)
+		if header.Filename != "voice.ogg" || string(data) != "OggS" {
+			t.Errorf("Unexpected file %s with %q", header.Filename, data)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(transcriptionResponse{Text: "where is my order"})
+	}))
+	defer server.Close()
+
+	client := &Client{
+		apiKey:     "test-key",
+		baseURL:    server.URL,
+		httpClient: http.DefaultClient,
+	}
+
+	text, err := client.CreateTranscription(context.Background(), strings.NewReader("OggS"), "voice.ogg", "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if text != "where is my order" {
+		t.Errorf("Expected transcript, got %q", text)
+	}
+}
+
+func TestCreateTranscriptionAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"message":"Invalid file format.","type":"invalid_request_error"}}`))
+	}))
+	defer server.Close()
+
+	client := &Client{
+		apiKey:     "test-key",
+		baseURL:    server.URL,
+		httpClient: http.DefaultClient,
+	}
+
+	_, err := client.CreateTranscription(context.Background(), strings.NewReader("x"), "voice.amr", "")
+	if err == nil || !strings.Contains(err.Error(), "Invalid file format") {
+		t.Errorf("Expected API error, got %v", err)
+	}
+}
+
+func TestDescribeImage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("Expected path /chat/completions, got %s", r.URL.Path)
+		}
+
+		var req visionCompletionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "gpt-4o-mini" {
+			t.Errorf("Expected default model gpt-4o-mini, got %s", req.Model)
+		}
+		if len(req.Messages) != 1 || len(req.Messages[0].Content) != 2 {
+			t.Fatalf("Expected one message with text and image parts, got %+v", req.Messages)
+		}
+		if req.Messages[0].Content[0].Text != "what is this?" {
+			t.Errorf("Expected prompt, got %q", req.Messages[0].Content[0].Text)
+		}
+		if url := req.Messages[0].Content[1].ImageURL.URL; url != "data:image/png;base64,iVBORw==" {
+			t.Errorf("Expected data URL, got %s", url)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"A router showing error E42."}}]}`))
+	}))
+	defer server.Close()
+
+	client := &Client{
+		apiKey:     "test-key",
+		baseURL:    server.URL,
+		httpClient: http.DefaultClient,
+	}
+
+	description, err := client.DescribeImage(context.Background(), []byte{0x89, 'P', 'N', 'G'}, "image/png", "what is this?", "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if description != "A router showing error E42." {
+		t.Errorf("Expected description, got %q", description)
+	}
+}
+
+func TestCreateSpeech(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/audio/speech" {
+			t.Errorf("Expected path /audio/speech, got %s", r.URL.Path)
+		}
+		var req speechRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if req.Model != "tts-1" || req.Voice != "alloy" {
+			t.Errorf("Expected default model and voice, got %s/%s", req.Model, req.Voice)
+		}
+		if req.Input != "Your order ships today." || req.ResponseFormat != "opus" {
+			t.Errorf("Unexpected request %+v", req)
+		}
+		w.Header().Set("Content-Type", "audio/ogg")
+		w.Write([]byte("OggS"))
+	}))
+	defer server.Close()
+
+	client := &Client{
+		apiKey:     "test-key",
+		baseURL:    server.URL,
+		httpClient: http.DefaultClient,
+	}
+
+	audio, err := client.CreateSpeech(context.Background(), "Your order ships today.", "", "opus", "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(audio) != "OggS" {
+		t.Errorf("Expected audio bytes, got %q", audio)
+	}
+}
+
+func TestCreateToolCompletion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req toolCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if len(req.Tools) != 1 || req.Tools[0].Function.Name != "calculator" {
+			t.Errorf("Expected calculator tool, got %+v", req.Tools)
+		}
+		if last := req.Messages[len(req.Messages)-1]; last.Role != "tool" || last.ToolCallID != "call_1" {
+			t.Errorf("Expected tool result message, got %+v", last)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":null,"tool_calls":[{"id":"call_2","type":"function","function":{"name":"calculator","arguments":"{\"expression\":\"2*3\"}"}}]},"finish_reason":"tool_calls"}]}`))
+	}))
+	defer server.Close()
+
+	client := &Client{
+		apiKey:     "test-key",
+		baseURL:    server.URL,
+		httpClient: http.DefaultClient,
+	}
+
+	tools := []Tool{{Type: "function", Function: FunctionDefinition{Name: "calculator", Parameters: map[string]any{"type": "object"}}}}
+	messages := []ToolChatMessage{
+		{Role: "user", Content: "what is 2*3?"},
+		{Role: "tool", Content: "6", ToolCallID: "call_1"},
+	}
+	msg, err := client.CreateToolCompletion(context.Background(), messages, "", tools, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(msg.ToolCalls) != 1 || msg.ToolCalls[0].Function.Arguments != `{"expression":"2*3"}` {
+		t.Errorf("Expected a tool call, got %+v", msg)
+	}
+}
+
+func TestUsageHook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/embeddings" {
+			w.Write([]byte(`{"data":[{"embedding":[0.1]}],"usage":{"prompt_tokens":7,"total_tokens":7}}`))
+			return
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"hi"}}],"usage":{"prompt_tokens":12,"completion_tokens":3,"total_tokens":15}}`))
+	}))
+	defer server.Close()
+
+	type call struct {
+		model string
+		usage Usage
+	}
+	var calls []call
+	client := NewClient("test-key", WithBaseURL(server.URL), WithUsageHook(func(ctx context.Context, model string, usage Usage) {
+		calls = append(calls, call{model, usage})
+	}))
+
+	if _, err := client.CreateChatCompletion(context.Background(), []ChatMessage{{Role: "user", Content: "hi"}}, "gpt-4o", nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := client.CreateEmbedding(context.Background(), "hi", ""); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := []call{
+		{"gpt-4o", Usage{PromptTokens: 12, CompletionTokens: 3}},
+		{"text-embedding-ada-002", Usage{PromptTokens: 7}},
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("Expected %d reported calls, got %+v", len(want), calls)
+	}
+	for i, w := range want {
+		if calls[i] != w {
+			t.Errorf("Expected call %d to report %+v, got %+v", i, w, calls[i])
+		}
+	}
+}
+
+func TestListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/models" {
+			t.Errorf("Expected GET /models, got %s %s", r.Method, r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"id":"gpt-4o","object":"model"},{"id":"text-embedding-3-small","object":"model"}]}`))
+	}))
+	defer server.Close()
+
+	models, err := NewClient("test-key", WithBaseURL(server.URL)).ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(models) != 2 || models[0] != "gpt-4o" || models[1] != "text-embedding-3-small" {
+		t.Errorf("Expected gpt-4o and text-embedding-3-small, got %v", models)
+	}
+}
@@ -0,0 +1,130 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Tool describes a function the model may call.
+type Tool struct {
+	Type     string             `json:"type"`
+	Function FunctionDefinition `json:"function"`
+}
+
+type FunctionDefinition struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Parameters is the JSON Schema of the function's arguments.
+	Parameters any `json:"parameters"`
+}
+
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Function FunctionCall `json:"function"`
+}
+
+type FunctionCall struct {
+	Name string `json:"name"`
+	// Arguments is a JSON object as generated by the model; it may be
+	// malformed.
+	Arguments string `json:"arguments"`
+}
+
+// ToolChatMessage is a chat message that can carry the model's tool calls
+// or, with role "tool", the result of one.
+type ToolChatMessage struct {
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+}
+
+type toolCompletionRequest struct {
+	Model       string            `json:"model"`
+	Messages    []ToolChatMessage `json:"messages"`
+	Tools       []Tool            `json:"tools,omitempty"`
+	Temperature *float64          `json:"temperature,omitempty"`
+	TopP        *float64          `json:"top_p,omitempty"`
+	MaxTokens   int               `json:"max_tokens,omitempty"`
+
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+}
+
+type toolCompletionResponse struct {
+	Choices []struct {
+		Message      ToolChatMessage `json:"message"`
+		FinishReason string          `json:"finish_reason"`
+	} `json:"choices"`
+	Usage Usage `json:"usage"`
+}
+
+// CreateToolCompletion is CreateChatCompletion with tools the model may
+// call. The returned message either answers or lists tool calls whose
+// results the caller appends before asking again.
+func (c *Client) CreateToolCompletion(ctx context.Context, messages []ToolChatMessage, model string, tools []Tool, opts *CompletionOptions) (*ToolChatMessage, error) {
+	if model == "" {
+		model = "gpt-3.5-turbo"
+	}
+
+	reqBody := toolCompletionRequest{
+		Model:    model,
+		Messages: messages,
+		Tools:    tools,
+	}
+
+	if opts != nil {
+		reqBody.Temperature = opts.Temperature
+		reqBody.TopP = opts.TopP
+		reqBody.MaxTokens = opts.MaxTokens
+		reqBody.ResponseFormat = opts.ResponseFormat
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr apiError
+		if err := json.Unmarshal(body, &apiErr); err == nil && apiErr.Error.Message != "" {
+			return nil, fmt.Errorf("OpenAI API error: %s (type: %s)", apiErr.Error.Message, apiErr.Error.Type)
+		}
+		return nil, fmt.Errorf("OpenAI API error: status %d", resp.StatusCode)
+	}
+
+	var toolResp toolCompletionResponse
+	if err := json.Unmarshal(body, &toolResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	c.reportUsage(ctx, model, toolResp.Usage)
+	if len(toolResp.Choices) == 0 {
+		return nil, fmt.Errorf("no completion returned")
+	}
+
+	return &toolResp.Choices[0].Message, nil
+}
@@ -0,0 +1,99 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+type contentPart struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *imageURL `json:"image_url,omitempty"`
+}
+
+type imageURL struct {
+	URL string `json:"url"`
+}
+
+type visionMessage struct {
+	Role    string        `json:"role"`
+	Content []contentPart `json:"content"`
+}
+
+type visionCompletionRequest struct {
+	Model     string          `json:"model"`
+	Messages  []visionMessage `json:"messages"`
+	MaxTokens int             `json:"max_tokens,omitempty"`
+}
+
+// DescribeImage sends an image and a prompt to a vision-capable chat model
+// and returns its reply. The image is inlined as a data URL, so it never has
+// to be publicly reachable.
+func (c *Client) DescribeImage(ctx context.Context, image []byte, mimeType, prompt, model string) (string, error) {
+	if model == "" {
+		model = "gpt-4o-mini"
+	}
+
+	reqBody := visionCompletionRequest{
+		Model: model,
+		Messages: []visionMessage{{
+			Role: "user",
+			Content: []contentPart{
+				{Type: "text", Text: prompt},
+				{Type: "image_url", ImageURL: &imageURL{
+					URL: "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(image),
+				}},
+			},
+		}},
+		MaxTokens: 500,
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(jsonBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr apiError
+		if err := json.Unmarshal(body, &apiErr); err == nil && apiErr.Error.Message != "" {
+			return "", fmt.Errorf("OpenAI API error: %s (type: %s)", apiErr.Error.Message, apiErr.Error.Type)
+		}
+		return "", fmt.Errorf("OpenAI API error: status %d", resp.StatusCode)
+	}
+
+	var chatResp chatCompletionResponse
+	if err := json.Unmarshal(body, &chatResp); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	c.reportUsage(ctx, model, chatResp.Usage)
+	if len(chatResp.Choices) == 0 {
+		return "", fmt.Errorf("no completion returned")
+	}
+
+	return chatResp.Choices[0].Message.Content, nil
+}
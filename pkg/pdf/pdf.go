@@ -0,0 +1,229 @@
+// Package pdf writes simple text documents as PDF: wrapped paragraphs in
+// the standard Helvetica fonts on A4 pages, with no external dependencies.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	pageWidth  = 595.0
+	pageHeight = 842.0
+	margin     = 50.0
+	lineFactor = 1.4
+)
+
+// Style is how a paragraph is set.
+type Style struct {
+	Size  float64
+	Bold  bool
+	Color Color
+}
+
+// Color is an RGB color with components from 0 to 1.
+type Color struct{ R, G, B float64 }
+
+// HexColor parses a #rrggbb color; anything else is black.
+func HexColor(hex string) Color {
+	var r, g, b uint8
+	if _, err := fmt.Sscanf(hex, "#%02x%02x%02x", &r, &g, &b); err != nil {
+		return Color{}
+	}
+	return Color{float64(r) / 255, float64(g) / 255, float64(b) / 255}
+}
+
+// Document collects pages as text is added. Text outside Windows-1252 is
+// replaced with "?", since the standard fonts cannot show it.
+type Document struct {
+	title string
+	pages []*bytes.Buffer
+	y     float64
+}
+
+func New(title string) *Document {
+	d := &Document{title: title}
+	d.newPage()
+	return d
+}
+
+func (d *Document) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = pageHeight - margin
+}
+
+// Paragraph adds text wrapped to the page width, starting new pages as
+// needed. Line breaks in text are kept.
+func (d *Document) Paragraph(text string, style Style) {
+	if style.Size <= 0 {
+		style.Size = 10
+	}
+	lineHeight := style.Size * lineFactor
+	for _, line := range wrap(text, style, pageWidth-2*margin) {
+		if d.y-lineHeight < margin {
+			d.newPage()
+		}
+		d.y -= lineHeight
+		font := "F1"
+		if style.Bold {
+			font = "F2"
+		}
+		fmt.Fprintf(d.pages[len(d.pages)-1], "BT /%s %.1f Tf %.3f %.3f %.3f rg %.1f %.1f Td (%s) Tj ET\n",
+			font, style.Size, style.Color.R, style.Color.G, style.Color.B, margin, d.y, escape(encode(line)))
+	}
+}
+
+// Space adds vertical space.
+func (d *Document) Space(height float64) {
+	d.y -= height
+	if d.y < margin {
+		d.newPage()
+	}
+}
+
+// Rule draws a horizontal line across the page.
+func (d *Document) Rule(color Color) {
+	d.Space(6)
+	fmt.Fprintf(d.pages[len(d.pages)-1], "%.3f %.3f %.3f RG 1 w %.1f %.1f m %.1f %.1f l S\n",
+		color.R, color.G, color.B, margin, d.y, pageWidth-margin, d.y)
+	d.Space(6)
+}
+
+// Bytes returns the finished PDF.
+func (d *Document) Bytes() []byte {
+	var objects []string
+	add := func(obj string) int {
+		objects = append(objects, obj)
+		return len(objects)
+	}
+
+	add("<< /Type /Catalog /Pages 2 0 R >>")
+	pagesIndex := add("") // filled in once the page objects are known
+	add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	info := add(fmt.Sprintf("<< /Title (%s) /Producer (lucidRAG) >>", escape(encode(d.title))))
+
+	kids := make([]string, 0, len(d.pages))
+	for _, page := range d.pages {
+		content := add(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+		pageObj := add(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", pageWidth, pageHeight, content))
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageObj))
+	}
+	objects[pagesIndex-1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, info, xref)
+	return buf.Bytes()
+}
+
+// wrap splits text into lines no wider than width, breaking overlong words.
+func wrap(text string, style Style, width float64) []string {
+	var lines []string
+	for _, para := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if textWidth(candidate, style) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			for textWidth(word, style) > width {
+				cut := fitRunes(word, style, width)
+				lines = append(lines, word[:cut])
+				word = word[cut:]
+			}
+			line = word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// fitRunes returns the byte length of the longest prefix of word that fits
+// in width, and at least one rune.
+func fitRunes(word string, style Style, width float64) int {
+	end := 0
+	for i, r := range word {
+		next := i + len(string(r))
+		if end > 0 && textWidth(word[:next], style) > width {
+			break
+		}
+		end = next
+	}
+	return end
+}
+
+// helveticaWidths holds the Helvetica advance widths, in thousandths of the
+// font size, for the printable ASCII characters.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+func textWidth(text string, style Style) float64 {
+	total := 0
+	for _, r := range text {
+		if r >= 32 && r < 127 {
+			total += helveticaWidths[r-32]
+		} else {
+			total += 556
+		}
+	}
+	width := float64(total) * style.Size / 1000
+	if style.Bold {
+		// Helvetica-Bold runs about a tenth wider.
+		width *= 1.1
+	}
+	return width
+}
+
+// winAnsi maps the characters of Windows-1252's 0x80-0x9F range.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92,
+	'“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// encode converts text to the fonts' Windows-1252 encoding.
+func encode(text string) string {
+	out := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r == '\t':
+			out = append(out, ' ')
+		case r >= 32 && r < 127, r >= 0xA0 && r <= 0xFF:
+			out = append(out, byte(r))
+		case winAnsi[r] != 0:
+			out = append(out, winAnsi[r])
+		default:
+			out = append(out, '?')
+		}
+	}
+	return string(out)
+}
+
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(s)
+}
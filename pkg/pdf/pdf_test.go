@@ -0,0 +1,59 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDocumentBytes(t *testing.T) {
+	doc := New("Transcript (Ana)")
+	doc.Paragraph("Acme Support", Style{Size: 18, Bold: true, Color: HexColor("#2563eb")})
+	doc.Rule(HexColor("#2563eb"))
+	for i := 0; i < 80; i++ {
+		doc.Paragraph(fmt.Sprintf("Línea %d: ¿cuánto cuesta el plan (anual)? — 😀", i), Style{Size: 10})
+	}
+	data := doc.Bytes()
+
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatalf("Expected a PDF header and trailer")
+	}
+	if got := bytes.Count(data, []byte("/Type /Page ")); got != 2 {
+		t.Errorf("Expected 2 pages, got %d", got)
+	}
+	if !bytes.Contains(data, []byte("L\xednea 0: \xbfcu\xe1nto cuesta el plan \\(anual\\)? \x97 ?")) {
+		t.Errorf("Expected Windows-1252 text with escaped parentheses")
+	}
+
+	// Every xref offset must point at its object.
+	xref := bytes.LastIndex(data, []byte("xref\n"))
+	lines := strings.Split(string(data[xref:]), "\n")
+	for i, line := range lines[3:] {
+		if !strings.HasSuffix(line, " n ") {
+			break
+		}
+		var off int
+		_, _ = fmt.Sscanf(line, "%010d", &off)
+		if want := fmt.Sprintf("%d 0 obj", i+1); !bytes.HasPrefix(data[off:], []byte(want)) {
+			t.Errorf("Expected offset %d to start %q", off, want)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	style := Style{Size: 10}
+	lines := wrap("one two three\n"+strings.Repeat("x", 200), style, 100)
+
+	if lines[0] != "one two three" {
+		t.Errorf("Expected the short line kept, got %q", lines[0])
+	}
+	if len(lines) < 3 {
+		t.Fatalf("Expected the long word broken across lines, got %d lines", len(lines))
+	}
+	for _, line := range lines {
+		if textWidth(line, style) > 100 {
+			t.Errorf("Expected lines within the width, got %q", line)
+		}
+	}
+}
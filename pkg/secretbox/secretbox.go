@@ -0,0 +1,72 @@
+// Package secretbox encrypts small secrets, such as integration credentials,
+// before they are stored. Secrets are sealed with AES-256-GCM under a key
+// derived from a passphrase and encoded as text.
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// version prefixes sealed values so the format can change later.
+const version = "v1:"
+
+var (
+	ErrNoKey     = errors.New("encryption key is required")
+	ErrMalformed = errors.New("malformed sealed value")
+)
+
+type Box struct {
+	aead cipher.AEAD
+}
+
+// New returns a box keyed by the SHA-256 of passphrase.
+func New(passphrase string) (*Box, error) {
+	if passphrase == "" {
+		return nil, ErrNoKey
+	}
+	key := sha256.Sum256([]byte(passphrase))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Box{aead: aead}, nil
+}
+
+// Seal encrypts plaintext with a random nonce.
+func (b *Box) Seal(plaintext string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return version + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value produced by Seal. It fails if the value was sealed
+// under another key or has been tampered with.
+func (b *Box) Open(sealed string) (string, error) {
+	encoded, ok := strings.CutPrefix(sealed, version)
+	if !ok {
+		return "", ErrMalformed
+	}
+	data, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(data) < b.aead.NonceSize() {
+		return "", ErrMalformed
+	}
+	nonce, ciphertext := data[:b.aead.NonceSize()], data[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
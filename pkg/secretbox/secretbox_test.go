@@ -0,0 +1,50 @@
+package secretbox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	box, err := New("passphrase")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	sealed, err := box.Seal("pat-na1-secret")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if strings.Contains(sealed, "pat-na1-secret") {
+		t.Errorf("Expected sealed value not to contain the secret, got %q", sealed)
+	}
+	again, _ := box.Seal("pat-na1-secret")
+	if again == sealed {
+		t.Error("Expected a fresh nonce for each seal")
+	}
+
+	plaintext, err := box.Open(sealed)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if plaintext != "pat-na1-secret" {
+		t.Errorf("Expected original secret, got %q", plaintext)
+	}
+}
+
+func TestOpenRejectsOtherKeyAndGarbage(t *testing.T) {
+	box, _ := New("passphrase")
+	other, _ := New("another")
+	sealed, _ := box.Seal("secret")
+
+	if _, err := other.Open(sealed); err == nil {
+		t.Error("Expected error opening with another key")
+	}
+	if _, err := box.Open("plain"); !errors.Is(err, ErrMalformed) {
+		t.Errorf("Expected ErrMalformed, got %v", err)
+	}
+	if _, err := New(""); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Expected ErrNoKey, got %v", err)
+	}
+}
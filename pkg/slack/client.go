@@ -0,0 +1,169 @@
+// Package slack is a client for the parts of the Slack Web API a bot needs:
+// posting messages, looking up users and verifying request signatures.
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	defaultBaseURL = "https://slack.com/api"
+	defaultTimeout = 10 * time.Second
+	maxResponse    = 1 << 20
+)
+
+var ErrNoEmail = errors.New("slack user has no email")
+
+type Client struct {
+	token      string
+	baseURL    string
+	httpClient *http.Client
+}
+
+type Option func(*Client)
+
+func WithBaseURL(url string) Option {
+	return func(c *Client) {
+		c.baseURL = url
+	}
+}
+
+func NewClient(token string, opts ...Option) *Client {
+	c := &Client{
+		token:   token,
+		baseURL: defaultBaseURL,
+		httpClient: &http.Client{
+			Timeout: defaultTimeout,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// apiResponse is the envelope of every Web API response. Failures are
+// reported with ok=false and HTTP 200.
+type apiResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+type postMessageRequest struct {
+	Channel  string `json:"channel"`
+	Text     string `json:"text"`
+	ThreadTS string `json:"thread_ts,omitempty"`
+}
+
+// PostMessage posts text to channel, in the thread started by threadTS when
+// set, and returns the new message's timestamp.
+func (c *Client) PostMessage(ctx context.Context, channel, threadTS, text string) (string, error) {
+	body, err := json.Marshal(postMessageRequest{Channel: channel, Text: text, ThreadTS: threadTS})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat.postMessage", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	var resp struct {
+		apiResponse
+		TS string `json:"ts"`
+	}
+	if err := c.do(req, &resp); err != nil {
+		return "", err
+	}
+	return resp.TS, nil
+}
+
+// UserEmail returns the email of a workspace member. It needs the
+// users:read.email scope.
+func (c *Client) UserEmail(ctx context.Context, userID string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/users.info?user="+url.QueryEscape(userID), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var resp struct {
+		apiResponse
+		User struct {
+			Profile struct {
+				Email string `json:"email"`
+			} `json:"profile"`
+		} `json:"user"`
+	}
+	if err := c.do(req, &resp); err != nil {
+		return "", err
+	}
+	if resp.User.Profile.Email == "" {
+		return "", ErrNoEmail
+	}
+	return resp.User.Profile.Email, nil
+}
+
+// Respond posts a reply to a slash command's response_url. Ephemeral replies
+// are only shown to the user who ran the command.
+func (c *Client) Respond(ctx context.Context, responseURL, text string, ephemeral bool) error {
+	responseType := "in_channel"
+	if ephemeral {
+		responseType = "ephemeral"
+	}
+	body, err := json.Marshal(map[string]string{"response_type": responseType, "text": text})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack response_url returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (c *Client) do(req *http.Request, out interface{ ok() (bool, string) }) error {
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponse))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack API returned status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if ok, apiErr := out.ok(); !ok {
+		return fmt.Errorf("slack API error: %s", apiErr)
+	}
+	return nil
+}
+
+func (r *apiResponse) ok() (bool, string) { return r.OK, r.Error }
@@ -0,0 +1,79 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPostMessage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xoxb-test" {
+			t.Errorf("Expected bot token, got %q", r.Header.Get("Authorization"))
+		}
+		if r.URL.Path != "/chat.postMessage" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		var msg postMessageRequest
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Fatalf("Failed to decode message: %v", err)
+		}
+		if msg.Channel != "C1" || msg.ThreadTS != "1700000000.000100" || msg.Text != "hello" {
+			t.Errorf("Unexpected message %+v", msg)
+		}
+		w.Write([]byte(`{"ok":true,"ts":"1700000001.000200"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("xoxb-test", WithBaseURL(server.URL))
+	ts, err := client.PostMessage(context.Background(), "C1", "1700000000.000100", "hello")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if ts != "1700000001.000200" {
+		t.Errorf("Expected message ts, got %s", ts)
+	}
+}
+
+func TestUserEmailAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("user") == "U1" {
+			w.Write([]byte(`{"ok":true,"user":{"profile":{"email":"ana@example.com"}}}`))
+			return
+		}
+		w.Write([]byte(`{"ok":false,"error":"user_not_found"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("xoxb-test", WithBaseURL(server.URL))
+	email, err := client.UserEmail(context.Background(), "U1")
+	if err != nil || email != "ana@example.com" {
+		t.Errorf("Expected ana@example.com, got %q (%v)", email, err)
+	}
+	if _, err := client.UserEmail(context.Background(), "U2"); err == nil || !strings.Contains(err.Error(), "user_not_found") {
+		t.Errorf("Expected Slack error, got %v", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"type":"event_callback"}`)
+	now := time.Unix(1700000000, 0)
+	header := Sign("secret", body, now)
+
+	if err := Verify("secret", header, body, now.Add(time.Minute)); err != nil {
+		t.Errorf("Expected valid signature, got %v", err)
+	}
+	if err := Verify("other", header, body, now); err != ErrBadSignature {
+		t.Errorf("Expected ErrBadSignature for wrong secret, got %v", err)
+	}
+	if err := Verify("secret", header, []byte(`{}`), now); err != ErrBadSignature {
+		t.Errorf("Expected ErrBadSignature for changed body, got %v", err)
+	}
+	if err := Verify("secret", header, body, now.Add(10*time.Minute)); err != ErrBadSignature {
+		t.Errorf("Expected ErrBadSignature for stale request, got %v", err)
+	}
+}
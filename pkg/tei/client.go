@@ -0,0 +1,267 @@
+// Package tei is a client for self-hosted embedding servers that speak the
+// HuggingFace text-embeddings-inference API, such as TEI itself or a
+// Sentence-Transformers server in front of the same routes.
+package tei
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/pkg/breaker"
+)
+
+const (
+	defaultTimeout   = 30 * time.Second
+	defaultBatchSize = 32
+	maxResponse      = 64 << 20
+)
+
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+	batchSize  int
+
+	mu sync.Mutex
+	// info and dimensions are detected on first use and kept, since a
+	// server serves one model for its lifetime.
+	info       *Info
+	dimensions int
+}
+
+// Info describes the server's model, as reported by GET /info.
+type Info struct {
+	ModelID            string `json:"model_id"`
+	MaxClientBatchSize int    `json:"max_client_batch_size"`
+	MaxInputLength     int    `json:"max_input_length"`
+}
+
+type Option func(*Client)
+
+// WithAPIKey sends key as a bearer token, for servers started with
+// --api-key.
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.httpClient.Timeout = timeout
+	}
+}
+
+// WithBatchSize caps the texts sent in one request. Zero uses the
+// server's max_client_batch_size.
+func WithBatchSize(n int) Option {
+	return func(c *Client) {
+		c.batchSize = n
+	}
+}
+
+// WithBreaker makes calls fail fast with breaker.ErrOpen while the server
+// keeps failing.
+func WithBreaker(b *breaker.Breaker) Option {
+	return func(c *Client) {
+		c.httpClient.Transport = b.Transport(c.httpClient.Transport)
+	}
+}
+
+func NewClient(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL: baseURL,
+		httpClient: &http.Client{
+			Timeout: defaultTimeout,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+type embedRequest struct {
+	Inputs    []string `json:"inputs"`
+	Truncate  bool     `json:"truncate"`
+	Normalize bool     `json:"normalize"`
+}
+
+type apiError struct {
+	Error     string `json:"error"`
+	ErrorType string `json:"error_type"`
+}
+
+// Info returns the server's model.
+func (c *Client) Info(ctx context.Context) (*Info, error) {
+	c.mu.Lock()
+	info := c.info
+	c.mu.Unlock()
+	if info != nil {
+		return info, nil
+	}
+
+	info = &Info{}
+	if err := c.do(ctx, http.MethodGet, "/info", nil, info); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.info = info
+	c.mu.Unlock()
+	return info, nil
+}
+
+// ListModels returns the one model the server serves. It doubles as a
+// health check.
+func (c *Client) ListModels(ctx context.Context) ([]string, error) {
+	info, err := c.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return []string{info.ModelID}, nil
+}
+
+// Dimensions returns the size of the server's vectors, embedding a short
+// text the first time to find out.
+func (c *Client) Dimensions(ctx context.Context) (int, error) {
+	c.mu.Lock()
+	dimensions := c.dimensions
+	c.mu.Unlock()
+	if dimensions > 0 {
+		return dimensions, nil
+	}
+
+	vectors, err := c.Embed(ctx, []string{"dimension check"})
+	if err != nil {
+		return 0, err
+	}
+	return len(vectors[0]), nil
+}
+
+// Embed returns a normalized vector for each text, in order. Texts are
+// sent in batches of the configured size; inputs longer than the model
+// accepts are truncated.
+func (c *Client) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	size, err := c.batch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	vectors := make([][]float64, 0, len(texts))
+	for start := 0; start < len(texts); start += size {
+		batch := texts[start:min(start+size, len(texts))]
+		var out [][]float64
+		if err := c.do(ctx, http.MethodPost, "/embed", embedRequest{Inputs: batch, Truncate: true, Normalize: true}, &out); err != nil {
+			return nil, err
+		}
+		if len(out) != len(batch) {
+			return nil, fmt.Errorf("embedding server returned %d vectors for %d texts", len(out), len(batch))
+		}
+		vectors = append(vectors, out...)
+	}
+
+	if len(vectors) > 0 {
+		c.mu.Lock()
+		c.dimensions = len(vectors[0])
+		c.mu.Unlock()
+	}
+	return vectors, nil
+}
+
+// CreateEmbeddingWithDimensions embeds text with the server's model. The
+// model is checked against the one the server serves; dimensions, when
+// set, must match its vector size, since the server cannot shorten
+// vectors.
+func (c *Client) CreateEmbeddingWithDimensions(ctx context.Context, text string, model string, dimensions int) ([]float64, error) {
+	vectors, err := c.CreateEmbeddings(ctx, []string{text}, model)
+	if err != nil {
+		return nil, err
+	}
+	if dimensions > 0 && len(vectors[0]) != dimensions {
+		return nil, fmt.Errorf("embedding server returns %d dimensions, not %d", len(vectors[0]), dimensions)
+	}
+	return vectors[0], nil
+}
+
+// CreateEmbeddings embeds texts in batches with the server's model, after
+// checking it is model.
+func (c *Client) CreateEmbeddings(ctx context.Context, texts []string, model string) ([][]float64, error) {
+	info, err := c.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if model != "" && model != info.ModelID {
+		return nil, fmt.Errorf("embedding server serves %s, not %s", info.ModelID, model)
+	}
+	return c.Embed(ctx, texts)
+}
+
+// batch returns the batch size: the configured one, else the server's
+// limit, else a default.
+func (c *Client) batch(ctx context.Context) (int, error) {
+	if c.batchSize > 0 {
+		return c.batchSize, nil
+	}
+	info, err := c.Info(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if info.MaxClientBatchSize > 0 {
+		return info.MaxClientBatchSize, nil
+	}
+	return defaultBatchSize, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponse))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr apiError
+		if err := json.Unmarshal(data, &apiErr); err == nil && apiErr.Error != "" {
+			return fmt.Errorf("embedding server error: %s (type: %s)", apiErr.Error, apiErr.ErrorType)
+		}
+		return fmt.Errorf("embedding server error: status %d", resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package tei
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func teiServer(t *testing.T, batches *[]int) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"unauthorized","error_type":"auth"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/info":
+			_, _ = w.Write([]byte(`{"model_id":"BAAI/bge-small-en-v1.5","max_client_batch_size":2,"max_input_length":512}`))
+		case "/embed":
+			var req embedRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Fatalf("Failed to decode request: %v", err)
+			}
+			if !req.Truncate || !req.Normalize {
+				t.Errorf("Expected truncate and normalize, got %+v", req)
+			}
+			*batches = append(*batches, len(req.Inputs))
+			out := make([][]float64, len(req.Inputs))
+			for i := range out {
+				out[i] = []float64{float64(len(req.Inputs[i])), 0, 1}
+			}
+			_ = json.NewEncoder(w).Encode(out)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestEmbedBatches(t *testing.T) {
+	var batches []int
+	server := teiServer(t, &batches)
+	defer server.Close()
+
+	client := NewClient(server.URL, WithAPIKey("secret"))
+	vectors, err := client.CreateEmbeddings(context.Background(), []string{"a", "bb", "ccc", "dddd", "eeeee"}, "BAAI/bge-small-en-v1.5")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(vectors) != 5 || vectors[4][0] != 5 {
+		t.Errorf("Expected 5 vectors in order, got %v", vectors)
+	}
+	if len(batches) != 3 || batches[0] != 2 || batches[2] != 1 {
+		t.Errorf("Expected batches of the server's max size 2, got %v", batches)
+	}
+
+	dimensions, err := client.Dimensions(context.Background())
+	if err != nil || dimensions != 3 {
+		t.Errorf("Expected 3 dimensions, got %d, %v", dimensions, err)
+	}
+	if len(batches) != 3 {
+		t.Errorf("Expected the dimensions of earlier vectors reused, got %d requests", len(batches))
+	}
+}
+
+func TestCreateEmbeddingChecks(t *testing.T) {
+	var batches []int
+	server := teiServer(t, &batches)
+	defer server.Close()
+
+	client := NewClient(server.URL, WithAPIKey("secret"), WithBatchSize(8))
+	if _, err := client.CreateEmbeddingWithDimensions(context.Background(), "hello", "intfloat/e5-large", 0); err == nil || !strings.Contains(err.Error(), "BAAI/bge-small-en-v1.5") {
+		t.Errorf("Expected an error naming the served model, got %v", err)
+	}
+	if _, err := client.CreateEmbeddingWithDimensions(context.Background(), "hello", "BAAI/bge-small-en-v1.5", 384); err == nil {
+		t.Error("Expected an error for mismatched dimensions")
+	}
+	vector, err := client.CreateEmbeddingWithDimensions(context.Background(), "hello", "BAAI/bge-small-en-v1.5", 3)
+	if err != nil || len(vector) != 3 {
+		t.Errorf("Expected a 3-dimension vector, got %v, %v", vector, err)
+	}
+
+	models, err := client.ListModels(context.Background())
+	if err != nil || len(models) != 1 || models[0] != "BAAI/bge-small-en-v1.5" {
+		t.Errorf("Expected the served model listed, got %v, %v", models, err)
+	}
+
+	unauthorized := NewClient(server.URL)
+	if _, err := unauthorized.Info(context.Background()); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("Expected the server's error, got %v", err)
+	}
+}
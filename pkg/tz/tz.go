@@ -0,0 +1,74 @@
+// Package tz carries the time zone a request is made in and parses the
+// times clients send in it. Times with an explicit offset keep it; times
+// without one are read as wall clock time in the request's zone.
+package tz
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrInvalidTime = errors.New("invalid time")
+
+// localLayouts are the accepted forms of a time without an offset, most
+// precise first. A date alone means the start of that day.
+var localLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	time.DateOnly,
+}
+
+// Parse reads value as an RFC 3339 time or, without an offset, as a local
+// time in loc such as "2024-03-01T08:30" or "2024-03-01".
+func Parse(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	for _, layout := range localLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, ErrInvalidTime
+}
+
+type contextKey struct{}
+
+// resolver looks the location up once, when it is first needed.
+type resolver struct {
+	once    sync.Once
+	resolve func() *time.Location
+	loc     *time.Location
+}
+
+// WithResolver returns ctx carrying resolve, which is called at most once
+// and only if a time zone is needed, so requests that parse no times do not
+// pay for looking up the user's.
+func WithResolver(ctx context.Context, resolve func() *time.Location) context.Context {
+	return context.WithValue(ctx, contextKey{}, &resolver{resolve: resolve})
+}
+
+// WithLocation returns ctx carrying loc.
+func WithLocation(ctx context.Context, loc *time.Location) context.Context {
+	return WithResolver(ctx, func() *time.Location { return loc })
+}
+
+// FromContext returns the location ctx carries, or UTC.
+func FromContext(ctx context.Context) *time.Location {
+	r, ok := ctx.Value(contextKey{}).(*resolver)
+	if !ok {
+		return time.UTC
+	}
+	r.once.Do(func() { r.loc = r.resolve() })
+	if r.loc == nil {
+		return time.UTC
+	}
+	return r.loc
+}
@@ -0,0 +1,74 @@
+package tz
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	guatemala := time.FixedZone("CST", -6*60*60)
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2024-03-01T08:30:00Z", time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)},
+		{"2024-03-01T08:30:00+02:00", time.Date(2024, 3, 1, 6, 30, 0, 0, time.UTC)},
+		{"2024-03-01T08:30:15", time.Date(2024, 3, 1, 14, 30, 15, 0, time.UTC)},
+		{"2024-03-01T08:30", time.Date(2024, 3, 1, 14, 30, 0, 0, time.UTC)},
+		{"2024-03-01 08:30", time.Date(2024, 3, 1, 14, 30, 0, 0, time.UTC)},
+		{"2024-03-01", time.Date(2024, 3, 1, 6, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.value, guatemala)
+		if err != nil {
+			t.Errorf("Parse(%q) returned error %v", tt.value, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("Parse(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "yesterday", "03/01/2024", "2024-13-01"} {
+		if _, err := Parse(value, guatemala); err == nil {
+			t.Errorf("Parse(%q) should fail", value)
+		}
+	}
+}
+
+func TestParseNilLocation(t *testing.T) {
+	got, err := Parse("2024-03-01", nil)
+	if err != nil || !got.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Parse with nil location = %v, %v; want UTC midnight", got, err)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if loc := FromContext(context.Background()); loc != time.UTC {
+		t.Errorf("Expected UTC without a location, got %v", loc)
+	}
+
+	calls := 0
+	lima := time.FixedZone("PET", -5*60*60)
+	ctx := WithResolver(context.Background(), func() *time.Location {
+		calls++
+		return lima
+	})
+	if calls != 0 {
+		t.Fatal("Expected the resolver not to run until needed")
+	}
+	for range 3 {
+		if loc := FromContext(ctx); loc != lima {
+			t.Errorf("Expected %v, got %v", lima, loc)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected the resolver to run once, ran %d times", calls)
+	}
+
+	if loc := FromContext(WithLocation(context.Background(), nil)); loc != time.UTC {
+		t.Errorf("Expected UTC for a nil location, got %v", loc)
+	}
+}
@@ -0,0 +1,85 @@
+package vectormath
+
+const kmeansIterations = 50
+
+// KMeans groups vectors into at most k clusters by cosine similarity
+// (spherical k-means). It returns each vector's cluster and the unit
+// centroid of each cluster. Seeds are picked farthest-first, so the same
+// input always gives the same clusters. It returns nil when the vectors are
+// empty or of different lengths.
+func KMeans(vectors [][]float64, k int) ([]int, [][]float64) {
+	if len(vectors) == 0 || k <= 0 {
+		return nil, nil
+	}
+	dims := len(vectors[0])
+	units := make([][]float64, len(vectors))
+	for i, v := range vectors {
+		if len(v) != dims {
+			return nil, nil
+		}
+		units[i] = NormalizeVector(v)
+	}
+	k = min(k, len(units))
+
+	centroids := seedCentroids(units, k)
+	assignments := make([]int, len(units))
+	for iteration := range kmeansIterations {
+		changed := iteration == 0
+		for i, v := range units {
+			best, bestScore := 0, -2.0
+			for c, centroid := range centroids {
+				if score := dot(v, centroid); score > bestScore {
+					best, bestScore = c, score
+				}
+			}
+			if assignments[i] != best {
+				assignments[i] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+
+		sums := make([][]float64, len(centroids))
+		for c := range sums {
+			sums[c] = make([]float64, dims)
+		}
+		for i, v := range units {
+			for j, x := range v {
+				sums[assignments[i]][j] += x
+			}
+		}
+		for c, sum := range sums {
+			// An emptied cluster keeps its centroid.
+			if normalize(sum) {
+				centroids[c] = sum
+			}
+		}
+	}
+	return assignments, centroids
+}
+
+// seedCentroids picks k of units: the first, then repeatedly the one least
+// similar to every centroid picked so far.
+func seedCentroids(units [][]float64, k int) [][]float64 {
+	centroids := [][]float64{append([]float64(nil), units[0]...)}
+	closest := make([]float64, len(units))
+	for i, v := range units {
+		closest[i] = dot(v, centroids[0])
+	}
+	for len(centroids) < k {
+		next := 0
+		for i := range units {
+			if closest[i] < closest[next] {
+				next = i
+			}
+		}
+		centroid := append([]float64(nil), units[next]...)
+		centroids = append(centroids, centroid)
+		for i, v := range units {
+			closest[i] = max(closest[i], dot(v, centroid))
+		}
+	}
+	return centroids
+}
@@ -0,0 +1,43 @@
+package vectormath
+
+import "testing"
+
+func TestKMeans(t *testing.T) {
+	vectors := [][]float64{
+		{1, 0.1, 0}, {0.9, 0, 0.1}, {1, 0.05, 0.05},
+		{0, 1, 0.1}, {0.1, 0.9, 0},
+		{0, 0.1, 1}, {0.05, 0, 0.8},
+	}
+	assignments, centroids := KMeans(vectors, 3)
+	if len(assignments) != len(vectors) || len(centroids) != 3 {
+		t.Fatalf("Expected 3 clusters over %d vectors, got %v and %d centroids", len(vectors), assignments, len(centroids))
+	}
+	groups := [][]int{{0, 1, 2}, {3, 4}, {5, 6}}
+	seen := map[int]bool{}
+	for _, group := range groups {
+		cluster := assignments[group[0]]
+		if seen[cluster] {
+			t.Errorf("Expected separate groups in separate clusters, got %v", assignments)
+		}
+		seen[cluster] = true
+		for _, i := range group[1:] {
+			if assignments[i] != cluster {
+				t.Errorf("Expected vector %d with vector %d, got %v", i, group[0], assignments)
+			}
+		}
+	}
+
+	again, _ := KMeans(vectors, 3)
+	for i := range again {
+		if again[i] != assignments[i] {
+			t.Fatalf("Expected the same clusters on every run, got %v and %v", assignments, again)
+		}
+	}
+
+	if assignments, _ := KMeans(vectors[:2], 5); len(assignments) != 2 {
+		t.Errorf("Expected k capped at the number of vectors, got %v", assignments)
+	}
+	if assignments, _ := KMeans([][]float64{{1}, {1, 2}}, 2); assignments != nil {
+		t.Errorf("Expected nil for mismatched lengths, got %v", assignments)
+	}
+}
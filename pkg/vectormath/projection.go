@@ -0,0 +1,157 @@
+package vectormath
+
+import "math"
+
+const (
+	pcaIterations = 100
+	pcaTolerance  = 1e-9
+)
+
+// PCA2D projects vectors onto their first two principal components. It
+// returns a point per vector and the share of the total variance each
+// component explains. The components are found by power iteration on the
+// centered vectors, so the d×d covariance matrix is never built. It
+// returns nil when the vectors are empty or of different lengths.
+func PCA2D(vectors [][]float64) ([][2]float64, [2]float64) {
+	var explained [2]float64
+	if len(vectors) == 0 || len(vectors[0]) == 0 {
+		return nil, explained
+	}
+	dims := len(vectors[0])
+	mean := make([]float64, dims)
+	for _, v := range vectors {
+		if len(v) != dims {
+			return nil, explained
+		}
+		for j, x := range v {
+			mean[j] += x
+		}
+	}
+	n := float64(len(vectors))
+	for j := range mean {
+		mean[j] /= n
+	}
+
+	centered := make([][]float64, len(vectors))
+	var total float64
+	for i, v := range vectors {
+		row := make([]float64, dims)
+		for j, x := range v {
+			row[j] = x - mean[j]
+			total += row[j] * row[j]
+		}
+		centered[i] = row
+	}
+
+	points := make([][2]float64, len(vectors))
+	if total == 0 {
+		return points, explained
+	}
+
+	var components [][]float64
+	for k := range 2 {
+		component, variance := principalComponent(centered, components)
+		if component == nil {
+			break
+		}
+		components = append(components, component)
+		explained[k] = variance / total
+		for i, row := range centered {
+			points[i][k] = dot(row, component)
+		}
+	}
+	return points, explained
+}
+
+// principalComponent returns the unit direction of greatest variance in
+// rows orthogonal to found, and the sum of squared projections onto it.
+func principalComponent(rows, found [][]float64) ([]float64, float64) {
+	dims := len(rows[0])
+
+	// Start from the row farthest from the mean: unlike a fixed vector it
+	// cannot be orthogonal to the component unless all rows are.
+	v := make([]float64, dims)
+	var best float64
+	for _, row := range rows {
+		if norm := dot(row, row); norm > best {
+			best = norm
+			copy(v, row)
+		}
+	}
+	orthogonalize(v, found)
+	if !normalize(v) {
+		return nil, 0
+	}
+
+	projections := make([]float64, len(rows))
+	for range pcaIterations {
+		next := make([]float64, dims)
+		for i, row := range rows {
+			projections[i] = dot(row, v)
+			for j, x := range row {
+				next[j] += projections[i] * x
+			}
+		}
+		orthogonalize(next, found)
+		if !normalize(next) {
+			return nil, 0
+		}
+		converged := math.Abs(dot(next, v)) > 1-pcaTolerance
+		v = next
+		if converged {
+			break
+		}
+	}
+
+	// Point the component at its largest coordinate, so repeated exports
+	// do not flip the plot.
+	largest := 0
+	for j := range v {
+		if math.Abs(v[j]) > math.Abs(v[largest]) {
+			largest = j
+		}
+	}
+	if v[largest] < 0 {
+		for j := range v {
+			v[j] = -v[j]
+		}
+	}
+
+	var variance float64
+	for _, row := range rows {
+		p := dot(row, v)
+		variance += p * p
+	}
+	return v, variance
+}
+
+func dot(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+// orthogonalize removes from v its projection onto each unit vector in
+// basis.
+func orthogonalize(v []float64, basis [][]float64) {
+	for _, b := range basis {
+		p := dot(v, b)
+		for j := range v {
+			v[j] -= p * b[j]
+		}
+	}
+}
+
+// normalize scales v to unit length, reporting false if it is zero.
+func normalize(v []float64) bool {
+	norm := math.Sqrt(dot(v, v))
+	if norm < 1e-12 {
+		return false
+	}
+	for j := range v {
+		v[j] /= norm
+	}
+	return true
+}
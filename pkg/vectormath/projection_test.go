@@ -0,0 +1,60 @@
+package vectormath
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPCA2D(t *testing.T) {
+	// Points spread widely along (1, 1, 0) and a little along (0, 0, 1).
+	vectors := [][]float64{
+		{-4, -4, 0.5},
+		{-2, -2, -0.5},
+		{2, 2, -0.5},
+		{4, 4, 0.5},
+	}
+	points, explained := PCA2D(vectors)
+	if len(points) != len(vectors) {
+		t.Fatalf("Expected %d points, got %d", len(vectors), len(points))
+	}
+
+	wantX := []float64{-4 * math.Sqrt2, -2 * math.Sqrt2, 2 * math.Sqrt2, 4 * math.Sqrt2}
+	for i, p := range points {
+		if math.Abs(p[0]-wantX[i]) > 1e-6 {
+			t.Errorf("Point %d: expected x %.4f, got %.4f", i, wantX[i], p[0])
+		}
+		if math.Abs(math.Abs(p[1])-0.5) > 1e-6 {
+			t.Errorf("Point %d: expected |y| 0.5, got %.4f", i, p[1])
+		}
+	}
+	if total := explained[0] + explained[1]; math.Abs(total-1) > 1e-6 {
+		t.Errorf("Expected two components to explain all variance, got %v", explained)
+	}
+	if math.Abs(explained[0]-80.0/81) > 1e-6 {
+		t.Errorf("Expected the first component to explain 80/81 of the variance, got %v", explained)
+	}
+}
+
+func TestPCA2DDegenerate(t *testing.T) {
+	if points, _ := PCA2D(nil); points != nil {
+		t.Errorf("Expected nil for no vectors, got %v", points)
+	}
+	if points, _ := PCA2D([][]float64{{1, 2}, {1}}); points != nil {
+		t.Errorf("Expected nil for mismatched lengths, got %v", points)
+	}
+
+	points, explained := PCA2D([][]float64{{1, 2}, {1, 2}})
+	if len(points) != 2 || points[0] != [2]float64{} || explained != [2]float64{} {
+		t.Errorf("Expected identical vectors at the origin, got %v %v", points, explained)
+	}
+}
+
+func TestCentroid(t *testing.T) {
+	got := Centroid([][]float64{{1, 2}, {3, 6}})
+	if len(got) != 2 || got[0] != 2 || got[1] != 4 {
+		t.Errorf("Expected [2 4], got %v", got)
+	}
+	if Centroid(nil) != nil || Centroid([][]float64{{1}, {1, 2}}) != nil {
+		t.Error("Expected nil for no vectors or mismatched lengths")
+	}
+}
@@ -70,6 +70,27 @@ func TopKBySimilarity(query []float64, vectors [][]float64, k int, threshold flo
 	return scores
 }
 
+// Centroid returns the element-wise mean of vectors, or nil when there are
+// none or they differ in length.
+func Centroid(vectors [][]float64) []float64 {
+	if len(vectors) == 0 {
+		return nil
+	}
+	mean := make([]float64, len(vectors[0]))
+	for _, v := range vectors {
+		if len(v) != len(mean) {
+			return nil
+		}
+		for i, x := range v {
+			mean[i] += x
+		}
+	}
+	for i := range mean {
+		mean[i] /= float64(len(vectors))
+	}
+	return mean
+}
+
 func NormalizeVector(v []float64) []float64 {
 	if len(v) == 0 {
 		return v
@@ -0,0 +1,433 @@
+// Package whatsapp is a client for the WhatsApp Cloud API.
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"github.com/elprogramadorgt/lucidRAG/pkg/breaker"
+)
+
+const (
+	defaultBaseURL    = "https://graph.facebook.com"
+	defaultAPIVersion = "v17.0"
+	defaultTimeout    = 30 * time.Second
+	// MaxMediaBytes is the largest media file WhatsApp accepts.
+	MaxMediaBytes = 16 << 20
+)
+
+var ErrMediaTooLarge = errors.New("media exceeds size limit")
+
+type Client struct {
+	accessToken string
+	baseURL     string
+	apiVersion  string
+	httpClient  *http.Client
+}
+
+type Option func(*Client)
+
+func WithBaseURL(url string) Option {
+	return func(c *Client) {
+		c.baseURL = url
+	}
+}
+
+func WithAPIVersion(version string) Option {
+	return func(c *Client) {
+		if version != "" {
+			c.apiVersion = version
+		}
+	}
+}
+
+// WithBreaker makes calls fail fast with breaker.ErrOpen while the API
+// keeps failing.
+func WithBreaker(b *breaker.Breaker) Option {
+	return func(c *Client) {
+		c.httpClient.Transport = b.Transport(c.httpClient.Transport)
+	}
+}
+
+func NewClient(accessToken string, opts ...Option) *Client {
+	c := &Client{
+		accessToken: accessToken,
+		baseURL:     defaultBaseURL,
+		apiVersion:  defaultAPIVersion,
+		httpClient: &http.Client{
+			Timeout: defaultTimeout,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+type apiError struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Code    int    `json:"code"`
+	} `json:"error"`
+}
+
+type mediaInfo struct {
+	URL      string `json:"url"`
+	MimeType string `json:"mime_type"`
+	FileSize int64  `json:"file_size"`
+}
+
+// DownloadMedia fetches a media file sent to the business number. Media IDs
+// from webhooks resolve to a short-lived URL that needs the same token.
+func (c *Client) DownloadMedia(ctx context.Context, mediaID string) ([]byte, string, error) {
+	var info mediaInfo
+	body, err := c.get(ctx, c.baseURL+"/"+c.apiVersion+"/"+mediaID)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, "", fmt.Errorf("failed to unmarshal media info: %w", err)
+	}
+	if info.URL == "" {
+		return nil, "", fmt.Errorf("no url returned for media %s", mediaID)
+	}
+	if info.FileSize > MaxMediaBytes {
+		return nil, "", ErrMediaTooLarge
+	}
+
+	data, err := c.get(ctx, info.URL)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, info.MimeType, nil
+}
+
+// UploadMedia uploads a file for phoneNumberID to send later and returns its
+// media ID.
+func (c *Client) UploadMedia(ctx context.Context, phoneNumberID string, data []byte, mimeType, filename string) (string, error) {
+	if len(data) > MaxMediaBytes {
+		return "", ErrMediaTooLarge
+	}
+
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	if err := form.WriteField("messaging_product", "whatsapp"); err != nil {
+		return "", fmt.Errorf("failed to build request: %w", err)
+	}
+	if err := form.WriteField("type", mimeType); err != nil {
+		return "", fmt.Errorf("failed to build request: %w", err)
+	}
+	part, err := form.CreatePart(map[string][]string{
+		"Content-Disposition": {fmt.Sprintf(`form-data; name="file"; filename=%q`, filename)},
+		"Content-Type":        {mimeType},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to build request: %w", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return "", fmt.Errorf("failed to build request: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return "", fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/"+c.apiVersion+"/"+phoneNumberID+"/media", &buf)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	body, err := c.do(req)
+	if err != nil {
+		return "", err
+	}
+	var uploaded struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(body, &uploaded); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if uploaded.ID == "" {
+		return "", errors.New("no media id returned")
+	}
+	return uploaded.ID, nil
+}
+
+type audioMessage struct {
+	MessagingProduct string `json:"messaging_product"`
+	To               string `json:"to"`
+	Type             string `json:"type"`
+	Audio            struct {
+		ID string `json:"id"`
+	} `json:"audio"`
+}
+
+// SendAudio sends previously uploaded audio from phoneNumberID to the
+// WhatsApp user to.
+func (c *Client) SendAudio(ctx context.Context, phoneNumberID, to, mediaID string) error {
+	msg := audioMessage{MessagingProduct: "whatsapp", To: to, Type: "audio"}
+	msg.Audio.ID = mediaID
+
+	jsonBody, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/"+c.apiVersion+"/"+phoneNumberID+"/messages", bytes.NewReader(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = c.do(req)
+	return err
+}
+
+type textMessage struct {
+	MessagingProduct string `json:"messaging_product"`
+	To               string `json:"to"`
+	Type             string `json:"type"`
+	Text             struct {
+		Body string `json:"body"`
+	} `json:"text"`
+}
+
+// SendText sends a text message from phoneNumberID to the WhatsApp user to.
+func (c *Client) SendText(ctx context.Context, phoneNumberID, to, body string) error {
+	msg := textMessage{MessagingProduct: "whatsapp", To: to, Type: "text"}
+	msg.Text.Body = body
+
+	jsonBody, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/"+c.apiVersion+"/"+phoneNumberID+"/messages", bytes.NewReader(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = c.do(req)
+	return err
+}
+
+// mediaMessage sends previously uploaded media. Filename applies to
+// documents only.
+type mediaMessage struct {
+	MessagingProduct string     `json:"messaging_product"`
+	To               string     `json:"to"`
+	Type             string     `json:"type"`
+	Document         *mediaPart `json:"document,omitempty"`
+	Image            *mediaPart `json:"image,omitempty"`
+}
+
+type mediaPart struct {
+	ID       string `json:"id"`
+	Caption  string `json:"caption,omitempty"`
+	Filename string `json:"filename,omitempty"`
+}
+
+// SendDocument sends a previously uploaded file from phoneNumberID to the
+// WhatsApp user to, shown under filename.
+func (c *Client) SendDocument(ctx context.Context, phoneNumberID, to, mediaID, filename, caption string) error {
+	return c.sendMessage(ctx, phoneNumberID, mediaMessage{
+		MessagingProduct: "whatsapp", To: to, Type: "document",
+		Document: &mediaPart{ID: mediaID, Caption: caption, Filename: filename},
+	})
+}
+
+// SendImage sends a previously uploaded image from phoneNumberID to the
+// WhatsApp user to.
+func (c *Client) SendImage(ctx context.Context, phoneNumberID, to, mediaID, caption string) error {
+	return c.sendMessage(ctx, phoneNumberID, mediaMessage{
+		MessagingProduct: "whatsapp", To: to, Type: "image",
+		Image: &mediaPart{ID: mediaID, Caption: caption},
+	})
+}
+
+// MaxButtons is how many reply buttons a message may carry, and
+// MaxButtonTitle how many characters each button's title may have.
+const (
+	MaxButtons     = 3
+	MaxButtonTitle = 20
+)
+
+type buttonMessage struct {
+	MessagingProduct string `json:"messaging_product"`
+	To               string `json:"to"`
+	Type             string `json:"type"`
+	Interactive      struct {
+		Type string `json:"type"`
+		Body struct {
+			Text string `json:"text"`
+		} `json:"body"`
+		Action struct {
+			Buttons []replyButton `json:"buttons"`
+		} `json:"action"`
+	} `json:"interactive"`
+}
+
+type replyButton struct {
+	Type  string `json:"type"`
+	Reply struct {
+		ID    string `json:"id"`
+		Title string `json:"title"`
+	} `json:"reply"`
+}
+
+// SendButtons sends body from phoneNumberID to the WhatsApp user to with a
+// reply button for each of titles, of which there may be up to MaxButtons.
+// A tapped button comes back as an interactive message carrying its title.
+func (c *Client) SendButtons(ctx context.Context, phoneNumberID, to, body string, titles []string) error {
+	if len(titles) == 0 || len(titles) > MaxButtons {
+		return fmt.Errorf("a message takes 1 to %d buttons, got %d", MaxButtons, len(titles))
+	}
+	msg := buttonMessage{MessagingProduct: "whatsapp", To: to, Type: "interactive"}
+	msg.Interactive.Type = "button"
+	msg.Interactive.Body.Text = body
+	for i, title := range titles {
+		button := replyButton{Type: "reply"}
+		button.Reply.ID = fmt.Sprintf("button_%d", i+1)
+		button.Reply.Title = title
+		msg.Interactive.Action.Buttons = append(msg.Interactive.Action.Buttons, button)
+	}
+	return c.sendMessage(ctx, phoneNumberID, msg)
+}
+
+// Flow is a WhatsApp Flow, a form built in WhatsApp Manager, sent as a
+// message. Token is echoed back in the flow's completion reply, so the
+// answers can be matched to what was sent; Screen is the one the flow
+// opens on.
+type Flow struct {
+	ID     string
+	Token  string
+	CTA    string
+	Body   string
+	Screen string
+}
+
+type flowMessage struct {
+	MessagingProduct string `json:"messaging_product"`
+	To               string `json:"to"`
+	Type             string `json:"type"`
+	Interactive      struct {
+		Type string `json:"type"`
+		Body struct {
+			Text string `json:"text"`
+		} `json:"body"`
+		Action struct {
+			Name       string         `json:"name"`
+			Parameters flowParameters `json:"parameters"`
+		} `json:"action"`
+	} `json:"interactive"`
+}
+
+type flowParameters struct {
+	Version       string      `json:"flow_message_version"`
+	Token         string      `json:"flow_token"`
+	ID            string      `json:"flow_id"`
+	CTA           string      `json:"flow_cta"`
+	Action        string      `json:"flow_action"`
+	ActionPayload *flowScreen `json:"flow_action_payload,omitempty"`
+}
+
+type flowScreen struct {
+	Screen string `json:"screen"`
+}
+
+// SendFlow sends flow from phoneNumberID to the WhatsApp user to, as body
+// with a button labelled with the flow's CTA that opens it. A completed
+// flow comes back as an interactive nfm_reply message.
+func (c *Client) SendFlow(ctx context.Context, phoneNumberID, to string, flow Flow) error {
+	msg := flowMessage{MessagingProduct: "whatsapp", To: to, Type: "interactive"}
+	msg.Interactive.Type = "flow"
+	msg.Interactive.Body.Text = flow.Body
+	msg.Interactive.Action.Name = "flow"
+	msg.Interactive.Action.Parameters = flowParameters{
+		Version: "3", Token: flow.Token, ID: flow.ID, CTA: flow.CTA, Action: "navigate",
+	}
+	if flow.Screen != "" {
+		msg.Interactive.Action.Parameters.ActionPayload = &flowScreen{Screen: flow.Screen}
+	}
+	return c.sendMessage(ctx, phoneNumberID, msg)
+}
+
+type typingMessage struct {
+	MessagingProduct string `json:"messaging_product"`
+	Status           string `json:"status"`
+	MessageID        string `json:"message_id"`
+	TypingIndicator  struct {
+		Type string `json:"type"`
+	} `json:"typing_indicator"`
+}
+
+// SendTyping marks the incoming message messageID as read and shows its
+// sender that phoneNumberID is typing. WhatsApp hides the indicator once a
+// reply is sent or after 25 seconds, whichever comes first.
+func (c *Client) SendTyping(ctx context.Context, phoneNumberID, messageID string) error {
+	msg := typingMessage{MessagingProduct: "whatsapp", Status: "read", MessageID: messageID}
+	msg.TypingIndicator.Type = "text"
+	return c.sendMessage(ctx, phoneNumberID, msg)
+}
+
+func (c *Client) sendMessage(ctx context.Context, phoneNumberID string, msg any) error {
+	jsonBody, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/"+c.apiVersion+"/"+phoneNumberID+"/messages", bytes.NewReader(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = c.do(req)
+	return err
+}
+
+func (c *Client) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	return c.do(req)
+}
+
+func (c *Client) do(req *http.Request) ([]byte, error) {
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxMediaBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if len(body) > MaxMediaBytes {
+		return nil, ErrMediaTooLarge
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr apiError
+		if err := json.Unmarshal(body, &apiErr); err == nil && apiErr.Error.Message != "" {
+			return nil, fmt.Errorf("WhatsApp API error: %s (code: %d)", apiErr.Error.Message, apiErr.Error.Code)
+		}
+		return nil, fmt.Errorf("WhatsApp API error: status %d", resp.StatusCode)
+	}
+	return body, nil
+}
@@ -0,0 +1,250 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDownloadMedia(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("Expected Authorization header on %s", r.URL.Path)
+		}
+		switch r.URL.Path {
+		case "/v19.0/media-1":
+			json.NewEncoder(w).Encode(mediaInfo{URL: server.URL + "/files/media-1", MimeType: "audio/ogg; codecs=opus", FileSize: 4})
+		case "/files/media-1":
+			w.Write([]byte("OggS"))
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token", WithBaseURL(server.URL), WithAPIVersion("v19.0"))
+	data, mimeType, err := client.DownloadMedia(context.Background(), "media-1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(data) != "OggS" {
+		t.Errorf("Expected media bytes, got %q", data)
+	}
+	if mimeType != "audio/ogg; codecs=opus" {
+		t.Errorf("Expected mime type, got %s", mimeType)
+	}
+}
+
+func TestDownloadMediaTooLarge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(mediaInfo{URL: "http://unused", FileSize: MaxMediaBytes + 1})
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token", WithBaseURL(server.URL))
+	if _, _, err := client.DownloadMedia(context.Background(), "media-1"); !errors.Is(err, ErrMediaTooLarge) {
+		t.Errorf("Expected ErrMediaTooLarge, got %v", err)
+	}
+}
+
+func TestDownloadMediaAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"message":"Invalid media id","type":"OAuthException","code":100}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token", WithBaseURL(server.URL))
+	if _, _, err := client.DownloadMedia(context.Background(), "bad"); err == nil {
+		t.Fatal("Expected error for API error response")
+	}
+}
+
+func TestUploadAndSendAudio(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("Expected Authorization header on %s", r.URL.Path)
+		}
+		switch r.URL.Path {
+		case "/v17.0/phone-1/media":
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				t.Fatalf("Expected multipart body, got %v", err)
+			}
+			file, header, err := r.FormFile("file")
+			if err != nil {
+				t.Fatalf("Expected file part, got %v", err)
+			}
+			data, _ := io.ReadAll(file)
+			if string(data) != "OggS" || header.Header.Get("Content-Type") != "audio/ogg" {
+				t.Errorf("Unexpected file %q with type %s", data, header.Header.Get("Content-Type"))
+			}
+			if r.FormValue("messaging_product") != "whatsapp" {
+				t.Error("Expected messaging_product field")
+			}
+			w.Write([]byte(`{"id":"media-9"}`))
+		case "/v17.0/phone-1/messages":
+			var msg audioMessage
+			if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+				t.Fatalf("Failed to decode message: %v", err)
+			}
+			if msg.To != "15551234" || msg.Type != "audio" || msg.Audio.ID != "media-9" {
+				t.Errorf("Unexpected message %+v", msg)
+			}
+			w.Write([]byte(`{"messages":[{"id":"wamid.1"}]}`))
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token", WithBaseURL(server.URL))
+	mediaID, err := client.UploadMedia(context.Background(), "phone-1", []byte("OggS"), "audio/ogg", "reply.ogg")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if mediaID != "media-9" {
+		t.Errorf("Expected media-9, got %s", mediaID)
+	}
+	if err := client.SendAudio(context.Background(), "phone-1", "15551234", mediaID); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestSendDocumentAndImage(t *testing.T) {
+	var sent []mediaMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v17.0/phone-1/messages" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		var msg mediaMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Fatalf("Failed to decode message: %v", err)
+		}
+		sent = append(sent, msg)
+		w.Write([]byte(`{"messages":[{"id":"wamid.1"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token", WithBaseURL(server.URL))
+	if err := client.SendDocument(context.Background(), "phone-1", "15551234", "media-1", "manual.pdf", "Setup guide"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := client.SendImage(context.Background(), "phone-1", "15551234", "media-2", "Page 4"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(sent) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(sent))
+	}
+	if doc := sent[0].Document; sent[0].Type != "document" || doc == nil || doc.ID != "media-1" || doc.Filename != "manual.pdf" || sent[0].Image != nil {
+		t.Errorf("Unexpected document message %+v", sent[0])
+	}
+	if img := sent[1].Image; sent[1].Type != "image" || img == nil || img.ID != "media-2" || img.Caption != "Page 4" {
+		t.Errorf("Unexpected image message %+v", sent[1])
+	}
+}
+
+func TestSendButtons(t *testing.T) {
+	var msg buttonMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Fatalf("Failed to decode message: %v", err)
+		}
+		w.Write([]byte(`{"messages":[{"id":"wamid.1"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token", WithBaseURL(server.URL))
+	if err := client.SendButtons(context.Background(), "phone-1", "15551234", "Welcome!", []string{"Hours", "Address"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	buttons := msg.Interactive.Action.Buttons
+	if msg.Type != "interactive" || msg.Interactive.Type != "button" || msg.Interactive.Body.Text != "Welcome!" {
+		t.Errorf("Unexpected message %+v", msg)
+	}
+	if len(buttons) != 2 || buttons[1].Reply.ID != "button_2" || buttons[1].Reply.Title != "Address" {
+		t.Errorf("Unexpected buttons %+v", buttons)
+	}
+
+	if err := client.SendButtons(context.Background(), "phone-1", "15551234", "Pick one", []string{"a", "b", "c", "d"}); err == nil {
+		t.Error("Expected an error for four buttons")
+	}
+}
+
+func TestSendText(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v17.0/phone-1/messages" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		var msg textMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Fatalf("Failed to decode message: %v", err)
+		}
+		if msg.To != "15551234" || msg.Type != "text" || msg.Text.Body != "Your order shipped" {
+			t.Errorf("Unexpected message %+v", msg)
+		}
+		w.Write([]byte(`{"messages":[{"id":"wamid.2"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token", WithBaseURL(server.URL))
+	if err := client.SendText(context.Background(), "phone-1", "15551234", "Your order shipped"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestSendTyping(t *testing.T) {
+	var msg typingMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v17.0/phone-1/messages" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Fatalf("Failed to decode message: %v", err)
+		}
+		w.Write([]byte(`{"success":true}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token", WithBaseURL(server.URL))
+	if err := client.SendTyping(context.Background(), "phone-1", "wamid.in"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if msg.Status != "read" || msg.MessageID != "wamid.in" || msg.TypingIndicator.Type != "text" {
+		t.Errorf("Unexpected message %+v", msg)
+	}
+}
+
+func TestSendFlow(t *testing.T) {
+	var msg flowMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Fatalf("Failed to decode message: %v", err)
+		}
+		w.Write([]byte(`{"messages":[{"id":"wamid.3"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token", WithBaseURL(server.URL))
+	flow := Flow{ID: "1234", Token: "flow-1:abc", CTA: "Book now", Body: "Pick a time", Screen: "BOOKING"}
+	if err := client.SendFlow(context.Background(), "phone-1", "15551234", flow); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	params := msg.Interactive.Action.Parameters
+	if msg.Type != "interactive" || msg.Interactive.Type != "flow" || msg.Interactive.Body.Text != "Pick a time" || msg.Interactive.Action.Name != "flow" {
+		t.Errorf("Unexpected message %+v", msg)
+	}
+	if params.ID != "1234" || params.Token != "flow-1:abc" || params.CTA != "Book now" || params.Action != "navigate" {
+		t.Errorf("Unexpected parameters %+v", params)
+	}
+	if params.ActionPayload == nil || params.ActionPayload.Screen != "BOOKING" {
+		t.Errorf("Expected the flow to open on BOOKING, got %+v", params.ActionPayload)
+	}
+}
@@ -1,4 +1,4 @@
-import { Component, OnInit, signal, computed } from '@angular/core';
+import { Component, OnDestroy, OnInit, signal, computed } from '@angular/core';
 import { CommonModule } from '@angular/common';
 import { ConversationService } from '../../services/conversation.service';
 import { SearchService } from '../../services/search.service';
@@ -12,8 +12,9 @@ import { Conversation } from '../../models/conversation.model';
   templateUrl: './conversation-list.html',
   styleUrls: ['./conversation-list.scss'],
 })
-export class ConversationListComponent implements OnInit {
+export class ConversationListComponent implements OnInit, OnDestroy {
   focusedIndex = signal(-1);
+  private closeStream?: () => void;
 
   // Use filtered conversations from search service
   displayedConversations = computed(() => {
@@ -30,6 +31,11 @@ export class ConversationListComponent implements OnInit {
       next: (data) => this.conversationService.conversations.set(data.conversations),
       error: () => this.conversationService.conversations.set([]),
     });
+    this.closeStream = this.conversationService.connectStream();
+  }
+
+  ngOnDestroy(): void {
+    this.closeStream?.();
   }
 
   selectConversation(conv: Conversation): void {
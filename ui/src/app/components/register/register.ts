@@ -1,13 +1,25 @@
-import { Component, signal } from '@angular/core';
+import { AfterViewInit, Component, ElementRef, ViewChild, signal } from '@angular/core';
 import { CommonModule } from '@angular/common';
 import { FormsModule } from '@angular/forms';
 import { Router, RouterLink } from '@angular/router';
 import { TranslateModule } from '@ngx-translate/core';
 import { AuthService } from '../../services/auth.service';
+import { CaptchaSettings } from '../../models/user.model';
 import { ThemeToggleComponent } from '../shared/theme-toggle/theme-toggle';
 import { LanguageSwitcherComponent } from '../shared/language-switcher/language-switcher';
 import { SocialLoginButtonsComponent } from '../shared/social-login-buttons/social-login-buttons';
 
+const CAPTCHA_SCRIPTS: Record<string, string> = {
+  hcaptcha: 'https://js.hcaptcha.com/1/api.js?render=explicit',
+  turnstile: 'https://challenges.cloudflare.com/turnstile/v0/api.js?render=explicit',
+};
+
+// hCaptcha and Turnstile widgets share this API.
+interface CaptchaWidgetApi {
+  render(container: HTMLElement, options: Record<string, unknown>): string;
+  reset(widgetId?: string): void;
+}
+
 @Component({
   selector: 'app-register',
   standalone: true,
@@ -15,7 +27,9 @@ import { SocialLoginButtonsComponent } from '../shared/social-login-buttons/soci
   templateUrl: './register.html',
   styleUrls: ['./register.scss'],
 })
-export class RegisterComponent {
+export class RegisterComponent implements AfterViewInit {
+  @ViewChild('captchaContainer') captchaContainer?: ElementRef<HTMLElement>;
+
   firstName = signal('');
   lastName = signal('');
   email = signal('');
@@ -23,9 +37,51 @@ export class RegisterComponent {
   confirmPassword = signal('');
   isLoading = signal(false);
   errorMessage = signal('');
+  website = signal('');
+  captchaToken = signal('');
+  captcha = signal<CaptchaSettings>({});
+  private captchaWidgetId?: string;
 
   constructor(private authService: AuthService, private router: Router) {}
 
+  ngAfterViewInit(): void {
+    this.authService.getCaptcha().subscribe((settings) => {
+      if (settings.provider && settings.site_key) {
+        this.captcha.set(settings);
+        this.loadCaptcha(settings);
+      }
+    });
+  }
+
+  private loadCaptcha(settings: CaptchaSettings): void {
+    const script = document.createElement('script');
+    script.src = CAPTCHA_SCRIPTS[settings.provider!];
+    script.async = true;
+    script.onload = () => {
+      const api = this.captchaApi();
+      if (!api || !this.captchaContainer) {
+        return;
+      }
+      this.captchaWidgetId = api.render(this.captchaContainer.nativeElement, {
+        sitekey: settings.site_key,
+        callback: (token: string) => this.captchaToken.set(token),
+        'expired-callback': () => this.captchaToken.set(''),
+      });
+    };
+    document.head.appendChild(script);
+  }
+
+  private captchaApi(): CaptchaWidgetApi | undefined {
+    const provider = this.captcha().provider;
+    return provider ? (window as unknown as Record<string, CaptchaWidgetApi>)[provider] : undefined;
+  }
+
+  // A captcha response is good for one attempt only.
+  private resetCaptcha(): void {
+    this.captchaToken.set('');
+    this.captchaApi()?.reset(this.captchaWidgetId);
+  }
+
   onSubmit(): void {
     if (!this.firstName() || !this.lastName() || !this.email() || !this.password()) {
       this.errorMessage.set('Please fill in all fields');
@@ -42,6 +98,11 @@ export class RegisterComponent {
       return;
     }
 
+    if (this.captcha().provider && !this.captchaToken()) {
+      this.errorMessage.set('Please complete the captcha');
+      return;
+    }
+
     this.isLoading.set(true);
     this.errorMessage.set('');
 
@@ -51,6 +112,8 @@ export class RegisterComponent {
         password: this.password(),
         first_name: this.firstName(),
         last_name: this.lastName(),
+        website: this.website(),
+        captcha_token: this.captchaToken(),
       })
       .subscribe({
         next: () => {
@@ -59,6 +122,7 @@ export class RegisterComponent {
         },
         error: (error) => {
           this.isLoading.set(false);
+          this.resetCaptcha();
           this.errorMessage.set(error.error?.error || 'Registration failed');
         },
       });
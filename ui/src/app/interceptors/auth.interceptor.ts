@@ -1,9 +1,26 @@
 import { HttpInterceptorFn } from '@angular/common/http';
+import { environment } from '../../environments/environment';
+
+const DEVICE_ID_KEY = 'lucidrag_device_id';
+
+// deviceId is a random ID kept per browser. The API uses it to tell devices
+// apart when admin sessions are bound to the device they started on.
+function deviceId(): string {
+  let id = localStorage.getItem(DEVICE_ID_KEY);
+  if (!id) {
+    id = crypto.randomUUID();
+    localStorage.setItem(DEVICE_ID_KEY, id);
+  }
+  return id;
+}
 
 export const authInterceptor: HttpInterceptorFn = (req, next) => {
   // Add withCredentials to send cookies with cross-origin requests
-  const clonedRequest = req.clone({
+  let clonedRequest = req.clone({
     withCredentials: true
   });
+  if (req.url.startsWith(environment.apiUrl)) {
+    clonedRequest = clonedRequest.clone({ setHeaders: { 'X-Device-ID': deviceId() } });
+  }
   return next(clonedRequest);
 };
@@ -19,6 +19,14 @@ export interface RegisterRequest {
   password: string;
   first_name: string;
   last_name: string;
+  // Honeypot: hidden from people, so it is only filled in by bots.
+  website?: string;
+  captcha_token?: string;
+}
+
+export interface CaptchaSettings {
+  provider?: 'hcaptcha' | 'turnstile';
+  site_key?: string;
 }
 
 export interface LoginResponse {
@@ -2,7 +2,7 @@ import { Injectable, signal } from '@angular/core';
 import { HttpClient } from '@angular/common/http';
 import { Router } from '@angular/router';
 import { Observable, tap, catchError, of, map } from 'rxjs';
-import { LoginRequest, RegisterRequest, LoginResponse, User } from '../models/user.model';
+import { LoginRequest, RegisterRequest, LoginResponse, User, CaptchaSettings } from '../models/user.model';
 import { environment } from '../../environments/environment';
 
 @Injectable({
@@ -35,6 +35,10 @@ export class AuthService {
     );
   }
 
+  getCaptcha(): Observable<CaptchaSettings> {
+    return this.http.get<CaptchaSettings>(`${environment.apiUrl}/v1/auth/captcha`).pipe(catchError(() => of({})));
+  }
+
   logout(): void {
     // Call backend to clear cookie
     this.http.post(`${environment.apiUrl}/v1/auth/logout`, {}).subscribe({
@@ -111,8 +111,46 @@ export class ConversationService {
       );
   }
 
+  /**
+   * Opens the live inbox stream and refreshes the list whenever the server
+   * reports a new message or conversation change. Returns a function that
+   * closes the stream.
+   */
+  connectStream(): () => void {
+    const source = new EventSource(`${environment.apiUrl}/v1/conversations/stream`, {
+      withCredentials: true,
+    });
+    const refresh = () => this.getConversations().subscribe({ error: () => {} });
+
+    source.addEventListener('conversation.updated', refresh);
+    source.addEventListener('message.created', (event) => {
+      const payload = JSON.parse((event as MessageEvent).data);
+      const selected = this.selectedConversation();
+      if (selected && selected.id === payload.conversation_id) {
+        this.getConversationMessages(selected.id).subscribe({ error: () => {} });
+      }
+    });
+
+    return () => source.close();
+  }
+
+  markRead(conversationId: string): Observable<{ message: string }> {
+    return this.http
+      .post<{ message: string }>(`${environment.apiUrl}/v1/conversations/${conversationId}/read`, {})
+      .pipe(
+        tap(() => {
+          this.conversations.update((convs) =>
+            convs.map((conv) => (conv.id === conversationId ? { ...conv, unreadCount: 0 } : conv))
+          );
+        })
+      );
+  }
+
   selectConversation(conversation: Conversation): void {
     this.selectedConversation.set(conversation);
+    if (conversation.unreadCount > 0) {
+      this.markRead(conversation.id).subscribe({ error: () => {} });
+    }
   }
 
   clearMessages(): void {